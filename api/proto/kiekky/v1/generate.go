@@ -0,0 +1,6 @@
+// Package kiekkyv1 is the generated code for the internal gRPC API defined
+// in the .proto files next to it. Regenerate after editing them with
+// go generate; protoc, protoc-gen-go and protoc-gen-go-grpc must be on PATH.
+package kiekkyv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative kiekky/v1/users.proto kiekky/v1/posts.proto kiekky/v1/matches.proto
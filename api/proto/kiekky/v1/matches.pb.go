@@ -0,0 +1,430 @@
+// Internal read API for dating matches, served on the gRPC port to other
+// Kiekky services. Not exposed to apps.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: kiekky/v1/matches.proto
+
+package kiekkyv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Match struct {
+	state     protoimpl.MessageState `protogen:"open.v1"`
+	Id        int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	User1Id   int64                  `protobuf:"varint,2,opt,name=user1_id,json=user1Id,proto3" json:"user1_id,omitempty"`
+	User2Id   int64                  `protobuf:"varint,3,opt,name=user2_id,json=user2Id,proto3" json:"user2_id,omitempty"`
+	MatchType string                 `protobuf:"bytes,4,opt,name=match_type,json=matchType,proto3" json:"match_type,omitempty"`
+	// 0 when the match wasn't scored
+	CompatibilityScore float64                `protobuf:"fixed64,5,opt,name=compatibility_score,json=compatibilityScore,proto3" json:"compatibility_score,omitempty"`
+	InteractionCount   int32                  `protobuf:"varint,6,opt,name=interaction_count,json=interactionCount,proto3" json:"interaction_count,omitempty"`
+	IsActive           bool                   `protobuf:"varint,7,opt,name=is_active,json=isActive,proto3" json:"is_active,omitempty"`
+	MatchedAt          *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=matched_at,json=matchedAt,proto3" json:"matched_at,omitempty"`
+	LastInteraction    *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=last_interaction,json=lastInteraction,proto3" json:"last_interaction,omitempty"`
+	UnmatchedAt        *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=unmatched_at,json=unmatchedAt,proto3" json:"unmatched_at,omitempty"`
+	unknownFields      protoimpl.UnknownFields
+	sizeCache          protoimpl.SizeCache
+}
+
+func (x *Match) Reset() {
+	*x = Match{}
+	mi := &file_kiekky_v1_matches_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Match) ProtoMessage() {}
+
+func (x *Match) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_matches_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Match.ProtoReflect.Descriptor instead.
+func (*Match) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_matches_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Match) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Match) GetUser1Id() int64 {
+	if x != nil {
+		return x.User1Id
+	}
+	return 0
+}
+
+func (x *Match) GetUser2Id() int64 {
+	if x != nil {
+		return x.User2Id
+	}
+	return 0
+}
+
+func (x *Match) GetMatchType() string {
+	if x != nil {
+		return x.MatchType
+	}
+	return ""
+}
+
+func (x *Match) GetCompatibilityScore() float64 {
+	if x != nil {
+		return x.CompatibilityScore
+	}
+	return 0
+}
+
+func (x *Match) GetInteractionCount() int32 {
+	if x != nil {
+		return x.InteractionCount
+	}
+	return 0
+}
+
+func (x *Match) GetIsActive() bool {
+	if x != nil {
+		return x.IsActive
+	}
+	return false
+}
+
+func (x *Match) GetMatchedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.MatchedAt
+	}
+	return nil
+}
+
+func (x *Match) GetLastInteraction() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastInteraction
+	}
+	return nil
+}
+
+func (x *Match) GetUnmatchedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UnmatchedAt
+	}
+	return nil
+}
+
+type ListMatchesRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// List ended matches instead of active ones
+	Inactive      bool `protobuf:"varint,2,opt,name=inactive,proto3" json:"inactive,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMatchesRequest) Reset() {
+	*x = ListMatchesRequest{}
+	mi := &file_kiekky_v1_matches_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMatchesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMatchesRequest) ProtoMessage() {}
+
+func (x *ListMatchesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_matches_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMatchesRequest.ProtoReflect.Descriptor instead.
+func (*ListMatchesRequest) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_matches_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ListMatchesRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ListMatchesRequest) GetInactive() bool {
+	if x != nil {
+		return x.Inactive
+	}
+	return false
+}
+
+type ListMatchesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matches       []*Match               `protobuf:"bytes,1,rep,name=matches,proto3" json:"matches,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListMatchesResponse) Reset() {
+	*x = ListMatchesResponse{}
+	mi := &file_kiekky_v1_matches_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListMatchesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListMatchesResponse) ProtoMessage() {}
+
+func (x *ListMatchesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_matches_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListMatchesResponse.ProtoReflect.Descriptor instead.
+func (*ListMatchesResponse) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_matches_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *ListMatchesResponse) GetMatches() []*Match {
+	if x != nil {
+		return x.Matches
+	}
+	return nil
+}
+
+type IsMatchedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	OtherUserId   int64                  `protobuf:"varint,2,opt,name=other_user_id,json=otherUserId,proto3" json:"other_user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IsMatchedRequest) Reset() {
+	*x = IsMatchedRequest{}
+	mi := &file_kiekky_v1_matches_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IsMatchedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IsMatchedRequest) ProtoMessage() {}
+
+func (x *IsMatchedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_matches_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IsMatchedRequest.ProtoReflect.Descriptor instead.
+func (*IsMatchedRequest) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_matches_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *IsMatchedRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *IsMatchedRequest) GetOtherUserId() int64 {
+	if x != nil {
+		return x.OtherUserId
+	}
+	return 0
+}
+
+type IsMatchedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Matched       bool                   `protobuf:"varint,1,opt,name=matched,proto3" json:"matched,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *IsMatchedResponse) Reset() {
+	*x = IsMatchedResponse{}
+	mi := &file_kiekky_v1_matches_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *IsMatchedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*IsMatchedResponse) ProtoMessage() {}
+
+func (x *IsMatchedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_matches_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use IsMatchedResponse.ProtoReflect.Descriptor instead.
+func (*IsMatchedResponse) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_matches_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *IsMatchedResponse) GetMatched() bool {
+	if x != nil {
+		return x.Matched
+	}
+	return false
+}
+
+var File_kiekky_v1_matches_proto protoreflect.FileDescriptor
+
+const file_kiekky_v1_matches_proto_rawDesc = "" +
+	"\n" +
+	"\x17kiekky/v1/matches.proto\x12\tkiekky.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xa8\x03\n" +
+	"\x05Match\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
+	"\buser1_id\x18\x02 \x01(\x03R\auser1Id\x12\x19\n" +
+	"\buser2_id\x18\x03 \x01(\x03R\auser2Id\x12\x1d\n" +
+	"\n" +
+	"match_type\x18\x04 \x01(\tR\tmatchType\x12/\n" +
+	"\x13compatibility_score\x18\x05 \x01(\x01R\x12compatibilityScore\x12+\n" +
+	"\x11interaction_count\x18\x06 \x01(\x05R\x10interactionCount\x12\x1b\n" +
+	"\tis_active\x18\a \x01(\bR\bisActive\x129\n" +
+	"\n" +
+	"matched_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\tmatchedAt\x12E\n" +
+	"\x10last_interaction\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\x0flastInteraction\x12=\n" +
+	"\funmatched_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\vunmatchedAt\"I\n" +
+	"\x12ListMatchesRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x1a\n" +
+	"\binactive\x18\x02 \x01(\bR\binactive\"A\n" +
+	"\x13ListMatchesResponse\x12*\n" +
+	"\amatches\x18\x01 \x03(\v2\x10.kiekky.v1.MatchR\amatches\"O\n" +
+	"\x10IsMatchedRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\"\n" +
+	"\rother_user_id\x18\x02 \x01(\x03R\votherUserId\"-\n" +
+	"\x11IsMatchedResponse\x12\x18\n" +
+	"\amatched\x18\x01 \x01(\bR\amatched2\xa4\x01\n" +
+	"\fMatchService\x12L\n" +
+	"\vListMatches\x12\x1d.kiekky.v1.ListMatchesRequest\x1a\x1e.kiekky.v1.ListMatchesResponse\x12F\n" +
+	"\tIsMatched\x12\x1b.kiekky.v1.IsMatchedRequest\x1a\x1c.kiekky.v1.IsMatchedResponseBEZCgithub.com/imadgeboyega/kiekky-backend/api/proto/kiekky/v1;kiekkyv1b\x06proto3"
+
+var (
+	file_kiekky_v1_matches_proto_rawDescOnce sync.Once
+	file_kiekky_v1_matches_proto_rawDescData []byte
+)
+
+func file_kiekky_v1_matches_proto_rawDescGZIP() []byte {
+	file_kiekky_v1_matches_proto_rawDescOnce.Do(func() {
+		file_kiekky_v1_matches_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_kiekky_v1_matches_proto_rawDesc), len(file_kiekky_v1_matches_proto_rawDesc)))
+	})
+	return file_kiekky_v1_matches_proto_rawDescData
+}
+
+var file_kiekky_v1_matches_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_kiekky_v1_matches_proto_goTypes = []any{
+	(*Match)(nil),                 // 0: kiekky.v1.Match
+	(*ListMatchesRequest)(nil),    // 1: kiekky.v1.ListMatchesRequest
+	(*ListMatchesResponse)(nil),   // 2: kiekky.v1.ListMatchesResponse
+	(*IsMatchedRequest)(nil),      // 3: kiekky.v1.IsMatchedRequest
+	(*IsMatchedResponse)(nil),     // 4: kiekky.v1.IsMatchedResponse
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
+var file_kiekky_v1_matches_proto_depIdxs = []int32{
+	5, // 0: kiekky.v1.Match.matched_at:type_name -> google.protobuf.Timestamp
+	5, // 1: kiekky.v1.Match.last_interaction:type_name -> google.protobuf.Timestamp
+	5, // 2: kiekky.v1.Match.unmatched_at:type_name -> google.protobuf.Timestamp
+	0, // 3: kiekky.v1.ListMatchesResponse.matches:type_name -> kiekky.v1.Match
+	1, // 4: kiekky.v1.MatchService.ListMatches:input_type -> kiekky.v1.ListMatchesRequest
+	3, // 5: kiekky.v1.MatchService.IsMatched:input_type -> kiekky.v1.IsMatchedRequest
+	2, // 6: kiekky.v1.MatchService.ListMatches:output_type -> kiekky.v1.ListMatchesResponse
+	4, // 7: kiekky.v1.MatchService.IsMatched:output_type -> kiekky.v1.IsMatchedResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_kiekky_v1_matches_proto_init() }
+func file_kiekky_v1_matches_proto_init() {
+	if File_kiekky_v1_matches_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kiekky_v1_matches_proto_rawDesc), len(file_kiekky_v1_matches_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_kiekky_v1_matches_proto_goTypes,
+		DependencyIndexes: file_kiekky_v1_matches_proto_depIdxs,
+		MessageInfos:      file_kiekky_v1_matches_proto_msgTypes,
+	}.Build()
+	File_kiekky_v1_matches_proto = out.File
+	file_kiekky_v1_matches_proto_goTypes = nil
+	file_kiekky_v1_matches_proto_depIdxs = nil
+}
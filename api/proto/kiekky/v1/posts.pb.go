@@ -0,0 +1,499 @@
+// Internal read API for posts, served on the gRPC port to other Kiekky
+// services. Not exposed to apps.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: kiekky/v1/posts.proto
+
+package kiekkyv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Post struct {
+	state    protoimpl.MessageState `protogen:"open.v1"`
+	Id       int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	UserId   int64                  `protobuf:"varint,2,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	Caption  string                 `protobuf:"bytes,3,opt,name=caption,proto3" json:"caption,omitempty"`
+	Location string                 `protobuf:"bytes,4,opt,name=location,proto3" json:"location,omitempty"`
+	// public, followers or private
+	Visibility    string       `protobuf:"bytes,5,opt,name=visibility,proto3" json:"visibility,omitempty"`
+	Media         []*PostMedia `protobuf:"bytes,6,rep,name=media,proto3" json:"media,omitempty"`
+	LikesCount    int32        `protobuf:"varint,7,opt,name=likes_count,json=likesCount,proto3" json:"likes_count,omitempty"`
+	CommentsCount int32        `protobuf:"varint,8,opt,name=comments_count,json=commentsCount,proto3" json:"comments_count,omitempty"`
+	RepostsCount  int32        `protobuf:"varint,9,opt,name=reposts_count,json=repostsCount,proto3" json:"reposts_count,omitempty"`
+	// Set on reposts
+	OriginalPostId int64                  `protobuf:"varint,10,opt,name=original_post_id,json=originalPostId,proto3" json:"original_post_id,omitempty"`
+	CreatedAt      *timestamppb.Timestamp `protobuf:"bytes,11,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	UpdatedAt      *timestamppb.Timestamp `protobuf:"bytes,12,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *Post) Reset() {
+	*x = Post{}
+	mi := &file_kiekky_v1_posts_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Post) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Post) ProtoMessage() {}
+
+func (x *Post) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_posts_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Post.ProtoReflect.Descriptor instead.
+func (*Post) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_posts_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Post) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Post) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *Post) GetCaption() string {
+	if x != nil {
+		return x.Caption
+	}
+	return ""
+}
+
+func (x *Post) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *Post) GetVisibility() string {
+	if x != nil {
+		return x.Visibility
+	}
+	return ""
+}
+
+func (x *Post) GetMedia() []*PostMedia {
+	if x != nil {
+		return x.Media
+	}
+	return nil
+}
+
+func (x *Post) GetLikesCount() int32 {
+	if x != nil {
+		return x.LikesCount
+	}
+	return 0
+}
+
+func (x *Post) GetCommentsCount() int32 {
+	if x != nil {
+		return x.CommentsCount
+	}
+	return 0
+}
+
+func (x *Post) GetRepostsCount() int32 {
+	if x != nil {
+		return x.RepostsCount
+	}
+	return 0
+}
+
+func (x *Post) GetOriginalPostId() int64 {
+	if x != nil {
+		return x.OriginalPostId
+	}
+	return 0
+}
+
+func (x *Post) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Post) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+type PostMedia struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Url   string                 `protobuf:"bytes,2,opt,name=url,proto3" json:"url,omitempty"`
+	// image or video
+	Type          string `protobuf:"bytes,3,opt,name=type,proto3" json:"type,omitempty"`
+	Position      int32  `protobuf:"varint,4,opt,name=position,proto3" json:"position,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PostMedia) Reset() {
+	*x = PostMedia{}
+	mi := &file_kiekky_v1_posts_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PostMedia) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PostMedia) ProtoMessage() {}
+
+func (x *PostMedia) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_posts_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PostMedia.ProtoReflect.Descriptor instead.
+func (*PostMedia) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_posts_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PostMedia) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *PostMedia) GetUrl() string {
+	if x != nil {
+		return x.Url
+	}
+	return ""
+}
+
+func (x *PostMedia) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *PostMedia) GetPosition() int32 {
+	if x != nil {
+		return x.Position
+	}
+	return 0
+}
+
+type GetPostRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	PostId        int64                  `protobuf:"varint,1,opt,name=post_id,json=postId,proto3" json:"post_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetPostRequest) Reset() {
+	*x = GetPostRequest{}
+	mi := &file_kiekky_v1_posts_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetPostRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetPostRequest) ProtoMessage() {}
+
+func (x *GetPostRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_posts_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetPostRequest.ProtoReflect.Descriptor instead.
+func (*GetPostRequest) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_posts_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetPostRequest) GetPostId() int64 {
+	if x != nil {
+		return x.PostId
+	}
+	return 0
+}
+
+type ListUserPostsRequest struct {
+	state  protoimpl.MessageState `protogen:"open.v1"`
+	UserId int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	// 1-based; defaults to 1
+	Page int32 `protobuf:"varint,2,opt,name=page,proto3" json:"page,omitempty"`
+	// Defaults to 20, at most 100
+	PageSize      int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserPostsRequest) Reset() {
+	*x = ListUserPostsRequest{}
+	mi := &file_kiekky_v1_posts_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserPostsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserPostsRequest) ProtoMessage() {}
+
+func (x *ListUserPostsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_posts_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserPostsRequest.ProtoReflect.Descriptor instead.
+func (*ListUserPostsRequest) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_posts_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListUserPostsRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+func (x *ListUserPostsRequest) GetPage() int32 {
+	if x != nil {
+		return x.Page
+	}
+	return 0
+}
+
+func (x *ListUserPostsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+type ListUserPostsResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Posts         []*Post                `protobuf:"bytes,1,rep,name=posts,proto3" json:"posts,omitempty"`
+	Total         int32                  `protobuf:"varint,2,opt,name=total,proto3" json:"total,omitempty"`
+	HasNext       bool                   `protobuf:"varint,3,opt,name=has_next,json=hasNext,proto3" json:"has_next,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListUserPostsResponse) Reset() {
+	*x = ListUserPostsResponse{}
+	mi := &file_kiekky_v1_posts_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListUserPostsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListUserPostsResponse) ProtoMessage() {}
+
+func (x *ListUserPostsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_posts_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListUserPostsResponse.ProtoReflect.Descriptor instead.
+func (*ListUserPostsResponse) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_posts_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListUserPostsResponse) GetPosts() []*Post {
+	if x != nil {
+		return x.Posts
+	}
+	return nil
+}
+
+func (x *ListUserPostsResponse) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *ListUserPostsResponse) GetHasNext() bool {
+	if x != nil {
+		return x.HasNext
+	}
+	return false
+}
+
+var File_kiekky_v1_posts_proto protoreflect.FileDescriptor
+
+const file_kiekky_v1_posts_proto_rawDesc = "" +
+	"\n" +
+	"\x15kiekky/v1/posts.proto\x12\tkiekky.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xbe\x03\n" +
+	"\x04Post\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x17\n" +
+	"\auser_id\x18\x02 \x01(\x03R\x06userId\x12\x18\n" +
+	"\acaption\x18\x03 \x01(\tR\acaption\x12\x1a\n" +
+	"\blocation\x18\x04 \x01(\tR\blocation\x12\x1e\n" +
+	"\n" +
+	"visibility\x18\x05 \x01(\tR\n" +
+	"visibility\x12*\n" +
+	"\x05media\x18\x06 \x03(\v2\x14.kiekky.v1.PostMediaR\x05media\x12\x1f\n" +
+	"\vlikes_count\x18\a \x01(\x05R\n" +
+	"likesCount\x12%\n" +
+	"\x0ecomments_count\x18\b \x01(\x05R\rcommentsCount\x12#\n" +
+	"\rreposts_count\x18\t \x01(\x05R\frepostsCount\x12(\n" +
+	"\x10original_post_id\x18\n" +
+	" \x01(\x03R\x0eoriginalPostId\x129\n" +
+	"\n" +
+	"created_at\x18\v \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
+	"\n" +
+	"updated_at\x18\f \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"]\n" +
+	"\tPostMedia\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x10\n" +
+	"\x03url\x18\x02 \x01(\tR\x03url\x12\x12\n" +
+	"\x04type\x18\x03 \x01(\tR\x04type\x12\x1a\n" +
+	"\bposition\x18\x04 \x01(\x05R\bposition\")\n" +
+	"\x0eGetPostRequest\x12\x17\n" +
+	"\apost_id\x18\x01 \x01(\x03R\x06postId\"`\n" +
+	"\x14ListUserPostsRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\x12\x12\n" +
+	"\x04page\x18\x02 \x01(\x05R\x04page\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"o\n" +
+	"\x15ListUserPostsResponse\x12%\n" +
+	"\x05posts\x18\x01 \x03(\v2\x0f.kiekky.v1.PostR\x05posts\x12\x14\n" +
+	"\x05total\x18\x02 \x01(\x05R\x05total\x12\x19\n" +
+	"\bhas_next\x18\x03 \x01(\bR\ahasNext2\x98\x01\n" +
+	"\vPostService\x125\n" +
+	"\aGetPost\x12\x19.kiekky.v1.GetPostRequest\x1a\x0f.kiekky.v1.Post\x12R\n" +
+	"\rListUserPosts\x12\x1f.kiekky.v1.ListUserPostsRequest\x1a .kiekky.v1.ListUserPostsResponseBEZCgithub.com/imadgeboyega/kiekky-backend/api/proto/kiekky/v1;kiekkyv1b\x06proto3"
+
+var (
+	file_kiekky_v1_posts_proto_rawDescOnce sync.Once
+	file_kiekky_v1_posts_proto_rawDescData []byte
+)
+
+func file_kiekky_v1_posts_proto_rawDescGZIP() []byte {
+	file_kiekky_v1_posts_proto_rawDescOnce.Do(func() {
+		file_kiekky_v1_posts_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_kiekky_v1_posts_proto_rawDesc), len(file_kiekky_v1_posts_proto_rawDesc)))
+	})
+	return file_kiekky_v1_posts_proto_rawDescData
+}
+
+var file_kiekky_v1_posts_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_kiekky_v1_posts_proto_goTypes = []any{
+	(*Post)(nil),                  // 0: kiekky.v1.Post
+	(*PostMedia)(nil),             // 1: kiekky.v1.PostMedia
+	(*GetPostRequest)(nil),        // 2: kiekky.v1.GetPostRequest
+	(*ListUserPostsRequest)(nil),  // 3: kiekky.v1.ListUserPostsRequest
+	(*ListUserPostsResponse)(nil), // 4: kiekky.v1.ListUserPostsResponse
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
+var file_kiekky_v1_posts_proto_depIdxs = []int32{
+	1, // 0: kiekky.v1.Post.media:type_name -> kiekky.v1.PostMedia
+	5, // 1: kiekky.v1.Post.created_at:type_name -> google.protobuf.Timestamp
+	5, // 2: kiekky.v1.Post.updated_at:type_name -> google.protobuf.Timestamp
+	0, // 3: kiekky.v1.ListUserPostsResponse.posts:type_name -> kiekky.v1.Post
+	2, // 4: kiekky.v1.PostService.GetPost:input_type -> kiekky.v1.GetPostRequest
+	3, // 5: kiekky.v1.PostService.ListUserPosts:input_type -> kiekky.v1.ListUserPostsRequest
+	0, // 6: kiekky.v1.PostService.GetPost:output_type -> kiekky.v1.Post
+	4, // 7: kiekky.v1.PostService.ListUserPosts:output_type -> kiekky.v1.ListUserPostsResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_kiekky_v1_posts_proto_init() }
+func file_kiekky_v1_posts_proto_init() {
+	if File_kiekky_v1_posts_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kiekky_v1_posts_proto_rawDesc), len(file_kiekky_v1_posts_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_kiekky_v1_posts_proto_goTypes,
+		DependencyIndexes: file_kiekky_v1_posts_proto_depIdxs,
+		MessageInfos:      file_kiekky_v1_posts_proto_msgTypes,
+	}.Build()
+	File_kiekky_v1_posts_proto = out.File
+	file_kiekky_v1_posts_proto_goTypes = nil
+	file_kiekky_v1_posts_proto_depIdxs = nil
+}
@@ -0,0 +1,505 @@
+// Internal read API for users, served on the gRPC port to other Kiekky
+// services. Not exposed to apps.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        v5.29.3
+// source: kiekky/v1/users.proto
+
+package kiekkyv1
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type User struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Id             int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Username       string                 `protobuf:"bytes,2,opt,name=username,proto3" json:"username,omitempty"`
+	DisplayName    string                 `protobuf:"bytes,3,opt,name=display_name,json=displayName,proto3" json:"display_name,omitempty"`
+	ProfilePicture string                 `protobuf:"bytes,4,opt,name=profile_picture,json=profilePicture,proto3" json:"profile_picture,omitempty"`
+	IsGhost        bool                   `protobuf:"varint,5,opt,name=is_ghost,json=isGhost,proto3" json:"is_ghost,omitempty"`
+	Bio            string                 `protobuf:"bytes,6,opt,name=bio,proto3" json:"bio,omitempty"`
+	Gender         string                 `protobuf:"bytes,7,opt,name=gender,proto3" json:"gender,omitempty"`
+	// Age in whole years, 0 when the date of birth isn't set
+	Age      int32  `protobuf:"varint,8,opt,name=age,proto3" json:"age,omitempty"`
+	Location string `protobuf:"bytes,9,opt,name=location,proto3" json:"location,omitempty"`
+	// Unset when the user hasn't shared a location
+	Coordinates       *Coordinates `protobuf:"bytes,10,opt,name=coordinates,proto3" json:"coordinates,omitempty"`
+	Interests         []string     `protobuf:"bytes,11,rep,name=interests,proto3" json:"interests,omitempty"`
+	LookingFor        string       `protobuf:"bytes,12,opt,name=looking_for,json=lookingFor,proto3" json:"looking_for,omitempty"`
+	IsVerified        bool         `protobuf:"varint,13,opt,name=is_verified,json=isVerified,proto3" json:"is_verified,omitempty"`
+	IsPhotoVerified   bool         `protobuf:"varint,14,opt,name=is_photo_verified,json=isPhotoVerified,proto3" json:"is_photo_verified,omitempty"`
+	IsPremium         bool         `protobuf:"varint,15,opt,name=is_premium,json=isPremium,proto3" json:"is_premium,omitempty"`
+	IsProfileComplete bool         `protobuf:"varint,16,opt,name=is_profile_complete,json=isProfileComplete,proto3" json:"is_profile_complete,omitempty"`
+	// Presence as other users see it; empty for users who hide it
+	IsOnline      bool                   `protobuf:"varint,17,opt,name=is_online,json=isOnline,proto3" json:"is_online,omitempty"`
+	LastSeen      *timestamppb.Timestamp `protobuf:"bytes,18,opt,name=last_seen,json=lastSeen,proto3" json:"last_seen,omitempty"`
+	CreatedAt     *timestamppb.Timestamp `protobuf:"bytes,19,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *User) Reset() {
+	*x = User{}
+	mi := &file_kiekky_v1_users_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *User) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*User) ProtoMessage() {}
+
+func (x *User) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_users_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use User.ProtoReflect.Descriptor instead.
+func (*User) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_users_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *User) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *User) GetUsername() string {
+	if x != nil {
+		return x.Username
+	}
+	return ""
+}
+
+func (x *User) GetDisplayName() string {
+	if x != nil {
+		return x.DisplayName
+	}
+	return ""
+}
+
+func (x *User) GetProfilePicture() string {
+	if x != nil {
+		return x.ProfilePicture
+	}
+	return ""
+}
+
+func (x *User) GetIsGhost() bool {
+	if x != nil {
+		return x.IsGhost
+	}
+	return false
+}
+
+func (x *User) GetBio() string {
+	if x != nil {
+		return x.Bio
+	}
+	return ""
+}
+
+func (x *User) GetGender() string {
+	if x != nil {
+		return x.Gender
+	}
+	return ""
+}
+
+func (x *User) GetAge() int32 {
+	if x != nil {
+		return x.Age
+	}
+	return 0
+}
+
+func (x *User) GetLocation() string {
+	if x != nil {
+		return x.Location
+	}
+	return ""
+}
+
+func (x *User) GetCoordinates() *Coordinates {
+	if x != nil {
+		return x.Coordinates
+	}
+	return nil
+}
+
+func (x *User) GetInterests() []string {
+	if x != nil {
+		return x.Interests
+	}
+	return nil
+}
+
+func (x *User) GetLookingFor() string {
+	if x != nil {
+		return x.LookingFor
+	}
+	return ""
+}
+
+func (x *User) GetIsVerified() bool {
+	if x != nil {
+		return x.IsVerified
+	}
+	return false
+}
+
+func (x *User) GetIsPhotoVerified() bool {
+	if x != nil {
+		return x.IsPhotoVerified
+	}
+	return false
+}
+
+func (x *User) GetIsPremium() bool {
+	if x != nil {
+		return x.IsPremium
+	}
+	return false
+}
+
+func (x *User) GetIsProfileComplete() bool {
+	if x != nil {
+		return x.IsProfileComplete
+	}
+	return false
+}
+
+func (x *User) GetIsOnline() bool {
+	if x != nil {
+		return x.IsOnline
+	}
+	return false
+}
+
+func (x *User) GetLastSeen() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LastSeen
+	}
+	return nil
+}
+
+func (x *User) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+type Coordinates struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Latitude      float64                `protobuf:"fixed64,1,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     float64                `protobuf:"fixed64,2,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Coordinates) Reset() {
+	*x = Coordinates{}
+	mi := &file_kiekky_v1_users_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Coordinates) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Coordinates) ProtoMessage() {}
+
+func (x *Coordinates) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_users_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Coordinates.ProtoReflect.Descriptor instead.
+func (*Coordinates) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_users_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Coordinates) GetLatitude() float64 {
+	if x != nil {
+		return x.Latitude
+	}
+	return 0
+}
+
+func (x *Coordinates) GetLongitude() float64 {
+	if x != nil {
+		return x.Longitude
+	}
+	return 0
+}
+
+type GetUserRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserId        int64                  `protobuf:"varint,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetUserRequest) Reset() {
+	*x = GetUserRequest{}
+	mi := &file_kiekky_v1_users_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetUserRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetUserRequest) ProtoMessage() {}
+
+func (x *GetUserRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_users_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetUserRequest.ProtoReflect.Descriptor instead.
+func (*GetUserRequest) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_users_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetUserRequest) GetUserId() int64 {
+	if x != nil {
+		return x.UserId
+	}
+	return 0
+}
+
+type BatchGetUsersRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	UserIds       []int64                `protobuf:"varint,1,rep,packed,name=user_ids,json=userIds,proto3" json:"user_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetUsersRequest) Reset() {
+	*x = BatchGetUsersRequest{}
+	mi := &file_kiekky_v1_users_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetUsersRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetUsersRequest) ProtoMessage() {}
+
+func (x *BatchGetUsersRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_users_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetUsersRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetUsersRequest) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_users_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchGetUsersRequest) GetUserIds() []int64 {
+	if x != nil {
+		return x.UserIds
+	}
+	return nil
+}
+
+type BatchGetUsersResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Users         []*User                `protobuf:"bytes,1,rep,name=users,proto3" json:"users,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetUsersResponse) Reset() {
+	*x = BatchGetUsersResponse{}
+	mi := &file_kiekky_v1_users_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetUsersResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetUsersResponse) ProtoMessage() {}
+
+func (x *BatchGetUsersResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_kiekky_v1_users_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetUsersResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetUsersResponse) Descriptor() ([]byte, []int) {
+	return file_kiekky_v1_users_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchGetUsersResponse) GetUsers() []*User {
+	if x != nil {
+		return x.Users
+	}
+	return nil
+}
+
+var File_kiekky_v1_users_proto protoreflect.FileDescriptor
+
+const file_kiekky_v1_users_proto_rawDesc = "" +
+	"\n" +
+	"\x15kiekky/v1/users.proto\x12\tkiekky.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x97\x05\n" +
+	"\x04User\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1a\n" +
+	"\busername\x18\x02 \x01(\tR\busername\x12!\n" +
+	"\fdisplay_name\x18\x03 \x01(\tR\vdisplayName\x12'\n" +
+	"\x0fprofile_picture\x18\x04 \x01(\tR\x0eprofilePicture\x12\x19\n" +
+	"\bis_ghost\x18\x05 \x01(\bR\aisGhost\x12\x10\n" +
+	"\x03bio\x18\x06 \x01(\tR\x03bio\x12\x16\n" +
+	"\x06gender\x18\a \x01(\tR\x06gender\x12\x10\n" +
+	"\x03age\x18\b \x01(\x05R\x03age\x12\x1a\n" +
+	"\blocation\x18\t \x01(\tR\blocation\x128\n" +
+	"\vcoordinates\x18\n" +
+	" \x01(\v2\x16.kiekky.v1.CoordinatesR\vcoordinates\x12\x1c\n" +
+	"\tinterests\x18\v \x03(\tR\tinterests\x12\x1f\n" +
+	"\vlooking_for\x18\f \x01(\tR\n" +
+	"lookingFor\x12\x1f\n" +
+	"\vis_verified\x18\r \x01(\bR\n" +
+	"isVerified\x12*\n" +
+	"\x11is_photo_verified\x18\x0e \x01(\bR\x0fisPhotoVerified\x12\x1d\n" +
+	"\n" +
+	"is_premium\x18\x0f \x01(\bR\tisPremium\x12.\n" +
+	"\x13is_profile_complete\x18\x10 \x01(\bR\x11isProfileComplete\x12\x1b\n" +
+	"\tis_online\x18\x11 \x01(\bR\bisOnline\x127\n" +
+	"\tlast_seen\x18\x12 \x01(\v2\x1a.google.protobuf.TimestampR\blastSeen\x129\n" +
+	"\n" +
+	"created_at\x18\x13 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\"G\n" +
+	"\vCoordinates\x12\x1a\n" +
+	"\blatitude\x18\x01 \x01(\x01R\blatitude\x12\x1c\n" +
+	"\tlongitude\x18\x02 \x01(\x01R\tlongitude\")\n" +
+	"\x0eGetUserRequest\x12\x17\n" +
+	"\auser_id\x18\x01 \x01(\x03R\x06userId\"1\n" +
+	"\x14BatchGetUsersRequest\x12\x19\n" +
+	"\buser_ids\x18\x01 \x03(\x03R\auserIds\">\n" +
+	"\x15BatchGetUsersResponse\x12%\n" +
+	"\x05users\x18\x01 \x03(\v2\x0f.kiekky.v1.UserR\x05users2\x98\x01\n" +
+	"\vUserService\x125\n" +
+	"\aGetUser\x12\x19.kiekky.v1.GetUserRequest\x1a\x0f.kiekky.v1.User\x12R\n" +
+	"\rBatchGetUsers\x12\x1f.kiekky.v1.BatchGetUsersRequest\x1a .kiekky.v1.BatchGetUsersResponseBEZCgithub.com/imadgeboyega/kiekky-backend/api/proto/kiekky/v1;kiekkyv1b\x06proto3"
+
+var (
+	file_kiekky_v1_users_proto_rawDescOnce sync.Once
+	file_kiekky_v1_users_proto_rawDescData []byte
+)
+
+func file_kiekky_v1_users_proto_rawDescGZIP() []byte {
+	file_kiekky_v1_users_proto_rawDescOnce.Do(func() {
+		file_kiekky_v1_users_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_kiekky_v1_users_proto_rawDesc), len(file_kiekky_v1_users_proto_rawDesc)))
+	})
+	return file_kiekky_v1_users_proto_rawDescData
+}
+
+var file_kiekky_v1_users_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_kiekky_v1_users_proto_goTypes = []any{
+	(*User)(nil),                  // 0: kiekky.v1.User
+	(*Coordinates)(nil),           // 1: kiekky.v1.Coordinates
+	(*GetUserRequest)(nil),        // 2: kiekky.v1.GetUserRequest
+	(*BatchGetUsersRequest)(nil),  // 3: kiekky.v1.BatchGetUsersRequest
+	(*BatchGetUsersResponse)(nil), // 4: kiekky.v1.BatchGetUsersResponse
+	(*timestamppb.Timestamp)(nil), // 5: google.protobuf.Timestamp
+}
+var file_kiekky_v1_users_proto_depIdxs = []int32{
+	1, // 0: kiekky.v1.User.coordinates:type_name -> kiekky.v1.Coordinates
+	5, // 1: kiekky.v1.User.last_seen:type_name -> google.protobuf.Timestamp
+	5, // 2: kiekky.v1.User.created_at:type_name -> google.protobuf.Timestamp
+	0, // 3: kiekky.v1.BatchGetUsersResponse.users:type_name -> kiekky.v1.User
+	2, // 4: kiekky.v1.UserService.GetUser:input_type -> kiekky.v1.GetUserRequest
+	3, // 5: kiekky.v1.UserService.BatchGetUsers:input_type -> kiekky.v1.BatchGetUsersRequest
+	0, // 6: kiekky.v1.UserService.GetUser:output_type -> kiekky.v1.User
+	4, // 7: kiekky.v1.UserService.BatchGetUsers:output_type -> kiekky.v1.BatchGetUsersResponse
+	6, // [6:8] is the sub-list for method output_type
+	4, // [4:6] is the sub-list for method input_type
+	4, // [4:4] is the sub-list for extension type_name
+	4, // [4:4] is the sub-list for extension extendee
+	0, // [0:4] is the sub-list for field type_name
+}
+
+func init() { file_kiekky_v1_users_proto_init() }
+func file_kiekky_v1_users_proto_init() {
+	if File_kiekky_v1_users_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_kiekky_v1_users_proto_rawDesc), len(file_kiekky_v1_users_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_kiekky_v1_users_proto_goTypes,
+		DependencyIndexes: file_kiekky_v1_users_proto_depIdxs,
+		MessageInfos:      file_kiekky_v1_users_proto_msgTypes,
+	}.Build()
+	File_kiekky_v1_users_proto = out.File
+	file_kiekky_v1_users_proto_goTypes = nil
+	file_kiekky_v1_users_proto_depIdxs = nil
+}
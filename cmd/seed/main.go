@@ -0,0 +1,63 @@
+// cmd/seed/main.go
+// Fills the configured database with demo users, follows, posts, stories,
+// conversations, matches and hotpicks for local development and load tests.
+// Seeded users sign in with seed.Password.
+
+package main
+
+import (
+    "context"
+    "errors"
+    "flag"
+    "log"
+    "os/signal"
+    "syscall"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/app"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/config"
+    "github.com/imadgeboyega/kiekky-backend/internal/seed"
+)
+
+func main() {
+    users := flag.Int("users", 500, "number of users to create")
+    randSeed := flag.Int64("seed", 1, "random seed; the same seed produces the same data")
+    reset := flag.Bool("reset", false, "replace users seeded by an earlier run")
+    flag.Parse()
+
+    cfg, err := config.Load()
+    if err != nil {
+        log.Fatal("❌ Configuration could not be loaded: ", err)
+    }
+    if cfg.IsProduction() {
+        log.Fatal("❌ Refusing to seed a production database")
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    db, err := database.NewPostgresDBFromURL(cfg.Database.URL)
+    if err != nil {
+        log.Fatal("❌ Failed to connect to PostgreSQL: ", err)
+    }
+    defer db.Close()
+
+    if err := app.Migrate(db); err != nil {
+        log.Fatal("❌ Failed to run migrations: ", err)
+    }
+
+    log.Printf("🌱 Seeding %d users...", *users)
+    summary, err := seed.Run(ctx, db, seed.Options{Users: *users, Seed: *randSeed, Reset: *reset})
+    if errors.Is(err, seed.ErrAlreadySeeded) {
+        log.Fatal("❌ The database already has seeded users; run with -reset to replace them")
+    }
+    if err != nil {
+        log.Fatal("❌ ", err)
+    }
+
+    log.Printf("✅ Seeded %d users (%d photos), %d follows, %d posts with %d likes and %d comments, %d stories, "+
+        "%d matches, %d conversations with %d messages and %d hotpicks",
+        summary.Users, summary.Photos, summary.Follows, summary.Posts, summary.Likes, summary.Comments,
+        summary.Stories, summary.Matches, summary.Conversations, summary.Messages, summary.Hotpicks)
+    log.Printf("🔑 Sign in as any seeded user with password %q", seed.Password)
+}
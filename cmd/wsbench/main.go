@@ -0,0 +1,250 @@
+// cmd/wsbench/main.go
+// Load-test client for the messaging WebSocket. Opens one connection per
+// access token in -tokens (the hub keeps one connection per user, so tokens
+// must belong to different users, e.g. users created by cmd/seed), ramps them
+// up over -ramp, holds them for -duration while sending ping frames, and
+// reports connection counts, frames received and ping round trips.
+//
+// Raise the open file limit (ulimit -n) on both ends before going past a few
+// thousand connections.
+
+package main
+
+import (
+    "bufio"
+    "bytes"
+    "context"
+    "encoding/json"
+    "flag"
+    "log"
+    "net/http"
+    "os"
+    "os/signal"
+    "sort"
+    "sync"
+    "sync/atomic"
+    "syscall"
+    "time"
+
+    "github.com/gorilla/websocket"
+)
+
+type stats struct {
+    connected atomic.Int64
+    open      atomic.Int64
+    failed    atomic.Int64
+    dropped   atomic.Int64
+    frames    atomic.Int64
+
+    mu        sync.Mutex
+    connects  []time.Duration
+    roundTrip []time.Duration
+}
+
+func (s *stats) observe(samples *[]time.Duration, d time.Duration) {
+    s.mu.Lock()
+    *samples = append(*samples, d)
+    s.mu.Unlock()
+}
+
+// drain returns and resets the samples gathered since the last report
+func (s *stats) drain() (connects, roundTrip []time.Duration) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    connects, roundTrip = s.connects, s.roundTrip
+    s.connects, s.roundTrip = nil, nil
+    return connects, roundTrip
+}
+
+func main() {
+    url := flag.String("url", "ws://localhost:8080/ws", "WebSocket endpoint")
+    tokensFile := flag.String("tokens", "", "file with one access token per line, each for a different user")
+    conns := flag.Int("conns", 0, "connections to open; defaults to one per token")
+    ramp := flag.Duration("ramp", 30*time.Second, "time over which connections are opened")
+    duration := flag.Duration("duration", time.Minute, "how long to hold connections once all are opened")
+    pingEvery := flag.Duration("ping", 30*time.Second, "interval between ping frames on each connection; 0 disables them")
+    reportEvery := flag.Duration("report", 5*time.Second, "interval between progress reports")
+    flag.Parse()
+
+    if *tokensFile == "" {
+        log.Fatal("❌ -tokens is required")
+    }
+    tokens, err := readTokens(*tokensFile)
+    if err != nil {
+        log.Fatal("❌ Failed to read tokens: ", err)
+    }
+    if *conns <= 0 || *conns > len(tokens) {
+        *conns = len(tokens)
+    }
+    if *conns == 0 {
+        log.Fatal("❌ No tokens in ", *tokensFile)
+    }
+
+    ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+    defer stop()
+
+    dialer := &websocket.Dialer{
+        HandshakeTimeout: 30 * time.Second,
+        ReadBufferSize:   1024,
+        WriteBufferSize:  1024,
+    }
+
+    s := &stats{}
+    var wg sync.WaitGroup
+
+    go report(ctx, s, *reportEvery)
+
+    log.Printf("🚀 Opening %d connections to %s over %s", *conns, *url, *ramp)
+    interval := *ramp / time.Duration(*conns)
+    start := time.Now()
+    for i := 0; i < *conns && ctx.Err() == nil; i++ {
+        wg.Add(1)
+        go func(token string) {
+            defer wg.Done()
+            connect(ctx, dialer, *url, token, *pingEvery, s)
+        }(tokens[i])
+
+        if interval > 0 {
+            select {
+            case <-time.After(interval):
+            case <-ctx.Done():
+            }
+        }
+    }
+    log.Printf("✅ Ramp finished in %s: %d open, %d failed", time.Since(start).Round(time.Millisecond), s.open.Load(), s.failed.Load())
+
+    select {
+    case <-time.After(*duration):
+    case <-ctx.Done():
+    }
+    stop()
+    wg.Wait()
+
+    log.Printf("🏁 Done: %d connected, %d failed, %d dropped by the server, %d frames received",
+        s.connected.Load(), s.failed.Load(), s.dropped.Load(), s.frames.Load())
+}
+
+func readTokens(path string) ([]string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    var tokens []string
+    scanner := bufio.NewScanner(f)
+    scanner.Buffer(make([]byte, 0, 4096), 64*1024)
+    for scanner.Scan() {
+        if line := scanner.Text(); line != "" {
+            tokens = append(tokens, line)
+        }
+    }
+    return tokens, scanner.Err()
+}
+
+// connect holds one connection open until ctx is done, sending a ping frame
+// every pingEvery and timing the pong that answers it
+func connect(ctx context.Context, dialer *websocket.Dialer, url, token string, pingEvery time.Duration, s *stats) {
+    header := http.Header{}
+    header.Set("Authorization", "Bearer "+token)
+
+    started := time.Now()
+    conn, _, err := dialer.DialContext(ctx, url, header)
+    if err != nil {
+        if ctx.Err() == nil {
+            s.failed.Add(1)
+        }
+        return
+    }
+    s.observe(&s.connects, time.Since(started))
+    s.connected.Add(1)
+    s.open.Add(1)
+    defer s.open.Add(-1)
+
+    // The server's heartbeat pings are answered by the default ping handler
+    var pingSent atomic.Int64
+    done := make(chan struct{})
+    go func() {
+        defer close(done)
+        for {
+            _, data, err := conn.ReadMessage()
+            if err != nil {
+                if ctx.Err() == nil {
+                    s.dropped.Add(1)
+                }
+                return
+            }
+
+            // The server batches queued frames into one message, one per line
+            for _, line := range bytes.Split(data, []byte{'\n'}) {
+                s.frames.Add(1)
+
+                var frame struct {
+                    Type string `json:"type"`
+                }
+                if json.Unmarshal(line, &frame) == nil && frame.Type == "pong" {
+                    if sent := pingSent.Swap(0); sent != 0 {
+                        s.observe(&s.roundTrip, time.Since(time.Unix(0, sent)))
+                    }
+                }
+            }
+        }
+    }()
+
+    var ticks <-chan time.Time
+    if pingEvery > 0 {
+        ticker := time.NewTicker(pingEvery)
+        defer ticker.Stop()
+        ticks = ticker.C
+    }
+
+    for {
+        select {
+        case <-ticks:
+            pingSent.Store(time.Now().UnixNano())
+            conn.SetWriteDeadline(time.Now().Add(10 * time.Second))
+            if err := conn.WriteMessage(websocket.TextMessage, []byte(`{"type":"ping"}`)); err != nil {
+                conn.Close()
+                <-done
+                return
+            }
+
+        case <-done:
+            conn.Close()
+            return
+
+        case <-ctx.Done():
+            conn.SetWriteDeadline(time.Now().Add(time.Second))
+            conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""))
+            conn.Close()
+            <-done
+            return
+        }
+    }
+}
+
+func report(ctx context.Context, s *stats, every time.Duration) {
+    ticker := time.NewTicker(every)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ticker.C:
+            connects, roundTrip := s.drain()
+            log.Printf("open=%d failed=%d dropped=%d frames=%d connect p50=%s p99=%s ping p50=%s p99=%s",
+                s.open.Load(), s.failed.Load(), s.dropped.Load(), s.frames.Load(),
+                percentile(connects, 0.50), percentile(connects, 0.99),
+                percentile(roundTrip, 0.50), percentile(roundTrip, 0.99))
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+func percentile(samples []time.Duration, p float64) time.Duration {
+    if len(samples) == 0 {
+        return 0
+    }
+    sort.Slice(samples, func(i, j int) bool { return samples[i] < samples[j] })
+    return samples[int(float64(len(samples)-1)*p)].Round(time.Microsecond)
+}
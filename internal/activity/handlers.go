@@ -0,0 +1,71 @@
+// internal/activity/handlers.go
+
+package activity
+
+import (
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// GetActivity handles GET /activity?limit=&before=
+func (h *Handler) GetActivity(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    if limit <= 0 {
+        limit = DefaultFeedLimit
+    }
+    if limit > MaxFeedLimit {
+        limit = MaxFeedLimit
+    }
+
+    var before *time.Time
+    if v := r.URL.Query().Get("before"); v != "" {
+        t, err := time.Parse(time.RFC3339Nano, v)
+        if err != nil {
+            utils.RespondWithError(w, http.StatusBadRequest, "before must be an RFC 3339 timestamp")
+            return
+        }
+        before = &t
+    }
+
+    feed, err := h.service.GetFeed(r.Context(), userID, before, limit)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get activity")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, feed)
+}
+
+// MarkRead handles POST /activity/read
+func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    if err := h.service.MarkRead(r.Context(), userID, time.Now()); err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to mark activity as read")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Activity marked as read"})
+}
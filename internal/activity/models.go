@@ -0,0 +1,46 @@
+// internal/activity/models.go
+
+package activity
+
+import (
+    "time"
+)
+
+type ActivityType string
+
+const (
+    TypeProfileView ActivityType = "profile_view"
+    TypePostLike    ActivityType = "post_like"
+    TypeDateRequest ActivityType = "date_request"
+    TypeStoryView   ActivityType = "story_view"
+)
+
+// Actor is the user who viewed or liked
+type Actor struct {
+    ID             int64   `json:"id" db:"actor_id"`
+    Username       string  `json:"username" db:"username"`
+    DisplayName    *string `json:"display_name,omitempty" db:"display_name"`
+    ProfilePicture *string `json:"profile_picture,omitempty" db:"profile_picture"`
+}
+
+// Activity is one entry in the activity stream. TargetID is the liked post,
+// viewed story or date request, depending on Type.
+type Activity struct {
+    Type       ActivityType `json:"type" db:"type"`
+    Actor      *Actor       `json:"actor,omitempty"`
+    TargetID   *int64       `json:"target_id,omitempty" db:"target_id"`
+    OccurredAt time.Time    `json:"occurred_at" db:"occurred_at"`
+    IsUnread   bool         `json:"is_unread"`
+
+    // Locked is set on profile views shown to non-premium users, whose
+    // actor is withheld
+    Locked bool `json:"locked,omitempty"`
+}
+
+// Feed is a page of the activity stream
+type Feed struct {
+    Items       []*Activity `json:"items"`
+    UnreadCount int         `json:"unread_count"`
+    IsPremium   bool        `json:"is_premium"`
+    NextCursor  *time.Time  `json:"next_cursor,omitempty"`
+}
@@ -0,0 +1,138 @@
+// internal/activity/repository.go
+
+package activity
+
+import (
+    "context"
+    "database/sql"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+type Repository interface {
+    GetActivity(ctx context.Context, userID int64, before time.Time, limit int) ([]*Activity, error)
+    CountSince(ctx context.Context, userID int64, since time.Time) (int, error)
+    GetLastReadAt(ctx context.Context, userID int64) (time.Time, error)
+    SetLastReadAt(ctx context.Context, userID int64, readAt time.Time) error
+    IsPremium(ctx context.Context, userID int64) (bool, error)
+}
+
+type postgresRepository struct {
+    db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) Repository {
+    return &postgresRepository{db: db}
+}
+
+// activitySources merges everything that happened to user $1. Repeated
+// profile views by the same viewer collapse to one entry per day, since a
+// view is recorded on every profile fetch.
+const activitySources = `
+        SELECT 'profile_view' AS type, pv.viewer_id AS actor_id, NULL::BIGINT AS target_id,
+               MAX(pv.viewed_at) AS occurred_at
+        FROM profile_views pv
+        WHERE pv.profile_id = $1 AND pv.viewer_id <> $1
+        GROUP BY pv.viewer_id, pv.viewed_at::date
+        UNION ALL
+        SELECT 'post_like', pl.user_id, pl.post_id::BIGINT, pl.created_at
+        FROM post_likes pl
+        JOIN posts p ON p.id = pl.post_id
+        WHERE p.user_id = $1 AND pl.user_id <> $1 AND p.deleted_at IS NULL
+        UNION ALL
+        SELECT 'date_request', dr.sender_id, dr.id::BIGINT, dr.created_at
+        FROM date_requests dr
+        WHERE dr.receiver_id = $1
+        UNION ALL
+        SELECT 'story_view', sv.viewer_id, sv.story_id::BIGINT, sv.viewed_at
+        FROM story_views sv
+        JOIN stories s ON s.id = sv.story_id
+        WHERE s.user_id = $1 AND sv.viewer_id <> $1`
+
+// notBlocked excludes actors blocked by, or blocking, user $1
+const notBlocked = `
+        NOT EXISTS (
+            SELECT 1 FROM blocked_users b
+            WHERE (b.user_id = $1 AND b.blocked_id = a.actor_id)
+               OR (b.user_id = a.actor_id AND b.blocked_id = $1)
+        )`
+
+func (r *postgresRepository) GetActivity(ctx context.Context, userID int64, before time.Time, limit int) ([]*Activity, error) {
+    query := `
+        SELECT a.type, a.target_id, a.occurred_at,
+               a.actor_id, u.username, u.display_name, u.profile_picture
+        FROM (` + activitySources + `) a
+        JOIN users u ON u.id = a.actor_id
+        WHERE a.occurred_at < $2 AND ` + users.ActiveSQL("u") + ` AND` + notBlocked + `
+        ORDER BY a.occurred_at DESC
+        LIMIT $3`
+
+    rows, err := r.db.QueryContext(ctx, query, userID, before, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    items := []*Activity{}
+    for rows.Next() {
+        item := &Activity{Actor: &Actor{}}
+        err := rows.Scan(
+            &item.Type, &item.TargetID, &item.OccurredAt,
+            &item.Actor.ID, &item.Actor.Username, &item.Actor.DisplayName, &item.Actor.ProfilePicture,
+        )
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, item)
+    }
+    return items, rows.Err()
+}
+
+func (r *postgresRepository) CountSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+    query := `
+        SELECT COUNT(*)
+        FROM (` + activitySources + `) a
+        JOIN users u ON u.id = a.actor_id
+        WHERE a.occurred_at > $2 AND ` + users.ActiveSQL("u") + ` AND` + notBlocked
+
+    var count int
+    err := r.db.GetContext(ctx, &count, query, userID, since)
+    return count, err
+}
+
+// GetLastReadAt returns when the user last read their activity, or the zero time
+func (r *postgresRepository) GetLastReadAt(ctx context.Context, userID int64) (time.Time, error) {
+    var readAt time.Time
+    query := `SELECT last_read_at FROM activity_reads WHERE user_id = $1`
+
+    err := r.db.GetContext(ctx, &readAt, query, userID)
+    if err == sql.ErrNoRows {
+        return time.Time{}, nil
+    }
+    return readAt, err
+}
+
+func (r *postgresRepository) SetLastReadAt(ctx context.Context, userID int64, readAt time.Time) error {
+    query := `
+        INSERT INTO activity_reads (user_id, last_read_at)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id) DO UPDATE
+        SET last_read_at = GREATEST(activity_reads.last_read_at, EXCLUDED.last_read_at)`
+
+    _, err := r.db.ExecContext(ctx, query, userID, readAt)
+    return err
+}
+
+func (r *postgresRepository) IsPremium(ctx context.Context, userID int64) (bool, error) {
+    var premium bool
+    query := `SELECT COALESCE(is_premium, false) FROM users WHERE id = $1`
+
+    err := r.db.GetContext(ctx, &premium, query, userID)
+    if err == sql.ErrNoRows {
+        return false, nil
+    }
+    return premium, err
+}
@@ -0,0 +1,14 @@
+package activity
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/activity").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("", handler.GetActivity).Methods("GET")
+    api.HandleFunc("/read", handler.MarkRead).Methods("POST")
+}
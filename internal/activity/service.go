@@ -0,0 +1,102 @@
+// internal/activity/service.go
+
+package activity
+
+import (
+    "context"
+    "fmt"
+    "time"
+)
+
+const (
+    DefaultFeedLimit = 20
+    MaxFeedLimit     = 50
+)
+
+type Service interface {
+    GetFeed(ctx context.Context, userID int64, before *time.Time, limit int) (*Feed, error)
+    MarkRead(ctx context.Context, userID int64, readAt time.Time) error
+
+    // SetEntitlements sets the checker consulted for "who viewed you" access
+    SetEntitlements(entitlements Entitlements)
+}
+
+// Entitlements is the part of billing the activity stream needs
+type Entitlements interface {
+    CanSeeProfileViews(ctx context.Context, userID int64) (bool, error)
+}
+
+type service struct {
+    repo         Repository
+    entitlements Entitlements
+}
+
+func NewService(repo Repository) Service {
+    return &service{repo: repo}
+}
+
+// GetFeed returns the user's activity newest first, starting before the
+// cursor when one is given. Only premium users see who viewed their profile;
+// everyone else gets the view with the actor withheld.
+func (s *service) GetFeed(ctx context.Context, userID int64, before *time.Time, limit int) (*Feed, error) {
+    cursor := time.Now()
+    if before != nil {
+        cursor = *before
+    }
+
+    premium, err := s.canSeeProfileViews(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to check premium status: %w", err)
+    }
+
+    lastReadAt, err := s.repo.GetLastReadAt(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get last read time: %w", err)
+    }
+
+    items, err := s.repo.GetActivity(ctx, userID, cursor, limit)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get activity: %w", err)
+    }
+
+    unread, err := s.repo.CountSince(ctx, userID, lastReadAt)
+    if err != nil {
+        return nil, fmt.Errorf("failed to count unread activity: %w", err)
+    }
+
+    for _, item := range items {
+        item.IsUnread = item.OccurredAt.After(lastReadAt)
+        if item.Type == TypeProfileView && !premium {
+            item.Actor = nil
+            item.Locked = true
+        }
+    }
+
+    feed := &Feed{
+        Items:       items,
+        UnreadCount: unread,
+        IsPremium:   premium,
+    }
+    if len(items) == limit {
+        next := items[len(items)-1].OccurredAt
+        feed.NextCursor = &next
+    }
+    return feed, nil
+}
+
+// SetEntitlements sets the billing checker. Without one, the users.is_premium flag is used.
+func (s *service) SetEntitlements(entitlements Entitlements) {
+    s.entitlements = entitlements
+}
+
+func (s *service) canSeeProfileViews(ctx context.Context, userID int64) (bool, error) {
+    if s.entitlements != nil {
+        return s.entitlements.CanSeeProfileViews(ctx, userID)
+    }
+    return s.repo.IsPremium(ctx, userID)
+}
+
+// MarkRead marks everything up to readAt as read. Read markers only move forward.
+func (s *service) MarkRead(ctx context.Context, userID int64, readAt time.Time) error {
+    return s.repo.SetLastReadAt(ctx, userID, readAt)
+}
@@ -0,0 +1,473 @@
+// internal/admin/handlers.go
+
+package admin
+
+import (
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "strconv"
+    "time"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+    "github.com/imadgeboyega/kiekky-backend/internal/erasure"
+)
+
+// maxImportUploadSize bounds the multipart body of an import request
+const maxImportUploadSize = 10 << 20
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// ExportUsers handles GET /admin/users/export. By default the CSV is streamed
+// back; with destination=storage it is written to the export store by a
+// background job and a job is returned instead.
+func (h *Handler) ExportUsers(w http.ResponseWriter, r *http.Request) {
+    adminID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    filter, err := parseExportFilter(r)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+
+    if r.URL.Query().Get("destination") == "storage" {
+        job, err := h.service.StartExport(r.Context(), adminID, filter)
+        if err != nil {
+            if errors.Is(err, ErrExportStoreUnavailable) {
+                utils.RespondWithError(w, http.StatusServiceUnavailable, err.Error())
+                return
+            }
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to start export")
+            return
+        }
+        utils.RespondWithJSON(w, http.StatusAccepted, job)
+        return
+    }
+
+    filename := fmt.Sprintf("users_%s.csv", time.Now().UTC().Format("20060102T150405Z"))
+    w.Header().Set("Content-Type", "text/csv")
+    w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+    w.Header().Set("Cache-Control", "no-store")
+
+    // Headers are already sent once streaming starts, so a failure can only be logged
+    if err := h.service.ExportUsersCSV(r.Context(), adminID, filter, w); err != nil {
+        log.Printf("User export by admin %d failed: %v", adminID, err)
+    }
+}
+
+// ImportUsers handles POST /admin/users/import with a CSV in the "file" field
+func (h *Handler) ImportUsers(w http.ResponseWriter, r *http.Request) {
+    adminID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    r.Body = http.MaxBytesReader(w, r.Body, maxImportUploadSize)
+    if err := r.ParseMultipartForm(maxImportUploadSize); err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Import file too large or invalid form")
+        return
+    }
+
+    file, _, err := r.FormFile("file")
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "CSV file is required")
+        return
+    }
+    defer file.Close()
+
+    opts := &ImportOptions{SendInvitations: true}
+    if v := r.FormValue("send_invitations"); v != "" {
+        opts.SendInvitations, _ = strconv.ParseBool(v)
+    }
+
+    job, err := h.service.StartImport(r.Context(), adminID, file, opts)
+    if err != nil {
+        if errors.Is(err, ErrInvalidImportFile) || errors.Is(err, ErrTooManyImportRows) {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to start import")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusAccepted, job)
+}
+
+// GetJobs lists user export and import jobs, newest first
+func (h *Handler) GetJobs(w http.ResponseWriter, r *http.Request) {
+    limit, offset := parsePage(r)
+
+    jobs, err := h.service.ListJobs(r.Context(), limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get jobs")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "jobs": jobs,
+    })
+}
+
+// GetJob returns one job with its progress and per-row errors
+func (h *Handler) GetJob(w http.ResponseWriter, r *http.Request) {
+    jobID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid job ID")
+        return
+    }
+
+    job, err := h.service.GetJob(r.Context(), jobID)
+    if err != nil {
+        if errors.Is(err, ErrJobNotFound) {
+            utils.RespondWithError(w, http.StatusNotFound, "Job not found")
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get job")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, job)
+}
+
+// SearchUsers handles GET /admin/users?q=&status=&role=&verified=
+func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    q := r.URL.Query()
+    search := &UserSearch{
+        Query:  q.Get("q"),
+        Status: q.Get("status"),
+        Role:   q.Get("role"),
+    }
+    if v := q.Get("verified"); v != "" {
+        verified, err := strconv.ParseBool(v)
+        if err != nil {
+            utils.RespondWithError(w, http.StatusBadRequest, "verified must be true or false")
+            return
+        }
+        search.Verified = &verified
+    }
+    search.Limit, search.Offset = parsePage(r)
+
+    found, err := h.service.SearchUsers(r.Context(), actorID, search)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to search users")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "users": found,
+    })
+}
+
+// GetUser returns a user with their sessions, reports and content counts
+func (h *Handler) GetUser(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    detail, err := h.service.GetUserDetail(r.Context(), actorID, userID)
+    if err != nil {
+        respondWithUserError(w, err, "Failed to get user")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, detail)
+}
+
+// VerifyUser marks a user verified
+func (h *Handler) VerifyUser(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    if err := h.service.VerifyUser(r.Context(), actorID, userID); err != nil {
+        respondWithUserError(w, err, "Failed to verify user")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User verified"})
+}
+
+// SuspendUser suspends a user and signs them out everywhere
+func (h *Handler) SuspendUser(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    var req SuspendRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    if err := h.service.SuspendUser(r.Context(), actorID, userID, req.Reason); err != nil {
+        respondWithUserError(w, err, "Failed to suspend user")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User suspended"})
+}
+
+// UnsuspendUser lifts a suspension
+func (h *Handler) UnsuspendUser(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    if err := h.service.UnsuspendUser(r.Context(), actorID, userID); err != nil {
+        respondWithUserError(w, err, "Failed to unsuspend user")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "User unsuspended"})
+}
+
+// ResetPassword signs the user out and emails them a reset code
+func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    if err := h.service.ResetPassword(r.Context(), actorID, userID); err != nil {
+        respondWithUserError(w, err, "Failed to reset password")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Password reset code sent"})
+}
+
+// Impersonate returns a short-lived access token for the user. The session
+// is recorded in the audit log with the given reason.
+func (h *Handler) Impersonate(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    var req ImpersonateRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    resp, err := h.service.Impersonate(r.Context(), actorID, userID, &req)
+    if err != nil {
+        respondWithUserError(w, err, "Failed to start support session")
+        return
+    }
+
+    w.Header().Set("Cache-Control", "no-store")
+    utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// SetRole changes a user's role
+func (h *Handler) SetRole(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    var req RoleRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    if err := h.service.SetRole(r.Context(), actorID, userID, req.Role); err != nil {
+        respondWithUserError(w, err, "Failed to change role")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Role updated"})
+}
+
+// EraseUser erases an account and returns what will be purged
+func (h *Handler) EraseUser(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    manifest, err := h.service.EraseUser(r.Context(), actorID, userID)
+    if err != nil {
+        respondWithUserError(w, err, "Failed to erase user")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusAccepted, manifest)
+}
+
+// GetErasure shows which subsystems have purged an erased account
+func (h *Handler) GetErasure(w http.ResponseWriter, r *http.Request) {
+    actorID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    userID, ok := parseUserID(w, r)
+    if !ok {
+        return
+    }
+
+    purges, err := h.service.GetErasure(r.Context(), actorID, userID)
+    if err != nil {
+        respondWithUserError(w, err, "Failed to get erasure")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, purges)
+}
+
+// GetAuditLog handles GET /admin/audit-log?actor_id=&user_id=&action=
+func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+    q := r.URL.Query()
+    filter := &AuditFilter{Action: q.Get("action")}
+    filter.ActorID, _ = strconv.ParseInt(q.Get("actor_id"), 10, 64)
+    filter.TargetUserID, _ = strconv.ParseInt(q.Get("user_id"), 10, 64)
+    filter.Limit, filter.Offset = parsePage(r)
+
+    entries, err := h.service.ListAudit(r.Context(), filter)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get audit log")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "entries": entries,
+    })
+}
+
+func parseUserID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+    userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+        return 0, false
+    }
+    return userID, true
+}
+
+func parsePage(r *http.Request) (int, int) {
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+    if limit <= 0 || limit > 100 {
+        limit = 20
+    }
+    if offset < 0 {
+        offset = 0
+    }
+    return limit, offset
+}
+
+func respondWithUserError(w http.ResponseWriter, err error, message string) {
+    switch {
+    case errors.Is(err, ErrUserNotFound), errors.Is(err, auth.ErrUserNotFound), errors.Is(err, erasure.ErrUserNotFound):
+        utils.RespondWithError(w, http.StatusNotFound, "User not found")
+    case errors.Is(err, ErrForbidden), errors.Is(err, auth.ErrCannotImpersonate):
+        utils.RespondWithError(w, http.StatusForbidden, err.Error())
+    case errors.Is(err, ErrSelfAction), errors.Is(err, ErrNoEmail),
+        errors.Is(err, ErrNotSuspended), errors.Is(err, ErrAlreadyBlocked),
+        errors.Is(err, erasure.ErrAlreadyErased):
+        utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+    default:
+        utils.RespondWithError(w, http.StatusInternalServerError, message)
+    }
+}
+
+func parseExportFilter(r *http.Request) (*ExportFilter, error) {
+    q := r.URL.Query()
+    filter := &ExportFilter{
+        Status: q.Get("status"),
+    }
+
+    if v := q.Get("verified"); v != "" {
+        verified, err := strconv.ParseBool(v)
+        if err != nil {
+            return nil, errors.New("verified must be true or false")
+        }
+        filter.Verified = &verified
+    }
+    if v := q.Get("mask_pii"); v != "" {
+        mask, err := strconv.ParseBool(v)
+        if err != nil {
+            return nil, errors.New("mask_pii must be true or false")
+        }
+        filter.MaskPII = mask
+    }
+    if v := q.Get("created_after"); v != "" {
+        t, err := time.Parse(time.RFC3339, v)
+        if err != nil {
+            return nil, errors.New("created_after must be an RFC 3339 timestamp")
+        }
+        filter.CreatedAfter = &t
+    }
+    if v := q.Get("created_before"); v != "" {
+        t, err := time.Parse(time.RFC3339, v)
+        if err != nil {
+            return nil, errors.New("created_before must be an RFC 3339 timestamp")
+        }
+        filter.CreatedBefore = &t
+    }
+
+    return filter, nil
+}
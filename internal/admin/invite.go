@@ -0,0 +1,62 @@
+// internal/admin/invite.go
+
+package admin
+
+import (
+    "context"
+    "fmt"
+    "net/url"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/email"
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
+    notifications "github.com/imadgeboyega/kiekky-backend/internal/notification"
+)
+
+// Inviter tells an imported user their account exists
+type Inviter interface {
+    SendInvitation(ctx context.Context, row *ImportRow) error
+}
+
+type emailInviter struct {
+    emailService notifications.EmailService
+    appURL       string
+}
+
+// NewEmailInviter sends invitations through the notification email service.
+// The link leads to the app's password reset screen, which sends the user a
+// code to choose their first password.
+func NewEmailInviter(emailService notifications.EmailService, appURL string) Inviter {
+    return &emailInviter{
+        emailService: emailService,
+        appURL:       appURL,
+    }
+}
+
+func (i *emailInviter) SendInvitation(ctx context.Context, row *ImportRow) error {
+    name := row.DisplayName
+    if name == "" {
+        name = row.Username
+    }
+
+    setPasswordURL := fmt.Sprintf("%s/forgot-password?email=%s", i.appURL, url.QueryEscape(row.Email))
+
+    // Imported users have no language preference yet
+    lang := i18n.Default
+    message, err := email.Render("invitation", lang, email.Data{
+        "Title":          i18n.T(lang, "email.invitation.subject", nil),
+        "Name":           name,
+        "Username":       row.Username,
+        "SetPasswordURL": setPasswordURL,
+        "PreferencesURL": i.appURL + "/settings/notifications",
+    })
+    if err != nil {
+        return err
+    }
+
+    return i.emailService.SendEmail(ctx, &notifications.EmailNotification{
+        To:      row.Email,
+        Subject: message.Subject,
+        Body:    message.Text,
+        HTML:    message.HTML,
+    })
+}
@@ -0,0 +1,234 @@
+// internal/admin/models.go
+
+package admin
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "time"
+)
+
+// JobType distinguishes user exports from imports
+type JobType string
+
+const (
+    JobTypeExport JobType = "export"
+    JobTypeImport JobType = "import"
+)
+
+// JobStatus is the lifecycle of a transfer job
+type JobStatus string
+
+const (
+    JobStatusPending   JobStatus = "pending"
+    JobStatusRunning   JobStatus = "running"
+    JobStatusCompleted JobStatus = "completed"
+    JobStatusFailed    JobStatus = "failed"
+)
+
+// TransferJob is an asynchronous user export or import
+type TransferJob struct {
+    ID            int64           `json:"id" db:"id"`
+    Type          JobType         `json:"type" db:"type"`
+    Status        JobStatus       `json:"status" db:"status"`
+    CreatedBy     int64           `json:"created_by" db:"created_by"`
+    Params        json.RawMessage `json:"params" db:"params"`
+    TotalRows     int             `json:"total_rows" db:"total_rows"`
+    ProcessedRows int             `json:"processed_rows" db:"processed_rows"`
+    SucceededRows int             `json:"succeeded_rows" db:"succeeded_rows"`
+    FailedRows    int             `json:"failed_rows" db:"failed_rows"`
+    Errors        RowErrors       `json:"errors" db:"errors"`
+    ResultURL     *string         `json:"result_url,omitempty" db:"result_url"`
+    Error         *string         `json:"error,omitempty" db:"error"`
+    CreatedAt     time.Time       `json:"created_at" db:"created_at"`
+    StartedAt     *time.Time      `json:"started_at,omitempty" db:"started_at"`
+    CompletedAt   *time.Time      `json:"completed_at,omitempty" db:"completed_at"`
+}
+
+// RowError reports why one import row was rejected. Row is 1-based and
+// excludes the header.
+type RowError struct {
+    Row   int    `json:"row"`
+    Email string `json:"email,omitempty"`
+    Error string `json:"error"`
+}
+
+// RowErrors is stored as JSONB
+type RowErrors []RowError
+
+// Scan implements sql.Scanner interface
+func (e *RowErrors) Scan(value interface{}) error {
+    if value == nil {
+        *e = RowErrors{}
+        return nil
+    }
+
+    bytes, ok := value.([]byte)
+    if !ok {
+        return nil
+    }
+
+    return json.Unmarshal(bytes, e)
+}
+
+// Value implements driver.Valuer interface
+func (e RowErrors) Value() (driver.Value, error) {
+    if e == nil {
+        return "[]", nil
+    }
+    return json.Marshal(e)
+}
+
+// ExportFilter selects which users are exported
+type ExportFilter struct {
+    Verified      *bool      `json:"verified,omitempty"`
+    Status        string     `json:"status,omitempty"`
+    CreatedAfter  *time.Time `json:"created_after,omitempty"`
+    CreatedBefore *time.Time `json:"created_before,omitempty"`
+    MaskPII       bool       `json:"mask_pii"`
+}
+
+// ExportUser is one row of a user export
+type ExportUser struct {
+    ID            int64     `db:"id"`
+    Username      string    `db:"username"`
+    Email         string    `db:"email"`
+    Phone         string    `db:"phone"`
+    DisplayName   string    `db:"display_name"`
+    IsVerified    bool      `db:"is_verified"`
+    AccountStatus string    `db:"account_status"`
+    CreatedAt     time.Time `db:"created_at"`
+}
+
+// ImportRow is one parsed row of a user import file
+type ImportRow struct {
+    Row         int
+    Email       string
+    Username    string
+    DisplayName string
+    Phone       string
+}
+
+// ImportOptions controls how imported accounts are created
+type ImportOptions struct {
+    SendInvitations bool `json:"send_invitations"`
+}
+
+// UserSearch filters the admin user search. Query matches the username,
+// email, phone or display name, or an exact user ID.
+type UserSearch struct {
+    Query    string
+    Status   string
+    Role     string
+    Verified *bool
+    Limit    int
+    Offset   int
+}
+
+// UserSummary is a user as staff see them
+type UserSummary struct {
+    ID               int64      `json:"id" db:"id"`
+    Username         string     `json:"username" db:"username"`
+    Email            *string    `json:"email,omitempty" db:"email"`
+    Phone            *string    `json:"phone,omitempty" db:"phone"`
+    DisplayName      *string    `json:"display_name,omitempty" db:"display_name"`
+    ProfilePicture   *string    `json:"profile_picture,omitempty" db:"profile_picture"`
+    Provider         string     `json:"provider" db:"provider"`
+    IsVerified       bool       `json:"is_verified" db:"is_verified"`
+    AccountStatus    string     `json:"account_status" db:"account_status"`
+    Role             string     `json:"role" db:"role"`
+    SuspendedAt      *time.Time `json:"suspended_at,omitempty" db:"suspended_at"`
+    SuspensionReason *string    `json:"suspension_reason,omitempty" db:"suspension_reason"`
+    CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+}
+
+// UserDetail is everything support needs to look into an account
+type UserDetail struct {
+    User     *UserSummary      `json:"user"`
+    Sessions []*SessionSummary `json:"sessions"`
+    Reports  []*Report         `json:"reports"`
+    Counts   *ContentCounts    `json:"counts"`
+}
+
+// SessionSummary is one of the user's active sessions
+type SessionSummary struct {
+    ID         int64      `json:"id" db:"id"`
+    Device     *string    `json:"device,omitempty" db:"device_info"`
+    IPAddress  *string    `json:"ip_address,omitempty" db:"ip_address"`
+    Location   *string    `json:"location,omitempty" db:"location"`
+    CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+    LastUsedAt *time.Time `json:"last_used_at,omitempty" db:"last_used_at"`
+}
+
+// Report is a report another user made against this one
+type Report struct {
+    ID         int64     `json:"id" db:"id"`
+    ReporterID *int64    `json:"reporter_id,omitempty" db:"reporter_id"`
+    Reason     string    `json:"reason" db:"reason"`
+    Details    *string   `json:"details,omitempty" db:"details"`
+    Status     string    `json:"status" db:"status"`
+    CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// ContentCounts is how much the user has posted and how connected they are
+type ContentCounts struct {
+    Posts      int64 `json:"posts" db:"posts"`
+    Comments   int64 `json:"comments" db:"comments"`
+    Stories    int64 `json:"stories" db:"stories"`
+    Messages   int64 `json:"messages" db:"messages"`
+    Followers  int64 `json:"followers" db:"followers"`
+    Following  int64 `json:"following" db:"following"`
+    Violations int64 `json:"violations" db:"violations"`
+}
+
+// Audit log actions
+const (
+    ActionUserSearch        = "user.search"
+    ActionUserView          = "user.view"
+    ActionUserVerify        = "user.verify"
+    ActionUserSuspend       = "user.suspend"
+    ActionUserUnsuspend     = "user.unsuspend"
+    ActionUserPasswordReset = "user.password_reset"
+    ActionUserImpersonate   = "user.impersonate"
+    ActionUserRoleChange    = "user.role_change"
+    ActionUserErase         = "user.erase"
+    ActionUsersExport       = "users.export"
+    ActionUsersImport       = "users.import"
+)
+
+// AuditEntry is one recorded staff action
+type AuditEntry struct {
+    ID           int64           `json:"id" db:"id"`
+    ActorID      int64           `json:"actor_id" db:"actor_id"`
+    Action       string          `json:"action" db:"action"`
+    TargetUserID *int64          `json:"target_user_id,omitempty" db:"target_user_id"`
+    Details      json.RawMessage `json:"details,omitempty" db:"details"`
+    IPAddress    *string         `json:"ip_address,omitempty" db:"ip_address"`
+    CreatedAt    time.Time       `json:"created_at" db:"created_at"`
+}
+
+// AuditFilter selects audit log entries; zero values match everything
+type AuditFilter struct {
+    ActorID      int64
+    TargetUserID int64
+    Action       string
+    Limit        int
+    Offset       int
+}
+
+// SuspendRequest is the body of a suspension
+type SuspendRequest struct {
+    Reason string `json:"reason" validate:"required,max=500"`
+}
+
+// ImpersonateRequest is the body of a support session request. The reason
+// is kept in the audit log.
+type ImpersonateRequest struct {
+    Reason  string `json:"reason" validate:"required,max=500"`
+    Minutes int    `json:"minutes" validate:"omitempty,min=1,max=60"`
+}
+
+// RoleRequest changes a user's role
+type RoleRequest struct {
+    Role string `json:"role" validate:"required,oneof=user support admin"`
+}
@@ -0,0 +1,397 @@
+// internal/admin/repository.go
+
+package admin
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+)
+
+var (
+    ErrJobNotFound  = errors.New("job not found")
+    ErrUserNotFound = errors.New("user not found")
+)
+
+type Repository interface {
+    // Transfer jobs
+    CreateJob(ctx context.Context, job *TransferJob) error
+    GetJob(ctx context.Context, jobID int64) (*TransferJob, error)
+    ListJobs(ctx context.Context, limit, offset int) ([]*TransferJob, error)
+    StartJob(ctx context.Context, jobID int64, totalRows int) error
+    UpdateJobProgress(ctx context.Context, job *TransferJob) error
+    FinishJob(ctx context.Context, job *TransferJob) error
+
+    // Users
+    ExportUsers(ctx context.Context, filter *ExportFilter, fn func(*ExportUser) error) error
+    CreateImportedUser(ctx context.Context, row *ImportRow) (int64, error)
+
+    // User management
+    SearchUsers(ctx context.Context, search *UserSearch) ([]*UserSummary, error)
+    GetUser(ctx context.Context, userID int64) (*UserSummary, error)
+    GetActiveSessions(ctx context.Context, userID int64) ([]*SessionSummary, error)
+    GetReports(ctx context.Context, userID int64, limit int) ([]*Report, error)
+    GetContentCounts(ctx context.Context, userID int64) (*ContentCounts, error)
+    SetVerified(ctx context.Context, userID int64) error
+    SetAccountStatus(ctx context.Context, userID int64, status, reason string) error
+    SetRole(ctx context.Context, userID int64, role string) error
+
+    // Audit log
+    RecordAudit(ctx context.Context, entry *AuditEntry) error
+    ListAudit(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+const jobColumns = `
+    id, type, status, COALESCE(created_by, 0) AS created_by, COALESCE(params, '{}') AS params,
+    total_rows, processed_rows, succeeded_rows, failed_rows,
+    COALESCE(errors, '[]') AS errors, result_url, error,
+    created_at, started_at, completed_at`
+
+func (r *repository) CreateJob(ctx context.Context, job *TransferJob) error {
+    query := `
+        INSERT INTO user_transfer_jobs (type, status, created_by, params)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at`
+
+    return r.db.QueryRowContext(ctx, query, job.Type, job.Status, job.CreatedBy, []byte(job.Params)).
+        Scan(&job.ID, &job.CreatedAt)
+}
+
+func (r *repository) GetJob(ctx context.Context, jobID int64) (*TransferJob, error) {
+    var job TransferJob
+    query := `SELECT ` + jobColumns + ` FROM user_transfer_jobs WHERE id = $1`
+
+    err := r.db.GetContext(ctx, &job, query, jobID)
+    if err == sql.ErrNoRows {
+        return nil, ErrJobNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &job, nil
+}
+
+func (r *repository) ListJobs(ctx context.Context, limit, offset int) ([]*TransferJob, error) {
+    query := `SELECT ` + jobColumns + ` FROM user_transfer_jobs ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+
+    jobs := []*TransferJob{}
+    err := r.db.SelectContext(ctx, &jobs, query, limit, offset)
+    return jobs, err
+}
+
+func (r *repository) StartJob(ctx context.Context, jobID int64, totalRows int) error {
+    query := `
+        UPDATE user_transfer_jobs
+        SET status = $2, total_rows = $3, started_at = NOW()
+        WHERE id = $1`
+
+    _, err := r.db.ExecContext(ctx, query, jobID, JobStatusRunning, totalRows)
+    return err
+}
+
+func (r *repository) UpdateJobProgress(ctx context.Context, job *TransferJob) error {
+    query := `
+        UPDATE user_transfer_jobs
+        SET total_rows = $2, processed_rows = $3, succeeded_rows = $4, failed_rows = $5, errors = $6
+        WHERE id = $1`
+
+    _, err := r.db.ExecContext(ctx, query,
+        job.ID, job.TotalRows, job.ProcessedRows, job.SucceededRows, job.FailedRows, job.Errors)
+    return err
+}
+
+func (r *repository) FinishJob(ctx context.Context, job *TransferJob) error {
+    query := `
+        UPDATE user_transfer_jobs
+        SET status = $2, total_rows = $3, processed_rows = $4, succeeded_rows = $5, failed_rows = $6,
+            errors = $7, result_url = $8, error = $9, completed_at = NOW()
+        WHERE id = $1`
+
+    _, err := r.db.ExecContext(ctx, query,
+        job.ID, job.Status, job.TotalRows, job.ProcessedRows, job.SucceededRows, job.FailedRows,
+        job.Errors, job.ResultURL, job.Error)
+    return err
+}
+
+// ExportUsers streams users matching filter to fn in ID order
+func (r *repository) ExportUsers(ctx context.Context, filter *ExportFilter, fn func(*ExportUser) error) error {
+    var where []string
+    var args []interface{}
+
+    if filter.Verified != nil {
+        args = append(args, *filter.Verified)
+        where = append(where, fmt.Sprintf("is_verified = $%d", len(args)))
+    }
+    if filter.Status != "" {
+        args = append(args, filter.Status)
+        where = append(where, fmt.Sprintf("COALESCE(account_status, 'active') = $%d", len(args)))
+    }
+    if filter.CreatedAfter != nil {
+        args = append(args, *filter.CreatedAfter)
+        where = append(where, fmt.Sprintf("created_at >= $%d", len(args)))
+    }
+    if filter.CreatedBefore != nil {
+        args = append(args, *filter.CreatedBefore)
+        where = append(where, fmt.Sprintf("created_at < $%d", len(args)))
+    }
+
+    query := `
+        SELECT id, username, COALESCE(email, '') AS email, COALESCE(phone, '') AS phone,
+               COALESCE(display_name, '') AS display_name, COALESCE(is_verified, false) AS is_verified,
+               COALESCE(account_status, 'active') AS account_status, created_at
+        FROM users`
+    if len(where) > 0 {
+        query += " WHERE " + strings.Join(where, " AND ")
+    }
+    query += " ORDER BY id"
+
+    rows, err := r.db.QueryxContext(ctx, query, args...)
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var user ExportUser
+        if err := rows.StructScan(&user); err != nil {
+            return err
+        }
+        if err := fn(&user); err != nil {
+            return err
+        }
+    }
+    return rows.Err()
+}
+
+// CreateImportedUser inserts a pre-verified account without a password; the
+// user sets one through the password reset flow from their invitation
+func (r *repository) CreateImportedUser(ctx context.Context, row *ImportRow) (int64, error) {
+    query := `
+        INSERT INTO users (email, username, phone, display_name, provider, is_verified, created_at, updated_at)
+        VALUES ($1, $2, NULLIF($3, ''), NULLIF($4, ''), 'import', true, $5, $5)
+        RETURNING id`
+
+    var id int64
+    err := r.db.QueryRowContext(ctx, query,
+        strings.ToLower(row.Email), row.Username, row.Phone, row.DisplayName, time.Now(),
+    ).Scan(&id)
+    if err != nil {
+        var pgErr *pq.Error
+        if errors.As(err, &pgErr) && pgErr.Code == "23505" {
+            switch {
+            case strings.Contains(pgErr.Detail, "(email)"):
+                return 0, errors.New("email already registered")
+            case strings.Contains(pgErr.Detail, "(username)"):
+                return 0, errors.New("username already taken")
+            case strings.Contains(pgErr.Detail, "(phone)"):
+                return 0, errors.New("phone already registered")
+            }
+            return 0, errors.New("user already exists")
+        }
+        return 0, fmt.Errorf("failed to create user: %w", err)
+    }
+    return id, nil
+}
+
+const userSummaryColumns = `
+    id, username, email, phone, display_name, profile_picture,
+    COALESCE(provider, 'local') AS provider, COALESCE(is_verified, false) AS is_verified,
+    COALESCE(account_status, 'active') AS account_status, COALESCE(role, 'user') AS role,
+    suspended_at, suspension_reason, created_at`
+
+// SearchUsers returns matching users, newest first
+func (r *repository) SearchUsers(ctx context.Context, search *UserSearch) ([]*UserSummary, error) {
+    var where []string
+    var args []interface{}
+
+    if q := strings.TrimSpace(search.Query); q != "" {
+        args = append(args, "%"+escapeLike(strings.ToLower(q))+"%")
+        cond := fmt.Sprintf(`(LOWER(username) LIKE $%[1]d OR LOWER(email) LIKE $%[1]d
+            OR phone LIKE $%[1]d OR LOWER(display_name) LIKE $%[1]d`, len(args))
+        if id, err := strconv.ParseInt(q, 10, 64); err == nil {
+            args = append(args, id)
+            cond += fmt.Sprintf(" OR id = $%d", len(args))
+        }
+        where = append(where, cond+")")
+    }
+    if search.Status != "" {
+        args = append(args, search.Status)
+        where = append(where, fmt.Sprintf("COALESCE(account_status, 'active') = $%d", len(args)))
+    }
+    if search.Role != "" {
+        args = append(args, search.Role)
+        where = append(where, fmt.Sprintf("COALESCE(role, 'user') = $%d", len(args)))
+    }
+    if search.Verified != nil {
+        args = append(args, *search.Verified)
+        where = append(where, fmt.Sprintf("COALESCE(is_verified, false) = $%d", len(args)))
+    }
+
+    query := `SELECT ` + userSummaryColumns + ` FROM users`
+    if len(where) > 0 {
+        query += " WHERE " + strings.Join(where, " AND ")
+    }
+    args = append(args, search.Limit, search.Offset)
+    query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+    users := []*UserSummary{}
+    err := r.db.SelectContext(ctx, &users, query, args...)
+    return users, err
+}
+
+func (r *repository) GetUser(ctx context.Context, userID int64) (*UserSummary, error) {
+    var user UserSummary
+    query := `SELECT ` + userSummaryColumns + ` FROM users WHERE id = $1`
+
+    err := r.db.GetContext(ctx, &user, query, userID)
+    if err == sql.ErrNoRows {
+        return nil, ErrUserNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &user, nil
+}
+
+// GetActiveSessions returns the sessions that can still be used or refreshed
+func (r *repository) GetActiveSessions(ctx context.Context, userID int64) ([]*SessionSummary, error) {
+    query := `
+        SELECT id, device_info, ip_address, location, created_at, last_used_at
+        FROM sessions
+        WHERE user_id = $1 AND COALESCE(refresh_expires_at, expires_at) > NOW()
+        ORDER BY COALESCE(last_used_at, created_at) DESC`
+
+    sessions := []*SessionSummary{}
+    err := r.db.SelectContext(ctx, &sessions, query, userID)
+    return sessions, err
+}
+
+func (r *repository) GetReports(ctx context.Context, userID int64, limit int) ([]*Report, error) {
+    query := `
+        SELECT id, reporter_id, reason, details, COALESCE(status, 'open') AS status, created_at
+        FROM user_reports
+        WHERE reported_user_id = $1
+        ORDER BY created_at DESC
+        LIMIT $2`
+
+    reports := []*Report{}
+    err := r.db.SelectContext(ctx, &reports, query, userID, limit)
+    return reports, err
+}
+
+func (r *repository) GetContentCounts(ctx context.Context, userID int64) (*ContentCounts, error) {
+    var counts ContentCounts
+    query := `
+        SELECT
+            (SELECT COUNT(*) FROM posts WHERE user_id = $1 AND deleted_at IS NULL) AS posts,
+            (SELECT COUNT(*) FROM comments WHERE user_id = $1 AND deleted_at IS NULL) AS comments,
+            (SELECT COUNT(*) FROM stories WHERE user_id = $1) AS stories,
+            (SELECT COUNT(*) FROM messages WHERE sender_id = $1) AS messages,
+            (SELECT COUNT(*) FROM follows WHERE following_id = $1) AS followers,
+            (SELECT COUNT(*) FROM follows WHERE follower_id = $1) AS following,
+            (SELECT COALESCE(SUM(count), 0) FROM moderation_violations WHERE user_id = $1) AS violations`
+
+    err := r.db.GetContext(ctx, &counts, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    return &counts, nil
+}
+
+func (r *repository) SetVerified(ctx context.Context, userID int64) error {
+    query := `UPDATE users SET is_verified = TRUE, updated_at = NOW() WHERE id = $1`
+    return r.execOne(ctx, query, userID)
+}
+
+// SetAccountStatus changes the account status. Suspending records when and
+// why; any other status clears that.
+func (r *repository) SetAccountStatus(ctx context.Context, userID int64, status, reason string) error {
+    query := `
+        UPDATE users
+        SET account_status = $2,
+            suspended_at = CASE WHEN $2 = 'suspended' THEN NOW() END,
+            suspension_reason = CASE WHEN $2 = 'suspended' THEN NULLIF($3, '') END,
+            updated_at = NOW()
+        WHERE id = $1`
+    return r.execOne(ctx, query, userID, status, reason)
+}
+
+func (r *repository) SetRole(ctx context.Context, userID int64, role string) error {
+    query := `UPDATE users SET role = $2, updated_at = NOW() WHERE id = $1`
+    return r.execOne(ctx, query, userID, role)
+}
+
+// execOne runs an update of one user, returning ErrUserNotFound when there is none
+func (r *repository) execOne(ctx context.Context, query string, args ...interface{}) error {
+    result, err := r.db.ExecContext(ctx, query, args...)
+    if err != nil {
+        return err
+    }
+    if n, _ := result.RowsAffected(); n == 0 {
+        return ErrUserNotFound
+    }
+    return nil
+}
+
+func (r *repository) RecordAudit(ctx context.Context, entry *AuditEntry) error {
+    query := `
+        INSERT INTO admin_audit_log (actor_id, action, target_user_id, details, ip_address)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at`
+
+    var details interface{}
+    if len(entry.Details) > 0 {
+        details = string(entry.Details)
+    }
+    return r.db.QueryRowContext(ctx, query, entry.ActorID, entry.Action, entry.TargetUserID, details, entry.IPAddress).
+        Scan(&entry.ID, &entry.CreatedAt)
+}
+
+func (r *repository) ListAudit(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error) {
+    var where []string
+    var args []interface{}
+
+    if filter.ActorID != 0 {
+        args = append(args, filter.ActorID)
+        where = append(where, fmt.Sprintf("actor_id = $%d", len(args)))
+    }
+    if filter.TargetUserID != 0 {
+        args = append(args, filter.TargetUserID)
+        where = append(where, fmt.Sprintf("target_user_id = $%d", len(args)))
+    }
+    if filter.Action != "" {
+        args = append(args, filter.Action)
+        where = append(where, fmt.Sprintf("action = $%d", len(args)))
+    }
+
+    query := `SELECT id, COALESCE(actor_id, 0) AS actor_id, action, target_user_id, details, ip_address, created_at FROM admin_audit_log`
+    if len(where) > 0 {
+        query += " WHERE " + strings.Join(where, " AND ")
+    }
+    args = append(args, filter.Limit, filter.Offset)
+    query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+    entries := []*AuditEntry{}
+    err := r.db.SelectContext(ctx, &entries, query, args...)
+    return entries, err
+}
+
+// escapeLike escapes LIKE wildcards in user input
+func escapeLike(s string) string {
+    return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
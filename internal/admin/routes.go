@@ -0,0 +1,38 @@
+package admin
+
+import (
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/admin/users").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+    api.Use(authMiddleware.RequireStaff)
+
+    // User management (support and admins)
+    api.HandleFunc("", handler.SearchUsers).Methods("GET")
+    api.HandleFunc("/{id:[0-9]+}", handler.GetUser).Methods("GET")
+    api.HandleFunc("/{id:[0-9]+}/verify", handler.VerifyUser).Methods("POST")
+    api.HandleFunc("/{id:[0-9]+}/suspend", handler.SuspendUser).Methods("POST")
+    api.HandleFunc("/{id:[0-9]+}/unsuspend", handler.UnsuspendUser).Methods("POST")
+    api.HandleFunc("/{id:[0-9]+}/reset-password", handler.ResetPassword).Methods("POST")
+    api.HandleFunc("/{id:[0-9]+}/impersonate", handler.Impersonate).Methods("POST")
+
+    // Roles, erasure and bulk transfer (admins only)
+    api.Handle("/{id:[0-9]+}/role", authMiddleware.RequireAdmin(http.HandlerFunc(handler.SetRole))).Methods("PUT")
+    api.Handle("/{id:[0-9]+}/erase", authMiddleware.RequireAdmin(http.HandlerFunc(handler.EraseUser))).Methods("POST")
+    api.Handle("/{id:[0-9]+}/erasure", authMiddleware.RequireAdmin(http.HandlerFunc(handler.GetErasure))).Methods("GET")
+    api.Handle("/export", authMiddleware.RequireAdmin(http.HandlerFunc(handler.ExportUsers))).Methods("GET")
+    api.Handle("/import", authMiddleware.RequireAdmin(http.HandlerFunc(handler.ImportUsers))).Methods("POST")
+    api.Handle("/jobs", authMiddleware.RequireAdmin(http.HandlerFunc(handler.GetJobs))).Methods("GET")
+    api.Handle("/jobs/{id:[0-9]+}", authMiddleware.RequireAdmin(http.HandlerFunc(handler.GetJob))).Methods("GET")
+
+    audit := router.PathPrefix("/api/v1/admin/audit-log").Subrouter()
+    audit.Use(authMiddleware.Authenticate)
+    audit.Use(authMiddleware.RequireAdmin)
+
+    audit.HandleFunc("", handler.GetAuditLog).Methods("GET")
+}
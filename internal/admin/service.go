@@ -0,0 +1,389 @@
+// internal/admin/service.go
+
+package admin
+
+import (
+    "bytes"
+    "context"
+    "encoding/csv"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "net/mail"
+    "regexp"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/erasure"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+const (
+    MaxImportRows = 10000
+
+    // maxReportedRowErrors caps the errors kept on a job; the failed count is always exact
+    maxReportedRowErrors = 1000
+
+    // progressInterval is how many rows are processed between progress writes
+    progressInterval = 100
+
+    jobTimeout = 1 * time.Hour
+)
+
+var (
+    ErrExportStoreUnavailable = errors.New("export storage is not configured")
+    ErrInvalidImportFile      = errors.New("invalid import file")
+    ErrTooManyImportRows      = fmt.Errorf("import files are limited to %d rows", MaxImportRows)
+)
+
+var (
+    exportHeader  = []string{"id", "username", "email", "phone", "display_name", "is_verified", "account_status", "created_at"}
+    usernameRegex = regexp.MustCompile(`^[a-zA-Z0-9_.]{3,30}$`)
+)
+
+type Service interface {
+    ExportUsersCSV(ctx context.Context, adminID int64, filter *ExportFilter, w io.Writer) error
+    StartExport(ctx context.Context, adminID int64, filter *ExportFilter) (*TransferJob, error)
+    StartImport(ctx context.Context, adminID int64, file io.Reader, opts *ImportOptions) (*TransferJob, error)
+    GetJob(ctx context.Context, jobID int64) (*TransferJob, error)
+    ListJobs(ctx context.Context, limit, offset int) ([]*TransferJob, error)
+
+    // User management; actorID is the staff member acting
+    SearchUsers(ctx context.Context, actorID int64, search *UserSearch) ([]*UserSummary, error)
+    GetUserDetail(ctx context.Context, actorID, userID int64) (*UserDetail, error)
+    VerifyUser(ctx context.Context, actorID, userID int64) error
+    SuspendUser(ctx context.Context, actorID, userID int64, reason string) error
+    UnsuspendUser(ctx context.Context, actorID, userID int64) error
+    ResetPassword(ctx context.Context, actorID, userID int64) error
+    Impersonate(ctx context.Context, actorID, userID int64, req *ImpersonateRequest) (*auth.AuthResponse, error)
+    SetRole(ctx context.Context, actorID, userID int64, role string) error
+    EraseUser(ctx context.Context, actorID, userID int64) (*events.ErasureManifest, error)
+    GetErasure(ctx context.Context, actorID, userID int64) ([]*erasure.Purge, error)
+    ListAudit(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error)
+}
+
+type service struct {
+    repo     Repository
+    store    ExportStore
+    inviter  Inviter
+    accounts Accounts
+    eraser   erasure.Service
+}
+
+// NewService creates the admin service. store and inviter may be nil, which
+// disables stored exports and invitation emails respectively.
+func NewService(repo Repository, store ExportStore, inviter Inviter, accounts Accounts, eraser erasure.Service) Service {
+    return &service{
+        repo:     repo,
+        store:    store,
+        inviter:  inviter,
+        accounts: accounts,
+        eraser:   eraser,
+    }
+}
+
+// ExportUsersCSV streams matching users as CSV
+func (s *service) ExportUsersCSV(ctx context.Context, adminID int64, filter *ExportFilter, w io.Writer) error {
+    s.audit(ctx, adminID, ActionUsersExport, nil, map[string]interface{}{"mask_pii": filter.MaskPII})
+    _, err := s.writeExport(ctx, filter, w)
+    return err
+}
+
+// StartExport writes the export to the export store in the background
+func (s *service) StartExport(ctx context.Context, adminID int64, filter *ExportFilter) (*TransferJob, error) {
+    if s.store == nil {
+        return nil, ErrExportStoreUnavailable
+    }
+
+    job, err := s.createJob(ctx, JobTypeExport, adminID, filter)
+    if err != nil {
+        return nil, err
+    }
+
+    s.audit(ctx, adminID, ActionUsersExport, nil, map[string]interface{}{"job_id": job.ID, "mask_pii": filter.MaskPII})
+    go s.runExport(job, filter)
+
+    return job, nil
+}
+
+// StartImport validates the file's shape, then creates the accounts in the
+// background. Row-level problems are reported on the job, not returned here.
+func (s *service) StartImport(ctx context.Context, adminID int64, file io.Reader, opts *ImportOptions) (*TransferJob, error) {
+    rows, err := parseImport(file)
+    if err != nil {
+        return nil, err
+    }
+
+    job, err := s.createJob(ctx, JobTypeImport, adminID, opts)
+    if err != nil {
+        return nil, err
+    }
+
+    s.audit(ctx, adminID, ActionUsersImport, nil, map[string]interface{}{"job_id": job.ID, "rows": len(rows)})
+    go s.runImport(job, rows, opts)
+
+    return job, nil
+}
+
+func (s *service) GetJob(ctx context.Context, jobID int64) (*TransferJob, error) {
+    return s.repo.GetJob(ctx, jobID)
+}
+
+func (s *service) ListJobs(ctx context.Context, limit, offset int) ([]*TransferJob, error) {
+    return s.repo.ListJobs(ctx, limit, offset)
+}
+
+func (s *service) createJob(ctx context.Context, jobType JobType, adminID int64, params interface{}) (*TransferJob, error) {
+    data, err := json.Marshal(params)
+    if err != nil {
+        return nil, err
+    }
+
+    job := &TransferJob{
+        Type:      jobType,
+        Status:    JobStatusPending,
+        CreatedBy: adminID,
+        Params:    data,
+        Errors:    RowErrors{},
+    }
+    if err := s.repo.CreateJob(ctx, job); err != nil {
+        return nil, fmt.Errorf("failed to create job: %w", err)
+    }
+    return job, nil
+}
+
+func (s *service) runExport(job *TransferJob, filter *ExportFilter) {
+    ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+    defer cancel()
+
+    s.repo.StartJob(ctx, job.ID, 0)
+
+    var buf bytes.Buffer
+    count, err := s.writeExport(ctx, filter, &buf)
+    if err == nil {
+        var url string
+        name := fmt.Sprintf("users_%d_%s.csv", job.ID, time.Now().UTC().Format("20060102T150405Z"))
+        if url, err = s.store.Save(ctx, name, buf.Bytes()); err == nil {
+            job.ResultURL = &url
+        }
+    }
+
+    job.TotalRows = count
+    job.ProcessedRows = count
+    job.SucceededRows = count
+    s.finishJob(job, err)
+}
+
+func (s *service) runImport(job *TransferJob, rows []*ImportRow, opts *ImportOptions) {
+    ctx, cancel := context.WithTimeout(context.Background(), jobTimeout)
+    defer cancel()
+
+    job.TotalRows = len(rows)
+    s.repo.StartJob(ctx, job.ID, job.TotalRows)
+
+    seenEmails := make(map[string]bool, len(rows))
+    seenUsernames := make(map[string]bool, len(rows))
+
+    for _, row := range rows {
+        if ctx.Err() != nil {
+            break
+        }
+
+        err := validateImportRow(row)
+        if err == nil {
+            email, username := strings.ToLower(row.Email), strings.ToLower(row.Username)
+            switch {
+            case seenEmails[email]:
+                err = errors.New("duplicate email in file")
+            case seenUsernames[username]:
+                err = errors.New("duplicate username in file")
+            default:
+                seenEmails[email] = true
+                seenUsernames[username] = true
+                _, err = s.repo.CreateImportedUser(ctx, row)
+            }
+        }
+
+        job.ProcessedRows++
+        if err != nil {
+            job.FailedRows++
+            if len(job.Errors) < maxReportedRowErrors {
+                job.Errors = append(job.Errors, RowError{Row: row.Row, Email: row.Email, Error: err.Error()})
+            }
+        } else {
+            job.SucceededRows++
+            if opts.SendInvitations && s.inviter != nil {
+                if err := s.inviter.SendInvitation(ctx, row); err != nil {
+                    log.Printf("Failed to send invitation for import job %d row %d: %v", job.ID, row.Row, err)
+                }
+            }
+        }
+
+        if job.ProcessedRows%progressInterval == 0 {
+            if err := s.repo.UpdateJobProgress(ctx, job); err != nil {
+                log.Printf("Failed to update import job %d progress: %v", job.ID, err)
+            }
+        }
+    }
+
+    s.finishJob(job, ctx.Err())
+}
+
+func (s *service) finishJob(job *TransferJob, err error) {
+    job.Status = JobStatusCompleted
+    if err != nil {
+        job.Status = JobStatusFailed
+        msg := err.Error()
+        job.Error = &msg
+        log.Printf("User %s job %d failed: %v", job.Type, job.ID, err)
+    }
+
+    // The job context may have expired; recording the outcome must not
+    finishCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+    defer cancel()
+
+    if err := s.repo.FinishJob(finishCtx, job); err != nil {
+        log.Printf("Failed to finish user %s job %d: %v", job.Type, job.ID, err)
+    }
+}
+
+// writeExport writes the CSV and returns the number of users written
+func (s *service) writeExport(ctx context.Context, filter *ExportFilter, w io.Writer) (int, error) {
+    cw := csv.NewWriter(w)
+    if err := cw.Write(exportHeader); err != nil {
+        return 0, err
+    }
+
+    count := 0
+    err := s.repo.ExportUsers(ctx, filter, func(user *ExportUser) error {
+        email, phone, displayName := user.Email, user.Phone, user.DisplayName
+        if filter.MaskPII {
+            email, phone, displayName = maskEmail(email), maskPhone(phone), maskName(displayName)
+        }
+
+        count++
+        return cw.Write([]string{
+            strconv.FormatInt(user.ID, 10),
+            user.Username,
+            email,
+            phone,
+            displayName,
+            strconv.FormatBool(user.IsVerified),
+            user.AccountStatus,
+            user.CreatedAt.UTC().Format(time.RFC3339),
+        })
+    })
+    if err != nil {
+        return count, err
+    }
+
+    cw.Flush()
+    return count, cw.Error()
+}
+
+// parseImport reads an import CSV. The header must name email and username
+// columns; display_name and phone are optional. Columns may be in any order.
+func parseImport(file io.Reader) ([]*ImportRow, error) {
+    cr := csv.NewReader(file)
+    cr.FieldsPerRecord = -1
+    cr.TrimLeadingSpace = true
+
+    header, err := cr.Read()
+    if err != nil {
+        return nil, fmt.Errorf("%w: missing header row", ErrInvalidImportFile)
+    }
+
+    columns := make(map[string]int, len(header))
+    for i, name := range header {
+        columns[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+    }
+    if _, ok := columns["email"]; !ok {
+        return nil, fmt.Errorf("%w: email column is required", ErrInvalidImportFile)
+    }
+    if _, ok := columns["username"]; !ok {
+        return nil, fmt.Errorf("%w: username column is required", ErrInvalidImportFile)
+    }
+
+    field := func(record []string, name string) string {
+        if i, ok := columns[name]; ok && i < len(record) {
+            return strings.TrimSpace(record[i])
+        }
+        return ""
+    }
+
+    var rows []*ImportRow
+    for {
+        record, err := cr.Read()
+        if err == io.EOF {
+            break
+        }
+        if err != nil {
+            return nil, fmt.Errorf("%w: %v", ErrInvalidImportFile, err)
+        }
+        if len(rows) == MaxImportRows {
+            return nil, ErrTooManyImportRows
+        }
+
+        rows = append(rows, &ImportRow{
+            Row:         len(rows) + 1,
+            Email:       field(record, "email"),
+            Username:    field(record, "username"),
+            DisplayName: field(record, "display_name"),
+            Phone:       field(record, "phone"),
+        })
+    }
+
+    if len(rows) == 0 {
+        return nil, fmt.Errorf("%w: no rows", ErrInvalidImportFile)
+    }
+    return rows, nil
+}
+
+func validateImportRow(row *ImportRow) error {
+    if row.Email == "" {
+        return errors.New("email is required")
+    }
+    if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email {
+        return errors.New("invalid email")
+    }
+    if !usernameRegex.MatchString(row.Username) {
+        return errors.New("username must be 3-30 letters, digits, dots or underscores")
+    }
+    if len(row.DisplayName) > 100 {
+        return errors.New("display name is too long")
+    }
+    if len(row.Phone) > 20 {
+        return errors.New("phone is too long")
+    }
+    return nil
+}
+
+// maskEmail keeps the first character and the domain: j***@example.com
+func maskEmail(email string) string {
+    at := strings.LastIndex(email, "@")
+    if at <= 0 {
+        return maskName(email)
+    }
+    return email[:1] + "***" + email[at:]
+}
+
+// maskPhone keeps the last four digits
+func maskPhone(phone string) string {
+    if len(phone) <= 4 {
+        return strings.Repeat("*", len(phone))
+    }
+    return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
+
+// maskName keeps the first character
+func maskName(name string) string {
+    r := []rune(name)
+    if len(r) == 0 {
+        return ""
+    }
+    return string(r[0]) + "***"
+}
@@ -0,0 +1,100 @@
+// internal/admin/storage.go
+
+package admin
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "os"
+    "path/filepath"
+    "time"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/s3"
+)
+
+// exportLinkTTL is how long a presigned export download link stays valid
+const exportLinkTTL = 24 * time.Hour
+
+// ExportStore persists finished export files. Exports contain PII, so they
+// are never written anywhere publicly served.
+type ExportStore interface {
+    Save(ctx context.Context, name string, data []byte) (string, error)
+}
+
+// S3ExportStore keeps exports as private S3 objects and hands out presigned links
+type S3ExportStore struct {
+    s3Client *s3.S3
+    bucket   string
+    prefix   string
+}
+
+// NewS3ExportStore creates an S3 export store
+func NewS3ExportStore(bucket, region string) (ExportStore, error) {
+    sess, err := session.NewSession(&aws.Config{
+        Region: aws.String(region),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to create AWS session: %w", err)
+    }
+
+    return &S3ExportStore{
+        s3Client: s3.New(sess),
+        bucket:   bucket,
+        prefix:   "exports/users",
+    }, nil
+}
+
+// Save uploads the export and returns a presigned download URL
+func (s *S3ExportStore) Save(ctx context.Context, name string, data []byte) (string, error) {
+    key := fmt.Sprintf("%s/%s", s.prefix, name)
+
+    _, err := s.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+        Bucket:               aws.String(s.bucket),
+        Key:                  aws.String(key),
+        Body:                 bytes.NewReader(data),
+        ContentType:          aws.String("text/csv"),
+        ACL:                  aws.String("private"),
+        ServerSideEncryption: aws.String("AES256"),
+    })
+    if err != nil {
+        return "", fmt.Errorf("failed to upload export to S3: %w", err)
+    }
+
+    req, _ := s.s3Client.GetObjectRequest(&s3.GetObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key:    aws.String(key),
+    })
+    url, err := req.Presign(exportLinkTTL)
+    if err != nil {
+        return "", fmt.Errorf("failed to presign export URL: %w", err)
+    }
+
+    return url, nil
+}
+
+// LocalExportStore writes exports to a private directory on disk
+type LocalExportStore struct {
+    dir string
+}
+
+// NewLocalExportStore creates a local export store. dir must not be served over HTTP.
+func NewLocalExportStore(dir string) ExportStore {
+    return &LocalExportStore{dir: dir}
+}
+
+// Save writes the export and returns its file path
+func (s *LocalExportStore) Save(ctx context.Context, name string, data []byte) (string, error) {
+    if err := os.MkdirAll(s.dir, 0700); err != nil {
+        return "", fmt.Errorf("failed to create export directory: %w", err)
+    }
+
+    path := filepath.Join(s.dir, name)
+    if err := os.WriteFile(path, data, 0600); err != nil {
+        return "", fmt.Errorf("failed to write export: %w", err)
+    }
+
+    return path, nil
+}
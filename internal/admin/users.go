@@ -0,0 +1,285 @@
+// internal/admin/users.go
+// User management for support staff: search, account detail, verification,
+// suspension, password resets and support sessions. Every action is
+// recorded in the audit log.
+
+package admin
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+    "github.com/imadgeboyega/kiekky-backend/internal/erasure"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+// maxDetailReports caps the reports shown on a user's detail
+const maxDetailReports = 50
+
+var (
+    ErrForbidden      = errors.New("only admins can do this to staff accounts")
+    ErrSelfAction     = errors.New("you can't do this to your own account")
+    ErrNoEmail        = errors.New("user has no email address to send a reset code to")
+    ErrNotSuspended   = errors.New("user is not suspended")
+    ErrAlreadyBlocked = errors.New("user is already suspended or banned")
+)
+
+// Accounts is the part of auth user management needs
+type Accounts interface {
+    LogoutAllDevices(ctx context.Context, userID int64) error
+    InitiatePasswordReset(ctx context.Context, email string) error
+    Impersonate(ctx context.Context, adminID, userID int64, ttl time.Duration) (*auth.AuthResponse, error)
+}
+
+// SearchUsers finds users for staff
+func (s *service) SearchUsers(ctx context.Context, actorID int64, search *UserSearch) ([]*UserSummary, error) {
+    found, err := s.repo.SearchUsers(ctx, search)
+    if err != nil {
+        return nil, fmt.Errorf("failed to search users: %w", err)
+    }
+
+    s.audit(ctx, actorID, ActionUserSearch, nil, map[string]interface{}{
+        "query":   search.Query,
+        "status":  search.Status,
+        "role":    search.Role,
+        "results": len(found),
+    })
+    return found, nil
+}
+
+// GetUserDetail returns a user with their sessions, reports against them and
+// content counts
+func (s *service) GetUserDetail(ctx context.Context, actorID, userID int64) (*UserDetail, error) {
+    user, err := s.repo.GetUser(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    sessions, err := s.repo.GetActiveSessions(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get sessions: %w", err)
+    }
+    reports, err := s.repo.GetReports(ctx, userID, maxDetailReports)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get reports: %w", err)
+    }
+    counts, err := s.repo.GetContentCounts(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get content counts: %w", err)
+    }
+
+    s.audit(ctx, actorID, ActionUserView, &userID, nil)
+    return &UserDetail{
+        User:     user,
+        Sessions: sessions,
+        Reports:  reports,
+        Counts:   counts,
+    }, nil
+}
+
+// VerifyUser marks the user verified without an OTP
+func (s *service) VerifyUser(ctx context.Context, actorID, userID int64) error {
+    if _, err := s.target(ctx, actorID, userID); err != nil {
+        return err
+    }
+    if err := s.repo.SetVerified(ctx, userID); err != nil {
+        return err
+    }
+
+    s.audit(ctx, actorID, ActionUserVerify, &userID, nil)
+    return nil
+}
+
+// SuspendUser suspends the account and signs it out everywhere. Suspended
+// users can't sign in and are shown as ghosts.
+func (s *service) SuspendUser(ctx context.Context, actorID, userID int64, reason string) error {
+    user, err := s.target(ctx, actorID, userID)
+    if err != nil {
+        return err
+    }
+    if user.AccountStatus == users.StatusSuspended || user.AccountStatus == users.StatusBanned {
+        return ErrAlreadyBlocked
+    }
+
+    if err := s.repo.SetAccountStatus(ctx, userID, users.StatusSuspended, reason); err != nil {
+        return err
+    }
+    if err := s.accounts.LogoutAllDevices(ctx, userID); err != nil {
+        log.Printf("Failed to sign out suspended user %d: %v", userID, err)
+    }
+
+    s.audit(ctx, actorID, ActionUserSuspend, &userID, map[string]interface{}{"reason": reason})
+    return nil
+}
+
+// UnsuspendUser restores a suspended account
+func (s *service) UnsuspendUser(ctx context.Context, actorID, userID int64) error {
+    user, err := s.target(ctx, actorID, userID)
+    if err != nil {
+        return err
+    }
+    if user.AccountStatus != users.StatusSuspended {
+        return ErrNotSuspended
+    }
+
+    if err := s.repo.SetAccountStatus(ctx, userID, users.StatusActive, ""); err != nil {
+        return err
+    }
+
+    s.audit(ctx, actorID, ActionUserUnsuspend, &userID, nil)
+    return nil
+}
+
+// ResetPassword signs the user out everywhere and emails them a password
+// reset code. Staff never see or set the password.
+func (s *service) ResetPassword(ctx context.Context, actorID, userID int64) error {
+    user, err := s.target(ctx, actorID, userID)
+    if err != nil {
+        return err
+    }
+    if user.Email == nil || *user.Email == "" {
+        return ErrNoEmail
+    }
+
+    if err := s.accounts.LogoutAllDevices(ctx, userID); err != nil {
+        return fmt.Errorf("failed to sign out user: %w", err)
+    }
+    if err := s.accounts.InitiatePasswordReset(ctx, *user.Email); err != nil {
+        return fmt.Errorf("failed to send reset code: %w", err)
+    }
+
+    s.audit(ctx, actorID, ActionUserPasswordReset, &userID, nil)
+    return nil
+}
+
+// Impersonate opens a support session as the user. It is audited before
+// the token is issued, so there is never an unrecorded session.
+func (s *service) Impersonate(ctx context.Context, actorID, userID int64, req *ImpersonateRequest) (*auth.AuthResponse, error) {
+    if _, err := s.target(ctx, actorID, userID); err != nil {
+        return nil, err
+    }
+
+    ttl := auth.MaxImpersonationTTL
+    if req.Minutes > 0 {
+        ttl = time.Duration(req.Minutes) * time.Minute
+    }
+
+    err := s.recordAudit(ctx, actorID, ActionUserImpersonate, &userID, map[string]interface{}{
+        "reason":  req.Reason,
+        "minutes": int(ttl.Minutes()),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to record support session: %w", err)
+    }
+
+    return s.accounts.Impersonate(ctx, actorID, userID, ttl)
+}
+
+// SetRole changes a user's role. Routes restrict this to admins.
+func (s *service) SetRole(ctx context.Context, actorID, userID int64, role string) error {
+    if actorID == userID {
+        return ErrSelfAction
+    }
+    user, err := s.repo.GetUser(ctx, userID)
+    if err != nil {
+        return err
+    }
+    if err := s.repo.SetRole(ctx, userID, role); err != nil {
+        return err
+    }
+
+    s.audit(ctx, actorID, ActionUserRoleChange, &userID, map[string]interface{}{"from": user.Role, "to": role})
+    return nil
+}
+
+// EraseUser erases an account and signs it out everywhere. The account's
+// data is purged in the background; GetErasure reports the progress.
+// Routes restrict this to admins.
+func (s *service) EraseUser(ctx context.Context, actorID, userID int64) (*events.ErasureManifest, error) {
+    if _, err := s.target(ctx, actorID, userID); err != nil {
+        return nil, err
+    }
+
+    manifest, err := s.eraser.Erase(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.accounts.LogoutAllDevices(ctx, userID); err != nil {
+        log.Printf("Failed to sign out erased user %d: %v", userID, err)
+    }
+
+    s.audit(ctx, actorID, ActionUserErase, &userID, map[string]interface{}{
+        "posts":   len(manifest.PostIDs),
+        "stories": len(manifest.StoryIDs),
+    })
+    return manifest, nil
+}
+
+// GetErasure returns each subsystem's progress purging an erased account.
+// It is empty for accounts that haven't been erased.
+func (s *service) GetErasure(ctx context.Context, actorID, userID int64) ([]*erasure.Purge, error) {
+    return s.eraser.GetPurges(ctx, userID)
+}
+
+// ListAudit returns audit log entries, newest first
+func (s *service) ListAudit(ctx context.Context, filter *AuditFilter) ([]*AuditEntry, error) {
+    return s.repo.ListAudit(ctx, filter)
+}
+
+// target loads the user an action is aimed at. Staff can't act on their own
+// account, and only admins can act on other staff.
+func (s *service) target(ctx context.Context, actorID, userID int64) (*UserSummary, error) {
+    if actorID == userID {
+        return nil, ErrSelfAction
+    }
+
+    user, err := s.repo.GetUser(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if !auth.IsStaff(user.Role) {
+        return user, nil
+    }
+
+    actor, err := s.repo.GetUser(ctx, actorID)
+    if err != nil {
+        return nil, err
+    }
+    if actor.Role != auth.RoleAdmin {
+        return nil, ErrForbidden
+    }
+    return user, nil
+}
+
+// audit records a staff action, logging rather than failing when it can't
+func (s *service) audit(ctx context.Context, actorID int64, action string, targetUserID *int64, details map[string]interface{}) {
+    if err := s.recordAudit(ctx, actorID, action, targetUserID, details); err != nil {
+        log.Printf("Failed to record %s by staff %d in audit log: %v", action, actorID, err)
+    }
+}
+
+func (s *service) recordAudit(ctx context.Context, actorID int64, action string, targetUserID *int64, details map[string]interface{}) error {
+    entry := &AuditEntry{
+        ActorID:      actorID,
+        Action:       action,
+        TargetUserID: targetUserID,
+    }
+    if len(details) > 0 {
+        encoded, err := json.Marshal(details)
+        if err != nil {
+            return err
+        }
+        entry.Details = encoded
+    }
+    if ip := middleware.ClientInfoFromContext(ctx).IP; ip != "" {
+        entry.IPAddress = &ip
+    }
+    return s.repo.RecordAudit(ctx, entry)
+}
@@ -0,0 +1,43 @@
+// internal/analytics/events.go
+// Server-side events recorded from other modules' domain events
+
+package analytics
+
+import (
+    "context"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+// SubscribeEvents subscribes analytics to the events it records
+func (s *service) SubscribeEvents(bus *events.Bus) {
+    events.Subscribe(bus, "analytics", s.onPostReposted)
+    events.Subscribe(bus, "analytics", s.onMatchCreated)
+    events.Subscribe(bus, "analytics", s.onMessageSent)
+}
+
+func (s *service) onPostReposted(ctx context.Context, e events.PostReposted) error {
+    s.Track(ctx, e.ReposterID, EventRepost, map[string]interface{}{
+        "post_id":   e.PostID,
+        "repost_id": e.RepostID,
+    })
+    return nil
+}
+
+// onMatchCreated records the match for both users, for the match rate
+func (s *service) onMatchCreated(ctx context.Context, e events.MatchCreated) error {
+    properties := map[string]interface{}{"match_id": e.MatchID, "match_type": e.MatchType}
+    s.Track(ctx, e.User1ID, EventMatch, properties)
+    s.Track(ctx, e.User2ID, EventMatch, properties)
+    return nil
+}
+
+// onMessageSent records the sender's first message; later ones are ignored
+// by Track
+func (s *service) onMessageSent(ctx context.Context, e events.MessageSent) error {
+    s.Track(ctx, e.SenderID, EventFirstMessage, map[string]interface{}{
+        "conversation_id": e.ConversationID,
+        "message_type":    e.MessageType,
+    })
+    return nil
+}
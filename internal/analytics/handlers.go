@@ -0,0 +1,166 @@
+// internal/analytics/handlers.go
+
+package analytics
+
+import (
+    "errors"
+    "net/http"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// defaultRangeDays is how far back the admin charts go without ?from=
+const defaultRangeDays = 30
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// IngestEvents handles POST /events with a batch of client events
+func (h *Handler) IngestEvents(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    var req IngestRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    result, err := h.service.Ingest(r.Context(), userID, &req)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to record events")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusAccepted, result)
+}
+
+// GetDAU handles GET /admin/analytics/dau?from=&to=
+func (h *Handler) GetDAU(w http.ResponseWriter, r *http.Request) {
+    from, to, ok := parseRange(w, r)
+    if !ok {
+        return
+    }
+
+    points, err := h.service.GetDAU(r.Context(), from, to)
+    if err != nil {
+        respondWithServiceError(w, err, "Failed to get daily active users")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"from": from, "to": to, "points": points})
+}
+
+// GetRetention handles GET /admin/analytics/retention?from=&to=, where the
+// range selects signup cohorts
+func (h *Handler) GetRetention(w http.ResponseWriter, r *http.Request) {
+    from, to, ok := parseRange(w, r)
+    if !ok {
+        return
+    }
+
+    cohorts, err := h.service.GetRetention(r.Context(), from, to)
+    if err != nil {
+        respondWithServiceError(w, err, "Failed to get retention")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"from": from, "to": to, "cohorts": cohorts})
+}
+
+// GetMatchRate handles GET /admin/analytics/match-rate?from=&to=
+func (h *Handler) GetMatchRate(w http.ResponseWriter, r *http.Request) {
+    from, to, ok := parseRange(w, r)
+    if !ok {
+        return
+    }
+
+    points, err := h.service.GetMatchRate(r.Context(), from, to)
+    if err != nil {
+        respondWithServiceError(w, err, "Failed to get match rate")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"from": from, "to": to, "points": points})
+}
+
+// GetFunnel handles GET /admin/analytics/funnel?from=&to=, where the range
+// selects signup days
+func (h *Handler) GetFunnel(w http.ResponseWriter, r *http.Request) {
+    from, to, ok := parseRange(w, r)
+    if !ok {
+        return
+    }
+
+    steps, err := h.service.GetFunnel(r.Context(), from, to)
+    if err != nil {
+        respondWithServiceError(w, err, "Failed to get funnel")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{"from": from, "to": to, "steps": steps})
+}
+
+// Aggregate handles POST /admin/analytics/aggregate?day=YYYY-MM-DD, which
+// recomputes one day's aggregates
+func (h *Handler) Aggregate(w http.ResponseWriter, r *http.Request) {
+    day, err := time.Parse("2006-01-02", r.URL.Query().Get("day"))
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "day must be a date in YYYY-MM-DD format")
+        return
+    }
+    if !day.Before(time.Now().UTC().Truncate(24 * time.Hour)) {
+        utils.RespondWithError(w, http.StatusBadRequest, "Only past days can be aggregated")
+        return
+    }
+
+    if err := h.service.AggregateDay(r.Context(), day); err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to aggregate day")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Day aggregated"})
+}
+
+// parseRange reads ?from= and ?to= as YYYY-MM-DD dates. They default to the
+// last 30 days up to yesterday.
+func parseRange(w http.ResponseWriter, r *http.Request) (time.Time, time.Time, bool) {
+    to := time.Now().UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+    if v := r.URL.Query().Get("to"); v != "" {
+        t, err := time.Parse("2006-01-02", v)
+        if err != nil {
+            utils.RespondWithError(w, http.StatusBadRequest, "to must be a date in YYYY-MM-DD format")
+            return time.Time{}, time.Time{}, false
+        }
+        to = t
+    }
+
+    from := to.AddDate(0, 0, -(defaultRangeDays - 1))
+    if v := r.URL.Query().Get("from"); v != "" {
+        t, err := time.Parse("2006-01-02", v)
+        if err != nil {
+            utils.RespondWithError(w, http.StatusBadRequest, "from must be a date in YYYY-MM-DD format")
+            return time.Time{}, time.Time{}, false
+        }
+        from = t
+    }
+
+    return from, to, true
+}
+
+func respondWithServiceError(w http.ResponseWriter, err error, message string) {
+    if errors.Is(err, ErrInvalidRange) {
+        utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        return
+    }
+    utils.RespondWithError(w, http.StatusInternalServerError, message)
+}
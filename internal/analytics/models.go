@@ -0,0 +1,125 @@
+// internal/analytics/models.go
+
+package analytics
+
+import (
+    "encoding/json"
+    "time"
+)
+
+// Funnel events, emitted by the server. Each is recorded once per user, the
+// first time it happens, so counting them counts users.
+const (
+    EventSignup             = "signup"
+    EventVerified           = "verified"
+    EventProfileComplete    = "profile_complete"
+    EventPhotoAdded         = "photo_added"
+    EventPreferencesSet     = "preferences_set"
+    EventOnboardingComplete = "onboarding_complete"
+    EventFirstMessage       = "first_message"
+    EventMatch              = "match"
+)
+
+// Client events the app sends through POST /events. Clients may send other
+// names too; these are the ones the daily aggregates read.
+const (
+    EventScreenView = "screen_view"
+    EventSwipe      = "swipe" // properties: direction (left, right, super)
+)
+
+// Engagement events emitted by the server, recorded every time
+const (
+    EventRepost = "repost"
+)
+
+// Event sources
+const (
+    SourceClient = "client"
+    SourceServer = "server"
+)
+
+// Daily metric names in analytics_daily_metrics
+const (
+    MetricDAU              = "dau"
+    MetricSignups          = "signups"
+    MetricVerified         = "verified"
+    MetricProfilesComplete = "profiles_complete"
+    MetricFirstMessages    = "first_messages"
+    MetricMatches          = "matches"
+    MetricSwipes           = "swipes"
+    MetricRightSwipes      = "right_swipes"
+    MetricScreenViews      = "screen_views"
+)
+
+// RetentionDays are the day offsets retention is computed for
+var RetentionDays = []int{1, 7, 30}
+
+// Event is one stored analytics event
+type Event struct {
+    ID         int64           `json:"id" db:"id"`
+    UserID     *int64          `json:"user_id,omitempty" db:"user_id"`
+    Name       string          `json:"name" db:"name"`
+    Source     string          `json:"source" db:"source"`
+    Properties json.RawMessage `json:"properties,omitempty" db:"properties"`
+    Platform   *string         `json:"platform,omitempty" db:"platform"`
+    SessionID  *string         `json:"session_id,omitempty" db:"session_id"`
+    OccurredAt time.Time       `json:"occurred_at" db:"occurred_at"`
+    ReceivedAt time.Time       `json:"received_at" db:"received_at"`
+}
+
+// ClientEvent is one event in an ingestion batch
+type ClientEvent struct {
+    Name       string                 `json:"name" validate:"required,max=64"`
+    Properties map[string]interface{} `json:"properties,omitempty"`
+    SessionID  string                 `json:"session_id,omitempty" validate:"omitempty,max=64"`
+    OccurredAt *time.Time             `json:"occurred_at,omitempty"`
+}
+
+// IngestRequest is a batch of client events
+type IngestRequest struct {
+    Platform string         `json:"platform,omitempty" validate:"omitempty,oneof=ios android web"`
+    Events   []*ClientEvent `json:"events" validate:"required,min=1,max=50,dive"`
+}
+
+// IngestResult reports how much of a batch was stored
+type IngestResult struct {
+    Accepted int `json:"accepted"`
+    Rejected int `json:"rejected"`
+}
+
+// DailyMetric is one metric's value for a day
+type DailyMetric struct {
+    Day    time.Time `json:"day" db:"day"`
+    Metric string    `json:"metric" db:"metric"`
+    Value  float64   `json:"value" db:"value"`
+}
+
+// Point is one day of a chart series
+type Point struct {
+    Day   time.Time `json:"day"`
+    Value float64   `json:"value"`
+}
+
+// MatchRatePoint is one day of the match rate chart: matches per right swipe
+type MatchRatePoint struct {
+    Day         time.Time `json:"day"`
+    RightSwipes int64     `json:"right_swipes"`
+    Matches     int64     `json:"matches"`
+    Rate        float64   `json:"rate"`
+}
+
+// Cohort is the retention of users who signed up on one day. Retained maps
+// a day offset ("d1", "d7", "d30") to the share of the cohort active on it;
+// offsets that haven't happened yet are left out.
+type Cohort struct {
+    Day      time.Time          `json:"day" db:"cohort_day"`
+    Size     int64              `json:"size" db:"cohort_size"`
+    Retained map[string]float64 `json:"retained"`
+}
+
+// FunnelStep is one step of the signup funnel over a date range
+type FunnelStep struct {
+    Event string  `json:"event"`
+    Users int64   `json:"users"`
+    Rate  float64 `json:"rate"` // Share of signups that reached the step
+}
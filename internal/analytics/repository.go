@@ -0,0 +1,289 @@
+// internal/analytics/repository.go
+
+package analytics
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+)
+
+type Repository interface {
+    InsertEvents(ctx context.Context, events []*Event) error
+
+    // RecordMilestone records that userID reached a funnel event, and
+    // reports whether this was the first time
+    RecordMilestone(ctx context.Context, userID int64, event string, at time.Time) (bool, error)
+
+    // LastAggregatedDay returns the latest day with daily metrics, or the
+    // zero time if nothing has been aggregated
+    LastAggregatedDay(ctx context.Context) (time.Time, error)
+    AggregateDay(ctx context.Context, day time.Time) error
+
+    GetMetrics(ctx context.Context, metrics []string, from, to time.Time) ([]*DailyMetric, error)
+    GetRetention(ctx context.Context, from, to time.Time) ([]*Cohort, error)
+    CountFunnel(ctx context.Context, from, to time.Time) (map[string]int64, error)
+}
+
+type postgresRepository struct {
+    db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) Repository {
+    return &postgresRepository{db: db}
+}
+
+func (r *postgresRepository) InsertEvents(ctx context.Context, events []*Event) error {
+    if len(events) == 0 {
+        return nil
+    }
+
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    stmt, err := tx.PreparexContext(ctx, `
+        INSERT INTO analytics_events (user_id, name, source, properties, platform, session_id, occurred_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)`)
+    if err != nil {
+        return err
+    }
+    defer stmt.Close()
+
+    for _, e := range events {
+        var properties interface{}
+        if len(e.Properties) > 0 {
+            properties = string(e.Properties)
+        }
+        _, err := stmt.ExecContext(ctx, e.UserID, e.Name, e.Source, properties, e.Platform, e.SessionID, e.OccurredAt)
+        if err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+func (r *postgresRepository) RecordMilestone(ctx context.Context, userID int64, event string, at time.Time) (bool, error) {
+    query := `
+        INSERT INTO analytics_milestones (user_id, event, reached_at)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id, event) DO NOTHING`
+
+    result, err := r.db.ExecContext(ctx, query, userID, event, at)
+    if err != nil {
+        return false, err
+    }
+    n, err := result.RowsAffected()
+    return n > 0, err
+}
+
+func (r *postgresRepository) LastAggregatedDay(ctx context.Context) (time.Time, error) {
+    var day sql.NullTime
+    err := r.db.GetContext(ctx, &day, `SELECT MAX(day) FROM analytics_daily_metrics`)
+    if err != nil || !day.Valid {
+        return time.Time{}, err
+    }
+    return day.Time, nil
+}
+
+// AggregateDay computes the daily metrics for day, and the retention of
+// every cohort that reaches a retention offset on it. Days are UTC, and
+// rerunning a day overwrites its figures.
+func (r *postgresRepository) AggregateDay(ctx context.Context, day time.Time) error {
+    start := day.UTC().Truncate(24 * time.Hour)
+    end := start.Add(24 * time.Hour)
+
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    // Activity and client events. Both users of a match get a match event,
+    // so matches are counted by match ID.
+    var counts struct {
+        DAU         int64 `db:"dau"`
+        Swipes      int64 `db:"swipes"`
+        RightSwipes int64 `db:"right_swipes"`
+        ScreenViews int64 `db:"screen_views"`
+        Matches     int64 `db:"matches"`
+    }
+    err = tx.GetContext(ctx, &counts, `
+        SELECT
+            COUNT(DISTINCT user_id) AS dau,
+            COUNT(*) FILTER (WHERE name = $3) AS swipes,
+            COUNT(*) FILTER (WHERE name = $3 AND properties->>'direction' IN ('right', 'super')) AS right_swipes,
+            COUNT(*) FILTER (WHERE name = $4) AS screen_views,
+            COUNT(DISTINCT properties->>'match_id') FILTER (WHERE name = $5) AS matches
+        FROM analytics_events
+        WHERE occurred_at >= $1 AND occurred_at < $2`,
+        start, end, EventSwipe, EventScreenView, EventMatch)
+    if err != nil {
+        return err
+    }
+
+    values := map[string]float64{
+        MetricDAU:         float64(counts.DAU),
+        MetricSwipes:      float64(counts.Swipes),
+        MetricRightSwipes: float64(counts.RightSwipes),
+        MetricScreenViews: float64(counts.ScreenViews),
+        MetricMatches:     float64(counts.Matches),
+    }
+
+    // Funnel milestones reached that day
+    milestoneMetrics := map[string]string{
+        EventSignup:          MetricSignups,
+        EventVerified:        MetricVerified,
+        EventProfileComplete: MetricProfilesComplete,
+        EventFirstMessage:    MetricFirstMessages,
+    }
+    for _, metric := range milestoneMetrics {
+        values[metric] = 0
+    }
+
+    rows, err := tx.QueryContext(ctx, `
+        SELECT event, COUNT(*)
+        FROM analytics_milestones
+        WHERE reached_at >= $1 AND reached_at < $2
+        GROUP BY event`, start, end)
+    if err != nil {
+        return err
+    }
+    for rows.Next() {
+        var event string
+        var count int64
+        if err := rows.Scan(&event, &count); err != nil {
+            rows.Close()
+            return err
+        }
+        if metric, ok := milestoneMetrics[event]; ok {
+            values[metric] = float64(count)
+        }
+    }
+    rows.Close()
+    if err := rows.Err(); err != nil {
+        return err
+    }
+
+    for metric, value := range values {
+        _, err := tx.ExecContext(ctx, `
+            INSERT INTO analytics_daily_metrics (day, metric, value, computed_at)
+            VALUES ($1::date, $2, $3, NOW())
+            ON CONFLICT (day, metric) DO UPDATE
+            SET value = EXCLUDED.value, computed_at = EXCLUDED.computed_at`,
+            start, metric, value)
+        if err != nil {
+            return fmt.Errorf("failed to store %s: %w", metric, err)
+        }
+    }
+
+    // Retention: of the users who signed up offset days before day, how
+    // many were active on it
+    for _, offset := range RetentionDays {
+        cohortStart := start.AddDate(0, 0, -offset)
+        _, err := tx.ExecContext(ctx, `
+            INSERT INTO analytics_daily_retention (cohort_day, day_offset, cohort_size, retained, computed_at)
+            SELECT $1::date, $2, COUNT(*),
+                   COUNT(*) FILTER (WHERE EXISTS (
+                       SELECT 1 FROM analytics_events e
+                       WHERE e.user_id = u.id AND e.occurred_at >= $4 AND e.occurred_at < $5
+                   )),
+                   NOW()
+            FROM users u
+            WHERE u.created_at >= $1 AND u.created_at < $3
+            ON CONFLICT (cohort_day, day_offset) DO UPDATE
+            SET cohort_size = EXCLUDED.cohort_size, retained = EXCLUDED.retained, computed_at = EXCLUDED.computed_at`,
+            cohortStart, offset, cohortStart.Add(24*time.Hour), start, end)
+        if err != nil {
+            return fmt.Errorf("failed to store d%d retention: %w", offset, err)
+        }
+    }
+
+    return tx.Commit()
+}
+
+func (r *postgresRepository) GetMetrics(ctx context.Context, metrics []string, from, to time.Time) ([]*DailyMetric, error) {
+    query := `
+        SELECT day, metric, value
+        FROM analytics_daily_metrics
+        WHERE metric = ANY($1) AND day >= $2::date AND day <= $3::date
+        ORDER BY day`
+
+    items := []*DailyMetric{}
+    err := r.db.SelectContext(ctx, &items, query, pq.Array(metrics), from, to)
+    return items, err
+}
+
+func (r *postgresRepository) GetRetention(ctx context.Context, from, to time.Time) ([]*Cohort, error) {
+    query := `
+        SELECT cohort_day, day_offset, cohort_size, retained
+        FROM analytics_daily_retention
+        WHERE cohort_day >= $1::date AND cohort_day <= $2::date
+        ORDER BY cohort_day, day_offset`
+
+    rows, err := r.db.QueryContext(ctx, query, from, to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    cohorts := []*Cohort{}
+    var current *Cohort
+    for rows.Next() {
+        var day time.Time
+        var offset int
+        var size, retained int64
+        if err := rows.Scan(&day, &offset, &size, &retained); err != nil {
+            return nil, err
+        }
+
+        if current == nil || !current.Day.Equal(day) {
+            current = &Cohort{Day: day, Retained: map[string]float64{}}
+            cohorts = append(cohorts, current)
+        }
+        if size > current.Size {
+            current.Size = size
+        }
+        rate := 0.0
+        if size > 0 {
+            rate = float64(retained) / float64(size)
+        }
+        current.Retained[fmt.Sprintf("d%d", offset)] = rate
+    }
+    return cohorts, rows.Err()
+}
+
+// CountFunnel counts, for users who signed up between from and to, how many
+// have reached each funnel event since
+func (r *postgresRepository) CountFunnel(ctx context.Context, from, to time.Time) (map[string]int64, error) {
+    query := `
+        SELECT m.event, COUNT(*)
+        FROM analytics_milestones s
+        JOIN analytics_milestones m ON m.user_id = s.user_id
+        WHERE s.event = $1 AND s.reached_at >= $2 AND s.reached_at < $3
+        GROUP BY m.event`
+
+    rows, err := r.db.QueryContext(ctx, query, EventSignup, from, to)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    counts := map[string]int64{}
+    for rows.Next() {
+        var event string
+        var count int64
+        if err := rows.Scan(&event, &count); err != nil {
+            return nil, err
+        }
+        counts[event] = count
+    }
+    return counts, rows.Err()
+}
@@ -0,0 +1,23 @@
+package analytics
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/events").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("", handler.IngestEvents).Methods("POST")
+
+    admin := router.PathPrefix("/api/v1/admin/analytics").Subrouter()
+    admin.Use(authMiddleware.Authenticate)
+    admin.Use(authMiddleware.RequireStaff)
+
+    admin.HandleFunc("/dau", handler.GetDAU).Methods("GET")
+    admin.HandleFunc("/retention", handler.GetRetention).Methods("GET")
+    admin.HandleFunc("/match-rate", handler.GetMatchRate).Methods("GET")
+    admin.HandleFunc("/funnel", handler.GetFunnel).Methods("GET")
+    admin.HandleFunc("/aggregate", handler.Aggregate).Methods("POST")
+}
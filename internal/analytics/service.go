@@ -0,0 +1,328 @@
+// internal/analytics/service.go
+
+package analytics
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "regexp"
+    "sync"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+const (
+    // Client events older than this are dropped, and ones dated in the
+    // future are clamped to when they arrived
+    maxEventAge     = 7 * 24 * time.Hour
+    maxClockSkew    = 5 * time.Minute
+    maxBackfillDays = 31
+
+    trackTimeout = 5 * time.Second
+)
+
+var (
+    ErrInvalidRange = errors.New("from must be before to, and at most a year apart")
+
+    eventName = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+)
+
+// onceEvents are funnel events recorded only the first time per user. Match
+// is a funnel step too, but every match is recorded.
+var onceEvents = map[string]bool{
+    EventSignup:             true,
+    EventVerified:           true,
+    EventProfileComplete:    true,
+    EventPhotoAdded:         true,
+    EventPreferencesSet:     true,
+    EventOnboardingComplete: true,
+    EventFirstMessage:       true,
+}
+
+// serverEvents can't be sent by clients, so the funnel can't be spoofed
+var serverEvents = map[string]bool{
+    EventSignup:             true,
+    EventVerified:           true,
+    EventProfileComplete:    true,
+    EventPhotoAdded:         true,
+    EventPreferencesSet:     true,
+    EventOnboardingComplete: true,
+    EventFirstMessage:       true,
+    EventMatch:              true,
+}
+
+type Service interface {
+    // Ingest stores a batch of client events for userID. Invalid events are
+    // skipped and counted as rejected.
+    Ingest(ctx context.Context, userID int64, req *IngestRequest) (*IngestResult, error)
+
+    // Track records a server-side event. It never fails the caller: errors
+    // are logged and the write happens in the background.
+    Track(ctx context.Context, userID int64, event string, properties map[string]interface{})
+
+    // AggregatePending aggregates every day since the last aggregated one,
+    // up to yesterday, and returns how many days were aggregated
+    AggregatePending(ctx context.Context, now time.Time) (int, error)
+    AggregateDay(ctx context.Context, day time.Time) error
+
+    GetDAU(ctx context.Context, from, to time.Time) ([]*Point, error)
+    GetMatchRate(ctx context.Context, from, to time.Time) ([]*MatchRatePoint, error)
+    GetRetention(ctx context.Context, from, to time.Time) ([]*Cohort, error)
+    GetFunnel(ctx context.Context, from, to time.Time) ([]*FunnelStep, error)
+
+    // SubscribeEvents subscribes to the domain events recorded as server
+    // events
+    SubscribeEvents(bus *events.Bus)
+}
+
+type service struct {
+    repo Repository
+
+    // reached caches userID/event milestones already recorded, so events
+    // like first_message don't hit the database on every message
+    reached sync.Map
+}
+
+func NewService(repo Repository) Service {
+    return &service{repo: repo}
+}
+
+func (s *service) Ingest(ctx context.Context, userID int64, req *IngestRequest) (*IngestResult, error) {
+    now := time.Now().UTC()
+    result := &IngestResult{}
+
+    var platform *string
+    if req.Platform != "" {
+        platform = &req.Platform
+    }
+
+    events := make([]*Event, 0, len(req.Events))
+    for _, ce := range req.Events {
+        if !eventName.MatchString(ce.Name) || serverEvents[ce.Name] {
+            result.Rejected++
+            continue
+        }
+
+        occurredAt := now
+        if ce.OccurredAt != nil {
+            occurredAt = ce.OccurredAt.UTC()
+            if occurredAt.After(now.Add(maxClockSkew)) {
+                occurredAt = now
+            }
+            if now.Sub(occurredAt) > maxEventAge {
+                result.Rejected++
+                continue
+            }
+        }
+
+        properties, err := encodeProperties(ce.Properties)
+        if err != nil {
+            result.Rejected++
+            continue
+        }
+
+        event := &Event{
+            UserID:     &userID,
+            Name:       ce.Name,
+            Source:     SourceClient,
+            Properties: properties,
+            Platform:   platform,
+            OccurredAt: occurredAt,
+        }
+        if ce.SessionID != "" {
+            sessionID := ce.SessionID
+            event.SessionID = &sessionID
+        }
+        events = append(events, event)
+    }
+
+    if err := s.repo.InsertEvents(ctx, events); err != nil {
+        return nil, fmt.Errorf("failed to store events: %w", err)
+    }
+    result.Accepted = len(events)
+    return result, nil
+}
+
+func (s *service) Track(ctx context.Context, userID int64, event string, properties map[string]interface{}) {
+    go func() {
+        // The request that caused the event may finish before the write does
+        ctx, cancel := context.WithTimeout(context.Background(), trackTimeout)
+        defer cancel()
+
+        if err := s.track(ctx, userID, event, properties, time.Now().UTC()); err != nil {
+            log.Printf("Failed to track %s for user %d: %v", event, userID, err)
+        }
+    }()
+}
+
+func (s *service) track(ctx context.Context, userID int64, event string, properties map[string]interface{}, at time.Time) error {
+    if serverEvents[event] {
+        key := fmt.Sprintf("%d:%s", userID, event)
+        if onceEvents[event] {
+            if _, seen := s.reached.Load(key); seen {
+                return nil
+            }
+        }
+
+        first, err := s.repo.RecordMilestone(ctx, userID, event, at)
+        if err != nil {
+            return err
+        }
+        s.reached.Store(key, true)
+        if onceEvents[event] && !first {
+            return nil
+        }
+    }
+
+    encoded, err := encodeProperties(properties)
+    if err != nil {
+        return err
+    }
+
+    return s.repo.InsertEvents(ctx, []*Event{{
+        UserID:     &userID,
+        Name:       event,
+        Source:     SourceServer,
+        Properties: encoded,
+        OccurredAt: at,
+    }})
+}
+
+func (s *service) AggregatePending(ctx context.Context, now time.Time) (int, error) {
+    yesterday := now.UTC().Truncate(24*time.Hour).AddDate(0, 0, -1)
+
+    last, err := s.repo.LastAggregatedDay(ctx)
+    if err != nil {
+        return 0, fmt.Errorf("failed to get last aggregated day: %w", err)
+    }
+
+    day := yesterday.AddDate(0, 0, -(maxBackfillDays - 1))
+    if !last.IsZero() && last.UTC().AddDate(0, 0, 1).After(day) {
+        day = last.UTC().AddDate(0, 0, 1)
+    }
+
+    aggregated := 0
+    for ; !day.After(yesterday); day = day.AddDate(0, 0, 1) {
+        if err := s.repo.AggregateDay(ctx, day); err != nil {
+            return aggregated, fmt.Errorf("failed to aggregate %s: %w", day.Format("2006-01-02"), err)
+        }
+        aggregated++
+    }
+    return aggregated, nil
+}
+
+// AggregateDay recomputes one day, e.g. to backfill after a fix
+func (s *service) AggregateDay(ctx context.Context, day time.Time) error {
+    return s.repo.AggregateDay(ctx, day)
+}
+
+func (s *service) GetDAU(ctx context.Context, from, to time.Time) ([]*Point, error) {
+    if err := checkRange(from, to); err != nil {
+        return nil, err
+    }
+
+    metrics, err := s.repo.GetMetrics(ctx, []string{MetricDAU}, from, to)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get daily active users: %w", err)
+    }
+
+    points := make([]*Point, len(metrics))
+    for i, m := range metrics {
+        points[i] = &Point{Day: m.Day, Value: m.Value}
+    }
+    return points, nil
+}
+
+// GetMatchRate returns matches per right swipe for each day
+func (s *service) GetMatchRate(ctx context.Context, from, to time.Time) ([]*MatchRatePoint, error) {
+    if err := checkRange(from, to); err != nil {
+        return nil, err
+    }
+
+    metrics, err := s.repo.GetMetrics(ctx, []string{MetricRightSwipes, MetricMatches}, from, to)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get match rate: %w", err)
+    }
+
+    points := []*MatchRatePoint{}
+    byDay := map[time.Time]*MatchRatePoint{}
+    for _, m := range metrics {
+        point, ok := byDay[m.Day]
+        if !ok {
+            point = &MatchRatePoint{Day: m.Day}
+            byDay[m.Day] = point
+            points = append(points, point)
+        }
+        switch m.Metric {
+        case MetricRightSwipes:
+            point.RightSwipes = int64(m.Value)
+        case MetricMatches:
+            point.Matches = int64(m.Value)
+        }
+    }
+
+    for _, point := range points {
+        if point.RightSwipes > 0 {
+            point.Rate = float64(point.Matches) / float64(point.RightSwipes)
+        }
+    }
+    return points, nil
+}
+
+func (s *service) GetRetention(ctx context.Context, from, to time.Time) ([]*Cohort, error) {
+    if err := checkRange(from, to); err != nil {
+        return nil, err
+    }
+
+    cohorts, err := s.repo.GetRetention(ctx, from, to)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get retention: %w", err)
+    }
+    return cohorts, nil
+}
+
+// GetFunnel returns how far users who signed up between from and to have
+// got through the funnel
+func (s *service) GetFunnel(ctx context.Context, from, to time.Time) ([]*FunnelStep, error) {
+    if err := checkRange(from, to); err != nil {
+        return nil, err
+    }
+
+    counts, err := s.repo.CountFunnel(ctx, from, to.AddDate(0, 0, 1))
+    if err != nil {
+        return nil, fmt.Errorf("failed to get funnel: %w", err)
+    }
+
+    signups := counts[EventSignup]
+    steps := []string{
+        EventSignup, EventVerified, EventProfileComplete, EventPhotoAdded, EventPreferencesSet,
+        EventOnboardingComplete, EventFirstMessage, EventMatch,
+    }
+    funnel := make([]*FunnelStep, len(steps))
+    for i, event := range steps {
+        step := &FunnelStep{Event: event, Users: counts[event]}
+        if signups > 0 {
+            step.Rate = float64(step.Users) / float64(signups)
+        }
+        funnel[i] = step
+    }
+    return funnel, nil
+}
+
+func checkRange(from, to time.Time) error {
+    if to.Before(from) || to.Sub(from) > 366*24*time.Hour {
+        return ErrInvalidRange
+    }
+    return nil
+}
+
+func encodeProperties(properties map[string]interface{}) (json.RawMessage, error) {
+    if len(properties) == 0 {
+        return nil, nil
+    }
+    return json.Marshal(properties)
+}
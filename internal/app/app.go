@@ -0,0 +1,308 @@
+// internal/app/app.go
+// Application wiring: builds every module from the config, starts them in
+// dependency order and stops them in reverse
+//
+// main only loads the config and calls Run. Integration tests can build the
+// whole server in-process with New + Start and serve Handler() from httptest.
+
+package app
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "log"
+    "net/http"
+    "sync"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/gorilla/mux"
+    "google.golang.org/grpc"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/activity"
+    "github.com/imadgeboyega/kiekky-backend/internal/admin"
+    "github.com/imadgeboyega/kiekky-backend/internal/analytics"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/billing"
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/cache"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/counters"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/health"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/outbox"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/sms"
+    "github.com/imadgeboyega/kiekky-backend/internal/contacts"
+    "github.com/imadgeboyega/kiekky-backend/internal/erasure"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/jobs"
+    "github.com/imadgeboyega/kiekky-backend/internal/config"
+    "github.com/imadgeboyega/kiekky-backend/internal/dating"
+    "github.com/imadgeboyega/kiekky-backend/internal/gifts"
+    "github.com/imadgeboyega/kiekky-backend/internal/onboarding"
+    "github.com/imadgeboyega/kiekky-backend/internal/referrals"
+    "github.com/imadgeboyega/kiekky-backend/internal/interests"
+    "github.com/imadgeboyega/kiekky-backend/internal/messaging"
+    "github.com/imadgeboyega/kiekky-backend/internal/moderation"
+    notifications "github.com/imadgeboyega/kiekky-backend/internal/notification"
+    "github.com/imadgeboyega/kiekky-backend/internal/otp"
+    "github.com/imadgeboyega/kiekky-backend/internal/posts"
+    "github.com/imadgeboyega/kiekky-backend/internal/profile"
+    "github.com/imadgeboyega/kiekky-backend/internal/prompts"
+    "github.com/imadgeboyega/kiekky-backend/internal/stories"
+    "github.com/imadgeboyega/kiekky-backend/internal/suppression"
+    "github.com/imadgeboyega/kiekky-backend/internal/textfilter"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+    "github.com/imadgeboyega/kiekky-backend/internal/verification"
+)
+
+// shutdownTimeout bounds how long Run waits for requests and jobs to drain
+const shutdownTimeout = 30 * time.Second
+
+// Application is the whole API server. Fields are set by Start and are nil
+// before it; modules that are turned off in the config stay nil.
+type Application struct {
+    Config *config.Config
+
+    // Infrastructure
+    DB     *sql.DB
+    Redis  *redis.Client // nil without REDIS_URL or when Redis is unreachable
+    Health *health.Checker
+    Jobs   *jobs.Registry
+    Router *mux.Router
+
+    // Shared services used by several modules
+    OTP          otp.Service
+    Suppressions suppression.Service
+    Users        users.Client
+    Blocks       blocks.Service
+    Moderation   moderation.Service
+    TextFilter   textfilter.Filter // nil when ENABLE_TEXT_FILTER is off
+    ViewCounter  *counters.ViewCounter
+    Analytics    analytics.Service
+    Cache        *cache.Cache
+    UnitOfWork   *database.UnitOfWork
+    Outbox       *outbox.Outbox
+    Events       *events.Bus
+
+    // Feature modules
+    Auth          auth.Service
+    Profile       profile.Service
+    Posts         *posts.Service
+    Stories       stories.Service
+    Interests     interests.Service
+    Prompts       prompts.Service
+    Activity      activity.Service
+    Notifications notifications.Service
+    Admin         admin.Service
+    Erasure       erasure.Service
+    Verification  verification.Service
+    Billing       billing.Service
+    Gifts         gifts.Service
+    Referrals     referrals.Service
+    Onboarding    onboarding.Service
+    Contacts      contacts.Service
+    Messaging     *messaging.MessageService
+    Hub           *messaging.Hub
+    Dating        dating.Service
+
+    // Internals shared between module constructors
+    moderationProvider moderation.Provider // nil when uploads aren't moderated
+    authMiddleware     *auth.Middleware
+    emailService       notifications.EmailService
+    smsRouter          *sms.Router // nil when SMS is mocked
+
+    server     *http.Server
+    handler    *health.SwapHandler
+    routes     http.Handler // Router wrapped in the global middleware
+    grpcServer *grpc.Server // nil when GRPC_PORT is empty
+
+    // Background jobs run under ctx and are waited for on shutdown
+    ctx     context.Context
+    cancel  context.CancelFunc
+    workers sync.WaitGroup
+}
+
+// New creates an application for cfg. It does no I/O; call Start or Run.
+func New(cfg *config.Config) *Application {
+    ctx, cancel := context.WithCancel(context.Background())
+
+    a := &Application{
+        Config: cfg,
+        Health: health.NewChecker(2 * time.Second),
+        Jobs:   jobs.NewRegistry(),
+        ctx:    ctx,
+        cancel: cancel,
+    }
+
+    // Until Start finishes only the health probes are served, and readiness
+    // answers 503
+    a.Health.Starting("migrations")
+    a.Health.Starting("routes")
+
+    startupRouter := mux.NewRouter()
+    health.RegisterRoutes(startupRouter, a.Health)
+    a.handler = health.NewSwapHandler(startupRouter)
+
+    a.server = &http.Server{
+        Addr:         fmt.Sprintf(":%s", cfg.Server.Port),
+        Handler:      a.handler,
+        ReadTimeout:  15 * time.Second,
+        WriteTimeout: 15 * time.Second,
+        IdleTimeout:  60 * time.Second,
+    }
+
+    return a
+}
+
+// Handler serves the API. Before Start completes it only answers health probes.
+func (a *Application) Handler() http.Handler {
+    return a.handler
+}
+
+// Run serves HTTP, starts the application and blocks until ctx is cancelled,
+// then shuts down. Health probes are answered while startup is in progress.
+func (a *Application) Run(ctx context.Context) error {
+    serveErr := make(chan error, 2)
+    go func() {
+        log.Printf("🩺 Serving health probes on http://localhost%s while starting up", a.server.Addr)
+        if err := a.server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+            serveErr <- err
+        }
+    }()
+
+    if err := a.Start(ctx); err != nil {
+        a.shutdownWithTimeout()
+        return err
+    }
+    
+    if err := a.serveGRPC(serveErr); err != nil {
+        a.shutdownWithTimeout()
+        return err
+    }
+
+    log.Println("\n========================================")
+    log.Printf("🚀 Server ready on http://localhost%s", a.server.Addr)
+    log.Printf("🌍 Environment: %s", a.Config.Server.Environment)
+    log.Println("========================================")
+
+    select {
+    case <-ctx.Done():
+        log.Println("\n⚠️  Shutdown signal received...")
+    case err := <-serveErr:
+        a.shutdownWithTimeout()
+        return fmt.Errorf("server failed: %w", err)
+    }
+
+    return a.shutdownWithTimeout()
+}
+
+// Start connects to the infrastructure, runs migrations, builds every module
+// and starts background work. Modules are built in dependency order; a
+// module may only use the ones built before it.
+func (a *Application) Start(ctx context.Context) error {
+    steps := []struct {
+        name string
+        run  func(context.Context) error
+    }{
+        {"Connecting to PostgreSQL", a.connectPostgres},
+        {"Connecting to Redis", a.connectRedis},
+        {"Running database migrations", a.migrate},
+        {"Initializing shared services", a.initShared},
+        {"Initializing Profile system, onboarding and contacts", a.initProfile},
+        {"Initializing authentication system", a.initAuth},
+        {"Initializing Posts module", a.initPosts},
+        {"Initializing Stories module", a.initStories},
+        {"Initializing Notifications module", a.initNotifications},
+        {"Initializing admin, verification, billing, gifts and referrals", a.initAccounts},
+        {"Initializing Messaging module", a.initMessaging},
+        {"Initializing Dating module", a.initDating},
+        {"Initializing internal gRPC API", a.initGRPC},
+        {"Subscribing to module events", a.initEvents},
+        {"Setting up routes", a.initRoutes},
+    }
+
+    for i, step := range steps {
+        log.Printf("\n▶️  Step %d/%d: %s...", i+1, len(steps), step.name)
+        if err := step.run(ctx); err != nil {
+            return fmt.Errorf("%s: %w", step.name, err)
+        }
+    }
+
+    a.startBackground()
+
+    a.handler.Swap(a.routes)
+    a.Health.Done("routes")
+    return nil
+}
+
+// Shutdown stops the application in reverse start order: readiness fails
+// first and requests keep being served for SHUTDOWN_DRAIN_DELAY so load
+// balancers drain, then HTTP stops accepting and finishes in-flight
+// requests, then gRPC, websockets, background jobs and finally the
+// connections they were using.
+func (a *Application) Shutdown(ctx context.Context) error {
+    a.Health.Starting("shutdown")
+
+    if delay := a.Config.Server.ShutdownDrainDelay; delay > 0 {
+        log.Printf("   - Draining for %s...", delay)
+        select {
+        case <-time.After(delay):
+        case <-ctx.Done():
+        }
+    }
+
+    log.Println("   - Stopping HTTP server...")
+    serverErr := a.server.Shutdown(ctx)
+    
+    if a.grpcServer != nil {
+        log.Println("   - Stopping gRPC server...")
+        a.stopGRPC(ctx)
+    }
+
+    if a.Hub != nil {
+        log.Println("   - Shutting down messaging hub...")
+        a.Hub.Shutdown()
+    }
+
+    log.Println("   - Stopping background jobs...")
+    a.cancel()
+    done := make(chan struct{})
+    go func() {
+        a.workers.Wait()
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-ctx.Done():
+        log.Println("   ⚠️  Background jobs did not stop in time")
+    }
+
+    if a.Redis != nil {
+        a.Redis.Close()
+    }
+    if a.DB != nil {
+        a.DB.Close()
+    }
+
+    if serverErr != nil {
+        return fmt.Errorf("server forced to shutdown: %w", serverErr)
+    }
+    log.Println("✅ Server exited gracefully")
+    return nil
+}
+
+func (a *Application) shutdownWithTimeout() error {
+    ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+    defer cancel()
+    return a.Shutdown(ctx)
+}
+
+// goWorker runs fn in the background until shutdown
+func (a *Application) goWorker(fn func(ctx context.Context)) {
+    a.workers.Add(1)
+    go func() {
+        defer a.workers.Done()
+        fn(a.ctx)
+    }()
+}
@@ -0,0 +1,304 @@
+// internal/app/background.go
+// Background jobs and schedulers, started once every module is built
+
+package app
+
+import (
+    "context"
+    "log"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/counters"
+    notifications "github.com/imadgeboyega/kiekky-backend/internal/notification"
+    "github.com/imadgeboyega/kiekky-backend/internal/stories"
+)
+
+// startBackground starts the websocket hub and every periodic job. They all
+// stop when the application shuts down.
+func (a *Application) startBackground() {
+    cfg := a.Config
+
+    a.goWorker(func(ctx context.Context) { a.Hub.Run() })
+    log.Println("   ✅ WebSocket hub started")
+
+    a.goWorker(a.runOTPCleanup)
+    a.goWorker(counters.NewScheduler(a.ViewCounter, 1*time.Minute, 3).Start) // reconcile at 3 AM
+    a.goWorker(stories.NewCleanupService(a.Stories, cfg.Stories.CleanupInterval).Start)
+    a.goWorker(a.runStoryVideoProcessing)
+
+    // Message cleanup (expired messages) and large conversation exports
+    a.goWorker(a.runMessageCleanup)
+    a.goWorker(a.runConversationExports)
+    log.Println("   ✅ Message cleanup job started")
+
+    // Scheduled notifications, and cleanup of old ones
+    a.goWorker(notifications.NewNotificationScheduler(a.Notifications, 1*time.Minute).Start)
+    a.goWorker(notifications.NewNotificationCleanupJob(
+        a.Notifications,
+        24*time.Hour,    // Run daily
+        30*24*time.Hour, // Keep notifications for 30 days
+    ).Start)
+
+    if cfg.Notifications.EnableDigest {
+        a.goWorker(notifications.NewDigestScheduler(a.Notifications, "0 9 * * *").Start)
+        log.Println("   ✅ Notification digest scheduler started (9AM daily)")
+    }
+
+    // Retry transient notification delivery failures with backoff
+    a.goWorker(a.runDeliveryRetries)
+    log.Println("   ✅ Notification delivery retry job started")
+
+    // Fan out notification campaigns to their segments, rate limited
+    a.goWorker(a.runCampaigns)
+    log.Println("   ✅ Notification campaign job started")
+
+    // Daily hotpicks, and cleanup of expired ones
+    a.goWorker(a.runHotpickGeneration)
+    a.goWorker(a.runHotpickCleanup)
+    log.Println("   ✅ Hotpick generation job started")
+
+    // Daily analytics aggregates, computed once each UTC day has ended
+    a.goWorker(a.runAnalyticsAggregation)
+    log.Println("   ✅ Analytics aggregation job started")
+
+    // Publish scheduled posts once their time comes
+    a.goWorker(a.runScheduledPosts)
+    log.Println("   ✅ Scheduled post publisher started")
+
+    // Purge posts and comments that have been in the trash for 30 days
+    a.goWorker(a.runTrashPurge)
+    log.Println("   ✅ Post trash purge job started")
+
+    // Remove abandoned drafts and the media uploaded for them
+    a.goWorker(a.runDraftCleanup)
+    log.Println("   ✅ Draft cleanup job started")
+
+    // Deliver outbox events to their subscribers, and drop old delivered ones
+    a.goWorker(a.runOutboxDispatch)
+    a.goWorker(a.runOutboxPurge)
+    log.Println("   ✅ Outbox dispatcher started")
+
+    // Correct drift in the denormalized like, comment, follow and unread counts
+    a.goWorker(a.runCounterReconciliation)
+    log.Println("   ✅ Counter reconciliation job started")
+
+    // Weekly recap, sent Monday 9AM in each user's timezone
+    if cfg.Notifications.EnableWeeklyRecap {
+        a.goWorker(a.runWeeklyRecaps)
+        log.Println("   ✅ Weekly recap job started")
+    }
+}
+
+// runPeriodic runs fn under a job name every interval until ctx is cancelled
+func (a *Application) runPeriodic(ctx context.Context, name string, interval, timeout time.Duration, fn func(ctx context.Context) (map[string]int64, error)) {
+    a.Jobs.Register(name)
+
+    ticker := time.NewTicker(interval)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ticker.C:
+            runCtx, cancel := context.WithTimeout(ctx, timeout)
+            a.Jobs.Run(runCtx, name, fn)
+            cancel()
+        case <-ctx.Done():
+            return
+        }
+    }
+}
+
+// OTP cleanup job
+func (a *Application) runOTPCleanup(ctx context.Context) {
+    a.runPeriodic(ctx, "otp_cleanup", 1*time.Hour, 30*time.Second, func(ctx context.Context) (map[string]int64, error) {
+        purged, err := a.OTP.CleanupExpiredOTPs(ctx)
+        return map[string]int64{"rows_purged": purged}, err
+    })
+}
+
+// Weekly recap job. Runs hourly so each timezone is picked up at its own 9AM.
+func (a *Application) runWeeklyRecaps(ctx context.Context) {
+    a.runPeriodic(ctx, "weekly_recap", 1*time.Hour, 30*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        sent, err := a.Notifications.SendWeeklyRecaps(ctx, time.Now())
+        return map[string]int64{"recaps_sent": int64(sent)}, err
+    })
+}
+
+// Hotpick generation job. Runs hourly so each timezone gets its picks at its
+// own ready hour; users who already have today's are skipped.
+func (a *Application) runHotpickGeneration(ctx context.Context) {
+    a.runPeriodic(ctx, "hotpick_generation", 1*time.Hour, 30*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        generated, err := a.Dating.GenerateDailyHotpicks(ctx, time.Now())
+        return map[string]int64{"users_generated": int64(generated)}, err
+    })
+}
+
+// Expired hotpick cleanup job
+func (a *Application) runHotpickCleanup(ctx context.Context) {
+    a.runPeriodic(ctx, "hotpick_cleanup", 24*time.Hour, 10*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        return nil, a.Dating.CleanupExpiredHotpicks(ctx)
+    })
+}
+
+// Analytics aggregation job. Runs hourly, but only days that have ended and
+// aren't aggregated yet are computed, so in practice it works nightly.
+func (a *Application) runAnalyticsAggregation(ctx context.Context) {
+    a.runPeriodic(ctx, "analytics_aggregation", 1*time.Hour, 30*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        days, err := a.Analytics.AggregatePending(ctx, time.Now())
+        return map[string]int64{"days_aggregated": int64(days)}, err
+    })
+}
+
+// Scheduled post publisher. Posts go out within a minute of publish_at.
+func (a *Application) runScheduledPosts(ctx context.Context) {
+    a.runPeriodic(ctx, "scheduled_post_publish", 1*time.Minute, 5*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        published, err := a.Posts.PublishDuePosts(ctx)
+        return map[string]int64{"posts_published": int64(published)}, err
+    })
+}
+
+// Trash purge job. Deleted posts and comments are removed for good, along
+// with media files no other post uses.
+func (a *Application) runTrashPurge(ctx context.Context) {
+    a.runPeriodic(ctx, "post_trash_purge", 6*time.Hour, 30*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        result, err := a.Posts.PurgeTrash(ctx)
+        return map[string]int64{
+            "posts_purged":        result.Posts,
+            "comments_purged":     result.Comments,
+            "media_files_deleted": result.MediaFiles,
+        }, err
+    })
+}
+
+// Draft cleanup job. Drafts untouched for 30 days are deleted, then files
+// uploaded for drafts that nothing uses any more.
+func (a *Application) runDraftCleanup(ctx context.Context) {
+    a.runPeriodic(ctx, "post_draft_cleanup", 24*time.Hour, 30*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        drafts, files, err := a.Posts.CleanupDrafts(ctx)
+        return map[string]int64{
+            "drafts_deleted":      drafts,
+            "media_files_deleted": files,
+        }, err
+    })
+}
+
+// Counter reconciliation job. Stored counts are kept current on every write;
+// this recounts them from the source rows in case any drifted.
+func (a *Application) runCounterReconciliation(ctx context.Context) {
+    a.runPeriodic(ctx, "counter_reconciliation", 24*time.Hour, 30*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        stats := map[string]int64{}
+
+        posts, err := a.Posts.ReconcileCounts(ctx)
+        stats["posts_corrected"] = posts
+        if err != nil {
+            return stats, err
+        }
+
+        users, err := a.Profile.ReconcileFollowCounts(ctx)
+        stats["users_corrected"] = users
+        if err != nil {
+            return stats, err
+        }
+
+        participants, err := a.Messaging.ReconcileUnreadCounts(ctx)
+        stats["participants_corrected"] = participants
+        return stats, err
+    })
+}
+
+// Outbox dispatcher. Runs as soon as new events are committed, and every
+// few seconds for retries and for events another instance committed.
+func (a *Application) runOutboxDispatch(ctx context.Context) {
+    const name = "outbox_dispatch"
+    a.Jobs.Register(name)
+
+    ticker := time.NewTicker(5 * time.Second)
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ticker.C:
+        case <-a.Outbox.Wake():
+        case <-ctx.Done():
+            return
+        }
+
+        runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+        a.Jobs.Run(runCtx, name, func(ctx context.Context) (map[string]int64, error) {
+            result, err := a.Outbox.Dispatch(ctx)
+            return map[string]int64{
+                "events_published": result.Published,
+                "events_retried":   result.Retried,
+                "events_failed":    result.Failed,
+            }, err
+        })
+        cancel()
+    }
+}
+
+// Outbox purge job. Delivered events are kept a week for debugging.
+func (a *Application) runOutboxPurge(ctx context.Context) {
+    a.runPeriodic(ctx, "outbox_purge", 24*time.Hour, 10*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        purged, err := a.Outbox.Purge(ctx, 7*24*time.Hour)
+        return map[string]int64{"events_purged": purged}, err
+    })
+}
+
+func (a *Application) runDeliveryRetries(ctx context.Context) {
+    a.runPeriodic(ctx, "notification_delivery_retry", 30*time.Second, 5*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        retried, err := a.Notifications.RetryDeliveries(ctx)
+        return map[string]int64{"deliveries_retried": int64(retried)}, err
+    })
+}
+
+// Notification campaigns. Each due campaign gets up to its per-minute rate
+// of recipients a run.
+func (a *Application) runCampaigns(ctx context.Context) {
+    a.runPeriodic(ctx, "notification_campaigns", 1*time.Minute, 5*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        sent, err := a.Notifications.ProcessCampaigns(ctx, time.Now())
+        return map[string]int64{"campaign_recipients": int64(sent)}, err
+    })
+}
+
+// Story video processing. New videos are picked up within seconds; stories
+// stay hidden from viewers until theirs is done.
+func (a *Application) runStoryVideoProcessing(ctx context.Context) {
+    a.runPeriodic(ctx, "story_video_processing", 10*time.Second, 30*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        ready, err := a.Stories.ProcessPendingVideos(ctx)
+        return map[string]int64{"videos_ready": int64(ready)}, err
+    })
+}
+
+func (a *Application) runConversationExports(ctx context.Context) {
+    a.runPeriodic(ctx, "conversation_export", 30*time.Second, 30*time.Minute, func(ctx context.Context) (map[string]int64, error) {
+        exported, err := a.Messaging.ProcessExportJobs(ctx)
+        return map[string]int64{"exports_completed": int64(exported)}, err
+    })
+}
+
+// Message cleanup job
+func (a *Application) runMessageCleanup(ctx context.Context) {
+    ticker := time.NewTicker(24 * time.Hour) // Run daily
+    defer ticker.Stop()
+
+    for {
+        select {
+        case <-ticker.C:
+            runCtx, cancel := context.WithTimeout(ctx, 5*time.Minute)
+
+            // Clean up expired messages (if disappearing messages are enabled)
+            if err := a.Messaging.CleanupExpiredMessages(runCtx); err != nil {
+                log.Printf("Failed to cleanup expired messages: %v", err)
+            }
+
+            // Clean up old message receipts (optional, for performance)
+            if err := a.Messaging.CleanupOldReceipts(runCtx, 90*24*time.Hour); err != nil {
+                log.Printf("Failed to cleanup old receipts: %v", err)
+            }
+
+            cancel()
+        case <-ctx.Done():
+            return
+        }
+    }
+}
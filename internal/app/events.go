@@ -0,0 +1,20 @@
+// internal/app/events.go
+// Domain event subscriptions. Each module subscribes its own handlers, so
+// modules react to each other's events without importing each other.
+
+package app
+
+import "context"
+
+// initEvents subscribes the modules that react to domain events. It runs
+// once every module is built and before the outbox dispatcher starts.
+func (a *Application) initEvents(ctx context.Context) error {
+    a.Notifications.SubscribeEvents(a.Events)
+    a.Analytics.SubscribeEvents(a.Events)
+    a.Messaging.SubscribeEvents(a.Events)
+    a.Referrals.SubscribeEvents(a.Events)
+    a.Onboarding.SubscribeEvents(a.Events)
+    a.Contacts.SubscribeEvents(a.Events)
+    a.Erasure.SubscribeEvents(a.Events)
+    return nil
+}
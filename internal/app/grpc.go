@@ -0,0 +1,75 @@
+// internal/app/grpc.go
+// The internal gRPC API, served on GRPC_PORT next to the HTTP API
+
+package app
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "net"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/grpcapi"
+)
+
+// initGRPC builds the gRPC server; Run starts serving it. Nothing is built
+// when GRPC_PORT is empty.
+func (a *Application) initGRPC(ctx context.Context) error {
+    if a.Config.GRPC.Port == "" {
+        log.Println("⏭️  gRPC API disabled (GRPC_PORT not set)")
+        return nil
+    }
+
+    server, err := grpcapi.NewServer(a.Config.GRPC, grpcapi.Services{
+        Users:  a.Users,
+        Posts:  a.Posts,
+        Dating: a.Dating,
+    })
+    if err != nil {
+        return err
+    }
+    a.grpcServer = server
+
+    log.Println("✅ gRPC API initialized")
+    return nil
+}
+
+// serveGRPC listens on GRPC_PORT and serves until stopGRPC. Errors other
+// than the server being stopped are sent to errs.
+func (a *Application) serveGRPC(errs chan<- error) error {
+    if a.grpcServer == nil {
+        return nil
+    }
+
+    listener, err := net.Listen("tcp", fmt.Sprintf(":%s", a.Config.GRPC.Port))
+    if err != nil {
+        return fmt.Errorf("gRPC listen: %w", err)
+    }
+
+    go func() {
+        if err := a.grpcServer.Serve(listener); err != nil {
+            errs <- fmt.Errorf("gRPC: %w", err)
+        }
+    }()
+    log.Printf("🔌 gRPC API listening on :%s", a.Config.GRPC.Port)
+    return nil
+}
+
+// stopGRPC lets in-flight calls finish, or cuts them off when ctx ends first
+func (a *Application) stopGRPC(ctx context.Context) {
+    if a.grpcServer == nil {
+        return
+    }
+
+    done := make(chan struct{})
+    go func() {
+        a.grpcServer.GracefulStop()
+        close(done)
+    }()
+    select {
+    case <-done:
+    case <-ctx.Done():
+        log.Println("   ⚠️  gRPC calls did not finish in time")
+        a.grpcServer.Stop()
+    }
+}
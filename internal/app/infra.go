@@ -0,0 +1,68 @@
+// internal/app/infra.go
+// Database and cache connections
+
+package app
+
+import (
+    "context"
+    "fmt"
+    "log"
+
+    "github.com/go-redis/redis/v8"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/health"
+)
+
+func (a *Application) connectPostgres(ctx context.Context) error {
+    db, err := database.NewPostgresDBFromURL(a.Config.Database.URL)
+    if err != nil {
+        return fmt.Errorf("failed to connect to PostgreSQL: %w", err)
+    }
+    a.DB = db
+
+    if err := db.PingContext(ctx); err != nil {
+        return fmt.Errorf("failed to ping PostgreSQL: %w", err)
+    }
+    log.Println("✅ Connected to PostgreSQL successfully")
+
+    a.Health.Add("postgres", true, health.Postgres(db))
+    return nil
+}
+
+// connectRedis connects to Redis if configured. Redis is optional: every
+// module falls back to Postgres without it, so failures are only logged.
+func (a *Application) connectRedis(ctx context.Context) error {
+    if a.Config.Database.RedisURL == "" {
+        log.Println("⚠️  Redis URL not configured, skipping Redis connection")
+        return nil
+    }
+
+    opt, err := redis.ParseURL(a.Config.Database.RedisURL)
+    if err != nil {
+        log.Printf("⚠️  Warning: Invalid Redis URL (%v), continuing without Redis", err)
+    } else {
+        client := redis.NewClient(opt)
+        if err := client.Ping(ctx).Err(); err != nil {
+            log.Printf("⚠️  Redis ping failed: %v, continuing without Redis", err)
+            client.Close()
+        } else {
+            a.Redis = client
+            log.Println("✅ Connected to Redis successfully")
+        }
+    }
+
+    // Losing Redis only degrades readiness
+    a.Health.Add("redis", false, health.Redis(a.Redis))
+    return nil
+}
+
+func (a *Application) migrate(ctx context.Context) error {
+    if err := runMigrations(a.DB); err != nil {
+        return fmt.Errorf("failed to run migrations: %w", err)
+    }
+    log.Println("✅ Database migrations completed")
+
+    a.Health.Done("migrations")
+    return nil
+}
@@ -0,0 +1,204 @@
+//go:build integration
+
+package app_test
+
+import (
+    "fmt"
+    "net/http"
+    "testing"
+    "time"
+)
+
+func TestAuthFlow(t *testing.T) {
+    u := newUser(t)
+
+    // The token from verification works, and nothing works without one
+    call(t, "GET", "/api/v1/profile", u.Token, nil).expect(t, http.StatusOK)
+    call(t, "GET", "/api/v1/profile", "", nil).expect(t, http.StatusUnauthorized)
+
+    call(t, "POST", "/api/auth/signin", "", map[string]string{
+        "email_or_phone": u.Email,
+        "password":       "not-the-password",
+    }).expect(t, http.StatusUnauthorized)
+
+    var signin struct {
+        Data struct {
+            RequiresOTP bool   `json:"requires_otp"`
+            AccessToken string `json:"access_token"`
+        } `json:"data"`
+    }
+    call(t, "POST", "/api/auth/signin", "", map[string]string{
+        "email_or_phone": u.Email,
+        "password":       testPassword,
+    }).expect(t, http.StatusOK).decode(t, &signin)
+    if signin.Data.RequiresOTP || signin.Data.AccessToken == "" {
+        t.Fatalf("signin: requires OTP %v, token %q; want a token straight away", signin.Data.RequiresOTP, signin.Data.AccessToken)
+    }
+
+    // Signing out revokes that session only
+    call(t, "POST", "/api/auth/logout", signin.Data.AccessToken, nil).expect(t, http.StatusOK)
+    call(t, "GET", "/api/v1/profile", signin.Data.AccessToken, nil).expect(t, http.StatusUnauthorized)
+    call(t, "GET", "/api/v1/profile", u.Token, nil).expect(t, http.StatusOK)
+}
+
+// post is the part of a post the tests check
+type post struct {
+    ID      int64  `json:"id"`
+    UserID  int64  `json:"user_id"`
+    Caption string `json:"caption"`
+}
+
+// createPost publishes a public text post by u
+func createPost(t *testing.T, u *testUser, caption string) post {
+    t.Helper()
+
+    var created struct {
+        Data post `json:"data"`
+    }
+    call(t, "POST", "/api/v1/posts", u.Token, map[string]string{
+        "caption":    caption,
+        "visibility": "public",
+    }).expect(t, http.StatusCreated).decode(t, &created)
+    if created.Data.ID == 0 || created.Data.UserID != u.ID {
+        t.Fatalf("created post %+v, want an ID and author %d", created.Data, u.ID)
+    }
+    return created.Data
+}
+
+// feed returns the post IDs on the first page of u's feed
+func feed(t *testing.T, u *testUser) map[int64]bool {
+    t.Helper()
+
+    var page struct {
+        Data struct {
+            Posts []post `json:"posts"`
+        } `json:"data"`
+    }
+    call(t, "GET", "/api/v1/posts/feed", u.Token, nil).expect(t, http.StatusOK).decode(t, &page)
+
+    ids := map[int64]bool{}
+    for _, p := range page.Data.Posts {
+        ids[p.ID] = true
+    }
+    return ids
+}
+
+func TestPostsFlow(t *testing.T) {
+    author, follower, stranger := newUser(t), newUser(t), newUser(t)
+    follow(t, follower, author)
+
+    p := createPost(t, author, "Integration post by "+author.Username)
+
+    if !feed(t, follower)[p.ID] {
+        t.Errorf("post %d missing from a follower's feed", p.ID)
+    }
+    if feed(t, stranger)[p.ID] {
+        t.Errorf("post %d in the feed of someone not following the author", p.ID)
+    }
+
+    var got struct {
+        Data post `json:"data"`
+    }
+    call(t, "GET", fmt.Sprintf("/api/v1/posts/%d", p.ID), stranger.Token, nil).expect(t, http.StatusOK).decode(t, &got)
+    if got.Data.Caption != p.Caption {
+        t.Errorf("caption = %q, want %q", got.Data.Caption, p.Caption)
+    }
+
+    call(t, "GET", "/api/v1/posts/999999999", stranger.Token, nil).expect(t, http.StatusNotFound)
+}
+
+func TestMessagingFlow(t *testing.T) {
+    alice, bob := newOnboardedUser(t), newOnboardedUser(t)
+
+    var conversation struct {
+        Data struct {
+            ID int64 `json:"id"`
+        } `json:"data"`
+    }
+    path := fmt.Sprintf("/api/v1/messages/conversations/direct/%d", bob.ID)
+    call(t, "POST", path, alice.Token, nil).expect(t, http.StatusOK).decode(t, &conversation)
+    conversationID := conversation.Data.ID
+
+    // Asking again finds the same conversation
+    var again struct {
+        Data struct {
+            ID int64 `json:"id"`
+        } `json:"data"`
+    }
+    call(t, "POST", path, alice.Token, nil).expect(t, http.StatusOK).decode(t, &again)
+    if again.Data.ID != conversationID {
+        t.Errorf("second direct conversation %d, want the first, %d", again.Data.ID, conversationID)
+    }
+
+    content := "Hello from " + alice.Username
+    call(t, "POST", "/api/v1/messages/messages", alice.Token, map[string]interface{}{
+        "conversation_id": conversationID,
+        "content":         content,
+        "message_type":    "text",
+    }).expect(t, http.StatusCreated)
+
+    var messages struct {
+        Data []struct {
+            SenderID int64  `json:"sender_id"`
+            Content  string `json:"content"`
+        } `json:"data"`
+    }
+    messagesPath := fmt.Sprintf("/api/v1/messages/conversations/%d/messages", conversationID)
+    call(t, "GET", messagesPath, bob.Token, nil).expect(t, http.StatusOK).decode(t, &messages)
+    if len(messages.Data) != 1 || messages.Data[0].Content != content || messages.Data[0].SenderID != alice.ID {
+        t.Errorf("bob sees messages %+v, want alice's %q", messages.Data, content)
+    }
+
+    // Outsiders can't read it, and users who haven't set up a profile can't
+    // send messages
+    carol := newUser(t)
+    if r := call(t, "GET", messagesPath, carol.Token, nil); r.Code == http.StatusOK {
+        t.Errorf("a non-participant read the conversation: %s", r.Body.String())
+    }
+
+    call(t, "POST", fmt.Sprintf("/api/v1/messages/conversations/direct/%d", alice.ID), carol.Token, nil).
+        expect(t, http.StatusOK).decode(t, &conversation)
+    call(t, "POST", "/api/v1/messages/messages", carol.Token, map[string]interface{}{
+        "conversation_id": conversation.Data.ID,
+        "content":         "Hi",
+        "message_type":    "text",
+    }).expect(t, http.StatusForbidden)
+}
+
+func TestNotificationsFlow(t *testing.T) {
+    author, fan := newUser(t), newUser(t)
+    p := createPost(t, author, "Like this, "+fan.Username)
+
+    call(t, "POST", fmt.Sprintf("/api/v1/posts/%d/like", p.ID), fan.Token, nil).expect(t, http.StatusOK)
+
+    // Likes notify the author in the background
+    eventually(t, 10*time.Second, "the like notification", func() bool {
+        var list struct {
+            Notifications []struct {
+                Type string                 `json:"type"`
+                Data map[string]interface{} `json:"data"`
+            } `json:"notifications"`
+        }
+        call(t, "GET", "/api/v1/notifications", author.Token, nil).expect(t, http.StatusOK).decode(t, &list)
+        for _, n := range list.Notifications {
+            if n.Type == "like" && fmt.Sprint(n.Data["post_id"]) == fmt.Sprint(p.ID) {
+                return true
+            }
+        }
+        return false
+    })
+
+    var unread struct {
+        UnreadCount int `json:"unread_count"`
+    }
+    call(t, "GET", "/api/v1/notifications/unread-count", author.Token, nil).expect(t, http.StatusOK).decode(t, &unread)
+    if unread.UnreadCount == 0 {
+        t.Error("unread count is 0 with an unread like")
+    }
+
+    call(t, "PUT", "/api/v1/notifications/read-all", author.Token, nil).expect(t, http.StatusOK)
+    call(t, "GET", "/api/v1/notifications/unread-count", author.Token, nil).expect(t, http.StatusOK).decode(t, &unread)
+    if unread.UnreadCount != 0 {
+        t.Errorf("unread count = %d after reading all, want 0", unread.UnreadCount)
+    }
+}
@@ -0,0 +1,1393 @@
+// internal/app/migrations.go
+// Database schema, applied at startup
+
+package app
+
+import (
+    "database/sql"
+    "fmt"
+    "log"
+    "strings"
+)
+
+// Migrate applies the schema to db, as the server does on startup. Tools
+// that work on the database without starting the server run it first.
+func Migrate(db *sql.DB) error {
+    return runMigrations(db)
+}
+
+// runMigrations executes database migrations - UPDATED
+func runMigrations(db *sql.DB) error {
+    log.Println("   - Checking existing tables...")
+    
+    // Check if tables already exist
+    var userTableExists bool
+    err := db.QueryRow(`
+        SELECT EXISTS (
+            SELECT FROM information_schema.tables 
+            WHERE table_schema = 'public' 
+            AND table_name = 'users'
+        )
+    `).Scan(&userTableExists)
+    
+    if err != nil {
+        return fmt.Errorf("failed to check tables: %w", err)
+    }
+    
+    if userTableExists {
+        log.Println("   ✅ Tables already exist, running additional migrations if needed...")
+    }
+    
+    log.Println("   - Creating/updating tables...")
+    
+    // Create tables if they don't exist
+    migrations := []string{
+        // Users table (simplified to match what's already in Supabase)
+        `CREATE TABLE IF NOT EXISTS users (
+            id SERIAL PRIMARY KEY,
+            email VARCHAR(255) UNIQUE,
+            username VARCHAR(100) UNIQUE NOT NULL,
+            password_hash VARCHAR(255),
+            phone VARCHAR(20) UNIQUE,
+            provider VARCHAR(50) DEFAULT 'local',
+            provider_id VARCHAR(255),
+            is_verified BOOLEAN DEFAULT FALSE,
+            is_profile_complete BOOLEAN DEFAULT FALSE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        // Profile columns of the users read model, for databases created
+        // before they were added to the table above
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_picture TEXT`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS cover_photo TEXT`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS bio TEXT`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS date_of_birth DATE`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS gender VARCHAR(10)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS location VARCHAR(100)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS interests TEXT[]`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS looking_for VARCHAR(50)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS relationship_status VARCHAR(50)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS height INTEGER`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS education VARCHAR(200)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS work VARCHAR(200)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS languages TEXT[]`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS instagram VARCHAR(50)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS twitter VARCHAR(50)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS website VARCHAR(200)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified BOOLEAN DEFAULT FALSE`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_verified BOOLEAN DEFAULT FALSE`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS privacy_settings JSONB DEFAULT '{}'`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS notification_settings JSONB DEFAULT '{}'`,
+        
+        // Sessions table
+        `CREATE TABLE IF NOT EXISTS sessions (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            token TEXT NOT NULL UNIQUE,
+            refresh_token TEXT NOT NULL UNIQUE,
+            device_info TEXT,
+            ip_address VARCHAR(45),
+            expires_at TIMESTAMP NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        // Posts tables
+        `CREATE TABLE IF NOT EXISTS posts (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            caption TEXT,
+            location VARCHAR(255),
+            visibility VARCHAR(20) DEFAULT 'public',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        `CREATE TABLE IF NOT EXISTS post_media (
+            id SERIAL PRIMARY KEY,
+            post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+            media_url TEXT NOT NULL,
+            media_type VARCHAR(20) NOT NULL,
+            position INTEGER DEFAULT 0,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        `CREATE TABLE IF NOT EXISTS post_likes (
+            post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (post_id, user_id)
+        )`,
+        
+        `CREATE TABLE IF NOT EXISTS comments (
+            id SERIAL PRIMARY KEY,
+            post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            parent_id INTEGER REFERENCES comments(id) ON DELETE CASCADE,
+            content TEXT NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        `CREATE TABLE IF NOT EXISTS follows (
+            follower_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            following_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (follower_id, following_id)
+        )`,
+        
+        // Flushed approximate view counts (stories, posts)
+        `CREATE TABLE IF NOT EXISTS view_counts (
+            entity_type VARCHAR(20) NOT NULL,
+            entity_id BIGINT NOT NULL,
+            view_count BIGINT NOT NULL DEFAULT 0,
+            reconciled_at TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (entity_type, entity_id)
+        )`,
+        
+        // Story stickers (mentions, post and link stickers) and reshared posts
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS elements JSONB DEFAULT '[]'`,
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS shared_post_id INTEGER REFERENCES posts(id) ON DELETE SET NULL`,
+        
+        // Story audiences and close friends
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS audience VARCHAR(20) DEFAULT 'public'`,
+        `CREATE TABLE IF NOT EXISTS close_friends (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            friend_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, friend_id)
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_close_friends_friend_id ON close_friends(friend_id)`,
+        
+        // Hotpick swipe time, counted against the daily swipe quota
+        `DO $$
+        BEGIN
+            IF to_regclass('hotpicks') IS NOT NULL THEN
+                ALTER TABLE hotpicks ADD COLUMN IF NOT EXISTS acted_at TIMESTAMP;
+                CREATE INDEX IF NOT EXISTS idx_hotpicks_user_acted_at ON hotpicks(user_id, acted_at)
+                    WHERE is_acted_on = TRUE;
+            END IF;
+        END $$`,
+        
+        // Spotlight boosts and purchased boost credits
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS boost_credits INTEGER DEFAULT 0`,
+        `CREATE TABLE IF NOT EXISTS profile_boosts (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            source VARCHAR(20) NOT NULL,
+            multiplier DECIMAL(4,2) NOT NULL,
+            impressions INTEGER DEFAULT 0,
+            started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            expires_at TIMESTAMP NOT NULL
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_profile_boosts_user_expires ON profile_boosts(user_id, expires_at DESC)`,
+        
+        // Profile completion time, drives the new-profile discovery boost
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS profile_completed_at TIMESTAMP`,
+        `UPDATE users SET profile_completed_at = created_at
+            WHERE is_profile_complete = TRUE AND profile_completed_at IS NULL`,
+        
+        // User timezone, used to schedule the weekly recap
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) DEFAULT 'UTC'`,
+        // Language for notifications, OTP messages and emails sent to the user
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS preferred_language VARCHAR(10) NOT NULL DEFAULT 'en'`,
+        
+        // Notification preferences, including the weekly recap opt-out
+        `CREATE TABLE IF NOT EXISTS notification_preferences (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER UNIQUE NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            push_enabled BOOLEAN DEFAULT TRUE,
+            email_enabled BOOLEAN DEFAULT TRUE,
+            sms_enabled BOOLEAN DEFAULT FALSE,
+            likes BOOLEAN DEFAULT TRUE,
+            comments BOOLEAN DEFAULT TRUE,
+            follows BOOLEAN DEFAULT TRUE,
+            messages BOOLEAN DEFAULT TRUE,
+            matches BOOLEAN DEFAULT TRUE,
+            story_views BOOLEAN DEFAULT TRUE,
+            story_replies BOOLEAN DEFAULT TRUE,
+            mentions BOOLEAN DEFAULT TRUE,
+            promotions BOOLEAN DEFAULT TRUE,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS weekly_recap BOOLEAN DEFAULT TRUE`,
+        `ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS contacts_joined BOOLEAN DEFAULT TRUE`,
+        
+        // Quiet hours: push and SMS are held in scheduled_notifications until they end
+        `ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS quiet_hours_start VARCHAR(5)`,
+        `ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS quiet_hours_end VARCHAR(5)`,
+        `ALTER TABLE notification_preferences ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC'`,
+        `CREATE TABLE IF NOT EXISTS scheduled_notifications (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER REFERENCES users(id) ON DELETE CASCADE,
+            notification_id BIGINT,
+            type VARCHAR(50) NOT NULL,
+            title VARCHAR(200) NOT NULL,
+            message TEXT NOT NULL,
+            data JSONB DEFAULT '{}',
+            channels JSONB DEFAULT '[]',
+            scheduled_for TIMESTAMP NOT NULL,
+            status VARCHAR(20) DEFAULT 'pending',
+            sent_at TIMESTAMP,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `ALTER TABLE scheduled_notifications ADD COLUMN IF NOT EXISTS notification_id BIGINT`,
+        `CREATE INDEX IF NOT EXISTS idx_scheduled_notifications_pending ON scheduled_notifications(scheduled_for) WHERE status = 'pending'`,
+        
+        // One row per user and recap week (the user's local Monday) so a recap is never sent twice
+        `CREATE TABLE IF NOT EXISTS weekly_recaps (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            week_start DATE NOT NULL,
+            sent_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, week_start)
+        )`,
+        `DO $$
+        BEGIN
+            IF to_regclass('profile_views') IS NOT NULL THEN
+                CREATE INDEX IF NOT EXISTS idx_profile_views_profile_viewed ON profile_views(profile_id, viewed_at);
+                CREATE INDEX IF NOT EXISTS idx_profile_views_viewer_profile ON profile_views(viewer_id, profile_id, viewed_at);
+            END IF;
+        END $$`,
+        
+        // Notification delivery log, one row per notification and channel
+        `DO $$
+        BEGIN
+            IF to_regclass('notifications') IS NOT NULL THEN
+                CREATE TABLE IF NOT EXISTS notification_deliveries (
+                    id SERIAL PRIMARY KEY,
+                    notification_id INTEGER NOT NULL REFERENCES notifications(id) ON DELETE CASCADE,
+                    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                    channel VARCHAR(20) NOT NULL,
+                    status VARCHAR(20) NOT NULL,
+                    provider_message_id TEXT,
+                    attempts INTEGER NOT NULL DEFAULT 0,
+                    last_error TEXT,
+                    next_attempt_at TIMESTAMP,
+                    sent_at TIMESTAMP,
+                    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+                );
+                CREATE INDEX IF NOT EXISTS idx_notification_deliveries_retry ON notification_deliveries(next_attempt_at) WHERE status = 'queued';
+                CREATE INDEX IF NOT EXISTS idx_notification_deliveries_created ON notification_deliveries(created_at DESC);
+                CREATE INDEX IF NOT EXISTS idx_notification_deliveries_notification ON notification_deliveries(notification_id);
+            END IF;
+        END $$`,
+        
+        // Grouped notifications: likes, follows and views of one object collapse into one row
+        `DO $$
+        BEGIN
+            IF to_regclass('notifications') IS NOT NULL THEN
+                ALTER TABLE notifications ADD COLUMN IF NOT EXISTS group_key VARCHAR(100);
+                ALTER TABLE notifications ADD COLUMN IF NOT EXISTS actor_ids BIGINT[];
+                ALTER TABLE notifications ADD COLUMN IF NOT EXISTS actor_count INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE notifications ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP;
+                ALTER TABLE notifications ADD COLUMN IF NOT EXISTS group_pushed_at TIMESTAMP;
+                CREATE INDEX IF NOT EXISTS idx_notifications_open_groups ON notifications(user_id, group_key)
+                    WHERE group_key IS NOT NULL AND is_read = false;
+            END IF;
+        END $$`,
+        
+        // Unread badge counts are read on every new notification and read marker
+        `DO $$
+        BEGIN
+            IF to_regclass('notifications') IS NOT NULL THEN
+                CREATE INDEX IF NOT EXISTS idx_notifications_user_unread ON notifications(user_id) WHERE is_read = false;
+            END IF;
+        END $$`,
+        
+        // Activity stream: premium unlocks "who viewed you", read markers drive unread counts
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS is_premium BOOLEAN DEFAULT FALSE`,
+        `CREATE TABLE IF NOT EXISTS activity_reads (
+            user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+            last_read_at TIMESTAMP NOT NULL
+        )`,
+        
+        // Billing subscriptions, one row per provider subscription
+        `CREATE TABLE IF NOT EXISTS subscriptions (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            plan_id VARCHAR(50) NOT NULL,
+            provider VARCHAR(20) NOT NULL,
+            provider_subscription_id VARCHAR(255) NOT NULL,
+            provider_customer_id VARCHAR(255),
+            status VARCHAR(20) NOT NULL,
+            current_period_end TIMESTAMP,
+            cancel_at_period_end BOOLEAN DEFAULT FALSE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE (provider, provider_subscription_id)
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_subscriptions_user ON subscriptions(user_id, updated_at DESC)`,
+        
+        // Processed webhook events, so provider retries are applied once
+        `CREATE TABLE IF NOT EXISTS billing_webhook_events (
+            provider VARCHAR(20) NOT NULL,
+            event_id VARCHAR(255) NOT NULL,
+            received_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (provider, event_id)
+        )`,
+        
+        // Photo verification: selfie challenges and their review
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS is_photo_verified BOOLEAN DEFAULT FALSE`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS photo_verified_at TIMESTAMP`,
+        `CREATE TABLE IF NOT EXISTS photo_verifications (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            pose_id VARCHAR(30) NOT NULL,
+            status VARCHAR(20) NOT NULL,
+            selfie_ref TEXT,
+            match_score DECIMAL(5,4),
+            reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+            review_note TEXT,
+            expires_at TIMESTAMP NOT NULL,
+            submitted_at TIMESTAMP,
+            reviewed_at TIMESTAMP,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_photo_verifications_user ON photo_verifications(user_id, created_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_photo_verifications_status ON photo_verifications(status, submitted_at)`,
+        
+        // Content moderation: uploads held for admin review
+        `CREATE TABLE IF NOT EXISTS moderation_items (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            source VARCHAR(30) NOT NULL,
+            folder VARCHAR(255) NOT NULL DEFAULT '',
+            filename VARCHAR(255) NOT NULL,
+            content_type VARCHAR(100) NOT NULL,
+            quarantine_ref TEXT NOT NULL,
+            labels JSONB NOT NULL DEFAULT '[]',
+            score DECIMAL(5,4) NOT NULL DEFAULT 0,
+            status VARCHAR(20) NOT NULL,
+            published_url TEXT,
+            reviewed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+            review_note TEXT,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            reviewed_at TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_moderation_items_status ON moderation_items(status, created_at)`,
+        `CREATE INDEX IF NOT EXISTS idx_moderation_items_user ON moderation_items(user_id, created_at DESC)`,
+        `CREATE TABLE IF NOT EXISTS moderation_violations (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            kind VARCHAR(30) NOT NULL,
+            source VARCHAR(30) NOT NULL,
+            count INTEGER NOT NULL DEFAULT 0,
+            first_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            last_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, kind, source)
+        )`,
+        
+        // Signed-in devices
+        `ALTER TABLE sessions ADD COLUMN IF NOT EXISTS user_agent TEXT`,
+        `ALTER TABLE sessions ADD COLUMN IF NOT EXISTS device_fingerprint VARCHAR(64)`,
+        `ALTER TABLE sessions ADD COLUMN IF NOT EXISTS location VARCHAR(255)`,
+        `ALTER TABLE sessions ADD COLUMN IF NOT EXISTS last_used_at TIMESTAMP`,
+        `ALTER TABLE sessions ADD COLUMN IF NOT EXISTS refresh_expires_at TIMESTAMP`,
+        `CREATE INDEX IF NOT EXISTS idx_sessions_user ON sessions(user_id)`,
+        `CREATE TABLE IF NOT EXISTS user_devices (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            fingerprint VARCHAR(64) NOT NULL,
+            label VARCHAR(100),
+            first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, fingerprint)
+        )`,
+        
+        // Two-factor authentication
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS two_factor_method VARCHAR(10)`,
+        `CREATE TABLE IF NOT EXISTS user_totp (
+            user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+            secret TEXT NOT NULL,
+            confirmed_at TIMESTAMP,
+            last_used_step BIGINT NOT NULL DEFAULT 0,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE TABLE IF NOT EXISTS user_recovery_codes (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            code_hash VARCHAR(64) NOT NULL,
+            used_at TIMESTAMP,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user ON user_recovery_codes(user_id, code_hash)`,
+        
+        // Signin history for login anomaly detection
+        `CREATE TABLE IF NOT EXISTS login_locations (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            network VARCHAR(64) NOT NULL,
+            country VARCHAR(2),
+            first_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            last_seen_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, network)
+        )`,
+        
+        // Username, email and phone changes
+        `CREATE TABLE IF NOT EXISTS pending_identifier_changes (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            kind VARCHAR(20) NOT NULL,
+            new_value VARCHAR(255) NOT NULL,
+            expires_at TIMESTAMP NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, kind)
+        )`,
+        `CREATE TABLE IF NOT EXISTS user_identifier_history (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            kind VARCHAR(20) NOT NULL,
+            old_value VARCHAR(255),
+            new_value VARCHAR(255) NOT NULL,
+            changed_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_user_identifier_history_user ON user_identifier_history(user_id, kind, changed_at DESC)`,
+        
+        // Admin user export/import jobs
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS display_name VARCHAR(100)`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS account_status VARCHAR(20) DEFAULT 'active'`,
+        `CREATE TABLE IF NOT EXISTS user_transfer_jobs (
+            id SERIAL PRIMARY KEY,
+            type VARCHAR(20) NOT NULL,
+            status VARCHAR(20) NOT NULL DEFAULT 'pending',
+            created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+            params JSONB DEFAULT '{}',
+            total_rows INTEGER DEFAULT 0,
+            processed_rows INTEGER DEFAULT 0,
+            succeeded_rows INTEGER DEFAULT 0,
+            failed_rows INTEGER DEFAULT 0,
+            errors JSONB DEFAULT '[]',
+            result_url TEXT,
+            error TEXT,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            started_at TIMESTAMP,
+            completed_at TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_user_transfer_jobs_created ON user_transfer_jobs(created_at DESC)`,
+        
+        // Notification templates, versioned so seeding can upgrade and admins can roll back
+        `CREATE TABLE IF NOT EXISTS notification_templates (
+            id SERIAL PRIMARY KEY,
+            type VARCHAR(50) NOT NULL,
+            language VARCHAR(10) NOT NULL DEFAULT 'en',
+            title_template TEXT NOT NULL,
+            body_template TEXT NOT NULL,
+            variables JSONB DEFAULT '[]',
+            version INTEGER NOT NULL DEFAULT 1,
+            pinned BOOLEAN DEFAULT FALSE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE(type, language)
+        )`,
+        `ALTER TABLE notification_templates ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1`,
+        `ALTER TABLE notification_templates ADD COLUMN IF NOT EXISTS pinned BOOLEAN DEFAULT FALSE`,
+        
+        `CREATE TABLE IF NOT EXISTS notification_template_versions (
+            id SERIAL PRIMARY KEY,
+            type VARCHAR(50) NOT NULL,
+            language VARCHAR(10) NOT NULL,
+            version INTEGER NOT NULL,
+            title_template TEXT NOT NULL,
+            body_template TEXT NOT NULL,
+            variables JSONB DEFAULT '[]',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE(type, language, version)
+        )`,
+        `INSERT INTO notification_template_versions (type, language, version, title_template, body_template, variables)
+            SELECT type, language, version, title_template, body_template, variables FROM notification_templates
+            ON CONFLICT (type, language, version) DO NOTHING`,
+        
+        // Analytics: raw client and server events, each user's funnel
+        // milestones, and the nightly aggregates the admin charts read
+        `CREATE TABLE IF NOT EXISTS analytics_events (
+            id BIGSERIAL PRIMARY KEY,
+            user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+            name VARCHAR(64) NOT NULL,
+            source VARCHAR(10) NOT NULL,
+            properties JSONB,
+            platform VARCHAR(20),
+            session_id VARCHAR(64),
+            occurred_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            received_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_analytics_events_occurred ON analytics_events(occurred_at)`,
+        `CREATE INDEX IF NOT EXISTS idx_analytics_events_user ON analytics_events(user_id, occurred_at)`,
+        `CREATE TABLE IF NOT EXISTS analytics_milestones (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            event VARCHAR(64) NOT NULL,
+            reached_at TIMESTAMP WITH TIME ZONE NOT NULL,
+            PRIMARY KEY (user_id, event)
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_analytics_milestones_reached ON analytics_milestones(event, reached_at)`,
+        `CREATE TABLE IF NOT EXISTS analytics_daily_metrics (
+            day DATE NOT NULL,
+            metric VARCHAR(64) NOT NULL,
+            value DOUBLE PRECISION NOT NULL DEFAULT 0,
+            computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (day, metric)
+        )`,
+        `CREATE TABLE IF NOT EXISTS analytics_daily_retention (
+            cohort_day DATE NOT NULL,
+            day_offset SMALLINT NOT NULL,
+            cohort_size INTEGER NOT NULL DEFAULT 0,
+            retained INTEGER NOT NULL DEFAULT 0,
+            computed_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (cohort_day, day_offset)
+        )`,
+        
+        // Staff roles, suspensions, user reports and the admin audit log
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) DEFAULT 'user'`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS suspended_at TIMESTAMP`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS suspension_reason TEXT`,
+        `CREATE TABLE IF NOT EXISTS user_reports (
+            id SERIAL PRIMARY KEY,
+            reporter_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+            reported_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            reason VARCHAR(50) NOT NULL,
+            details TEXT,
+            status VARCHAR(20) DEFAULT 'open',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_user_reports_reported ON user_reports(reported_user_id, created_at DESC)`,
+        `CREATE TABLE IF NOT EXISTS admin_audit_log (
+            id BIGSERIAL PRIMARY KEY,
+            actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+            action VARCHAR(50) NOT NULL,
+            target_user_id INTEGER,
+            details JSONB,
+            ip_address VARCHAR(45),
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_admin_audit_log_target ON admin_audit_log(target_user_id, created_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_admin_audit_log_actor ON admin_audit_log(actor_id, created_at DESC)`,
+        
+        // Soft delete for posts and comments, purged after 30 days in the trash
+        `ALTER TABLE posts ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+        `ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP`,
+        `ALTER TABLE comments ADD COLUMN IF NOT EXISTS deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL`,
+        `CREATE INDEX IF NOT EXISTS idx_posts_deleted_at ON posts(user_id, deleted_at DESC) WHERE deleted_at IS NOT NULL`,
+        `CREATE INDEX IF NOT EXISTS idx_comments_deleted_at ON comments(deleted_by, deleted_at DESC) WHERE deleted_at IS NOT NULL`,
+        
+        // Scheduled posts, published by the scheduler once publish_at passes
+        `ALTER TABLE posts ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'published'`,
+        `ALTER TABLE posts ADD COLUMN IF NOT EXISTS publish_at TIMESTAMP WITH TIME ZONE`,
+        `CREATE INDEX IF NOT EXISTS idx_posts_scheduled ON posts(publish_at) WHERE status = 'scheduled' AND deleted_at IS NULL`,
+        
+        // Post drafts, kept apart from posts so they never reach a feed
+        `CREATE TABLE IF NOT EXISTS post_drafts (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            caption TEXT NOT NULL DEFAULT '',
+            location VARCHAR(255) NOT NULL DEFAULT '',
+            visibility VARCHAR(20) NOT NULL DEFAULT '',
+            media_urls TEXT[] NOT NULL DEFAULT '{}',
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_post_drafts_user ON post_drafts(user_id, updated_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_post_drafts_updated ON post_drafts(updated_at)`,
+        
+        // Files uploaded for drafts, removed once no draft or post uses them
+        `CREATE TABLE IF NOT EXISTS post_draft_uploads (
+            url TEXT PRIMARY KEY,
+            user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+            draft_id INTEGER REFERENCES post_drafts(id) ON DELETE SET NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_post_draft_uploads_created ON post_draft_uploads(created_at)`,
+        
+        // Explore trending: recent public posts and each viewer's reports
+        `CREATE INDEX IF NOT EXISTS idx_posts_trending ON posts(created_at DESC) WHERE visibility = 'public' AND original_post_id IS NULL AND deleted_at IS NULL`,
+        `CREATE INDEX IF NOT EXISTS idx_user_reports_reporter ON user_reports(reporter_id)`,
+        
+        // Places for location tagging, saved from geocoder results
+        `CREATE TABLE IF NOT EXISTS places (
+            id SERIAL PRIMARY KEY,
+            name VARCHAR(255) NOT NULL,
+            address TEXT,
+            city VARCHAR(100),
+            country VARCHAR(2),
+            latitude DOUBLE PRECISION NOT NULL,
+            longitude DOUBLE PRECISION NOT NULL,
+            provider VARCHAR(30) NOT NULL,
+            provider_id VARCHAR(100) NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE (provider, provider_id)
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_places_location ON places(latitude, longitude)`,
+        `ALTER TABLE posts ADD COLUMN IF NOT EXISTS place_id INTEGER REFERENCES places(id) ON DELETE SET NULL`,
+        `ALTER TABLE posts ADD COLUMN IF NOT EXISTS latitude DOUBLE PRECISION`,
+        `ALTER TABLE posts ADD COLUMN IF NOT EXISTS longitude DOUBLE PRECISION`,
+        `CREATE INDEX IF NOT EXISTS idx_posts_place ON posts(place_id, created_at DESC) WHERE place_id IS NOT NULL AND deleted_at IS NULL`,
+        `CREATE INDEX IF NOT EXISTS idx_posts_location ON posts(latitude, longitude) WHERE latitude IS NOT NULL AND deleted_at IS NULL`,
+        
+        // Denormalized like, comment and follow counts, kept by the triggers
+        // below and reconciled nightly. Existing rows are backfilled when the
+        // columns are first added.
+        `DO $$
+        BEGIN
+            IF NOT EXISTS (
+                SELECT 1 FROM information_schema.columns
+                WHERE table_name = 'posts' AND column_name = 'likes_count'
+            ) THEN
+                ALTER TABLE posts ADD COLUMN likes_count INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE posts ADD COLUMN comments_count INTEGER NOT NULL DEFAULT 0;
+                UPDATE posts p SET
+                    likes_count = (SELECT COUNT(*) FROM post_likes l WHERE l.post_id = p.id),
+                    comments_count = (SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL);
+            END IF;
+        END $$`,
+        `DO $$
+        BEGIN
+            IF NOT EXISTS (
+                SELECT 1 FROM information_schema.columns
+                WHERE table_name = 'users' AND column_name = 'followers_count'
+            ) THEN
+                ALTER TABLE users ADD COLUMN followers_count INTEGER NOT NULL DEFAULT 0;
+                ALTER TABLE users ADD COLUMN following_count INTEGER NOT NULL DEFAULT 0;
+                UPDATE users u SET
+                    followers_count = (SELECT COUNT(*) FROM follows WHERE following_id = u.id),
+                    following_count = (SELECT COUNT(*) FROM follows WHERE follower_id = u.id);
+            END IF;
+        END $$`,
+        `CREATE OR REPLACE FUNCTION count_post_likes() RETURNS trigger AS $$
+        BEGIN
+            IF TG_OP = 'INSERT' THEN
+                UPDATE posts SET likes_count = likes_count + 1 WHERE id = NEW.post_id;
+            ELSE
+                UPDATE posts SET likes_count = GREATEST(likes_count - 1, 0) WHERE id = OLD.post_id;
+            END IF;
+            RETURN NULL;
+        END $$ LANGUAGE plpgsql`,
+        `DROP TRIGGER IF EXISTS post_likes_count ON post_likes`,
+        `CREATE TRIGGER post_likes_count AFTER INSERT OR DELETE ON post_likes
+            FOR EACH ROW EXECUTE FUNCTION count_post_likes()`,
+        // Only live comments count, so moving one to or from the trash does too
+        `CREATE OR REPLACE FUNCTION count_post_comments() RETURNS trigger AS $$
+        DECLARE
+            delta INTEGER := 0;
+        BEGIN
+            IF TG_OP IN ('INSERT', 'UPDATE') AND NEW.deleted_at IS NULL THEN
+                delta := delta + 1;
+            END IF;
+            IF TG_OP IN ('DELETE', 'UPDATE') AND OLD.deleted_at IS NULL THEN
+                delta := delta - 1;
+            END IF;
+            IF delta <> 0 THEN
+                UPDATE posts SET comments_count = GREATEST(comments_count + delta, 0)
+                WHERE id = COALESCE(NEW.post_id, OLD.post_id);
+            END IF;
+            RETURN NULL;
+        END $$ LANGUAGE plpgsql`,
+        `DROP TRIGGER IF EXISTS comments_count ON comments`,
+        `CREATE TRIGGER comments_count AFTER INSERT OR DELETE OR UPDATE OF deleted_at ON comments
+            FOR EACH ROW EXECUTE FUNCTION count_post_comments()`,
+        `CREATE OR REPLACE FUNCTION count_follows() RETURNS trigger AS $$
+        BEGIN
+            IF TG_OP = 'INSERT' THEN
+                UPDATE users SET followers_count = followers_count + 1 WHERE id = NEW.following_id;
+                UPDATE users SET following_count = following_count + 1 WHERE id = NEW.follower_id;
+            ELSE
+                UPDATE users SET followers_count = GREATEST(followers_count - 1, 0) WHERE id = OLD.following_id;
+                UPDATE users SET following_count = GREATEST(following_count - 1, 0) WHERE id = OLD.follower_id;
+            END IF;
+            RETURN NULL;
+        END $$ LANGUAGE plpgsql`,
+        `DROP TRIGGER IF EXISTS follows_count ON follows`,
+        `CREATE TRIGGER follows_count AFTER INSERT OR DELETE ON follows
+            FOR EACH ROW EXECUTE FUNCTION count_follows()`,
+        
+        // Event outbox, written in the same transaction as the change each
+        // event describes, and which subscribers have handled each event
+        `CREATE TABLE IF NOT EXISTS outbox_events (
+            id BIGSERIAL PRIMARY KEY,
+            event_type VARCHAR(100) NOT NULL,
+            idempotency_key VARCHAR(255) NOT NULL UNIQUE,
+            payload JSONB NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT,
+            available_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            published_at TIMESTAMP,
+            failed_at TIMESTAMP,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_outbox_events_pending ON outbox_events(available_at, id)
+            WHERE published_at IS NULL AND failed_at IS NULL`,
+        `CREATE INDEX IF NOT EXISTS idx_outbox_events_published ON outbox_events(published_at)
+            WHERE published_at IS NOT NULL`,
+        `CREATE TABLE IF NOT EXISTS outbox_deliveries (
+            event_id BIGINT NOT NULL REFERENCES outbox_events(id) ON DELETE CASCADE,
+            handler VARCHAR(100) NOT NULL,
+            delivered_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (event_id, handler)
+        )`,
+        
+        // Each subsystem's progress purging an erased account's data
+        `CREATE TABLE IF NOT EXISTS erasure_purges (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            subsystem VARCHAR(50) NOT NULL,
+            items INTEGER NOT NULL DEFAULT 0,
+            last_error TEXT,
+            started_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            completed_at TIMESTAMP,
+            PRIMARY KEY (user_id, subsystem)
+        )`,
+        
+        // Story interactions viewers' apps report, for creator insights
+        `DO $$
+        BEGIN
+            IF to_regclass('stories') IS NOT NULL THEN
+                CREATE TABLE IF NOT EXISTS story_interactions (
+                    id SERIAL PRIMARY KEY,
+                    story_id INTEGER NOT NULL REFERENCES stories(id) ON DELETE CASCADE,
+                    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                    interaction_type VARCHAR(20) NOT NULL,
+                    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+                    UNIQUE(story_id, user_id, interaction_type)
+                );
+            END IF;
+        END $$`,
+        
+        // Story video processing: transcoded renditions, and stories hidden
+        // from viewers until they are ready
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS processing_status VARCHAR(20) NOT NULL DEFAULT 'ready'`,
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS processing_error TEXT`,
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS processing_attempts INTEGER NOT NULL DEFAULT 0`,
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS processing_started_at TIMESTAMP`,
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS playback_url TEXT`,
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS hls_url TEXT`,
+        `DO $$
+        BEGIN
+            IF to_regclass('stories') IS NOT NULL THEN
+                CREATE INDEX IF NOT EXISTS idx_stories_processing ON stories(created_at)
+                    WHERE processing_status IN ('pending', 'processing');
+            END IF;
+        END $$`,
+        
+        // Story highlights, with the order they are shown in and whether the
+        // cover was uploaded for the highlight (and is deleted with it)
+        `CREATE TABLE IF NOT EXISTS story_highlights (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            title VARCHAR(100) NOT NULL,
+            cover_image TEXT,
+            story_ids BIGINT[] NOT NULL DEFAULT '{}',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `ALTER TABLE story_highlights ADD COLUMN IF NOT EXISTS position INTEGER NOT NULL DEFAULT 0`,
+        `ALTER TABLE story_highlights ADD COLUMN IF NOT EXISTS cover_uploaded BOOLEAN NOT NULL DEFAULT FALSE`,
+        `CREATE INDEX IF NOT EXISTS idx_story_highlights_user ON story_highlights(user_id, position)`,
+        
+        // Story archive: when each story was archived, and each user's policy
+        `ALTER TABLE IF EXISTS stories ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP`,
+        `DO $$
+        BEGIN
+            IF to_regclass('stories') IS NOT NULL THEN
+                CREATE INDEX IF NOT EXISTS idx_stories_unarchived ON stories(expires_at)
+                    WHERE archived_at IS NULL;
+            END IF;
+        END $$`,
+        `CREATE TABLE IF NOT EXISTS story_archive_settings (
+            user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+            archive_enabled BOOLEAN NOT NULL DEFAULT TRUE,
+            retention_days INTEGER,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        // Addresses that bounced or complained, which no email is sent to
+        `CREATE TABLE IF NOT EXISTS email_suppressions (
+            email VARCHAR(255) PRIMARY KEY,
+            reason VARCHAR(20) NOT NULL,
+            source VARCHAR(50) NOT NULL,
+            detail TEXT NOT NULL DEFAULT '',
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_email_suppressions_updated ON email_suppressions(updated_at DESC)`,
+        
+        // One-time codes. Databases set up before this migration already
+        // have the table; new ones get it here.
+        `CREATE TABLE IF NOT EXISTS otps (
+            id BIGSERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            code VARCHAR(10) NOT NULL,
+            type VARCHAR(30) NOT NULL,
+            method VARCHAR(20) NOT NULL,
+            recipient VARCHAR(255) NOT NULL,
+            attempts INTEGER NOT NULL DEFAULT 0,
+            verified BOOLEAN NOT NULL DEFAULT FALSE,
+            expires_at TIMESTAMP NOT NULL,
+            verified_at TIMESTAMP,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        // OTP send limits count codes per address without Redis
+        `DO $$
+        BEGIN
+            IF to_regclass('otps') IS NOT NULL THEN
+                CREATE INDEX IF NOT EXISTS idx_otps_recipient_created ON otps(recipient, created_at DESC);
+            END IF;
+        END $$`,
+        
+        // Segmented notification campaigns and their open/click events
+        `CREATE TABLE IF NOT EXISTS notification_campaigns (
+            id SERIAL PRIMARY KEY,
+            name VARCHAR(100) NOT NULL,
+            title VARCHAR(200) NOT NULL,
+            message TEXT NOT NULL,
+            data JSONB DEFAULT '{}',
+            channels JSONB DEFAULT '[]',
+            segment JSONB NOT NULL DEFAULT '{}',
+            status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+            scheduled_for TIMESTAMP NOT NULL,
+            rate_per_minute INTEGER NOT NULL,
+            audience_size INTEGER NOT NULL DEFAULT 0,
+            sent_count INTEGER NOT NULL DEFAULT 0,
+            last_user_id BIGINT NOT NULL DEFAULT 0,
+            last_batch_at TIMESTAMP,
+            created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            started_at TIMESTAMP,
+            completed_at TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_notification_campaigns_due ON notification_campaigns(scheduled_for) WHERE status IN ('scheduled', 'sending')`,
+        `CREATE TABLE IF NOT EXISTS campaign_events (
+            campaign_id INTEGER NOT NULL REFERENCES notification_campaigns(id) ON DELETE CASCADE,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            event VARCHAR(10) NOT NULL,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (campaign_id, user_id, event)
+        )`,
+        
+        // Notification actions: which one was taken, so it only runs once
+        `DO $$
+        BEGIN
+            IF to_regclass('notifications') IS NOT NULL THEN
+                ALTER TABLE notifications ADD COLUMN IF NOT EXISTS resolved_at TIMESTAMP;
+                ALTER TABLE notifications ADD COLUMN IF NOT EXISTS resolved_action VARCHAR(50);
+            END IF;
+        END $$`,
+        
+        // Saved dating preferences, the default filters for discovery and hotpicks
+        `CREATE TABLE IF NOT EXISTS dating_preferences (
+            user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+            min_age INTEGER NOT NULL DEFAULT 18,
+            max_age INTEGER NOT NULL DEFAULT 100,
+            max_distance_km INTEGER NOT NULL DEFAULT 100,
+            genders TEXT[] NOT NULL DEFAULT '{}',
+            intent VARCHAR(50),
+            updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        // Daily hotpicks, one set per user per day
+        `CREATE TABLE IF NOT EXISTS hotpicks (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            recommended_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            score DECIMAL(6,4) NOT NULL,
+            reason TEXT,
+            factors JSONB,
+            is_seen BOOLEAN DEFAULT FALSE,
+            is_acted_on BOOLEAN DEFAULT FALSE,
+            action_type VARCHAR(20),
+            expires_at TIMESTAMP,
+            created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            acted_at TIMESTAMP
+        )`,
+        `CREATE UNIQUE INDEX IF NOT EXISTS idx_hotpicks_user_recommended_day ON hotpicks(user_id, recommended_user_id, (DATE(created_at)))`,
+        `CREATE INDEX IF NOT EXISTS idx_hotpicks_user_created ON hotpicks(user_id, created_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_hotpicks_user_acted_at ON hotpicks(user_id, acted_at) WHERE is_acted_on = TRUE`,
+        
+        // Set while a rewound pass waits to be shown again
+        `ALTER TABLE hotpicks ADD COLUMN IF NOT EXISTS rewound_at TIMESTAMP`,
+        
+        // Virtual gifts catalog, priced in coins
+        `CREATE TABLE IF NOT EXISTS gifts (
+            id SERIAL PRIMARY KEY,
+            slug VARCHAR(50) UNIQUE NOT NULL,
+            name VARCHAR(50) NOT NULL,
+            icon_url TEXT,
+            coins INTEGER NOT NULL CHECK (coins > 0),
+            sort_order INTEGER DEFAULT 0,
+            is_active BOOLEAN DEFAULT TRUE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `INSERT INTO gifts (slug, name, coins, sort_order) VALUES
+            ('rose', 'Rose', 10, 1),
+            ('heart', 'Heart', 25, 2),
+            ('coffee', 'Coffee', 40, 3),
+            ('teddy-bear', 'Teddy Bear', 50, 4),
+            ('chocolates', 'Chocolates', 75, 5),
+            ('bouquet', 'Bouquet', 100, 6),
+            ('champagne', 'Champagne', 250, 7),
+            ('diamond-ring', 'Diamond Ring', 1000, 8)
+        ON CONFLICT (slug) DO NOTHING`,
+        
+        // Coin wallets and their history. Purchases carry the provider
+        // payment as reference, so each is credited once.
+        `CREATE TABLE IF NOT EXISTS coin_wallets (
+            user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+            balance INTEGER NOT NULL DEFAULT 0 CHECK (balance >= 0),
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        // message_id isn't a foreign key: messages are created further down
+        `CREATE TABLE IF NOT EXISTS sent_gifts (
+            id SERIAL PRIMARY KEY,
+            gift_id INTEGER NOT NULL REFERENCES gifts(id),
+            sender_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            recipient_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            message_id INTEGER,
+            note VARCHAR(200),
+            coins INTEGER NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_sent_gifts_recipient ON sent_gifts(recipient_id, created_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_sent_gifts_sender ON sent_gifts(sender_id, created_at DESC)`,
+        `CREATE TABLE IF NOT EXISTS coin_transactions (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            amount INTEGER NOT NULL,
+            balance_after INTEGER NOT NULL,
+            kind VARCHAR(20) NOT NULL,
+            sent_gift_id INTEGER REFERENCES sent_gifts(id) ON DELETE SET NULL,
+            reference VARCHAR(255) UNIQUE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_coin_transactions_user ON coin_transactions(user_id, id DESC)`,
+        
+        // Referral program: one invite code per user, and the signups made
+        // with them. signup_ip and device_id feed the self-referral checks.
+        `CREATE TABLE IF NOT EXISTS referral_codes (
+            user_id INTEGER PRIMARY KEY REFERENCES users(id) ON DELETE CASCADE,
+            code VARCHAR(20) NOT NULL UNIQUE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE TABLE IF NOT EXISTS referrals (
+            id SERIAL PRIMARY KEY,
+            referrer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            invitee_id INTEGER NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+            code VARCHAR(20) NOT NULL,
+            status VARCHAR(20) NOT NULL DEFAULT 'pending',
+            reject_reason VARCHAR(50),
+            signup_ip VARCHAR(45),
+            device_id VARCHAR(255),
+            verified_at TIMESTAMP,
+            profile_completed_at TIMESTAMP,
+            rewarded_at TIMESTAMP,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_referrals_referrer ON referrals(referrer_id, created_at DESC)`,
+        
+        // Onboarding steps each user has completed
+        `CREATE TABLE IF NOT EXISTS onboarding_steps (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            step VARCHAR(20) NOT NULL,
+            completed_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, step)
+        )`,
+        
+        // Contact sync: each user's phone number is matched by its SHA-256, the
+        // same hash clients upload their address book as
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS phone_hash VARCHAR(64)`,
+        `CREATE OR REPLACE FUNCTION set_phone_hash() RETURNS trigger AS $$
+        BEGIN
+            NEW.phone_hash := CASE WHEN NEW.phone IS NULL OR NEW.phone = '' THEN NULL
+                ELSE encode(sha256(convert_to(NEW.phone, 'UTF8')), 'hex') END;
+            RETURN NEW;
+        END;
+        $$ LANGUAGE plpgsql`,
+        `DROP TRIGGER IF EXISTS users_phone_hash ON users`,
+        `CREATE TRIGGER users_phone_hash BEFORE INSERT OR UPDATE OF phone ON users
+            FOR EACH ROW EXECUTE FUNCTION set_phone_hash()`,
+        `UPDATE users SET phone_hash = encode(sha256(convert_to(phone, 'UTF8')), 'hex')
+            WHERE phone IS NOT NULL AND phone <> '' AND phone_hash IS NULL`,
+        `CREATE INDEX IF NOT EXISTS idx_users_phone_hash ON users(phone_hash) WHERE phone_hash IS NOT NULL`,
+        `CREATE TABLE IF NOT EXISTS contact_hashes (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            phone_hash VARCHAR(64) NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, phone_hash)
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_contact_hashes_phone_hash ON contact_hashes(phone_hash)`,
+        
+        // Presence, updated by the WebSocket hub and throttled on authenticated requests
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS last_active TIMESTAMP`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS last_seen TIMESTAMP`,
+        `ALTER TABLE users ADD COLUMN IF NOT EXISTS is_online BOOLEAN DEFAULT FALSE`,
+        
+        // Profile photo gallery; the primary photo is also users.profile_picture
+        `CREATE TABLE IF NOT EXISTS profile_photos (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            url TEXT NOT NULL,
+            position INTEGER NOT NULL DEFAULT 0,
+            is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_profile_photos_user ON profile_photos(user_id, position)`,
+        `CREATE UNIQUE INDEX IF NOT EXISTS idx_profile_photos_primary ON profile_photos(user_id) WHERE is_primary`,
+        `INSERT INTO profile_photos (user_id, url, position, is_primary)
+            SELECT u.id, u.profile_picture, 0, TRUE FROM users u
+            WHERE u.profile_picture IS NOT NULL AND u.profile_picture <> ''
+            AND NOT EXISTS (SELECT 1 FROM profile_photos pp WHERE pp.user_id = u.id)`,
+        
+        
+        
+        // Create indexes
+        `CREATE INDEX IF NOT EXISTS idx_users_email ON users(email)`,
+        `CREATE INDEX IF NOT EXISTS idx_users_username ON users(username)`,
+        `CREATE INDEX IF NOT EXISTS idx_users_location ON users(latitude, longitude) WHERE latitude IS NOT NULL`,
+        `CREATE INDEX IF NOT EXISTS idx_sessions_token ON sessions(token)`,
+        `CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions(user_id)`,
+        
+        // Posts indexes
+        `CREATE INDEX IF NOT EXISTS idx_posts_user_id ON posts(user_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_posts_created_at ON posts(created_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_posts_visibility ON posts(visibility)`,
+        `CREATE INDEX IF NOT EXISTS idx_post_media_post_id ON post_media(post_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_post_likes_post_id ON post_likes(post_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_post_likes_user_id ON post_likes(user_id)`,
+        
+        // Reposts reference the original post
+        `ALTER TABLE posts ADD COLUMN IF NOT EXISTS original_post_id INTEGER REFERENCES posts(id) ON DELETE SET NULL`,
+        `CREATE INDEX IF NOT EXISTS idx_posts_original_post_id ON posts(original_post_id) WHERE original_post_id IS NOT NULL`,
+        
+        // Saved posts (bookmarks), optionally grouped into named collections
+        `CREATE TABLE IF NOT EXISTS saved_posts (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            post_id INTEGER NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+            collection VARCHAR(50) NOT NULL DEFAULT '',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, post_id)
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_saved_posts_user_collection ON saved_posts(user_id, collection, created_at DESC)`,
+        
+        // OTP lookups only ever read unverified rows, and cleanup scans by expiry.
+        // The otps table predates these migrations, so skip when it is absent.
+        `DO $$
+        BEGIN
+            IF to_regclass('otps') IS NOT NULL THEN
+                CREATE INDEX IF NOT EXISTS idx_otps_user_type_active ON otps(user_id, type, created_at DESC) WHERE verified = false;
+                CREATE INDEX IF NOT EXISTS idx_otps_recipient_type_active ON otps(recipient, type, created_at DESC) WHERE verified = false;
+                CREATE INDEX IF NOT EXISTS idx_otps_expires_at ON otps(expires_at);
+                CREATE INDEX IF NOT EXISTS idx_otps_verified_at ON otps(verified_at) WHERE verified = true;
+            END IF;
+        END $$`,
+        
+        // Canonical interests catalog; profiles should store these names
+        `CREATE TABLE IF NOT EXISTS interests (
+            id SERIAL PRIMARY KEY,
+            slug VARCHAR(60) UNIQUE NOT NULL,
+            name VARCHAR(60) NOT NULL,
+            category VARCHAR(40),
+            aliases TEXT[] DEFAULT '{}',
+            is_active BOOLEAN DEFAULT TRUE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `INSERT INTO interests (slug, name, category, aliases) VALUES
+            ('music', 'Music', 'arts', ARRAY['songs']),
+            ('hip-hop', 'Hip-Hop', 'arts', ARRAY['hip hop', 'rap']),
+            ('afrobeats', 'Afrobeats', 'arts', ARRAY['afrobeat', 'afro']),
+            ('movies', 'Movies', 'arts', ARRAY['film', 'cinema']),
+            ('photography', 'Photography', 'arts', ARRAY['photos']),
+            ('art', 'Art', 'arts', ARRAY['painting', 'drawing']),
+            ('dancing', 'Dancing', 'arts', ARRAY['dance']),
+            ('reading', 'Reading', 'lifestyle', ARRAY['books']),
+            ('writing', 'Writing', 'arts', ARRAY['poetry']),
+            ('fashion', 'Fashion', 'lifestyle', ARRAY['style']),
+            ('cooking', 'Cooking', 'food', ARRAY['chef']),
+            ('foodie', 'Foodie', 'food', ARRAY['food', 'eating out']),
+            ('travel', 'Travel', 'lifestyle', ARRAY['travelling', 'traveling']),
+            ('fitness', 'Fitness', 'sports', ARRAY['gym', 'workout']),
+            ('yoga', 'Yoga', 'sports', '{}'),
+            ('running', 'Running', 'sports', ARRAY['jogging']),
+            ('football', 'Football', 'sports', ARRAY['soccer']),
+            ('basketball', 'Basketball', 'sports', '{}'),
+            ('gaming', 'Gaming', 'technology', ARRAY['video games', 'games']),
+            ('technology', 'Technology', 'technology', ARRAY['tech']),
+            ('coding', 'Coding', 'technology', ARRAY['programming']),
+            ('entrepreneurship', 'Entrepreneurship', 'career', ARRAY['business', 'startups']),
+            ('volunteering', 'Volunteering', 'lifestyle', ARRAY['charity']),
+            ('nature', 'Nature', 'outdoors', ARRAY['outdoors']),
+            ('hiking', 'Hiking', 'outdoors', ARRAY['trekking']),
+            ('camping', 'Camping', 'outdoors', '{}'),
+            ('pets', 'Pets', 'lifestyle', ARRAY['dogs', 'cats']),
+            ('wine', 'Wine', 'food', '{}'),
+            ('coffee', 'Coffee', 'food', '{}'),
+            ('spirituality', 'Spirituality', 'lifestyle', ARRAY['faith', 'religion']),
+            ('meditation', 'Meditation', 'lifestyle', ARRAY['mindfulness']),
+            ('comedy', 'Comedy', 'arts', ARRAY['stand-up']),
+            ('anime', 'Anime', 'arts', ARRAY['manga']),
+            ('board-games', 'Board Games', 'lifestyle', ARRAY['chess']),
+            ('languages', 'Languages', 'lifestyle', ARRAY['language learning'])
+        ON CONFLICT (slug) DO NOTHING`,
+        `CREATE INDEX IF NOT EXISTS idx_users_interests ON users USING GIN (interests)`,
+        // Map free-text profile interests onto catalog names, matching name,
+        // slug or alias the way the interests service normalizes them. Values
+        // with no match are kept as they are.
+        `CREATE OR REPLACE FUNCTION interest_key(value TEXT) RETURNS TEXT AS $$
+            SELECT TRIM(REGEXP_REPLACE(TRANSLATE(LOWER(value), '-_', '  '), '\s+', ' ', 'g'))
+        $$ LANGUAGE SQL IMMUTABLE`,
+        `UPDATE users u
+        SET interests = m.interests
+        FROM (
+            SELECT v.user_id, ARRAY_AGG(v.interest ORDER BY v.ord) AS interests
+            FROM (
+                SELECT DISTINCT ON (t.user_id, COALESCE(c.name, TRIM(t.value)))
+                    t.user_id, COALESCE(c.name, TRIM(t.value)) AS interest, t.ord
+                FROM (
+                    SELECT src.id AS user_id, i.value, i.ord
+                    FROM users src, UNNEST(src.interests) WITH ORDINALITY AS i(value, ord)
+                ) t
+                LEFT JOIN LATERAL (
+                    SELECT name FROM interests
+                    WHERE interest_key(name) = interest_key(t.value)
+                       OR interest_key(slug) = interest_key(t.value)
+                       OR interest_key(t.value) IN (SELECT interest_key(a) FROM UNNEST(aliases) AS a)
+                    ORDER BY interest_key(name) = interest_key(t.value) DESC, id
+                    LIMIT 1
+                ) c ON TRUE
+                WHERE TRIM(t.value) <> ''
+                ORDER BY t.user_id, COALESCE(c.name, TRIM(t.value)), t.ord
+            ) v
+            GROUP BY v.user_id
+        ) m
+        WHERE u.id = m.user_id AND u.interests IS DISTINCT FROM m.interests`,
+        
+        // Icebreaker prompts catalog and up to three answers per user
+        `CREATE TABLE IF NOT EXISTS profile_prompts (
+            id SERIAL PRIMARY KEY,
+            slug VARCHAR(60) UNIQUE NOT NULL,
+            text VARCHAR(150) NOT NULL,
+            category VARCHAR(40),
+            is_active BOOLEAN DEFAULT TRUE,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `INSERT INTO profile_prompts (slug, text, category) VALUES
+            ('perfect-sunday', 'My perfect Sunday', 'lifestyle'),
+            ('weekend-plans', 'This weekend I''m probably', 'lifestyle'),
+            ('go-to-karaoke', 'My go-to karaoke song', 'fun'),
+            ('unpopular-opinion', 'My most unpopular opinion', 'fun'),
+            ('best-travel-story', 'My best travel story', 'travel'),
+            ('dream-destination', 'The next place I want to visit', 'travel'),
+            ('comfort-food', 'My comfort food', 'food'),
+            ('jollof-debate', 'Whose jollof is best', 'food'),
+            ('green-flag', 'A green flag I look for', 'dating'),
+            ('first-date', 'The ideal first date', 'dating'),
+            ('love-language', 'My love language', 'dating'),
+            ('looking-for', 'I''m looking for someone who', 'dating'),
+            ('simple-pleasures', 'A simple pleasure I love', 'personality'),
+            ('proud-of', 'Something I''m proud of', 'personality'),
+            ('learning', 'Currently learning', 'personality'),
+            ('never-shut-up', 'I could talk for hours about', 'personality')
+        ON CONFLICT (slug) DO NOTHING`,
+        `CREATE TABLE IF NOT EXISTS user_prompt_answers (
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            prompt_id INTEGER NOT NULL REFERENCES profile_prompts(id) ON DELETE CASCADE,
+            answer VARCHAR(150) NOT NULL,
+            position SMALLINT NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (user_id, prompt_id)
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_comments_post_id ON comments(post_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_comments_parent_id ON comments(parent_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_follows_follower_id ON follows(follower_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_follows_following_id ON follows(following_id)`,
+    }
+    
+    for i, migration := range migrations {
+        log.Printf("   - Running migration %d/%d...", i+1, len(migrations))
+        if _, err := db.Exec(migration); err != nil {
+            // Don't fail on duplicate key errors (indexes already exist)
+            if !strings.Contains(err.Error(), "already exists") {
+                return fmt.Errorf("migration %d failed: %w", i+1, err)
+            }
+            log.Printf("   - Migration %d skipped (already exists)", i+1)
+        }
+    }
+
+    // Add messaging tables
+    log.Println("   - Running messaging migrations...")
+    messagingMigrations := []string{
+        // Conversations table
+        `CREATE TABLE IF NOT EXISTS conversations (
+            id SERIAL PRIMARY KEY,
+            type VARCHAR(20) DEFAULT 'direct',
+            name VARCHAR(100),
+            avatar_url TEXT,
+            created_by INTEGER REFERENCES users(id),
+            is_active BOOLEAN DEFAULT TRUE,
+            last_message_at TIMESTAMP WITH TIME ZONE,
+            last_message_preview TEXT,
+            metadata JSONB DEFAULT '{}',
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        // Conversation participants
+        `CREATE TABLE IF NOT EXISTS conversation_participants (
+            id SERIAL PRIMARY KEY,
+            conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            role VARCHAR(20) DEFAULT 'member',
+            joined_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            left_at TIMESTAMP WITH TIME ZONE,
+            last_read_at TIMESTAMP WITH TIME ZONE,
+            last_read_message_id INTEGER,
+            is_muted BOOLEAN DEFAULT FALSE,
+            muted_until TIMESTAMP WITH TIME ZONE,
+            is_archived BOOLEAN DEFAULT FALSE,
+            notification_preference VARCHAR(20) DEFAULT 'all',
+            unread_count INTEGER DEFAULT 0,
+            is_typing BOOLEAN DEFAULT FALSE,
+            typing_started_at TIMESTAMP WITH TIME ZONE,
+            CONSTRAINT unique_conversation_participant UNIQUE(conversation_id, user_id)
+        )`,
+        
+        // Messages table
+        `CREATE TABLE IF NOT EXISTS messages (
+            id SERIAL PRIMARY KEY,
+            conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+            sender_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            parent_message_id INTEGER REFERENCES messages(id),
+            content TEXT,
+            message_type VARCHAR(20) DEFAULT 'text',
+            media_url TEXT,
+            media_thumbnail_url TEXT,
+            media_size INTEGER,
+            media_duration INTEGER,
+            metadata JSONB DEFAULT '{}',
+            is_edited BOOLEAN DEFAULT FALSE,
+            edited_at TIMESTAMP WITH TIME ZONE,
+            is_deleted BOOLEAN DEFAULT FALSE,
+            deleted_at TIMESTAMP WITH TIME ZONE,
+            delivered_at TIMESTAMP WITH TIME ZONE,
+            expires_at TIMESTAMP WITH TIME ZONE,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        // Message receipts
+        `CREATE TABLE IF NOT EXISTS message_receipts (
+            id SERIAL PRIMARY KEY,
+            message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            delivered_at TIMESTAMP WITH TIME ZONE,
+            read_at TIMESTAMP WITH TIME ZONE,
+            CONSTRAINT unique_message_receipt UNIQUE(message_id, user_id)
+        )`,
+        
+        // Message reactions
+        `CREATE TABLE IF NOT EXISTS message_reactions (
+            id SERIAL PRIMARY KEY,
+            message_id INTEGER NOT NULL REFERENCES messages(id) ON DELETE CASCADE,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            reaction VARCHAR(50) NOT NULL,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT unique_message_reaction UNIQUE(message_id, user_id, reaction)
+        )`,
+        
+        // Push tokens
+        `CREATE TABLE IF NOT EXISTS push_tokens (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            token TEXT NOT NULL UNIQUE,
+            platform VARCHAR(20) NOT NULL,
+            device_id VARCHAR(255),
+            is_active BOOLEAN DEFAULT TRUE,
+            last_used_at TIMESTAMP WITH TIME ZONE,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        
+        // Blocked conversations
+        `CREATE TABLE IF NOT EXISTS blocked_conversations (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            blocked_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            blocked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            CONSTRAINT unique_blocked_conversation UNIQUE(user_id, blocked_user_id)
+        )`,
+        
+        // Blocks from every module live in blocked_users; carry over blocks
+        // made from messaging and from the original schema's blocks table
+        `CREATE TABLE IF NOT EXISTS blocked_users (
+            id SERIAL PRIMARY KEY,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            blocked_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            blocked_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE UNIQUE INDEX IF NOT EXISTS idx_blocked_users_pair ON blocked_users(user_id, blocked_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_blocked_users_blocked ON blocked_users(blocked_id)`,
+        `INSERT INTO blocked_users (user_id, blocked_id, blocked_at)
+            SELECT user_id, blocked_user_id, blocked_at FROM blocked_conversations
+            WHERE user_id <> blocked_user_id
+            ON CONFLICT (user_id, blocked_id) DO NOTHING`,
+        `DO $$ BEGIN IF to_regclass('blocks') IS NOT NULL THEN
+            INSERT INTO blocked_users (user_id, blocked_id, blocked_at)
+                SELECT blocker_id, blocked_id, created_at FROM blocks
+                ON CONFLICT (user_id, blocked_id) DO NOTHING;
+        END IF; END $$`,
+        
+        // Indexes for messaging
+        `CREATE INDEX IF NOT EXISTS idx_conversations_updated ON conversations(updated_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_conversations_last_message ON conversations(last_message_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_participants_conversation ON conversation_participants(conversation_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_participants_user ON conversation_participants(user_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_messages_conversation ON messages(conversation_id, created_at DESC)`,
+        `CREATE INDEX IF NOT EXISTS idx_messages_sender ON messages(sender_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_receipts_message ON message_receipts(message_id)`,
+        `CREATE INDEX IF NOT EXISTS idx_push_tokens_user ON push_tokens(user_id)`,
+        
+        // Client-generated idempotency keys, so a resent WebSocket message isn't stored twice
+        `ALTER TABLE messages ADD COLUMN IF NOT EXISTS client_message_id VARCHAR(64)`,
+        `CREATE UNIQUE INDEX IF NOT EXISTS idx_messages_client_id ON messages(sender_id, client_message_id)
+            WHERE client_message_id IS NOT NULL`,
+        
+        // Pinned messages per conversation, and conversations pinned per participant
+        `CREATE TABLE IF NOT EXISTS pinned_messages (
+            id SERIAL PRIMARY KEY,
+            conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+            message_id INTEGER NOT NULL UNIQUE REFERENCES messages(id) ON DELETE CASCADE,
+            pinned_by INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            pinned_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_pinned_messages_conversation ON pinned_messages(conversation_id, pinned_at DESC)`,
+        `ALTER TABLE conversation_participants ADD COLUMN IF NOT EXISTS pinned_at TIMESTAMP WITH TIME ZONE`,
+        
+        // Conversation exports too large to stream, generated by the export job
+        `CREATE TABLE IF NOT EXISTS conversation_exports (
+            id SERIAL PRIMARY KEY,
+            conversation_id INTEGER NOT NULL REFERENCES conversations(id) ON DELETE CASCADE,
+            user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+            format VARCHAR(10) NOT NULL,
+            status VARCHAR(20) NOT NULL DEFAULT 'pending',
+            message_count INTEGER DEFAULT 0,
+            file_url TEXT,
+            error TEXT,
+            created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+            started_at TIMESTAMP WITH TIME ZONE,
+            completed_at TIMESTAMP WITH TIME ZONE
+        )`,
+        `CREATE INDEX IF NOT EXISTS idx_conversation_exports_queue ON conversation_exports(created_at)
+            WHERE status IN ('pending', 'running')`,
+        
+        // Push tokens remember which provider issued them
+        `ALTER TABLE push_tokens ADD COLUMN IF NOT EXISTS token_type VARCHAR(20) NOT NULL DEFAULT 'fcm'`,
+    }
+    
+    // Run messaging migrations
+    for i, migration := range messagingMigrations {
+        log.Printf("   - Running messaging migration %d/%d...", i+1, len(messagingMigrations))
+        if _, err := db.Exec(migration); err != nil {
+            if !strings.Contains(err.Error(), "already exists") {
+                return fmt.Errorf("messaging migration %d failed: %w", i+1, err)
+            }
+            log.Printf("   - Messaging migration %d skipped (already exists)", i+1)
+        }
+    }
+    
+    log.Println("   ✅ All migrations executed successfully")
+    return nil
+}
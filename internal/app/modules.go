@@ -0,0 +1,861 @@
+// internal/app/modules.go
+// Constructors for each module, in the order Start runs them
+
+package app
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "strings"
+    "time"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/jmoiron/sqlx"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/activity"
+    "github.com/imadgeboyega/kiekky-backend/internal/admin"
+    "github.com/imadgeboyega/kiekky-backend/internal/analytics"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/billing"
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/cache"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/counters"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/health"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/media"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/outbox"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/sms"
+    "github.com/imadgeboyega/kiekky-backend/internal/contacts"
+    "github.com/imadgeboyega/kiekky-backend/internal/dating"
+    "github.com/imadgeboyega/kiekky-backend/internal/erasure"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/gifts"
+    "github.com/imadgeboyega/kiekky-backend/internal/onboarding"
+    "github.com/imadgeboyega/kiekky-backend/internal/referrals"
+    "github.com/imadgeboyega/kiekky-backend/internal/interests"
+    "github.com/imadgeboyega/kiekky-backend/internal/messaging"
+    "github.com/imadgeboyega/kiekky-backend/internal/moderation"
+    notifications "github.com/imadgeboyega/kiekky-backend/internal/notification"
+    "github.com/imadgeboyega/kiekky-backend/internal/otp"
+    "github.com/imadgeboyega/kiekky-backend/internal/password"
+    "github.com/imadgeboyega/kiekky-backend/internal/posts"
+    "github.com/imadgeboyega/kiekky-backend/internal/profile"
+    "github.com/imadgeboyega/kiekky-backend/internal/prompts"
+    "github.com/imadgeboyega/kiekky-backend/internal/stories"
+    "github.com/imadgeboyega/kiekky-backend/internal/suppression"
+    "github.com/imadgeboyega/kiekky-backend/internal/textfilter"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+    "github.com/imadgeboyega/kiekky-backend/internal/verification"
+)
+
+// initShared builds the services several modules depend on: the users
+// client, OTPs, email suppressions, blocks, signed media URLs, content moderation, text filtering
+// and analytics
+func (a *Application) initShared(ctx context.Context) error {
+    cfg := a.Config
+    db := a.sqlxDB()
+
+    // Addresses that bounced or complained aren't emailed by OTPs or
+    // notifications
+    a.Suppressions = suppression.NewService(suppression.NewPostgresRepository(db))
+    if cfg.Email.SendGridWebhookPublicKey != "" {
+        provider, err := suppression.NewSendGridProvider(cfg.Email.SendGridWebhookPublicKey)
+        if err != nil {
+            return err
+        }
+        a.Suppressions.RegisterProvider(provider)
+    }
+    if cfg.Email.SESTopicARN != "" {
+        a.Suppressions.RegisterProvider(suppression.NewSESProvider(cfg.Email.SESTopicARN))
+    }
+
+    // OTP delivery
+    var emailProvider otp.EmailProvider
+    if cfg.Email.Provider == "mock" {
+        emailProvider = otp.NewMockEmailProvider()
+        log.Println("   ⚠️  Using mock email provider (development mode)")
+    } else {
+        emailProvider = a.newOTPEmailProvider(cfg.Email.Provider)
+        log.Printf("   ✅ Using %s for emails", cfg.Email.Provider)
+        if cfg.Email.Fallback != "" {
+            emailProvider = otp.NewFailoverEmailProvider(emailProvider, a.newOTPEmailProvider(cfg.Email.Fallback), cfg.Email.FailoverCooldown)
+            log.Printf("   ✅ Failing over to %s for emails", cfg.Email.Fallback)
+        }
+    }
+    emailProvider = otp.NewSuppressedEmailProvider(emailProvider, a.Suppressions)
+
+    // SMS is shared with notifications and routed between providers
+    a.smsRouter = a.newSMSRouter()
+    var smsProvider otp.SMSProvider
+    if a.smsRouter != nil {
+        smsProvider = otp.NewRoutedSMSProvider(a.smsRouter)
+        log.Printf("   ✅ Using %s for SMS", strings.Join(a.smsRouter.Providers(), ", "))
+    } else {
+        smsProvider = otp.NewMockSMSProvider()
+        log.Println("   ⚠️  Using mock SMS provider (development mode)")
+    }
+
+    a.OTP = otp.NewService(otp.NewPostgresRepository(db), a.Redis, emailProvider, smsProvider, &otp.OTPConfig{
+        Length:      cfg.OTP.Length,
+        Expiry:      cfg.OTP.Expiry,
+        MaxAttempts: cfg.OTP.MaxAttempts,
+        RateLimit: otp.RateLimitConfig{
+            MaxRequests:     cfg.OTP.ResendMax,
+            MaxPerIP:        cfg.OTP.MaxPerIP,
+            MaxPerDevice:    cfg.OTP.MaxPerDevice,
+            Window:          cfg.OTP.ResendWindow,
+            LockoutAfter:    cfg.OTP.LockoutAfter,
+            LockoutDuration: cfg.OTP.LockoutDuration,
+            MaxLockout:      cfg.OTP.MaxLockout,
+        },
+    })
+    log.Println("   ✅ OTP system initialized")
+
+    // Other modules read users through one client rather than querying the
+    // users table themselves
+    a.Users = users.NewClient(db)
+
+    // Blocks are shared by every module, so one block applies everywhere
+    a.Blocks = blocks.NewService(blocks.NewRepository(db), a.Redis)
+
+    // Signed URLs for media behind access-controlled resources
+    mediaOrigins := media.S3Origins(cfg.Storage.S3Bucket, cfg.Storage.S3Region, cfg.Server.BaseURL)
+    switch cfg.Storage.MediaURLSigning {
+    case "cloudfront":
+        signer, err := media.NewCloudFrontSigner(cfg.Storage.CloudFront.Domain, cfg.Storage.CloudFront.KeyPairID, cfg.Storage.CloudFront.PrivateKeyPath, mediaOrigins)
+        if err != nil {
+            return fmt.Errorf("failed to initialize CloudFront signing: %w", err)
+        }
+        media.SetSigner(signer, cfg.Storage.SignedMediaURLTTL)
+        log.Println("   ✅ Private media served through signed CloudFront URLs")
+    case "s3":
+        signer, err := media.NewS3Signer(cfg.Storage.S3Bucket, cfg.Storage.S3Region, mediaOrigins)
+        if err != nil {
+            return fmt.Errorf("failed to initialize S3 URL signing: %w", err)
+        }
+        media.SetSigner(signer, cfg.Storage.SignedMediaURLTTL)
+        log.Println("   ✅ Private media served through presigned S3 URLs")
+    }
+
+    // Content moderation. Flagged uploads are quarantined privately, like
+    // selfies, until an admin reviews them. Without a provider uploads go
+    // straight through but violation counters are still kept.
+    var err error
+    switch cfg.Moderation.Provider {
+    case "mock":
+        a.moderationProvider = moderation.NewMockProvider()
+    case "rekognition":
+        a.moderationProvider, err = moderation.NewRekognitionProvider(cfg.Storage.AWSRegion)
+        if err != nil {
+            return fmt.Errorf("failed to initialize Rekognition moderation: %w", err)
+        }
+    }
+
+    var quarantineStore moderation.QuarantineStore
+    if cfg.Storage.UseS3 {
+        quarantineStore, err = moderation.NewS3QuarantineStore(cfg.Storage.S3Bucket, cfg.Storage.S3Region)
+        if err != nil {
+            log.Printf("⚠️  Warning: S3 quarantine store unavailable: %v", err)
+        }
+    } else {
+        quarantineStore = moderation.NewLocalQuarantineStore(cfg.Moderation.QuarantineDir)
+    }
+
+    a.Moderation = moderation.NewService(moderation.NewRepository(db), quarantineStore, a.moderationProvider, moderation.Thresholds{
+        Review:       cfg.Moderation.ReviewThreshold,
+        Reject:       cfg.Moderation.RejectThreshold,
+        BlurRejected: cfg.Moderation.BlurRejected,
+    })
+    if a.moderationProvider != nil {
+        log.Printf("   ✅ Uploads moderated with %s provider", cfg.Moderation.Provider)
+    }
+
+    // Profanity and spam filtering; violations feed moderation's counters
+    if cfg.TextFilter.Enabled {
+        words := textfilter.DefaultWords
+        if cfg.TextFilter.WordListDir != "" {
+            words, err = textfilter.LoadWordLists(cfg.TextFilter.WordListDir)
+            if err != nil {
+                return fmt.Errorf("failed to load text filter word lists: %w", err)
+            }
+        }
+        filter := textfilter.NewService(textfilter.Config{
+            Words:    words,
+            Mode:     textfilter.Mode(cfg.TextFilter.Mode),
+            MaxLinks: cfg.TextFilter.MaxLinks,
+        })
+        filter.SetRecorder(a.Moderation)
+        a.TextFilter = filter
+        log.Printf("   ✅ Text filter enabled (%s mode)", cfg.TextFilter.Mode)
+    }
+
+    // Product analytics; modules report funnel events to it
+    a.Analytics = analytics.NewService(analytics.NewPostgresRepository(db))
+
+    // Read-model cache for feeds, profiles and conversation lists; every
+    // lookup misses without Redis
+    a.Cache = cache.New(a.Redis)
+
+    // Transactions for flows that write through several repositories
+    a.UnitOfWork = database.NewUnitOfWork(db)
+
+    // Events for other modules, stored with the change they describe
+    a.Outbox = outbox.New(db)
+    a.Events = events.NewBus(a.Outbox)
+
+    return nil
+}
+
+func (a *Application) initProfile(ctx context.Context) error {
+    uploader := a.moderated(a.newMediaUploader("profile"), "profile")
+
+    profileService := profile.NewService(profile.NewPostgresRepository(a.sqlxDB()), uploader, a.Blocks)
+    if a.TextFilter != nil {
+        profileService.SetTextFilter(a.TextFilter)
+    }
+    profileService.SetTracker(a.Analytics)
+    profileService.SetCache(a.Cache)
+    profileService.SetEvents(a.Events)
+    profileService.SetCompletionThreshold(a.Config.Profile.CompletionThreshold)
+    a.Profile = profileService
+
+    // Attach held profile photos once they are approved
+    a.Moderation.AddListener(func(ctx context.Context, item *moderation.Item) {
+        if item.Source != "profile" || item.PublishedURL == nil {
+            return
+        }
+        if err := profileService.ApplyApprovedPhoto(ctx, item.UserID, item.Folder, *item.PublishedURL); err != nil {
+            log.Printf("Failed to apply approved photo for user %d: %v", item.UserID, err)
+        }
+    })
+
+    // Onboarding follows new users from verification through profile setup,
+    // photos and dating preferences, and gates messaging and swiping
+    a.Onboarding = onboarding.NewService(onboarding.NewRepository(a.sqlxDB()))
+    a.Onboarding.SetTracker(a.Analytics)
+
+    // Contact sync finds people users know and feeds discovery
+    a.Contacts = contacts.NewService(contacts.NewRepository(a.sqlxDB()))
+    a.Contacts.SetEvents(a.Events)
+    profileService.SetContacts(a.Contacts)
+
+    log.Println("✅ Profile system initialized")
+    return nil
+}
+
+func (a *Application) initAuth(ctx context.Context) error {
+    cfg := a.Config
+
+    authService := auth.NewService(auth.NewPostgresRepository(a.DB), a.Redis, a.OTP, &auth.Config{
+        JWTSecret:          cfg.Auth.JWTSecret,
+        AccessTokenExpiry:  cfg.Auth.AccessTokenExpiry,
+        RefreshTokenExpiry: cfg.Auth.RefreshTokenExpiry,
+        BCryptCost:         cfg.Auth.BCryptCost,
+        Enable2FA:          cfg.Auth.Enable2FA,
+        TOTPIssuer:         cfg.Auth.TOTPIssuer,
+        TOTPEncryptionKey:  cfg.Auth.TOTPEncryptionKey,
+
+        MaxLoginAttempts:    cfg.Auth.LoginAttemptsMax,
+        LoginAttemptsWindow: cfg.Auth.LoginAttemptsWindow,
+        StepUpAfterFailures: cfg.Auth.LoginStepUpAfter,
+        LockDuration:        cfg.Auth.AccountLockDuration,
+        BaseURL:             cfg.Server.BaseURL,
+
+        UsernameChangeCooldown: cfg.Auth.UsernameChangeCooldown,
+    })
+
+    var breachChecker password.BreachChecker
+    if cfg.Password.BreachCheck {
+        breachChecker = password.NewRangeClient(cfg.Password.BreachAPIURL, cfg.Password.BreachTimeout)
+    }
+    authService.SetPasswordPolicy(password.NewPolicy(password.Config{
+        MinLength: cfg.Password.MinLength,
+        MinScore:  cfg.Password.MinScore,
+    }, breachChecker))
+    authService.SetTracker(a.Analytics)
+    authService.SetUnitOfWork(a.UnitOfWork)
+    authService.SetEvents(a.Events)
+
+    a.Auth = authService
+    a.authMiddleware = auth.NewMiddleware(authService)
+
+    log.Println("✅ Authentication system initialized")
+    return nil
+}
+
+func (a *Application) initPosts(ctx context.Context) error {
+    cfg := a.Config
+
+    uploadService := a.newPostsUploader()
+
+    a.Posts = posts.NewService(posts.NewRepository(a.DB), uploadService)
+    a.Posts.SetCache(a.Cache)
+    a.Posts.SetUnitOfWork(a.UnitOfWork)
+    a.Posts.SetEvents(a.Events)
+    a.Posts.SetUsers(a.Users)
+    if cfg.Places.Geocoder == "nominatim" {
+        a.Posts.SetGeocoder(posts.NewNominatimGeocoder(cfg.Places.GeocoderURL, cfg.Places.GeocoderUserAgent))
+        log.Println("   ✅ Place search backed by Nominatim")
+    }
+    if a.moderationProvider != nil {
+        a.Posts.SetMediaUploader(moderation.Wrap(posts.ContextUploader{UploadService: uploadService}, a.Moderation, "posts"))
+    }
+    if a.TextFilter != nil {
+        a.Posts.SetTextFilter(a.TextFilter)
+    }
+
+    log.Println("✅ Posts module initialized")
+    return nil
+}
+
+// initStories builds stories and the smaller profile-adjacent catalogs
+func (a *Application) initStories(ctx context.Context) error {
+    db := a.sqlxDB()
+
+    // Approximate view counters (Redis HyperLogLog, flushed to Postgres)
+    a.ViewCounter = counters.NewViewCounter(a.Redis, db)
+    a.ViewCounter.Register(counters.KindStory, counters.Source{
+        Table:        "story_views",
+        EntityColumn: "story_id",
+        ViewerColumn: "viewer_id",
+    })
+
+    storiesCfg := a.Config.Stories
+    uploader := a.moderated(a.newMediaUploader("stories"), "stories")
+    a.Stories = stories.NewService(stories.NewPostgresRepository(db), uploader, a.ViewCounter, storiesCfg.ExpiryHours, storiesCfg.MaxVideoSeconds)
+    a.Stories.SetEvents(a.Events)
+    a.Stories.SetUnitOfWork(a.UnitOfWork)
+    a.Stories.SetArchiveRetention(time.Duration(storiesCfg.ArchiveDays) * 24 * time.Hour)
+
+    // Archived media is only moved in storage, so it isn't screened again
+    if mover, ok := a.newMediaUploader("story archive").(stories.MediaMover); ok {
+        a.Stories.SetMediaMover(mover)
+    }
+
+    // Video transcoding. Renditions are made from moderated uploads, so they
+    // are stored without another scan.
+    switch storiesCfg.Transcoder {
+    case "ffmpeg":
+        transcoder, err := stories.NewFFmpegTranscoder(a.newMediaUploader("story renditions"))
+        if err != nil {
+            log.Printf("⚠️  Story video transcoding disabled: %v", err)
+        } else {
+            a.Stories.SetTranscoder(transcoder)
+            log.Println("   ✅ Transcoding story videos with ffmpeg")
+        }
+    case "http":
+        a.Stories.SetTranscoder(stories.NewHTTPTranscoder(storiesCfg.TranscoderURL, storiesCfg.TranscoderSecret))
+        log.Println("   ✅ Transcoding story videos with external service")
+    }
+
+    // Interests catalog (profile editor suggestions); profiles store its names
+    a.Interests = interests.NewService(interests.NewRepository(db), a.Redis)
+    a.Profile.SetInterests(a.Interests)
+
+    // Icebreaker prompts catalog and profile answers
+    a.Prompts = prompts.NewService(prompts.NewRepository(db), a.Redis)
+
+    // Activity stream (who viewed/liked you)
+    a.Activity = activity.NewService(activity.NewPostgresRepository(db))
+
+    log.Println("✅ Stories module initialized")
+    return nil
+}
+
+func (a *Application) initNotifications(ctx context.Context) error {
+    cfg := a.Config
+    repo := notifications.NewPostgresRepository(a.sqlxDB())
+
+    var pushService notifications.PushService
+    var webPushKey string
+    if cfg.Notifications.EnablePush {
+        // Tokens go to the provider that issued them: FCM for Android, web
+        // and iOS builds using Firebase, APNs for iOS tokens from Apple
+        router := notifications.NewPushRouter()
+        fcmService, err := notifications.NewFCMPushService(
+            ctx,
+            cfg.Notifications.FirebaseCredentialsPath,
+            cfg.Notifications.FirebaseCredentialsJSON,
+        )
+        if err != nil {
+            log.Printf("Warning: Failed to initialize FCM push service: %v", err)
+            router.Register(notifications.TokenTypeFCM, notifications.NewMockPushService())
+        } else {
+            router.Register(notifications.TokenTypeFCM, fcmService)
+            log.Println("   ✅ FCM Push service initialized")
+        }
+        if cfg.Notifications.APNSKeyID != "" {
+            if apnsService, err := a.newAPNSPushService(); err != nil {
+                log.Printf("Warning: Failed to initialize APNs push service: %v", err)
+            } else {
+                router.Register(notifications.TokenTypeAPNs, apnsService)
+                log.Println("   ✅ APNs Push service initialized")
+            }
+        }
+        if webPush, err := a.newWebPushService(); err != nil {
+            log.Printf("Warning: Web push disabled: %v", err)
+        } else if webPush != nil {
+            router.Register(notifications.TokenTypeWebPush, webPush)
+            webPushKey = webPush.PublicKey()
+            log.Println("   ✅ Web push service initialized")
+        }
+        pushService = router
+    } else {
+        pushService = notifications.NewMockPushService()
+        log.Println("   📝 Using mock push service (development mode)")
+    }
+
+    if cfg.Notifications.EnableEmail && cfg.Email.Provider != "mock" {
+        emailService, err := a.newNotificationEmailService(cfg.Email.Provider)
+        if err != nil {
+            log.Printf("Warning: Failed to initialize %s email service: %v", cfg.Email.Provider, err)
+            a.emailService = notifications.NewMockEmailService()
+        } else {
+            a.emailService = emailService
+            log.Printf("   ✅ %s email service initialized", cfg.Email.Provider)
+        }
+        if cfg.Email.Fallback != "" && err == nil {
+            if fallback, err := a.newNotificationEmailService(cfg.Email.Fallback); err != nil {
+                log.Printf("Warning: Failed to initialize %s fallback email service: %v", cfg.Email.Fallback, err)
+            } else {
+                a.emailService = notifications.NewFailoverEmailService(a.emailService, fallback, cfg.Email.FailoverCooldown)
+            }
+        }
+    } else {
+        a.emailService = notifications.NewMockEmailService()
+        log.Println("   📝 Using mock email service (development mode)")
+    }
+    a.emailService = notifications.NewSuppressedEmailService(a.emailService, a.Suppressions)
+
+    var smsService notifications.SMSService
+    if cfg.Notifications.EnableSMS && a.smsRouter != nil {
+        smsService = notifications.NewRoutedSMSService(a.smsRouter)
+        log.Println("   ✅ SMS notification service initialized")
+    } else {
+        smsService = notifications.NewMockSMSService()
+        log.Println("   📝 Using mock SMS service (development mode)")
+    }
+
+    if err := notifications.InitializeDefaultTemplates(ctx, repo); err != nil {
+        log.Printf("Warning: Failed to initialize default templates: %v", err)
+    }
+
+    service := notifications.NewService(
+        repo,
+        pushService,
+        a.emailService,
+        smsService,
+        notifications.NewTemplateService(repo),
+    )
+
+    // Email and SMS go to users' real contact details. Deleted or suspended
+    // users are shown as ghosts and never contacted.
+    service.SetUserInfoProvider(notifications.NewUserInfoProvider(a.Users))
+
+    // Nothing is delivered between users with a block
+    service.SetBlocks(a.Blocks)
+
+    // "A and 5 others liked your post" instead of one notification per like
+    service.SetGroupingWindow(cfg.Notifications.GroupWindow)
+    service.SetAppURL(cfg.Server.AppURL)
+    service.SetIOSTokenType(notifications.TokenType(cfg.Notifications.IOSPushProvider))
+    if webPushKey != "" {
+        service.SetWebPushPublicKey(webPushKey)
+    }
+
+    // Providers' delivery reports update the SMS delivery log
+    if a.smsRouter != nil {
+        service.SetSMSCallbacks(a.smsRouter)
+    }
+
+    a.Notifications = service
+
+    // Stories and auth are built first, so hand them the notifier now. Posts
+    // notifications come from its events, see initEvents.
+    a.Stories.SetNotifier(service)
+    a.Auth.SetNotifier(service)
+
+    log.Println("✅ Notifications module initialized")
+    return nil
+}
+
+// initAccounts builds admin user management, photo verification, billing,
+// the gift wallets billing credits, and the referral program paying into them
+func (a *Application) initAccounts(ctx context.Context) error {
+    cfg := a.Config
+    db := a.sqlxDB()
+    appURL := cfg.Server.AppURL
+    var err error
+
+    // Admin user export/import. Exports hold PII, so they go to private S3
+    // objects or a directory that is never served over HTTP.
+    var exportStore admin.ExportStore
+    if cfg.Storage.UseS3 {
+        exportStore, err = admin.NewS3ExportStore(cfg.Storage.S3Bucket, cfg.Storage.S3Region)
+        if err != nil {
+            log.Printf("⚠️  Warning: S3 export store unavailable: %v", err)
+        }
+    } else {
+        exportStore = admin.NewLocalExportStore(cfg.Storage.AdminExportDir)
+    }
+
+    // Account erasure, and the purgers that delete an erased account's
+    // copies. Profile and story media share a store.
+    a.Erasure = erasure.NewService(erasure.NewRepository(db), a.UnitOfWork, a.Events)
+    media := a.newMediaUploader("erased account")
+    a.Erasure.AddMediaPurger(map[string]erasure.FileDeleter{
+        erasure.MediaProfile: media,
+        erasure.MediaStories: media,
+        erasure.MediaPosts:   posts.ContextUploader{UploadService: a.newPostsUploader()},
+    })
+
+    a.Admin = admin.NewService(admin.NewRepository(db), exportStore, admin.NewEmailInviter(a.emailService, appURL), a.Auth, a.Erasure)
+
+    // Photo verification. Selfies are biometric, so like exports they are
+    // kept private and only reachable through the admin review endpoints.
+    var selfieStore verification.SelfieStore
+    if cfg.Storage.UseS3 {
+        selfieStore, err = verification.NewS3SelfieStore(cfg.Storage.S3Bucket, cfg.Storage.S3Region)
+        if err != nil {
+            log.Printf("⚠️  Warning: S3 selfie store unavailable: %v", err)
+        }
+    } else {
+        selfieStore = verification.NewLocalSelfieStore(cfg.Storage.SelfieDir)
+    }
+    a.Verification = verification.NewService(verification.NewRepository(db), selfieStore)
+
+    // Billing: subscriptions and the entitlements other modules consult
+    a.Billing = billing.NewService(billing.NewPostgresRepository(db), a.Redis)
+    if cfg.Billing.StripeSecretKey != "" {
+        a.Billing.RegisterProvider(billing.NewStripeProvider(billing.StripeConfig{
+            SecretKey:     cfg.Billing.StripeSecretKey,
+            WebhookSecret: cfg.Billing.StripeWebhookSecret,
+            SuccessURL:    appURL + "/billing/success",
+            CancelURL:     appURL + "/billing/cancel",
+            PriceIDs: map[string]string{
+                billing.PlanPremiumMonthly: cfg.Billing.StripePricePremiumMonthly,
+                billing.PlanPremiumYearly:  cfg.Billing.StripePricePremiumYearly,
+                billing.CoinPackSmall:      cfg.Billing.StripePriceCoins100,
+                billing.CoinPackMedium:     cfg.Billing.StripePriceCoins550,
+                billing.CoinPackLarge:      cfg.Billing.StripePriceCoins1200,
+            },
+        }))
+        log.Println("   ✅ Stripe billing enabled")
+    } else {
+        log.Println("   ⚠️  No billing provider configured - checkout disabled")
+    }
+    a.Activity.SetEntitlements(a.Billing)
+    a.Profile.SetEntitlements(a.Billing)
+
+    // Gifts: the catalog, coin wallets credited by billing, and sent gifts
+    a.Gifts = gifts.NewService(gifts.NewRepository(db), a.Redis)
+    a.Gifts.SetBlocks(a.Blocks)
+    a.Gifts.SetUnitOfWork(a.UnitOfWork)
+    a.Billing.SetWallet(a.Gifts)
+
+    // Referrals: invite codes redeemed at signup, rewarded in coins or
+    // premium days once the invitee is verified with a complete profile
+    a.Referrals = referrals.NewService(referrals.NewRepository(db), &referrals.Config{
+        RewardType:        cfg.Referrals.RewardType,
+        RewardCoins:       cfg.Referrals.RewardCoins,
+        RewardPremiumDays: cfg.Referrals.RewardPremiumDays,
+        AppURL:            appURL,
+    })
+    a.Referrals.SetWallet(a.Gifts)
+    a.Referrals.SetPremium(a.Billing)
+    a.Auth.SetReferrals(a.Referrals)
+
+    return nil
+}
+
+func (a *Application) initMessaging(ctx context.Context) error {
+    cfg := a.Config
+    repo := messaging.NewPostgresRepository(a.sqlxDB())
+
+    // Message media goes to S3; without it media messages are disabled
+    var storage messaging.StorageService
+    if cfg.Storage.UseS3 {
+        awsSession, err := session.NewSession(&aws.Config{
+            Region: aws.String(cfg.Storage.S3Region),
+        })
+        if err != nil {
+            log.Printf("⚠️  Warning: AWS session creation failed for messaging: %v", err)
+        } else {
+            storage = messaging.NewStorageService(
+                awsSession,
+                cfg.Storage.S3Bucket,
+                cfg.Server.BaseURL, // CDN URL for serving media
+                cfg.Storage.Uploads.Message,
+            )
+            log.Println("   ✅ Using S3 for message media storage")
+        }
+        a.Health.Add("s3", false, health.S3Bucket(awsSession, cfg.Storage.S3Bucket))
+    } else {
+        log.Println("   ⚠️  Storage service disabled for messaging - S3 not configured")
+    }
+
+    var pushService messaging.PushService
+    credentialsFile := cfg.Messaging.FCMCredentialsFile
+    if credentialsFile != "" && fileExists(credentialsFile) {
+        var err error
+        pushService, err = messaging.NewPushService(credentialsFile, repo)
+        if err != nil {
+            log.Printf("   ⚠️  Warning: Push notifications disabled: %v", err)
+            pushService = messaging.NewMockPushService()
+        } else {
+            log.Println("   ✅ Firebase push notifications enabled")
+        }
+    } else {
+        log.Println("   ⚠️  Push notifications disabled - Firebase credentials not configured")
+        pushService = messaging.NewMockPushService()
+    }
+    if cfg.Notifications.EnablePush || credentialsFile != "" {
+        a.Health.Add("fcm", false, health.Reachable("https://fcm.googleapis.com/"))
+    }
+
+    a.Messaging = messaging.NewService(repo, storage, pushService, a.Blocks, a.Users)
+    a.Messaging.SetCache(a.Cache)
+
+    // The hub and the service need each other, so the hub is set afterwards
+    a.Hub = messaging.NewHub(a.Messaging)
+    a.Messaging.SetHub(a.Hub)
+    a.Health.Add("messaging_hub", true, func(ctx context.Context) error {
+        if !a.Hub.Running() {
+            return errors.New("not running")
+        }
+        return nil
+    })
+
+    // Unread conversation summaries are opt-in
+    if cfg.Messaging.EnableConversationSummaries {
+        a.Messaging.SetSummarizer(messaging.NewHeuristicSummarizer())
+        log.Println("   ✅ Conversation summaries enabled")
+    }
+    if a.TextFilter != nil {
+        a.Messaging.SetTextFilter(a.TextFilter)
+    }
+    a.Messaging.SetEvents(a.Events)
+    a.Messaging.SetOnboarding(a.Onboarding)
+
+    // Notification badge counts are pushed over the same sockets
+    a.Notifications.SetRealtime(a.Hub)
+
+    log.Println("✅ Messaging module initialized successfully")
+    return nil
+}
+
+// initDating builds dating: date requests, matches, hotpicks, quotas and
+// spotlight boosts
+func (a *Application) initDating(ctx context.Context) error {
+    cfg := a.Config.Discovery
+    repo := dating.NewPostgresRepository(a.sqlxDB())
+
+    service := dating.NewService(repo, dating.NewMatchingEngine(repo), a.Profile, a.Notifications)
+    service.SetNewProfileBoostWindow(cfg.NewProfileBoostWindow)
+    service.SetQuotas(dating.QuotaConfig{
+        DailySwipes:              cfg.DailySwipeQuota,
+        PremiumDailySwipes:       cfg.PremiumDailySwipeQuota,
+        DailyDateRequests:        cfg.DailyDateRequestQuota,
+        PremiumDailyDateRequests: cfg.PremiumDailyDateRequestQuota,
+    })
+    service.SetBoosts(dating.BoostConfig{
+        Duration:             cfg.BoostDuration,
+        Multiplier:           cfg.BoostMultiplier,
+        MonthlyBoosts:        cfg.MonthlyBoosts,
+        PremiumMonthlyBoosts: cfg.PremiumMonthlyBoosts,
+    })
+    service.SetHotpicks(dating.HotpickConfig{
+        PerDay:      cfg.DailyHotpicks,
+        ReadyHour:   cfg.HotpickReadyHour,
+        MaxPerGroup: cfg.HotpickMaxPerGroup,
+    })
+    service.SetRewindWindow(cfg.RewindWindow)
+    service.SetRedis(a.Redis)
+    service.SetEntitlements(a.Billing)
+    service.SetOnboarding(a.Onboarding)
+    service.SetBlocks(a.Blocks)
+    service.SetEvents(a.Events)
+    service.SetUnitOfWork(a.UnitOfWork)
+    service.SetUsers(a.Users)
+    a.Dating = service
+
+    log.Println("✅ Dating module initialized successfully")
+    return nil
+}
+
+// newMediaUploader stores profile and story media in S3, or on local disk
+// when S3 is off or unavailable
+func (a *Application) newMediaUploader(module string) profile.UploadService {
+    cfg := a.Config
+    localURL := cfg.Server.BaseURL + "/uploads"
+
+    if cfg.Storage.UseS3 {
+        uploader, err := profile.NewS3UploadService(cfg.Storage.S3Bucket, cfg.Storage.S3Region)
+        if err == nil {
+            log.Printf("   ✅ Using S3 for %s uploads", module)
+            return uploader
+        }
+        log.Printf("⚠️  Failed to init S3 for %s, using local: %v", module, err)
+    }
+
+    log.Printf("   ✅ Using local storage for %s uploads", module)
+    return profile.NewLocalUploadService(cfg.Storage.LocalUploadDir, localURL)
+}
+
+// newPostsUploader stores post media in S3, or on local disk when S3 is off
+func (a *Application) newPostsUploader() *posts.UploadService {
+    cfg := a.Config
+    return posts.NewUploadService(posts.UploadConfig{
+        UseS3:          cfg.Storage.UseS3,
+        S3Bucket:       cfg.Storage.S3Bucket,
+        AWSRegion:      cfg.Storage.S3Region,
+        LocalUploadDir: cfg.Storage.LocalUploadDir,
+        BaseURL:        cfg.Server.BaseURL,
+    })
+}
+
+// newSMSRouter registers the configured SMS providers with their routes and
+// costs, or returns nil when SMS is mocked
+func (a *Application) newSMSRouter() *sms.Router {
+    cfg := a.Config.SMS
+    if cfg.Provider == "mock" || cfg.Provider == "" {
+        return nil
+    }
+
+    router := sms.NewRouter(cfg.FailoverCooldown)
+    for _, name := range cfg.Providers {
+        switch name {
+        case "twilio":
+            router.Register(sms.NewTwilioProvider(
+                cfg.Twilio.AccountSID,
+                cfg.Twilio.AuthToken,
+                cfg.Twilio.FromNumber,
+                a.Config.Server.BaseURL+"/api/v1/notifications/webhooks/sms/twilio",
+            ))
+        case "termii":
+            router.Register(sms.NewTermiiProvider(cfg.Termii.APIKey, cfg.Termii.SenderID, cfg.Termii.SecretKey, cfg.Termii.BaseURL))
+        case "africastalking":
+            router.Register(sms.NewAfricasTalkingProvider(
+                cfg.AfricasTalking.Username,
+                cfg.AfricasTalking.APIKey,
+                cfg.AfricasTalking.SenderID,
+                cfg.AfricasTalking.CallbackToken,
+            ))
+        }
+    }
+
+    routes := make([]sms.Route, len(cfg.Routes))
+    for i, route := range cfg.Routes {
+        routes[i] = sms.Route{Prefix: route.Prefix, Providers: route.Providers}
+    }
+    router.SetRoutes(routes)
+
+    costs := make([]sms.Cost, len(cfg.Costs))
+    for i, cost := range cfg.Costs {
+        costs[i] = sms.Cost{Provider: cost.Provider, Prefix: cost.Prefix, Price: cost.Price}
+    }
+    router.SetCosts(costs)
+
+    return router
+}
+
+// newOTPEmailProvider creates the named email provider for OTPs
+func (a *Application) newOTPEmailProvider(name string) otp.EmailProvider {
+    cfg := a.Config.Email
+    if name == "sendgrid" {
+        return otp.NewSendGridEmailProvider(cfg.SendGridAPIKey, cfg.From)
+    }
+    return otp.NewSMTPEmailProvider(
+        cfg.SMTP.Host,
+        fmt.Sprintf("%d", cfg.SMTP.Port),
+        cfg.SMTP.Username,
+        cfg.SMTP.Password,
+        cfg.From,
+    )
+}
+
+// newNotificationEmailService creates the named email service for
+// notifications
+func (a *Application) newNotificationEmailService(name string) (notifications.EmailService, error) {
+    cfg := a.Config.Email
+    if name == "sendgrid" {
+        return notifications.NewSendGridEmailService(cfg.SendGridAPIKey, cfg.From, cfg.FromName)
+    }
+    return notifications.NewSMTPEmailService(
+        cfg.SMTP.Host,
+        cfg.SMTP.Port,
+        cfg.SMTP.Username,
+        cfg.SMTP.Password,
+        cfg.From,
+        cfg.FromName,
+    )
+}
+
+// newAPNSPushService creates the APNs push service from its .p8 key, read
+// from a file unless given inline
+func (a *Application) newAPNSPushService() (*notifications.APNSPushService, error) {
+    cfg := a.Config.Notifications
+    key := []byte(cfg.APNSKey)
+    if cfg.APNSKeyPath != "" {
+        var err error
+        if key, err = os.ReadFile(cfg.APNSKeyPath); err != nil {
+            return nil, fmt.Errorf("failed to read APNs key: %w", err)
+        }
+    }
+    return notifications.NewAPNSPushService(key, cfg.APNSKeyID, cfg.APNSTeamID, cfg.APNSBundleID, cfg.APNSProduction)
+}
+
+// newWebPushService creates the web push service from the VAPID keys. In
+// development a missing key is replaced with a throwaway one, which
+// invalidates browsers' subscriptions on every restart; elsewhere web push
+// stays off and this returns nil.
+func (a *Application) newWebPushService() (*notifications.WebPushService, error) {
+    cfg := a.Config.Notifications
+    privateKey := cfg.VAPIDPrivateKey
+    if privateKey == "" {
+        if !a.Config.IsDevelopment() {
+            return nil, nil
+        }
+        keys, err := notifications.GenerateVAPIDKeys()
+        if err != nil {
+            return nil, err
+        }
+        log.Printf("   📝 Using throwaway VAPID keys; set VAPID_PRIVATE_KEY=%s to keep web push subscriptions", keys.PrivateKey)
+        privateKey = keys.PrivateKey
+    }
+
+    service, err := notifications.NewWebPushService(privateKey, cfg.VAPIDSubject)
+    if err != nil {
+        return nil, err
+    }
+    if cfg.VAPIDPublicKey != "" && cfg.VAPIDPublicKey != service.PublicKey() {
+        return nil, errors.New("VAPID_PUBLIC_KEY doesn't match VAPID_PRIVATE_KEY")
+    }
+    return service, nil
+}
+
+// moderated puts an uploader behind content moderation when a provider is set
+func (a *Application) moderated(uploader profile.UploadService, source string) profile.UploadService {
+    if a.moderationProvider == nil {
+        return uploader
+    }
+    return moderation.Wrap(uploader, a.Moderation, source)
+}
+
+// sqlxDB wraps the shared connection pool for repositories that use sqlx
+func (a *Application) sqlxDB() *sqlx.DB {
+    return sqlx.NewDb(a.DB, "postgres")
+}
+
+// fileExists reports whether filename is an existing regular file
+func fileExists(filename string) bool {
+    info, err := os.Stat(filename)
+    if err != nil {
+        return false
+    }
+    return !info.IsDir()
+}
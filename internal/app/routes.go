@@ -0,0 +1,464 @@
+// internal/app/routes.go
+// HTTP routes and request middleware
+
+package app
+
+import (
+    "context"
+    "log"
+    "net/http"
+    "strings"
+    "time"
+
+    "github.com/gorilla/mux"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/activity"
+    "github.com/imadgeboyega/kiekky-backend/internal/admin"
+    "github.com/imadgeboyega/kiekky-backend/internal/analytics"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/billing"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/email"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/errorreport"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/health"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/jobs"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+    "github.com/imadgeboyega/kiekky-backend/internal/contacts"
+    "github.com/imadgeboyega/kiekky-backend/internal/dating"
+    "github.com/imadgeboyega/kiekky-backend/internal/gifts"
+    "github.com/imadgeboyega/kiekky-backend/internal/onboarding"
+    "github.com/imadgeboyega/kiekky-backend/internal/referrals"
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
+    "github.com/imadgeboyega/kiekky-backend/internal/interests"
+    "github.com/imadgeboyega/kiekky-backend/internal/messaging"
+    "github.com/imadgeboyega/kiekky-backend/internal/moderation"
+    notifications "github.com/imadgeboyega/kiekky-backend/internal/notification"
+    "github.com/imadgeboyega/kiekky-backend/internal/posts"
+    "github.com/imadgeboyega/kiekky-backend/internal/profile"
+    "github.com/imadgeboyega/kiekky-backend/internal/prompts"
+    "github.com/imadgeboyega/kiekky-backend/internal/stories"
+    "github.com/imadgeboyega/kiekky-backend/internal/suppression"
+    "github.com/imadgeboyega/kiekky-backend/internal/verification"
+)
+
+// initRoutes builds the full router. It replaces the startup router, which
+// only serves health probes, once Start finishes.
+func (a *Application) initRoutes(ctx context.Context) error {
+    cfg := a.Config
+    authMiddleware := a.authMiddleware
+    uploads := cfg.Storage.Uploads
+    router := mux.NewRouter()
+    
+    // Static files for uploads. Uploads get a new name when replaced, so
+    // clients can keep them for a day; FileServer handles revalidation.
+    if !cfg.Storage.UseS3 {
+        router.PathPrefix("/uploads/").Handler(
+            middleware.CacheControl("public, max-age=86400")(
+                http.StripPrefix("/uploads/",
+                    http.FileServer(http.Dir(cfg.Storage.LocalUploadDir)))))
+        log.Println("   ✅ Static file server configured")
+    }
+    
+    // Health checks
+    health.RegisterRoutes(router, a.Health)
+    router.HandleFunc("/api", apiInfo).Methods("GET")
+    
+    // Register auth routes (includes OTP endpoints)
+    authHandler := auth.NewHandler(a.Auth)
+    authHandler.RegisterRoutes(router)
+    authHandler.RegisterSessionRoutes(router, authMiddleware)
+    authHandler.RegisterTwoFactorRoutes(router, authMiddleware)
+    authHandler.RegisterAccountRoutes(router, authMiddleware)
+    log.Println("   ✅ Auth routes registered")
+    
+    // Register profile routes
+    profileHandler := profile.NewHandler(a.Profile)
+    profileHandler.SetUploadLimit(uploads.Image)
+    profile.RegisterRoutes(router, profileHandler, authMiddleware)
+    log.Println("   ✅ Profile routes registered")
+    
+    // Register posts routes
+    postsHandler := posts.NewHandler(a.Posts)
+    postsHandler.SetUploadLimit(uploads.Post)
+    posts.RegisterRoutes(router, postsHandler, authMiddleware)
+    log.Println("   ✅ Posts routes registered")
+
+    // Register stories routes
+    storiesHandler := stories.NewHandler(a.Stories)
+    storiesHandler.SetUploadLimits(uploads.Story, uploads.Image)
+    stories.RegisterRoutes(router, storiesHandler, authMiddleware)
+    log.Println("   ✅ Stories routes registered")
+    
+    // Register interests routes
+    interests.RegisterRoutes(router, interests.NewHandler(a.Interests), authMiddleware)
+    log.Println("   ✅ Interests routes registered")
+    
+    // Register prompts routes
+    prompts.RegisterRoutes(router, prompts.NewHandler(a.Prompts), authMiddleware)
+    log.Println("   ✅ Prompts routes registered")
+    
+    // Register activity routes
+    activity.RegisterRoutes(router, activity.NewHandler(a.Activity), authMiddleware)
+    log.Println("   ✅ Activity routes registered")
+    
+    // Register analytics event ingestion and admin charts
+    analytics.RegisterRoutes(router, analytics.NewHandler(a.Analytics), authMiddleware)
+    log.Println("   ✅ Analytics routes registered")
+    
+    // Register billing routes
+    billing.RegisterRoutes(router, billing.NewHandler(a.Billing), authMiddleware)
+    log.Println("   ✅ Billing routes registered")
+    
+    // Register gifts routes
+    gifts.RegisterRoutes(router, gifts.NewHandler(a.Gifts), authMiddleware)
+    log.Println("   ✅ Gifts routes registered")
+
+    // Register onboarding routes
+    onboarding.RegisterRoutes(router, onboarding.NewHandler(a.Onboarding), authMiddleware)
+    log.Println("   ✅ Onboarding routes registered")
+
+    // Register contacts routes
+    contacts.RegisterRoutes(router, contacts.NewHandler(a.Contacts), authMiddleware)
+    log.Println("   ✅ Contacts routes registered")
+
+    // Register referrals routes
+    referrals.RegisterRoutes(router, referrals.NewHandler(a.Referrals), authMiddleware)
+    log.Println("   ✅ Referrals routes registered")
+    
+    // Register email bounce webhooks and suppression list management
+    suppression.RegisterRoutes(router, suppression.NewHandler(a.Suppressions), authMiddleware)
+    log.Println("   ✅ Email suppression routes registered")
+    
+    // Register messaging routes. Messaging wraps handler funcs rather than handlers.
+    messagingHandler := messaging.NewHandler(a.Messaging, a.Hub)
+    messagingHandler.SetUploadLimit(uploads.Message)
+    messaging.RegisterRoutes(router, messagingHandler, func(next http.HandlerFunc) http.HandlerFunc {
+        return authMiddleware.Authenticate(next).ServeHTTP
+    })
+    messaging.RegisterHealthCheck(router, messagingHandler)
+    log.Println("   ✅ Messaging routes registered")
+
+    // Register dating routes
+    dating.RegisterRoutes(router, dating.NewHandler(a.Dating), authMiddleware)
+    log.Println("   ✅ Dating routes registered")
+
+    // Register notifications routes
+    notificationsHandler := notifications.NewHandler(a.Notifications)
+    notificationsHandler.SetActionRouter(router)
+    notifications.RegisterRoutes(router, notificationsHandler, authMiddleware)
+    log.Println("   ✅ Notifications routes registered")
+
+    // Register background job status routes
+    jobs.RegisterRoutes(router, jobs.NewHandler(a.Jobs), authMiddleware.Authenticate, authMiddleware.RequireStaff)
+    log.Println("   ✅ Job status routes registered")
+
+    // Register admin user management, export/import and audit log routes
+    admin.RegisterRoutes(router, admin.NewHandler(a.Admin), authMiddleware)
+    log.Println("   ✅ Admin user management routes registered")
+    
+    // Register photo verification routes
+    verification.RegisterRoutes(router, verification.NewHandler(a.Verification), authMiddleware)
+    log.Println("   ✅ Verification routes registered")
+    
+    // Register content moderation routes
+    moderation.RegisterRoutes(router, moderation.NewHandler(a.Moderation), authMiddleware)
+    log.Println("   ✅ Moderation routes registered")
+
+    // Rendered email previews, for working on templates
+    if cfg.IsDevelopment() {
+        email.RegisterPreviewRoutes(router)
+        log.Println("   ✅ Email preview routes registered (development only)")
+    }
+
+    // Add middleware
+    router.Use(loggingMiddleware)
+    router.Use(middleware.ClientInfoCapture(cfg.Security.TrustProxyHeaders))
+    router.Use(i18n.Middleware)
+    a.Router = router
+
+    // Security headers and CORS wrap the router instead of going through
+    // router.Use, which mux skips for unmatched paths and methods, so 404s,
+    // 405s and preflights get them too
+    cors := middleware.CORS(middleware.CORSConfig{
+        AllowedOrigins:   cfg.CORS.AllowedOrigins,
+        AllowedMethods:   cfg.CORS.AllowedMethods,
+        AllowedHeaders:   cfg.CORS.AllowedHeaders,
+        AllowCredentials: cfg.CORS.AllowCredentials,
+        MaxAge:           cfg.CORS.MaxAge,
+    })
+    security := middleware.SecurityHeaders(middleware.SecurityConfig{
+        HSTSMaxAge:            cfg.Security.HSTSMaxAge,
+        ContentSecurityPolicy: cfg.Security.ContentSecurityPolicy,
+        ForceHTTPS:            cfg.Security.ForceHTTPS,
+        TrustProxyHeaders:     cfg.Security.TrustProxyHeaders,
+        RedirectExemptPaths:   []string{"/health"},
+    })
+    reporter, err := a.errorReporter()
+    if err != nil {
+        return err
+    }
+    recoverer := middleware.Recover(reporter, cfg.Security.TrustProxyHeaders)
+    requestID := middleware.RequestID(cfg.Security.TrustProxyHeaders)
+    a.routes = requestID(recoverer(security(cors(router))))
+    log.Printf("   ✅ CORS allowed origins: %s", strings.Join(cfg.CORS.AllowedOrigins, ", "))
+    return nil
+}
+
+// errorReporter sends panics to Sentry when SENTRY_DSN is set, and only
+// logs them otherwise
+func (a *Application) errorReporter() (errorreport.Reporter, error) {
+    cfg := a.Config
+    if cfg.Errors.SentryDSN == "" {
+        log.Println("   ⏭️  Sentry disabled (SENTRY_DSN not set), panics are only logged")
+        return errorreport.LogReporter{}, nil
+    }
+
+    sentry, err := errorreport.NewSentry(cfg.Errors.SentryDSN, cfg.Server.Environment, cfg.Errors.Release)
+    if err != nil {
+        return nil, err
+    }
+    log.Println("   ✅ Reporting panics to Sentry")
+    return sentry, nil
+}
+
+// apiInfo returns API information
+func apiInfo(w http.ResponseWriter, r *http.Request) {
+    log.Printf("📥 API info request from %s", r.RemoteAddr)
+    
+    w.Header().Set("Content-Type", "application/json")
+    w.WriteHeader(http.StatusOK)
+    w.Write([]byte(`{
+        "name": "Social Dating App API",
+        "version": "1.0.0",
+        "status": "running",
+        "endpoints": {
+            "health": "GET /health",
+            "health_live": "GET /health/live",
+            "health_ready": "GET /health/ready",
+            "auth": {
+                "signup": "POST /api/auth/signup",
+                "signin": "POST /api/auth/signin",
+                "verify": "POST /api/auth/verify-otp",
+                "refresh": "POST /api/auth/refresh",
+                "logout": "POST /api/auth/logout",
+                "sessions": "GET /api/v1/auth/sessions",
+                "revoke_session": "DELETE /api/v1/auth/sessions/{id}",
+                "two_factor": "GET /api/v1/auth/2fa",
+                "enroll_totp": "POST /api/v1/auth/2fa/totp/enroll",
+                "change_username": "PUT /api/v1/auth/account/username",
+                "change_email": "POST /api/v1/auth/account/email",
+                "change_phone": "POST /api/v1/auth/account/phone"
+            },
+            "posts": {
+                "create": "POST /api/v1/posts",
+                "get": "GET /api/v1/posts/{id}",
+                "update": "PUT /api/v1/posts/{id}",
+                "delete": "DELETE /api/v1/posts/{id} (moves to trash)",
+                "restore": "POST /api/v1/posts/{id}/restore",
+                "trash": "GET /api/v1/posts/trash",
+                "scheduled": "GET /api/v1/posts/scheduled (create with publish_at to schedule)",
+                "update_scheduled": "PUT /api/v1/posts/scheduled/{id}",
+                "cancel_scheduled": "DELETE /api/v1/posts/scheduled/{id}",
+                "drafts": "GET/POST /api/v1/posts/drafts",
+                "draft": "GET/PUT/DELETE /api/v1/posts/drafts/{id}",
+                "draft_media": "POST /api/v1/posts/drafts/{id}/media",
+                "publish_draft": "POST /api/v1/posts/drafts/{id}/publish",
+                "like": "POST /api/v1/posts/{id}/like",
+                "unlike": "DELETE /api/v1/posts/{id}/like",
+                "comment": "POST /api/v1/posts/{id}/comment",
+                "delete_comment": "DELETE /api/v1/comments/{id} (moves to trash)",
+                "restore_comment": "POST /api/v1/comments/{id}/restore",
+                "comments_trash": "GET /api/v1/comments/trash",
+                "feed": "GET /api/v1/posts/feed",
+                "explore": "GET /api/v1/posts/explore (trending, ?country= overrides the detected country)",
+                "nearby": "GET /api/v1/posts/nearby?lat=&lng=&radius_km= (defaults to your saved location)",
+                "repost": "POST /api/v1/posts/{id}/repost",
+                "save": "POST/DELETE /api/v1/posts/{id}/save",
+                "saved": "GET /api/v1/posts/saved?collection=",
+                "collections": "GET /api/v1/posts/saved/collections"
+            },
+            "places": {
+                "search": "GET /api/v1/places/search?q=&lat=&lng=",
+                "get": "GET /api/v1/places/{id}",
+                "posts": "GET /api/v1/places/{id}/posts",
+                "tagging": "POST /api/v1/posts with place_id, or latitude and longitude"
+            },
+            "stories": {
+                "create": "POST /api/v1/stories",
+                "get": "GET /api/v1/stories/{id}",
+                "delete": "DELETE /api/v1/stories/{id}",
+                "view": "POST /api/v1/stories/{id}/view",
+                "sharePost": "POST /api/v1/stories/share",
+                "closeFriends": "GET/POST/DELETE /api/v1/stories/close-friends/{userId}",
+                "archive": "GET /api/v1/stories/archive",
+                "archiveSettings": "GET/PUT /api/v1/stories/archive/settings",
+                "feed": "GET /api/v1/stories/feed"
+            },
+            "messaging": {
+                "websocket": "GET /ws",
+                "conversations": {
+                    "list": "GET /api/v1/messages/conversations",
+                    "create": "POST /api/v1/messages/conversations",
+                    "get": "GET /api/v1/messages/conversations/{id}",
+                    "delete": "DELETE /api/v1/messages/conversations/{id}"
+                },
+                "messages": {
+                    "send": "POST /api/v1/messages/messages",
+                    "list": "GET /api/v1/messages/conversations/{id}/messages",
+                    "edit": "PUT /api/v1/messages/messages/{id}",
+                    "delete": "DELETE /api/v1/messages/messages/{id}",
+                    "markRead": "POST /api/v1/messages/messages/read",
+                    "receipts": "GET /api/v1/messages/conversations/{id}/receipts",
+                    "summary": "GET /api/v1/messages/conversations/{id}/summary",
+                    "export": "GET /api/v1/messages/conversations/{id}/export?format=json|text",
+                    "exportStatus": "GET /api/v1/messages/exports/{id}"
+                },
+                "reactions": {
+                    "add": "POST /api/v1/messages/messages/{id}/reactions",
+                    "remove": "DELETE /api/v1/messages/messages/{id}/reactions/{reaction}"
+                },
+                "voice": {
+                    "start": "POST /api/v1/messages/voice",
+                    "chunk": "PUT /api/v1/messages/voice/{uploadId}/chunks/{index}",
+                    "complete": "POST /api/v1/messages/voice/{uploadId}/complete",
+                    "playback": "GET /api/v1/messages/messages/{id}/playback"
+                },
+                "typing": "POST /api/v1/messages/typing",
+                "pushTokens": {
+                    "register": "POST /api/v1/messages/push-tokens",
+                    "unregister": "DELETE /api/v1/messages/push-tokens/{token}"
+                },
+                "blocking": {
+                    "block": "POST /api/v1/messages/block/{userId}",
+                    "unblock": "POST /api/v1/messages/unblock/{userId}"
+                }
+            },
+            "interests": {
+                "browse": "GET /api/v1/interests?category=",
+                "search": "GET /api/v1/interests/search?q=&category=&limit=",
+                "suggest": "GET /api/v1/interests/suggest?q=&limit="
+            },
+            "prompts": {
+                "catalog": "GET /api/v1/prompts",
+                "myAnswers": "GET /api/v1/prompts/answers",
+                "setAnswers": "PUT /api/v1/prompts/answers",
+                "deleteAnswer": "DELETE /api/v1/prompts/answers/{promptId}"
+            },
+            "activity": {
+                "feed": "GET /api/v1/activity?limit=&before=",
+                "markRead": "POST /api/v1/activity/read"
+            },
+            "analytics": {
+                "events": "POST /api/v1/events"
+            },
+            "billing": {
+                "plans": "GET /api/v1/billing/plans",
+                "subscription": "GET /api/v1/billing/subscription",
+                "checkout": "POST /api/v1/billing/checkout",
+                "cancel": "POST /api/v1/billing/subscription/cancel",
+                "coinPacks": "GET /api/v1/billing/coins",
+                "coinCheckout": "POST /api/v1/billing/coins/checkout",
+                "webhook": "POST /api/v1/billing/webhooks/{provider}"
+            },
+            "gifts": {
+                "catalog": "GET /api/v1/gifts",
+                "send": "POST /api/v1/gifts/send",
+                "received": "GET /api/v1/gifts/received?limit=&offset=",
+                "sent": "GET /api/v1/gifts/sent?limit=&offset=",
+                "showcase": "GET /api/v1/gifts/users/{userId}/showcase",
+                "wallet": "GET /api/v1/gifts/wallet",
+                "transactions": "GET /api/v1/gifts/wallet/transactions?limit=&before="
+            },
+            "onboarding": {
+                "progress": "GET /api/v1/onboarding"
+            },
+            "contacts": {
+                "sync": "POST /api/v1/contacts/sync",
+                "suggestions": "GET /api/v1/contacts/suggestions?limit="
+            },
+            "referrals": {
+                "dashboard": "GET /api/v1/referrals?limit=&offset="
+            },
+            "email": {
+                "webhook": "POST /api/v1/email/webhooks/{provider}"
+            },
+            "protected": {
+                "me": "GET /api/v1/me (requires auth)"
+            },
+            "admin": {
+                "jobs": "GET /api/v1/admin/jobs",
+                "notification_deliveries": "GET /api/v1/admin/notifications/deliveries?status=&channel=&user_id=",
+                "notification_delivery_health": "GET /api/v1/admin/notifications/deliveries/health?hours=",
+                "search_users": "GET /api/v1/admin/users?q=&status=&role=&verified=",
+                "user_detail": "GET /api/v1/admin/users/{id}",
+                "verify_user": "POST /api/v1/admin/users/{id}/verify",
+                "suspend_user": "POST /api/v1/admin/users/{id}/suspend",
+                "unsuspend_user": "POST /api/v1/admin/users/{id}/unsuspend",
+                "reset_user_password": "POST /api/v1/admin/users/{id}/reset-password",
+                "impersonate_user": "POST /api/v1/admin/users/{id}/impersonate",
+                "set_user_role": "PUT /api/v1/admin/users/{id}/role",
+                "erase_user": "POST /api/v1/admin/users/{id}/erase",
+                "user_erasure": "GET /api/v1/admin/users/{id}/erasure",
+                "audit_log": "GET /api/v1/admin/audit-log?actor_id=&user_id=&action=",
+                "export_users": "GET /api/v1/admin/users/export",
+                "import_users": "POST /api/v1/admin/users/import",
+                "transfer_jobs": "GET /api/v1/admin/users/jobs",
+                "transfer_job": "GET /api/v1/admin/users/jobs/{id}",
+                "verifications": "GET /api/v1/admin/verifications?status=",
+                "verification_selfie": "GET /api/v1/admin/verifications/{id}/selfie",
+                "approve_verification": "POST /api/v1/admin/verifications/{id}/approve",
+                "reject_verification": "POST /api/v1/admin/verifications/{id}/reject",
+                "moderation_queue": "GET /api/v1/admin/moderation?status=",
+                "moderation_media": "GET /api/v1/admin/moderation/{id}/media",
+                "approve_upload": "POST /api/v1/admin/moderation/{id}/approve",
+                "reject_upload": "POST /api/v1/admin/moderation/{id}/reject",
+                "user_violations": "GET /api/v1/admin/moderation/users/{id}/violations",
+                "email_suppressions": "GET /api/v1/admin/email/suppressions?reason=&q=",
+                "add_email_suppression": "POST /api/v1/admin/email/suppressions",
+                "remove_email_suppression": "DELETE /api/v1/admin/email/suppressions/{email}",
+                "analytics_dau": "GET /api/v1/admin/analytics/dau?from=&to=",
+                "analytics_retention": "GET /api/v1/admin/analytics/retention?from=&to=",
+                "analytics_match_rate": "GET /api/v1/admin/analytics/match-rate?from=&to=",
+                "analytics_funnel": "GET /api/v1/admin/analytics/funnel?from=&to=",
+                "analytics_aggregate": "POST /api/v1/admin/analytics/aggregate?day="
+            },
+            "moderation": {
+                "upload_status": "GET /api/v1/moderation/uploads/{id}"
+            },
+            "verification": {
+                "status": "GET /api/v1/verification",
+                "challenge": "POST /api/v1/verification/challenge",
+                "selfie": "POST /api/v1/verification/{id}/selfie"
+            }
+        }
+    }`))
+}
+
+// loggingMiddleware logs all requests
+func loggingMiddleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        start := time.Now()
+        
+        // Log request details
+        log.Printf("→ %s %s from %s", r.Method, r.RequestURI, r.RemoteAddr)
+        
+        // Wrap response writer to capture status code
+        wrapped := &responseWriter{ResponseWriter: w, statusCode: http.StatusOK}
+        
+        next.ServeHTTP(wrapped, r)
+        
+        // Log response details
+        duration := time.Since(start)
+        log.Printf("← %s %s [%d] %v", r.Method, r.RequestURI, wrapped.statusCode, duration)
+    })
+}
+
+// responseWriter wraps http.ResponseWriter to capture status code
+type responseWriter struct {
+    http.ResponseWriter
+    statusCode int
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+    rw.statusCode = code
+    rw.ResponseWriter.WriteHeader(code)
+}
@@ -0,0 +1,189 @@
+// internal/auth/anomaly.go
+// Login anomaly detection: step-up verification for unusual signins and
+// temporary account locks after repeated failures
+
+package auth
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "net"
+    "net/url"
+    "strconv"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+)
+
+var ErrAccountLocked = errors.New("account temporarily locked")
+
+// Reasons a signin needs extra verification
+const (
+    riskFailedAttempts = "several failed sign-in attempts"
+    riskNewCountry     = "a sign-in from a new country"
+    riskNewNetwork     = "a sign-in from a new network"
+)
+
+// assessLogin returns why a signin with the right password still looks
+// unusual, or "" if it doesn't. Users with no signin history are never
+// flagged for location.
+func (s *service) assessLogin(ctx context.Context, userID int64, failures int64) string {
+    if s.config.StepUpAfterFailures > 0 && failures >= int64(s.config.StepUpAfterFailures) {
+        return riskFailedAttempts
+    }
+
+    client := middleware.ClientInfoFromContext(ctx)
+    network := networkOf(client.IP)
+    if network == "" {
+        return ""
+    }
+
+    known, err := s.repo.GetLoginLocations(ctx, userID)
+    if err != nil {
+        log.Printf("Failed to get login locations for user %d: %v", userID, err)
+        return ""
+    }
+    if len(known) == 0 {
+        return ""
+    }
+
+    // Compare countries when the CDN tells us, since mobile users change
+    // networks all the time; otherwise fall back to the network itself
+    if client.Country != "" {
+        seenCountry := false
+        for _, location := range known {
+            if location.Country == nil {
+                continue
+            }
+            seenCountry = true
+            if *location.Country == client.Country {
+                return ""
+            }
+        }
+        if seenCountry {
+            return riskNewCountry
+        }
+    }
+
+    for _, location := range known {
+        if location.Network == network {
+            return ""
+        }
+    }
+    return riskNewNetwork
+}
+
+// recordLoginLocation remembers where a successful signin came from
+func (s *service) recordLoginLocation(ctx context.Context, userID int64) {
+    client := middleware.ClientInfoFromContext(ctx)
+    network := networkOf(client.IP)
+    if network == "" {
+        return
+    }
+
+    if err := s.repo.RecordLoginLocation(ctx, userID, network, client.Country); err != nil {
+        log.Printf("Failed to record login location for user %d: %v", userID, err)
+    }
+}
+
+// notifySuspiciousLogin warns the user that a signin was challenged
+func (s *service) notifySuspiciousLogin(ctx context.Context, userID int64, reason string) {
+    if s.notifier == nil {
+        return
+    }
+
+    client := middleware.ClientInfoFromContext(ctx)
+    go func(at time.Time) {
+        if err := s.notifier.SendSuspiciousLoginNotification(context.Background(), userID, reason, client.Location(), client.IP, at); err != nil {
+            log.Printf("Failed to send suspicious login alert to user %d: %v", userID, err)
+        }
+    }(time.Now())
+}
+
+// isLocked reports whether the account is temporarily locked
+func (s *service) isLocked(ctx context.Context, userID int64) bool {
+    if s.redis == nil {
+        return false
+    }
+    locked, err := s.redis.Exists(ctx, accountLockKey(userID)).Result()
+    return err == nil && locked > 0
+}
+
+// lockAccount locks the account for LockDuration and emails the user a
+// link to unlock it early
+func (s *service) lockAccount(ctx context.Context, userID int64) {
+    if s.redis == nil || s.config.LockDuration <= 0 {
+        return
+    }
+
+    until := time.Now().Add(s.config.LockDuration)
+    if err := s.redis.Set(ctx, accountLockKey(userID), until.Unix(), s.config.LockDuration).Err(); err != nil {
+        log.Printf("Failed to lock account %d: %v", userID, err)
+        return
+    }
+
+    token := s.generateSecureToken()
+    if err := s.redis.Set(ctx, accountUnlockKey(token), userID, s.config.LockDuration).Err(); err != nil {
+        log.Printf("Failed to store unlock token for user %d: %v", userID, err)
+        return
+    }
+
+    if s.notifier == nil {
+        return
+    }
+    unlockURL := s.config.BaseURL + "/api/auth/unlock?token=" + url.QueryEscape(token)
+    go func() {
+        if err := s.notifier.SendAccountLockedNotification(context.Background(), userID, unlockURL, until); err != nil {
+            log.Printf("Failed to send account locked alert to user %d: %v", userID, err)
+        }
+    }()
+}
+
+// UnlockAccount lifts a temporary lock using the token from the unlock email
+func (s *service) UnlockAccount(ctx context.Context, token string) error {
+    if s.redis == nil || token == "" {
+        return ErrInvalidToken
+    }
+
+    value, err := s.redis.GetDel(ctx, accountUnlockKey(token)).Result()
+    if err != nil {
+        return ErrInvalidToken
+    }
+    userID, err := strconv.ParseInt(value, 10, 64)
+    if err != nil {
+        return ErrInvalidToken
+    }
+
+    s.redis.Del(ctx, accountLockKey(userID))
+    s.clearFailedAttempts(ctx, loginAttemptKey(userID))
+    return nil
+}
+
+// loginAttemptKey identifies a user's failed password attempts, whichever
+// of email, phone or username they signed in with
+func loginAttemptKey(userID int64) string {
+    return fmt.Sprintf("user:%d", userID)
+}
+
+func accountLockKey(userID int64) string {
+    return fmt.Sprintf("account_lock:%d", userID)
+}
+
+func accountUnlockKey(token string) string {
+    return fmt.Sprintf("account_unlock:%s", token)
+}
+
+// networkOf groups an IP with its neighbours (/24 for IPv4, /48 for IPv6),
+// so a new address from the same provider isn't treated as a new location
+func networkOf(ip string) string {
+    parsed := net.ParseIP(ip)
+    if parsed == nil {
+        return ""
+    }
+    if v4 := parsed.To4(); v4 != nil {
+        return (&net.IPNet{IP: v4.Mask(net.CIDRMask(24, 32)), Mask: net.CIDRMask(24, 32)}).String()
+    }
+    return (&net.IPNet{IP: parsed.Mask(net.CIDRMask(48, 128)), Mask: net.CIDRMask(48, 128)}).String()
+}
@@ -0,0 +1,66 @@
+// internal/auth/context.go
+// What Authenticate and OptionalAuthenticate store in the request context.
+// The keys are unexported, so only this package can set them and no other
+// value can collide with them.
+
+package auth
+
+import (
+    "context"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type contextKey int
+
+const (
+    userIDKey contextKey = iota
+    emailKey
+    usernameKey
+    sessionIDKey
+    impersonatorIDKey
+)
+
+// withClaims returns ctx carrying the user the token was issued to
+func withClaims(ctx context.Context, claims *utils.JWTClaims) context.Context {
+    ctx = context.WithValue(ctx, userIDKey, claims.UserID)
+    ctx = context.WithValue(ctx, emailKey, claims.Email)
+    ctx = context.WithValue(ctx, usernameKey, claims.Username)
+    ctx = context.WithValue(ctx, sessionIDKey, claims.SessionID)
+    if claims.ImpersonatorID != 0 {
+        ctx = context.WithValue(ctx, impersonatorIDKey, claims.ImpersonatorID)
+    }
+    return ctx
+}
+
+// UserIDFromContext returns the authenticated user, or false outside an
+// authenticated request
+func UserIDFromContext(ctx context.Context) (int64, bool) {
+    userID, ok := ctx.Value(userIDKey).(int64)
+    return userID, ok
+}
+
+// SessionIDFromContext returns the session the request's token belongs to
+func SessionIDFromContext(ctx context.Context) (int64, bool) {
+    sessionID, ok := ctx.Value(sessionIDKey).(int64)
+    return sessionID, ok
+}
+
+// ImpersonatorIDFromContext returns the admin behind a support session, if
+// the request is one
+func ImpersonatorIDFromContext(ctx context.Context) (int64, bool) {
+    adminID, ok := ctx.Value(impersonatorIDKey).(int64)
+    return adminID, ok
+}
+
+// EmailFromContext returns the authenticated user's email
+func EmailFromContext(ctx context.Context) (string, bool) {
+    email, ok := ctx.Value(emailKey).(string)
+    return email, ok
+}
+
+// UsernameFromContext returns the authenticated user's username
+func UsernameFromContext(ctx context.Context) (string, bool) {
+    username, ok := ctx.Value(usernameKey).(string)
+    return username, ok
+}
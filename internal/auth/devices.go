@@ -0,0 +1,104 @@
+// internal/auth/devices.go
+// Device detection for sessions and new-device login alerts
+
+package auth
+
+import (
+    "context"
+    "crypto/sha256"
+    "encoding/hex"
+    "strings"
+    "time"
+)
+
+// Notifier sends security alerts to users
+type Notifier interface {
+    SendNewDeviceLoginNotification(ctx context.Context, userID int64, device, location, ipAddress string, at time.Time) error
+    SendSuspiciousLoginNotification(ctx context.Context, userID int64, reason, location, ipAddress string, at time.Time) error
+    SendAccountLockedNotification(ctx context.Context, userID int64, unlockURL string, until time.Time) error
+}
+
+// Device is what we can tell about a client from its request
+type Device struct {
+    Browser string // e.g. Chrome, Safari, Kiekky app
+    OS      string // e.g. iOS, Android, macOS
+    Type    string // mobile, tablet or desktop
+}
+
+// Label is a human-readable description, e.g. "Chrome on macOS"
+func (d Device) Label() string {
+    switch {
+    case d.Browser != "" && d.OS != "":
+        return d.Browser + " on " + d.OS
+    case d.Browser != "":
+        return d.Browser
+    case d.OS != "":
+        return d.OS + " device"
+    }
+    return "Unknown device"
+}
+
+// parseUserAgent identifies the browser, OS and device type from a
+// User-Agent header. It only needs to be good enough to show users which
+// device a session belongs to.
+func parseUserAgent(ua string) Device {
+    var d Device
+
+    switch {
+    case strings.Contains(ua, "iPad"):
+        d.OS, d.Type = "iPadOS", "tablet"
+    case strings.Contains(ua, "iPhone") || strings.Contains(ua, "iPod"):
+        d.OS, d.Type = "iOS", "mobile"
+    case strings.Contains(ua, "Android"):
+        d.OS, d.Type = "Android", "mobile"
+        if !strings.Contains(ua, "Mobile") && strings.Contains(ua, "Mozilla") {
+            d.Type = "tablet"
+        }
+    case strings.Contains(ua, "okhttp"):
+        d.OS, d.Type = "Android", "mobile"
+    case strings.Contains(ua, "CFNetwork") || strings.Contains(ua, "Darwin"):
+        d.OS, d.Type = "iOS", "mobile"
+    case strings.Contains(ua, "Windows"):
+        d.OS, d.Type = "Windows", "desktop"
+    case strings.Contains(ua, "CrOS"):
+        d.OS, d.Type = "ChromeOS", "desktop"
+    case strings.Contains(ua, "Mac OS X") || strings.Contains(ua, "Macintosh"):
+        d.OS, d.Type = "macOS", "desktop"
+    case strings.Contains(ua, "Linux"):
+        d.OS, d.Type = "Linux", "desktop"
+    }
+
+    // Order matters: most browsers also claim to be Chrome and Safari
+    switch {
+    case strings.Contains(ua, "Kiekky"):
+        d.Browser = "Kiekky app"
+    case strings.Contains(ua, "okhttp") || strings.Contains(ua, "CFNetwork"):
+        d.Browser = "Kiekky app"
+    case strings.Contains(ua, "Edg/") || strings.Contains(ua, "EdgiOS") || strings.Contains(ua, "EdgA"):
+        d.Browser = "Edge"
+    case strings.Contains(ua, "OPR/") || strings.Contains(ua, "Opera"):
+        d.Browser = "Opera"
+    case strings.Contains(ua, "SamsungBrowser"):
+        d.Browser = "Samsung Internet"
+    case strings.Contains(ua, "Firefox/") || strings.Contains(ua, "FxiOS"):
+        d.Browser = "Firefox"
+    case strings.Contains(ua, "Chrome/") || strings.Contains(ua, "CriOS"):
+        d.Browser = "Chrome"
+    case strings.Contains(ua, "Safari/"):
+        d.Browser = "Safari"
+    }
+
+    return d
+}
+
+// deviceFingerprint identifies a device across logins. Apps send a stable
+// install ID in X-Device-ID; browsers are told apart only by browser, OS and
+// device type, so two identical browsers look like one device.
+func deviceFingerprint(deviceID string, d Device) string {
+    source := "id:" + deviceID
+    if deviceID == "" {
+        source = "ua:" + strings.Join([]string{d.Browser, d.OS, d.Type}, "|")
+    }
+    sum := sha256.Sum256([]byte(source))
+    return hex.EncodeToString(sum[:])
+}
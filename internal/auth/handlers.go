@@ -3,12 +3,16 @@
 package auth
 
 import (
-    "encoding/json"
+    "errors"
     "net/http"
+    "strconv"
     "strings"
     
     "github.com/gorilla/mux"
     "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
+    "github.com/imadgeboyega/kiekky-backend/internal/otp"
+    "github.com/imadgeboyega/kiekky-backend/internal/password"
 )
 
 // Handler holds dependencies for auth endpoints
@@ -37,22 +41,55 @@ func (h *Handler) RegisterRoutes(router *mux.Router) {
     auth.HandleFunc("/refresh", h.RefreshToken).Methods("POST")
     auth.HandleFunc("/forgot-password", h.ForgotPassword).Methods("POST")
     auth.HandleFunc("/reset-password", h.ResetPassword).Methods("POST")
+    auth.HandleFunc("/unlock", h.UnlockAccount).Methods("GET", "POST")
     
     // Protected routes
     auth.HandleFunc("/logout", h.Logout).Methods("POST")
     auth.HandleFunc("/logout-all", h.LogoutAllDevices).Methods("POST")
 }
 
+// RegisterSessionRoutes registers the signed-in device endpoints, which
+// need an authenticated user
+func (h *Handler) RegisterSessionRoutes(router *mux.Router, middleware *Middleware) {
+    sessions := router.PathPrefix("/api/v1/auth/sessions").Subrouter()
+    sessions.Use(middleware.Authenticate)
+    
+    sessions.HandleFunc("", h.ListSessions).Methods("GET")
+    sessions.HandleFunc("/{id:[0-9]+}", h.RevokeSession).Methods("DELETE")
+}
+
+// RegisterTwoFactorRoutes registers two-factor setup endpoints, which need
+// an authenticated user
+func (h *Handler) RegisterTwoFactorRoutes(router *mux.Router, middleware *Middleware) {
+    twoFactor := router.PathPrefix("/api/v1/auth/2fa").Subrouter()
+    twoFactor.Use(middleware.Authenticate)
+    
+    twoFactor.HandleFunc("", h.GetTwoFactorStatus).Methods("GET")
+    twoFactor.HandleFunc("/method", h.SetTwoFactorMethod).Methods("PUT")
+    twoFactor.HandleFunc("/totp/enroll", h.EnrollTOTP).Methods("POST")
+    twoFactor.HandleFunc("/totp/confirm", h.ConfirmTOTP).Methods("POST")
+    twoFactor.HandleFunc("/totp", h.DisableTOTP).Methods("DELETE")
+    twoFactor.HandleFunc("/recovery-codes", h.RegenerateRecoveryCodes).Methods("POST")
+}
+
+// RegisterAccountRoutes registers the username, email and phone change
+// endpoints, which need an authenticated user
+func (h *Handler) RegisterAccountRoutes(router *mux.Router, middleware *Middleware) {
+    account := router.PathPrefix("/api/v1/auth/account").Subrouter()
+    account.Use(middleware.Authenticate)
+    
+    account.HandleFunc("/username", h.ChangeUsername).Methods("PUT")
+    account.HandleFunc("/email", h.RequestEmailChange).Methods("POST")
+    account.HandleFunc("/email/verify", h.ConfirmEmailChange).Methods("POST")
+    account.HandleFunc("/phone", h.RequestPhoneChange).Methods("POST")
+    account.HandleFunc("/phone/verify", h.ConfirmPhoneChange).Methods("POST")
+    account.HandleFunc("/history", h.GetIdentifierHistory).Methods("GET")
+}
+
 // Signup handles user registration 
 func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
     var req SignupRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-    
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -64,7 +101,12 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
             utils.ErrorResponse(w, "Email already registered", http.StatusConflict)
         case ErrUsernameAlreadyExists:
             utils.ErrorResponse(w, "Username already taken", http.StatusConflict)
+        case ErrInvalidReferralCode:
+            utils.ErrorResponse(w, "Invalid referral code", http.StatusBadRequest)
         default:
+            if respondWeakPassword(w, err) {
+                return
+            }
             utils.ErrorResponse(w, "Failed to create account", http.StatusInternalServerError)
         }
         return
@@ -96,13 +138,7 @@ func (h *Handler) Signup(w http.ResponseWriter, r *http.Request) {
 // Signin handles user login
 func (h *Handler) Signin(w http.ResponseWriter, r *http.Request) {
     var req SigninRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-    
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -113,6 +149,10 @@ func (h *Handler) Signin(w http.ResponseWriter, r *http.Request) {
             utils.ErrorResponse(w, "Invalid email/phone or password", http.StatusUnauthorized)
         case ErrTooManyAttempts:
             utils.ErrorResponse(w, "Too many login attempts. Please try again later.", http.StatusTooManyRequests)
+        case ErrAccountLocked:
+            utils.ErrorResponse(w, "Account temporarily locked after too many failed attempts. Check your email to unlock it.", http.StatusLocked)
+        case ErrAccountSuspended:
+            utils.ErrorResponse(w, "This account has been suspended", http.StatusForbidden)
         default:
             utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
         }
@@ -127,16 +167,11 @@ func (h *Handler) Signin(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) VerifySigninOTP(w http.ResponseWriter, r *http.Request) {
     var req struct {
         PendingToken string `json:"pending_token" validate:"required"`
-        OTP          string `json:"otp" validate:"required,len=6,numeric"`
+        // A 6-digit code, or a recovery code for authenticator app users
+        OTP string `json:"otp" validate:"required,max=20"`
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-    
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -146,6 +181,18 @@ func (h *Handler) VerifySigninOTP(w http.ResponseWriter, r *http.Request) {
             utils.ErrorResponse(w, "Invalid OTP", http.StatusBadRequest)
             return
         }
+        if err == ErrTooManyAttempts {
+            utils.ErrorResponse(w, "Too many attempts. Please try again later.", http.StatusTooManyRequests)
+            return
+        }
+        if err == ErrTwoFactorUnavailable {
+            utils.ErrorResponse(w, err.Error(), http.StatusServiceUnavailable)
+            return
+        }
+        if err == ErrAccountSuspended {
+            utils.ErrorResponse(w, "This account has been suspended", http.StatusForbidden)
+            return
+        }
         utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
         return
     }
@@ -156,17 +203,19 @@ func (h *Handler) VerifySigninOTP(w http.ResponseWriter, r *http.Request) {
 // GoogleAuth handles Google OAuth
 func (h *Handler) GoogleAuth(w http.ResponseWriter, r *http.Request) {
     var req GoogleAuthRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    authResp, err := h.service.GoogleAuth(r.Context(), &req)
+    if err == ErrAccountSuspended {
+        utils.ErrorResponse(w, "This account has been suspended", http.StatusForbidden)
+        return
+    }
+    if err == ErrInvalidReferralCode {
+        utils.ErrorResponse(w, "Invalid referral code", http.StatusBadRequest)
         return
     }
-    
-    authResp, err := h.service.GoogleAuth(r.Context(), &req)
     if err != nil {
         utils.ErrorResponse(w, err.Error(), http.StatusUnauthorized)
         return
@@ -178,23 +227,22 @@ func (h *Handler) GoogleAuth(w http.ResponseWriter, r *http.Request) {
 // VerifyOTP handles OTP verification for signup
 func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
     var req OTPVerificationRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-    
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
     authResp, err := h.service.VerifySignupOTP(r.Context(), &req)
     if err != nil {
+        if otp.RespondRateLimited(w, err) {
+            return
+        }
         switch err {
         case ErrInvalidOTP:
             utils.ErrorResponse(w, "Invalid or expired OTP", http.StatusBadRequest)
         case ErrTooManyAttempts:
             utils.ErrorResponse(w, "Too many attempts. Please request a new code.", http.StatusTooManyRequests)
+        case ErrAccountSuspended:
+            utils.ErrorResponse(w, "This account has been suspended", http.StatusForbidden)
         default:
             utils.ErrorResponse(w, "Failed to verify OTP", http.StatusInternalServerError)
         }
@@ -212,13 +260,7 @@ func (h *Handler) ResendOTP(w http.ResponseWriter, r *http.Request) {
         Type  string `json:"type" validate:"required"` // signup, signin, password_reset
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-    
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -230,6 +272,9 @@ func (h *Handler) ResendOTP(w http.ResponseWriter, r *http.Request) {
     }
     
     if err := h.service.ResendOTP(r.Context(), resendReq); err != nil {
+        if otp.RespondRateLimited(w, err) {
+            return
+        }
         if err == ErrTooManyAttempts {
             utils.ErrorResponse(w, "Too many requests. Please wait before trying again.", http.StatusTooManyRequests)
             return
@@ -246,13 +291,7 @@ func (h *Handler) ResendOTP(w http.ResponseWriter, r *http.Request) {
 // RefreshToken handles token refresh
 func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
     var req RefreshTokenRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-    
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -268,13 +307,7 @@ func (h *Handler) RefreshToken(w http.ResponseWriter, r *http.Request) {
 // ForgotPassword initiates password reset
 func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
     var req PasswordResetRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-    
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -288,13 +321,7 @@ func (h *Handler) ForgotPassword(w http.ResponseWriter, r *http.Request) {
 // ResetPassword completes password reset
 func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
     var req PasswordResetConfirmRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
-        return
-    }
-    
-    if err := utils.ValidateStruct(req); err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -304,6 +331,9 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
             utils.ErrorResponse(w, "Invalid or expired reset token", http.StatusBadRequest)
             return
         }
+        if respondWeakPassword(w, err) {
+            return
+        }
         utils.ErrorResponse(w, "Failed to reset password", http.StatusInternalServerError)
         return
     }
@@ -313,6 +343,35 @@ func (h *Handler) ResetPassword(w http.ResponseWriter, r *http.Request) {
     }, http.StatusOK)
 }
 
+// UnlockAccount lifts a temporary lock using the token from the unlock email.
+// GET serves the emailed link; POST takes the token in the body.
+func (h *Handler) UnlockAccount(w http.ResponseWriter, r *http.Request) {
+    token := r.URL.Query().Get("token")
+    if r.Method == http.MethodPost {
+        var req struct {
+            Token string `json:"token" validate:"required"`
+        }
+        if !utils.DecodeAndValidate(w, r, &req) {
+            return
+        }
+        token = req.Token
+    }
+    
+    if token == "" {
+        utils.ErrorResponse(w, "Unlock token is required", http.StatusBadRequest)
+        return
+    }
+    
+    if err := h.service.UnlockAccount(r.Context(), token); err != nil {
+        utils.ErrorResponse(w, "Invalid or expired unlock link", http.StatusBadRequest)
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]string{
+        "message": "Account unlocked. You can sign in again.",
+    }, http.StatusOK)
+}
+
 // Logout handles user logout
 func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
     authHeader := r.Header.Get("Authorization")
@@ -341,7 +400,7 @@ func (h *Handler) Logout(w http.ResponseWriter, r *http.Request) {
 
 // LogoutAllDevices logs out from all devices
 func (h *Handler) LogoutAllDevices(w http.ResponseWriter, r *http.Request) {
-    userID, ok := r.Context().Value("userID").(int64)
+    userID, ok := UserIDFromContext(r.Context())
     if !ok {
         utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
         return
@@ -355,4 +414,363 @@ func (h *Handler) LogoutAllDevices(w http.ResponseWriter, r *http.Request) {
     utils.SuccessResponse(w, map[string]string{
         "message": "Logged out from all devices successfully",
     }, http.StatusOK)
-}
\ No newline at end of file
+}
+
+// ListSessions lists the devices the user is signed in on
+func (h *Handler) ListSessions(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    currentSessionID, _ := SessionIDFromContext(r.Context())
+    
+    sessions, err := h.service.ListSessions(r.Context(), userID, currentSessionID)
+    if err != nil {
+        utils.ErrorResponse(w, "Failed to list sessions", http.StatusInternalServerError)
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]interface{}{
+        "sessions": sessions,
+    }, http.StatusOK)
+}
+
+// RevokeSession signs the user out on one device
+func (h *Handler) RevokeSession(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    sessionID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.ErrorResponse(w, "Invalid session ID", http.StatusBadRequest)
+        return
+    }
+    
+    if err := h.service.RevokeSession(r.Context(), userID, sessionID); err != nil {
+        if err == ErrSessionNotFound {
+            utils.ErrorResponse(w, "Session not found", http.StatusNotFound)
+            return
+        }
+        utils.ErrorResponse(w, "Failed to revoke session", http.StatusInternalServerError)
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]string{
+        "message": "Session revoked successfully",
+    }, http.StatusOK)
+}
+
+// GetTwoFactorStatus shows the user's two-factor setup
+func (h *Handler) GetTwoFactorStatus(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    status, err := h.service.GetTwoFactorStatus(r.Context(), userID)
+    if err != nil {
+        respondTwoFactorError(w, err, "Failed to get two-factor status")
+        return
+    }
+    
+    utils.SuccessResponse(w, status, http.StatusOK)
+}
+
+// SetTwoFactorMethod selects how the user receives signin codes
+func (h *Handler) SetTwoFactorMethod(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var req TwoFactorMethodRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    if err := h.service.SetTwoFactorMethod(r.Context(), userID, req.Method, req.Code); err != nil {
+        respondTwoFactorError(w, err, "Failed to update two-factor method")
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]string{
+        "message": "Two-factor method updated",
+    }, http.StatusOK)
+}
+
+// EnrollTOTP starts authenticator app setup
+func (h *Handler) EnrollTOTP(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    enrollment, err := h.service.EnrollTOTP(r.Context(), userID)
+    if err != nil {
+        respondTwoFactorError(w, err, "Failed to start authenticator setup")
+        return
+    }
+    
+    utils.SuccessResponse(w, enrollment, http.StatusOK)
+}
+
+// ConfirmTOTP finishes authenticator app setup and returns recovery codes
+func (h *Handler) ConfirmTOTP(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var req TwoFactorCodeRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    codes, err := h.service.ConfirmTOTP(r.Context(), userID, req.Code)
+    if err != nil {
+        respondTwoFactorError(w, err, "Failed to confirm authenticator")
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]interface{}{
+        "message":        "Authenticator app enabled. Store these recovery codes somewhere safe.",
+        "recovery_codes": codes,
+    }, http.StatusOK)
+}
+
+// DisableTOTP removes the authenticator app
+func (h *Handler) DisableTOTP(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var req TwoFactorCodeRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    if err := h.service.DisableTOTP(r.Context(), userID, req.Code); err != nil {
+        respondTwoFactorError(w, err, "Failed to disable authenticator")
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]string{
+        "message": "Authenticator app disabled",
+    }, http.StatusOK)
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes
+func (h *Handler) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var req TwoFactorCodeRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    codes, err := h.service.RegenerateRecoveryCodes(r.Context(), userID, req.Code)
+    if err != nil {
+        respondTwoFactorError(w, err, "Failed to regenerate recovery codes")
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]interface{}{
+        "recovery_codes": codes,
+    }, http.StatusOK)
+}
+
+// ChangeUsername picks a new username
+func (h *Handler) ChangeUsername(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var req UsernameChangeRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    user, err := h.service.ChangeUsername(r.Context(), userID, req.Username)
+    if err != nil {
+        respondIdentifierError(w, err, "Failed to change username")
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]interface{}{
+        "message": "Username changed",
+        "user":    user,
+    }, http.StatusOK)
+}
+
+// RequestEmailChange sends a verification code to a new email address
+func (h *Handler) RequestEmailChange(w http.ResponseWriter, r *http.Request) {
+    var req EmailChangeRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    h.requestIdentifierChange(w, r, IdentifierEmail, req.Email)
+}
+
+// RequestPhoneChange sends a verification code to a new phone number
+func (h *Handler) RequestPhoneChange(w http.ResponseWriter, r *http.Request) {
+    var req PhoneChangeRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    h.requestIdentifierChange(w, r, IdentifierPhone, req.Phone)
+}
+
+func (h *Handler) requestIdentifierChange(w http.ResponseWriter, r *http.Request, kind, value string) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    change, err := h.service.RequestIdentifierChange(r.Context(), userID, kind, value)
+    if err != nil {
+        respondIdentifierError(w, err, "Failed to send verification code")
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]interface{}{
+        "message": i18n.T(i18n.FromRequest(r), "otp.sent", i18n.Vars{"recipient": change.Value}),
+        "pending": change,
+    }, http.StatusOK)
+}
+
+// ConfirmEmailChange verifies the code sent to the new email and switches to it
+func (h *Handler) ConfirmEmailChange(w http.ResponseWriter, r *http.Request) {
+    h.confirmIdentifierChange(w, r, IdentifierEmail)
+}
+
+// ConfirmPhoneChange verifies the code sent to the new phone and switches to it
+func (h *Handler) ConfirmPhoneChange(w http.ResponseWriter, r *http.Request) {
+    h.confirmIdentifierChange(w, r, IdentifierPhone)
+}
+
+func (h *Handler) confirmIdentifierChange(w http.ResponseWriter, r *http.Request, kind string) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var req IdentifierCodeRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    user, err := h.service.ConfirmIdentifierChange(r.Context(), userID, kind, req.Code)
+    if err != nil {
+        respondIdentifierError(w, err, "Failed to update "+kind)
+        return
+    }
+    
+    message := "Email updated"
+    if kind == IdentifierPhone {
+        message = "Phone number updated"
+    }
+    utils.SuccessResponse(w, map[string]interface{}{
+        "message": message,
+        "user":    user,
+    }, http.StatusOK)
+}
+
+// GetIdentifierHistory lists past username, email and phone changes
+func (h *Handler) GetIdentifierHistory(w http.ResponseWriter, r *http.Request) {
+    userID, ok := UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    changes, err := h.service.GetIdentifierHistory(r.Context(), userID)
+    if err != nil {
+        utils.ErrorResponse(w, "Failed to get history", http.StatusInternalServerError)
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]interface{}{
+        "changes": changes,
+    }, http.StatusOK)
+}
+
+// respondWeakPassword reports a refused password with its strength score
+// and suggestions, returning false for other errors
+func respondWeakPassword(w http.ResponseWriter, err error) bool {
+    var weak *password.WeakPasswordError
+    if !errors.As(err, &weak) {
+        return false
+    }
+    
+    utils.RespondWithJSON(w, http.StatusBadRequest, map[string]interface{}{
+        "success": false,
+        "error":   "Password " + strings.Join(weak.Reasons, "; "),
+        "details": weak,
+    })
+    return true
+}
+
+func respondTwoFactorError(w http.ResponseWriter, err error, fallback string) {
+    switch err {
+    case ErrInvalidOTP:
+        utils.ErrorResponse(w, "Invalid code", http.StatusBadRequest)
+    case ErrTOTPNotEnrolled:
+        utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+    case ErrTOTPAlreadyEnabled:
+        utils.ErrorResponse(w, err.Error(), http.StatusConflict)
+    case ErrInvalidTwoFactorMethod:
+        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    case ErrTwoFactorCodeRequired:
+        utils.ErrorResponse(w, err.Error(), http.StatusForbidden)
+    case ErrTooManyAttempts:
+        utils.ErrorResponse(w, "Too many wrong codes. Please try again later.", http.StatusTooManyRequests)
+    case ErrTwoFactorUnavailable:
+        utils.ErrorResponse(w, err.Error(), http.StatusServiceUnavailable)
+    case ErrUserNotFound:
+        utils.ErrorResponse(w, "User not found", http.StatusNotFound)
+    default:
+        utils.ErrorResponse(w, fallback, http.StatusInternalServerError)
+    }
+}
+
+func respondIdentifierError(w http.ResponseWriter, err error, fallback string) {
+    switch {
+    case errors.Is(err, ErrEmailAlreadyExists):
+        utils.ErrorResponse(w, "Email already registered", http.StatusConflict)
+    case errors.Is(err, ErrPhoneAlreadyExists):
+        utils.ErrorResponse(w, "Phone number already registered", http.StatusConflict)
+    case errors.Is(err, ErrUsernameAlreadyExists):
+        utils.ErrorResponse(w, "Username already taken", http.StatusConflict)
+    case errors.Is(err, ErrUsernameChangeTooSoon):
+        utils.ErrorResponse(w, err.Error(), http.StatusTooManyRequests)
+    case otp.RespondRateLimited(w, err):
+    case errors.Is(err, otp.ErrEmailSuppressed):
+        utils.ErrorResponse(w, "This email address can't receive email, please use another one", http.StatusUnprocessableEntity)
+    case errors.Is(err, ErrSameIdentifier), errors.Is(err, ErrNoPendingChange):
+        utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+    case errors.Is(err, ErrInvalidOTP):
+        utils.ErrorResponse(w, "Invalid or expired code", http.StatusBadRequest)
+    default:
+        utils.ErrorResponse(w, fallback, http.StatusInternalServerError)
+    }
+}
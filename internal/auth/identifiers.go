@@ -0,0 +1,177 @@
+// internal/auth/identifiers.go
+// Changing username, email and phone after signup
+//
+// A new email or phone is staged and only swapped in once the OTP sent to
+// it is verified, so an account can't be moved to an address its owner
+// doesn't control. Usernames change immediately but only once per cooldown.
+
+package auth
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/otp"
+)
+
+var (
+    ErrNoPendingChange       = errors.New("no pending change, or it has expired")
+    ErrSameIdentifier        = errors.New("new value is the same as the current one")
+    ErrUsernameChangeTooSoon = errors.New("username was changed too recently")
+)
+
+// UsernameCooldownError says when the username can next be changed. It
+// matches ErrUsernameChangeTooSoon with errors.Is.
+type UsernameCooldownError struct {
+    NextChangeAt time.Time
+}
+
+func (e *UsernameCooldownError) Error() string {
+    return fmt.Sprintf("%s, try again after %s", ErrUsernameChangeTooSoon, e.NextChangeAt.Format(time.RFC3339))
+}
+
+func (e *UsernameCooldownError) Is(target error) bool {
+    return target == ErrUsernameChangeTooSoon
+}
+
+// pendingIdentifierTTL is how long a staged email or phone waits for its OTP
+const pendingIdentifierTTL = 30 * time.Minute
+
+// ChangeUsername renames the user, at most once per UsernameChangeCooldown
+func (s *service) ChangeUsername(ctx context.Context, userID int64, username string) (*User, error) {
+    username = strings.ToLower(strings.TrimSpace(username))
+
+    user, err := s.repo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if user.Username == username {
+        return nil, ErrSameIdentifier
+    }
+
+    if s.config.UsernameChangeCooldown > 0 {
+        last, err := s.repo.LastIdentifierChange(ctx, userID, IdentifierUsername)
+        if err != nil {
+            return nil, err
+        }
+        if last != nil {
+            if next := last.Add(s.config.UsernameChangeCooldown); time.Now().Before(next) {
+                return nil, &UsernameCooldownError{NextChangeAt: next}
+            }
+        }
+    }
+
+    if taken, err := s.repo.IsUsernameTaken(ctx, username); err != nil {
+        return nil, fmt.Errorf("failed to check username: %w", err)
+    } else if taken {
+        return nil, ErrUsernameAlreadyExists
+    }
+
+    if err := s.repo.ApplyIdentifierChange(ctx, userID, IdentifierUsername, username); err != nil {
+        return nil, err
+    }
+
+    return s.repo.GetUserByID(ctx, userID)
+}
+
+// RequestIdentifierChange stages a new email or phone and sends an OTP to it
+func (s *service) RequestIdentifierChange(ctx context.Context, userID int64, kind, value string) (*PendingIdentifierChange, error) {
+    user, err := s.repo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    otpReq := &otp.SendOTPRequest{UserID: userID, Language: user.PreferredLanguage}
+    switch kind {
+    case IdentifierEmail:
+        value = strings.ToLower(strings.TrimSpace(value))
+        if user.Email != nil && strings.EqualFold(*user.Email, value) {
+            return nil, ErrSameIdentifier
+        }
+        if taken, err := s.repo.IsEmailTaken(ctx, value); err != nil {
+            return nil, fmt.Errorf("failed to check email: %w", err)
+        } else if taken {
+            return nil, ErrEmailAlreadyExists
+        }
+        otpReq.Email, otpReq.Type, otpReq.Method = value, otp.OTPTypeEmailVerify, otp.DeliveryMethodEmail
+    case IdentifierPhone:
+        value = strings.TrimSpace(value)
+        if user.Phone != nil && *user.Phone == value {
+            return nil, ErrSameIdentifier
+        }
+        if taken, err := s.repo.IsPhoneTaken(ctx, value); err != nil {
+            return nil, fmt.Errorf("failed to check phone: %w", err)
+        } else if taken {
+            return nil, ErrPhoneAlreadyExists
+        }
+        otpReq.Phone, otpReq.Type, otpReq.Method = value, otp.OTPTypePhoneVerify, otp.DeliveryMethodSMS
+    default:
+        return nil, fmt.Errorf("unknown identifier %q", kind)
+    }
+
+    change := &PendingIdentifierChange{
+        Kind:      kind,
+        Value:     value,
+        ExpiresAt: time.Now().Add(pendingIdentifierTTL),
+    }
+    if err := s.repo.SavePendingIdentifierChange(ctx, userID, change); err != nil {
+        return nil, err
+    }
+
+    if _, err := s.otpService.GenerateOTP(ctx, otpReq); err != nil {
+        return nil, fmt.Errorf("failed to send verification code: %w", err)
+    }
+
+    return change, nil
+}
+
+// ConfirmIdentifierChange verifies the OTP sent to a staged email or phone
+// and swaps it in
+func (s *service) ConfirmIdentifierChange(ctx context.Context, userID int64, kind, code string) (*User, error) {
+    change, err := s.repo.GetPendingIdentifierChange(ctx, userID, kind)
+    if err != nil {
+        return nil, err
+    }
+
+    otpType := otp.OTPTypeEmailVerify
+    if kind == IdentifierPhone {
+        otpType = otp.OTPTypePhoneVerify
+    }
+    otpReq := &otp.VerifyOTPRequest{
+        UserID: userID,
+        Code:   code,
+        Type:   otpType,
+    }
+    if err := s.otpService.VerifyOTP(ctx, otpReq); err != nil {
+        if errors.Is(err, otp.ErrRateLimitExceeded) {
+            return nil, err
+        }
+        return nil, fmt.Errorf("%w: %v", ErrInvalidOTP, err)
+    }
+
+    // The address may have been claimed by someone else while we waited
+    if err := s.repo.ApplyIdentifierChange(ctx, userID, kind, change.Value); err != nil {
+        return nil, err
+    }
+
+    return s.repo.GetUserByID(ctx, userID)
+}
+
+// GetIdentifierHistory lists the user's past username, email and phone changes
+func (s *service) GetIdentifierHistory(ctx context.Context, userID int64) ([]*IdentifierChange, error) {
+    return s.repo.ListIdentifierChanges(ctx, userID)
+}
+
+// identifierTakenError is the "already exists" error for an identifier
+func identifierTakenError(kind string) error {
+    switch kind {
+    case IdentifierEmail:
+        return ErrEmailAlreadyExists
+    case IdentifierPhone:
+        return ErrPhoneAlreadyExists
+    }
+    return ErrUsernameAlreadyExists
+}
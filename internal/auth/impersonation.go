@@ -0,0 +1,96 @@
+// internal/auth/impersonation.go
+// Support sessions: staff signed in as a user to reproduce a problem
+
+package auth
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// MaxImpersonationTTL caps how long a support session lasts
+const MaxImpersonationTTL = 1 * time.Hour
+
+var ErrCannotImpersonate = errors.New("staff accounts can't be impersonated")
+
+// Impersonate opens a support session for staff member adminID acting as
+// userID. Only an access token is issued, so the session can't be extended,
+// and the token carries adminID so requests made with it can be told apart.
+// The session shows up in the user's session list and can be revoked like
+// any other.
+func (s *service) Impersonate(ctx context.Context, adminID, userID int64, ttl time.Duration) (*AuthResponse, error) {
+    if ttl <= 0 || ttl > MaxImpersonationTTL {
+        ttl = MaxImpersonationTTL
+    }
+
+    user, err := s.repo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if IsStaff(user.Role) {
+        return nil, ErrCannotImpersonate
+    }
+
+    sessionID, err := s.repo.NextSessionID(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    email := ""
+    if user.Email != nil {
+        email = *user.Email
+    }
+
+    now := time.Now()
+    accessToken, err := utils.GenerateJWT(&utils.JWTClaims{
+        UserID:         user.ID,
+        Email:          email,
+        Username:       user.Username,
+        Type:           "access",
+        SessionID:      sessionID,
+        ImpersonatorID: adminID,
+        ExpiresAt:      now.Add(ttl).Unix(),
+        IssuedAt:       now.Unix(),
+        NotBefore:      now.Unix(),
+        Issuer:         "kiekky-backend",
+        Subject:        fmt.Sprintf("%d", user.ID),
+    }, s.config.JWTSecret)
+    if err != nil {
+        return nil, fmt.Errorf("failed to generate access token: %w", err)
+    }
+
+    // The refresh token column is required, but this one is never handed out
+    refreshToken := s.generateSecureToken()
+
+    client := middleware.ClientInfoFromContext(ctx)
+    label := fmt.Sprintf("Support session (staff #%d)", adminID)
+    expiresAt := now.Add(ttl)
+    session := &Session{
+        ID:               sessionID,
+        UserID:           user.ID,
+        Token:            accessToken,
+        RefreshToken:     refreshToken,
+        DeviceInfo:       &label,
+        IPAddress:        optionalString(client.IP),
+        ExpiresAt:        expiresAt,
+        CreatedAt:        now,
+        UserAgent:        optionalString(client.UserAgent),
+        LastUsedAt:       &now,
+        RefreshExpiresAt: &expiresAt,
+    }
+    if err := s.repo.CreateSession(ctx, session); err != nil {
+        return nil, fmt.Errorf("failed to create session: %w", err)
+    }
+
+    return &AuthResponse{
+        User:        user,
+        AccessToken: accessToken,
+        ExpiresIn:   int(ttl.Seconds()),
+        TokenType:   "Bearer",
+    }, nil
+}
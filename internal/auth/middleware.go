@@ -4,10 +4,12 @@
 package auth
 
 import (
-    "context"
+    "errors"
+    "log"
     "net/http"
     "strings"
     
+    "github.com/imadgeboyega/kiekky-backend/internal/common/errorreport"
     "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
 )
 
@@ -47,14 +49,26 @@ func (m *Middleware) Authenticate(next http.Handler) http.Handler {
             return
         }
         
-        // 4. Add user information to request context
+        // 4. Reject tokens from signed-out sessions. When neither Redis nor
+        // the database can say, the session may have been revoked, so the
+        // token isn't trusted.
+        if err := m.service.CheckSession(r.Context(), claims); err != nil {
+            if errors.Is(err, ErrSessionRevoked) {
+                utils.ErrorResponse(w, "Session has been revoked", http.StatusUnauthorized)
+                return
+            }
+            log.Printf("Failed to check session %d: %v", claims.SessionID, err)
+            utils.ErrorResponse(w, "Unable to verify session, please try again", http.StatusServiceUnavailable)
+            return
+        }
+        
+        // 5. Add user information to request context
         // This allows handlers to access user data without another database query
-        ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-        ctx = context.WithValue(ctx, "email", claims.Email)
-        ctx = context.WithValue(ctx, "username", claims.Username)
+        ctx := withClaims(r.Context(), claims)
+        errorreport.SetUser(ctx, claims.UserID)
         
-        // 5. Pass to the next handler with the updated context
-        next.ServeHTTP(w, r.WithContext(ctx))
+        // 6. Pass to the next handler with the updated context
+        m.TrackActivity(next).ServeHTTP(w, r.WithContext(ctx))
     })
 }
 
@@ -78,15 +92,28 @@ func (m *Middleware) OptionalAuthenticate(next http.Handler) http.Handler {
             return
         }
         
-        // 3. If valid, add user context
-        if claims.Type == "access" {
-            ctx := context.WithValue(r.Context(), "userID", claims.UserID)
-            ctx = context.WithValue(ctx, "email", claims.Email)
-            ctx = context.WithValue(ctx, "username", claims.Username)
-            r = r.WithContext(ctx)
+        // 3. If valid and the session is known to be live, add user context
+        if claims.Type == "access" && m.service.CheckSession(r.Context(), claims) == nil {
+            r = r.WithContext(withClaims(r.Context(), claims))
+            errorreport.SetUser(r.Context(), claims.UserID)
         }
         
         // 4. Continue with or without user context
+        m.TrackActivity(next).ServeHTTP(w, r)
+    })
+}
+
+// TrackActivity records the authenticated user as active, throttled so only
+// the first request in a while writes. Authenticate and OptionalAuthenticate
+// apply it; requests made while impersonating don't count.
+func (m *Middleware) TrackActivity(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        userID, ok := UserIDFromContext(r.Context())
+        _, impersonated := ImpersonatorIDFromContext(r.Context())
+        if ok && !impersonated {
+            m.service.TouchActivity(r.Context(), userID)
+        }
+        
         next.ServeHTTP(w, r)
     })
 }
@@ -96,7 +123,7 @@ func (m *Middleware) OptionalAuthenticate(next http.Handler) http.Handler {
 func (m *Middleware) RequireVerified(next http.Handler) http.Handler {
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         // 1. Get user ID from context (set by Authenticate)
-        userID, ok := r.Context().Value("userID").(int64)
+        userID, ok := UserIDFromContext(r.Context())
         if !ok {
             utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
             return
@@ -124,7 +151,7 @@ func (m *Middleware) RequireVerified(next http.Handler) http.Handler {
 func (m *Middleware) RequireProfileComplete(next http.Handler) http.Handler {
     return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
         // 1. Get user ID from context
-        userID, ok := r.Context().Value("userID").(int64)
+        userID, ok := UserIDFromContext(r.Context())
         if !ok {
             utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
             return
@@ -165,23 +192,3 @@ func (m *Middleware) extractToken(r *http.Request) string {
     // 3. Return the token part
     return parts[1]
 }
-
-// Helper functions for handlers to get user info from context
-
-// GetUserIDFromContext extracts user ID from request context
-func GetUserIDFromContext(ctx context.Context) (int64, bool) {
-    userID, ok := ctx.Value("userID").(int64)
-    return userID, ok
-}
-
-// GetEmailFromContext extracts email from request context
-func GetEmailFromContext(ctx context.Context) (string, bool) {
-    email, ok := ctx.Value("email").(string)
-    return email, ok
-}
-
-// GetUsernameFromContext extracts username from request context
-func GetUsernameFromContext(ctx context.Context) (string, bool) {
-    username, ok := ctx.Value("username").(string)
-    return username, ok
-}
\ No newline at end of file
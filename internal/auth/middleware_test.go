@@ -0,0 +1,72 @@
+package auth
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/http/httptest"
+    "testing"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// sessionService accepts any token and answers CheckSession with err
+type sessionService struct {
+    Service
+    err error
+}
+
+func (s sessionService) ValidateToken(ctx context.Context, token string) (*utils.JWTClaims, error) {
+    return &utils.JWTClaims{UserID: 1, Type: "access", SessionID: 9}, nil
+}
+
+func (s sessionService) CheckSession(ctx context.Context, claims *utils.JWTClaims) error {
+    return s.err
+}
+
+func (s sessionService) TouchActivity(ctx context.Context, userID int64) {}
+
+func TestAuthenticateSessionCheck(t *testing.T) {
+    tests := []struct {
+        name       string
+        err        error
+        wantStatus int
+    }{
+        {name: "live session", wantStatus: http.StatusOK},
+        {name: "revoked session", err: ErrSessionRevoked, wantStatus: http.StatusUnauthorized},
+        {name: "session store down", err: errors.New("connection refused"), wantStatus: http.StatusServiceUnavailable},
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            m := NewMiddleware(sessionService{err: tt.err})
+            handler := m.Authenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+                w.WriteHeader(http.StatusOK)
+            }))
+
+            req := httptest.NewRequest("GET", "/api/v1/profile", nil)
+            req.Header.Set("Authorization", "Bearer token")
+            rec := httptest.NewRecorder()
+            handler.ServeHTTP(rec, req)
+
+            if rec.Code != tt.wantStatus {
+                t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+            }
+        })
+    }
+}
+
+func TestOptionalAuthenticateSessionCheck(t *testing.T) {
+    for _, err := range []error{ErrSessionRevoked, errors.New("connection refused")} {
+        m := NewMiddleware(sessionService{err: err})
+        handler := m.OptionalAuthenticate(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+            if _, ok := UserIDFromContext(r.Context()); ok {
+                t.Errorf("CheckSession error %q: request was authenticated", err)
+            }
+        }))
+
+        req := httptest.NewRequest("GET", "/api/v1/posts/1", nil)
+        req.Header.Set("Authorization", "Bearer token")
+        handler.ServeHTTP(httptest.NewRecorder(), req)
+    }
+}
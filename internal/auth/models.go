@@ -23,6 +23,9 @@ type User struct {
     ProviderID        *string   `json:"provider_id" db:"provider_id"`          // Google user ID
     IsVerified        bool      `json:"is_verified" db:"is_verified"`
     IsProfileComplete bool      `json:"is_profile_complete" db:"is_profile_complete"`
+    PreferredLanguage string    `json:"preferred_language" db:"preferred_language"` // Language for notifications and OTPs
+    Role              string    `json:"role" db:"role"`                        // user, support or admin
+    AccountStatus     string    `json:"account_status" db:"account_status"`    // active, suspended, banned or deleted
     CreatedAt         time.Time `json:"created_at" db:"created_at"`
     UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -51,6 +54,116 @@ type Session struct {
     IPAddress    *string   `json:"ip_address" db:"ip_address"`
     ExpiresAt    time.Time `json:"expires_at" db:"expires_at"`
     CreatedAt    time.Time `json:"created_at" db:"created_at"`
+    
+    // Device details captured at login
+    UserAgent         *string    `json:"user_agent" db:"user_agent"`
+    DeviceFingerprint *string    `json:"-" db:"device_fingerprint"`
+    Location          *string    `json:"location" db:"location"`
+    LastUsedAt        *time.Time `json:"last_used_at" db:"last_used_at"`
+    RefreshExpiresAt  *time.Time `json:"-" db:"refresh_expires_at"`
+}
+
+// SessionInfo is a signed-in device as shown to its owner
+type SessionInfo struct {
+    ID         int64     `json:"id"`
+    Device     string    `json:"device"`
+    IPAddress  string    `json:"ip_address,omitempty"`
+    Location   string    `json:"location,omitempty"`
+    LastUsedAt time.Time `json:"last_used_at"`
+    CreatedAt  time.Time `json:"created_at"`
+    Current    bool      `json:"current"`
+}
+
+// Two-factor methods a user can choose
+const (
+    TwoFactorOTP  = "otp"  // Code sent by email or SMS
+    TwoFactorTOTP = "totp" // Code from an authenticator app
+)
+
+// TOTPSecret is a user's authenticator app enrollment
+type TOTPSecret struct {
+    UserID       int64      `db:"user_id"`
+    Secret       string     `db:"secret"` // Encrypted
+    ConfirmedAt  *time.Time `db:"confirmed_at"`
+    LastUsedStep int64      `db:"last_used_step"`
+    CreatedAt    time.Time  `db:"created_at"`
+}
+
+// LoginLocation is a network a user has signed in from before
+type LoginLocation struct {
+    Network     string    `db:"network"`
+    Country     *string   `db:"country"`
+    FirstSeenAt time.Time `db:"first_seen_at"`
+    LastSeenAt  time.Time `db:"last_seen_at"`
+}
+
+// TwoFactorStatus is a user's two-factor setup
+type TwoFactorStatus struct {
+    Method                 string `json:"method"` // "", otp or totp
+    Required               bool   `json:"required"`
+    TOTPEnabled            bool   `json:"totp_enabled"`
+    RecoveryCodesRemaining int    `json:"recovery_codes_remaining"`
+}
+
+// TOTPEnrollment is shown once while setting up an authenticator app
+type TOTPEnrollment struct {
+    Secret          string `json:"secret"`
+    ProvisioningURI string `json:"provisioning_uri"`
+}
+
+// TwoFactorCodeRequest carries an authenticator or recovery code
+type TwoFactorCodeRequest struct {
+    Code string `json:"code" validate:"required,max=20"`
+}
+
+// TwoFactorMethodRequest selects how a user receives two-factor codes
+type TwoFactorMethodRequest struct {
+    Method string `json:"method" validate:"omitempty,oneof=otp totp none"`
+    // An authenticator or recovery code, required once an authenticator is set up
+    Code string `json:"code,omitempty" validate:"max=20"`
+}
+
+// Identifiers a user can change after signup
+const (
+    IdentifierUsername = "username"
+    IdentifierEmail    = "email"
+    IdentifierPhone    = "phone"
+)
+
+// UsernameChangeRequest picks a new username
+type UsernameChangeRequest struct {
+    Username string `json:"username" validate:"required,min=3,max=30,alphanum"`
+}
+
+// EmailChangeRequest stages a new email address until it is verified
+type EmailChangeRequest struct {
+    Email string `json:"email" validate:"required,email"`
+}
+
+// PhoneChangeRequest stages a new phone number until it is verified
+type PhoneChangeRequest struct {
+    Phone string `json:"phone" validate:"required,e164"`
+}
+
+// IdentifierCodeRequest carries the OTP sent to a new email or phone
+type IdentifierCodeRequest struct {
+    Code string `json:"code" validate:"required,len=6,numeric"`
+}
+
+// PendingIdentifierChange is a new email or phone waiting for its OTP
+type PendingIdentifierChange struct {
+    Kind      string    `json:"kind"`
+    Value     string    `json:"value"`
+    ExpiresAt time.Time `json:"expires_at"`
+}
+
+// IdentifierChange is an entry in a user's username, email and phone history
+type IdentifierChange struct {
+    ID        int64     `json:"id"`
+    Kind      string    `json:"kind"`
+    OldValue  *string   `json:"old_value,omitempty"`
+    NewValue  string    `json:"new_value"`
+    ChangedAt time.Time `json:"changed_at"`
 }
 
 // SignupRequest is what the client sends to create an account
@@ -62,6 +175,7 @@ type SignupRequest struct {
     Password        string  `json:"password" validate:"required,min=8,max=100"`
     ConfirmPassword string  `json:"confirm_password" validate:"required,eqfield=Password"`
     AcceptTerms     bool    `json:"accept_terms" validate:"required"`
+    ReferralCode    string  `json:"referral_code" validate:"omitempty,max=20"`
 }
 
 // SigninRequest handles both email and username login
@@ -72,7 +186,8 @@ type SigninRequest struct {
 
 // GoogleAuthRequest for OAuth signin/signup
 type GoogleAuthRequest struct {
-    IDToken string `json:"id_token" validate:"required"` // Google ID token from frontend
+    IDToken      string `json:"id_token" validate:"required"` // Google ID token from frontend
+    ReferralCode string `json:"referral_code" validate:"omitempty,max=20"` // Only used when this creates the account
 }
 
 // OTPVerificationRequest for verifying email/phone
@@ -11,6 +11,9 @@ import (
     "time"
     
     "github.com/lib/pq"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
 )
 
 // Repository interface defines all database operations for auth
@@ -39,6 +42,41 @@ type Repository interface {
     UpdateSession(ctx context.Context, session *Session) error
     DeleteSessionByToken(ctx context.Context, token string) error
     DeleteUserSessions(ctx context.Context, userID int64) error
+    NextSessionID(ctx context.Context) (int64, error)
+    ListUserSessions(ctx context.Context, userID int64) ([]*Session, error)
+    SessionExists(ctx context.Context, sessionID int64) (bool, error)
+    TouchSession(ctx context.Context, sessionID int64, ipAddress string, interval time.Duration) error
+    TouchLastActive(ctx context.Context, userID int64, interval time.Duration) error
+    DeleteSession(ctx context.Context, sessionID int64) error
+    DeleteUserSession(ctx context.Context, userID, sessionID int64) (bool, error)
+    
+    // Known devices
+    RememberDevice(ctx context.Context, userID int64, fingerprint, label string) (bool, error)
+    HasDevices(ctx context.Context, userID int64) (bool, error)
+    
+    // Signin history
+    GetLoginLocations(ctx context.Context, userID int64) ([]*LoginLocation, error)
+    RecordLoginLocation(ctx context.Context, userID int64, network, country string) error
+    
+    // Two-factor authentication
+    GetTwoFactorMethod(ctx context.Context, userID int64) (string, error)
+    SetTwoFactorMethod(ctx context.Context, userID int64, method string) error
+    SaveTOTPSecret(ctx context.Context, userID int64, secret string) error
+    GetTOTPSecret(ctx context.Context, userID int64) (*TOTPSecret, error)
+    ConfirmTOTP(ctx context.Context, userID int64) error
+    UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error)
+    DeleteTOTPSecret(ctx context.Context, userID int64) error
+    ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error
+    UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error)
+    CountRecoveryCodes(ctx context.Context, userID int64) (int, error)
+    DeleteRecoveryCodes(ctx context.Context, userID int64) error
+    
+    // Username, email and phone changes
+    SavePendingIdentifierChange(ctx context.Context, userID int64, change *PendingIdentifierChange) error
+    GetPendingIdentifierChange(ctx context.Context, userID int64, kind string) (*PendingIdentifierChange, error)
+    ApplyIdentifierChange(ctx context.Context, userID int64, kind, value string) error
+    LastIdentifierChange(ctx context.Context, userID int64, kind string) (*time.Time, error)
+    ListIdentifierChanges(ctx context.Context, userID int64) ([]*IdentifierChange, error)
 }
 
 // postgresRepository implements Repository using PostgreSQL
@@ -54,8 +92,8 @@ func NewPostgresRepository(db *sql.DB) Repository {
 // CreateUser inserts a new user into the database
 func (r *postgresRepository) CreateUser(ctx context.Context, user *User) error {
     query := `
-        INSERT INTO users (email, username, password_hash, phone, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO users (email, username, password_hash, phone, preferred_language, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id`
     
     // QueryRowContext executes the query and returns a single row
@@ -67,6 +105,7 @@ func (r *postgresRepository) CreateUser(ctx context.Context, user *User) error {
         user.Username,
         user.PasswordHash,
         user.Phone,
+        i18n.Match(user.PreferredLanguage),
         user.CreatedAt,
         user.UpdatedAt,
     ).Scan(&user.ID)
@@ -89,7 +128,8 @@ func (r *postgresRepository) GetUserByID(ctx context.Context, id int64) (*User,
     user := &User{}
     query := `
         SELECT id, email, username, password_hash, phone, is_verified, 
-               is_profile_complete, created_at, updated_at
+               is_profile_complete, COALESCE(preferred_language, 'en'),
+               COALESCE(role, 'user'), COALESCE(account_status, 'active'), created_at, updated_at
         FROM users
         WHERE id = $1`
     
@@ -101,6 +141,9 @@ func (r *postgresRepository) GetUserByID(ctx context.Context, id int64) (*User,
         &user.Phone,
         &user.IsVerified,
         &user.IsProfileComplete,
+        &user.PreferredLanguage,
+        &user.Role,
+        &user.AccountStatus,
         &user.CreatedAt,
         &user.UpdatedAt,
     )
@@ -120,7 +163,8 @@ func (r *postgresRepository) GetUserByEmail(ctx context.Context, email string) (
     user := &User{}
     query := `
         SELECT id, email, username, password_hash, phone, is_verified, 
-               is_profile_complete, created_at, updated_at
+               is_profile_complete, COALESCE(preferred_language, 'en'),
+               COALESCE(role, 'user'), COALESCE(account_status, 'active'), created_at, updated_at
         FROM users
         WHERE LOWER(email) = LOWER($1)`
     
@@ -133,6 +177,9 @@ func (r *postgresRepository) GetUserByEmail(ctx context.Context, email string) (
         &user.Phone,
         &user.IsVerified,
         &user.IsProfileComplete,
+        &user.PreferredLanguage,
+        &user.Role,
+        &user.AccountStatus,
         &user.CreatedAt,
         &user.UpdatedAt,
     )
@@ -152,7 +199,8 @@ func (r *postgresRepository) GetUserByUsername(ctx context.Context, username str
     user := &User{}
     query := `
         SELECT id, email, username, password_hash, phone, is_verified, 
-               is_profile_complete, created_at, updated_at
+               is_profile_complete, COALESCE(preferred_language, 'en'),
+               COALESCE(role, 'user'), COALESCE(account_status, 'active'), created_at, updated_at
         FROM users
         WHERE LOWER(username) = LOWER($1)`
     
@@ -164,6 +212,9 @@ func (r *postgresRepository) GetUserByUsername(ctx context.Context, username str
         &user.Phone,
         &user.IsVerified,
         &user.IsProfileComplete,
+        &user.PreferredLanguage,
+        &user.Role,
+        &user.AccountStatus,
         &user.CreatedAt,
         &user.UpdatedAt,
     )
@@ -185,7 +236,8 @@ func (r *postgresRepository) GetUserByPhone(ctx context.Context, phone string) (
     
     query := `
         SELECT id, username, email, phone, password_hash, provider, provider_id, 
-               is_verified, created_at, updated_at
+               is_verified, COALESCE(preferred_language, 'en'),
+               COALESCE(role, 'user'), COALESCE(account_status, 'active'), created_at, updated_at
         FROM users
         WHERE phone = $1
         LIMIT 1
@@ -200,6 +252,9 @@ func (r *postgresRepository) GetUserByPhone(ctx context.Context, phone string) (
         &user.Provider,
         &providerID,
         &user.IsVerified,
+        &user.PreferredLanguage,
+        &user.Role,
+        &user.AccountStatus,
         &user.CreatedAt,
         &user.UpdatedAt,
     )
@@ -230,7 +285,8 @@ func (r *postgresRepository) GetUserByEmailOrUsername(ctx context.Context, email
     user := &User{}
     query := `
         SELECT id, email, username, password_hash, phone, is_verified, 
-               is_profile_complete, created_at, updated_at
+               is_profile_complete, COALESCE(preferred_language, 'en'),
+               COALESCE(role, 'user'), COALESCE(account_status, 'active'), created_at, updated_at
         FROM users
         WHERE LOWER(email) = LOWER($1) OR LOWER(username) = LOWER($1)`
     
@@ -242,6 +298,9 @@ func (r *postgresRepository) GetUserByEmailOrUsername(ctx context.Context, email
         &user.Phone,
         &user.IsVerified,
         &user.IsProfileComplete,
+        &user.PreferredLanguage,
+        &user.Role,
+        &user.AccountStatus,
         &user.CreatedAt,
         &user.UpdatedAt,
     )
@@ -261,7 +320,11 @@ func (r *postgresRepository) UpdateUser(ctx context.Context, user *User) error {
     query := `
         UPDATE users 
         SET email = $1, username = $2, phone = $3, is_verified = $4, 
-            is_profile_complete = $5, updated_at = $6
+            is_profile_complete = $5, updated_at = $6,
+            profile_completed_at = CASE
+                WHEN $5 AND profile_completed_at IS NULL THEN $6
+                ELSE profile_completed_at
+            END
         WHERE id = $7`
     
     _, err := r.db.ExecContext(
@@ -286,23 +349,64 @@ func (r *postgresRepository) UpdateUser(ctx context.Context, user *User) error {
 // VerifyUser marks a user as verified
 func (r *postgresRepository) VerifyUser(ctx context.Context, userID int64) error {
     query := `UPDATE users SET is_verified = true, updated_at = $1 WHERE id = $2`
-    _, err := r.db.ExecContext(ctx, query, time.Now(), userID)
+    _, err := database.Conn(ctx, r.db).ExecContext(ctx, query, time.Now(), userID)
     if err != nil {
         return fmt.Errorf("failed to verify user: %w", err)
     }
     return nil
 }
 
-// CreateSession creates a new session
+// sessionColumns are the columns scanned by scanSession, in order
+const sessionColumns = `id, user_id, token, refresh_token, device_info, ip_address, expires_at, created_at,
+        user_agent, device_fingerprint, location, last_used_at, refresh_expires_at`
+
+// scanSession reads a row selected with sessionColumns
+func scanSession(row interface{ Scan(...interface{}) error }) (*Session, error) {
+    session := &Session{}
+    err := row.Scan(
+        &session.ID,
+        &session.UserID,
+        &session.Token,
+        &session.RefreshToken,
+        &session.DeviceInfo,
+        &session.IPAddress,
+        &session.ExpiresAt,
+        &session.CreatedAt,
+        &session.UserAgent,
+        &session.DeviceFingerprint,
+        &session.Location,
+        &session.LastUsedAt,
+        &session.RefreshExpiresAt,
+    )
+    return session, err
+}
+
+// NextSessionID reserves an ID for a session about to be created, so its
+// tokens can carry it
+func (r *postgresRepository) NextSessionID(ctx context.Context) (int64, error) {
+    var id int64
+    query := `SELECT nextval(pg_get_serial_sequence('sessions', 'id'))`
+    
+    if err := database.Conn(ctx, r.db).QueryRowContext(ctx, query).Scan(&id); err != nil {
+        return 0, fmt.Errorf("failed to reserve session id: %w", err)
+    }
+    
+    return id, nil
+}
+
+// CreateSession creates a new session. A reserved ID is used when set.
 func (r *postgresRepository) CreateSession(ctx context.Context, session *Session) error {
     query := `
-        INSERT INTO sessions (user_id, token, refresh_token, device_info, ip_address, expires_at, created_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7)
+        INSERT INTO sessions (id, user_id, token, refresh_token, device_info, ip_address, expires_at, created_at,
+            user_agent, device_fingerprint, location, last_used_at, refresh_expires_at)
+        VALUES (COALESCE(NULLIF($1::BIGINT, 0), nextval(pg_get_serial_sequence('sessions', 'id'))),
+            $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
         RETURNING id`
     
-    err := r.db.QueryRowContext(
+    err := database.Conn(ctx, r.db).QueryRowContext(
         ctx,
         query,
+        session.ID,
         session.UserID,
         session.Token,
         session.RefreshToken,
@@ -310,6 +414,11 @@ func (r *postgresRepository) CreateSession(ctx context.Context, session *Session
         session.IPAddress,
         session.ExpiresAt,
         session.CreatedAt,
+        session.UserAgent,
+        session.DeviceFingerprint,
+        session.Location,
+        session.LastUsedAt,
+        session.RefreshExpiresAt,
     ).Scan(&session.ID)
     
     if err != nil {
@@ -321,23 +430,11 @@ func (r *postgresRepository) CreateSession(ctx context.Context, session *Session
 
 // GetSessionByToken retrieves a session by access token
 func (r *postgresRepository) GetSessionByToken(ctx context.Context, token string) (*Session, error) {
-    session := &Session{}
-    query := `
-        SELECT id, user_id, token, refresh_token, device_info, ip_address, expires_at, created_at
+    query := `SELECT ` + sessionColumns + `
         FROM sessions
         WHERE token = $1 AND expires_at > NOW()`
     
-    err := r.db.QueryRowContext(ctx, query, token).Scan(
-        &session.ID,
-        &session.UserID,
-        &session.Token,
-        &session.RefreshToken,
-        &session.DeviceInfo,
-        &session.IPAddress,
-        &session.ExpiresAt,
-        &session.CreatedAt,
-    )
-    
+    session, err := scanSession(r.db.QueryRowContext(ctx, query, token))
     if err == sql.ErrNoRows {
         return nil, fmt.Errorf("session not found or expired")
     }
@@ -350,23 +447,11 @@ func (r *postgresRepository) GetSessionByToken(ctx context.Context, token string
 
 // GetSessionByRefreshToken retrieves a session by refresh token
 func (r *postgresRepository) GetSessionByRefreshToken(ctx context.Context, refreshToken string) (*Session, error) {
-    session := &Session{}
-    query := `
-        SELECT id, user_id, token, refresh_token, device_info, ip_address, expires_at, created_at
+    query := `SELECT ` + sessionColumns + `
         FROM sessions
         WHERE refresh_token = $1`
     
-    err := r.db.QueryRowContext(ctx, query, refreshToken).Scan(
-        &session.ID,
-        &session.UserID,
-        &session.Token,
-        &session.RefreshToken,
-        &session.DeviceInfo,
-        &session.IPAddress,
-        &session.ExpiresAt,
-        &session.CreatedAt,
-    )
-    
+    session, err := scanSession(r.db.QueryRowContext(ctx, query, refreshToken))
     if err == sql.ErrNoRows {
         return nil, fmt.Errorf("session not found")
     }
@@ -377,12 +462,13 @@ func (r *postgresRepository) GetSessionByRefreshToken(ctx context.Context, refre
     return session, nil
 }
 
-// UpdateSession updates session tokens
+// UpdateSession rotates session tokens and records where it was last used
 func (r *postgresRepository) UpdateSession(ctx context.Context, session *Session) error {
     query := `
         UPDATE sessions 
-        SET token = $1, refresh_token = $2, expires_at = $3
-        WHERE id = $4`
+        SET token = $1, refresh_token = $2, expires_at = $3, refresh_expires_at = $4,
+            ip_address = COALESCE($5, ip_address), location = COALESCE($6, location), last_used_at = NOW()
+        WHERE id = $7`
     
     _, err := r.db.ExecContext(
         ctx,
@@ -390,6 +476,9 @@ func (r *postgresRepository) UpdateSession(ctx context.Context, session *Session
         session.Token,
         session.RefreshToken,
         session.ExpiresAt,
+        session.RefreshExpiresAt,
+        session.IPAddress,
+        session.Location,
         session.ID,
     )
     
@@ -400,6 +489,74 @@ func (r *postgresRepository) UpdateSession(ctx context.Context, session *Session
     return nil
 }
 
+// ListUserSessions returns the user's sessions that can still be refreshed,
+// most recently used first
+func (r *postgresRepository) ListUserSessions(ctx context.Context, userID int64) ([]*Session, error) {
+    query := `SELECT ` + sessionColumns + `
+        FROM sessions
+        WHERE user_id = $1 AND COALESCE(refresh_expires_at, expires_at) > NOW()
+        ORDER BY COALESCE(last_used_at, created_at) DESC`
+    
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list sessions: %w", err)
+    }
+    defer rows.Close()
+    
+    sessions := []*Session{}
+    for rows.Next() {
+        session, err := scanSession(rows)
+        if err != nil {
+            return nil, fmt.Errorf("failed to scan session: %w", err)
+        }
+        sessions = append(sessions, session)
+    }
+    
+    return sessions, rows.Err()
+}
+
+// SessionExists reports whether a session is still active
+func (r *postgresRepository) SessionExists(ctx context.Context, sessionID int64) (bool, error) {
+    var exists bool
+    query := `SELECT EXISTS(SELECT 1 FROM sessions WHERE id = $1)`
+    
+    if err := r.db.QueryRowContext(ctx, query, sessionID).Scan(&exists); err != nil {
+        return false, fmt.Errorf("failed to check session: %w", err)
+    }
+    
+    return exists, nil
+}
+
+// TouchSession records that a session was used, at most once per interval
+func (r *postgresRepository) TouchSession(ctx context.Context, sessionID int64, ipAddress string, interval time.Duration) error {
+    query := `
+        UPDATE sessions
+        SET last_used_at = NOW(), ip_address = COALESCE(NULLIF($2, ''), ip_address)
+        WHERE id = $1 AND (last_used_at IS NULL OR last_used_at < NOW() - $3 * INTERVAL '1 second')`
+    
+    _, err := r.db.ExecContext(ctx, query, sessionID, ipAddress, interval.Seconds())
+    if err != nil {
+        return fmt.Errorf("failed to touch session: %w", err)
+    }
+    
+    return nil
+}
+
+// TouchLastActive records that a user was active, at most once per interval
+func (r *postgresRepository) TouchLastActive(ctx context.Context, userID int64, interval time.Duration) error {
+    query := `
+        UPDATE users
+        SET last_active = NOW(), last_seen = NOW()
+        WHERE id = $1 AND (last_active IS NULL OR last_active < NOW() - $2 * INTERVAL '1 second')`
+    
+    _, err := r.db.ExecContext(ctx, query, userID, interval.Seconds())
+    if err != nil {
+        return fmt.Errorf("failed to touch last active: %w", err)
+    }
+    
+    return nil
+}
+
 // DeleteSession deletes a session by ID
 func (r *postgresRepository) DeleteSession(ctx context.Context, sessionID int64) error {
     query := `DELETE FROM sessions WHERE id = $1`
@@ -412,6 +569,50 @@ func (r *postgresRepository) DeleteSession(ctx context.Context, sessionID int64)
     return nil
 }
 
+// DeleteUserSession deletes one of the user's sessions, reporting false if
+// the user has no such session
+func (r *postgresRepository) DeleteUserSession(ctx context.Context, userID, sessionID int64) (bool, error) {
+    query := `DELETE FROM sessions WHERE id = $1 AND user_id = $2`
+    
+    result, err := r.db.ExecContext(ctx, query, sessionID, userID)
+    if err != nil {
+        return false, fmt.Errorf("failed to delete session: %w", err)
+    }
+    
+    n, err := result.RowsAffected()
+    return n > 0, err
+}
+
+// RememberDevice records that the user signed in from a device, reporting
+// whether the device was new for them
+func (r *postgresRepository) RememberDevice(ctx context.Context, userID int64, fingerprint, label string) (bool, error) {
+    var inserted bool
+    query := `
+        INSERT INTO user_devices (user_id, fingerprint, label, first_seen_at, last_seen_at)
+        VALUES ($1, $2, $3, NOW(), NOW())
+        ON CONFLICT (user_id, fingerprint)
+        DO UPDATE SET label = EXCLUDED.label, last_seen_at = NOW()
+        RETURNING (xmax = 0)`
+    
+    if err := r.db.QueryRowContext(ctx, query, userID, fingerprint, label).Scan(&inserted); err != nil {
+        return false, fmt.Errorf("failed to remember device: %w", err)
+    }
+    
+    return inserted, nil
+}
+
+// HasDevices reports whether the user has signed in from any device before
+func (r *postgresRepository) HasDevices(ctx context.Context, userID int64) (bool, error) {
+    var exists bool
+    query := `SELECT EXISTS(SELECT 1 FROM user_devices WHERE user_id = $1)`
+    
+    if err := r.db.QueryRowContext(ctx, query, userID).Scan(&exists); err != nil {
+        return false, fmt.Errorf("failed to check devices: %w", err)
+    }
+    
+    return exists, nil
+}
+
 // DeleteSessionByToken deletes a session by token (for logout)
 func (r *postgresRepository) DeleteSessionByToken(ctx context.Context, token string) error {
     query := `DELETE FROM sessions WHERE token = $1`
@@ -471,4 +672,335 @@ func (r *postgresRepository) IsUsernameTaken(ctx context.Context, username strin
     }
     
     return exists, nil
-}
\ No newline at end of file
+}
+
+// GetLoginLocations returns the networks the user has signed in from
+func (r *postgresRepository) GetLoginLocations(ctx context.Context, userID int64) ([]*LoginLocation, error) {
+    query := `
+        SELECT network, country, first_seen_at, last_seen_at
+        FROM login_locations
+        WHERE user_id = $1
+        ORDER BY last_seen_at DESC`
+    
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get login locations: %w", err)
+    }
+    defer rows.Close()
+    
+    locations := []*LoginLocation{}
+    for rows.Next() {
+        location := &LoginLocation{}
+        if err := rows.Scan(&location.Network, &location.Country, &location.FirstSeenAt, &location.LastSeenAt); err != nil {
+            return nil, fmt.Errorf("failed to scan login location: %w", err)
+        }
+        locations = append(locations, location)
+    }
+    
+    return locations, rows.Err()
+}
+
+// RecordLoginLocation remembers a network the user signed in from
+func (r *postgresRepository) RecordLoginLocation(ctx context.Context, userID int64, network, country string) error {
+    query := `
+        INSERT INTO login_locations (user_id, network, country, first_seen_at, last_seen_at)
+        VALUES ($1, $2, NULLIF($3, ''), NOW(), NOW())
+        ON CONFLICT (user_id, network)
+        DO UPDATE SET country = COALESCE(EXCLUDED.country, login_locations.country), last_seen_at = NOW()`
+    
+    if _, err := r.db.ExecContext(ctx, query, userID, network, country); err != nil {
+        return fmt.Errorf("failed to record login location: %w", err)
+    }
+    
+    return nil
+}
+
+// GetTwoFactorMethod returns the user's chosen two-factor method, "" if none
+func (r *postgresRepository) GetTwoFactorMethod(ctx context.Context, userID int64) (string, error) {
+    var method sql.NullString
+    query := `SELECT two_factor_method FROM users WHERE id = $1`
+    
+    if err := r.db.QueryRowContext(ctx, query, userID).Scan(&method); err != nil {
+        if err == sql.ErrNoRows {
+            return "", ErrUserNotFound
+        }
+        return "", fmt.Errorf("failed to get two-factor method: %w", err)
+    }
+    
+    return method.String, nil
+}
+
+// SetTwoFactorMethod stores the user's two-factor method; "" clears it
+func (r *postgresRepository) SetTwoFactorMethod(ctx context.Context, userID int64, method string) error {
+    query := `UPDATE users SET two_factor_method = NULLIF($2, ''), updated_at = NOW() WHERE id = $1`
+    
+    if _, err := r.db.ExecContext(ctx, query, userID, method); err != nil {
+        return fmt.Errorf("failed to set two-factor method: %w", err)
+    }
+    
+    return nil
+}
+
+// SaveTOTPSecret starts a new, unconfirmed authenticator enrollment
+func (r *postgresRepository) SaveTOTPSecret(ctx context.Context, userID int64, secret string) error {
+    query := `
+        INSERT INTO user_totp (user_id, secret, confirmed_at, last_used_step, created_at)
+        VALUES ($1, $2, NULL, 0, NOW())
+        ON CONFLICT (user_id)
+        DO UPDATE SET secret = EXCLUDED.secret, confirmed_at = NULL, last_used_step = 0, created_at = NOW()`
+    
+    if _, err := r.db.ExecContext(ctx, query, userID, secret); err != nil {
+        return fmt.Errorf("failed to save TOTP secret: %w", err)
+    }
+    
+    return nil
+}
+
+// GetTOTPSecret returns the user's enrollment, or ErrTOTPNotEnrolled
+func (r *postgresRepository) GetTOTPSecret(ctx context.Context, userID int64) (*TOTPSecret, error) {
+    totp := &TOTPSecret{}
+    query := `
+        SELECT user_id, secret, confirmed_at, last_used_step, created_at
+        FROM user_totp
+        WHERE user_id = $1`
+    
+    err := r.db.QueryRowContext(ctx, query, userID).Scan(
+        &totp.UserID,
+        &totp.Secret,
+        &totp.ConfirmedAt,
+        &totp.LastUsedStep,
+        &totp.CreatedAt,
+    )
+    if err == sql.ErrNoRows {
+        return nil, ErrTOTPNotEnrolled
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get TOTP secret: %w", err)
+    }
+    
+    return totp, nil
+}
+
+// ConfirmTOTP marks the enrollment as set up
+func (r *postgresRepository) ConfirmTOTP(ctx context.Context, userID int64) error {
+    query := `UPDATE user_totp SET confirmed_at = NOW() WHERE user_id = $1`
+    
+    if _, err := r.db.ExecContext(ctx, query, userID); err != nil {
+        return fmt.Errorf("failed to confirm TOTP: %w", err)
+    }
+    
+    return nil
+}
+
+// UseTOTPStep records a code's time step as used, reporting false if that
+// step or a later one was already used, so a code can't be replayed
+func (r *postgresRepository) UseTOTPStep(ctx context.Context, userID int64, step int64) (bool, error) {
+    query := `UPDATE user_totp SET last_used_step = $2 WHERE user_id = $1 AND last_used_step < $2`
+    
+    result, err := r.db.ExecContext(ctx, query, userID, step)
+    if err != nil {
+        return false, fmt.Errorf("failed to use TOTP code: %w", err)
+    }
+    
+    n, err := result.RowsAffected()
+    return n > 0, err
+}
+
+// DeleteTOTPSecret removes the user's authenticator enrollment
+func (r *postgresRepository) DeleteTOTPSecret(ctx context.Context, userID int64) error {
+    if _, err := r.db.ExecContext(ctx, `DELETE FROM user_totp WHERE user_id = $1`, userID); err != nil {
+        return fmt.Errorf("failed to delete TOTP secret: %w", err)
+    }
+    
+    return nil
+}
+
+// ReplaceRecoveryCodes swaps the user's recovery codes for a new set
+func (r *postgresRepository) ReplaceRecoveryCodes(ctx context.Context, userID int64, hashes []string) error {
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+    
+    if _, err := tx.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return fmt.Errorf("failed to delete recovery codes: %w", err)
+    }
+    
+    query := `INSERT INTO user_recovery_codes (user_id, code_hash, created_at) VALUES ($1, $2, NOW())`
+    for _, hash := range hashes {
+        if _, err := tx.ExecContext(ctx, query, userID, hash); err != nil {
+            return fmt.Errorf("failed to save recovery code: %w", err)
+        }
+    }
+    
+    return tx.Commit()
+}
+
+// UseRecoveryCode spends a recovery code, reporting false if it doesn't
+// exist or was already used
+func (r *postgresRepository) UseRecoveryCode(ctx context.Context, userID int64, hash string) (bool, error) {
+    query := `
+        UPDATE user_recovery_codes
+        SET used_at = NOW()
+        WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL`
+    
+    result, err := r.db.ExecContext(ctx, query, userID, hash)
+    if err != nil {
+        return false, fmt.Errorf("failed to use recovery code: %w", err)
+    }
+    
+    n, err := result.RowsAffected()
+    return n > 0, err
+}
+
+// CountRecoveryCodes returns how many unused recovery codes the user has
+func (r *postgresRepository) CountRecoveryCodes(ctx context.Context, userID int64) (int, error) {
+    var count int
+    query := `SELECT COUNT(*) FROM user_recovery_codes WHERE user_id = $1 AND used_at IS NULL`
+    
+    if err := r.db.QueryRowContext(ctx, query, userID).Scan(&count); err != nil {
+        return 0, fmt.Errorf("failed to count recovery codes: %w", err)
+    }
+    
+    return count, nil
+}
+
+// DeleteRecoveryCodes removes all of the user's recovery codes
+func (r *postgresRepository) DeleteRecoveryCodes(ctx context.Context, userID int64) error {
+    if _, err := r.db.ExecContext(ctx, `DELETE FROM user_recovery_codes WHERE user_id = $1`, userID); err != nil {
+        return fmt.Errorf("failed to delete recovery codes: %w", err)
+    }
+    
+    return nil
+}
+
+// identifierColumns maps a changeable identifier to its users column
+var identifierColumns = map[string]string{
+    IdentifierUsername: "username",
+    IdentifierEmail:    "email",
+    IdentifierPhone:    "phone",
+}
+
+// SavePendingIdentifierChange stages a new email or phone, replacing any
+// earlier one of the same kind
+func (r *postgresRepository) SavePendingIdentifierChange(ctx context.Context, userID int64, change *PendingIdentifierChange) error {
+    query := `
+        INSERT INTO pending_identifier_changes (user_id, kind, new_value, expires_at, created_at)
+        VALUES ($1, $2, $3, $4, NOW())
+        ON CONFLICT (user_id, kind) DO UPDATE
+        SET new_value = EXCLUDED.new_value, expires_at = EXCLUDED.expires_at, created_at = NOW()`
+    
+    if _, err := r.db.ExecContext(ctx, query, userID, change.Kind, change.Value, change.ExpiresAt); err != nil {
+        return fmt.Errorf("failed to save pending change: %w", err)
+    }
+    
+    return nil
+}
+
+// GetPendingIdentifierChange returns an unexpired staged change
+func (r *postgresRepository) GetPendingIdentifierChange(ctx context.Context, userID int64, kind string) (*PendingIdentifierChange, error) {
+    change := &PendingIdentifierChange{Kind: kind}
+    query := `
+        SELECT new_value, expires_at
+        FROM pending_identifier_changes
+        WHERE user_id = $1 AND kind = $2 AND expires_at > NOW()`
+    
+    err := r.db.QueryRowContext(ctx, query, userID, kind).Scan(&change.Value, &change.ExpiresAt)
+    if err == sql.ErrNoRows {
+        return nil, ErrNoPendingChange
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to get pending change: %w", err)
+    }
+    
+    return change, nil
+}
+
+// ApplyIdentifierChange swaps in the new value, records it in the history
+// and clears any staged change of the same kind, all in one transaction
+func (r *postgresRepository) ApplyIdentifierChange(ctx context.Context, userID int64, kind, value string) error {
+    column, ok := identifierColumns[kind]
+    if !ok {
+        return fmt.Errorf("unknown identifier %q", kind)
+    }
+    
+    tx, err := r.db.BeginTx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    defer tx.Rollback()
+    
+    var oldValue sql.NullString
+    query := fmt.Sprintf(`SELECT %s FROM users WHERE id = $1 FOR UPDATE`, column)
+    if err := tx.QueryRowContext(ctx, query, userID).Scan(&oldValue); err != nil {
+        if err == sql.ErrNoRows {
+            return ErrUserNotFound
+        }
+        return fmt.Errorf("failed to get user: %w", err)
+    }
+    
+    query = fmt.Sprintf(`UPDATE users SET %s = $1, updated_at = NOW() WHERE id = $2`, column)
+    if _, err := tx.ExecContext(ctx, query, value, userID); err != nil {
+        if pgErr, ok := err.(*pq.Error); ok && pgErr.Code == "23505" { // unique_violation
+            return identifierTakenError(kind)
+        }
+        return fmt.Errorf("failed to update %s: %w", column, err)
+    }
+    
+    query = `
+        INSERT INTO user_identifier_history (user_id, kind, old_value, new_value, changed_at)
+        VALUES ($1, $2, $3, $4, NOW())`
+    if _, err := tx.ExecContext(ctx, query, userID, kind, oldValue, value); err != nil {
+        return fmt.Errorf("failed to record change: %w", err)
+    }
+    
+    query = `DELETE FROM pending_identifier_changes WHERE user_id = $1 AND kind = $2`
+    if _, err := tx.ExecContext(ctx, query, userID, kind); err != nil {
+        return fmt.Errorf("failed to clear pending change: %w", err)
+    }
+    
+    return tx.Commit()
+}
+
+// LastIdentifierChange returns when the identifier last changed, or nil if
+// it never has
+func (r *postgresRepository) LastIdentifierChange(ctx context.Context, userID int64, kind string) (*time.Time, error) {
+    var changedAt sql.NullTime
+    query := `SELECT MAX(changed_at) FROM user_identifier_history WHERE user_id = $1 AND kind = $2`
+    
+    if err := r.db.QueryRowContext(ctx, query, userID, kind).Scan(&changedAt); err != nil {
+        return nil, fmt.Errorf("failed to get last change: %w", err)
+    }
+    if !changedAt.Valid {
+        return nil, nil
+    }
+    
+    return &changedAt.Time, nil
+}
+
+// ListIdentifierChanges returns the user's identifier history, newest first
+func (r *postgresRepository) ListIdentifierChanges(ctx context.Context, userID int64) ([]*IdentifierChange, error) {
+    query := `
+        SELECT id, kind, old_value, new_value, changed_at
+        FROM user_identifier_history
+        WHERE user_id = $1
+        ORDER BY changed_at DESC`
+    
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to list identifier changes: %w", err)
+    }
+    defer rows.Close()
+    
+    var changes []*IdentifierChange
+    for rows.Next() {
+        change := &IdentifierChange{}
+        if err := rows.Scan(&change.ID, &change.Kind, &change.OldValue, &change.NewValue, &change.ChangedAt); err != nil {
+            return nil, fmt.Errorf("failed to scan identifier change: %w", err)
+        }
+        changes = append(changes, change)
+    }
+    
+    return changes, rows.Err()
+}
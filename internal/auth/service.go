@@ -11,8 +11,10 @@ import (
     "encoding/json"
     "errors"
     "fmt"
+    "log"
     "math/big"
     "regexp"
+    "strconv"
     "strings"
     "time"
     
@@ -21,7 +23,12 @@ import (
     "google.golang.org/api/oauth2/v2"
     "google.golang.org/api/option"
     
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
     "github.com/imadgeboyega/kiekky-backend/internal/otp"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
     "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
 )
 
@@ -36,8 +43,17 @@ var (
     ErrInvalidToken          = errors.New("invalid token")
     ErrTooManyAttempts       = errors.New("too many attempts")
     ErrInvalidOTP = errors.New("invalid OTP")
+    ErrSessionNotFound       = errors.New("session not found")
+    ErrSessionRevoked        = errors.New("session has been revoked")
+    ErrInvalidReferralCode   = errors.New("invalid referral code")
 )
 
+// sessionTouchInterval is how often a session's last-used time is updated
+const sessionTouchInterval = 5 * time.Minute
+
+// activityTouchInterval is how often a user's last active time is updated
+const activityTouchInterval = time.Minute
+
 // Service interface
 type Service interface {
     // Registration and authentication
@@ -52,17 +68,81 @@ type Service interface {
     RefreshToken(ctx context.Context, refreshToken string) (*AuthResponse, error)
     ValidateToken(ctx context.Context, token string) (*utils.JWTClaims, error)
     
+    // UnlockAccount lifts a lock from too many failed signins
+    UnlockAccount(ctx context.Context, token string) error
+    
     // Session management
     Logout(ctx context.Context, token string) error
     LogoutAllDevices(ctx context.Context, userID int64) error
+    ListSessions(ctx context.Context, userID, currentSessionID int64) ([]*SessionInfo, error)
+    RevokeSession(ctx context.Context, userID, sessionID int64) error
+    // CheckSession rejects tokens whose session was revoked and records
+    // that the session was used
+    CheckSession(ctx context.Context, claims *utils.JWTClaims) error
+    // TouchActivity records that the user is active, at most once per
+    // activityTouchInterval
+    TouchActivity(ctx context.Context, userID int64)
     
     // Password management
     InitiatePasswordReset(ctx context.Context, email string) error
     VerifyPasswordResetOTP(ctx context.Context, email string, otpCode string) (string, error)
     ResetPassword(ctx context.Context, resetToken string, newPassword string) error
     
+    // Two-factor authentication
+    GetTwoFactorStatus(ctx context.Context, userID int64) (*TwoFactorStatus, error)
+    EnrollTOTP(ctx context.Context, userID int64) (*TOTPEnrollment, error)
+    ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error)
+    DisableTOTP(ctx context.Context, userID int64, code string) error
+    RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error)
+    SetTwoFactorMethod(ctx context.Context, userID int64, method, code string) error
+    
+    // Username, email and phone changes
+    ChangeUsername(ctx context.Context, userID int64, username string) (*User, error)
+    RequestIdentifierChange(ctx context.Context, userID int64, kind, value string) (*PendingIdentifierChange, error)
+    ConfirmIdentifierChange(ctx context.Context, userID int64, kind, code string) (*User, error)
+    GetIdentifierHistory(ctx context.Context, userID int64) ([]*IdentifierChange, error)
+    
     // User queries
     GetUserByID(ctx context.Context, userID int64) (*User, error)
+    
+    // Impersonate opens a short support session for staff acting as a user
+    Impersonate(ctx context.Context, adminID, userID int64, ttl time.Duration) (*AuthResponse, error)
+    
+    // SetNotifier sets where new-device login alerts are sent
+    SetNotifier(notifier Notifier)
+    
+    // SetPasswordPolicy sets the rules new passwords must meet
+    SetPasswordPolicy(policy PasswordPolicy)
+    
+    // SetTracker sets where signup and verification funnel events go
+    SetTracker(tracker Tracker)
+    
+    // SetUnitOfWork makes multi-step flows such as signup verification atomic
+    SetUnitOfWork(uow *database.UnitOfWork)
+    
+    // SetEvents sets the bus account verifications are published on
+    SetEvents(bus *events.Bus)
+    
+    // SetReferrals sets where signups with a referral code are attributed
+    SetReferrals(referrals Referrals)
+}
+
+// Tracker records analytics funnel events
+type Tracker interface {
+    Track(ctx context.Context, userID int64, event string, properties map[string]interface{})
+}
+
+// Referrals checks referral codes given at signup and credits the referrer
+// with the new account
+type Referrals interface {
+    IsValidCode(ctx context.Context, code string) (bool, error)
+    Attribute(ctx context.Context, inviteeID int64, code string) error
+}
+
+// PasswordPolicy checks new passwords at signup and reset, returning a
+// *password.WeakPasswordError when one is refused
+type PasswordPolicy interface {
+    Check(ctx context.Context, password string, userInputs ...string) error
 }
 
 // service implementation
@@ -71,6 +151,13 @@ type service struct {
     redis      *redis.Client
     otpService otp.Service
     config     *Config
+    notifier   Notifier
+    secrets    *secretBox
+    passwords  PasswordPolicy
+    tracker    Tracker
+    uow        *database.UnitOfWork
+    events     *events.Bus
+    referrals  Referrals
 }
 
 // Config holds service configuration
@@ -80,18 +167,113 @@ type Config struct {
     RefreshTokenExpiry  time.Duration
     BCryptCost          int
     Enable2FA           bool  // Global 2FA setting
+    TOTPIssuer          string // Account name shown in authenticator apps
+    TOTPEncryptionKey   string // Encrypts TOTP secrets at rest; defaults to JWTSecret
+    
+    // Login anomaly detection
+    MaxLoginAttempts    int           // Failed passwords before the account locks; 0 disables locking
+    LoginAttemptsWindow time.Duration // How long failed attempts are counted
+    StepUpAfterFailures int           // Failed passwords before a correct one also needs an OTP
+    LockDuration        time.Duration
+    BaseURL             string        // For links in unlock emails
+    
+    UsernameChangeCooldown time.Duration // Minimum time between username changes
 }
 
 // NewService creates a new auth service
 func NewService(repo Repository, redis *redis.Client, otpService otp.Service, config *Config) Service {
+    if config.TOTPIssuer == "" {
+        config.TOTPIssuer = "Kiekky"
+    }
+    key := config.TOTPEncryptionKey
+    if key == "" {
+        key = config.JWTSecret
+    }
+    // The key is hashed to a valid AES-256 length, so this can't fail
+    secrets, _ := newSecretBox(key)
+    
     return &service{
         repo:       repo,
         redis:      redis,
         otpService: otpService,
         config:     config,
+        secrets:    secrets,
+    }
+}
+
+// SetNotifier sets the notifier after initialization, since notifications
+// are set up after auth
+func (s *service) SetNotifier(notifier Notifier) {
+    s.notifier = notifier
+}
+
+// SetPasswordPolicy sets the password policy after initialization
+func (s *service) SetPasswordPolicy(policy PasswordPolicy) {
+    s.passwords = policy
+}
+
+// SetTracker sets the analytics tracker after initialization
+func (s *service) SetTracker(tracker Tracker) {
+    s.tracker = tracker
+}
+
+// SetUnitOfWork sets the unit of work after initialization
+func (s *service) SetUnitOfWork(uow *database.UnitOfWork) {
+    s.uow = uow
+}
+
+// SetEvents sets the event bus after initialization
+func (s *service) SetEvents(bus *events.Bus) {
+    s.events = bus
+}
+
+// SetReferrals sets the referral program after initialization, since it is
+// set up after auth
+func (s *service) SetReferrals(referrals Referrals) {
+    s.referrals = referrals
+}
+
+// checkReferralCode rejects a referral code that doesn't belong to anyone
+func (s *service) checkReferralCode(ctx context.Context, code string) error {
+    if code == "" || s.referrals == nil {
+        return nil
+    }
+    valid, err := s.referrals.IsValidCode(ctx, code)
+    if err != nil {
+        return fmt.Errorf("failed to check referral code: %w", err)
+    }
+    if !valid {
+        return ErrInvalidReferralCode
+    }
+    return nil
+}
+
+// attributeReferral credits the referrer with a new account. The account
+// exists either way, so a failure is only logged.
+func (s *service) attributeReferral(ctx context.Context, userID int64, code string) {
+    if code == "" || s.referrals == nil {
+        return
+    }
+    if err := s.referrals.Attribute(ctx, userID, code); err != nil {
+        log.Printf("Failed to attribute referral %q to user %d: %v", code, userID, err)
     }
 }
 
+// track records a funnel event, if a tracker is set
+func (s *service) track(ctx context.Context, userID int64, event string, properties map[string]interface{}) {
+    if s.tracker != nil {
+        s.tracker.Track(ctx, userID, event, properties)
+    }
+}
+
+// checkPassword applies the password policy, if one is set
+func (s *service) checkPassword(ctx context.Context, password string, userInputs ...string) error {
+    if s.passwords == nil {
+        return nil
+    }
+    return s.passwords.Check(ctx, password, userInputs...)
+}
+
 // Signup creates a new user account and sends verification OTP
 func (s *service) Signup(ctx context.Context, req *SignupRequest) (*SignupResponse, error) {
     // 1. Validate passwords match
@@ -139,14 +321,31 @@ func (s *service) Signup(ctx context.Context, req *SignupRequest) (*SignupRespon
         return nil, ErrUsernameAlreadyExists
     }
     
-    // 5. Hash password
+    // 5. Check password strength against the account's own details
+    userInputs := []string{req.Username}
+    if normalizedEmail != nil {
+        userInputs = append(userInputs, *normalizedEmail)
+    }
+    if normalizedPhone != nil {
+        userInputs = append(userInputs, *normalizedPhone)
+    }
+    if err := s.checkPassword(ctx, req.Password, userInputs...); err != nil {
+        return nil, err
+    }
+    
+    req.ReferralCode = strings.TrimSpace(req.ReferralCode)
+    if err := s.checkReferralCode(ctx, req.ReferralCode); err != nil {
+        return nil, err
+    }
+    
+    // 6. Hash password
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), s.config.BCryptCost)
     if err != nil {
         return nil, fmt.Errorf("failed to hash password: %w", err)
     }
     hashedPasswordStr := string(hashedPassword)
     
-    // 6. Create user
+    // 7. Create user
     user := &User{
         Email:             normalizedEmail,
         Username:          req.Username,
@@ -155,25 +354,31 @@ func (s *service) Signup(ctx context.Context, req *SignupRequest) (*SignupRespon
         Provider:          "local",
         IsVerified:        false,
         IsProfileComplete: false,
+        PreferredLanguage: i18n.FromContext(ctx), // From Accept-Language at signup
+        Role:              RoleUser,
+        AccountStatus:     users.StatusActive,
         CreatedAt:         time.Now(),
         UpdatedAt:         time.Now(),
     }
     
-    // 7. Save to database
+    // 8. Save to database
     if err := s.repo.CreateUser(ctx, user); err != nil {
         return nil, fmt.Errorf("failed to create user: %w", err)
     }
+    s.track(ctx, user.ID, "signup", map[string]interface{}{"provider": user.Provider})
+    s.attributeReferral(ctx, user.ID, req.ReferralCode)
     
-    // 8. Send verification OTP using OTP service
+    // 9. Send verification OTP using OTP service
     var otpSent bool
     var otpMessage string
     
     if normalizedEmail != nil {
         otpReq := &otp.SendOTPRequest{
-            UserID: user.ID,
-            Email:  *normalizedEmail,
-            Type:   otp.OTPTypeSignup,
-            Method: otp.DeliveryMethodEmail,
+            UserID:   user.ID,
+            Email:    *normalizedEmail,
+            Type:     otp.OTPTypeSignup,
+            Method:   otp.DeliveryMethodEmail,
+            Language: user.PreferredLanguage,
         }
         
         if _, err := s.otpService.GenerateOTP(ctx, otpReq); err != nil {
@@ -181,23 +386,24 @@ func (s *service) Signup(ctx context.Context, req *SignupRequest) (*SignupRespon
             fmt.Printf("Failed to send OTP email: %v\n", err)
         } else {
             otpSent = true
-            otpMessage = fmt.Sprintf("Verification code sent to %s", *normalizedEmail)
+            otpMessage = i18n.T(user.PreferredLanguage, "otp.sent", i18n.Vars{"recipient": *normalizedEmail})
         }
     }
     
     if normalizedPhone != nil && !otpSent {
         otpReq := &otp.SendOTPRequest{
-            UserID: user.ID,
-            Phone:  *normalizedPhone,
-            Type:   otp.OTPTypeSignup,
-            Method: otp.DeliveryMethodSMS,
+            UserID:   user.ID,
+            Phone:    *normalizedPhone,
+            Type:     otp.OTPTypeSignup,
+            Method:   otp.DeliveryMethodSMS,
+            Language: user.PreferredLanguage,
         }
         
         if _, err := s.otpService.GenerateOTP(ctx, otpReq); err != nil {
             fmt.Printf("Failed to send OTP SMS: %v\n", err)
         } else {
             otpSent = true
-            otpMessage = fmt.Sprintf("Verification code sent to %s", *normalizedPhone)
+            otpMessage = i18n.T(user.PreferredLanguage, "otp.sent", i18n.Vars{"recipient": *normalizedPhone})
         }
     }
     
@@ -229,34 +435,50 @@ func (s *service) VerifySignupOTP(ctx context.Context, req *OTPVerificationReque
         return nil, errors.New("invalid email or phone format")
     }
     
-    // 2. Verify OTP using OTP service
-    if err := s.otpService.VerifyOTP(ctx, otpReq); err != nil {
-        return nil, fmt.Errorf("OTP verification failed: %w", err)
-    }
-    
-    // 3. Get user
-    var user *User
-    var err error
-    
-    if otpReq.Email != "" {
-        user, err = s.repo.GetUserByEmail(ctx, otpReq.Email)
-    } else {
-        user, err = s.repo.GetUserByPhone(ctx, otpReq.Phone)
-    }
-    
+    // Using up the OTP, verifying the user and creating their session
+    // succeed or fail together, so a failed session doesn't burn the code
+    var resp *AuthResponse
+    err := s.uow.Do(ctx, func(ctx context.Context) error {
+        // 2. Verify OTP using OTP service
+        if err := s.otpService.VerifyOTP(ctx, otpReq); err != nil {
+            return fmt.Errorf("OTP verification failed: %w", err)
+        }
+        
+        // 3. Get user
+        var user *User
+        var err error
+        
+        if otpReq.Email != "" {
+            user, err = s.repo.GetUserByEmail(ctx, otpReq.Email)
+        } else {
+            user, err = s.repo.GetUserByPhone(ctx, otpReq.Phone)
+        }
+        
+        if err != nil {
+            return err
+        }
+        
+        // 4. Mark user as verified
+        if err := s.repo.VerifyUser(ctx, user.ID); err != nil {
+            return fmt.Errorf("failed to verify user: %w", err)
+        }
+        
+        user.IsVerified = true
+        if err := s.events.Publish(ctx, strconv.FormatInt(user.ID, 10), events.UserVerified{UserID: user.ID}); err != nil {
+            return err
+        }
+        database.AfterCommit(ctx, func() {
+            s.track(ctx, user.ID, "verified", nil)
+        })
+        
+        // 5. Create auth session
+        resp, err = s.createAuthSession(ctx, user)
+        return err
+    })
     if err != nil {
         return nil, err
     }
-    
-    // 4. Mark user as verified
-    if err := s.repo.VerifyUser(ctx, user.ID); err != nil {
-        return nil, fmt.Errorf("failed to verify user: %w", err)
-    }
-    
-    user.IsVerified = true
-    
-    // 5. Create auth session
-    return s.createAuthSession(ctx, user)
+    return resp, nil
 }
 
 // Signin authenticates a user
@@ -283,24 +505,39 @@ func (s *service) Signin(ctx context.Context, req *SigninRequest) (*SigninRespon
         return nil, errors.New("this account uses social login")
     }
     
-    // 3. Verify password
+    if s.isLocked(ctx, user.ID) {
+        return nil, ErrAccountLocked
+    }
+    
+    // 3. Verify password, locking the account after too many failures
+    attemptKey := loginAttemptKey(user.ID)
     if err := bcrypt.CompareHashAndPassword([]byte(*user.PasswordHash), []byte(req.Password)); err != nil {
-        s.recordFailedAttempt(ctx, req.EmailOrPhone)
+        failures := s.recordFailedAttempt(ctx, attemptKey)
+        if s.config.MaxLoginAttempts > 0 && failures >= int64(s.config.MaxLoginAttempts) {
+            s.lockAccount(ctx, user.ID)
+            return nil, ErrAccountLocked
+        }
         return nil, ErrInvalidCredentials
     }
     
-    // 4. Clear failed attempts
-    s.clearFailedAttempts(ctx, req.EmailOrPhone)
+    if err := checkAccountStatus(user); err != nil {
+        return nil, err
+    }
+    
+    // 4. Check for anything unusual before clearing failed attempts
+    risk := s.assessLogin(ctx, user.ID, s.failedAttempts(ctx, attemptKey))
+    s.clearFailedAttempts(ctx, attemptKey)
     
     // 5. Check if user is verified
     if !user.IsVerified {
         // Send new OTP for verification
         if user.Email != nil {
             otpReq := &otp.SendOTPRequest{
-                UserID: user.ID,
-                Email:  *user.Email,
-                Type:   otp.OTPTypeSignup,
-                Method: otp.DeliveryMethodEmail,
+                UserID:   user.ID,
+                Email:    *user.Email,
+                Type:     otp.OTPTypeSignup,
+                Method:   otp.DeliveryMethodEmail,
+                Language: user.PreferredLanguage,
             }
             s.otpService.GenerateOTP(ctx, otpReq)
         }
@@ -311,24 +548,55 @@ func (s *service) Signin(ctx context.Context, req *SigninRequest) (*SigninRespon
         }, nil
     }
     
-    // 6. Check if 2FA is enabled
-    if s.config.Enable2FA {
+    // 6. Check which second factor the user needs, if any. Unusual signins
+    // need an OTP even when the user has no 2FA.
+    method, err := s.twoFactorMethod(ctx, user.ID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to check two-factor method: %w", err)
+    }
+    
+    stepUp := false
+    if risk != "" {
+        s.notifySuspiciousLogin(ctx, user.ID, risk)
+        if method == "" {
+            method = TwoFactorOTP
+            stepUp = true
+        }
+    }
+    
+    if method == TwoFactorTOTP {
+        pendingToken := s.generateSecureToken()
+        if err := s.storePendingAuth(ctx, pendingToken, user.ID, TwoFactorTOTP); err != nil {
+            return nil, fmt.Errorf("failed to store pending auth: %w", err)
+        }
+        
+        return &SigninResponse{
+            RequiresOTP:  true,
+            PendingToken: pendingToken,
+            Message:      "Enter the code from your authenticator app or a recovery code",
+            OTPType:      "totp",
+        }, nil
+    }
+    
+    if method == TwoFactorOTP {
         // Generate and send 2FA OTP
         var otpReq *otp.SendOTPRequest
         
         if user.Email != nil {
             otpReq = &otp.SendOTPRequest{
-                UserID: user.ID,
-                Email:  *user.Email,
-                Type:   otp.OTPTypeSignin,
-                Method: otp.DeliveryMethodEmail,
+                UserID:   user.ID,
+                Email:    *user.Email,
+                Type:     otp.OTPTypeSignin,
+                Method:   otp.DeliveryMethodEmail,
+                Language: user.PreferredLanguage,
             }
         } else if user.Phone != nil {
             otpReq = &otp.SendOTPRequest{
-                UserID: user.ID,
-                Phone:  *user.Phone,
-                Type:   otp.OTPTypeSignin,
-                Method: otp.DeliveryMethodSMS,
+                UserID:   user.ID,
+                Phone:    *user.Phone,
+                Type:     otp.OTPTypeSignin,
+                Method:   otp.DeliveryMethodSMS,
+                Language: user.PreferredLanguage,
             }
         }
         
@@ -340,10 +608,19 @@ func (s *service) Signin(ctx context.Context, req *SigninRequest) (*SigninRespon
         pendingToken := s.generateSecureToken()
         
         // Store pending auth info
-        if err := s.storePendingAuth(ctx, pendingToken, user.ID); err != nil {
+        if err := s.storePendingAuth(ctx, pendingToken, user.ID, TwoFactorOTP); err != nil {
             return nil, fmt.Errorf("failed to store pending auth: %w", err)
         }
         
+        if stepUp {
+            return &SigninResponse{
+                RequiresOTP:  true,
+                PendingToken: pendingToken,
+                Message:      fmt.Sprintf("We noticed %s. Enter the code sent to your registered email/phone to continue.", risk),
+                OTPType:      "step_up",
+            }, nil
+        }
+        
         return &SigninResponse{
             RequiresOTP:  true,
             PendingToken: pendingToken,
@@ -368,7 +645,7 @@ func (s *service) Signin(ctx context.Context, req *SigninRequest) (*SigninRespon
 // VerifySigninOTP verifies 2FA OTP during signin
 func (s *service) VerifySigninOTP(ctx context.Context, pendingToken string, otpCode string) (*AuthResponse, error) {
     // 1. Get pending auth info
-    userID, err := s.getPendingAuth(ctx, pendingToken)
+    userID, method, err := s.getPendingAuth(ctx, pendingToken)
     if err != nil {
         return nil, errors.New("invalid or expired session")
     }
@@ -379,7 +656,20 @@ func (s *service) VerifySigninOTP(ctx context.Context, pendingToken string, otpC
         return nil, err
     }
     
-    // 3. Verify OTP
+    // 3. Authenticator users verify against their app or a recovery code
+    if method == TwoFactorTOTP {
+        if err := s.verifySecondFactor(ctx, userID, otpCode); err != nil {
+            if err == ErrTooManyAttempts {
+                s.clearPendingAuth(ctx, pendingToken)
+            }
+            return nil, err
+        }
+        
+        s.clearPendingAuth(ctx, pendingToken)
+        return s.createAuthSession(ctx, user)
+    }
+    
+    // 4. Verify OTP
     otpReq := &otp.VerifyOTPRequest{
         UserID: userID,
         Code:   otpCode,
@@ -396,10 +686,10 @@ func (s *service) VerifySigninOTP(ctx context.Context, pendingToken string, otpC
         return nil, fmt.Errorf("invalid OTP: %w", err)
     }
     
-    // 4. Clear pending auth
+    // 5. Clear pending auth
     s.clearPendingAuth(ctx, pendingToken)
     
-    // 5. Create session
+    // 6. Create session
     return s.createAuthSession(ctx, user)
 }
 
@@ -450,10 +740,11 @@ func (s *service) InitiatePasswordReset(ctx context.Context, email string) error
     
     // 2. Send OTP for password reset
     otpReq := &otp.SendOTPRequest{
-        UserID: user.ID,
-        Email:  email,
-        Type:   otp.OTPTypePasswordReset,
-        Method: otp.DeliveryMethodEmail,
+        UserID:   user.ID,
+        Email:    email,
+        Type:     otp.OTPTypePasswordReset,
+        Method:   otp.DeliveryMethodEmail,
+        Language: user.PreferredLanguage,
     }
     
     _, err = s.otpService.GenerateOTP(ctx, otpReq)
@@ -520,10 +811,6 @@ func (s *service) ResetPassword(ctx context.Context, resetToken string, newPassw
         if email == "" {
             return errors.New("missing email in reset token")
         }
-
-        
-        // Delete the token after use
-        s.redis.Del(ctx, key)
     } else {
         return errors.New("password reset not available")
     }
@@ -534,6 +821,19 @@ func (s *service) ResetPassword(ctx context.Context, resetToken string, newPassw
         return err
     }
     
+    // Check the new password before spending the token, so the user can
+    // try another
+    userInputs := []string{user.Username, email}
+    if user.Phone != nil {
+        userInputs = append(userInputs, *user.Phone)
+    }
+    if err := s.checkPassword(ctx, newPassword, userInputs...); err != nil {
+        return err
+    }
+    
+    // Delete the token after use
+    s.redis.Del(ctx, fmt.Sprintf("password_reset:%s", resetToken))
+    
     // 3. Hash new password
     hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), s.config.BCryptCost)
     if err != nil {
@@ -548,7 +848,7 @@ func (s *service) ResetPassword(ctx context.Context, resetToken string, newPassw
     }
     
     // 5. Logout all devices for security
-    s.repo.DeleteUserSessions(ctx, userID)
+    s.LogoutAllDevices(ctx, userID)
     
     return nil
 }
@@ -569,21 +869,35 @@ func (s *service) GoogleAuth(ctx context.Context, req *GoogleAuthRequest) (*Auth
     // 2. Check if user exists
     user, err := s.repo.GetUserByEmail(ctx, tokenInfo.Email)
     if err != nil {
+        req.ReferralCode = strings.TrimSpace(req.ReferralCode)
+        if err := s.checkReferralCode(ctx, req.ReferralCode); err != nil {
+            return nil, err
+        }
+        
         // 3. Create new user
         username := generateUsernameFromEmail(tokenInfo.Email)
         user = &User{
-            Email:      &tokenInfo.Email,
-            Username:   username,
-            Provider:   "google",
-            ProviderID: &tokenInfo.UserId,
-            IsVerified: true, // Google accounts are pre-verified
-            CreatedAt:  time.Now(),
-            UpdatedAt:  time.Now(),
+            Email:             &tokenInfo.Email,
+            Username:          username,
+            Provider:          "google",
+            ProviderID:        &tokenInfo.UserId,
+            IsVerified:        true, // Google accounts are pre-verified
+            PreferredLanguage: i18n.FromContext(ctx),
+            Role:              RoleUser,
+            AccountStatus:     users.StatusActive,
+            CreatedAt:         time.Now(),
+            UpdatedAt:         time.Now(),
         }
         
         if err := s.repo.CreateUser(ctx, user); err != nil {
             return nil, fmt.Errorf("failed to create user: %w", err)
         }
+        s.track(ctx, user.ID, "signup", map[string]interface{}{"provider": user.Provider})
+        s.track(ctx, user.ID, "verified", map[string]interface{}{"provider": user.Provider})
+        s.attributeReferral(ctx, user.ID, req.ReferralCode)
+        if err := s.events.Publish(ctx, strconv.FormatInt(user.ID, 10), events.UserVerified{UserID: user.ID}); err != nil {
+            log.Printf("Failed to publish verification of user %d: %v", user.ID, err)
+        }
     } else {
         // Update provider info if needed
         if user.Provider == "local" {
@@ -600,28 +914,58 @@ func (s *service) GoogleAuth(ctx context.Context, req *GoogleAuthRequest) (*Auth
 // Helper functions
 
 func (s *service) createAuthSession(ctx context.Context, user *User) (*AuthResponse, error) {
-    accessToken, err := s.generateAccessToken(user)
+    if err := checkAccountStatus(user); err != nil {
+        return nil, err
+    }
+    
+    // Reserve the session ID first so the tokens can carry it
+    sessionID, err := s.repo.NextSessionID(ctx)
+    if err != nil {
+        return nil, err
+    }
+    
+    accessToken, err := s.generateAccessToken(user, sessionID)
     if err != nil {
         return nil, fmt.Errorf("failed to generate access token: %w", err)
     }
     
-    refreshToken, err := s.generateRefreshToken(user)
+    refreshToken, err := s.generateRefreshToken(user, sessionID)
     if err != nil {
         return nil, fmt.Errorf("failed to generate refresh token: %w", err)
     }
     
+    client := middleware.ClientInfoFromContext(ctx)
+    device := parseUserAgent(client.UserAgent)
+    label := device.Label()
+    fingerprint := deviceFingerprint(client.DeviceID, device)
+    
+    now := time.Now()
+    refreshExpiresAt := now.Add(s.config.RefreshTokenExpiry)
     session := &Session{
-        UserID:       user.ID,
-        Token:        accessToken,
-        RefreshToken: refreshToken,
-        ExpiresAt:    time.Now().Add(s.config.AccessTokenExpiry),
-        CreatedAt:    time.Now(),
+        ID:                sessionID,
+        UserID:            user.ID,
+        Token:             accessToken,
+        RefreshToken:      refreshToken,
+        DeviceInfo:        &label,
+        IPAddress:         optionalString(client.IP),
+        ExpiresAt:         now.Add(s.config.AccessTokenExpiry),
+        CreatedAt:         now,
+        UserAgent:         optionalString(client.UserAgent),
+        DeviceFingerprint: &fingerprint,
+        Location:          optionalString(client.Location()),
+        LastUsedAt:        &now,
+        RefreshExpiresAt:  &refreshExpiresAt,
     }
     
     if err := s.repo.CreateSession(ctx, session); err != nil {
         return nil, fmt.Errorf("failed to create session: %w", err)
     }
     
+    database.AfterCommit(ctx, func() {
+        s.checkNewDevice(ctx, user.ID, fingerprint, label, client.Location(), client.IP)
+        s.recordLoginLocation(ctx, user.ID)
+    })
+    
     return &AuthResponse{
         User:         user,
         AccessToken:  accessToken,
@@ -631,7 +975,34 @@ func (s *service) createAuthSession(ctx context.Context, user *User) (*AuthRespo
     }, nil
 }
 
-func (s *service) storePendingAuth(ctx context.Context, token string, userID int64) error {
+// checkNewDevice remembers the device a user signed in from and alerts them
+// when it's one they haven't used before. A user's first device is not
+// alerted on.
+func (s *service) checkNewDevice(ctx context.Context, userID int64, fingerprint, label, location, ip string) {
+    hadDevices, err := s.repo.HasDevices(ctx, userID)
+    if err != nil {
+        log.Printf("Failed to check devices for user %d: %v", userID, err)
+        return
+    }
+    
+    isNew, err := s.repo.RememberDevice(ctx, userID, fingerprint, label)
+    if err != nil {
+        log.Printf("Failed to remember device for user %d: %v", userID, err)
+        return
+    }
+    
+    if !isNew || !hadDevices || s.notifier == nil {
+        return
+    }
+    
+    go func(at time.Time) {
+        if err := s.notifier.SendNewDeviceLoginNotification(context.Background(), userID, label, location, ip, at); err != nil {
+            log.Printf("Failed to send new device alert to user %d: %v", userID, err)
+        }
+    }(time.Now())
+}
+
+func (s *service) storePendingAuth(ctx context.Context, token string, userID int64, method string) error {
     if s.redis == nil {
         return errors.New("redis not available")
     }
@@ -639,29 +1010,36 @@ func (s *service) storePendingAuth(ctx context.Context, token string, userID int
     key := fmt.Sprintf("pending_auth:%s", token)
     data := map[string]interface{}{
         "user_id": userID,
+        "method":  method,
         "expires": time.Now().Add(10 * time.Minute).Unix(),
     }
     jsonData, _ := json.Marshal(data)
     return s.redis.Set(ctx, key, jsonData, 10*time.Minute).Err()
 }
 
-func (s *service) getPendingAuth(ctx context.Context, token string) (int64, error) {
+func (s *service) getPendingAuth(ctx context.Context, token string) (int64, string, error) {
     if s.redis == nil {
-        return 0, errors.New("redis not available")
+        return 0, "", errors.New("redis not available")
     }
     
     key := fmt.Sprintf("pending_auth:%s", token)
     data, err := s.redis.Get(ctx, key).Result()
     if err != nil {
-        return 0, err
+        return 0, "", err
     }
     
     var pendingData map[string]interface{}
     if err := json.Unmarshal([]byte(data), &pendingData); err != nil {
-        return 0, err
+        return 0, "", err
+    }
+    
+    // Signins started before methods were stored used OTP
+    method, _ := pendingData["method"].(string)
+    if method == "" {
+        method = TwoFactorOTP
     }
     
-    return int64(pendingData["user_id"].(float64)), nil
+    return int64(pendingData["user_id"].(float64)), method, nil
 }
 
 func (s *service) clearPendingAuth(ctx context.Context, token string) {
@@ -671,13 +1049,37 @@ func (s *service) clearPendingAuth(ctx context.Context, token string) {
     }
 }
 
-func (s *service) recordFailedAttempt(ctx context.Context, identifier string) {
+// recordFailedAttempt counts a failed attempt and returns the total within
+// the attempts window
+func (s *service) recordFailedAttempt(ctx context.Context, identifier string) int64 {
     if s.redis == nil {
-        return
+        return 0
     }
     key := fmt.Sprintf("failed:%s", identifier)
-    s.redis.Incr(ctx, key)
-    s.redis.Expire(ctx, key, 15*time.Minute)
+    count, err := s.redis.Incr(ctx, key).Result()
+    if err != nil {
+        return 0
+    }
+    if count == 1 {
+        s.redis.Expire(ctx, key, s.loginAttemptsWindow())
+    }
+    return count
+}
+
+// failedAttempts returns the failed attempts counted within the window
+func (s *service) failedAttempts(ctx context.Context, identifier string) int64 {
+    if s.redis == nil {
+        return 0
+    }
+    count, _ := s.redis.Get(ctx, fmt.Sprintf("failed:%s", identifier)).Int64()
+    return count
+}
+
+func (s *service) loginAttemptsWindow() time.Duration {
+    if s.config.LoginAttemptsWindow > 0 {
+        return s.config.LoginAttemptsWindow
+    }
+    return 15 * time.Minute
 }
 
 func (s *service) clearFailedAttempts(ctx context.Context, identifier string) {
@@ -688,9 +1090,8 @@ func (s *service) clearFailedAttempts(ctx context.Context, identifier string) {
     s.redis.Del(ctx, key)
 }
 
-// Implement remaining interface methods...
+// RefreshToken issues new tokens for a session, replacing the old pair
 func (s *service) RefreshToken(ctx context.Context, refreshToken string) (*AuthResponse, error) {
-    // Implementation as before
     claims, err := utils.ValidateJWT(refreshToken, s.config.JWTSecret)
     if err != nil {
         return nil, ErrInvalidToken
@@ -709,8 +1110,41 @@ func (s *service) RefreshToken(ctx context.Context, refreshToken string) (*AuthR
     if err != nil {
         return nil, err
     }
+    if err := checkAccountStatus(user); err != nil {
+        return nil, err
+    }
     
-    return s.createAuthSession(ctx, user)
+    accessToken, err := s.generateAccessToken(user, session.ID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to generate access token: %w", err)
+    }
+    
+    newRefreshToken, err := s.generateRefreshToken(user, session.ID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to generate refresh token: %w", err)
+    }
+    
+    client := middleware.ClientInfoFromContext(ctx)
+    now := time.Now()
+    refreshExpiresAt := now.Add(s.config.RefreshTokenExpiry)
+    session.Token = accessToken
+    session.RefreshToken = newRefreshToken
+    session.ExpiresAt = now.Add(s.config.AccessTokenExpiry)
+    session.RefreshExpiresAt = &refreshExpiresAt
+    session.IPAddress = optionalString(client.IP)
+    session.Location = optionalString(client.Location())
+    
+    if err := s.repo.UpdateSession(ctx, session); err != nil {
+        return nil, err
+    }
+    
+    return &AuthResponse{
+        User:         user,
+        AccessToken:  accessToken,
+        RefreshToken: newRefreshToken,
+        ExpiresIn:    int(s.config.AccessTokenExpiry.Seconds()),
+        TokenType:    "Bearer",
+    }, nil
 }
 
 func (s *service) ValidateToken(ctx context.Context, token string) (*utils.JWTClaims, error) {
@@ -718,18 +1152,148 @@ func (s *service) ValidateToken(ctx context.Context, token string) (*utils.JWTCl
 }
 
 func (s *service) Logout(ctx context.Context, token string) error {
+    if claims, err := utils.ValidateJWT(token, s.config.JWTSecret); err == nil {
+        s.markRevoked(ctx, claims.SessionID)
+    }
     return s.repo.DeleteSessionByToken(ctx, token)
 }
 
 func (s *service) LogoutAllDevices(ctx context.Context, userID int64) error {
+    if sessions, err := s.repo.ListUserSessions(ctx, userID); err == nil {
+        for _, session := range sessions {
+            s.markRevoked(ctx, session.ID)
+        }
+    }
     return s.repo.DeleteUserSessions(ctx, userID)
 }
 
+// ListSessions returns the devices the user is signed in on, flagging the
+// one making the request
+func (s *service) ListSessions(ctx context.Context, userID, currentSessionID int64) ([]*SessionInfo, error) {
+    sessions, err := s.repo.ListUserSessions(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    
+    infos := make([]*SessionInfo, 0, len(sessions))
+    for _, session := range sessions {
+        info := &SessionInfo{
+            ID:         session.ID,
+            Device:     "Unknown device",
+            LastUsedAt: session.CreatedAt,
+            CreatedAt:  session.CreatedAt,
+            Current:    session.ID == currentSessionID,
+        }
+        if session.DeviceInfo != nil && *session.DeviceInfo != "" {
+            info.Device = *session.DeviceInfo
+        }
+        if session.IPAddress != nil {
+            info.IPAddress = *session.IPAddress
+        }
+        if session.Location != nil {
+            info.Location = *session.Location
+        }
+        if session.LastUsedAt != nil {
+            info.LastUsedAt = *session.LastUsedAt
+        }
+        infos = append(infos, info)
+    }
+    
+    return infos, nil
+}
+
+// RevokeSession signs the user out on one device
+func (s *service) RevokeSession(ctx context.Context, userID, sessionID int64) error {
+    deleted, err := s.repo.DeleteUserSession(ctx, userID, sessionID)
+    if err != nil {
+        return err
+    }
+    if !deleted {
+        return ErrSessionNotFound
+    }
+    
+    s.markRevoked(ctx, sessionID)
+    return nil
+}
+
+// CheckSession is called for every authenticated request. Revocations are
+// looked up in Redis when available, falling back to the sessions table.
+func (s *service) CheckSession(ctx context.Context, claims *utils.JWTClaims) error {
+    // Tokens issued before sessions were tracked expire on their own
+    if claims.SessionID == 0 {
+        return nil
+    }
+    
+    if s.redis != nil {
+        revoked, err := s.redis.Exists(ctx, revokedSessionKey(claims.SessionID)).Result()
+        if err == nil {
+            if revoked > 0 {
+                return ErrSessionRevoked
+            }
+            s.touchSession(ctx, claims.SessionID)
+            return nil
+        }
+    }
+    
+    exists, err := s.repo.SessionExists(ctx, claims.SessionID)
+    if err != nil {
+        return err
+    }
+    if !exists {
+        return ErrSessionRevoked
+    }
+    
+    s.touchSession(ctx, claims.SessionID)
+    return nil
+}
+
+// markRevoked rejects a session's outstanding access tokens until they
+// expire. Without Redis, CheckSession finds the session missing instead.
+func (s *service) markRevoked(ctx context.Context, sessionID int64) {
+    if s.redis == nil || sessionID == 0 {
+        return
+    }
+    s.redis.Set(ctx, revokedSessionKey(sessionID), 1, s.config.AccessTokenExpiry)
+}
+
+// touchSession updates the session's last-used time and IP, at most once
+// per sessionTouchInterval
+func (s *service) touchSession(ctx context.Context, sessionID int64) {
+    if s.redis != nil {
+        key := fmt.Sprintf("session_seen:%d", sessionID)
+        if first, err := s.redis.SetNX(ctx, key, 1, sessionTouchInterval).Result(); err == nil && !first {
+            return
+        }
+    }
+    
+    client := middleware.ClientInfoFromContext(ctx)
+    if err := s.repo.TouchSession(ctx, sessionID, client.IP, sessionTouchInterval); err != nil {
+        log.Printf("Failed to update session %d: %v", sessionID, err)
+    }
+}
+
+func (s *service) TouchActivity(ctx context.Context, userID int64) {
+    if s.redis != nil {
+        key := fmt.Sprintf("user_active:%d", userID)
+        if first, err := s.redis.SetNX(ctx, key, 1, activityTouchInterval).Result(); err == nil && !first {
+            return
+        }
+    }
+    
+    if err := s.repo.TouchLastActive(ctx, userID, activityTouchInterval); err != nil {
+        log.Printf("Failed to update last active for user %d: %v", userID, err)
+    }
+}
+
+func revokedSessionKey(sessionID int64) string {
+    return fmt.Sprintf("revoked_session:%d", sessionID)
+}
+
 func (s *service) GetUserByID(ctx context.Context, userID int64) (*User, error) {
     return s.repo.GetUserByID(ctx, userID)
 }
 
-func (s *service) generateAccessToken(user *User) (string, error) {
+func (s *service) generateAccessToken(user *User, sessionID int64) (string, error) {
     email := ""
     if user.Email != nil {
         email = *user.Email
@@ -740,6 +1304,7 @@ func (s *service) generateAccessToken(user *User) (string, error) {
         Email:     email,
         Username:  user.Username,
         Type:      "access",
+        SessionID: sessionID,
         ExpiresAt: time.Now().Add(s.config.AccessTokenExpiry).Unix(),
         IssuedAt:  time.Now().Unix(),
         NotBefore: time.Now().Unix(),
@@ -750,10 +1315,11 @@ func (s *service) generateAccessToken(user *User) (string, error) {
     return utils.GenerateJWT(claims, s.config.JWTSecret)
 }
 
-func (s *service) generateRefreshToken(user *User) (string, error) {
+func (s *service) generateRefreshToken(user *User, sessionID int64) (string, error) {
     claims := &utils.JWTClaims{
         UserID:    user.ID,
         Type:      "refresh",
+        SessionID: sessionID,
         ExpiresAt: time.Now().Add(s.config.RefreshTokenExpiry).Unix(),
         IssuedAt:  time.Now().Unix(),
         NotBefore: time.Now().Unix(),
@@ -788,6 +1354,14 @@ func generateUsernameFromEmail(email string) string {
     return fmt.Sprintf("%s_%s", base, suffix)
 }
 
+// optionalString maps "" to nil for nullable columns
+func optionalString(value string) *string {
+    if value == "" {
+        return nil
+    }
+    return &value
+}
+
 func generateRandomString(length int) string {
     const charset = "abcdefghijklmnopqrstuvwxyz0123456789"
     result := make([]byte, length)
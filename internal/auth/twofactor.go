@@ -0,0 +1,347 @@
+// internal/auth/twofactor.go
+// Two-factor setup: authenticator app enrollment, method selection and
+// recovery codes
+
+package auth
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+)
+
+var (
+    ErrTOTPNotEnrolled        = errors.New("authenticator app not set up")
+    ErrTOTPAlreadyEnabled     = errors.New("authenticator app already enabled")
+    ErrInvalidTwoFactorMethod = errors.New("two-factor method not available for this account")
+    ErrTwoFactorCodeRequired  = errors.New("a current authenticator or recovery code is required")
+    ErrTwoFactorUnavailable   = errors.New("two-factor verification is temporarily unavailable")
+)
+
+const (
+    // maxSecondFactorFailures is how many wrong authenticator or recovery
+    // codes a user can enter before verification locks
+    maxSecondFactorFailures = 5
+
+    // secondFactorLockout is how long wrong codes are counted from the
+    // first one, and how long verification stays locked after the last
+    secondFactorLockout = 15 * time.Minute
+)
+
+// GetTwoFactorStatus describes the user's two-factor setup
+func (s *service) GetTwoFactorStatus(ctx context.Context, userID int64) (*TwoFactorStatus, error) {
+    method, err := s.repo.GetTwoFactorMethod(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    status := &TwoFactorStatus{Method: method}
+    if totp, err := s.repo.GetTOTPSecret(ctx, userID); err == nil {
+        status.TOTPEnabled = totp.ConfirmedAt != nil
+    } else if err != ErrTOTPNotEnrolled {
+        return nil, err
+    }
+
+    if status.RecoveryCodesRemaining, err = s.repo.CountRecoveryCodes(ctx, userID); err != nil {
+        return nil, err
+    }
+
+    effective, err := s.twoFactorMethod(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    status.Required = effective != ""
+
+    return status, nil
+}
+
+// EnrollTOTP starts authenticator setup. The secret only takes effect once
+// ConfirmTOTP has seen a code generated from it.
+func (s *service) EnrollTOTP(ctx context.Context, userID int64) (*TOTPEnrollment, error) {
+    user, err := s.repo.GetUserByID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if existing, err := s.repo.GetTOTPSecret(ctx, userID); err == nil && existing.ConfirmedAt != nil {
+        return nil, ErrTOTPAlreadyEnabled
+    } else if err != nil && err != ErrTOTPNotEnrolled {
+        return nil, err
+    }
+
+    secret, err := generateTOTPSecret()
+    if err != nil {
+        return nil, fmt.Errorf("failed to generate secret: %w", err)
+    }
+    sealed, err := s.secrets.seal(secret)
+    if err != nil {
+        return nil, fmt.Errorf("failed to encrypt secret: %w", err)
+    }
+    if err := s.repo.SaveTOTPSecret(ctx, userID, sealed); err != nil {
+        return nil, err
+    }
+
+    account := user.Username
+    if user.Email != nil {
+        account = *user.Email
+    }
+
+    return &TOTPEnrollment{
+        Secret:          secret,
+        ProvisioningURI: totpProvisioningURI(s.config.TOTPIssuer, account, secret),
+    }, nil
+}
+
+// ConfirmTOTP finishes authenticator setup with a code from the app, makes
+// it the user's two-factor method and returns their recovery codes
+func (s *service) ConfirmTOTP(ctx context.Context, userID int64, code string) ([]string, error) {
+    totp, err := s.repo.GetTOTPSecret(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if totp.ConfirmedAt != nil {
+        return nil, ErrTOTPAlreadyEnabled
+    }
+
+    if err := s.limitSecondFactor(ctx, userID, func() error {
+        return s.checkTOTP(ctx, totp, code)
+    }); err != nil {
+        return nil, err
+    }
+    if err := s.repo.ConfirmTOTP(ctx, userID); err != nil {
+        return nil, err
+    }
+    if err := s.repo.SetTwoFactorMethod(ctx, userID, TwoFactorTOTP); err != nil {
+        return nil, err
+    }
+
+    return s.issueRecoveryCodes(ctx, userID)
+}
+
+// DisableTOTP removes the authenticator app after checking a current code
+// or recovery code. Users who had it as their method fall back to whatever
+// the global setting requires.
+func (s *service) DisableTOTP(ctx context.Context, userID int64, code string) error {
+    if err := s.verifySecondFactor(ctx, userID, code); err != nil {
+        return err
+    }
+
+    if err := s.repo.DeleteTOTPSecret(ctx, userID); err != nil {
+        return err
+    }
+    if err := s.repo.DeleteRecoveryCodes(ctx, userID); err != nil {
+        return err
+    }
+
+    method, err := s.repo.GetTwoFactorMethod(ctx, userID)
+    if err != nil {
+        return err
+    }
+    if method == TwoFactorTOTP {
+        return s.repo.SetTwoFactorMethod(ctx, userID, "")
+    }
+    return nil
+}
+
+// RegenerateRecoveryCodes replaces the user's recovery codes after checking
+// a current authenticator code
+func (s *service) RegenerateRecoveryCodes(ctx context.Context, userID int64, code string) ([]string, error) {
+    totp, err := s.confirmedTOTP(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if err := s.limitSecondFactor(ctx, userID, func() error {
+        return s.checkTOTP(ctx, totp, code)
+    }); err != nil {
+        return nil, err
+    }
+
+    return s.issueRecoveryCodes(ctx, userID)
+}
+
+// SetTwoFactorMethod picks how the user receives codes at signin: "otp" by
+// email or SMS, "totp" from an enrolled authenticator app, or "none". Users
+// with an authenticator must give a current code or a recovery code, so a
+// stolen access token can't switch two-factor off.
+func (s *service) SetTwoFactorMethod(ctx context.Context, userID int64, method, code string) error {
+    switch method {
+    case TwoFactorTOTP:
+        if _, err := s.confirmedTOTP(ctx, userID); err != nil {
+            return err
+        }
+    case TwoFactorOTP:
+        user, err := s.repo.GetUserByID(ctx, userID)
+        if err != nil {
+            return err
+        }
+        if user.Email == nil && user.Phone == nil {
+            return ErrInvalidTwoFactorMethod
+        }
+    case "none", "":
+        method = ""
+    default:
+        return ErrInvalidTwoFactorMethod
+    }
+
+    if _, err := s.confirmedTOTP(ctx, userID); err == nil {
+        if code == "" {
+            return ErrTwoFactorCodeRequired
+        }
+        if err := s.verifySecondFactor(ctx, userID, code); err != nil {
+            return err
+        }
+    } else if err != ErrTOTPNotEnrolled {
+        return err
+    }
+
+    return s.repo.SetTwoFactorMethod(ctx, userID, method)
+}
+
+// twoFactorMethod is the method a signin must pass: the user's choice if it
+// is usable, otherwise OTP when 2FA is enabled globally, otherwise none
+func (s *service) twoFactorMethod(ctx context.Context, userID int64) (string, error) {
+    method, err := s.repo.GetTwoFactorMethod(ctx, userID)
+    if err != nil {
+        return "", err
+    }
+
+    switch method {
+    case TwoFactorTOTP:
+        if _, err := s.confirmedTOTP(ctx, userID); err == nil {
+            return TwoFactorTOTP, nil
+        } else if err != ErrTOTPNotEnrolled {
+            return "", err
+        }
+    case TwoFactorOTP:
+        return TwoFactorOTP, nil
+    }
+
+    if s.config.Enable2FA {
+        return TwoFactorOTP, nil
+    }
+    return "", nil
+}
+
+// confirmedTOTP returns the user's authenticator enrollment if setup was finished
+func (s *service) confirmedTOTP(ctx context.Context, userID int64) (*TOTPSecret, error) {
+    totp, err := s.repo.GetTOTPSecret(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if totp.ConfirmedAt == nil {
+        return nil, ErrTOTPNotEnrolled
+    }
+    return totp, nil
+}
+
+// checkTOTP verifies an authenticator code and burns its time step
+func (s *service) checkTOTP(ctx context.Context, totp *TOTPSecret, code string) error {
+    secret, err := s.secrets.open(totp.Secret)
+    if err != nil {
+        return fmt.Errorf("failed to decrypt secret: %w", err)
+    }
+
+    step, ok := matchTOTP(secret, code, time.Now())
+    if !ok {
+        return ErrInvalidOTP
+    }
+
+    fresh, err := s.repo.UseTOTPStep(ctx, totp.UserID, step)
+    if err != nil {
+        return err
+    }
+    if !fresh {
+        return ErrInvalidOTP
+    }
+    return nil
+}
+
+// verifySecondFactor accepts a current authenticator code or an unused
+// recovery code
+func (s *service) verifySecondFactor(ctx context.Context, userID int64, code string) error {
+    totp, err := s.confirmedTOTP(ctx, userID)
+    if err != nil {
+        return err
+    }
+
+    return s.limitSecondFactor(ctx, userID, func() error {
+        if len(code) == totpDigits {
+            return s.checkTOTP(ctx, totp, code)
+        }
+
+        used, err := s.repo.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+        if err != nil {
+            return err
+        }
+        if !used {
+            return ErrInvalidOTP
+        }
+        return nil
+    })
+}
+
+// limitSecondFactor runs check, which verifies an authenticator or recovery
+// code, under the user's limit on wrong codes. Signin and every settings
+// change share the one count per user, so starting a fresh signin doesn't
+// reset it. Six-digit codes can't go unthrottled, so codes are refused
+// while the count can't be kept.
+func (s *service) limitSecondFactor(ctx context.Context, userID int64, check func() error) error {
+    if s.redis == nil {
+        return ErrTwoFactorUnavailable
+    }
+
+    // Count the attempt before checking it, so parallel guesses can't all
+    // slip in under the limit
+    key := secondFactorFailuresKey(userID)
+    failures, err := s.redis.Incr(ctx, key).Result()
+    if err != nil {
+        log.Printf("Failed to count two-factor attempt for user %d: %v", userID, err)
+        return ErrTwoFactorUnavailable
+    }
+    if failures == 1 {
+        if err := s.redis.Expire(ctx, key, secondFactorLockout).Err(); err != nil {
+            log.Printf("Failed to count two-factor attempt for user %d: %v", userID, err)
+            s.redis.Del(ctx, key)
+            return ErrTwoFactorUnavailable
+        }
+    }
+    if failures > maxSecondFactorFailures {
+        return ErrTooManyAttempts
+    }
+
+    err = check()
+    switch err {
+    case nil:
+        s.redis.Del(ctx, key)
+    case ErrInvalidOTP:
+        if failures == maxSecondFactorFailures {
+            s.redis.Expire(ctx, key, secondFactorLockout)
+            return ErrTooManyAttempts
+        }
+    default:
+        // Only wrong codes count
+        s.redis.Decr(ctx, key)
+    }
+    return err
+}
+
+func secondFactorFailuresKey(userID int64) string {
+    return fmt.Sprintf("second_factor_failures:%d", userID)
+}
+
+// issueRecoveryCodes replaces the user's recovery codes, returning the
+// plaintext codes, which are never shown again
+func (s *service) issueRecoveryCodes(ctx context.Context, userID int64) ([]string, error) {
+    codes := generateRecoveryCodes()
+    hashes := make([]string, len(codes))
+    for i, code := range codes {
+        hashes[i] = hashRecoveryCode(code)
+    }
+
+    if err := s.repo.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+        return nil, err
+    }
+    return codes, nil
+}
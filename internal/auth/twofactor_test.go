@@ -0,0 +1,105 @@
+package auth
+
+import (
+    "context"
+    "encoding/json"
+    "net/http"
+    "net/http/httptest"
+    "strings"
+    "testing"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// twoFactorRepo is a user with an email address, optionally with a
+// confirmed authenticator
+type twoFactorRepo struct {
+    Repository
+    enrolled bool
+
+    methodSet bool
+    method    string
+}
+
+func (r *twoFactorRepo) GetUserByID(ctx context.Context, userID int64) (*User, error) {
+    email := "someone@example.com"
+    return &User{ID: userID, Email: &email}, nil
+}
+
+func (r *twoFactorRepo) GetTOTPSecret(ctx context.Context, userID int64) (*TOTPSecret, error) {
+    if !r.enrolled {
+        return nil, ErrTOTPNotEnrolled
+    }
+    confirmed := time.Now()
+    return &TOTPSecret{UserID: userID, ConfirmedAt: &confirmed}, nil
+}
+
+func (r *twoFactorRepo) SetTwoFactorMethod(ctx context.Context, userID int64, method string) error {
+    r.methodSet, r.method = true, method
+    return nil
+}
+
+func newTwoFactorService(repo Repository) *service {
+    return NewService(repo, nil, nil, &Config{JWTSecret: "test"}).(*service)
+}
+
+func TestSetTwoFactorMethodNeedsCodeWithAuthenticator(t *testing.T) {
+    for _, method := range []string{"none", "", TwoFactorOTP, TwoFactorTOTP} {
+        repo := &twoFactorRepo{enrolled: true}
+        err := newTwoFactorService(repo).SetTwoFactorMethod(context.Background(), 1, method, "")
+        if err != ErrTwoFactorCodeRequired {
+            t.Errorf("method %q without a code: error = %v, want ErrTwoFactorCodeRequired", method, err)
+        }
+        if repo.methodSet {
+            t.Errorf("method %q without a code was changed to %q", method, repo.method)
+        }
+    }
+}
+
+func TestSetTwoFactorMethodRefusesCodesWithoutLimiter(t *testing.T) {
+    // No Redis, so wrong codes can't be counted
+    repo := &twoFactorRepo{enrolled: true}
+    err := newTwoFactorService(repo).SetTwoFactorMethod(context.Background(), 1, "none", "123456")
+    if err != ErrTwoFactorUnavailable {
+        t.Errorf("error = %v, want ErrTwoFactorUnavailable", err)
+    }
+    if repo.methodSet {
+        t.Errorf("method was changed to %q", repo.method)
+    }
+}
+
+func TestSetTwoFactorMethodWithoutAuthenticator(t *testing.T) {
+    repo := &twoFactorRepo{}
+    if err := newTwoFactorService(repo).SetTwoFactorMethod(context.Background(), 1, TwoFactorOTP, ""); err != nil {
+        t.Fatalf("error = %v", err)
+    }
+    if !repo.methodSet || repo.method != TwoFactorOTP {
+        t.Errorf("method = %q, want %q", repo.method, TwoFactorOTP)
+    }
+}
+
+func TestSetTwoFactorMethodHandlerRejectsMissingCode(t *testing.T) {
+    repo := &twoFactorRepo{enrolled: true}
+    handler := NewHandler(newTwoFactorService(repo))
+
+    req := httptest.NewRequest("PUT", "/api/v1/auth/2fa/method", strings.NewReader(`{"method":"none"}`))
+    req.Header.Set("Content-Type", "application/json")
+    req = req.WithContext(withClaims(req.Context(), &utils.JWTClaims{UserID: 1, Type: "access"}))
+    rec := httptest.NewRecorder()
+    handler.SetTwoFactorMethod(rec, req)
+
+    if rec.Code != http.StatusForbidden {
+        t.Fatalf("status = %d, want %d", rec.Code, http.StatusForbidden)
+    }
+    var body utils.Response
+    if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+        t.Fatal(err)
+    }
+    if body.Error != ErrTwoFactorCodeRequired.Error() {
+        t.Errorf("error = %q, want %q", body.Error, ErrTwoFactorCodeRequired.Error())
+    }
+    if repo.methodSet {
+        t.Errorf("method was changed to %q", repo.method)
+    }
+}
@@ -0,0 +1,57 @@
+// internal/billing/coins.go
+
+package billing
+
+import "context"
+
+const (
+    CoinPackSmall  = "coins_100"
+    CoinPackMedium = "coins_550"
+    CoinPackLarge  = "coins_1200"
+)
+
+// CoinPack is a one-off purchase of coins for virtual gifts
+type CoinPack struct {
+    ID         string `json:"id"`
+    Name       string `json:"name"`
+    Coins      int    `json:"coins"`
+    PriceCents int64  `json:"price_cents"`
+    Currency   string `json:"currency"`
+}
+
+// coinPacks is the coin pack catalog. As with plans, prices are display
+// prices and the provider's price is what is charged.
+var coinPacks = []*CoinPack{
+    {ID: CoinPackSmall, Name: "100 coins", Coins: 100, PriceCents: 99, Currency: "USD"},
+    {ID: CoinPackMedium, Name: "550 coins", Coins: 550, PriceCents: 499, Currency: "USD"},
+    {ID: CoinPackLarge, Name: "1200 coins", Coins: 1200, PriceCents: 999, Currency: "USD"},
+}
+
+// CoinPacks returns the coin pack catalog
+func CoinPacks() []*CoinPack {
+    return coinPacks
+}
+
+// GetCoinPack returns a coin pack by ID, or nil
+func GetCoinPack(packID string) *CoinPack {
+    for _, p := range coinPacks {
+        if p.ID == packID {
+            return p
+        }
+    }
+    return nil
+}
+
+// Wallet holds the coins users buy. Gifts implements it; billing credits it
+// when a provider confirms a coin pack payment.
+type Wallet interface {
+    // CreditPurchase adds coins once per reference, so a redelivered
+    // payment isn't credited twice
+    CreditPurchase(ctx context.Context, userID int64, coins int, reference string) error
+}
+
+// CoinCheckoutRequest starts a coin pack purchase
+type CoinCheckoutRequest struct {
+    PackID   string `json:"pack_id" validate:"required"`
+    Provider string `json:"provider" validate:"omitempty,max=32"`
+}
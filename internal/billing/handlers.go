@@ -0,0 +1,171 @@
+// internal/billing/handlers.go
+
+package billing
+
+import (
+    "errors"
+    "io"
+    "log"
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// maxWebhookSize bounds webhook bodies; provider events are a few KB
+const maxWebhookSize = 1 << 20
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// GetPlans handles GET /billing/plans
+func (h *Handler) GetPlans(w http.ResponseWriter, r *http.Request) {
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "plans": h.service.GetPlans(),
+    })
+}
+
+// GetSubscription handles GET /billing/subscription
+func (h *Handler) GetSubscription(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    resp, err := h.service.GetSubscription(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get subscription")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, resp)
+}
+
+// CreateCheckout handles POST /billing/checkout
+func (h *Handler) CreateCheckout(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    var req CheckoutRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    checkout, err := h.service.CreateCheckout(r.Context(), userID, &req)
+    if err != nil {
+        switch {
+        case errors.Is(err, ErrPlanNotFound):
+            utils.RespondWithError(w, http.StatusBadRequest, "Plan not found")
+        case errors.Is(err, ErrUnknownProvider):
+            utils.RespondWithError(w, http.StatusBadRequest, "Unsupported payment provider")
+        case errors.Is(err, ErrAlreadySubscribed):
+            utils.RespondWithError(w, http.StatusConflict, "You already have an active subscription")
+        default:
+            log.Printf("Checkout for user %d failed: %v", userID, err)
+            utils.RespondWithError(w, http.StatusBadGateway, "Failed to start checkout")
+        }
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, checkout)
+}
+
+// GetCoinPacks handles GET /billing/coins
+func (h *Handler) GetCoinPacks(w http.ResponseWriter, r *http.Request) {
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "packs": h.service.GetCoinPacks(),
+    })
+}
+
+// CreateCoinCheckout handles POST /billing/coins/checkout
+func (h *Handler) CreateCoinCheckout(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    var req CoinCheckoutRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    checkout, err := h.service.CreateCoinCheckout(r.Context(), userID, &req)
+    if err != nil {
+        switch {
+        case errors.Is(err, ErrCoinPackNotFound):
+            utils.RespondWithError(w, http.StatusBadRequest, "Coin pack not found")
+        case errors.Is(err, ErrUnknownProvider):
+            utils.RespondWithError(w, http.StatusBadRequest, "Unsupported payment provider")
+        default:
+            log.Printf("Coin checkout for user %d failed: %v", userID, err)
+            utils.RespondWithError(w, http.StatusBadGateway, "Failed to start checkout")
+        }
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, checkout)
+}
+
+// CancelSubscription handles POST /billing/subscription/cancel
+func (h *Handler) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    sub, err := h.service.CancelSubscription(r.Context(), userID)
+    if err != nil {
+        if errors.Is(err, ErrNoSubscription) {
+            utils.RespondWithError(w, http.StatusNotFound, "No active subscription")
+            return
+        }
+        if errors.Is(err, ErrNotCancellable) {
+            utils.RespondWithError(w, http.StatusConflict, "Granted premium days can't be cancelled")
+            return
+        }
+        log.Printf("Cancel subscription for user %d failed: %v", userID, err)
+        utils.RespondWithError(w, http.StatusBadGateway, "Failed to cancel subscription")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, sub)
+}
+
+// HandleWebhook handles POST /billing/webhooks/{provider}. Providers retry on
+// non-2xx responses, so only a processing failure returns 5xx.
+func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+    provider := mux.Vars(r)["provider"]
+
+    payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid payload")
+        return
+    }
+
+    if err := h.service.HandleWebhook(r.Context(), provider, payload, r.Header); err != nil {
+        switch {
+        case errors.Is(err, ErrUnknownProvider):
+            utils.RespondWithError(w, http.StatusNotFound, "Unknown provider")
+        case errors.Is(err, ErrInvalidSignature):
+            utils.RespondWithError(w, http.StatusBadRequest, "Invalid signature")
+        default:
+            log.Printf("Failed to process %s webhook: %v", provider, err)
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to process webhook")
+        }
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]bool{"received": true})
+}
@@ -0,0 +1,117 @@
+// internal/billing/models.go
+
+package billing
+
+import (
+    "time"
+)
+
+type SubscriptionStatus string
+
+const (
+    StatusActive   SubscriptionStatus = "active"
+    StatusTrialing SubscriptionStatus = "trialing"
+    StatusPastDue  SubscriptionStatus = "past_due"
+    StatusCanceled SubscriptionStatus = "canceled"
+    StatusExpired  SubscriptionStatus = "expired"
+
+    // StatusIncomplete is a subscription whose first payment hasn't gone through
+    StatusIncomplete SubscriptionStatus = "incomplete"
+)
+
+// GrantsAccess reports whether a subscription in this status unlocks its plan.
+// Past-due subscriptions keep access while the provider retries payment.
+func (s SubscriptionStatus) GrantsAccess() bool {
+    return s == StatusActive || s == StatusTrialing || s == StatusPastDue
+}
+
+// Entitlements are what a plan unlocks. Other services consult these through
+// the Checker rather than looking at plans or subscriptions directly.
+type Entitlements struct {
+    CanSeeProfileViews bool `json:"can_see_profile_views"`
+    DailySwipeLimit    int  `json:"daily_swipe_limit"` // 0 means unlimited
+    DailySuperLikes    int  `json:"daily_super_likes"`
+    MonthlyBoosts      int  `json:"monthly_boosts"`
+    CanRewind          bool `json:"can_rewind"`
+    AdFree             bool `json:"ad_free"`
+}
+
+type Plan struct {
+    ID           string       `json:"id"`
+    Name         string       `json:"name"`
+    PriceCents   int64        `json:"price_cents"`
+    Currency     string       `json:"currency"`
+    Interval     string       `json:"interval"` // month, year
+    Entitlements Entitlements `json:"entitlements"`
+}
+
+type Subscription struct {
+    ID                     int64              `json:"id" db:"id"`
+    UserID                 int64              `json:"user_id" db:"user_id"`
+    PlanID                 string             `json:"plan_id" db:"plan_id"`
+    Provider               string             `json:"provider" db:"provider"`
+    ProviderSubscriptionID string             `json:"-" db:"provider_subscription_id"`
+    ProviderCustomerID     *string            `json:"-" db:"provider_customer_id"`
+    Status                 SubscriptionStatus `json:"status" db:"status"`
+    CurrentPeriodEnd       *time.Time         `json:"current_period_end,omitempty" db:"current_period_end"`
+    CancelAtPeriodEnd      bool               `json:"cancel_at_period_end" db:"cancel_at_period_end"`
+    CreatedAt              time.Time          `json:"created_at" db:"created_at"`
+    UpdatedAt              time.Time          `json:"updated_at" db:"updated_at"`
+}
+
+// IsCurrent reports whether the subscription unlocks its plan at now
+func (s *Subscription) IsCurrent(now time.Time) bool {
+    if !s.Status.GrantsAccess() {
+        return false
+    }
+    return s.CurrentPeriodEnd == nil || now.Before(*s.CurrentPeriodEnd)
+}
+
+// SubscriptionResponse is the user's current billing state
+type SubscriptionResponse struct {
+    Plan         *Plan         `json:"plan"`
+    Subscription *Subscription `json:"subscription,omitempty"`
+    Entitlements Entitlements  `json:"entitlements"`
+}
+
+// CheckoutRequest starts a subscription purchase
+type CheckoutRequest struct {
+    PlanID   string `json:"plan_id" validate:"required"`
+    Provider string `json:"provider" validate:"omitempty,max=32"`
+}
+
+// Checkout is where to send the user to pay
+type Checkout struct {
+    Provider  string `json:"provider"`
+    SessionID string `json:"session_id"`
+    URL       string `json:"url"`
+}
+
+// EventType is a provider webhook normalized to what billing cares about
+type EventType string
+
+const (
+    EventSubscriptionActivated EventType = "subscription.activated"
+    EventSubscriptionUpdated   EventType = "subscription.updated"
+    EventSubscriptionCanceled  EventType = "subscription.canceled"
+    EventPaymentFailed         EventType = "payment.failed"
+    EventCoinsPurchased        EventType = "coins.purchased"
+    EventIgnored               EventType = "ignored"
+)
+
+// WebhookEvent is a verified provider webhook. Fields other than ID, Type and
+// ProviderSubscriptionID are only set when the provider sent them. Coin
+// purchases carry CoinPackID and PaymentID instead of a subscription.
+type WebhookEvent struct {
+    ID                     string
+    Type                   EventType
+    UserID                 int64
+    PlanID                 string
+    ProviderSubscriptionID string
+    ProviderCustomerID     string
+    Status                 SubscriptionStatus
+    CurrentPeriodEnd       *time.Time
+    CancelAtPeriodEnd      bool
+    CoinPackID             string
+    PaymentID              string
+}
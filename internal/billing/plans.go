@@ -0,0 +1,62 @@
+// internal/billing/plans.go
+
+package billing
+
+const (
+    PlanFree           = "free"
+    PlanPremiumMonthly = "premium_monthly"
+    PlanPremiumYearly  = "premium_yearly"
+)
+
+var premiumEntitlements = Entitlements{
+    CanSeeProfileViews: true,
+    DailySwipeLimit:    0,
+    DailySuperLikes:    5,
+    MonthlyBoosts:      1,
+    CanRewind:          true,
+    AdFree:             true,
+}
+
+// plans is the plan catalog. Prices are display prices; what is charged is
+// set by the provider's price for the plan.
+var plans = []*Plan{
+    {
+        ID:       PlanFree,
+        Name:     "Free",
+        Currency: "USD",
+        Entitlements: Entitlements{
+            DailySwipeLimit: 50,
+        },
+    },
+    {
+        ID:           PlanPremiumMonthly,
+        Name:         "Premium",
+        PriceCents:   999,
+        Currency:     "USD",
+        Interval:     "month",
+        Entitlements: premiumEntitlements,
+    },
+    {
+        ID:           PlanPremiumYearly,
+        Name:         "Premium (yearly)",
+        PriceCents:   7999,
+        Currency:     "USD",
+        Interval:     "year",
+        Entitlements: premiumEntitlements,
+    },
+}
+
+// Plans returns the plan catalog
+func Plans() []*Plan {
+    return plans
+}
+
+// GetPlan returns a plan by ID, or nil
+func GetPlan(planID string) *Plan {
+    for _, p := range plans {
+        if p.ID == planID {
+            return p
+        }
+    }
+    return nil
+}
@@ -0,0 +1,33 @@
+// internal/billing/provider.go
+
+package billing
+
+import (
+    "context"
+    "errors"
+    "net/http"
+)
+
+var (
+    ErrInvalidSignature = errors.New("invalid webhook signature")
+    ErrUnknownProvider  = errors.New("unknown billing provider")
+)
+
+// Provider is a payment provider. Stripe is built in; Paystack and
+// Flutterwave plug in by implementing this and registering with the service.
+type Provider interface {
+    Name() string
+
+    // CreateCheckout starts a hosted checkout for plan
+    CreateCheckout(ctx context.Context, userID int64, email string, plan *Plan) (*Checkout, error)
+
+    // CreatePaymentCheckout starts a hosted checkout for a one-off coin pack
+    CreatePaymentCheckout(ctx context.Context, userID int64, email string, pack *CoinPack) (*Checkout, error)
+
+    // CancelSubscription stops renewal at the end of the current period
+    CancelSubscription(ctx context.Context, providerSubscriptionID string) error
+
+    // ParseWebhook verifies a webhook's signature and normalizes it. Events
+    // billing doesn't handle come back with Type EventIgnored.
+    ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error)
+}
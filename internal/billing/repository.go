@@ -0,0 +1,163 @@
+// internal/billing/repository.go
+
+package billing
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+
+    "github.com/jmoiron/sqlx"
+)
+
+var ErrNoSubscription = errors.New("no active subscription")
+
+type Repository interface {
+    // Subscriptions
+    GetCurrentSubscription(ctx context.Context, userID int64) (*Subscription, error)
+    GetSubscriptionByProviderID(ctx context.Context, provider, providerSubscriptionID string) (*Subscription, error)
+    UpsertSubscription(ctx context.Context, sub *Subscription) error
+    CreateGrant(ctx context.Context, userID int64, planID string, days int, reference string) (bool, error)
+
+    // Webhook idempotency
+    ClaimWebhookEvent(ctx context.Context, provider, eventID string) (bool, error)
+    ReleaseWebhookEvent(ctx context.Context, provider, eventID string) error
+
+    // Users
+    GetUserEmail(ctx context.Context, userID int64) (string, error)
+    SetUserPremium(ctx context.Context, userID int64, premium bool) error
+}
+
+type postgresRepository struct {
+    db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) Repository {
+    return &postgresRepository{db: db}
+}
+
+const subscriptionColumns = `
+    id, user_id, plan_id, provider, provider_subscription_id, provider_customer_id,
+    status, current_period_end, cancel_at_period_end, created_at, updated_at`
+
+// GetCurrentSubscription returns the user's most recent subscription that
+// still grants access. Paid subscriptions come before granted days.
+func (r *postgresRepository) GetCurrentSubscription(ctx context.Context, userID int64) (*Subscription, error) {
+    var sub Subscription
+    query := `
+        SELECT ` + subscriptionColumns + `
+        FROM subscriptions
+        WHERE user_id = $1
+          AND status IN ($2, $3, $4)
+          AND (current_period_end IS NULL OR current_period_end > NOW())
+        ORDER BY provider = $5, updated_at DESC
+        LIMIT 1`
+
+    err := r.db.GetContext(ctx, &sub, query, userID, StatusActive, StatusTrialing, StatusPastDue, ProviderGrant)
+    if err == sql.ErrNoRows {
+        return nil, ErrNoSubscription
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &sub, nil
+}
+
+func (r *postgresRepository) GetSubscriptionByProviderID(ctx context.Context, provider, providerSubscriptionID string) (*Subscription, error) {
+    var sub Subscription
+    query := `
+        SELECT ` + subscriptionColumns + `
+        FROM subscriptions
+        WHERE provider = $1 AND provider_subscription_id = $2`
+
+    err := r.db.GetContext(ctx, &sub, query, provider, providerSubscriptionID)
+    if err == sql.ErrNoRows {
+        return nil, ErrNoSubscription
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &sub, nil
+}
+
+// UpsertSubscription records the provider's view of a subscription. Fields the
+// provider left out keep their stored values.
+func (r *postgresRepository) UpsertSubscription(ctx context.Context, sub *Subscription) error {
+    query := `
+        INSERT INTO subscriptions (
+            user_id, plan_id, provider, provider_subscription_id, provider_customer_id,
+            status, current_period_end, cancel_at_period_end
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        ON CONFLICT (provider, provider_subscription_id) DO UPDATE SET
+            plan_id = COALESCE(NULLIF(EXCLUDED.plan_id, ''), subscriptions.plan_id),
+            provider_customer_id = COALESCE(EXCLUDED.provider_customer_id, subscriptions.provider_customer_id),
+            status = EXCLUDED.status,
+            current_period_end = COALESCE(EXCLUDED.current_period_end, subscriptions.current_period_end),
+            cancel_at_period_end = EXCLUDED.cancel_at_period_end,
+            updated_at = NOW()
+        RETURNING ` + subscriptionColumns
+
+    return r.db.QueryRowxContext(ctx, query,
+        sub.UserID, sub.PlanID, sub.Provider, sub.ProviderSubscriptionID, sub.ProviderCustomerID,
+        sub.Status, sub.CurrentPeriodEnd, sub.CancelAtPeriodEnd,
+    ).StructScan(sub)
+}
+
+// CreateGrant records days of a plan given away, as a subscription that
+// doesn't renew. It starts when the user's latest grant ends, so grants add
+// up. It returns false when reference was already granted.
+func (r *postgresRepository) CreateGrant(ctx context.Context, userID int64, planID string, days int, reference string) (bool, error) {
+    query := `
+        INSERT INTO subscriptions (
+            user_id, plan_id, provider, provider_subscription_id,
+            status, current_period_end, cancel_at_period_end
+        )
+        SELECT $1, $2, $3, $4, $5,
+               GREATEST(NOW(), COALESCE(MAX(current_period_end), NOW())) + make_interval(days => $6),
+               true
+        FROM subscriptions
+        WHERE user_id = $1 AND provider = $3 AND status = $5
+        ON CONFLICT (provider, provider_subscription_id) DO NOTHING`
+
+    result, err := r.db.ExecContext(ctx, query, userID, planID, ProviderGrant, reference, StatusActive, days)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// ClaimWebhookEvent records a webhook event, returning false if it was already processed
+func (r *postgresRepository) ClaimWebhookEvent(ctx context.Context, provider, eventID string) (bool, error) {
+    query := `
+        INSERT INTO billing_webhook_events (provider, event_id)
+        VALUES ($1, $2)
+        ON CONFLICT DO NOTHING`
+
+    result, err := r.db.ExecContext(ctx, query, provider, eventID)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// ReleaseWebhookEvent forgets a claimed event so the provider's retry is processed
+func (r *postgresRepository) ReleaseWebhookEvent(ctx context.Context, provider, eventID string) error {
+    _, err := r.db.ExecContext(ctx,
+        `DELETE FROM billing_webhook_events WHERE provider = $1 AND event_id = $2`, provider, eventID)
+    return err
+}
+
+func (r *postgresRepository) GetUserEmail(ctx context.Context, userID int64) (string, error) {
+    var email string
+    err := r.db.GetContext(ctx, &email, `SELECT COALESCE(email, '') FROM users WHERE id = $1`, userID)
+    return email, err
+}
+
+// SetUserPremium keeps users.is_premium in step with the subscription
+func (r *postgresRepository) SetUserPremium(ctx context.Context, userID int64, premium bool) error {
+    _, err := r.db.ExecContext(ctx,
+        `UPDATE users SET is_premium = $2, updated_at = NOW() WHERE id = $1`, userID, premium)
+    return err
+}
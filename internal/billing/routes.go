@@ -0,0 +1,21 @@
+package billing
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    // Webhooks are authenticated by the provider's signature, not a user token
+    router.HandleFunc("/api/v1/billing/webhooks/{provider}", handler.HandleWebhook).Methods("POST")
+
+    api := router.PathPrefix("/api/v1/billing").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("/plans", handler.GetPlans).Methods("GET")
+    api.HandleFunc("/subscription", handler.GetSubscription).Methods("GET")
+    api.HandleFunc("/subscription/cancel", handler.CancelSubscription).Methods("POST")
+    api.HandleFunc("/checkout", handler.CreateCheckout).Methods("POST")
+    api.HandleFunc("/coins", handler.GetCoinPacks).Methods("GET")
+    api.HandleFunc("/coins/checkout", handler.CreateCoinCheckout).Methods("POST")
+}
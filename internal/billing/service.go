@@ -0,0 +1,408 @@
+// internal/billing/service.go
+
+package billing
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+)
+
+var (
+    ErrPlanNotFound      = errors.New("plan not found")
+    ErrAlreadySubscribed = errors.New("already subscribed")
+    ErrCoinPackNotFound  = errors.New("coin pack not found")
+    ErrNotCancellable    = errors.New("granted premium days can't be cancelled")
+)
+
+// ProviderGrant is the provider of premium days given away rather than paid
+// for, such as referral rewards
+const ProviderGrant = "grant"
+
+// entitlementCacheTTL bounds how stale a cached plan can be if a webhook's
+// invalidation is missed
+const entitlementCacheTTL = 10 * time.Minute
+
+// Checker answers what a user is entitled to. Other services depend on this
+// rather than on plans or subscriptions.
+type Checker interface {
+    GetEntitlements(ctx context.Context, userID int64) (*Entitlements, error)
+    CanSeeProfileViews(ctx context.Context, userID int64) (bool, error)
+    CanRewind(ctx context.Context, userID int64) (bool, error)
+    DailySwipeLimit(ctx context.Context, userID int64) (int, error)
+}
+
+type Service interface {
+    Checker
+
+    GetPlans() []*Plan
+    GetSubscription(ctx context.Context, userID int64) (*SubscriptionResponse, error)
+    CreateCheckout(ctx context.Context, userID int64, req *CheckoutRequest) (*Checkout, error)
+    CancelSubscription(ctx context.Context, userID int64) (*Subscription, error)
+    HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error
+
+    // Coins for virtual gifts
+    GetCoinPacks() []*CoinPack
+    CreateCoinCheckout(ctx context.Context, userID int64, req *CoinCheckoutRequest) (*Checkout, error)
+
+    // SetWallet sets where purchased coins are credited
+    SetWallet(wallet Wallet)
+
+    // GrantPremium gives the user days of premium, once per reference
+    GrantPremium(ctx context.Context, userID int64, days int, reference string) error
+
+    // RegisterProvider adds a payment provider. The first one registered is the default.
+    RegisterProvider(provider Provider)
+}
+
+type service struct {
+    repo            Repository
+    redis           *redis.Client
+    providers       map[string]Provider
+    defaultProvider string
+    wallet          Wallet
+}
+
+// NewService creates the billing service. redisClient may be nil, in which
+// case entitlements are read from Postgres on every check.
+func NewService(repo Repository, redisClient *redis.Client) Service {
+    return &service{
+        repo:      repo,
+        redis:     redisClient,
+        providers: make(map[string]Provider),
+    }
+}
+
+func (s *service) RegisterProvider(provider Provider) {
+    if s.defaultProvider == "" {
+        s.defaultProvider = provider.Name()
+    }
+    s.providers[provider.Name()] = provider
+}
+
+func (s *service) SetWallet(wallet Wallet) {
+    s.wallet = wallet
+}
+
+func (s *service) GetPlans() []*Plan {
+    return Plans()
+}
+
+func (s *service) GetSubscription(ctx context.Context, userID int64) (*SubscriptionResponse, error) {
+    sub, err := s.repo.GetCurrentSubscription(ctx, userID)
+    if err != nil && !errors.Is(err, ErrNoSubscription) {
+        return nil, err
+    }
+
+    plan := GetPlan(PlanFree)
+    if sub != nil {
+        if p := GetPlan(sub.PlanID); p != nil {
+            plan = p
+        }
+    }
+
+    return &SubscriptionResponse{
+        Plan:         plan,
+        Subscription: sub,
+        Entitlements: plan.Entitlements,
+    }, nil
+}
+
+func (s *service) CreateCheckout(ctx context.Context, userID int64, req *CheckoutRequest) (*Checkout, error) {
+    plan := GetPlan(req.PlanID)
+    if plan == nil || plan.ID == PlanFree {
+        return nil, ErrPlanNotFound
+    }
+
+    provider, err := s.provider(req.Provider)
+    if err != nil {
+        return nil, err
+    }
+
+    // Granted days don't stop the user from subscribing
+    if sub, err := s.repo.GetCurrentSubscription(ctx, userID); err == nil {
+        if sub.Provider != ProviderGrant {
+            return nil, ErrAlreadySubscribed
+        }
+    } else if !errors.Is(err, ErrNoSubscription) {
+        return nil, err
+    }
+
+    email, err := s.repo.GetUserEmail(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get user email: %w", err)
+    }
+
+    return provider.CreateCheckout(ctx, userID, email, plan)
+}
+
+func (s *service) GetCoinPacks() []*CoinPack {
+    return CoinPacks()
+}
+
+// CreateCoinCheckout starts a one-off payment for a coin pack. The coins are
+// credited when the provider's webhook confirms payment.
+func (s *service) CreateCoinCheckout(ctx context.Context, userID int64, req *CoinCheckoutRequest) (*Checkout, error) {
+    pack := GetCoinPack(req.PackID)
+    if pack == nil {
+        return nil, ErrCoinPackNotFound
+    }
+
+    provider, err := s.provider(req.Provider)
+    if err != nil {
+        return nil, err
+    }
+
+    email, err := s.repo.GetUserEmail(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get user email: %w", err)
+    }
+
+    return provider.CreatePaymentCheckout(ctx, userID, email, pack)
+}
+
+// provider returns the named provider, or the default for ""
+func (s *service) provider(name string) (Provider, error) {
+    if name == "" {
+        name = s.defaultProvider
+    }
+    provider, ok := s.providers[name]
+    if !ok {
+        return nil, ErrUnknownProvider
+    }
+    return provider, nil
+}
+
+// CancelSubscription stops renewal; access continues to the end of the paid period
+func (s *service) CancelSubscription(ctx context.Context, userID int64) (*Subscription, error) {
+    sub, err := s.repo.GetCurrentSubscription(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if sub.Provider == ProviderGrant {
+        return nil, ErrNotCancellable
+    }
+
+    provider, ok := s.providers[sub.Provider]
+    if !ok {
+        return nil, ErrUnknownProvider
+    }
+
+    if err := provider.CancelSubscription(ctx, sub.ProviderSubscriptionID); err != nil {
+        return nil, err
+    }
+
+    // The provider's webhook will confirm this; record it now so the UI is current
+    sub.CancelAtPeriodEnd = true
+    if err := s.repo.UpsertSubscription(ctx, sub); err != nil {
+        return nil, err
+    }
+    return sub, nil
+}
+
+// HandleWebhook applies a provider webhook. Each event is processed once; a
+// failure releases it so the provider's retry is processed.
+func (s *service) HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error {
+    provider, ok := s.providers[providerName]
+    if !ok {
+        return ErrUnknownProvider
+    }
+
+    event, err := provider.ParseWebhook(payload, header)
+    if err != nil {
+        return err
+    }
+    if event.Type == EventIgnored {
+        return nil
+    }
+
+    claimed, err := s.repo.ClaimWebhookEvent(ctx, providerName, event.ID)
+    if err != nil {
+        return err
+    }
+    if !claimed {
+        return nil
+    }
+
+    if err := s.applyEvent(ctx, providerName, event); err != nil {
+        s.repo.ReleaseWebhookEvent(ctx, providerName, event.ID)
+        return err
+    }
+    return nil
+}
+
+func (s *service) applyEvent(ctx context.Context, providerName string, event *WebhookEvent) error {
+    if event.Type == EventCoinsPurchased {
+        return s.applyCoinPurchase(ctx, providerName, event)
+    }
+
+    sub := &Subscription{
+        UserID:                 event.UserID,
+        PlanID:                 event.PlanID,
+        Provider:               providerName,
+        ProviderSubscriptionID: event.ProviderSubscriptionID,
+        Status:                 event.Status,
+        CurrentPeriodEnd:       event.CurrentPeriodEnd,
+        CancelAtPeriodEnd:      event.CancelAtPeriodEnd,
+    }
+    if event.ProviderCustomerID != "" {
+        sub.ProviderCustomerID = &event.ProviderCustomerID
+    }
+
+    // Events such as payment failures don't carry our user or plan
+    existing, err := s.repo.GetSubscriptionByProviderID(ctx, providerName, event.ProviderSubscriptionID)
+    switch {
+    case err == nil:
+        sub.UserID = existing.UserID
+        if sub.PlanID == "" {
+            sub.PlanID = existing.PlanID
+        }
+        if event.Type == EventPaymentFailed || event.Type == EventSubscriptionActivated {
+            sub.CurrentPeriodEnd = existing.CurrentPeriodEnd
+            sub.CancelAtPeriodEnd = existing.CancelAtPeriodEnd
+        }
+    case errors.Is(err, ErrNoSubscription):
+        if sub.UserID == 0 || GetPlan(sub.PlanID) == nil {
+            log.Printf("Ignoring %s event %s for unknown subscription %s", providerName, event.ID, event.ProviderSubscriptionID)
+            return nil
+        }
+    default:
+        return err
+    }
+
+    if err := s.repo.UpsertSubscription(ctx, sub); err != nil {
+        return fmt.Errorf("failed to save subscription: %w", err)
+    }
+
+    return s.syncUser(ctx, sub.UserID)
+}
+
+// applyCoinPurchase credits a paid coin pack to the buyer's wallet
+func (s *service) applyCoinPurchase(ctx context.Context, providerName string, event *WebhookEvent) error {
+    pack := GetCoinPack(event.CoinPackID)
+    if pack == nil || event.UserID == 0 || event.PaymentID == "" {
+        log.Printf("Ignoring %s event %s for unknown coin pack %q", providerName, event.ID, event.CoinPackID)
+        return nil
+    }
+    // Fail so the provider retries once a wallet is configured
+    if s.wallet == nil {
+        return errors.New("no wallet configured for coin purchases")
+    }
+
+    if err := s.wallet.CreditPurchase(ctx, event.UserID, pack.Coins, providerName+":"+event.PaymentID); err != nil {
+        return fmt.Errorf("failed to credit coins: %w", err)
+    }
+    return nil
+}
+
+// GrantPremium gives the user days of the monthly premium plan, after any
+// days they were already granted
+func (s *service) GrantPremium(ctx context.Context, userID int64, days int, reference string) error {
+    granted, err := s.repo.CreateGrant(ctx, userID, PlanPremiumMonthly, days, reference)
+    if err != nil {
+        return fmt.Errorf("failed to grant premium: %w", err)
+    }
+    if !granted {
+        return nil
+    }
+    return s.syncUser(ctx, userID)
+}
+
+// syncUser refreshes the user's premium flag and drops cached entitlements
+func (s *service) syncUser(ctx context.Context, userID int64) error {
+    _, err := s.repo.GetCurrentSubscription(ctx, userID)
+    if err != nil && !errors.Is(err, ErrNoSubscription) {
+        return err
+    }
+
+    if err := s.repo.SetUserPremium(ctx, userID, err == nil); err != nil {
+        return err
+    }
+
+    if s.redis != nil {
+        s.redis.Del(ctx, entitlementCacheKey(userID))
+    }
+    return nil
+}
+
+// Checker
+
+func (s *service) GetEntitlements(ctx context.Context, userID int64) (*Entitlements, error) {
+    planID, err := s.currentPlanID(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    plan := GetPlan(planID)
+    if plan == nil {
+        plan = GetPlan(PlanFree)
+    }
+    entitlements := plan.Entitlements
+    return &entitlements, nil
+}
+
+func (s *service) CanSeeProfileViews(ctx context.Context, userID int64) (bool, error) {
+    entitlements, err := s.GetEntitlements(ctx, userID)
+    if err != nil {
+        return false, err
+    }
+    return entitlements.CanSeeProfileViews, nil
+}
+
+func (s *service) CanRewind(ctx context.Context, userID int64) (bool, error) {
+    entitlements, err := s.GetEntitlements(ctx, userID)
+    if err != nil {
+        return false, err
+    }
+    return entitlements.CanRewind, nil
+}
+
+func (s *service) DailySwipeLimit(ctx context.Context, userID int64) (int, error) {
+    entitlements, err := s.GetEntitlements(ctx, userID)
+    if err != nil {
+        return 0, err
+    }
+    return entitlements.DailySwipeLimit, nil
+}
+
+// currentPlanID returns the user's plan, cached in Redis
+func (s *service) currentPlanID(ctx context.Context, userID int64) (string, error) {
+    key := entitlementCacheKey(userID)
+    if s.redis != nil {
+        if planID, err := s.redis.Get(ctx, key).Result(); err == nil {
+            return planID, nil
+        }
+    }
+
+    planID := PlanFree
+    sub, err := s.repo.GetCurrentSubscription(ctx, userID)
+    switch {
+    case err == nil:
+        planID = sub.PlanID
+    case !errors.Is(err, ErrNoSubscription):
+        return "", err
+    }
+
+    if s.redis != nil {
+        ttl := entitlementCacheTTL
+        // Don't serve a lapsed plan from cache past the end of its period
+        if sub != nil && sub.CurrentPeriodEnd != nil {
+            if untilEnd := time.Until(*sub.CurrentPeriodEnd); untilEnd < ttl {
+                ttl = untilEnd
+            }
+        }
+        if ttl > 0 {
+            s.redis.Set(ctx, key, planID, ttl)
+        }
+    }
+    return planID, nil
+}
+
+func entitlementCacheKey(userID int64) string {
+    return fmt.Sprintf("billing:plan:%d", userID)
+}
@@ -0,0 +1,316 @@
+// internal/billing/stripe.go
+
+package billing
+
+import (
+    "context"
+    "crypto/hmac"
+    "crypto/sha256"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "strconv"
+    "strings"
+    "time"
+)
+
+const (
+    stripeAPIURL = "https://api.stripe.com/v1"
+
+    // stripeSignatureTolerance bounds how old a signed webhook may be, to stop replays
+    stripeSignatureTolerance = 5 * time.Minute
+)
+
+type StripeConfig struct {
+    SecretKey     string
+    WebhookSecret string
+    SuccessURL    string
+    CancelURL     string
+
+    // PriceIDs maps plan and coin pack IDs to Stripe price IDs
+    PriceIDs map[string]string
+}
+
+type stripeProvider struct {
+    config     StripeConfig
+    httpClient *http.Client
+}
+
+// NewStripeProvider creates a Stripe provider that talks to the Stripe API directly
+func NewStripeProvider(config StripeConfig) Provider {
+    return &stripeProvider{
+        config:     config,
+        httpClient: &http.Client{Timeout: 15 * time.Second},
+    }
+}
+
+func (p *stripeProvider) Name() string {
+    return "stripe"
+}
+
+func (p *stripeProvider) CreateCheckout(ctx context.Context, userID int64, email string, plan *Plan) (*Checkout, error) {
+    priceID, ok := p.config.PriceIDs[plan.ID]
+    if !ok || priceID == "" {
+        return nil, fmt.Errorf("no Stripe price configured for plan %s", plan.ID)
+    }
+
+    userIDStr := strconv.FormatInt(userID, 10)
+    form := url.Values{
+        "mode":                                  {"subscription"},
+        "line_items[0][price]":                  {priceID},
+        "line_items[0][quantity]":               {"1"},
+        "client_reference_id":                   {userIDStr},
+        "success_url":                           {p.config.SuccessURL},
+        "cancel_url":                            {p.config.CancelURL},
+        "metadata[user_id]":                     {userIDStr},
+        "metadata[plan_id]":                     {plan.ID},
+        "subscription_data[metadata][user_id]": {userIDStr},
+        "subscription_data[metadata][plan_id]": {plan.ID},
+    }
+    if email != "" {
+        form.Set("customer_email", email)
+    }
+
+    var session struct {
+        ID  string `json:"id"`
+        URL string `json:"url"`
+    }
+    if err := p.post(ctx, "/checkout/sessions", form, &session); err != nil {
+        return nil, err
+    }
+
+    return &Checkout{Provider: p.Name(), SessionID: session.ID, URL: session.URL}, nil
+}
+
+// CreatePaymentCheckout starts a one-off Stripe payment for a coin pack. The
+// session's metadata carries the pack back in the completed webhook.
+func (p *stripeProvider) CreatePaymentCheckout(ctx context.Context, userID int64, email string, pack *CoinPack) (*Checkout, error) {
+    priceID, ok := p.config.PriceIDs[pack.ID]
+    if !ok || priceID == "" {
+        return nil, fmt.Errorf("no Stripe price configured for coin pack %s", pack.ID)
+    }
+
+    userIDStr := strconv.FormatInt(userID, 10)
+    form := url.Values{
+        "mode":                    {"payment"},
+        "line_items[0][price]":    {priceID},
+        "line_items[0][quantity]": {"1"},
+        "client_reference_id":     {userIDStr},
+        "success_url":             {p.config.SuccessURL},
+        "cancel_url":              {p.config.CancelURL},
+        "metadata[user_id]":       {userIDStr},
+        "metadata[coin_pack_id]":  {pack.ID},
+    }
+    if email != "" {
+        form.Set("customer_email", email)
+    }
+
+    var session struct {
+        ID  string `json:"id"`
+        URL string `json:"url"`
+    }
+    if err := p.post(ctx, "/checkout/sessions", form, &session); err != nil {
+        return nil, err
+    }
+
+    return &Checkout{Provider: p.Name(), SessionID: session.ID, URL: session.URL}, nil
+}
+
+func (p *stripeProvider) CancelSubscription(ctx context.Context, providerSubscriptionID string) error {
+    form := url.Values{"cancel_at_period_end": {"true"}}
+    return p.post(ctx, "/subscriptions/"+url.PathEscape(providerSubscriptionID), form, nil)
+}
+
+func (p *stripeProvider) post(ctx context.Context, path string, form url.Values, out interface{}) error {
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, stripeAPIURL+path, strings.NewReader(form.Encode()))
+    if err != nil {
+        return err
+    }
+    req.Header.Set("Authorization", "Bearer "+p.config.SecretKey)
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+    resp, err := p.httpClient.Do(req)
+    if err != nil {
+        return fmt.Errorf("stripe request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    body, err := io.ReadAll(resp.Body)
+    if err != nil {
+        return err
+    }
+    if resp.StatusCode >= 300 {
+        var apiErr struct {
+            Error struct {
+                Message string `json:"message"`
+            } `json:"error"`
+        }
+        json.Unmarshal(body, &apiErr)
+        return fmt.Errorf("stripe error (%d): %s", resp.StatusCode, apiErr.Error.Message)
+    }
+
+    if out == nil {
+        return nil
+    }
+    return json.Unmarshal(body, out)
+}
+
+// stripeObject holds the fields billing reads from the event's data.object,
+// which is a checkout session, subscription or invoice depending on the event
+type stripeObject struct {
+    ID                string            `json:"id"`
+    ClientReferenceID string            `json:"client_reference_id"`
+    Customer          string            `json:"customer"`
+    Subscription      string            `json:"subscription"`
+    Status            string            `json:"status"`
+    PaymentStatus     string            `json:"payment_status"`
+    CurrentPeriodEnd  int64             `json:"current_period_end"`
+    CancelAtPeriodEnd bool              `json:"cancel_at_period_end"`
+    Metadata          map[string]string `json:"metadata"`
+}
+
+func (p *stripeProvider) ParseWebhook(payload []byte, header http.Header) (*WebhookEvent, error) {
+    if err := p.verifySignature(payload, header.Get("Stripe-Signature"), time.Now()); err != nil {
+        return nil, err
+    }
+
+    var event struct {
+        ID   string `json:"id"`
+        Type string `json:"type"`
+        Data struct {
+            Object stripeObject `json:"object"`
+        } `json:"data"`
+    }
+    if err := json.Unmarshal(payload, &event); err != nil {
+        return nil, fmt.Errorf("invalid stripe event: %w", err)
+    }
+
+    obj := event.Data.Object
+    result := &WebhookEvent{
+        ID:                 event.ID,
+        Type:               EventIgnored,
+        ProviderCustomerID: obj.Customer,
+        PlanID:             obj.Metadata["plan_id"],
+    }
+    if userID, err := strconv.ParseInt(obj.Metadata["user_id"], 10, 64); err == nil {
+        result.UserID = userID
+    }
+
+    switch event.Type {
+    case "checkout.session.completed":
+        if obj.Subscription == "" {
+            coinPurchase(result, obj)
+            return result, nil
+        }
+        result.Type = EventSubscriptionActivated
+        result.ProviderSubscriptionID = obj.Subscription
+        result.Status = StatusActive
+        if result.UserID == 0 {
+            result.UserID, _ = strconv.ParseInt(obj.ClientReferenceID, 10, 64)
+        }
+
+    case "checkout.session.async_payment_succeeded":
+        // Delayed payment methods confirm a coin pack purchase later
+        coinPurchase(result, obj)
+
+    case "customer.subscription.created", "customer.subscription.updated":
+        result.Type = EventSubscriptionUpdated
+        result.ProviderSubscriptionID = obj.ID
+        result.Status = stripeStatus(obj.Status)
+        result.CancelAtPeriodEnd = obj.CancelAtPeriodEnd
+        if obj.CurrentPeriodEnd > 0 {
+            end := time.Unix(obj.CurrentPeriodEnd, 0)
+            result.CurrentPeriodEnd = &end
+        }
+
+    case "customer.subscription.deleted":
+        result.Type = EventSubscriptionCanceled
+        result.ProviderSubscriptionID = obj.ID
+        result.Status = StatusCanceled
+
+    case "invoice.payment_failed":
+        if obj.Subscription == "" {
+            return result, nil
+        }
+        result.Type = EventPaymentFailed
+        result.ProviderSubscriptionID = obj.Subscription
+        result.Status = StatusPastDue
+    }
+
+    return result, nil
+}
+
+// verifySignature checks a Stripe-Signature header: t=<unix>,v1=<hex hmac>[,v1=...]
+// where the HMAC-SHA256 is over "<t>.<payload>" keyed with the webhook secret
+func (p *stripeProvider) verifySignature(payload []byte, header string, now time.Time) error {
+    if p.config.WebhookSecret == "" || header == "" {
+        return ErrInvalidSignature
+    }
+
+    var timestamp string
+    var signatures []string
+    for _, part := range strings.Split(header, ",") {
+        kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+        if len(kv) != 2 {
+            continue
+        }
+        switch kv[0] {
+        case "t":
+            timestamp = kv[1]
+        case "v1":
+            signatures = append(signatures, kv[1])
+        }
+    }
+
+    ts, err := strconv.ParseInt(timestamp, 10, 64)
+    if err != nil || len(signatures) == 0 {
+        return ErrInvalidSignature
+    }
+    age := now.Sub(time.Unix(ts, 0))
+    if age > stripeSignatureTolerance || age < -stripeSignatureTolerance {
+        return ErrInvalidSignature
+    }
+
+    mac := hmac.New(sha256.New, []byte(p.config.WebhookSecret))
+    mac.Write([]byte(timestamp + "."))
+    mac.Write(payload)
+    expected := mac.Sum(nil)
+
+    for _, sig := range signatures {
+        decoded, err := hex.DecodeString(sig)
+        if err == nil && hmac.Equal(decoded, expected) {
+            return nil
+        }
+    }
+    return ErrInvalidSignature
+}
+
+// coinPurchase marks a paid coin pack checkout session as a coin purchase
+func coinPurchase(result *WebhookEvent, obj stripeObject) {
+    if obj.Metadata["coin_pack_id"] == "" || obj.PaymentStatus != "paid" {
+        return
+    }
+    result.Type = EventCoinsPurchased
+    result.CoinPackID = obj.Metadata["coin_pack_id"]
+    result.PaymentID = obj.ID
+}
+
+func stripeStatus(status string) SubscriptionStatus {
+    switch status {
+    case "active":
+        return StatusActive
+    case "trialing":
+        return StatusTrialing
+    case "past_due":
+        return StatusPastDue
+    case "canceled":
+        return StatusCanceled
+    case "incomplete":
+        return StatusIncomplete
+    default: // unpaid, incomplete_expired, paused
+        return StatusExpired
+    }
+}
@@ -0,0 +1,88 @@
+// internal/blocks/repository.go
+
+package blocks
+
+import (
+    "context"
+
+    "github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+    Block(ctx context.Context, userID, blockedID int64) (bool, error)
+    Unblock(ctx context.Context, userID, blockedID int64) (bool, error)
+    IsBlocked(ctx context.Context, userID, otherID int64) (bool, error)
+    GetBlocked(ctx context.Context, userID int64) ([]int64, error)
+    GetRelated(ctx context.Context, userID int64) ([]int64, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+// Block records that userID blocked blockedID. It reports false when the
+// block already existed.
+func (r *repository) Block(ctx context.Context, userID, blockedID int64) (bool, error) {
+    query := `
+        INSERT INTO blocked_users (user_id, blocked_id, blocked_at)
+        VALUES ($1, $2, NOW())
+        ON CONFLICT (user_id, blocked_id) DO NOTHING`
+
+    result, err := r.db.ExecContext(ctx, query, userID, blockedID)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// Unblock removes userID's block on blockedID. It reports false when there
+// was no such block.
+func (r *repository) Unblock(ctx context.Context, userID, blockedID int64) (bool, error) {
+    query := `DELETE FROM blocked_users WHERE user_id = $1 AND blocked_id = $2`
+
+    result, err := r.db.ExecContext(ctx, query, userID, blockedID)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// IsBlocked reports whether either user has blocked the other
+func (r *repository) IsBlocked(ctx context.Context, userID, otherID int64) (bool, error) {
+    var blocked bool
+    query := `SELECT NOT ` + NotBlockedSQL("$1::integer", "$2::integer")
+
+    err := r.db.GetContext(ctx, &blocked, query, userID, otherID)
+    return blocked, err
+}
+
+// GetBlocked returns the users userID has blocked, most recent first
+func (r *repository) GetBlocked(ctx context.Context, userID int64) ([]int64, error) {
+    query := `SELECT blocked_id FROM blocked_users WHERE user_id = $1 ORDER BY blocked_at DESC`
+
+    blocked := []int64{}
+    if err := r.db.SelectContext(ctx, &blocked, query, userID); err != nil {
+        return nil, err
+    }
+    return blocked, nil
+}
+
+// GetRelated returns every user with a block in either direction with userID
+func (r *repository) GetRelated(ctx context.Context, userID int64) ([]int64, error) {
+    query := `
+        SELECT blocked_id FROM blocked_users WHERE user_id = $1
+        UNION
+        SELECT user_id FROM blocked_users WHERE blocked_id = $1`
+
+    related := []int64{}
+    if err := r.db.SelectContext(ctx, &related, query, userID); err != nil {
+        return nil, err
+    }
+    return related, nil
+}
@@ -0,0 +1,150 @@
+// internal/blocks/service.go
+
+package blocks
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+)
+
+const relatedCacheTTL = 10 * time.Minute
+
+var (
+    ErrCannotBlockSelf = errors.New("cannot block yourself")
+    ErrAlreadyBlocked  = errors.New("user is already blocked")
+)
+
+// Listener is told about every block and unblock, whichever module made it.
+// blocked is false for an unblock.
+type Listener func(ctx context.Context, blockerID, blockedID int64, blocked bool)
+
+type Service interface {
+    Block(ctx context.Context, userID, blockedID int64) error
+    Unblock(ctx context.Context, userID, blockedID int64) error
+    IsBlocked(ctx context.Context, userID, otherID int64) (bool, error)
+    GetBlocked(ctx context.Context, userID int64) ([]int64, error)
+    RelatedIDs(ctx context.Context, userID int64) ([]int64, error)
+    AddListener(listener Listener)
+}
+
+type service struct {
+    repo  Repository
+    redis *redis.Client
+
+    listeners    []Listener
+    listenersMux sync.RWMutex
+}
+
+// NewService creates the blocks service. redisClient may be nil, in which
+// case RelatedIDs reads from Postgres every time.
+func NewService(repo Repository, redisClient *redis.Client) Service {
+    return &service{
+        repo:  repo,
+        redis: redisClient,
+    }
+}
+
+func (s *service) Block(ctx context.Context, userID, blockedID int64) error {
+    if userID == blockedID {
+        return ErrCannotBlockSelf
+    }
+
+    created, err := s.repo.Block(ctx, userID, blockedID)
+    if err != nil {
+        return err
+    }
+    if !created {
+        return ErrAlreadyBlocked
+    }
+
+    s.changed(ctx, userID, blockedID, true)
+    return nil
+}
+
+// Unblock removes userID's block on blockedID. A block the other user made
+// stays in place.
+func (s *service) Unblock(ctx context.Context, userID, blockedID int64) error {
+    removed, err := s.repo.Unblock(ctx, userID, blockedID)
+    if err != nil {
+        return err
+    }
+    if removed {
+        s.changed(ctx, userID, blockedID, false)
+    }
+    return nil
+}
+
+// IsBlocked reports whether either user has blocked the other
+func (s *service) IsBlocked(ctx context.Context, userID, otherID int64) (bool, error) {
+    if userID == otherID {
+        return false, nil
+    }
+    return s.repo.IsBlocked(ctx, userID, otherID)
+}
+
+// GetBlocked returns the users userID has blocked
+func (s *service) GetBlocked(ctx context.Context, userID int64) ([]int64, error) {
+    return s.repo.GetBlocked(ctx, userID)
+}
+
+// RelatedIDs returns every user with a block in either direction with userID,
+// for callers that filter in Go rather than in SQL
+func (s *service) RelatedIDs(ctx context.Context, userID int64) ([]int64, error) {
+    key := relatedCacheKey(userID)
+    if s.redis != nil {
+        if cached, err := s.redis.Get(ctx, key).Bytes(); err == nil {
+            var related []int64
+            if json.Unmarshal(cached, &related) == nil {
+                return related, nil
+            }
+        }
+    }
+
+    related, err := s.repo.GetRelated(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    if s.redis != nil {
+        if data, err := json.Marshal(related); err == nil {
+            s.redis.Set(ctx, key, data, relatedCacheTTL)
+        }
+    }
+    return related, nil
+}
+
+// AddListener registers a callback for block changes. Listeners run in the
+// order they were added, after the change is stored.
+func (s *service) AddListener(listener Listener) {
+    s.listenersMux.Lock()
+    defer s.listenersMux.Unlock()
+    s.listeners = append(s.listeners, listener)
+}
+
+// changed drops both users' cached lists and tells the listeners
+func (s *service) changed(ctx context.Context, blockerID, blockedID int64, blocked bool) {
+    if s.redis != nil {
+        if err := s.redis.Del(ctx, relatedCacheKey(blockerID), relatedCacheKey(blockedID)).Err(); err != nil {
+            log.Printf("Failed to clear block cache: %v", err)
+        }
+    }
+
+    s.listenersMux.RLock()
+    listeners := append([]Listener(nil), s.listeners...)
+    s.listenersMux.RUnlock()
+
+    for _, listener := range listeners {
+        listener(ctx, blockerID, blockedID, blocked)
+    }
+}
+
+func relatedCacheKey(userID int64) string {
+    return fmt.Sprintf("blocks:related:%d", userID)
+}
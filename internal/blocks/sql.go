@@ -0,0 +1,20 @@
+// internal/blocks/sql.go
+// Blocking policy shared by every module
+//
+// A block works in both directions: once either user blocks the other,
+// neither sees the other's profile, posts, stories or dating cards, they
+// can't message each other, and neither is notified about the other.
+// blocked_users is the single source of truth.
+
+package blocks
+
+import "fmt"
+
+// NotBlockedSQL is true when there is no block in either direction between
+// the users named by the two SQL expressions (columns or placeholders)
+func NotBlockedSQL(viewer, other string) string {
+    return fmt.Sprintf(`NOT EXISTS (
+            SELECT 1 FROM blocked_users b
+            WHERE (b.user_id = %[1]s AND b.blocked_id = %[2]s)
+               OR (b.user_id = %[2]s AND b.blocked_id = %[1]s))`, viewer, other)
+}
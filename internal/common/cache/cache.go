@@ -0,0 +1,149 @@
+// internal/common/cache/cache.go
+// Redis-backed read-model cache for hot objects
+// Each kind of object has its own TTL. Entries are grouped by the ID they
+// belong to (a user, a post...), with one variant per page or viewer, so a
+// write can drop everything cached for that ID in one call.
+
+package cache
+
+import (
+    "context"
+    "encoding/json"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var requests = promauto.NewCounterVec(
+    prometheus.CounterOpts{
+        Name: "cache_requests_total",
+        Help: "Read-model cache lookups by kind and result (hit or miss)",
+    },
+    []string{"kind", "result"},
+)
+
+// Kind is a type of cached object and how long it is kept
+type Kind struct {
+    Name string
+    TTL  time.Duration
+}
+
+// Cached read models. TTLs bound staleness from writes that don't
+// invalidate, such as counts changed by other users.
+var (
+    Profile       = Kind{Name: "profile", TTL: 5 * time.Minute}
+    Feed          = Kind{Name: "feed", TTL: 2 * time.Minute}
+    Conversations = Kind{Name: "conversations", TTL: 1 * time.Minute}
+    Trending      = Kind{Name: "trending", TTL: 10 * time.Minute}
+)
+
+// Cache reads and writes cached objects. A Cache with no Redis client is
+// valid: every lookup misses and nothing is stored.
+type Cache struct {
+    redis *redis.Client
+}
+
+// New creates a cache. redisClient may be nil to disable caching.
+func New(redisClient *redis.Client) *Cache {
+    return &Cache{redis: redisClient}
+}
+
+func key(kind Kind, id int64) string {
+    return fmt.Sprintf("cache:%s:%d", kind.Name, id)
+}
+
+// Variant builds a variant name from its parts, e.g. Variant(page, limit)
+func Variant(parts ...interface{}) string {
+    variant := ""
+    for i, part := range parts {
+        if i > 0 {
+            variant += ":"
+        }
+        variant += fmt.Sprint(part)
+    }
+    return variant
+}
+
+// Get loads the cached variant of id into dest, reporting whether it was found
+func (c *Cache) Get(ctx context.Context, kind Kind, id int64, variant string, dest interface{}) bool {
+    if c == nil || c.redis == nil {
+        return false
+    }
+
+    data, err := c.redis.HGet(ctx, key(kind, id), variant).Bytes()
+    if err == nil && json.Unmarshal(data, dest) == nil {
+        requests.WithLabelValues(kind.Name, "hit").Inc()
+        return true
+    }
+    if err != nil && err != redis.Nil {
+        log.Printf("Cache read of %s %d failed: %v", kind.Name, id, err)
+    }
+
+    requests.WithLabelValues(kind.Name, "miss").Inc()
+    return false
+}
+
+// Set caches value as a variant of id. All variants of id expire together,
+// kind.TTL after the first is stored.
+func (c *Cache) Set(ctx context.Context, kind Kind, id int64, variant string, value interface{}) {
+    if c == nil || c.redis == nil {
+        return
+    }
+
+    data, err := json.Marshal(value)
+    if err != nil {
+        log.Printf("Failed to encode %s %d for the cache: %v", kind.Name, id, err)
+        return
+    }
+
+    k := key(kind, id)
+    pipe := c.redis.TxPipeline()
+    pipe.HSet(ctx, k, variant, data)
+    ttl := pipe.TTL(ctx, k)
+    if _, err := pipe.Exec(ctx); err != nil {
+        log.Printf("Cache write of %s %d failed: %v", kind.Name, id, err)
+        return
+    }
+
+    // Only the first variant sets the expiry, so a busy ID can't keep stale
+    // variants alive by adding new ones
+    if ttl.Val() < 0 {
+        c.redis.Expire(ctx, k, kind.TTL)
+    }
+}
+
+// Invalidate drops every cached variant of the given IDs. Call it after
+// any write that changes what those objects would show.
+func (c *Cache) Invalidate(ctx context.Context, kind Kind, ids ...int64) {
+    if c == nil || c.redis == nil || len(ids) == 0 {
+        return
+    }
+
+    keys := make([]string, len(ids))
+    for i, id := range ids {
+        keys[i] = key(kind, id)
+    }
+    if err := c.redis.Unlink(ctx, keys...).Err(); err != nil {
+        log.Printf("Failed to invalidate %d cached %s entries: %v", len(ids), kind.Name, err)
+    }
+}
+
+// Fetch returns the cached variant of id, or loads it with load and caches
+// the result. Load errors are returned and never cached.
+func Fetch[T any](ctx context.Context, c *Cache, kind Kind, id int64, variant string, load func() (T, error)) (T, error) {
+    var value T
+    if c.Get(ctx, kind, id, variant, &value) {
+        return value, nil
+    }
+
+    value, err := load()
+    if err != nil {
+        return value, err
+    }
+    c.Set(ctx, kind, id, variant, value)
+    return value, nil
+}
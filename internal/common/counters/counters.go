@@ -0,0 +1,301 @@
+// internal/common/counters/counters.go
+// Approximate unique-view counters backed by Redis HyperLogLog
+// Counts are flushed to Postgres periodically and reconciled against the
+// exact view tables nightly, so reads never need a COUNT(*) per request
+
+package counters
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strconv"
+    "strings"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+)
+
+// Entity kinds that can be counted
+const (
+    KindStory = "story"
+    KindPost  = "post"
+)
+
+// Source describes the table holding exact (entity, viewer) rows for a kind.
+// It is used for reconciliation and as the fallback when Redis is unavailable.
+type Source struct {
+    Table        string
+    EntityColumn string
+    ViewerColumn string
+}
+
+// ViewCounter counts unique viewers per entity
+type ViewCounter struct {
+    redis   *redis.Client
+    db      *sqlx.DB
+    sources map[string]Source
+    keyTTL  time.Duration
+}
+
+// NewViewCounter creates a counter. redisClient may be nil, in which case
+// counts are read from Postgres directly.
+func NewViewCounter(redisClient *redis.Client, db *sqlx.DB) *ViewCounter {
+    return &ViewCounter{
+        redis:   redisClient,
+        db:      db,
+        sources: make(map[string]Source),
+        keyTTL:  7 * 24 * time.Hour,
+    }
+}
+
+// Register adds the exact source table for a kind
+func (c *ViewCounter) Register(kind string, source Source) {
+    c.sources[kind] = source
+}
+
+func hllKey(kind string, id int64) string {
+    return fmt.Sprintf("views:%s:%d", kind, id)
+}
+
+func dirtyKey(kind string) string {
+    return fmt.Sprintf("views:dirty:%s", kind)
+}
+
+// Record adds a viewer to the entity's HyperLogLog and marks it for flushing
+func (c *ViewCounter) Record(ctx context.Context, kind string, id, viewerID int64) error {
+    if c.redis == nil {
+        return nil
+    }
+
+    key := hllKey(kind, id)
+    pipe := c.redis.TxPipeline()
+    pipe.PFAdd(ctx, key, strconv.FormatInt(viewerID, 10))
+    pipe.Expire(ctx, key, c.keyTTL)
+    pipe.SAdd(ctx, dirtyKey(kind), id)
+    _, err := pipe.Exec(ctx)
+    return err
+}
+
+// Count returns the approximate unique view count for an entity
+func (c *ViewCounter) Count(ctx context.Context, kind string, id int64) (int, error) {
+    counts, err := c.Counts(ctx, kind, []int64{id})
+    if err != nil {
+        return 0, err
+    }
+    return counts[id], nil
+}
+
+// Counts returns approximate unique view counts for several entities at once
+func (c *ViewCounter) Counts(ctx context.Context, kind string, ids []int64) (map[int64]int, error) {
+    counts := make(map[int64]int, len(ids))
+    if len(ids) == 0 {
+        return counts, nil
+    }
+
+    if c.redis == nil {
+        return c.exactCounts(ctx, kind, ids)
+    }
+
+    // Stored counts cover views recorded before the HLL key existed (e.g. after
+    // a Redis restart), so the larger of the two values wins
+    stored, err := c.storedCounts(ctx, kind, ids)
+    if err != nil {
+        return nil, err
+    }
+
+    pipe := c.redis.Pipeline()
+    cmds := make(map[int64]*redis.IntCmd, len(ids))
+    for _, id := range ids {
+        cmds[id] = pipe.PFCount(ctx, hllKey(kind, id))
+    }
+    if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+        log.Printf("View counter: redis unavailable, using stored counts: %v", err)
+        return stored, nil
+    }
+
+    for _, id := range ids {
+        count := stored[id]
+        if approx := int(cmds[id].Val()); approx > count {
+            count = approx
+        }
+        counts[id] = count
+    }
+
+    return counts, nil
+}
+
+// storedCounts reads the last flushed counts from Postgres
+func (c *ViewCounter) storedCounts(ctx context.Context, kind string, ids []int64) (map[int64]int, error) {
+    query := `
+        SELECT entity_id, view_count
+        FROM view_counts
+        WHERE entity_type = $1 AND entity_id = ANY($2)`
+
+    rows, err := c.db.QueryContext(ctx, query, kind, pq.Array(ids))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    counts := make(map[int64]int, len(ids))
+    for rows.Next() {
+        var id int64
+        var count int
+        if err := rows.Scan(&id, &count); err != nil {
+            return nil, err
+        }
+        counts[id] = count
+    }
+    return counts, rows.Err()
+}
+
+// exactCounts counts distinct viewers from the source table
+func (c *ViewCounter) exactCounts(ctx context.Context, kind string, ids []int64) (map[int64]int, error) {
+    source, ok := c.sources[kind]
+    if !ok {
+        return nil, fmt.Errorf("no view source registered for %s", kind)
+    }
+
+    query := fmt.Sprintf(`
+        SELECT %[2]s, COUNT(DISTINCT %[3]s)
+        FROM %[1]s
+        WHERE %[2]s = ANY($1)
+        GROUP BY %[2]s`,
+        source.Table, source.EntityColumn, source.ViewerColumn)
+
+    rows, err := c.db.QueryContext(ctx, query, pq.Array(ids))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    counts := make(map[int64]int, len(ids))
+    for rows.Next() {
+        var id int64
+        var count int
+        if err := rows.Scan(&id, &count); err != nil {
+            return nil, err
+        }
+        counts[id] = count
+    }
+    return counts, rows.Err()
+}
+
+// Flush writes the counts of entities viewed since the last flush to Postgres
+func (c *ViewCounter) Flush(ctx context.Context) error {
+    if c.redis == nil {
+        return nil
+    }
+
+    for kind := range c.sources {
+        members, err := c.redis.SPopN(ctx, dirtyKey(kind), 1000).Result()
+        if err != nil && err != redis.Nil {
+            return fmt.Errorf("failed to read dirty %s counters: %w", kind, err)
+        }
+        if len(members) == 0 {
+            continue
+        }
+
+        ids := make([]int64, 0, len(members))
+        for _, m := range members {
+            if id, err := strconv.ParseInt(m, 10, 64); err == nil {
+                ids = append(ids, id)
+            }
+        }
+
+        counts, err := c.Counts(ctx, kind, ids)
+        if err != nil {
+            // Put them back so the next flush retries
+            c.redis.SAdd(ctx, dirtyKey(kind), toInterfaces(ids)...)
+            return err
+        }
+
+        if err := c.upsert(ctx, kind, counts); err != nil {
+            c.redis.SAdd(ctx, dirtyKey(kind), toInterfaces(ids)...)
+            return err
+        }
+
+        log.Printf("Flushed %d %s view counters", len(counts), kind)
+    }
+
+    return nil
+}
+
+// Reconcile replaces stored counts with exact counts from the source tables
+func (c *ViewCounter) Reconcile(ctx context.Context) error {
+    for kind, source := range c.sources {
+        query := fmt.Sprintf(`
+            INSERT INTO view_counts (entity_type, entity_id, view_count, reconciled_at, updated_at)
+            SELECT $1, %[2]s, COUNT(DISTINCT %[3]s), NOW(), NOW()
+            FROM %[1]s
+            GROUP BY %[2]s
+            ON CONFLICT (entity_type, entity_id)
+            DO UPDATE SET view_count = EXCLUDED.view_count,
+                          reconciled_at = NOW(),
+                          updated_at = NOW()`,
+            source.Table, source.EntityColumn, source.ViewerColumn)
+
+        result, err := c.db.ExecContext(ctx, query, kind)
+        if err != nil {
+            return fmt.Errorf("failed to reconcile %s view counts: %w", kind, err)
+        }
+
+        // Drop rows whose entity no longer has any views (e.g. deleted stories)
+        cleanup := fmt.Sprintf(`
+            DELETE FROM view_counts vc
+            WHERE vc.entity_type = $1
+            AND NOT EXISTS (SELECT 1 FROM %[1]s s WHERE s.%[2]s = vc.entity_id)`,
+            source.Table, source.EntityColumn)
+        if _, err := c.db.ExecContext(ctx, cleanup, kind); err != nil {
+            return fmt.Errorf("failed to prune %s view counts: %w", kind, err)
+        }
+
+        affected, _ := result.RowsAffected()
+        log.Printf("Reconciled %d %s view counters", affected, kind)
+    }
+
+    return nil
+}
+
+func (c *ViewCounter) upsert(ctx context.Context, kind string, counts map[int64]int) error {
+    if len(counts) == 0 {
+        return nil
+    }
+
+    ids := make([]int64, 0, len(counts))
+    values := make([]int64, 0, len(counts))
+    for id, count := range counts {
+        ids = append(ids, id)
+        values = append(values, int64(count))
+    }
+
+    query := `
+        INSERT INTO view_counts (entity_type, entity_id, view_count, updated_at)
+        SELECT $1, UNNEST($2::bigint[]), UNNEST($3::bigint[]), NOW()
+        ON CONFLICT (entity_type, entity_id)
+        DO UPDATE SET view_count = GREATEST(view_counts.view_count, EXCLUDED.view_count),
+                      updated_at = NOW()`
+
+    _, err := c.db.ExecContext(ctx, query, kind, pq.Array(ids), pq.Array(values))
+    return err
+}
+
+func toInterfaces(ids []int64) []interface{} {
+    out := make([]interface{}, len(ids))
+    for i, id := range ids {
+        out[i] = id
+    }
+    return out
+}
+
+// Kinds returns the registered kinds, mostly for logging
+func (c *ViewCounter) Kinds() string {
+    kinds := make([]string, 0, len(c.sources))
+    for kind := range c.sources {
+        kinds = append(kinds, kind)
+    }
+    return strings.Join(kinds, ", ")
+}
@@ -0,0 +1,81 @@
+// internal/common/counters/scheduler.go
+// Background flushing and nightly reconciliation for view counters
+
+package counters
+
+import (
+    "context"
+    "log"
+    "time"
+)
+
+// Scheduler periodically flushes counters and reconciles them once a day
+type Scheduler struct {
+    counter       *ViewCounter
+    flushInterval time.Duration
+    reconcileHour int
+}
+
+// NewScheduler creates a counter scheduler. Reconciliation runs daily at reconcileHour (local time).
+func NewScheduler(counter *ViewCounter, flushInterval time.Duration, reconcileHour int) *Scheduler {
+    if flushInterval == 0 {
+        flushInterval = 1 * time.Minute
+    }
+    
+    return &Scheduler{
+        counter:       counter,
+        flushInterval: flushInterval,
+        reconcileHour: reconcileHour,
+    }
+}
+
+// Start runs the flush and reconcile loops until ctx is cancelled
+func (s *Scheduler) Start(ctx context.Context) {
+    log.Printf("Starting view counter scheduler (%s) with flush interval: %v", s.counter.Kinds(), s.flushInterval)
+    
+    go s.runReconcile(ctx)
+    
+    ticker := time.NewTicker(s.flushInterval)
+    defer ticker.Stop()
+    
+    for {
+        select {
+        case <-ticker.C:
+            if err := s.counter.Flush(ctx); err != nil {
+                log.Printf("Failed to flush view counters: %v", err)
+            }
+        case <-ctx.Done():
+            // Final flush so recent views aren't lost on shutdown
+            flushCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+            s.counter.Flush(flushCtx)
+            cancel()
+            log.Println("Stopping view counter scheduler")
+            return
+        }
+    }
+}
+
+func (s *Scheduler) runReconcile(ctx context.Context) {
+    for {
+        now := time.Now()
+        next := time.Date(now.Year(), now.Month(), now.Day(), s.reconcileHour, 0, 0, 0, now.Location())
+        if now.After(next) {
+            next = next.Add(24 * time.Hour)
+        }
+        
+        timer := time.NewTimer(next.Sub(now))
+        
+        select {
+        case <-timer.C:
+            startTime := time.Now()
+            if err := s.counter.Reconcile(ctx); err != nil {
+                log.Printf("View counter reconciliation failed: %v", err)
+            } else {
+                log.Printf("View counter reconciliation completed in %v", time.Since(startTime))
+            }
+        case <-ctx.Done():
+            timer.Stop()
+            return
+        }
+    }
+}
@@ -0,0 +1,126 @@
+// internal/common/database/tx.go
+// Transactions spanning several repositories
+// A service runs a multi-step flow inside UnitOfWork.Do. Repositories take
+// their connection from Conn or ConnX, which return the transaction carried
+// by the context when there is one, so the same repository method works
+// both on its own and as part of a larger unit of work.
+
+package database
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "log"
+
+    "github.com/jmoiron/sqlx"
+)
+
+// Querier runs statements. *sql.DB, *sql.Tx, *sqlx.DB and *sqlx.Tx all
+// satisfy it.
+type Querier interface {
+    ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+    QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+    QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+type txKey struct{}
+
+// unit is a transaction and what waits for it to commit. tx is cleared once
+// the transaction ends, so a context that outlives it, such as the one an
+// after-commit hook closes over, goes back to plain connections.
+type unit struct {
+    tx          *sqlx.Tx
+    afterCommit []func()
+}
+
+func unitFrom(ctx context.Context) *unit {
+    u, _ := ctx.Value(txKey{}).(*unit)
+    if u == nil || u.tx == nil {
+        return nil
+    }
+    return u
+}
+
+// UnitOfWork runs functions in a database transaction
+type UnitOfWork struct {
+    db *sqlx.DB
+}
+
+// NewUnitOfWork creates a unit of work on db
+func NewUnitOfWork(db *sqlx.DB) *UnitOfWork {
+    return &UnitOfWork{db: db}
+}
+
+// Do runs fn in a transaction, committed if fn returns nil and rolled back
+// if it returns an error or panics. Repositories called with the context fn
+// receives take part in the transaction. A Do inside another joins the
+// outer transaction, and a nil UnitOfWork runs fn without one.
+func (w *UnitOfWork) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+    if w == nil || InTx(ctx) {
+        return fn(ctx)
+    }
+
+    tx, err := w.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return fmt.Errorf("failed to begin transaction: %w", err)
+    }
+    u := &unit{tx: tx}
+
+    defer func() {
+        u.tx = nil
+        if p := recover(); p != nil {
+            tx.Rollback()
+            panic(p)
+        }
+    }()
+
+    if err := fn(context.WithValue(ctx, txKey{}, u)); err != nil {
+        if rbErr := tx.Rollback(); rbErr != nil {
+            log.Printf("Failed to roll back transaction: %v", rbErr)
+        }
+        return err
+    }
+    if err := tx.Commit(); err != nil {
+        return fmt.Errorf("failed to commit transaction: %w", err)
+    }
+    u.tx = nil
+
+    for _, fn := range u.afterCommit {
+        fn()
+    }
+    return nil
+}
+
+// InTx reports whether ctx carries a transaction
+func InTx(ctx context.Context) bool {
+    return unitFrom(ctx) != nil
+}
+
+// AfterCommit runs fn once the transaction in ctx has committed, and never
+// if it rolls back. Outside a transaction fn runs straight away. Use it for
+// side effects such as notifications that must not announce rolled back
+// writes.
+func AfterCommit(ctx context.Context, fn func()) {
+    if u := unitFrom(ctx); u != nil {
+        u.afterCommit = append(u.afterCommit, fn)
+        return
+    }
+    fn()
+}
+
+// Conn returns the transaction in ctx, or db outside a transaction
+func Conn(ctx context.Context, db *sql.DB) Querier {
+    if u := unitFrom(ctx); u != nil {
+        return u.tx
+    }
+    return db
+}
+
+// ConnX is Conn for sqlx repositories
+func ConnX(ctx context.Context, db *sqlx.DB) sqlx.ExtContext {
+    if u := unitFrom(ctx); u != nil {
+        return u.tx
+    }
+    return db
+}
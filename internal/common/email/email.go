@@ -0,0 +1,148 @@
+// internal/common/email/email.go
+// HTML and plain text email templates with the Kiekky layout
+//
+// Each email is a pair of files under templates/: name.html and name.txt.
+// Both define a "content" block that the matching layout wraps with the
+// header, preview text and footer. Text comes from the i18n catalogs through
+// the t function, {{t .Lang "key" "var" value}}, so an email is rendered in
+// the recipient's language.
+
+package email
+
+import (
+    "bytes"
+    "embed"
+    "fmt"
+    htmltemplate "html/template"
+    "path"
+    "sort"
+    "strings"
+    "sync"
+    texttemplate "text/template"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
+)
+
+//go:embed templates/*.html templates/*.txt
+var files embed.FS
+
+// Data is what a template renders. The layout reads Title, Preheader,
+// UnsubscribeURL and PreferencesURL; Lang and Year are filled in by Render.
+type Data map[string]interface{}
+
+// Message is a rendered email
+type Message struct {
+    Subject string
+    HTML    string
+    Text    string
+}
+
+// templateSet is one email's HTML and plain text templates
+type templateSet struct {
+    html *htmltemplate.Template
+    text *texttemplate.Template
+}
+
+var (
+    loadOnce  sync.Once
+    templates map[string]*templateSet
+    loadErr   error
+)
+
+// Render renders the named email in lang. The subject is data's Title.
+func Render(name, lang string, data Data) (*Message, error) {
+    loadOnce.Do(load)
+    if loadErr != nil {
+        return nil, loadErr
+    }
+
+    set, ok := templates[name]
+    if !ok {
+        return nil, fmt.Errorf("unknown email template %q", name)
+    }
+
+    values := make(Data, len(data)+2)
+    for k, v := range data {
+        values[k] = v
+    }
+    values["Lang"] = i18n.Match(lang)
+    values["Year"] = time.Now().Year()
+    for _, key := range []string{"Title", "Preheader", "UnsubscribeURL", "PreferencesURL"} {
+        if _, ok := values[key]; !ok {
+            values[key] = ""
+        }
+    }
+
+    var html, text bytes.Buffer
+    if err := set.html.ExecuteTemplate(&html, "layout", values); err != nil {
+        return nil, fmt.Errorf("failed to render %s email: %w", name, err)
+    }
+    if err := set.text.ExecuteTemplate(&text, "layout", values); err != nil {
+        return nil, fmt.Errorf("failed to render %s plain text email: %w", name, err)
+    }
+
+    return &Message{
+        Subject: fmt.Sprint(values["Title"]),
+        HTML:    html.String(),
+        Text:    strings.TrimSpace(text.String()) + "\n",
+    }, nil
+}
+
+// Names returns the names of the available emails, sorted
+func Names() []string {
+    loadOnce.Do(load)
+
+    names := make([]string, 0, len(templates))
+    for name := range templates {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
+
+// load parses every email with its layout
+func load() {
+    entries, err := files.ReadDir("templates")
+    if err != nil {
+        loadErr = err
+        return
+    }
+
+    templates = make(map[string]*templateSet)
+    for _, entry := range entries {
+        name := strings.TrimSuffix(entry.Name(), ".html")
+        if name == entry.Name() || name == "layout" {
+            continue
+        }
+
+        html, err := htmltemplate.New(name).Funcs(htmltemplate.FuncMap{"t": translate}).
+            ParseFS(files, "templates/layout.html", path.Join("templates", name+".html"))
+        if err != nil {
+            loadErr = fmt.Errorf("failed to parse %s email: %w", name, err)
+            return
+        }
+
+        text, err := texttemplate.New(name).Funcs(texttemplate.FuncMap{"t": translate}).
+            ParseFS(files, "templates/layout.txt", path.Join("templates", name+".txt"))
+        if err != nil {
+            loadErr = fmt.Errorf("failed to parse %s plain text email: %w", name, err)
+            return
+        }
+
+        templates[name] = &templateSet{html: html, text: text}
+    }
+}
+
+// translate is the templates' t function: a catalog key in lang followed by
+// name/value pairs for its placeholders
+func translate(lang, key string, pairs ...interface{}) string {
+    var vars i18n.Vars
+    if len(pairs) > 0 {
+        vars = make(i18n.Vars, len(pairs)/2)
+        for i := 0; i+1 < len(pairs); i += 2 {
+            vars[fmt.Sprint(pairs[i])] = pairs[i+1]
+        }
+    }
+    return i18n.T(lang, key, vars)
+}
@@ -0,0 +1,88 @@
+// internal/common/email/preview.go
+// Development endpoint for looking at rendered emails in a browser
+
+package email
+
+import (
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
+)
+
+// samples is placeholder data for previewing each email
+var samples = map[string]Data{
+    "otp": {
+        "Title":     "Verify Your Account",
+        "Preheader": "Your Kiekky code is 123456",
+        "Code":      "123456",
+        "Minutes":   10,
+    },
+    "notification": {
+        "Title":          "New Follower! 👥",
+        "Message":        "Ada Lovelace started following you",
+        "ActionURL":      "https://kiekky.com/users/42",
+        "PreferencesURL": "https://kiekky.com/settings/notifications",
+    },
+    "weekly_recap": {
+        "Title": "Your week on Kiekky, Ada 📊",
+        "Name":  "Ada",
+        "Recap": map[string]interface{}{
+            "ProfileViews":  128,
+            "LikesReceived": 64,
+            "NewMatches":    3,
+            "TopPost":       map[string]interface{}{"Likes": 40, "Caption": "Sunday in the park"},
+        },
+        "TopPostURL":     "https://kiekky.com/posts/7",
+        "UnsubscribeURL": "https://kiekky.com/settings/notifications?unsubscribe=weekly_recap",
+        "PreferencesURL": "https://kiekky.com/settings/notifications",
+    },
+    "invitation": {
+        "Title":          "You're invited to Kiekky",
+        "Name":           "Ada",
+        "Username":       "ada",
+        "SetPasswordURL": "https://kiekky.com/forgot-password?email=ada%40example.com",
+        "PreferencesURL": "https://kiekky.com/settings/notifications",
+    },
+}
+
+// listPreviews returns the emails that can be previewed
+func listPreviews(w http.ResponseWriter, r *http.Request) {
+    utils.SuccessResponse(w, map[string]interface{}{
+        "templates": Names(),
+        "languages": i18n.Supported(),
+    }, http.StatusOK)
+}
+
+// preview renders an email with sample data. ?lang= picks the language and
+// ?format=text shows the plain text alternative.
+func preview(w http.ResponseWriter, r *http.Request) {
+    name := mux.Vars(r)["name"]
+
+    lang := i18n.FromRequest(r)
+    if q := r.URL.Query().Get("lang"); q != "" {
+        lang = i18n.Match(q)
+    }
+
+    msg, err := Render(name, lang, samples[name])
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+        return
+    }
+
+    if r.URL.Query().Get("format") == "text" {
+        w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+        w.Write([]byte(msg.Text))
+        return
+    }
+    w.Header().Set("Content-Type", "text/html; charset=utf-8")
+    w.Write([]byte(msg.HTML))
+}
+
+// RegisterPreviewRoutes mounts the email previews. They show sample data
+// only, but should still be mounted in development alone.
+func RegisterPreviewRoutes(router *mux.Router) {
+    router.HandleFunc("/dev/emails", listPreviews).Methods("GET")
+    router.HandleFunc("/dev/emails/{name}", preview).Methods("GET")
+}
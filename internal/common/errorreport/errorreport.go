@@ -0,0 +1,102 @@
+// internal/common/errorreport/errorreport.go
+// Package errorreport sends panics, with their stack and the request they
+// happened in, to an error tracker such as Sentry.
+
+package errorreport
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Event is one panic or unexpected error
+type Event struct {
+	Message string
+	Frames  []Frame // Innermost call first
+	Time    time.Time
+
+	// Request context, empty outside HTTP requests
+	RequestID string
+	Method    string
+	URL       string
+	IP        string
+	UserAgent string
+	UserID    int64 // 0 when the request wasn't authenticated
+}
+
+// Frame is one call in a stack trace
+type Frame struct {
+	Function string
+	File     string
+	Line     int
+}
+
+// Reporter receives events. Report must not block on the network; the
+// request that panicked is waiting on it.
+type Reporter interface {
+	Report(ctx context.Context, event *Event)
+}
+
+// LogReporter logs events with their stack, for when no tracker is set up
+type LogReporter struct{}
+
+func (LogReporter) Report(ctx context.Context, event *Event) {
+	var stack strings.Builder
+	for _, f := range event.Frames {
+		fmt.Fprintf(&stack, "\n    %s\n        %s:%d", f.Function, f.File, f.Line)
+	}
+	log.Printf("🔥 %s [request %s, user %d]%s", event.Message, event.RequestID, event.UserID, stack.String())
+}
+
+// Stack captures the calling goroutine's stack, skipping skip frames above
+// the caller of Stack
+func Stack(skip int) []Frame {
+	pcs := make([]uintptr, 64)
+	n := runtime.Callers(skip+2, pcs)
+
+	var frames []Frame
+	it := runtime.CallersFrames(pcs[:n])
+	for {
+		f, more := it.Next()
+		frames = append(frames, Frame{Function: f.Function, File: f.File, Line: f.Line})
+		if !more {
+			return frames
+		}
+	}
+}
+
+type scopeKey struct{}
+
+// Scope holds request details learned after the panic handler was set up,
+// such as the authenticated user
+type Scope struct {
+	mu     sync.Mutex
+	userID int64
+}
+
+// WithScope returns ctx carrying a new scope for SetUser to fill in
+func WithScope(ctx context.Context) (context.Context, *Scope) {
+	scope := &Scope{}
+	return context.WithValue(ctx, scopeKey{}, scope), scope
+}
+
+// SetUser records the authenticated user on ctx's scope, if it has one
+func SetUser(ctx context.Context, userID int64) {
+	if scope, ok := ctx.Value(scopeKey{}).(*Scope); ok {
+		scope.mu.Lock()
+		scope.userID = userID
+		scope.mu.Unlock()
+	}
+}
+
+// UserID returns the user set with SetUser, or 0
+func (s *Scope) UserID() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.userID
+}
@@ -0,0 +1,231 @@
+// internal/common/errorreport/sentry.go
+// Sentry reporter, speaking the envelope protocol over plain HTTP
+
+package errorreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// sentryTimeout bounds one delivery attempt
+	sentryTimeout = 5 * time.Second
+
+	// sentryMaxInFlight bounds concurrent deliveries; events past it are
+	// logged and dropped rather than piling up goroutines during an outage
+	sentryMaxInFlight = 8
+
+	// inAppPrefix marks frames from this module, which Sentry highlights
+	inAppPrefix = "github.com/imadgeboyega/kiekky-backend/"
+)
+
+// Sentry sends events to a Sentry project. Delivery happens in the
+// background, so Report returns at once.
+type Sentry struct {
+	dsn         string
+	endpoint    string
+	auth        string
+	environment string
+	release     string
+	serverName  string
+	client      *http.Client
+	inFlight    chan struct{}
+}
+
+// NewSentry builds a reporter for a DSN such as
+// https://<key>@o0.ingest.sentry.io/<project>
+func NewSentry(dsn, environment, release string) (*Sentry, error) {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.User == nil || u.User.Username() == "" {
+		return nil, fmt.Errorf("invalid Sentry DSN")
+	}
+	path := strings.TrimSuffix(u.Path, "/")
+	slash := strings.LastIndex(path, "/")
+	projectID := path[slash+1:]
+	if projectID == "" {
+		return nil, fmt.Errorf("Sentry DSN has no project ID")
+	}
+
+	hostname, _ := os.Hostname()
+	return &Sentry{
+		dsn:         dsn,
+		endpoint:    fmt.Sprintf("%s://%s%s/api/%s/envelope/", u.Scheme, u.Host, path[:slash], projectID),
+		auth:        fmt.Sprintf("Sentry sentry_version=7, sentry_client=kiekky-backend/1.0, sentry_key=%s", u.User.Username()),
+		environment: environment,
+		release:     release,
+		serverName:  hostname,
+		client:      &http.Client{Timeout: sentryTimeout},
+		inFlight:    make(chan struct{}, sentryMaxInFlight),
+	}, nil
+}
+
+func (s *Sentry) Report(ctx context.Context, event *Event) {
+	select {
+	case s.inFlight <- struct{}{}:
+	default:
+		log.Printf("⚠️  Sentry busy, dropping event: %s", event.Message)
+		return
+	}
+
+	go func() {
+		defer func() { <-s.inFlight }()
+		if err := s.send(event); err != nil {
+			log.Printf("⚠️  Failed to report to Sentry: %v (event: %s)", err, event.Message)
+		}
+	}()
+}
+
+func (s *Sentry) send(event *Event) error {
+	body, err := s.envelope(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, s.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-sentry-envelope")
+	req.Header.Set("X-Sentry-Auth", s.auth)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("sentry returned %s", resp.Status)
+	}
+	return nil
+}
+
+// envelope encodes event as an envelope: a header line, an item header
+// line and the event itself
+func (s *Sentry) envelope(event *Event) ([]byte, error) {
+	var id [16]byte
+	rand.Read(id[:])
+	eventID := hex.EncodeToString(id[:])
+
+	payload, err := json.Marshal(s.sentryEvent(eventID, event))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	json.NewEncoder(&buf).Encode(map[string]string{
+		"event_id": eventID,
+		"dsn":      s.dsn,
+		"sent_at":  time.Now().UTC().Format(time.RFC3339),
+	})
+	json.NewEncoder(&buf).Encode(map[string]interface{}{
+		"type":   "event",
+		"length": len(payload),
+	})
+	buf.Write(payload)
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// Event payload, trimmed to the fields we fill in
+
+type sentryEvent struct {
+	EventID     string            `json:"event_id"`
+	Timestamp   string            `json:"timestamp"`
+	Platform    string            `json:"platform"`
+	Level       string            `json:"level"`
+	Environment string            `json:"environment,omitempty"`
+	Release     string            `json:"release,omitempty"`
+	ServerName  string            `json:"server_name,omitempty"`
+	Exception   sentryExceptions  `json:"exception"`
+	Request     *sentryRequest    `json:"request,omitempty"`
+	User        *sentryUser       `json:"user,omitempty"`
+	Tags        map[string]string `json:"tags,omitempty"`
+}
+
+type sentryExceptions struct {
+	Values []sentryException `json:"values"`
+}
+
+type sentryException struct {
+	Type       string           `json:"type"`
+	Value      string           `json:"value"`
+	Stacktrace sentryStacktrace `json:"stacktrace"`
+}
+
+type sentryStacktrace struct {
+	Frames []sentryFrame `json:"frames"`
+}
+
+type sentryFrame struct {
+	Function string `json:"function"`
+	AbsPath  string `json:"abs_path"`
+	Lineno   int    `json:"lineno"`
+	InApp    bool   `json:"in_app"`
+}
+
+type sentryRequest struct {
+	Method  string            `json:"method"`
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type sentryUser struct {
+	ID        string `json:"id,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+}
+
+func (s *Sentry) sentryEvent(eventID string, event *Event) *sentryEvent {
+	out := &sentryEvent{
+		EventID:     eventID,
+		Timestamp:   event.Time.UTC().Format(time.RFC3339Nano),
+		Platform:    "go",
+		Level:       "fatal",
+		Environment: s.environment,
+		Release:     s.release,
+		ServerName:  s.serverName,
+	}
+
+	// Sentry lists frames outermost first
+	frames := make([]sentryFrame, len(event.Frames))
+	for i, f := range event.Frames {
+		frames[len(frames)-1-i] = sentryFrame{
+			Function: f.Function,
+			AbsPath:  f.File,
+			Lineno:   f.Line,
+			InApp:    strings.HasPrefix(f.Function, inAppPrefix),
+		}
+	}
+	out.Exception.Values = []sentryException{{
+		Type:       "panic",
+		Value:      event.Message,
+		Stacktrace: sentryStacktrace{Frames: frames},
+	}}
+
+	if event.Method != "" {
+		out.Request = &sentryRequest{Method: event.Method, URL: event.URL}
+		if event.UserAgent != "" {
+			out.Request.Headers = map[string]string{"User-Agent": event.UserAgent}
+		}
+	}
+	if event.UserID != 0 || event.IP != "" {
+		out.User = &sentryUser{IPAddress: event.IP}
+		if event.UserID != 0 {
+			out.User.ID = fmt.Sprint(event.UserID)
+		}
+	}
+	if event.RequestID != "" {
+		out.Tags = map[string]string{"request_id": event.RequestID}
+	}
+	return out
+}
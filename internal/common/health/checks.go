@@ -0,0 +1,71 @@
+// internal/common/health/checks.go
+// Checks for the services the API depends on
+
+package health
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "net/http"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/go-redis/redis/v8"
+)
+
+// Postgres checks the database answers a ping
+func Postgres(db *sql.DB) CheckFunc {
+    return func(ctx context.Context) error {
+        return db.PingContext(ctx)
+    }
+}
+
+// Redis checks the cache answers a ping. A nil client means Redis was
+// configured but couldn't be reached at startup.
+func Redis(client *redis.Client) CheckFunc {
+    return func(ctx context.Context) error {
+        if client == nil {
+            return errors.New("not connected")
+        }
+        return client.Ping(ctx).Err()
+    }
+}
+
+// S3Bucket checks the bucket exists and the credentials can reach it
+func S3Bucket(sess *session.Session, bucket string) CheckFunc {
+    return func(ctx context.Context) error {
+        if sess == nil {
+            return errors.New("no AWS session")
+        }
+        _, err := s3.New(sess).HeadBucketWithContext(ctx, &s3.HeadBucketInput{
+            Bucket: aws.String(bucket),
+        })
+        return err
+    }
+}
+
+// Reachable checks an HTTP endpoint answers at all. Any response below 500
+// counts, since unauthenticated requests to APIs such as FCM are refused
+// with 4xx even when the service is healthy.
+func Reachable(url string) CheckFunc {
+    return func(ctx context.Context) error {
+        req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+        if err != nil {
+            return err
+        }
+
+        resp, err := http.DefaultClient.Do(req)
+        if err != nil {
+            return err
+        }
+        resp.Body.Close()
+
+        if resp.StatusCode >= 500 {
+            return fmt.Errorf("unexpected status %d", resp.StatusCode)
+        }
+        return nil
+    }
+}
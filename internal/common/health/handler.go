@@ -0,0 +1,64 @@
+// internal/common/health/handler.go
+
+package health
+
+import (
+    "net/http"
+    "sync/atomic"
+    "time"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// LiveHandler reports the process is up. It checks no dependencies, so a
+// database outage doesn't get the process restarted.
+func (c *Checker) LiveHandler(w http.ResponseWriter, r *http.Request) {
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "status":    "alive",
+        "timestamp": time.Now(),
+        "uptime":    c.Uptime().Round(time.Second).String(),
+    })
+}
+
+// ReadyHandler runs every check, answering 503 while startup is still in
+// progress or a critical dependency is down
+func (c *Checker) ReadyHandler(w http.ResponseWriter, r *http.Request) {
+    report := c.Check(r.Context())
+
+    status := http.StatusOK
+    if !report.Ready() {
+        status = http.StatusServiceUnavailable
+    }
+    utils.RespondWithJSON(w, status, report)
+}
+
+// RegisterRoutes mounts the probes. /health is kept for existing load
+// balancer configs and answers like /health/ready.
+func RegisterRoutes(router *mux.Router, checker *Checker) {
+    router.HandleFunc("/health", checker.ReadyHandler).Methods("GET")
+    router.HandleFunc("/health/live", checker.LiveHandler).Methods("GET")
+    router.HandleFunc("/health/ready", checker.ReadyHandler).Methods("GET")
+}
+
+// SwapHandler serves through a handler that can be replaced while the server
+// is running, so probes can be answered before the full router is built
+type SwapHandler struct {
+    current atomic.Value
+}
+
+// NewSwapHandler creates a SwapHandler serving initial
+func NewSwapHandler(initial http.Handler) *SwapHandler {
+    h := &SwapHandler{}
+    h.Swap(initial)
+    return h
+}
+
+// Swap replaces the handler for subsequent requests
+func (h *SwapHandler) Swap(next http.Handler) {
+    h.current.Store(&next)
+}
+
+func (h *SwapHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+    (*h.current.Load().(*http.Handler)).ServeHTTP(w, r)
+}
@@ -0,0 +1,182 @@
+// internal/common/health/health.go
+// Dependency checks for liveness and readiness probes
+
+package health
+
+import (
+    "context"
+    "fmt"
+    "sort"
+    "sync"
+    "time"
+)
+
+// Statuses reported for the whole service and for each check
+const (
+    StatusReady    = "ready"
+    StatusDegraded = "degraded" // A non-critical dependency is down
+    StatusNotReady = "not_ready"
+
+    CheckUp   = "up"
+    CheckDown = "down"
+)
+
+// defaultTimeout bounds each check so one hanging dependency can't stall the probe
+const defaultTimeout = 2 * time.Second
+
+// CheckFunc reports whether a dependency is reachable
+type CheckFunc func(ctx context.Context) error
+
+type check struct {
+    name     string
+    critical bool
+    fn       CheckFunc
+}
+
+// Result is the outcome of a single check
+type Result struct {
+    Status    string  `json:"status"`
+    Critical  bool    `json:"critical"`
+    LatencyMs float64 `json:"latency_ms"`
+    Error     string  `json:"error,omitempty"`
+}
+
+// Report is the readiness of the service as a whole
+type Report struct {
+    Status    string            `json:"status"`
+    Starting  []string          `json:"starting,omitempty"`
+    Checks    map[string]Result `json:"checks"`
+    Timestamp time.Time         `json:"timestamp"`
+    Uptime    string            `json:"uptime"`
+}
+
+// Ready reports whether the service should receive traffic
+func (r *Report) Ready() bool {
+    return r.Status != StatusNotReady
+}
+
+// Checker runs the registered dependency checks and tracks startup steps
+// that must finish before the service is ready
+type Checker struct {
+    mu       sync.RWMutex
+    checks   []check
+    starting map[string]bool
+    started  time.Time
+    timeout  time.Duration
+}
+
+// NewChecker creates a checker; timeout applies to each check, 0 uses the default
+func NewChecker(timeout time.Duration) *Checker {
+    if timeout <= 0 {
+        timeout = defaultTimeout
+    }
+
+    return &Checker{
+        starting: make(map[string]bool),
+        started:  time.Now(),
+        timeout:  timeout,
+    }
+}
+
+// Add registers a dependency check. A failing critical check makes the
+// service not ready; other failures only degrade it.
+func (c *Checker) Add(name string, critical bool, fn CheckFunc) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.checks = append(c.checks, check{name: name, critical: critical, fn: fn})
+}
+
+// Starting marks a startup step as in progress; the service is not ready
+// until Done is called for it
+func (c *Checker) Starting(step string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    c.starting[step] = true
+}
+
+// Done marks a startup step as finished
+func (c *Checker) Done(step string) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    delete(c.starting, step)
+}
+
+// Uptime is how long the checker has existed, i.e. roughly the process uptime
+func (c *Checker) Uptime() time.Duration {
+    return time.Since(c.started)
+}
+
+// Check runs every check concurrently and combines the results
+func (c *Checker) Check(ctx context.Context) *Report {
+    c.mu.RLock()
+    checks := make([]check, len(c.checks))
+    copy(checks, c.checks)
+    starting := make([]string, 0, len(c.starting))
+    for step := range c.starting {
+        starting = append(starting, step)
+    }
+    c.mu.RUnlock()
+    sort.Strings(starting)
+
+    results := make([]Result, len(checks))
+    var wg sync.WaitGroup
+    for i, ch := range checks {
+        wg.Add(1)
+        go func(i int, ch check) {
+            defer wg.Done()
+            results[i] = c.run(ctx, ch)
+        }(i, ch)
+    }
+    wg.Wait()
+
+    report := &Report{
+        Status:    StatusReady,
+        Starting:  starting,
+        Checks:    make(map[string]Result, len(checks)),
+        Timestamp: time.Now(),
+        Uptime:    c.Uptime().Round(time.Second).String(),
+    }
+    if len(starting) > 0 {
+        report.Status = StatusNotReady
+    }
+
+    for i, ch := range checks {
+        result := results[i]
+        report.Checks[ch.name] = result
+
+        if result.Status == CheckUp {
+            continue
+        }
+        if ch.critical {
+            report.Status = StatusNotReady
+        } else if report.Status == StatusReady {
+            report.Status = StatusDegraded
+        }
+    }
+
+    return report
+}
+
+// run executes one check under the per-check timeout, turning a panic into a failure
+func (c *Checker) run(ctx context.Context, ch check) (result Result) {
+    ctx, cancel := context.WithTimeout(ctx, c.timeout)
+    defer cancel()
+
+    start := time.Now()
+    defer func() {
+        if r := recover(); r != nil {
+            result.Status = CheckDown
+            result.Error = fmt.Sprintf("check panicked: %v", r)
+        }
+        result.Critical = ch.critical
+        result.LatencyMs = float64(time.Since(start).Microseconds()) / 1000
+    }()
+
+    if err := ch.fn(ctx); err != nil {
+        return Result{Status: CheckDown, Error: err.Error()}
+    }
+    return Result{Status: CheckUp}
+}
@@ -0,0 +1,36 @@
+// internal/common/jobs/handler.go
+
+package jobs
+
+import (
+    "net/http"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// Handler serves job status over HTTP
+type Handler struct {
+    registry *Registry
+}
+
+// NewHandler creates a job status handler
+func NewHandler(registry *Registry) *Handler {
+    return &Handler{registry: registry}
+}
+
+// GetStatus lists every registered job with its last run
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "jobs": h.registry.Statuses(),
+    })
+}
+
+// RegisterRoutes mounts the job status endpoint behind middleware, which
+// must authenticate the caller and limit it to staff
+func RegisterRoutes(router *mux.Router, handler *Handler, middleware ...mux.MiddlewareFunc) {
+    admin := router.PathPrefix("/api/v1/admin/jobs").Subrouter()
+    admin.Use(middleware...)
+
+    admin.HandleFunc("", handler.GetStatus).Methods("GET")
+}
@@ -0,0 +1,141 @@
+// internal/common/jobs/jobs.go
+// Run tracking for background jobs
+
+package jobs
+
+import (
+    "context"
+    "log"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+    jobRunsTotal = promauto.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "jobs_runs_total",
+            Help: "Total number of background job runs",
+        },
+        []string{"job", "status"},
+    )
+
+    jobDuration = promauto.NewHistogramVec(
+        prometheus.HistogramOpts{
+            Name:    "jobs_run_duration_seconds",
+            Help:    "Duration of background job runs",
+            Buckets: prometheus.ExponentialBuckets(0.01, 4, 8),
+        },
+        []string{"job"},
+    )
+)
+
+// Func is a single run of a job. It returns named counts describing the work
+// done (e.g. rows purged), which are reported on the status endpoint.
+type Func func(ctx context.Context) (map[string]int64, error)
+
+// Status is the last known state of a job
+type Status struct {
+    Name           string           `json:"name"`
+    Running        bool             `json:"running"`
+    LastStartedAt  *time.Time       `json:"last_started_at,omitempty"`
+    LastFinishedAt *time.Time       `json:"last_finished_at,omitempty"`
+    LastDurationMs int64            `json:"last_duration_ms"`
+    LastError      string           `json:"last_error,omitempty"`
+    LastSuccessAt  *time.Time       `json:"last_success_at,omitempty"`
+    LastResult     map[string]int64 `json:"last_result,omitempty"`
+    Runs           int64            `json:"runs"`
+    Failures       int64            `json:"failures"`
+}
+
+// Registry records the status of every job run through it
+type Registry struct {
+    mu   sync.RWMutex
+    jobs map[string]*Status
+}
+
+// NewRegistry creates an empty job registry
+func NewRegistry() *Registry {
+    return &Registry{jobs: make(map[string]*Status)}
+}
+
+// Register adds a job so it is listed before its first run
+func (r *Registry) Register(name string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    if _, ok := r.jobs[name]; !ok {
+        r.jobs[name] = &Status{Name: name}
+    }
+}
+
+// Run executes fn under the given job name and records the outcome
+func (r *Registry) Run(ctx context.Context, name string, fn Func) error {
+    started := time.Now()
+
+    r.mu.Lock()
+    status, ok := r.jobs[name]
+    if !ok {
+        status = &Status{Name: name}
+        r.jobs[name] = status
+    }
+    status.Running = true
+    status.LastStartedAt = &started
+    r.mu.Unlock()
+
+    result, err := fn(ctx)
+
+    finished := time.Now()
+    elapsed := finished.Sub(started)
+    jobDuration.WithLabelValues(name).Observe(elapsed.Seconds())
+
+    r.mu.Lock()
+    status.Running = false
+    status.LastFinishedAt = &finished
+    status.LastDurationMs = elapsed.Milliseconds()
+    status.LastResult = result
+    status.Runs++
+    if err != nil {
+        status.Failures++
+        status.LastError = err.Error()
+    } else {
+        status.LastError = ""
+        status.LastSuccessAt = &finished
+    }
+    r.mu.Unlock()
+
+    if err != nil {
+        jobRunsTotal.WithLabelValues(name, "error").Inc()
+        log.Printf("Job %s failed after %v: %v", name, elapsed, err)
+    } else {
+        jobRunsTotal.WithLabelValues(name, "success").Inc()
+    }
+
+    return err
+}
+
+// Statuses returns a snapshot of all jobs, sorted by name
+func (r *Registry) Statuses() []Status {
+    r.mu.RLock()
+    defer r.mu.RUnlock()
+
+    statuses := make([]Status, 0, len(r.jobs))
+    for _, s := range r.jobs {
+        snapshot := *s
+        if s.LastResult != nil {
+            snapshot.LastResult = make(map[string]int64, len(s.LastResult))
+            for k, v := range s.LastResult {
+                snapshot.LastResult[k] = v
+            }
+        }
+        statuses = append(statuses, snapshot)
+    }
+
+    sort.Slice(statuses, func(i, j int) bool {
+        return statuses[i].Name < statuses[j].Name
+    })
+    return statuses
+}
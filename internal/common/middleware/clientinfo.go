@@ -0,0 +1,122 @@
+// internal/common/middleware/clientinfo.go
+// Captures who is making a request: IP, user agent and rough location
+
+package middleware
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ClientInfo describes the client behind a request
+type ClientInfo struct {
+	IP        string
+	UserAgent string
+	// DeviceID is an app-generated install identifier sent in X-Device-ID
+	DeviceID string
+	// Country and City come from CDN geolocation headers and may be empty
+	Country string
+	City    string
+}
+
+// Location is the client's approximate location, e.g. "Lagos, NG"
+func (c ClientInfo) Location() string {
+	switch {
+	case c.City != "" && c.Country != "":
+		return c.City + ", " + c.Country
+	case c.Country != "":
+		return c.Country
+	}
+	return c.City
+}
+
+type clientInfoKey struct{}
+
+// maxDeviceIDLength bounds the X-Device-ID header we keep
+const maxDeviceIDLength = 128
+
+// ClientInfoCapture records the client's IP, user agent and location in the
+// request context. Proxy and CDN headers are only read when trustProxy is set,
+// as clients can send them directly otherwise.
+func ClientInfoCapture(trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			info := ClientInfo{
+				IP:        clientIP(r, trustProxy),
+				UserAgent: r.UserAgent(),
+				DeviceID:  strings.TrimSpace(r.Header.Get("X-Device-ID")),
+			}
+			if len(info.DeviceID) > maxDeviceIDLength {
+				info.DeviceID = info.DeviceID[:maxDeviceIDLength]
+			}
+			if trustProxy {
+				info.Country, info.City = geoHeaders(r)
+			}
+
+			ctx := context.WithValue(r.Context(), clientInfoKey{}, info)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// ClientInfoFromContext returns the client captured for the request, or an
+// empty ClientInfo outside an HTTP request
+func ClientInfoFromContext(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey{}).(ClientInfo)
+	return info
+}
+
+// clientIP returns the client's address, preferring the first hop recorded
+// by a trusted proxy
+func clientIP(r *http.Request, trustProxy bool) string {
+	if trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-For"); fwd != "" {
+			if ip := parseIP(strings.Split(fwd, ",")[0]); ip != "" {
+				return ip
+			}
+		}
+		if fwd := forwardedParam(r.Header.Get("Forwarded"), "for"); fwd != "" {
+			if ip := parseIP(fwd); ip != "" {
+				return ip
+			}
+		}
+		if ip := parseIP(r.Header.Get("X-Real-IP")); ip != "" {
+			return ip
+		}
+	}
+	return parseIP(r.RemoteAddr)
+}
+
+// parseIP accepts an address with or without a port ("1.2.3.4:80",
+// "[::1]:80", "::1") and returns just the IP
+func parseIP(addr string) string {
+	addr = strings.TrimSpace(addr)
+	if host, _, err := net.SplitHostPort(addr); err == nil {
+		addr = host
+	}
+	addr = strings.Trim(addr, "[]")
+	if ip := net.ParseIP(addr); ip != nil {
+		return ip.String()
+	}
+	return ""
+}
+
+// geoHeaders reads the country and city added by Cloudflare or CloudFront
+func geoHeaders(r *http.Request) (country, city string) {
+	country = r.Header.Get("CF-IPCountry")
+	if country == "" {
+		country = r.Header.Get("CloudFront-Viewer-Country")
+	}
+	city = r.Header.Get("CF-IPCity")
+	if city == "" {
+		city = r.Header.Get("CloudFront-Viewer-City")
+	}
+
+	// Cloudflare uses XX for unknown and T1 for Tor
+	if country == "XX" || country == "T1" {
+		country = ""
+	}
+	return strings.TrimSpace(country), strings.TrimSpace(city)
+}
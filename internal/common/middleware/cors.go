@@ -0,0 +1,96 @@
+// internal/common/middleware/cors.go
+// Cross-origin requests from browsers, limited to configured origins
+
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CORSConfig controls which browser origins may call the API
+type CORSConfig struct {
+	// AllowedOrigins are exact origins such as https://kiekky.com. "*" allows
+	// any origin, without credentials.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+	// AllowCredentials lets browsers send cookies and read the response
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache a preflight. Zero leaves it to the browser.
+	MaxAge time.Duration
+}
+
+// CORS answers preflight requests and adds Access-Control-Allow-* headers
+// for allowed origins. The matching origin is echoed back rather than "*",
+// so credentialed requests work. Requests from other origins are served
+// without CORS headers, which makes the browser block the response, and
+// their preflights get 403.
+//
+// Wrap the whole router with it; mux skips router.Use middleware for
+// methods a route doesn't declare, which includes most preflights.
+func CORS(cfg CORSConfig) func(http.Handler) http.Handler {
+	allowed := make(map[string]bool, len(cfg.AllowedOrigins))
+	anyOrigin := false
+	for _, origin := range cfg.AllowedOrigins {
+		if origin == "*" {
+			anyOrigin = true
+			continue
+		}
+		allowed[strings.ToLower(strings.TrimSuffix(origin, "/"))] = true
+	}
+
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+	maxAge := strconv.FormatInt(int64(cfg.MaxAge.Seconds()), 10)
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			origin := r.Header.Get("Origin")
+			preflight := r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != ""
+
+			h := w.Header()
+			// Responses differ by origin, so caches must key on it
+			h.Add("Vary", "Origin")
+			if origin == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if !anyOrigin && !allowed[strings.ToLower(origin)] {
+				if preflight {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if anyOrigin {
+				h.Set("Access-Control-Allow-Origin", "*")
+			} else {
+				h.Set("Access-Control-Allow-Origin", origin)
+				if cfg.AllowCredentials {
+					h.Set("Access-Control-Allow-Credentials", "true")
+				}
+			}
+
+			if !preflight {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h.Add("Vary", "Access-Control-Request-Method, Access-Control-Request-Headers")
+			h.Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				h.Set("Access-Control-Allow-Headers", headers)
+			}
+			if cfg.MaxAge > 0 {
+				h.Set("Access-Control-Max-Age", maxAge)
+			}
+			w.WriteHeader(http.StatusNoContent)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+// internal/common/middleware/recover.go
+// Panic recovery, so a bug fails one request with a 500 instead of leaving
+// the client hanging on a dropped connection
+
+package middleware
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/common/errorreport"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// Recover turns a panic in next into a 500 that carries the request ID, and
+// sends the panic with its stack, the request and the user to reporter.
+// Put it inside RequestID and outside everything else. Handlers can name
+// the user with errorreport.SetUser; auth.Authenticate does.
+func Recover(reporter errorreport.Reporter, trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx, scope := errorreport.WithScope(r.Context())
+			r = r.WithContext(ctx)
+
+			defer func() {
+				p := recover()
+				if p == nil {
+					return
+				}
+				// net/http's way of aborting a response on purpose
+				if p == http.ErrAbortHandler {
+					panic(p)
+				}
+
+				requestID := RequestIDFromContext(ctx)
+				event := &errorreport.Event{
+					Message:   fmt.Sprintf("panic: %v", p),
+					Frames:    errorreport.Stack(2),
+					Time:      time.Now(),
+					RequestID: requestID,
+					Method:    r.Method,
+					URL:       r.URL.Path,
+					IP:        clientIP(r, trustProxy),
+					UserAgent: r.UserAgent(),
+					UserID:    scope.UserID(),
+				}
+				log.Printf("❌ %s in %s %s [request %s]", event.Message, r.Method, r.URL.Path, requestID)
+				reporter.Report(ctx, event)
+
+				// Has no effect if the handler already started writing
+				utils.RespondWithJSON(w, http.StatusInternalServerError, map[string]interface{}{
+					"success":    false,
+					"error":      "Internal server error",
+					"request_id": requestID,
+				})
+			}()
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
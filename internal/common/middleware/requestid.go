@@ -0,0 +1,63 @@
+// internal/common/middleware/requestid.go
+// Request IDs, to tie a client's error report to the server's logs
+
+package middleware
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the request ID in requests and responses
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLength bounds request IDs passed in by a proxy
+const maxRequestIDLength = 64
+
+type requestIDKey struct{}
+
+// RequestID gives every request an ID, stored in the context and echoed in
+// the X-Request-ID response header. An ID set by a trusted proxy is kept
+// so both sides log the same one.
+func RequestID(trustProxy bool) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			id := ""
+			if trustProxy {
+				id = r.Header.Get(RequestIDHeader)
+			}
+			if !validRequestID(id) {
+				id = uuid.NewString()
+			}
+
+			w.Header().Set(RequestIDHeader, id)
+			ctx := context.WithValue(r.Context(), requestIDKey{}, id)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// RequestIDFromContext returns the request's ID, or "" outside an HTTP request
+func RequestIDFromContext(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+	return id
+}
+
+// validRequestID accepts short IDs of letters, digits and -_.: so a proxy
+// can't inject anything odd into logs
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLength {
+		return false
+	}
+	for _, c := range id {
+		switch {
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		case c == '-', c == '_', c == '.', c == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,178 @@
+// internal/common/middleware/security.go
+// Security hardening headers and optional HTTPS enforcement
+
+package middleware
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SecurityConfig controls the hardening middleware
+type SecurityConfig struct {
+	// HSTSMaxAge is sent in Strict-Transport-Security on HTTPS requests. Zero disables HSTS.
+	HSTSMaxAge time.Duration
+	// ContentSecurityPolicy is applied to HTML responses only (share pages, email previews)
+	ContentSecurityPolicy string
+	// ForceHTTPS redirects plain HTTP requests to HTTPS
+	ForceHTTPS bool
+	// TrustProxyHeaders honours X-Forwarded-Proto/Host and Forwarded from a reverse proxy.
+	// Only enable behind a proxy that overwrites these headers.
+	TrustProxyHeaders bool
+	// RedirectExemptPaths are served over HTTP even when ForceHTTPS is on (e.g. load balancer health checks)
+	RedirectExemptPaths []string
+}
+
+// SecurityHeaders sets HSTS, X-Content-Type-Options, X-Frame-Options and
+// Referrer-Policy on every response, adds the CSP to HTML responses and
+// optionally redirects HTTP to HTTPS.
+func SecurityHeaders(cfg SecurityConfig) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			secure := isSecure(r, cfg.TrustProxyHeaders)
+
+			if cfg.ForceHTTPS && !secure && !isExempt(r.URL.Path, cfg.RedirectExemptPaths) {
+				target := "https://" + requestHost(r, cfg.TrustProxyHeaders) + r.URL.RequestURI()
+
+				// 308 keeps the method and body for non-GET requests
+				status := http.StatusMovedPermanently
+				if r.Method != http.MethodGet && r.Method != http.MethodHead {
+					status = http.StatusPermanentRedirect
+				}
+				http.Redirect(w, r, target, status)
+				return
+			}
+
+			h := w.Header()
+			h.Set("X-Content-Type-Options", "nosniff")
+			h.Set("X-Frame-Options", "DENY")
+			h.Set("Referrer-Policy", "strict-origin-when-cross-origin")
+
+			// Browsers ignore HSTS received over plain HTTP, so only send it on HTTPS
+			if secure && cfg.HSTSMaxAge > 0 {
+				h.Set("Strict-Transport-Security",
+					fmt.Sprintf("max-age=%d; includeSubDomains", int64(cfg.HSTSMaxAge.Seconds())))
+			}
+
+			if cfg.ContentSecurityPolicy != "" {
+				w = &cspWriter{ResponseWriter: w, policy: cfg.ContentSecurityPolicy}
+			}
+
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// isSecure reports whether the client connection is HTTPS
+func isSecure(r *http.Request, trustProxy bool) bool {
+	if r.TLS != nil {
+		return true
+	}
+	if !trustProxy {
+		return false
+	}
+
+	// X-Forwarded-Proto may hold a list when several proxies are chained; the first is the client's
+	if proto := r.Header.Get("X-Forwarded-Proto"); proto != "" {
+		return strings.EqualFold(strings.TrimSpace(strings.Split(proto, ",")[0]), "https")
+	}
+
+	// RFC 7239: Forwarded: for=1.2.3.4;proto=https;host=example.com
+	if proto := forwardedParam(r.Header.Get("Forwarded"), "proto"); proto != "" {
+		return strings.EqualFold(proto, "https")
+	}
+
+	return false
+}
+
+// requestHost returns the host the client used, without port
+func requestHost(r *http.Request, trustProxy bool) string {
+	host := r.Host
+	if trustProxy {
+		if fwd := r.Header.Get("X-Forwarded-Host"); fwd != "" {
+			host = strings.TrimSpace(strings.Split(fwd, ",")[0])
+		} else if fwd := forwardedParam(r.Header.Get("Forwarded"), "host"); fwd != "" {
+			host = fwd
+		}
+	}
+
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		return h
+	}
+	return host
+}
+
+// forwardedParam extracts a parameter from the first element of a Forwarded header
+func forwardedParam(header, name string) string {
+	if header == "" {
+		return ""
+	}
+
+	first := strings.Split(header, ",")[0]
+	for _, pair := range strings.Split(first, ";") {
+		kv := strings.SplitN(strings.TrimSpace(pair), "=", 2)
+		if len(kv) == 2 && strings.EqualFold(kv[0], name) {
+			return strings.Trim(kv[1], `"`)
+		}
+	}
+	return ""
+}
+
+func isExempt(path string, exempt []string) bool {
+	for _, p := range exempt {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+// cspWriter adds the Content-Security-Policy header once the handler has
+// declared an HTML response
+type cspWriter struct {
+	http.ResponseWriter
+	policy      string
+	wroteHeader bool
+}
+
+func (w *cspWriter) WriteHeader(code int) {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		if strings.HasPrefix(strings.ToLower(w.Header().Get("Content-Type")), "text/html") {
+			w.Header().Set("Content-Security-Policy", w.policy)
+		}
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *cspWriter) Write(b []byte) (int, error) {
+	if !w.wroteHeader {
+		// Mirror net/http's content sniffing so handlers that don't set a type are covered
+		if w.Header().Get("Content-Type") == "" {
+			w.Header().Set("Content-Type", http.DetectContentType(b))
+		}
+		w.WriteHeader(http.StatusOK)
+	}
+	return w.ResponseWriter.Write(b)
+}
+
+// Flush keeps streaming responses working through the wrapper
+func (w *cspWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Hijack keeps WebSocket upgrades working through the wrapper
+func (w *cspWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	return h.Hijack()
+}
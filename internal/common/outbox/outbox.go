@@ -0,0 +1,323 @@
+// internal/common/outbox/outbox.go
+// Transactional outbox for events other modules react to
+// A module publishes an event in the same transaction as the change it
+// describes, so the event exists exactly when the change does. The
+// dispatcher then hands each event to its subscribers, retrying until every
+// one has succeeded. Delivery is at least once: a handler can see an event
+// again after a crash, and should use its key to ignore repeats.
+
+package outbox
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+)
+
+const (
+    // dispatchBatchSize is how many events are claimed at a time
+    dispatchBatchSize = 100
+
+    // leaseDuration is how long a claimed event is left to one dispatcher
+    // before another may pick it up, in case the first one crashed
+    leaseDuration = 5 * time.Minute
+
+    // handlerTimeout bounds a single handler call
+    handlerTimeout = 30 * time.Second
+
+    // maxAttempts is how many times an event is dispatched before it is
+    // marked failed and left for inspection
+    maxAttempts = 10
+
+    retryBaseBackoff = 30 * time.Second
+    retryMaxBackoff  = 1 * time.Hour
+)
+
+var eventsTotal = promauto.NewCounterVec(
+    prometheus.CounterOpts{
+        Name: "outbox_events_total",
+        Help: "Outbox events by type and dispatch result",
+    },
+    []string{"type", "result"},
+)
+
+// Event is a stored outbox event. Key identifies the occurrence, e.g.
+// "post.reposted:42", and is what handlers dedupe on.
+type Event struct {
+    ID        int64           `db:"id"`
+    Type      string          `db:"event_type"`
+    Key       string          `db:"idempotency_key"`
+    Payload   json.RawMessage `db:"payload"`
+    Attempts  int             `db:"attempts"`
+    CreatedAt time.Time       `db:"created_at"`
+}
+
+// Decode unmarshals the event's payload into v
+func (e *Event) Decode(v interface{}) error {
+    return json.Unmarshal(e.Payload, v)
+}
+
+// Handler reacts to an event. Returning an error retries the event later.
+type Handler func(ctx context.Context, event *Event) error
+
+type subscriber struct {
+    name    string
+    handler Handler
+}
+
+// DispatchResult counts what a dispatch run did
+type DispatchResult struct {
+    Published int64
+    Retried   int64
+    Failed    int64
+}
+
+// Outbox stores events and dispatches them to subscribers
+type Outbox struct {
+    db   *sqlx.DB
+    wake chan struct{}
+
+    mu          sync.RWMutex
+    subscribers map[string][]subscriber
+}
+
+// New creates an outbox on db
+func New(db *sqlx.DB) *Outbox {
+    return &Outbox{
+        db:          db,
+        wake:        make(chan struct{}, 1),
+        subscribers: map[string][]subscriber{},
+    }
+}
+
+// Subscribe calls handler for every event of eventType. name identifies the
+// subscriber in the delivery records, so it must stay the same across
+// releases or events in flight are handled twice.
+func (o *Outbox) Subscribe(eventType, name string, handler Handler) {
+    o.mu.Lock()
+    defer o.mu.Unlock()
+    o.subscribers[eventType] = append(o.subscribers[eventType], subscriber{name: name, handler: handler})
+}
+
+func (o *Outbox) subscribersOf(eventType string) []subscriber {
+    o.mu.RLock()
+    defer o.mu.RUnlock()
+    return o.subscribers[eventType]
+}
+
+// Publish stores an event, inside the transaction in ctx when there is one.
+// Publishing a key again is a no-op, so a retried write doesn't repeat its
+// events. A nil outbox drops the event.
+func (o *Outbox) Publish(ctx context.Context, eventType, key string, payload interface{}) error {
+    if o == nil {
+        return nil
+    }
+
+    data, err := json.Marshal(payload)
+    if err != nil {
+        return fmt.Errorf("failed to encode %s event: %w", eventType, err)
+    }
+
+    query := `
+        INSERT INTO outbox_events (event_type, idempotency_key, payload)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (idempotency_key) DO NOTHING`
+
+    if _, err := database.ConnX(ctx, o.db).ExecContext(ctx, query, eventType, key, data); err != nil {
+        return fmt.Errorf("failed to store %s event: %w", eventType, err)
+    }
+
+    database.AfterCommit(ctx, o.notify)
+    return nil
+}
+
+// notify wakes the dispatcher without waiting for it
+func (o *Outbox) notify() {
+    select {
+    case o.wake <- struct{}{}:
+    default:
+    }
+}
+
+// Wake receives when new events were committed, so the dispatcher can run
+// straight away rather than at its next tick
+func (o *Outbox) Wake() <-chan struct{} {
+    return o.wake
+}
+
+// Dispatch hands pending events to their subscribers until none are due
+func (o *Outbox) Dispatch(ctx context.Context) (DispatchResult, error) {
+    var result DispatchResult
+    for ctx.Err() == nil {
+        events, err := o.claim(ctx)
+        if err != nil {
+            return result, err
+        }
+
+        delivered, err := o.deliveredHandlers(ctx, events)
+        if err != nil {
+            return result, err
+        }
+
+        for _, event := range events {
+            if err := o.dispatch(ctx, event, delivered[event.ID], &result); err != nil {
+                return result, err
+            }
+        }
+
+        if len(events) < dispatchBatchSize {
+            break
+        }
+    }
+    return result, ctx.Err()
+}
+
+// claim leases a batch of due events to this dispatcher and counts the
+// attempt
+func (o *Outbox) claim(ctx context.Context) ([]*Event, error) {
+    query := `
+        UPDATE outbox_events
+        SET available_at = NOW() + $1 * INTERVAL '1 second', attempts = attempts + 1
+        WHERE id IN (
+            SELECT id FROM outbox_events
+            WHERE published_at IS NULL AND failed_at IS NULL AND available_at <= NOW()
+            ORDER BY id
+            LIMIT $2
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, event_type, idempotency_key, payload, attempts, created_at`
+
+    var events []*Event
+    if err := o.db.SelectContext(ctx, &events, query, leaseDuration.Seconds(), dispatchBatchSize); err != nil {
+        return nil, fmt.Errorf("failed to claim outbox events: %w", err)
+    }
+    return events, nil
+}
+
+// deliveredHandlers returns, per event, the subscribers that already
+// handled it on an earlier attempt
+func (o *Outbox) deliveredHandlers(ctx context.Context, events []*Event) (map[int64]map[string]bool, error) {
+    delivered := map[int64]map[string]bool{}
+    if len(events) == 0 {
+        return delivered, nil
+    }
+
+    ids := make([]int64, len(events))
+    for i, event := range events {
+        ids[i] = event.ID
+    }
+
+    rows, err := o.db.QueryContext(ctx, `SELECT event_id, handler FROM outbox_deliveries WHERE event_id = ANY($1)`, pq.Array(ids))
+    if err != nil {
+        return nil, fmt.Errorf("failed to get outbox deliveries: %w", err)
+    }
+    defer rows.Close()
+
+    for rows.Next() {
+        var (
+            eventID int64
+            handler string
+        )
+        if err := rows.Scan(&eventID, &handler); err != nil {
+            return nil, err
+        }
+        if delivered[eventID] == nil {
+            delivered[eventID] = map[string]bool{}
+        }
+        delivered[eventID][handler] = true
+    }
+    return delivered, rows.Err()
+}
+
+// dispatch runs the subscribers that haven't handled event yet, then marks
+// it published or schedules a retry
+func (o *Outbox) dispatch(ctx context.Context, event *Event, delivered map[string]bool, result *DispatchResult) error {
+    var failures []error
+    for _, sub := range o.subscribersOf(event.Type) {
+        if delivered[sub.name] {
+            continue
+        }
+
+        if err := o.handle(ctx, sub, event); err != nil {
+            log.Printf("Outbox handler %s failed for event %d (%s): %v", sub.name, event.ID, event.Type, err)
+            failures = append(failures, fmt.Errorf("%s: %w", sub.name, err))
+            continue
+        }
+
+        if _, err := o.db.ExecContext(ctx, `
+            INSERT INTO outbox_deliveries (event_id, handler) VALUES ($1, $2)
+            ON CONFLICT DO NOTHING`, event.ID, sub.name); err != nil {
+            return fmt.Errorf("failed to record outbox delivery: %w", err)
+        }
+    }
+
+    if len(failures) == 0 {
+        result.Published++
+        eventsTotal.WithLabelValues(event.Type, "published").Inc()
+        _, err := o.db.ExecContext(ctx, `UPDATE outbox_events SET published_at = NOW(), last_error = NULL WHERE id = $1`, event.ID)
+        return err
+    }
+
+    lastError := errors.Join(failures...).Error()
+    if event.Attempts >= maxAttempts {
+        result.Failed++
+        eventsTotal.WithLabelValues(event.Type, "failed").Inc()
+        _, err := o.db.ExecContext(ctx, `UPDATE outbox_events SET failed_at = NOW(), last_error = $2 WHERE id = $1`, event.ID, lastError)
+        return err
+    }
+
+    result.Retried++
+    eventsTotal.WithLabelValues(event.Type, "retried").Inc()
+    _, err := o.db.ExecContext(ctx, `UPDATE outbox_events SET available_at = $2, last_error = $3 WHERE id = $1`,
+        event.ID, time.Now().Add(retryBackoff(event.Attempts)), lastError)
+    return err
+}
+
+// handle calls one subscriber, turning a panic into an error so one bad
+// handler can't stop the dispatcher
+func (o *Outbox) handle(ctx context.Context, sub subscriber, event *Event) (err error) {
+    ctx, cancel := context.WithTimeout(ctx, handlerTimeout)
+    defer cancel()
+
+    defer func() {
+        if p := recover(); p != nil {
+            err = fmt.Errorf("panic: %v", p)
+        }
+    }()
+    return sub.handler(ctx, event)
+}
+
+// Purge deletes published events older than retention, and returns how
+// many were deleted. Failed events are kept until someone looks at them.
+func (o *Outbox) Purge(ctx context.Context, retention time.Duration) (int64, error) {
+    result, err := o.db.ExecContext(ctx, `
+        DELETE FROM outbox_events
+        WHERE published_at IS NOT NULL AND published_at < $1`, time.Now().Add(-retention))
+    if err != nil {
+        return 0, fmt.Errorf("failed to purge outbox events: %w", err)
+    }
+    return result.RowsAffected()
+}
+
+// retryBackoff is the wait before the retry that follows the given attempt
+func retryBackoff(attempts int) time.Duration {
+    backoff := retryBaseBackoff
+    for i := 1; i < attempts; i++ {
+        backoff *= 2
+        if backoff >= retryMaxBackoff {
+            return retryMaxBackoff
+        }
+    }
+    return backoff
+}
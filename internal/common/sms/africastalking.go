@@ -0,0 +1,139 @@
+// internal/common/sms/africastalking.go
+
+package sms
+
+import (
+    "context"
+    "crypto/subtle"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "net/url"
+    "strings"
+    "time"
+)
+
+// AfricasTalkingProvider sends SMS through Africa's Talking. Its delivery
+// reports aren't signed, so the callback URL set in the dashboard carries a
+// ?token= that must match callbackToken.
+type AfricasTalkingProvider struct {
+    username      string
+    apiKey        string
+    senderID      string
+    callbackToken string
+    baseURL       string
+    client        *http.Client
+}
+
+// NewAfricasTalkingProvider creates an Africa's Talking provider. The
+// sandbox API is used for the "sandbox" username.
+func NewAfricasTalkingProvider(username, apiKey, senderID, callbackToken string) *AfricasTalkingProvider {
+    baseURL := "https://api.africastalking.com"
+    if username == "sandbox" {
+        baseURL = "https://api.sandbox.africastalking.com"
+    }
+    return &AfricasTalkingProvider{
+        username:      username,
+        apiKey:        apiKey,
+        senderID:      senderID,
+        callbackToken: callbackToken,
+        baseURL:       baseURL,
+        client:        &http.Client{Timeout: 15 * time.Second},
+    }
+}
+
+func (p *AfricasTalkingProvider) Name() string {
+    return "africastalking"
+}
+
+// Recipient status codes Africa's Talking gives for numbers it won't deliver
+// to: invalid, unsupported type, blacklisted and unroutable
+var africasTalkingRejected = map[int]bool{403: true, 404: true, 406: true, 407: true}
+
+// Send sends a message through Africa's Talking
+func (p *AfricasTalkingProvider) Send(ctx context.Context, msg *Message) (string, error) {
+    form := url.Values{
+        "username": {p.username},
+        "to":       {msg.To},
+        "message":  {msg.Body},
+    }
+    if p.senderID != "" {
+        form.Set("from", p.senderID)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/version1/messaging", strings.NewReader(form.Encode()))
+    if err != nil {
+        return "", err
+    }
+    req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+    req.Header.Set("Accept", "application/json")
+    req.Header.Set("apiKey", p.apiKey)
+
+    resp, err := p.client.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("africa's talking request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+        return "", fmt.Errorf("africa's talking returned %s", resp.Status)
+    }
+
+    var result struct {
+        SMSMessageData struct {
+            Message    string `json:"Message"`
+            Recipients []struct {
+                StatusCode int    `json:"statusCode"`
+                Status     string `json:"status"`
+                MessageID  string `json:"messageId"`
+            } `json:"Recipients"`
+        } `json:"SMSMessageData"`
+    }
+    if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+        return "", fmt.Errorf("failed to decode africa's talking response: %w", err)
+    }
+    if len(result.SMSMessageData.Recipients) == 0 {
+        return "", fmt.Errorf("africa's talking sent nothing: %s", result.SMSMessageData.Message)
+    }
+
+    recipient := result.SMSMessageData.Recipients[0]
+    switch {
+    case recipient.StatusCode >= 100 && recipient.StatusCode <= 102:
+        return recipient.MessageID, nil
+    case africasTalkingRejected[recipient.StatusCode]:
+        return "", fmt.Errorf("%w: %s", ErrRejected, recipient.Status)
+    default:
+        return "", fmt.Errorf("africa's talking failed to send: %s", recipient.Status)
+    }
+}
+
+// ParseCallback reads an Africa's Talking delivery report, a form post
+func (p *AfricasTalkingProvider) ParseCallback(req *CallbackRequest) (*StatusUpdate, error) {
+    token := req.Query.Get("token")
+    if p.callbackToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(p.callbackToken)) != 1 {
+        return nil, ErrInvalidSignature
+    }
+
+    form, err := url.ParseQuery(string(req.Payload))
+    if err != nil {
+        return nil, fmt.Errorf("invalid delivery report: %w", err)
+    }
+
+    update := &StatusUpdate{MessageID: form.Get("id")}
+    switch form.Get("status") {
+    case "Success":
+        update.Status = StatusDelivered
+    case "Sent":
+        update.Status = StatusSent
+    case "Failed", "Rejected", "AbsentSubscriber", "Expired":
+        update.Status = StatusFailed
+        update.Error = form.Get("status")
+        if reason := form.Get("failureReason"); reason != "" {
+            update.Error += ": " + reason
+        }
+    default:
+        // Submitted, Buffered
+        return nil, nil
+    }
+    return update, nil
+}
@@ -0,0 +1,229 @@
+// internal/common/sms/router.go
+// Provider selection and failover
+
+package sms
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "math"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+// AnyNumber is the cost prefix that matches every number
+const AnyNumber = "*"
+
+var sendAttemptsTotal = promauto.NewCounterVec(
+    prometheus.CounterOpts{
+        Name: "sms_send_attempts_total",
+        Help: "Total number of SMS send attempts by provider and outcome",
+    },
+    []string{"provider", "outcome"},
+)
+
+// Route sends numbers starting with Prefix (a calling code such as +234)
+// through Providers, in order
+type Route struct {
+    Prefix    string
+    Providers []string
+}
+
+// Cost is what a provider charges per message to numbers starting with
+// Prefix, or to any number for AnyNumber
+type Cost struct {
+    Provider string
+    Prefix   string
+    Price    float64
+}
+
+// Router sends messages through the registered providers. A provider that
+// errors is passed over for the cooldown, unless every other one has failed
+// too.
+type Router struct {
+    providers map[string]Provider
+    order     []string
+    routes    []Route
+    costs     []Cost
+    cooldown  time.Duration
+
+    mu        sync.Mutex
+    downUntil map[string]time.Time
+}
+
+// NewRouter creates a router with no providers
+func NewRouter(cooldown time.Duration) *Router {
+    return &Router{
+        providers: make(map[string]Provider),
+        cooldown:  cooldown,
+        downUntil: make(map[string]time.Time),
+    }
+}
+
+// Register adds a provider. Providers are tried in the order they were
+// registered when neither routes nor costs decide.
+func (r *Router) Register(provider Provider) {
+    if _, ok := r.providers[provider.Name()]; !ok {
+        r.order = append(r.order, provider.Name())
+    }
+    r.providers[provider.Name()] = provider
+}
+
+// SetRoutes replaces the per-country routes. Providers they name that
+// aren't registered are skipped.
+func (r *Router) SetRoutes(routes []Route) {
+    r.routes = routes
+}
+
+// SetCosts replaces the price list used for numbers without a route
+func (r *Router) SetCosts(costs []Cost) {
+    r.costs = costs
+}
+
+// Providers returns the registered providers' names
+func (r *Router) Providers() []string {
+    return append([]string(nil), r.order...)
+}
+
+// Send tries the providers for msg.To in turn until one accepts it. The
+// error wraps ErrRejected when every provider rejected the message.
+func (r *Router) Send(ctx context.Context, msg *Message) (*Result, error) {
+    candidates := r.candidates(msg.To)
+    if len(candidates) == 0 {
+        return nil, ErrNoProviders
+    }
+
+    var errs []error
+    rejected := true
+    for _, provider := range candidates {
+        if err := ctx.Err(); err != nil {
+            return nil, err
+        }
+
+        messageID, err := provider.Send(ctx, msg)
+        if err == nil {
+            r.markUp(provider.Name())
+            sendAttemptsTotal.WithLabelValues(provider.Name(), "sent").Inc()
+            return &Result{Provider: provider.Name(), MessageID: messageID}, nil
+        }
+
+        errs = append(errs, fmt.Errorf("%s: %w", provider.Name(), err))
+        if errors.Is(err, ErrRejected) {
+            // The number, not the provider, is the problem; another
+            // provider may still reach it
+            sendAttemptsTotal.WithLabelValues(provider.Name(), "rejected").Inc()
+            continue
+        }
+
+        rejected = false
+        r.markDown(provider.Name())
+        sendAttemptsTotal.WithLabelValues(provider.Name(), "error").Inc()
+        log.Printf("SMS provider %s failed, failing over: %v", provider.Name(), err)
+    }
+
+    err := errors.Join(errs...)
+    if rejected {
+        return nil, fmt.Errorf("%w: %v", ErrRejected, err)
+    }
+    return nil, err
+}
+
+// ParseCallback verifies and normalizes a named provider's status callback
+func (r *Router) ParseCallback(provider string, req *CallbackRequest) (*StatusUpdate, error) {
+    p, ok := r.providers[provider]
+    if !ok {
+        return nil, ErrUnknownProvider
+    }
+
+    update, err := p.ParseCallback(req)
+    if err != nil || update == nil {
+        return nil, err
+    }
+    update.Provider = provider
+    return update, nil
+}
+
+// candidates returns the providers to try for a number: those its route
+// names, or else every provider from cheapest to dearest. Providers cooling
+// down after an error go last.
+func (r *Router) candidates(to string) []Provider {
+    var names []string
+    if route := r.route(to); route != nil {
+        names = route.Providers
+    } else {
+        names = append([]string(nil), r.order...)
+        sort.SliceStable(names, func(i, j int) bool {
+            return r.price(names[i], to) < r.price(names[j], to)
+        })
+    }
+
+    now := time.Now()
+    r.mu.Lock()
+    defer r.mu.Unlock()
+
+    var healthy, down []Provider
+    for _, name := range names {
+        provider, ok := r.providers[name]
+        if !ok {
+            continue
+        }
+        if now.Before(r.downUntil[name]) {
+            down = append(down, provider)
+        } else {
+            healthy = append(healthy, provider)
+        }
+    }
+    return append(healthy, down...)
+}
+
+// route returns the route with the longest prefix matching to, if any
+func (r *Router) route(to string) *Route {
+    var best *Route
+    for i := range r.routes {
+        route := &r.routes[i]
+        if strings.HasPrefix(to, route.Prefix) && (best == nil || len(route.Prefix) > len(best.Prefix)) {
+            best = route
+        }
+    }
+    return best
+}
+
+// price is what provider charges for to, by its longest matching prefix.
+// Providers without a price sort last.
+func (r *Router) price(provider, to string) float64 {
+    price, matched := math.Inf(1), -1
+    for _, cost := range r.costs {
+        if cost.Provider != provider {
+            continue
+        }
+        length := len(cost.Prefix)
+        if cost.Prefix == AnyNumber {
+            length = 0
+        } else if !strings.HasPrefix(to, cost.Prefix) {
+            continue
+        }
+        if length > matched {
+            price, matched = cost.Price, length
+        }
+    }
+    return price
+}
+
+func (r *Router) markDown(provider string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    r.downUntil[provider] = time.Now().Add(r.cooldown)
+}
+
+func (r *Router) markUp(provider string) {
+    r.mu.Lock()
+    defer r.mu.Unlock()
+    delete(r.downUntil, provider)
+}
@@ -0,0 +1,76 @@
+// internal/common/sms/sms.go
+// SMS delivery through interchangeable providers
+//
+// Providers register with a Router, which picks the ones to try for each
+// number from per-country routes or, where no route is set, by price, and
+// fails over to the next one when a provider errors. Providers report
+// delivery status back to a callback URL; the Router verifies and
+// normalizes those reports for the notification delivery log.
+
+package sms
+
+import (
+    "context"
+    "errors"
+    "net/http"
+    "net/url"
+)
+
+var (
+    // ErrRejected marks a message a provider will never deliver, such as one
+    // to an invalid or blacklisted number. Retrying won't help.
+    ErrRejected = errors.New("message rejected by provider")
+
+    ErrInvalidSignature = errors.New("invalid callback signature")
+    ErrUnknownProvider  = errors.New("unknown SMS provider")
+    ErrNoProviders      = errors.New("no SMS providers configured")
+)
+
+// Status is a message's delivery status as reported by its provider
+type Status string
+
+const (
+    StatusSent      Status = "sent"      // Handed to the carrier
+    StatusDelivered Status = "delivered" // Reached the handset
+    StatusFailed    Status = "failed"    // Will not be delivered
+)
+
+// Message is one SMS. To is an E.164 number such as +2348012345678.
+type Message struct {
+    To   string
+    Body string
+}
+
+// Result says which provider sent a message, under what ID
+type Result struct {
+    Provider  string
+    MessageID string
+}
+
+// CallbackRequest is a delivery status callback as received
+type CallbackRequest struct {
+    Payload []byte
+    Header  http.Header
+    Query   url.Values
+}
+
+// StatusUpdate is a normalized delivery status callback
+type StatusUpdate struct {
+    Provider  string
+    MessageID string
+    Status    Status
+    Error     string // Why the message failed, if it did
+}
+
+// Provider is an SMS provider
+type Provider interface {
+    Name() string
+
+    // Send sends a message and returns the provider's ID for it. Failures
+    // that retrying can't fix wrap ErrRejected.
+    Send(ctx context.Context, msg *Message) (string, error)
+
+    // ParseCallback verifies a delivery status callback and normalizes it.
+    // Intermediate statuses the log doesn't track come back as nil.
+    ParseCallback(req *CallbackRequest) (*StatusUpdate, error)
+}
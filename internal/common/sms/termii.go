@@ -0,0 +1,124 @@
+// internal/common/sms/termii.go
+
+package sms
+
+import (
+    "bytes"
+    "context"
+    "crypto/hmac"
+    "crypto/sha512"
+    "encoding/hex"
+    "encoding/json"
+    "fmt"
+    "net/http"
+    "strings"
+    "time"
+)
+
+// DefaultTermiiURL is Termii's API for accounts without a dedicated one
+const DefaultTermiiURL = "https://api.ng.termii.com"
+
+// TermiiProvider sends SMS through Termii. Delivery reports are set up in
+// the Termii dashboard and signed with the account's secret key.
+type TermiiProvider struct {
+    apiKey    string
+    senderID  string
+    secretKey string
+    baseURL   string
+    client    *http.Client
+}
+
+// NewTermiiProvider creates a Termii provider. baseURL may be empty for
+// DefaultTermiiURL.
+func NewTermiiProvider(apiKey, senderID, secretKey, baseURL string) *TermiiProvider {
+    if baseURL == "" {
+        baseURL = DefaultTermiiURL
+    }
+    return &TermiiProvider{
+        apiKey:    apiKey,
+        senderID:  senderID,
+        secretKey: secretKey,
+        baseURL:   strings.TrimRight(baseURL, "/"),
+        client:    &http.Client{Timeout: 15 * time.Second},
+    }
+}
+
+func (p *TermiiProvider) Name() string {
+    return "termii"
+}
+
+// Send sends a message through Termii's generic route
+func (p *TermiiProvider) Send(ctx context.Context, msg *Message) (string, error) {
+    body, err := json.Marshal(map[string]string{
+        "api_key": p.apiKey,
+        "to":      strings.TrimPrefix(msg.To, "+"),
+        "from":    p.senderID,
+        "sms":     msg.Body,
+        "type":    "plain",
+        "channel": "generic",
+    })
+    if err != nil {
+        return "", err
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.baseURL+"/api/sms/send", bytes.NewReader(body))
+    if err != nil {
+        return "", err
+    }
+    req.Header.Set("Content-Type", "application/json")
+
+    resp, err := p.client.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("termii request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    var result struct {
+        MessageID string `json:"message_id"`
+        Message   string `json:"message"`
+    }
+    json.NewDecoder(resp.Body).Decode(&result)
+
+    switch {
+    case resp.StatusCode == http.StatusOK:
+        return result.MessageID, nil
+    case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity:
+        return "", fmt.Errorf("%w: %s", ErrRejected, result.Message)
+    default:
+        return "", fmt.Errorf("termii returned %s: %s", resp.Status, result.Message)
+    }
+}
+
+// ParseCallback reads a Termii delivery report, JSON signed with
+// HMAC-SHA512 in the X-Termii-Signature header
+func (p *TermiiProvider) ParseCallback(req *CallbackRequest) (*StatusUpdate, error) {
+    mac := hmac.New(sha512.New, []byte(p.secretKey))
+    mac.Write(req.Payload)
+    signature, err := hex.DecodeString(req.Header.Get("X-Termii-Signature"))
+    if p.secretKey == "" || err != nil || !hmac.Equal(signature, mac.Sum(nil)) {
+        return nil, ErrInvalidSignature
+    }
+
+    var report struct {
+        MessageID string `json:"message_id"`
+        Status    string `json:"status"`
+    }
+    if err := json.Unmarshal(req.Payload, &report); err != nil {
+        return nil, fmt.Errorf("invalid delivery report: %w", err)
+    }
+
+    update := &StatusUpdate{MessageID: report.MessageID}
+    switch status := strings.ToLower(report.Status); {
+    case status == "delivered":
+        update.Status = StatusDelivered
+    case status == "message sent" || status == "sent":
+        update.Status = StatusSent
+    case strings.Contains(status, "fail"), strings.Contains(status, "reject"),
+        strings.Contains(status, "expired"), strings.Contains(status, "dnd"):
+        update.Status = StatusFailed
+        update.Error = report.Status
+    default:
+        return nil, nil
+    }
+    return update, nil
+}
@@ -0,0 +1,100 @@
+// internal/common/sms/twilio.go
+
+package sms
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "net/url"
+
+    "github.com/twilio/twilio-go"
+    "github.com/twilio/twilio-go/client"
+    twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+)
+
+// TwilioProvider sends SMS through Twilio. Status callbacks are requested
+// per message and signed with the auth token.
+type TwilioProvider struct {
+    client      *twilio.RestClient
+    validator   client.RequestValidator
+    from        string
+    callbackURL string
+}
+
+// NewTwilioProvider creates a Twilio provider. callbackURL is where Twilio
+// posts delivery status; leave it empty to not ask for callbacks.
+func NewTwilioProvider(accountSID, authToken, from, callbackURL string) *TwilioProvider {
+    return &TwilioProvider{
+        client: twilio.NewRestClientWithParams(twilio.ClientParams{
+            Username: accountSID,
+            Password: authToken,
+        }),
+        validator:   client.NewRequestValidator(authToken),
+        from:        from,
+        callbackURL: callbackURL,
+    }
+}
+
+func (p *TwilioProvider) Name() string {
+    return "twilio"
+}
+
+// Send sends a message through Twilio
+func (p *TwilioProvider) Send(ctx context.Context, msg *Message) (string, error) {
+    params := &twilioApi.CreateMessageParams{}
+    params.SetTo(msg.To)
+    params.SetFrom(p.from)
+    params.SetBody(msg.Body)
+    if p.callbackURL != "" {
+        params.SetStatusCallback(p.callbackURL)
+    }
+
+    resp, err := p.client.Api.CreateMessage(params)
+    if err != nil {
+        // Twilio answers 400 for numbers it will never deliver to, such as
+        // invalid or unsubscribed ones
+        var restErr *client.TwilioRestError
+        if errors.As(err, &restErr) && restErr.Status == 400 {
+            return "", fmt.Errorf("%w: %v", ErrRejected, err)
+        }
+        return "", err
+    }
+
+    if resp.Sid == nil {
+        return "", nil
+    }
+    return *resp.Sid, nil
+}
+
+// ParseCallback reads a Twilio status callback, a form post signed in the
+// X-Twilio-Signature header
+func (p *TwilioProvider) ParseCallback(req *CallbackRequest) (*StatusUpdate, error) {
+    form, err := url.ParseQuery(string(req.Payload))
+    if err != nil {
+        return nil, fmt.Errorf("invalid callback body: %w", err)
+    }
+
+    params := make(map[string]string, len(form))
+    for key := range form {
+        params[key] = form.Get(key)
+    }
+    if p.callbackURL == "" || !p.validator.Validate(p.callbackURL, params, req.Header.Get("X-Twilio-Signature")) {
+        return nil, ErrInvalidSignature
+    }
+
+    update := &StatusUpdate{MessageID: form.Get("MessageSid")}
+    switch form.Get("MessageStatus") {
+    case "sent":
+        update.Status = StatusSent
+    case "delivered":
+        update.Status = StatusDelivered
+    case "undelivered", "failed":
+        update.Status = StatusFailed
+        update.Error = "twilio error " + form.Get("ErrorCode")
+    default:
+        // queued, sending, accepted
+        return nil, nil
+    }
+    return update, nil
+}
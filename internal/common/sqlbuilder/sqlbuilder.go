@@ -0,0 +1,179 @@
+// internal/common/sqlbuilder/sqlbuilder.go
+// Builders for SQL that has to be put together at runtime, such as updates
+// of only the fields a client sent, or inserts of a variable number of rows.
+// Values always travel as numbered parameters and column names are checked,
+// so nothing from a request ends up in the SQL text.
+
+package sqlbuilder
+
+import (
+    "fmt"
+    "regexp"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+var identifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// checkIdentifier rejects anything that isn't a plain lowercase table or
+// column name
+func checkIdentifier(name string) error {
+    if !identifier.MatchString(name) {
+        return fmt.Errorf("sqlbuilder: invalid identifier %q", name)
+    }
+    return nil
+}
+
+// Args collects query parameters and hands out their placeholders
+type Args struct {
+    values []interface{}
+}
+
+// Add appends a parameter and returns its placeholder, e.g. $3
+func (a *Args) Add(value interface{}) string {
+    a.values = append(a.values, value)
+    return "$" + strconv.Itoa(len(a.values))
+}
+
+// Values returns the parameters in placeholder order
+func (a *Args) Values() []interface{} {
+    return a.values
+}
+
+// bind replaces each ? in condition with the placeholder of the matching
+// value. Conditions can't use Postgres' ? JSON operators.
+func (a *Args) bind(condition string, values []interface{}) (string, error) {
+    if n := strings.Count(condition, "?"); n != len(values) {
+        return "", fmt.Errorf("sqlbuilder: %q has %d placeholders for %d values", condition, n, len(values))
+    }
+
+    var b strings.Builder
+    i := 0
+    for _, r := range condition {
+        if r == '?' {
+            b.WriteString(a.Add(values[i]))
+            i++
+            continue
+        }
+        b.WriteRune(r)
+    }
+    return b.String(), nil
+}
+
+// Update builds an UPDATE that sets only the columns given to it
+type Update struct {
+    table   string
+    args    Args
+    sets    []string
+    changed int
+    where   []string
+    err     error
+}
+
+// NewUpdate starts an UPDATE of table
+func NewUpdate(table string) *Update {
+    u := &Update{table: table}
+    u.fail(checkIdentifier(table))
+    return u
+}
+
+func (u *Update) fail(err error) {
+    if u.err == nil {
+        u.err = err
+    }
+}
+
+// Set sets column to value
+func (u *Update) Set(column string, value interface{}) *Update {
+    u.fail(checkIdentifier(column))
+    u.sets = append(u.sets, column+" = "+u.args.Add(value))
+    u.changed++
+    return u
+}
+
+// SetMap sets each column in values, in column order so the same fields
+// always produce the same statement
+func (u *Update) SetMap(values map[string]interface{}) *Update {
+    columns := make([]string, 0, len(values))
+    for column := range values {
+        columns = append(columns, column)
+    }
+    sort.Strings(columns)
+
+    for _, column := range columns {
+        u.Set(column, values[column])
+    }
+    return u
+}
+
+// SetSQL sets column to a fixed SQL expression such as NOW(). It doesn't
+// count as a change for Changed.
+func (u *Update) SetSQL(column, expr string) *Update {
+    u.fail(checkIdentifier(column))
+    u.sets = append(u.sets, column+" = "+expr)
+    return u
+}
+
+// Where adds a condition, ANDed with the others. Each ? in condition is
+// bound to the next of values.
+func (u *Update) Where(condition string, values ...interface{}) *Update {
+    bound, err := u.args.bind(condition, values)
+    u.fail(err)
+    u.where = append(u.where, bound)
+    return u
+}
+
+// Changed reports whether any column was given a value with Set
+func (u *Update) Changed() bool {
+    return u.changed > 0
+}
+
+// Build returns the statement and its parameters. An update without a
+// WHERE condition is refused rather than touching every row.
+func (u *Update) Build() (string, []interface{}, error) {
+    if u.err != nil {
+        return "", nil, u.err
+    }
+    if len(u.sets) == 0 {
+        return "", nil, fmt.Errorf("sqlbuilder: update of %s sets nothing", u.table)
+    }
+    if len(u.where) == 0 {
+        return "", nil, fmt.Errorf("sqlbuilder: update of %s has no condition", u.table)
+    }
+
+    query := "UPDATE " + u.table + " SET " + strings.Join(u.sets, ", ") +
+        " WHERE " + strings.Join(u.where, " AND ")
+    return query, u.args.Values(), nil
+}
+
+// Insert builds a multi-row INSERT of rows into columns of table
+func Insert(table string, columns []string, rows [][]interface{}) (string, []interface{}, error) {
+    if err := checkIdentifier(table); err != nil {
+        return "", nil, err
+    }
+    for _, column := range columns {
+        if err := checkIdentifier(column); err != nil {
+            return "", nil, err
+        }
+    }
+    if len(rows) == 0 {
+        return "", nil, fmt.Errorf("sqlbuilder: insert into %s has no rows", table)
+    }
+
+    var args Args
+    tuples := make([]string, len(rows))
+    for i, row := range rows {
+        if len(row) != len(columns) {
+            return "", nil, fmt.Errorf("sqlbuilder: row %d has %d values for %d columns", i, len(row), len(columns))
+        }
+        placeholders := make([]string, len(row))
+        for j, value := range row {
+            placeholders[j] = args.Add(value)
+        }
+        tuples[i] = "(" + strings.Join(placeholders, ", ") + ")"
+    }
+
+    query := "INSERT INTO " + table + " (" + strings.Join(columns, ", ") + ") VALUES " + strings.Join(tuples, ", ")
+    return query, args.Values(), nil
+}
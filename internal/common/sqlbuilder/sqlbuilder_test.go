@@ -0,0 +1,226 @@
+package sqlbuilder
+
+import (
+    "reflect"
+    "strings"
+    "testing"
+)
+
+func TestUpdateBuild(t *testing.T) {
+    tests := []struct {
+        name      string
+        build     func() *Update
+        wantQuery string
+        wantArgs  []interface{}
+        wantErr   string
+    }{
+        {
+            name: "set and where share numbering",
+            build: func() *Update {
+                return NewUpdate("users").Set("bio", "hi").Set("age", 30).Where("id = ?", int64(7))
+            },
+            wantQuery: "UPDATE users SET bio = $1, age = $2 WHERE id = $3",
+            wantArgs:  []interface{}{"hi", 30, int64(7)},
+        },
+        {
+            name: "set map is sorted by column",
+            build: func() *Update {
+                return NewUpdate("users").SetMap(map[string]interface{}{"zodiac": "leo", "age": 30, "bio": "hi"}).Where("id = ?", 1)
+            },
+            wantQuery: "UPDATE users SET age = $1, bio = $2, zodiac = $3 WHERE id = $4",
+            wantArgs:  []interface{}{30, "hi", "leo", 1},
+        },
+        {
+            name: "set sql takes no parameter",
+            build: func() *Update {
+                return NewUpdate("posts").Set("caption", "x").SetSQL("updated_at", "NOW()").Where("id = ?", 2)
+            },
+            wantQuery: "UPDATE posts SET caption = $1, updated_at = NOW() WHERE id = $2",
+            wantArgs:  []interface{}{"x", 2},
+        },
+        {
+            name: "several conditions are ANDed in order",
+            build: func() *Update {
+                return NewUpdate("posts").Set("caption", "x").Where("id = ? AND user_id = ?", 2, 3).Where("deleted_at IS NULL")
+            },
+            wantQuery: "UPDATE posts SET caption = $1 WHERE id = $2 AND user_id = $3 AND deleted_at IS NULL",
+            wantArgs:  []interface{}{"x", 2, 3},
+        },
+        {
+            name:    "nothing set",
+            build:   func() *Update { return NewUpdate("users").Where("id = ?", 1) },
+            wantErr: "sets nothing",
+        },
+        {
+            name:    "empty set map",
+            build:   func() *Update { return NewUpdate("users").SetMap(map[string]interface{}{}).Where("id = ?", 1) },
+            wantErr: "sets nothing",
+        },
+        {
+            name:    "no condition",
+            build:   func() *Update { return NewUpdate("users").Set("bio", "hi") },
+            wantErr: "has no condition",
+        },
+        {
+            name:    "placeholder count mismatch",
+            build:   func() *Update { return NewUpdate("users").Set("bio", "hi").Where("id = ? AND age > ?", 1) },
+            wantErr: "2 placeholders for 1 values",
+        },
+        {
+            name:    "invalid table",
+            build:   func() *Update { return NewUpdate("users; DROP TABLE users").Set("bio", "hi").Where("id = ?", 1) },
+            wantErr: "invalid identifier",
+        },
+        {
+            name:    "invalid column",
+            build:   func() *Update { return NewUpdate("users").Set(`bio" = 'x'; --`, "hi").Where("id = ?", 1) },
+            wantErr: "invalid identifier",
+        },
+        {
+            name:    "invalid set map column",
+            build:   func() *Update { return NewUpdate("users").SetMap(map[string]interface{}{"Bio": "hi"}).Where("id = ?", 1) },
+            wantErr: "invalid identifier",
+        },
+        {
+            name:    "invalid set sql column",
+            build:   func() *Update { return NewUpdate("users").SetSQL("1col", "NOW()").Where("id = ?", 1) },
+            wantErr: "invalid identifier",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            query, args, err := tt.build().Build()
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("Build() error = %v, want one containing %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Build() error = %v", err)
+            }
+            if query != tt.wantQuery {
+                t.Errorf("query = %q, want %q", query, tt.wantQuery)
+            }
+            if !reflect.DeepEqual(args, tt.wantArgs) {
+                t.Errorf("args = %v, want %v", args, tt.wantArgs)
+            }
+        })
+    }
+}
+
+func TestUpdateChanged(t *testing.T) {
+    if NewUpdate("users").SetSQL("updated_at", "NOW()").Changed() {
+        t.Error("SetSQL alone counted as a change")
+    }
+    if !NewUpdate("users").Set("bio", "hi").Changed() {
+        t.Error("Set didn't count as a change")
+    }
+}
+
+func TestInsert(t *testing.T) {
+    tests := []struct {
+        name      string
+        table     string
+        columns   []string
+        rows      [][]interface{}
+        wantQuery string
+        wantArgs  []interface{}
+        wantErr   string
+    }{
+        {
+            name:      "one row",
+            table:     "post_media",
+            columns:   []string{"post_id", "media_url"},
+            rows:      [][]interface{}{{1, "a.jpg"}},
+            wantQuery: "INSERT INTO post_media (post_id, media_url) VALUES ($1, $2)",
+            wantArgs:  []interface{}{1, "a.jpg"},
+        },
+        {
+            name:    "rows are numbered in row then column order",
+            table:   "post_media",
+            columns: []string{"post_id", "media_url", "position"},
+            rows: [][]interface{}{
+                {1, "a.jpg", 0},
+                {1, "b.jpg", 1},
+                {1, "c.mp4", 2},
+            },
+            wantQuery: "INSERT INTO post_media (post_id, media_url, position) VALUES ($1, $2, $3), ($4, $5, $6), ($7, $8, $9)",
+            wantArgs:  []interface{}{1, "a.jpg", 0, 1, "b.jpg", 1, 1, "c.mp4", 2},
+        },
+        {
+            name:    "no rows",
+            table:   "post_media",
+            columns: []string{"post_id"},
+            wantErr: "has no rows",
+        },
+        {
+            name:    "row too short",
+            table:   "post_media",
+            columns: []string{"post_id", "media_url"},
+            rows:    [][]interface{}{{1, "a.jpg"}, {2}},
+            wantErr: "row 1 has 1 values for 2 columns",
+        },
+        {
+            name:    "invalid table",
+            table:   "post-media",
+            columns: []string{"post_id"},
+            rows:    [][]interface{}{{1}},
+            wantErr: "invalid identifier",
+        },
+        {
+            name:    "invalid column",
+            table:   "post_media",
+            columns: []string{"post_id", "url) VALUES (1); --"},
+            rows:    [][]interface{}{{1, "x"}},
+            wantErr: "invalid identifier",
+        },
+    }
+
+    for _, tt := range tests {
+        t.Run(tt.name, func(t *testing.T) {
+            query, args, err := Insert(tt.table, tt.columns, tt.rows)
+            if tt.wantErr != "" {
+                if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+                    t.Fatalf("Insert() error = %v, want one containing %q", err, tt.wantErr)
+                }
+                return
+            }
+            if err != nil {
+                t.Fatalf("Insert() error = %v", err)
+            }
+            if query != tt.wantQuery {
+                t.Errorf("query = %q, want %q", query, tt.wantQuery)
+            }
+            if !reflect.DeepEqual(args, tt.wantArgs) {
+                t.Errorf("args = %v, want %v", args, tt.wantArgs)
+            }
+        })
+    }
+}
+
+func TestArgsAdd(t *testing.T) {
+    var args Args
+    for i, want := range []string{"$1", "$2", "$3"} {
+        if got := args.Add(i); got != want {
+            t.Errorf("Add #%d = %q, want %q", i, got, want)
+        }
+    }
+    if !reflect.DeepEqual(args.Values(), []interface{}{0, 1, 2}) {
+        t.Errorf("Values() = %v", args.Values())
+    }
+}
+
+func TestCheckIdentifier(t *testing.T) {
+    for _, name := range []string{"users", "_hidden", "post_media2"} {
+        if err := checkIdentifier(name); err != nil {
+            t.Errorf("checkIdentifier(%q) = %v, want nil", name, err)
+        }
+    }
+    for _, name := range []string{"", "Users", "2fa", "user id", "users.id", `"users"`, "id;--"} {
+        if err := checkIdentifier(name); err == nil {
+            t.Errorf("checkIdentifier(%q) accepted an invalid name", name)
+        }
+    }
+}
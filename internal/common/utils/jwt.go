@@ -18,6 +18,12 @@ type JWTClaims struct {
     Email    string `json:"email"`
     Username string `json:"username"`
     Type     string `json:"type"` // "access" or "refresh"
+    // SessionID ties the token to its row in sessions so it can be revoked.
+    // Zero for tokens issued before sessions were tracked.
+    SessionID int64 `json:"sid"`
+    // ImpersonatorID is the admin acting as this user in a support session,
+    // or zero
+    ImpersonatorID int64 `json:"imp,omitempty"`
     // Standard JWT claims
     ExpiresAt int64  `json:"exp"`
     IssuedAt  int64  `json:"iat"`
@@ -29,17 +35,22 @@ type JWTClaims struct {
 // GenerateJWT creates a new JWT token
 func GenerateJWT(claims *JWTClaims, secret string) (string, error) {
     // Create token with claims
-    token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+    mapClaims := jwt.MapClaims{
         "user_id":  fmt.Sprintf("%d", claims.UserID), // Convert to string
         "email":    claims.Email,
         "username": claims.Username,
         "type":     claims.Type,
+        "sid":      claims.SessionID,
         "exp":      claims.ExpiresAt,
         "iat":      claims.IssuedAt,
         "nbf":      claims.NotBefore,
         "iss":      claims.Issuer,
         "sub":      claims.Subject,
-    })
+    }
+    if claims.ImpersonatorID != 0 {
+        mapClaims["imp"] = claims.ImpersonatorID
+    }
+    token := jwt.NewWithClaims(jwt.SigningMethodHS256, mapClaims)
     
     // Sign token with secret
     tokenString, err := token.SignedString([]byte(secret))
@@ -83,11 +94,14 @@ func ValidateJWT(tokenString string, secret string) (*JWTClaims, error) {
             Email:     getStringClaim(claims, "email"),
             Username:  getStringClaim(claims, "username"),
             Type:      getStringClaim(claims, "type"),
+            SessionID: getInt64Claim(claims, "sid"),
             ExpiresAt: getInt64Claim(claims, "exp"),
             IssuedAt:  getInt64Claim(claims, "iat"),
             NotBefore: getInt64Claim(claims, "nbf"),
             Issuer:    getStringClaim(claims, "iss"),
             Subject:   getStringClaim(claims, "sub"),
+
+            ImpersonatorID: getInt64Claim(claims, "imp"),
         }, nil
     }
     
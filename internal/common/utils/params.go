@@ -0,0 +1,22 @@
+// internal/common/utils/params.go
+// Path parameters, read from the gorilla/mux route the request matched
+
+package utils
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// PathParam returns the named path parameter, or "" when the matched route
+// has none
+func PathParam(r *http.Request, name string) string {
+	return mux.Vars(r)[name]
+}
+
+// PathInt64 parses the named path parameter as an ID
+func PathInt64(r *http.Request, name string) (int64, error) {
+	return strconv.ParseInt(PathParam(r, name), 10, 64)
+}
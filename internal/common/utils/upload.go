@@ -0,0 +1,116 @@
+// internal/common/utils/upload.go
+// Size-capped multipart uploads. Files are spilled to temp files or
+// streamed, never held whole in memory.
+
+package utils
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// formOverhead is allowed on top of an upload's limit for part headers
+	// and the form's other fields
+	formOverhead = 1 << 20
+
+	// multipartMemory is how much of a parsed form is kept in memory; file
+	// parts past it are written to temp files
+	multipartMemory = 1 << 20
+)
+
+// ErrUploadTooLarge is returned when an upload goes over its limit
+var ErrUploadTooLarge = errors.New("upload too large")
+
+// ParseUpload caps the request body at maxBytes, plus room for the other
+// form fields, and parses the multipart form with files written to temp
+// files. It returns ErrUploadTooLarge when the body is over the cap and
+// http.ErrNotMultipart when the request isn't a multipart form.
+func ParseUpload(w http.ResponseWriter, r *http.Request, maxBytes int64) error {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+formOverhead)
+	if err := r.ParseMultipartForm(multipartMemory); err != nil {
+		if IsTooLarge(err) {
+			return ErrUploadTooLarge
+		}
+		return err
+	}
+	return nil
+}
+
+// StreamedFile is a file part read straight off the request body
+type StreamedFile struct {
+	io.Reader
+	Filename    string
+	ContentType string // Sniffed from the first 512 bytes
+}
+
+// StreamUpload caps the request body like ParseUpload, then skips ahead to
+// the file in the named field and returns it unread so it can be streamed
+// to storage. Reads fail with ErrUploadTooLarge once the file goes over
+// maxBytes. Fields after the file are not parsed, and the file must be read
+// before the handler returns.
+func StreamUpload(w http.ResponseWriter, r *http.Request, field string, maxBytes int64) (*StreamedFile, error) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxBytes+formOverhead)
+	mr, err := r.MultipartReader()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		part, err := mr.NextPart()
+		if err == io.EOF {
+			return nil, http.ErrMissingFile
+		}
+		if err != nil {
+			if IsTooLarge(err) {
+				return nil, ErrUploadTooLarge
+			}
+			return nil, err
+		}
+		if part.FormName() != field || part.FileName() == "" {
+			continue
+		}
+
+		body := bufio.NewReader(&uploadReader{r: part, remaining: maxBytes})
+		head, err := body.Peek(512)
+		if err != nil && err != io.EOF {
+			return nil, err
+		}
+		return &StreamedFile{
+			Reader:      body,
+			Filename:    part.FileName(),
+			ContentType: http.DetectContentType(head),
+		}, nil
+	}
+}
+
+// IsTooLarge reports whether err is ErrUploadTooLarge or came from a body
+// going over its http.MaxBytesReader cap
+func IsTooLarge(err error) bool {
+	var maxErr *http.MaxBytesError
+	return errors.Is(err, ErrUploadTooLarge) || errors.As(err, &maxErr)
+}
+
+// UploadTooLargeMessage is the 413 error message for an upload limit
+func UploadTooLargeMessage(maxBytes int64) string {
+	return fmt.Sprintf("File too large; the limit is %d MB", maxBytes>>20)
+}
+
+// uploadReader reads a file part, failing with ErrUploadTooLarge once the
+// part or the body behind it goes over the limit
+type uploadReader struct {
+	r         io.Reader
+	remaining int64
+}
+
+func (u *uploadReader) Read(p []byte) (int, error) {
+	n, err := u.r.Read(p)
+	u.remaining -= int64(n)
+	if u.remaining < 0 || IsTooLarge(err) {
+		return n, ErrUploadTooLarge
+	}
+	return n, err
+}
@@ -4,54 +4,255 @@
 package utils
 
 import (
-    "fmt"
+    "encoding/json"
+    "errors"
+    "io"
+    "net/http"
+    "reflect"
     "strings"
-    
+    "unicode"
+
     "github.com/go-playground/validator/v10"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
 )
 
-// Global validator instance
-var validate = validator.New()
+// Global validator instance. Fields are reported by their JSON names so
+// clients can match errors to the fields they sent.
+var validate = newValidator()
+
+func newValidator() *validator.Validate {
+    v := validator.New()
+    v.RegisterTagNameFunc(func(f reflect.StructField) string {
+        name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]
+        if name == "-" {
+            return ""
+        }
+        if name == "" {
+            return f.Name
+        }
+        return name
+    })
+    // language accepts the codes there are message catalogs for
+    v.RegisterValidation("language", func(fl validator.FieldLevel) bool {
+        return i18n.IsSupported(fl.Field().String())
+    })
+    return v
+}
+
+// maxBodySize caps JSON request bodies decoded by DecodeAndValidate
+const maxBodySize = 1 << 20 // 1MB
+
+// FieldError describes one invalid field in a request body
+type FieldError struct {
+    Field   string `json:"field"`
+    Code    string `json:"code"` // The failed rule, e.g. "required" or "max"
+    Param   string `json:"param,omitempty"`
+    Message string `json:"message"`
+}
 
-// ValidateStruct validates a struct based on its tags
+// ValidationError is returned when a request fails validation
+type ValidationError struct {
+    Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+    messages := make([]string, len(e.Fields))
+    for i, f := range e.Fields {
+        messages[i] = f.Message
+    }
+    return strings.Join(messages, ", ")
+}
+
+// ValidationErrorResponse is the body sent for a request that fails validation
+type ValidationErrorResponse struct {
+    Success bool         `json:"success"`
+    Error   string       `json:"error"`
+    Fields  []FieldError `json:"fields"`
+}
+
+// ValidateStruct validates a struct based on its tags. Messages are in English;
+// handlers should prefer DecodeAndValidate, which localizes them.
 func ValidateStruct(s interface{}) error {
+    return validateStruct(s, i18n.Default)
+}
+
+func validateStruct(s interface{}, lang string) error {
     err := validate.Struct(s)
-    if err != nil {
-        // Format validation errors into readable messages
-        var errors []string
-        for _, err := range err.(validator.ValidationErrors) {
-            errors = append(errors, formatFieldError(err))
-        }
-        return fmt.Errorf(strings.Join (errors, ", "))
+    if err == nil {
+        return nil
+    }
+
+    var fieldErrs validator.ValidationErrors
+    if !errors.As(err, &fieldErrs) {
+        return err
+    }
+
+    t := reflect.TypeOf(s)
+    for t.Kind() == reflect.Ptr {
+        t = t.Elem()
+    }
+
+    verr := &ValidationError{Fields: make([]FieldError, 0, len(fieldErrs))}
+    for _, fe := range fieldErrs {
+        param := fieldParam(fe, t)
+        verr.Fields = append(verr.Fields, FieldError{
+            Field:   fieldPath(fe),
+            Code:    fe.Tag(),
+            Param:   param,
+            Message: formatFieldError(fe, param, lang),
+        })
+    }
+    return verr
+}
+
+// DecodeAndValidate decodes a JSON request body into dst and validates it
+// against its struct tags. On failure it writes a 400 response, with
+// field-level errors in the language from Accept-Language, and returns false.
+//
+//	var req CreatePostRequest
+//	if !utils.DecodeAndValidate(w, r, &req) {
+//	    return
+//	}
+func DecodeAndValidate(w http.ResponseWriter, r *http.Request, dst interface{}) bool {
+    lang := i18n.FromRequest(r)
+
+    if err := decodeJSON(w, r, dst); err != nil {
+        ErrorResponse(w, i18n.T(lang, "validation.invalid_body", nil), http.StatusBadRequest)
+        return false
+    }
+
+    if err := validateStruct(dst, lang); err != nil {
+        RespondWithValidationError(w, r, err)
+        return false
+    }
+    return true
+}
+
+// Validate validates an already decoded request, such as one built from form
+// or query values, and writes a 400 response if it's invalid
+func Validate(w http.ResponseWriter, r *http.Request, req interface{}) bool {
+    if err := validateStruct(req, i18n.FromRequest(r)); err != nil {
+        RespondWithValidationError(w, r, err)
+        return false
+    }
+    return true
+}
+
+// RespondWithValidationError writes a 400 response for err. A *ValidationError
+// is sent with its field errors; anything else as a plain error message.
+func RespondWithValidationError(w http.ResponseWriter, r *http.Request, err error) {
+    var verr *ValidationError
+    if !errors.As(err, &verr) {
+        ErrorResponse(w, err.Error(), http.StatusBadRequest)
+        return
+    }
+
+    RespondWithJSON(w, http.StatusBadRequest, ValidationErrorResponse{
+        Success: false,
+        Error:   i18n.T(i18n.FromRequest(r), "validation.failed", nil),
+        Fields:  verr.Fields,
+    })
+}
+
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst interface{}) error {
+    if r.Body == nil {
+        return io.EOF
+    }
+
+    dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+    if err := dec.Decode(dst); err != nil {
+        return err
+    }
+    // Reject trailing data after the JSON value
+    if dec.More() {
+        return errors.New("request body must contain a single JSON value")
     }
     return nil
 }
 
+// fieldPath returns the JSON path of a field, e.g. "interests[2]" or
+// "location.latitude", without the top-level struct name
+func fieldPath(fe validator.FieldError) string {
+    ns := fe.Namespace()
+    if i := strings.Index(ns, "."); i >= 0 {
+        return ns[i+1:]
+    }
+    return fe.Field()
+}
+
+// fieldParam returns the rule's parameter, with references to other fields
+// (eqfield=Password) given by their JSON names
+func fieldParam(fe validator.FieldError, t reflect.Type) string {
+    param := fe.Param()
+    switch fe.Tag() {
+    case "eqfield", "required_without", "required_if", "required_with":
+        // required_if's parameter is "Field value"; only the field is named
+        if i := strings.IndexByte(param, ' '); i >= 0 {
+            param = param[:i]
+        }
+        param = jsonName(t, param)
+    }
+    return param
+}
+
 // formatFieldError converts validator errors to human-readable messages
-func formatFieldError(fe validator.FieldError) string {
+func formatFieldError(fe validator.FieldError, param, lang string) string {
     field := fe.Field()
     tag := fe.Tag()
-    
+
+    key := tag
     switch tag {
-    case "required":
-        return fmt.Sprintf("%s is required", field)
-    case "email":
-        return fmt.Sprintf("%s must be a valid email", field)
-    case "min":
-        return fmt.Sprintf("%s must be at least %s characters", field, fe.Param())
-    case "max":
-        return fmt.Sprintf("%s must be at most %s characters", field, fe.Param())
-    case "len":
-        return fmt.Sprintf("%s must be exactly %s characters", field, fe.Param())
-    case "alphanum":
-        return fmt.Sprintf("%s must contain only letters and numbers", field)
-    case "numeric":
-        return fmt.Sprintf("%s must contain only numbers", field)
-    case "eqfield":
-        return fmt.Sprintf("%s must match %s", field, fe.Param())
-    case "e164":
-        return fmt.Sprintf("%s must be a valid phone number (E.164 format)", field)
-    default:
-        return fmt.Sprintf("%s is invalid", field)
-    }
-}
\ No newline at end of file
+    case "min", "max", "len":
+        // The meaning depends on the field type: characters, items or a value
+        switch fe.Kind() {
+        case reflect.String:
+            key = tag + "_string"
+        case reflect.Slice, reflect.Array, reflect.Map:
+            key = tag + "_items"
+        default:
+            key = tag + "_number"
+        }
+    case "oneof":
+        param = strings.Join(strings.Fields(param), ", ")
+    case "language":
+        param = strings.Join(i18n.Supported(), ", ")
+    }
+
+    if _, ok := i18n.Lookup(lang, "validation."+key); !ok {
+        key = "invalid"
+    }
+    return i18n.T(lang, "validation."+key, i18n.Vars{"field": field, "param": param})
+}
+
+// jsonName returns the JSON name of a field named in a tag parameter
+// (eqfield=Password, required_without=MediaURL). Fields of nested structs
+// aren't found on t and are converted to snake_case instead.
+func jsonName(t reflect.Type, goName string) string {
+    if t.Kind() == reflect.Struct {
+        if f, ok := t.FieldByName(goName); ok {
+            if name := strings.SplitN(f.Tag.Get("json"), ",", 2)[0]; name != "" && name != "-" {
+                return name
+            }
+        }
+    }
+    return snakeCase(goName)
+}
+
+// snakeCase converts a Go field name to snake_case
+func snakeCase(name string) string {
+    runes := []rune(name)
+    var b strings.Builder
+    for i, r := range runes {
+        if unicode.IsUpper(r) {
+            // Start a new word at a lower-to-upper change or at the end of an
+            // acronym ("MediaURL" -> "media_url", "URLPath" -> "url_path")
+            if i > 0 && (unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1]))) {
+                b.WriteByte('_')
+            }
+            r = unicode.ToLower(r)
+        }
+        b.WriteRune(r)
+    }
+    return b.String()
+}
@@ -0,0 +1,172 @@
+// internal/config/auth.go
+// Authentication, password policy and OTP settings
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// defaultJWTSecret is only acceptable outside production
+const defaultJWTSecret = "your-super-secret-key-change-this-in-production"
+
+// AuthConfig configures tokens, two-factor and login protection
+type AuthConfig struct {
+	JWTSecret          string
+	BCryptCost         int
+	AccessTokenExpiry  time.Duration
+	RefreshTokenExpiry time.Duration
+
+	Enable2FA         bool // Require an OTP on every signin
+	EnableOAuth       bool
+	TOTPIssuer        string
+	TOTPEncryptionKey string // Defaults to JWTSecret; changing it invalidates enrolled apps
+
+	// Rate limiting and login anomaly detection
+	LoginAttemptsMax    int // Failed passwords before the account locks
+	LoginAttemptsWindow time.Duration
+	LoginStepUpAfter    int           // Failed passwords before a correct one also needs an OTP
+	AccountLockDuration time.Duration // How long LoginAttemptsMax failures lock an account
+
+	UsernameChangeCooldown time.Duration // Minimum time between username changes
+}
+
+func loadAuth(l *loader) AuthConfig {
+	return AuthConfig{
+		JWTSecret:          l.str("JWT_SECRET", defaultJWTSecret),
+		BCryptCost:         l.int("BCRYPT_COST", 10),
+		AccessTokenExpiry:  l.duration("ACCESS_TOKEN_EXPIRY", time.Hour),
+		RefreshTokenExpiry: l.duration("REFRESH_TOKEN_EXPIRY", 720*time.Hour), // 30 days
+
+		Enable2FA:         l.bool("ENABLE_2FA", false),
+		EnableOAuth:       l.bool("ENABLE_OAUTH", true),
+		TOTPIssuer:        l.str("TOTP_ISSUER", "Kiekky"),
+		TOTPEncryptionKey: l.str("TOTP_ENCRYPTION_KEY", ""),
+
+		LoginAttemptsMax:    l.int("LOGIN_ATTEMPTS_MAX", 5),
+		LoginAttemptsWindow: l.duration("LOGIN_ATTEMPTS_WINDOW", 15*time.Minute),
+		LoginStepUpAfter:    l.int("LOGIN_STEP_UP_AFTER", 3),
+		AccountLockDuration: l.duration("ACCOUNT_LOCK_DURATION", 30*time.Minute),
+
+		UsernameChangeCooldown: l.duration("USERNAME_CHANGE_COOLDOWN", 720*time.Hour), // 30 days
+	}
+}
+
+func (c AuthConfig) validate(production bool) []string {
+	var problems []string
+
+	if production && c.JWTSecret == defaultJWTSecret {
+		problems = append(problems, "JWT_SECRET must be set to a long random value in production")
+	}
+	if c.BCryptCost < 4 || c.BCryptCost > 31 {
+		problems = append(problems, fmt.Sprintf("BCRYPT_COST=%d must be between 4 and 31", c.BCryptCost))
+	}
+	if c.AccessTokenExpiry <= 0 || c.RefreshTokenExpiry <= c.AccessTokenExpiry {
+		problems = append(problems, "ACCESS_TOKEN_EXPIRY must be positive and shorter than REFRESH_TOKEN_EXPIRY")
+	}
+	if c.LoginAttemptsMax < 1 || c.LoginAttemptsWindow <= 0 {
+		problems = append(problems, "LOGIN_ATTEMPTS_MAX and LOGIN_ATTEMPTS_WINDOW must be positive")
+	}
+	if c.LoginStepUpAfter < 0 || c.AccountLockDuration < 0 {
+		problems = append(problems, "LOGIN_STEP_UP_AFTER and ACCOUNT_LOCK_DURATION must not be negative")
+	}
+	if c.UsernameChangeCooldown < 0 {
+		problems = append(problems, "USERNAME_CHANGE_COOLDOWN must not be negative")
+	}
+
+	return problems
+}
+
+// PasswordConfig configures the policy for new passwords
+type PasswordConfig struct {
+	MinLength     int
+	MinScore      int           // zxcvbn-style score, 0-4
+	BreachCheck   bool          // Check new passwords against a breached password range API
+	BreachAPIURL  string        // Pwned Passwords or a self-hosted mirror
+	BreachTimeout time.Duration // Breach check is skipped if it takes longer
+}
+
+func loadPassword(l *loader) PasswordConfig {
+	return PasswordConfig{
+		MinLength:     l.int("PASSWORD_MIN_LENGTH", 8),
+		MinScore:      l.int("PASSWORD_MIN_SCORE", 2),
+		BreachCheck:   l.bool("PASSWORD_BREACH_CHECK", true),
+		BreachAPIURL:  l.str("PASSWORD_BREACH_API_URL", "https://api.pwnedpasswords.com"),
+		BreachTimeout: l.duration("PASSWORD_BREACH_TIMEOUT", 2*time.Second),
+	}
+}
+
+func (c PasswordConfig) validate() []string {
+	var problems []string
+
+	// Request validation already refuses passwords under 8 characters
+	if c.MinLength < 8 || c.MinLength > 100 {
+		problems = append(problems, fmt.Sprintf("PASSWORD_MIN_LENGTH=%d must be between 8 and 100", c.MinLength))
+	}
+	if c.MinScore < 0 || c.MinScore > 4 {
+		problems = append(problems, fmt.Sprintf("PASSWORD_MIN_SCORE=%d must be between 0 and 4", c.MinScore))
+	}
+	if c.BreachCheck && !isAbsoluteURL(c.BreachAPIURL) {
+		problems = append(problems, "PASSWORD_BREACH_API_URL must be an absolute URL, or set PASSWORD_BREACH_CHECK=false")
+	}
+
+	return problems
+}
+
+// OTPConfig configures one-time codes sent by email and SMS
+type OTPConfig struct {
+	Length       int
+	Expiry       time.Duration
+	MaxAttempts  int
+	ResendMax    int // Codes one address or number can be sent per ResendWindow
+	ResendWindow time.Duration
+
+	// Limits across addresses, which need Redis. 0 turns a limit off.
+	MaxPerIP        int           // Codes one client IP can request per ResendWindow
+	MaxPerDevice    int           // Codes one device can request per ResendWindow
+	LockoutAfter    int           // Wrong codes per ResendWindow before the address is locked out
+	LockoutDuration time.Duration // First lockout; each further one in a day doubles
+	MaxLockout      time.Duration
+}
+
+func loadOTP(l *loader) OTPConfig {
+	return OTPConfig{
+		Length:       l.int("OTP_LENGTH", 6),
+		Expiry:       l.duration("OTP_EXPIRY", 10*time.Minute),
+		MaxAttempts:  l.int("MAX_OTP_ATTEMPTS", 5),
+		ResendMax:    l.int("OTP_RESEND_MAX", 3),
+		ResendWindow: l.duration("OTP_RESEND_WINDOW", time.Hour),
+
+		MaxPerIP:        l.int("OTP_MAX_PER_IP", 10),
+		MaxPerDevice:    l.int("OTP_MAX_PER_DEVICE", 5),
+		LockoutAfter:    l.int("OTP_LOCKOUT_AFTER", 5),
+		LockoutDuration: l.duration("OTP_LOCKOUT_DURATION", 15*time.Minute),
+		MaxLockout:      l.duration("OTP_MAX_LOCKOUT", 24*time.Hour),
+	}
+}
+
+func (c OTPConfig) validate() []string {
+	var problems []string
+
+	if c.Length < 4 || c.Length > 8 {
+		problems = append(problems, fmt.Sprintf("OTP_LENGTH=%d must be between 4 and 8", c.Length))
+	}
+	if c.MaxAttempts < 1 || c.MaxAttempts > 10 {
+		problems = append(problems, fmt.Sprintf("MAX_OTP_ATTEMPTS=%d must be between 1 and 10", c.MaxAttempts))
+	}
+	if c.Expiry <= 0 {
+		problems = append(problems, "OTP_EXPIRY must be positive")
+	}
+	if c.ResendMax < 1 || c.ResendWindow <= 0 {
+		problems = append(problems, "OTP_RESEND_MAX and OTP_RESEND_WINDOW must be positive")
+	}
+	if c.MaxPerIP < 0 || c.MaxPerDevice < 0 || c.LockoutAfter < 0 {
+		problems = append(problems, "OTP_MAX_PER_IP, OTP_MAX_PER_DEVICE and OTP_LOCKOUT_AFTER must not be negative")
+	}
+	if c.LockoutAfter > 0 && (c.LockoutDuration <= 0 || c.MaxLockout < c.LockoutDuration) {
+		problems = append(problems, "OTP_LOCKOUT_DURATION must be positive and no longer than OTP_MAX_LOCKOUT")
+	}
+
+	return problems
+}
@@ -0,0 +1,371 @@
+// internal/config/delivery.go
+// Email, SMS and notification delivery settings
+
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// EmailConfig configures outgoing email for OTPs and notifications
+type EmailConfig struct {
+	Provider string // "smtp", "sendgrid" or "mock"
+	From     string
+	FromName string
+
+	// Fallback takes over while Provider is failing, for FailoverCooldown
+	// after each error. Empty for no failover.
+	Fallback         string // "smtp", "sendgrid" or ""
+	FailoverCooldown time.Duration
+
+	SMTP           SMTPConfig
+	SendGridAPIKey string
+
+	// Bounce and complaint webhooks. Each is off until configured.
+	SendGridWebhookPublicKey string // Signed event webhook verification key
+	SESTopicARN              string // SNS topic SES publishes bounces and complaints to
+}
+
+// SMTPConfig configures an SMTP relay
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+}
+
+func loadEmail(l *loader) EmailConfig {
+	return EmailConfig{
+		Provider: l.str("EMAIL_PROVIDER", "smtp"),
+		From:     l.str("EMAIL_FROM", l.str("SMTP_FROM", "noreply@kiekky.com")),
+		FromName: l.str("EMAIL_FROM_NAME", l.str("SMTP_FROM_NAME", "Kiekky")),
+
+		SMTP: SMTPConfig{
+			Host:     l.str("SMTP_HOST", "smtp.gmail.com"),
+			Port:     l.int("SMTP_PORT", 587),
+			Username: l.str("SMTP_USERNAME", l.str("SMTP_USER", "")),
+			Password: l.str("SMTP_PASSWORD", ""),
+		},
+		SendGridAPIKey: l.str("SENDGRID_API_KEY", ""),
+
+		Fallback:         l.str("EMAIL_FALLBACK_PROVIDER", ""),
+		FailoverCooldown: l.duration("EMAIL_FAILOVER_COOLDOWN", time.Minute),
+
+		SendGridWebhookPublicKey: l.str("SENDGRID_WEBHOOK_PUBLIC_KEY", ""),
+		SESTopicARN:              l.str("SES_SNS_TOPIC_ARN", ""),
+	}
+}
+
+func (c EmailConfig) validate(production bool) []string {
+	var problems []string
+
+	switch c.Provider {
+	case "smtp", "sendgrid":
+		problems = append(problems, c.validateProvider("EMAIL_PROVIDER", c.Provider, production)...)
+	case "mock":
+		if production {
+			problems = append(problems, "EMAIL_PROVIDER=mock cannot be used in production; use smtp or sendgrid")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("EMAIL_PROVIDER=%q must be smtp, sendgrid or mock", c.Provider))
+	}
+
+	switch c.Fallback {
+	case "":
+	case c.Provider:
+		problems = append(problems, "EMAIL_FALLBACK_PROVIDER must differ from EMAIL_PROVIDER")
+	case "smtp", "sendgrid":
+		problems = append(problems, c.validateProvider("EMAIL_FALLBACK_PROVIDER", c.Fallback, production)...)
+	default:
+		problems = append(problems, fmt.Sprintf("EMAIL_FALLBACK_PROVIDER=%q must be smtp, sendgrid or empty", c.Fallback))
+	}
+	if c.FailoverCooldown <= 0 {
+		problems = append(problems, "EMAIL_FAILOVER_COOLDOWN must be positive")
+	}
+	if c.From == "" {
+		problems = append(problems, "EMAIL_FROM must be set")
+	}
+
+	return problems
+}
+
+// validateProvider checks the settings a real email provider needs. key is
+// the variable that selected it.
+func (c EmailConfig) validateProvider(key, provider string, production bool) []string {
+	var problems []string
+
+	switch provider {
+	case "smtp":
+		if production && (c.SMTP.Host == "" || c.SMTP.Username == "" || c.SMTP.Password == "") {
+			problems = append(problems, fmt.Sprintf("SMTP_HOST, SMTP_USERNAME and SMTP_PASSWORD are required for %s=smtp in production", key))
+		}
+		if c.SMTP.Port < 1 || c.SMTP.Port > 65535 {
+			problems = append(problems, fmt.Sprintf("SMTP_PORT=%d must be between 1 and 65535", c.SMTP.Port))
+		}
+	case "sendgrid":
+		if production && c.SendGridAPIKey == "" {
+			problems = append(problems, fmt.Sprintf("SENDGRID_API_KEY is required for %s=sendgrid in production", key))
+		}
+	}
+
+	return problems
+}
+
+// SMSConfig configures outgoing SMS for OTPs and notifications
+type SMSConfig struct {
+	Provider string // "twilio", "termii", "africastalking" or "mock"
+
+	// Providers are tried in this order when neither a route nor costs
+	// decide; it defaults to Provider alone. Routes send numbers with a
+	// calling code to set providers; other numbers go to the cheapest
+	// provider by Costs. A provider that errors is passed over for
+	// FailoverCooldown.
+	Providers        []string
+	Routes           []SMSRoute
+	Costs            []SMSCost
+	FailoverCooldown time.Duration
+
+	Twilio         TwilioConfig
+	Termii         TermiiConfig
+	AfricasTalking AfricasTalkingConfig
+}
+
+// SMSRoute sends numbers starting with Prefix through Providers, in order
+type SMSRoute struct {
+	Prefix    string
+	Providers []string
+}
+
+// SMSCost is a provider's price per message to numbers starting with
+// Prefix, or to any number for "*"
+type SMSCost struct {
+	Provider string
+	Prefix   string
+	Price    float64
+}
+
+// TwilioConfig configures the Twilio SMS API
+type TwilioConfig struct {
+	AccountSID string
+	AuthToken  string
+	FromNumber string
+}
+
+// TermiiConfig configures the Termii SMS API. SecretKey verifies its
+// delivery reports.
+type TermiiConfig struct {
+	APIKey    string
+	SenderID  string
+	SecretKey string
+	BaseURL   string
+}
+
+// AfricasTalkingConfig configures the Africa's Talking SMS API.
+// CallbackToken must be in its delivery report URL as ?token=.
+type AfricasTalkingConfig struct {
+	Username      string
+	APIKey        string
+	SenderID      string
+	CallbackToken string
+}
+
+func loadSMS(l *loader) SMSConfig {
+	c := SMSConfig{
+		Provider:         l.str("SMS_PROVIDER", "mock"),
+		FailoverCooldown: l.duration("SMS_FAILOVER_COOLDOWN", time.Minute),
+
+		Twilio: TwilioConfig{
+			AccountSID: l.str("TWILIO_ACCOUNT_SID", ""),
+			AuthToken:  l.str("TWILIO_AUTH_TOKEN", ""),
+			FromNumber: l.str("TWILIO_FROM_NUMBER", l.str("TWILIO_PHONE_NUMBER", "")),
+		},
+		Termii: TermiiConfig{
+			APIKey:    l.str("TERMII_API_KEY", ""),
+			SenderID:  l.str("TERMII_SENDER_ID", ""),
+			SecretKey: l.str("TERMII_SECRET_KEY", ""),
+			BaseURL:   l.str("TERMII_BASE_URL", ""),
+		},
+		AfricasTalking: AfricasTalkingConfig{
+			Username:      l.str("AT_USERNAME", ""),
+			APIKey:        l.str("AT_API_KEY", ""),
+			SenderID:      l.str("AT_SENDER_ID", ""),
+			CallbackToken: l.str("AT_CALLBACK_TOKEN", ""),
+		},
+	}
+	c.Providers = l.list("SMS_PROVIDERS", []string{c.Provider})
+
+	// SMS_ROUTES=+234=termii|africastalking,+254=africastalking
+	for _, entry := range l.list("SMS_ROUTES", nil) {
+		prefix, providers, ok := strings.Cut(entry, "=")
+		if !ok || !strings.HasPrefix(prefix, "+") || providers == "" {
+			l.invalid("SMS_ROUTES", entry, "a route such as +234=termii|twilio")
+			continue
+		}
+		c.Routes = append(c.Routes, SMSRoute{Prefix: prefix, Providers: strings.Split(providers, "|")})
+	}
+
+	// SMS_COSTS=termii:+234=0.0025,twilio:*=0.0079
+	for _, entry := range l.list("SMS_COSTS", nil) {
+		provider, rest, _ := strings.Cut(entry, ":")
+		prefix, price, _ := strings.Cut(rest, "=")
+		value, err := strconv.ParseFloat(price, 64)
+		if provider == "" || prefix == "" || err != nil {
+			l.invalid("SMS_COSTS", entry, "a cost such as termii:+234=0.0025")
+			continue
+		}
+		c.Costs = append(c.Costs, SMSCost{Provider: provider, Prefix: prefix, Price: value})
+	}
+
+	return c
+}
+
+// validate checks the SMS providers; smsRequired is set when SMS
+// notifications or signin OTPs are turned on
+func (c SMSConfig) validate(production, smsRequired bool) []string {
+	var problems []string
+
+	if c.Provider == "mock" || c.Provider == "" {
+		if production && smsRequired {
+			problems = append(problems, "SMS_PROVIDER=mock cannot be used in production while SMS notifications or ENABLE_2FA are on; use twilio, termii or africastalking")
+		}
+		return problems
+	}
+
+	providers := make(map[string]bool)
+	for _, provider := range c.Providers {
+		providers[provider] = true
+
+		switch provider {
+		case "twilio":
+			if smsRequired && (c.Twilio.AccountSID == "" || c.Twilio.AuthToken == "" || c.Twilio.FromNumber == "") {
+				problems = append(problems, "TWILIO_ACCOUNT_SID, TWILIO_AUTH_TOKEN and TWILIO_FROM_NUMBER are required while SMS notifications or ENABLE_2FA are on")
+			}
+		case "termii":
+			if smsRequired && (c.Termii.APIKey == "" || c.Termii.SenderID == "") {
+				problems = append(problems, "TERMII_API_KEY and TERMII_SENDER_ID are required while SMS notifications or ENABLE_2FA are on")
+			}
+		case "africastalking":
+			if smsRequired && (c.AfricasTalking.Username == "" || c.AfricasTalking.APIKey == "") {
+				problems = append(problems, "AT_USERNAME and AT_API_KEY are required while SMS notifications or ENABLE_2FA are on")
+			}
+		default:
+			problems = append(problems, fmt.Sprintf("SMS provider %q must be twilio, termii or africastalking", provider))
+		}
+	}
+
+	for _, route := range c.Routes {
+		for _, provider := range route.Providers {
+			if !providers[provider] {
+				problems = append(problems, fmt.Sprintf("SMS_ROUTES sends %s to %q, which isn't in SMS_PROVIDERS", route.Prefix, provider))
+			}
+		}
+	}
+	if c.FailoverCooldown < 0 {
+		problems = append(problems, "SMS_FAILOVER_COOLDOWN must not be negative")
+	}
+
+	return problems
+}
+
+// NotificationsConfig configures which notification channels are live
+type NotificationsConfig struct {
+	EnableEmail bool // Otherwise emails are logged instead of sent
+	EnablePush  bool
+	EnableSMS   bool
+
+	GroupWindow       time.Duration // How long likes, follows and views collapse into one notification; 0 turns grouping off
+	EnableDigest      bool
+	EnableWeeklyRecap bool
+
+	// Firebase service account for push, as a file or inline JSON
+	FirebaseCredentialsPath string
+	FirebaseCredentialsJSON string
+
+	// IOSPushProvider is "fcm" or "apns": who issued iOS push tokens the app
+	// registers without saying
+	IOSPushProvider string
+
+	// APNs token auth for iOS tokens registered with Apple directly, on when
+	// APNSKeyID is set. The .p8 key is a file or inline PEM.
+	APNSKeyPath    string
+	APNSKey        string
+	APNSKeyID      string
+	APNSTeamID     string
+	APNSBundleID   string
+	APNSProduction bool
+
+	// VAPID key pair for web push, base64url encoded. The public key is
+	// derived from the private one; when it's set too it must match. The
+	// subject is a mailto: or https: contact for push services.
+	VAPIDPrivateKey string
+	VAPIDPublicKey  string
+	VAPIDSubject    string
+}
+
+func loadNotifications(l *loader) NotificationsConfig {
+	return NotificationsConfig{
+		EnableEmail: l.bool("ENABLE_EMAIL_NOTIFICATIONS", false),
+		EnablePush:  l.bool("ENABLE_PUSH_NOTIFICATIONS", false),
+		EnableSMS:   l.bool("ENABLE_SMS_NOTIFICATIONS", false),
+
+		GroupWindow:       l.duration("NOTIFICATION_GROUP_WINDOW", time.Hour),
+		EnableDigest:      l.bool("ENABLE_NOTIFICATION_DIGEST", false),
+		EnableWeeklyRecap: l.bool("ENABLE_WEEKLY_RECAP", true),
+
+		FirebaseCredentialsPath: l.str("FIREBASE_CREDENTIALS_PATH", ""),
+		FirebaseCredentialsJSON: l.str("FIREBASE_CREDENTIALS_JSON", ""),
+
+		IOSPushProvider: l.str("PUSH_IOS_PROVIDER", "fcm"),
+
+		APNSKeyPath:    l.str("APNS_KEY_PATH", ""),
+		APNSKey:        l.str("APNS_KEY", ""),
+		APNSKeyID:      l.str("APNS_KEY_ID", ""),
+		APNSTeamID:     l.str("APNS_TEAM_ID", ""),
+		APNSBundleID:   l.str("APNS_BUNDLE_ID", ""),
+		APNSProduction: l.bool("APNS_PRODUCTION", false),
+
+		VAPIDPrivateKey: l.str("VAPID_PRIVATE_KEY", ""),
+		VAPIDPublicKey:  l.str("VAPID_PUBLIC_KEY", ""),
+		VAPIDSubject:    l.str("VAPID_SUBJECT", "mailto:support@kiekky.com"),
+	}
+}
+
+func (c NotificationsConfig) validate() []string {
+	var problems []string
+
+	if c.EnablePush && c.FirebaseCredentialsPath == "" && c.FirebaseCredentialsJSON == "" {
+		problems = append(problems, "ENABLE_PUSH_NOTIFICATIONS needs FIREBASE_CREDENTIALS_PATH or FIREBASE_CREDENTIALS_JSON")
+	}
+	if c.GroupWindow < 0 {
+		problems = append(problems, "NOTIFICATION_GROUP_WINDOW must not be negative; use 0 to turn grouping off")
+	}
+
+	switch c.IOSPushProvider {
+	case "fcm":
+	case "apns":
+		if c.EnablePush && c.APNSKeyID == "" {
+			problems = append(problems, "PUSH_IOS_PROVIDER=apns needs APNS_KEY_ID")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("PUSH_IOS_PROVIDER must be fcm or apns, got %q", c.IOSPushProvider))
+	}
+	if c.APNSKeyID != "" {
+		if c.APNSKeyPath == "" && c.APNSKey == "" {
+			problems = append(problems, "APNS_KEY_ID needs APNS_KEY_PATH or APNS_KEY")
+		}
+		if c.APNSTeamID == "" || c.APNSBundleID == "" {
+			problems = append(problems, "APNS_KEY_ID needs APNS_TEAM_ID and APNS_BUNDLE_ID")
+		}
+	}
+	if c.VAPIDPublicKey != "" && c.VAPIDPrivateKey == "" {
+		problems = append(problems, "VAPID_PUBLIC_KEY needs VAPID_PRIVATE_KEY")
+	}
+	if !strings.HasPrefix(c.VAPIDSubject, "mailto:") && !strings.HasPrefix(c.VAPIDSubject, "https:") {
+		problems = append(problems, "VAPID_SUBJECT must be a mailto: or https: URL")
+	}
+
+	return problems
+}
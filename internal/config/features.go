@@ -0,0 +1,306 @@
+// internal/config/features.go
+// Profile, discovery, places, stories, messaging and billing settings
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// ProfileConfig configures profiles and profile features
+type ProfileConfig struct {
+	MaxPictureSize      string
+	MaxInterests        int
+	CompletionRequired  bool
+	CompletionThreshold int // Completion percentage that lists a profile in discovery
+	MinAge              int
+	MaxAge              int
+	EnableVerification  bool
+	EnableLocation      bool
+}
+
+func loadProfile(l *loader) ProfileConfig {
+	return ProfileConfig{
+		MaxPictureSize:      l.str("MAX_PROFILE_PICTURE_SIZE", "5MB"),
+		MaxInterests:        l.int("MAX_INTERESTS", 10),
+		CompletionRequired:  l.bool("PROFILE_COMPLETION_REQUIRED", false),
+		CompletionThreshold: l.int("PROFILE_COMPLETION_THRESHOLD", 70),
+		MinAge:              l.int("MIN_AGE", 18),
+		MaxAge:              l.int("MAX_AGE", 100),
+		EnableVerification:  l.bool("ENABLE_PROFILE_VERIFICATION", true),
+		EnableLocation:      l.bool("ENABLE_LOCATION_FEATURES", true),
+	}
+}
+
+func (c ProfileConfig) validate() []string {
+	var problems []string
+
+	if c.MinAge < 13 || c.MinAge > c.MaxAge {
+		problems = append(problems, fmt.Sprintf("MIN_AGE=%d and MAX_AGE=%d must satisfy 13 <= MIN_AGE <= MAX_AGE", c.MinAge, c.MaxAge))
+	}
+	if c.CompletionThreshold < 0 || c.CompletionThreshold > 100 {
+		problems = append(problems, fmt.Sprintf("PROFILE_COMPLETION_THRESHOLD=%d must be between 0 and 100", c.CompletionThreshold))
+	}
+	if c.MaxInterests < 1 || c.MaxInterests > 50 {
+		problems = append(problems, fmt.Sprintf("MAX_INTERESTS=%d must be between 1 and 50", c.MaxInterests))
+	}
+
+	return problems
+}
+
+// DiscoveryConfig configures dating discovery, hotpicks, quotas and boosts
+type DiscoveryConfig struct {
+	NewProfileBoostWindow time.Duration // How long a freshly completed profile is boosted in discovery
+
+	// Daily quotas, reset at the user's local midnight; 0 means unlimited
+	DailySwipeQuota              int
+	PremiumDailySwipeQuota       int
+	DailyDateRequestQuota        int
+	PremiumDailyDateRequestQuota int
+
+	// Spotlight boosts
+	BoostDuration        time.Duration
+	BoostMultiplier      float64
+	MonthlyBoosts        int
+	PremiumMonthlyBoosts int
+
+	// Daily hotpicks, generated at HotpickReadyHour in each user's timezone.
+	// At most HotpickMaxPerGroup of them share a city or age bracket while
+	// there are other candidates.
+	DailyHotpicks      int
+	HotpickReadyHour   int
+	HotpickMaxPerGroup int
+
+	RewindWindow time.Duration // How long after a pass premium users can rewind it; 0 disables rewind
+}
+
+func loadDiscovery(l *loader) DiscoveryConfig {
+	return DiscoveryConfig{
+		NewProfileBoostWindow: l.duration("NEW_PROFILE_BOOST_WINDOW", 72*time.Hour),
+
+		DailySwipeQuota:              l.int("DAILY_SWIPE_QUOTA", 50),
+		PremiumDailySwipeQuota:       l.int("PREMIUM_DAILY_SWIPE_QUOTA", 0),
+		DailyDateRequestQuota:        l.int("DAILY_DATE_REQUEST_QUOTA", 5),
+		PremiumDailyDateRequestQuota: l.int("PREMIUM_DAILY_DATE_REQUEST_QUOTA", 20),
+
+		BoostDuration:        l.duration("BOOST_DURATION", 30*time.Minute),
+		BoostMultiplier:      l.float("BOOST_MULTIPLIER", 3.0),
+		MonthlyBoosts:        l.int("MONTHLY_BOOSTS", 0),
+		PremiumMonthlyBoosts: l.int("PREMIUM_MONTHLY_BOOSTS", 1),
+
+		DailyHotpicks:      l.int("DAILY_HOTPICKS", 10),
+		HotpickReadyHour:   l.int("HOTPICK_READY_HOUR", 9),
+		HotpickMaxPerGroup: l.int("HOTPICK_MAX_PER_GROUP", 3),
+
+		RewindWindow: l.duration("REWIND_WINDOW", 5*time.Minute),
+	}
+}
+
+func (c DiscoveryConfig) validate() []string {
+	var problems []string
+
+	if c.DailySwipeQuota < 0 || c.PremiumDailySwipeQuota < 0 || c.DailyDateRequestQuota < 0 || c.PremiumDailyDateRequestQuota < 0 {
+		problems = append(problems, "daily swipe and date request quotas must not be negative; use 0 for unlimited")
+	}
+	if c.MonthlyBoosts < 0 || c.PremiumMonthlyBoosts < 0 {
+		problems = append(problems, "MONTHLY_BOOSTS and PREMIUM_MONTHLY_BOOSTS must not be negative")
+	}
+	if c.BoostDuration <= 0 || c.BoostMultiplier < 1 {
+		problems = append(problems, "BOOST_DURATION must be positive and BOOST_MULTIPLIER at least 1")
+	}
+	if c.NewProfileBoostWindow < 0 {
+		problems = append(problems, "NEW_PROFILE_BOOST_WINDOW must not be negative")
+	}
+	if c.DailyHotpicks < 1 || c.HotpickMaxPerGroup < 1 {
+		problems = append(problems, "DAILY_HOTPICKS and HOTPICK_MAX_PER_GROUP must be at least 1")
+	}
+	if c.HotpickReadyHour < 0 || c.HotpickReadyHour > 23 {
+		problems = append(problems, fmt.Sprintf("HOTPICK_READY_HOUR=%d must be between 0 and 23", c.HotpickReadyHour))
+	}
+	if c.RewindWindow < 0 {
+		problems = append(problems, "REWIND_WINDOW must not be negative")
+	}
+
+	return problems
+}
+
+// PlacesConfig configures place search for location tagging
+type PlacesConfig struct {
+	Geocoder          string // "" (local places only) or "nominatim"
+	GeocoderURL       string
+	GeocoderUserAgent string // Nominatim requires one identifying the app
+}
+
+func loadPlaces(l *loader) PlacesConfig {
+	return PlacesConfig{
+		Geocoder:          l.str("PLACES_GEOCODER", ""),
+		GeocoderURL:       l.str("PLACES_GEOCODER_URL", "https://nominatim.openstreetmap.org"),
+		GeocoderUserAgent: l.str("PLACES_GEOCODER_USER_AGENT", "kiekky-backend"),
+	}
+}
+
+func (c PlacesConfig) validate() []string {
+	var problems []string
+
+	switch c.Geocoder {
+	case "", "nominatim":
+	default:
+		problems = append(problems, fmt.Sprintf("PLACES_GEOCODER=%q must be empty or nominatim", c.Geocoder))
+	}
+	if c.Geocoder != "" && c.GeocoderURL == "" {
+		problems = append(problems, "PLACES_GEOCODER_URL is required when PLACES_GEOCODER is set")
+	}
+
+	return problems
+}
+
+// StoriesConfig configures story lifetime, cleanup and video processing
+type StoriesConfig struct {
+	ExpiryHours     int
+	CleanupInterval time.Duration
+
+	// Expired stories stay in their author's archive for ArchiveDays before
+	// they are deleted, unless the author chose their own retention
+	ArchiveDays int
+
+	// Videos longer than MaxVideoSeconds are rejected. Transcoder is empty
+	// (videos are served as uploaded), ffmpeg, or http for an external
+	// service at TranscoderURL.
+	MaxVideoSeconds  int
+	Transcoder       string
+	TranscoderURL    string
+	TranscoderSecret string
+}
+
+func loadStories(l *loader) StoriesConfig {
+	return StoriesConfig{
+		ExpiryHours:      l.int("STORY_EXPIRY_HOURS", 24),
+		CleanupInterval:  l.duration("STORY_CLEANUP_INTERVAL", time.Hour),
+		ArchiveDays:      l.int("STORY_ARCHIVE_DAYS", 30),
+		MaxVideoSeconds:  l.int("STORY_MAX_VIDEO_SECONDS", 30),
+		Transcoder:       l.str("STORY_TRANSCODER", ""),
+		TranscoderURL:    l.str("STORY_TRANSCODER_URL", ""),
+		TranscoderSecret: l.str("STORY_TRANSCODER_SECRET", ""),
+	}
+}
+
+func (c StoriesConfig) validate() []string {
+	var problems []string
+
+	if c.ExpiryHours < 1 {
+		problems = append(problems, fmt.Sprintf("STORY_EXPIRY_HOURS=%d must be at least 1", c.ExpiryHours))
+	}
+	if c.CleanupInterval < time.Minute {
+		problems = append(problems, "STORY_CLEANUP_INTERVAL must be at least 1m")
+	}
+	if c.ArchiveDays < 0 {
+		problems = append(problems, fmt.Sprintf("STORY_ARCHIVE_DAYS=%d must not be negative", c.ArchiveDays))
+	}
+	if c.MaxVideoSeconds < 1 || c.MaxVideoSeconds > 60 {
+		problems = append(problems, fmt.Sprintf("STORY_MAX_VIDEO_SECONDS=%d must be between 1 and 60", c.MaxVideoSeconds))
+	}
+	switch c.Transcoder {
+	case "", "ffmpeg":
+	case "http":
+		if c.TranscoderURL == "" {
+			problems = append(problems, "STORY_TRANSCODER_URL is required when STORY_TRANSCODER=http")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("STORY_TRANSCODER=%q must be empty, ffmpeg or http", c.Transcoder))
+	}
+
+	return problems
+}
+
+// MessagingConfig configures direct messaging
+type MessagingConfig struct {
+	EnableConversationSummaries bool
+	FCMCredentialsFile          string // Defaults to FIREBASE_CREDENTIALS_PATH
+}
+
+func loadMessaging(l *loader) MessagingConfig {
+	return MessagingConfig{
+		EnableConversationSummaries: l.bool("ENABLE_CONVERSATION_SUMMARIES", false),
+		FCMCredentialsFile:          l.str("FCM_CREDENTIALS_FILE", ""),
+	}
+}
+
+// BillingConfig configures Stripe subscriptions and coin packs. Billing is
+// off without a secret key.
+type BillingConfig struct {
+	StripeSecretKey           string
+	StripeWebhookSecret       string
+	StripePricePremiumMonthly string // Stripe price ID for the premium_monthly plan
+	StripePricePremiumYearly  string // Stripe price ID for the premium_yearly plan
+
+	// Stripe price IDs for the coin packs; a pack without one can't be bought
+	StripePriceCoins100  string
+	StripePriceCoins550  string
+	StripePriceCoins1200 string
+}
+
+func loadBilling(l *loader) BillingConfig {
+	return BillingConfig{
+		StripeSecretKey:           l.str("STRIPE_SECRET_KEY", ""),
+		StripeWebhookSecret:       l.str("STRIPE_WEBHOOK_SECRET", ""),
+		StripePricePremiumMonthly: l.str("STRIPE_PRICE_PREMIUM_MONTHLY", ""),
+		StripePricePremiumYearly:  l.str("STRIPE_PRICE_PREMIUM_YEARLY", ""),
+
+		StripePriceCoins100:  l.str("STRIPE_PRICE_COINS_100", ""),
+		StripePriceCoins550:  l.str("STRIPE_PRICE_COINS_550", ""),
+		StripePriceCoins1200: l.str("STRIPE_PRICE_COINS_1200", ""),
+	}
+}
+
+func (c BillingConfig) validate() []string {
+	if c.StripeSecretKey == "" {
+		return nil
+	}
+
+	var problems []string
+	if c.StripeWebhookSecret == "" {
+		problems = append(problems, "STRIPE_WEBHOOK_SECRET is required when STRIPE_SECRET_KEY is set")
+	}
+	if c.StripePricePremiumMonthly == "" && c.StripePricePremiumYearly == "" {
+		problems = append(problems, "STRIPE_PRICE_PREMIUM_MONTHLY or STRIPE_PRICE_PREMIUM_YEARLY is required when STRIPE_SECRET_KEY is set")
+	}
+
+	return problems
+}
+
+// ReferralConfig configures the rewards for inviting friends. Both the
+// referrer and the invitee get the reward once the invitee has verified
+// their account and completed their profile.
+type ReferralConfig struct {
+	RewardType        string // "coins" or "premium_days"
+	RewardCoins       int
+	RewardPremiumDays int
+}
+
+func loadReferrals(l *loader) ReferralConfig {
+	return ReferralConfig{
+		RewardType:        l.str("REFERRAL_REWARD_TYPE", "coins"),
+		RewardCoins:       l.int("REFERRAL_REWARD_COINS", 100),
+		RewardPremiumDays: l.int("REFERRAL_REWARD_PREMIUM_DAYS", 7),
+	}
+}
+
+func (c ReferralConfig) validate() []string {
+	var problems []string
+
+	switch c.RewardType {
+	case "coins":
+		if c.RewardCoins < 1 {
+			problems = append(problems, "REFERRAL_REWARD_COINS must be at least 1")
+		}
+	case "premium_days":
+		if c.RewardPremiumDays < 1 {
+			problems = append(problems, "REFERRAL_REWARD_PREMIUM_DAYS must be at least 1")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("REFERRAL_REWARD_TYPE=%q must be coins or premium_days", c.RewardType))
+	}
+
+	return problems
+}
@@ -0,0 +1,141 @@
+// internal/config/loader.go
+// Reads settings from the environment and config files
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// loader looks settings up in the environment first, then in the config
+// files, and remembers every value it couldn't parse so they can all be
+// reported at once
+type loader struct {
+	file map[string]string
+	errs []string
+}
+
+// newLoader reads the optional .env file and, if CONFIG_FILE is set, the
+// file it names. Both use KEY=VALUE lines; CONFIG_FILE wins over .env.
+func newLoader() (*loader, error) {
+	l := &loader{file: make(map[string]string)}
+
+	if values, err := godotenv.Read(".env"); err == nil {
+		for key, value := range values {
+			l.file[key] = value
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read .env: %w", err)
+	}
+
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		values, err := godotenv.Read(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CONFIG_FILE %s: %w", path, err)
+		}
+		for key, value := range values {
+			l.file[key] = value
+		}
+	}
+
+	return l, nil
+}
+
+func (l *loader) lookup(key string) (string, bool) {
+	if value := os.Getenv(key); value != "" {
+		return value, true
+	}
+	if value := l.file[key]; value != "" {
+		return value, true
+	}
+	return "", false
+}
+
+func (l *loader) invalid(key, value, want string) {
+	l.errs = append(l.errs, fmt.Sprintf("%s=%q is not %s", key, value, want))
+}
+
+// str gets a string setting
+func (l *loader) str(key, defaultValue string) string {
+	if value, ok := l.lookup(key); ok {
+		return value
+	}
+	return defaultValue
+}
+
+// int gets an integer setting
+func (l *loader) int(key string, defaultValue int) int {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	intVal, err := strconv.Atoi(value)
+	if err != nil {
+		l.invalid(key, value, "a whole number")
+		return defaultValue
+	}
+	return intVal
+}
+
+// float gets a decimal setting
+func (l *loader) float(key string, defaultValue float64) float64 {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	floatVal, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		l.invalid(key, value, "a number")
+		return defaultValue
+	}
+	return floatVal
+}
+
+// duration gets a duration setting such as "15m" or "720h"
+func (l *loader) duration(key string, defaultValue time.Duration) time.Duration {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		l.invalid(key, value, `a duration such as "30s", "15m" or "24h"`)
+		return defaultValue
+	}
+	return duration
+}
+
+// list gets a comma-separated setting, dropping blank entries
+func (l *loader) list(key string, defaultValue []string) []string {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// bool gets a true/false setting
+func (l *loader) bool(key string, defaultValue bool) bool {
+	value, ok := l.lookup(key)
+	if !ok {
+		return defaultValue
+	}
+	boolVal, err := strconv.ParseBool(value)
+	if err != nil {
+		l.invalid(key, value, "true or false")
+		return defaultValue
+	}
+	return boolVal
+}
@@ -0,0 +1,180 @@
+// internal/config/storage.go
+// Media storage and content moderation settings
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// StorageConfig configures where uploads, exports and selfies are kept
+type StorageConfig struct {
+	UseS3              bool
+	AWSRegion          string
+	AWSAccessKeyID     string
+	AWSSecretAccessKey string
+	S3Bucket           string
+	S3Region           string // Defaults to AWSRegion
+
+	// Used when S3 is off
+	LocalUploadDir string
+	AdminExportDir string // Private directory for admin user exports
+	SelfieDir      string // Private directory for verification selfies
+
+	// Signed URLs for private media: "" (off), "s3" or "cloudfront"
+	MediaURLSigning   string
+	SignedMediaURLTTL time.Duration
+	CloudFront        CloudFrontConfig
+
+	// Request body caps; larger uploads are refused with 413
+	Uploads UploadLimits
+}
+
+// UploadLimits caps upload request bodies, in bytes
+type UploadLimits struct {
+	Image   int64 // Profile pictures, covers, gallery photos and highlight covers
+	Post    int64 // All of a post's or draft's files together
+	Story   int64
+	Message int64 // Also the most messaging storage accepts per file
+}
+
+// CloudFrontConfig configures CloudFront signed URLs
+type CloudFrontConfig struct {
+	Domain         string
+	KeyPairID      string
+	PrivateKeyPath string
+}
+
+func loadStorage(l *loader) StorageConfig {
+	region := l.str("AWS_REGION", "us-east-1")
+
+	return StorageConfig{
+		UseS3:              l.bool("USE_S3", false),
+		AWSRegion:          region,
+		AWSAccessKeyID:     l.str("AWS_ACCESS_KEY_ID", ""),
+		AWSSecretAccessKey: l.str("AWS_SECRET_ACCESS_KEY", ""),
+		S3Bucket:           l.str("S3_BUCKET_NAME", "social-dating-uploads"),
+		S3Region:           l.str("S3_REGION", region),
+
+		LocalUploadDir: l.str("LOCAL_UPLOAD_DIR", "./uploads"),
+		AdminExportDir: l.str("ADMIN_EXPORT_DIR", "./exports"),
+		SelfieDir:      l.str("VERIFICATION_SELFIE_DIR", "./verification"),
+
+		MediaURLSigning:   l.str("MEDIA_URL_SIGNING", ""),
+		SignedMediaURLTTL: l.duration("SIGNED_MEDIA_URL_TTL", 15*time.Minute),
+		CloudFront: CloudFrontConfig{
+			Domain:         l.str("CLOUDFRONT_DOMAIN", ""),
+			KeyPairID:      l.str("CLOUDFRONT_KEY_PAIR_ID", ""),
+			PrivateKeyPath: l.str("CLOUDFRONT_PRIVATE_KEY_PATH", ""),
+		},
+
+		Uploads: UploadLimits{
+			Image:   int64(l.int("UPLOAD_MAX_IMAGE_MB", 10)) << 20,
+			Post:    int64(l.int("UPLOAD_MAX_POST_MB", 100)) << 20,
+			Story:   int64(l.int("UPLOAD_MAX_STORY_MB", 100)) << 20,
+			Message: int64(l.int("UPLOAD_MAX_MESSAGE_MB", 50)) << 20,
+		},
+	}
+}
+
+func (c StorageConfig) validate() []string {
+	var problems []string
+
+	if c.UseS3 {
+		if c.AWSAccessKeyID == "" || c.AWSSecretAccessKey == "" || c.S3Bucket == "" {
+			problems = append(problems, "USE_S3 needs AWS_ACCESS_KEY_ID, AWS_SECRET_ACCESS_KEY and S3_BUCKET_NAME")
+		}
+	} else if c.LocalUploadDir == "" || c.AdminExportDir == "" || c.SelfieDir == "" {
+		problems = append(problems, "LOCAL_UPLOAD_DIR, ADMIN_EXPORT_DIR and VERIFICATION_SELFIE_DIR must be set when USE_S3 is off")
+	}
+
+	switch c.MediaURLSigning {
+	case "":
+	case "s3":
+		if !c.UseS3 {
+			problems = append(problems, "MEDIA_URL_SIGNING=s3 requires USE_S3=true")
+		}
+	case "cloudfront":
+		if c.CloudFront.Domain == "" || c.CloudFront.KeyPairID == "" || c.CloudFront.PrivateKeyPath == "" {
+			problems = append(problems, "MEDIA_URL_SIGNING=cloudfront needs CLOUDFRONT_DOMAIN, CLOUDFRONT_KEY_PAIR_ID and CLOUDFRONT_PRIVATE_KEY_PATH")
+		}
+	default:
+		problems = append(problems, fmt.Sprintf("MEDIA_URL_SIGNING=%q must be empty, s3 or cloudfront", c.MediaURLSigning))
+	}
+	if c.MediaURLSigning != "" && c.SignedMediaURLTTL <= 0 {
+		problems = append(problems, "SIGNED_MEDIA_URL_TTL must be positive")
+	}
+	if c.Uploads.Image <= 0 || c.Uploads.Post <= 0 || c.Uploads.Story <= 0 || c.Uploads.Message <= 0 {
+		problems = append(problems, "UPLOAD_MAX_IMAGE_MB, UPLOAD_MAX_POST_MB, UPLOAD_MAX_STORY_MB and UPLOAD_MAX_MESSAGE_MB must be positive")
+	}
+
+	return problems
+}
+
+// ModerationConfig configures moderation of uploaded images and videos
+type ModerationConfig struct {
+	Provider        string  // "" (off), "mock" or "rekognition"
+	ReviewThreshold float64 // Provider confidence, 0-1, above which uploads are held for review
+	RejectThreshold float64 // Provider confidence, 0-1, above which uploads are refused
+	BlurRejected    bool    // Blur images over the reject threshold instead of refusing them
+	QuarantineDir   string  // Private directory for held uploads when S3 is off
+}
+
+func loadModeration(l *loader) ModerationConfig {
+	return ModerationConfig{
+		Provider:        l.str("MODERATION_PROVIDER", ""),
+		ReviewThreshold: l.float("MODERATION_REVIEW_THRESHOLD", 0.6),
+		RejectThreshold: l.float("MODERATION_REJECT_THRESHOLD", 0.9),
+		BlurRejected:    l.bool("MODERATION_BLUR_REJECTED", false),
+		QuarantineDir:   l.str("MODERATION_QUARANTINE_DIR", "./quarantine"),
+	}
+}
+
+func (c ModerationConfig) validate() []string {
+	var problems []string
+
+	switch c.Provider {
+	case "", "mock", "rekognition":
+	default:
+		problems = append(problems, fmt.Sprintf("MODERATION_PROVIDER=%q must be empty, mock or rekognition", c.Provider))
+	}
+	if c.Provider != "" {
+		if c.ReviewThreshold <= 0 || c.ReviewThreshold > c.RejectThreshold || c.RejectThreshold > 1 {
+			problems = append(problems, "MODERATION_REVIEW_THRESHOLD and MODERATION_REJECT_THRESHOLD must satisfy 0 < review <= reject <= 1")
+		}
+	}
+
+	return problems
+}
+
+// TextFilterConfig configures profanity and spam filtering of captions,
+// comments, messages and bios
+type TextFilterConfig struct {
+	Enabled     bool
+	Mode        string // "mask" or "reject" profanity; spam is always rejected
+	WordListDir string // Directory with mild.txt, moderate.txt, severe.txt; built-in list if empty
+	MaxLinks    int
+}
+
+func loadTextFilter(l *loader) TextFilterConfig {
+	return TextFilterConfig{
+		Enabled:     l.bool("ENABLE_TEXT_FILTER", true),
+		Mode:        l.str("TEXT_FILTER_MODE", "mask"),
+		WordListDir: l.str("TEXT_FILTER_WORDLIST_DIR", ""),
+		MaxLinks:    l.int("TEXT_FILTER_MAX_LINKS", 2),
+	}
+}
+
+func (c TextFilterConfig) validate() []string {
+	var problems []string
+
+	if c.Mode != "mask" && c.Mode != "reject" {
+		problems = append(problems, fmt.Sprintf("TEXT_FILTER_MODE=%q must be mask or reject", c.Mode))
+	}
+	if c.MaxLinks < 0 {
+		problems = append(problems, "TEXT_FILTER_MAX_LINKS must not be negative")
+	}
+
+	return problems
+}
@@ -0,0 +1,34 @@
+// internal/contacts/events.go
+// Contact joined notices sent in response to new users verifying
+
+package contacts
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+// SubscribeEvents subscribes contacts to the events it acts on
+func (s *service) SubscribeEvents(bus *events.Bus) {
+    events.Subscribe(bus, "contacts", s.onUserVerified)
+}
+
+// onUserVerified tells everyone with the new user's number in their contacts
+// that they've joined. Each notice is keyed by both users, so a retry doesn't
+// repeat the ones already published.
+func (s *service) onUserVerified(ctx context.Context, e events.UserVerified) error {
+    uploaderIDs, err := s.repo.GetUploaders(ctx, e.UserID)
+    if err != nil {
+        return err
+    }
+
+    for _, uploaderID := range uploaderIDs {
+        key := fmt.Sprintf("%d:%d", uploaderID, e.UserID)
+        if err := s.events.Publish(ctx, key, events.ContactJoined{UserID: uploaderID, ContactID: e.UserID}); err != nil {
+            return err
+        }
+    }
+    return nil
+}
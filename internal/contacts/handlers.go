@@ -0,0 +1,60 @@
+// internal/contacts/handlers.go
+
+package contacts
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// SyncContacts handles POST /contacts/sync
+func (h *Handler) SyncContacts(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    var req SyncRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    result, err := h.service.Sync(r.Context(), userID, req.Hashes)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to sync contacts")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, result)
+}
+
+// GetSuggestions handles GET /contacts/suggestions?limit=
+func (h *Handler) GetSuggestions(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+    suggestions, err := h.service.PeopleYouMayKnow(r.Context(), userID, limit)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get people you may know")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, SuggestionsResponse{Users: suggestions})
+}
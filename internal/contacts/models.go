@@ -0,0 +1,25 @@
+// internal/contacts/models.go
+
+package contacts
+
+import (
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+// SyncRequest uploads the user's address book as hashed phone numbers: the
+// lowercase hex SHA-256 of each number in E.164 form, e.g. "+2348012345678".
+// Each sync replaces the last, so an empty list clears it.
+type SyncRequest struct {
+    Hashes []string `json:"hashes" validate:"required,max=5000,dive,len=64,hexadecimal"`
+}
+
+// SyncResponse lists the synced contacts who are on the app
+type SyncResponse struct {
+    Synced  int               `json:"synced"`
+    Matches []*users.Identity `json:"matches"`
+}
+
+// SuggestionsResponse is returned by the people you may know endpoint
+type SuggestionsResponse struct {
+    Users []*users.Identity `json:"users"`
+}
@@ -0,0 +1,95 @@
+// internal/contacts/repository.go
+
+package contacts
+
+import (
+    "context"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+)
+
+// discoverableSQL is true for users who haven't opted out of being found by
+// their phone number
+const discoverableSQL = `COALESCE((u.privacy_settings->>'hide_from_contacts')::boolean, false) = false`
+
+type Repository interface {
+    ReplaceHashes(ctx context.Context, userID int64, hashes []string) error
+    GetMatches(ctx context.Context, userID int64, excludeFollowed bool, limit int) ([]*users.Identity, error)
+    GetUploaders(ctx context.Context, contactID int64) ([]int64, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+// ReplaceHashes stores hashes as the user's contacts, dropping any from an
+// earlier sync that aren't in this one
+func (r *repository) ReplaceHashes(ctx context.Context, userID int64, hashes []string) error {
+    query := `
+        WITH removed AS (
+            DELETE FROM contact_hashes
+            WHERE user_id = $1 AND NOT (phone_hash = ANY($2::text[]))
+        )
+        INSERT INTO contact_hashes (user_id, phone_hash)
+        SELECT $1, UNNEST($2::text[])
+        ON CONFLICT DO NOTHING`
+
+    _, err := r.db.ExecContext(ctx, query, userID, pq.Array(hashes))
+    return err
+}
+
+// GetMatches returns the active, discoverable users in the user's synced
+// contacts, newest first, leaving out anyone blocked either way
+func (r *repository) GetMatches(ctx context.Context, userID int64, excludeFollowed bool, limit int) ([]*users.Identity, error) {
+    query := `
+        SELECT u.id, u.username, ` + users.DisplayNameSQL("u") + ` AS display_name,
+            ` + users.ProfilePictureSQL("u") + ` AS profile_picture
+        FROM contact_hashes c
+        JOIN users u ON u.phone_hash = c.phone_hash
+        WHERE c.user_id = $1
+          AND u.id <> $1
+          AND ` + users.ActiveSQL("u") + `
+          AND ` + discoverableSQL + `
+          AND ` + blocks.NotBlockedSQL("$1", "u.id")
+    if excludeFollowed {
+        query += `
+          AND NOT EXISTS (SELECT 1 FROM follows f WHERE f.follower_id = $1 AND f.following_id = u.id)`
+    }
+    query += `
+        ORDER BY u.created_at DESC
+        LIMIT $2`
+
+    matches := []*users.Identity{}
+    if err := r.db.SelectContext(ctx, &matches, query, userID, limit); err != nil {
+        return nil, err
+    }
+    return matches, nil
+}
+
+// GetUploaders returns the active users with contactID's phone number in
+// their contacts, none if contactID has opted out of being found by it
+func (r *repository) GetUploaders(ctx context.Context, contactID int64) ([]int64, error) {
+    query := `
+        SELECT c.user_id
+        FROM users u
+        JOIN contact_hashes c ON c.phone_hash = u.phone_hash
+        JOIN users uploader ON uploader.id = c.user_id
+        WHERE u.id = $1
+          AND c.user_id <> $1
+          AND ` + discoverableSQL + `
+          AND ` + users.ActiveSQL("uploader") + `
+          AND ` + blocks.NotBlockedSQL("$1", "c.user_id")
+
+    userIDs := []int64{}
+    if err := r.db.SelectContext(ctx, &userIDs, query, contactID); err != nil {
+        return nil, err
+    }
+    return userIDs, nil
+}
@@ -0,0 +1,14 @@
+package contacts
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/contacts").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("/sync", handler.SyncContacts).Methods("POST")
+    api.HandleFunc("/suggestions", handler.GetSuggestions).Methods("GET")
+}
@@ -0,0 +1,85 @@
+// internal/contacts/service.go
+
+package contacts
+
+import (
+    "context"
+    "fmt"
+    "strings"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+const (
+    DefaultSuggestionLimit = 10
+    MaxSuggestionLimit     = 50
+
+    // maxSyncMatches bounds the matches returned from one sync
+    maxSyncMatches = 100
+)
+
+type Service interface {
+    Sync(ctx context.Context, userID int64, hashes []string) (*SyncResponse, error)
+
+    // PeopleYouMayKnow returns contacts on the app the user doesn't follow
+    // yet, shown in discovery
+    PeopleYouMayKnow(ctx context.Context, userID int64, limit int) ([]*users.Identity, error)
+
+    // SubscribeEvents subscribes to new users verifying, to tell the users
+    // who have them in their contacts
+    SubscribeEvents(bus *events.Bus)
+
+    SetEvents(bus *events.Bus)
+}
+
+type service struct {
+    repo   Repository
+    events *events.Bus
+}
+
+func NewService(repo Repository) Service {
+    return &service{repo: repo}
+}
+
+// SetEvents sets where contact joined events are published
+func (s *service) SetEvents(bus *events.Bus) {
+    s.events = bus
+}
+
+// Sync replaces the user's contacts with hashes and returns those on the app
+func (s *service) Sync(ctx context.Context, userID int64, hashes []string) (*SyncResponse, error) {
+    unique := make([]string, 0, len(hashes))
+    seen := make(map[string]bool, len(hashes))
+    for _, hash := range hashes {
+        hash = strings.ToLower(hash)
+        if !seen[hash] {
+            seen[hash] = true
+            unique = append(unique, hash)
+        }
+    }
+
+    if err := s.repo.ReplaceHashes(ctx, userID, unique); err != nil {
+        return nil, fmt.Errorf("failed to store contacts: %w", err)
+    }
+
+    matches, err := s.repo.GetMatches(ctx, userID, false, maxSyncMatches)
+    if err != nil {
+        return nil, fmt.Errorf("failed to match contacts: %w", err)
+    }
+
+    return &SyncResponse{
+        Synced:  len(unique),
+        Matches: matches,
+    }, nil
+}
+
+func (s *service) PeopleYouMayKnow(ctx context.Context, userID int64, limit int) ([]*users.Identity, error) {
+    if limit <= 0 {
+        limit = DefaultSuggestionLimit
+    }
+    if limit > MaxSuggestionLimit {
+        limit = MaxSuggestionLimit
+    }
+    return s.repo.GetMatches(ctx, userID, true, limit)
+}
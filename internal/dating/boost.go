@@ -0,0 +1,48 @@
+// internal/dating/boost.go
+
+package dating
+
+import (
+    "math"
+    "time"
+)
+
+const (
+    DefaultNewProfileBoostWindow = 72 * time.Hour
+    defaultNewProfileMaxBoost    = 1.5
+)
+
+// NewProfileBoost gives profiles a temporary ranking lift right after they are
+// completed. The multiplier starts at MaxMultiplier and eases down to 1.0 over
+// Window, so new users get early exposure without dominating for days.
+type NewProfileBoost struct {
+    Window        time.Duration
+    MaxMultiplier float64
+}
+
+func defaultNewProfileBoost() NewProfileBoost {
+    return NewProfileBoost{
+        Window:        DefaultNewProfileBoostWindow,
+        MaxMultiplier: defaultNewProfileMaxBoost,
+    }
+}
+
+// IsNew reports whether the profile is still inside the boost window
+func (b NewProfileBoost) IsNew(completedAt *time.Time, now time.Time) bool {
+    if completedAt == nil || b.Window <= 0 {
+        return false
+    }
+    age := now.Sub(*completedAt)
+    return age >= 0 && age < b.Window
+}
+
+// Multiplier returns the score multiplier for a profile completed at completedAt
+func (b NewProfileBoost) Multiplier(completedAt *time.Time, now time.Time) float64 {
+    if !b.IsNew(completedAt, now) || b.MaxMultiplier <= 1 {
+        return 1.0
+    }
+
+    // Quadratic ease-out: strongest in the first hours, flattening towards the end
+    remaining := 1 - float64(now.Sub(*completedAt))/float64(b.Window)
+    return 1 + (b.MaxMultiplier-1)*math.Pow(remaining, 2)
+}
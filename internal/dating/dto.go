@@ -1,17 +1,21 @@
 // internal/dating/dto.go
 package dating
 
-import "time"
+import (
+    "time"
+
+    "github.com/lib/pq"
+)
 
 // DTOs for API requests/responses
 
 type CreateDateRequestDTO struct {
-    ReceiverID      int64   `json:"receiver_id" validate:"required"`
+    ReceiverID      int64   `json:"receiver_id" validate:"required,gt=0"`
     Message         string  `json:"message" validate:"required,min=10,max=500"`
-    ProposedDate    string  `json:"proposed_date,omitempty"`
-    Location        string  `json:"location,omitempty"`
-    LocationLat     float64 `json:"location_lat,omitempty"`
-    LocationLng     float64 `json:"location_lng,omitempty"`
+    ProposedDate    string  `json:"proposed_date,omitempty" validate:"omitempty,datetime=2006-01-02T15:04:05Z07:00"`
+    Location        string  `json:"location,omitempty" validate:"omitempty,max=200"`
+    LocationLat     float64 `json:"location_lat,omitempty" validate:"omitempty,latitude"`
+    LocationLng     float64 `json:"location_lng,omitempty" validate:"omitempty,longitude"`
     DateType        string  `json:"date_type,omitempty" validate:"omitempty,oneof=coffee dinner lunch drinks activity"`
     DurationMinutes int     `json:"duration_minutes,omitempty" validate:"omitempty,min=30,max=480"`
 }
@@ -39,15 +43,32 @@ type MatchFilters struct {
     Limit         int     `json:"limit"`
 }
 
+// CandidateFilters narrow the users considered for discovery and hotpicks.
+// MaxDistance is measured from Latitude/Longitude, the searching user's
+// location.
 type CandidateFilters struct {
-    ExcludeMatched    bool    `json:"exclude_matched"`
-    ExcludeBlocked    bool    `json:"exclude_blocked"`
-    ExcludeDeclined   bool    `json:"exclude_declined"`
-    Gender            string  `json:"gender"`
-    MinAge            int     `json:"min_age"`
-    MaxAge            int     `json:"max_age"`
-    MaxDistance       float64 `json:"max_distance"`
-    Limit             int     `json:"limit"`
+    ExcludeMatched    bool     `json:"exclude_matched"`
+    ExcludeBlocked    bool     `json:"exclude_blocked"`
+    ExcludeDeclined   bool     `json:"exclude_declined"`
+    Genders           []string `json:"genders"`
+    MinAge            int      `json:"min_age"`
+    MaxAge            int      `json:"max_age"`
+    MaxDistance       float64  `json:"max_distance"`
+    LookingFor        string   `json:"looking_for"`
+    Latitude          float64  `json:"latitude"`
+    Longitude         float64  `json:"longitude"`
+    VerifiedOnly      bool     `json:"verified_only"`
+    Interests         []string `json:"interests"` // any of these
+    Limit             int      `json:"limit"`
+}
+
+// UpdatePreferencesDTO replaces a user's dating preferences
+type UpdatePreferencesDTO struct {
+    MinAge        int      `json:"min_age" validate:"required,min=18,max=100"`
+    MaxAge        int      `json:"max_age" validate:"required,min=18,max=100"`
+    MaxDistanceKm int      `json:"max_distance_km" validate:"required,min=1,max=500"`
+    Genders       []string `json:"genders" validate:"omitempty,max=3,dive,oneof=male female other"`
+    Intent        string   `json:"intent,omitempty" validate:"omitempty,max=50"`
 }
 
 // Supporting types
@@ -59,6 +80,10 @@ type UserInfo struct {
     ProfilePicture *string `json:"profile_picture,omitempty" db:"profile_picture"`
     Bio            *string `json:"bio,omitempty" db:"bio"`
     Age            *int    `json:"age,omitempty" db:"age"`
+
+    // IsNew marks profiles inside the new-profile boost window so clients can show a badge
+    IsNew              bool       `json:"is_new"`
+    ProfileCompletedAt *time.Time `json:"-" db:"profile_completed_at"`
 }
 
 type UserProfile struct {
@@ -77,14 +102,11 @@ type UserProfile struct {
     Longitude         float64   `json:"longitude" db:"location_lng"`
     City              *string   `json:"city,omitempty" db:"city"`
     Country           *string   `json:"country,omitempty" db:"country"`
+    Timezone          string    `json:"-" db:"timezone"`
     
     // Preferences
-    Interests         []string  `json:"interests" db:"interests"`
+    Interests         pq.StringArray `json:"interests" db:"interests"`
     LookingFor        string    `json:"looking_for" db:"looking_for"`
-    PreferredGender   *string   `json:"preferred_gender,omitempty" db:"preferred_gender"`
-    PreferredMinAge   *int      `json:"preferred_min_age,omitempty" db:"preferred_min_age"`
-    PreferredMaxAge   *int      `json:"preferred_max_age,omitempty" db:"preferred_max_age"`
-    PreferredDistance *float64  `json:"preferred_distance,omitempty" db:"preferred_distance"`
     
     // Activity & Status
     LastActive        time.Time `json:"last_active" db:"last_active"`
@@ -97,16 +119,32 @@ type UserProfile struct {
     CompletionScore   float64   `json:"completion_score" db:"completion_score"`
     PhotoCount        int       `json:"photo_count" db:"photo_count"`
     
+    // Set when the profile was first completed; drives the new-profile boost
+    ProfileCompletedAt *time.Time `json:"profile_completed_at,omitempty" db:"profile_completed_at"`
+    
+    // Set while the user has a spotlight boost running
+    BoostedUntil      *time.Time `json:"-" db:"boosted_until"`
+    BoostMultiplier   *float64   `json:"-" db:"boost_multiplier"`
+    
     CreatedAt         time.Time `json:"created_at" db:"created_at"`
     UpdatedAt         time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// PromptAnswer is a user's answer to an icebreaker prompt
+type PromptAnswer struct {
+    UserID   int64  `db:"user_id"`
+    PromptID int64  `db:"prompt_id"`
+    Prompt   string `db:"prompt"`
+    Answer   string `db:"answer"`
+}
+
 type ScoredCandidate struct {
     UserID  int64                 `json:"user_id"`
     Profile *UserProfile          `json:"profile"`
     Score   float64               `json:"score"`
     Factors *CompatibilityFactors `json:"factors"`
     Reason  string                `json:"reason"`
+    IsNew   bool                  `json:"is_new"`
 }
 
 type UserInteraction struct {
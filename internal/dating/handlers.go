@@ -3,11 +3,14 @@
 package dating
 
 import (
-    "encoding/json"
+    "errors"
     "net/http"
     "strconv"
+    "strings"
+    "time"
     
     "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
     "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
 )
 
@@ -20,29 +23,50 @@ func NewHandler(service Service) *Handler {
 }
 
 func (h *Handler) CreateDateRequest(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     var dto CreateDateRequestDTO
-    if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &dto) {
         return
     }
     
-    request, err := h.service.CreateDateRequest(r.Context(), userID, &dto)
+    request, quota, err := h.service.CreateDateRequest(r.Context(), userID, &dto)
     if err != nil {
+        if respondQuotaExceeded(w, err) {
+            return
+        }
         if err == ErrAlreadyRequested {
             utils.RespondWithError(w, http.StatusConflict, err.Error())
             return
         }
+        if err == ErrCannotRequestSelf {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+            return
+        }
+        if err == ErrUserBlocked {
+            utils.RespondWithError(w, http.StatusForbidden, err.Error())
+            return
+        }
         utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create date request")
         return
     }
     
-    utils.RespondWithJSON(w, http.StatusCreated, request)
+    utils.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
+        "request": request,
+        "quota":   quota,
+    })
 }
 
 func (h *Handler) GetDateRequests(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     requestType := r.URL.Query().Get("type")
     
     if requestType == "" {
@@ -59,7 +83,11 @@ func (h *Handler) GetDateRequests(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) RespondToRequest(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     vars := mux.Vars(r)
     requestID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -69,8 +97,7 @@ func (h *Handler) RespondToRequest(w http.ResponseWriter, r *http.Request) {
     }
     
     var dto RespondDateRequestDTO
-    if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &dto) {
         return
     }
     
@@ -88,7 +115,11 @@ func (h *Handler) RespondToRequest(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetHotpicks(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     params := &GetHotpicksParams{
         Limit:         10,
@@ -111,7 +142,11 @@ func (h *Handler) GetHotpicks(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetMatches(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     active := true
     if activeStr := r.URL.Query().Get("active"); activeStr == "false" {
@@ -128,7 +163,11 @@ func (h *Handler) GetMatches(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) CancelRequest(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     vars := mux.Vars(r)
     requestID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -151,7 +190,11 @@ func (h *Handler) CancelRequest(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetUpcomingDates(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     dates, err := h.service.GetUpcomingDates(r.Context(), userID)
     if err != nil {
@@ -163,7 +206,11 @@ func (h *Handler) GetUpcomingDates(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Unmatch(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     vars := mux.Vars(r)
     matchID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -186,7 +233,11 @@ func (h *Handler) Unmatch(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) CheckMatch(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     vars := mux.Vars(r)
     otherUserID, err := strconv.ParseInt(vars["userId"], 10, 64)
@@ -205,6 +256,12 @@ func (h *Handler) CheckMatch(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) RecordAction(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
     vars := mux.Vars(r)
     hotpickID, err := strconv.ParseInt(vars["id"], 10, 64)
     if err != nil {
@@ -213,25 +270,148 @@ func (h *Handler) RecordAction(w http.ResponseWriter, r *http.Request) {
     }
     
     var dto struct {
-        Action string `json:"action"`
+        Action string `json:"action" validate:"required,oneof=like pass"`
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &dto) {
         return
     }
     
-    err = h.service.RecordHotpickAction(r.Context(), hotpickID, dto.Action)
+    quota, err := h.service.RecordHotpickAction(r.Context(), userID, hotpickID, dto.Action)
     if err != nil {
-        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to record action")
+        if respondQuotaExceeded(w, err) {
+            return
+        }
+        switch err {
+        case ErrInvalidAction:
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        case ErrHotpickNotFound:
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+        case ErrUnauthorized:
+            utils.RespondWithError(w, http.StatusForbidden, err.Error())
+        case ErrAlreadyActedOn:
+            utils.RespondWithError(w, http.StatusConflict, err.Error())
+        case ErrOnboardingIncomplete:
+            utils.RespondWithError(w, http.StatusForbidden, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to record action")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "message": "Action recorded",
+        "quota":   quota,
+    })
+}
+
+// RewindSwipe undoes the user's most recent pass so the profile comes back
+// as their next hotpick
+func (h *Handler) RewindSwipe(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    hotpick, err := h.service.RewindSwipe(r.Context(), userID)
+    if err != nil {
+        switch err {
+        case ErrRewindNotAllowed:
+            utils.RespondWithError(w, http.StatusPaymentRequired, err.Error())
+        case ErrNothingToRewind:
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to rewind swipe")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, hotpick)
+}
+
+func (h *Handler) ActivateBoost(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
         return
     }
     
-    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Action recorded"})
+    boost, err := h.service.ActivateBoost(r.Context(), userID)
+    if err != nil {
+        switch err {
+        case ErrBoostActive:
+            utils.RespondWithError(w, http.StatusConflict, err.Error())
+        case ErrNoBoostCredits:
+            utils.RespondWithError(w, http.StatusPaymentRequired, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to activate boost")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusCreated, boost)
+}
+
+func (h *Handler) GetBoost(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    status, err := h.service.GetBoostStatus(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get boost")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, status)
+}
+
+func (h *Handler) GetQuotas(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    quotas := make(map[QuotaKind]*Quota, 2)
+    for _, kind := range []QuotaKind{QuotaSwipes, QuotaDateRequests} {
+        quota, err := h.service.GetQuota(r.Context(), userID, kind)
+        if err != nil {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get quotas")
+            return
+        }
+        quotas[kind] = quota
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, quotas)
+}
+
+// respondQuotaExceeded writes a 429 with the exhausted quota if err is a
+// QuotaExceededError, reporting whether it did
+func respondQuotaExceeded(w http.ResponseWriter, err error) bool {
+    var quotaErr *QuotaExceededError
+    if !errors.As(err, &quotaErr) {
+        return false
+    }
+    
+    w.Header().Set("Retry-After", strconv.Itoa(int(time.Until(quotaErr.Quota.ResetsAt).Seconds())+1))
+    utils.RespondWithJSON(w, http.StatusTooManyRequests, map[string]interface{}{
+        "error": quotaErr.Error(),
+        "code":  "quota_exceeded",
+        "quota": quotaErr.Quota,
+    })
+    return true
 }
 
 func (h *Handler) GenerateHotpicks(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     err := h.service.GenerateHotpicks(r.Context(), userID)
     if err != nil {
@@ -243,7 +423,11 @@ func (h *Handler) GenerateHotpicks(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetCompatibility(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     vars := mux.Vars(r)
     otherUserID, err := strconv.ParseInt(vars["userId"], 10, 64)
@@ -267,14 +451,16 @@ func (h *Handler) GetCompatibility(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DiscoverMatches(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
-    // Parse query parameters for filters
+    // Parse query parameters for filters. Any left out come from the
+    // user's saved preferences.
     filters := &MatchFilters{
-        MinAge:    18,
-        MaxAge:    100,
-        MaxDistance: 100,
-        Limit:     20,
+        Limit: 20,
     }
     
     if minAge := r.URL.Query().Get("min_age"); minAge != "" {
@@ -289,6 +475,23 @@ func (h *Handler) DiscoverMatches(w http.ResponseWriter, r *http.Request) {
         }
     }
     
+    if distance := r.URL.Query().Get("max_distance"); distance != "" {
+        if km, err := strconv.ParseFloat(distance, 64); err == nil {
+            filters.MaxDistance = km
+        }
+    }
+    
+    filters.Gender = r.URL.Query().Get("gender")
+    filters.LookingFor = r.URL.Query().Get("looking_for")
+    filters.IsVerified = r.URL.Query().Get("verified_only") == "true"
+    if interests := r.URL.Query().Get("interests"); interests != "" {
+        for _, interest := range strings.Split(interests, ",") {
+            if interest = strings.TrimSpace(interest); interest != "" {
+                filters.Interests = append(filters.Interests, interest)
+            }
+        }
+    }
+    
     matches, err := h.service.FindPotentialMatches(r.Context(), userID, filters)
     if err != nil {
         utils.RespondWithError(w, http.StatusInternalServerError, "Failed to discover matches")
@@ -296,4 +499,62 @@ func (h *Handler) DiscoverMatches(w http.ResponseWriter, r *http.Request) {
     }
     
     utils.RespondWithJSON(w, http.StatusOK, matches)
-}
\ No newline at end of file
+}
+
+func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    prefs, err := h.service.GetPreferences(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get preferences")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, prefs)
+}
+
+func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    var dto UpdatePreferencesDTO
+    if !utils.DecodeAndValidate(w, r, &dto) {
+        return
+    }
+    
+    prefs, err := h.service.UpdatePreferences(r.Context(), userID, &dto)
+    if err != nil {
+        if err == ErrInvalidAgeRange {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update preferences")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, prefs)
+}
+
+// ResetPreferences deletes the saved preferences and returns the defaults
+// now in effect
+func (h *Handler) ResetPreferences(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    if err := h.service.ResetPreferences(r.Context(), userID); err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to reset preferences")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, DefaultPreferences(userID))
+}
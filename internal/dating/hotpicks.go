@@ -0,0 +1,78 @@
+// internal/dating/hotpicks.go
+
+package dating
+
+import "strings"
+
+// hotpickAgeBucket is the span of years grouped together when keeping a
+// day's hotpicks varied
+const hotpickAgeBucket = 5
+
+// HotpickConfig sets how many hotpicks each user gets a day, the local hour
+// they arrive at, and how varied they must be
+type HotpickConfig struct {
+    PerDay      int
+    ReadyHour   int
+    MaxPerGroup int // Most picks from one city, and from one age bucket
+}
+
+func DefaultHotpickConfig() HotpickConfig {
+    return HotpickConfig{
+        PerDay:      10,
+        ReadyHour:   9,
+        MaxPerGroup: 3,
+    }
+}
+
+// pickDiverse takes up to n candidates in rank order, passing over any that
+// would put more than maxPerGroup picks in one city or one age bucket. When
+// that leaves fewer than n, the best of those passed over fill the rest, so
+// users with a small pool still get a full set.
+func pickDiverse(ranked []*ScoredCandidate, n, maxPerGroup int) []*ScoredCandidate {
+    if len(ranked) <= n {
+        return ranked
+    }
+    if maxPerGroup <= 0 {
+        return ranked[:n]
+    }
+
+    picks := make([]*ScoredCandidate, 0, n)
+    var passed []*ScoredCandidate
+    cities := make(map[string]int)
+    ageBuckets := make(map[int]int)
+
+    for _, candidate := range ranked {
+        if len(picks) == n {
+            break
+        }
+
+        city := candidateCity(candidate.Profile)
+        bucket := calculateAge(candidate.Profile.BirthDate) / hotpickAgeBucket
+        if (city != "" && cities[city] >= maxPerGroup) || ageBuckets[bucket] >= maxPerGroup {
+            passed = append(passed, candidate)
+            continue
+        }
+
+        if city != "" {
+            cities[city]++
+        }
+        ageBuckets[bucket]++
+        picks = append(picks, candidate)
+    }
+
+    for _, candidate := range passed {
+        if len(picks) == n {
+            break
+        }
+        picks = append(picks, candidate)
+    }
+    return picks
+}
+
+// candidateCity is the candidate's city for grouping, or "" if unknown
+func candidateCity(profile *UserProfile) string {
+    if profile.City == nil {
+        return ""
+    }
+    return strings.ToLower(strings.TrimSpace(*profile.City))
+}
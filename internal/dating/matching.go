@@ -7,6 +7,7 @@ import (
     "math"
     "sort"
     "fmt"
+    "strings"
     "time"
 )
 
@@ -28,7 +29,8 @@ func (m *matchingEngine) CalculateCompatibility(ctx context.Context, user1, user
     factors := &CompatibilityFactors{}
     
     // 1. Calculate interests match (30% weight)
-    factors.InterestsMatch = m.calculateInterestsScore(user1.Interests, user2.Interests)
+    factors.SharedInterests = sharedInterests(user1.Interests, user2.Interests)
+    factors.InterestsMatch = m.calculateInterestsScore(user1.Interests, user2.Interests, len(factors.SharedInterests))
     
     // 2. Calculate location proximity (20% weight)
     factors.LocationProximity = m.calculateLocationScore(
@@ -72,30 +74,45 @@ func (m *matchingEngine) CalculateCompatibility(ctx context.Context, user1, user
     return totalScore, factors, nil
 }
 
-func (m *matchingEngine) calculateInterestsScore(interests1, interests2 []string) float64 {
+// sharedInterestBoost is added to the interests score per shared interest,
+// so users with long interest lists who share several aren't marked down by
+// the overlap ratio alone
+const sharedInterestBoost = 0.1
+
+func (m *matchingEngine) calculateInterestsScore(interests1, interests2 []string, shared int) float64 {
     if len(interests1) == 0 || len(interests2) == 0 {
         return 0.5
     }
     
+    // Jaccard similarity coefficient
+    union := len(interests1) + len(interests2) - shared
+    if union == 0 {
+        return 0
+    }
+    
+    score := float64(shared) / float64(union)
+    return math.Min(1.0, score + float64(shared) * sharedInterestBoost)
+}
+
+// sharedInterests returns the interests both users list, in the order the
+// second lists them. Profiles store catalog names, but case is ignored for
+// any not yet mapped onto the catalog.
+func sharedInterests(interests1, interests2 []string) []string {
     interestMap := make(map[string]bool)
     for _, interest := range interests1 {
-        interestMap[interest] = true
+        interestMap[strings.ToLower(interest)] = true
     }
     
-    matches := 0
+    shared := []string{}
     for _, interest := range interests2 {
-        if interestMap[interest] {
-            matches++
+        key := strings.ToLower(interest)
+        if interestMap[key] {
+            shared = append(shared, interest)
+            delete(interestMap, key)
         }
     }
     
-    // Jaccard similarity coefficient
-    union := len(interests1) + len(interests2) - matches
-    if union == 0 {
-        return 0
-    }
-    
-    return float64(matches) / float64(union)
+    return shared
 }
 
 func (m *matchingEngine) calculateLocationScore(lat1, lon1, lat2, lon2 float64) float64 {
@@ -41,6 +41,14 @@ var (
         },
     )
     
+    boostsActivated = promauto.NewCounterVec(
+        prometheus.CounterOpts{
+            Name: "dating_boosts_activated_total",
+            Help: "Total number of spotlight boosts started",
+        },
+        []string{"source"},
+    )
+    
     responseTime = promauto.NewHistogramVec(
         prometheus.HistogramOpts{
             Name: "dating_response_time_seconds",
@@ -111,6 +119,10 @@ func RecordMatch() {
     matchesTotal.Inc()
 }
 
+func RecordBoostActivated(source string) {
+    boostsActivated.WithLabelValues(source).Inc()
+}
+
 func RecordCompatibilityScore(score float64) {
     compatibilityScores.Observe(score)
 }
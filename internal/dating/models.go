@@ -55,6 +55,7 @@ type Hotpick struct {
     IsSeen            bool            `json:"is_seen" db:"is_seen"`
     IsActedOn         bool            `json:"is_acted_on" db:"is_acted_on"`
     ActionType        *string         `json:"action_type,omitempty" db:"action_type"`
+    ActedAt           *time.Time      `json:"acted_at,omitempty" db:"acted_at"`
     ExpiresAt         *time.Time      `json:"expires_at,omitempty" db:"expires_at"`
     CreatedAt         time.Time       `json:"created_at" db:"created_at"`
     RecommendedUser   *UserInfo       `json:"recommended_user,omitempty"`
@@ -68,4 +69,6 @@ type CompatibilityFactors struct {
     PreferencesMatch    float64 `json:"preferences_match"`
     ProfileCompleteness float64 `json:"profile_completeness"`
     EngagementLevel     float64 `json:"engagement_level"`
+    
+    SharedInterests     []string `json:"shared_interests,omitempty"`
 }
\ No newline at end of file
@@ -0,0 +1,119 @@
+// internal/dating/preferences.go
+
+package dating
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/lib/pq"
+)
+
+var ErrInvalidAgeRange = errors.New("min_age must not be above max_age")
+
+// Preferences used for users who haven't saved any
+const (
+    DefaultMinAge      = 18
+    DefaultMaxAge      = 100
+    DefaultMaxDistance = 100 // km
+)
+
+// Preferences are who a user wants to see in discovery and hotpicks. No
+// genders means any gender, and no intent any intent.
+type Preferences struct {
+    UserID        int64          `json:"user_id" db:"user_id"`
+    MinAge        int            `json:"min_age" db:"min_age"`
+    MaxAge        int            `json:"max_age" db:"max_age"`
+    MaxDistanceKm int            `json:"max_distance_km" db:"max_distance_km"`
+    Genders       pq.StringArray `json:"genders" db:"genders"`
+    Intent        *string        `json:"intent,omitempty" db:"intent"`
+    UpdatedAt     *time.Time     `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// DefaultPreferences are the preferences of a user who hasn't saved any
+func DefaultPreferences(userID int64) *Preferences {
+    return &Preferences{
+        UserID:        userID,
+        MinAge:        DefaultMinAge,
+        MaxAge:        DefaultMaxAge,
+        MaxDistanceKm: DefaultMaxDistance,
+        Genders:       pq.StringArray{},
+    }
+}
+
+// candidateFilters turns the preferences into filters for candidates around
+// the user's location
+func (p *Preferences) candidateFilters(profile *UserProfile) *CandidateFilters {
+    return &CandidateFilters{
+        ExcludeMatched:  true,
+        ExcludeBlocked:  true,
+        ExcludeDeclined: true,
+        Genders:         p.Genders,
+        MinAge:          p.MinAge,
+        MaxAge:          p.MaxAge,
+        MaxDistance:     float64(p.MaxDistanceKm),
+        LookingFor:      derefString(p.Intent, ""),
+        Latitude:        profile.Latitude,
+        Longitude:       profile.Longitude,
+        Limit:           100,
+    }
+}
+
+// GetPreferences returns the user's saved preferences, or the defaults if
+// they haven't saved any
+func (s *service) GetPreferences(ctx context.Context, userID int64) (*Preferences, error) {
+    return loadPreferences(ctx, s.repo, userID)
+}
+
+// UpdatePreferences saves the user's preferences, replacing any saved before
+func (s *service) UpdatePreferences(ctx context.Context, userID int64, dto *UpdatePreferencesDTO) (*Preferences, error) {
+    if dto.MinAge > dto.MaxAge {
+        return nil, ErrInvalidAgeRange
+    }
+
+    prefs := &Preferences{
+        UserID:        userID,
+        MinAge:        dto.MinAge,
+        MaxAge:        dto.MaxAge,
+        MaxDistanceKm: dto.MaxDistanceKm,
+        Genders:       pq.StringArray(dto.Genders),
+    }
+    if prefs.Genders == nil {
+        prefs.Genders = pq.StringArray{}
+    }
+    if dto.Intent != "" {
+        prefs.Intent = &dto.Intent
+    }
+
+    if err := s.repo.UpsertPreferences(ctx, prefs); err != nil {
+        return nil, fmt.Errorf("failed to save preferences: %w", err)
+    }
+    // Only the first save is published; later ones share the key
+    if err := s.events.Publish(ctx, fmt.Sprint(userID), events.DatingPreferencesSaved{UserID: userID}); err != nil {
+        log.Printf("Failed to publish preferences for user %d: %v", userID, err)
+    }
+    return prefs, nil
+}
+
+// ResetPreferences deletes the user's saved preferences, going back to the
+// defaults
+func (s *service) ResetPreferences(ctx context.Context, userID int64) error {
+    return s.repo.DeletePreferences(ctx, userID)
+}
+
+// loadPreferences gets a user's saved preferences, falling back to the
+// defaults
+func loadPreferences(ctx context.Context, repo Repository, userID int64) (*Preferences, error) {
+    prefs, err := repo.GetPreferences(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if prefs == nil {
+        return DefaultPreferences(userID), nil
+    }
+    return prefs, nil
+}
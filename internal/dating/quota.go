@@ -0,0 +1,147 @@
+// internal/dating/quota.go
+
+package dating
+
+import (
+    "context"
+    "fmt"
+    "time"
+)
+
+type QuotaKind string
+
+const (
+    QuotaSwipes       QuotaKind = "swipes"
+    QuotaDateRequests QuotaKind = "date_requests"
+)
+
+// QuotaConfig sets the daily allowances. A limit of zero or less means unlimited.
+type QuotaConfig struct {
+    DailySwipes              int
+    PremiumDailySwipes       int
+    DailyDateRequests        int
+    PremiumDailyDateRequests int
+}
+
+func DefaultQuotaConfig() QuotaConfig {
+    return QuotaConfig{
+        DailySwipes:              50,
+        PremiumDailySwipes:       0,
+        DailyDateRequests:        5,
+        PremiumDailyDateRequests: 20,
+    }
+}
+
+func (c QuotaConfig) limit(kind QuotaKind, premium bool) int {
+    switch {
+    case kind == QuotaSwipes && premium:
+        return c.PremiumDailySwipes
+    case kind == QuotaSwipes:
+        return c.DailySwipes
+    case premium:
+        return c.PremiumDailyDateRequests
+    default:
+        return c.DailyDateRequests
+    }
+}
+
+// Quota is a user's standing for one kind of action today. Remaining is -1
+// when the quota is unlimited.
+type Quota struct {
+    Kind      QuotaKind `json:"kind"`
+    Limit     int       `json:"limit"`
+    Used      int       `json:"used"`
+    Remaining int       `json:"remaining"`
+    ResetsAt  time.Time `json:"resets_at"`
+}
+
+func (q *Quota) Unlimited() bool {
+    return q.Limit <= 0
+}
+
+func (q *Quota) Exhausted() bool {
+    return !q.Unlimited() && q.Remaining <= 0
+}
+
+// consume records one use against a quota already checked to have room
+func (q *Quota) consume() {
+    q.Used++
+    if !q.Unlimited() {
+        q.Remaining--
+    }
+}
+
+// QuotaExceededError is returned when a daily quota is used up
+type QuotaExceededError struct {
+    Quota *Quota
+}
+
+func (e *QuotaExceededError) Error() string {
+    return fmt.Sprintf("daily %s limit of %d reached", e.Quota.Kind, e.Quota.Limit)
+}
+
+// QuotaUser is what quota and boost checks need to know about a user
+type QuotaUser struct {
+    Timezone     string `db:"timezone"`
+    IsPremium    bool   `db:"is_premium"`
+    BoostCredits int    `db:"boost_credits"`
+}
+
+// GetQuota returns the user's quota for kind, counted since their local midnight
+func (s *service) GetQuota(ctx context.Context, userID int64, kind QuotaKind) (*Quota, error) {
+    user, err := s.repo.GetQuotaUser(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    dayStart := localMidnight(now, user.Timezone)
+
+    var used int
+    if kind == QuotaSwipes {
+        used, err = s.repo.GetSwipeCountSince(ctx, userID, dayStart)
+    } else {
+        used, err = s.repo.GetRecentRequestCount(ctx, userID, now.Sub(dayStart))
+    }
+    if err != nil {
+        return nil, err
+    }
+
+    quota := &Quota{
+        Kind:      kind,
+        Limit:     s.quotas.limit(kind, user.IsPremium),
+        Used:      used,
+        Remaining: -1,
+        ResetsAt:  dayStart.AddDate(0, 0, 1),
+    }
+    if !quota.Unlimited() {
+        quota.Remaining = quota.Limit - used
+        if quota.Remaining < 0 {
+            quota.Remaining = 0
+        }
+    }
+    return quota, nil
+}
+
+// checkQuota returns the quota, or a QuotaExceededError if none is left
+func (s *service) checkQuota(ctx context.Context, userID int64, kind QuotaKind) (*Quota, error) {
+    quota, err := s.GetQuota(ctx, userID, kind)
+    if err != nil {
+        return nil, err
+    }
+    if quota.Exhausted() {
+        return nil, &QuotaExceededError{Quota: quota}
+    }
+    return quota, nil
+}
+
+// localMidnight returns the start of now's day in the given timezone,
+// falling back to UTC for unknown zones
+func localMidnight(now time.Time, timezone string) time.Time {
+    loc, err := time.LoadLocation(timezone)
+    if err != nil {
+        loc = time.UTC
+    }
+    local := now.In(loc)
+    return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, loc)
+}
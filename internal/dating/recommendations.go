@@ -5,94 +5,145 @@ package dating
 import (
     "context"
     "encoding/json"
+    "fmt"
+    "log"
     "sort"
+    "strings"
     "time"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
 )
 
 type RecommendationEngine struct {
-    service        Service
-    matchingEngine MatchingEngine
-    repo          Repository
+    service         Service
+    matchingEngine  MatchingEngine
+    repo            Repository
+    users           users.Client
+    newProfileBoost NewProfileBoost
+    hotpicks        HotpickConfig
+    events          *events.Bus
+    uow             *database.UnitOfWork
 }
 
 func NewRecommendationEngine(service Service, engine MatchingEngine, repo Repository) *RecommendationEngine {
     return &RecommendationEngine{
-        service:        service,
-        matchingEngine: engine,
-        repo:          repo,
+        service:         service,
+        matchingEngine:  engine,
+        repo:            repo,
+        newProfileBoost: defaultNewProfileBoost(),
+        hotpicks:        DefaultHotpickConfig(),
     }
 }
 
-func (r *RecommendationEngine) GenerateDailyHotpicks(ctx context.Context) error {
-    // Get all active users
+// GenerateDailyHotpicks gives each active user whose local day has reached
+// the ready hour their hotpicks for the day, unless they already have them.
+// Returns how many users got picks.
+func (r *RecommendationEngine) GenerateDailyHotpicks(ctx context.Context, now time.Time) (int, error) {
     activeUsers, err := r.repo.GetActiveUsers(ctx, 30)
     if err != nil {
-        return err
+        return 0, err
     }
     
+    generated := 0
     for _, user := range activeUsers {
-        // Skip if already generated today
-        hasToday, err := r.repo.HasTodayHotpicks(ctx, user.ID)
-        if err != nil || hasToday {
+        dayStart := localMidnight(now, user.Timezone)
+        if now.Sub(dayStart) < time.Duration(r.hotpicks.ReadyHour)*time.Hour {
             continue
         }
         
-        // Get user preferences and profile
-        userProfile, err := r.repo.GetUserProfile(ctx, user.ID)
+        created, err := r.generateHotpicks(ctx, user.ID, dayStart)
         if err != nil {
-            continue
+            log.Printf("Failed to generate hotpicks for user %d: %v", user.ID, err)
         }
-        
-        // Find candidates
-        candidates, err := r.findCandidates(ctx, user.ID, userProfile)
-        if err != nil {
-            continue
+        if created > 0 {
+            generated++
         }
-        
-        // Score and rank candidates
-        scoredCandidates := r.scoreAndRank(ctx, userProfile, candidates)
-        
-        // Create hotpicks (top 10)
-        for i, candidate := range scoredCandidates {
-            if i >= 10 {
-                break
-            }
-            
+        if err := ctx.Err(); err != nil {
+            return generated, err
+        }
+    }
+    
+    return generated, nil
+}
+
+// generateHotpicks creates the user's picks for the day starting at
+// dayStart, expiring when it ends, and announces them. Returns how many
+// were created; none if the user already has the day's picks.
+func (r *RecommendationEngine) generateHotpicks(ctx context.Context, userID int64, dayStart time.Time) (int, error) {
+    hasToday, err := r.repo.HasHotpicksSince(ctx, userID, dayStart)
+    if err != nil || hasToday {
+        return 0, err
+    }
+    
+    userProfile, err := getUserProfile(ctx, r.users, userID)
+    if err != nil {
+        return 0, err
+    }
+    
+    candidates, err := r.findCandidates(ctx, userID, userProfile)
+    if err != nil {
+        return 0, err
+    }
+    
+    picks := pickDiverse(r.scoreAndRank(ctx, userProfile, candidates), r.hotpicks.PerDay, r.hotpicks.MaxPerGroup)
+    if len(picks) == 0 {
+        return 0, nil
+    }
+    
+    expiresAt := dayStart.AddDate(0, 0, 1)
+    err = r.uow.Do(ctx, func(ctx context.Context) error {
+        for _, candidate := range picks {
             factorsJSON, _ := json.Marshal(candidate.Factors)
             
             hotpick := &Hotpick{
-                UserID:            user.ID,
+                UserID:            userID,
                 RecommendedUserID: candidate.UserID,
-                Score:            candidate.Score,
-                Reason:           &candidate.Reason,
-                Factors:          factorsJSON,
-                ExpiresAt:        ptr(time.Now().Add(24 * time.Hour)),
+                Score:             candidate.Score,
+                Reason:            &candidate.Reason,
+                Factors:           factorsJSON,
+                ExpiresAt:         &expiresAt,
+            }
+            if err := r.repo.CreateHotpick(ctx, hotpick); err != nil {
+                return err
             }
-            
-            r.repo.CreateHotpick(ctx, hotpick)
         }
+        
+        return r.events.Publish(ctx, fmt.Sprint(userID), events.HotpicksReady{
+            UserID: userID,
+            Count:  len(picks),
+        })
+    })
+    if err != nil {
+        return 0, err
     }
     
-    return nil
+    recordBoostImpressions(ctx, r.repo, picks)
+    return len(picks), nil
 }
 
+// findCandidates finds who the user's saved preferences allow
 func (r *RecommendationEngine) findCandidates(ctx context.Context, userID int64, profile *UserProfile) ([]*UserProfile, error) {
-    filters := &CandidateFilters{
-        ExcludeMatched:    true,
-        ExcludeBlocked:    true,
-        ExcludeDeclined:   true,
-        Gender:            derefString(profile.PreferredGender, ""),
-        MinAge:            derefInt(profile.PreferredMinAge, 18),
-        MaxAge:            derefInt(profile.PreferredMaxAge, 100),
-        MaxDistance:       derefFloat64(profile.PreferredDistance, 100.0),
-        Limit:             100,
-    }
-    
-    return r.repo.FindCandidates(ctx, userID, filters)
+    prefs, err := loadPreferences(ctx, r.repo, userID)
+    if err != nil {
+        return nil, err
+    }
+    
+    return r.repo.FindCandidates(ctx, userID, prefs.candidateFilters(profile))
 }
 
 func (r *RecommendationEngine) scoreAndRank(ctx context.Context, userProfile *UserProfile, candidates []*UserProfile) []*ScoredCandidate {
     scored := make([]*ScoredCandidate, 0, len(candidates))
+    now := time.Now()
+    
+    // Prompt answers only feed the explanation, so ranking goes ahead without them
+    userIDs := make([]int64, 0, len(candidates)+1)
+    userIDs = append(userIDs, userProfile.ID)
+    for _, candidate := range candidates {
+        userIDs = append(userIDs, candidate.ID)
+    }
+    answers, _ := r.repo.GetPromptAnswers(ctx, userIDs)
     
     for _, candidate := range candidates {
         score, factors, _ := r.matchingEngine.CalculateCompatibility(ctx, userProfile, candidate)
@@ -101,7 +152,7 @@ func (r *RecommendationEngine) scoreAndRank(ctx context.Context, userProfile *Us
         score = r.applyBoosters(ctx, userProfile, candidate, score)
         
         // Generate reason
-        reason := r.generateReason(factors, candidate)
+        reason := r.generateReason(factors, candidate, sharedPromptReason(answers[userProfile.ID], answers[candidate.ID]))
         
         scored = append(scored, &ScoredCandidate{
             UserID:  candidate.ID,
@@ -109,6 +160,7 @@ func (r *RecommendationEngine) scoreAndRank(ctx context.Context, userProfile *Us
             Score:   score,
             Factors: factors,
             Reason:  reason,
+            IsNew:   r.newProfileBoost.IsNew(candidate.ProfileCompletedAt, now),
         })
     }
     
@@ -123,10 +175,8 @@ func (r *RecommendationEngine) scoreAndRank(ctx context.Context, userProfile *Us
 func (r *RecommendationEngine) applyBoosters(ctx context.Context, user, candidate *UserProfile, baseScore float64) float64 {
     score := baseScore
     
-    // New user boost
-    if time.Since(candidate.CreatedAt) < 7*24*time.Hour {
-        score *= 1.2
-    }
+    // New profile boost, decays over the boost window
+    score *= r.newProfileBoost.Multiplier(candidate.ProfileCompletedAt, time.Now())
     
     // Active user boost
     if time.Since(candidate.LastActive) < 24*time.Hour {
@@ -143,16 +193,27 @@ func (r *RecommendationEngine) applyBoosters(ctx context.Context, user, candidat
         score *= 1.1
     }
     
-    // Mutual interests super boost
-    mutualInterests := r.countMutualInterests(user.Interests, candidate.Interests)
-    if mutualInterests >= 3 {
-        score *= 1.3
+    // Shared interests boost, 10% per shared interest up to three
+    shared := float64(len(sharedInterests(user.Interests, candidate.Interests)))
+    score *= 1 + 0.1*min(shared, 3)
+    
+    score = min(1.0, score)
+    
+    // Spotlight boost goes on after the cap so boosted profiles can outrank
+    // otherwise perfect matches while it runs
+    if isBoosted(candidate, time.Now()) && candidate.BoostMultiplier != nil {
+        score *= *candidate.BoostMultiplier
     }
     
-    return min(1.0, score)
+    return score
 }
 
-func (r *RecommendationEngine) generateReason(factors *CompatibilityFactors, candidate *UserProfile) string {
+func (r *RecommendationEngine) generateReason(factors *CompatibilityFactors, candidate *UserProfile, promptReason string) string {
+    // A shared prompt answer is the most personal reason, so it wins
+    if promptReason != "" {
+        return promptReason
+    }
+    
     reasons := []string{}
     
     if factors.InterestsMatch > 0.7 {
@@ -172,20 +233,33 @@ func (r *RecommendationEngine) generateReason(factors *CompatibilityFactors, can
     return candidate.DisplayName + " " + reasons[0]
 }
 
-func (r *RecommendationEngine) countMutualInterests(interests1, interests2 []string) int {
-    interestMap := make(map[string]bool)
-    for _, interest := range interests1 {
-        interestMap[interest] = true
+// sharedPromptReason explains the strongest prompt overlap between two users:
+// the same answer to the same prompt, then both having answered a prompt.
+// Returns "" when they have none in common.
+func sharedPromptReason(userAnswers, candidateAnswers []*PromptAnswer) string {
+    mine := make(map[int64]*PromptAnswer, len(userAnswers))
+    for _, answer := range userAnswers {
+        mine[answer.PromptID] = answer
     }
     
-    count := 0
-    for _, interest := range interests2 {
-        if interestMap[interest] {
-            count++
+    var samePrompt *PromptAnswer
+    for _, theirs := range candidateAnswers {
+        answer, ok := mine[theirs.PromptID]
+        if !ok {
+            continue
+        }
+        if strings.EqualFold(strings.TrimSpace(answer.Answer), strings.TrimSpace(theirs.Answer)) {
+            return fmt.Sprintf("You both answered %q to %q", theirs.Answer, theirs.Prompt)
+        }
+        if samePrompt == nil {
+            samePrompt = theirs
         }
     }
     
-    return count
+    if samePrompt != nil {
+        return fmt.Sprintf("You both answered %q", samePrompt.Prompt)
+    }
+    return ""
 }
 
 // Helper functions
@@ -220,4 +294,12 @@ func derefFloat64(f *float64, defaultValue float64) float64 {
         return *f
     }
     return defaultValue
-}
\ No newline at end of file
+}
+func calculateAge(birthDate time.Time) int {
+    now := time.Now()
+    age := now.Year() - birthDate.Year()
+    if now.YearDay() < birthDate.YearDay() {
+        age--
+    }
+    return age
+}
@@ -11,6 +11,10 @@ import (
     "time"
     
     "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
 )
 
 type Repository interface {
@@ -31,13 +35,14 @@ type Repository interface {
     
     // Hotpicks
     CreateHotpick(ctx context.Context, hotpick *Hotpick) error
+    GetHotpick(ctx context.Context, id int64) (*Hotpick, error)
     GetUserHotpicks(ctx context.Context, userID int64, limit int, excludeViewed bool) ([]*Hotpick, error)
     UpdateHotpick(ctx context.Context, hotpick *Hotpick) error
+    RewindHotpick(ctx context.Context, id, userID int64) (*Hotpick, error)
     DeleteExpiredHotpicks(ctx context.Context) error
-    HasTodayHotpicks(ctx context.Context, userID int64) (bool, error)
+    HasHotpicksSince(ctx context.Context, userID int64, since time.Time) (bool, error)
     
     // User Profiles for matching
-    GetUserProfile(ctx context.Context, userID int64) (*UserProfile, error)
     GetActiveUsers(ctx context.Context, daysActive int) ([]*UserProfile, error)
     FindCandidates(ctx context.Context, userID int64, filters *CandidateFilters) ([]*UserProfile, error)
     
@@ -45,6 +50,24 @@ type Repository interface {
     GetUserReportCount(ctx context.Context, userID int64, days int) (int, error)
     GetRecentRequestCount(ctx context.Context, userID int64, duration time.Duration) (int, error)
     GetDeclineCount(ctx context.Context, senderID, receiverID int64) (int, error)
+    
+    // Quotas
+    GetQuotaUser(ctx context.Context, userID int64) (*QuotaUser, error)
+    GetSwipeCountSince(ctx context.Context, userID int64, since time.Time) (int, error)
+    
+    // Prompts
+    GetPromptAnswers(ctx context.Context, userIDs []int64) (map[int64][]*PromptAnswer, error)
+    
+    // Preferences
+    GetPreferences(ctx context.Context, userID int64) (*Preferences, error)
+    UpsertPreferences(ctx context.Context, prefs *Preferences) error
+    DeletePreferences(ctx context.Context, userID int64) error
+    
+    // Spotlight boosts
+    CreateBoost(ctx context.Context, boost *ProfileBoost, monthlyAllowance int, monthStart time.Time) error
+    GetLatestBoost(ctx context.Context, userID int64) (*ProfileBoost, error)
+    CountBoostsSince(ctx context.Context, userID int64, source string, since time.Time) (int, error)
+    RecordBoostImpressions(ctx context.Context, userIDs []int64) error
 
     // FIXED: Add GetDB method to expose database connection when needed
     GetDB() *sqlx.DB
@@ -73,7 +96,7 @@ func (r *postgresRepository) CreateDateRequest(ctx context.Context, req *DateReq
         RETURNING id, created_at, updated_at
     `
     
-    err := r.db.QueryRowxContext(
+    err := database.ConnX(ctx, r.db).QueryRowxContext(
         ctx, query,
         req.SenderID, req.ReceiverID, req.Message, req.ProposedDate,
         req.Location, req.LocationLat, req.LocationLng,
@@ -112,7 +135,7 @@ func (r *postgresRepository) UpdateDateRequest(ctx context.Context, req *DateReq
         WHERE id = $1
     `
     
-    _, err := r.db.ExecContext(
+    _, err := database.ConnX(ctx, r.db).ExecContext(
         ctx, query,
         req.ID, req.Status, req.ResponseMessage,
         req.DeclinedReason, req.RespondedAt,
@@ -208,10 +231,30 @@ func (r *postgresRepository) HasPendingRequest(ctx context.Context, senderID, re
     return exists, err
 }
 
+func (r *postgresRepository) GetHotpick(ctx context.Context, id int64) (*Hotpick, error) {
+    var hotpick Hotpick
+    query := `
+        SELECT id, user_id, recommended_user_id, score, reason, factors,
+               is_seen, is_acted_on, action_type, acted_at, expires_at, created_at
+        FROM hotpicks
+        WHERE id = $1
+    `
+    
+    err := r.db.GetContext(ctx, &hotpick, query, id)
+    if err == sql.ErrNoRows {
+        return nil, ErrHotpickNotFound
+    }
+    
+    return &hotpick, err
+}
+
 func (r *postgresRepository) UpdateHotpick(ctx context.Context, hotpick *Hotpick) error {
+    // acted_at is stamped once, on the first action, and drives the swipe quota
     query := `
         UPDATE hotpicks
-        SET is_seen = $2, is_acted_on = $3, action_type = $4
+        SET is_seen = $2, is_acted_on = $3, action_type = $4,
+            acted_at = CASE WHEN $3 AND acted_at IS NULL THEN NOW() ELSE acted_at END,
+            rewound_at = CASE WHEN $3 THEN NULL ELSE rewound_at END
         WHERE id = $1
     `
     
@@ -223,6 +266,28 @@ func (r *postgresRepository) UpdateHotpick(ctx context.Context, hotpick *Hotpick
     return err
 }
 
+// RewindHotpick undoes a pass on one of the user's unexpired hotpicks,
+// marking it rewound so it is listed first. acted_at is kept for the swipe
+// quota. Returns ErrHotpickNotFound when there is no such pass to undo.
+func (r *postgresRepository) RewindHotpick(ctx context.Context, id, userID int64) (*Hotpick, error) {
+    var hotpick Hotpick
+    query := `
+        UPDATE hotpicks
+        SET is_seen = FALSE, is_acted_on = FALSE, action_type = NULL, rewound_at = NOW()
+        WHERE id = $1 AND user_id = $2 AND action_type = 'pass'
+              AND (expires_at IS NULL OR expires_at > NOW())
+        RETURNING id, user_id, recommended_user_id, score, reason, factors,
+                  is_seen, is_acted_on, action_type, acted_at, expires_at, created_at
+    `
+    
+    err := r.db.GetContext(ctx, &hotpick, query, id, userID)
+    if err == sql.ErrNoRows {
+        return nil, ErrHotpickNotFound
+    }
+    
+    return &hotpick, err
+}
+
 // Match Methods
 
 func (r *postgresRepository) CreateMatch(ctx context.Context, match *Match) error {
@@ -244,7 +309,7 @@ func (r *postgresRepository) CreateMatch(ctx context.Context, match *Match) erro
         RETURNING id, matched_at
     `
     
-    err := r.db.QueryRowxContext(
+    err := database.ConnX(ctx, r.db).QueryRowxContext(
         ctx, query,
         match.User1ID, match.User2ID, match.MatchType, match.CompatibilityScore,
     ).Scan(&match.ID, &match.MatchedAt)
@@ -289,6 +354,7 @@ func (r *postgresRepository) GetUserMatches(ctx context.Context, userID int64, a
         JOIN users u1 ON m.user1_id = u1.id
         JOIN users u2 ON m.user2_id = u2.id
         WHERE (m.user1_id = $1 OR m.user2_id = $1) AND m.is_active = $2
+              AND ` + blocks.NotBlockedSQL("m.user1_id", "m.user2_id") + `
         ORDER BY m.matched_at DESC
     `
     
@@ -370,7 +436,7 @@ func (r *postgresRepository) CreateHotpick(ctx context.Context, hotpick *Hotpick
         RETURNING id, created_at
     `
     
-    err := r.db.QueryRowxContext(
+    err := database.ConnX(ctx, r.db).QueryRowxContext(
         ctx, query,
         hotpick.UserID, hotpick.RecommendedUserID,
         hotpick.Score, hotpick.Reason, factorsJSON, hotpick.ExpiresAt,
@@ -379,59 +445,49 @@ func (r *postgresRepository) CreateHotpick(ctx context.Context, hotpick *Hotpick
     return err
 }
 
-func (r *postgresRepository) HasTodayHotpicks(ctx context.Context, userID int64) (bool, error) {
+// HasHotpicksSince reports whether the user was given hotpicks since the
+// start of their day
+func (r *postgresRepository) HasHotpicksSince(ctx context.Context, userID int64, since time.Time) (bool, error) {
     var exists bool
     query := `
         SELECT EXISTS(
             SELECT 1 FROM hotpicks
-            WHERE user_id = $1 AND DATE(created_at) = CURRENT_DATE
+            WHERE user_id = $1 AND created_at >= $2
         )
     `
     
-    err := r.db.GetContext(ctx, &exists, query, userID)
+    err := r.db.GetContext(ctx, &exists, query, userID, since)
     return exists, err
 }
 
-func (r *postgresRepository) GetUserProfile(ctx context.Context, userID int64) (*UserProfile, error) {
-    var profile UserProfile
-    query := `
-        SELECT id, username, display_name, bio, birth_date, gender,
-               profile_picture, location_lat, location_lng, interests,
-               looking_for, last_active, is_verified, created_at
-        FROM users
-        WHERE id = $1
-    `
-    
-    err := r.db.GetContext(ctx, &profile, query, userID)
-    if err == sql.ErrNoRows {
-        return nil, errors.New("user profile not found")
-    }
-    
-    return &profile, err
-}
-
 func (r *postgresRepository) GetUserHotpicks(ctx context.Context, userID int64, limit int, excludeViewed bool) ([]*Hotpick, error) {
     var hotpicks []*Hotpick
     
     query := `
-        SELECT h.*,
+        SELECT h.id, h.user_id, h.recommended_user_id, h.score, h.reason,
+               h.factors, h.is_seen, h.is_acted_on, h.action_type,
+               h.expires_at, h.created_at,
                u.id as "recommended_user.id",
                u.username as "recommended_user.username",
                u.display_name as "recommended_user.display_name",
                u.profile_picture as "recommended_user.profile_picture",
                u.bio as "recommended_user.bio",
-               EXTRACT(YEAR FROM AGE(u.birth_date)) as "recommended_user.age"
+               EXTRACT(YEAR FROM AGE(u.date_of_birth)) as "recommended_user.age",
+               u.profile_completed_at as "recommended_user.profile_completed_at"
         FROM hotpicks h
         JOIN users u ON h.recommended_user_id = u.id
         WHERE h.user_id = $1 
               AND (h.expires_at IS NULL OR h.expires_at > NOW())
+              AND COALESCE(u.is_profile_complete, false)
+              AND ` + blocks.NotBlockedSQL("$1", "h.recommended_user_id") + `
     `
     
     if excludeViewed {
         query += " AND h.is_seen = FALSE"
     }
     
-    query += " ORDER BY h.score DESC, h.created_at DESC LIMIT $2"
+    // A rewound pass comes back as the next pick
+    query += " ORDER BY h.rewound_at DESC NULLS LAST, h.score DESC, h.created_at DESC LIMIT $2"
     
     rows, err := r.db.QueryxContext(ctx, query, userID, limit)
     if err != nil {
@@ -450,6 +506,7 @@ func (r *postgresRepository) GetUserHotpicks(ctx context.Context, userID int64,
             &hotpick.ExpiresAt, &hotpick.CreatedAt,
             &user.ID, &user.Username, &user.DisplayName,
             &user.ProfilePicture, &user.Bio, &user.Age,
+            &user.ProfileCompletedAt,
         )
         if err != nil {
             continue
@@ -475,9 +532,13 @@ func (r *postgresRepository) DeleteExpiredHotpicks(ctx context.Context) error {
 func (r *postgresRepository) GetActiveUsers(ctx context.Context, daysActive int) ([]*UserProfile, error) {
     var users []*UserProfile
     query := `
-        SELECT id, username, display_name, bio, birth_date, gender,
-               profile_picture, location_lat, location_lng, interests,
-               looking_for, last_active, is_verified, created_at
+        SELECT id, username, COALESCE(display_name, username) AS display_name, bio,
+               date_of_birth AS birth_date, COALESCE(gender, '') AS gender, profile_picture,
+               COALESCE(latitude, 0) AS location_lat, COALESCE(longitude, 0) AS location_lng,
+               interests, COALESCE(looking_for, '') AS looking_for,
+               COALESCE(last_active, created_at) AS last_active,
+               COALESCE(is_verified, false) AS is_verified, profile_completed_at, created_at,
+               COALESCE(timezone, 'UTC') AS timezone
         FROM users
         WHERE last_active > NOW() - INTERVAL '%d days'
         AND is_profile_complete = TRUE
@@ -492,10 +553,21 @@ func (r *postgresRepository) FindCandidates(ctx context.Context, userID int64, f
     var candidates []*UserProfile
     
     query := `
-        SELECT DISTINCT u.id, u.username, u.display_name, u.bio, u.birth_date, 
-               u.gender, u.profile_picture, u.location_lat, u.location_lng, 
-               u.interests, u.looking_for, u.last_active, u.is_verified, u.created_at
+        SELECT DISTINCT u.id, u.username, COALESCE(u.display_name, u.username) AS display_name,
+               u.bio, u.date_of_birth AS birth_date, COALESCE(u.gender, '') AS gender,
+               u.profile_picture, COALESCE(u.latitude, 0) AS location_lat,
+               COALESCE(u.longitude, 0) AS location_lng, u.location AS city, u.interests,
+               COALESCE(u.looking_for, '') AS looking_for,
+               COALESCE(u.last_active, u.created_at) AS last_active,
+               COALESCE(u.is_verified, false) AS is_verified, u.profile_completed_at, u.created_at,
+               pb.expires_at AS boosted_until, pb.multiplier AS boost_multiplier
         FROM users u
+        LEFT JOIN LATERAL (
+            SELECT expires_at, multiplier FROM profile_boosts
+            WHERE user_id = u.id AND expires_at > NOW()
+            ORDER BY expires_at DESC
+            LIMIT 1
+        ) pb ON TRUE
         WHERE u.id != $1
         AND u.is_profile_complete = TRUE
     `
@@ -503,24 +575,53 @@ func (r *postgresRepository) FindCandidates(ctx context.Context, userID int64, f
     args := []interface{}{userID}
     argCount := 1
     
-    if filters.Gender != "" {
+    if len(filters.Genders) > 0 {
+        argCount++
+        query += fmt.Sprintf(" AND u.gender = ANY($%d)", argCount)
+        args = append(args, pq.Array(filters.Genders))
+    }
+    
+    if filters.LookingFor != "" {
         argCount++
-        query += fmt.Sprintf(" AND u.gender = $%d", argCount)
-        args = append(args, filters.Gender)
+        query += fmt.Sprintf(" AND u.looking_for = $%d", argCount)
+        args = append(args, filters.LookingFor)
     }
     
     if filters.MinAge > 0 {
         argCount++
-        query += fmt.Sprintf(" AND EXTRACT(YEAR FROM AGE(u.birth_date)) >= $%d", argCount)
+        query += fmt.Sprintf(" AND EXTRACT(YEAR FROM AGE(u.date_of_birth)) >= $%d", argCount)
         args = append(args, filters.MinAge)
     }
     
     if filters.MaxAge > 0 {
         argCount++
-        query += fmt.Sprintf(" AND EXTRACT(YEAR FROM AGE(u.birth_date)) <= $%d", argCount)
+        query += fmt.Sprintf(" AND EXTRACT(YEAR FROM AGE(u.date_of_birth)) <= $%d", argCount)
         args = append(args, filters.MaxAge)
     }
     
+    if filters.MaxDistance > 0 && (filters.Latitude != 0 || filters.Longitude != 0) {
+        // Users who haven't shared a location aren't ruled out
+        query += fmt.Sprintf(" AND (u.latitude IS NULL OR u.longitude IS NULL OR %s <= $%d)",
+            distanceSQL(fmt.Sprintf("$%d", argCount+1), fmt.Sprintf("$%d", argCount+2), "u.latitude", "u.longitude"),
+            argCount+3)
+        args = append(args, filters.Latitude, filters.Longitude, filters.MaxDistance)
+        argCount += 3
+    }
+    
+    if filters.VerifiedOnly {
+        query += " AND u.is_photo_verified = TRUE"
+    }
+    
+    if len(filters.Interests) > 0 {
+        argCount++
+        query += fmt.Sprintf(" AND u.interests && $%d", argCount)
+        args = append(args, pq.Array(filters.Interests))
+    }
+    
+    if filters.ExcludeBlocked {
+        query += " AND " + blocks.NotBlockedSQL("$1", "u.id")
+    }
+    
     if filters.ExcludeMatched {
         query += `
             AND u.id NOT IN (
@@ -534,6 +635,9 @@ func (r *postgresRepository) FindCandidates(ctx context.Context, userID int64, f
         `
     }
     
+    // Boosted users first, so the limit never cuts them off
+    query += " ORDER BY boosted_until DESC NULLS LAST"
+    
     if filters.Limit > 0 {
         query += fmt.Sprintf(" LIMIT %d", filters.Limit)
     }
@@ -542,6 +646,61 @@ func (r *postgresRepository) FindCandidates(ctx context.Context, userID int64, f
     return candidates, err
 }
 
+// distanceSQL is the great-circle distance in km between two points given
+// as SQL expressions
+func distanceSQL(lat1, lng1, lat2, lng2 string) string {
+    return fmt.Sprintf(`(6371 * 2 * ASIN(SQRT(
+        POWER(SIN(RADIANS(%[3]s - %[1]s) / 2), 2) +
+        COS(RADIANS(%[1]s)) * COS(RADIANS(%[3]s)) * POWER(SIN(RADIANS(%[4]s - %[2]s) / 2), 2))))`,
+        lat1, lng1, lat2, lng2)
+}
+
+// GetPreferences gets the user's saved preferences, or nil if they haven't
+// saved any
+func (r *postgresRepository) GetPreferences(ctx context.Context, userID int64) (*Preferences, error) {
+    var prefs Preferences
+    query := `
+        SELECT user_id, min_age, max_age, max_distance_km, genders, intent, updated_at
+        FROM dating_preferences
+        WHERE user_id = $1
+    `
+    
+    err := r.db.GetContext(ctx, &prefs, query, userID)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    return &prefs, nil
+}
+
+func (r *postgresRepository) UpsertPreferences(ctx context.Context, prefs *Preferences) error {
+    query := `
+        INSERT INTO dating_preferences (user_id, min_age, max_age, max_distance_km, genders, intent, updated_at)
+        VALUES ($1, $2, $3, $4, $5, $6, NOW())
+        ON CONFLICT (user_id) DO UPDATE SET
+            min_age = EXCLUDED.min_age,
+            max_age = EXCLUDED.max_age,
+            max_distance_km = EXCLUDED.max_distance_km,
+            genders = EXCLUDED.genders,
+            intent = EXCLUDED.intent,
+            updated_at = EXCLUDED.updated_at
+        RETURNING updated_at
+    `
+    
+    return r.db.QueryRowContext(ctx, query,
+        prefs.UserID, prefs.MinAge, prefs.MaxAge, prefs.MaxDistanceKm,
+        prefs.Genders, prefs.Intent,
+    ).Scan(&prefs.UpdatedAt)
+}
+
+func (r *postgresRepository) DeletePreferences(ctx context.Context, userID int64) error {
+    _, err := r.db.ExecContext(ctx, `DELETE FROM dating_preferences WHERE user_id = $1`, userID)
+    return err
+}
+
 func (r *postgresRepository) GetUserReportCount(ctx context.Context, userID int64, days int) (int, error) {
     var count int
     query := `
@@ -566,6 +725,162 @@ func (r *postgresRepository) GetRecentRequestCount(ctx context.Context, userID i
     return count, err
 }
 
+func (r *postgresRepository) GetQuotaUser(ctx context.Context, userID int64) (*QuotaUser, error) {
+    var user QuotaUser
+    query := `
+        SELECT COALESCE(timezone, 'UTC') AS timezone, COALESCE(is_premium, false) AS is_premium,
+               COALESCE(boost_credits, 0) AS boost_credits
+        FROM users
+        WHERE id = $1
+    `
+    
+    err := r.db.GetContext(ctx, &user, query, userID)
+    if err == sql.ErrNoRows {
+        return nil, errors.New("user profile not found")
+    }
+    
+    return &user, err
+}
+
+func (r *postgresRepository) GetSwipeCountSince(ctx context.Context, userID int64, since time.Time) (int, error) {
+    var count int
+    query := `
+        SELECT COUNT(*) FROM hotpicks
+        WHERE user_id = $1
+        AND is_acted_on = TRUE
+        AND acted_at >= $2
+    `
+    
+    err := r.db.GetContext(ctx, &count, query, userID, since)
+    return count, err
+}
+
+// GetPromptAnswers loads icebreaker answers for the given users, keyed by user
+func (r *postgresRepository) GetPromptAnswers(ctx context.Context, userIDs []int64) (map[int64][]*PromptAnswer, error) {
+    query := `
+        SELECT a.user_id, a.prompt_id, p.text AS prompt, a.answer
+        FROM user_prompt_answers a
+        JOIN profile_prompts p ON p.id = a.prompt_id
+        WHERE a.user_id = ANY($1)
+        ORDER BY a.user_id, a.position
+    `
+    
+    var answers []*PromptAnswer
+    if err := r.db.SelectContext(ctx, &answers, query, pq.Array(userIDs)); err != nil {
+        return nil, err
+    }
+    
+    byUser := make(map[int64][]*PromptAnswer)
+    for _, answer := range answers {
+        byUser[answer.UserID] = append(byUser[answer.UserID], answer)
+    }
+    return byUser, nil
+}
+
+// CreateBoost starts a boost, charging the monthly allowance if any is left
+// and a purchased credit otherwise. The user row is locked so concurrent
+// activations can't overlap or overspend.
+func (r *postgresRepository) CreateBoost(ctx context.Context, boost *ProfileBoost, monthlyAllowance int, monthStart time.Time) error {
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
+    var credits int
+    err = tx.GetContext(ctx, &credits, `SELECT COALESCE(boost_credits, 0) FROM users WHERE id = $1 FOR UPDATE`, boost.UserID)
+    if err != nil {
+        return err
+    }
+    
+    var active bool
+    err = tx.GetContext(ctx, &active, `SELECT EXISTS(SELECT 1 FROM profile_boosts WHERE user_id = $1 AND expires_at > NOW())`, boost.UserID)
+    if err != nil {
+        return err
+    }
+    if active {
+        return ErrBoostActive
+    }
+    
+    var usedMonthly int
+    err = tx.GetContext(ctx, &usedMonthly, `
+        SELECT COUNT(*) FROM profile_boosts
+        WHERE user_id = $1 AND source = $2 AND started_at >= $3
+    `, boost.UserID, BoostSourceMonthly, monthStart)
+    if err != nil {
+        return err
+    }
+    
+    switch {
+    case usedMonthly < monthlyAllowance:
+        boost.Source = BoostSourceMonthly
+    case credits > 0:
+        boost.Source = BoostSourceCredit
+        if _, err := tx.ExecContext(ctx, `UPDATE users SET boost_credits = boost_credits - 1 WHERE id = $1`, boost.UserID); err != nil {
+            return err
+        }
+    default:
+        return ErrNoBoostCredits
+    }
+    
+    query := `
+        INSERT INTO profile_boosts (user_id, source, multiplier, started_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id
+    `
+    err = tx.QueryRowxContext(ctx, query,
+        boost.UserID, boost.Source, boost.Multiplier, boost.StartedAt, boost.ExpiresAt,
+    ).Scan(&boost.ID)
+    if err != nil {
+        return err
+    }
+    
+    return tx.Commit()
+}
+
+func (r *postgresRepository) GetLatestBoost(ctx context.Context, userID int64) (*ProfileBoost, error) {
+    var boost ProfileBoost
+    query := `
+        SELECT id, user_id, source, multiplier, impressions, started_at, expires_at
+        FROM profile_boosts
+        WHERE user_id = $1
+        ORDER BY started_at DESC
+        LIMIT 1
+    `
+    
+    err := r.db.GetContext(ctx, &boost, query, userID)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    return &boost, nil
+}
+
+func (r *postgresRepository) CountBoostsSince(ctx context.Context, userID int64, source string, since time.Time) (int, error) {
+    var count int
+    query := `
+        SELECT COUNT(*) FROM profile_boosts
+        WHERE user_id = $1 AND source = $2 AND started_at >= $3
+    `
+    
+    err := r.db.GetContext(ctx, &count, query, userID, source, since)
+    return count, err
+}
+
+func (r *postgresRepository) RecordBoostImpressions(ctx context.Context, userIDs []int64) error {
+    query := `
+        UPDATE profile_boosts
+        SET impressions = impressions + 1
+        WHERE user_id = ANY($1) AND expires_at > NOW()
+    `
+    
+    _, err := r.db.ExecContext(ctx, query, pq.Array(userIDs))
+    return err
+}
+
 func (r *postgresRepository) GetDeclineCount(ctx context.Context, senderID, receiverID int64) (int, error) {
     var count int
     query := `
@@ -0,0 +1,135 @@
+// internal/dating/rewind.go
+
+package dating
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+)
+
+var (
+    ErrRewindNotAllowed = errors.New("rewind is a premium feature")
+    ErrNothingToRewind  = errors.New("no recent pass to rewind")
+)
+
+const (
+    DefaultRewindWindow = 5 * time.Minute
+
+    // swipeStackSize is how many of a user's latest swipes are kept for rewind
+    swipeStackSize = 20
+)
+
+// Entitlements is the part of billing dating needs
+type Entitlements interface {
+    CanRewind(ctx context.Context, userID int64) (bool, error)
+}
+
+// recentSwipe is one entry on a user's recent-swipe stack in Redis
+type recentSwipe struct {
+    HotpickID int64     `json:"hotpick_id"`
+    UserID    int64     `json:"user_id"`
+    Action    string    `json:"action"`
+    At        time.Time `json:"at"`
+}
+
+func swipeStackKey(userID int64) string {
+    return fmt.Sprintf("dating:swipes:%d", userID)
+}
+
+// pushSwipe puts a swipe on top of the user's recent-swipe stack. The stack
+// outlives its newest swipe by the rewind window, so idle stacks clean
+// themselves up.
+func (s *service) pushSwipe(ctx context.Context, userID int64, swipe recentSwipe) {
+    if s.redis == nil || s.rewindWindow <= 0 {
+        return
+    }
+
+    data, err := json.Marshal(swipe)
+    if err != nil {
+        return
+    }
+
+    key := swipeStackKey(userID)
+    pipe := s.redis.TxPipeline()
+    pipe.LPush(ctx, key, data)
+    pipe.LTrim(ctx, key, 0, swipeStackSize-1)
+    pipe.Expire(ctx, key, s.rewindWindow)
+    if _, err := pipe.Exec(ctx); err != nil {
+        log.Printf("Failed to record swipe for user %d: %v", userID, err)
+    }
+}
+
+// RewindSwipe undoes the user's most recent pass within the rewind window.
+// The hotpick goes back to unseen and is listed first, so the profile is the
+// next one they see. The original swipe still counts against today's quota,
+// and swiping the profile again doesn't count a second time.
+func (s *service) RewindSwipe(ctx context.Context, userID int64) (*Hotpick, error) {
+    allowed, err := s.canRewind(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if !allowed {
+        return nil, ErrRewindNotAllowed
+    }
+    if s.redis == nil || s.rewindWindow <= 0 {
+        return nil, ErrNothingToRewind
+    }
+
+    key := swipeStackKey(userID)
+    entries, err := s.redis.LRange(ctx, key, 0, -1).Result()
+    if err != nil {
+        return nil, fmt.Errorf("failed to load recent swipes: %w", err)
+    }
+
+    cutoff := time.Now().Add(-s.rewindWindow)
+    for _, entry := range entries {
+        var swipe recentSwipe
+        if err := json.Unmarshal([]byte(entry), &swipe); err != nil {
+            continue
+        }
+        // Newest first, so everything from here on is too old
+        if swipe.At.Before(cutoff) {
+            break
+        }
+        if swipe.Action != "pass" {
+            continue
+        }
+
+        // Taking the entry off the stack claims it, so a double tap
+        // can't rewind the same pass twice
+        removed, err := s.redis.LRem(ctx, key, 1, entry).Result()
+        if err != nil {
+            return nil, fmt.Errorf("failed to claim swipe: %w", err)
+        }
+        if removed == 0 {
+            continue
+        }
+
+        hotpick, err := s.repo.RewindHotpick(ctx, swipe.HotpickID, userID)
+        if err == ErrHotpickNotFound {
+            // Expired or swiped again since
+            continue
+        }
+        if err != nil {
+            return nil, err
+        }
+        return hotpick, nil
+    }
+
+    return nil, ErrNothingToRewind
+}
+
+func (s *service) canRewind(ctx context.Context, userID int64) (bool, error) {
+    if s.entitlements != nil {
+        return s.entitlements.CanRewind(ctx, userID)
+    }
+    user, err := s.repo.GetQuotaUser(ctx, userID)
+    if err != nil {
+        return false, err
+    }
+    return user.IsPremium, nil
+}
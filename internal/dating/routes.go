@@ -16,6 +16,9 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.M
     api.HandleFunc("/requests/{id}/cancel", handler.CancelRequest).Methods("POST")
     api.HandleFunc("/upcoming", handler.GetUpcomingDates).Methods("GET")
     
+    // Daily quotas
+    api.HandleFunc("/quotas", handler.GetQuotas).Methods("GET")
+    
     // Matches
     api.HandleFunc("/matches", handler.GetMatches).Methods("GET")
     api.HandleFunc("/matches/{id}/unmatch", handler.Unmatch).Methods("POST")
@@ -25,6 +28,16 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.M
     api.HandleFunc("/hotpicks", handler.GetHotpicks).Methods("GET")
     api.HandleFunc("/hotpicks/{id}/action", handler.RecordAction).Methods("POST")
     api.HandleFunc("/hotpicks/generate", handler.GenerateHotpicks).Methods("POST")
+    api.HandleFunc("/swipes/rewind", handler.RewindSwipe).Methods("POST")
+    
+    // Spotlight boost
+    api.HandleFunc("/boost", handler.ActivateBoost).Methods("POST")
+    api.HandleFunc("/boost", handler.GetBoost).Methods("GET")
+    
+    // Preferences
+    api.HandleFunc("/preferences", handler.GetPreferences).Methods("GET")
+    api.HandleFunc("/preferences", handler.UpdatePreferences).Methods("PUT")
+    api.HandleFunc("/preferences", handler.ResetPreferences).Methods("DELETE")
     
     // Compatibility
     api.HandleFunc("/compatibility/{userId}", handler.GetCompatibility).Methods("GET")
@@ -15,8 +15,11 @@ func NewScheduler(service Service) *Scheduler {
 }
 
 func (s *Scheduler) Start(ctx context.Context) {
-    // Daily hotpicks generation at 9 AM
-    go s.runDaily(ctx, 9, 0, s.service.GenerateDailyHotpicks)
+    // Hotpicks hourly, so each timezone gets them at its own ready hour
+    go s.runHourly(ctx, func(ctx context.Context) error {
+        _, err := s.service.GenerateDailyHotpicks(ctx, time.Now())
+        return err
+    })
     
     // Date reminders every hour
     go s.runHourly(ctx, s.service.SendDateReminders)
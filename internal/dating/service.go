@@ -5,7 +5,14 @@ package dating
 import (
     "context"
     "errors"
+    "fmt"
     "time"
+    
+    "github.com/go-redis/redis/v8"
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
 )
 
 var (
@@ -15,11 +22,21 @@ var (
     ErrAlreadyMatched = errors.New("already matched with this user")
     ErrNotMatched = errors.New("not matched with this user")
     ErrUnauthorized = errors.New("unauthorized to perform this action")
+    ErrHotpickNotFound = errors.New("hotpick not found")
+    ErrAlreadyActedOn = errors.New("hotpick already acted on")
+    ErrInvalidAction = errors.New("invalid hotpick action")
+    ErrUserBlocked = errors.New("user is blocked")
+    ErrOnboardingIncomplete = errors.New("finish onboarding to start swiping")
 )
 
+// Onboarding is the part of onboarding dating needs
+type Onboarding interface {
+    CanSwipe(ctx context.Context, userID int64) (bool, error)
+}
+
 type Service interface {
     // Date Requests
-    CreateDateRequest(ctx context.Context, userID int64, dto *CreateDateRequestDTO) (*DateRequest, error)
+    CreateDateRequest(ctx context.Context, userID int64, dto *CreateDateRequestDTO) (*DateRequest, *Quota, error)
     RespondToDateRequest(ctx context.Context, requestID int64, userID int64, dto *RespondDateRequestDTO) (*DateRequest, error)
     GetDateRequests(ctx context.Context, userID int64, requestType string) ([]*DateRequest, error)
     CancelDateRequest(ctx context.Context, requestID int64, userID int64) error
@@ -34,16 +51,43 @@ type Service interface {
     // Hotpicks & Recommendations
     GenerateHotpicks(ctx context.Context, userID int64) error
     GetHotpicks(ctx context.Context, userID int64, params *GetHotpicksParams) ([]*Hotpick, error)
-    RecordHotpickAction(ctx context.Context, hotpickID int64, action string) error
+    RecordHotpickAction(ctx context.Context, userID, hotpickID int64, action string) (*Quota, error)
+    RewindSwipe(ctx context.Context, userID int64) (*Hotpick, error)
+    
+    // Quotas
+    GetQuota(ctx context.Context, userID int64, kind QuotaKind) (*Quota, error)
+    
+    // Spotlight boosts
+    ActivateBoost(ctx context.Context, userID int64) (*ProfileBoost, error)
+    GetBoostStatus(ctx context.Context, userID int64) (*BoostStatus, error)
+    
+    // Preferences
+    GetPreferences(ctx context.Context, userID int64) (*Preferences, error)
+    UpdatePreferences(ctx context.Context, userID int64, dto *UpdatePreferencesDTO) (*Preferences, error)
+    ResetPreferences(ctx context.Context, userID int64) error
     
     // Matching Algorithm
     CalculateCompatibility(ctx context.Context, user1ID, user2ID int64) (float64, *CompatibilityFactors, error)
     FindPotentialMatches(ctx context.Context, userID int64, filters *MatchFilters) ([]*UserInfo, error)
     
     // Scheduled Jobs
-    GenerateDailyHotpicks(ctx context.Context) error
+    GenerateDailyHotpicks(ctx context.Context, now time.Time) (int, error)
     SendDateReminders(ctx context.Context) error
     CleanupExpiredHotpicks(ctx context.Context) error
+    
+    // Configuration
+    SetNewProfileBoostWindow(window time.Duration)
+    SetQuotas(quotas QuotaConfig)
+    SetBoosts(boosts BoostConfig)
+    SetHotpicks(hotpicks HotpickConfig)
+    SetRewindWindow(window time.Duration)
+    SetRedis(redisClient *redis.Client)
+    SetEntitlements(entitlements Entitlements)
+    SetOnboarding(onboarding Onboarding)
+    SetBlocks(blockService blocks.Service)
+    SetEvents(bus *events.Bus)
+    SetUnitOfWork(uow *database.UnitOfWork)
+    SetUsers(client users.Client)
 }
 
 type service struct {
@@ -51,17 +95,113 @@ type service struct {
     matchingEngine  MatchingEngine
     profileService  interface{}
     notifyService   interface{}
+    newProfileBoost NewProfileBoost
+    quotas          QuotaConfig
+    boosts          BoostConfig
+    hotpicks        HotpickConfig
+    rewindWindow    time.Duration
+    redis           *redis.Client
+    entitlements    Entitlements
+    onboarding      Onboarding
+    blocks          blocks.Service
+    users           users.Client
+    events          *events.Bus
+    uow             *database.UnitOfWork
 }
 
 func NewService(repo Repository, matchingEngine MatchingEngine, profileService interface{}, notifyService interface{}) Service {
     return &service{
-        repo:           repo,
-        matchingEngine: matchingEngine,
-        profileService: profileService,
-        notifyService:  notifyService,
+        repo:            repo,
+        matchingEngine:  matchingEngine,
+        profileService:  profileService,
+        notifyService:   notifyService,
+        newProfileBoost: defaultNewProfileBoost(),
+        quotas:          DefaultQuotaConfig(),
+        boosts:          DefaultBoostConfig(),
+        hotpicks:        DefaultHotpickConfig(),
+        rewindWindow:    DefaultRewindWindow,
     }
 }
 
+// SetNewProfileBoostWindow configures how long freshly completed profiles are boosted.
+// A zero window disables the boost.
+func (s *service) SetNewProfileBoostWindow(window time.Duration) {
+    s.newProfileBoost.Window = window
+}
+
+// SetQuotas configures the daily swipe and date request allowances
+func (s *service) SetQuotas(quotas QuotaConfig) {
+    s.quotas = quotas
+}
+
+// SetBoosts configures spotlight boost length, strength and monthly allowances
+func (s *service) SetBoosts(boosts BoostConfig) {
+    s.boosts = boosts
+}
+
+// SetHotpicks configures how many daily hotpicks users get, when, and how
+// varied they are
+func (s *service) SetHotpicks(hotpicks HotpickConfig) {
+    s.hotpicks = hotpicks
+}
+
+// SetRewindWindow configures how long after a pass it can be rewound. A
+// zero window disables rewind.
+func (s *service) SetRewindWindow(window time.Duration) {
+    s.rewindWindow = window
+}
+
+// SetRedis sets the client holding each user's recent swipes for rewind.
+// redisClient may be nil, in which case there is nothing to rewind.
+func (s *service) SetRedis(redisClient *redis.Client) {
+    s.redis = redisClient
+}
+
+// SetEntitlements sets the billing checker for rewind access. Without one,
+// the users.is_premium flag is used.
+func (s *service) SetEntitlements(entitlements Entitlements) {
+    s.entitlements = entitlements
+}
+
+// SetOnboarding gates swiping behind the onboarding steps it needs. Without
+// one, anyone can swipe.
+func (s *service) SetOnboarding(onboarding Onboarding) {
+    s.onboarding = onboarding
+}
+
+// SetBlocks sets the shared blocks service, so users with a block in either
+// direction can't send each other date requests
+func (s *service) SetBlocks(blockService blocks.Service) {
+    s.blocks = blockService
+}
+
+// SetEvents sets the bus MatchCreated, HotpicksReady and saved preferences
+// are published on, for analytics, notifications and onboarding
+func (s *service) SetEvents(bus *events.Bus) {
+    s.events = bus
+}
+
+// SetUnitOfWork makes saving a date request with its event, accepting one
+// with its match, and saving a day's hotpicks with their event, atomic
+func (s *service) SetUnitOfWork(uow *database.UnitOfWork) {
+    s.uow = uow
+}
+
+// SetUsers sets the shared users client profiles are matched from
+func (s *service) SetUsers(client users.Client) {
+    s.users = client
+}
+
+func (s *service) recommendationEngine() *RecommendationEngine {
+    engine := NewRecommendationEngine(s, s.matchingEngine, s.repo)
+    engine.users = s.users
+    engine.newProfileBoost = s.newProfileBoost
+    engine.hotpicks = s.hotpicks
+    engine.events = s.events
+    engine.uow = s.uow
+    return engine
+}
+
 func (s *service) GetDateRequests(ctx context.Context, userID int64, requestType string) ([]*DateRequest, error) {
     return s.repo.GetUserDateRequests(ctx, userID, requestType)
 }
@@ -74,28 +214,103 @@ func (s *service) IsMatched(ctx context.Context, user1ID, user2ID int64) (bool,
     return s.repo.IsMatched(ctx, user1ID, user2ID)
 }
 
+// GenerateHotpicks generates the user's hotpicks for today now, without
+// waiting for the ready hour
 func (s *service) GenerateHotpicks(ctx context.Context, userID int64) error {
-    engine := NewRecommendationEngine(s, s.matchingEngine, s.repo)
-    return engine.GenerateDailyHotpicks(ctx)
+    user, err := s.repo.GetQuotaUser(ctx, userID)
+    if err != nil {
+        return err
+    }
+    
+    _, err = s.recommendationEngine().generateHotpicks(ctx, userID, localMidnight(time.Now(), user.Timezone))
+    return err
 }
 
 func (s *service) GetHotpicks(ctx context.Context, userID int64, params *GetHotpicksParams) ([]*Hotpick, error) {
-    return s.repo.GetUserHotpicks(ctx, userID, params.Limit, params.ExcludeViewed)
+    hotpicks, err := s.repo.GetUserHotpicks(ctx, userID, params.Limit, params.ExcludeViewed)
+    if err != nil {
+        return nil, err
+    }
+    
+    now := time.Now()
+    for _, hotpick := range hotpicks {
+        if hotpick.RecommendedUser != nil {
+            hotpick.RecommendedUser.IsNew = s.newProfileBoost.IsNew(hotpick.RecommendedUser.ProfileCompletedAt, now)
+        }
+    }
+    
+    return hotpicks, nil
 }
 
-func (s *service) RecordHotpickAction(ctx context.Context, hotpickID int64, action string) error {
-    // Would fetch hotpick, update action, and save
-    return nil
+// RecordHotpickAction records a swipe on one of the user's hotpicks. Each
+// swipe counts against the daily swipe quota.
+func (s *service) RecordHotpickAction(ctx context.Context, userID, hotpickID int64, action string) (*Quota, error) {
+    if action != "like" && action != "pass" {
+        return nil, ErrInvalidAction
+    }
+    
+    if s.onboarding != nil {
+        allowed, err := s.onboarding.CanSwipe(ctx, userID)
+        if err != nil {
+            return nil, err
+        }
+        if !allowed {
+            return nil, ErrOnboardingIncomplete
+        }
+    }
+    
+    hotpick, err := s.repo.GetHotpick(ctx, hotpickID)
+    if err != nil {
+        return nil, err
+    }
+    if hotpick.UserID != userID {
+        return nil, ErrUnauthorized
+    }
+    if hotpick.IsActedOn {
+        return nil, ErrAlreadyActedOn
+    }
+    
+    // A rewound hotpick was counted when first swiped, so swiping it again
+    // is free
+    rewound := hotpick.ActedAt != nil
+    var quota *Quota
+    if rewound {
+        quota, err = s.GetQuota(ctx, userID, QuotaSwipes)
+    } else {
+        quota, err = s.checkQuota(ctx, userID, QuotaSwipes)
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    hotpick.IsSeen = true
+    hotpick.IsActedOn = true
+    hotpick.ActionType = &action
+    if err := s.repo.UpdateHotpick(ctx, hotpick); err != nil {
+        return nil, err
+    }
+    
+    s.pushSwipe(ctx, userID, recentSwipe{
+        HotpickID: hotpick.ID,
+        UserID:    hotpick.RecommendedUserID,
+        Action:    action,
+        At:        time.Now(),
+    })
+    
+    if !rewound {
+        quota.consume()
+    }
+    return quota, nil
 }
 
 func (s *service) CalculateCompatibility(ctx context.Context, user1ID, user2ID int64) (float64, *CompatibilityFactors, error) {
     // Get user profiles
-    user1Profile, err := s.repo.GetUserProfile(ctx, user1ID)
+    user1Profile, err := getUserProfile(ctx, s.users, user1ID)
     if err != nil {
         return 0, nil, err
     }
     
-    user2Profile, err := s.repo.GetUserProfile(ctx, user2ID)
+    user2Profile, err := getUserProfile(ctx, s.users, user2ID)
     if err != nil {
         return 0, nil, err
     }
@@ -103,24 +318,135 @@ func (s *service) CalculateCompatibility(ctx context.Context, user1ID, user2ID i
     return s.matchingEngine.CalculateCompatibility(ctx, user1Profile, user2Profile)
 }
 
+// getUserProfile reads the user a match is scored for
+func getUserProfile(ctx context.Context, client users.Client, userID int64) (*UserProfile, error) {
+    user, err := client.Get(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    profile := &UserProfile{
+        ID:                 user.ID,
+        Username:           user.Username,
+        DisplayName:        user.DisplayName,
+        Bio:                user.Bio,
+        Age:                user.Age(time.Now()),
+        ProfilePicture:     user.ProfilePicture,
+        City:               user.Location,
+        Timezone:           user.Timezone,
+        Interests:          user.Interests,
+        IsVerified:         user.IsVerified,
+        ProfileCompletedAt: user.ProfileCompletedAt,
+        CreatedAt:          user.CreatedAt,
+    }
+    if user.DateOfBirth != nil {
+        profile.BirthDate = *user.DateOfBirth
+    }
+    if user.Gender != nil {
+        profile.Gender = *user.Gender
+    }
+    if user.Latitude != nil && user.Longitude != nil {
+        profile.Latitude, profile.Longitude = *user.Latitude, *user.Longitude
+    }
+    if user.LookingFor != nil {
+        profile.LookingFor = *user.LookingFor
+    }
+    if user.LastActive != nil {
+        profile.LastActive = *user.LastActive
+    }
+    return profile, nil
+}
+
 func (s *service) FindPotentialMatches(ctx context.Context, userID int64, filters *MatchFilters) ([]*UserInfo, error) {
-    // Implementation would use repo.FindCandidates
-    return nil, nil
+    userProfile, err := getUserProfile(ctx, s.users, userID)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Saved preferences fill in whatever the request didn't filter on
+    prefs, err := loadPreferences(ctx, s.repo, userID)
+    if err != nil {
+        return nil, err
+    }
+    candidateFilters := prefs.candidateFilters(userProfile)
+    if filters.Gender != "" {
+        candidateFilters.Genders = []string{filters.Gender}
+    }
+    if filters.MinAge > 0 {
+        candidateFilters.MinAge = filters.MinAge
+    }
+    if filters.MaxAge > 0 {
+        candidateFilters.MaxAge = filters.MaxAge
+    }
+    if filters.MaxDistance > 0 {
+        candidateFilters.MaxDistance = filters.MaxDistance
+    }
+    if filters.LookingFor != "" {
+        candidateFilters.LookingFor = filters.LookingFor
+    }
+    candidateFilters.VerifiedOnly = filters.IsVerified
+    candidateFilters.Interests = filters.Interests
+    
+    candidates, err := s.repo.FindCandidates(ctx, userID, candidateFilters)
+    if err != nil {
+        return nil, err
+    }
+    
+    // Rank with the same boosters as hotpicks so new profiles surface in discovery too
+    scored := s.recommendationEngine().scoreAndRank(ctx, userProfile, candidates)
+    
+    limit := filters.Limit
+    if limit <= 0 || limit > len(scored) {
+        limit = len(scored)
+    }
+    recordBoostImpressions(ctx, s.repo, scored[:limit])
+    
+    matches := make([]*UserInfo, 0, limit)
+    for _, candidate := range scored[:limit] {
+        age := calculateAge(candidate.Profile.BirthDate)
+        matches = append(matches, &UserInfo{
+            ID:                 candidate.Profile.ID,
+            Username:           candidate.Profile.Username,
+            DisplayName:        candidate.Profile.DisplayName,
+            ProfilePicture:     candidate.Profile.ProfilePicture,
+            Bio:                candidate.Profile.Bio,
+            Age:                &age,
+            IsNew:              candidate.IsNew,
+            ProfileCompletedAt: candidate.Profile.ProfileCompletedAt,
+        })
+    }
+    
+    return matches, nil
 }
 
-func (s *service) CreateDateRequest(ctx context.Context, userID int64, dto *CreateDateRequestDTO) (*DateRequest, error) {
+func (s *service) CreateDateRequest(ctx context.Context, userID int64, dto *CreateDateRequestDTO) (*DateRequest, *Quota, error) {
     // Validation
     if userID == dto.ReceiverID {
-        return nil, ErrCannotRequestSelf
+        return nil, nil, ErrCannotRequestSelf
+    }
+    
+    if s.blocks != nil {
+        blocked, err := s.blocks.IsBlocked(ctx, userID, dto.ReceiverID)
+        if err != nil {
+            return nil, nil, err
+        }
+        if blocked {
+            return nil, nil, ErrUserBlocked
+        }
     }
     
     // Check for existing pending request
     hasPending, err := s.repo.HasPendingRequest(ctx, userID, dto.ReceiverID)
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
     if hasPending {
-        return nil, ErrAlreadyRequested
+        return nil, nil, ErrAlreadyRequested
+    }
+    
+    quota, err := s.checkQuota(ctx, userID, QuotaDateRequests)
+    if err != nil {
+        return nil, nil, err
     }
     
     // Create request
@@ -147,15 +473,23 @@ func (s *service) CreateDateRequest(ctx context.Context, userID int64, dto *Crea
         request.DateType = &dto.DateType
     }
     
-    err = s.repo.CreateDateRequest(ctx, request)
+    // The request and the event notifying its receiver are saved together
+    err = s.uow.Do(ctx, func(ctx context.Context) error {
+        if err := s.repo.CreateDateRequest(ctx, request); err != nil {
+            return err
+        }
+        return s.events.Publish(ctx, fmt.Sprint(request.ID), events.DateRequestCreated{
+            RequestID:  request.ID,
+            SenderID:   request.SenderID,
+            ReceiverID: request.ReceiverID,
+        })
+    })
     if err != nil {
-        return nil, err
+        return nil, nil, err
     }
     
-    // Send notification
-    // s.notifyService.SendDateRequestNotification(dto.ReceiverID, request)
-    
-    return request, nil
+    quota.consume()
+    return request, quota, nil
 }
 
 func (s *service) RespondToDateRequest(ctx context.Context, requestID int64, userID int64, dto *RespondDateRequestDTO) (*DateRequest, error) {
@@ -184,19 +518,24 @@ func (s *service) RespondToDateRequest(ctx context.Context, requestID int64, use
         request.DeclinedReason = &dto.DeclinedReason
     }
     
-    err = s.repo.UpdateDateRequest(ctx, request)
+    // An accepted request and its match are saved together, so a request
+    // is never left accepted without one
+    err = s.uow.Do(ctx, func(ctx context.Context) error {
+        if err := s.repo.UpdateDateRequest(ctx, request); err != nil {
+            return err
+        }
+        
+        if dto.Status == "accepted" {
+            if _, err := s.CreateMatch(ctx, request.SenderID, request.ReceiverID, "date_accepted"); err != nil {
+                return fmt.Errorf("failed to create match: %w", err)
+            }
+        }
+        return nil
+    })
     if err != nil {
         return nil, err
     }
     
-    // If accepted, create a match
-    if dto.Status == "accepted" {
-        _, err = s.CreateMatch(ctx, request.SenderID, request.ReceiverID, "date_accepted")
-        if err != nil {
-            // Log error but don't fail the response
-        }
-    }
-    
     return request, nil
 }
 
@@ -224,8 +563,19 @@ func (s *service) CreateMatch(ctx context.Context, user1ID, user2ID int64, match
         return nil, err
     }
     
-    // Record metric
-    RecordMatch()
+    // A rematch reuses the match row, so the key includes when it was made
+    key := fmt.Sprintf("%d:%d", match.ID, match.MatchedAt.Unix())
+    if err := s.events.Publish(ctx, key, events.MatchCreated{
+        MatchID:   match.ID,
+        User1ID:   match.User1ID,
+        User2ID:   match.User2ID,
+        MatchType: matchType,
+    }); err != nil {
+        return nil, err
+    }
+    
+    // Record metric once the match is sure to exist
+    database.AfterCommit(ctx, RecordMatch)
     
     // Notify users via WebSocket if available
     // s.hub.NotifyMatch(user1ID, user2ID, match)
@@ -270,9 +620,8 @@ func (s *service) UnmatchUser(ctx context.Context, matchID int64, userID int64)
     return s.repo.UpdateMatch(ctx, match)
 }
 
-func (s *service) GenerateDailyHotpicks(ctx context.Context) error {
-    engine := NewRecommendationEngine(s, s.matchingEngine, s.repo)
-    return engine.GenerateDailyHotpicks(ctx)
+func (s *service) GenerateDailyHotpicks(ctx context.Context, now time.Time) (int, error) {
+    return s.recommendationEngine().GenerateDailyHotpicks(ctx, now)
 }
 
 func (s *service) SendDateReminders(ctx context.Context) error {
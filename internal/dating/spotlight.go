@@ -0,0 +1,150 @@
+// internal/dating/spotlight.go
+
+package dating
+
+import (
+    "context"
+    "errors"
+    "time"
+)
+
+var (
+    ErrBoostActive    = errors.New("a boost is already active")
+    ErrNoBoostCredits = errors.New("no boost credits left")
+)
+
+const (
+    BoostSourceMonthly = "monthly"
+    BoostSourceCredit  = "credit"
+)
+
+// BoostConfig sets how spotlight boosts behave. Monthly allowances are used
+// before purchased credits.
+type BoostConfig struct {
+    Duration             time.Duration
+    Multiplier           float64
+    MonthlyBoosts        int
+    PremiumMonthlyBoosts int
+}
+
+func DefaultBoostConfig() BoostConfig {
+    return BoostConfig{
+        Duration:             30 * time.Minute,
+        Multiplier:           3.0,
+        MonthlyBoosts:        0,
+        PremiumMonthlyBoosts: 1,
+    }
+}
+
+// ProfileBoost is one spotlight boost. Impressions counts how often the user
+// was shown in discovery or hotpicks while it ran.
+type ProfileBoost struct {
+    ID          int64     `json:"id" db:"id"`
+    UserID      int64     `json:"user_id" db:"user_id"`
+    Source      string    `json:"source" db:"source"`
+    Multiplier  float64   `json:"multiplier" db:"multiplier"`
+    Impressions int       `json:"impressions" db:"impressions"`
+    StartedAt   time.Time `json:"started_at" db:"started_at"`
+    ExpiresAt   time.Time `json:"expires_at" db:"expires_at"`
+}
+
+func (b *ProfileBoost) IsActive(now time.Time) bool {
+    return now.Before(b.ExpiresAt)
+}
+
+// BoostStatus is the user's current or most recent boost and what they have left
+type BoostStatus struct {
+    Boost            *ProfileBoost `json:"boost,omitempty"`
+    Active           bool          `json:"active"`
+    MonthlyRemaining int           `json:"monthly_remaining"`
+    Credits          int           `json:"credits"`
+}
+
+// ActivateBoost starts a boost, spending this month's allowance first and
+// then a purchased credit
+func (s *service) ActivateBoost(ctx context.Context, userID int64) (*ProfileBoost, error) {
+    user, err := s.repo.GetQuotaUser(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    boost := &ProfileBoost{
+        UserID:     userID,
+        Multiplier: s.boosts.Multiplier,
+        StartedAt:  now,
+        ExpiresAt:  now.Add(s.boosts.Duration),
+    }
+
+    err = s.repo.CreateBoost(ctx, boost, s.monthlyBoosts(user.IsPremium), monthStart(now))
+    if err != nil {
+        return nil, err
+    }
+
+    RecordBoostActivated(boost.Source)
+    return boost, nil
+}
+
+// GetBoostStatus returns the latest boost with its impressions and the
+// boosts the user can still start
+func (s *service) GetBoostStatus(ctx context.Context, userID int64) (*BoostStatus, error) {
+    user, err := s.repo.GetQuotaUser(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    now := time.Now()
+    boost, err := s.repo.GetLatestBoost(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    used, err := s.repo.CountBoostsSince(ctx, userID, BoostSourceMonthly, monthStart(now))
+    if err != nil {
+        return nil, err
+    }
+
+    status := &BoostStatus{
+        Boost:            boost,
+        Active:           boost != nil && boost.IsActive(now),
+        MonthlyRemaining: s.monthlyBoosts(user.IsPremium) - used,
+        Credits:          user.BoostCredits,
+    }
+    if status.MonthlyRemaining < 0 {
+        status.MonthlyRemaining = 0
+    }
+    return status, nil
+}
+
+// recordBoostImpressions credits an impression to each boosted candidate shown
+func recordBoostImpressions(ctx context.Context, repo Repository, candidates []*ScoredCandidate) {
+    now := time.Now()
+    var boosted []int64
+    for _, candidate := range candidates {
+        if isBoosted(candidate.Profile, now) {
+            boosted = append(boosted, candidate.UserID)
+        }
+    }
+    if len(boosted) == 0 {
+        return
+    }
+    repo.RecordBoostImpressions(ctx, boosted)
+}
+
+func (s *service) monthlyBoosts(premium bool) int {
+    if premium {
+        return s.boosts.PremiumMonthlyBoosts
+    }
+    return s.boosts.MonthlyBoosts
+}
+
+func isBoosted(profile *UserProfile, now time.Time) bool {
+    return profile.BoostedUntil != nil && now.Before(*profile.BoostedUntil)
+}
+
+// monthStart is the start of now's calendar month in UTC, when monthly
+// allowances renew
+func monthStart(now time.Time) time.Time {
+    now = now.UTC()
+    return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
@@ -7,6 +7,8 @@ import (
     "net/http"
     
     "github.com/gorilla/websocket"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
 )
 
 var upgrader = websocket.Upgrader{
@@ -106,7 +108,11 @@ func (h *Hub) NotifyDateReminder(userID int64, request *DateRequest) {
 
 func (h *Hub) ServeWS(w http.ResponseWriter, r *http.Request) {
     // Get user ID from context (set by auth middleware)
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        http.Error(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     conn, err := upgrader.Upgrade(w, r, nil)
     if err != nil {
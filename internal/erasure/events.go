@@ -0,0 +1,70 @@
+// internal/erasure/events.go
+
+package erasure
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io/fs"
+    "log"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+// FileDeleter deletes a stored file by its URL
+type FileDeleter interface {
+    DeleteFile(ctx context.Context, url string) error
+}
+
+// SubscribeEvents subscribes each purger to user.erased separately, so the
+// outbox retries a failed purger without rerunning the others
+func (s *service) SubscribeEvents(bus *events.Bus) {
+    for _, subsystem := range s.subsystems() {
+        subsystem, purge := subsystem, s.purgers[subsystem]
+        events.Subscribe(bus, "erasure."+subsystem, func(ctx context.Context, e events.UserErased) error {
+            items, err := purge(ctx, e)
+            if err != nil {
+                if recordErr := s.repo.FailPurge(ctx, e.UserID, subsystem, err.Error()); recordErr != nil {
+                    log.Printf("Failed to record %s purge error for user %d: %v", subsystem, e.UserID, recordErr)
+                }
+                return err
+            }
+            return s.repo.CompletePurge(ctx, e.UserID, subsystem, items)
+        })
+    }
+}
+
+// AddMediaPurger deletes an erased account's stored files. deleters are
+// keyed by the module whose storage holds the files, as in the manifest.
+func (s *service) AddMediaPurger(deleters map[string]FileDeleter) {
+    s.purgers["media"] = func(ctx context.Context, e events.UserErased) (int, error) {
+        return purgeMedia(ctx, deleters, e.Manifest.Media)
+    }
+}
+
+// purgeMedia deletes every file in media, carrying on past failures. Files
+// already gone count as deleted, so a retry only fails on the ones left.
+func purgeMedia(ctx context.Context, deleters map[string]FileDeleter, media map[string][]string) (int, error) {
+    deleted := 0
+    var failed []string
+    for module, urls := range media {
+        deleter := deleters[module]
+        for _, url := range urls {
+            if deleter == nil {
+                failed = append(failed, fmt.Sprintf("%s: no %s storage", url, module))
+                continue
+            }
+            if err := deleter.DeleteFile(ctx, url); err != nil && !errors.Is(err, fs.ErrNotExist) {
+                failed = append(failed, fmt.Sprintf("%s: %v", url, err))
+                continue
+            }
+            deleted++
+        }
+    }
+
+    if len(failed) > 0 {
+        return deleted, fmt.Errorf("failed to delete %d of %d files, first %s", len(failed), deleted+len(failed), failed[0])
+    }
+    return deleted, nil
+}
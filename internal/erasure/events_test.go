@@ -0,0 +1,67 @@
+package erasure
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "os"
+    "strings"
+    "testing"
+)
+
+// fakeStorage deletes files from a set, failing for URLs in fail
+type fakeStorage struct {
+    files map[string]bool
+    fail  map[string]bool
+}
+
+func (s *fakeStorage) DeleteFile(ctx context.Context, url string) error {
+    if s.fail[url] {
+        return errors.New("access denied")
+    }
+    if !s.files[url] {
+        return fmt.Errorf("failed to delete file: %w", os.ErrNotExist)
+    }
+    delete(s.files, url)
+    return nil
+}
+
+func TestPurgeMedia(t *testing.T) {
+    profile := &fakeStorage{files: map[string]bool{"/uploads/avatar.jpg": true}}
+    posts := &fakeStorage{
+        files: map[string]bool{"/uploads/post.jpg": true, "/uploads/locked.jpg": true},
+        fail:  map[string]bool{"/uploads/locked.jpg": true},
+    }
+    deleters := map[string]FileDeleter{MediaProfile: profile, MediaPosts: posts}
+    media := map[string][]string{
+        MediaProfile: {"/uploads/avatar.jpg", "/uploads/gone.jpg"},
+        MediaPosts:   {"/uploads/post.jpg", "/uploads/locked.jpg"},
+    }
+
+    deleted, err := purgeMedia(context.Background(), deleters, media)
+    if err == nil || !strings.Contains(err.Error(), "/uploads/locked.jpg") {
+        t.Fatalf("error = %v, want the locked file's failure", err)
+    }
+    if deleted != 3 {
+        t.Errorf("deleted = %d, want 3 counting the file already gone", deleted)
+    }
+    if len(profile.files) != 0 || posts.files["/uploads/post.jpg"] {
+        t.Errorf("files left behind: profile %v, posts %v", profile.files, posts.files)
+    }
+
+    // The retry only has the failure left
+    delete(posts.fail, "/uploads/locked.jpg")
+    if _, err := purgeMedia(context.Background(), deleters, media); err != nil {
+        t.Fatalf("retry: %v", err)
+    }
+    if len(posts.files) != 0 {
+        t.Errorf("files left behind after retry: %v", posts.files)
+    }
+}
+
+func TestPurgeMediaWithoutStorage(t *testing.T) {
+    media := map[string][]string{MediaStories: {"/uploads/story.mp4"}}
+    if _, err := purgeMedia(context.Background(), map[string]FileDeleter{}, media); err == nil {
+        t.Fatal("purged media with no storage to delete it from")
+    }
+}
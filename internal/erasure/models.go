@@ -0,0 +1,25 @@
+// internal/erasure/models.go
+
+package erasure
+
+import "time"
+
+// Modules whose storage holds an erased account's media, as keyed in the
+// user.erased manifest
+const (
+    MediaProfile = "profile"
+    MediaPosts   = "posts"
+    MediaStories = "stories"
+)
+
+// Purge is one subsystem's progress purging an erased account. It is
+// created when the account is erased and completed by the subsystem's
+// user.erased subscriber; LastError is the latest failed attempt.
+type Purge struct {
+    UserID      int64      `json:"user_id" db:"user_id"`
+    Subsystem   string     `json:"subsystem" db:"subsystem"`
+    Items       int        `json:"items" db:"items"`
+    LastError   *string    `json:"last_error,omitempty" db:"last_error"`
+    StartedAt   time.Time  `json:"started_at" db:"started_at"`
+    CompletedAt *time.Time `json:"completed_at,omitempty" db:"completed_at"`
+}
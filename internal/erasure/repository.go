@@ -0,0 +1,148 @@
+// internal/erasure/repository.go
+
+package erasure
+
+import (
+    "context"
+    "database/sql"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+)
+
+type Repository interface {
+    LockAccountStatus(ctx context.Context, userID int64) (string, error)
+    MarkErased(ctx context.Context, userID int64) error
+    GetManifest(ctx context.Context, userID int64) (*events.ErasureManifest, error)
+
+    // Per-subsystem purge tracking
+    StartPurges(ctx context.Context, userID int64, subsystems []string) error
+    CompletePurge(ctx context.Context, userID int64, subsystem string, items int) error
+    FailPurge(ctx context.Context, userID int64, subsystem, message string) error
+    GetPurges(ctx context.Context, userID int64) ([]*Purge, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+// LockAccountStatus returns the account status, locking the user's row until
+// the transaction in ctx ends so two erasures can't race
+func (r *repository) LockAccountStatus(ctx context.Context, userID int64) (string, error) {
+    var status string
+    err := sqlx.GetContext(ctx, database.ConnX(ctx, r.db), &status,
+        `SELECT account_status FROM users WHERE id = $1 FOR UPDATE`, userID)
+    if err == sql.ErrNoRows {
+        return "", ErrUserNotFound
+    }
+    return status, err
+}
+
+// MarkErased marks the account deleted, which hides it and its content
+func (r *repository) MarkErased(ctx context.Context, userID int64) error {
+    _, err := database.ConnX(ctx, r.db).ExecContext(ctx,
+        `UPDATE users SET account_status = $2, updated_at = NOW() WHERE id = $1`,
+        userID, users.StatusDeleted)
+    return err
+}
+
+// GetManifest lists the user's posts, stories and stored media
+func (r *repository) GetManifest(ctx context.Context, userID int64) (*events.ErasureManifest, error) {
+    db := database.ConnX(ctx, r.db)
+    manifest := &events.ErasureManifest{Media: map[string][]string{}}
+
+    if err := sqlx.SelectContext(ctx, db, &manifest.PostIDs,
+        `SELECT id FROM posts WHERE user_id = $1 ORDER BY id`, userID); err != nil {
+        return nil, err
+    }
+    if err := sqlx.SelectContext(ctx, db, &manifest.StoryIDs,
+        `SELECT id FROM stories WHERE user_id = $1 ORDER BY id`, userID); err != nil {
+        return nil, err
+    }
+
+    media := map[string]string{
+        MediaProfile: `
+            SELECT url FROM (
+                SELECT profile_picture AS url FROM users WHERE id = $1
+                UNION SELECT cover_photo FROM users WHERE id = $1
+                UNION SELECT url FROM profile_photos WHERE user_id = $1
+            ) m
+            WHERE COALESCE(url, '') <> ''`,
+        MediaPosts: `
+            SELECT DISTINCT pm.media_url
+            FROM post_media pm
+            JOIN posts p ON p.id = pm.post_id
+            WHERE p.user_id = $1`,
+        MediaStories: `
+            SELECT url FROM (
+                SELECT media_url AS url FROM stories WHERE user_id = $1
+                UNION SELECT thumbnail_url FROM stories WHERE user_id = $1
+                UNION SELECT playback_url FROM stories WHERE user_id = $1
+                UNION SELECT cover_image FROM story_highlights WHERE user_id = $1 AND cover_uploaded
+            ) m
+            WHERE COALESCE(url, '') <> ''`,
+    }
+    for module, query := range media {
+        var urls []string
+        if err := sqlx.SelectContext(ctx, db, &urls, query, userID); err != nil {
+            return nil, err
+        }
+        if len(urls) > 0 {
+            manifest.Media[module] = urls
+        }
+    }
+    return manifest, nil
+}
+
+// StartPurges records a pending purge for each subsystem
+func (r *repository) StartPurges(ctx context.Context, userID int64, subsystems []string) error {
+    query := `
+        INSERT INTO erasure_purges (user_id, subsystem)
+        SELECT $1, UNNEST($2::text[])
+        ON CONFLICT (user_id, subsystem) DO NOTHING`
+
+    _, err := database.ConnX(ctx, r.db).ExecContext(ctx, query, userID, pq.Array(subsystems))
+    return err
+}
+
+func (r *repository) CompletePurge(ctx context.Context, userID int64, subsystem string, items int) error {
+    query := `
+        UPDATE erasure_purges
+        SET items = $3, last_error = NULL, completed_at = NOW()
+        WHERE user_id = $1 AND subsystem = $2`
+
+    _, err := r.db.ExecContext(ctx, query, userID, subsystem, items)
+    return err
+}
+
+func (r *repository) FailPurge(ctx context.Context, userID int64, subsystem, message string) error {
+    query := `
+        UPDATE erasure_purges
+        SET last_error = $3
+        WHERE user_id = $1 AND subsystem = $2 AND completed_at IS NULL`
+
+    _, err := r.db.ExecContext(ctx, query, userID, subsystem, message)
+    return err
+}
+
+// GetPurges returns the user's purges by subsystem
+func (r *repository) GetPurges(ctx context.Context, userID int64) ([]*Purge, error) {
+    query := `
+        SELECT user_id, subsystem, items, last_error, started_at, completed_at
+        FROM erasure_purges
+        WHERE user_id = $1
+        ORDER BY subsystem`
+
+    purges := []*Purge{}
+    if err := r.db.SelectContext(ctx, &purges, query, userID); err != nil {
+        return nil, err
+    }
+    return purges, nil
+}
@@ -0,0 +1,109 @@
+// internal/erasure/service.go
+// Account erasure. Erasing an account marks it deleted and, in the same
+// transaction, publishes user.erased through the outbox with a manifest of
+// what the account held. Each subsystem holding copies of the data purges
+// them in its own subscriber, and its progress is tracked per account so
+// support can see which have finished.
+//
+// The erasure itself is a first step: a deleted account and its content are
+// hidden everywhere (see internal/users), but the rows are not anonymized
+// yet. Stored media is the only copy purged so far; there is no search index
+// or analytics sink in the tree yet, and each would add a purger of its own.
+
+package erasure
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strconv"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+var (
+    ErrUserNotFound  = errors.New("user not found")
+    ErrAlreadyErased = errors.New("account is already erased")
+)
+
+type Service interface {
+    Erase(ctx context.Context, userID int64) (*events.ErasureManifest, error)
+    GetPurges(ctx context.Context, userID int64) ([]*Purge, error)
+
+    // Purgers; add them before SubscribeEvents
+    AddMediaPurger(deleters map[string]FileDeleter)
+    SubscribeEvents(bus *events.Bus)
+}
+
+// purger removes an erased account's data from one subsystem, returning how
+// many items it removed. It must be safe to run again after a failure.
+type purger func(ctx context.Context, e events.UserErased) (int, error)
+
+type service struct {
+    repo    Repository
+    uow     *database.UnitOfWork
+    events  *events.Bus
+    purgers map[string]purger
+}
+
+func NewService(repo Repository, uow *database.UnitOfWork, bus *events.Bus) Service {
+    return &service{
+        repo:    repo,
+        uow:     uow,
+        events:  bus,
+        purgers: map[string]purger{},
+    }
+}
+
+// Erase marks the account deleted, records a pending purge for every
+// subsystem and publishes user.erased, all or nothing
+func (s *service) Erase(ctx context.Context, userID int64) (*events.ErasureManifest, error) {
+    var manifest *events.ErasureManifest
+    err := s.uow.Do(ctx, func(ctx context.Context) error {
+        status, err := s.repo.LockAccountStatus(ctx, userID)
+        if err != nil {
+            return err
+        }
+        if status == users.StatusDeleted {
+            return ErrAlreadyErased
+        }
+
+        manifest, err = s.repo.GetManifest(ctx, userID)
+        if err != nil {
+            return fmt.Errorf("failed to list account content: %w", err)
+        }
+        if err := s.repo.MarkErased(ctx, userID); err != nil {
+            return fmt.Errorf("failed to mark account erased: %w", err)
+        }
+        if err := s.repo.StartPurges(ctx, userID, s.subsystems()); err != nil {
+            return fmt.Errorf("failed to record purges: %w", err)
+        }
+
+        return s.events.Publish(ctx, strconv.FormatInt(userID, 10), events.UserErased{
+            UserID:   userID,
+            ErasedAt: time.Now(),
+            Manifest: *manifest,
+        })
+    })
+    if err != nil {
+        return nil, err
+    }
+    return manifest, nil
+}
+
+func (s *service) GetPurges(ctx context.Context, userID int64) ([]*Purge, error) {
+    return s.repo.GetPurges(ctx, userID)
+}
+
+func (s *service) subsystems() []string {
+    names := make([]string, 0, len(s.purgers))
+    for name := range s.purgers {
+        names = append(names, name)
+    }
+    sort.Strings(names)
+    return names
+}
@@ -0,0 +1,104 @@
+// internal/events/bus.go
+// The bus delivering events to subscribers
+// Publish stores an event in the outbox with the change it describes, so it
+// is delivered even if the process stops right after. Emit is for frequent
+// events where losing one on a crash is acceptable: subscribers run in the
+// background once the change commits, without touching the database.
+
+package events
+
+import (
+    "context"
+    "log"
+    "sync"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/outbox"
+)
+
+// emitTimeout bounds the subscribers of an emitted event
+const emitTimeout = 30 * time.Second
+
+type subscriber struct {
+    module  string
+    handler func(ctx context.Context, event Event) error
+}
+
+// Bus routes events to the modules subscribed to them
+type Bus struct {
+    outbox *outbox.Outbox
+
+    mu          sync.RWMutex
+    subscribers map[string][]subscriber
+}
+
+// NewBus creates a bus whose published events go through o
+func NewBus(o *outbox.Outbox) *Bus {
+    return &Bus{
+        outbox:      o,
+        subscribers: map[string][]subscriber{},
+    }
+}
+
+// Subscribe calls handler for every event of type E, published or emitted.
+// module names the subscriber and must be unique per event type.
+func Subscribe[E Event](b *Bus, module string, handler func(ctx context.Context, event E) error) {
+    var zero E
+    name := zero.EventName()
+
+    b.mu.Lock()
+    b.subscribers[name] = append(b.subscribers[name], subscriber{
+        module: module,
+        handler: func(ctx context.Context, event Event) error {
+            return handler(ctx, event.(E))
+        },
+    })
+    b.mu.Unlock()
+
+    b.outbox.Subscribe(name, module, func(ctx context.Context, stored *outbox.Event) error {
+        var event E
+        if err := stored.Decode(&event); err != nil {
+            return err
+        }
+        return handler(ctx, event)
+    })
+}
+
+// Publish stores event for delivery, in the transaction in ctx when there
+// is one. key identifies this occurrence of the event; publishing the same
+// key again is a no-op. A nil bus drops the event.
+func (b *Bus) Publish(ctx context.Context, key string, event Event) error {
+    if b == nil {
+        return nil
+    }
+    return b.outbox.Publish(ctx, event.EventName(), event.EventName()+":"+key, event)
+}
+
+// Emit hands event to its subscribers in the background, once the
+// transaction in ctx commits. Failures are logged and not retried.
+func (b *Bus) Emit(ctx context.Context, event Event) {
+    if b == nil {
+        return
+    }
+
+    b.mu.RLock()
+    subscribers := b.subscribers[event.EventName()]
+    b.mu.RUnlock()
+    if len(subscribers) == 0 {
+        return
+    }
+
+    database.AfterCommit(ctx, func() {
+        go func() {
+            ctx, cancel := context.WithTimeout(context.Background(), emitTimeout)
+            defer cancel()
+
+            for _, sub := range subscribers {
+                if err := sub.handler(ctx, event); err != nil {
+                    log.Printf("Event %s: %s handler failed: %v", event.EventName(), sub.module, err)
+                }
+            }
+        }()
+    })
+}
@@ -0,0 +1,154 @@
+// internal/events/events.go
+// Domain events modules publish instead of calling each other's services
+// A module publishes what happened; the modules that care subscribe to it
+// when the app starts. Neither needs to know about the other, only about the
+// event types defined here.
+
+package events
+
+import "time"
+
+// Event is something that happened in one module that others may react to
+type Event interface {
+    // EventName identifies the event type, e.g. "post.liked". It must not
+    // change once events of the type have been stored.
+    EventName() string
+}
+
+// UserVerified is published when a new account is verified, by OTP or by
+// signing up with a provider that verifies email itself
+type UserVerified struct {
+    UserID int64 `json:"user_id"`
+}
+
+func (UserVerified) EventName() string { return "user.verified" }
+
+// ProfileCompleted is published when a user finishes setting up their profile
+type ProfileCompleted struct {
+    UserID int64 `json:"user_id"`
+}
+
+func (ProfileCompleted) EventName() string { return "profile.completed" }
+
+// ProfilePhotoAdded is published when a user's profile picture is set,
+// including once moderation approves a held one
+type ProfilePhotoAdded struct {
+    UserID int64 `json:"user_id"`
+}
+
+func (ProfilePhotoAdded) EventName() string { return "profile.photo_added" }
+
+// DatingPreferencesSaved is published when a user saves who they want to see
+// in discovery
+type DatingPreferencesSaved struct {
+    UserID int64 `json:"user_id"`
+}
+
+func (DatingPreferencesSaved) EventName() string { return "dating.preferences_saved" }
+
+// ContactJoined is published for each user with a new user's phone number in
+// their synced contacts, once the new user verifies
+type ContactJoined struct {
+    UserID    int64 `json:"user_id"`
+    ContactID int64 `json:"contact_id"`
+}
+
+func (ContactJoined) EventName() string { return "contact.joined" }
+
+// PostLiked is emitted when a user likes a post
+type PostLiked struct {
+    UserID  int64 `json:"user_id"`
+    PostID  int64 `json:"post_id"`
+    OwnerID int64 `json:"owner_id"`
+}
+
+func (PostLiked) EventName() string { return "post.liked" }
+
+// PostReposted is published when a user reposts someone's post
+type PostReposted struct {
+    ReposterID int64 `json:"reposter_id"`
+    OwnerID    int64 `json:"owner_id"`
+    PostID     int64 `json:"post_id"`
+    RepostID   int64 `json:"repost_id"`
+}
+
+func (PostReposted) EventName() string { return "post.reposted" }
+
+// PostPublished is published when a scheduled post goes out
+type PostPublished struct {
+    UserID int64 `json:"user_id"`
+    PostID int64 `json:"post_id"`
+}
+
+func (PostPublished) EventName() string { return "post.published" }
+
+// StoryReplied is published when a user replies to someone else's story.
+// Message and Reaction are empty when not given.
+type StoryReplied struct {
+    ReplyID        int64     `json:"reply_id"`
+    StoryID        int64     `json:"story_id"`
+    StoryOwnerID   int64     `json:"story_owner_id"`
+    ReplierID      int64     `json:"replier_id"`
+    Message        string    `json:"message,omitempty"`
+    Reaction       string    `json:"reaction,omitempty"`
+    StoryMediaType string    `json:"story_media_type"`
+    StoryExpiresAt time.Time `json:"story_expires_at"`
+}
+
+func (StoryReplied) EventName() string { return "story.replied" }
+
+// MatchCreated is published when two users match
+type MatchCreated struct {
+    MatchID   int64  `json:"match_id"`
+    User1ID   int64  `json:"user1_id"`
+    User2ID   int64  `json:"user2_id"`
+    MatchType string `json:"match_type"`
+}
+
+func (MatchCreated) EventName() string { return "match.created" }
+
+// DateRequestCreated is published when a user asks another on a date
+type DateRequestCreated struct {
+    RequestID  int64 `json:"request_id"`
+    SenderID   int64 `json:"sender_id"`
+    ReceiverID int64 `json:"receiver_id"`
+}
+
+func (DateRequestCreated) EventName() string { return "date_request.created" }
+
+// HotpicksReady is published when a user's hotpicks for the day are generated
+type HotpicksReady struct {
+    UserID int64 `json:"user_id"`
+    Count  int   `json:"count"`
+}
+
+func (HotpicksReady) EventName() string { return "hotpicks.ready" }
+
+// MessageSent is emitted when a message is sent in a conversation
+type MessageSent struct {
+    MessageID      int64  `json:"message_id"`
+    ConversationID int64  `json:"conversation_id"`
+    SenderID       int64  `json:"sender_id"`
+    MessageType    string `json:"message_type"`
+}
+
+func (MessageSent) EventName() string { return "message.sent" }
+
+// UserErased is published when an account is erased, for every system
+// holding a copy of its data to purge it. Manifest lists what the account
+// held, since the rows may be gone by the time a subscriber runs.
+type UserErased struct {
+    UserID   int64           `json:"user_id"`
+    ErasedAt time.Time       `json:"erased_at"`
+    Manifest ErasureManifest `json:"manifest"`
+}
+
+func (UserErased) EventName() string { return "user.erased" }
+
+// ErasureManifest is what an erased account held. Media maps the module
+// whose storage holds the files, e.g. "posts", to their URLs.
+type ErasureManifest struct {
+    PostIDs  []int64             `json:"post_ids,omitempty"`
+    StoryIDs []int64             `json:"story_ids,omitempty"`
+    Media    map[string][]string `json:"media,omitempty"`
+}
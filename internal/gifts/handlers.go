@@ -0,0 +1,184 @@
+// internal/gifts/handlers.go
+
+package gifts
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// GetCatalog handles GET /gifts
+func (h *Handler) GetCatalog(w http.ResponseWriter, r *http.Request) {
+    catalog, err := h.service.GetCatalog(r.Context())
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get gifts")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, catalog)
+}
+
+// SendGift handles POST /gifts/send
+func (h *Handler) SendGift(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    var req SendGiftRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    sent, wallet, err := h.service.SendGift(r.Context(), userID, &req)
+    if err != nil {
+        switch err {
+        case ErrCannotGiftSelf:
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        case ErrGiftNotFound, ErrUserNotFound, ErrMessageNotFound:
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+        case ErrInsufficientCoins:
+            utils.RespondWithError(w, http.StatusPaymentRequired, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to send gift")
+        }
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusCreated, map[string]interface{}{
+        "gift":   sent,
+        "wallet": wallet,
+    })
+}
+
+// GetWallet handles GET /gifts/wallet
+func (h *Handler) GetWallet(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    wallet, err := h.service.GetWallet(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get wallet")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, wallet)
+}
+
+// GetTransactions handles GET /gifts/wallet/transactions?limit=&before=,
+// where before is the ID of the last transaction already shown
+func (h *Handler) GetTransactions(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+
+    var before int64
+    if v := r.URL.Query().Get("before"); v != "" {
+        var err error
+        before, err = strconv.ParseInt(v, 10, 64)
+        if err != nil || before < 0 {
+            utils.RespondWithError(w, http.StatusBadRequest, "before must be a transaction ID")
+            return
+        }
+    }
+
+    transactions, err := h.service.GetTransactions(r.Context(), userID, before, limit)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get transactions")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, transactions)
+}
+
+// GetReceived handles GET /gifts/received?limit=&offset=
+func (h *Handler) GetReceived(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    limit, offset := parsePage(r)
+
+    received, err := h.service.GetReceived(r.Context(), userID, limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get received gifts")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, received)
+}
+
+// GetSent handles GET /gifts/sent?limit=&offset=
+func (h *Handler) GetSent(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    limit, offset := parsePage(r)
+
+    sent, err := h.service.GetSent(r.Context(), userID, limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get sent gifts")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, sent)
+}
+
+// GetShowcase handles GET /gifts/users/{userId}/showcase
+func (h *Handler) GetShowcase(w http.ResponseWriter, r *http.Request) {
+    viewerID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    userID, err := strconv.ParseInt(mux.Vars(r)["userId"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+        return
+    }
+
+    showcase, err := h.service.GetShowcase(r.Context(), userID, viewerID)
+    if err != nil {
+        if err == ErrUserNotFound {
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get gift showcase")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, showcase)
+}
+
+func parsePage(r *http.Request) (int, int) {
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+    if offset < 0 {
+        offset = 0
+    }
+    return limit, offset
+}
@@ -0,0 +1,84 @@
+// internal/gifts/models.go
+
+package gifts
+
+import (
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+// Coin transaction kinds
+const (
+    TransactionPurchase = "purchase"
+    TransactionGiftSent = "gift_sent"
+    TransactionReferral = "referral"
+)
+
+// Gift is an entry in the virtual gift catalog, priced in coins
+type Gift struct {
+    ID      int64   `json:"id" db:"id"`
+    Slug    string  `json:"slug" db:"slug"`
+    Name    string  `json:"name" db:"name"`
+    IconURL *string `json:"icon_url,omitempty" db:"icon_url"`
+    Coins   int     `json:"coins" db:"coins"`
+}
+
+// Wallet is a user's coin balance. Users who never bought coins have an
+// empty one.
+type Wallet struct {
+    UserID    int64      `json:"user_id" db:"user_id"`
+    Balance   int        `json:"balance" db:"balance"`
+    UpdatedAt *time.Time `json:"updated_at,omitempty" db:"updated_at"`
+}
+
+// Transaction is one entry in a user's coin history. Amount is positive for
+// coins in and negative for coins out.
+type Transaction struct {
+    ID           int64     `json:"id" db:"id"`
+    UserID       int64     `json:"user_id" db:"user_id"`
+    Amount       int       `json:"amount" db:"amount"`
+    BalanceAfter int       `json:"balance_after" db:"balance_after"`
+    Kind         string    `json:"kind" db:"kind"`
+    SentGiftID   *int64    `json:"sent_gift_id,omitempty" db:"sent_gift_id"`
+    Reference    *string   `json:"-" db:"reference"`
+    CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// SentGift is a gift from one user to another, on their profile or attached
+// to a message in their conversation. Coins is what the sender paid.
+type SentGift struct {
+    ID          int64     `json:"id" db:"id"`
+    GiftID      int64     `json:"gift_id" db:"gift_id"`
+    SenderID    int64     `json:"sender_id" db:"sender_id"`
+    RecipientID int64     `json:"recipient_id" db:"recipient_id"`
+    MessageID   *int64    `json:"message_id,omitempty" db:"message_id"`
+    Note        *string   `json:"note,omitempty" db:"note"`
+    Coins       int       `json:"coins" db:"coins"`
+    CreatedAt   time.Time `json:"created_at" db:"created_at"`
+
+    Gift   *Gift           `json:"gift,omitempty"`
+    Sender *users.Identity `json:"sender,omitempty"`
+}
+
+// ShowcaseItem is one kind of gift a user has received and how many times
+type ShowcaseItem struct {
+    Gift  *Gift `json:"gift"`
+    Count int   `json:"count"`
+}
+
+// Showcase is the gifts shown on a user's profile, most received first
+type Showcase struct {
+    UserID int64           `json:"user_id"`
+    Total  int             `json:"total"`
+    Items  []*ShowcaseItem `json:"items"`
+}
+
+// SendGiftRequest sends a gift to a user's profile, or attaches it to a
+// message the sender sent them when MessageID is set
+type SendGiftRequest struct {
+    GiftID      int64  `json:"gift_id" validate:"required"`
+    RecipientID int64  `json:"recipient_id" validate:"required"`
+    MessageID   *int64 `json:"message_id"`
+    Note        string `json:"note" validate:"max=200"`
+}
@@ -0,0 +1,293 @@
+// internal/gifts/repository.go
+
+package gifts
+
+import (
+    "context"
+    "database/sql"
+    "encoding/json"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+    "github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+    // Catalog
+    GetCatalog(ctx context.Context) ([]*Gift, error)
+
+    // Wallets
+    GetWallet(ctx context.Context, userID int64) (*Wallet, error)
+    Credit(ctx context.Context, userID int64, coins int, kind, reference string) (bool, error)
+    DebitWallet(ctx context.Context, userID int64, coins int) (int, bool, error)
+    CreateTransaction(ctx context.Context, tx *Transaction) error
+    GetTransactions(ctx context.Context, userID, before int64, limit int) ([]*Transaction, error)
+
+    // Sent gifts
+    CreateSentGift(ctx context.Context, gift *SentGift) error
+    AttachToMessage(ctx context.Context, messageID int64, gift *SentGift) error
+    GetReceived(ctx context.Context, userID int64, limit, offset int) ([]*SentGift, error)
+    GetSent(ctx context.Context, userID int64, limit, offset int) ([]*SentGift, error)
+    GetShowcase(ctx context.Context, userID int64) ([]*ShowcaseItem, error)
+
+    // Users and messages
+    UserExists(ctx context.Context, userID int64) (bool, error)
+    IsMessageBetween(ctx context.Context, messageID, senderID, recipientID int64) (bool, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+// GetCatalog returns every active gift, cheapest first
+func (r *repository) GetCatalog(ctx context.Context) ([]*Gift, error) {
+    query := `
+        SELECT id, slug, name, icon_url, coins
+        FROM gifts
+        WHERE is_active = true
+        ORDER BY sort_order, coins, id`
+
+    catalog := []*Gift{}
+    if err := r.db.SelectContext(ctx, &catalog, query); err != nil {
+        return nil, err
+    }
+    return catalog, nil
+}
+
+func (r *repository) GetWallet(ctx context.Context, userID int64) (*Wallet, error) {
+    wallet := &Wallet{UserID: userID}
+    err := r.db.GetContext(ctx, wallet,
+        `SELECT user_id, balance, updated_at FROM coin_wallets WHERE user_id = $1`, userID)
+    if err == sql.ErrNoRows {
+        return &Wallet{UserID: userID}, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return wallet, nil
+}
+
+// Credit adds coins and records the transaction in one statement. It
+// returns false without crediting when reference was already credited; two
+// deliveries racing fail on the unique reference and roll back.
+func (r *repository) Credit(ctx context.Context, userID int64, coins int, kind, reference string) (bool, error) {
+    query := `
+        WITH wallet AS (
+            INSERT INTO coin_wallets (user_id, balance)
+            SELECT $1, $2
+            WHERE NOT EXISTS (SELECT 1 FROM coin_transactions WHERE reference = $3)
+            ON CONFLICT (user_id) DO UPDATE SET
+                balance = coin_wallets.balance + EXCLUDED.balance,
+                updated_at = NOW()
+            RETURNING balance
+        )
+        INSERT INTO coin_transactions (user_id, amount, balance_after, kind, reference)
+        SELECT $1, $2, balance, $4, $3 FROM wallet`
+
+    result, err := database.ConnX(ctx, r.db).ExecContext(ctx, query, userID, coins, reference, kind)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// DebitWallet takes coins from the wallet if it holds enough, returning the
+// new balance and whether it did
+func (r *repository) DebitWallet(ctx context.Context, userID int64, coins int) (int, bool, error) {
+    query := `
+        UPDATE coin_wallets
+        SET balance = balance - $2, updated_at = NOW()
+        WHERE user_id = $1 AND balance >= $2
+        RETURNING balance`
+
+    var balance int
+    err := sqlx.GetContext(ctx, database.ConnX(ctx, r.db), &balance, query, userID, coins)
+    if err == sql.ErrNoRows {
+        return 0, false, nil
+    }
+    if err != nil {
+        return 0, false, err
+    }
+    return balance, true, nil
+}
+
+func (r *repository) CreateTransaction(ctx context.Context, tx *Transaction) error {
+    query := `
+        INSERT INTO coin_transactions (user_id, amount, balance_after, kind, sent_gift_id, reference)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at`
+
+    return database.ConnX(ctx, r.db).QueryRowxContext(ctx, query,
+        tx.UserID, tx.Amount, tx.BalanceAfter, tx.Kind, tx.SentGiftID, tx.Reference,
+    ).Scan(&tx.ID, &tx.CreatedAt)
+}
+
+// GetTransactions returns the user's coin history newest first, starting
+// below the before ID when it is set
+func (r *repository) GetTransactions(ctx context.Context, userID, before int64, limit int) ([]*Transaction, error) {
+    query := `
+        SELECT id, user_id, amount, balance_after, kind, sent_gift_id, reference, created_at
+        FROM coin_transactions
+        WHERE user_id = $1 AND ($2 = 0 OR id < $2)
+        ORDER BY id DESC
+        LIMIT $3`
+
+    transactions := []*Transaction{}
+    if err := r.db.SelectContext(ctx, &transactions, query, userID, before, limit); err != nil {
+        return nil, err
+    }
+    return transactions, nil
+}
+
+func (r *repository) CreateSentGift(ctx context.Context, gift *SentGift) error {
+    query := `
+        INSERT INTO sent_gifts (gift_id, sender_id, recipient_id, message_id, note, coins)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        RETURNING id, created_at`
+
+    return database.ConnX(ctx, r.db).QueryRowxContext(ctx, query,
+        gift.GiftID, gift.SenderID, gift.RecipientID, gift.MessageID, gift.Note, gift.Coins,
+    ).Scan(&gift.ID, &gift.CreatedAt)
+}
+
+// AttachToMessage records the gift in the message's metadata, so it shows
+// with the message in the conversation
+func (r *repository) AttachToMessage(ctx context.Context, messageID int64, gift *SentGift) error {
+    attachment, err := json.Marshal(map[string]interface{}{
+        "id":       gift.ID,
+        "gift_id":  gift.GiftID,
+        "name":     gift.Gift.Name,
+        "icon_url": gift.Gift.IconURL,
+        "coins":    gift.Coins,
+    })
+    if err != nil {
+        return err
+    }
+
+    query := `
+        UPDATE messages
+        SET metadata = COALESCE(metadata, '{}'::jsonb) || jsonb_build_object('gift', $2::jsonb)
+        WHERE id = $1`
+
+    _, err = database.ConnX(ctx, r.db).ExecContext(ctx, query, messageID, string(attachment))
+    return err
+}
+
+// sentGiftColumns selects a sent gift with its catalog entry and sender,
+// shown as a ghost when the sender is gone
+var sentGiftColumns = `
+        s.id, s.gift_id, s.sender_id, s.recipient_id, s.message_id, s.note, s.coins, s.created_at,
+        g.slug, g.name, g.icon_url, g.coins,
+        ` + users.UsernameSQL("u") + `, ` + users.DisplayNameSQL("u") + `, ` + users.ProfilePictureSQL("u") + `,
+        ` + users.GhostSQL("u")
+
+// GetReceived returns gifts sent to the user, newest first
+func (r *repository) GetReceived(ctx context.Context, userID int64, limit, offset int) ([]*SentGift, error) {
+    query := `
+        SELECT ` + sentGiftColumns + `
+        FROM sent_gifts s
+        JOIN gifts g ON g.id = s.gift_id
+        LEFT JOIN users u ON u.id = s.sender_id
+        WHERE s.recipient_id = $1
+        ORDER BY s.created_at DESC
+        LIMIT $2 OFFSET $3`
+
+    return r.querySentGifts(ctx, query, userID, limit, offset)
+}
+
+// GetSent returns gifts the user sent, newest first
+func (r *repository) GetSent(ctx context.Context, userID int64, limit, offset int) ([]*SentGift, error) {
+    query := `
+        SELECT ` + sentGiftColumns + `
+        FROM sent_gifts s
+        JOIN gifts g ON g.id = s.gift_id
+        LEFT JOIN users u ON u.id = s.sender_id
+        WHERE s.sender_id = $1
+        ORDER BY s.created_at DESC
+        LIMIT $2 OFFSET $3`
+
+    return r.querySentGifts(ctx, query, userID, limit, offset)
+}
+
+func (r *repository) querySentGifts(ctx context.Context, query string, args ...interface{}) ([]*SentGift, error) {
+    rows, err := r.db.QueryContext(ctx, query, args...)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    sent := []*SentGift{}
+    for rows.Next() {
+        s := &SentGift{Gift: &Gift{}, Sender: &users.Identity{}}
+        err := rows.Scan(
+            &s.ID, &s.GiftID, &s.SenderID, &s.RecipientID, &s.MessageID, &s.Note, &s.Coins, &s.CreatedAt,
+            &s.Gift.Slug, &s.Gift.Name, &s.Gift.IconURL, &s.Gift.Coins,
+            &s.Sender.Username, &s.Sender.DisplayName, &s.Sender.ProfilePicture, &s.Sender.IsGhost,
+        )
+        if err != nil {
+            return nil, err
+        }
+        s.Gift.ID = s.GiftID
+        s.Sender.ID = s.SenderID
+        sent = append(sent, s)
+    }
+    return sent, rows.Err()
+}
+
+// GetShowcase counts the gifts the user has received by kind, most received
+// first
+func (r *repository) GetShowcase(ctx context.Context, userID int64) ([]*ShowcaseItem, error) {
+    query := `
+        SELECT g.id, g.slug, g.name, g.icon_url, g.coins, COUNT(*)
+        FROM sent_gifts s
+        JOIN gifts g ON g.id = s.gift_id
+        WHERE s.recipient_id = $1
+        GROUP BY g.id
+        ORDER BY COUNT(*) DESC, g.coins DESC`
+
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    items := []*ShowcaseItem{}
+    for rows.Next() {
+        item := &ShowcaseItem{Gift: &Gift{}}
+        err := rows.Scan(&item.Gift.ID, &item.Gift.Slug, &item.Gift.Name, &item.Gift.IconURL, &item.Gift.Coins, &item.Count)
+        if err != nil {
+            return nil, err
+        }
+        items = append(items, item)
+    }
+    return items, rows.Err()
+}
+
+// UserExists reports whether the user exists and is active
+func (r *repository) UserExists(ctx context.Context, userID int64) (bool, error) {
+    var exists bool
+    query := `SELECT EXISTS(SELECT 1 FROM users u WHERE u.id = $1 AND ` + users.ActiveSQL("u") + `)`
+    err := r.db.GetContext(ctx, &exists, query, userID)
+    return exists, err
+}
+
+// IsMessageBetween reports whether the message was sent by senderID into a
+// conversation recipientID is still part of
+func (r *repository) IsMessageBetween(ctx context.Context, messageID, senderID, recipientID int64) (bool, error) {
+    var exists bool
+    query := `
+        SELECT EXISTS(
+            SELECT 1 FROM messages m
+            JOIN conversation_participants cp
+              ON cp.conversation_id = m.conversation_id AND cp.user_id = $3 AND cp.left_at IS NULL
+            WHERE m.id = $1 AND m.sender_id = $2 AND COALESCE(m.is_deleted, false) = false
+        )`
+
+    err := r.db.GetContext(ctx, &exists, query, messageID, senderID, recipientID)
+    return exists, err
+}
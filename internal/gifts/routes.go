@@ -0,0 +1,21 @@
+package gifts
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/gifts").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("", handler.GetCatalog).Methods("GET")
+    api.HandleFunc("/send", handler.SendGift).Methods("POST")
+    api.HandleFunc("/received", handler.GetReceived).Methods("GET")
+    api.HandleFunc("/sent", handler.GetSent).Methods("GET")
+    api.HandleFunc("/users/{userId}/showcase", handler.GetShowcase).Methods("GET")
+
+    // Coins are bought through /billing/coins/checkout
+    api.HandleFunc("/wallet", handler.GetWallet).Methods("GET")
+    api.HandleFunc("/wallet/transactions", handler.GetTransactions).Methods("GET")
+}
@@ -0,0 +1,277 @@
+// internal/gifts/service.go
+
+package gifts
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+)
+
+const (
+    DefaultListLimit = 20
+    MaxListLimit     = 100
+
+    catalogCacheKey = "gifts:catalog"
+    catalogCacheTTL = 1 * time.Hour
+)
+
+var (
+    ErrGiftNotFound      = errors.New("gift not found")
+    ErrUserNotFound      = errors.New("user not found")
+    ErrCannotGiftSelf    = errors.New("cannot send a gift to yourself")
+    ErrMessageNotFound   = errors.New("message not found")
+    ErrInsufficientCoins = errors.New("not enough coins")
+)
+
+type Service interface {
+    GetCatalog(ctx context.Context) ([]*Gift, error)
+
+    // Wallet
+    GetWallet(ctx context.Context, userID int64) (*Wallet, error)
+    GetTransactions(ctx context.Context, userID, before int64, limit int) ([]*Transaction, error)
+    CreditPurchase(ctx context.Context, userID int64, coins int, reference string) error
+    CreditReward(ctx context.Context, userID int64, coins int, reference string) error
+
+    // Gifts
+    SendGift(ctx context.Context, senderID int64, req *SendGiftRequest) (*SentGift, *Wallet, error)
+    GetReceived(ctx context.Context, userID int64, limit, offset int) ([]*SentGift, error)
+    GetSent(ctx context.Context, userID int64, limit, offset int) ([]*SentGift, error)
+    GetShowcase(ctx context.Context, userID, viewerID int64) (*Showcase, error)
+
+    SetBlocks(blockService blocks.Service)
+    SetUnitOfWork(uow *database.UnitOfWork)
+}
+
+type service struct {
+    repo   Repository
+    redis  *redis.Client
+    blocks blocks.Service
+    uow    *database.UnitOfWork
+}
+
+// NewService creates the gifts service. redisClient may be nil, in which
+// case the catalog is read from Postgres on every request.
+func NewService(repo Repository, redisClient *redis.Client) Service {
+    return &service{
+        repo:  repo,
+        redis: redisClient,
+    }
+}
+
+// SetBlocks sets the shared blocks service, so blocked users can't send
+// each other gifts or see each other's showcase
+func (s *service) SetBlocks(blockService blocks.Service) {
+    s.blocks = blockService
+}
+
+// SetUnitOfWork makes paying for a gift and delivering it one transaction
+func (s *service) SetUnitOfWork(uow *database.UnitOfWork) {
+    s.uow = uow
+}
+
+func (s *service) GetCatalog(ctx context.Context) ([]*Gift, error) {
+    return s.getCatalog(ctx)
+}
+
+func (s *service) GetWallet(ctx context.Context, userID int64) (*Wallet, error) {
+    return s.repo.GetWallet(ctx, userID)
+}
+
+func (s *service) GetTransactions(ctx context.Context, userID, before int64, limit int) ([]*Transaction, error) {
+    return s.repo.GetTransactions(ctx, userID, before, clampLimit(limit))
+}
+
+// CreditPurchase adds coins bought through billing. A reference already
+// credited is ignored, so provider retries are safe.
+func (s *service) CreditPurchase(ctx context.Context, userID int64, coins int, reference string) error {
+    _, err := s.repo.Credit(ctx, userID, coins, TransactionPurchase, reference)
+    return err
+}
+
+// CreditReward adds coins earned through the referral program. Like
+// purchases, a reference is only credited once.
+func (s *service) CreditReward(ctx context.Context, userID int64, coins int, reference string) error {
+    _, err := s.repo.Credit(ctx, userID, coins, TransactionReferral, reference)
+    return err
+}
+
+// SendGift pays for a gift from the sender's wallet and gives it to the
+// recipient. With a message ID, the gift is attached to that message, which
+// must be one the sender sent into a conversation with the recipient.
+func (s *service) SendGift(ctx context.Context, senderID int64, req *SendGiftRequest) (*SentGift, *Wallet, error) {
+    if req.RecipientID == senderID {
+        return nil, nil, ErrCannotGiftSelf
+    }
+
+    gift, err := s.findGift(ctx, req.GiftID)
+    if err != nil {
+        return nil, nil, err
+    }
+
+    if err := s.checkVisible(ctx, req.RecipientID, senderID); err != nil {
+        return nil, nil, err
+    }
+
+    if req.MessageID != nil {
+        ok, err := s.repo.IsMessageBetween(ctx, *req.MessageID, senderID, req.RecipientID)
+        if err != nil {
+            return nil, nil, err
+        }
+        if !ok {
+            return nil, nil, ErrMessageNotFound
+        }
+    }
+
+    sent := &SentGift{
+        GiftID:      gift.ID,
+        SenderID:    senderID,
+        RecipientID: req.RecipientID,
+        MessageID:   req.MessageID,
+        Coins:       gift.Coins,
+        Gift:        gift,
+    }
+    if note := strings.TrimSpace(req.Note); note != "" {
+        sent.Note = &note
+    }
+
+    wallet := &Wallet{UserID: senderID}
+    err = s.uow.Do(ctx, func(ctx context.Context) error {
+        balance, ok, err := s.repo.DebitWallet(ctx, senderID, gift.Coins)
+        if err != nil {
+            return err
+        }
+        if !ok {
+            return ErrInsufficientCoins
+        }
+        wallet.Balance = balance
+
+        if err := s.repo.CreateSentGift(ctx, sent); err != nil {
+            return fmt.Errorf("failed to save gift: %w", err)
+        }
+
+        if err := s.repo.CreateTransaction(ctx, &Transaction{
+            UserID:       senderID,
+            Amount:       -gift.Coins,
+            BalanceAfter: balance,
+            Kind:         TransactionGiftSent,
+            SentGiftID:   &sent.ID,
+        }); err != nil {
+            return fmt.Errorf("failed to record transaction: %w", err)
+        }
+
+        if sent.MessageID != nil {
+            return s.repo.AttachToMessage(ctx, *sent.MessageID, sent)
+        }
+        return nil
+    })
+    if err != nil {
+        return nil, nil, err
+    }
+
+    return sent, wallet, nil
+}
+
+func (s *service) GetReceived(ctx context.Context, userID int64, limit, offset int) ([]*SentGift, error) {
+    return s.repo.GetReceived(ctx, userID, clampLimit(limit), offset)
+}
+
+func (s *service) GetSent(ctx context.Context, userID int64, limit, offset int) ([]*SentGift, error) {
+    return s.repo.GetSent(ctx, userID, clampLimit(limit), offset)
+}
+
+// GetShowcase returns the gifts shown on a user's profile
+func (s *service) GetShowcase(ctx context.Context, userID, viewerID int64) (*Showcase, error) {
+    if err := s.checkVisible(ctx, userID, viewerID); err != nil {
+        return nil, err
+    }
+
+    items, err := s.repo.GetShowcase(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    showcase := &Showcase{UserID: userID, Items: items}
+    for _, item := range items {
+        showcase.Total += item.Count
+    }
+    return showcase, nil
+}
+
+// checkVisible returns ErrUserNotFound unless the user is active and neither
+// they nor the viewer has blocked the other
+func (s *service) checkVisible(ctx context.Context, userID, viewerID int64) error {
+    exists, err := s.repo.UserExists(ctx, userID)
+    if err != nil {
+        return err
+    }
+    if !exists {
+        return ErrUserNotFound
+    }
+
+    if s.blocks != nil {
+        blocked, err := s.blocks.IsBlocked(ctx, userID, viewerID)
+        if err != nil {
+            return err
+        }
+        if blocked {
+            return ErrUserNotFound
+        }
+    }
+    return nil
+}
+
+// findGift looks up an active gift in the catalog
+func (s *service) findGift(ctx context.Context, giftID int64) (*Gift, error) {
+    catalog, err := s.getCatalog(ctx)
+    if err != nil {
+        return nil, err
+    }
+    for _, gift := range catalog {
+        if gift.ID == giftID {
+            return gift, nil
+        }
+    }
+    return nil, ErrGiftNotFound
+}
+
+// getCatalog reads the catalog from Redis, falling back to Postgres
+func (s *service) getCatalog(ctx context.Context) ([]*Gift, error) {
+    if s.redis != nil {
+        if data, err := s.redis.Get(ctx, catalogCacheKey).Bytes(); err == nil {
+            var catalog []*Gift
+            if err := json.Unmarshal(data, &catalog); err == nil {
+                return catalog, nil
+            }
+        }
+    }
+
+    catalog, err := s.repo.GetCatalog(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load gift catalog: %w", err)
+    }
+
+    if s.redis != nil {
+        if data, err := json.Marshal(catalog); err == nil {
+            s.redis.Set(ctx, catalogCacheKey, data, catalogCacheTTL)
+        }
+    }
+    return catalog, nil
+}
+
+func clampLimit(limit int) int {
+    if limit <= 0 {
+        return DefaultListLimit
+    }
+    if limit > MaxListLimit {
+        return MaxListLimit
+    }
+    return limit
+}
@@ -0,0 +1,66 @@
+// internal/grpcapi/matches.go
+
+package grpcapi
+
+import (
+    "context"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    kiekkyv1 "github.com/imadgeboyega/kiekky-backend/api/proto/kiekky/v1"
+    "github.com/imadgeboyega/kiekky-backend/internal/dating"
+)
+
+type matchServer struct {
+    kiekkyv1.UnimplementedMatchServiceServer
+    dating dating.Service
+}
+
+func (s *matchServer) ListMatches(ctx context.Context, req *kiekkyv1.ListMatchesRequest) (*kiekkyv1.ListMatchesResponse, error) {
+    if req.GetUserId() <= 0 {
+        return nil, status.Error(codes.InvalidArgument, "user_id is required")
+    }
+
+    matches, err := s.dating.GetMatches(ctx, req.GetUserId(), !req.GetInactive())
+    if err != nil {
+        return nil, internalError("ListMatches", err)
+    }
+
+    resp := &kiekkyv1.ListMatchesResponse{Matches: make([]*kiekkyv1.Match, 0, len(matches))}
+    for _, m := range matches {
+        resp.Matches = append(resp.Matches, matchProto(m))
+    }
+    return resp, nil
+}
+
+func (s *matchServer) IsMatched(ctx context.Context, req *kiekkyv1.IsMatchedRequest) (*kiekkyv1.IsMatchedResponse, error) {
+    if req.GetUserId() <= 0 || req.GetOtherUserId() <= 0 {
+        return nil, status.Error(codes.InvalidArgument, "user_id and other_user_id are required")
+    }
+
+    matched, err := s.dating.IsMatched(ctx, req.GetUserId(), req.GetOtherUserId())
+    if err != nil {
+        return nil, internalError("IsMatched", err)
+    }
+    return &kiekkyv1.IsMatchedResponse{Matched: matched}, nil
+}
+
+func matchProto(m *dating.Match) *kiekkyv1.Match {
+    out := &kiekkyv1.Match{
+        Id:               m.ID,
+        User1Id:          m.User1ID,
+        User2Id:          m.User2ID,
+        MatchType:        m.MatchType,
+        InteractionCount: int32(m.InteractionCount),
+        IsActive:         m.IsActive,
+        MatchedAt:        timestamppb.New(m.MatchedAt),
+        LastInteraction:  timestampValue(m.LastInteraction),
+        UnmatchedAt:      timestampValue(m.UnmatchedAt),
+    }
+    if m.CompatibilityScore != nil {
+        out.CompatibilityScore = *m.CompatibilityScore
+    }
+    return out
+}
@@ -0,0 +1,104 @@
+// internal/grpcapi/posts.go
+
+package grpcapi
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    kiekkyv1 "github.com/imadgeboyega/kiekky-backend/api/proto/kiekky/v1"
+    "github.com/imadgeboyega/kiekky-backend/internal/posts"
+)
+
+const (
+    defaultPostsPageSize = 20
+    maxPostsPageSize     = 100
+)
+
+type postServer struct {
+    kiekkyv1.UnimplementedPostServiceServer
+    posts *posts.Service
+}
+
+// GetPost and ListUserPosts read as no particular viewer, so blocks don't
+// hide anything and is_liked/is_saved aren't part of the response
+
+func (s *postServer) GetPost(ctx context.Context, req *kiekkyv1.GetPostRequest) (*kiekkyv1.Post, error) {
+    if req.GetPostId() <= 0 {
+        return nil, status.Error(codes.InvalidArgument, "post_id is required")
+    }
+
+    post, err := s.posts.GetPost(req.GetPostId(), 0)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, status.Error(codes.NotFound, "post not found")
+    }
+    if err != nil {
+        return nil, internalError("GetPost", err)
+    }
+    return postProto(post), nil
+}
+
+func (s *postServer) ListUserPosts(ctx context.Context, req *kiekkyv1.ListUserPostsRequest) (*kiekkyv1.ListUserPostsResponse, error) {
+    if req.GetUserId() <= 0 {
+        return nil, status.Error(codes.InvalidArgument, "user_id is required")
+    }
+
+    page := int(req.GetPage())
+    if page < 1 {
+        page = 1
+    }
+    pageSize := int(req.GetPageSize())
+    if pageSize <= 0 {
+        pageSize = defaultPostsPageSize
+    }
+    if pageSize > maxPostsPageSize {
+        pageSize = maxPostsPageSize
+    }
+
+    feed, err := s.posts.GetUserPosts(req.GetUserId(), 0, page, pageSize)
+    if err != nil {
+        return nil, internalError("ListUserPosts", err)
+    }
+
+    resp := &kiekkyv1.ListUserPostsResponse{
+        Posts:   make([]*kiekkyv1.Post, 0, len(feed.Posts)),
+        Total:   int32(feed.Pagination.Total),
+        HasNext: feed.Pagination.HasNext,
+    }
+    for i := range feed.Posts {
+        resp.Posts = append(resp.Posts, postProto(&feed.Posts[i]))
+    }
+    return resp, nil
+}
+
+func postProto(p *posts.Post) *kiekkyv1.Post {
+    out := &kiekkyv1.Post{
+        Id:            p.ID,
+        UserId:        p.UserID,
+        Caption:       p.Caption,
+        Location:      p.Location.String,
+        Visibility:    p.Visibility,
+        LikesCount:    int32(p.LikesCount),
+        CommentsCount: int32(p.CommentsCount),
+        RepostsCount:  int32(p.RepostsCount),
+        CreatedAt:     timestamppb.New(p.CreatedAt),
+        UpdatedAt:     timestamppb.New(p.UpdatedAt),
+    }
+    if p.OriginalPostID != nil {
+        out.OriginalPostId = *p.OriginalPostID
+    }
+    for _, m := range p.Media {
+        out.Media = append(out.Media, &kiekkyv1.PostMedia{
+            Id:       m.ID,
+            Url:      m.MediaURL,
+            Type:     m.MediaType,
+            Position: int32(m.Position),
+        })
+    }
+    return out
+}
@@ -0,0 +1,151 @@
+// internal/grpcapi/server.go
+// Internal gRPC API: read access to users, posts and matches for services
+// that split off from this binary, such as the recommendation engine and
+// moderation workers. It runs next to the HTTP API on its own port and is
+// never exposed to apps.
+
+package grpcapi
+
+import (
+    "context"
+    "crypto/tls"
+    "crypto/x509"
+    "errors"
+    "fmt"
+    "log"
+    "os"
+    "runtime/debug"
+
+    "google.golang.org/grpc"
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/credentials"
+    "google.golang.org/grpc/credentials/insecure"
+    "google.golang.org/grpc/health"
+    healthpb "google.golang.org/grpc/health/grpc_health_v1"
+    "google.golang.org/grpc/peer"
+    "google.golang.org/grpc/status"
+
+    kiekkyv1 "github.com/imadgeboyega/kiekky-backend/api/proto/kiekky/v1"
+    "github.com/imadgeboyega/kiekky-backend/internal/config"
+    "github.com/imadgeboyega/kiekky-backend/internal/dating"
+    "github.com/imadgeboyega/kiekky-backend/internal/posts"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+// Services are the modules the API reads from
+type Services struct {
+    Users  users.Client
+    Posts  *posts.Service
+    Dating dating.Service
+}
+
+// NewServer builds the gRPC server with every internal service and the
+// standard health service registered. With TLS configured, clients must
+// present a certificate signed by the client CA and, if an allowlist is
+// set, named in it; without it connections are plaintext, which config
+// validation only allows outside production.
+func NewServer(cfg config.GRPCConfig, services Services) (*grpc.Server, error) {
+    opts := []grpc.ServerOption{
+        grpc.ChainUnaryInterceptor(recoverUnary, authorizeUnary(cfg.AllowedClients)),
+    }
+
+    if cfg.TLSEnabled() {
+        tlsConfig, err := serverTLSConfig(cfg)
+        if err != nil {
+            return nil, err
+        }
+        opts = append(opts, grpc.Creds(credentials.NewTLS(tlsConfig)))
+    } else {
+        log.Println("⚠️  gRPC API is running without TLS; set GRPC_TLS_CERT_FILE, GRPC_TLS_KEY_FILE and GRPC_CLIENT_CA_FILE")
+        opts = append(opts, grpc.Creds(insecure.NewCredentials()))
+    }
+
+    server := grpc.NewServer(opts...)
+    kiekkyv1.RegisterUserServiceServer(server, &userServer{users: services.Users})
+    kiekkyv1.RegisterPostServiceServer(server, &postServer{posts: services.Posts})
+    kiekkyv1.RegisterMatchServiceServer(server, &matchServer{dating: services.Dating})
+    healthpb.RegisterHealthServer(server, health.NewServer())
+
+    return server, nil
+}
+
+// serverTLSConfig requires and verifies client certificates
+func serverTLSConfig(cfg config.GRPCConfig) (*tls.Config, error) {
+    cert, err := tls.LoadX509KeyPair(cfg.CertFile, cfg.KeyFile)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load gRPC server certificate: %w", err)
+    }
+
+    caPEM, err := os.ReadFile(cfg.ClientCAFile)
+    if err != nil {
+        return nil, fmt.Errorf("failed to read gRPC client CA: %w", err)
+    }
+    clientCAs := x509.NewCertPool()
+    if !clientCAs.AppendCertsFromPEM(caPEM) {
+        return nil, errors.New("gRPC client CA file has no PEM certificates")
+    }
+
+    return &tls.Config{
+        Certificates: []tls.Certificate{cert},
+        ClientCAs:    clientCAs,
+        ClientAuth:   tls.RequireAndVerifyClientCert,
+        MinVersion:   tls.VersionTLS12,
+    }, nil
+}
+
+// authorizeUnary rejects callers whose verified client certificate isn't
+// named in allowed. An empty allowlist admits every verified client.
+func authorizeUnary(allowed []string) grpc.UnaryServerInterceptor {
+    names := make(map[string]bool, len(allowed))
+    for _, name := range allowed {
+        names[name] = true
+    }
+
+    return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+        if len(names) == 0 {
+            return handler(ctx, req)
+        }
+
+        client := clientName(ctx, names)
+        if client == "" {
+            return nil, status.Error(codes.PermissionDenied, "client certificate is not allowed to call this API")
+        }
+        return handler(ctx, req)
+    }
+}
+
+// clientName returns the first name on the caller's verified certificate
+// that is in names, or "" if there is none
+func clientName(ctx context.Context, names map[string]bool) string {
+    p, ok := peer.FromContext(ctx)
+    if !ok {
+        return ""
+    }
+    tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+    if !ok || len(tlsInfo.State.VerifiedChains) == 0 || len(tlsInfo.State.VerifiedChains[0]) == 0 {
+        return ""
+    }
+
+    leaf := tlsInfo.State.VerifiedChains[0][0]
+    if names[leaf.Subject.CommonName] {
+        return leaf.Subject.CommonName
+    }
+    for _, name := range leaf.DNSNames {
+        if names[name] {
+            return name
+        }
+    }
+    return ""
+}
+
+// recoverUnary turns a panicking handler into an Internal error instead of
+// taking the whole process down
+func recoverUnary(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+    defer func() {
+        if r := recover(); r != nil {
+            log.Printf("❌ gRPC %s panicked: %v\n%s", info.FullMethod, r, debug.Stack())
+            err = status.Error(codes.Internal, "internal error")
+        }
+    }()
+    return handler(ctx, req)
+}
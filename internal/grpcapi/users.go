@@ -0,0 +1,108 @@
+// internal/grpcapi/users.go
+
+package grpcapi
+
+import (
+    "context"
+    "errors"
+    "log"
+    "time"
+
+    "google.golang.org/grpc/codes"
+    "google.golang.org/grpc/status"
+    "google.golang.org/protobuf/types/known/timestamppb"
+
+    kiekkyv1 "github.com/imadgeboyega/kiekky-backend/api/proto/kiekky/v1"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+// maxBatchUsers bounds BatchGetUsers so one call can't load the whole table
+const maxBatchUsers = 500
+
+type userServer struct {
+    kiekkyv1.UnimplementedUserServiceServer
+    users users.Client
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *kiekkyv1.GetUserRequest) (*kiekkyv1.User, error) {
+    if req.GetUserId() <= 0 {
+        return nil, status.Error(codes.InvalidArgument, "user_id is required")
+    }
+
+    user, err := s.users.Get(ctx, req.GetUserId())
+    if errors.Is(err, users.ErrUserNotFound) {
+        return nil, status.Error(codes.NotFound, "user not found")
+    }
+    if err != nil {
+        return nil, internalError("GetUser", err)
+    }
+    return userProto(user, time.Now()), nil
+}
+
+func (s *userServer) BatchGetUsers(ctx context.Context, req *kiekkyv1.BatchGetUsersRequest) (*kiekkyv1.BatchGetUsersResponse, error) {
+    ids := req.GetUserIds()
+    if len(ids) > maxBatchUsers {
+        return nil, status.Errorf(codes.InvalidArgument, "at most %d user_ids per call", maxBatchUsers)
+    }
+
+    found, err := s.users.GetMany(ctx, ids)
+    if err != nil {
+        return nil, internalError("BatchGetUsers", err)
+    }
+
+    now := time.Now()
+    resp := &kiekkyv1.BatchGetUsersResponse{Users: make([]*kiekkyv1.User, 0, len(ids))}
+    for _, id := range ids {
+        resp.Users = append(resp.Users, userProto(found[id], now))
+    }
+    return resp, nil
+}
+
+func userProto(u *users.User, now time.Time) *kiekkyv1.User {
+    out := &kiekkyv1.User{
+        Id:                u.ID,
+        Username:          u.Username,
+        DisplayName:       u.DisplayName,
+        ProfilePicture:    stringValue(u.ProfilePicture),
+        IsGhost:           u.IsGhost,
+        Bio:               stringValue(u.Bio),
+        Gender:            stringValue(u.Gender),
+        Age:               int32(u.Age(now)),
+        Location:          stringValue(u.Location),
+        Interests:         u.Interests,
+        LookingFor:        stringValue(u.LookingFor),
+        IsVerified:        u.IsVerified,
+        IsPhotoVerified:   u.IsPhotoVerified,
+        IsPremium:         u.IsPremium,
+        IsProfileComplete: u.IsProfileComplete,
+        IsOnline:          u.IsOnline,
+        LastSeen:          timestampValue(u.LastSeen),
+    }
+    if u.Latitude != nil && u.Longitude != nil {
+        out.Coordinates = &kiekkyv1.Coordinates{Latitude: *u.Latitude, Longitude: *u.Longitude}
+    }
+    if !u.CreatedAt.IsZero() {
+        out.CreatedAt = timestamppb.New(u.CreatedAt)
+    }
+    return out
+}
+
+func stringValue(s *string) string {
+    if s == nil {
+        return ""
+    }
+    return *s
+}
+
+func timestampValue(t *time.Time) *timestamppb.Timestamp {
+    if t == nil {
+        return nil
+    }
+    return timestamppb.New(*t)
+}
+
+// internalError logs the cause and hides it from the caller
+func internalError(method string, err error) error {
+    log.Printf("❌ gRPC %s failed: %v", method, err)
+    return status.Error(codes.Internal, "internal error")
+}
@@ -0,0 +1,198 @@
+// internal/i18n/i18n.go
+// Message catalogs and language negotiation for user-facing text
+//
+// Every user-facing string lives in a catalog under a dotted key such as
+// "otp.sms.body". Messages use {name} placeholders filled from Vars. A key
+// missing from a language falls back to English, so English must have every
+// key.
+//
+// The language of a request comes from Accept-Language (see Middleware); the
+// language of anything sent to a user later, like notifications and OTPs,
+// comes from their users.preferred_language.
+
+package i18n
+
+import (
+    "context"
+    "fmt"
+    "net/http"
+    "sort"
+    "strconv"
+    "strings"
+)
+
+// Default is the fallback language, and the one every catalog key must exist in
+const Default = "en"
+
+// Catalog maps message keys to messages in one language
+type Catalog map[string]string
+
+// Vars fills the {name} placeholders of a message
+type Vars map[string]interface{}
+
+// catalogs holds every supported language
+var catalogs = map[string]Catalog{
+    "en": english,
+    "fr": french,
+    "es": spanish,
+    "pt": portuguese,
+}
+
+// Supported returns the supported language codes, sorted
+func Supported() []string {
+    langs := make([]string, 0, len(catalogs))
+    for lang := range catalogs {
+        langs = append(langs, lang)
+    }
+    sort.Strings(langs)
+    return langs
+}
+
+// IsSupported reports whether lang is a supported language code
+func IsSupported(lang string) bool {
+    _, ok := catalogs[lang]
+    return ok
+}
+
+// Normalize maps a language tag to a supported language code ("pt-BR" and
+// "PT" both give "pt"), or returns "" if it isn't supported
+func Normalize(tag string) string {
+    base := strings.ToLower(strings.TrimSpace(tag))
+    if i := strings.IndexAny(base, "-_"); i >= 0 {
+        base = base[:i]
+    }
+    if IsSupported(base) {
+        return base
+    }
+    return ""
+}
+
+// Match returns the first supported language among tags, or Default
+func Match(tags ...string) string {
+    for _, tag := range tags {
+        if lang := Normalize(tag); lang != "" {
+            return lang
+        }
+    }
+    return Default
+}
+
+// T returns the message for key in lang with vars filled in. It falls back to
+// English, then to the key itself so a missing message is visible but harmless.
+func T(lang, key string, vars Vars) string {
+    msg, ok := Lookup(lang, key)
+    if !ok {
+        return key
+    }
+    return Format(msg, vars)
+}
+
+// Lookup returns the message for key in lang, falling back to English
+func Lookup(lang, key string) (string, bool) {
+    if msg, ok := catalogs[lang][key]; ok {
+        return msg, true
+    }
+    msg, ok := catalogs[Default][key]
+    return msg, ok
+}
+
+// Has reports whether lang itself, without falling back, has a message for key
+func Has(lang, key string) bool {
+    _, ok := catalogs[lang][key]
+    return ok
+}
+
+// Format fills the {name} placeholders in msg. Placeholders without a value
+// are left as they are.
+func Format(msg string, vars Vars) string {
+    if len(vars) == 0 || !strings.Contains(msg, "{") {
+        return msg
+    }
+
+    pairs := make([]string, 0, len(vars)*2)
+    for name, value := range vars {
+        pairs = append(pairs, "{"+name+"}", fmt.Sprint(value))
+    }
+    return strings.NewReplacer(pairs...).Replace(msg)
+}
+
+// Negotiate picks the best supported language for an Accept-Language header,
+// or Default
+func Negotiate(acceptLanguage string) string {
+    return Match(parseAcceptLanguage(acceptLanguage)...)
+}
+
+// parseAcceptLanguage returns the language tags in the header ordered by
+// quality, dropping any with q=0
+func parseAcceptLanguage(header string) []string {
+    type weighted struct {
+        tag string
+        q   float64
+    }
+
+    var tags []weighted
+    for _, part := range strings.Split(header, ",") {
+        fields := strings.Split(strings.TrimSpace(part), ";")
+        tag := strings.TrimSpace(fields[0])
+        if tag == "" || tag == "*" {
+            continue
+        }
+
+        q := 1.0
+        for _, param := range fields[1:] {
+            param = strings.TrimSpace(param)
+            if strings.HasPrefix(param, "q=") {
+                if v, err := strconv.ParseFloat(param[2:], 64); err == nil {
+                    q = v
+                }
+            }
+        }
+        if q > 0 {
+            tags = append(tags, weighted{tag, q})
+        }
+    }
+
+    sort.SliceStable(tags, func(i, j int) bool { return tags[i].q > tags[j].q })
+
+    ordered := make([]string, len(tags))
+    for i, t := range tags {
+        ordered[i] = t.tag
+    }
+    return ordered
+}
+
+type contextKey struct{}
+
+// WithLanguage returns a context carrying lang
+func WithLanguage(ctx context.Context, lang string) context.Context {
+    return context.WithValue(ctx, contextKey{}, lang)
+}
+
+// FromContext returns the language stored by WithLanguage or Middleware, or
+// Default
+func FromContext(ctx context.Context) string {
+    if lang, ok := ctx.Value(contextKey{}).(string); ok && lang != "" {
+        return lang
+    }
+    return Default
+}
+
+// FromRequest returns the request's language: the one Middleware negotiated,
+// or else the best match for its Accept-Language header
+func FromRequest(r *http.Request) string {
+    if lang, ok := r.Context().Value(contextKey{}).(string); ok && lang != "" {
+        return lang
+    }
+    return Negotiate(r.Header.Get("Accept-Language"))
+}
+
+// Middleware negotiates the request language from Accept-Language and stores
+// it in the request context
+func Middleware(next http.Handler) http.Handler {
+    return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+        lang := Negotiate(r.Header.Get("Accept-Language"))
+        w.Header().Set("Content-Language", lang)
+        w.Header().Add("Vary", "Accept-Language")
+        next.ServeHTTP(w, r.WithContext(WithLanguage(r.Context(), lang)))
+    })
+}
@@ -0,0 +1,132 @@
+// internal/i18n/messages_en.go
+// English messages. Every key must exist here; other languages fall back to it.
+
+package i18n
+
+var english = Catalog{
+    // Request validation. {field} is the JSON field name, {param} the rule's parameter.
+    "validation.invalid_body":      "Invalid request body",
+    "validation.failed":            "Validation failed",
+    "validation.invalid":           "{field} is invalid",
+    "validation.required":          "{field} is required",
+    "validation.required_without":  "{field} is required when {param} is not provided",
+    "validation.required_with":     "{field} is required when {param} is provided",
+    "validation.required_if":       "{field} is required for this {param}",
+    "validation.email":             "{field} must be a valid email",
+    "validation.e164":              "{field} must be a valid phone number (E.164 format)",
+    "validation.url":               "{field} must be a valid URL",
+    "validation.uuid":              "{field} must be a valid UUID",
+    "validation.alphanum":          "{field} must contain only letters and numbers",
+    "validation.numeric":           "{field} must contain only numbers",
+    "validation.eqfield":           "{field} must match {param}",
+    "validation.oneof":             "{field} must be one of: {param}",
+    "validation.timezone":          "{field} must be a valid IANA timezone",
+    "validation.language":          "{field} must be a supported language: {param}",
+    "validation.latitude":          "{field} must be a valid latitude",
+    "validation.longitude":         "{field} must be a valid longitude",
+    "validation.datetime":          "{field} must be a valid date and time (RFC 3339)",
+    "validation.min_string":        "{field} must be at least {param} characters",
+    "validation.max_string":        "{field} must be at most {param} characters",
+    "validation.len_string":        "{field} must be exactly {param} characters",
+    "validation.min_items":         "{field} must have at least {param} items",
+    "validation.max_items":         "{field} must have at most {param} items",
+    "validation.len_items":         "{field} must have exactly {param} items",
+    "validation.min_number":        "{field} must be at least {param}",
+    "validation.max_number":        "{field} must be at most {param}",
+    "validation.len_number":        "{field} must be {param}",
+    "validation.gt":                "{field} must be greater than {param}",
+    "validation.gte":               "{field} must be at least {param}",
+    "validation.lt":                "{field} must be less than {param}",
+    "validation.lte":               "{field} must be at most {param}",
+
+    // OTP codes. {code} is the code, {minutes} how long it is valid.
+    "otp.subject.signup":         "Verify Your Account",
+    "otp.subject.signin":         "Two-Factor Authentication Code",
+    "otp.subject.password_reset": "Password Reset Code",
+    "otp.subject.phone_verify":   "Verify Your Phone Number",
+    "otp.subject.email_verify":   "Verify Your Email Address",
+    "otp.subject.default":        "Verification Code",
+    "otp.sms.body":               "Your verification code is: {code}. It will expire in {minutes} minutes.",
+    "otp.sent":                   "Verification code sent to {recipient}",
+
+    // Notification defaults, used when no stored template exists in the
+    // recipient's language. Placeholders match the template variables.
+    "notification.default.title":        "Kiekky Notification",
+    "notification.default.body":         "You have a new notification",
+    "notification.user_name":            "User {id}",
+    "notification.welcome.title":        "Welcome to Kiekky, {username}! 🎉",
+    "notification.welcome.body":         "We're excited to have you join our community. Complete your profile to get started!",
+    "notification.follow.title":         "New Follower! 👥",
+    "notification.follow.body":          "{follower_name} started following you",
+    "notification.like.title":           "Your post got a new like! ❤️",
+    "notification.like.body":            "{liker_name} liked your post",
+    "notification.comment.title":        "New comment on your post 💬",
+    "notification.comment.body":         "{commenter_name}: {comment}",
+    "notification.message.title":        "Message from {sender_name} 💌",
+    "notification.message.body":         "{message}",
+    "notification.match.title":          "It's a Match! 💕",
+    "notification.match.body":           "You have a new match! Start a conversation now.",
+    "notification.date_request.title":   "New date request 🌹",
+    "notification.date_request.body":    "{sender_name} would like to go on a date with you",
+    "notification.hotpicks.title":       "Your hotpicks are here 🔥",
+    "notification.hotpicks.body":        "{count} people picked for you today. Take a look before they're gone!",
+    "notification.contact_joined.title": "Someone you know is here 👋",
+    "notification.contact_joined.body":  "{contact_name} from your contacts just joined Kiekky. Say hi!",
+    "notification.story_view.title":     "Story View 👀",
+    "notification.story_view.body":      "{viewer_name} viewed your story",
+    "notification.story_reply.title":    "Story Reply 💬",
+    "notification.story_reply.body":     "{replier_name} replied to your story: {reply}",
+    "notification.mention.title":        "You were mentioned! 📢",
+    "notification.mention.body":         "{mentioner_name} mentioned you",
+    "notification.repost.title":         "Your post was reposted 🔁",
+    "notification.repost.body":          "{reposter_name} reposted your post",
+    "notification.post_published.title": "Your post is live 🚀",
+    "notification.post_published.body":  "Your scheduled post was published",
+    "notification.profile_update.title": "Profile Updated ✅",
+    "notification.profile_update.body":  "Your {field} was updated successfully",
+    "notification.verification.title":   "Verify Your Account 🔐",
+    "notification.verification.body":    "Your verification code is: {code}",
+    "notification.security.title":       "Security Alert 🚨",
+    "notification.security.body":        "We noticed {action} on your account. If this wasn't you, secure your account now.",
+    "notification.promotion.title":      "Special Offer! 🎁",
+    "notification.promotion.body":       "Check out our {offer}",
+    "notification.maintenance.title":    "Scheduled Maintenance 🔧",
+    "notification.maintenance.body":     "We'll be performing maintenance {time}",
+    "notification.weekly_recap.title":   "Your week on Kiekky, {username} 📊",
+    "notification.weekly_recap.body":    "{profile_views} profile views, {likes_received} likes and {new_matches} new matches this week",
+
+    // Notification action buttons
+    "notification.action.accept":        "Accept",
+    "notification.action.decline":       "Decline",
+    "notification.action.reply":         "Reply",
+
+    // Account security notifications. {near} is empty or notification.near.
+    "notification.near":                    " near {location}",
+    "notification.new_device_login.title":  "New sign-in to your account 🔐",
+    "notification.new_device_login.body":   "New sign-in from {device}{near}. If this wasn't you, sign out of that device and change your password.",
+    "notification.suspicious_login.title":  "Unusual sign-in attempt 🔐",
+    "notification.suspicious_login.body":   "We asked for extra verification after {reason}{near}. If this wasn't you, change your password.",
+    "notification.account_locked.title":    "Your account has been locked 🔒",
+    "notification.account_locked.body":     "We locked your account after too many failed sign-in attempts. It unlocks automatically at {until} UTC, or you can unlock it now: {unlock_url}",
+
+    // Email layout and bodies (internal/common/email/templates)
+    "email.footer.rights":           "© {year} Kiekky. All rights reserved.",
+    "email.footer.unsubscribe":      "Unsubscribe",
+    "email.footer.preferences":      "Update preferences",
+    "email.otp.preheader":           "Your Kiekky code is {code}",
+    "email.otp.intro":               "Use this code to continue:",
+    "email.otp.expires":             "This code expires in {minutes} minutes.",
+    "email.otp.ignore":              "If you didn't request this code, you can safely ignore this email.",
+    "email.notification.open":       "Open Kiekky",
+    "email.recap.greeting":          "Hi {name}, here's how your week went:",
+    "email.recap.profile_views":     "profile views",
+    "email.recap.likes":             "likes",
+    "email.recap.new_matches":       "new matches",
+    "email.recap.top_post":          "Your top post this week got {likes} likes",
+    "email.recap.view_post":         "View post",
+    "email.invitation.subject":      "You're invited to Kiekky",
+    "email.invitation.greeting":     "Hi {name},",
+    "email.invitation.created":      "An account has been created for you on Kiekky with the username {username}.",
+    "email.invitation.set_password": "Set a password to sign in for the first time:",
+    "email.invitation.button":       "Set your password",
+}
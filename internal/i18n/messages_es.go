@@ -0,0 +1,126 @@
+// internal/i18n/messages_es.go
+// Spanish messages
+
+package i18n
+
+var spanish = Catalog{
+    "validation.invalid_body":      "Cuerpo de la solicitud no válido",
+    "validation.failed":            "La validación falló",
+    "validation.invalid":           "{field} no es válido",
+    "validation.required":          "{field} es obligatorio",
+    "validation.required_without":  "{field} es obligatorio si no se proporciona {param}",
+    "validation.required_with":     "{field} es obligatorio si se proporciona {param}",
+    "validation.required_if":       "{field} es obligatorio para este {param}",
+    "validation.email":             "{field} debe ser un correo electrónico válido",
+    "validation.e164":              "{field} debe ser un número de teléfono válido (formato E.164)",
+    "validation.url":               "{field} debe ser una URL válida",
+    "validation.uuid":              "{field} debe ser un UUID válido",
+    "validation.alphanum":          "{field} solo puede contener letras y números",
+    "validation.numeric":           "{field} solo puede contener números",
+    "validation.eqfield":           "{field} debe coincidir con {param}",
+    "validation.oneof":             "{field} debe ser uno de: {param}",
+    "validation.timezone":          "{field} debe ser una zona horaria IANA válida",
+    "validation.language":          "{field} debe ser un idioma admitido: {param}",
+    "validation.latitude":          "{field} debe ser una latitud válida",
+    "validation.longitude":         "{field} debe ser una longitud válida",
+    "validation.datetime":          "{field} debe ser una fecha y hora válidas (RFC 3339)",
+    "validation.min_string":        "{field} debe tener al menos {param} caracteres",
+    "validation.max_string":        "{field} debe tener como máximo {param} caracteres",
+    "validation.len_string":        "{field} debe tener exactamente {param} caracteres",
+    "validation.min_items":         "{field} debe tener al menos {param} elementos",
+    "validation.max_items":         "{field} debe tener como máximo {param} elementos",
+    "validation.len_items":         "{field} debe tener exactamente {param} elementos",
+    "validation.min_number":        "{field} debe ser al menos {param}",
+    "validation.max_number":        "{field} debe ser como máximo {param}",
+    "validation.len_number":        "{field} debe ser {param}",
+    "validation.gt":                "{field} debe ser mayor que {param}",
+    "validation.gte":               "{field} debe ser al menos {param}",
+    "validation.lt":                "{field} debe ser menor que {param}",
+    "validation.lte":               "{field} debe ser como máximo {param}",
+
+    "otp.subject.signup":         "Verifica tu cuenta",
+    "otp.subject.signin":         "Código de autenticación de dos factores",
+    "otp.subject.password_reset": "Código para restablecer la contraseña",
+    "otp.subject.phone_verify":   "Verifica tu número de teléfono",
+    "otp.subject.email_verify":   "Verifica tu correo electrónico",
+    "otp.subject.default":        "Código de verificación",
+    "otp.sms.body":               "Tu código de verificación es: {code}. Caducará en {minutes} minutos.",
+    "otp.sent":                   "Código de verificación enviado a {recipient}",
+
+    "notification.default.title":        "Notificación de Kiekky",
+    "notification.default.body":         "Tienes una nueva notificación",
+    "notification.user_name":            "Usuario {id}",
+    "notification.welcome.title":        "¡Bienvenido a Kiekky, {username}! 🎉",
+    "notification.welcome.body":         "Estamos emocionados de tenerte en nuestra comunidad. ¡Completa tu perfil para empezar!",
+    "notification.follow.title":         "¡Nuevo seguidor! 👥",
+    "notification.follow.body":          "{follower_name} empezó a seguirte",
+    "notification.like.title":           "¡A alguien le gustó tu publicación! ❤️",
+    "notification.like.body":            "A {liker_name} le gustó tu publicación",
+    "notification.comment.title":        "Nuevo comentario en tu publicación 💬",
+    "notification.comment.body":         "{commenter_name}: {comment}",
+    "notification.message.title":        "Mensaje de {sender_name} 💌",
+    "notification.message.body":         "{message}",
+    "notification.match.title":          "¡Es un match! 💕",
+    "notification.match.body":           "¡Tienes un nuevo match! Empieza una conversación ahora.",
+    "notification.date_request.title":   "Nueva solicitud de cita 🌹",
+    "notification.date_request.body":    "{sender_name} quiere tener una cita contigo",
+    "notification.hotpicks.title":       "Tus favoritos del día ya están aquí 🔥",
+    "notification.hotpicks.body":        "{count} personas elegidas para ti hoy. ¡Échales un vistazo antes de que desaparezcan!",
+    "notification.contact_joined.title": "Alguien que conoces está aquí 👋",
+    "notification.contact_joined.body":  "{contact_name}, de tus contactos, acaba de unirse a Kiekky. ¡Salúdale!",
+    "notification.story_view.title":     "Vista de historia 👀",
+    "notification.story_view.body":      "{viewer_name} vio tu historia",
+    "notification.story_reply.title":    "Respuesta a tu historia 💬",
+    "notification.story_reply.body":     "{replier_name} respondió a tu historia: {reply}",
+    "notification.mention.title":        "¡Te mencionaron! 📢",
+    "notification.mention.body":         "{mentioner_name} te mencionó",
+    "notification.repost.title":         "Tu publicación fue compartida 🔁",
+    "notification.repost.body":          "{reposter_name} compartió tu publicación",
+    "notification.post_published.title": "Tu publicación ya está visible 🚀",
+    "notification.post_published.body":  "Tu publicación programada fue publicada",
+    "notification.profile_update.title": "Perfil actualizado ✅",
+    "notification.profile_update.body":  "Tu {field} se actualizó correctamente",
+    "notification.verification.title":   "Verifica tu cuenta 🔐",
+    "notification.verification.body":    "Tu código de verificación es: {code}",
+    "notification.security.title":       "Alerta de seguridad 🚨",
+    "notification.security.body":        "Detectamos {action} en tu cuenta. Si no fuiste tú, protege tu cuenta ahora.",
+    "notification.promotion.title":      "¡Oferta especial! 🎁",
+    "notification.promotion.body":       "Descubre nuestra {offer}",
+    "notification.maintenance.title":    "Mantenimiento programado 🔧",
+    "notification.maintenance.body":     "Realizaremos tareas de mantenimiento {time}",
+    "notification.weekly_recap.title":   "Tu semana en Kiekky, {username} 📊",
+    "notification.weekly_recap.body":    "{profile_views} visitas al perfil, {likes_received} me gusta y {new_matches} nuevos matches esta semana",
+
+    // Notification action buttons
+    "notification.action.accept":        "Aceptar",
+    "notification.action.decline":       "Rechazar",
+    "notification.action.reply":         "Responder",
+
+    "notification.near":                    " cerca de {location}",
+    "notification.new_device_login.title":  "Nuevo inicio de sesión en tu cuenta 🔐",
+    "notification.new_device_login.body":   "Nuevo inicio de sesión desde {device}{near}. Si no fuiste tú, cierra la sesión en ese dispositivo y cambia tu contraseña.",
+    "notification.suspicious_login.title":  "Intento de inicio de sesión inusual 🔐",
+    "notification.suspicious_login.body":   "Pedimos una verificación adicional tras {reason}{near}. Si no fuiste tú, cambia tu contraseña.",
+    "notification.account_locked.title":    "Tu cuenta ha sido bloqueada 🔒",
+    "notification.account_locked.body":     "Bloqueamos tu cuenta tras demasiados intentos fallidos de inicio de sesión. Se desbloqueará automáticamente a las {until} UTC, o puedes desbloquearla ahora: {unlock_url}",
+
+    "email.footer.rights":           "© {year} Kiekky. Todos los derechos reservados.",
+    "email.footer.unsubscribe":      "Darse de baja",
+    "email.footer.preferences":      "Actualizar preferencias",
+    "email.otp.preheader":           "Tu código de Kiekky es {code}",
+    "email.otp.intro":               "Usa este código para continuar:",
+    "email.otp.expires":             "Este código caduca en {minutes} minutos.",
+    "email.otp.ignore":              "Si no solicitaste este código, puedes ignorar este correo.",
+    "email.notification.open":       "Abrir Kiekky",
+    "email.recap.greeting":          "Hola {name}, así fue tu semana:",
+    "email.recap.profile_views":     "visitas al perfil",
+    "email.recap.likes":             "me gusta",
+    "email.recap.new_matches":       "nuevos matches",
+    "email.recap.top_post":          "Tu mejor publicación de la semana recibió {likes} me gusta",
+    "email.recap.view_post":         "Ver publicación",
+    "email.invitation.subject":      "Te han invitado a Kiekky",
+    "email.invitation.greeting":     "Hola {name}:",
+    "email.invitation.created":      "Se ha creado una cuenta para ti en Kiekky con el nombre de usuario {username}.",
+    "email.invitation.set_password": "Crea una contraseña para iniciar sesión por primera vez:",
+    "email.invitation.button":       "Crear contraseña",
+}
@@ -0,0 +1,126 @@
+// internal/i18n/messages_fr.go
+// French messages
+
+package i18n
+
+var french = Catalog{
+    "validation.invalid_body":      "Corps de la requête invalide",
+    "validation.failed":            "La validation a échoué",
+    "validation.invalid":           "{field} est invalide",
+    "validation.required":          "{field} est obligatoire",
+    "validation.required_without":  "{field} est obligatoire si {param} n'est pas fourni",
+    "validation.required_with":     "{field} est obligatoire si {param} est fourni",
+    "validation.required_if":       "{field} est obligatoire pour ce {param}",
+    "validation.email":             "{field} doit être une adresse e-mail valide",
+    "validation.e164":              "{field} doit être un numéro de téléphone valide (format E.164)",
+    "validation.url":               "{field} doit être une URL valide",
+    "validation.uuid":              "{field} doit être un UUID valide",
+    "validation.alphanum":          "{field} ne doit contenir que des lettres et des chiffres",
+    "validation.numeric":           "{field} ne doit contenir que des chiffres",
+    "validation.eqfield":           "{field} doit correspondre à {param}",
+    "validation.oneof":             "{field} doit être l'une des valeurs : {param}",
+    "validation.timezone":          "{field} doit être un fuseau horaire IANA valide",
+    "validation.language":          "{field} doit être une langue prise en charge : {param}",
+    "validation.latitude":          "{field} doit être une latitude valide",
+    "validation.longitude":         "{field} doit être une longitude valide",
+    "validation.datetime":          "{field} doit être une date et heure valides (RFC 3339)",
+    "validation.min_string":        "{field} doit contenir au moins {param} caractères",
+    "validation.max_string":        "{field} doit contenir au plus {param} caractères",
+    "validation.len_string":        "{field} doit contenir exactement {param} caractères",
+    "validation.min_items":         "{field} doit contenir au moins {param} éléments",
+    "validation.max_items":         "{field} doit contenir au plus {param} éléments",
+    "validation.len_items":         "{field} doit contenir exactement {param} éléments",
+    "validation.min_number":        "{field} doit être au moins {param}",
+    "validation.max_number":        "{field} doit être au plus {param}",
+    "validation.len_number":        "{field} doit être {param}",
+    "validation.gt":                "{field} doit être supérieur à {param}",
+    "validation.gte":               "{field} doit être au moins {param}",
+    "validation.lt":                "{field} doit être inférieur à {param}",
+    "validation.lte":               "{field} doit être au plus {param}",
+
+    "otp.subject.signup":         "Vérifiez votre compte",
+    "otp.subject.signin":         "Code d'authentification à deux facteurs",
+    "otp.subject.password_reset": "Code de réinitialisation du mot de passe",
+    "otp.subject.phone_verify":   "Vérifiez votre numéro de téléphone",
+    "otp.subject.email_verify":   "Vérifiez votre adresse e-mail",
+    "otp.subject.default":        "Code de vérification",
+    "otp.sms.body":               "Votre code de vérification est : {code}. Il expirera dans {minutes} minutes.",
+    "otp.sent":                   "Code de vérification envoyé à {recipient}",
+
+    "notification.default.title":        "Notification Kiekky",
+    "notification.default.body":         "Vous avez une nouvelle notification",
+    "notification.user_name":            "Utilisateur {id}",
+    "notification.welcome.title":        "Bienvenue sur Kiekky, {username} ! 🎉",
+    "notification.welcome.body":         "Nous sommes ravis de vous accueillir dans notre communauté. Complétez votre profil pour commencer !",
+    "notification.follow.title":         "Nouvel abonné ! 👥",
+    "notification.follow.body":          "{follower_name} a commencé à vous suivre",
+    "notification.like.title":           "Votre publication a reçu un j'aime ! ❤️",
+    "notification.like.body":            "{liker_name} a aimé votre publication",
+    "notification.comment.title":        "Nouveau commentaire sur votre publication 💬",
+    "notification.comment.body":         "{commenter_name} : {comment}",
+    "notification.message.title":        "Message de {sender_name} 💌",
+    "notification.message.body":         "{message}",
+    "notification.match.title":          "C'est un match ! 💕",
+    "notification.match.body":           "Vous avez un nouveau match ! Lancez la conversation.",
+    "notification.date_request.title":   "Nouvelle demande de rendez-vous 🌹",
+    "notification.date_request.body":    "{sender_name} aimerait sortir avec vous",
+    "notification.hotpicks.title":       "Vos coups de cœur sont là 🔥",
+    "notification.hotpicks.body":        "{count} personnes choisies pour vous aujourd'hui. Jetez un œil avant qu'elles ne disparaissent !",
+    "notification.contact_joined.title": "Quelqu'un que vous connaissez est là 👋",
+    "notification.contact_joined.body":  "{contact_name}, de vos contacts, vient de rejoindre Kiekky. Dites bonjour !",
+    "notification.story_view.title":     "Vue de story 👀",
+    "notification.story_view.body":      "{viewer_name} a vu votre story",
+    "notification.story_reply.title":    "Réponse à votre story 💬",
+    "notification.story_reply.body":     "{replier_name} a répondu à votre story : {reply}",
+    "notification.mention.title":        "Vous avez été mentionné ! 📢",
+    "notification.mention.body":         "{mentioner_name} vous a mentionné",
+    "notification.repost.title":         "Votre publication a été republiée 🔁",
+    "notification.repost.body":          "{reposter_name} a republié votre publication",
+    "notification.post_published.title": "Votre publication est en ligne 🚀",
+    "notification.post_published.body":  "Votre publication programmée a été publiée",
+    "notification.profile_update.title": "Profil mis à jour ✅",
+    "notification.profile_update.body":  "Votre {field} a bien été mis à jour",
+    "notification.verification.title":   "Vérifiez votre compte 🔐",
+    "notification.verification.body":    "Votre code de vérification est : {code}",
+    "notification.security.title":       "Alerte de sécurité 🚨",
+    "notification.security.body":        "Nous avons remarqué {action} sur votre compte. Si ce n'était pas vous, sécurisez votre compte dès maintenant.",
+    "notification.promotion.title":      "Offre spéciale ! 🎁",
+    "notification.promotion.body":       "Découvrez notre {offer}",
+    "notification.maintenance.title":    "Maintenance programmée 🔧",
+    "notification.maintenance.body":     "Une maintenance aura lieu {time}",
+    "notification.weekly_recap.title":   "Votre semaine sur Kiekky, {username} 📊",
+    "notification.weekly_recap.body":    "{profile_views} vues du profil, {likes_received} j'aime et {new_matches} nouveaux matchs cette semaine",
+
+    // Notification action buttons
+    "notification.action.accept":        "Accepter",
+    "notification.action.decline":       "Refuser",
+    "notification.action.reply":         "Répondre",
+
+    "notification.near":                    " près de {location}",
+    "notification.new_device_login.title":  "Nouvelle connexion à votre compte 🔐",
+    "notification.new_device_login.body":   "Nouvelle connexion depuis {device}{near}. Si ce n'était pas vous, déconnectez cet appareil et changez votre mot de passe.",
+    "notification.suspicious_login.title":  "Tentative de connexion inhabituelle 🔐",
+    "notification.suspicious_login.body":   "Nous avons demandé une vérification supplémentaire après {reason}{near}. Si ce n'était pas vous, changez votre mot de passe.",
+    "notification.account_locked.title":    "Votre compte a été verrouillé 🔒",
+    "notification.account_locked.body":     "Nous avons verrouillé votre compte après trop de tentatives de connexion échouées. Il sera déverrouillé automatiquement à {until} UTC, ou vous pouvez le déverrouiller maintenant : {unlock_url}",
+
+    "email.footer.rights":           "© {year} Kiekky. Tous droits réservés.",
+    "email.footer.unsubscribe":      "Se désabonner",
+    "email.footer.preferences":      "Modifier mes préférences",
+    "email.otp.preheader":           "Votre code Kiekky est {code}",
+    "email.otp.intro":               "Utilisez ce code pour continuer :",
+    "email.otp.expires":             "Ce code expire dans {minutes} minutes.",
+    "email.otp.ignore":              "Si vous n'avez pas demandé ce code, vous pouvez ignorer cet e-mail.",
+    "email.notification.open":       "Ouvrir Kiekky",
+    "email.recap.greeting":          "Bonjour {name}, voici le résumé de votre semaine :",
+    "email.recap.profile_views":     "vues du profil",
+    "email.recap.likes":             "j'aime",
+    "email.recap.new_matches":       "nouveaux matchs",
+    "email.recap.top_post":          "Votre meilleure publication de la semaine a reçu {likes} j'aime",
+    "email.recap.view_post":         "Voir la publication",
+    "email.invitation.subject":      "Vous êtes invité sur Kiekky",
+    "email.invitation.greeting":     "Bonjour {name},",
+    "email.invitation.created":      "Un compte a été créé pour vous sur Kiekky avec le nom d'utilisateur {username}.",
+    "email.invitation.set_password": "Choisissez un mot de passe pour vous connecter la première fois :",
+    "email.invitation.button":       "Choisir mon mot de passe",
+}
@@ -0,0 +1,126 @@
+// internal/i18n/messages_pt.go
+// Portuguese messages
+
+package i18n
+
+var portuguese = Catalog{
+    "validation.invalid_body":      "Corpo da requisição inválido",
+    "validation.failed":            "A validação falhou",
+    "validation.invalid":           "{field} é inválido",
+    "validation.required":          "{field} é obrigatório",
+    "validation.required_without":  "{field} é obrigatório quando {param} não é informado",
+    "validation.required_with":     "{field} é obrigatório quando {param} é informado",
+    "validation.required_if":       "{field} é obrigatório para este {param}",
+    "validation.email":             "{field} deve ser um e-mail válido",
+    "validation.e164":              "{field} deve ser um número de telefone válido (formato E.164)",
+    "validation.url":               "{field} deve ser uma URL válida",
+    "validation.uuid":              "{field} deve ser um UUID válido",
+    "validation.alphanum":          "{field} deve conter apenas letras e números",
+    "validation.numeric":           "{field} deve conter apenas números",
+    "validation.eqfield":           "{field} deve ser igual a {param}",
+    "validation.oneof":             "{field} deve ser um de: {param}",
+    "validation.timezone":          "{field} deve ser um fuso horário IANA válido",
+    "validation.language":          "{field} deve ser um idioma suportado: {param}",
+    "validation.latitude":          "{field} deve ser uma latitude válida",
+    "validation.longitude":         "{field} deve ser uma longitude válida",
+    "validation.datetime":          "{field} deve ser uma data e hora válidas (RFC 3339)",
+    "validation.min_string":        "{field} deve ter pelo menos {param} caracteres",
+    "validation.max_string":        "{field} deve ter no máximo {param} caracteres",
+    "validation.len_string":        "{field} deve ter exatamente {param} caracteres",
+    "validation.min_items":         "{field} deve ter pelo menos {param} itens",
+    "validation.max_items":         "{field} deve ter no máximo {param} itens",
+    "validation.len_items":         "{field} deve ter exatamente {param} itens",
+    "validation.min_number":        "{field} deve ser no mínimo {param}",
+    "validation.max_number":        "{field} deve ser no máximo {param}",
+    "validation.len_number":        "{field} deve ser {param}",
+    "validation.gt":                "{field} deve ser maior que {param}",
+    "validation.gte":               "{field} deve ser no mínimo {param}",
+    "validation.lt":                "{field} deve ser menor que {param}",
+    "validation.lte":               "{field} deve ser no máximo {param}",
+
+    "otp.subject.signup":         "Verifique sua conta",
+    "otp.subject.signin":         "Código de autenticação de dois fatores",
+    "otp.subject.password_reset": "Código de redefinição de senha",
+    "otp.subject.phone_verify":   "Verifique seu número de telefone",
+    "otp.subject.email_verify":   "Verifique seu endereço de e-mail",
+    "otp.subject.default":        "Código de verificação",
+    "otp.sms.body":               "Seu código de verificação é: {code}. Ele expira em {minutes} minutos.",
+    "otp.sent":                   "Código de verificação enviado para {recipient}",
+
+    "notification.default.title":        "Notificação do Kiekky",
+    "notification.default.body":         "Você tem uma nova notificação",
+    "notification.user_name":            "Usuário {id}",
+    "notification.welcome.title":        "Bem-vindo ao Kiekky, {username}! 🎉",
+    "notification.welcome.body":         "Estamos felizes em ter você na nossa comunidade. Complete seu perfil para começar!",
+    "notification.follow.title":         "Novo seguidor! 👥",
+    "notification.follow.body":          "{follower_name} começou a seguir você",
+    "notification.like.title":           "Sua publicação recebeu uma curtida! ❤️",
+    "notification.like.body":            "{liker_name} curtiu sua publicação",
+    "notification.comment.title":        "Novo comentário na sua publicação 💬",
+    "notification.comment.body":         "{commenter_name}: {comment}",
+    "notification.message.title":        "Mensagem de {sender_name} 💌",
+    "notification.message.body":         "{message}",
+    "notification.match.title":          "Deu match! 💕",
+    "notification.match.body":           "Você tem um novo match! Comece uma conversa agora.",
+    "notification.date_request.title":   "Novo pedido de encontro 🌹",
+    "notification.date_request.body":    "{sender_name} quer sair com você",
+    "notification.hotpicks.title":       "Seus destaques chegaram 🔥",
+    "notification.hotpicks.body":        "{count} pessoas escolhidas para você hoje. Dê uma olhada antes que sumam!",
+    "notification.contact_joined.title": "Alguém que você conhece chegou 👋",
+    "notification.contact_joined.body":  "{contact_name}, dos seus contatos, acabou de entrar no Kiekky. Diga oi!",
+    "notification.story_view.title":     "Visualização do story 👀",
+    "notification.story_view.body":      "{viewer_name} viu seu story",
+    "notification.story_reply.title":    "Resposta ao story 💬",
+    "notification.story_reply.body":     "{replier_name} respondeu ao seu story: {reply}",
+    "notification.mention.title":        "Você foi mencionado! 📢",
+    "notification.mention.body":         "{mentioner_name} mencionou você",
+    "notification.repost.title":         "Sua publicação foi republicada 🔁",
+    "notification.repost.body":          "{reposter_name} republicou sua publicação",
+    "notification.post_published.title": "Sua publicação está no ar 🚀",
+    "notification.post_published.body":  "Sua publicação agendada foi publicada",
+    "notification.profile_update.title": "Perfil atualizado ✅",
+    "notification.profile_update.body":  "Seu {field} foi atualizado com sucesso",
+    "notification.verification.title":   "Verifique sua conta 🔐",
+    "notification.verification.body":    "Seu código de verificação é: {code}",
+    "notification.security.title":       "Alerta de segurança 🚨",
+    "notification.security.body":        "Notamos {action} na sua conta. Se não foi você, proteja sua conta agora.",
+    "notification.promotion.title":      "Oferta especial! 🎁",
+    "notification.promotion.body":       "Confira nossa {offer}",
+    "notification.maintenance.title":    "Manutenção programada 🔧",
+    "notification.maintenance.body":     "Faremos uma manutenção {time}",
+    "notification.weekly_recap.title":   "Sua semana no Kiekky, {username} 📊",
+    "notification.weekly_recap.body":    "{profile_views} visualizações do perfil, {likes_received} curtidas e {new_matches} novos matches nesta semana",
+
+    // Notification action buttons
+    "notification.action.accept":        "Aceitar",
+    "notification.action.decline":       "Recusar",
+    "notification.action.reply":         "Responder",
+
+    "notification.near":                    " perto de {location}",
+    "notification.new_device_login.title":  "Novo acesso à sua conta 🔐",
+    "notification.new_device_login.body":   "Novo acesso a partir de {device}{near}. Se não foi você, desconecte esse dispositivo e altere sua senha.",
+    "notification.suspicious_login.title":  "Tentativa de acesso incomum 🔐",
+    "notification.suspicious_login.body":   "Pedimos uma verificação extra após {reason}{near}. Se não foi você, altere sua senha.",
+    "notification.account_locked.title":    "Sua conta foi bloqueada 🔒",
+    "notification.account_locked.body":     "Bloqueamos sua conta após muitas tentativas de acesso sem sucesso. Ela será desbloqueada automaticamente às {until} UTC, ou você pode desbloqueá-la agora: {unlock_url}",
+
+    "email.footer.rights":           "© {year} Kiekky. Todos os direitos reservados.",
+    "email.footer.unsubscribe":      "Cancelar inscrição",
+    "email.footer.preferences":      "Atualizar preferências",
+    "email.otp.preheader":           "Seu código do Kiekky é {code}",
+    "email.otp.intro":               "Use este código para continuar:",
+    "email.otp.expires":             "Este código expira em {minutes} minutos.",
+    "email.otp.ignore":              "Se você não solicitou este código, pode ignorar este e-mail.",
+    "email.notification.open":       "Abrir o Kiekky",
+    "email.recap.greeting":          "Olá {name}, veja como foi sua semana:",
+    "email.recap.profile_views":     "visualizações do perfil",
+    "email.recap.likes":             "curtidas",
+    "email.recap.new_matches":       "novos matches",
+    "email.recap.top_post":          "Sua melhor publicação da semana recebeu {likes} curtidas",
+    "email.recap.view_post":         "Ver publicação",
+    "email.invitation.subject":      "Você foi convidado para o Kiekky",
+    "email.invitation.greeting":     "Olá {name},",
+    "email.invitation.created":      "Uma conta foi criada para você no Kiekky com o nome de usuário {username}.",
+    "email.invitation.set_password": "Defina uma senha para entrar pela primeira vez:",
+    "email.invitation.button":       "Definir minha senha",
+}
@@ -0,0 +1,98 @@
+// internal/interests/handlers.go
+
+package interests
+
+import (
+    "net/http"
+    "strconv"
+    "strings"
+    "unicode/utf8"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// SuggestInterests handles GET /interests/suggest?q=&limit=
+func (h *Handler) SuggestInterests(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    query := r.URL.Query().Get("q")
+    if utf8.RuneCountInString(query) > MaxQueryLength {
+        utils.RespondWithError(w, http.StatusBadRequest, "Query is too long")
+        return
+    }
+
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    if limit <= 0 {
+        limit = DefaultSuggestLimit
+    }
+    if limit > MaxSuggestLimit {
+        limit = MaxSuggestLimit
+    }
+
+    suggestions, err := h.service.SuggestInterests(r.Context(), userID, query, limit)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get interest suggestions")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, SuggestResponse{
+        Query:       query,
+        Suggestions: suggestions,
+    })
+}
+
+// BrowseInterests handles GET /interests?category=
+func (h *Handler) BrowseInterests(w http.ResponseWriter, r *http.Request) {
+    categories, err := h.service.Browse(r.Context(), r.URL.Query().Get("category"))
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get interests")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, BrowseResponse{Categories: categories})
+}
+
+// SearchInterests handles GET /interests/search?q=&category=&limit=
+func (h *Handler) SearchInterests(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query().Get("q")
+    if strings.TrimSpace(query) == "" {
+        utils.RespondWithError(w, http.StatusBadRequest, "Query is required")
+        return
+    }
+    if utf8.RuneCountInString(query) > MaxQueryLength {
+        utils.RespondWithError(w, http.StatusBadRequest, "Query is too long")
+        return
+    }
+
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    if limit <= 0 {
+        limit = DefaultSearchLimit
+    }
+    if limit > MaxSearchLimit {
+        limit = MaxSearchLimit
+    }
+
+    results, err := h.service.Search(r.Context(), query, r.URL.Query().Get("category"), limit)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to search interests")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, SearchResponse{
+        Query:     query,
+        Interests: results,
+    })
+}
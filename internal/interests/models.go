@@ -0,0 +1,72 @@
+// internal/interests/models.go
+
+package interests
+
+import (
+    "github.com/lib/pq"
+)
+
+// Interest is an entry in the canonical interests catalog
+type Interest struct {
+    ID       int64          `json:"id" db:"id"`
+    Slug     string         `json:"slug" db:"slug"`
+    Name     string         `json:"name" db:"name"`
+    Category string         `json:"category,omitempty" db:"category"`
+    Aliases  pq.StringArray `json:"aliases,omitempty" db:"aliases"`
+}
+
+// Match types, strongest first
+const (
+    MatchExact      = "exact"
+    MatchPrefix     = "prefix"
+    MatchWordPrefix = "word_prefix"
+    MatchFuzzy      = "fuzzy"
+    MatchPopular    = "popular"
+)
+
+// Suggestion is a catalog interest offered for a partial query
+type Suggestion struct {
+    ID         int64  `json:"id"`
+    Slug       string `json:"slug"`
+    Name       string `json:"name"`
+    Category   string `json:"category,omitempty"`
+    Popularity int    `json:"popularity"`
+    Match      string `json:"match"`
+}
+
+// SuggestResponse is returned by the suggest endpoint
+type SuggestResponse struct {
+    Query       string        `json:"query"`
+    Suggestions []*Suggestion `json:"suggestions"`
+}
+
+// CatalogInterest is a catalog interest with how many users list it
+type CatalogInterest struct {
+    *Interest
+    UserCount int `json:"user_count"`
+}
+
+// Category groups the catalog interests in it
+type Category struct {
+    Name      string             `json:"name"`
+    Interests []*CatalogInterest `json:"interests"`
+}
+
+// BrowseResponse is returned by the browse endpoint
+type BrowseResponse struct {
+    Categories []*Category `json:"categories"`
+}
+
+// SearchResponse is returned by the search endpoint
+type SearchResponse struct {
+    Query     string             `json:"query"`
+    Interests []*CatalogInterest `json:"interests"`
+}
+
+// viewerContext is what popularity is scoped to: users near the viewer or
+// sharing at least one of their interests
+type viewerContext struct {
+    Latitude  *float64       `db:"latitude"`
+    Longitude *float64       `db:"longitude"`
+    Interests pq.StringArray `db:"interests"`
+}
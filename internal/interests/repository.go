@@ -0,0 +1,136 @@
+// internal/interests/repository.go
+
+package interests
+
+import (
+    "context"
+    "database/sql"
+    "math"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+)
+
+// nearbyRadiusKm bounds the "nearby users" half of the popularity signal
+const nearbyRadiusKm = 50.0
+
+type Repository interface {
+    GetCatalog(ctx context.Context) ([]*Interest, error)
+    CountUsers(ctx context.Context) (map[int64]int, error)
+    GetViewerContext(ctx context.Context, userID int64) (*viewerContext, error)
+    CountInterestUsage(ctx context.Context, userID int64, viewer *viewerContext) (map[string]int, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+// GetCatalog returns every active catalog interest
+func (r *repository) GetCatalog(ctx context.Context) ([]*Interest, error) {
+    query := `
+        SELECT id, slug, name, COALESCE(category, '') AS category, COALESCE(aliases, '{}') AS aliases
+        FROM interests
+        WHERE is_active = true
+        ORDER BY id`
+
+    catalog := []*Interest{}
+    if err := r.db.SelectContext(ctx, &catalog, query); err != nil {
+        return nil, err
+    }
+    return catalog, nil
+}
+
+// CountUsers counts the active users listing each catalog interest. Profiles
+// store catalog names, so this is an exact match the GIN index can serve.
+func (r *repository) CountUsers(ctx context.Context) (map[int64]int, error) {
+    query := `
+        SELECT i.id, COUNT(u.id)
+        FROM interests i
+        JOIN users u ON u.interests @> ARRAY[i.name::text] AND ` + users.ActiveSQL("u") + `
+        WHERE i.is_active = true
+        GROUP BY i.id`
+
+    rows, err := r.db.QueryContext(ctx, query)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    counts := make(map[int64]int)
+    for rows.Next() {
+        var id int64
+        var n int
+        if err := rows.Scan(&id, &n); err != nil {
+            return nil, err
+        }
+        counts[id] = n
+    }
+    return counts, rows.Err()
+}
+
+// GetViewerContext loads the viewer's location and current interests
+func (r *repository) GetViewerContext(ctx context.Context, userID int64) (*viewerContext, error) {
+    query := `SELECT latitude, longitude, COALESCE(interests, '{}') AS interests FROM users WHERE id = $1`
+
+    var viewer viewerContext
+    err := r.db.GetContext(ctx, &viewer, query, userID)
+    if err == sql.ErrNoRows {
+        return &viewerContext{}, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &viewer, nil
+}
+
+// CountInterestUsage counts how many users list each interest, keyed by the
+// lowercased interest text. Only users near the viewer or sharing one of
+// their interests are counted; with neither signal every active user is.
+func (r *repository) CountInterestUsage(ctx context.Context, userID int64, viewer *viewerContext) (map[string]int, error) {
+    hasLocation := viewer.Latitude != nil && viewer.Longitude != nil
+    global := !hasLocation && len(viewer.Interests) == 0
+
+    // Bounding box rather than a great-circle distance; good enough for ranking
+    var latDelta, lngDelta float64
+    if hasLocation {
+        latDelta = nearbyRadiusKm / 111.0
+        lngDelta = nearbyRadiusKm / (111.0 * math.Max(math.Cos(*viewer.Latitude*math.Pi/180), 0.01))
+    }
+
+    query := `
+        SELECT LOWER(TRIM(i)) AS interest, COUNT(DISTINCT u.id) AS users
+        FROM users u, UNNEST(u.interests) AS i
+        WHERE u.id <> $1
+          AND COALESCE(u.account_status, 'active') = 'active'
+          AND (
+              $2::boolean
+              OR ($3::float8 IS NOT NULL
+                  AND u.latitude BETWEEN $3::float8 - $5::float8 AND $3::float8 + $5::float8
+                  AND u.longitude BETWEEN $4::float8 - $6::float8 AND $4::float8 + $6::float8)
+              OR u.interests && $7::text[]
+          )
+        GROUP BY 1`
+
+    rows, err := r.db.QueryContext(ctx, query,
+        userID, global, viewer.Latitude, viewer.Longitude, latDelta, lngDelta, pq.Array(viewer.Interests))
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    counts := make(map[string]int)
+    for rows.Next() {
+        var interest string
+        var users int
+        if err := rows.Scan(&interest, &users); err != nil {
+            return nil, err
+        }
+        counts[interest] = users
+    }
+    return counts, rows.Err()
+}
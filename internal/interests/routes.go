@@ -0,0 +1,16 @@
+package interests
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/interests").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("", middleware.ConditionalGETFunc(middleware.CacheCatalog, handler.BrowseInterests)).Methods("GET")
+    api.HandleFunc("/search", handler.SearchInterests).Methods("GET")
+    api.HandleFunc("/suggest", handler.SuggestInterests).Methods("GET")
+}
@@ -0,0 +1,428 @@
+// internal/interests/service.go
+
+package interests
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "sort"
+    "strings"
+    "time"
+
+    "github.com/go-redis/redis/v8"
+)
+
+const (
+    DefaultSuggestLimit = 10
+    MaxSuggestLimit     = 25
+    MaxQueryLength      = 50
+
+    DefaultSearchLimit = 20
+    MaxSearchLimit     = 50
+
+    catalogCacheKey = "interests:catalog"
+    catalogCacheTTL = 1 * time.Hour
+
+    userCountsCacheKey = "interests:user_counts"
+    userCountsCacheTTL = 10 * time.Minute
+
+    popularityCacheTTL = 30 * time.Minute
+)
+
+var ErrUnknownInterest = errors.New("unknown interest")
+
+type Service interface {
+    SuggestInterests(ctx context.Context, userID int64, query string, limit int) ([]*Suggestion, error)
+
+    // Catalog
+    Browse(ctx context.Context, category string) ([]*Category, error)
+    Search(ctx context.Context, query, category string, limit int) ([]*CatalogInterest, error)
+
+    // Canonicalize maps profile input onto catalog names, called by profile
+    Canonicalize(ctx context.Context, values []string) ([]string, error)
+}
+
+type service struct {
+    repo  Repository
+    redis *redis.Client
+}
+
+// NewService creates the interests service. redisClient may be nil, in which
+// case the catalog and popularity are read from Postgres on every request.
+func NewService(repo Repository, redisClient *redis.Client) Service {
+    return &service{
+        repo:  repo,
+        redis: redisClient,
+    }
+}
+
+// SuggestInterests matches query against the catalog by prefix, word prefix
+// and edit distance, ranking each tier by popularity among the viewer's
+// nearby and like-minded users. An empty query returns the most popular.
+func (s *service) SuggestInterests(ctx context.Context, userID int64, query string, limit int) ([]*Suggestion, error) {
+    catalog, err := s.getCatalog(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    popularity, err := s.getPopularity(ctx, userID, catalog)
+    if err != nil {
+        // Suggestions still work unranked
+        log.Printf("Failed to load interest popularity for user %d: %v", userID, err)
+        popularity = map[int64]int{}
+    }
+
+    query = normalize(query)
+    suggestions := make([]*Suggestion, 0, limit)
+    ranks := make(map[int64]int)
+
+    for _, interest := range catalog {
+        match, rank := MatchPopular, 0
+        if query != "" {
+            match, rank = matchInterest(query, interest)
+            if rank == 0 {
+                continue
+            }
+        }
+
+        ranks[interest.ID] = rank
+        suggestions = append(suggestions, &Suggestion{
+            ID:         interest.ID,
+            Slug:       interest.Slug,
+            Name:       interest.Name,
+            Category:   interest.Category,
+            Popularity: popularity[interest.ID],
+            Match:      match,
+        })
+    }
+
+    sort.SliceStable(suggestions, func(i, j int) bool {
+        a, b := suggestions[i], suggestions[j]
+        if ranks[a.ID] != ranks[b.ID] {
+            return ranks[a.ID] > ranks[b.ID]
+        }
+        if a.Popularity != b.Popularity {
+            return a.Popularity > b.Popularity
+        }
+        return a.Name < b.Name
+    })
+
+    if len(suggestions) > limit {
+        suggestions = suggestions[:limit]
+    }
+    return suggestions, nil
+}
+
+// Browse returns the catalog grouped by category, each interest with its
+// user count. category narrows it to one category.
+func (s *service) Browse(ctx context.Context, category string) ([]*Category, error) {
+    entries, err := s.getCatalogWithCounts(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    category = normalize(category)
+    categories := []*Category{}
+    byName := make(map[string]*Category)
+    for _, entry := range entries {
+        name := entry.Category
+        if name == "" {
+            name = "other"
+        }
+        if category != "" && normalize(name) != category {
+            continue
+        }
+
+        c, ok := byName[name]
+        if !ok {
+            c = &Category{Name: name, Interests: []*CatalogInterest{}}
+            byName[name] = c
+            categories = append(categories, c)
+        }
+        c.Interests = append(c.Interests, entry)
+    }
+
+    sort.SliceStable(categories, func(i, j int) bool {
+        return categories[i].Name < categories[j].Name
+    })
+    for _, c := range categories {
+        sort.SliceStable(c.Interests, func(i, j int) bool {
+            return c.Interests[i].Name < c.Interests[j].Name
+        })
+    }
+    return categories, nil
+}
+
+// Search matches query against catalog names and aliases the same way
+// suggestions do, ranking each tier by how many users list the interest
+func (s *service) Search(ctx context.Context, query, category string, limit int) ([]*CatalogInterest, error) {
+    entries, err := s.getCatalogWithCounts(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    query, category = normalize(query), normalize(category)
+    results := make([]*CatalogInterest, 0, limit)
+    ranks := make(map[int64]int)
+    for _, entry := range entries {
+        if category != "" && normalize(entry.Category) != category {
+            continue
+        }
+        _, rank := matchInterest(query, entry.Interest)
+        if rank == 0 {
+            continue
+        }
+        ranks[entry.ID] = rank
+        results = append(results, entry)
+    }
+
+    sort.SliceStable(results, func(i, j int) bool {
+        a, b := results[i], results[j]
+        if ranks[a.ID] != ranks[b.ID] {
+            return ranks[a.ID] > ranks[b.ID]
+        }
+        if a.UserCount != b.UserCount {
+            return a.UserCount > b.UserCount
+        }
+        return a.Name < b.Name
+    })
+
+    if len(results) > limit {
+        results = results[:limit]
+    }
+    return results, nil
+}
+
+// Canonicalize returns the catalog name for each value, matched by name,
+// slug or alias, dropping duplicates. A value the catalog doesn't know
+// fails with ErrUnknownInterest.
+func (s *service) Canonicalize(ctx context.Context, values []string) ([]string, error) {
+    catalog, err := s.getCatalog(ctx)
+    if err != nil {
+        return nil, err
+    }
+    lookup := catalogLookup(catalog)
+
+    names := make([]string, 0, len(values))
+    seen := make(map[int64]bool)
+    for _, value := range values {
+        interest, ok := lookup[normalize(value)]
+        if !ok {
+            return nil, fmt.Errorf("%w: %s", ErrUnknownInterest, strings.TrimSpace(value))
+        }
+        if seen[interest.ID] {
+            continue
+        }
+        seen[interest.ID] = true
+        names = append(names, interest.Name)
+    }
+    return names, nil
+}
+
+// getCatalogWithCounts pairs each catalog interest with its user count. The
+// catalog is still served if the counts can't be loaded.
+func (s *service) getCatalogWithCounts(ctx context.Context) ([]*CatalogInterest, error) {
+    catalog, err := s.getCatalog(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    counts, err := s.getUserCounts(ctx)
+    if err != nil {
+        log.Printf("Failed to load interest user counts: %v", err)
+        counts = map[int64]int{}
+    }
+
+    entries := make([]*CatalogInterest, len(catalog))
+    for i, interest := range catalog {
+        entries[i] = &CatalogInterest{Interest: interest, UserCount: counts[interest.ID]}
+    }
+    return entries, nil
+}
+
+// getUserCounts reads per-interest user counts from Redis, falling back to
+// Postgres
+func (s *service) getUserCounts(ctx context.Context) (map[int64]int, error) {
+    if s.redis != nil {
+        if data, err := s.redis.Get(ctx, userCountsCacheKey).Bytes(); err == nil {
+            var counts map[int64]int
+            if err := json.Unmarshal(data, &counts); err == nil {
+                return counts, nil
+            }
+        }
+    }
+
+    counts, err := s.repo.CountUsers(ctx)
+    if err != nil {
+        return nil, err
+    }
+
+    if s.redis != nil {
+        if data, err := json.Marshal(counts); err == nil {
+            s.redis.Set(ctx, userCountsCacheKey, data, userCountsCacheTTL)
+        }
+    }
+    return counts, nil
+}
+
+// getCatalog reads the catalog from Redis, falling back to Postgres
+func (s *service) getCatalog(ctx context.Context) ([]*Interest, error) {
+    if s.redis != nil {
+        if data, err := s.redis.Get(ctx, catalogCacheKey).Bytes(); err == nil {
+            var catalog []*Interest
+            if err := json.Unmarshal(data, &catalog); err == nil {
+                return catalog, nil
+            }
+        }
+    }
+
+    catalog, err := s.repo.GetCatalog(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load interests catalog: %w", err)
+    }
+
+    if s.redis != nil {
+        if data, err := json.Marshal(catalog); err == nil {
+            s.redis.Set(ctx, catalogCacheKey, data, catalogCacheTTL)
+        }
+    }
+    return catalog, nil
+}
+
+// getPopularity returns, per catalog interest, how many of the viewer's
+// nearby or similar users list it. Free-text profile interests are folded
+// onto catalog entries by name, slug or alias.
+func (s *service) getPopularity(ctx context.Context, userID int64, catalog []*Interest) (map[int64]int, error) {
+    key := fmt.Sprintf("interests:popularity:%d", userID)
+
+    if s.redis != nil {
+        if data, err := s.redis.Get(ctx, key).Bytes(); err == nil {
+            var popularity map[int64]int
+            if err := json.Unmarshal(data, &popularity); err == nil {
+                return popularity, nil
+            }
+        }
+    }
+
+    viewer, err := s.repo.GetViewerContext(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    counts, err := s.repo.CountInterestUsage(ctx, userID, viewer)
+    if err != nil {
+        return nil, err
+    }
+
+    lookup := catalogLookup(catalog)
+    popularity := make(map[int64]int)
+    for text, n := range counts {
+        if interest, ok := lookup[normalize(text)]; ok {
+            popularity[interest.ID] += n
+        }
+    }
+
+    if s.redis != nil {
+        if data, err := json.Marshal(popularity); err == nil {
+            s.redis.Set(ctx, key, data, popularityCacheTTL)
+        }
+    }
+    return popularity, nil
+}
+
+// catalogLookup indexes the catalog by normalized name, slug and alias
+func catalogLookup(catalog []*Interest) map[string]*Interest {
+    lookup := make(map[string]*Interest)
+    for _, interest := range catalog {
+        lookup[normalize(interest.Name)] = interest
+        lookup[normalize(interest.Slug)] = interest
+        for _, alias := range interest.Aliases {
+            lookup[normalize(alias)] = interest
+        }
+    }
+    return lookup
+}
+
+// normalize lowercases and collapses separators so "Hip-Hop", "hip hop" and
+// "hip_hop" compare equal
+func normalize(s string) string {
+    s = strings.ToLower(strings.TrimSpace(s))
+    s = strings.NewReplacer("-", " ", "_", " ").Replace(s)
+    return strings.Join(strings.Fields(s), " ")
+}
+
+// matchInterest scores query against an interest's name and aliases,
+// returning the best match type and its rank (0 when nothing matches)
+func matchInterest(query string, interest *Interest) (string, int) {
+    bestMatch, bestRank := "", 0
+
+    candidates := append([]string{interest.Name}, interest.Aliases...)
+    for _, candidate := range candidates {
+        match, rank := matchText(query, normalize(candidate))
+        if rank > bestRank {
+            bestMatch, bestRank = match, rank
+        }
+    }
+    return bestMatch, bestRank
+}
+
+func matchText(query, text string) (string, int) {
+    switch {
+    case text == query:
+        return MatchExact, 4
+    case strings.HasPrefix(text, query):
+        return MatchPrefix, 3
+    }
+
+    for _, word := range strings.Fields(text) {
+        if strings.HasPrefix(word, query) {
+            return MatchWordPrefix, 2
+        }
+    }
+
+    // Fuzzy: compare against the same-length prefix so partial input still matches
+    q := []rune(query)
+    if len(q) < 3 {
+        return "", 0
+    }
+    maxEdits := 1
+    if len(q) > 5 {
+        maxEdits = 2
+    }
+
+    t := []rune(text)
+    for _, n := range []int{len(q) - 1, len(q), len(q) + 1} {
+        if n <= 0 || n > len(t) {
+            continue
+        }
+        if editDistance(q, t[:n]) <= maxEdits {
+            return MatchFuzzy, 1
+        }
+    }
+    return "", 0
+}
+
+// editDistance is the Levenshtein distance between a and b
+func editDistance(a, b []rune) int {
+    prev := make([]int, len(b)+1)
+    curr := make([]int, len(b)+1)
+    for j := range prev {
+        prev[j] = j
+    }
+
+    for i := 1; i <= len(a); i++ {
+        curr[0] = i
+        for j := 1; j <= len(b); j++ {
+            cost := 1
+            if a[i-1] == b[j-1] {
+                cost = 0
+            }
+            curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+        }
+        prev, curr = curr, prev
+    }
+    return prev[len(b)]
+}
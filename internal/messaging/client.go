@@ -5,11 +5,22 @@ package messaging
 import (
     "context"
     "encoding/json"
+    "errors"
+    "fmt"
     "log"
+    "sync"
+    "sync/atomic"
     "time"
     
     "github.com/gorilla/websocket"
 )
+
+var (
+    errInvalidFrame         = errors.New("invalid frame payload")
+    errUnknownFrame         = errors.New("unknown frame type")
+    errTooManySubscriptions = errors.New("too many conversation subscriptions")
+)
+
 // Client represents a websocket client
 type Client struct {
     hub      *Hub
@@ -17,15 +28,34 @@ type Client struct {
     send     chan []byte
     userID   int64
     service  Service
+    
+    // Conversations this connection subscribed to. Until its first subscribe
+    // frame a client receives events for all of its conversations.
+    subscriptions   map[int64]struct{}
+    subscribed      bool
+    subscriptionMux sync.RWMutex
+    
+    // Pings sent since the last pong
+    missedPongs int32
+    
+    // Guards send against being closed mid-enqueue
+    sendMux sync.RWMutex
+    closed  bool
+    
+    // When the send queue first overflowed (unix nanos), 0 while the client
+    // keeps up and -1 once it has been disconnected for being slow
+    slowSince atomic.Int64
 }
 
 func NewClient(hub *Hub, conn *websocket.Conn, userID int64, service Service) *Client {
     return &Client{
         hub:     hub,
         conn:    conn,
-        send:    make(chan []byte, 256),
+        send:    make(chan []byte, maxQueuedMessages),
         userID:  userID,
         service: service,
+        
+        subscriptions: make(map[int64]struct{}),
     }
 }
 
@@ -43,6 +73,7 @@ func (c *Client) readPump() {
     c.conn.SetReadLimit(maxMessageSize)
     c.conn.SetReadDeadline(time.Now().Add(pongWait))
     c.conn.SetPongHandler(func(string) error {
+        atomic.StoreInt32(&c.missedPongs, 0)
         c.conn.SetReadDeadline(time.Now().Add(pongWait))
         return nil
     })
@@ -96,6 +127,12 @@ func (c *Client) writePump() {
             
         case <-ticker.C:
             c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+            if atomic.AddInt32(&c.missedPongs, 1) > maxMissedPongs {
+                log.Printf("User %d missed %d heartbeats, disconnecting", c.userID, maxMissedPongs)
+                c.conn.WriteMessage(websocket.CloseMessage,
+                    websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "heartbeat timeout"))
+                return
+            }
             if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
                 return
             }
@@ -103,42 +140,78 @@ func (c *Client) writePump() {
     }
 }
 
+// processMessage handles one client frame. Failures are answered with a
+// nack; successes with an ack when the frame asked for one.
 func (c *Client) processMessage(data []byte) {
     var msg WSMessage
     if err := json.Unmarshal(data, &msg); err != nil {
-        log.Printf("Error unmarshaling message: %v", err)
+        c.sendFrame(WSTypeNack, nackFor(errInvalidFrame))
         return
     }
     
     ctx := context.Background()
     
+    var ack *WSAck
+    var err error
     switch WSMessageType(msg.Type) {
     case WSTypeMessage:
-        c.handleNewMessage(ctx, msg.Data)
+        ack, err = c.handleNewMessage(ctx, msg.Data)
     case WSTypeTyping:
-        c.handleTypingIndicator(ctx, msg.Data, true)
+        err = c.handleTypingIndicator(ctx, msg.Data, true)
     case WSTypeStopTyping:
-        c.handleTypingIndicator(ctx, msg.Data, false)
+        err = c.handleTypingIndicator(ctx, msg.Data, false)
     case WSTypeRead:
-        c.handleMarkRead(ctx, msg.Data)
+        err = c.handleMarkRead(ctx, msg.Data)
     case WSTypeReaction:
-        c.handleReaction(ctx, msg.Data)
+        err = c.handleReaction(ctx, msg.Data)
+    case WSTypeSubscribe:
+        ack, err = c.handleSubscribe(ctx, msg.Data)
+    case WSTypeUnsubscribe:
+        ack, err = c.handleUnsubscribe(msg.Data)
+    case WSTypePing:
+        c.sendFrame(WSTypePong, nil)
+        return
     default:
-        log.Printf("Unknown message type: %s", msg.Type)
+        err = fmt.Errorf("%w: %s", errUnknownFrame, msg.Type)
     }
+    
+    if err != nil {
+        nack := nackFor(err)
+        nack.ID = msg.ID
+        if ack != nil {
+            nack.ClientMessageID = ack.ClientMessageID
+        }
+        c.sendFrame(WSTypeNack, nack)
+        return
+    }
+    
+    if ack == nil {
+        if msg.ID == "" {
+            return
+        }
+        ack = &WSAck{}
+    }
+    ack.ID = msg.ID
+    c.sendFrame(WSTypeAck, ack)
 }
 
-func (c *Client) handleNewMessage(ctx context.Context, payload json.RawMessage) {
+// handleNewMessage sends a message. The client's client_message_id makes
+// retries safe: a key that was already used is acked with the original message.
+func (c *Client) handleNewMessage(ctx context.Context, payload json.RawMessage) (*WSAck, error) {
     var messageData struct {
-        ConversationID int64  `json:"conversation_id"`
-        Content        string `json:"content"`
-        MessageType    string `json:"message_type,omitempty"`
-        TempID         string `json:"temp_id,omitempty"`
+        ConversationID  int64  `json:"conversation_id"`
+        Content         string `json:"content"`
+        MessageType     string `json:"message_type,omitempty"`
+        ClientMessageID string `json:"client_message_id,omitempty"`
     }
     
     if err := json.Unmarshal(payload, &messageData); err != nil {
-        log.Printf("Error parsing message data: %v", err)
-        return
+        return nil, errInvalidFrame
+    }
+    
+    ack := &WSAck{ClientMessageID: messageData.ClientMessageID}
+    if len(messageData.ClientMessageID) > maxClientMessageIDLength {
+        return ack, errInvalidFrame
     }
     
     // Default to text if not specified
@@ -146,19 +219,32 @@ func (c *Client) handleNewMessage(ctx context.Context, payload json.RawMessage)
         messageData.MessageType = "text"
     }
     
+    if messageData.ClientMessageID != "" {
+        existing, err := c.service.FindSentMessage(ctx, c.userID, messageData.ClientMessageID)
+        if err != nil {
+            return ack, err
+        }
+        if existing != nil {
+            ack.MessageID = existing.ID
+            ack.Duplicate = true
+            return ack, nil
+        }
+    }
+    
     // Create the message using SendMessageRequest
     req := &SendMessageRequest{
-        ConversationID: messageData.ConversationID,
-        Content:        messageData.Content,
-        MessageType:    messageData.MessageType,
+        ConversationID:  messageData.ConversationID,
+        Content:         messageData.Content,
+        MessageType:     messageData.MessageType,
+        ClientMessageID: messageData.ClientMessageID,
     }
     
     message, err := c.service.SendMessage(ctx, c.userID, req)
     if err != nil {
         log.Printf("Error creating message: %v", err)
-        c.sendError("message_failed", err.Error(), messageData.TempID)
-        return
+        return ack, err
     }
+    ack.MessageID = message.ID
     
     // Broadcast via hub
     wsMsg := WSMessage{
@@ -167,21 +253,108 @@ func (c *Client) handleNewMessage(ctx context.Context, payload json.RawMessage)
         Timestamp: message.CreatedAt,
     }
     c.hub.SendToConversation(message.ConversationID, wsMsg, c.userID)
+    
+    return ack, nil
+}
+
+// handleSubscribe subscribes to conversation events. Conversations the user
+// isn't in are listed as rejected.
+func (c *Client) handleSubscribe(ctx context.Context, payload json.RawMessage) (*WSAck, error) {
+    var subscribeData struct {
+        ConversationIDs []int64 `json:"conversation_ids"`
+    }
+    
+    if err := json.Unmarshal(payload, &subscribeData); err != nil || len(subscribeData.ConversationIDs) == 0 {
+        return nil, errInvalidFrame
+    }
+    
+    ack := &WSAck{}
+    var accepted []int64
+    for _, conversationID := range subscribeData.ConversationIDs {
+        if c.service.IsUserInConversation(ctx, c.userID, conversationID) {
+            accepted = append(accepted, conversationID)
+        } else {
+            ack.Rejected = append(ack.Rejected, conversationID)
+        }
+    }
+    
+    if err := c.subscribe(accepted); err != nil {
+        return nil, err
+    }
+    ack.ConversationIDs = accepted
+    return ack, nil
 }
 
-func (c *Client) handleTypingIndicator(ctx context.Context, payload json.RawMessage, isTyping bool) {
+func (c *Client) handleUnsubscribe(payload json.RawMessage) (*WSAck, error) {
+    var unsubscribeData struct {
+        ConversationIDs []int64 `json:"conversation_ids"`
+    }
+    
+    if err := json.Unmarshal(payload, &unsubscribeData); err != nil || len(unsubscribeData.ConversationIDs) == 0 {
+        return nil, errInvalidFrame
+    }
+    
+    c.unsubscribe(unsubscribeData.ConversationIDs)
+    return &WSAck{ConversationIDs: unsubscribeData.ConversationIDs}, nil
+}
+
+// subscribe switches the client to explicit subscriptions and adds the
+// given conversations
+func (c *Client) subscribe(conversationIDs []int64) error {
+    c.subscriptionMux.Lock()
+    defer c.subscriptionMux.Unlock()
+    
+    added := 0
+    for _, conversationID := range conversationIDs {
+        if _, ok := c.subscriptions[conversationID]; !ok {
+            added++
+        }
+    }
+    if len(c.subscriptions)+added > maxSubscriptions {
+        return errTooManySubscriptions
+    }
+    
+    c.subscribed = true
+    for _, conversationID := range conversationIDs {
+        c.subscriptions[conversationID] = struct{}{}
+    }
+    return nil
+}
+
+func (c *Client) unsubscribe(conversationIDs []int64) {
+    c.subscriptionMux.Lock()
+    defer c.subscriptionMux.Unlock()
+    
+    c.subscribed = true
+    for _, conversationID := range conversationIDs {
+        delete(c.subscriptions, conversationID)
+    }
+}
+
+// wants reports whether the client should get a conversation's events
+func (c *Client) wants(conversationID int64) bool {
+    c.subscriptionMux.RLock()
+    defer c.subscriptionMux.RUnlock()
+    
+    if !c.subscribed {
+        return true
+    }
+    _, ok := c.subscriptions[conversationID]
+    return ok
+}
+
+func (c *Client) handleTypingIndicator(ctx context.Context, payload json.RawMessage, isTyping bool) error {
     var typingData struct {
         ConversationID int64 `json:"conversation_id"`
     }
     
     if err := json.Unmarshal(payload, &typingData); err != nil {
-        log.Printf("Error parsing typing data: %v", err)
-        return
+        return errInvalidFrame
     }
     
     // Verify user is part of conversation
     if !c.service.IsUserInConversation(ctx, c.userID, typingData.ConversationID) {
-        return
+        return ErrNotParticipant
     }
     
     // Update typing status
@@ -202,23 +375,23 @@ func (c *Client) handleTypingIndicator(ctx context.Context, payload json.RawMess
         Timestamp: time.Now(),
     }
     c.hub.SendToConversation(typingData.ConversationID, wsMsg, c.userID)
+    return nil
 }
 
-func (c *Client) handleMarkRead(ctx context.Context, payload json.RawMessage) {
+func (c *Client) handleMarkRead(ctx context.Context, payload json.RawMessage) error {
     var readData struct {
         MessageIDs []int64 `json:"message_ids"`
     }
     
     if err := json.Unmarshal(payload, &readData); err != nil {
-        log.Printf("Error parsing read data: %v", err)
-        return
+        return errInvalidFrame
     }
     
     // Mark messages as read
     receipts, err := c.service.MarkMessagesRead(ctx, c.userID, readData.MessageIDs)
     if err != nil {
         log.Printf("Error marking as read: %v", err)
-        return
+        return err
     }
     
     // Broadcast read receipts
@@ -240,9 +413,10 @@ func (c *Client) handleMarkRead(ctx context.Context, payload json.RawMessage) {
         }
         c.hub.SendToConversation(message.ConversationID, wsMsg, c.userID)
     }
+    return nil
 }
 
-func (c *Client) handleReaction(ctx context.Context, payload json.RawMessage) {
+func (c *Client) handleReaction(ctx context.Context, payload json.RawMessage) error {
     var reactionData struct {
         MessageID int64  `json:"message_id"`
         Emoji     string `json:"emoji"`
@@ -250,19 +424,18 @@ func (c *Client) handleReaction(ctx context.Context, payload json.RawMessage) {
     }
     
     if err := json.Unmarshal(payload, &reactionData); err != nil {
-        log.Printf("Error parsing reaction data: %v", err)
-        return
+        return errInvalidFrame
     }
     
     // Get message first to verify access
     message, err := c.service.GetMessage(ctx, reactionData.MessageID)
     if err != nil {
-        return
+        return ErrMessageNotFound
     }
     
     // Verify user is in conversation
     if !c.service.IsUserInConversation(ctx, c.userID, message.ConversationID) {
-        return
+        return ErrNotParticipant
     }
     
     // Add or remove reaction
@@ -275,7 +448,7 @@ func (c *Client) handleReaction(ctx context.Context, payload json.RawMessage) {
     
     if err != nil {
         log.Printf("Error handling reaction: %v", err)
-        return
+        return err
     }
     
     // Broadcast reaction update
@@ -291,34 +464,96 @@ func (c *Client) handleReaction(ctx context.Context, payload json.RawMessage) {
         Timestamp: time.Now(),
     }
     c.hub.SendToConversation(message.ConversationID, wsMsg, c.userID)
+    return nil
 }
 
-// Helper method to send error messages
-func (c *Client) sendError(errorType, message string, tempID string) {
-    errorData := map[string]interface{}{
-        "error_type": errorType,
-        "message":    message,
-        "temp_id":    tempID,
-    }
-    
-    errorMsg := &WSMessage{
-        Type:      "error",
-        Data:      mustMarshal(errorData),
+// sendFrame queues a server frame for the client, dropping it if the
+// client's send buffer is full
+func (c *Client) sendFrame(frameType WSMessageType, payload interface{}) {
+    frame := WSMessage{
+        Type:      string(frameType),
+        Data:      mustMarshal(payload),
         Timestamp: time.Now(),
     }
     
-    data, err := json.Marshal(errorMsg)
+    data, err := json.Marshal(frame)
     if err != nil {
         return
     }
     
+    c.enqueue(data)
+}
+
+// enqueue queues a frame without blocking and reports whether it was queued.
+// Frames for a client whose queue is full are dropped; if it stays full for
+// slowClientTimeout the client is disconnected so it can't hold memory and
+// hub time indefinitely.
+func (c *Client) enqueue(data []byte) bool {
+    c.sendMux.RLock()
+    defer c.sendMux.RUnlock()
+    
+    if c.closed {
+        return false
+    }
+    
+    wsSendQueueDepth.Observe(float64(len(c.send)))
+    
     select {
     case c.send <- data:
+        // Drained to half: the client has caught up
+        if since := c.slowSince.Load(); since > 0 && len(c.send) < cap(c.send)/2 {
+            c.slowSince.CompareAndSwap(since, 0)
+        }
+        return true
     default:
-        // Client send channel is full
     }
+    
+    wsFramesDropped.Inc()
+    
+    now := time.Now().UnixNano()
+    since := c.slowSince.Load()
+    if since == 0 {
+        c.slowSince.CompareAndSwap(0, now)
+        return false
+    }
+    if since > 0 && time.Duration(now-since) >= slowClientTimeout && c.slowSince.CompareAndSwap(since, -1) {
+        wsSlowClientDisconnects.Inc()
+        log.Printf("User %d not reading its queue for %s, disconnecting", c.userID, slowClientTimeout)
+        go func() { c.hub.unregister <- c }()
+    }
+    return false
 }
 
+// nackFor turns a frame's failure into a nack with a stable code
+func nackFor(err error) *WSNack {
+    code := "failed"
+    switch {
+    case errors.Is(err, errInvalidFrame):
+        code = "invalid_payload"
+    case errors.Is(err, errUnknownFrame):
+        code = "unknown_type"
+    case errors.Is(err, errTooManySubscriptions):
+        code = "too_many_subscriptions"
+    case errors.Is(err, ErrNotParticipant):
+        code = "not_participant"
+    case errors.Is(err, ErrBlocked):
+        code = "blocked"
+    case errors.Is(err, ErrOnboardingIncomplete):
+        code = "onboarding_incomplete"
+    case errors.Is(err, ErrMessageNotFound):
+        code = "not_found"
+    }
+    return &WSNack{Code: code, Message: err.Error()}
+}
+
+// Close closes the send queue, which makes writePump send a close frame and
+// hang up. It is safe to call more than once.
 func (c *Client) Close() {
-    close(c.send)
+    c.sendMux.Lock()
+    defer c.sendMux.Unlock()
+    
+    if !c.closed {
+        c.closed = true
+        close(c.send)
+    }
 }
\ No newline at end of file
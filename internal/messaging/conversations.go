@@ -0,0 +1,428 @@
+// internal/messaging/conversations.go
+
+package messaging
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "io"
+    "strings"
+    "time"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/common/cache"
+    "github.com/imadgeboyega/kiekky-backend/internal/textfilter"
+)
+
+// Participant roles
+const (
+    RoleAdmin  = "admin"
+    RoleMember = "member"
+)
+
+// maxSearchResults caps message search results
+const maxSearchResults = 50
+
+// CreateConversation starts a direct or group conversation. A direct
+// conversation with someone the user already talks to returns the existing one.
+func (s *MessageService) CreateConversation(ctx context.Context, userID int64, req *CreateConversationRequest) (*Conversation, error) {
+    if req.Type == "direct" {
+        if len(req.ParticipantIDs) != 1 {
+            return nil, errors.New("a direct conversation has exactly one other participant")
+        }
+        return s.GetOrCreateDirectConversation(ctx, userID, req.ParticipantIDs[0])
+    }
+    
+    for _, participantID := range req.ParticipantIDs {
+        if participantID != userID && s.IsBlocked(ctx, userID, participantID) {
+            return nil, ErrBlocked
+        }
+    }
+    
+    now := time.Now()
+    conv := &Conversation{
+        Type:      req.Type,
+        CreatedBy: &userID,
+        IsActive:  true,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    if name := strings.TrimSpace(req.Name); name != "" {
+        conv.Name = &name
+    }
+    if err := s.repo.CreateConversation(ctx, conv); err != nil {
+        return nil, err
+    }
+    
+    if err := s.addParticipant(ctx, conv.ID, userID, RoleAdmin); err != nil {
+        return nil, err
+    }
+    for _, participantID := range req.ParticipantIDs {
+        if participantID == userID {
+            continue
+        }
+        if err := s.addParticipant(ctx, conv.ID, participantID, RoleMember); err != nil {
+            return nil, err
+        }
+    }
+    
+    conv.Participants, _ = s.repo.GetConversationParticipants(ctx, conv.ID)
+    s.invalidateParticipants(ctx, conv.Participants)
+    return conv, nil
+}
+
+// GetOrCreateDirectConversation returns the one-to-one conversation between
+// two users, creating it on first contact
+func (s *MessageService) GetOrCreateDirectConversation(ctx context.Context, user1ID, user2ID int64) (*Conversation, error) {
+    if user1ID == user2ID {
+        return nil, errors.New("cannot start a conversation with yourself")
+    }
+    if s.IsBlocked(ctx, user1ID, user2ID) {
+        return nil, ErrBlocked
+    }
+    
+    conv, err := s.repo.GetDirectConversation(ctx, user1ID, user2ID)
+    if err != nil {
+        return nil, err
+    }
+    if conv != nil {
+        conv.Participants, _ = s.repo.GetConversationParticipants(ctx, conv.ID)
+        return conv, nil
+    }
+    
+    now := time.Now()
+    conv = &Conversation{
+        Type:      "direct",
+        CreatedBy: &user1ID,
+        IsActive:  true,
+        CreatedAt: now,
+        UpdatedAt: now,
+    }
+    if err := s.repo.CreateConversation(ctx, conv); err != nil {
+        return nil, err
+    }
+    for _, userID := range []int64{user1ID, user2ID} {
+        if err := s.addParticipant(ctx, conv.ID, userID, RoleMember); err != nil {
+            return nil, err
+        }
+    }
+    s.invalidateLists(ctx, user1ID, user2ID)
+    
+    conv.Participants, _ = s.repo.GetConversationParticipants(ctx, conv.ID)
+    return conv, nil
+}
+
+// GetConversation returns a conversation with its participants
+func (s *MessageService) GetConversation(ctx context.Context, conversationID, userID int64) (*Conversation, error) {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return nil, ErrNotParticipant
+    }
+    
+    conv, err := s.repo.GetConversation(ctx, conversationID)
+    if errors.Is(err, sql.ErrNoRows) || (err == nil && !conv.IsActive) {
+        return nil, ErrConversationNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    
+    conv.Participants, _ = s.repo.GetConversationParticipants(ctx, conversationID)
+    return conv, nil
+}
+
+// GetUserConversations lists the user's conversations, pinned first. Pages
+// are cached until a message or membership change in one of them.
+func (s *MessageService) GetUserConversations(ctx context.Context, userID int64, limit, offset int) ([]*Conversation, error) {
+    return cache.Fetch(ctx, s.cache, cache.Conversations, userID, cache.Variant(limit, offset), func() ([]*Conversation, error) {
+        return s.loadUserConversations(ctx, userID, limit, offset)
+    })
+}
+
+func (s *MessageService) loadUserConversations(ctx context.Context, userID int64, limit, offset int) ([]*Conversation, error) {
+    conversations, err := s.repo.GetUserConversations(ctx, userID, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+    
+    for _, conv := range conversations {
+        conv.Participants, _ = s.repo.GetConversationParticipants(ctx, conv.ID)
+    }
+    return conversations, nil
+}
+
+// GetConversationParticipants lists the current participants of a conversation
+func (s *MessageService) GetConversationParticipants(ctx context.Context, conversationID int64) ([]*Participant, error) {
+    return s.repo.GetConversationParticipants(ctx, conversationID)
+}
+
+// DeleteConversation deactivates a conversation for everyone
+func (s *MessageService) DeleteConversation(ctx context.Context, userID, conversationID int64) error {
+    conv, err := s.GetConversation(ctx, conversationID, userID)
+    if err != nil {
+        return err
+    }
+    if conv.CreatedBy == nil || *conv.CreatedBy != userID {
+        return ErrUnauthorized
+    }
+    if err := s.repo.DeleteConversation(ctx, conversationID); err != nil {
+        return err
+    }
+    s.invalidateParticipants(ctx, conv.Participants)
+    return nil
+}
+
+// AddParticipant adds a user to a group conversation. Adding someone already
+// in it is not an error.
+func (s *MessageService) AddParticipant(ctx context.Context, conversationID, userID int64) error {
+    conv, err := s.repo.GetConversation(ctx, conversationID)
+    if err != nil {
+        return ErrConversationNotFound
+    }
+    if conv.Type != "group" {
+        return errors.New("participants can only be added to group conversations")
+    }
+    if s.IsUserInConversation(ctx, userID, conversationID) {
+        return nil
+    }
+    if err := s.addParticipant(ctx, conversationID, userID, RoleMember); err != nil {
+        return err
+    }
+    s.invalidateConversation(ctx, conversationID)
+    return nil
+}
+
+// RemoveParticipant removes targetUserID from a conversation. Anyone may
+// leave; only the creator may remove someone else.
+func (s *MessageService) RemoveParticipant(ctx context.Context, userID, conversationID, targetUserID int64) error {
+    conv, err := s.GetConversation(ctx, conversationID, userID)
+    if err != nil {
+        return err
+    }
+    if targetUserID != userID && (conv.CreatedBy == nil || *conv.CreatedBy != userID) {
+        return ErrUnauthorized
+    }
+    if err := s.repo.RemoveParticipant(ctx, conversationID, targetUserID); err != nil {
+        return err
+    }
+    s.invalidateParticipants(ctx, conv.Participants)
+    return nil
+}
+
+// MuteConversation stops push notifications for a conversation
+func (s *MessageService) MuteConversation(ctx context.Context, userID, conversationID int64) error {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return ErrNotParticipant
+    }
+    defer s.invalidateLists(ctx, userID)
+    return s.repo.SetParticipantMuted(ctx, conversationID, userID, true)
+}
+
+// UnmuteConversation resumes push notifications for a conversation
+func (s *MessageService) UnmuteConversation(ctx context.Context, userID, conversationID int64) error {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return ErrNotParticipant
+    }
+    defer s.invalidateLists(ctx, userID)
+    return s.repo.SetParticipantMuted(ctx, conversationID, userID, false)
+}
+
+// ArchiveConversation hides a conversation from the user's main list
+func (s *MessageService) ArchiveConversation(ctx context.Context, userID, conversationID int64) error {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return ErrNotParticipant
+    }
+    defer s.invalidateLists(ctx, userID)
+    return s.repo.SetParticipantArchived(ctx, conversationID, userID, true)
+}
+
+// UnarchiveConversation returns a conversation to the user's main list
+func (s *MessageService) UnarchiveConversation(ctx context.Context, userID, conversationID int64) error {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return ErrNotParticipant
+    }
+    defer s.invalidateLists(ctx, userID)
+    return s.repo.SetParticipantArchived(ctx, conversationID, userID, false)
+}
+
+// UpdateTypingStatus records whether the user is typing in a conversation
+func (s *MessageService) UpdateTypingStatus(ctx context.Context, userID, conversationID int64, isTyping bool) error {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return ErrNotParticipant
+    }
+    return s.repo.UpdateTypingStatus(ctx, conversationID, userID, isTyping)
+}
+
+// addParticipant adds a user to a conversation with the given role
+func (s *MessageService) addParticipant(ctx context.Context, conversationID, userID int64, role string) error {
+    return s.repo.AddParticipant(ctx, &Participant{
+        ConversationID:         conversationID,
+        UserID:                 userID,
+        Role:                   role,
+        JoinedAt:               time.Now(),
+        NotificationPreference: "all",
+    })
+}
+
+// GetMessage returns a message with its sender
+func (s *MessageService) GetMessage(ctx context.Context, messageID int64) (*Message, error) {
+    message, err := s.repo.GetMessage(ctx, messageID)
+    if err != nil || message.IsDeleted {
+        return nil, ErrMessageNotFound
+    }
+    message.Sender, _ = s.getUserInfo(ctx, message.SenderID)
+    return message, nil
+}
+
+// GetConversationMessages pages through a conversation's messages, newest first
+func (s *MessageService) GetConversationMessages(ctx context.Context, conversationID, userID int64, limit, offset int) ([]*Message, error) {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return nil, ErrNotParticipant
+    }
+    return s.repo.GetConversationMessages(ctx, conversationID, limit, offset)
+}
+
+// GetUndeliveredMessages lists messages not yet delivered to the user
+func (s *MessageService) GetUndeliveredMessages(ctx context.Context, userID int64) ([]*Message, error) {
+    return s.repo.GetUndeliveredMessages(ctx, userID)
+}
+
+// EditMessage changes the text of the user's own message
+func (s *MessageService) EditMessage(ctx context.Context, messageID, userID int64, content string) (*Message, error) {
+    message, err := s.repo.GetMessage(ctx, messageID)
+    if err != nil || message.IsDeleted {
+        return nil, ErrMessageNotFound
+    }
+    if message.SenderID != userID {
+        return nil, ErrUnauthorized
+    }
+    
+    if s.textFilter != nil && content != "" {
+        content, err = s.textFilter.Filter(ctx, userID, textfilter.FieldMessage, content)
+        if err != nil {
+            return nil, err
+        }
+    }
+    
+    if err := s.repo.UpdateMessage(ctx, messageID, content); err != nil {
+        return nil, err
+    }
+    s.invalidateConversation(ctx, message.ConversationID)
+    
+    now := time.Now()
+    message.Content = &content
+    message.IsEdited = true
+    message.EditedAt = &now
+    message.Sender, _ = s.getUserInfo(ctx, userID)
+    return message, nil
+}
+
+// DeleteMessage deletes the user's own message and tells the conversation
+func (s *MessageService) DeleteMessage(ctx context.Context, messageID, userID int64) error {
+    message, err := s.repo.GetMessage(ctx, messageID)
+    if err != nil || message.IsDeleted {
+        return ErrMessageNotFound
+    }
+    if message.SenderID != userID {
+        return ErrUnauthorized
+    }
+    
+    if err := s.repo.DeleteMessage(ctx, messageID); err != nil {
+        return err
+    }
+    s.invalidateConversation(ctx, message.ConversationID)
+    
+    if s.hub != nil {
+        s.hub.SendToConversation(message.ConversationID, WSMessage{
+            Type: string(WSTypeMessageDeleted),
+            Data: mustMarshal(map[string]interface{}{
+                "conversation_id": message.ConversationID,
+                "message_id":      messageID,
+            }),
+            Timestamp: time.Now(),
+        }, userID)
+    }
+    return nil
+}
+
+// SearchMessages finds messages containing query in the user's conversations
+func (s *MessageService) SearchMessages(ctx context.Context, userID int64, query string) ([]*Message, error) {
+    return s.repo.SearchMessages(ctx, userID, query, maxSearchResults)
+}
+
+// AddReaction reacts to a message in one of the user's conversations
+func (s *MessageService) AddReaction(ctx context.Context, userID, messageID int64, reaction string) (*Reaction, error) {
+    message, err := s.repo.GetMessage(ctx, messageID)
+    if err != nil || message.IsDeleted {
+        return nil, ErrMessageNotFound
+    }
+    if !s.IsUserInConversation(ctx, userID, message.ConversationID) {
+        return nil, ErrNotParticipant
+    }
+    
+    r := &Reaction{
+        MessageID: messageID,
+        UserID:    userID,
+        Emoji:     reaction,
+        CreatedAt: time.Now(),
+    }
+    if err := s.repo.AddReaction(ctx, r); err != nil {
+        return nil, err
+    }
+    return r, nil
+}
+
+// RemoveReaction removes the user's reaction from a message
+func (s *MessageService) RemoveReaction(ctx context.Context, userID, messageID int64, reaction string) error {
+    message, err := s.repo.GetMessage(ctx, messageID)
+    if err != nil {
+        return ErrMessageNotFound
+    }
+    if !s.IsUserInConversation(ctx, userID, message.ConversationID) {
+        return ErrNotParticipant
+    }
+    return s.repo.RemoveReaction(ctx, messageID, userID, reaction)
+}
+
+// GetReactions lists a message's reactions
+func (s *MessageService) GetReactions(ctx context.Context, messageID int64) ([]*Reaction, error) {
+    return s.repo.GetMessageReactions(ctx, messageID)
+}
+
+// RegisterPushToken saves a device token for push notifications
+func (s *MessageService) RegisterPushToken(ctx context.Context, userID int64, req *PushTokenRequest) error {
+    return s.repo.SavePushToken(ctx, userID, req.Token, req.Platform, req.DeviceID)
+}
+
+// UnregisterPushToken stops pushes to a device token
+func (s *MessageService) UnregisterPushToken(ctx context.Context, token string) error {
+    return s.repo.DeletePushToken(ctx, token)
+}
+
+// UploadMedia stores a file to attach to a message
+func (s *MessageService) UploadMedia(ctx context.Context, userID int64, file io.Reader, filename, contentType string) (string, error) {
+    if s.storageService == nil {
+        return "", ErrStorageUnavailable
+    }
+    
+    return s.storageService.UploadMedia(ctx, file, filename, contentType)
+}
+
+// GetContactsOnlineStatus reports which of the user's contacts are connected.
+// Contacts hiding their online status are always reported offline.
+func (s *MessageService) GetContactsOnlineStatus(ctx context.Context, userID int64) (map[int64]bool, error) {
+    contacts, err := s.repo.GetUserContacts(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    
+    found, err := s.users.GetMany(ctx, contacts)
+    if err != nil {
+        return nil, err
+    }
+    
+    statuses := make(map[int64]bool, len(contacts))
+    for _, contactID := range contacts {
+        statuses[contactID] = !found[contactID].HidesPresence && s.hub != nil && s.hub.IsUserOnline(contactID)
+    }
+    return statuses, nil
+}
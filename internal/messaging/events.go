@@ -0,0 +1,74 @@
+// internal/messaging/events.go
+// Messages sent in response to other modules' domain events
+
+package messaging
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/textfilter"
+)
+
+// SubscribeEvents subscribes messaging to the events that start or continue
+// conversations
+func (s *MessageService) SubscribeEvents(bus *events.Bus) {
+    events.Subscribe(bus, "messaging", s.onStoryReplied)
+}
+
+// onStoryReplied delivers a story reply to the author's inbox, in the
+// direct conversation between the two users. The reply ID is the message's
+// idempotency key, so a redelivered event doesn't send it twice.
+func (s *MessageService) onStoryReplied(ctx context.Context, e events.StoryReplied) error {
+    conv, err := s.GetOrCreateDirectConversation(ctx, e.ReplierID, e.StoryOwnerID)
+    if errors.Is(err, ErrBlocked) {
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    metadata, err := json.Marshal(map[string]interface{}{"story": StoryReference{
+        StoryID:   e.StoryID,
+        OwnerID:   e.StoryOwnerID,
+        MediaType: e.StoryMediaType,
+        ExpiresAt: e.StoryExpiresAt,
+        Reaction:  e.Reaction,
+    }})
+    if err != nil {
+        return err
+    }
+
+    content := e.Message
+    if content == "" {
+        content = e.Reaction
+    }
+
+    message, err := s.SendMessage(ctx, e.ReplierID, &SendMessageRequest{
+        ConversationID:  conv.ID,
+        Content:         content,
+        MessageType:     "story_reply",
+        Metadata:        metadata,
+        ClientMessageID: fmt.Sprintf("story_reply:%d", e.ReplyID),
+    })
+    if errors.Is(err, ErrBlocked) || errors.Is(err, ErrOnboardingIncomplete) || errors.Is(err, textfilter.ErrContentRejected) {
+        log.Printf("Story reply %d not delivered to user %d: %v", e.ReplyID, e.StoryOwnerID, err)
+        return nil
+    }
+    if err != nil {
+        return err
+    }
+
+    if s.hub != nil {
+        s.hub.SendToConversation(conv.ID, WSMessage{
+            Type:      string(WSTypeMessage),
+            Data:      mustMarshal(message),
+            Timestamp: message.CreatedAt,
+        }, e.ReplierID)
+    }
+    return nil
+}
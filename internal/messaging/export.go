@@ -0,0 +1,321 @@
+// internal/messaging/export.go
+
+package messaging
+
+import (
+    "bufio"
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "os"
+    "strings"
+    "sync"
+    "time"
+)
+
+const (
+    ExportFormatJSON = "json"
+    ExportFormatText = "text"
+
+    // MaxSyncExportMessages is the largest conversation streamed straight back
+    // to the client; bigger ones are generated by the export job
+    MaxSyncExportMessages = 5000
+
+    // Each user may request this many exports per exportRateWindow
+    maxExportsPerWindow = 3
+    exportRateWindow    = 1 * time.Hour
+
+    // exportJobBatch is how many queued exports one job run picks up
+    exportJobBatch = 5
+
+    exportDownloadURLExpiry = 1 * time.Hour
+)
+
+var (
+    ErrInvalidExportFormat = errors.New("export format must be json or text")
+    ErrExportRateLimited   = fmt.Errorf("at most %d exports per hour", maxExportsPerWindow)
+    ErrExportNotFound      = errors.New("export not found")
+)
+
+// ExportStatus is the lifecycle of a queued export
+type ExportStatus string
+
+const (
+    ExportStatusPending   ExportStatus = "pending"
+    ExportStatusRunning   ExportStatus = "running"
+    ExportStatusCompleted ExportStatus = "completed"
+    ExportStatusFailed    ExportStatus = "failed"
+)
+
+// ExportJob is a conversation export generated in the background
+type ExportJob struct {
+    ID             int64        `json:"id" db:"id"`
+    ConversationID int64        `json:"conversation_id" db:"conversation_id"`
+    UserID         int64        `json:"user_id" db:"user_id"`
+    Format         string       `json:"format" db:"format"`
+    Status         ExportStatus `json:"status" db:"status"`
+    MessageCount   int          `json:"message_count" db:"message_count"`
+    FileURL        *string      `json:"-" db:"file_url"`
+    Error          *string      `json:"error,omitempty" db:"error"`
+    CreatedAt      time.Time    `json:"created_at" db:"created_at"`
+    StartedAt      *time.Time   `json:"started_at,omitempty" db:"started_at"`
+    CompletedAt    *time.Time   `json:"completed_at,omitempty" db:"completed_at"`
+
+    // Signed link to the finished file, set when the job is fetched
+    DownloadURL *PlaybackURL `json:"download,omitempty"`
+}
+
+// ExportMessage is one message as written to an export
+type ExportMessage struct {
+    ID              int64     `json:"id"`
+    SenderID        int64     `json:"sender_id"`
+    SenderUsername  string    `json:"sender_username"`
+    SenderName      string    `json:"sender_display_name,omitempty"`
+    ParentMessageID *int64    `json:"parent_message_id,omitempty"`
+    MessageType     string    `json:"message_type"`
+    Content         *string   `json:"content,omitempty"`
+    MediaURL        *string   `json:"media_url,omitempty"`
+    IsEdited        bool      `json:"is_edited"`
+    CreatedAt       time.Time `json:"created_at"`
+}
+
+// RequestConversationExport checks the user may export the conversation now.
+// Small conversations return a nil job and should be streamed with
+// WriteConversationExport; large ones are queued for the export job.
+func (s *MessageService) RequestConversationExport(ctx context.Context, userID, conversationID int64, format string) (*ExportJob, error) {
+    if format != ExportFormatJSON && format != ExportFormatText {
+        return nil, ErrInvalidExportFormat
+    }
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return nil, ErrNotParticipant
+    }
+
+    count, err := s.repo.CountConversationMessages(ctx, conversationID)
+    if err != nil {
+        return nil, err
+    }
+    if count > MaxSyncExportMessages && s.storageService == nil {
+        return nil, ErrStorageUnavailable
+    }
+
+    if !s.exportLimiter.allow(userID, time.Now()) {
+        return nil, ErrExportRateLimited
+    }
+
+    if count <= MaxSyncExportMessages {
+        return nil, nil
+    }
+
+    job := &ExportJob{
+        ConversationID: conversationID,
+        UserID:         userID,
+        Format:         format,
+        Status:         ExportStatusPending,
+        MessageCount:   count,
+    }
+    if err := s.repo.CreateExportJob(ctx, job); err != nil {
+        return nil, fmt.Errorf("failed to queue export: %w", err)
+    }
+    return job, nil
+}
+
+// WriteConversationExport writes the conversation's full history, oldest first
+func (s *MessageService) WriteConversationExport(ctx context.Context, conversationID int64, format string, w io.Writer) (int, error) {
+    bw := bufio.NewWriter(w)
+    count := 0
+
+    var err error
+    switch format {
+    case ExportFormatJSON:
+        fmt.Fprintf(bw, `{"conversation_id":%d,"exported_at":%q,"messages":[`, conversationID, time.Now().UTC().Format(time.RFC3339))
+        enc := json.NewEncoder(bw)
+        err = s.repo.StreamConversationMessages(ctx, conversationID, func(msg *ExportMessage) error {
+            if count > 0 {
+                bw.WriteByte(',')
+            }
+            count++
+            return enc.Encode(msg)
+        })
+        if err == nil {
+            _, err = bw.WriteString("]}\n")
+        }
+    case ExportFormatText:
+        err = s.repo.StreamConversationMessages(ctx, conversationID, func(msg *ExportMessage) error {
+            count++
+            _, err := bw.WriteString(formatExportLine(msg))
+            return err
+        })
+    default:
+        return 0, ErrInvalidExportFormat
+    }
+    if err != nil {
+        return count, err
+    }
+
+    return count, bw.Flush()
+}
+
+// GetExportJob returns one of the user's queued exports, with a download
+// link once it has finished
+func (s *MessageService) GetExportJob(ctx context.Context, userID, jobID int64) (*ExportJob, error) {
+    job, err := s.repo.GetExportJob(ctx, jobID)
+    if err != nil {
+        return nil, err
+    }
+    if job == nil || job.UserID != userID {
+        return nil, ErrExportNotFound
+    }
+
+    if job.Status == ExportStatusCompleted && job.FileURL != nil && s.storageService != nil {
+        signedURL, err := s.storageService.GetSignedURL(ctx, *job.FileURL, exportDownloadURLExpiry)
+        if err != nil {
+            return nil, err
+        }
+        job.DownloadURL = &PlaybackURL{
+            URL:       signedURL,
+            ExpiresAt: time.Now().Add(exportDownloadURLExpiry),
+        }
+    }
+    return job, nil
+}
+
+// ProcessExportJobs generates queued exports and returns how many finished
+func (s *MessageService) ProcessExportJobs(ctx context.Context) (int, error) {
+    if s.storageService == nil {
+        return 0, nil
+    }
+
+    jobs, err := s.repo.ClaimExportJobs(ctx, exportJobBatch)
+    if err != nil {
+        return 0, err
+    }
+
+    done := 0
+    for _, job := range jobs {
+        err := s.runExportJob(ctx, job)
+
+        job.Status = ExportStatusCompleted
+        if err != nil {
+            job.Status = ExportStatusFailed
+            msg := err.Error()
+            job.Error = &msg
+            log.Printf("Conversation export %d failed: %v", job.ID, err)
+        }
+        if err := s.repo.FinishExportJob(ctx, job); err != nil {
+            log.Printf("Failed to finish conversation export %d: %v", job.ID, err)
+            continue
+        }
+        if job.Status == ExportStatusCompleted {
+            done++
+            s.notifyExportReady(job)
+        }
+    }
+    return done, nil
+}
+
+// runExportJob writes the export to a temp file, then uploads it privately
+func (s *MessageService) runExportJob(ctx context.Context, job *ExportJob) error {
+    f, err := os.CreateTemp("", fmt.Sprintf("chat-export-%d-*", job.ID))
+    if err != nil {
+        return err
+    }
+    defer os.Remove(f.Name())
+    defer f.Close()
+
+    count, err := s.WriteConversationExport(ctx, job.ConversationID, job.Format, f)
+    if err != nil {
+        return err
+    }
+    if _, err := f.Seek(0, io.SeekStart); err != nil {
+        return err
+    }
+
+    ext, contentType := ".json", "application/json"
+    if job.Format == ExportFormatText {
+        ext, contentType = ".txt", "text/plain; charset=utf-8"
+    }
+    name := fmt.Sprintf("conversation_%d_%s%s", job.ConversationID, time.Now().UTC().Format("20060102T150405Z"), ext)
+
+    fileURL, err := s.storageService.UploadExport(ctx, f, name, contentType)
+    if err != nil {
+        return err
+    }
+
+    job.MessageCount = count
+    job.FileURL = &fileURL
+    return nil
+}
+
+// notifyExportReady tells the requester's open sockets their export can be downloaded
+func (s *MessageService) notifyExportReady(job *ExportJob) {
+    if s.hub == nil {
+        return
+    }
+
+    s.hub.SendToUser(job.UserID, WSMessage{
+        Type: string(WSTypeExportReady),
+        Data: mustMarshal(map[string]interface{}{
+            "export_id":       job.ID,
+            "conversation_id": job.ConversationID,
+        }),
+        Timestamp: time.Now(),
+    })
+}
+
+// formatExportLine renders one message of a plain text export:
+// [2006-01-02 15:04:05] name: text (media URL on its own line)
+func formatExportLine(msg *ExportMessage) string {
+    name := msg.SenderName
+    if name == "" {
+        name = msg.SenderUsername
+    }
+
+    var b strings.Builder
+    fmt.Fprintf(&b, "[%s] %s:", msg.CreatedAt.UTC().Format("2006-01-02 15:04:05"), name)
+    if msg.Content != nil && *msg.Content != "" {
+        b.WriteString(" ")
+        b.WriteString(strings.ReplaceAll(*msg.Content, "\n", "\n    "))
+    }
+    if msg.IsEdited {
+        b.WriteString(" (edited)")
+    }
+    if msg.MediaURL != nil && *msg.MediaURL != "" {
+        fmt.Fprintf(&b, "\n    <%s> %s", msg.MessageType, *msg.MediaURL)
+    }
+    b.WriteString("\n")
+    return b.String()
+}
+
+// exportLimiter caps how often each user can request an export, over a
+// sliding window
+type exportLimiter struct {
+    mu       sync.Mutex
+    requests map[int64][]time.Time
+}
+
+func newExportLimiter() *exportLimiter {
+    return &exportLimiter{requests: make(map[int64][]time.Time)}
+}
+
+func (l *exportLimiter) allow(userID int64, now time.Time) bool {
+    l.mu.Lock()
+    defer l.mu.Unlock()
+
+    cutoff := now.Add(-exportRateWindow)
+    recent := l.requests[userID][:0]
+    for _, t := range l.requests[userID] {
+        if t.After(cutoff) {
+            recent = append(recent, t)
+        }
+    }
+
+    if len(recent) >= maxExportsPerWindow {
+        l.requests[userID] = recent
+        return false
+    }
+    l.requests[userID] = append(recent, now)
+    return true
+}
@@ -4,42 +4,59 @@ package messaging
 
 import (
     "encoding/json"
+    "errors"
     "net/http"
     "strconv"
+    "sync"
     "log"
     "time"
     "fmt"
     
     "github.com/gorilla/mux"
     "github.com/gorilla/websocket"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
     "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+    "github.com/imadgeboyega/kiekky-backend/internal/textfilter"
 )
 
+// Buffers are small because most frames are; larger ones are read in pieces.
+// WriteBufferPool lets idle connections give their write buffer back.
 var upgrader = websocket.Upgrader{
     ReadBufferSize:  1024,
     WriteBufferSize: 1024,
+    WriteBufferPool: &sync.Pool{},
     CheckOrigin: func(r *http.Request) bool {
         // Configure CORS as needed
         return true
     },
 }
 
+// defaultMaxUploadSize caps message media until SetUploadLimit is called
+const defaultMaxUploadSize = 50 << 20
+
 type Handler struct {
-    service Service
-    hub     *Hub
+    service       Service
+    hub           *Hub
+    maxUploadSize int64
 }
 
 func NewHandler(service Service, hub *Hub) *Handler {
     return &Handler{
-        service: service,
-        hub:     hub,
+        service:       service,
+        hub:           hub,
+        maxUploadSize: defaultMaxUploadSize,
     }
 }
 
+// SetUploadLimit caps the size of message media uploads, in bytes
+func (h *Handler) SetUploadLimit(maxBytes int64) {
+    h.maxUploadSize = maxBytes
+}
+
 // HandleWebSocket handles WebSocket connections
 func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
     // Get user ID from context (set by auth middleware)
-    userID, ok := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
     if !ok {
         http.Error(w, "Unauthorized", http.StatusUnauthorized)
         return
@@ -63,7 +80,11 @@ func (h *Handler) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 
 // CreateConversation creates a new conversation
 func (h *Handler) GetConversation(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     conversation, err := h.service.GetConversation(r.Context(), conversationID, userID)
@@ -77,7 +98,11 @@ func (h *Handler) GetConversation(w http.ResponseWriter, r *http.Request) {
 
 // GetConversations gets user's conversations
 func (h *Handler) GetConversations(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
     offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
@@ -97,7 +122,11 @@ func (h *Handler) GetConversations(w http.ResponseWriter, r *http.Request) {
 
 // GetMessages gets conversation messages
 func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     conversationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     if err != nil {
@@ -123,17 +152,26 @@ func (h *Handler) GetMessages(w http.ResponseWriter, r *http.Request) {
 
 // SendMessage sends a message (REST fallback)
 func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     var req SendMessageRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
     message, err := h.service.SendMessage(r.Context(), userID, &req)
     if err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+        status := http.StatusInternalServerError
+        if errors.Is(err, textfilter.ErrContentRejected) {
+            status = http.StatusBadRequest
+        } else if errors.Is(err, ErrOnboardingIncomplete) {
+            status = http.StatusForbidden
+        }
+        utils.ErrorResponse(w, err.Error(), status)
         return
     }
     
@@ -151,14 +189,17 @@ func (h *Handler) SendMessage(w http.ResponseWriter, r *http.Request) {
 
 // MarkRead marks messages as read
 func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     var req struct {
-        MessageIDs []int64 `json:"message_ids"`
+        MessageIDs []int64 `json:"message_ids" validate:"required,min=1,max=500,dive,gt=0"`
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -171,13 +212,80 @@ func (h *Handler) MarkRead(w http.ResponseWriter, r *http.Request) {
     utils.SuccessResponse(w, receipts, http.StatusOK)
 }
 
+// GetConversationReceipts returns aggregate read/delivery state for recent messages
+func (h *Handler) GetConversationReceipts(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    conversationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.ErrorResponse(w, "Invalid conversation ID", http.StatusBadRequest)
+        return
+    }
+    
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    if limit <= 0 || limit > 200 {
+        limit = 50
+    }
+    
+    receipts, err := h.service.GetConversationReceipts(r.Context(), userID, conversationID, limit)
+    if err != nil {
+        if err == ErrNotParticipant {
+            utils.ErrorResponse(w, err.Error(), http.StatusForbidden)
+            return
+        }
+        utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+        return
+    }
+    
+    utils.SuccessResponse(w, receipts, http.StatusOK)
+}
+
+// GetConversationSummary returns a recap of the user's unread messages
+func (h *Handler) GetConversationSummary(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    conversationID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.ErrorResponse(w, "Invalid conversation ID", http.StatusBadRequest)
+        return
+    }
+    
+    summary, err := h.service.GetConversationSummary(r.Context(), userID, conversationID)
+    if err != nil {
+        switch err {
+        case ErrSummariesDisabled, ErrConversationNotFound:
+            utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+        case ErrNotParticipant:
+            utils.ErrorResponse(w, err.Error(), http.StatusForbidden)
+        case ErrSummaryNotNeeded:
+            utils.ErrorResponse(w, err.Error(), http.StatusConflict)
+        default:
+            utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+        }
+        return
+    }
+    
+    utils.SuccessResponse(w, summary, http.StatusOK)
+}
+
 // RegisterPushToken registers a push notification token
 func (h *Handler) RegisterPushToken(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     var req PushTokenRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -191,7 +299,11 @@ func (h *Handler) RegisterPushToken(w http.ResponseWriter, r *http.Request) {
 
 // BlockUser blocks a user from messaging
 func (h *Handler) BlockUser(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     blockedUserID, err := strconv.ParseInt(mux.Vars(r)["userId"], 10, 64)
     if err != nil {
@@ -208,7 +320,11 @@ func (h *Handler) BlockUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UpdateConversation(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     // Verify user is participant
@@ -217,9 +333,8 @@ func (h *Handler) UpdateConversation(w http.ResponseWriter, r *http.Request) {
         return
     }
     
-    var updates map[string]interface{}
-    if err := json.NewDecoder(r.Body).Decode(&updates); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    var req UpdateConversationRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -228,7 +343,11 @@ func (h *Handler) UpdateConversation(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteConversation(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     // Only creator can delete
@@ -248,15 +367,18 @@ func (h *Handler) DeleteConversation(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) AddParticipants(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     var req struct {
-        UserIDs []int64 `json:"user_ids"`
+        UserIDs []int64 `json:"user_ids" validate:"required,min=1,max=50,dive,gt=0"`
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -271,7 +393,11 @@ func (h *Handler) AddParticipants(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) RemoveParticipant(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     targetUserID, _ := strconv.ParseInt(mux.Vars(r)["userId"], 10, 64)
     
@@ -286,7 +412,11 @@ func (h *Handler) RemoveParticipant(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) MuteConversation(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     err := h.service.MuteConversation(r.Context(), userID, conversationID)
@@ -299,7 +429,11 @@ func (h *Handler) MuteConversation(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UnmuteConversation(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     err := h.service.UnmuteConversation(r.Context(), userID, conversationID)
@@ -312,7 +446,11 @@ func (h *Handler) UnmuteConversation(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) ArchiveConversation(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     err := h.service.ArchiveConversation(r.Context(), userID, conversationID)
@@ -325,7 +463,11 @@ func (h *Handler) ArchiveConversation(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UnarchiveConversation(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     err := h.service.UnarchiveConversation(r.Context(), userID, conversationID)
@@ -338,7 +480,11 @@ func (h *Handler) UnarchiveConversation(w http.ResponseWriter, r *http.Request)
 }
 
 func (h *Handler) GetMessage(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     messageID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     message, err := h.service.GetMessage(r.Context(), messageID)
@@ -357,15 +503,18 @@ func (h *Handler) GetMessage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) EditMessage(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     messageID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     var req struct {
-        Content string `json:"content"`
+        Content string `json:"content" validate:"required,max=5000"`
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -387,7 +536,11 @@ func (h *Handler) EditMessage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     messageID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     err := h.service.DeleteMessage(r.Context(), messageID, userID)
@@ -400,14 +553,17 @@ func (h *Handler) DeleteMessage(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) MarkDelivered(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     var req struct {
-        MessageIDs []int64 `json:"message_ids"`
+        MessageIDs []int64 `json:"message_ids" validate:"required,min=1,max=500,dive,gt=0"`
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -420,15 +576,18 @@ func (h *Handler) MarkDelivered(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) AddReaction(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     messageID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     
     var req struct {
-        Emoji string `json:"emoji"`
+        Emoji string `json:"emoji" validate:"required,max=32"`
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -442,7 +601,11 @@ func (h *Handler) AddReaction(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) RemoveReaction(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     messageID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
     reaction := mux.Vars(r)["reaction"]
     
@@ -468,15 +631,18 @@ func (h *Handler) GetReactions(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UpdateTyping(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     var req struct {
-        ConversationID int64 `json:"conversation_id"`
+        ConversationID int64 `json:"conversation_id" validate:"required,gt=0"`
         IsTyping      bool  `json:"is_typing"`
     }
     
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.ErrorResponse(w, "Invalid request", http.StatusBadRequest)
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -502,7 +668,11 @@ func (h *Handler) UnregisterPushToken(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetPushTokens(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     tokens, err := h.service.GetPushTokens(r.Context(), userID)
     if err != nil {
@@ -514,7 +684,11 @@ func (h *Handler) GetPushTokens(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) SearchMessages(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     query := r.URL.Query().Get("q")
     
     if query == "" {
@@ -532,7 +706,11 @@ func (h *Handler) SearchMessages(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UnblockUser(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     blockedUserID, _ := strconv.ParseInt(mux.Vars(r)["userId"], 10, 64)
     
     err := h.service.UnblockUser(r.Context(), userID, blockedUserID)
@@ -545,7 +723,11 @@ func (h *Handler) UnblockUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     users, err := h.service.GetBlockedUsers(r.Context(), userID)
     if err != nil {
@@ -557,26 +739,33 @@ func (h *Handler) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UploadMedia(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
-    
-    // Parse multipart form
-    err := r.ParseMultipartForm(10 << 20) // 10MB
-    if err != nil {
-        utils.ErrorResponse(w, "File too large", http.StatusBadRequest)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
         return
     }
     
-    file, header, err := r.FormFile("file")
+    // Stream the file straight to storage rather than parsing the form
+    file, err := utils.StreamUpload(w, r, "file", h.maxUploadSize)
     if err != nil {
+        if utils.IsTooLarge(err) {
+            utils.ErrorResponse(w, utils.UploadTooLargeMessage(h.maxUploadSize), http.StatusRequestEntityTooLarge)
+            return
+        }
         utils.ErrorResponse(w, "Invalid file", http.StatusBadRequest)
         return
     }
-    defer file.Close()
     
-    // Upload file (implement in service)
-    url, err := h.service.UploadMedia(r.Context(), userID, file, header)
+    url, err := h.service.UploadMedia(r.Context(), userID, file, file.Filename, file.ContentType)
     if err != nil {
-        utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+        switch err {
+        case ErrMediaTooLarge:
+            utils.ErrorResponse(w, utils.UploadTooLargeMessage(h.maxUploadSize), http.StatusRequestEntityTooLarge)
+        case ErrStorageUnavailable:
+            utils.ErrorResponse(w, err.Error(), http.StatusServiceUnavailable)
+        default:
+            utils.ErrorResponse(w, err.Error(), http.StatusInternalServerError)
+        }
         return
     }
     
@@ -584,7 +773,11 @@ func (h *Handler) UploadMedia(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetOnlineStatus(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     
     // Get user's contacts online status
     statuses, err := h.service.GetContactsOnlineStatus(r.Context(), userID)
@@ -597,7 +790,11 @@ func (h *Handler) GetOnlineStatus(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetOrCreateDirectConversation(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
     otherUserID, _ := strconv.ParseInt(mux.Vars(r)["userId"], 10, 64)
     
     conversation, err := h.service.GetOrCreateDirectConversation(r.Context(), userID, otherUserID)
@@ -609,6 +806,289 @@ func (h *Handler) GetOrCreateDirectConversation(w http.ResponseWriter, r *http.R
     utils.SuccessResponse(w, conversation, http.StatusOK)
 }
 
+// StartVoiceUpload opens a chunked upload session for a voice note
+func (h *Handler) StartVoiceUpload(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var req StartVoiceUploadRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    upload, err := h.service.StartVoiceUpload(r.Context(), userID, &req)
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), voiceErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, upload, http.StatusCreated)
+}
+
+// UploadVoiceChunk receives one raw chunk of a voice note
+func (h *Handler) UploadVoiceChunk(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    vars := mux.Vars(r)
+    
+    index, err := strconv.Atoi(vars["index"])
+    if err != nil {
+        utils.ErrorResponse(w, "Invalid chunk index", http.StatusBadRequest)
+        return
+    }
+    
+    upload, err := h.service.UploadVoiceChunk(r.Context(), userID, vars["uploadId"], index, r.Body)
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), voiceErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, upload, http.StatusOK)
+}
+
+// CompleteVoiceUpload finalizes a voice note and sends it to the conversation
+func (h *Handler) CompleteVoiceUpload(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    
+    var req CompleteVoiceUploadRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    message, err := h.service.CompleteVoiceUpload(r.Context(), userID, mux.Vars(r)["uploadId"], &req)
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), voiceErrorStatus(err))
+        return
+    }
+    
+    wsMsg := WSMessage{
+        Type:      string(WSTypeMessage),
+        Data:      mustMarshal(message),
+        Timestamp: message.CreatedAt,
+    }
+    h.hub.SendToConversation(message.ConversationID, wsMsg, userID)
+    
+    utils.SuccessResponse(w, message, http.StatusCreated)
+}
+
+// GetPlaybackURL returns a signed URL for playing back message media
+func (h *Handler) GetPlaybackURL(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    messageID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    
+    playback, err := h.service.GetPlaybackURL(r.Context(), userID, messageID)
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), voiceErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, playback, http.StatusOK)
+}
+
+func voiceErrorStatus(err error) int {
+    switch err {
+    case ErrVoiceUploadNotFound, ErrMessageNotFound:
+        return http.StatusNotFound
+    case ErrNotParticipant, ErrBlocked, ErrOnboardingIncomplete:
+        return http.StatusForbidden
+    case ErrVoiceNoteTooLarge:
+        return http.StatusRequestEntityTooLarge
+    case ErrTooManyVoiceUploads:
+        return http.StatusTooManyRequests
+    case ErrVoiceUploadIncomplete, ErrVoiceNoteTooLong, ErrUnsupportedAudio:
+        return http.StatusBadRequest
+    case ErrStorageUnavailable:
+        return http.StatusServiceUnavailable
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
+// GetPinnedMessages lists a conversation's pinned messages
+func (h *Handler) GetPinnedMessages(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    
+    pins, err := h.service.GetPinnedMessages(r.Context(), userID, conversationID)
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), pinErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, pins, http.StatusOK)
+}
+
+func (h *Handler) PinMessage(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    messageID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    
+    pin, err := h.service.PinMessage(r.Context(), userID, messageID)
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), pinErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, pin, http.StatusOK)
+}
+
+func (h *Handler) UnpinMessage(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    messageID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    
+    if err := h.service.UnpinMessage(r.Context(), userID, messageID); err != nil {
+        utils.ErrorResponse(w, err.Error(), pinErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]string{"status": "unpinned"}, http.StatusOK)
+}
+
+func (h *Handler) PinConversation(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    
+    if err := h.service.PinConversation(r.Context(), userID, conversationID); err != nil {
+        utils.ErrorResponse(w, err.Error(), pinErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]string{"status": "pinned"}, http.StatusOK)
+}
+
+func (h *Handler) UnpinConversation(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    
+    if err := h.service.UnpinConversation(r.Context(), userID, conversationID); err != nil {
+        utils.ErrorResponse(w, err.Error(), pinErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, map[string]string{"status": "unpinned"}, http.StatusOK)
+}
+
+func pinErrorStatus(err error) int {
+    switch err {
+    case ErrMessageNotFound, ErrNotPinned:
+        return http.StatusNotFound
+    case ErrNotParticipant:
+        return http.StatusForbidden
+    case ErrTooManyPins:
+        return http.StatusConflict
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
+// ExportConversation streams the conversation history as JSON or plain text.
+// Conversations over MaxSyncExportMessages are queued instead and answered
+// with 202 and the export job to poll.
+func (h *Handler) ExportConversation(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    conversationID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    
+    format := r.URL.Query().Get("format")
+    if format == "" {
+        format = ExportFormatJSON
+    }
+    
+    job, err := h.service.RequestConversationExport(r.Context(), userID, conversationID, format)
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), exportErrorStatus(err))
+        return
+    }
+    if job != nil {
+        utils.SuccessResponse(w, job, http.StatusAccepted)
+        return
+    }
+    
+    ext, contentType := "json", "application/json"
+    if format == ExportFormatText {
+        ext, contentType = "txt", "text/plain; charset=utf-8"
+    }
+    w.Header().Set("Content-Type", contentType)
+    w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="conversation_%d.%s"`, conversationID, ext))
+    w.WriteHeader(http.StatusOK)
+    
+    // Headers are already sent, so a failure part way can only be logged
+    if _, err := h.service.WriteConversationExport(r.Context(), conversationID, format, w); err != nil {
+        log.Printf("Conversation %d export for user %d failed: %v", conversationID, userID, err)
+    }
+}
+
+// GetExport returns the status of a queued export, with a download link once done
+func (h *Handler) GetExport(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+        return
+    }
+    jobID, _ := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    
+    job, err := h.service.GetExportJob(r.Context(), userID, jobID)
+    if err != nil {
+        utils.ErrorResponse(w, err.Error(), exportErrorStatus(err))
+        return
+    }
+    
+    utils.SuccessResponse(w, job, http.StatusOK)
+}
+
+func exportErrorStatus(err error) int {
+    switch err {
+    case ErrInvalidExportFormat:
+        return http.StatusBadRequest
+    case ErrNotParticipant:
+        return http.StatusForbidden
+    case ErrExportNotFound:
+        return http.StatusNotFound
+    case ErrExportRateLimited:
+        return http.StatusTooManyRequests
+    case ErrStorageUnavailable:
+        return http.StatusServiceUnavailable
+    default:
+        return http.StatusInternalServerError
+    }
+}
+
 func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
     status := map[string]interface{}{
         "status": "healthy",
@@ -7,14 +7,16 @@ import (
     "encoding/json"
     "log"
     "sync"
+    "sync/atomic"
     "time"
 )
 
 // Hub maintains active websocket connections
 type Hub struct {
-    // Registered clients
-    clients    map[int64]*Client
-    clientsMux sync.RWMutex
+    // Registered clients, spread over shards by user ID so routing and
+    // connects don't all contend on one lock
+    shards      [hubShards]clientShard
+    connections atomic.Int64
     
     // Message broadcast channels
     broadcast  chan BroadcastMessage
@@ -32,29 +34,84 @@ type Hub struct {
     
     // WaitGroup for pending operations
     wg         sync.WaitGroup
+    
+    // Set while Run is processing events
+    running    atomic.Bool
+    
+    // Cached block lookups so routing doesn't hit the database per event
+    blocks     map[blockPair]blockEntry
+    blocksMux  sync.RWMutex
+}
+
+type clientShard struct {
+    mu      sync.RWMutex
+    clients map[int64]*Client
+}
+
+// blockCacheTTL bounds how long a cached block lookup is trusted. Blocks made
+// through this server update the cache immediately; the TTL covers other instances.
+const blockCacheTTL = 30 * time.Second
+
+// blockPair is an unordered pair of user IDs (lowest first)
+type blockPair struct {
+    a, b int64
+}
+
+type blockEntry struct {
+    blocked bool
+    expires time.Time
+}
+
+func newBlockPair(userID, otherUserID int64) blockPair {
+    if userID > otherUserID {
+        userID, otherUserID = otherUserID, userID
+    }
+    return blockPair{a: userID, b: otherUserID}
 }
 
 type BroadcastMessage struct {
     UserIDs []int64
     Message WSMessage
+    
+    // ConversationID scopes the message to a conversation, so it only reaches
+    // clients subscribed to it
+    ConversationID int64
 }
 
 func NewHub(service Service) *Hub {
     ctx, cancel := context.WithCancel(context.Background())
     
-    return &Hub{
-        clients:    make(map[int64]*Client),
-        broadcast:  make(chan BroadcastMessage, 256),
-        register:   make(chan *Client),
-        unregister: make(chan *Client),
+    h := &Hub{
+        broadcast:  make(chan BroadcastMessage, hubQueueSize),
+        register:   make(chan *Client, hubQueueSize),
+        unregister: make(chan *Client, hubQueueSize),
         service:    service,
         ctx:        ctx,
         cancel:     cancel,
+        blocks:     make(map[blockPair]blockEntry),
+    }
+    for i := range h.shards {
+        h.shards[i].clients = make(map[int64]*Client)
     }
+    return h
+}
+
+func (h *Hub) shard(userID int64) *clientShard {
+    return &h.shards[uint64(userID)%hubShards]
+}
+
+// client returns the user's open connection, or nil
+func (h *Hub) client(userID int64) *Client {
+    shard := h.shard(userID)
+    shard.mu.RLock()
+    defer shard.mu.RUnlock()
+    return shard.clients[userID]
 }
 
 func (h *Hub) Run() {
+    h.running.Store(true)
     defer func() {
+        h.running.Store(false)
         h.cleanup()
     }()
     
@@ -72,19 +129,31 @@ func (h *Hub) Run() {
         case <-h.ctx.Done():
             return
         }
+        
+        wsRegisterQueue.Set(float64(len(h.register)))
+        wsUnregisterQueue.Set(float64(len(h.unregister)))
+        wsBroadcastQueue.Set(float64(len(h.broadcast)))
     }
 }
 
 func (h *Hub) registerClient(client *Client) {
-    h.clientsMux.Lock()
-    defer h.clientsMux.Unlock()
+    shard := h.shard(client.userID)
+    shard.mu.Lock()
     
     // Remove old connection for the same user
-    if oldClient, exists := h.clients[client.userID]; exists {
+    oldClient, exists := shard.clients[client.userID]
+    if exists {
         oldClient.Close()
     }
     
-    h.clients[client.userID] = client
+    shard.clients[client.userID] = client
+    shard.mu.Unlock()
+    
+    total := h.connections.Load()
+    if !exists {
+        total = h.connections.Add(1)
+        wsConnections.Set(float64(total))
+    }
     
     // Update user online status
     h.wg.Add(1)
@@ -107,16 +176,25 @@ func (h *Hub) registerClient(client *Client) {
         h.notifyOnlineStatus(client.userID, true)
     }()
     
-    log.Printf("User %d connected. Total clients: %d", client.userID, len(h.clients))
+    log.Printf("User %d connected. Total clients: %d", client.userID, total)
 }
 
+// unregisterClient closes the client. It only takes the user offline if the
+// client is still their current connection, not one a reconnect replaced.
 func (h *Hub) unregisterClient(client *Client) {
-    h.clientsMux.Lock()
-    defer h.clientsMux.Unlock()
+    client.Close()
+    
+    shard := h.shard(client.userID)
+    shard.mu.Lock()
+    current := shard.clients[client.userID] == client
+    if current {
+        delete(shard.clients, client.userID)
+    }
+    shard.mu.Unlock()
     
-    if _, exists := h.clients[client.userID]; exists {
-        client.Close()
-        delete(h.clients, client.userID)
+    if current {
+        total := h.connections.Add(-1)
+        wsConnections.Set(float64(total))
         
         // Update user online status
         h.wg.Add(1)
@@ -132,40 +210,61 @@ func (h *Hub) unregisterClient(client *Client) {
             h.notifyOnlineStatus(client.userID, false)
         }()
         
-        log.Printf("User %d disconnected. Total clients: %d", client.userID, len(h.clients))
+        log.Printf("User %d disconnected. Total clients: %d", client.userID, total)
     }
 }
 
+// broadcastMessage encodes the message once and queues it for each online
+// recipient; see Client.enqueue for what happens to clients that fall behind
 func (h *Hub) broadcastMessage(msg BroadcastMessage) {
-    h.clientsMux.RLock()
-    defer h.clientsMux.RUnlock()
+    var data, activity []byte
     
     for _, userID := range msg.UserIDs {
-        if client, exists := h.clients[userID]; exists {
-            data, err := json.Marshal(msg.Message)
-            if err != nil {
-                log.Printf("Error marshalling message: %v", err)
+        client := h.client(userID)
+        if client == nil {
+            continue
+        }
+        
+        if msg.ConversationID != 0 && !client.wants(msg.ConversationID) {
+            // Unsubscribed clients only hear that a new message arrived
+            if msg.Message.Type != string(WSTypeMessage) {
                 continue
             }
-            
-            select {
-            case client.send <- data:
-            default:
-                // Unregister if channel is blocked
-                go h.unregisterClient(client)
+            if activity == nil {
+                activity = mustMarshalJSON(WSMessage{
+                    Type:      string(WSTypeConversationActivity),
+                    Data:      mustMarshalJSON(map[string]interface{}{"conversation_id": msg.ConversationID}),
+                    Timestamp: msg.Message.Timestamp,
+                })
+            }
+            client.enqueue(activity)
+            continue
+        }
+        
+        if data == nil {
+            var err error
+            if data, err = json.Marshal(msg.Message); err != nil {
+                log.Printf("Error marshalling message: %v", err)
+                return
             }
         }
+        client.enqueue(data)
     }
 }
 
 func (h *Hub) cleanup() {
     // Close all client connections
-    h.clientsMux.Lock()
-    for _, client := range h.clients {
-        client.Close()
+    for i := range h.shards {
+        shard := &h.shards[i]
+        shard.mu.Lock()
+        for _, client := range shard.clients {
+            client.Close()
+        }
+        shard.clients = make(map[int64]*Client)
+        shard.mu.Unlock()
     }
-    h.clients = make(map[int64]*Client)
-    h.clientsMux.Unlock()
+    h.connections.Store(0)
+    wsConnections.Set(0)
     
     // Wait for all pending operations
     h.wg.Wait()
@@ -199,17 +298,26 @@ func (h *Hub) sendPendingMessages(client *Client) {
             continue
         }
         
-        select {
-        case client.send <- data:
-            // Mark message as delivered for this specific user
-            go h.service.MarkMessageDelivered(h.ctx, msg.ID, client.userID)
-        default:
-            // Channel blocked, skip
+        // Mark message as delivered for this specific user; anything the
+        // queue can't take stays pending for the next connect
+        if !client.enqueue(data) {
+            return
         }
+        go h.service.MarkMessageDelivered(h.ctx, msg.ID, client.userID)
     }
 }
 
 func (h *Hub) notifyOnlineStatus(userID int64, online bool) {
+    // Users hiding their online status aren't announced at all
+    shows, err := h.service.ShowsOnlineStatus(h.ctx, userID)
+    if err != nil {
+        log.Printf("Error checking online status privacy for user %d: %v", userID, err)
+        return
+    }
+    if !shows {
+        return
+    }
+    
     // Get user's contacts
     contacts, err := h.service.GetUserContacts(h.ctx, userID)
     if err != nil {
@@ -232,8 +340,8 @@ func (h *Hub) notifyOnlineStatus(userID int64, online bool) {
         Timestamp: time.Now(),
     }
     
-    // Notify each contact
-    for _, contactID := range contacts {
+    // Notify each contact, except those with a block in either direction
+    for _, contactID := range h.filterBlocked(userID, contacts) {
         h.SendToUser(contactID, msg)
     }
 }
@@ -251,11 +359,8 @@ func (h *Hub) sendPushNotification(userID int64, message WSMessage) {
 }
 
 func (h *Hub) SendToUser(userID int64, message WSMessage) {
-    h.clientsMux.RLock()
-    client, exists := h.clients[userID]
-    h.clientsMux.RUnlock()
-    
-    if !exists {
+    client := h.client(userID)
+    if client == nil {
         // User offline, send push notification
         go h.sendPushNotification(userID, message)
         return
@@ -266,11 +371,7 @@ func (h *Hub) SendToUser(userID int64, message WSMessage) {
         return
     }
     
-    select {
-    case client.send <- data:
-    default:
-        go h.unregisterClient(client)
-    }
+    client.enqueue(data)
 }
 
 func (h *Hub) SendToConversation(conversationID int64, message WSMessage, excludeUserID int64) {
@@ -287,18 +388,21 @@ func (h *Hub) SendToConversation(conversationID int64, message WSMessage, exclud
         }
     }
     
+    // excludeUserID is the user who triggered the event; don't route it to
+    // anyone they have blocked or who has blocked them
+    if excludeUserID != 0 {
+        userIDs = h.filterBlocked(excludeUserID, userIDs)
+    }
+    
     h.broadcastMessage(BroadcastMessage{
-        UserIDs: userIDs,
-        Message: message,
+        UserIDs:        userIDs,
+        Message:        message,
+        ConversationID: conversationID,
     })
 }
 
 func (h *Hub) IsUserOnline(userID int64) bool {
-    h.clientsMux.RLock()
-    defer h.clientsMux.RUnlock()
-    
-    _, exists := h.clients[userID]
-    return exists
+    return h.client(userID) != nil
 }
 
 func (h *Hub) BroadcastToConversation(conversationID int64, message *WSMessage) {
@@ -321,23 +425,144 @@ func (h *Hub) BroadcastToConversationExcept(conversationID int64, exceptUserID i
         return
     }
     
-    // Send to all except specified user
+    // Send to all except specified user and users blocked with them
     for _, p := range participants {
-        if p.UserID != exceptUserID {
+        if p.UserID != exceptUserID && !h.isBlockedPair(exceptUserID, p.UserID) {
             h.SendToUser(p.UserID, *message)
         }
     }
 }
 
+// isBlockedPair reports whether either user has blocked the other, using the cache
+func (h *Hub) isBlockedPair(userID, otherUserID int64) bool {
+    if userID == otherUserID {
+        return false
+    }
+    
+    pair := newBlockPair(userID, otherUserID)
+    
+    h.blocksMux.RLock()
+    entry, ok := h.blocks[pair]
+    h.blocksMux.RUnlock()
+    
+    if ok && time.Now().Before(entry.expires) {
+        return entry.blocked
+    }
+    
+    blocked := h.service.IsBlocked(h.ctx, userID, otherUserID)
+    h.setBlocked(pair, blocked)
+    return blocked
+}
+
+func (h *Hub) setBlocked(pair blockPair, blocked bool) {
+    now := time.Now()
+    
+    h.blocksMux.Lock()
+    defer h.blocksMux.Unlock()
+    
+    // Keep the cache bounded by dropping expired entries once it grows
+    if len(h.blocks) > 10000 {
+        for p, entry := range h.blocks {
+            if now.After(entry.expires) {
+                delete(h.blocks, p)
+            }
+        }
+    }
+    
+    h.blocks[pair] = blockEntry{blocked: blocked, expires: now.Add(blockCacheTTL)}
+}
+
+// filterBlocked removes recipients that have a block with userID in either direction
+func (h *Hub) filterBlocked(userID int64, recipients []int64) []int64 {
+    filtered := recipients[:0:0]
+    for _, recipientID := range recipients {
+        if !h.isBlockedPair(userID, recipientID) {
+            filtered = append(filtered, recipientID)
+        }
+    }
+    return filtered
+}
+
+// HandleBlock marks the pair as blocked immediately and clears live state in
+// the conversations they share: typing indicators are stopped and each side
+// sees the other go offline, so nothing lingers on either client.
+func (h *Hub) HandleBlock(userID, blockedUserID int64, sharedConversationIDs []int64) {
+    h.setBlocked(newBlockPair(userID, blockedUserID), true)
+    
+    now := time.Now()
+    for _, pair := range [][2]int64{{userID, blockedUserID}, {blockedUserID, userID}} {
+        recipientID, otherID := pair[0], pair[1]
+        if !h.IsUserOnline(recipientID) {
+            continue
+        }
+        
+        for _, convID := range sharedConversationIDs {
+            h.service.UpdateTypingStatus(h.ctx, otherID, convID, false)
+            h.sendToOnlineUser(recipientID, WSMessage{
+                Type: string(WSTypeStopTyping),
+                Data: mustMarshalJSON(map[string]interface{}{
+                    "user_id":         otherID,
+                    "conversation_id": convID,
+                }),
+                Timestamp: now,
+            })
+        }
+        
+        h.sendToOnlineUser(recipientID, WSMessage{
+            Type: "presence",
+            Data: mustMarshalJSON(map[string]interface{}{
+                "user_id": otherID,
+                "status":  "offline",
+            }),
+            Timestamp: now,
+        })
+    }
+}
+
+// HandleUnblock drops the cached block so routing resumes on the next event
+func (h *Hub) HandleUnblock(userID, blockedUserID int64) {
+    h.blocksMux.Lock()
+    delete(h.blocks, newBlockPair(userID, blockedUserID))
+    h.blocksMux.Unlock()
+}
+
+// sendToOnlineUser delivers a message only over an open connection, never via push
+func (h *Hub) sendToOnlineUser(userID int64, message WSMessage) {
+    h.broadcastMessage(BroadcastMessage{
+        UserIDs: []int64{userID},
+        Message: message,
+    })
+}
+
+// SendEvent delivers a small event from another module, such as a badge
+// count, to a user's open connection. Offline users get nothing; they fetch
+// the state when they reconnect.
+func (h *Hub) SendEvent(userID int64, eventType string, payload interface{}) {
+    data, err := json.Marshal(payload)
+    if err != nil {
+        log.Printf("Failed to encode %s event: %v", eventType, err)
+        return
+    }
+    
+    h.sendToOnlineUser(userID, WSMessage{
+        Type:      eventType,
+        Data:      data,
+        Timestamp: time.Now(),
+    })
+}
+
 func (h *Hub) Shutdown() {
     h.cancel()
     h.wg.Wait() // Wait for Run() to exit
 }
 
+// Running reports whether the hub is routing events
+func (h *Hub) Running() bool {
+    return h.running.Load()
+}
+
 func (h *Hub) GetActiveConnections() int {
-    h.clientsMux.RLock()
-    defer h.clientsMux.RUnlock()
-    return len(h.clients)
+    return int(h.connections.Load())
 }
 
 func mustMarshalJSON(v interface{}) json.RawMessage {
@@ -0,0 +1,51 @@
+// internal/messaging/metrics.go
+
+package messaging
+
+import (
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+    wsConnections = promauto.NewGauge(
+        prometheus.GaugeOpts{
+            Name: "messaging_ws_connections",
+            Help: "Number of open WebSocket connections on this instance",
+        },
+    )
+
+    wsHubQueueLength = promauto.NewGaugeVec(
+        prometheus.GaugeOpts{
+            Name: "messaging_ws_hub_queue_length",
+            Help: "Events waiting for the hub's run loop",
+        },
+        []string{"queue"},
+    )
+
+    wsSendQueueDepth = promauto.NewHistogram(
+        prometheus.HistogramOpts{
+            Name:    "messaging_ws_send_queue_depth",
+            Help:    "Frames already queued for a connection when another is added",
+            Buckets: []float64{0, 1, 4, 16, 64, 128, 192, maxQueuedMessages},
+        },
+    )
+
+    wsFramesDropped = promauto.NewCounter(
+        prometheus.CounterOpts{
+            Name: "messaging_ws_frames_dropped_total",
+            Help: "Total number of frames dropped because a connection's send queue was full",
+        },
+    )
+
+    wsSlowClientDisconnects = promauto.NewCounter(
+        prometheus.CounterOpts{
+            Name: "messaging_ws_slow_client_disconnects_total",
+            Help: "Total number of connections closed for not keeping up with their send queue",
+        },
+    )
+
+    wsRegisterQueue   = wsHubQueueLength.WithLabelValues("register")
+    wsUnregisterQueue = wsHubQueueLength.WithLabelValues("unregister")
+    wsBroadcastQueue  = wsHubQueueLength.WithLabelValues("broadcast")
+)
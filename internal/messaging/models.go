@@ -5,6 +5,8 @@ package messaging
 import (
     "time"
     "encoding/json"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/common/media"
 )
 
 // UserInfo represents basic user information
@@ -50,7 +52,8 @@ type Conversation struct {
     
     // Computed fields
     Participants        []*Participant  `json:"participants,omitempty"`
-    UnreadCount         int             `json:"unread_count,omitempty"`
+    UnreadCount         int             `json:"unread_count,omitempty" db:"unread_count"`
+    IsPinned            bool            `json:"is_pinned,omitempty" db:"is_pinned"` // Pinned by the user listing conversations
     LastMessage         *Message        `json:"last_message,omitempty"`
 }
 
@@ -70,6 +73,7 @@ type Participant struct {
     IsTyping              bool       `json:"is_typing" db:"is_typing"`
     MutedUntil           *time.Time `json:"muted_until,omitempty" db:"muted_until"`
     TypingStartedAt       *time.Time `json:"typing_started_at,omitempty" db:"typing_started_at"`
+    PinnedAt              *time.Time `json:"pinned_at,omitempty" db:"pinned_at"`
     
     // Joined fields
     User                  *UserInfo  `json:"user,omitempty"`
@@ -93,6 +97,7 @@ type Message struct {
     IsDeleted         bool            `json:"is_deleted" db:"is_deleted"`
     DeletedAt         *time.Time      `json:"deleted_at,omitempty" db:"deleted_at"`
     DeliveredAt       *time.Time      `json:"delivered_at,omitempty" db:"delivered_at"`
+    ClientMessageID   *string         `json:"client_message_id,omitempty" db:"client_message_id"`
     CreatedAt         time.Time       `json:"created_at" db:"created_at"`
     
     // Computed fields
@@ -103,6 +108,16 @@ type Message struct {
     IsRead            bool            `json:"is_read,omitempty"`
 }
 
+// MarshalJSON serves message media through short-lived signed URLs, since
+// only the conversation's participants may see it
+func (m Message) MarshalJSON() ([]byte, error) {
+    type message Message
+    out := message(m)
+    out.MediaURL = media.SignURLPtr(m.MediaURL)
+    out.MediaThumbnailURL = media.SignURLPtr(m.MediaThumbnailURL)
+    return json.Marshal(out)
+}
+
 // Receipt represents message delivery/read receipt
 type Receipt struct {
     ID          int64      `json:"id" db:"id"`
@@ -113,8 +128,41 @@ type Receipt struct {
     User        *UserInfo  `json:"user,omitempty"`
 }
 
+// Aggregate receipt states for a message, from the sender's point of view
+const (
+    ReceiptStateSent      = "sent"      // Not yet delivered to every recipient
+    ReceiptStateDelivered = "delivered" // Delivered to every recipient
+    ReceiptStateRead      = "read"      // Read by every recipient
+)
+
+// MessageReceiptSummary aggregates receipts of a single message across recipients
+type MessageReceiptSummary struct {
+    MessageID      int64   `json:"message_id"`
+    SenderID       int64   `json:"sender_id"`
+    State          string  `json:"state"`
+    RecipientCount int     `json:"recipient_count"`
+    DeliveredCount int     `json:"delivered_count"`
+    ReadCount      int     `json:"read_count"`
+    ReadBy         []int64 `json:"read_by"`
+}
+
+// ParticipantReadState is how far a participant has read in a conversation
+type ParticipantReadState struct {
+    UserID            int64      `json:"user_id"`
+    LastReadMessageID *int64     `json:"last_read_message_id,omitempty"`
+    LastReadAt        *time.Time `json:"last_read_at,omitempty"`
+}
+
+// ConversationReceipts is the compact "seen by" view of a conversation
+type ConversationReceipts struct {
+    ConversationID int64                    `json:"conversation_id"`
+    Messages       []*MessageReceiptSummary `json:"messages"`
+    Participants   []*ParticipantReadState  `json:"participants"`
+}
+
 // WebSocket message types
 type WSMessage struct {
+    ID        string          `json:"id,omitempty"` // Set by the client on frames it wants acked; echoed in the ack or nack
     Type      string          `json:"type"`
     Data      json.RawMessage `json:"data"`
     Timestamp time.Time       `json:"timestamp"`
@@ -133,8 +181,33 @@ const (
     WSTypeReaction       WSMessageType = "reaction"
     WSTypeMessageDeleted WSMessageType = "message_deleted"
     WSTypeMessageEdited  WSMessageType = "message_edited"
+    WSTypeReadReceipt    WSMessageType = "read_receipt"
+    WSTypeMessagePinned   WSMessageType = "message_pinned"
+    WSTypeMessageUnpinned WSMessageType = "message_unpinned"
+    WSTypeExportReady     WSMessageType = "export_ready"
+    
+    // Subscription protocol. Client frames:
+    WSTypeSubscribe   WSMessageType = "subscribe"
+    WSTypeUnsubscribe WSMessageType = "unsubscribe"
+    WSTypePing        WSMessageType = "ping"
+    
+    // Server frames:
+    WSTypeAck                  WSMessageType = "ack"
+    WSTypeNack                 WSMessageType = "nack"
+    WSTypePong                 WSMessageType = "pong"
+    WSTypeConversationActivity WSMessageType = "conversation_activity"
 )
 
+// PinnedMessage is a message pinned to the top of its conversation
+type PinnedMessage struct {
+    ID             int64     `json:"id" db:"id"`
+    ConversationID int64     `json:"conversation_id" db:"conversation_id"`
+    MessageID      int64     `json:"message_id" db:"message_id"`
+    PinnedBy       int64     `json:"pinned_by" db:"pinned_by"`
+    PinnedAt       time.Time `json:"pinned_at" db:"pinned_at"`
+    Message        *Message  `json:"message,omitempty"`
+}
+
 // Request DTOs
 type CreateConversationRequest struct {
     Type         string   `json:"type" validate:"required,oneof=direct group"`
@@ -142,12 +215,54 @@ type CreateConversationRequest struct {
     ParticipantIDs []int64 `json:"participant_ids" validate:"required,min=1"`
 }
 
+type UpdateConversationRequest struct {
+    Name      *string `json:"name,omitempty" validate:"omitempty,min=1,max=100"`
+    AvatarURL *string `json:"avatar_url,omitempty" validate:"omitempty,url"`
+}
+
 type SendMessageRequest struct {
-    ConversationID  int64           `json:"conversation_id" validate:"required"`
-    Content         string          `json:"content" validate:"required_without=MediaURL"`
-    MessageType     string          `json:"message_type" validate:"required,oneof=text image video audio file location sticker"`
+    ConversationID  int64           `json:"conversation_id" validate:"required,gt=0"`
+    Content         string          `json:"content" validate:"required_without=MediaURL,max=5000"`
+    MessageType     string          `json:"message_type" validate:"required,oneof=text image video audio voice file location sticker"`
     MediaURL        string          `json:"media_url" validate:"omitempty,url"`
-    ParentMessageID *int64          `json:"parent_message_id,omitempty"`
+    ParentMessageID *int64          `json:"parent_message_id,omitempty" validate:"omitempty,gt=0"`
     Metadata        json.RawMessage `json:"metadata,omitempty"`
+    ClientMessageID string          `json:"client_message_id,omitempty" validate:"omitempty,max=64"` // Idempotency key; resending it returns the original message
 }
 
+// VoiceMetadata is stored in the message metadata column for voice notes
+type VoiceMetadata struct {
+    DurationMs int    `json:"duration_ms"`
+    Waveform   []int  `json:"waveform"` // normalized peaks, 0-100
+    MimeType   string `json:"mime_type"`
+    Size       int64  `json:"size"`
+}
+
+// StoryReference is stored in the message metadata column, under "story",
+// for replies to a story. Clients load the story itself by ID, so it stays
+// subject to the story's audience and expiry.
+type StoryReference struct {
+    StoryID   int64     `json:"story_id"`
+    OwnerID   int64     `json:"owner_id"`
+    MediaType string    `json:"media_type"`
+    ExpiresAt time.Time `json:"expires_at"`
+    Reaction  string    `json:"reaction,omitempty"`
+}
+
+// StartVoiceUploadRequest starts a chunked voice note upload
+type StartVoiceUploadRequest struct {
+    ConversationID int64  `json:"conversation_id" validate:"required"`
+    MimeType       string `json:"mime_type" validate:"required"`
+}
+
+// CompleteVoiceUploadRequest finalizes a voice note upload and sends it
+type CompleteVoiceUploadRequest struct {
+    ParentMessageID *int64 `json:"parent_message_id,omitempty"`
+    TotalChunks     int    `json:"total_chunks" validate:"required,min=1"`
+}
+
+// PlaybackURL is a short-lived signed URL for private media
+type PlaybackURL struct {
+    URL       string    `json:"url"`
+    ExpiresAt time.Time `json:"expires_at"`
+}
@@ -86,23 +86,30 @@ func (s *pushService) SendNotification(ctx context.Context, userID int64, notifi
             Data: notification.Data,
         }
         
-        // Platform-specific configuration
+        // Platform-specific configuration, from the payload schema the
+        // notification service also uses
         switch token.Platform {
         case "ios":
             message.APNS = &messaging.APNSConfig{
                 Payload: &messaging.APNSPayload{
                     Aps: &messaging.Aps{
-                        Badge: &notification.Badge,
-                        Sound: notification.Sound,
+                        Badge:    &notification.Badge,
+                        Sound:    notification.Sound,
+                        Category: notification.Data["category"],
                     },
                 },
             }
+            if collapseKey := notification.Data["collapse_key"]; collapseKey != "" {
+                message.APNS.Headers = map[string]string{"apns-collapse-id": collapseKey}
+            }
         case "android":
             message.Android = &messaging.AndroidConfig{
-                Priority: "high",
+                Priority:    "high",
+                CollapseKey: notification.Data["collapse_key"],
                 Notification: &messaging.AndroidNotification{
-                    Sound:    notification.Sound,
-                    Priority: messaging.PriorityHigh,
+                    Sound:     notification.Sound,
+                    Priority:  messaging.PriorityHigh,
+                    ChannelID: notification.Data["channel_id"],
                 },
             }
         }
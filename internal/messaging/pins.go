@@ -0,0 +1,119 @@
+// internal/messaging/pins.go
+
+package messaging
+
+import (
+    "context"
+    "errors"
+    "time"
+)
+
+// maxPinnedMessages is how many messages a conversation can have pinned at once
+const maxPinnedMessages = 5
+
+var (
+    ErrTooManyPins = errors.New("conversation already has the maximum number of pinned messages")
+    ErrNotPinned   = errors.New("message is not pinned")
+)
+
+// PinMessage pins a message to the top of its conversation. Any participant
+// may pin; pinning an already pinned message returns the existing pin.
+func (s *MessageService) PinMessage(ctx context.Context, userID, messageID int64) (*PinnedMessage, error) {
+    message, err := s.repo.GetMessage(ctx, messageID)
+    if err != nil || message.IsDeleted {
+        return nil, ErrMessageNotFound
+    }
+    if !s.IsUserInConversation(ctx, userID, message.ConversationID) {
+        return nil, ErrNotParticipant
+    }
+
+    pin, created, err := s.repo.PinMessage(ctx, message.ConversationID, messageID, userID, maxPinnedMessages)
+    if err != nil {
+        return nil, err
+    }
+    if pin == nil {
+        return nil, ErrTooManyPins
+    }
+    pin.Message = message
+
+    if created {
+        s.broadcastPin(WSTypeMessagePinned, pin.ConversationID, messageID, userID, pin.PinnedAt)
+    }
+    return pin, nil
+}
+
+// UnpinMessage removes a message's pin
+func (s *MessageService) UnpinMessage(ctx context.Context, userID, messageID int64) error {
+    message, err := s.repo.GetMessage(ctx, messageID)
+    if err != nil {
+        return ErrMessageNotFound
+    }
+    if !s.IsUserInConversation(ctx, userID, message.ConversationID) {
+        return ErrNotParticipant
+    }
+
+    removed, err := s.repo.UnpinMessage(ctx, messageID)
+    if err != nil {
+        return err
+    }
+    if !removed {
+        return ErrNotPinned
+    }
+
+    s.broadcastPin(WSTypeMessageUnpinned, message.ConversationID, messageID, userID, time.Now())
+    return nil
+}
+
+// GetPinnedMessages lists a conversation's pinned messages, most recent pin first
+func (s *MessageService) GetPinnedMessages(ctx context.Context, userID, conversationID int64) ([]*PinnedMessage, error) {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return nil, ErrNotParticipant
+    }
+
+    pins, err := s.repo.GetPinnedMessages(ctx, conversationID)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, pin := range pins {
+        if pin.Message != nil {
+            pin.Message.Sender, _ = s.getUserInfo(ctx, pin.Message.SenderID)
+        }
+    }
+    return pins, nil
+}
+
+// PinConversation keeps a conversation at the top of the user's list
+func (s *MessageService) PinConversation(ctx context.Context, userID, conversationID int64) error {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return ErrNotParticipant
+    }
+    defer s.invalidateLists(ctx, userID)
+    return s.repo.SetConversationPinned(ctx, conversationID, userID, true)
+}
+
+// UnpinConversation returns a conversation to its place by latest message
+func (s *MessageService) UnpinConversation(ctx context.Context, userID, conversationID int64) error {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return ErrNotParticipant
+    }
+    defer s.invalidateLists(ctx, userID)
+    return s.repo.SetConversationPinned(ctx, conversationID, userID, false)
+}
+
+// broadcastPin tells the other participants a message was pinned or unpinned
+func (s *MessageService) broadcastPin(eventType WSMessageType, convID, messageID, userID int64, at time.Time) {
+    if s.hub == nil {
+        return
+    }
+
+    s.hub.SendToConversation(convID, WSMessage{
+        Type: string(eventType),
+        Data: mustMarshal(map[string]interface{}{
+            "conversation_id": convID,
+            "message_id":      messageID,
+            "user_id":         userID,
+        }),
+        Timestamp: at,
+    }, userID)
+}
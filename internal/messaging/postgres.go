@@ -5,11 +5,20 @@ package messaging
 import (
     "context"
     "database/sql"
+    "sort"
     "time"
     
     "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/common/sqlbuilder"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
 )
 
+// Messages from deleted or suspended users stay in their conversations,
+// attributed to a ghost, so a missing or inactive sender never drops a row
+var ghostUserColumns = users.UsernameSQL("u") + `, ` + users.DisplayNameSQL("u") + `, ` + users.ProfilePictureSQL("u")
+
 type postgresRepository struct {
     db *sqlx.DB
 }
@@ -51,14 +60,11 @@ func (r *postgresRepository) GetConversation(ctx context.Context, id int64) (*Co
 
 func (r *postgresRepository) GetUserConversations(ctx context.Context, userID int64, limit, offset int) ([]*Conversation, error) {
     query := `
-        SELECT c.*, COUNT(m.id) FILTER (WHERE m.sender_id != $1 AND mr.read_at IS NULL) as unread_count
+        SELECT c.*, cp.unread_count, cp.pinned_at IS NOT NULL as is_pinned
         FROM conversations c
         INNER JOIN conversation_participants cp ON c.id = cp.conversation_id
-        LEFT JOIN messages m ON c.id = m.conversation_id
-        LEFT JOIN message_receipts mr ON m.id = mr.message_id AND mr.user_id = $1
         WHERE cp.user_id = $1 AND cp.left_at IS NULL
-        GROUP BY c.id
-        ORDER BY c.last_message_at DESC NULLS LAST
+        ORDER BY cp.pinned_at DESC NULLS LAST, c.last_message_at DESC NULLS LAST
         LIMIT $2 OFFSET $3`
     
     var conversations []*Conversation
@@ -67,20 +73,16 @@ func (r *postgresRepository) GetUserConversations(ctx context.Context, userID in
 }
 
 func (r *postgresRepository) UpdateConversation(ctx context.Context, id int64, updates map[string]interface{}) error {
-    // Build dynamic update query
-    query := `UPDATE conversations SET updated_at = NOW()`
-    args := []interface{}{id}
-    argCount := 1
-    
-    for key, value := range updates {
-        argCount++
-        query += `, ` + key + ` = $` + string(rune(argCount))
-        args = append(args, value)
+    query, args, err := sqlbuilder.NewUpdate("conversations").
+        SetSQL("updated_at", "NOW()").
+        SetMap(updates).
+        Where("id = ?", id).
+        Build()
+    if err != nil {
+        return err
     }
     
-    query += ` WHERE id = $1`
-    
-    _, err := r.db.ExecContext(ctx, query, args...)
+    _, err = r.db.ExecContext(ctx, query, args...)
     return err
 }
 
@@ -112,6 +114,21 @@ func (r *postgresRepository) GetDirectConversation(ctx context.Context, user1ID,
     return &conv, err
 }
 
+// GetSharedConversationIDs returns the conversations both users are still in
+func (r *postgresRepository) GetSharedConversationIDs(ctx context.Context, user1ID, user2ID int64) ([]int64, error) {
+    query := `
+        SELECT cp1.conversation_id
+        FROM conversation_participants cp1
+        JOIN conversation_participants cp2
+            ON cp2.conversation_id = cp1.conversation_id
+            AND cp2.user_id = $2 AND cp2.left_at IS NULL
+        WHERE cp1.user_id = $1 AND cp1.left_at IS NULL`
+    
+    var ids []int64
+    err := r.db.SelectContext(ctx, &ids, query, user1ID, user2ID)
+    return ids, err
+}
+
 func (r *postgresRepository) UpdateConversationLastMessage(ctx context.Context, convID, messageID int64, preview *string) error {
     query := `
         UPDATE conversations 
@@ -153,7 +170,9 @@ func (r *postgresRepository) RemoveParticipant(ctx context.Context, convID, user
 
 func (r *postgresRepository) GetConversationParticipants(ctx context.Context, convID int64) ([]*Participant, error) {
     query := `
-        SELECT cp.*, u.id, u.username, u.display_name, u.profile_picture, u.is_online, u.last_seen
+        SELECT cp.*, cp.user_id, ` + ghostUserColumns + `,
+               ` + users.IsOnlineSQL("u") + `,
+               ` + users.LastSeenSQL("u") + `
         FROM conversation_participants cp
         LEFT JOIN users u ON cp.user_id = u.id
         WHERE cp.conversation_id = $1 AND cp.left_at IS NULL`
@@ -203,7 +222,7 @@ func (r *postgresRepository) UpdateLastRead(ctx context.Context, convID, userID,
     query := `
         UPDATE conversation_participants 
         SET last_read_at = NOW(), 
-            last_read_message_id = $3,
+            last_read_message_id = GREATEST(COALESCE(last_read_message_id, 0), $3),
             unread_count = 0
         WHERE conversation_id = $1 AND user_id = $2`
     
@@ -241,28 +260,67 @@ func (r *postgresRepository) UpdateTypingStatus(ctx context.Context, convID, use
     return err
 }
 
+func (r *postgresRepository) SetParticipantMuted(ctx context.Context, convID, userID int64, muted bool) error {
+    query := `
+        UPDATE conversation_participants 
+        SET is_muted = $3, muted_until = NULL
+        WHERE conversation_id = $1 AND user_id = $2`
+    
+    _, err := r.db.ExecContext(ctx, query, convID, userID, muted)
+    return err
+}
+
+func (r *postgresRepository) SetParticipantArchived(ctx context.Context, convID, userID int64, archived bool) error {
+    query := `
+        UPDATE conversation_participants 
+        SET is_archived = $3
+        WHERE conversation_id = $1 AND user_id = $2`
+    
+    _, err := r.db.ExecContext(ctx, query, convID, userID, archived)
+    return err
+}
+
 // Messages
 func (r *postgresRepository) CreateMessage(ctx context.Context, message *Message) error {
     query := `
         INSERT INTO messages (
             conversation_id, sender_id, parent_message_id, content,
             message_type, media_url, media_thumbnail_url, media_size,
-            media_duration, metadata, created_at
+            media_duration, metadata, client_message_id, created_at
         ) VALUES (
-            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11
-        ) RETURNING id`
+            $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12
+        )
+        ON CONFLICT (sender_id, client_message_id) WHERE client_message_id IS NOT NULL DO NOTHING
+        RETURNING id`
     
+    // A repeated client_message_id inserts nothing and returns sql.ErrNoRows
     err := r.db.QueryRowContext(
         ctx, query,
         message.ConversationID, message.SenderID, message.ParentMessageID,
         message.Content, message.MessageType, message.MediaURL,
         message.MediaThumbnailURL, message.MediaSize, message.MediaDuration,
-        message.Metadata, message.CreatedAt,
+        message.Metadata, message.ClientMessageID, message.CreatedAt,
     ).Scan(&message.ID)
     
     return err
 }
 
+// GetMessageByClientID finds a message by its sender's idempotency key.
+// Returns nil when there is none.
+func (r *postgresRepository) GetMessageByClientID(ctx context.Context, senderID int64, clientMessageID string) (*Message, error) {
+    query := `SELECT * FROM messages WHERE sender_id = $1 AND client_message_id = $2`
+    
+    var msg Message
+    err := r.db.GetContext(ctx, &msg, query, senderID, clientMessageID)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &msg, nil
+}
+
 func (r *postgresRepository) GetMessage(ctx context.Context, id int64) (*Message, error) {
     query := `SELECT * FROM messages WHERE id = $1`
     
@@ -275,7 +333,7 @@ func (r *postgresRepository) GetConversationMessages(ctx context.Context, convID
     query := `
         SELECT 
             m.*,
-            u.id, u.username, u.display_name, u.profile_picture
+            m.sender_id, ` + ghostUserColumns + `
         FROM messages m
         LEFT JOIN users u ON m.sender_id = u.id
         WHERE m.conversation_id = $1 AND m.is_deleted = false
@@ -312,6 +370,62 @@ func (r *postgresRepository) GetConversationMessages(ctx context.Context, convID
     return messages, nil
 }
 
+// GetMessagesAfter returns up to limit of the newest messages after afterID, oldest first
+func (r *postgresRepository) GetMessagesAfter(ctx context.Context, convID, afterID int64, limit int) ([]*Message, error) {
+    query := `
+        SELECT * FROM (
+            SELECT 
+                m.*,
+                m.sender_id AS sender_user_id, ` + ghostUserColumns + `
+            FROM messages m
+            LEFT JOIN users u ON m.sender_id = u.id
+            WHERE m.conversation_id = $1 AND m.id > $2 AND m.is_deleted = false
+            ORDER BY m.id DESC
+            LIMIT $3
+        ) recent
+        ORDER BY id ASC`
+    
+    rows, err := r.db.QueryContext(ctx, query, convID, afterID, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    var messages []*Message
+    for rows.Next() {
+        var msg Message
+        var sender UserInfo
+        
+        err := rows.Scan(
+            &msg.ID, &msg.ConversationID, &msg.SenderID, &msg.ParentMessageID,
+            &msg.Content, &msg.MessageType, &msg.MediaURL, &msg.MediaThumbnailURL,
+            &msg.MediaSize, &msg.MediaDuration, &msg.Metadata, &msg.IsEdited,
+            &msg.EditedAt, &msg.IsDeleted, &msg.DeletedAt, &msg.DeliveredAt,
+            &msg.CreatedAt,
+            &sender.ID, &sender.Username, &sender.DisplayName, &sender.ProfilePicture,
+        )
+        if err != nil {
+            continue
+        }
+        
+        msg.Sender = &sender
+        messages = append(messages, &msg)
+    }
+    
+    return messages, rows.Err()
+}
+
+// GetLastMessageID returns the id of the newest message in a conversation, or 0 if empty
+func (r *postgresRepository) GetLastMessageID(ctx context.Context, convID int64) (int64, error) {
+    query := `
+        SELECT COALESCE(MAX(id), 0) FROM messages
+        WHERE conversation_id = $1 AND is_deleted = false`
+    
+    var id int64
+    err := r.db.QueryRowContext(ctx, query, convID).Scan(&id)
+    return id, err
+}
+
 func (r *postgresRepository) GetUndeliveredMessages(ctx context.Context, userID int64) ([]*Message, error) {
     query := `
         SELECT m.* FROM messages m
@@ -414,6 +528,60 @@ func (r *postgresRepository) GetMessageReceipts(ctx context.Context, messageID i
     return receipts, err
 }
 
+// GetReceiptSummaries aggregates receipts for the latest messages in a conversation.
+// A recipient counts as having read a message if they have a read receipt for it
+// or their last_read_message_id is at or past it.
+func (r *postgresRepository) GetReceiptSummaries(ctx context.Context, convID int64, limit int) ([]*MessageReceiptSummary, error) {
+    query := `
+        SELECT m.id, m.sender_id,
+               COUNT(cp.user_id) AS recipient_count,
+               COUNT(cp.user_id) FILTER (
+                   WHERE mr.delivered_at IS NOT NULL OR mr.read_at IS NOT NULL
+                   OR cp.last_read_message_id >= m.id
+               ) AS delivered_count,
+               COUNT(cp.user_id) FILTER (
+                   WHERE mr.read_at IS NOT NULL OR cp.last_read_message_id >= m.id
+               ) AS read_count,
+               COALESCE(ARRAY_AGG(cp.user_id) FILTER (
+                   WHERE mr.read_at IS NOT NULL OR cp.last_read_message_id >= m.id
+               ), '{}') AS read_by
+        FROM messages m
+        LEFT JOIN conversation_participants cp 
+            ON cp.conversation_id = m.conversation_id 
+            AND cp.user_id <> m.sender_id 
+            AND cp.left_at IS NULL
+            AND cp.joined_at <= m.created_at
+        LEFT JOIN message_receipts mr ON mr.message_id = m.id AND mr.user_id = cp.user_id
+        WHERE m.id IN (
+            SELECT id FROM messages 
+            WHERE conversation_id = $1 AND is_deleted = FALSE
+            ORDER BY id DESC
+            LIMIT $2
+        )
+        GROUP BY m.id, m.sender_id
+        ORDER BY m.id DESC`
+    
+    rows, err := r.db.QueryContext(ctx, query, convID, limit)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    var summaries []*MessageReceiptSummary
+    for rows.Next() {
+        var s MessageReceiptSummary
+        if err := rows.Scan(
+            &s.MessageID, &s.SenderID, &s.RecipientCount,
+            &s.DeliveredCount, &s.ReadCount, pq.Array(&s.ReadBy),
+        ); err != nil {
+            return nil, err
+        }
+        summaries = append(summaries, &s)
+    }
+    
+    return summaries, rows.Err()
+}
+
 // Reactions
 func (r *postgresRepository) AddReaction(ctx context.Context, reaction *Reaction) error {
     query := `
@@ -456,15 +624,224 @@ func (r *postgresRepository) GetMessageReactions(ctx context.Context, messageID
     return reactions, err
 }
 
+// Pins
+
+// PinMessage pins a message unless its conversation already has limit pins.
+// The conversation row is locked so concurrent pins can't overshoot the limit.
+// Returns a nil pin when the limit is reached, and created=false when the
+// message was already pinned.
+func (r *postgresRepository) PinMessage(ctx context.Context, convID, messageID, userID int64, limit int) (*PinnedMessage, bool, error) {
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return nil, false, err
+    }
+    defer tx.Rollback()
+    
+    if _, err := tx.ExecContext(ctx, `SELECT id FROM conversations WHERE id = $1 FOR UPDATE`, convID); err != nil {
+        return nil, false, err
+    }
+    
+    var pin PinnedMessage
+    err = tx.GetContext(ctx, &pin, `
+        SELECT id, conversation_id, message_id, pinned_by, pinned_at
+        FROM pinned_messages WHERE message_id = $1`, messageID)
+    if err == nil {
+        return &pin, false, nil
+    }
+    if err != sql.ErrNoRows {
+        return nil, false, err
+    }
+    
+    var count int
+    if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM pinned_messages WHERE conversation_id = $1`, convID); err != nil {
+        return nil, false, err
+    }
+    if count >= limit {
+        return nil, false, nil
+    }
+    
+    err = tx.GetContext(ctx, &pin, `
+        INSERT INTO pinned_messages (conversation_id, message_id, pinned_by, pinned_at)
+        VALUES ($1, $2, $3, NOW())
+        RETURNING id, conversation_id, message_id, pinned_by, pinned_at`,
+        convID, messageID, userID)
+    if err != nil {
+        return nil, false, err
+    }
+    
+    return &pin, true, tx.Commit()
+}
+
+// UnpinMessage removes a message's pin, reporting whether there was one
+func (r *postgresRepository) UnpinMessage(ctx context.Context, messageID int64) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `DELETE FROM pinned_messages WHERE message_id = $1`, messageID)
+    if err != nil {
+        return false, err
+    }
+    
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// GetPinnedMessages returns a conversation's pins, most recent first, with
+// their messages. Pins of deleted messages are left out.
+func (r *postgresRepository) GetPinnedMessages(ctx context.Context, convID int64) ([]*PinnedMessage, error) {
+    query := `
+        SELECT pm.id, pm.conversation_id, pm.message_id, pm.pinned_by, pm.pinned_at
+        FROM pinned_messages pm
+        INNER JOIN messages m ON m.id = pm.message_id
+        WHERE pm.conversation_id = $1 AND m.is_deleted = false
+        ORDER BY pm.pinned_at DESC`
+    
+    var pins []*PinnedMessage
+    if err := r.db.SelectContext(ctx, &pins, query, convID); err != nil {
+        return nil, err
+    }
+    
+    for _, pin := range pins {
+        message, err := r.GetMessage(ctx, pin.MessageID)
+        if err != nil {
+            return nil, err
+        }
+        pin.Message = message
+    }
+    
+    return pins, nil
+}
+
+// SetConversationPinned pins or unpins a conversation in one user's list
+func (r *postgresRepository) SetConversationPinned(ctx context.Context, convID, userID int64, pinned bool) error {
+    query := `
+        UPDATE conversation_participants 
+        SET pinned_at = CASE WHEN $3 THEN COALESCE(pinned_at, NOW()) ELSE NULL END
+        WHERE conversation_id = $1 AND user_id = $2`
+    
+    _, err := r.db.ExecContext(ctx, query, convID, userID, pinned)
+    return err
+}
+
+// Exports
+
+// CountConversationMessages counts the messages an export would include
+func (r *postgresRepository) CountConversationMessages(ctx context.Context, convID int64) (int, error) {
+    query := `SELECT COUNT(*) FROM messages WHERE conversation_id = $1 AND is_deleted = false`
+    
+    var count int
+    err := r.db.QueryRowContext(ctx, query, convID).Scan(&count)
+    return count, err
+}
+
+// StreamConversationMessages calls fn for every message in a conversation,
+// oldest first, reading rows as they arrive rather than loading them all
+func (r *postgresRepository) StreamConversationMessages(ctx context.Context, convID int64, fn func(*ExportMessage) error) error {
+    query := `
+        SELECT m.id, m.sender_id, ` + users.UsernameSQL("u") + `, ` + users.DisplayNameSQL("u") + `,
+               m.parent_message_id, m.message_type, m.content, m.media_url,
+               m.is_edited, m.created_at
+        FROM messages m
+        LEFT JOIN users u ON m.sender_id = u.id
+        WHERE m.conversation_id = $1 AND m.is_deleted = false
+        ORDER BY m.id ASC`
+    
+    rows, err := r.db.QueryContext(ctx, query, convID)
+    if err != nil {
+        return err
+    }
+    defer rows.Close()
+    
+    for rows.Next() {
+        var msg ExportMessage
+        var displayName sql.NullString
+        if err := rows.Scan(
+            &msg.ID, &msg.SenderID, &msg.SenderUsername, &displayName,
+            &msg.ParentMessageID, &msg.MessageType, &msg.Content, &msg.MediaURL,
+            &msg.IsEdited, &msg.CreatedAt,
+        ); err != nil {
+            return err
+        }
+        msg.SenderName = displayName.String
+        
+        if err := fn(&msg); err != nil {
+            return err
+        }
+    }
+    
+    return rows.Err()
+}
+
+func (r *postgresRepository) CreateExportJob(ctx context.Context, job *ExportJob) error {
+    query := `
+        INSERT INTO conversation_exports (conversation_id, user_id, format, status, message_count)
+        VALUES ($1, $2, $3, $4, $5)
+        RETURNING id, created_at`
+    
+    return r.db.QueryRowContext(
+        ctx, query,
+        job.ConversationID, job.UserID, job.Format, job.Status, job.MessageCount,
+    ).Scan(&job.ID, &job.CreatedAt)
+}
+
+// GetExportJob returns nil when there is no such export
+func (r *postgresRepository) GetExportJob(ctx context.Context, id int64) (*ExportJob, error) {
+    query := `SELECT * FROM conversation_exports WHERE id = $1`
+    
+    var job ExportJob
+    err := r.db.GetContext(ctx, &job, query, id)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &job, nil
+}
+
+// ClaimExportJobs marks up to limit pending exports as running and returns
+// them, oldest first. Exports left running for an hour (a crashed worker) are
+// picked up again.
+func (r *postgresRepository) ClaimExportJobs(ctx context.Context, limit int) ([]*ExportJob, error) {
+    query := `
+        UPDATE conversation_exports
+        SET status = 'running', started_at = NOW()
+        WHERE id IN (
+            SELECT id FROM conversation_exports
+            WHERE status = 'pending'
+               OR (status = 'running' AND started_at < NOW() - INTERVAL '1 hour')
+            ORDER BY created_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING *`
+    
+    var jobs []*ExportJob
+    if err := r.db.SelectContext(ctx, &jobs, query, limit); err != nil {
+        return nil, err
+    }
+    
+    sort.Slice(jobs, func(i, j int) bool { return jobs[i].ID < jobs[j].ID })
+    return jobs, nil
+}
+
+func (r *postgresRepository) FinishExportJob(ctx context.Context, job *ExportJob) error {
+    query := `
+        UPDATE conversation_exports
+        SET status = $2, message_count = $3, file_url = $4, error = $5, completed_at = NOW()
+        WHERE id = $1`
+    
+    _, err := r.db.ExecContext(ctx, query, job.ID, job.Status, job.MessageCount, job.FileURL, job.Error)
+    return err
+}
+
 // Push tokens
 func (r *postgresRepository) SavePushToken(ctx context.Context, userID int64, token, platform, deviceID string) error {
     query := `
-        INSERT INTO push_tokens (user_id, token, platform, device_id, created_at, updated_at)
-        VALUES ($1, $2, $3, $4, NOW(), NOW())
+        INSERT INTO push_tokens (user_id, token, platform, token_type, device_id, created_at, updated_at)
+        VALUES ($1, $2, $3, 'fcm', $4, NOW(), NOW())
         ON CONFLICT (token) 
         DO UPDATE SET 
             user_id = $1,
             platform = $3,
+            token_type = 'fcm',
             device_id = $4,
             is_active = true,
             updated_at = NOW()`
@@ -482,60 +859,14 @@ func (r *postgresRepository) DeletePushToken(ctx context.Context, token string)
 func (r *postgresRepository) GetUserPushTokens(ctx context.Context, userID int64) ([]*PushToken, error) {
     query := `
         SELECT * FROM push_tokens 
-        WHERE user_id = $1 AND is_active = true`
+        WHERE user_id = $1 AND is_active = true AND token_type = 'fcm'`
     
     var tokens []*PushToken
     err := r.db.SelectContext(ctx, &tokens, query, userID)
     return tokens, err
 }
 
-// Blocking
-func (r *postgresRepository) BlockUser(ctx context.Context, userID, blockedUserID int64) error {
-    query := `
-        INSERT INTO blocked_conversations (user_id, blocked_user_id, blocked_at)
-        VALUES ($1, $2, NOW())
-        ON CONFLICT (user_id, blocked_user_id) DO NOTHING`
-    
-    _, err := r.db.ExecContext(ctx, query, userID, blockedUserID)
-    return err
-}
-
-func (r *postgresRepository) UnblockUser(ctx context.Context, userID, blockedUserID int64) error {
-    query := `
-        DELETE FROM blocked_conversations 
-        WHERE user_id = $1 AND blocked_user_id = $2`
-    
-    _, err := r.db.ExecContext(ctx, query, userID, blockedUserID)
-    return err
-}
-
-func (r *postgresRepository) IsBlocked(ctx context.Context, userID, targetUserID int64) (bool, error) {
-    query := `
-        SELECT EXISTS(
-            SELECT 1 FROM blocked_conversations 
-            WHERE (user_id = $1 AND blocked_user_id = $2)
-            OR (user_id = $2 AND blocked_user_id = $1)
-        )`
-    
-    var exists bool
-    err := r.db.QueryRowContext(ctx, query, userID, targetUserID).Scan(&exists)
-    return exists, err
-}
-
 // User info
-func (r *postgresRepository) GetUserInfo(ctx context.Context, userID int64) (*UserInfo, error) {
-    query := `
-        SELECT id, username, 
-               COALESCE(display_name, username) as display_name, 
-               profile_picture, is_online, last_seen
-        FROM users 
-        WHERE id = $1`
-    
-    var user UserInfo
-    err := r.db.GetContext(ctx, &user, query, userID)
-    return &user, err
-}
-
 func (r *postgresRepository) GetUserContacts(ctx context.Context, userID int64) ([]int64, error) {
     query := `
         SELECT DISTINCT 
@@ -594,4 +925,32 @@ func (r *postgresRepository) DeleteOldReceipts(ctx context.Context, age time.Dur
     cutoff := time.Now().Add(-age)
     _, err := r.db.ExecContext(ctx, query, cutoff)
     return err
-}
\ No newline at end of file
+}
+
+// ReconcileUnreadCounts recounts each participant's unread messages since
+// their last read message and fixes the stored counts that drifted. It
+// returns how many participants were corrected.
+func (r *postgresRepository) ReconcileUnreadCounts(ctx context.Context) (int64, error) {
+    query := `
+        UPDATE conversation_participants cp
+        SET unread_count = actual.unread
+        FROM (
+            SELECT p.id, (
+                SELECT COUNT(*) FROM messages m
+                WHERE m.conversation_id = p.conversation_id
+                  AND m.sender_id != p.user_id
+                  AND m.is_deleted = false
+                  AND m.created_at >= p.joined_at
+                  AND m.id > COALESCE(p.last_read_message_id, 0)
+            ) AS unread
+            FROM conversation_participants p
+            WHERE p.left_at IS NULL
+        ) actual
+        WHERE cp.id = actual.id AND cp.unread_count IS DISTINCT FROM actual.unread`
+    
+    result, err := r.db.ExecContext(ctx, query)
+    if err != nil {
+        return 0, err
+    }
+    return result.RowsAffected()
+}
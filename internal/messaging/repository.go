@@ -15,6 +15,7 @@ type Repository interface {
     UpdateConversation(ctx context.Context, id int64, updates map[string]interface{}) error
     DeleteConversation(ctx context.Context, id int64) error
     GetDirectConversation(ctx context.Context, user1ID, user2ID int64) (*Conversation, error)
+    GetSharedConversationIDs(ctx context.Context, user1ID, user2ID int64) ([]int64, error)
     UpdateConversationLastMessage(ctx context.Context, convID, messageID int64, preview *string) error
     
     // Participants
@@ -26,11 +27,16 @@ type Repository interface {
     IncrementUnreadCount(ctx context.Context, convID, userID int64) error
     ResetUnreadCount(ctx context.Context, convID, userID int64) error
     UpdateTypingStatus(ctx context.Context, convID, userID int64, isTyping bool) error
+    SetParticipantMuted(ctx context.Context, convID, userID int64, muted bool) error
+    SetParticipantArchived(ctx context.Context, convID, userID int64, archived bool) error
     
     // Messages
     CreateMessage(ctx context.Context, message *Message) error
     GetMessage(ctx context.Context, id int64) (*Message, error)
+    GetMessageByClientID(ctx context.Context, senderID int64, clientMessageID string) (*Message, error)
     GetConversationMessages(ctx context.Context, convID int64, limit, offset int) ([]*Message, error)
+    GetMessagesAfter(ctx context.Context, convID, afterID int64, limit int) ([]*Message, error)
+    GetLastMessageID(ctx context.Context, convID int64) (int64, error)
     GetUndeliveredMessages(ctx context.Context, userID int64) ([]*Message, error)
     UpdateMessage(ctx context.Context, id int64, content string) error
     DeleteMessage(ctx context.Context, id int64) error
@@ -41,24 +47,34 @@ type Repository interface {
     CreateReceipt(ctx context.Context, receipt *Receipt) error
     UpdateReceipt(ctx context.Context, receipt *Receipt) error
     GetMessageReceipts(ctx context.Context, messageID int64) ([]*Receipt, error)
+    GetReceiptSummaries(ctx context.Context, convID int64, limit int) ([]*MessageReceiptSummary, error)
     
     // Reactions
     AddReaction(ctx context.Context, reaction *Reaction) error
     RemoveReaction(ctx context.Context, messageID, userID int64, reaction string) error
     GetMessageReactions(ctx context.Context, messageID int64) ([]*Reaction, error)
     
+    // Pins
+    PinMessage(ctx context.Context, convID, messageID, userID int64, limit int) (*PinnedMessage, bool, error)
+    UnpinMessage(ctx context.Context, messageID int64) (bool, error)
+    GetPinnedMessages(ctx context.Context, convID int64) ([]*PinnedMessage, error)
+    SetConversationPinned(ctx context.Context, convID, userID int64, pinned bool) error
+    
+    // Exports
+    CountConversationMessages(ctx context.Context, convID int64) (int, error)
+    StreamConversationMessages(ctx context.Context, convID int64, fn func(*ExportMessage) error) error
+    CreateExportJob(ctx context.Context, job *ExportJob) error
+    GetExportJob(ctx context.Context, id int64) (*ExportJob, error)
+    ClaimExportJobs(ctx context.Context, limit int) ([]*ExportJob, error)
+    FinishExportJob(ctx context.Context, job *ExportJob) error
+    
     // Push tokens
     SavePushToken(ctx context.Context, userID int64, token, platform, deviceID string) error
     DeletePushToken(ctx context.Context, token string) error
     GetUserPushTokens(ctx context.Context, userID int64) ([]*PushToken, error)
     
-    // Blocking
-    BlockUser(ctx context.Context, userID, blockedUserID int64) error
-    UnblockUser(ctx context.Context, userID, blockedUserID int64) error
-    IsBlocked(ctx context.Context, userID, targetUserID int64) (bool, error)
     
     // User info
-    GetUserInfo(ctx context.Context, userID int64) (*UserInfo, error)
     GetUserContacts(ctx context.Context, userID int64) ([]int64, error)
     UpdateUserOnlineStatus(ctx context.Context, userID int64, isOnline bool, lastSeen time.Time) error
     GetTypingUsers(ctx context.Context, conversationID int64) ([]int64, error)
@@ -66,6 +82,7 @@ type Repository interface {
     // Cleanup methods
     DeleteExpiredMessages(ctx context.Context) error
     DeleteOldReceipts(ctx context.Context, age time.Duration) error
+    ReconcileUnreadCounts(ctx context.Context) (int64, error)
 }
 
 // PushToken represents a device push notification token
@@ -74,6 +91,7 @@ type PushToken struct {
     UserID     int64     `json:"user_id" db:"user_id"`
     Token      string    `json:"token" db:"token"`
     Platform   string    `json:"platform" db:"platform"`
+    TokenType  string    `json:"token_type" db:"token_type"`
     DeviceID   string    `json:"device_id" db:"device_id"`
     IsActive   bool      `json:"is_active" db:"is_active"`
     LastUsedAt *time.Time `json:"last_used_at" db:"last_used_at"`
@@ -4,6 +4,7 @@ package messaging
 
 import (
     "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
     "net/http"
 )
 
@@ -26,7 +27,7 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware AuthMid
     })
     
     // Conversation endpoints
-    api.HandleFunc("/conversations", handler.GetConversations).Methods("GET")
+    api.HandleFunc("/conversations", middleware.ConditionalGETFunc(middleware.CachePerUser, handler.GetConversations)).Methods("GET")
     api.HandleFunc("/conversations/{id:[0-9]+}", handler.GetConversation).Methods("GET")
     api.HandleFunc("/conversations/{id:[0-9]+}", handler.UpdateConversation).Methods("PUT", "PATCH")
     api.HandleFunc("/conversations/{id:[0-9]+}", handler.DeleteConversation).Methods("DELETE")
@@ -36,13 +37,28 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware AuthMid
     api.HandleFunc("/conversations/{id:[0-9]+}/unmute", handler.UnmuteConversation).Methods("POST")
     api.HandleFunc("/conversations/{id:[0-9]+}/archive", handler.ArchiveConversation).Methods("POST")
     api.HandleFunc("/conversations/{id:[0-9]+}/unarchive", handler.UnarchiveConversation).Methods("POST")
+    api.HandleFunc("/conversations/{id:[0-9]+}/pin", handler.PinConversation).Methods("POST")
+    api.HandleFunc("/conversations/{id:[0-9]+}/unpin", handler.UnpinConversation).Methods("POST")
     
     // Message endpoints
     api.HandleFunc("/conversations/{id:[0-9]+}/messages", handler.GetMessages).Methods("GET")
+    api.HandleFunc("/conversations/{id:[0-9]+}/receipts", handler.GetConversationReceipts).Methods("GET")
+    api.HandleFunc("/conversations/{id:[0-9]+}/summary", handler.GetConversationSummary).Methods("GET")
+    api.HandleFunc("/conversations/{id:[0-9]+}/pins", handler.GetPinnedMessages).Methods("GET")
+    api.HandleFunc("/conversations/{id:[0-9]+}/export", handler.ExportConversation).Methods("GET")
+    api.HandleFunc("/exports/{id:[0-9]+}", handler.GetExport).Methods("GET")
     api.HandleFunc("/messages", handler.SendMessage).Methods("POST")
     api.HandleFunc("/messages/{id:[0-9]+}", handler.GetMessage).Methods("GET")
     api.HandleFunc("/messages/{id:[0-9]+}", handler.EditMessage).Methods("PUT", "PATCH")
     api.HandleFunc("/messages/{id:[0-9]+}", handler.DeleteMessage).Methods("DELETE")
+    api.HandleFunc("/messages/{id:[0-9]+}/pin", handler.PinMessage).Methods("POST")
+    api.HandleFunc("/messages/{id:[0-9]+}/pin", handler.UnpinMessage).Methods("DELETE")
+    
+    // Voice note endpoints
+    api.HandleFunc("/voice", handler.StartVoiceUpload).Methods("POST")
+    api.HandleFunc("/voice/{uploadId}/chunks/{index:[0-9]+}", handler.UploadVoiceChunk).Methods("PUT")
+    api.HandleFunc("/voice/{uploadId}/complete", handler.CompleteVoiceUpload).Methods("POST")
+    api.HandleFunc("/messages/{id:[0-9]+}/playback", handler.GetPlaybackURL).Methods("GET")
     
     // Message status endpoints
     api.HandleFunc("/messages/read", handler.MarkRead).Methods("POST")
@@ -9,8 +9,17 @@ import (
     "time"
     "log"
     "io"
-    "mime/multipart"
+    "math"
+    "os"
+    "encoding/json"
+    "database/sql"
 
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/cache"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    notifications "github.com/imadgeboyega/kiekky-backend/internal/notification"
+    "github.com/imadgeboyega/kiekky-backend/internal/textfilter"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
 )
 
 var (
@@ -19,6 +28,7 @@ var (
     ErrUnauthorized = errors.New("unauthorized")
     ErrBlocked = errors.New("user is blocked")
     ErrNotParticipant = errors.New("not a participant in this conversation")
+    ErrOnboardingIncomplete = errors.New("finish onboarding to send messages")
 )
 
 type Service interface {
@@ -32,6 +42,7 @@ type Service interface {
     // Messages
     SendMessage(ctx context.Context, userID int64, req *SendMessageRequest) (*Message, error)
     GetMessage(ctx context.Context, messageID int64) (*Message, error)
+    FindSentMessage(ctx context.Context, userID int64, clientMessageID string) (*Message, error)
     GetConversationMessages(ctx context.Context, conversationID, userID int64, limit, offset int) ([]*Message, error)
     EditMessage(ctx context.Context, messageID, userID int64, content string) (*Message, error)
     DeleteMessage(ctx context.Context, messageID, userID int64) error
@@ -39,17 +50,33 @@ type Service interface {
     // Message status
     MarkMessageDelivered(ctx context.Context, messageID, userID int64) error
     MarkMessagesRead(ctx context.Context, userID int64, messageIDs []int64) ([]*Receipt, error)
+    GetConversationReceipts(ctx context.Context, userID, conversationID int64, limit int) (*ConversationReceipts, error)
+    GetConversationSummary(ctx context.Context, userID, conversationID int64) (*ConversationSummary, error)
     GetUndeliveredMessages(ctx context.Context, userID int64) ([]*Message, error)
     
     // Reactions
     AddReaction(ctx context.Context, userID, messageID int64, reaction string) (*Reaction, error)
     RemoveReaction(ctx context.Context, userID, messageID int64, reaction string) error
     
+    // Pins
+    PinMessage(ctx context.Context, userID, messageID int64) (*PinnedMessage, error)
+    UnpinMessage(ctx context.Context, userID, messageID int64) error
+    GetPinnedMessages(ctx context.Context, userID, conversationID int64) ([]*PinnedMessage, error)
+    PinConversation(ctx context.Context, userID, conversationID int64) error
+    UnpinConversation(ctx context.Context, userID, conversationID int64) error
+    
+    // Export
+    RequestConversationExport(ctx context.Context, userID, conversationID int64, format string) (*ExportJob, error)
+    WriteConversationExport(ctx context.Context, conversationID int64, format string, w io.Writer) (int, error)
+    GetExportJob(ctx context.Context, userID, jobID int64) (*ExportJob, error)
+    ProcessExportJobs(ctx context.Context) (int, error)
+    
     // Typing indicators
     UpdateTypingStatus(ctx context.Context, userID, conversationID int64, isTyping bool) error
     
     // Online status
     UpdateOnlineStatus(ctx context.Context, userID int64, isOnline bool) error
+    ShowsOnlineStatus(ctx context.Context, userID int64) (bool, error)
     
     // Push notifications
     RegisterPushToken(ctx context.Context, userID int64, req *PushTokenRequest) error
@@ -66,6 +93,19 @@ type Service interface {
     // Hub management
     SetHub(hub *Hub)
     
+    // Summaries
+    SetSummarizer(summarizer Summarizer)
+    
+    // Text filtering
+    SetTextFilter(filter textfilter.Filter)
+    
+    // Domain events
+    SetEvents(bus *events.Bus)
+    SubscribeEvents(bus *events.Bus)
+    
+    // Onboarding gate
+    SetOnboarding(onboarding Onboarding)
+    
     // Missing cleanup methods
     CleanupExpiredMessages(ctx context.Context) error
     CleanupOldReceipts(ctx context.Context, age time.Duration) error
@@ -85,8 +125,14 @@ type Service interface {
     UnregisterPushToken(ctx context.Context, token string) error
     SearchMessages(ctx context.Context, userID int64, query string) ([]*Message, error)
     GetBlockedUsers(ctx context.Context, userID int64) ([]*UserInfo, error)
-    UploadMedia(ctx context.Context, userID int64, file io.Reader, header *multipart.FileHeader) (string, error)
+    UploadMedia(ctx context.Context, userID int64, file io.Reader, filename, contentType string) (string, error)
     GetContactsOnlineStatus(ctx context.Context, userID int64) (map[int64]bool, error)
+    
+    // Voice notes
+    StartVoiceUpload(ctx context.Context, userID int64, req *StartVoiceUploadRequest) (*VoiceUpload, error)
+    UploadVoiceChunk(ctx context.Context, userID int64, uploadID string, index int, chunk io.Reader) (*VoiceUpload, error)
+    CompleteVoiceUpload(ctx context.Context, userID int64, uploadID string, req *CompleteVoiceUploadRequest) (*Message, error)
+    GetPlaybackURL(ctx context.Context, userID, messageID int64) (*PlaybackURL, error)
 }
 
 // Update service struct to export it:
@@ -95,15 +141,42 @@ type MessageService struct { // Changed from 'service' to 'MessageService' and e
     hub            *Hub
     storageService StorageService
     pushService    PushService
+    voiceUploads   *voiceUploadStore
+    audioAnalyzer  AudioAnalyzer
+    summarizer     Summarizer
+    summaries      *summaryCache
+    exportLimiter  *exportLimiter
+    blocks         blocks.Service
+    users          users.Client
+    textFilter     textfilter.Filter
+    events         *events.Bus
+    cache          *cache.Cache
+    onboarding     Onboarding
+}
+
+// Onboarding is the part of onboarding messaging needs
+type Onboarding interface {
+    CanMessage(ctx context.Context, userID int64) (bool, error)
 }
 
 // Update NewService to return concrete type for type assertion:
-func NewService(repo Repository, storageService StorageService, pushService PushService) *MessageService {
-    return &MessageService{
+// Blocks come from the shared blocks service; the service listens to it so a
+// block made from a profile clears realtime state here too. Senders and
+// presence are read through the shared users client.
+func NewService(repo Repository, storageService StorageService, pushService PushService, blockService blocks.Service, userClient users.Client) *MessageService {
+    s := &MessageService{
         repo:           repo,
         storageService: storageService,
         pushService:    pushService,
+        voiceUploads:   newVoiceUploadStore(),
+        audioAnalyzer:  NewAudioAnalyzer(),
+        summaries:      newSummaryCache(),
+        exportLimiter:  newExportLimiter(),
+        blocks:         blockService,
+        users:          userClient,
     }
+    blockService.AddListener(s.handleBlockChange)
+    return s
 }
 
 // SetHub sets the hub after initialization to avoid circular dependency
@@ -111,6 +184,88 @@ func (s *MessageService) SetHub(hub *Hub) {
     s.hub = hub
 }
 
+// SetSummarizer enables unread conversation summaries. Leaving it unset (nil)
+// keeps the feature disabled.
+func (s *MessageService) SetSummarizer(summarizer Summarizer) {
+    s.summarizer = summarizer
+}
+
+// SetTextFilter screens message text for profanity and spam. Leaving it
+// unset (nil) stores messages as sent.
+func (s *MessageService) SetTextFilter(filter textfilter.Filter) {
+    s.textFilter = filter
+}
+
+// SetEvents sets the bus MessageSent is emitted on. Leaving it unset (nil)
+// emits nothing.
+func (s *MessageService) SetEvents(bus *events.Bus) {
+    s.events = bus
+}
+
+// SetOnboarding gates sending messages behind the onboarding steps it
+// needs. Leaving it unset (nil) lets anyone send.
+func (s *MessageService) SetOnboarding(onboarding Onboarding) {
+    s.onboarding = onboarding
+}
+
+// checkOnboarding returns ErrOnboardingIncomplete if the user hasn't done
+// the onboarding steps messaging needs
+func (s *MessageService) checkOnboarding(ctx context.Context, userID int64) error {
+    if s.onboarding == nil {
+        return nil
+    }
+    allowed, err := s.onboarding.CanMessage(ctx, userID)
+    if err != nil {
+        return err
+    }
+    if !allowed {
+        return ErrOnboardingIncomplete
+    }
+    return nil
+}
+
+// SetCache caches conversation lists between reads. Leaving it unset (nil)
+// reads them from the database every time.
+func (s *MessageService) SetCache(c *cache.Cache) {
+    s.cache = c
+}
+
+// invalidateLists drops the cached conversation lists of the given users
+func (s *MessageService) invalidateLists(ctx context.Context, userIDs ...int64) {
+    s.cache.Invalidate(ctx, cache.Conversations, userIDs...)
+}
+
+// invalidateParticipants drops the cached conversation lists of everyone in
+// the given participant list
+func (s *MessageService) invalidateParticipants(ctx context.Context, participants []*Participant) {
+    userIDs := make([]int64, len(participants))
+    for i, p := range participants {
+        userIDs[i] = p.UserID
+    }
+    s.invalidateLists(ctx, userIDs...)
+}
+
+// invalidateConversation drops the cached conversation lists of everyone in
+// a conversation, after a write that changes how it is listed
+func (s *MessageService) invalidateConversation(ctx context.Context, conversationID int64) {
+    if s.cache == nil {
+        return
+    }
+    participants, _ := s.repo.GetConversationParticipants(ctx, conversationID)
+    s.invalidateParticipants(ctx, participants)
+}
+
+// emitMessageSent tells other modules, such as analytics, about a sent
+// message
+func (s *MessageService) emitMessageSent(ctx context.Context, message *Message) {
+    s.events.Emit(ctx, events.MessageSent{
+        MessageID:      message.ID,
+        ConversationID: message.ConversationID,
+        SenderID:       message.SenderID,
+        MessageType:    message.MessageType,
+    })
+}
+
 // SendMessage sends a new message
 func (s *MessageService) SendMessage(ctx context.Context, userID int64, req *SendMessageRequest) (*Message, error) {
     // Verify user is participant
@@ -118,6 +273,10 @@ func (s *MessageService) SendMessage(ctx context.Context, userID int64, req *Sen
         return nil, ErrNotParticipant
     }
     
+    if err := s.checkOnboarding(ctx, userID); err != nil {
+        return nil, err
+    }
+    
     // Check for blocked users
     participants, _ := s.repo.GetConversationParticipants(ctx, req.ConversationID)
     for _, p := range participants {
@@ -126,6 +285,22 @@ func (s *MessageService) SendMessage(ctx context.Context, userID int64, req *Sen
         }
     }
     
+    // A resent idempotency key gets the original message back
+    if req.ClientMessageID != "" {
+        existing, err := s.FindSentMessage(ctx, userID, req.ClientMessageID)
+        if err != nil || existing != nil {
+            return existing, err
+        }
+    }
+    
+    if s.textFilter != nil && req.Content != "" {
+        content, err := s.textFilter.Filter(ctx, userID, textfilter.FieldMessage, req.Content)
+        if err != nil {
+            return nil, err
+        }
+        req.Content = content
+    }
+    
     // Handle media upload if needed
     var mediaURL, thumbnailURL string
     var mediaSize, mediaDuration int
@@ -157,9 +332,16 @@ func (s *MessageService) SendMessage(ctx context.Context, userID int64, req *Sen
         Metadata:         req.Metadata,
         CreatedAt:        time.Now(),
     }
+    if req.ClientMessageID != "" {
+        message.ClientMessageID = &req.ClientMessageID
+    }
     
-    // Save to database
+    // Save to database. Losing a race with the same idempotency key means
+    // the other send stored the message.
     if err := s.repo.CreateMessage(ctx, message); err != nil {
+        if errors.Is(err, sql.ErrNoRows) && req.ClientMessageID != "" {
+            return s.FindSentMessage(ctx, userID, req.ClientMessageID)
+        }
         return nil, err
     }
     
@@ -172,9 +354,11 @@ func (s *MessageService) SendMessage(ctx context.Context, userID int64, req *Sen
             s.repo.IncrementUnreadCount(ctx, req.ConversationID, p.UserID)
         }
     }
+    s.invalidateParticipants(ctx, participants)
     
     // Load sender info
-    message.Sender, _ = s.repo.GetUserInfo(ctx, userID)
+    message.Sender, _ = s.getUserInfo(ctx, userID)
+    s.emitMessageSent(ctx, message)
     
     // Send push notifications to offline users
     go s.sendMessageNotifications(ctx, message, participants)
@@ -182,6 +366,17 @@ func (s *MessageService) SendMessage(ctx context.Context, userID int64, req *Sen
     return message, nil
 }
 
+// FindSentMessage returns the message userID sent with the given idempotency
+// key, or nil if there is none
+func (s *MessageService) FindSentMessage(ctx context.Context, userID int64, clientMessageID string) (*Message, error) {
+    message, err := s.repo.GetMessageByClientID(ctx, userID, clientMessageID)
+    if err != nil || message == nil {
+        return nil, err
+    }
+    message.Sender, _ = s.getUserInfo(ctx, userID)
+    return message, nil
+}
+
 func (s *MessageService) IsUserInConversation(ctx context.Context, userID, conversationID int64) bool {
     isIn, err := s.repo.IsUserInConversation(ctx, userID, conversationID)
     if err != nil {
@@ -191,21 +386,78 @@ func (s *MessageService) IsUserInConversation(ctx context.Context, userID, conve
 }
 
 func (s *MessageService) IsBlocked(ctx context.Context, userID, targetUserID int64) bool {
-    blocked, err := s.repo.IsBlocked(ctx, userID, targetUserID)
+    blocked, err := s.blocks.IsBlocked(ctx, userID, targetUserID)
     if err != nil {
         return false
     }
     return blocked
 }
 
+// BlockUser blocks a user. Blocking someone already blocked is not an error here.
+func (s *MessageService) BlockUser(ctx context.Context, userID, blockedUserID int64) error {
+    err := s.blocks.Block(ctx, userID, blockedUserID)
+    if errors.Is(err, blocks.ErrAlreadyBlocked) {
+        return nil
+    }
+    return err
+}
+
+// UnblockUser removes a block
+func (s *MessageService) UnblockUser(ctx context.Context, userID, blockedUserID int64) error {
+    return s.blocks.Unblock(ctx, userID, blockedUserID)
+}
+
+// GetBlockedUsers lists the users userID has blocked
+func (s *MessageService) GetBlockedUsers(ctx context.Context, userID int64) ([]*UserInfo, error) {
+    blockedIDs, err := s.blocks.GetBlocked(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    
+    blockedUsers := make([]*UserInfo, 0, len(blockedIDs))
+    for _, blockedID := range blockedIDs {
+        user, err := s.getUserInfo(ctx, blockedID)
+        if err != nil {
+            continue
+        }
+        blockedUsers = append(blockedUsers, user)
+    }
+    return blockedUsers, nil
+}
+
+// handleBlockChange immediately stops realtime events between two users on a
+// block, wherever it was made, and lets routing resume on an unblock
+func (s *MessageService) handleBlockChange(ctx context.Context, blockerID, blockedID int64, blocked bool) {
+    if s.hub == nil {
+        return
+    }
+    
+    if blocked {
+        s.hub.HandleBlock(blockerID, blockedID, s.sharedConversationIDs(ctx, blockerID, blockedID))
+    } else {
+        s.hub.HandleUnblock(blockerID, blockedID)
+    }
+}
+
+// sharedConversationIDs returns the conversations both users participate in
+func (s *MessageService) sharedConversationIDs(ctx context.Context, userID, otherUserID int64) []int64 {
+    shared, err := s.repo.GetSharedConversationIDs(ctx, userID, otherUserID)
+    if err != nil {
+        log.Printf("Error loading shared conversations for block: %v", err)
+        return nil
+    }
+    return shared
+}
+
 func (s *MessageService) sendMessageNotifications(ctx context.Context, message *Message, participants []*Participant) {
-    // Skip if no push service
-    if s.pushService == nil {
+    // Skip if no push service. Story replies are notified by the
+    // notifications module as story_reply instead.
+    if s.pushService == nil || message.MessageType == "story_reply" {
         return
     }
     
     // Get sender info
-    sender, _ := s.repo.GetUserInfo(ctx, message.SenderID)
+    sender, _ := s.getUserInfo(ctx, message.SenderID)
     if sender == nil {
         return
     }
@@ -221,6 +473,8 @@ func (s *MessageService) sendMessageNotifications(ctx context.Context, message *
             body = ptr("Sent a video")
         case "audio":
             body = ptr("Sent an audio message")
+        case "voice":
+            body = ptr("Sent a voice message")
         case "file":
             body = ptr("Sent a file")
         default:
@@ -276,32 +530,232 @@ func (s *MessageService) CleanupOldReceipts(ctx context.Context, age time.Durati
     return err
 }
 
+// ReconcileUnreadCounts corrects stored unread counts against the messages
+// themselves. Sends and reads keep them current; this catches drift, such as
+// deleted messages that were still counted.
+func (s *MessageService) ReconcileUnreadCounts(ctx context.Context) (int64, error) {
+    return s.repo.ReconcileUnreadCounts(ctx)
+}
+
 func (s *MessageService) GetPendingMessages(ctx context.Context, userID int64) ([]*Message, error) {
     // Get undelivered messages for a user
     return s.repo.GetUndeliveredMessages(ctx, userID)
 }
 
-func (s *MessageService) MarkMessageDelivered(ctx context.Context, messageID int64) error {
-    // Get message to find recipients
+// MarkMessageDelivered records that a message reached one of its recipients
+func (s *MessageService) MarkMessageDelivered(ctx context.Context, messageID, userID int64) error {
     message, err := s.repo.GetMessage(ctx, messageID)
     if err != nil {
         return err
     }
     
-    // Get conversation participants
-    participants, err := s.repo.GetConversationParticipants(ctx, message.ConversationID)
+    // Senders don't get receipts for their own messages
+    if message.SenderID == userID {
+        return nil
+    }
+    
+    return s.repo.MarkMessageDelivered(ctx, messageID, userID)
+}
+
+// MarkMessagesRead records read receipts for the given messages, advances the
+// reader's last read position and notifies the other participants
+func (s *MessageService) MarkMessagesRead(ctx context.Context, userID int64, messageIDs []int64) ([]*Receipt, error) {
+    now := time.Now()
+    receipts := make([]*Receipt, 0, len(messageIDs))
+    lastRead := make(map[int64]int64)      // conversation -> highest message read
+    readIDs := make(map[int64][]int64)     // conversation -> messages read
+    
+    for _, messageID := range messageIDs {
+        message, err := s.repo.GetMessage(ctx, messageID)
+        if err != nil {
+            continue
+        }
+        
+        // Own messages don't get receipts
+        if message.SenderID == userID {
+            continue
+        }
+        
+        if _, seen := lastRead[message.ConversationID]; !seen {
+            if !s.IsUserInConversation(ctx, userID, message.ConversationID) {
+                continue
+            }
+        }
+        
+        receipt := &Receipt{
+            MessageID:   messageID,
+            UserID:      userID,
+            DeliveredAt: &now,
+            ReadAt:      &now,
+        }
+        if err := s.repo.CreateReceipt(ctx, receipt); err != nil {
+            return nil, err
+        }
+        receipts = append(receipts, receipt)
+        
+        if messageID > lastRead[message.ConversationID] {
+            lastRead[message.ConversationID] = messageID
+        }
+        readIDs[message.ConversationID] = append(readIDs[message.ConversationID], messageID)
+    }
+    
+    for convID, messageID := range lastRead {
+        if err := s.repo.UpdateLastRead(ctx, convID, userID, messageID); err != nil {
+            return nil, err
+        }
+        s.broadcastReadReceipt(convID, userID, messageID, readIDs[convID], now)
+    }
+    if len(lastRead) > 0 {
+        s.invalidateLists(ctx, userID)
+    }
+    
+    return receipts, nil
+}
+
+// broadcastReadReceipt tells the other participants how far a user has read
+func (s *MessageService) broadcastReadReceipt(convID, userID, lastReadMessageID int64, messageIDs []int64, readAt time.Time) {
+    if s.hub == nil {
+        return
+    }
+    
+    s.hub.SendToConversation(convID, WSMessage{
+        Type: string(WSTypeReadReceipt),
+        Data: mustMarshal(map[string]interface{}{
+            "conversation_id":      convID,
+            "user_id":              userID,
+            "last_read_message_id": lastReadMessageID,
+            "message_ids":          messageIDs,
+            "read_at":              readAt,
+        }),
+        Timestamp: readAt,
+    }, userID)
+}
+
+// GetConversationReceipts returns per-message aggregate receipt state for the
+// latest messages and each participant's last read message
+func (s *MessageService) GetConversationReceipts(ctx context.Context, userID, conversationID int64, limit int) (*ConversationReceipts, error) {
+    if !s.IsUserInConversation(ctx, userID, conversationID) {
+        return nil, ErrNotParticipant
+    }
+    
+    summaries, err := s.repo.GetReceiptSummaries(ctx, conversationID, limit)
     if err != nil {
-        return err
+        return nil, err
     }
     
-    // Mark delivered for all participants except sender
+    for _, summary := range summaries {
+        switch {
+        case summary.RecipientCount > 0 && summary.ReadCount == summary.RecipientCount:
+            summary.State = ReceiptStateRead
+        case summary.RecipientCount > 0 && summary.DeliveredCount == summary.RecipientCount:
+            summary.State = ReceiptStateDelivered
+        default:
+            summary.State = ReceiptStateSent
+        }
+    }
+    
+    participants, err := s.repo.GetConversationParticipants(ctx, conversationID)
+    if err != nil {
+        return nil, err
+    }
+    
+    states := make([]*ParticipantReadState, 0, len(participants))
     for _, p := range participants {
-        if p.UserID != message.SenderID {
-            s.repo.MarkMessageDelivered(ctx, messageID, p.UserID)
+        states = append(states, &ParticipantReadState{
+            UserID:            p.UserID,
+            LastReadMessageID: p.LastReadMessageID,
+            LastReadAt:        p.LastReadAt,
+        })
+    }
+    
+    if summaries == nil {
+        summaries = []*MessageReceiptSummary{}
+    }
+    
+    return &ConversationReceipts{
+        ConversationID: conversationID,
+        Messages:       summaries,
+        Participants:   states,
+    }, nil
+}
+
+// GetConversationSummary recaps the user's unread backlog once it reaches
+// MinUnreadForSummary messages. Results are cached until a new message arrives.
+func (s *MessageService) GetConversationSummary(ctx context.Context, userID, conversationID int64) (*ConversationSummary, error) {
+    if s.summarizer == nil {
+        return nil, ErrSummariesDisabled
+    }
+    
+    conv, err := s.repo.GetConversation(ctx, conversationID)
+    if err != nil {
+        return nil, ErrConversationNotFound
+    }
+    
+    participants, err := s.repo.GetConversationParticipants(ctx, conversationID)
+    if err != nil {
+        return nil, err
+    }
+    
+    var viewer *Participant
+    for _, p := range participants {
+        if p.UserID == userID {
+            viewer = p
+            break
         }
     }
+    if viewer == nil {
+        return nil, ErrNotParticipant
+    }
+    if viewer.UnreadCount < MinUnreadForSummary {
+        return nil, ErrSummaryNotNeeded
+    }
     
-    return nil
+    lastMessageID, err := s.repo.GetLastMessageID(ctx, conversationID)
+    if err != nil {
+        return nil, err
+    }
+    if cached := s.summaries.get(conversationID, userID, lastMessageID); cached != nil {
+        return cached, nil
+    }
+    
+    var afterID int64
+    if viewer.LastReadMessageID != nil {
+        afterID = *viewer.LastReadMessageID
+    }
+    
+    messages, err := s.repo.GetMessagesAfter(ctx, conversationID, afterID, maxSummaryMessages)
+    if err != nil {
+        return nil, err
+    }
+    if len(messages) == 0 {
+        return nil, ErrSummaryNotNeeded
+    }
+    
+    viewerInfo := viewer.User
+    if viewerInfo == nil {
+        viewerInfo = &UserInfo{ID: userID}
+    }
+    
+    summary, err := s.summarizer.Summarize(ctx, &SummaryInput{
+        ConversationID:   conversationID,
+        ConversationType: conv.Type,
+        Viewer:           viewerInfo,
+        Participants:     participants,
+        Messages:         messages,
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to summarize conversation: %w", err)
+    }
+    
+    summary.ConversationID = conversationID
+    summary.UnreadCount = viewer.UnreadCount
+    summary.FromMessageID = messages[0].ID
+    summary.LastMessageID = lastMessageID
+    summary.GeneratedAt = time.Now()
+    
+    s.summaries.set(userID, summary)
+    
+    return summary, nil
 }
 
 func (s *MessageService) GetPushTokens(ctx context.Context, userID int64) ([]*PushToken, error) {
@@ -311,12 +765,28 @@ func (s *MessageService) GetPushTokens(ctx context.Context, userID int64) ([]*Pu
 func (s *MessageService) SendPushNotification(ctx context.Context, tokens []*PushToken, message WSMessage) error {
     // Convert WSMessage to notification format
     var title, body string
+    payload := &notifications.PushPayload{
+        Type:      notifications.NotificationType(message.Type),
+        DeepLink:  notifications.DeepLinkScheme + "messages",
+        ChannelID: notifications.ChannelMessages,
+    }
     
     // Parse message data
     switch message.Type {
     case "message":
         title = "New Message"
         body = "You have a new message"
+        payload.Type = notifications.TypeMessage
+        payload.Category = notifications.CategoryMessage
+        
+        // Let the app open the conversation at this message
+        var sent Message
+        if err := json.Unmarshal(message.Data, &sent); err == nil && sent.ConversationID > 0 {
+            payload.ConversationID = sent.ConversationID
+            payload.MessageID = sent.ID
+            payload.DeepLink = fmt.Sprintf("%smessages/%d", notifications.DeepLinkScheme, sent.ConversationID)
+            payload.CollapseKey = fmt.Sprintf("conversation:%d", sent.ConversationID)
+        }
     case "typing":
         return nil // Don't send push for typing
     default:
@@ -327,13 +797,16 @@ func (s *MessageService) SendPushNotification(ctx context.Context, tokens []*Pus
     notification := &PushNotification{
         Title: title,
         Body:  body,
-        Data: map[string]string{
-            "type": message.Type,
-        },
+        Data:  payload.Data(),
     }
     
-    // Send to each token
+    // The push service sends to all of a user's devices, so each user once
+    notified := make(map[int64]bool)
     for _, token := range tokens {
+        if notified[token.UserID] {
+            continue
+        }
+        notified[token.UserID] = true
         if err := s.pushService.SendNotification(ctx, token.UserID, notification); err != nil {
             log.Printf("Failed to send push notification: %v", err)
         }
@@ -351,6 +824,196 @@ func (s *MessageService) UpdateOnlineStatus(ctx context.Context, userID int64, i
     return s.repo.UpdateUserOnlineStatus(ctx, userID, isOnline, lastSeen)
 }
 
+// ShowsOnlineStatus reports whether the user lets others see when they're
+// online
+func (s *MessageService) ShowsOnlineStatus(ctx context.Context, userID int64) (bool, error) {
+    user, err := s.users.Get(ctx, userID)
+    if err != nil {
+        return false, err
+    }
+    return !user.HidesPresence, nil
+}
+
+// getUserInfo returns how a user is shown in messaging. Users that are
+// missing or not active come back as ghosts.
+func (s *MessageService) getUserInfo(ctx context.Context, userID int64) (*UserInfo, error) {
+    found, err := s.users.GetMany(ctx, []int64{userID})
+    if err != nil {
+        return nil, err
+    }
+    user := found[userID]
+    return &UserInfo{
+        ID:             user.ID,
+        Username:       user.Username,
+        DisplayName:    user.DisplayName,
+        ProfilePicture: user.ProfilePicture,
+        IsOnline:       user.IsOnline,
+        LastSeen:       user.LastSeen,
+    }, nil
+}
+
+// StartVoiceUpload opens a chunked upload session for a voice note
+func (s *MessageService) StartVoiceUpload(ctx context.Context, userID int64, req *StartVoiceUploadRequest) (*VoiceUpload, error) {
+    if s.storageService == nil {
+        return nil, ErrStorageUnavailable
+    }
+    
+    if !s.IsUserInConversation(ctx, userID, req.ConversationID) {
+        return nil, ErrNotParticipant
+    }
+    
+    if err := s.checkOnboarding(ctx, userID); err != nil {
+        return nil, err
+    }
+    
+    if _, ok := voiceContentTypes[req.MimeType]; !ok {
+        return nil, ErrUnsupportedAudio
+    }
+    
+    return s.voiceUploads.create(userID, req.ConversationID, req.MimeType)
+}
+
+// UploadVoiceChunk stores one chunk of a voice note
+func (s *MessageService) UploadVoiceChunk(ctx context.Context, userID int64, uploadID string, index int, chunk io.Reader) (*VoiceUpload, error) {
+    upload, err := s.voiceUploads.get(uploadID, userID)
+    if err != nil {
+        return nil, err
+    }
+    
+    if err := s.voiceUploads.writeChunk(upload, index, chunk); err != nil {
+        return nil, err
+    }
+    
+    return upload, nil
+}
+
+// CompleteVoiceUpload assembles the chunks, extracts duration and waveform,
+// stores the audio privately and sends it as a voice message
+func (s *MessageService) CompleteVoiceUpload(ctx context.Context, userID int64, uploadID string, req *CompleteVoiceUploadRequest) (*Message, error) {
+    upload, err := s.voiceUploads.get(uploadID, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer s.voiceUploads.remove(upload)
+    
+    // Membership may have changed since the upload started
+    if !s.IsUserInConversation(ctx, userID, upload.ConversationID) {
+        return nil, ErrNotParticipant
+    }
+    
+    participants, _ := s.repo.GetConversationParticipants(ctx, upload.ConversationID)
+    for _, p := range participants {
+        if p.UserID != userID && s.IsBlocked(ctx, userID, p.UserID) {
+            return nil, ErrBlocked
+        }
+    }
+    
+    path, err := s.voiceUploads.assemble(upload, req.TotalChunks)
+    if err != nil {
+        return nil, err
+    }
+    
+    voiceMeta := VoiceMetadata{
+        MimeType: upload.MimeType,
+        Size:     upload.ReceivedBytes,
+        Waveform: make([]int, voiceWaveformBars),
+    }
+    
+    analysis, err := s.audioAnalyzer.Analyze(ctx, path, upload.MimeType)
+    if err != nil {
+        // Still deliver the note; clients fall back to reading duration on playback
+        log.Printf("Failed to analyze voice note %s: %v", upload.ID, err)
+    } else {
+        if time.Duration(analysis.DurationMs)*time.Millisecond > maxVoiceNoteDuration {
+            return nil, ErrVoiceNoteTooLong
+        }
+        voiceMeta.DurationMs = analysis.DurationMs
+        voiceMeta.Waveform = analysis.Waveform
+    }
+    
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+    
+    mediaURL, err := s.storageService.UploadPrivateMedia(ctx, f, "voice"+voiceContentTypes[upload.MimeType], upload.MimeType)
+    if err != nil {
+        return nil, err
+    }
+    
+    metadata, err := json.Marshal(map[string]interface{}{"voice": voiceMeta})
+    if err != nil {
+        return nil, err
+    }
+    
+    mediaSize := int(upload.ReceivedBytes)
+    mediaDuration := int(math.Ceil(float64(voiceMeta.DurationMs) / 1000))
+    
+    message := &Message{
+        ConversationID:  upload.ConversationID,
+        SenderID:        userID,
+        ParentMessageID: req.ParentMessageID,
+        MessageType:     "voice",
+        MediaURL:        &mediaURL,
+        MediaSize:       &mediaSize,
+        MediaDuration:   &mediaDuration,
+        Metadata:        metadata,
+        CreatedAt:       time.Now(),
+    }
+    
+    if err := s.repo.CreateMessage(ctx, message); err != nil {
+        s.storageService.DeleteMedia(ctx, mediaURL)
+        return nil, err
+    }
+    
+    s.repo.UpdateConversationLastMessage(ctx, upload.ConversationID, message.ID, ptr("Voice message"))
+    
+    for _, p := range participants {
+        if p.UserID != userID {
+            s.repo.IncrementUnreadCount(ctx, upload.ConversationID, p.UserID)
+        }
+    }
+    s.invalidateParticipants(ctx, participants)
+    
+    message.Sender, _ = s.getUserInfo(ctx, userID)
+    s.emitMessageSent(ctx, message)
+    
+    go s.sendMessageNotifications(ctx, message, participants)
+    
+    return message, nil
+}
+
+// GetPlaybackURL returns a signed URL for a voice message the user can access
+func (s *MessageService) GetPlaybackURL(ctx context.Context, userID, messageID int64) (*PlaybackURL, error) {
+    if s.storageService == nil {
+        return nil, ErrStorageUnavailable
+    }
+    
+    message, err := s.repo.GetMessage(ctx, messageID)
+    if err != nil {
+        return nil, ErrMessageNotFound
+    }
+    
+    if !s.IsUserInConversation(ctx, userID, message.ConversationID) {
+        return nil, ErrNotParticipant
+    }
+    
+    if message.IsDeleted || message.MediaURL == nil || *message.MediaURL == "" {
+        return nil, ErrMessageNotFound
+    }
+    
+    signedURL, err := s.storageService.GetSignedURL(ctx, *message.MediaURL, voicePlaybackURLExpiry)
+    if err != nil {
+        return nil, err
+    }
+    
+    return &PlaybackURL{
+        URL:       signedURL,
+        ExpiresAt: time.Now().Add(voicePlaybackURLExpiry),
+    }, nil
+}
+
 // Helper function
 func ptr(s string) *string {
     return &s
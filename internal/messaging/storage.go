@@ -3,26 +3,32 @@
 package messaging
 
 import (
-    "bytes"
     "context"
+    "errors"
     "fmt"
     "io"
-    "mime/multipart"
     "path/filepath"
     "strings"
     "time"
-    "net/http"
     
     "github.com/aws/aws-sdk-go/aws"
     "github.com/aws/aws-sdk-go/aws/session"
     "github.com/aws/aws-sdk-go/service/s3"
+    "github.com/aws/aws-sdk-go/service/s3/s3manager"
     "github.com/google/uuid"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
 )
 
+// ErrMediaTooLarge is returned when an upload goes over the storage limit
+var ErrMediaTooLarge = errors.New("file exceeds the maximum upload size")
+
 type StorageService interface {
     ProcessMedia(ctx context.Context, mediaURL string, messageType string) (*MediaInfo, error)
     UploadMedia(ctx context.Context, file io.Reader, filename string, contentType string) (string, error)
-    UploadMultipartFile(ctx context.Context, file multipart.File, header *multipart.FileHeader) (string, error)
+    UploadPrivateMedia(ctx context.Context, file io.Reader, filename string, contentType string) (string, error)
+    UploadExport(ctx context.Context, file io.ReadSeeker, filename string, contentType string) (string, error)
+    GetSignedURL(ctx context.Context, mediaURL string, expiry time.Duration) (string, error)
     DeleteMedia(ctx context.Context, mediaURL string) error
     GenerateThumbnail(ctx context.Context, mediaURL string) (string, error)
     GetMediaMetadata(ctx context.Context, mediaURL string) (*MediaMetadata, error)
@@ -46,6 +52,7 @@ type MediaMetadata struct {
 
 type storageService struct {
     s3Client     *s3.S3
+    uploader     *s3manager.Uploader
     bucketName   string
     cdnURL       string
     maxFileSize  int64
@@ -54,8 +61,14 @@ type storageService struct {
 
 // NewStorageService creates a new storage service
 func NewStorageService(awsSession *session.Session, bucketName, cdnURL string, maxFileSize int64) StorageService {
+    client := s3.New(awsSession)
     return &storageService{
-        s3Client:    s3.New(awsSession),
+        s3Client:    client,
+        // One part in flight at a time, so an upload holds a single part
+        // buffer however large the file is
+        uploader: s3manager.NewUploaderWithClient(client, func(u *s3manager.Uploader) {
+            u.Concurrency = 1
+        }),
         bucketName:  bucketName,
         cdnURL:      cdnURL,
         maxFileSize: maxFileSize,
@@ -63,6 +76,7 @@ func NewStorageService(awsSession *session.Session, bucketName, cdnURL string, m
             "image/jpeg", "image/png", "image/gif", "image/webp",
             "video/mp4", "video/quicktime", "video/webm",
             "audio/mpeg", "audio/wav", "audio/ogg",
+            "audio/mp4", "audio/x-m4a", "audio/aac", "audio/opus", "audio/webm", "audio/x-wav",
             "application/pdf", "application/zip",
         },
     }
@@ -100,6 +114,40 @@ func (s *storageService) ProcessMedia(ctx context.Context, mediaURL string, mess
 
 // UploadMedia uploads a file to S3
 func (s *storageService) UploadMedia(ctx context.Context, file io.Reader, filename string, contentType string) (string, error) {
+    return s.upload(ctx, file, filename, contentType, "messages", "public-read")
+}
+
+// UploadPrivateMedia uploads a file that is only reachable through signed URLs
+func (s *storageService) UploadPrivateMedia(ctx context.Context, file io.Reader, filename string, contentType string) (string, error) {
+    return s.upload(ctx, file, filename, contentType, "messages/private", "private")
+}
+
+// UploadExport stores a conversation export privately. Exports are generated
+// by the server, so they skip the media type and size checks.
+func (s *storageService) UploadExport(ctx context.Context, file io.ReadSeeker, filename string, contentType string) (string, error) {
+    key := fmt.Sprintf("exports/conversations/%s/%s-%s",
+        time.Now().Format("2006/01/02"),
+        uuid.New().String(),
+        filepath.Base(filename),
+    )
+    
+    _, err := s.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+        Bucket:               aws.String(s.bucketName),
+        Key:                  aws.String(key),
+        Body:                 file,
+        ContentType:          aws.String(contentType),
+        ContentDisposition:   aws.String(fmt.Sprintf(`attachment; filename="%s"`, filepath.Base(filename))),
+        ACL:                  aws.String("private"),
+        ServerSideEncryption: aws.String("AES256"),
+    })
+    if err != nil {
+        return "", fmt.Errorf("failed to upload export to S3: %v", err)
+    }
+    
+    return fmt.Sprintf("%s/%s", s.cdnURL, key), nil
+}
+
+func (s *storageService) upload(ctx context.Context, file io.Reader, filename, contentType, folder, acl string) (string, error) {
     // Validate content type
     if !s.isAllowedType(contentType) {
         return "", fmt.Errorf("file type %s not allowed", contentType)
@@ -107,39 +155,30 @@ func (s *storageService) UploadMedia(ctx context.Context, file io.Reader, filena
     
     // Generate unique key
     ext := filepath.Ext(filename)
-    key := fmt.Sprintf("messages/%s/%s%s", 
+    key := fmt.Sprintf("%s/%s/%s%s", 
+        folder,
         time.Now().Format("2006/01/02"),
         uuid.New().String(),
         ext,
     )
     
-    // Read file into buffer to check size
-    buf := new(bytes.Buffer)
-    size, err := io.Copy(buf, file)
-    if err != nil {
-        return "", fmt.Errorf("failed to read file: %v", err)
-    }
-    
-    // Check file size
-    if size > s.maxFileSize {
-        return "", fmt.Errorf("file size %d exceeds maximum allowed size %d", size, s.maxFileSize)
-    }
-    
-    // Upload to S3
-    _, err = s.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
-        Bucket:        aws.String(s.bucketName),
-        Key:           aws.String(key),
-        Body:          bytes.NewReader(buf.Bytes()),
-        ContentType:   aws.String(contentType),
-        ContentLength: aws.Int64(size),
-        ACL:           aws.String("public-read"),
+    // Stream to S3 in parts, aborting once the file goes over the limit
+    body := &cappedReader{r: file, remaining: s.maxFileSize}
+    _, err := s.uploader.UploadWithContext(ctx, &s3manager.UploadInput{
+        Bucket:      aws.String(s.bucketName),
+        Key:         aws.String(key),
+        Body:        body,
+        ContentType: aws.String(contentType),
+        ACL:         aws.String(acl),
         Metadata: map[string]*string{
             "uploaded-at": aws.String(time.Now().Format(time.RFC3339)),
             "file-name":   aws.String(filename),
         },
     })
-    
     if err != nil {
+        if errors.Is(body.err, ErrMediaTooLarge) || utils.IsTooLarge(body.err) {
+            return "", ErrMediaTooLarge
+        }
         return "", fmt.Errorf("failed to upload to S3: %v", err)
     }
     
@@ -147,24 +186,6 @@ func (s *storageService) UploadMedia(ctx context.Context, file io.Reader, filena
     return fmt.Sprintf("%s/%s", s.cdnURL, key), nil
 }
 
-// UploadMultipartFile handles multipart file upload
-func (s *storageService) UploadMultipartFile(ctx context.Context, file multipart.File, header *multipart.FileHeader) (string, error) {
-    defer file.Close()
-    
-    // Detect content type
-    buffer := make([]byte, 512)
-    _, err := file.Read(buffer)
-    if err != nil {
-        return "", err
-    }
-    contentType := http.DetectContentType(buffer)
-    
-    // Reset file reader
-    file.Seek(0, 0)
-    
-    return s.UploadMedia(ctx, file, header.Filename, contentType)
-}
-
 // DeleteMedia deletes media from S3
 func (s *storageService) DeleteMedia(ctx context.Context, mediaURL string) error {
     // Extract key from URL
@@ -178,6 +199,24 @@ func (s *storageService) DeleteMedia(ctx context.Context, mediaURL string) error
     return err
 }
 
+// GetSignedURL returns a time-limited URL for reading a private object
+func (s *storageService) GetSignedURL(ctx context.Context, mediaURL string, expiry time.Duration) (string, error) {
+    key := strings.TrimPrefix(mediaURL, s.cdnURL+"/")
+    
+    req, _ := s.s3Client.GetObjectRequest(&s3.GetObjectInput{
+        Bucket: aws.String(s.bucketName),
+        Key:    aws.String(key),
+    })
+    req.SetContext(ctx)
+    
+    signedURL, err := req.Presign(expiry)
+    if err != nil {
+        return "", fmt.Errorf("failed to sign URL: %v", err)
+    }
+    
+    return signedURL, nil
+}
+
 // GenerateThumbnail generates a thumbnail for images/videos
 func (s *storageService) GenerateThumbnail(ctx context.Context, mediaURL string) (string, error) {
     // This would typically use an image processing service like AWS Lambda
@@ -214,6 +253,26 @@ func (s *storageService) GetMediaMetadata(ctx context.Context, mediaURL string)
     }, nil
 }
 
+// cappedReader fails with ErrMediaTooLarge once more than remaining bytes
+// are read, and keeps the read error since the uploader hides it
+type cappedReader struct {
+    r         io.Reader
+    remaining int64
+    err       error
+}
+
+func (c *cappedReader) Read(p []byte) (int, error) {
+    n, err := c.r.Read(p)
+    c.remaining -= int64(n)
+    if c.remaining < 0 {
+        err = ErrMediaTooLarge
+    }
+    if err != nil && err != io.EOF {
+        c.err = err
+    }
+    return n, err
+}
+
 // Helper methods
 func (s *storageService) isAllowedType(contentType string) bool {
     for _, allowed := range s.allowedTypes {
@@ -236,7 +295,7 @@ func (s *storageService) getMimeType(messageType, filename string) string {
         return "image/jpeg"
     case "video":
         return "video/mp4"
-    case "audio":
+    case "audio", "voice":
         return "audio/mpeg"
     case "file":
         return "application/octet-stream"
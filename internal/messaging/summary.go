@@ -0,0 +1,282 @@
+// internal/messaging/summary.go
+
+package messaging
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "sort"
+    "strings"
+    "sync"
+    "time"
+    "unicode"
+)
+
+const (
+    // MinUnreadForSummary is the unread backlog at which a recap is offered
+    MinUnreadForSummary = 50
+
+    // maxSummaryMessages caps how many unread messages are fed to the summarizer
+    maxSummaryMessages = 500
+
+    maxSummaryTopics    = 5
+    maxSummaryQuestions = 10
+    maxSummaryCacheSize = 10000
+)
+
+var (
+    ErrSummariesDisabled = errors.New("conversation summaries are disabled")
+    ErrSummaryNotNeeded  = errors.New("not enough unread messages to summarize")
+)
+
+// SummaryParticipant is someone who posted in the unread backlog
+type SummaryParticipant struct {
+    UserID       int64  `json:"user_id"`
+    Username     string `json:"username"`
+    DisplayName  string `json:"display_name"`
+    MessageCount int    `json:"message_count"`
+}
+
+// SummaryQuestion is an unread question that appears to be aimed at the viewer
+type SummaryQuestion struct {
+    MessageID int64     `json:"message_id"`
+    SenderID  int64     `json:"sender_id"`
+    Text      string    `json:"text"`
+    CreatedAt time.Time `json:"created_at"`
+}
+
+// ConversationSummary is a short recap of a user's unread messages
+type ConversationSummary struct {
+    ConversationID int64                 `json:"conversation_id"`
+    UnreadCount    int                   `json:"unread_count"`
+    FromMessageID  int64                 `json:"from_message_id"`
+    LastMessageID  int64                 `json:"last_message_id"`
+    Recap          string                `json:"recap"`
+    Participants   []*SummaryParticipant `json:"participants"`
+    Topics         []string              `json:"topics"`
+    Questions      []*SummaryQuestion    `json:"questions"`
+    GeneratedAt    time.Time             `json:"generated_at"`
+}
+
+// SummaryInput is what a summarizer works from. Messages are oldest first.
+type SummaryInput struct {
+    ConversationID   int64
+    ConversationType string
+    Viewer           *UserInfo
+    Participants     []*Participant
+    Messages         []*Message
+}
+
+// Summarizer produces a recap for a backlog of messages. The default
+// implementation is heuristic; a model-backed one can be plugged in with
+// SetSummarizer.
+type Summarizer interface {
+    Summarize(ctx context.Context, input *SummaryInput) (*ConversationSummary, error)
+}
+
+// heuristicSummarizer builds recaps from message counts, word frequency and
+// question marks, without any external service
+type heuristicSummarizer struct{}
+
+// NewHeuristicSummarizer creates the default summarizer
+func NewHeuristicSummarizer() Summarizer {
+    return &heuristicSummarizer{}
+}
+
+func (h *heuristicSummarizer) Summarize(ctx context.Context, input *SummaryInput) (*ConversationSummary, error) {
+    summary := &ConversationSummary{
+        ConversationID: input.ConversationID,
+        Participants:   []*SummaryParticipant{},
+        Topics:         []string{},
+        Questions:      []*SummaryQuestion{},
+    }
+
+    users := make(map[int64]*UserInfo)
+    for _, p := range input.Participants {
+        if p.User != nil {
+            users[p.UserID] = p.User
+        }
+    }
+
+    direct := input.ConversationType == "direct"
+    counts := make(map[int64]*SummaryParticipant)
+    words := make(map[string]int)
+
+    for _, msg := range input.Messages {
+        sender, ok := counts[msg.SenderID]
+        if !ok {
+            sender = &SummaryParticipant{UserID: msg.SenderID}
+            if u := users[msg.SenderID]; u != nil {
+                sender.Username = u.Username
+                sender.DisplayName = u.DisplayName
+            } else if msg.Sender != nil {
+                sender.Username = msg.Sender.Username
+                sender.DisplayName = msg.Sender.DisplayName
+            }
+            counts[msg.SenderID] = sender
+            summary.Participants = append(summary.Participants, sender)
+        }
+        sender.MessageCount++
+
+        if msg.Content == nil || msg.MessageType != "text" {
+            continue
+        }
+        text := *msg.Content
+
+        for _, word := range tokenize(text) {
+            words[word]++
+        }
+
+        if msg.SenderID != input.Viewer.ID && len(summary.Questions) < maxSummaryQuestions &&
+            strings.Contains(text, "?") && (direct || addressesUser(text, input.Viewer)) {
+            summary.Questions = append(summary.Questions, &SummaryQuestion{
+                MessageID: msg.ID,
+                SenderID:  msg.SenderID,
+                Text:      text,
+                CreatedAt: msg.CreatedAt,
+            })
+        }
+    }
+
+    sort.SliceStable(summary.Participants, func(i, j int) bool {
+        return summary.Participants[i].MessageCount > summary.Participants[j].MessageCount
+    })
+
+    summary.Topics = topWords(words, maxSummaryTopics)
+    summary.Recap = buildRecap(len(input.Messages), summary)
+
+    return summary, nil
+}
+
+// addressesUser reports whether text mentions the user by @username or display name
+func addressesUser(text string, user *UserInfo) bool {
+    lower := strings.ToLower(text)
+    if user.Username != "" && strings.Contains(lower, "@"+strings.ToLower(user.Username)) {
+        return true
+    }
+    return user.DisplayName != "" && strings.Contains(lower, strings.ToLower(user.DisplayName))
+}
+
+var summaryStopWords = map[string]bool{
+    "about": true, "after": true, "again": true, "also": true, "been": true,
+    "before": true, "being": true, "could": true, "does": true, "doing": true,
+    "dont": true, "from": true, "going": true, "gonna": true, "have": true,
+    "just": true, "know": true, "like": true, "lol": true, "make": true,
+    "more": true, "much": true, "only": true, "really": true, "said": true,
+    "should": true, "some": true, "that": true, "thats": true, "their": true,
+    "them": true, "then": true, "there": true, "they": true, "thing": true,
+    "think": true, "this": true, "want": true, "well": true, "were": true,
+    "what": true, "when": true, "where": true, "which": true, "will": true,
+    "with": true, "would": true, "yeah": true, "your": true, "youre": true,
+}
+
+// tokenize returns lowercase words worth counting as topics
+func tokenize(text string) []string {
+    fields := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+        return !unicode.IsLetter(r) && r != '\'' && r != '#'
+    })
+
+    words := make([]string, 0, len(fields))
+    for _, f := range fields {
+        f = strings.TrimLeft(strings.ReplaceAll(f, "'", ""), "#")
+        if len([]rune(f)) < 4 || summaryStopWords[f] {
+            continue
+        }
+        words = append(words, f)
+    }
+    return words
+}
+
+// topWords returns the most frequent words seen at least twice
+func topWords(counts map[string]int, limit int) []string {
+    words := make([]string, 0, len(counts))
+    for word, n := range counts {
+        if n >= 2 {
+            words = append(words, word)
+        }
+    }
+
+    sort.Slice(words, func(i, j int) bool {
+        if counts[words[i]] != counts[words[j]] {
+            return counts[words[i]] > counts[words[j]]
+        }
+        return words[i] < words[j]
+    })
+
+    if len(words) > limit {
+        words = words[:limit]
+    }
+    return words
+}
+
+func buildRecap(messageCount int, summary *ConversationSummary) string {
+    names := make([]string, 0, 3)
+    for _, p := range summary.Participants {
+        if len(names) == 3 {
+            break
+        }
+        name := p.DisplayName
+        if name == "" {
+            name = p.Username
+        }
+        names = append(names, name)
+    }
+
+    recap := fmt.Sprintf("%d new messages", messageCount)
+    if len(names) > 0 {
+        recap += " from " + strings.Join(names, ", ")
+        if extra := len(summary.Participants) - len(names); extra > 0 {
+            recap += fmt.Sprintf(" and %d others", extra)
+        }
+    }
+    recap += "."
+
+    if len(summary.Topics) > 0 {
+        recap += " Mostly about " + strings.Join(summary.Topics, ", ") + "."
+    }
+    if n := len(summary.Questions); n == 1 {
+        recap += " 1 question for you."
+    } else if n > 1 {
+        recap += fmt.Sprintf(" %d questions for you.", n)
+    }
+
+    return recap
+}
+
+// summaryCache keeps the latest summary per user and conversation. An entry is
+// only reused while the conversation's last message is unchanged.
+type summaryCache struct {
+    mu      sync.Mutex
+    entries map[summaryKey]*ConversationSummary
+}
+
+type summaryKey struct {
+    conversationID int64
+    userID         int64
+}
+
+func newSummaryCache() *summaryCache {
+    return &summaryCache{entries: make(map[summaryKey]*ConversationSummary)}
+}
+
+func (c *summaryCache) get(conversationID, userID, lastMessageID int64) *ConversationSummary {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    summary, ok := c.entries[summaryKey{conversationID, userID}]
+    if !ok || summary.LastMessageID != lastMessageID {
+        return nil
+    }
+    return summary
+}
+
+func (c *summaryCache) set(userID int64, summary *ConversationSummary) {
+    c.mu.Lock()
+    defer c.mu.Unlock()
+
+    if len(c.entries) >= maxSummaryCacheSize {
+        c.entries = make(map[summaryKey]*ConversationSummary)
+    }
+    c.entries[summaryKey{summary.ConversationID, userID}] = summary
+}
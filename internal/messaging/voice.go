@@ -0,0 +1,461 @@
+// internal/messaging/voice.go
+
+package messaging
+
+import (
+    "bufio"
+    "context"
+    "encoding/binary"
+    "errors"
+    "fmt"
+    "io"
+    "math"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "sort"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// Voice note limits
+const (
+    // Size of each chunk the client is expected to send
+    voiceChunkSize = 256 * 1024 // 256KB
+
+    // Maximum size of an assembled voice note
+    maxVoiceNoteSize = 16 * 1024 * 1024 // 16MB
+
+    // Maximum length of a voice note
+    maxVoiceNoteDuration = 15 * time.Minute
+
+    // How long an unfinished upload session is kept around at most
+    voiceUploadTTL = 30 * time.Minute
+
+    // How long an upload session is kept without receiving a chunk
+    voiceUploadIdleTTL = 5 * time.Minute
+
+    // Unfinished upload sessions a user can have open at once. Each can
+    // spool up to maxVoiceNoteSize to local disk.
+    maxVoiceUploadsPerUser = 3
+
+    // Number of bars in the waveform preview
+    voiceWaveformBars = 64
+
+    // Lifetime of a signed playback URL
+    voicePlaybackURLExpiry = 15 * time.Minute
+)
+
+var (
+    ErrVoiceUploadNotFound   = errors.New("voice upload not found or expired")
+    ErrVoiceUploadIncomplete = errors.New("voice upload is missing chunks")
+    ErrTooManyVoiceUploads   = errors.New("too many voice uploads in progress")
+    ErrVoiceNoteTooLarge     = errors.New("voice note exceeds maximum size")
+    ErrVoiceNoteTooLong      = errors.New("voice note exceeds maximum duration")
+    ErrUnsupportedAudio      = errors.New("unsupported audio format")
+    ErrStorageUnavailable    = errors.New("media storage is not configured")
+)
+
+// Audio content types accepted for voice notes, mapped to file extensions
+var voiceContentTypes = map[string]string{
+    "audio/mpeg":  ".mp3",
+    "audio/mp4":   ".m4a",
+    "audio/x-m4a": ".m4a",
+    "audio/aac":   ".aac",
+    "audio/ogg":   ".ogg",
+    "audio/opus":  ".opus",
+    "audio/webm":  ".webm",
+    "audio/wav":   ".wav",
+    "audio/x-wav": ".wav",
+}
+
+// VoiceUpload tracks an in-progress chunked voice note upload
+type VoiceUpload struct {
+    ID             string    `json:"upload_id"`
+    UserID         int64     `json:"-"`
+    ConversationID int64     `json:"conversation_id"`
+    MimeType       string    `json:"mime_type"`
+    ChunkSize      int       `json:"chunk_size"`
+    ReceivedChunks int       `json:"received_chunks"`
+    ReceivedBytes  int64     `json:"received_bytes"`
+    ExpiresAt      time.Time `json:"expires_at"` // Moves forward with each chunk, up to deadline
+
+    dir      string
+    chunks   map[int]int64
+    deadline time.Time
+}
+
+// voiceUploadStore keeps upload sessions in memory and spools chunks to
+// local disk, so it only works on a single instance: every request of an
+// upload must reach the process that started it. Running more than one
+// instance needs sticky routing on the upload ID or a shared store.
+type voiceUploadStore struct {
+    mu      sync.Mutex
+    uploads map[string]*VoiceUpload
+    baseDir string
+}
+
+func newVoiceUploadStore() *voiceUploadStore {
+    return &voiceUploadStore{
+        uploads: make(map[string]*VoiceUpload),
+        baseDir: filepath.Join(os.TempDir(), "kiekky-voice"),
+    }
+}
+
+func (s *voiceUploadStore) create(userID, conversationID int64, mimeType string) (*VoiceUpload, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.purgeExpiredLocked()
+
+    open := 0
+    for _, upload := range s.uploads {
+        if upload.UserID == userID {
+            open++
+        }
+    }
+    if open >= maxVoiceUploadsPerUser {
+        return nil, ErrTooManyVoiceUploads
+    }
+
+    now := time.Now()
+    upload := &VoiceUpload{
+        ID:             uuid.New().String(),
+        UserID:         userID,
+        ConversationID: conversationID,
+        MimeType:       mimeType,
+        ChunkSize:      voiceChunkSize,
+        ExpiresAt:      now.Add(voiceUploadIdleTTL),
+        chunks:         make(map[int]int64),
+        deadline:       now.Add(voiceUploadTTL),
+    }
+    upload.dir = filepath.Join(s.baseDir, upload.ID)
+
+    if err := os.MkdirAll(upload.dir, 0700); err != nil {
+        return nil, fmt.Errorf("failed to create upload directory: %v", err)
+    }
+
+    s.uploads[upload.ID] = upload
+    return upload, nil
+}
+
+// get returns the upload if it exists, belongs to the user and hasn't expired
+func (s *voiceUploadStore) get(uploadID string, userID int64) (*VoiceUpload, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    s.purgeExpiredLocked()
+
+    upload, ok := s.uploads[uploadID]
+    if !ok || upload.UserID != userID {
+        return nil, ErrVoiceUploadNotFound
+    }
+    return upload, nil
+}
+
+func (s *voiceUploadStore) writeChunk(upload *VoiceUpload, index int, data io.Reader) error {
+    if index < 0 {
+        return fmt.Errorf("invalid chunk index %d", index)
+    }
+
+    path := filepath.Join(upload.dir, fmt.Sprintf("%06d.part", index))
+    f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+    if err != nil {
+        return fmt.Errorf("failed to store chunk: %v", err)
+    }
+
+    // Read at most one byte over the chunk size so oversized chunks are rejected
+    n, err := io.Copy(f, io.LimitReader(data, voiceChunkSize+1))
+    f.Close()
+    if err != nil {
+        os.Remove(path)
+        return fmt.Errorf("failed to store chunk: %v", err)
+    }
+    if n > voiceChunkSize {
+        os.Remove(path)
+        return fmt.Errorf("chunk exceeds %d bytes", voiceChunkSize)
+    }
+
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    previous := upload.chunks[index]
+    if upload.ReceivedBytes-previous+n > maxVoiceNoteSize {
+        os.Remove(path)
+        delete(upload.chunks, index)
+        upload.ReceivedBytes -= previous
+        upload.ReceivedChunks = len(upload.chunks)
+        return ErrVoiceNoteTooLarge
+    }
+
+    upload.chunks[index] = n
+    upload.ReceivedBytes += n - previous
+    upload.ReceivedChunks = len(upload.chunks)
+
+    upload.ExpiresAt = time.Now().Add(voiceUploadIdleTTL)
+    if upload.ExpiresAt.After(upload.deadline) {
+        upload.ExpiresAt = upload.deadline
+    }
+    return nil
+}
+
+// assemble concatenates the chunks in order into a single file
+func (s *voiceUploadStore) assemble(upload *VoiceUpload, totalChunks int) (string, error) {
+    s.mu.Lock()
+    indexes := make([]int, 0, len(upload.chunks))
+    for idx := range upload.chunks {
+        indexes = append(indexes, idx)
+    }
+    s.mu.Unlock()
+
+    sort.Ints(indexes)
+    if len(indexes) != totalChunks || (totalChunks > 0 && indexes[totalChunks-1] != totalChunks-1) {
+        return "", ErrVoiceUploadIncomplete
+    }
+
+    ext := voiceContentTypes[upload.MimeType]
+    outPath := filepath.Join(upload.dir, "voice"+ext)
+    out, err := os.Create(outPath)
+    if err != nil {
+        return "", fmt.Errorf("failed to assemble voice note: %v", err)
+    }
+    defer out.Close()
+
+    for _, idx := range indexes {
+        part, err := os.Open(filepath.Join(upload.dir, fmt.Sprintf("%06d.part", idx)))
+        if err != nil {
+            return "", fmt.Errorf("failed to assemble voice note: %v", err)
+        }
+        _, err = io.Copy(out, part)
+        part.Close()
+        if err != nil {
+            return "", fmt.Errorf("failed to assemble voice note: %v", err)
+        }
+    }
+
+    return outPath, nil
+}
+
+func (s *voiceUploadStore) remove(upload *VoiceUpload) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.removeLocked(upload)
+}
+
+func (s *voiceUploadStore) removeLocked(upload *VoiceUpload) {
+    delete(s.uploads, upload.ID)
+    os.RemoveAll(upload.dir)
+}
+
+// purgeExpiredLocked drops sessions that went idle or ran out of time,
+// along with their chunks on disk
+func (s *voiceUploadStore) purgeExpiredLocked() {
+    now := time.Now()
+    for _, upload := range s.uploads {
+        if now.After(upload.ExpiresAt) {
+            s.removeLocked(upload)
+        }
+    }
+}
+
+// AudioAnalysis holds the values extracted from a voice note
+type AudioAnalysis struct {
+    DurationMs int
+    Waveform   []int
+}
+
+// AudioAnalyzer extracts duration and waveform data from an audio file
+type AudioAnalyzer interface {
+    Analyze(ctx context.Context, path string, mimeType string) (*AudioAnalysis, error)
+}
+
+// audioAnalyzer decodes audio with ffmpeg when it is installed and falls
+// back to reading PCM WAV files directly
+type audioAnalyzer struct {
+    ffmpegPath string
+}
+
+// Sample rate used when decoding with ffmpeg; plenty for a waveform preview
+const analysisSampleRate = 8000
+
+// NewAudioAnalyzer creates the default audio analyzer
+func NewAudioAnalyzer() AudioAnalyzer {
+    path, _ := exec.LookPath("ffmpeg")
+    return &audioAnalyzer{ffmpegPath: path}
+}
+
+func (a *audioAnalyzer) Analyze(ctx context.Context, path string, mimeType string) (*AudioAnalysis, error) {
+    if a.ffmpegPath != "" {
+        return a.analyzeWithFFmpeg(ctx, path)
+    }
+
+    if mimeType == "audio/wav" || mimeType == "audio/x-wav" {
+        return analyzeWAV(path)
+    }
+
+    return nil, ErrUnsupportedAudio
+}
+
+// analyzeWithFFmpeg decodes to mono 16-bit PCM and reads the samples from stdout
+func (a *audioAnalyzer) analyzeWithFFmpeg(ctx context.Context, path string) (*AudioAnalysis, error) {
+    cmd := exec.CommandContext(ctx, a.ffmpegPath,
+        "-v", "error",
+        "-i", path,
+        "-ac", "1",
+        "-ar", fmt.Sprintf("%d", analysisSampleRate),
+        "-f", "s16le",
+        "-",
+    )
+
+    stdout, err := cmd.StdoutPipe()
+    if err != nil {
+        return nil, err
+    }
+    if err := cmd.Start(); err != nil {
+        return nil, fmt.Errorf("failed to start ffmpeg: %v", err)
+    }
+
+    samples, readErr := readPCM16(bufio.NewReader(stdout), 1)
+    if err := cmd.Wait(); err != nil {
+        return nil, fmt.Errorf("failed to decode audio: %v", err)
+    }
+    if readErr != nil {
+        return nil, readErr
+    }
+
+    return &AudioAnalysis{
+        DurationMs: len(samples) * 1000 / analysisSampleRate,
+        Waveform:   buildWaveform(samples, voiceWaveformBars),
+    }, nil
+}
+
+// analyzeWAV reads a canonical PCM WAV file without external tools
+func analyzeWAV(path string) (*AudioAnalysis, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return nil, err
+    }
+    defer f.Close()
+
+    r := bufio.NewReader(f)
+
+    var riff [12]byte
+    if _, err := io.ReadFull(r, riff[:]); err != nil {
+        return nil, ErrUnsupportedAudio
+    }
+    if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+        return nil, ErrUnsupportedAudio
+    }
+
+    var (
+        audioFormat   uint16
+        channels      uint16
+        sampleRate    uint32
+        bitsPerSample uint16
+    )
+
+    // Walk chunks until the data chunk
+    for {
+        var header [8]byte
+        if _, err := io.ReadFull(r, header[:]); err != nil {
+            return nil, ErrUnsupportedAudio
+        }
+        id := string(header[0:4])
+        size := binary.LittleEndian.Uint32(header[4:8])
+
+        switch id {
+        case "fmt ":
+            // The size comes from the upload; real fmt chunks are 16, 18 or
+            // 40 bytes, so refuse anything that would make us allocate more
+            if size < 16 || size > 64 {
+                return nil, ErrUnsupportedAudio
+            }
+            body := make([]byte, size+size%2)
+            if _, err := io.ReadFull(r, body); err != nil {
+                return nil, ErrUnsupportedAudio
+            }
+            audioFormat = binary.LittleEndian.Uint16(body[0:2])
+            channels = binary.LittleEndian.Uint16(body[2:4])
+            sampleRate = binary.LittleEndian.Uint32(body[4:8])
+            bitsPerSample = binary.LittleEndian.Uint16(body[14:16])
+        case "data":
+            if audioFormat != 1 || bitsPerSample != 16 || channels == 0 || sampleRate == 0 {
+                return nil, ErrUnsupportedAudio
+            }
+            samples, err := readPCM16(io.LimitReader(r, int64(size)), int(channels))
+            if err != nil {
+                return nil, err
+            }
+            return &AudioAnalysis{
+                DurationMs: int(int64(len(samples)) * 1000 / int64(sampleRate)),
+                Waveform:   buildWaveform(samples, voiceWaveformBars),
+            }, nil
+        default:
+            if _, err := io.CopyN(io.Discard, r, int64(size)+int64(size%2)); err != nil {
+                return nil, ErrUnsupportedAudio
+            }
+        }
+    }
+}
+
+// readPCM16 reads little-endian 16-bit samples, keeping the first channel
+func readPCM16(r io.Reader, channels int) ([]int16, error) {
+    frame := make([]byte, 2*channels)
+    var samples []int16
+    for {
+        _, err := io.ReadFull(r, frame)
+        if err == io.EOF || err == io.ErrUnexpectedEOF {
+            return samples, nil
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to read audio samples: %v", err)
+        }
+        samples = append(samples, int16(binary.LittleEndian.Uint16(frame[0:2])))
+    }
+}
+
+// buildWaveform reduces samples to a fixed number of RMS bars scaled to 0-100
+func buildWaveform(samples []int16, bars int) []int {
+    waveform := make([]int, bars)
+    if len(samples) == 0 {
+        return waveform
+    }
+
+    bucketSize := len(samples) / bars
+    if bucketSize == 0 {
+        bucketSize = 1
+    }
+
+    levels := make([]float64, bars)
+    peak := 0.0
+    for i := 0; i < bars; i++ {
+        start := i * bucketSize
+        if start >= len(samples) {
+            break
+        }
+        end := start + bucketSize
+        if i == bars-1 || end > len(samples) {
+            end = len(samples)
+        }
+
+        var sum float64
+        for _, s := range samples[start:end] {
+            v := float64(s)
+            sum += v * v
+        }
+        levels[i] = math.Sqrt(sum / float64(end-start))
+        if levels[i] > peak {
+            peak = levels[i]
+        }
+    }
+
+    if peak == 0 {
+        return waveform
+    }
+    for i, level := range levels {
+        waveform[i] = int(math.Round(level / peak * 100))
+    }
+    return waveform
+}
@@ -0,0 +1,83 @@
+package messaging
+
+import (
+    "os"
+    "strings"
+    "testing"
+    "time"
+)
+
+func newTestVoiceUploadStore(t *testing.T) *voiceUploadStore {
+    s := newVoiceUploadStore()
+    s.baseDir = t.TempDir()
+    return s
+}
+
+func TestVoiceUploadsPerUserCap(t *testing.T) {
+    s := newTestVoiceUploadStore(t)
+
+    var uploads []*VoiceUpload
+    for i := 0; i < maxVoiceUploadsPerUser; i++ {
+        upload, err := s.create(1, 10, "audio/ogg")
+        if err != nil {
+            t.Fatalf("upload %d: %v", i, err)
+        }
+        uploads = append(uploads, upload)
+    }
+
+    if _, err := s.create(1, 10, "audio/ogg"); err != ErrTooManyVoiceUploads {
+        t.Fatalf("upload past the cap: error = %v, want ErrTooManyVoiceUploads", err)
+    }
+    if _, err := s.create(2, 10, "audio/ogg"); err != nil {
+        t.Fatalf("another user's upload: %v", err)
+    }
+
+    // Finishing one frees a slot
+    s.remove(uploads[0])
+    if _, err := s.create(1, 10, "audio/ogg"); err != nil {
+        t.Fatalf("upload after one finished: %v", err)
+    }
+}
+
+func TestVoiceUploadIdleExpiry(t *testing.T) {
+    s := newTestVoiceUploadStore(t)
+
+    idle, err := s.create(1, 10, "audio/ogg")
+    if err != nil {
+        t.Fatal(err)
+    }
+    active, err := s.create(1, 10, "audio/ogg")
+    if err != nil {
+        t.Fatal(err)
+    }
+
+    // A chunk keeps a session alive; sessions without one go idle
+    past := time.Now().Add(-time.Second)
+    idle.ExpiresAt = past
+    active.ExpiresAt = past
+    if err := s.writeChunk(active, 0, strings.NewReader("chunk")); err != nil {
+        t.Fatal(err)
+    }
+    if !active.ExpiresAt.After(time.Now()) {
+        t.Errorf("chunk didn't extend the session")
+    }
+
+    if _, err := s.get(idle.ID, 1); err != ErrVoiceUploadNotFound {
+        t.Errorf("idle upload: error = %v, want ErrVoiceUploadNotFound", err)
+    }
+    if _, err := os.Stat(idle.dir); !os.IsNotExist(err) {
+        t.Errorf("idle upload's chunks were left on disk")
+    }
+    if _, err := s.get(active.ID, 1); err != nil {
+        t.Errorf("active upload: %v", err)
+    }
+
+    // Chunks never extend a session past its deadline
+    active.deadline = time.Now().Add(time.Minute)
+    if err := s.writeChunk(active, 1, strings.NewReader("chunk")); err != nil {
+        t.Fatal(err)
+    }
+    if !active.ExpiresAt.Equal(active.deadline) {
+        t.Errorf("expires at %v, want the deadline %v", active.ExpiresAt, active.deadline)
+    }
+}
@@ -14,19 +14,61 @@ const (
     // Time allowed to write a message to the peer
     writeWait = 10 * time.Second
     
-    // Time allowed to read the next pong message from the peer
-    pongWait = 60 * time.Second
+    // Server heartbeat: a ping every heartbeatInterval. A client that leaves
+    // maxMissedPongs pings in a row unanswered is disconnected.
+    heartbeatInterval = 25 * time.Second
+    maxMissedPongs    = 2
+    
+    // Time allowed to read the next frame or pong from the peer
+    pongWait = heartbeatInterval * (maxMissedPongs + 1)
     
     // Send pings to peer with this period (must be less than pongWait)
-    pingPeriod = (pongWait * 9) / 10
+    pingPeriod = heartbeatInterval
     
     // Maximum message size allowed from peer
     maxMessageSize = 512 * 1024 // 512KB
     
     // Maximum number of queued messages per client
     maxQueuedMessages = 256
+
+    // How long a client's queue may stay full, with frames being dropped,
+    // before the connection is closed
+    slowClientTimeout = 10 * time.Second
+
+    // Number of independently locked client maps in the hub
+    hubShards = 64
+
+    // Buffer for the hub's register, unregister and broadcast channels, so
+    // connection bursts don't block handlers on the run loop
+    hubQueueSize = 4096
+
+    // Maximum number of conversations one connection may subscribe to
+    maxSubscriptions = 200
+    
+    // Maximum length of a client-generated idempotency key
+    maxClientMessageIDLength = 64
 )
 
+// WSAck acknowledges a client frame. Message frames are always acked; other
+// frames only when they carry an id.
+type WSAck struct {
+    ID              string  `json:"id,omitempty"`
+    ClientMessageID string  `json:"client_message_id,omitempty"`
+    MessageID       int64   `json:"message_id,omitempty"`
+    Duplicate       bool    `json:"duplicate,omitempty"` // The idempotency key was already used; MessageID is the original
+    ConversationIDs []int64 `json:"conversation_ids,omitempty"`
+    Rejected        []int64 `json:"rejected,omitempty"` // Conversations the user isn't in
+}
+
+// WSNack rejects a client frame. Clients may retry a message frame with the
+// same client_message_id without risk of sending it twice.
+type WSNack struct {
+    ID              string `json:"id,omitempty"`
+    ClientMessageID string `json:"client_message_id,omitempty"`
+    Code            string `json:"code"`
+    Message         string `json:"message"`
+}
+
 // WSError represents a WebSocket error message
 type WSError struct {
     Code    string `json:"code"`
@@ -0,0 +1,196 @@
+// internal/moderation/handlers.go
+
+package moderation
+
+import (
+    "errors"
+    "io"
+    "log"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// GetUpload handles GET /moderation/uploads/{id}, letting a user follow a held upload
+func (h *Handler) GetUpload(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    itemID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid moderation item ID")
+        return
+    }
+
+    item, err := h.service.GetUserItem(r.Context(), userID, itemID)
+    if err != nil {
+        if errors.Is(err, ErrItemNotFound) {
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get upload status")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, item)
+}
+
+// ListItems handles GET /admin/moderation?status=&limit=&offset=
+func (h *Handler) ListItems(w http.ResponseWriter, r *http.Request) {
+    status := Status(r.URL.Query().Get("status"))
+    if status == "" {
+        status = StatusPending
+    }
+
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    if limit <= 0 || limit > 100 {
+        limit = 20
+    }
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+    if offset < 0 {
+        offset = 0
+    }
+
+    items, err := h.service.ListItems(r.Context(), status, limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get moderation queue")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, items)
+}
+
+// GetUserViolations handles GET /admin/moderation/users/{id}/violations
+func (h *Handler) GetUserViolations(w http.ResponseWriter, r *http.Request) {
+    userID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+        return
+    }
+
+    violations, err := h.service.GetUserViolations(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get violations")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, violations)
+}
+
+// GetMedia handles GET /admin/moderation/{id}/media
+func (h *Handler) GetMedia(w http.ResponseWriter, r *http.Request) {
+    itemID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid moderation item ID")
+        return
+    }
+
+    media, item, err := h.service.OpenMedia(r.Context(), itemID)
+    if err != nil {
+        h.respondReviewError(w, err)
+        return
+    }
+    defer media.Close()
+
+    w.Header().Set("Content-Type", item.ContentType)
+    w.Header().Set("Cache-Control", "no-store")
+    if _, err := io.Copy(w, media); err != nil {
+        log.Printf("Failed to stream media for moderation item %d: %v", itemID, err)
+    }
+}
+
+// Approve handles POST /admin/moderation/{id}/approve
+func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
+    adminID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    itemID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid moderation item ID")
+        return
+    }
+
+    item, err := h.service.Approve(r.Context(), itemID, adminID)
+    if err != nil {
+        h.respondReviewError(w, err)
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, item)
+}
+
+// Reject handles POST /admin/moderation/{id}/reject
+func (h *Handler) Reject(w http.ResponseWriter, r *http.Request) {
+    adminID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    itemID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid moderation item ID")
+        return
+    }
+
+    var req RejectRequest
+    if r.ContentLength > 0 && !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    item, err := h.service.Reject(r.Context(), itemID, adminID, req.Reason)
+    if err != nil {
+        h.respondReviewError(w, err)
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, item)
+}
+
+func (h *Handler) respondReviewError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, ErrItemNotFound):
+        utils.RespondWithError(w, http.StatusNotFound, err.Error())
+    case errors.Is(err, ErrNotPending):
+        utils.RespondWithError(w, http.StatusConflict, err.Error())
+    case errors.Is(err, ErrStoreUnavailable), errors.Is(err, ErrNoPublisher):
+        utils.RespondWithError(w, http.StatusServiceUnavailable, err.Error())
+    default:
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to review upload")
+    }
+}
+
+// RespondUploadError writes the response for an upload stopped by moderation,
+// reporting false for any other error so the caller can handle it
+func RespondUploadError(w http.ResponseWriter, err error) bool {
+    if item, ok := AsQuarantined(err); ok {
+        utils.RespondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+            "status":        StatusPending,
+            "moderation_id": item.ID,
+            "message":       "Upload is being reviewed and will be published once approved",
+        })
+        return true
+    }
+    if errors.Is(err, ErrContentRejected) {
+        utils.RespondWithError(w, http.StatusUnprocessableEntity, err.Error())
+        return true
+    }
+    return false
+}
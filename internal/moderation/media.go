@@ -0,0 +1,166 @@
+// internal/moderation/media.go
+
+package moderation
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "image"
+    "image/color"
+    "image/jpeg"
+    "image/png"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "sort"
+    "strings"
+)
+
+const (
+    // maxVideoFrames caps how many frames of a video are scanned
+    maxVideoFrames = 12
+
+    // Blurred images are pixelated into blocks this fraction of the longer side
+    blurBlocksPerSide = 24
+)
+
+var (
+    ErrVideoScanUnavailable = errors.New("video scanning requires ffmpeg")
+    ErrUnsupportedMedia     = errors.New("media type cannot be scanned")
+)
+
+func isImage(contentType string) bool {
+    return strings.HasPrefix(contentType, "image/")
+}
+
+func isVideo(contentType string) bool {
+    return strings.HasPrefix(contentType, "video/")
+}
+
+// frameExtractor samples still frames from a video with ffmpeg
+type frameExtractor struct {
+    ffmpegPath string
+}
+
+func newFrameExtractor() *frameExtractor {
+    path, _ := exec.LookPath("ffmpeg")
+    return &frameExtractor{ffmpegPath: path}
+}
+
+// Extract returns JPEG frames: the first frame plus scene changes across the
+// whole video, up to maxVideoFrames
+func (e *frameExtractor) Extract(ctx context.Context, video []byte) ([][]byte, error) {
+    if e.ffmpegPath == "" {
+        return nil, ErrVideoScanUnavailable
+    }
+
+    dir, err := os.MkdirTemp("", "moderation-frames-*")
+    if err != nil {
+        return nil, err
+    }
+    defer os.RemoveAll(dir)
+
+    input := filepath.Join(dir, "input")
+    if err := os.WriteFile(input, video, 0600); err != nil {
+        return nil, err
+    }
+
+    cmd := exec.CommandContext(ctx, e.ffmpegPath,
+        "-v", "error",
+        "-i", input,
+        "-vf", "select='eq(n\\,0)+gt(scene\\,0.3)',scale='min(1024,iw)':-2",
+        "-vsync", "vfr",
+        "-frames:v", fmt.Sprintf("%d", maxVideoFrames),
+        filepath.Join(dir, "frame-%03d.jpg"),
+    )
+    if out, err := cmd.CombinedOutput(); err != nil {
+        return nil, fmt.Errorf("failed to extract video frames: %v: %s", err, bytes.TrimSpace(out))
+    }
+
+    names, err := filepath.Glob(filepath.Join(dir, "frame-*.jpg"))
+    if err != nil {
+        return nil, err
+    }
+    sort.Strings(names)
+
+    frames := make([][]byte, 0, len(names))
+    for _, name := range names {
+        frame, err := os.ReadFile(name)
+        if err != nil {
+            return nil, err
+        }
+        frames = append(frames, frame)
+    }
+    if len(frames) == 0 {
+        return nil, errors.New("no frames extracted from video")
+    }
+    return frames, nil
+}
+
+// blurImage pixelates an image beyond recognition, keeping its format and
+// dimensions. Only JPEG and PNG can be blurred.
+func blurImage(data []byte, contentType string) ([]byte, error) {
+    if contentType != "image/jpeg" && contentType != "image/png" {
+        return nil, ErrUnsupportedMedia
+    }
+
+    src, _, err := image.Decode(bytes.NewReader(data))
+    if err != nil {
+        return nil, fmt.Errorf("failed to decode image: %w", err)
+    }
+
+    bounds := src.Bounds()
+    block := bounds.Dx()
+    if bounds.Dy() > block {
+        block = bounds.Dy()
+    }
+    block /= blurBlocksPerSide
+    if block < 1 {
+        block = 1
+    }
+
+    dst := image.NewRGBA(bounds)
+    for y := bounds.Min.Y; y < bounds.Max.Y; y += block {
+        for x := bounds.Min.X; x < bounds.Max.X; x += block {
+            cell := image.Rect(x, y, x+block, y+block).Intersect(bounds)
+            fill := averageColor(src, cell)
+            for cy := cell.Min.Y; cy < cell.Max.Y; cy++ {
+                for cx := cell.Min.X; cx < cell.Max.X; cx++ {
+                    dst.Set(cx, cy, fill)
+                }
+            }
+        }
+    }
+
+    var buf bytes.Buffer
+    if contentType == "image/png" {
+        err = png.Encode(&buf, dst)
+    } else {
+        err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+    }
+    if err != nil {
+        return nil, fmt.Errorf("failed to encode blurred image: %w", err)
+    }
+    return buf.Bytes(), nil
+}
+
+// averageColor is the mean colour of the pixels in r
+func averageColor(img image.Image, r image.Rectangle) color.RGBA64 {
+    var sr, sg, sb, sa, n uint64
+    for y := r.Min.Y; y < r.Max.Y; y++ {
+        for x := r.Min.X; x < r.Max.X; x++ {
+            cr, cg, cb, ca := img.At(x, y).RGBA()
+            sr += uint64(cr)
+            sg += uint64(cg)
+            sb += uint64(cb)
+            sa += uint64(ca)
+            n++
+        }
+    }
+    if n == 0 {
+        return color.RGBA64{}
+    }
+    return color.RGBA64{R: uint16(sr / n), G: uint16(sg / n), B: uint16(sb / n), A: uint16(sa / n)}
+}
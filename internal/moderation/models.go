@@ -0,0 +1,147 @@
+// internal/moderation/models.go
+
+package moderation
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "time"
+)
+
+// Category is the kind of unsafe content a label belongs to
+type Category string
+
+const (
+    CategoryNudity   Category = "nudity"
+    CategoryViolence Category = "violence"
+)
+
+// Label is one finding from a moderation provider
+type Label struct {
+    Name     string   `json:"name"`
+    Category Category `json:"category"`
+    // Confidence is the provider's certainty, 0-1
+    Confidence float64 `json:"confidence"`
+}
+
+// Labels is stored as JSONB
+type Labels []Label
+
+// Scan implements sql.Scanner interface
+func (l *Labels) Scan(value interface{}) error {
+    if value == nil {
+        *l = Labels{}
+        return nil
+    }
+
+    bytes, ok := value.([]byte)
+    if !ok {
+        return nil
+    }
+
+    return json.Unmarshal(bytes, l)
+}
+
+// Value implements driver.Valuer interface
+func (l Labels) Value() (driver.Value, error) {
+    if l == nil {
+        return "[]", nil
+    }
+    return json.Marshal(l)
+}
+
+// score is the highest confidence among the labels
+func (l Labels) score() float64 {
+    max := 0.0
+    for _, label := range l {
+        if label.Confidence > max {
+            max = label.Confidence
+        }
+    }
+    return max
+}
+
+// Action is what happens to an upload after it has been scanned
+type Action string
+
+const (
+    ActionAllow      Action = "allow"
+    ActionBlur       Action = "blur"
+    ActionQuarantine Action = "quarantine"
+    ActionReject     Action = "reject"
+)
+
+// Status is the review state of a quarantined upload
+type Status string
+
+const (
+    StatusPending  Status = "pending"
+    StatusApproved Status = "approved"
+    StatusRejected Status = "rejected"
+)
+
+// Thresholds decide the action for an upload's score. Scores at or above
+// Review are held for an admin; at or above Reject the upload is refused,
+// or blurred instead when BlurRejected is set and the upload is an image.
+type Thresholds struct {
+    Review       float64
+    Reject       float64
+    BlurRejected bool
+}
+
+// Upload is a file on its way to storage
+type Upload struct {
+    UserID      int64
+    Source      string // feature the upload came from: posts, stories, profile
+    Folder      string
+    Filename    string
+    ContentType string
+    Data        []byte
+}
+
+// Decision is the outcome of screening an upload
+type Decision struct {
+    Action Action
+    Labels Labels
+    Score  float64
+
+    // Data replaces the upload's content when it was blurred
+    Data []byte
+    // Item is the review entry for a quarantined upload
+    Item *Item
+}
+
+// Item is a quarantined upload awaiting or past admin review
+type Item struct {
+    ID            int64      `json:"id" db:"id"`
+    UserID        int64      `json:"user_id" db:"user_id"`
+    Source        string     `json:"source" db:"source"`
+    Folder        string     `json:"-" db:"folder"`
+    Filename      string     `json:"filename" db:"filename"`
+    ContentType   string     `json:"content_type" db:"content_type"`
+    QuarantineRef string     `json:"-" db:"quarantine_ref"`
+    Labels        Labels     `json:"labels" db:"labels"`
+    Score         float64    `json:"score" db:"score"`
+    Status        Status     `json:"status" db:"status"`
+    PublishedURL  *string    `json:"published_url,omitempty" db:"published_url"`
+    ReviewedBy    *int64     `json:"reviewed_by,omitempty" db:"reviewed_by"`
+    ReviewNote    *string    `json:"review_note,omitempty" db:"review_note"`
+    CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+    ReviewedAt    *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+}
+
+// Violation counts one kind of content violation by a user from one source,
+// e.g. profanity in comments
+type Violation struct {
+    UserID  int64     `json:"user_id" db:"user_id"`
+    Kind    string    `json:"kind" db:"kind"`
+    Source  string    `json:"source" db:"source"`
+    Count   int       `json:"count" db:"count"`
+    FirstAt time.Time `json:"first_at" db:"first_at"`
+    LastAt  time.Time `json:"last_at" db:"last_at"`
+}
+
+// RejectRequest is the admin's reason for rejecting an upload
+type RejectRequest struct {
+    Reason string `json:"reason" validate:"omitempty,max=500"`
+}
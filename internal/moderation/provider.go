@@ -0,0 +1,93 @@
+// internal/moderation/provider.go
+
+package moderation
+
+import (
+    "context"
+    "fmt"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/rekognition"
+)
+
+// Provider scans an image for unsafe content. Videos are scanned frame by
+// frame, so providers only ever see JPEG or PNG images.
+type Provider interface {
+    ScanImage(ctx context.Context, image []byte) (Labels, error)
+}
+
+// MockProvider finds nothing in any image; for development and tests
+type MockProvider struct{}
+
+// NewMockProvider creates a provider that allows everything
+func NewMockProvider() Provider {
+    return &MockProvider{}
+}
+
+// ScanImage returns no labels
+func (p *MockProvider) ScanImage(ctx context.Context, image []byte) (Labels, error) {
+    return Labels{}, nil
+}
+
+// rekognitionMinConfidence is the lowest confidence Rekognition reports, in percent
+const rekognitionMinConfidence = 50
+
+// rekognitionCategories maps Rekognition's moderation taxonomy onto our
+// categories. Labels outside it (drugs, gambling, hate symbols...) are ignored.
+var rekognitionCategories = map[string]Category{
+    "Explicit Nudity": CategoryNudity,
+    "Explicit": CategoryNudity,
+    "Non-Explicit Nudity of Intimate parts and Kissing": CategoryNudity,
+    "Suggestive": CategoryNudity,
+    "Violence": CategoryViolence,
+    "Graphic Violence": CategoryViolence,
+    "Visually Disturbing": CategoryViolence,
+}
+
+// RekognitionProvider scans images with AWS Rekognition's moderation labels
+type RekognitionProvider struct {
+    client *rekognition.Rekognition
+}
+
+// NewRekognitionProvider creates a Rekognition provider
+func NewRekognitionProvider(region string) (Provider, error) {
+    sess, err := session.NewSession(&aws.Config{
+        Region: aws.String(region),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to create AWS session: %w", err)
+    }
+
+    return &RekognitionProvider{client: rekognition.New(sess)}, nil
+}
+
+// ScanImage returns the nudity and violence labels Rekognition finds
+func (p *RekognitionProvider) ScanImage(ctx context.Context, image []byte) (Labels, error) {
+    out, err := p.client.DetectModerationLabelsWithContext(ctx, &rekognition.DetectModerationLabelsInput{
+        Image:         &rekognition.Image{Bytes: image},
+        MinConfidence: aws.Float64(rekognitionMinConfidence),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("rekognition moderation failed: %w", err)
+    }
+
+    labels := Labels{}
+    for _, l := range out.ModerationLabels {
+        name := aws.StringValue(l.Name)
+        category, ok := rekognitionCategories[name]
+        if !ok {
+            category, ok = rekognitionCategories[aws.StringValue(l.ParentName)]
+        }
+        if !ok {
+            continue
+        }
+
+        labels = append(labels, Label{
+            Name:       name,
+            Category:   category,
+            Confidence: aws.Float64Value(l.Confidence) / 100,
+        })
+    }
+    return labels, nil
+}
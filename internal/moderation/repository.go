@@ -0,0 +1,149 @@
+// internal/moderation/repository.go
+
+package moderation
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+
+    "github.com/jmoiron/sqlx"
+)
+
+var ErrItemNotFound = errors.New("moderation item not found")
+
+type Repository interface {
+    CreateItem(ctx context.Context, item *Item) error
+    GetItem(ctx context.Context, id int64) (*Item, error)
+    ListItems(ctx context.Context, status Status, limit, offset int) ([]*Item, error)
+    Approve(ctx context.Context, id, reviewerID int64) (bool, error)
+    SetPublishedURL(ctx context.Context, id int64, url string) error
+    Reopen(ctx context.Context, id int64) error
+    Reject(ctx context.Context, id, reviewerID int64, reason string) (bool, error)
+
+    RecordViolation(ctx context.Context, userID int64, kind, source string) error
+    GetUserViolations(ctx context.Context, userID int64) ([]*Violation, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+const itemColumns = `
+    id, user_id, source, folder, filename, content_type, quarantine_ref, labels, score,
+    status, published_url, reviewed_by, review_note, created_at, reviewed_at`
+
+func (r *repository) CreateItem(ctx context.Context, item *Item) error {
+    query := `
+        INSERT INTO moderation_items
+            (user_id, source, folder, filename, content_type, quarantine_ref, labels, score, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id, created_at`
+
+    return r.db.QueryRowContext(ctx, query,
+        item.UserID, item.Source, item.Folder, item.Filename, item.ContentType,
+        item.QuarantineRef, item.Labels, item.Score, item.Status,
+    ).Scan(&item.ID, &item.CreatedAt)
+}
+
+func (r *repository) GetItem(ctx context.Context, id int64) (*Item, error) {
+    var item Item
+    query := `SELECT ` + itemColumns + ` FROM moderation_items WHERE id = $1`
+
+    err := r.db.GetContext(ctx, &item, query, id)
+    if err == sql.ErrNoRows {
+        return nil, ErrItemNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &item, nil
+}
+
+// ListItems returns items in the given status, oldest first
+func (r *repository) ListItems(ctx context.Context, status Status, limit, offset int) ([]*Item, error) {
+    query := `SELECT ` + itemColumns + ` FROM moderation_items
+        WHERE status = $1
+        ORDER BY created_at, id
+        LIMIT $2 OFFSET $3`
+
+    items := []*Item{}
+    err := r.db.SelectContext(ctx, &items, query, status, limit, offset)
+    return items, err
+}
+
+// Approve claims a pending item for publishing, reporting false if it was
+// already reviewed
+func (r *repository) Approve(ctx context.Context, id, reviewerID int64) (bool, error) {
+    query := `
+        UPDATE moderation_items
+        SET status = $2, reviewed_by = $3, reviewed_at = NOW()
+        WHERE id = $1 AND status = $4`
+
+    result, err := r.db.ExecContext(ctx, query, id, StatusApproved, reviewerID, StatusPending)
+    if err != nil {
+        return false, err
+    }
+    n, err := result.RowsAffected()
+    return n > 0, err
+}
+
+func (r *repository) SetPublishedURL(ctx context.Context, id int64, url string) error {
+    _, err := r.db.ExecContext(ctx, `UPDATE moderation_items SET published_url = $2 WHERE id = $1`, id, url)
+    return err
+}
+
+// Reopen puts an approved item back in the queue when publishing it failed
+func (r *repository) Reopen(ctx context.Context, id int64) error {
+    query := `
+        UPDATE moderation_items
+        SET status = $2, reviewed_by = NULL, reviewed_at = NULL
+        WHERE id = $1 AND status = $3 AND published_url IS NULL`
+
+    _, err := r.db.ExecContext(ctx, query, id, StatusPending, StatusApproved)
+    return err
+}
+
+// Reject marks a pending item rejected
+func (r *repository) Reject(ctx context.Context, id, reviewerID int64, reason string) (bool, error) {
+    query := `
+        UPDATE moderation_items
+        SET status = $2, reviewed_by = $3, review_note = NULLIF($4, ''), reviewed_at = NOW()
+        WHERE id = $1 AND status = $5`
+
+    result, err := r.db.ExecContext(ctx, query, id, StatusRejected, reviewerID, reason, StatusPending)
+    if err != nil {
+        return false, err
+    }
+    n, err := result.RowsAffected()
+    return n > 0, err
+}
+
+// RecordViolation bumps the user's counter for kind from source
+func (r *repository) RecordViolation(ctx context.Context, userID int64, kind, source string) error {
+    query := `
+        INSERT INTO moderation_violations (user_id, kind, source, count, first_at, last_at)
+        VALUES ($1, $2, $3, 1, NOW(), NOW())
+        ON CONFLICT (user_id, kind, source)
+        DO UPDATE SET count = moderation_violations.count + 1, last_at = NOW()`
+
+    _, err := r.db.ExecContext(ctx, query, userID, kind, source)
+    return err
+}
+
+// GetUserViolations returns the user's counters, most recent first
+func (r *repository) GetUserViolations(ctx context.Context, userID int64) ([]*Violation, error) {
+    query := `
+        SELECT user_id, kind, source, count, first_at, last_at
+        FROM moderation_violations
+        WHERE user_id = $1
+        ORDER BY last_at DESC`
+
+    violations := []*Violation{}
+    err := r.db.SelectContext(ctx, &violations, query, userID)
+    return violations, err
+}
@@ -0,0 +1,24 @@
+package moderation
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/moderation").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("/uploads/{id:[0-9]+}", handler.GetUpload).Methods("GET")
+
+    // Review queue (support and admins)
+    admin := router.PathPrefix("/api/v1/admin/moderation").Subrouter()
+    admin.Use(authMiddleware.Authenticate)
+    admin.Use(authMiddleware.RequireStaff)
+
+    admin.HandleFunc("", handler.ListItems).Methods("GET")
+    admin.HandleFunc("/{id:[0-9]+}/media", handler.GetMedia).Methods("GET")
+    admin.HandleFunc("/{id:[0-9]+}/approve", handler.Approve).Methods("POST")
+    admin.HandleFunc("/{id:[0-9]+}/reject", handler.Reject).Methods("POST")
+    admin.HandleFunc("/users/{id:[0-9]+}/violations", handler.GetUserViolations).Methods("GET")
+}
@@ -0,0 +1,372 @@
+// internal/moderation/service.go
+// Content moderation for uploaded media
+//
+// Uploads are scanned before they reach public storage. Anything a provider
+// scores at or above the review threshold is held in a private quarantine
+// until an admin approves it (and it is published) or rejects it (and it is
+// deleted). Uploads past the reject threshold are refused outright, or
+// blurred instead when configured.
+
+package moderation
+
+import (
+    "bytes"
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "mime/multipart"
+    "path/filepath"
+    "sync"
+    "time"
+
+    "github.com/google/uuid"
+)
+
+// scanTimeout bounds how long one upload can spend with the provider
+const scanTimeout = 60 * time.Second
+
+var (
+    ErrContentRejected  = errors.New("upload rejected by content moderation")
+    ErrNotPending       = errors.New("item is not awaiting review")
+    ErrStoreUnavailable = errors.New("quarantine storage is not configured")
+    ErrNoPublisher      = errors.New("no uploader registered for item source")
+)
+
+// QuarantinedError is returned for an upload held for admin review
+type QuarantinedError struct {
+    Item *Item
+}
+
+func (e *QuarantinedError) Error() string {
+    return fmt.Sprintf("upload held for review (moderation item %d)", e.Item.ID)
+}
+
+// AsQuarantined reports whether err is a held upload, returning its item
+func AsQuarantined(err error) (*Item, bool) {
+    var q *QuarantinedError
+    if errors.As(err, &q) {
+        return q.Item, true
+    }
+    return nil, false
+}
+
+// Listener is told about every approved upload once it has been published
+type Listener func(ctx context.Context, item *Item)
+
+type Service interface {
+    // Pipeline
+    Screen(ctx context.Context, upload *Upload) (*Decision, error)
+    RegisterPublisher(source string, publisher Uploader)
+    AddListener(listener Listener)
+
+    // Uploaders
+    GetUserItem(ctx context.Context, userID, itemID int64) (*Item, error)
+
+    // Admin review
+    ListItems(ctx context.Context, status Status, limit, offset int) ([]*Item, error)
+    OpenMedia(ctx context.Context, itemID int64) (io.ReadCloser, *Item, error)
+    Approve(ctx context.Context, itemID, reviewerID int64) (*Item, error)
+    Reject(ctx context.Context, itemID, reviewerID int64, reason string) (*Item, error)
+
+    // Violation counters, fed by the text filter among others
+    RecordViolation(ctx context.Context, userID int64, kind, source string) error
+    GetUserViolations(ctx context.Context, userID int64) ([]*Violation, error)
+}
+
+type service struct {
+    repo       Repository
+    store      QuarantineStore
+    provider   Provider
+    thresholds Thresholds
+    frames     *frameExtractor
+
+    mu         sync.RWMutex
+    publishers map[string]Uploader
+    listeners  []Listener
+}
+
+// NewService creates the moderation service. Without a provider uploads
+// are not scanned, but violations are still counted.
+func NewService(repo Repository, store QuarantineStore, provider Provider, thresholds Thresholds) Service {
+    return &service{
+        repo:       repo,
+        store:      store,
+        provider:   provider,
+        thresholds: thresholds,
+        frames:     newFrameExtractor(),
+        publishers: make(map[string]Uploader),
+    }
+}
+
+// RegisterPublisher sets the uploader approved items from source are published with
+func (s *service) RegisterPublisher(source string, publisher Uploader) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.publishers[source] = publisher
+}
+
+// AddListener registers a callback for approved uploads, e.g. to attach a
+// held profile picture once it is published
+func (s *service) AddListener(listener Listener) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.listeners = append(s.listeners, listener)
+}
+
+// Screen scans an image or video upload and decides what happens to it.
+// Uploads that can't be scanned go to review rather than straight through.
+func (s *service) Screen(ctx context.Context, upload *Upload) (*Decision, error) {
+    if s.provider == nil || (!isImage(upload.ContentType) && !isVideo(upload.ContentType)) {
+        return &Decision{Action: ActionAllow, Labels: Labels{}}, nil
+    }
+
+    labels, err := s.scan(ctx, upload)
+    if err != nil {
+        log.Printf("Moderation scan failed for %s upload by user %d: %v", upload.Source, upload.UserID, err)
+        return s.quarantine(ctx, upload, Labels{})
+    }
+
+    score := labels.score()
+    switch {
+    case score >= s.thresholds.Reject:
+        if s.thresholds.BlurRejected && isImage(upload.ContentType) {
+            blurred, err := blurImage(upload.Data, upload.ContentType)
+            if err == nil {
+                return &Decision{Action: ActionBlur, Labels: labels, Score: score, Data: blurred}, nil
+            }
+            log.Printf("Failed to blur %s upload by user %d: %v", upload.Source, upload.UserID, err)
+        }
+        return &Decision{Action: ActionReject, Labels: labels, Score: score}, nil
+    case score >= s.thresholds.Review:
+        return s.quarantine(ctx, upload, labels)
+    }
+
+    return &Decision{Action: ActionAllow, Labels: labels, Score: score}, nil
+}
+
+// scan runs the provider over an image, or over sampled frames keeping each
+// label's highest confidence
+func (s *service) scan(ctx context.Context, upload *Upload) (Labels, error) {
+    ctx, cancel := context.WithTimeout(ctx, scanTimeout)
+    defer cancel()
+
+    switch {
+    case upload.ContentType == "image/jpeg" || upload.ContentType == "image/png":
+        return s.provider.ScanImage(ctx, upload.Data)
+    case isImage(upload.ContentType) || isVideo(upload.ContentType):
+        // Videos, and images providers can't read (GIF, WebP), are scanned
+        // as JPEG frames
+        frames, err := s.frames.Extract(ctx, upload.Data)
+        if err != nil {
+            return nil, err
+        }
+
+        best := map[string]Label{}
+        for _, frame := range frames {
+            labels, err := s.provider.ScanImage(ctx, frame)
+            if err != nil {
+                return nil, err
+            }
+            for _, l := range labels {
+                if l.Confidence > best[l.Name].Confidence {
+                    best[l.Name] = l
+                }
+            }
+        }
+
+        labels := Labels{}
+        for _, l := range best {
+            labels = append(labels, l)
+        }
+        return labels, nil
+    }
+
+    return nil, ErrUnsupportedMedia
+}
+
+// quarantine holds an upload for review
+func (s *service) quarantine(ctx context.Context, upload *Upload, labels Labels) (*Decision, error) {
+    if s.store == nil {
+        return nil, ErrStoreUnavailable
+    }
+
+    name := fmt.Sprintf("%d_%s%s", upload.UserID, uuid.New().String(), filepath.Ext(upload.Filename))
+    ref, err := s.store.Save(ctx, name, upload.Data, upload.ContentType)
+    if err != nil {
+        return nil, err
+    }
+
+    item := &Item{
+        UserID:        upload.UserID,
+        Source:        upload.Source,
+        Folder:        upload.Folder,
+        Filename:      upload.Filename,
+        ContentType:   upload.ContentType,
+        QuarantineRef: ref,
+        Labels:        labels,
+        Score:         labels.score(),
+        Status:        StatusPending,
+    }
+    if err := s.repo.CreateItem(ctx, item); err != nil {
+        s.store.Delete(ctx, ref)
+        return nil, fmt.Errorf("failed to queue upload for review: %w", err)
+    }
+
+    return &Decision{Action: ActionQuarantine, Labels: labels, Score: item.Score, Item: item}, nil
+}
+
+// GetUserItem returns one of the user's held uploads
+func (s *service) GetUserItem(ctx context.Context, userID, itemID int64) (*Item, error) {
+    item, err := s.repo.GetItem(ctx, itemID)
+    if err != nil {
+        return nil, err
+    }
+    if item.UserID != userID {
+        return nil, ErrItemNotFound
+    }
+    return item, nil
+}
+
+func (s *service) ListItems(ctx context.Context, status Status, limit, offset int) ([]*Item, error) {
+    return s.repo.ListItems(ctx, status, limit, offset)
+}
+
+// OpenMedia streams a pending item's file for review
+func (s *service) OpenMedia(ctx context.Context, itemID int64) (io.ReadCloser, *Item, error) {
+    if s.store == nil {
+        return nil, nil, ErrStoreUnavailable
+    }
+
+    item, err := s.repo.GetItem(ctx, itemID)
+    if err != nil {
+        return nil, nil, err
+    }
+    if item.Status != StatusPending {
+        return nil, nil, ErrNotPending
+    }
+
+    media, err := s.store.Open(ctx, item.QuarantineRef)
+    if err != nil {
+        return nil, nil, err
+    }
+    return media, item, nil
+}
+
+// Approve publishes a held upload through its source's uploader. The item is
+// claimed first so two admins can't publish it twice, and reopened if
+// publishing fails.
+func (s *service) Approve(ctx context.Context, itemID, reviewerID int64) (*Item, error) {
+    if s.store == nil {
+        return nil, ErrStoreUnavailable
+    }
+
+    item, err := s.repo.GetItem(ctx, itemID)
+    if err != nil {
+        return nil, err
+    }
+
+    s.mu.RLock()
+    publisher := s.publishers[item.Source]
+    s.mu.RUnlock()
+    if publisher == nil {
+        return nil, ErrNoPublisher
+    }
+
+    ok, err := s.repo.Approve(ctx, itemID, reviewerID)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        return nil, ErrNotPending
+    }
+
+    url, err := s.publish(ctx, publisher, item)
+    if err != nil {
+        if reopenErr := s.repo.Reopen(ctx, itemID); reopenErr != nil {
+            log.Printf("Failed to reopen moderation item %d: %v", itemID, reopenErr)
+        }
+        return nil, fmt.Errorf("failed to publish approved upload: %w", err)
+    }
+
+    if err := s.repo.SetPublishedURL(ctx, itemID, url); err != nil {
+        return nil, err
+    }
+    if err := s.store.Delete(ctx, item.QuarantineRef); err != nil {
+        log.Printf("Failed to delete quarantined file for moderation item %d: %v", itemID, err)
+    }
+
+    item, err = s.repo.GetItem(ctx, itemID)
+    if err != nil {
+        return nil, err
+    }
+
+    s.mu.RLock()
+    listeners := append([]Listener(nil), s.listeners...)
+    s.mu.RUnlock()
+    for _, listener := range listeners {
+        listener(ctx, item)
+    }
+    return item, nil
+}
+
+// publish hands the quarantined file to the source's uploader
+func (s *service) publish(ctx context.Context, publisher Uploader, item *Item) (string, error) {
+    f, err := s.store.Open(ctx, item.QuarantineRef)
+    if err != nil {
+        return "", err
+    }
+    data, err := io.ReadAll(f)
+    f.Close()
+    if err != nil {
+        return "", err
+    }
+
+    header := fileHeader(item.Filename, item.ContentType, len(data))
+    return publisher.UploadFile(ctx, newMemoryFile(data), header, item.Folder)
+}
+
+// Reject discards a held upload
+func (s *service) Reject(ctx context.Context, itemID, reviewerID int64, reason string) (*Item, error) {
+    ok, err := s.repo.Reject(ctx, itemID, reviewerID, reason)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        if _, err := s.repo.GetItem(ctx, itemID); err != nil {
+            return nil, err
+        }
+        return nil, ErrNotPending
+    }
+
+    item, err := s.repo.GetItem(ctx, itemID)
+    if err != nil {
+        return nil, err
+    }
+    if s.store != nil {
+        if err := s.store.Delete(ctx, item.QuarantineRef); err != nil {
+            log.Printf("Failed to delete quarantined file for moderation item %d: %v", itemID, err)
+        }
+    }
+    return item, nil
+}
+
+func (s *service) RecordViolation(ctx context.Context, userID int64, kind, source string) error {
+    return s.repo.RecordViolation(ctx, userID, kind, source)
+}
+
+func (s *service) GetUserViolations(ctx context.Context, userID int64) ([]*Violation, error) {
+    return s.repo.GetUserViolations(ctx, userID)
+}
+
+// memoryFile is an in-memory multipart.File
+type memoryFile struct {
+    *bytes.Reader
+}
+
+func newMemoryFile(data []byte) multipart.File {
+    return memoryFile{bytes.NewReader(data)}
+}
+
+func (memoryFile) Close() error { return nil }
@@ -0,0 +1,124 @@
+// internal/moderation/storage.go
+
+package moderation
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/s3"
+)
+
+// QuarantineStore holds flagged uploads until an admin reviews them. Nothing
+// in it is publicly served; admins view it through the API.
+type QuarantineStore interface {
+    Save(ctx context.Context, name string, data []byte, contentType string) (string, error)
+    Open(ctx context.Context, ref string) (io.ReadCloser, error)
+    Delete(ctx context.Context, ref string) error
+}
+
+// S3QuarantineStore keeps quarantined uploads as private, encrypted S3 objects
+type S3QuarantineStore struct {
+    s3Client *s3.S3
+    bucket   string
+    prefix   string
+}
+
+// NewS3QuarantineStore creates an S3 quarantine store
+func NewS3QuarantineStore(bucket, region string) (QuarantineStore, error) {
+    sess, err := session.NewSession(&aws.Config{
+        Region: aws.String(region),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to create AWS session: %w", err)
+    }
+
+    return &S3QuarantineStore{
+        s3Client: s3.New(sess),
+        bucket:   bucket,
+        prefix:   "moderation/quarantine",
+    }, nil
+}
+
+// Save uploads the file and returns its object key
+func (s *S3QuarantineStore) Save(ctx context.Context, name string, data []byte, contentType string) (string, error) {
+    key := fmt.Sprintf("%s/%s", s.prefix, name)
+
+    _, err := s.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+        Bucket:               aws.String(s.bucket),
+        Key:                  aws.String(key),
+        Body:                 bytes.NewReader(data),
+        ContentType:          aws.String(contentType),
+        ACL:                  aws.String("private"),
+        ServerSideEncryption: aws.String("AES256"),
+    })
+    if err != nil {
+        return "", fmt.Errorf("failed to quarantine upload in S3: %w", err)
+    }
+
+    return key, nil
+}
+
+// Open streams a quarantined file
+func (s *S3QuarantineStore) Open(ctx context.Context, ref string) (io.ReadCloser, error) {
+    out, err := s.s3Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key:    aws.String(ref),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to read quarantined upload from S3: %w", err)
+    }
+    return out.Body, nil
+}
+
+// Delete removes a quarantined file
+func (s *S3QuarantineStore) Delete(ctx context.Context, ref string) error {
+    _, err := s.s3Client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key:    aws.String(ref),
+    })
+    return err
+}
+
+// LocalQuarantineStore writes quarantined uploads to a private directory on disk
+type LocalQuarantineStore struct {
+    dir string
+}
+
+// NewLocalQuarantineStore creates a local quarantine store. dir must not be served over HTTP.
+func NewLocalQuarantineStore(dir string) QuarantineStore {
+    return &LocalQuarantineStore{dir: dir}
+}
+
+// Save writes the file and returns its name
+func (s *LocalQuarantineStore) Save(ctx context.Context, name string, data []byte, contentType string) (string, error) {
+    if err := os.MkdirAll(s.dir, 0700); err != nil {
+        return "", fmt.Errorf("failed to create quarantine directory: %w", err)
+    }
+
+    if err := os.WriteFile(filepath.Join(s.dir, name), data, 0600); err != nil {
+        return "", fmt.Errorf("failed to write quarantined upload: %w", err)
+    }
+
+    return name, nil
+}
+
+// Open reads a quarantined file
+func (s *LocalQuarantineStore) Open(ctx context.Context, ref string) (io.ReadCloser, error) {
+    return os.Open(filepath.Join(s.dir, filepath.Base(ref)))
+}
+
+// Delete removes a quarantined file
+func (s *LocalQuarantineStore) Delete(ctx context.Context, ref string) error {
+    err := os.Remove(filepath.Join(s.dir, filepath.Base(ref)))
+    if os.IsNotExist(err) {
+        return nil
+    }
+    return err
+}
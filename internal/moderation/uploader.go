@@ -0,0 +1,108 @@
+// internal/moderation/uploader.go
+
+package moderation
+
+import (
+    "context"
+    "fmt"
+    "io"
+    "mime"
+    "mime/multipart"
+    "net/http"
+    "net/textproto"
+    "path/filepath"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+// Uploader is the upload service shape shared by stories and profiles
+type Uploader interface {
+    UploadFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, folder string) (string, error)
+    DeleteFile(ctx context.Context, url string) error
+}
+
+// moderatedUploader screens every upload before passing it to the wrapped uploader
+type moderatedUploader struct {
+    inner   Uploader
+    service Service
+    source  string
+}
+
+// Wrap screens uploads to inner through the moderation service. Held
+// uploads fail with a *QuarantinedError and are published through inner
+// once approved. The uploading user is read from the request context.
+func Wrap(inner Uploader, service Service, source string) Uploader {
+    service.RegisterPublisher(source, inner)
+    return &moderatedUploader{
+        inner:   inner,
+        service: service,
+        source:  source,
+    }
+}
+
+func (u *moderatedUploader) UploadFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, folder string) (string, error) {
+    data, err := io.ReadAll(file)
+    if err != nil {
+        return "", fmt.Errorf("failed to read upload: %w", err)
+    }
+
+    userID, _ := auth.UserIDFromContext(ctx)
+    decision, err := u.service.Screen(ctx, &Upload{
+        UserID:      userID,
+        Source:      u.source,
+        Folder:      folder,
+        Filename:    header.Filename,
+        ContentType: detectContentType(data, header),
+        Data:        data,
+    })
+    if err != nil {
+        return "", err
+    }
+
+    switch decision.Action {
+    case ActionReject:
+        return "", ErrContentRejected
+    case ActionQuarantine:
+        return "", &QuarantinedError{Item: decision.Item}
+    case ActionBlur:
+        data = decision.Data
+        header = &multipart.FileHeader{
+            Filename: header.Filename,
+            Size:     int64(len(data)),
+            Header:   header.Header,
+        }
+    }
+
+    return u.inner.UploadFile(ctx, newMemoryFile(data), header, folder)
+}
+
+func (u *moderatedUploader) DeleteFile(ctx context.Context, url string) error {
+    return u.inner.DeleteFile(ctx, url)
+}
+
+// detectContentType sniffs the upload, falling back to the declared type and
+// then the extension for formats the sniffer doesn't know (e.g. .mov)
+func detectContentType(data []byte, header *multipart.FileHeader) string {
+    if sniffed := http.DetectContentType(data); sniffed != "application/octet-stream" {
+        return sniffed
+    }
+    if declared := header.Header.Get("Content-Type"); declared != "" {
+        if mediaType, _, err := mime.ParseMediaType(declared); err == nil && mediaType != "application/octet-stream" {
+            return mediaType
+        }
+    }
+    if byExt := mime.TypeByExtension(filepath.Ext(header.Filename)); byExt != "" {
+        mediaType, _, _ := mime.ParseMediaType(byExt)
+        return mediaType
+    }
+    return "application/octet-stream"
+}
+
+// fileHeader builds the header an uploader sees for a file we hold in memory
+func fileHeader(filename, contentType string, size int) *multipart.FileHeader {
+    return &multipart.FileHeader{
+        Filename: filename,
+        Size:     int64(size),
+        Header:   textproto.MIMEHeader{"Content-Type": {contentType}},
+    }
+}
@@ -0,0 +1,212 @@
+// internal/notification/actions.go
+// Action buttons on notifications, like Accept and Decline on a date request
+
+package notifications
+
+import (
+    "bytes"
+    "context"
+    "net/http"
+    "regexp"
+    "strconv"
+    "strings"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
+)
+
+// Notification action IDs
+const (
+    ActionAccept  = "accept"
+    ActionDecline = "decline"
+    ActionReply   = "reply"
+)
+
+// inputPlaceholder marks where the text an action takes goes in its payload
+const inputPlaceholder = "{input}"
+
+// actionTemplates are the actions each notification type offers. Endpoints
+// and payload strings use {key} placeholders for the notification's data;
+// an action whose placeholders its data can't fill isn't offered.
+var actionTemplates = map[NotificationType][]NotificationAction{
+    TypeDateRequest: {
+        {
+            ID:       ActionAccept,
+            Method:   http.MethodPost,
+            Endpoint: "/api/v1/dating/requests/{date_request_id}/respond",
+            Payload:  map[string]interface{}{"status": "accepted"},
+        },
+        {
+            ID:          ActionDecline,
+            Method:      http.MethodPost,
+            Endpoint:    "/api/v1/dating/requests/{date_request_id}/respond",
+            Payload:     map[string]interface{}{"status": "declined"},
+            Destructive: true,
+        },
+    },
+    TypeMessage: {
+        {
+            ID:       ActionReply,
+            Method:   http.MethodPost,
+            Endpoint: "/api/v1/messages/messages",
+            Payload: map[string]interface{}{
+                "conversation_id":   "{conversation_id}",
+                "parent_message_id": "{message_id}",
+                "message_type":      "text",
+                "content":           inputPlaceholder,
+            },
+            Input: true,
+        },
+    },
+}
+
+var placeholderPattern = regexp.MustCompile(`\{(\w+)\}`)
+
+// actionsFor returns the actions a notification offers, labelled in lang,
+// with its data filled in. A resolved notification offers none.
+func actionsFor(notification *Notification, lang string) []NotificationAction {
+    if notification.ResolvedAt != nil {
+        return nil
+    }
+
+    var actions []NotificationAction
+    for _, template := range actionTemplates[notification.Type] {
+        action, ok := renderAction(template, notification.Data)
+        if !ok {
+            continue
+        }
+        action.Label = i18n.T(lang, "notification.action."+action.ID, nil)
+        actions = append(actions, action)
+    }
+    return actions
+}
+
+// renderAction fills an action template's placeholders from data, leaving
+// the input placeholder for the user's text
+func renderAction(template NotificationAction, data NotificationData) (NotificationAction, bool) {
+    action := template
+    complete := true
+
+    action.Endpoint = placeholderPattern.ReplaceAllStringFunc(template.Endpoint, func(placeholder string) string {
+        value, ok := placeholderValue(data, placeholder)
+        if !ok {
+            complete = false
+            return placeholder
+        }
+        if id, ok := value.(int64); ok {
+            return strconv.FormatInt(id, 10)
+        }
+        return value.(string)
+    })
+
+    // A placeholder filling a whole payload value keeps the data's type, so
+    // IDs stay numbers
+    action.Payload = make(map[string]interface{}, len(template.Payload))
+    for key, value := range template.Payload {
+        if placeholder, ok := value.(string); ok && placeholderPattern.MatchString(placeholder) && placeholder != inputPlaceholder {
+            if value, ok = placeholderValue(data, placeholder); !ok {
+                complete = false
+            }
+        }
+        action.Payload[key] = value
+    }
+
+    return action, complete
+}
+
+// placeholderValue looks up a {key} placeholder in notification data
+func placeholderValue(data NotificationData, placeholder string) (interface{}, bool) {
+    key := strings.Trim(placeholder, "{}")
+    if id, ok := int64Of(data[key]); ok {
+        return id, true
+    }
+    if value, ok := data[key].(string); ok && value != "" {
+        return value, true
+    }
+    return nil, false
+}
+
+// withInput returns the action's payload with the user's text in place of
+// the input placeholder
+func (a *NotificationAction) withInput(input string) map[string]interface{} {
+    payload := make(map[string]interface{}, len(a.Payload))
+    for key, value := range a.Payload {
+        if value == inputPlaceholder {
+            value = input
+        }
+        payload[key] = value
+    }
+    return payload
+}
+
+// ResolveAction checks the user can take an action on their notification
+// and records it as taken, so it only runs once. It returns the action
+// with the user's input in its payload, ready to call.
+func (s *service) ResolveAction(ctx context.Context, notificationID, userID int64, actionID, input string) (*NotificationAction, error) {
+    notification, err := s.GetNotification(ctx, notificationID, userID)
+    if err != nil {
+        return nil, err
+    }
+    if notification.ResolvedAt != nil {
+        return nil, ErrActionTaken
+    }
+
+    var action *NotificationAction
+    for i := range notification.Actions {
+        if notification.Actions[i].ID == actionID {
+            action = &notification.Actions[i]
+        }
+    }
+    if action == nil {
+        return nil, ErrActionNotFound
+    }
+    if action.Input && strings.TrimSpace(input) == "" {
+        return nil, ErrActionInputRequired
+    }
+    action.Payload = action.withInput(input)
+
+    resolved, err := s.repo.ResolveNotification(ctx, notificationID, userID, actionID)
+    if err != nil {
+        return nil, err
+    }
+    if !resolved {
+        return nil, ErrActionTaken
+    }
+
+    s.publishUnreadCount(ctx, userID)
+    return action, nil
+}
+
+// ReleaseAction offers a notification's actions again after the one taken
+// failed
+func (s *service) ReleaseAction(ctx context.Context, notificationID int64, actionID string) error {
+    return s.repo.UnresolveNotification(ctx, notificationID, actionID)
+}
+
+// actionRecorder captures the response of an action's endpoint when the
+// server takes the action for the client
+type actionRecorder struct {
+    header      http.Header
+    status      int
+    wroteHeader bool
+    body        bytes.Buffer
+}
+
+func newActionRecorder() *actionRecorder {
+    return &actionRecorder{header: make(http.Header), status: http.StatusOK}
+}
+
+func (r *actionRecorder) Header() http.Header {
+    return r.header
+}
+
+func (r *actionRecorder) Write(b []byte) (int, error) {
+    r.wroteHeader = true
+    return r.body.Write(b)
+}
+
+func (r *actionRecorder) WriteHeader(status int) {
+    if !r.wroteHeader {
+        r.status = status
+        r.wroteHeader = true
+    }
+}
@@ -0,0 +1,270 @@
+// internal/notification/apns.go
+
+package notifications
+
+import (
+    "bytes"
+    "context"
+    "crypto/ecdsa"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "log"
+    "net/http"
+    "strings"
+    "sync"
+    "time"
+
+    "github.com/golang-jwt/jwt/v4"
+)
+
+const (
+    apnsProductionURL = "https://api.push.apple.com"
+    apnsSandboxURL    = "https://api.sandbox.push.apple.com"
+
+    // Apple rejects provider tokens older than an hour, and ones refreshed
+    // more often than every 20 minutes
+    apnsTokenLifetime = 50 * time.Minute
+)
+
+// apnsUnregistered are the reasons APNs gives for a device token that will
+// never be delivered to again
+var apnsUnregistered = map[string]bool{
+    "BadDeviceToken":         true,
+    "DeviceTokenNotForTopic": true,
+    "Unregistered":           true,
+}
+
+// APNSPushService sends push notifications straight to Apple Push
+// Notification service, for iOS tokens the app registered with APNs rather
+// than Firebase. It authenticates with a token signed by a .p8 key.
+type APNSPushService struct {
+    key     *ecdsa.PrivateKey
+    keyID   string
+    teamID  string
+    topic   string
+    baseURL string
+    client  *http.Client
+
+    mu          sync.Mutex
+    token       string
+    tokenIssued time.Time
+}
+
+// NewAPNSPushService creates an APNs push service from a PEM encoded .p8
+// key. topic is the app's bundle ID; production picks the production
+// gateway over the sandbox one development builds register with.
+func NewAPNSPushService(key []byte, keyID, teamID, topic string, production bool) (*APNSPushService, error) {
+    if keyID == "" || teamID == "" || topic == "" {
+        return nil, errors.New("APNs needs a key ID, team ID and bundle ID")
+    }
+
+    privateKey, err := jwt.ParseECPrivateKeyFromPEM(key)
+    if err != nil {
+        return nil, fmt.Errorf("invalid APNs key: %w", err)
+    }
+
+    baseURL := apnsSandboxURL
+    if production {
+        baseURL = apnsProductionURL
+    }
+
+    // The default transport speaks HTTP/2, which APNs requires
+    return &APNSPushService{
+        key:     privateKey,
+        keyID:   keyID,
+        teamID:  teamID,
+        topic:   topic,
+        baseURL: baseURL,
+        client:  &http.Client{Timeout: 15 * time.Second},
+    }, nil
+}
+
+// SendPush sends a push notification to each of its APNs device tokens.
+// Reaching any one device counts as delivered.
+func (s *APNSPushService) SendPush(ctx context.Context, notification *PushNotification) error {
+    if len(notification.Tokens) == 0 {
+        return errors.New("no tokens provided")
+    }
+
+    body, err := json.Marshal(s.payload(notification))
+    if err != nil {
+        return Permanent(err)
+    }
+
+    var messageIDs []string
+    var lastErr, retryable error
+    for _, token := range notification.Tokens {
+        messageID, err := s.send(ctx, token, notification, body)
+        if err == nil {
+            messageIDs = append(messageIDs, messageID)
+            continue
+        }
+
+        log.Printf("Failed to send APNs notification to token %s: %v", token, err)
+        var apnsErr *apnsError
+        if errors.As(err, &apnsErr) && apnsErr.unregistered() {
+            notification.InvalidTokens = append(notification.InvalidTokens, token)
+        }
+        lastErr = err
+        if !IsPermanent(err) {
+            retryable = err
+        }
+    }
+
+    // Only give up for good when no device could be reached by retrying
+    if len(messageIDs) == 0 {
+        if retryable != nil {
+            return retryable
+        }
+        return lastErr
+    }
+
+    notification.MessageID = strings.Join(messageIDs, ",")
+    return nil
+}
+
+// SendBatchPush sends multiple push notifications
+func (s *APNSPushService) SendBatchPush(ctx context.Context, notifications []*PushNotification) error {
+    for _, notification := range notifications {
+        if err := s.SendPush(ctx, notification); err != nil {
+            log.Printf("Failed to send APNs notification in batch: %v", err)
+        }
+    }
+
+    return nil
+}
+
+// payload builds the APNs JSON body: the aps dictionary plus the
+// notification's data as custom keys
+func (s *APNSPushService) payload(notification *PushNotification) map[string]interface{} {
+    aps := map[string]interface{}{
+        "alert": map[string]string{
+            "title": notification.Title,
+            "body":  notification.Body,
+        },
+        "badge": notification.Badge,
+    }
+    if notification.Sound != "" {
+        aps["sound"] = notification.Sound
+    }
+    if notification.Category != "" {
+        aps["category"] = notification.Category
+    }
+    if notification.Image != "" {
+        // Lets the notification service extension download the image
+        aps["mutable-content"] = 1
+    }
+
+    payload := map[string]interface{}{"aps": aps}
+    for key, value := range notification.Data {
+        payload[key] = value
+    }
+    return payload
+}
+
+// send posts one notification to one device token and returns the apns-id
+// APNs gave it
+func (s *APNSPushService) send(ctx context.Context, token string, notification *PushNotification, body []byte) (string, error) {
+    providerToken, err := s.providerToken()
+    if err != nil {
+        return "", Permanent(err)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.baseURL+"/3/device/"+token, bytes.NewReader(body))
+    if err != nil {
+        return "", Permanent(err)
+    }
+    req.Header.Set("Authorization", "bearer "+providerToken)
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Set("apns-topic", s.topic)
+    req.Header.Set("apns-push-type", "alert")
+    req.Header.Set("apns-priority", apnsPriority(notification.Priority))
+    if notification.CollapseKey != "" {
+        req.Header.Set("apns-collapse-id", notification.CollapseKey)
+    }
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("APNs request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode == http.StatusOK {
+        return resp.Header.Get("apns-id"), nil
+    }
+
+    apnsErr := &apnsError{status: resp.StatusCode}
+    var result struct {
+        Reason string `json:"reason"`
+    }
+    json.NewDecoder(resp.Body).Decode(&result)
+    apnsErr.reason = result.Reason
+
+    switch {
+    case apnsErr.reason == "ExpiredProviderToken":
+        // Sign a fresh one for the next attempt
+        s.mu.Lock()
+        s.token = ""
+        s.mu.Unlock()
+        return "", apnsErr
+    case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+        return "", apnsErr
+    default:
+        // The token, topic or payload is wrong; resending won't fix it
+        return "", Permanent(apnsErr)
+    }
+}
+
+// providerToken returns the signed JWT APNs authenticates requests with,
+// signing a new one when the current one is due for renewal
+func (s *APNSPushService) providerToken() (string, error) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+
+    if s.token != "" && time.Since(s.tokenIssued) < apnsTokenLifetime {
+        return s.token, nil
+    }
+
+    now := time.Now()
+    token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+        "iss": s.teamID,
+        "iat": now.Unix(),
+    })
+    token.Header["kid"] = s.keyID
+
+    signed, err := token.SignedString(s.key)
+    if err != nil {
+        return "", fmt.Errorf("failed to sign APNs provider token: %w", err)
+    }
+
+    s.token = signed
+    s.tokenIssued = now
+    return signed, nil
+}
+
+// apnsPriority maps our priority to the apns-priority header
+func apnsPriority(priority Priority) string {
+    if priority == PriorityLow {
+        return "5"
+    }
+    return "10"
+}
+
+// apnsError is a request APNs refused, with the reason it gave
+type apnsError struct {
+    status int
+    reason string
+}
+
+func (e *apnsError) Error() string {
+    if e.reason == "" {
+        return fmt.Sprintf("APNs returned %d", e.status)
+    }
+    return fmt.Sprintf("APNs returned %d: %s", e.status, e.reason)
+}
+
+// unregistered reports whether the device token is gone for good
+func (e *apnsError) unregistered() bool {
+    return e.status == http.StatusGone || apnsUnregistered[e.reason]
+}
@@ -0,0 +1,53 @@
+// internal/notification/badge.go
+
+package notifications
+
+import (
+    "context"
+    "log"
+)
+
+// BadgeEventType is the WebSocket event sent when a user's unread count changes
+const BadgeEventType = "notification_badge"
+
+// Realtime sends events to users' open WebSocket connections. The messaging
+// hub implements it.
+type Realtime interface {
+    IsUserOnline(userID int64) bool
+    SendEvent(userID int64, eventType string, payload interface{})
+}
+
+// UnreadCount is the badge payload, both over HTTP and WebSocket
+type UnreadCount struct {
+    UnreadCount int `json:"unread_count"`
+}
+
+// SetRealtime sets where badge updates are sent. Without it clients poll
+// the unread count endpoint.
+func (s *service) SetRealtime(realtime Realtime) {
+    s.realtime = realtime
+}
+
+// GetUnreadCount returns how many of the user's notifications are unread
+func (s *service) GetUnreadCount(ctx context.Context, userID int64) (*UnreadCount, error) {
+    count, err := s.repo.GetUserNotificationCount(ctx, userID, true)
+    if err != nil {
+        return nil, err
+    }
+    return &UnreadCount{UnreadCount: count}, nil
+}
+
+// publishUnreadCount sends the user's current badge count if they're
+// connected. Offline users are skipped without touching the database.
+func (s *service) publishUnreadCount(ctx context.Context, userID int64) {
+    if s.realtime == nil || !s.realtime.IsUserOnline(userID) {
+        return
+    }
+
+    count, err := s.GetUnreadCount(ctx, userID)
+    if err != nil {
+        log.Printf("Failed to count unread notifications for user %d: %v", userID, err)
+        return
+    }
+    s.realtime.SendEvent(userID, BadgeEventType, count)
+}
@@ -0,0 +1,260 @@
+// internal/notification/campaigns.go
+
+package notifications
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "strings"
+    "time"
+)
+
+const (
+    // campaignBatchSize is how many recipients are created and pushed to at
+    // a time within a campaign's per-minute allowance
+    campaignBatchSize = 500
+
+    defaultCampaignRate = 1000
+
+    // campaignBatchInterval is how often each campaign gets a batch. It is a
+    // little under the job's one minute so a run that starts early isn't
+    // skipped.
+    campaignBatchInterval = 55 * time.Second
+)
+
+// PreviewCampaignAudience counts the users a segment would reach now
+func (s *service) PreviewCampaignAudience(ctx context.Context, segment *Segment) (*CampaignAudience, error) {
+    normalizeSegment(segment)
+    size, err := s.repo.CountSegment(ctx, segment)
+    if err != nil {
+        return nil, err
+    }
+    return &CampaignAudience{Size: size}, nil
+}
+
+// CreateCampaign schedules a campaign. Its audience is whoever the segment
+// selects as each batch goes out; the size at scheduling is kept to
+// compare against.
+func (s *service) CreateCampaign(ctx context.Context, createdBy int64, req *CreateCampaignRequest) (*Campaign, error) {
+    normalizeSegment(&req.Segment)
+
+    campaign := &Campaign{
+        Name:          req.Name,
+        Title:         req.Title,
+        Message:       req.Message,
+        Data:          req.Data,
+        Channels:      req.Channels,
+        Segment:       req.Segment,
+        Status:        CampaignScheduled,
+        ScheduledFor:  time.Now(),
+        RatePerMinute: req.RatePerMinute,
+        CreatedBy:     &createdBy,
+    }
+    if req.ScheduledFor != nil {
+        campaign.ScheduledFor = *req.ScheduledFor
+    }
+    if campaign.RatePerMinute == 0 {
+        campaign.RatePerMinute = defaultCampaignRate
+    }
+
+    size, err := s.repo.CountSegment(ctx, &campaign.Segment)
+    if err != nil {
+        return nil, fmt.Errorf("failed to size campaign audience: %w", err)
+    }
+    campaign.AudienceSize = size
+
+    if err := s.repo.CreateCampaign(ctx, campaign); err != nil {
+        return nil, err
+    }
+    return campaign, nil
+}
+
+// GetCampaign retrieves a campaign with its open and click metrics
+func (s *service) GetCampaign(ctx context.Context, campaignID int64) (*Campaign, error) {
+    campaign, err := s.repo.GetCampaign(ctx, campaignID)
+    if err != nil {
+        return nil, err
+    }
+    campaign.Metrics.rate(campaign.SentCount)
+    return campaign, nil
+}
+
+// GetCampaigns lists campaigns, newest first
+func (s *service) GetCampaigns(ctx context.Context, limit, offset int) ([]*Campaign, error) {
+    if limit <= 0 || limit > 100 {
+        limit = 20
+    }
+
+    campaigns, err := s.repo.GetCampaigns(ctx, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+    for _, campaign := range campaigns {
+        campaign.Metrics.rate(campaign.SentCount)
+    }
+    return campaigns, nil
+}
+
+// CancelCampaign stops a campaign before its next batch. Recipients
+// already sent to keep their notifications.
+func (s *service) CancelCampaign(ctx context.Context, campaignID int64) error {
+    cancelled, err := s.repo.CancelCampaign(ctx, campaignID)
+    if err != nil {
+        return err
+    }
+    if !cancelled {
+        if _, err := s.repo.GetCampaign(ctx, campaignID); err != nil {
+            return err
+        }
+        return ErrCampaignFinished
+    }
+    return nil
+}
+
+// ProcessCampaigns sends each due campaign its next minute's worth of
+// recipients. Returns how many users were sent to.
+func (s *service) ProcessCampaigns(ctx context.Context, now time.Time) (int, error) {
+    campaigns, err := s.repo.ClaimDueCampaigns(ctx, now, now.Add(-campaignBatchInterval))
+    if err != nil {
+        return 0, fmt.Errorf("failed to claim campaigns: %w", err)
+    }
+
+    sent := 0
+    for _, campaign := range campaigns {
+        n, err := s.sendCampaignMinute(ctx, campaign)
+        sent += n
+        if err != nil {
+            log.Printf("Failed to send campaign %d: %v", campaign.ID, err)
+        }
+        if err := ctx.Err(); err != nil {
+            return sent, err
+        }
+    }
+    return sent, nil
+}
+
+// sendCampaignMinute sends up to RatePerMinute recipients in batches,
+// completing the campaign once the segment runs out
+func (s *service) sendCampaignMinute(ctx context.Context, campaign *Campaign) (int, error) {
+    sent := 0
+    for sent < campaign.RatePerMinute {
+        limit := campaignBatchSize
+        if remaining := campaign.RatePerMinute - sent; remaining < limit {
+            limit = remaining
+        }
+
+        userIDs, err := s.repo.GetSegmentUserIDs(ctx, &campaign.Segment, campaign.LastUserID, limit)
+        if err != nil {
+            return sent, err
+        }
+        if len(userIDs) == 0 {
+            return sent, s.repo.CompleteCampaign(ctx, campaign.ID)
+        }
+
+        if err := s.sendCampaignBatch(ctx, campaign, userIDs); err != nil {
+            return sent, err
+        }
+        sent += len(userIDs)
+
+        campaign.LastUserID = userIDs[len(userIDs)-1]
+        sending, err := s.repo.AdvanceCampaign(ctx, campaign.ID, campaign.LastUserID, len(userIDs))
+        if err != nil || !sending {
+            // Cancelled while this batch went out
+            return sent, err
+        }
+
+        if len(userIDs) < limit {
+            return sent, s.repo.CompleteCampaign(ctx, campaign.ID)
+        }
+    }
+    return sent, nil
+}
+
+// sendCampaignBatch creates the in-app notifications for one batch and
+// sends it on each other channel to those who allow that channel. The
+// campaign ID travels in the data so clients can report opens and clicks.
+func (s *service) sendCampaignBatch(ctx context.Context, campaign *Campaign, userIDs []int64) error {
+    data := NotificationData{}
+    for key, value := range campaign.Data {
+        data[key] = value
+    }
+    data["campaign_id"] = campaign.ID
+
+    notifications := make([]*Notification, 0, len(userIDs))
+    for _, userID := range userIDs {
+        notifications = append(notifications, &Notification{
+            UserID:  userID,
+            Type:    TypePromotion,
+            Title:   campaign.Title,
+            Message: campaign.Message,
+            Data:    data,
+        })
+    }
+    if err := s.repo.CreateBatchNotifications(ctx, notifications); err != nil {
+        return err
+    }
+
+    for _, userID := range userIDs {
+        s.publishUnreadCount(ctx, userID)
+    }
+
+    for _, channel := range campaign.Channels {
+        if channel == ChannelInApp {
+            continue
+        }
+
+        recipients, err := s.repo.FilterChannelEnabled(ctx, userIDs, channel)
+        if err != nil {
+            log.Printf("Failed to check %s preferences for campaign %d: %v", channel, campaign.ID, err)
+            continue
+        }
+        if len(recipients) == 0 {
+            continue
+        }
+
+        switch channel {
+        case ChannelPush:
+            s.sendBatchPushNotifications(ctx, recipients, campaign.Title, campaign.Message, data)
+        case ChannelEmail:
+            s.sendBatchEmailNotifications(ctx, recipients, campaign.Title, campaign.Message, data)
+        case ChannelSMS:
+            s.sendBatchSMSNotifications(ctx, recipients, campaign.Message)
+        }
+    }
+    return nil
+}
+
+// RecordCampaignEvent records a recipient opening or clicking a campaign.
+// Only users the campaign was sent to count.
+func (s *service) RecordCampaignEvent(ctx context.Context, userID, campaignID int64, event string) error {
+    recorded, err := s.repo.RecordCampaignEvent(ctx, campaignID, userID, event)
+    if err != nil {
+        return err
+    }
+    if !recorded {
+        return ErrCampaignNotFound
+    }
+
+    // A click implies the notification was opened
+    if event == CampaignEventClick {
+        _, err = s.repo.RecordCampaignEvent(ctx, campaignID, userID, CampaignEventOpen)
+    }
+    return err
+}
+
+// rate works out open and click rates over the recipients sent to
+func (m *CampaignMetrics) rate(sent int) {
+    if m == nil || sent == 0 {
+        return
+    }
+    m.OpenRate = float64(m.Opens) / float64(sent)
+    m.ClickRate = float64(m.Clicks) / float64(sent)
+}
+
+// normalizeSegment upper-cases country codes to match stored ones
+func normalizeSegment(segment *Segment) {
+    for i, country := range segment.Countries {
+        segment.Countries[i] = strings.ToUpper(country)
+    }
+}
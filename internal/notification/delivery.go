@@ -0,0 +1,250 @@
+// internal/notification/delivery.go
+
+package notifications
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/prometheus/client_golang/prometheus"
+    "github.com/prometheus/client_golang/prometheus/promauto"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/sms"
+)
+
+const (
+    // Transient failures are retried after 30s, 1m, 2m and 4m before the
+    // delivery is given up as failed
+    maxDeliveryAttempts = 5
+    deliveryBaseBackoff = 30 * time.Second
+    deliveryMaxBackoff  = 1 * time.Hour
+
+    // deliveryRetryLease is how long a claimed retry is hidden from other runs
+    deliveryRetryLease     = 5 * time.Minute
+    deliveryRetryBatchSize = 100
+)
+
+var deliveryAttemptsTotal = promauto.NewCounterVec(
+    prometheus.CounterOpts{
+        Name: "notification_delivery_attempts_total",
+        Help: "Total number of notification delivery attempts by channel and outcome",
+    },
+    []string{"channel", "status"},
+)
+
+// errNothingToSend means a channel has nowhere to deliver to, such as a user
+// with no push tokens or a provider that isn't configured. Nothing is logged.
+var errNothingToSend = errors.New("nothing to deliver on this channel")
+
+// PermanentError marks a delivery failure that retrying can't fix, such as an
+// unregistered push token or a rejected address. Such deliveries are bounced.
+type PermanentError struct {
+    Err error
+}
+
+func (e *PermanentError) Error() string {
+    return e.Err.Error()
+}
+
+func (e *PermanentError) Unwrap() error {
+    return e.Err
+}
+
+// Permanent wraps err as a PermanentError. Providers use it for failures that
+// should not be retried.
+func Permanent(err error) error {
+    if err == nil {
+        return nil
+    }
+    return &PermanentError{Err: err}
+}
+
+// IsPermanent reports whether err is a failure that should not be retried
+func IsPermanent(err error) bool {
+    var permanent *PermanentError
+    return errors.As(err, &permanent)
+}
+
+// deliverChannel makes the first attempt at delivering a notification on one
+// channel and logs the outcome
+func (s *service) deliverChannel(ctx context.Context, channel DeliveryChannel, userID int64, notification *Notification) {
+    messageID, err := s.dispatch(ctx, channel, userID, notification)
+    if errors.Is(err, errNothingToSend) {
+        return
+    }
+
+    delivery := &Delivery{
+        NotificationID: notification.ID,
+        UserID:         userID,
+        Channel:        channel,
+        Attempts:       1,
+    }
+    delivery.record(messageID, err, time.Now())
+
+    if err := s.repo.CreateDelivery(ctx, delivery); err != nil {
+        log.Printf("Failed to log %s delivery of notification %d: %v", channel, notification.ID, err)
+    }
+}
+
+// RetryDeliveries retries queued deliveries that are due. Returns how many
+// were attempted.
+func (s *service) RetryDeliveries(ctx context.Context) (int, error) {
+    deliveries, err := s.repo.ClaimDueDeliveries(ctx, time.Now(), deliveryRetryLease, deliveryRetryBatchSize)
+    if err != nil {
+        return 0, fmt.Errorf("failed to claim deliveries: %w", err)
+    }
+
+    for _, delivery := range deliveries {
+        delivery.Attempts++
+
+        notification, err := s.repo.GetNotification(ctx, delivery.NotificationID)
+        if err == nil {
+            var messageID string
+            messageID, err = s.dispatch(ctx, delivery.Channel, delivery.UserID, notification)
+            if errors.Is(err, errNothingToSend) {
+                // The tokens or provider went away since the first attempt
+                err = Permanent(err)
+            }
+            delivery.record(messageID, err, time.Now())
+        } else {
+            delivery.record("", Permanent(fmt.Errorf("notification not found: %w", err)), time.Now())
+        }
+
+        if err := s.repo.UpdateDelivery(ctx, delivery); err != nil {
+            log.Printf("Failed to update delivery %d: %v", delivery.ID, err)
+        }
+    }
+
+    return len(deliveries), nil
+}
+
+// GetDeliveries lists logged deliveries for admins
+func (s *service) GetDeliveries(ctx context.Context, filter *DeliveryFilter) ([]*Delivery, error) {
+    if filter.Limit <= 0 || filter.Limit > 100 {
+        filter.Limit = 50
+    }
+    if filter.Offset < 0 {
+        filter.Offset = 0
+    }
+    return s.repo.GetDeliveries(ctx, filter)
+}
+
+// GetDeliveryHealth summarises deliveries per channel since the given time
+func (s *service) GetDeliveryHealth(ctx context.Context, since time.Time) (*DeliveryHealth, error) {
+    channels, err := s.repo.GetDeliveryHealth(ctx, since)
+    if err != nil {
+        return nil, err
+    }
+
+    for _, channel := range channels {
+        // Queued deliveries haven't finished, so they don't count either way
+        succeeded := channel.Sent + channel.Delivered
+        if finished := succeeded + channel.Failed + channel.Bounced; finished > 0 {
+            channel.SuccessRate = float64(succeeded) / float64(finished)
+        }
+    }
+
+    return &DeliveryHealth{Since: since, Channels: channels}, nil
+}
+
+// SetSMSCallbacks sets the parser SMS delivery status callbacks are read with
+func (s *service) SetSMSCallbacks(parser SMSCallbackParser) {
+    s.smsCallbacks = parser
+}
+
+// HandleSMSCallback records a provider's report on an SMS it sent. Reports
+// for messages that aren't in the log, such as OTPs, are ignored.
+func (s *service) HandleSMSCallback(ctx context.Context, provider string, req *sms.CallbackRequest) error {
+    if s.smsCallbacks == nil {
+        return sms.ErrUnknownProvider
+    }
+    
+    update, err := s.smsCallbacks.ParseCallback(provider, req)
+    if err != nil || update == nil || update.MessageID == "" {
+        return err
+    }
+    
+    var status DeliveryStatus
+    switch update.Status {
+    case sms.StatusDelivered:
+        status = DeliveryDelivered
+    case sms.StatusFailed:
+        status = DeliveryFailed
+    default:
+        // Sent is where the log already has it
+        return nil
+    }
+    
+    var lastError *string
+    if update.Error != "" {
+        lastError = &update.Error
+    }
+    if err := s.repo.UpdateDeliveryStatus(ctx, ChannelSMS, update.MessageID, status, lastError); err != nil {
+        return err
+    }
+    
+    deliveryAttemptsTotal.WithLabelValues(string(ChannelSMS), string(status)).Inc()
+    return nil
+}
+
+// dispatch sends a notification on one channel, returning the provider's
+// message ID
+func (s *service) dispatch(ctx context.Context, channel DeliveryChannel, userID int64, notification *Notification) (string, error) {
+    switch channel {
+    case ChannelPush:
+        return s.sendPushNotification(ctx, userID, notification)
+    case ChannelEmail:
+        return s.sendEmailNotification(ctx, userID, notification)
+    case ChannelSMS:
+        return s.sendSMSNotification(ctx, userID, notification)
+    default:
+        return "", errNothingToSend
+    }
+}
+
+// record applies the outcome of an attempt. Transient failures are queued
+// for a retry with exponential backoff until the attempts run out.
+func (d *Delivery) record(messageID string, err error, now time.Time) {
+    d.NextAttemptAt = nil
+    d.LastError = nil
+
+    switch {
+    case err == nil:
+        d.Status = DeliverySent
+        d.SentAt = &now
+        if messageID != "" {
+            d.ProviderMessageID = &messageID
+        }
+    case IsPermanent(err):
+        d.Status = DeliveryBounced
+    case d.Attempts >= maxDeliveryAttempts:
+        d.Status = DeliveryFailed
+    default:
+        d.Status = DeliveryQueued
+        next := now.Add(deliveryBackoff(d.Attempts))
+        d.NextAttemptAt = &next
+    }
+
+    if err != nil {
+        message := err.Error()
+        d.LastError = &message
+        log.Printf("Failed %s delivery attempt %d for notification %d: %v", d.Channel, d.Attempts, d.NotificationID, err)
+    }
+
+    deliveryAttemptsTotal.WithLabelValues(string(d.Channel), string(d.Status)).Inc()
+}
+
+// deliveryBackoff is the wait before the retry that follows the given attempt
+func deliveryBackoff(attempts int) time.Duration {
+    backoff := deliveryBaseBackoff
+    for i := 1; i < attempts; i++ {
+        backoff *= 2
+        if backoff >= deliveryMaxBackoff {
+            return deliveryMaxBackoff
+        }
+    }
+    return backoff
+}
@@ -3,14 +3,18 @@
 package notifications
 
 import (
-    "bytes"
     "context"
     "crypto/tls"
+    "errors"
     "fmt"
-    "html/template"
     "log"
-    "os"
+    "net/http"
+    "net/textproto"
+    "sync"
+    "time"
     
+    "github.com/sendgrid/sendgrid-go"
+    "github.com/sendgrid/sendgrid-go/helpers/mail"
     "gopkg.in/gomail.v2"
 )
 
@@ -26,18 +30,10 @@ type SMTPEmailService struct {
 }
 
 // NewSMTPEmailService creates a new SMTP email service
-func NewSMTPEmailService() (EmailService, error) {
-    host := os.Getenv("SMTP_HOST")
-    port := 587 // Default SMTP port
-    if portStr := os.Getenv("SMTP_PORT"); portStr != "" {
-        fmt.Sscanf(portStr, "%d", &port)
+func NewSMTPEmailService(host string, port int, username, password, from, fromName string) (EmailService, error) {
+    if port == 0 {
+        port = 587 // Default SMTP port
     }
-    
-    username := os.Getenv("SMTP_USERNAME")
-    password := os.Getenv("SMTP_PASSWORD")
-    from := os.Getenv("SMTP_FROM")
-    fromName := os.Getenv("SMTP_FROM_NAME")
-    
     if fromName == "" {
         fromName = "Kiekky"
     }
@@ -82,6 +78,11 @@ func (s *SMTPEmailService) SendEmail(ctx context.Context, notification *EmailNot
     // Send email
     if err := s.dialer.DialAndSend(m); err != nil {
         log.Printf("Failed to send email to %s: %v", notification.To, err)
+        // 5xx replies, such as an unknown mailbox, won't change on a retry
+        var smtpErr *textproto.Error
+        if errors.As(err, &smtpErr) && smtpErr.Code >= 500 {
+            return Permanent(err)
+        }
         return err
     }
     
@@ -103,30 +104,53 @@ func (s *SMTPEmailService) SendBatchEmails(ctx context.Context, notifications []
 
 // SendGridEmailService implements email notifications using SendGrid
 type SendGridEmailService struct {
-    apiKey string
-    from   string
+    client   *sendgrid.Client
+    from     string
+    fromName string
 }
 
 // NewSendGridEmailService creates a new SendGrid email service
-func NewSendGridEmailService() (EmailService, error) {
-    apiKey := os.Getenv("SENDGRID_API_KEY")
-    from := os.Getenv("SENDGRID_FROM_EMAIL")
-    
+func NewSendGridEmailService(apiKey, from, fromName string) (EmailService, error) {
     if apiKey == "" || from == "" {
         return nil, fmt.Errorf("incomplete SendGrid configuration")
     }
+    if fromName == "" {
+        fromName = "Kiekky"
+    }
     
     return &SendGridEmailService{
-        apiKey: apiKey,
-        from:   from,
+        client:   sendgrid.NewSendClient(apiKey),
+        from:     from,
+        fromName: fromName,
     }, nil
 }
 
 // SendEmail sends a single email via SendGrid
 func (s *SendGridEmailService) SendEmail(ctx context.Context, notification *EmailNotification) error {
-    // SendGrid implementation would go here
-    // This is a placeholder
-    log.Printf("SendGrid: Sending email to %s: %s", notification.To, notification.Subject)
+    message := mail.NewSingleEmail(
+        mail.NewEmail(s.fromName, s.from),
+        notification.Subject,
+        mail.NewEmail("", notification.To),
+        notification.Body,
+        notification.HTML,
+    )
+    
+    response, err := s.client.SendWithContext(ctx, message)
+    if err != nil {
+        return fmt.Errorf("failed to send email via SendGrid: %w", err)
+    }
+    
+    switch {
+    case response.StatusCode == http.StatusBadRequest:
+        // The message itself, usually the address, is invalid
+        return Permanent(fmt.Errorf("SendGrid rejected email: %s", response.Body))
+    case response.StatusCode >= 400:
+        return fmt.Errorf("SendGrid returned error status %d", response.StatusCode)
+    }
+    
+    if ids := response.Headers["X-Message-Id"]; len(ids) > 0 {
+        notification.MessageID = ids[0]
+    }
     return nil
 }
 
@@ -140,6 +164,110 @@ func (s *SendGridEmailService) SendBatchEmails(ctx context.Context, notification
     return nil
 }
 
+// FailoverEmailService sends through a primary service, switching to a
+// fallback when the primary errors. The primary is skipped for the cooldown
+// after an error. Permanent failures don't fail over; the fallback would
+// reject the same address.
+type FailoverEmailService struct {
+    primary  EmailService
+    fallback EmailService
+    cooldown time.Duration
+    
+    mu        sync.Mutex
+    downUntil time.Time
+}
+
+// NewFailoverEmailService creates a service that fails over from primary to
+// fallback
+func NewFailoverEmailService(primary, fallback EmailService, cooldown time.Duration) EmailService {
+    return &FailoverEmailService{
+        primary:  primary,
+        fallback: fallback,
+        cooldown: cooldown,
+    }
+}
+
+// SendEmail sends an email through whichever service is healthy
+func (s *FailoverEmailService) SendEmail(ctx context.Context, notification *EmailNotification) error {
+    s.mu.Lock()
+    primaryDown := time.Now().Before(s.downUntil)
+    s.mu.Unlock()
+    
+    if !primaryDown {
+        err := s.primary.SendEmail(ctx, notification)
+        if err == nil || IsPermanent(err) || ctx.Err() != nil {
+            return err
+        }
+        
+        s.mu.Lock()
+        s.downUntil = time.Now().Add(s.cooldown)
+        s.mu.Unlock()
+        log.Printf("Primary email service failed, failing over: %v", err)
+    }
+    
+    return s.fallback.SendEmail(ctx, notification)
+}
+
+// SendBatchEmails sends multiple emails, each failing over on its own
+func (s *FailoverEmailService) SendBatchEmails(ctx context.Context, notifications []*EmailNotification) error {
+    for _, notification := range notifications {
+        if err := s.SendEmail(ctx, notification); err != nil {
+            log.Printf("Failed to send email in batch: %v", err)
+        }
+    }
+    return nil
+}
+
+// ErrEmailSuppressed means the address bounced or complained before and is
+// no longer emailed
+var ErrEmailSuppressed = errors.New("email address is suppressed")
+
+// SuppressionChecker reports addresses that have bounced or complained and
+// must not be emailed
+type SuppressionChecker interface {
+    IsSuppressed(ctx context.Context, email string) (bool, error)
+}
+
+// SuppressedEmailService wraps an EmailService to refuse suppressed
+// addresses. The refusal is permanent, so the delivery is bounced rather
+// than retried.
+type SuppressedEmailService struct {
+    service      EmailService
+    suppressions SuppressionChecker
+}
+
+// NewSuppressedEmailService creates a service that checks suppressions
+// before sending through service
+func NewSuppressedEmailService(service EmailService, suppressions SuppressionChecker) EmailService {
+    return &SuppressedEmailService{
+        service:      service,
+        suppressions: suppressions,
+    }
+}
+
+// SendEmail sends an email unless its address is suppressed. If the check
+// fails the email is sent anyway.
+func (s *SuppressedEmailService) SendEmail(ctx context.Context, notification *EmailNotification) error {
+    suppressed, err := s.suppressions.IsSuppressed(ctx, notification.To)
+    if err != nil {
+        log.Printf("Failed to check email suppression for %s: %v", notification.To, err)
+    } else if suppressed {
+        return Permanent(ErrEmailSuppressed)
+    }
+    
+    return s.service.SendEmail(ctx, notification)
+}
+
+// SendBatchEmails sends multiple emails, skipping suppressed addresses
+func (s *SuppressedEmailService) SendBatchEmails(ctx context.Context, notifications []*EmailNotification) error {
+    for _, notification := range notifications {
+        if err := s.SendEmail(ctx, notification); err != nil {
+            log.Printf("Failed to send email in batch: %v", err)
+        }
+    }
+    return nil
+}
+
 // MockEmailService is a mock implementation for testing
 type MockEmailService struct {
     SentEmails []*EmailNotification
@@ -153,6 +281,7 @@ func NewMockEmailService() EmailService {
 
 func (m *MockEmailService) SendEmail(ctx context.Context, notification *EmailNotification) error {
     m.SentEmails = append(m.SentEmails, notification)
+    notification.MessageID = fmt.Sprintf("mock-email-%d", len(m.SentEmails))
     log.Printf("Mock: Sending email to %s: %s", notification.To, notification.Subject)
     return nil
 }
@@ -163,84 +292,3 @@ func (m *MockEmailService) SendBatchEmails(ctx context.Context, notifications []
     }
     return nil
 }
-
-// Email templates
-
-const baseEmailTemplate = `
-<!DOCTYPE html>
-<html>
-<head>
-    <meta charset="UTF-8">
-    <meta name="viewport" content="width=device-width, initial-scale=1.0">
-    <title>{{.Title}}</title>
-    <style>
-        body {
-            font-family: -apple-system, BlinkMacSystemFont, 'Segoe UI', Roboto, 'Helvetica Neue', Arial, sans-serif;
-            line-height: 1.6;
-            color: #333;
-            max-width: 600px;
-            margin: 0 auto;
-            padding: 20px;
-        }
-        .header {
-            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
-            color: white;
-            padding: 30px;
-            text-align: center;
-            border-radius: 10px 10px 0 0;
-        }
-        .content {
-            background: white;
-            padding: 30px;
-            border: 1px solid #e0e0e0;
-            border-radius: 0 0 10px 10px;
-        }
-        .button {
-            display: inline-block;
-            padding: 12px 30px;
-            background: linear-gradient(135deg, #667eea 0%, #764ba2 100%);
-            color: white;
-            text-decoration: none;
-            border-radius: 5px;
-            margin: 20px 0;
-        }
-        .footer {
-            text-align: center;
-            padding: 20px;
-            color: #666;
-            font-size: 14px;
-        }
-    </style>
-</head>
-<body>
-    <div class="header">
-        <h1>{{.Title}}</h1>
-    </div>
-    <div class="content">
-        {{.Content}}
-    </div>
-    <div class="footer">
-        <p>© 2024 Kiekky. All rights reserved.</p>
-        <p>
-            <a href="{{.UnsubscribeURL}}">Unsubscribe</a> | 
-            <a href="{{.PreferencesURL}}">Update Preferences</a>
-        </p>
-    </div>
-</body>
-</html>
-`
-
-// RenderEmailTemplate renders an email template with data
-func RenderEmailTemplate(templateName string, data map[string]interface{}) (string, error) {
-    tmpl, err := template.New("email").Parse(baseEmailTemplate)
-    if err != nil {
-        return "", err
-    }
-    
-    var buf bytes.Buffer
-    if err := tmpl.Execute(&buf, data); err != nil {
-        return "", err
-    }
-    
-    return buf.String(), nil
-}
\ No newline at end of file
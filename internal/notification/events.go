@@ -0,0 +1,61 @@
+// internal/notification/events.go
+// Notifications sent in response to other modules' domain events
+
+package notifications
+
+import (
+    "context"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+// SubscribeEvents subscribes notifications to the events it notifies about
+func (s *service) SubscribeEvents(bus *events.Bus) {
+    events.Subscribe(bus, "notifications", s.onPostLiked)
+    events.Subscribe(bus, "notifications", s.onPostReposted)
+    events.Subscribe(bus, "notifications", s.onPostPublished)
+    events.Subscribe(bus, "notifications", s.onMatchCreated)
+    events.Subscribe(bus, "notifications", s.onDateRequestCreated)
+    events.Subscribe(bus, "notifications", s.onHotpicksReady)
+    events.Subscribe(bus, "notifications", s.onContactJoined)
+    events.Subscribe(bus, "notifications", s.onStoryReplied)
+}
+
+func (s *service) onPostLiked(ctx context.Context, e events.PostLiked) error {
+    if e.UserID == e.OwnerID {
+        return nil
+    }
+    return s.SendLikeNotification(ctx, e.UserID, e.OwnerID, e.PostID)
+}
+
+func (s *service) onPostReposted(ctx context.Context, e events.PostReposted) error {
+    return s.SendRepostNotification(ctx, e.ReposterID, e.OwnerID, e.PostID, e.RepostID)
+}
+
+func (s *service) onPostPublished(ctx context.Context, e events.PostPublished) error {
+    return s.SendPostPublishedNotification(ctx, e.UserID, e.PostID)
+}
+
+func (s *service) onMatchCreated(ctx context.Context, e events.MatchCreated) error {
+    return s.SendMatchNotification(ctx, e.User1ID, e.User2ID)
+}
+
+func (s *service) onDateRequestCreated(ctx context.Context, e events.DateRequestCreated) error {
+    return s.SendDateRequestNotification(ctx, e.SenderID, e.ReceiverID, e.RequestID)
+}
+
+func (s *service) onHotpicksReady(ctx context.Context, e events.HotpicksReady) error {
+    return s.SendHotpicksNotification(ctx, e.UserID, e.Count)
+}
+
+func (s *service) onContactJoined(ctx context.Context, e events.ContactJoined) error {
+    return s.SendContactJoinedNotification(ctx, e.UserID, e.ContactID)
+}
+
+func (s *service) onStoryReplied(ctx context.Context, e events.StoryReplied) error {
+    reply := e.Message
+    if reply == "" {
+        reply = e.Reaction
+    }
+    return s.SendStoryReplyNotification(ctx, e.ReplierID, e.StoryOwnerID, e.StoryID, reply)
+}
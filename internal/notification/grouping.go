@@ -0,0 +1,123 @@
+// internal/notification/grouping.go
+
+package notifications
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "time"
+)
+
+// defaultGroupWindow is how long a grouped notification keeps collecting
+// activity after the last one, and how often it may be pushed again
+const defaultGroupWindow = 1 * time.Hour
+
+// maxGroupActors is how many recent actors a grouped notification keeps
+const maxGroupActors = 10
+
+// groupVerbs are the notification types that collapse, with how the
+// grouped message describes the activity
+var groupVerbs = map[NotificationType]string{
+    TypeLike:      "liked your post",
+    TypeFollow:    "started following you",
+    TypeStoryView: "viewed your story",
+}
+
+// SetGroupingWindow sets how long likes, follows and views keep collapsing
+// into the same notification. Zero turns grouping off.
+func (s *service) SetGroupingWindow(window time.Duration) {
+    s.groupWindow = window
+}
+
+// groupOf returns the group a notification collapses into and the user who
+// caused it. Likes group per post, story views per story, follows per user.
+func groupOf(req *CreateNotificationRequest) (string, int64, bool) {
+    actorID, ok := actorIDOf(req.Data)
+    if !ok {
+        return "", 0, false
+    }
+
+    switch req.Type {
+    case TypeLike:
+        if postID, ok := int64Of(req.Data["post_id"]); ok {
+            return fmt.Sprintf("like:post:%d", postID), actorID, true
+        }
+    case TypeStoryView:
+        if storyID, ok := int64Of(req.Data["story_id"]); ok {
+            return fmt.Sprintf("story_view:story:%d", storyID), actorID, true
+        }
+    case TypeFollow:
+        return "follow", actorID, true
+    }
+    return "", 0, false
+}
+
+// collapse folds a notification into the recipient's open group for the same
+// object, if there is one. The group is pushed again at most once per window.
+func (s *service) collapse(ctx context.Context, req *CreateNotificationRequest, groupKey string, actorID int64, prefs *NotificationPreferences) (*Notification, bool) {
+    since := time.Now().Add(-s.groupWindow)
+
+    notification, err := s.repo.AddToGroup(ctx, req.UserID, groupKey, actorID, since)
+    if err != nil {
+        log.Printf("Failed to group notification %s for user %d: %v", groupKey, req.UserID, err)
+        return nil, false
+    }
+    if notification == nil {
+        return nil, false
+    }
+
+    notification.Title = req.Title
+    notification.Message = s.groupedMessage(ctx, notification)
+    if err := s.repo.UpdateGroupContent(ctx, notification.ID, notification.Title, notification.Message); err != nil {
+        log.Printf("Failed to update grouped notification %d: %v", notification.ID, err)
+    }
+
+    // Only push is repeated; a grouped update is never worth an email or SMS
+    if !hasChannel(req.Channels, ChannelPush) && len(req.Channels) > 0 {
+        return notification, true
+    }
+    claimed, err := s.repo.ClaimGroupPush(ctx, notification.ID, since)
+    if err != nil {
+        log.Printf("Failed to claim push for grouped notification %d: %v", notification.ID, err)
+    }
+    if claimed {
+        s.sendOnChannels(ctx, notification, []DeliveryChannel{ChannelPush}, prefs)
+    }
+
+    return notification, true
+}
+
+// groupedMessage describes a group, e.g. "Ada and 5 others liked your post"
+func (s *service) groupedMessage(ctx context.Context, notification *Notification) string {
+    verb := groupVerbs[notification.Type]
+    if len(notification.ActorIDs) == 0 {
+        return notification.Message
+    }
+
+    latestID := notification.ActorIDs[0]
+    name := fmt.Sprintf("User %d", latestID)
+    if s.userInfo != nil {
+        if actor, err := s.userInfo.GetUserInfo(ctx, latestID); err == nil && actor != nil {
+            name = actor.DisplayName
+        }
+    }
+
+    switch others := notification.ActorCount - 1; {
+    case others <= 0:
+        return fmt.Sprintf("%s %s", name, verb)
+    case others == 1:
+        return fmt.Sprintf("%s and 1 other %s", name, verb)
+    default:
+        return fmt.Sprintf("%s and %d others %s", name, others, verb)
+    }
+}
+
+func hasChannel(channels []DeliveryChannel, channel DeliveryChannel) bool {
+    for _, c := range channels {
+        if c == channel {
+            return true
+        }
+    }
+    return false
+}
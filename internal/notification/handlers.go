@@ -3,26 +3,47 @@
 package notifications
 
 import (
+    "bytes"
     "encoding/json"
+    "errors"
+    "io"
+    "log"
     "net/http"
     "strconv"
     "time"
     
     "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/sms"
     "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
 )
 
+// maxCallbackSize bounds provider callback bodies; delivery reports are tiny
+const maxCallbackSize = 64 << 10
+
 type Handler struct {
     service Service
+    actions http.Handler
 }
 
 func NewHandler(service Service) *Handler {
     return &Handler{service: service}
 }
 
+// SetActionRouter sets the router notification actions are taken through,
+// so an action runs exactly as if the client had called its endpoint.
+// Without it actions can only be taken by the client.
+func (h *Handler) SetActionRouter(router http.Handler) {
+    h.actions = router
+}
+
 // GetNotifications retrieves notifications for the authenticated user
 func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     // Parse query parameters
     limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -42,9 +63,30 @@ func (h *Handler) GetNotifications(w http.ResponseWriter, r *http.Request) {
     utils.RespondWithJSON(w, http.StatusOK, response)
 }
 
+// GetUnreadCount returns the user's unread notification count for badges
+func (h *Handler) GetUnreadCount(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    count, err := h.service.GetUnreadCount(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get unread count")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, count)
+}
+
 // GetNotification retrieves a specific notification
 func (h *Handler) GetNotification(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     notificationID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -70,7 +112,11 @@ func (h *Handler) GetNotification(w http.ResponseWriter, r *http.Request) {
 
 // MarkAsRead marks a notification as read
 func (h *Handler) MarkAsRead(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     notificationID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -91,7 +137,11 @@ func (h *Handler) MarkAsRead(w http.ResponseWriter, r *http.Request) {
 
 // MarkAllAsRead marks all notifications as read for the user
 func (h *Handler) MarkAllAsRead(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     if err := h.service.MarkAllAsRead(r.Context(), userID); err != nil {
         utils.RespondWithError(w, http.StatusInternalServerError, "Failed to mark all as read")
@@ -105,7 +155,11 @@ func (h *Handler) MarkAllAsRead(w http.ResponseWriter, r *http.Request) {
 
 // DeleteNotification deletes a notification
 func (h *Handler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     notificationID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -124,19 +178,114 @@ func (h *Handler) DeleteNotification(w http.ResponseWriter, r *http.Request) {
     })
 }
 
+// ExecuteAction takes one of a notification's actions for the user and
+// marks the notification resolved. An action that takes input reads it
+// from the body. If the action's endpoint fails, its response is passed
+// through and the notification keeps its actions.
+func (h *Handler) ExecuteAction(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    vars := mux.Vars(r)
+    
+    notificationID, err := strconv.ParseInt(vars["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid notification ID")
+        return
+    }
+    
+    var req ExecuteActionRequest
+    if r.ContentLength > 0 && !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    if h.actions == nil {
+        utils.RespondWithError(w, http.StatusNotImplemented, "Notification actions must be taken by the client")
+        return
+    }
+    
+    action, err := h.service.ResolveAction(r.Context(), notificationID, userID, vars["action"], req.Input)
+    if err != nil {
+        switch {
+        case errors.Is(err, ErrNotificationNotFound), errors.Is(err, ErrUnauthorized):
+            utils.RespondWithError(w, http.StatusNotFound, "Notification not found")
+        case errors.Is(err, ErrActionNotFound):
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+        case errors.Is(err, ErrActionTaken):
+            utils.RespondWithError(w, http.StatusConflict, err.Error())
+        case errors.Is(err, ErrActionInputRequired):
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to take notification action")
+        }
+        return
+    }
+    
+    result, err := h.takeAction(r, action)
+    if err != nil || result.status < 200 || result.status >= 300 {
+        if err := h.service.ReleaseAction(r.Context(), notificationID, action.ID); err != nil {
+            log.Printf("Failed to release action %s on notification %d: %v", action.ID, notificationID, err)
+        }
+        if err != nil {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to take notification action")
+            return
+        }
+        
+        w.Header().Set("Content-Type", result.header.Get("Content-Type"))
+        w.WriteHeader(result.status)
+        w.Write(result.body.Bytes())
+        return
+    }
+    
+    notification, err := h.service.GetNotification(r.Context(), notificationID, userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get notification")
+        return
+    }
+    
+    response := &ActionResult{Notification: notification, Status: result.status}
+    if json.Valid(result.body.Bytes()) {
+        response.Response = result.body.Bytes()
+    }
+    utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
+// takeAction calls an action's endpoint through the router with the
+// original request's credentials
+func (h *Handler) takeAction(r *http.Request, action *NotificationAction) (*actionRecorder, error) {
+    body, err := json.Marshal(action.Payload)
+    if err != nil {
+        return nil, err
+    }
+    
+    req, err := http.NewRequestWithContext(r.Context(), action.Method, action.Endpoint, bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    req.Header = r.Header.Clone()
+    req.Header.Set("Content-Type", "application/json")
+    req.Header.Del("Content-Length")
+    req.RemoteAddr = r.RemoteAddr
+    req.Host = r.Host
+    req.TLS = r.TLS
+    
+    recorder := newActionRecorder()
+    h.actions.ServeHTTP(recorder, req)
+    return recorder, nil
+}
+
 // RegisterPushToken registers a device push token
 func (h *Handler) RegisterPushToken(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
-    
-    var req RegisterPushTokenRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
         return
     }
     
-    // Validate request
-    if req.Token == "" || req.Platform == "" || req.DeviceID == "" {
-        utils.RespondWithError(w, http.StatusBadRequest, "Token, platform, and device ID are required")
+    var req RegisterPushTokenRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -168,9 +317,79 @@ func (h *Handler) UnregisterPushToken(w http.ResponseWriter, r *http.Request) {
     })
 }
 
+// GetWebPushConfig returns the VAPID public key for the browser client to
+// subscribe with
+func (h *Handler) GetWebPushConfig(w http.ResponseWriter, r *http.Request) {
+    config, err := h.service.GetWebPushConfig(r.Context())
+    if err != nil {
+        utils.RespondWithError(w, http.StatusNotFound, "Web push is not available")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, config)
+}
+
+// RegisterWebPushSubscription stores the browser's push subscription
+func (h *Handler) RegisterWebPushSubscription(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    var req RegisterWebPushRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    if err := h.service.RegisterWebPushSubscription(r.Context(), userID, &req); err != nil {
+        switch {
+        case errors.Is(err, ErrWebPushDisabled):
+            utils.RespondWithError(w, http.StatusNotFound, "Web push is not available")
+        case errors.Is(err, ErrInvalidSubscription):
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to register web push subscription")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+        "message": "Web push subscription registered successfully",
+    })
+}
+
+// UnregisterWebPushSubscription removes the browser's push subscription
+func (h *Handler) UnregisterWebPushSubscription(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    endpoint := r.URL.Query().Get("endpoint")
+    if endpoint == "" {
+        utils.RespondWithError(w, http.StatusBadRequest, "Endpoint is required")
+        return
+    }
+    
+    if err := h.service.UnregisterWebPushSubscription(r.Context(), userID, endpoint); err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to unregister web push subscription")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+        "message": "Web push subscription unregistered successfully",
+    })
+}
+
 // GetPreferences retrieves notification preferences
 func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     preferences, err := h.service.GetPreferences(r.Context(), userID)
     if err != nil {
@@ -183,15 +402,22 @@ func (h *Handler) GetPreferences(w http.ResponseWriter, r *http.Request) {
 
 // UpdatePreferences updates notification preferences
 func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     var req UpdatePreferencesRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
     if err := h.service.UpdatePreferences(r.Context(), userID, &req); err != nil {
+        if err == ErrInvalidQuietHours || err == ErrInvalidTimezone {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+            return
+        }
         utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update preferences")
         return
     }
@@ -203,11 +429,8 @@ func (h *Handler) UpdatePreferences(w http.ResponseWriter, r *http.Request) {
 
 // SendNotification sends a notification (admin only)
 func (h *Handler) SendNotification(w http.ResponseWriter, r *http.Request) {
-    // TODO: Add admin authorization check
-    
     var req CreateNotificationRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -222,11 +445,8 @@ func (h *Handler) SendNotification(w http.ResponseWriter, r *http.Request) {
 
 // BroadcastNotification broadcasts a notification to multiple users (admin only)
 func (h *Handler) BroadcastNotification(w http.ResponseWriter, r *http.Request) {
-    // TODO: Add admin authorization check
-    
     var req BroadcastNotificationRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -242,11 +462,8 @@ func (h *Handler) BroadcastNotification(w http.ResponseWriter, r *http.Request)
 
 // ScheduleNotification schedules a notification (admin only)
 func (h *Handler) ScheduleNotification(w http.ResponseWriter, r *http.Request) {
-    // TODO: Add admin authorization check
-    
     var req ScheduleNotificationRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -267,8 +484,11 @@ func (h *Handler) ScheduleNotification(w http.ResponseWriter, r *http.Request) {
 
 // CancelScheduledNotification cancels a scheduled notification (admin only)
 func (h *Handler) CancelScheduledNotification(w http.ResponseWriter, r *http.Request) {
-    // TODO: Add admin authorization check
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     scheduledID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -287,9 +507,311 @@ func (h *Handler) CancelScheduledNotification(w http.ResponseWriter, r *http.Req
     })
 }
 
+// GetTemplates lists stored notification templates (admin only)
+func (h *Handler) GetTemplates(w http.ResponseWriter, r *http.Request) {
+    templates, err := h.service.GetTemplates(r.Context())
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get templates")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, templates)
+}
+
+// PreviewTemplateUpdates shows the diff between stored and default templates (admin only)
+func (h *Handler) PreviewTemplateUpdates(w http.ResponseWriter, r *http.Request) {
+    changes, err := h.service.PreviewTemplateUpdates(r.Context())
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to preview template updates")
+        return
+    }
+    
+    pending := 0
+    for _, change := range changes {
+        if change.Action == TemplateActionCreate || change.Action == TemplateActionUpgrade {
+            pending++
+        }
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "changes": changes,
+        "pending": pending,
+    })
+}
+
+// ApplyTemplateUpdates applies pending default template updates (admin only)
+func (h *Handler) ApplyTemplateUpdates(w http.ResponseWriter, r *http.Request) {
+    var req ApplyTemplatesRequest
+    if r.ContentLength > 0 && !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    applied, err := h.service.ApplyTemplateUpdates(r.Context(), &req)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to apply template updates")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "applied": applied,
+        "count":   len(applied),
+    })
+}
+
+// GetTemplateVersions lists the version history of a template (admin only)
+func (h *Handler) GetTemplateVersions(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    
+    versions, err := h.service.GetTemplateVersions(r.Context(), NotificationType(vars["type"]), vars["language"])
+    if err != nil {
+        if err == ErrTemplateNotFound {
+            utils.RespondWithError(w, http.StatusNotFound, "Template not found")
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get template versions")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, versions)
+}
+
+// RollbackTemplate restores an earlier version of a template (admin only)
+func (h *Handler) RollbackTemplate(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    
+    var req RollbackTemplateRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    template, err := h.service.RollbackTemplate(r.Context(), NotificationType(vars["type"]), vars["language"], req.Version)
+    if err != nil {
+        if err == ErrTemplateNotFound {
+            utils.RespondWithError(w, http.StatusNotFound, "Template version not found")
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to roll back template")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, template)
+}
+
+// GetDeliveries lists notification deliveries, optionally filtered by
+// status, channel or user (admin only)
+func (h *Handler) GetDeliveries(w http.ResponseWriter, r *http.Request) {
+    query := r.URL.Query()
+    
+    filter := &DeliveryFilter{
+        Status:  DeliveryStatus(query.Get("status")),
+        Channel: DeliveryChannel(query.Get("channel")),
+    }
+    filter.UserID, _ = strconv.ParseInt(query.Get("user_id"), 10, 64)
+    filter.Limit, _ = strconv.Atoi(query.Get("limit"))
+    filter.Offset, _ = strconv.Atoi(query.Get("offset"))
+    
+    deliveries, err := h.service.GetDeliveries(r.Context(), filter)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get deliveries")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, deliveries)
+}
+
+// GetDeliveryHealth summarises delivery outcomes per channel over the last
+// ?hours= hours, 24 by default (admin only)
+func (h *Handler) GetDeliveryHealth(w http.ResponseWriter, r *http.Request) {
+    hours, _ := strconv.Atoi(r.URL.Query().Get("hours"))
+    if hours <= 0 {
+        hours = 24
+    }
+    
+    health, err := h.service.GetDeliveryHealth(r.Context(), time.Now().Add(-time.Duration(hours)*time.Hour))
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get delivery health")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, health)
+}
+
+// PreviewCampaignAudience counts the users a segment currently selects
+// (admin only)
+func (h *Handler) PreviewCampaignAudience(w http.ResponseWriter, r *http.Request) {
+    var segment Segment
+    if !utils.DecodeAndValidate(w, r, &segment) {
+        return
+    }
+    
+    audience, err := h.service.PreviewCampaignAudience(r.Context(), &segment)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to preview campaign audience")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, audience)
+}
+
+// CreateCampaign schedules a campaign to a segment (admin only)
+func (h *Handler) CreateCampaign(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    var req CreateCampaignRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    campaign, err := h.service.CreateCampaign(r.Context(), userID, &req)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create campaign")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusCreated, campaign)
+}
+
+// GetCampaigns lists campaigns with their metrics (admin only)
+func (h *Handler) GetCampaigns(w http.ResponseWriter, r *http.Request) {
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+    
+    campaigns, err := h.service.GetCampaigns(r.Context(), limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get campaigns")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, campaigns)
+}
+
+// GetCampaign retrieves a campaign with its metrics (admin only)
+func (h *Handler) GetCampaign(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    
+    campaignID, err := strconv.ParseInt(vars["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid campaign ID")
+        return
+    }
+    
+    campaign, err := h.service.GetCampaign(r.Context(), campaignID)
+    if err != nil {
+        if errors.Is(err, ErrCampaignNotFound) {
+            utils.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get campaign")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, campaign)
+}
+
+// CancelCampaign stops a scheduled or sending campaign (admin only)
+func (h *Handler) CancelCampaign(w http.ResponseWriter, r *http.Request) {
+    vars := mux.Vars(r)
+    
+    campaignID, err := strconv.ParseInt(vars["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid campaign ID")
+        return
+    }
+    
+    if err := h.service.CancelCampaign(r.Context(), campaignID); err != nil {
+        switch {
+        case errors.Is(err, ErrCampaignNotFound):
+            utils.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+        case errors.Is(err, ErrCampaignFinished):
+            utils.RespondWithError(w, http.StatusConflict, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to cancel campaign")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+        "message": "Campaign cancelled",
+    })
+}
+
+// RecordCampaignEvent records the user opening or clicking a campaign
+// notification
+func (h *Handler) RecordCampaignEvent(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    vars := mux.Vars(r)
+    
+    campaignID, err := strconv.ParseInt(vars["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid campaign ID")
+        return
+    }
+    
+    var req CampaignEventRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    if err := h.service.RecordCampaignEvent(r.Context(), userID, campaignID, req.Event); err != nil {
+        if errors.Is(err, ErrCampaignNotFound) {
+            utils.RespondWithError(w, http.StatusNotFound, "Campaign not found")
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to record campaign event")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+        "message": "Campaign event recorded",
+    })
+}
+
+// HandleSMSCallback records an SMS provider's delivery report
+func (h *Handler) HandleSMSCallback(w http.ResponseWriter, r *http.Request) {
+    provider := mux.Vars(r)["provider"]
+    
+    payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxCallbackSize))
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid payload")
+        return
+    }
+    
+    err = h.service.HandleSMSCallback(r.Context(), provider, &sms.CallbackRequest{
+        Payload: payload,
+        Header:  r.Header,
+        Query:   r.URL.Query(),
+    })
+    if err != nil {
+        switch {
+        case errors.Is(err, sms.ErrUnknownProvider):
+            utils.RespondWithError(w, http.StatusNotFound, "Unknown provider")
+        case errors.Is(err, sms.ErrInvalidSignature):
+            utils.RespondWithError(w, http.StatusUnauthorized, "Invalid signature")
+        default:
+            log.Printf("Failed to process %s SMS callback: %v", provider, err)
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to process callback")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]bool{"received": true})
+}
+
 // TestPushNotification sends a test push notification
 func (h *Handler) TestPushNotification(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     req := &CreateNotificationRequest{
         UserID:   userID,
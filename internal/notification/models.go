@@ -6,6 +6,8 @@ import (
     "database/sql/driver"
     "encoding/json"
     "time"
+    
+    "github.com/lib/pq"
 )
 
 // NotificationType represents different notification types
@@ -18,9 +20,14 @@ const (
     TypeFollow         NotificationType = "follow"
     TypeMessage        NotificationType = "message"
     TypeMatch          NotificationType = "match"
+    TypeDateRequest    NotificationType = "date_request"
+    TypeHotpicks       NotificationType = "hotpicks"
+    TypeContactJoined  NotificationType = "contact_joined"
     TypeStoryView      NotificationType = "story_view"
     TypeStoryReply     NotificationType = "story_reply"
     TypeMention        NotificationType = "mention"
+    TypeRepost         NotificationType = "repost"
+    TypePostPublished  NotificationType = "post_published"
     
     // System notifications
     TypeWelcome        NotificationType = "welcome"
@@ -29,8 +36,18 @@ const (
     TypeSecurity       NotificationType = "security"
     TypePromotion      NotificationType = "promotion"
     TypeMaintenance    NotificationType = "maintenance"
+    TypeWeeklyRecap    NotificationType = "weekly_recap"
 )
 
+// AllNotificationTypes lists every notification type, used to make sure each
+// one has a default template
+var AllNotificationTypes = []NotificationType{
+    TypeLike, TypeComment, TypeFollow, TypeMessage, TypeMatch, TypeDateRequest,
+    TypeHotpicks, TypeContactJoined, TypeStoryView, TypeStoryReply, TypeMention, TypeRepost, TypePostPublished,
+    TypeWelcome, TypeProfileUpdate, TypeVerification, TypeSecurity,
+    TypePromotion, TypeMaintenance, TypeWeeklyRecap,
+}
+
 // DeliveryChannel represents notification delivery channels
 type DeliveryChannel string
 
@@ -41,6 +58,19 @@ const (
     ChannelSMS     DeliveryChannel = "sms"
 )
 
+// DeliveryStatus is where one channel's delivery of a notification stands.
+// A queued delivery is waiting for its first attempt or a retry. Sent SMS
+// become delivered, or failed, when the provider reports back.
+type DeliveryStatus string
+
+const (
+    DeliveryQueued    DeliveryStatus = "queued"
+    DeliverySent      DeliveryStatus = "sent"
+    DeliveryDelivered DeliveryStatus = "delivered"
+    DeliveryFailed    DeliveryStatus = "failed"
+    DeliveryBounced   DeliveryStatus = "bounced"
+)
+
 // Platform represents device platforms
 type Platform string
 
@@ -50,6 +80,16 @@ const (
     PlatformWeb     Platform = "web"
 )
 
+// TokenType is the push provider that issued a device token, and so the one
+// that delivers to it
+type TokenType string
+
+const (
+    TokenTypeFCM     TokenType = "fcm"     // Firebase Cloud Messaging, any platform
+    TokenTypeAPNs    TokenType = "apns"    // Apple Push Notification service, iOS only
+    TokenTypeWebPush TokenType = "webpush" // A browser's push service; the token is the subscription
+)
+
 // Priority represents notification priority levels
 type Priority string
 
@@ -71,9 +111,37 @@ type Notification struct {
     ReadAt      *time.Time       `json:"read_at,omitempty" db:"read_at"`
     CreatedAt   time.Time        `json:"created_at" db:"created_at"`
     
+    // Grouped notifications collapse repeated activity on one object. ActorIDs
+    // holds the most recent actors first; ActorCount counts all of them.
+    GroupKey    *string          `json:"group_key,omitempty" db:"group_key"`
+    ActorIDs    pq.Int64Array    `json:"actor_ids,omitempty" db:"actor_ids"`
+    ActorCount  int              `json:"actor_count,omitempty" db:"actor_count"`
+    UpdatedAt   *time.Time       `json:"updated_at,omitempty" db:"updated_at"`
+    
+    // Set once one of the notification's actions has been taken, after
+    // which it offers none
+    ResolvedAt     *time.Time `json:"resolved_at,omitempty" db:"resolved_at"`
+    ResolvedAction *string    `json:"resolved_action,omitempty" db:"resolved_action"`
+    
     // Additional fields for response
     Actor       *NotificationActor `json:"actor,omitempty"`
     ActionURL   string            `json:"action_url,omitempty"`
+    Actions     []NotificationAction `json:"actions,omitempty"`
+}
+
+// NotificationAction is a button on a notification, such as Accept on a
+// date request. Taking it calls Endpoint with Payload as the user, either
+// from the client or through the notification's action endpoint. An
+// action with Input takes text from the user, which replaces the
+// "{input}" placeholder in Payload.
+type NotificationAction struct {
+    ID          string                 `json:"id"`
+    Label       string                 `json:"label"`
+    Method      string                 `json:"method"`
+    Endpoint    string                 `json:"endpoint"`
+    Payload     map[string]interface{} `json:"payload,omitempty"`
+    Input       bool                   `json:"input,omitempty"`
+    Destructive bool                   `json:"destructive,omitempty"`
 }
 
 // NotificationData represents additional notification data
@@ -115,6 +183,7 @@ type PushToken struct {
     ID        int64     `json:"id" db:"id"`
     UserID    int64     `json:"user_id" db:"user_id"`
     Platform  Platform  `json:"platform" db:"platform"`
+    TokenType TokenType `json:"token_type" db:"token_type"`
     Token     string    `json:"token" db:"token"`
     DeviceID  string    `json:"device_id" db:"device_id"`
     IsActive  bool      `json:"is_active" db:"is_active"`
@@ -140,35 +209,318 @@ type NotificationPreferences struct {
     StoryReplies    bool      `json:"story_replies" db:"story_replies"`
     Mentions        bool      `json:"mentions" db:"mentions"`
     Promotions      bool      `json:"promotions" db:"promotions"`
+    WeeklyRecap     bool      `json:"weekly_recap" db:"weekly_recap"`
+    ContactsJoined  bool      `json:"contacts_joined" db:"contacts_joined"`
+    
+    // Quiet hours, as HH:MM in Timezone. Push and SMS due inside the window
+    // are held until it ends; in-app notifications are not affected.
+    QuietHoursStart *string   `json:"quiet_hours_start,omitempty" db:"quiet_hours_start"`
+    QuietHoursEnd   *string   `json:"quiet_hours_end,omitempty" db:"quiet_hours_end"`
+    Timezone        string    `json:"timezone" db:"timezone"`
     
     UpdatedAt       time.Time `json:"updated_at" db:"updated_at"`
 }
 
+// Delivery records one channel's delivery of a notification
+type Delivery struct {
+    ID                int64           `json:"id" db:"id"`
+    NotificationID    int64           `json:"notification_id" db:"notification_id"`
+    UserID            int64           `json:"user_id" db:"user_id"`
+    Channel           DeliveryChannel `json:"channel" db:"channel"`
+    Status            DeliveryStatus  `json:"status" db:"status"`
+    ProviderMessageID *string         `json:"provider_message_id,omitempty" db:"provider_message_id"`
+    Attempts          int             `json:"attempts" db:"attempts"`
+    LastError         *string         `json:"last_error,omitempty" db:"last_error"`
+    NextAttemptAt     *time.Time      `json:"next_attempt_at,omitempty" db:"next_attempt_at"`
+    SentAt            *time.Time      `json:"sent_at,omitempty" db:"sent_at"`
+    CreatedAt         time.Time       `json:"created_at" db:"created_at"`
+    UpdatedAt         time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// DeliveryFilter narrows the deliveries listed for admins
+type DeliveryFilter struct {
+    Status  DeliveryStatus
+    Channel DeliveryChannel
+    UserID  int64
+    Limit   int
+    Offset  int
+}
+
+// ChannelHealth summarises one channel's deliveries over a period
+type ChannelHealth struct {
+    Channel     DeliveryChannel `json:"channel" db:"channel"`
+    Total       int             `json:"total" db:"total"`
+    Queued      int             `json:"queued" db:"queued"`
+    Sent        int             `json:"sent" db:"sent"`
+    Delivered   int             `json:"delivered" db:"delivered"`
+    Failed      int             `json:"failed" db:"failed"`
+    Bounced     int             `json:"bounced" db:"bounced"`
+    Retried     int             `json:"retried" db:"retried"`
+    SuccessRate float64         `json:"success_rate"`
+}
+
+// DeliveryHealth is delivery health per channel since a point in time
+type DeliveryHealth struct {
+    Since    time.Time        `json:"since"`
+    Channels []*ChannelHealth `json:"channels"`
+}
+
+// CampaignStatus is where a campaign is in its fan-out
+type CampaignStatus string
+
+const (
+    CampaignScheduled CampaignStatus = "scheduled"
+    CampaignSending   CampaignStatus = "sending"
+    CampaignSent      CampaignStatus = "sent"
+    CampaignCancelled CampaignStatus = "cancelled"
+)
+
+// Campaign event types reported by clients
+const (
+    CampaignEventOpen  = "open"
+    CampaignEventClick = "click"
+)
+
+// Segment picks a campaign's audience out of active users who allow
+// promotions. Empty fields don't narrow it.
+type Segment struct {
+    Countries        []string `json:"countries,omitempty" validate:"omitempty,dive,len=2"`
+    Genders          []string `json:"genders,omitempty" validate:"omitempty,dive,oneof=male female other"`
+    ActiveWithinDays int      `json:"active_within_days,omitempty" validate:"omitempty,min=1,max=365"`
+    InactiveForDays  int      `json:"inactive_for_days,omitempty" validate:"omitempty,min=1,max=365"`
+    Premium          *bool    `json:"premium,omitempty"`
+}
+
+// Scan implements sql.Scanner interface
+func (s *Segment) Scan(value interface{}) error {
+    if value == nil {
+        *s = Segment{}
+        return nil
+    }
+    
+    bytes, ok := value.([]byte)
+    if !ok {
+        return nil
+    }
+    
+    return json.Unmarshal(bytes, s)
+}
+
+// Value implements driver.Valuer interface
+func (s Segment) Value() (driver.Value, error) {
+    return json.Marshal(s)
+}
+
+// Campaign is a promotion sent to a segment, fanned out in batches by the
+// campaign job at RatePerMinute recipients a minute
+type Campaign struct {
+    ID            int64            `json:"id" db:"id"`
+    Name          string           `json:"name" db:"name"`
+    Title         string           `json:"title" db:"title"`
+    Message       string           `json:"message" db:"message"`
+    Data          NotificationData `json:"data" db:"data"`
+    Channels      DeliveryChannels `json:"channels" db:"channels"`
+    Segment       Segment          `json:"segment" db:"segment"`
+    Status        CampaignStatus   `json:"status" db:"status"`
+    ScheduledFor  time.Time        `json:"scheduled_for" db:"scheduled_for"`
+    RatePerMinute int              `json:"rate_per_minute" db:"rate_per_minute"`
+    AudienceSize  int              `json:"audience_size" db:"audience_size"` // When scheduled
+    SentCount     int              `json:"sent_count" db:"sent_count"`
+    LastUserID    int64            `json:"-" db:"last_user_id"` // Fan-out cursor
+    LastBatchAt   *time.Time       `json:"-" db:"last_batch_at"`
+    CreatedBy     *int64           `json:"created_by,omitempty" db:"created_by"`
+    CreatedAt     time.Time        `json:"created_at" db:"created_at"`
+    StartedAt     *time.Time       `json:"started_at,omitempty" db:"started_at"`
+    CompletedAt   *time.Time       `json:"completed_at,omitempty" db:"completed_at"`
+    
+    Metrics *CampaignMetrics `json:"metrics,omitempty" db:"-"`
+}
+
+// CampaignMetrics counts recipients who opened or clicked a campaign
+type CampaignMetrics struct {
+    Opens     int     `json:"opens" db:"opens"`
+    Clicks    int     `json:"clicks" db:"clicks"`
+    OpenRate  float64 `json:"open_rate"`
+    ClickRate float64 `json:"click_rate"`
+}
+
+// CampaignAudience is how many users a segment reaches right now
+type CampaignAudience struct {
+    Size int `json:"size"`
+}
+
+// CreateCampaignRequest represents request to schedule a campaign
+type CreateCampaignRequest struct {
+    Name          string            `json:"name" validate:"required,max=100"`
+    Title         string            `json:"title" validate:"required,max=200"`
+    Message       string            `json:"message" validate:"required"`
+    Data          NotificationData  `json:"data,omitempty"`
+    Channels      []DeliveryChannel `json:"channels" validate:"required,min=1,dive,oneof=in_app push email sms"`
+    Segment       Segment           `json:"segment"`
+    ScheduledFor  *time.Time        `json:"scheduled_for,omitempty"` // Now if unset
+    RatePerMinute int               `json:"rate_per_minute,omitempty" validate:"omitempty,min=10,max=100000"`
+}
+
+// CampaignEventRequest reports a user opening or clicking a campaign
+type CampaignEventRequest struct {
+    Event string `json:"event" validate:"required,oneof=open click"`
+}
+
+// RecapRecipient is a user due a weekly recap
+type RecapRecipient struct {
+    UserID       int64  `db:"user_id"`
+    Username     string `db:"username"`
+    DisplayName  string `db:"display_name"`
+    Email        string `db:"email"`
+    Timezone     string `db:"timezone"`
+    Language     string `db:"language"`
+    EmailEnabled bool   `db:"email_enabled"`
+}
+
+// WeeklyRecap is a user's activity over one week
+type WeeklyRecap struct {
+    UserID        int64      `json:"user_id"`
+    From          time.Time  `json:"from"`
+    To            time.Time  `json:"to"`
+    ProfileViews  int        `json:"profile_views"`
+    LikesReceived int        `json:"likes_received"`
+    NewMatches    int        `json:"new_matches"`
+    TopPost       *RecapPost `json:"top_post,omitempty"`
+}
+
+// RecapPost is the post that received the most likes during the recap week
+type RecapPost struct {
+    ID      int64  `json:"id" db:"id"`
+    Caption string `json:"caption" db:"caption"`
+    Likes   int    `json:"likes" db:"likes"`
+}
+
+// IsEmpty reports whether nothing happened during the week
+func (w *WeeklyRecap) IsEmpty() bool {
+    return w.ProfileViews == 0 && w.LikesReceived == 0 && w.NewMatches == 0
+}
+
 // ScheduledNotification represents a scheduled notification
 type ScheduledNotification struct {
-    ID           int64            `json:"id" db:"id"`
-    UserID       *int64           `json:"user_id,omitempty" db:"user_id"` // Null for broadcast
-    Type         NotificationType `json:"type" db:"type"`
-    Title        string           `json:"title" db:"title"`
-    Message      string           `json:"message" db:"message"`
-    Data         NotificationData `json:"data" db:"data"`
-    Channels     []DeliveryChannel `json:"channels" db:"channels"`
-    ScheduledFor time.Time        `json:"scheduled_for" db:"scheduled_for"`
-    Status       string           `json:"status" db:"status"` // pending, sent, failed, cancelled
-    SentAt       *time.Time       `json:"sent_at,omitempty" db:"sent_at"`
-    CreatedAt    time.Time        `json:"created_at" db:"created_at"`
+    ID             int64            `json:"id" db:"id"`
+    UserID         *int64           `json:"user_id,omitempty" db:"user_id"`                 // Null for broadcast
+    NotificationID *int64           `json:"notification_id,omitempty" db:"notification_id"` // Set when an existing notification's delivery was held for quiet hours
+    Type           NotificationType `json:"type" db:"type"`
+    Title          string           `json:"title" db:"title"`
+    Message        string           `json:"message" db:"message"`
+    Data           NotificationData `json:"data" db:"data"`
+    Channels       DeliveryChannels `json:"channels" db:"channels"`
+    ScheduledFor   time.Time        `json:"scheduled_for" db:"scheduled_for"`
+    Status         string           `json:"status" db:"status"` // pending, sent, failed, cancelled
+    SentAt         *time.Time       `json:"sent_at,omitempty" db:"sent_at"`
+    CreatedAt      time.Time        `json:"created_at" db:"created_at"`
+}
+
+// DeliveryChannels is a list of channels stored as JSON
+type DeliveryChannels []DeliveryChannel
+
+// Scan implements sql.Scanner interface
+func (dc *DeliveryChannels) Scan(value interface{}) error {
+    if value == nil {
+        *dc = nil
+        return nil
+    }
+    
+    bytes, ok := value.([]byte)
+    if !ok {
+        return nil
+    }
+    
+    return json.Unmarshal(bytes, dc)
+}
+
+// Value implements driver.Valuer interface
+func (dc DeliveryChannels) Value() (driver.Value, error) {
+    if dc == nil {
+        return "[]", nil
+    }
+    return json.Marshal(dc)
 }
 
 // NotificationTemplate represents a notification template
 type NotificationTemplate struct {
-    ID            int64            `json:"id" db:"id"`
-    Type          NotificationType `json:"type" db:"type"`
-    Language      string           `json:"language" db:"language"`
-    TitleTemplate string           `json:"title_template" db:"title_template"`
-    BodyTemplate  string           `json:"body_template" db:"body_template"`
-    Variables     []string         `json:"variables" db:"variables"`
-    CreatedAt     time.Time        `json:"created_at" db:"created_at"`
-    UpdatedAt     time.Time        `json:"updated_at" db:"updated_at"`
+    ID            int64             `json:"id" db:"id"`
+    Type          NotificationType  `json:"type" db:"type"`
+    Language      string            `json:"language" db:"language"`
+    TitleTemplate string            `json:"title_template" db:"title_template"`
+    BodyTemplate  string            `json:"body_template" db:"body_template"`
+    Variables     TemplateVariables `json:"variables" db:"variables"`
+    Version       int               `json:"version" db:"version"`
+    Pinned        bool              `json:"pinned" db:"pinned"` // Set by a rollback; seeding leaves pinned templates alone
+    CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+    UpdatedAt     time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// TemplateVariables represents the variable names a template expects
+type TemplateVariables []string
+
+// Scan implements sql.Scanner interface
+func (tv *TemplateVariables) Scan(value interface{}) error {
+    if value == nil {
+        *tv = TemplateVariables{}
+        return nil
+    }
+    
+    bytes, ok := value.([]byte)
+    if !ok {
+        return nil
+    }
+    
+    return json.Unmarshal(bytes, tv)
+}
+
+// Value implements driver.Valuer interface
+func (tv TemplateVariables) Value() (driver.Value, error) {
+    if tv == nil {
+        return "[]", nil
+    }
+    return json.Marshal(tv)
+}
+
+// TemplateVersion represents an archived revision of a notification template
+type TemplateVersion struct {
+    ID            int64             `json:"id" db:"id"`
+    Type          NotificationType  `json:"type" db:"type"`
+    Language      string            `json:"language" db:"language"`
+    Version       int               `json:"version" db:"version"`
+    TitleTemplate string            `json:"title_template" db:"title_template"`
+    BodyTemplate  string            `json:"body_template" db:"body_template"`
+    Variables     TemplateVariables `json:"variables" db:"variables"`
+    CreatedAt     time.Time         `json:"created_at" db:"created_at"`
+}
+
+// TemplateAction describes what seeding would do with a default template
+type TemplateAction string
+
+const (
+    TemplateActionCreate    TemplateAction = "create"
+    TemplateActionUpgrade   TemplateAction = "upgrade"
+    TemplateActionUnchanged TemplateAction = "unchanged"
+    TemplateActionPinned    TemplateAction = "pinned"
+)
+
+// TemplatePreview represents a template rendered with sample data
+type TemplatePreview struct {
+    Title string `json:"title"`
+    Body  string `json:"body"`
+}
+
+// TemplateChange represents the difference between a stored template and
+// the default shipped with the current build
+type TemplateChange struct {
+    Type           NotificationType      `json:"type"`
+    Language       string                `json:"language"`
+    Action         TemplateAction        `json:"action"`
+    CurrentVersion int                   `json:"current_version"`
+    NewVersion     int                   `json:"new_version"`
+    Current        *NotificationTemplate `json:"current,omitempty"`
+    Proposed       *NotificationTemplate `json:"proposed"`
+    Preview        *TemplatePreview      `json:"preview,omitempty"`
 }
 
 // EmailNotification represents an email notification
@@ -179,6 +531,9 @@ type EmailNotification struct {
     HTML        string
     TemplateID  string
     Variables   map[string]interface{}
+    
+    // MessageID is set by the provider after a successful send, if it has one
+    MessageID   string
 }
 
 // SMSNotification represents an SMS notification
@@ -187,6 +542,9 @@ type SMSNotification struct {
     Message     string
     TemplateID  string
     Variables   map[string]interface{}
+    
+    // MessageID is set by the provider after a successful send, if it has one
+    MessageID   string
 }
 
 // PushNotification represents a push notification
@@ -200,6 +558,17 @@ type PushNotification struct {
     Priority    Priority
     CollapseKey string
     Image       string
+    ChannelID   string // Android notification channel
+    Category    string // iOS notification category
+    TokenType   TokenType // Provider that issued Tokens, which all share one
+    
+    // MessageID is set by the provider after a successful send. For several
+    // tokens it lists the IDs of the messages that were accepted.
+    MessageID   string
+    
+    // InvalidTokens is set by the provider to the tokens it says are no
+    // longer registered, which should not be sent to again
+    InvalidTokens []string
 }
 
 // CreateNotificationRequest represents request to create a notification
@@ -212,6 +581,20 @@ type CreateNotificationRequest struct {
     Channels []DeliveryChannel `json:"channels,omitempty"`
 }
 
+// ExecuteActionRequest carries the text for an action that takes input
+type ExecuteActionRequest struct {
+    Input string `json:"input,omitempty" validate:"max=5000"`
+}
+
+// ActionResult is the outcome of an action taken through the action
+// endpoint: the target endpoint's status and response, and the
+// notification, now resolved
+type ActionResult struct {
+    Notification *Notification   `json:"notification"`
+    Status       int             `json:"status"`
+    Response     json.RawMessage `json:"response,omitempty"`
+}
+
 // BroadcastNotificationRequest represents request to broadcast notifications
 type BroadcastNotificationRequest struct {
     UserIDs  []int64          `json:"user_ids,omitempty"` // Empty means all users
@@ -238,6 +621,22 @@ type RegisterPushTokenRequest struct {
     Platform Platform `json:"platform" validate:"required,oneof=ios android web"`
     Token    string   `json:"token" validate:"required"`
     DeviceID string   `json:"device_id" validate:"required"`
+    
+    // TokenType says who issued the token. Without it iOS tokens are taken
+    // to be the configured iOS provider's and the rest FCM's.
+    TokenType TokenType `json:"token_type,omitempty" validate:"omitempty,oneof=fcm apns"`
+}
+
+// RegisterWebPushRequest is a browser's PushSubscription, as its toJSON
+// gives it
+type RegisterWebPushRequest struct {
+    WebPushSubscription
+    DeviceID string `json:"device_id,omitempty"`
+}
+
+// WebPushConfig is what the browser client needs to subscribe
+type WebPushConfig struct {
+    PublicKey string `json:"public_key"`
 }
 
 // UpdatePreferencesRequest represents request to update notification preferences
@@ -254,6 +653,24 @@ type UpdatePreferencesRequest struct {
     StoryReplies    *bool `json:"story_replies,omitempty"`
     Mentions        *bool `json:"mentions,omitempty"`
     Promotions      *bool `json:"promotions,omitempty"`
+    WeeklyRecap     *bool `json:"weekly_recap,omitempty"`
+    ContactsJoined  *bool `json:"contacts_joined,omitempty"`
+    
+    // Quiet hours as HH:MM. Set both to an empty string to turn them off.
+    QuietHoursStart *string `json:"quiet_hours_start,omitempty"`
+    QuietHoursEnd   *string `json:"quiet_hours_end,omitempty"`
+    Timezone        *string `json:"timezone,omitempty"`
+}
+
+// ApplyTemplatesRequest represents request to apply pending default template updates
+type ApplyTemplatesRequest struct {
+    Types    []NotificationType `json:"types,omitempty"` // Empty means all pending changes
+    Language string             `json:"language,omitempty" validate:"omitempty,max=10"`
+}
+
+// RollbackTemplateRequest represents request to roll a template back to an earlier version
+type RollbackTemplateRequest struct {
+    Version int `json:"version" validate:"required,min=1"`
 }
 
 // NotificationsResponse represents paginated notifications response
@@ -0,0 +1,182 @@
+// internal/notification/payload.go
+
+package notifications
+
+import (
+    "encoding/json"
+    "fmt"
+    "strconv"
+)
+
+// DeepLinkScheme prefixes the deep links pushes carry, which the apps open
+// on tap
+const DeepLinkScheme = "kiekky://"
+
+// Android notification channels. The apps create these on first launch so
+// users can mute each kind separately.
+const (
+    ChannelMessages = "messages"
+    ChannelMatches  = "matches"
+    ChannelSocial   = "social"
+    ChannelAccount  = "account"
+    ChannelUpdates  = "updates"
+)
+
+// iOS notification categories, which decide the actions shown on a push
+const (
+    CategoryMessage     = "MESSAGE"      // Reply, mark read
+    CategoryMatch       = "MATCH"        // Say hi
+    CategoryPost        = "POST"         // View, like
+    CategoryDateRequest = "DATE_REQUEST" // Accept, decline
+)
+
+// PushPayload is the data a push carries so the app can act on a tap
+// without fetching the notification first. Messaging sends its pushes with
+// the same payload, without a NotificationID.
+type PushPayload struct {
+    NotificationID int64
+    Type           NotificationType
+    DeepLink       string
+    ImageURL       string
+    CollapseKey    string // A newer push with the same key replaces the older one
+    ChannelID      string // Android
+    Category       string // iOS
+
+    // Set on message pushes so the app can open the conversation at the
+    // message
+    ConversationID int64
+    MessageID      int64
+
+    // Buttons the app shows on the notification; see NotificationAction
+    Actions []NotificationAction
+}
+
+// Data flattens the payload into push data, which only carries strings.
+// Empty fields are left out.
+func (p *PushPayload) Data() map[string]string {
+    data := map[string]string{
+        "type":      string(p.Type),
+        "deep_link": p.DeepLink,
+    }
+    if p.NotificationID > 0 {
+        data["notification_id"] = strconv.FormatInt(p.NotificationID, 10)
+    }
+    for key, value := range map[string]string{
+        "image_url":    p.ImageURL,
+        "collapse_key": p.CollapseKey,
+        "channel_id":   p.ChannelID,
+        "category":     p.Category,
+    } {
+        if value != "" {
+            data[key] = value
+        }
+    }
+    if p.ConversationID > 0 {
+        data["conversation_id"] = strconv.FormatInt(p.ConversationID, 10)
+    }
+    if p.MessageID > 0 {
+        data["message_id"] = strconv.FormatInt(p.MessageID, 10)
+    }
+    if len(p.Actions) > 0 {
+        if actions, err := json.Marshal(p.Actions); err == nil {
+            data["actions"] = string(actions)
+        }
+    }
+    return data
+}
+
+// pushPayloadFor builds the push payload for a notification from its type
+// and data
+func pushPayloadFor(notification *Notification) *PushPayload {
+    payload := &PushPayload{
+        NotificationID: notification.ID,
+        Type:           notification.Type,
+        DeepLink:       DeepLinkScheme + "notifications",
+        ChannelID:      ChannelUpdates,
+    }
+    if path := linkPath(notification); path != "" {
+        payload.DeepLink = DeepLinkScheme + path
+    }
+    if image, ok := notification.Data["image_url"].(string); ok {
+        payload.ImageURL = image
+    }
+    if notification.GroupKey != nil {
+        payload.CollapseKey = *notification.GroupKey
+    }
+
+    switch notification.Type {
+    case TypeMessage, TypeStoryReply:
+        payload.ChannelID = ChannelMessages
+        payload.Category = CategoryMessage
+        payload.ConversationID, _ = int64Of(notification.Data["conversation_id"])
+        payload.MessageID, _ = int64Of(notification.Data["message_id"])
+        if payload.ConversationID > 0 {
+            payload.CollapseKey = fmt.Sprintf("conversation:%d", payload.ConversationID)
+        }
+    case TypeMatch:
+        payload.ChannelID = ChannelMatches
+        payload.Category = CategoryMatch
+    case TypeDateRequest:
+        payload.ChannelID = ChannelMatches
+        payload.Category = CategoryDateRequest
+    case TypeHotpicks:
+        payload.ChannelID = ChannelMatches
+    case TypeLike, TypeComment, TypeRepost, TypeMention, TypePostPublished:
+        payload.ChannelID = ChannelSocial
+        payload.Category = CategoryPost
+    case TypeFollow, TypeStoryView, TypeContactJoined:
+        payload.ChannelID = ChannelSocial
+    case TypeSecurity, TypeVerification:
+        payload.ChannelID = ChannelAccount
+    }
+    return payload
+}
+
+// linkPath is where a notification leads in the app, the same for web
+// action URLs and deep links, or "" for the notification list
+func linkPath(notification *Notification) string {
+    data := notification.Data
+    id := func(key string) (int64, bool) {
+        return int64Of(data[key])
+    }
+
+    switch notification.Type {
+    case TypeLike, TypeComment, TypeRepost, TypePostPublished:
+        if postID, ok := id("post_id"); ok {
+            return fmt.Sprintf("posts/%d", postID)
+        }
+    case TypeMention:
+        contentType, _ := data["content_type"].(string)
+        if contentID, ok := id("content_id"); ok && contentType != "" {
+            return fmt.Sprintf("%ss/%d", contentType, contentID)
+        }
+    case TypeFollow:
+        if followerID, ok := id("follower_id"); ok {
+            return fmt.Sprintf("users/%d", followerID)
+        }
+    case TypeContactJoined:
+        if contactID, ok := id("contact_id"); ok {
+            return fmt.Sprintf("users/%d", contactID)
+        }
+    case TypeStoryView:
+        if storyID, ok := id("story_id"); ok {
+            return fmt.Sprintf("stories/%d", storyID)
+        }
+    case TypeMessage, TypeStoryReply:
+        if conversationID, ok := id("conversation_id"); ok {
+            return fmt.Sprintf("messages/%d", conversationID)
+        }
+        return "messages"
+    case TypeMatch:
+        return "messages"
+    case TypeDateRequest:
+        return "dating/requests"
+    case TypeHotpicks:
+        return "dating/hotpicks"
+    case TypeSecurity:
+        return "settings/security"
+    case TypeWeeklyRecap:
+        return "recap"
+    }
+    return ""
+}
@@ -7,7 +7,7 @@ import (
     "errors"
     "fmt"
     "log"
-    "os"
+    "strings"
     
     firebase "firebase.google.com/go/v4"
     "firebase.google.com/go/v4/messaging"
@@ -19,12 +19,10 @@ type FCMPushService struct {
     client *messaging.Client
 }
 
-// NewFCMPushService creates a new FCM push service
-func NewFCMPushService(ctx context.Context) (PushService, error) {
-    // Get credentials from environment
-    credentialsPath := os.Getenv("FIREBASE_CREDENTIALS_PATH")
+// NewFCMPushService creates a new FCM push service from a service account
+// file or, if credentialsPath is empty, inline JSON
+func NewFCMPushService(ctx context.Context, credentialsPath, credentialsJSON string) (PushService, error) {
     if credentialsPath == "" {
-        credentialsJSON := os.Getenv("FIREBASE_CREDENTIALS_JSON")
         if credentialsJSON == "" {
             return nil, errors.New("FIREBASE_CREDENTIALS_PATH or FIREBASE_CREDENTIALS_JSON must be set")
         }
@@ -91,6 +89,7 @@ func (s *FCMPushService) SendPush(ctx context.Context, notification *PushNotific
         Notification: &messaging.AndroidNotification{
             Sound:       notification.Sound,
             ClickAction: "FLUTTER_NOTIFICATION_CLICK",
+            ChannelID:   notification.ChannelID,
         },
     }
     
@@ -108,11 +107,19 @@ func (s *FCMPushService) SendPush(ctx context.Context, notification *PushNotific
                     Title: notification.Title,
                     Body:  notification.Body,
                 },
-                Badge: &notification.Badge,
-                Sound: notification.Sound,
+                Badge:          &notification.Badge,
+                Sound:          notification.Sound,
+                Category:       notification.Category,
+                MutableContent: notification.Image != "",
             },
         },
     }
+    if notification.CollapseKey != "" {
+        apnsConfig.Headers["apns-collapse-id"] = notification.CollapseKey
+    }
+    if notification.Image != "" {
+        apnsConfig.FCMOptions = &messaging.APNSFCMOptions{ImageURL: notification.Image}
+    }
     
     // For single token, send individual message
     if len(notification.Tokens) == 1 {
@@ -127,10 +134,17 @@ func (s *FCMPushService) SendPush(ctx context.Context, notification *PushNotific
         response, err := s.client.Send(ctx, message)
         if err != nil {
             log.Printf("Failed to send push notification: %v", err)
+            if messaging.IsUnregistered(err) {
+                notification.InvalidTokens = append(notification.InvalidTokens, notification.Tokens[0])
+            }
+            if isPermanentFCMError(err) {
+                return Permanent(err)
+            }
             return err
         }
         
         log.Printf("Successfully sent push notification: %s", response)
+        notification.MessageID = response
         return nil
     }
     
@@ -153,20 +167,40 @@ func (s *FCMPushService) SendPush(ctx context.Context, notification *PushNotific
         return err
     }
     
+    var messageIDs []string
+    var lastErr error
+    permanent := true
+    for idx, resp := range batchResponse.Responses {
+        if resp.Success {
+            messageIDs = append(messageIDs, resp.MessageID)
+            continue
+        }
+        
+        // Log individual failures
+        log.Printf("Failed to send to token %s: %v", 
+            notification.Tokens[idx], resp.Error)
+        if messaging.IsUnregistered(resp.Error) {
+            notification.InvalidTokens = append(notification.InvalidTokens, notification.Tokens[idx])
+        }
+        lastErr = resp.Error
+        permanent = permanent && isPermanentFCMError(resp.Error)
+    }
+    
     if batchResponse.FailureCount > 0 {
         log.Printf("Failed to send %d out of %d push notifications", 
             batchResponse.FailureCount, len(messages))
-        
-        // Log individual failures
-        for idx, resp := range batchResponse.Responses {
-            if resp.Error != nil {
-                log.Printf("Failed to send to token %s: %v", 
-                    notification.Tokens[idx], resp.Error)
-            }
+    }
+    
+    // Reaching any one device counts as delivered
+    if batchResponse.SuccessCount == 0 && lastErr != nil {
+        if permanent {
+            return Permanent(lastErr)
         }
+        return lastErr
     }
     
     log.Printf("Successfully sent %d push notifications", batchResponse.SuccessCount)
+    notification.MessageID = strings.Join(messageIDs, ",")
     return nil
 }
 
@@ -182,6 +216,54 @@ func (s *FCMPushService) SendBatchPush(ctx context.Context, notifications []*Pus
     return nil
 }
 
+// PushRouter sends each push notification through the provider that issued
+// its tokens
+type PushRouter struct {
+    providers map[TokenType]PushService
+}
+
+// NewPushRouter creates a router with no providers
+func NewPushRouter() *PushRouter {
+    return &PushRouter{providers: make(map[TokenType]PushService)}
+}
+
+// Register sets the provider for tokens of tokenType
+func (r *PushRouter) Register(tokenType TokenType, provider PushService) {
+    r.providers[tokenType] = provider
+}
+
+// SendPush sends a push notification through its tokens' provider. Ones
+// without a token type are FCM's.
+func (r *PushRouter) SendPush(ctx context.Context, notification *PushNotification) error {
+    tokenType := notification.TokenType
+    if tokenType == "" {
+        tokenType = TokenTypeFCM
+    }
+    
+    provider, ok := r.providers[tokenType]
+    if !ok {
+        return Permanent(fmt.Errorf("no push provider for %s tokens", tokenType))
+    }
+    return provider.SendPush(ctx, notification)
+}
+
+// SendBatchPush sends multiple push notifications
+func (r *PushRouter) SendBatchPush(ctx context.Context, notifications []*PushNotification) error {
+    for _, notification := range notifications {
+        if err := r.SendPush(ctx, notification); err != nil {
+            log.Printf("Failed to send push notification in batch: %v", err)
+        }
+    }
+    
+    return nil
+}
+
+// isPermanentFCMError reports whether FCM rejected the token or message
+// itself, so sending it again can't succeed
+func isPermanentFCMError(err error) bool {
+    return messaging.IsUnregistered(err) || messaging.IsInvalidArgument(err) || messaging.IsSenderIDMismatch(err)
+}
+
 // mapPriority maps our priority to FCM priority
 func (s *FCMPushService) mapPriority(priority Priority) string {
     switch priority {
@@ -219,6 +301,7 @@ func NewMockPushService() PushService {
 
 func (m *MockPushService) SendPush(ctx context.Context, notification *PushNotification) error {
     m.SentNotifications = append(m.SentNotifications, notification)
+    notification.MessageID = fmt.Sprintf("mock-push-%d", len(m.SentNotifications))
     log.Printf("Mock: Sending push notification to %d devices: %s", 
         len(notification.Tokens), notification.Title)
     return nil
@@ -230,24 +313,3 @@ func (m *MockPushService) SendBatchPush(ctx context.Context, notifications []*Pu
     }
     return nil
 }
-
-// APNSPushService implements push notifications using Apple Push Notification Service
-// This is a placeholder - actual implementation would require APNS certificates
-type APNSPushService struct {
-    // APNS client configuration
-}
-
-func NewAPNSPushService(config map[string]string) (PushService, error) {
-    // Implementation would go here
-    return &APNSPushService{}, nil
-}
-
-func (s *APNSPushService) SendPush(ctx context.Context, notification *PushNotification) error {
-    // APNS implementation
-    return errors.New("APNS not implemented")
-}
-
-func (s *APNSPushService) SendBatchPush(ctx context.Context, notifications []*PushNotification) error {
-    // APNS batch implementation
-    return errors.New("APNS batch not implemented")
-}
\ No newline at end of file
@@ -0,0 +1,117 @@
+// internal/notification/quiet.go
+
+package notifications
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "time"
+)
+
+var (
+    ErrInvalidQuietHours = errors.New("quiet hours must be HH:MM")
+    ErrInvalidTimezone   = errors.New("unknown timezone")
+)
+
+const quietHoursLayout = "15:04"
+
+// quietUntil reports whether now falls inside the user's quiet hours and, if
+// so, when they end. Windows may cross midnight, e.g. 22:00 to 07:00.
+func (p *NotificationPreferences) quietUntil(now time.Time) (time.Time, bool) {
+    if p == nil || p.QuietHoursStart == nil || p.QuietHoursEnd == nil {
+        return time.Time{}, false
+    }
+
+    start, err := time.Parse(quietHoursLayout, *p.QuietHoursStart)
+    if err != nil {
+        return time.Time{}, false
+    }
+    end, err := time.Parse(quietHoursLayout, *p.QuietHoursEnd)
+    if err != nil || start.Equal(end) {
+        return time.Time{}, false
+    }
+
+    loc, err := time.LoadLocation(p.Timezone)
+    if err != nil {
+        loc = time.UTC
+    }
+    local := now.In(loc)
+
+    minute := local.Hour()*60 + local.Minute()
+    startMinute := start.Hour()*60 + start.Minute()
+    endMinute := end.Hour()*60 + end.Minute()
+
+    var quiet bool
+    if startMinute < endMinute {
+        quiet = minute >= startMinute && minute < endMinute
+    } else {
+        quiet = minute >= startMinute || minute < endMinute
+    }
+    if !quiet {
+        return time.Time{}, false
+    }
+
+    until := time.Date(local.Year(), local.Month(), local.Day(), end.Hour(), end.Minute(), 0, 0, loc)
+    if !until.After(local) {
+        until = until.AddDate(0, 0, 1)
+    }
+    return until, true
+}
+
+// validateQuietHours checks a quiet hours bound. An empty string clears it.
+func validateQuietHours(value string) error {
+    if value == "" {
+        return nil
+    }
+    if _, err := time.Parse(quietHoursLayout, value); err != nil || len(value) != len(quietHoursLayout) {
+        return ErrInvalidQuietHours
+    }
+    return nil
+}
+
+// nullIfEmpty stores an empty quiet hours bound as NULL
+func nullIfEmpty(value string) *string {
+    if value == "" {
+        return nil
+    }
+    return &value
+}
+
+// holdForQuietHours queues delivery of an already created notification on
+// the given channels until the user's quiet hours end
+func (s *service) holdForQuietHours(ctx context.Context, notification *Notification, channels []DeliveryChannel, until time.Time) {
+    userID := notification.UserID
+    notificationID := notification.ID
+
+    scheduled := &ScheduledNotification{
+        UserID:         &userID,
+        NotificationID: &notificationID,
+        Type:           notification.Type,
+        Title:          notification.Title,
+        Message:        notification.Message,
+        Data:           notification.Data,
+        Channels:       channels,
+        ScheduledFor:   until,
+        Status:         "pending",
+    }
+
+    if err := s.repo.CreateScheduledNotification(ctx, scheduled); err != nil {
+        log.Printf("Failed to hold notification %d for quiet hours: %v", notification.ID, err)
+    }
+}
+
+// deliverHeld delivers a notification that was held for quiet hours. The
+// in-app notification already exists, so only the held channels are sent.
+func (s *service) deliverHeld(ctx context.Context, scheduled *ScheduledNotification) error {
+    notification, err := s.repo.GetNotification(ctx, *scheduled.NotificationID)
+    if err != nil {
+        return fmt.Errorf("held notification not found: %w", err)
+    }
+
+    for _, channel := range scheduled.Channels {
+        s.deliverChannel(ctx, channel, notification.UserID, notification)
+    }
+    return nil
+}
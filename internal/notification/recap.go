@@ -0,0 +1,193 @@
+// internal/notification/recap.go
+
+package notifications
+
+import (
+    "context"
+    "fmt"
+    "log"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/email"
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
+)
+
+const (
+    // Recaps go out on Monday morning in each user's own timezone and cover
+    // the seven days before that Monday
+    recapSendWeekday = time.Monday
+    recapSendHour    = 9
+
+    // recapSendWindow is how long after the send time a recap is still sent,
+    // so a missed scheduler run catches up instead of skipping the week
+    recapSendWindow = 24 * time.Hour
+
+    recapBatchSize = 500
+)
+
+// SendWeeklyRecaps sends each opted-in user their weekly recap once it is
+// due in their timezone. A recap is claimed before sending, so overlapping
+// runs or instances never send the same week twice. Returns how many were sent.
+func (s *service) SendWeeklyRecaps(ctx context.Context, now time.Time) (int, error) {
+    // Anyone claimed for a week starting in the last six days is done; the
+    // exact week is checked on claim
+    sentSince := now.AddDate(0, 0, -6)
+
+    sent := 0
+    var afterID int64
+    for {
+        recipients, err := s.repo.GetRecapRecipients(ctx, afterID, sentSince, recapBatchSize)
+        if err != nil {
+            return sent, fmt.Errorf("failed to load recap recipients: %w", err)
+        }
+
+        for _, recipient := range recipients {
+            afterID = recipient.UserID
+
+            ok, err := s.sendWeeklyRecap(ctx, recipient, now)
+            if err != nil {
+                log.Printf("Failed to send weekly recap to user %d: %v", recipient.UserID, err)
+                continue
+            }
+            if ok {
+                sent++
+            }
+        }
+
+        if len(recipients) < recapBatchSize {
+            return sent, nil
+        }
+        if err := ctx.Err(); err != nil {
+            return sent, err
+        }
+    }
+}
+
+// sendWeeklyRecap sends one user's recap if it is due, reporting whether it was sent
+func (s *service) sendWeeklyRecap(ctx context.Context, recipient *RecapRecipient, now time.Time) (bool, error) {
+    loc, err := time.LoadLocation(recipient.Timezone)
+    if err != nil {
+        loc = time.UTC
+    }
+
+    weekStart, due := recapWeek(now, loc)
+    if !due {
+        return false, nil
+    }
+
+    claimed, err := s.repo.ClaimWeeklyRecap(ctx, recipient.UserID, weekStart)
+    if err != nil || !claimed {
+        return false, err
+    }
+
+    recap, err := s.repo.GetWeeklyRecapStats(ctx, recipient.UserID, weekStart.AddDate(0, 0, -7), weekStart)
+    if err != nil {
+        s.repo.ReleaseWeeklyRecap(ctx, recipient.UserID, weekStart)
+        return false, err
+    }
+
+    // Nothing to celebrate; keep the claim so the week isn't retried
+    if recap.IsEmpty() {
+        return false, nil
+    }
+
+    name := recipient.DisplayName
+    if name == "" {
+        name = recipient.Username
+    }
+
+    data := NotificationData{
+        "username":       name,
+        "profile_views":  recap.ProfileViews,
+        "likes_received": recap.LikesReceived,
+        "new_matches":    recap.NewMatches,
+        "week_start":     recap.From.Format("2006-01-02"),
+        "action":         "recap",
+    }
+    if recap.TopPost != nil {
+        data["top_post_id"] = recap.TopPost.ID
+        data["top_post_likes"] = recap.TopPost.Likes
+    }
+
+    title, body, err := s.renderRecap(ctx, i18n.Match(recipient.Language), data)
+    if err != nil {
+        s.repo.ReleaseWeeklyRecap(ctx, recipient.UserID, weekStart)
+        return false, err
+    }
+
+    notification, err := s.SendNotification(ctx, &CreateNotificationRequest{
+        UserID:   recipient.UserID,
+        Type:     TypeWeeklyRecap,
+        Title:    title,
+        Message:  body,
+        Data:     data,
+        Channels: []DeliveryChannel{ChannelInApp, ChannelPush},
+    })
+    if err != nil {
+        s.repo.ReleaseWeeklyRecap(ctx, recipient.UserID, weekStart)
+        return false, err
+    }
+
+    if recipient.EmailEnabled && recipient.Email != "" {
+        if err := s.sendRecapEmail(ctx, recipient, name, title, body, recap); err != nil {
+            // The in-app recap is already out, so don't release the claim
+            log.Printf("Failed to email weekly recap %d: %v", notification.ID, err)
+        }
+    }
+
+    return true, nil
+}
+
+// renderRecap renders the recap title and body in lang through the template engine
+func (s *service) renderRecap(ctx context.Context, lang string, data NotificationData) (string, string, error) {
+    if s.templateService == nil {
+        title, body := renderCatalog(lang, TypeWeeklyRecap, data)
+        return title, body, nil
+    }
+    return s.templateService.RenderTemplate(ctx, TypeWeeklyRecap, lang, data)
+}
+
+// sendRecapEmail sends the HTML version of the recap
+func (s *service) sendRecapEmail(ctx context.Context, recipient *RecapRecipient, name, title, body string, recap *WeeklyRecap) error {
+    if s.emailService == nil {
+        return nil
+    }
+
+    appURL := s.appURL
+
+    data := email.Data{
+        "Title":          title,
+        "Preheader":      body,
+        "Name":           name,
+        "Recap":          recap,
+        "UnsubscribeURL": appURL + "/settings/notifications?unsubscribe=weekly_recap",
+        "PreferencesURL": appURL + "/settings/notifications",
+    }
+    if recap.TopPost != nil {
+        data["TopPostURL"] = fmt.Sprintf("%s/posts/%d", appURL, recap.TopPost.ID)
+    }
+
+    message, err := email.Render(string(TypeWeeklyRecap), recipient.Language, data)
+    if err != nil {
+        return err
+    }
+
+    return s.emailService.SendEmail(ctx, &EmailNotification{
+        To:      recipient.Email,
+        Subject: title,
+        Body:    message.Text,
+        HTML:    message.HTML,
+    })
+}
+
+// recapWeek returns the local Monday that starts the current recap week and
+// whether the recap for it is due at now
+func recapWeek(now time.Time, loc *time.Location) (time.Time, bool) {
+    local := now.In(loc)
+    days := (int(local.Weekday()) - int(recapSendWeekday) + 7) % 7
+    weekStart := time.Date(local.Year(), local.Month(), local.Day()-days, 0, 0, 0, 0, loc)
+
+    sendAt := weekStart.Add(recapSendHour * time.Hour)
+    due := !local.Before(sendAt) && local.Sub(sendAt) < recapSendWindow
+    return weekStart, due
+}
@@ -6,20 +6,29 @@ import (
     "context"
     "database/sql"
     "encoding/json"
+    "fmt"
+    "strings"
     "time"
     
     "github.com/jmoiron/sqlx"
     "github.com/lib/pq"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/common/sqlbuilder"
 )
 
 type Repository interface {
     // Notifications CRUD
     CreateNotification(ctx context.Context, notification *Notification) error
     GetNotification(ctx context.Context, notificationID int64) (*Notification, error)
+    AddToGroup(ctx context.Context, userID int64, groupKey string, actorID int64, since time.Time) (*Notification, error)
+    UpdateGroupContent(ctx context.Context, notificationID int64, title, message string) error
+    ClaimGroupPush(ctx context.Context, notificationID int64, before time.Time) (bool, error)
     GetUserNotifications(ctx context.Context, userID int64, limit, offset int, unreadOnly bool) ([]*Notification, error)
     GetUserNotificationCount(ctx context.Context, userID int64, unreadOnly bool) (int, error)
     MarkAsRead(ctx context.Context, notificationID int64, userID int64) error
     MarkAllAsRead(ctx context.Context, userID int64) error
+    ResolveNotification(ctx context.Context, notificationID, userID int64, action string) (bool, error)
+    UnresolveNotification(ctx context.Context, notificationID int64, action string) error
     DeleteNotification(ctx context.Context, notificationID int64, userID int64) error
     DeleteOldNotifications(ctx context.Context, before time.Time) error
     
@@ -28,6 +37,7 @@ type Repository interface {
     GetUserPushTokens(ctx context.Context, userID int64, platform *Platform) ([]*PushToken, error)
     DeletePushToken(ctx context.Context, token string) error
     DeactivatePushToken(ctx context.Context, token string) error
+    DeleteWebPushSubscription(ctx context.Context, userID int64, endpoint string) error
     GetAllActivePushTokens(ctx context.Context, userIDs []int64) ([]*PushToken, error)
     
     // Preferences
@@ -46,10 +56,40 @@ type Repository interface {
     CreateTemplate(ctx context.Context, template *NotificationTemplate) error
     UpdateTemplate(ctx context.Context, template *NotificationTemplate) error
     GetAllTemplates(ctx context.Context) ([]*NotificationTemplate, error)
+    GetTemplateExact(ctx context.Context, notificationType NotificationType, language string) (*NotificationTemplate, error)
+    GetTemplateVersions(ctx context.Context, notificationType NotificationType, language string) ([]*TemplateVersion, error)
+    RollbackTemplate(ctx context.Context, notificationType NotificationType, language string, version int) (*NotificationTemplate, error)
     
     // Batch operations
     CreateBatchNotifications(ctx context.Context, notifications []*Notification) error
     GetUsersByPreference(ctx context.Context, preference string, enabled bool) ([]int64, error)
+    
+    // Weekly recap
+    GetRecapRecipients(ctx context.Context, afterID int64, sentSince time.Time, limit int) ([]*RecapRecipient, error)
+    GetWeeklyRecapStats(ctx context.Context, userID int64, from, to time.Time) (*WeeklyRecap, error)
+    ClaimWeeklyRecap(ctx context.Context, userID int64, weekStart time.Time) (bool, error)
+    ReleaseWeeklyRecap(ctx context.Context, userID int64, weekStart time.Time) error
+    
+    // Delivery log
+    CreateDelivery(ctx context.Context, delivery *Delivery) error
+    UpdateDelivery(ctx context.Context, delivery *Delivery) error
+    UpdateDeliveryStatus(ctx context.Context, channel DeliveryChannel, providerMessageID string, status DeliveryStatus, lastError *string) error
+    ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Delivery, error)
+    GetDeliveries(ctx context.Context, filter *DeliveryFilter) ([]*Delivery, error)
+    GetDeliveryHealth(ctx context.Context, since time.Time) ([]*ChannelHealth, error)
+    
+    // Campaigns
+    CountSegment(ctx context.Context, segment *Segment) (int, error)
+    GetSegmentUserIDs(ctx context.Context, segment *Segment, afterID int64, limit int) ([]int64, error)
+    FilterChannelEnabled(ctx context.Context, userIDs []int64, channel DeliveryChannel) ([]int64, error)
+    CreateCampaign(ctx context.Context, campaign *Campaign) error
+    GetCampaign(ctx context.Context, campaignID int64) (*Campaign, error)
+    GetCampaigns(ctx context.Context, limit, offset int) ([]*Campaign, error)
+    CancelCampaign(ctx context.Context, campaignID int64) (bool, error)
+    ClaimDueCampaigns(ctx context.Context, now, lastBatchBefore time.Time) ([]*Campaign, error)
+    AdvanceCampaign(ctx context.Context, campaignID, lastUserID int64, sent int) (bool, error)
+    CompleteCampaign(ctx context.Context, campaignID int64) error
+    RecordCampaignEvent(ctx context.Context, campaignID, userID int64, event string) (bool, error)
 }
 
 type postgresRepository struct {
@@ -63,8 +103,9 @@ func NewPostgresRepository(db *sqlx.DB) Repository {
 // CreateNotification creates a new notification
 func (r *postgresRepository) CreateNotification(ctx context.Context, notification *Notification) error {
     query := `
-        INSERT INTO notifications (user_id, type, title, message, data, is_read)
-        VALUES ($1, $2, $3, $4, $5, $6)
+        INSERT INTO notifications (user_id, type, title, message, data, is_read,
+            group_key, actor_ids, actor_count, group_pushed_at)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
         RETURNING id, created_at`
     
     dataJSON, err := json.Marshal(notification.Data)
@@ -72,6 +113,13 @@ func (r *postgresRepository) CreateNotification(ctx context.Context, notificatio
         return err
     }
     
+    // A new group counts as pushed when it is created
+    var groupPushedAt *time.Time
+    if notification.GroupKey != nil {
+        now := time.Now()
+        groupPushedAt = &now
+    }
+    
     err = r.db.QueryRowContext(ctx, query,
         notification.UserID,
         notification.Type,
@@ -79,6 +127,10 @@ func (r *postgresRepository) CreateNotification(ctx context.Context, notificatio
         notification.Message,
         dataJSON,
         notification.IsRead,
+        notification.GroupKey,
+        notification.ActorIDs,
+        notification.ActorCount,
+        groupPushedAt,
     ).Scan(&notification.ID, &notification.CreatedAt)
     
     return err
@@ -88,7 +140,8 @@ func (r *postgresRepository) CreateNotification(ctx context.Context, notificatio
 func (r *postgresRepository) GetNotification(ctx context.Context, notificationID int64) (*Notification, error) {
     var notification Notification
     query := `
-        SELECT id, user_id, type, title, message, data, is_read, read_at, created_at
+        SELECT id, user_id, type, title, message, data, is_read, read_at, created_at,
+               group_key, actor_ids, actor_count, updated_at, resolved_at, resolved_action
         FROM notifications
         WHERE id = $1`
     
@@ -99,10 +152,63 @@ func (r *postgresRepository) GetNotification(ctx context.Context, notificationID
     return &notification, err
 }
 
+// AddToGroup adds an actor to the recipient's unread group with the given
+// key, if one saw activity since the given time. The actor moves to the front
+// of the list and is only counted once. Returns nil when there is no such group.
+func (r *postgresRepository) AddToGroup(ctx context.Context, userID int64, groupKey string, actorID int64, since time.Time) (*Notification, error) {
+    var notification Notification
+    query := `
+        UPDATE notifications SET
+            actor_count = actor_count + CASE WHEN $3::bigint = ANY(COALESCE(actor_ids, '{}')) THEN 0 ELSE 1 END,
+            actor_ids = (array_prepend($3::bigint, array_remove(actor_ids, $3::bigint)))[1:` + fmt.Sprint(maxGroupActors) + `],
+            updated_at = NOW()
+        WHERE id = (
+            SELECT id FROM notifications
+            WHERE user_id = $1 AND group_key = $2 AND is_read = false
+              AND COALESCE(updated_at, created_at) > $4
+            ORDER BY COALESCE(updated_at, created_at) DESC
+            LIMIT 1
+            FOR UPDATE)
+        RETURNING id, user_id, type, title, message, data, is_read, read_at, created_at,
+                  group_key, actor_ids, actor_count, updated_at, resolved_at, resolved_action`
+    
+    err := r.db.GetContext(ctx, &notification, query, userID, groupKey, actorID, since)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &notification, nil
+}
+
+// UpdateGroupContent rewrites a grouped notification's text
+func (r *postgresRepository) UpdateGroupContent(ctx context.Context, notificationID int64, title, message string) error {
+    query := `UPDATE notifications SET title = $2, message = $3 WHERE id = $1`
+    _, err := r.db.ExecContext(ctx, query, notificationID, title, message)
+    return err
+}
+
+// ClaimGroupPush records that a grouped notification is being pushed again,
+// unless it was already pushed after the given time
+func (r *postgresRepository) ClaimGroupPush(ctx context.Context, notificationID int64, before time.Time) (bool, error) {
+    query := `
+        UPDATE notifications SET group_pushed_at = NOW()
+        WHERE id = $1 AND (group_pushed_at IS NULL OR group_pushed_at <= $2)`
+    
+    result, err := r.db.ExecContext(ctx, query, notificationID, before)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
 // GetUserNotifications retrieves notifications for a user
 func (r *postgresRepository) GetUserNotifications(ctx context.Context, userID int64, limit, offset int, unreadOnly bool) ([]*Notification, error) {
     query := `
-        SELECT id, user_id, type, title, message, data, is_read, read_at, created_at
+        SELECT id, user_id, type, title, message, data, is_read, read_at, created_at,
+               group_key, actor_ids, actor_count, updated_at, resolved_at, resolved_action
         FROM notifications
         WHERE user_id = $1`
     
@@ -110,7 +216,8 @@ func (r *postgresRepository) GetUserNotifications(ctx context.Context, userID in
         query += " AND is_read = false"
     }
     
-    query += " ORDER BY created_at DESC LIMIT $2 OFFSET $3"
+    // Grouped notifications move to the top when they see new activity
+    query += " ORDER BY COALESCE(updated_at, created_at) DESC LIMIT $2 OFFSET $3"
     
     rows, err := r.db.QueryContext(ctx, query, userID, limit, offset)
     if err != nil {
@@ -126,6 +233,8 @@ func (r *postgresRepository) GetUserNotifications(ctx context.Context, userID in
         err := rows.Scan(
             &n.ID, &n.UserID, &n.Type, &n.Title, &n.Message,
             &dataJSON, &n.IsRead, &n.ReadAt, &n.CreatedAt,
+            &n.GroupKey, &n.ActorIDs, &n.ActorCount, &n.UpdatedAt,
+            &n.ResolvedAt, &n.ResolvedAction,
         )
         if err != nil {
             return nil, err
@@ -176,6 +285,36 @@ func (r *postgresRepository) MarkAllAsRead(ctx context.Context, userID int64) er
     return err
 }
 
+// ResolveNotification records that the user took one of a notification's
+// actions, which also reads it. It reports false if an action was already
+// taken, so each notification's action runs once.
+func (r *postgresRepository) ResolveNotification(ctx context.Context, notificationID, userID int64, action string) (bool, error) {
+    query := `
+        UPDATE notifications
+        SET resolved_at = NOW(), resolved_action = $3,
+            is_read = true, read_at = COALESCE(read_at, NOW())
+        WHERE id = $1 AND user_id = $2 AND resolved_at IS NULL`
+    
+    result, err := r.db.ExecContext(ctx, query, notificationID, userID, action)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// UnresolveNotification offers a notification's actions again after the
+// one taken failed
+func (r *postgresRepository) UnresolveNotification(ctx context.Context, notificationID int64, action string) error {
+    query := `
+        UPDATE notifications
+        SET resolved_at = NULL, resolved_action = NULL
+        WHERE id = $1 AND resolved_action = $2`
+    
+    _, err := r.db.ExecContext(ctx, query, notificationID, action)
+    return err
+}
+
 // DeleteNotification deletes a notification
 func (r *postgresRepository) DeleteNotification(ctx context.Context, notificationID int64, userID int64) error {
     query := `DELETE FROM notifications WHERE id = $1 AND user_id = $2`
@@ -193,14 +332,14 @@ func (r *postgresRepository) DeleteOldNotifications(ctx context.Context, before
 // SavePushToken saves or updates a push token
 func (r *postgresRepository) SavePushToken(ctx context.Context, token *PushToken) error {
     query := `
-        INSERT INTO push_tokens (user_id, platform, token, device_id, is_active)
-        VALUES ($1, $2, $3, $4, $5)
-        ON CONFLICT (user_id, device_id) 
-        DO UPDATE SET token = $3, platform = $2, is_active = $5, updated_at = NOW()
+        INSERT INTO push_tokens (user_id, platform, token_type, token, device_id, is_active)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (token) 
+        DO UPDATE SET user_id = $1, platform = $2, token_type = $3, device_id = $5, is_active = $6, updated_at = NOW()
         RETURNING id, created_at, updated_at`
     
     err := r.db.QueryRowContext(ctx, query,
-        token.UserID, token.Platform, token.Token, token.DeviceID, true,
+        token.UserID, token.Platform, token.TokenType, token.Token, token.DeviceID, true,
     ).Scan(&token.ID, &token.CreatedAt, &token.UpdatedAt)
     
     return err
@@ -209,7 +348,7 @@ func (r *postgresRepository) SavePushToken(ctx context.Context, token *PushToken
 // GetUserPushTokens retrieves push tokens for a user
 func (r *postgresRepository) GetUserPushTokens(ctx context.Context, userID int64, platform *Platform) ([]*PushToken, error) {
     query := `
-        SELECT id, user_id, platform, token, device_id, is_active, created_at, updated_at
+        SELECT id, user_id, platform, token_type, token, device_id, is_active, created_at, updated_at
         FROM push_tokens
         WHERE user_id = $1 AND is_active = true`
     
@@ -234,16 +373,29 @@ func (r *postgresRepository) DeletePushToken(ctx context.Context, token string)
 
 // DeactivatePushToken deactivates a push token
 func (r *postgresRepository) DeactivatePushToken(ctx context.Context, token string) error {
-    query := `UPDATE push_tokens SET is_active = false WHERE token = $1`
+    query := `UPDATE push_tokens SET is_active = false, updated_at = NOW() WHERE token = $1`
     _, err := r.db.ExecContext(ctx, query, token)
     return err
 }
 
+// DeleteWebPushSubscription deletes a user's web push subscription by its
+// push service endpoint
+func (r *postgresRepository) DeleteWebPushSubscription(ctx context.Context, userID int64, endpoint string) error {
+    // Only web push tokens are JSON, and only CASE guarantees the others
+    // are never cast
+    query := `
+        DELETE FROM push_tokens
+        WHERE user_id = $1
+          AND CASE WHEN token_type = $2 THEN token::jsonb->>'endpoint' = $3 ELSE false END`
+    _, err := r.db.ExecContext(ctx, query, userID, TokenTypeWebPush, endpoint)
+    return err
+}
+
 // GetAllActivePushTokens gets all active push tokens for given users
 func (r *postgresRepository) GetAllActivePushTokens(ctx context.Context, userIDs []int64) ([]*PushToken, error) {
     if len(userIDs) == 0 {
         query := `
-            SELECT id, user_id, platform, token, device_id, is_active, created_at, updated_at
+            SELECT id, user_id, platform, token_type, token, device_id, is_active, created_at, updated_at
             FROM push_tokens
             WHERE is_active = true`
         
@@ -253,7 +405,7 @@ func (r *postgresRepository) GetAllActivePushTokens(ctx context.Context, userIDs
     }
     
     query := `
-        SELECT id, user_id, platform, token, device_id, is_active, created_at, updated_at
+        SELECT id, user_id, platform, token_type, token, device_id, is_active, created_at, updated_at
         FROM push_tokens
         WHERE user_id = ANY($1) AND is_active = true`
     
@@ -286,6 +438,9 @@ func (r *postgresRepository) GetUserPreferences(ctx context.Context, userID int6
             StoryReplies: true,
             Mentions:     true,
             Promotions:   true,
+            WeeklyRecap:    true,
+            ContactsJoined: true,
+            Timezone:       "UTC",
         }, nil
     }
     return &prefs, err
@@ -296,20 +451,28 @@ func (r *postgresRepository) SaveUserPreferences(ctx context.Context, prefs *Not
     query := `
         INSERT INTO notification_preferences 
         (user_id, push_enabled, email_enabled, sms_enabled, likes, comments, 
-         follows, messages, matches, story_views, story_replies, mentions, promotions)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+         follows, messages, matches, story_views, story_replies, mentions, promotions, weekly_recap,
+         quiet_hours_start, quiet_hours_end, timezone, contacts_joined)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
         ON CONFLICT (user_id) DO UPDATE SET
             push_enabled = $2, email_enabled = $3, sms_enabled = $4,
             likes = $5, comments = $6, follows = $7, messages = $8,
             matches = $9, story_views = $10, story_replies = $11,
-            mentions = $12, promotions = $13, updated_at = NOW()
+            mentions = $12, promotions = $13, weekly_recap = $14,
+            quiet_hours_start = $15, quiet_hours_end = $16, timezone = $17, contacts_joined = $18,
+            updated_at = NOW()
         RETURNING id, updated_at`
     
+    if prefs.Timezone == "" {
+        prefs.Timezone = "UTC"
+    }
+    
     err := r.db.QueryRowContext(ctx, query,
         prefs.UserID, prefs.PushEnabled, prefs.EmailEnabled, prefs.SMSEnabled,
         prefs.Likes, prefs.Comments, prefs.Follows, prefs.Messages,
         prefs.Matches, prefs.StoryViews, prefs.StoryReplies,
-        prefs.Mentions, prefs.Promotions,
+        prefs.Mentions, prefs.Promotions, prefs.WeeklyRecap,
+        prefs.QuietHoursStart, prefs.QuietHoursEnd, prefs.Timezone, prefs.ContactsJoined,
     ).Scan(&prefs.ID, &prefs.UpdatedAt)
     
     return err
@@ -321,19 +484,24 @@ func (r *postgresRepository) UpdateUserPreferences(ctx context.Context, userID i
         return nil
     }
     
-    query := "UPDATE notification_preferences SET updated_at = NOW()"
-    args := []interface{}{userID}
-    argCount := 1
-    
-    for key, value := range updates {
-        argCount++
-        query += ", " + key + " = $" + string(rune(argCount))
-        args = append(args, value)
+    // Users who never changed anything have no row yet; start from the defaults
+    if _, err := r.db.ExecContext(ctx,
+        `INSERT INTO notification_preferences (user_id) VALUES ($1) ON CONFLICT (user_id) DO NOTHING`,
+        userID,
+    ); err != nil {
+        return err
     }
     
-    query += " WHERE user_id = $1"
+    query, args, err := sqlbuilder.NewUpdate("notification_preferences").
+        SetSQL("updated_at", "NOW()").
+        SetMap(updates).
+        Where("user_id = ?", userID).
+        Build()
+    if err != nil {
+        return err
+    }
     
-    _, err := r.db.ExecContext(ctx, query, args...)
+    _, err = r.db.ExecContext(ctx, query, args...)
     return err
 }
 
@@ -341,15 +509,15 @@ func (r *postgresRepository) UpdateUserPreferences(ctx context.Context, userID i
 func (r *postgresRepository) CreateScheduledNotification(ctx context.Context, scheduled *ScheduledNotification) error {
     query := `
         INSERT INTO scheduled_notifications 
-        (user_id, type, title, message, data, channels, scheduled_for, status)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+        (user_id, notification_id, type, title, message, data, channels, scheduled_for, status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
         RETURNING id, created_at`
     
     dataJSON, _ := json.Marshal(scheduled.Data)
     channelsJSON, _ := json.Marshal(scheduled.Channels)
     
     err := r.db.QueryRowContext(ctx, query,
-        scheduled.UserID, scheduled.Type, scheduled.Title, scheduled.Message,
+        scheduled.UserID, scheduled.NotificationID, scheduled.Type, scheduled.Title, scheduled.Message,
         dataJSON, channelsJSON, scheduled.ScheduledFor, "pending",
     ).Scan(&scheduled.ID, &scheduled.CreatedAt)
     
@@ -399,40 +567,142 @@ func (r *postgresRepository) GetTemplate(ctx context.Context, notificationType N
     return &template, err
 }
 
-// CreateTemplate creates a notification template
+// GetTemplateExact retrieves a template for exactly the given language, without
+// falling back to English
+func (r *postgresRepository) GetTemplateExact(ctx context.Context, notificationType NotificationType, language string) (*NotificationTemplate, error) {
+    var template NotificationTemplate
+    query := `
+        SELECT * FROM notification_templates
+        WHERE type = $1 AND language = $2`
+    
+    err := r.db.GetContext(ctx, &template, query, notificationType, language)
+    if err != nil {
+        return nil, err
+    }
+    return &template, nil
+}
+
+// CreateTemplate creates a notification template and records its first version
 func (r *postgresRepository) CreateTemplate(ctx context.Context, template *NotificationTemplate) error {
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
+    if template.Version == 0 {
+        template.Version = 1
+    }
+    
     query := `
         INSERT INTO notification_templates 
-        (type, language, title_template, body_template, variables)
-        VALUES ($1, $2, $3, $4, $5)
+        (type, language, title_template, body_template, variables, version, pinned)
+        VALUES ($1, $2, $3, $4, $5, $6, $7)
         RETURNING id, created_at, updated_at`
     
-    variablesJSON, _ := json.Marshal(template.Variables)
-    
-    err := r.db.QueryRowContext(ctx, query,
+    err = tx.QueryRowContext(ctx, query,
         template.Type, template.Language, template.TitleTemplate,
-        template.BodyTemplate, variablesJSON,
+        template.BodyTemplate, template.Variables, template.Version, template.Pinned,
     ).Scan(&template.ID, &template.CreatedAt, &template.UpdatedAt)
+    if err != nil {
+        return err
+    }
     
-    return err
+    if err := r.archiveTemplate(ctx, tx, template); err != nil {
+        return err
+    }
+    
+    return tx.Commit()
 }
 
-// UpdateTemplate updates a notification template
+// UpdateTemplate updates a notification template and records the new version
 func (r *postgresRepository) UpdateTemplate(ctx context.Context, template *NotificationTemplate) error {
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+    
     query := `
         UPDATE notification_templates 
-        SET title_template = $3, body_template = $4, variables = $5, updated_at = NOW()
+        SET title_template = $3, body_template = $4, variables = $5,
+            version = $6, pinned = $7, updated_at = NOW()
         WHERE type = $1 AND language = $2`
     
-    variablesJSON, _ := json.Marshal(template.Variables)
-    
-    _, err := r.db.ExecContext(ctx, query,
+    result, err := tx.ExecContext(ctx, query,
         template.Type, template.Language, template.TitleTemplate,
-        template.BodyTemplate, variablesJSON,
+        template.BodyTemplate, template.Variables, template.Version, template.Pinned,
+    )
+    if err != nil {
+        return err
+    }
+    
+    rows, _ := result.RowsAffected()
+    if rows == 0 {
+        return sql.ErrNoRows
+    }
+    
+    if err := r.archiveTemplate(ctx, tx, template); err != nil {
+        return err
+    }
+    
+    return tx.Commit()
+}
+
+// archiveTemplate stores a copy of a template version so it can be rolled back to
+func (r *postgresRepository) archiveTemplate(ctx context.Context, tx *sqlx.Tx, template *NotificationTemplate) error {
+    query := `
+        INSERT INTO notification_template_versions 
+        (type, language, version, title_template, body_template, variables)
+        VALUES ($1, $2, $3, $4, $5, $6)
+        ON CONFLICT (type, language, version) 
+        DO UPDATE SET title_template = EXCLUDED.title_template,
+                      body_template = EXCLUDED.body_template,
+                      variables = EXCLUDED.variables`
+    
+    _, err := tx.ExecContext(ctx, query,
+        template.Type, template.Language, template.Version,
+        template.TitleTemplate, template.BodyTemplate, template.Variables,
     )
     return err
 }
 
+// GetTemplateVersions retrieves the archived versions of a template, newest first
+func (r *postgresRepository) GetTemplateVersions(ctx context.Context, notificationType NotificationType, language string) ([]*TemplateVersion, error) {
+    query := `
+        SELECT * FROM notification_template_versions
+        WHERE type = $1 AND language = $2
+        ORDER BY version DESC`
+    
+    var versions []*TemplateVersion
+    err := r.db.SelectContext(ctx, &versions, query, notificationType, language)
+    return versions, err
+}
+
+// RollbackTemplate restores an archived version of a template and pins it so
+// startup seeding does not immediately upgrade it again
+func (r *postgresRepository) RollbackTemplate(ctx context.Context, notificationType NotificationType, language string, version int) (*NotificationTemplate, error) {
+    query := `
+        UPDATE notification_templates t
+        SET title_template = v.title_template,
+            body_template = v.body_template,
+            variables = v.variables,
+            version = v.version,
+            pinned = TRUE,
+            updated_at = NOW()
+        FROM notification_template_versions v
+        WHERE t.type = $1 AND t.language = $2
+        AND v.type = t.type AND v.language = t.language AND v.version = $3
+        RETURNING t.*`
+    
+    var template NotificationTemplate
+    err := r.db.GetContext(ctx, &template, query, notificationType, language, version)
+    if err != nil {
+        return nil, err
+    }
+    return &template, nil
+}
+
 // GetAllTemplates retrieves all notification templates
 func (r *postgresRepository) GetAllTemplates(ctx context.Context) ([]*NotificationTemplate, error) {
     query := `SELECT * FROM notification_templates ORDER BY type, language`
@@ -479,4 +749,443 @@ func (r *postgresRepository) GetUsersByPreference(ctx context.Context, preferenc
     var userIDs []int64
     err := r.db.SelectContext(ctx, &userIDs, query, enabled)
     return userIDs, err
-}
\ No newline at end of file
+}
+
+// GetRecapRecipients pages through active users who haven't opted out of the
+// weekly recap and haven't been sent one since sentSince
+func (r *postgresRepository) GetRecapRecipients(ctx context.Context, afterID int64, sentSince time.Time, limit int) ([]*RecapRecipient, error) {
+    query := `
+        SELECT u.id AS user_id, u.username, COALESCE(u.display_name, '') AS display_name,
+               u.email, COALESCE(u.timezone, 'UTC') AS timezone,
+               COALESCE(u.preferred_language, 'en') AS language,
+               COALESCE(np.email_enabled, true) AS email_enabled
+        FROM users u
+        LEFT JOIN notification_preferences np ON np.user_id = u.id
+        WHERE u.id > $1
+          AND COALESCE(u.account_status, 'active') = 'active'
+          AND COALESCE(np.weekly_recap, true)
+          AND NOT EXISTS (
+              SELECT 1 FROM weekly_recaps wr
+              WHERE wr.user_id = u.id AND wr.week_start >= $2
+          )
+        ORDER BY u.id
+        LIMIT $3`
+    
+    var recipients []*RecapRecipient
+    err := r.db.SelectContext(ctx, &recipients, query, afterID, sentSince.Format("2006-01-02"), limit)
+    return recipients, err
+}
+
+// GetWeeklyRecapStats gathers a user's activity between from and to
+func (r *postgresRepository) GetWeeklyRecapStats(ctx context.Context, userID int64, from, to time.Time) (*WeeklyRecap, error) {
+    recap := &WeeklyRecap{UserID: userID, From: from, To: to}
+    
+    query := `
+        SELECT
+            (SELECT COUNT(*) FROM profile_views
+             WHERE profile_id = $1 AND viewed_at >= $2 AND viewed_at < $3) AS profile_views,
+            (SELECT COUNT(*) FROM post_likes pl
+             JOIN posts p ON p.id = pl.post_id
+             WHERE p.user_id = $1 AND pl.user_id <> $1 AND p.deleted_at IS NULL
+               AND pl.created_at >= $2 AND pl.created_at < $3) AS likes_received,
+            (SELECT COUNT(*) FROM matches
+             WHERE (user1_id = $1 OR user2_id = $1)
+               AND matched_at >= $2 AND matched_at < $3) AS new_matches`
+    
+    err := r.db.QueryRowContext(ctx, query, userID, from, to).Scan(
+        &recap.ProfileViews, &recap.LikesReceived, &recap.NewMatches,
+    )
+    if err != nil {
+        return nil, err
+    }
+    
+    var top RecapPost
+    topQuery := `
+        SELECT p.id, COALESCE(p.caption, '') AS caption, COUNT(*) AS likes
+        FROM post_likes pl
+        JOIN posts p ON p.id = pl.post_id
+        WHERE p.user_id = $1 AND pl.user_id <> $1 AND p.deleted_at IS NULL
+          AND pl.created_at >= $2 AND pl.created_at < $3
+        GROUP BY p.id, p.caption
+        ORDER BY likes DESC, p.id DESC
+        LIMIT 1`
+    
+    err = r.db.GetContext(ctx, &top, topQuery, userID, from, to)
+    switch {
+    case err == nil:
+        recap.TopPost = &top
+    case err != sql.ErrNoRows:
+        return nil, err
+    }
+    
+    return recap, nil
+}
+
+// ClaimWeeklyRecap records that the recap for weekStart is being sent. Weeks
+// are stored as the user's local date. It returns false when another run
+// already claimed it.
+func (r *postgresRepository) ClaimWeeklyRecap(ctx context.Context, userID int64, weekStart time.Time) (bool, error) {
+    query := `
+        INSERT INTO weekly_recaps (user_id, week_start)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id, week_start) DO NOTHING`
+    
+    result, err := r.db.ExecContext(ctx, query, userID, weekStart.Format("2006-01-02"))
+    if err != nil {
+        return false, err
+    }
+    
+    rows, err := result.RowsAffected()
+    return rows == 1, err
+}
+
+// ReleaseWeeklyRecap drops a claim so the recap is retried on the next run
+func (r *postgresRepository) ReleaseWeeklyRecap(ctx context.Context, userID int64, weekStart time.Time) error {
+    query := `DELETE FROM weekly_recaps WHERE user_id = $1 AND week_start = $2`
+    _, err := r.db.ExecContext(ctx, query, userID, weekStart.Format("2006-01-02"))
+    return err
+}
+
+// CreateDelivery records a channel's delivery of a notification
+func (r *postgresRepository) CreateDelivery(ctx context.Context, delivery *Delivery) error {
+    query := `
+        INSERT INTO notification_deliveries (
+            notification_id, user_id, channel, status, provider_message_id,
+            attempts, last_error, next_attempt_at, sent_at
+        ) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+        RETURNING id, created_at, updated_at`
+    
+    return r.db.QueryRowContext(ctx, query,
+        delivery.NotificationID, delivery.UserID, delivery.Channel, delivery.Status,
+        delivery.ProviderMessageID, delivery.Attempts, delivery.LastError,
+        delivery.NextAttemptAt, delivery.SentAt,
+    ).Scan(&delivery.ID, &delivery.CreatedAt, &delivery.UpdatedAt)
+}
+
+// UpdateDelivery stores the outcome of another attempt
+func (r *postgresRepository) UpdateDelivery(ctx context.Context, delivery *Delivery) error {
+    query := `
+        UPDATE notification_deliveries
+        SET status = $2, provider_message_id = $3, attempts = $4, last_error = $5,
+            next_attempt_at = $6, sent_at = $7, updated_at = NOW()
+        WHERE id = $1
+        RETURNING updated_at`
+    
+    return r.db.QueryRowContext(ctx, query,
+        delivery.ID, delivery.Status, delivery.ProviderMessageID, delivery.Attempts,
+        delivery.LastError, delivery.NextAttemptAt, delivery.SentAt,
+    ).Scan(&delivery.UpdatedAt)
+}
+
+// UpdateDeliveryStatus applies a provider's delivery report to a sent
+// delivery. Reports arriving after a final status are ignored.
+func (r *postgresRepository) UpdateDeliveryStatus(ctx context.Context, channel DeliveryChannel, providerMessageID string, status DeliveryStatus, lastError *string) error {
+    query := `
+        UPDATE notification_deliveries
+        SET status = $3, last_error = COALESCE($4, last_error), updated_at = NOW()
+        WHERE channel = $1 AND provider_message_id = $2 AND status = 'sent'`
+    
+    _, err := r.db.ExecContext(ctx, query, channel, providerMessageID, status, lastError)
+    return err
+}
+
+// ClaimDueDeliveries returns queued deliveries whose retry is due, pushing
+// their next attempt back by lease so overlapping runs don't send them twice
+func (r *postgresRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, lease time.Duration, limit int) ([]*Delivery, error) {
+    query := `
+        UPDATE notification_deliveries
+        SET next_attempt_at = $2, updated_at = NOW()
+        WHERE id IN (
+            SELECT id FROM notification_deliveries
+            WHERE status = $3 AND next_attempt_at <= $1
+            ORDER BY next_attempt_at
+            LIMIT $4
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING *`
+    
+    deliveries := []*Delivery{}
+    err := r.db.SelectContext(ctx, &deliveries, query, now, now.Add(lease), DeliveryQueued, limit)
+    return deliveries, err
+}
+
+// GetDeliveries lists deliveries, newest first
+func (r *postgresRepository) GetDeliveries(ctx context.Context, filter *DeliveryFilter) ([]*Delivery, error) {
+    query := `
+        SELECT * FROM notification_deliveries
+        WHERE ($1 = '' OR status = $1)
+          AND ($2 = '' OR channel = $2)
+          AND ($3 = 0 OR user_id = $3)
+        ORDER BY created_at DESC, id DESC
+        LIMIT $4 OFFSET $5`
+    
+    deliveries := []*Delivery{}
+    err := r.db.SelectContext(ctx, &deliveries, query,
+        string(filter.Status), string(filter.Channel), filter.UserID, filter.Limit, filter.Offset)
+    return deliveries, err
+}
+
+// GetDeliveryHealth counts deliveries created since the given time, per channel
+func (r *postgresRepository) GetDeliveryHealth(ctx context.Context, since time.Time) ([]*ChannelHealth, error) {
+    query := `
+        SELECT channel,
+               COUNT(*) AS total,
+               COUNT(*) FILTER (WHERE status = 'queued') AS queued,
+               COUNT(*) FILTER (WHERE status = 'sent') AS sent,
+               COUNT(*) FILTER (WHERE status = 'delivered') AS delivered,
+               COUNT(*) FILTER (WHERE status = 'failed') AS failed,
+               COUNT(*) FILTER (WHERE status = 'bounced') AS bounced,
+               COUNT(*) FILTER (WHERE attempts > 1) AS retried
+        FROM notification_deliveries
+        WHERE created_at >= $1
+        GROUP BY channel
+        ORDER BY channel`
+    
+    health := []*ChannelHealth{}
+    err := r.db.SelectContext(ctx, &health, query, since)
+    return health, err
+}
+
+// segmentWhere returns the conditions that select a segment's users out of
+// users u LEFT JOIN notification_preferences np. Only active users who
+// allow promotions are ever included.
+func segmentWhere(segment *Segment, args *sqlbuilder.Args) string {
+    conditions := []string{
+        "COALESCE(u.account_status, 'active') = 'active'",
+        "COALESCE(np.promotions, true)",
+    }
+    
+    // A user's country is where they last signed in from
+    if len(segment.Countries) > 0 {
+        conditions = append(conditions, `(
+            SELECT ll.country FROM login_locations ll
+            WHERE ll.user_id = u.id AND ll.country IS NOT NULL
+            ORDER BY ll.last_seen_at DESC
+            LIMIT 1
+        ) = ANY(`+args.Add(pq.Array(segment.Countries))+`)`)
+    }
+    if len(segment.Genders) > 0 {
+        conditions = append(conditions, "u.gender = ANY("+args.Add(pq.Array(segment.Genders))+")")
+    }
+    if segment.ActiveWithinDays > 0 {
+        conditions = append(conditions, "u.last_seen >= "+args.Add(time.Now().AddDate(0, 0, -segment.ActiveWithinDays)))
+    }
+    if segment.InactiveForDays > 0 {
+        conditions = append(conditions, "(u.last_seen IS NULL OR u.last_seen < "+args.Add(time.Now().AddDate(0, 0, -segment.InactiveForDays))+")")
+    }
+    if segment.Premium != nil {
+        conditions = append(conditions, "COALESCE(u.is_premium, false) = "+args.Add(*segment.Premium))
+    }
+    
+    return strings.Join(conditions, " AND ")
+}
+
+// CountSegment counts the users a segment currently selects
+func (r *postgresRepository) CountSegment(ctx context.Context, segment *Segment) (int, error) {
+    var args sqlbuilder.Args
+    query := `
+        SELECT COUNT(*)
+        FROM users u
+        LEFT JOIN notification_preferences np ON np.user_id = u.id
+        WHERE ` + segmentWhere(segment, &args)
+    
+    var count int
+    err := r.db.GetContext(ctx, &count, query, args.Values()...)
+    return count, err
+}
+
+// GetSegmentUserIDs pages through a segment's users in ID order
+func (r *postgresRepository) GetSegmentUserIDs(ctx context.Context, segment *Segment, afterID int64, limit int) ([]int64, error) {
+    var args sqlbuilder.Args
+    where := segmentWhere(segment, &args)
+    query := `
+        SELECT u.id
+        FROM users u
+        LEFT JOIN notification_preferences np ON np.user_id = u.id
+        WHERE u.id > ` + args.Add(afterID) + ` AND ` + where + `
+        ORDER BY u.id
+        LIMIT ` + args.Add(limit)
+    
+    var userIDs []int64
+    err := r.db.SelectContext(ctx, &userIDs, query, args.Values()...)
+    return userIDs, err
+}
+
+// channelPreferences are the preference columns that turn a channel off,
+// with the default for users who haven't saved preferences
+var channelPreferences = map[DeliveryChannel]string{
+    ChannelPush:  "COALESCE(np.push_enabled, true)",
+    ChannelEmail: "COALESCE(np.email_enabled, true)",
+    ChannelSMS:   "COALESCE(np.sms_enabled, false)",
+}
+
+// FilterChannelEnabled returns those of userIDs who haven't turned channel
+// off. In-app notifications can't be turned off.
+func (r *postgresRepository) FilterChannelEnabled(ctx context.Context, userIDs []int64, channel DeliveryChannel) ([]int64, error) {
+    enabled, ok := channelPreferences[channel]
+    if !ok || len(userIDs) == 0 {
+        return userIDs, nil
+    }
+    
+    query := `
+        SELECT u.id
+        FROM unnest($1::bigint[]) AS u(id)
+        LEFT JOIN notification_preferences np ON np.user_id = u.id
+        WHERE ` + enabled + `
+        ORDER BY u.id`
+    
+    var filtered []int64
+    err := r.db.SelectContext(ctx, &filtered, query, pq.Array(userIDs))
+    return filtered, err
+}
+
+// CreateCampaign stores a new scheduled campaign
+func (r *postgresRepository) CreateCampaign(ctx context.Context, campaign *Campaign) error {
+    query := `
+        INSERT INTO notification_campaigns (name, title, message, data, channels, segment,
+            status, scheduled_for, rate_per_minute, audience_size, created_by)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+        RETURNING id, created_at`
+    
+    return r.db.QueryRowContext(ctx, query,
+        campaign.Name, campaign.Title, campaign.Message, campaign.Data, campaign.Channels, campaign.Segment,
+        campaign.Status, campaign.ScheduledFor, campaign.RatePerMinute, campaign.AudienceSize, campaign.CreatedBy,
+    ).Scan(&campaign.ID, &campaign.CreatedAt)
+}
+
+// campaignWithMetrics is a campaign row with its open and click counts
+type campaignWithMetrics struct {
+    Campaign
+    Opens  int `db:"opens"`
+    Clicks int `db:"clicks"`
+}
+
+const campaignMetricsQuery = `
+    SELECT c.*,
+           (SELECT COUNT(*) FROM campaign_events e WHERE e.campaign_id = c.id AND e.event = 'open') AS opens,
+           (SELECT COUNT(*) FROM campaign_events e WHERE e.campaign_id = c.id AND e.event = 'click') AS clicks
+    FROM notification_campaigns c`
+
+func (row *campaignWithMetrics) campaign() *Campaign {
+    campaign := row.Campaign
+    campaign.Metrics = &CampaignMetrics{Opens: row.Opens, Clicks: row.Clicks}
+    return &campaign
+}
+
+// GetCampaign retrieves a campaign with its metrics
+func (r *postgresRepository) GetCampaign(ctx context.Context, campaignID int64) (*Campaign, error) {
+    var row campaignWithMetrics
+    err := r.db.GetContext(ctx, &row, campaignMetricsQuery+` WHERE c.id = $1`, campaignID)
+    if err == sql.ErrNoRows {
+        return nil, ErrCampaignNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return row.campaign(), nil
+}
+
+// GetCampaigns lists campaigns, newest first, with their metrics
+func (r *postgresRepository) GetCampaigns(ctx context.Context, limit, offset int) ([]*Campaign, error) {
+    var rows []*campaignWithMetrics
+    err := r.db.SelectContext(ctx, &rows, campaignMetricsQuery+`
+        ORDER BY c.created_at DESC, c.id DESC
+        LIMIT $1 OFFSET $2`, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+    
+    campaigns := make([]*Campaign, len(rows))
+    for i, row := range rows {
+        campaigns[i] = row.campaign()
+    }
+    return campaigns, nil
+}
+
+// CancelCampaign stops a campaign that hasn't finished, reporting whether
+// there was one to stop
+func (r *postgresRepository) CancelCampaign(ctx context.Context, campaignID int64) (bool, error) {
+    query := `
+        UPDATE notification_campaigns
+        SET status = 'cancelled', completed_at = NOW()
+        WHERE id = $1 AND status IN ('scheduled', 'sending')`
+    
+    result, err := r.db.ExecContext(ctx, query, campaignID)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// ClaimDueCampaigns marks campaigns that are due, and haven't had a batch
+// since lastBatchBefore, as sending a batch now. The claim keeps instances
+// from sending the same batch twice.
+func (r *postgresRepository) ClaimDueCampaigns(ctx context.Context, now, lastBatchBefore time.Time) ([]*Campaign, error) {
+    query := `
+        UPDATE notification_campaigns
+        SET status = 'sending', started_at = COALESCE(started_at, $1), last_batch_at = $1
+        WHERE status IN ('scheduled', 'sending')
+          AND scheduled_for <= $1
+          AND (last_batch_at IS NULL OR last_batch_at <= $2)
+        RETURNING *`
+    
+    var campaigns []*Campaign
+    err := r.db.SelectContext(ctx, &campaigns, query, now, lastBatchBefore)
+    return campaigns, err
+}
+
+// AdvanceCampaign moves a campaign's cursor past a sent batch. It reports
+// false if the campaign was cancelled meanwhile.
+func (r *postgresRepository) AdvanceCampaign(ctx context.Context, campaignID, lastUserID int64, sent int) (bool, error) {
+    query := `
+        UPDATE notification_campaigns
+        SET last_user_id = $2, sent_count = sent_count + $3
+        WHERE id = $1 AND status = 'sending'`
+    
+    result, err := r.db.ExecContext(ctx, query, campaignID, lastUserID, sent)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// CompleteCampaign marks a campaign whose whole audience has been sent to
+func (r *postgresRepository) CompleteCampaign(ctx context.Context, campaignID int64) error {
+    query := `
+        UPDATE notification_campaigns
+        SET status = 'sent', completed_at = NOW()
+        WHERE id = $1 AND status = 'sending'`
+    _, err := r.db.ExecContext(ctx, query, campaignID)
+    return err
+}
+
+// RecordCampaignEvent records a recipient opening or clicking a campaign,
+// once per event. It reports false if the user wasn't sent the campaign.
+func (r *postgresRepository) RecordCampaignEvent(ctx context.Context, campaignID, userID int64, event string) (bool, error) {
+    query := `
+        INSERT INTO campaign_events (campaign_id, user_id, event)
+        SELECT $1::integer, $2::integer, $3::varchar
+        WHERE EXISTS (
+            SELECT 1 FROM notifications
+            WHERE user_id = $2 AND type = $4 AND data->>'campaign_id' = $5
+        )
+        ON CONFLICT (campaign_id, user_id, event) DO NOTHING`
+    
+    result, err := r.db.ExecContext(ctx, query, campaignID, userID, event, TypePromotion, fmt.Sprint(campaignID))
+    if err != nil {
+        return false, err
+    }
+    
+    // A repeated event inserts nothing but still counts as recorded
+    if rows, err := result.RowsAffected(); err != nil || rows > 0 {
+        return rows > 0, err
+    }
+    var received bool
+    err = r.db.GetContext(ctx, &received, `
+        SELECT EXISTS (
+            SELECT 1 FROM campaign_events WHERE campaign_id = $1 AND user_id = $2
+        )`, campaignID, userID)
+    return received, err
+}
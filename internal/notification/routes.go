@@ -1,26 +1,42 @@
 package notifications
 
 import (
+    "net/http"
+
     "github.com/gorilla/mux"
     "github.com/imadgeboyega/kiekky-backend/internal/auth"
 )
 
 func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    // Delivery reports are authenticated by the provider's signature, not a
+    // user token
+    router.HandleFunc("/api/v1/notifications/webhooks/sms/{provider}", handler.HandleSMSCallback).Methods("POST")
+    
     // Protected routes
     api := router.PathPrefix("/api/v1/notifications").Subrouter()
     api.Use(authMiddleware.Authenticate)
     
     // User notifications
     api.HandleFunc("", handler.GetNotifications).Methods("GET")
+    api.HandleFunc("/unread-count", handler.GetUnreadCount).Methods("GET")
     api.HandleFunc("/{id}", handler.GetNotification).Methods("GET")
     api.HandleFunc("/{id}/read", handler.MarkAsRead).Methods("PUT")
     api.HandleFunc("/read-all", handler.MarkAllAsRead).Methods("PUT")
     api.HandleFunc("/{id}", handler.DeleteNotification).Methods("DELETE")
+    api.HandleFunc("/{id}/actions/{action}", handler.ExecuteAction).Methods("POST")
     
     // Push tokens
     api.HandleFunc("/push-token", handler.RegisterPushToken).Methods("POST")
     api.HandleFunc("/push-token", handler.UnregisterPushToken).Methods("DELETE")
     
+    // Web push for the browser client
+    api.HandleFunc("/web-push/config", handler.GetWebPushConfig).Methods("GET")
+    api.HandleFunc("/web-push/subscription", handler.RegisterWebPushSubscription).Methods("POST")
+    api.HandleFunc("/web-push/subscription", handler.UnregisterWebPushSubscription).Methods("DELETE")
+    
+    // Campaign opens and clicks
+    api.HandleFunc("/campaigns/{id}/events", handler.RecordCampaignEvent).Methods("POST")
+    
     // Preferences
     api.HandleFunc("/preferences", handler.GetPreferences).Methods("GET")
     api.HandleFunc("/preferences", handler.UpdatePreferences).Methods("PUT")
@@ -28,13 +44,31 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.M
     // Test notification
     api.HandleFunc("/test", handler.TestPushNotification).Methods("POST")
     
-    // Admin routes (should add admin middleware)
+    // Admin routes (support and admins; sends to many users are admins only)
     admin := router.PathPrefix("/api/v1/admin/notifications").Subrouter()
     admin.Use(authMiddleware.Authenticate)
-    // TODO: Add admin authorization middleware
+    admin.Use(authMiddleware.RequireStaff)
     
     admin.HandleFunc("/send", handler.SendNotification).Methods("POST")
-    admin.HandleFunc("/broadcast", handler.BroadcastNotification).Methods("POST")
-    admin.HandleFunc("/schedule", handler.ScheduleNotification).Methods("POST")
-    admin.HandleFunc("/schedule/{id}/cancel", handler.CancelScheduledNotification).Methods("PUT")
+    admin.Handle("/broadcast", authMiddleware.RequireAdmin(http.HandlerFunc(handler.BroadcastNotification))).Methods("POST")
+    admin.Handle("/schedule", authMiddleware.RequireAdmin(http.HandlerFunc(handler.ScheduleNotification))).Methods("POST")
+    admin.Handle("/schedule/{id}/cancel", authMiddleware.RequireAdmin(http.HandlerFunc(handler.CancelScheduledNotification))).Methods("PUT")
+    
+    // Delivery log
+    admin.HandleFunc("/deliveries", handler.GetDeliveries).Methods("GET")
+    admin.HandleFunc("/deliveries/health", handler.GetDeliveryHealth).Methods("GET")
+    
+    // Campaigns; staff can look, only admins can send or cancel
+    admin.HandleFunc("/campaigns", handler.GetCampaigns).Methods("GET")
+    admin.Handle("/campaigns", authMiddleware.RequireAdmin(http.HandlerFunc(handler.CreateCampaign))).Methods("POST")
+    admin.HandleFunc("/campaigns/preview", handler.PreviewCampaignAudience).Methods("POST")
+    admin.HandleFunc("/campaigns/{id}", handler.GetCampaign).Methods("GET")
+    admin.Handle("/campaigns/{id}/cancel", authMiddleware.RequireAdmin(http.HandlerFunc(handler.CancelCampaign))).Methods("PUT")
+    
+    // Templates
+    admin.HandleFunc("/templates", handler.GetTemplates).Methods("GET")
+    admin.HandleFunc("/templates/diff", handler.PreviewTemplateUpdates).Methods("GET")
+    admin.Handle("/templates/apply", authMiddleware.RequireAdmin(http.HandlerFunc(handler.ApplyTemplateUpdates))).Methods("POST")
+    admin.HandleFunc("/templates/{type}/{language}/versions", handler.GetTemplateVersions).Methods("GET")
+    admin.Handle("/templates/{type}/{language}/rollback", authMiddleware.RequireAdmin(http.HandlerFunc(handler.RollbackTemplate))).Methods("POST")
 }
\ No newline at end of file
@@ -4,10 +4,18 @@ package notifications
 
 import (
     "context"
+    "database/sql"
     "errors"
     "fmt"
     "log"
+    "strings"
     "time"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/email"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/sms"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
 )
 
 var (
@@ -15,8 +23,22 @@ var (
     ErrUnauthorized        = errors.New("unauthorized")
     ErrInvalidChannel      = errors.New("invalid delivery channel")
     ErrTemplateNotFound    = errors.New("template not found")
+    ErrActorBlocked        = errors.New("recipient has a block with the notification actor")
+    ErrWebPushDisabled     = errors.New("web push is not configured")
+    ErrInvalidSubscription = errors.New("invalid web push subscription")
+    ErrCampaignNotFound    = errors.New("campaign not found")
+    ErrCampaignFinished    = errors.New("campaign has already finished")
+    ErrActionNotFound      = errors.New("notification has no such action")
+    ErrActionTaken         = errors.New("an action was already taken on this notification")
+    ErrActionInputRequired = errors.New("this action needs input")
 )
 
+// actorKeys are the data keys that name the user who caused a notification
+var actorKeys = []string{
+    "actor_id", "follower_id", "liker_id", "commenter_id", "sender_id",
+    "mentioner_id", "reposter_id", "matched_user_id", "replier_id",
+}
+
 type Service interface {
     // Core notification operations
     SendNotification(ctx context.Context, req *CreateNotificationRequest) (*Notification, error)
@@ -25,11 +47,19 @@ type Service interface {
     GetNotification(ctx context.Context, notificationID int64, userID int64) (*Notification, error)
     MarkAsRead(ctx context.Context, notificationID int64, userID int64) error
     MarkAllAsRead(ctx context.Context, userID int64) error
+    GetUnreadCount(ctx context.Context, userID int64) (*UnreadCount, error)
     DeleteNotification(ctx context.Context, notificationID int64, userID int64) error
     
+    // Notification actions
+    ResolveAction(ctx context.Context, notificationID, userID int64, actionID, input string) (*NotificationAction, error)
+    ReleaseAction(ctx context.Context, notificationID int64, actionID string) error
+    
     // Push token management
     RegisterPushToken(ctx context.Context, userID int64, req *RegisterPushTokenRequest) error
     UnregisterPushToken(ctx context.Context, token string) error
+    GetWebPushConfig(ctx context.Context) (*WebPushConfig, error)
+    RegisterWebPushSubscription(ctx context.Context, userID int64, req *RegisterWebPushRequest) error
+    UnregisterWebPushSubscription(ctx context.Context, userID int64, endpoint string) error
     
     // Preferences
     GetPreferences(ctx context.Context, userID int64) (*NotificationPreferences, error)
@@ -45,14 +75,86 @@ type Service interface {
     SendFollowNotification(ctx context.Context, followerID, followedID int64) error
     SendLikeNotification(ctx context.Context, likerID, postOwnerID int64, postID int64) error
     SendCommentNotification(ctx context.Context, commenterID, postOwnerID int64, postID int64, comment string) error
-    SendMessageNotification(ctx context.Context, senderID, receiverID int64, message string) error
+    SendMessageNotification(ctx context.Context, senderID, receiverID, conversationID, messageID int64, message string) error
     SendMatchNotification(ctx context.Context, user1ID, user2ID int64) error
+    SendDateRequestNotification(ctx context.Context, senderID, receiverID, requestID int64) error
+    SendHotpicksNotification(ctx context.Context, userID int64, count int) error
+    SendContactJoinedNotification(ctx context.Context, userID, contactID int64) error
+    SendMentionNotification(ctx context.Context, mentionerID, mentionedUserID int64, contentType string, contentID int64) error
+    SendRepostNotification(ctx context.Context, reposterID, postOwnerID int64, postID, repostID int64) error
+    SendPostPublishedNotification(ctx context.Context, userID, postID int64) error
+    SendStoryReplyNotification(ctx context.Context, replierID, storyOwnerID, storyID int64, reply string) error
+    SendNewDeviceLoginNotification(ctx context.Context, userID int64, device, location, ipAddress string, at time.Time) error
+    SendSuspiciousLoginNotification(ctx context.Context, userID int64, reason, location, ipAddress string, at time.Time) error
+    SendAccountLockedNotification(ctx context.Context, userID int64, unlockURL string, until time.Time) error
+    
+    // Campaigns
+    PreviewCampaignAudience(ctx context.Context, segment *Segment) (*CampaignAudience, error)
+    CreateCampaign(ctx context.Context, createdBy int64, req *CreateCampaignRequest) (*Campaign, error)
+    GetCampaign(ctx context.Context, campaignID int64) (*Campaign, error)
+    GetCampaigns(ctx context.Context, limit, offset int) ([]*Campaign, error)
+    CancelCampaign(ctx context.Context, campaignID int64) error
+    ProcessCampaigns(ctx context.Context, now time.Time) (int, error)
+    RecordCampaignEvent(ctx context.Context, userID, campaignID int64, event string) error
+    
+    // Templates
+    GetTemplates(ctx context.Context) ([]*NotificationTemplate, error)
+    PreviewTemplateUpdates(ctx context.Context) ([]*TemplateChange, error)
+    ApplyTemplateUpdates(ctx context.Context, req *ApplyTemplatesRequest) ([]*TemplateChange, error)
+    GetTemplateVersions(ctx context.Context, notificationType NotificationType, language string) ([]*TemplateVersion, error)
+    RollbackTemplate(ctx context.Context, notificationType NotificationType, language string, version int) (*NotificationTemplate, error)
+    
+    // SetUserInfoProvider sets how recipients' contact details and actors
+    // are looked up
+    SetUserInfoProvider(provider UserInfoProvider)
+    
+    // SubscribeEvents subscribes to the domain events that notify users
+    SubscribeEvents(bus *events.Bus)
+    
+    // SetBlocks stops delivery of notifications between users with a block
+    SetBlocks(blockService blocks.Service)
+    
+    // SetGroupingWindow sets how long repeated activity collapses into one notification
+    SetGroupingWindow(window time.Duration)
+    
+    // SetRealtime sends badge count updates over WebSocket
+    SetRealtime(realtime Realtime)
+    
+    // SetAppURL sets the web app URL used for links in emails
+    SetAppURL(appURL string)
+    
+    // SetSMSCallbacks lets SMS providers' delivery reports update the
+    // delivery log
+    SetSMSCallbacks(parser SMSCallbackParser)
+    
+    // SetIOSTokenType sets which provider issued iOS push tokens registered
+    // without a token type
+    SetIOSTokenType(tokenType TokenType)
+    
+    // SetWebPushPublicKey sets the VAPID public key browsers subscribe with,
+    // turning on web push subscriptions
+    SetWebPushPublicKey(publicKey string)
+    
+    // Weekly recap
+    SendWeeklyRecaps(ctx context.Context, now time.Time) (int, error)
+    
+    // Delivery log
+    RetryDeliveries(ctx context.Context) (int, error)
+    GetDeliveries(ctx context.Context, filter *DeliveryFilter) ([]*Delivery, error)
+    GetDeliveryHealth(ctx context.Context, since time.Time) (*DeliveryHealth, error)
+    HandleSMSCallback(ctx context.Context, provider string, req *sms.CallbackRequest) error
     
     // Cleanup
     CleanupOldNotifications(ctx context.Context, olderThan time.Duration) error
 }
 
 // External service interfaces
+
+// SMSCallbackParser verifies and normalizes SMS providers' delivery status
+// callbacks
+type SMSCallbackParser interface {
+    ParseCallback(provider string, req *sms.CallbackRequest) (*sms.StatusUpdate, error)
+}
 type PushService interface {
     SendPush(ctx context.Context, notification *PushNotification) error
     SendBatchPush(ctx context.Context, notifications []*PushNotification) error
@@ -78,6 +180,14 @@ type service struct {
     emailService    EmailService
     smsService      SMSService
     templateService TemplateService
+    userInfo        UserInfoProvider
+    blocks          blocks.Service
+    groupWindow     time.Duration
+    realtime        Realtime
+    smsCallbacks    SMSCallbackParser
+    appURL          string
+    iosTokenType    TokenType
+    vapidPublicKey  string
 }
 
 func NewService(
@@ -93,11 +203,39 @@ func NewService(
         emailService:    emailService,
         smsService:      smsService,
         templateService: templateService,
+        groupWindow:     defaultGroupWindow,
+        appURL:          defaultAppURL,
+        iosTokenType:    TokenTypeFCM,
     }
 }
 
+// defaultAppURL is where links in emails point until SetAppURL is called
+const defaultAppURL = "https://kiekky.com"
+
+// SetAppURL sets the web app URL used for links in emails
+func (s *service) SetAppURL(appURL string) {
+    s.appURL = appURL
+}
+
+// SetIOSTokenType sets which provider issued iOS push tokens registered
+// without a token type. Older app builds only register FCM tokens.
+func (s *service) SetIOSTokenType(tokenType TokenType) {
+    s.iosTokenType = tokenType
+}
+
+// SetWebPushPublicKey sets the VAPID public key browsers subscribe with.
+// Without it web push subscriptions are refused.
+func (s *service) SetWebPushPublicKey(publicKey string) {
+    s.vapidPublicKey = publicKey
+}
+
 // SendNotification sends a notification to a user
 func (s *service) SendNotification(ctx context.Context, req *CreateNotificationRequest) (*Notification, error) {
+    // Nothing is delivered between users with a block in either direction
+    if s.isActorBlocked(ctx, req.UserID, req.Data) {
+        return nil, ErrActorBlocked
+    }
+    
     // Check user preferences
     prefs, err := s.repo.GetUserPreferences(ctx, req.UserID)
     if err != nil {
@@ -105,6 +243,15 @@ func (s *service) SendNotification(ctx context.Context, req *CreateNotificationR
         // Continue with default preferences
     }
     
+    // Repeated likes, follows and views collapse into one notification
+    groupKey, actorID, grouped := groupOf(req)
+    if grouped && s.groupWindow > 0 {
+        if notification, ok := s.collapse(ctx, req, groupKey, actorID, prefs); ok {
+            s.publishUnreadCount(ctx, req.UserID)
+            return notification, nil
+        }
+    }
+    
     // Create in-app notification
     notification := &Notification{
         UserID:  req.UserID,
@@ -114,6 +261,11 @@ func (s *service) SendNotification(ctx context.Context, req *CreateNotificationR
         Data:    req.Data,
         IsRead:  false,
     }
+    if grouped {
+        notification.GroupKey = &groupKey
+        notification.ActorIDs = []int64{actorID}
+        notification.ActorCount = 1
+    }
     
     if err := s.repo.CreateNotification(ctx, notification); err != nil {
         return nil, err
@@ -125,25 +277,48 @@ func (s *service) SendNotification(ctx context.Context, req *CreateNotificationR
         channels = s.getDefaultChannels(req.Type, prefs)
     }
     
-    // Send through requested channels
+    s.sendOnChannels(ctx, notification, channels, prefs)
+    s.publishUnreadCount(ctx, req.UserID)
+    
+    return notification, nil
+}
+
+// sendOnChannels sends a stored notification through the given channels the
+// user allows. Delivery outlives the request, and each attempt is logged in
+// notification_deliveries. Push and SMS during quiet hours are held until
+// the window ends.
+func (s *service) sendOnChannels(ctx context.Context, notification *Notification, channels []DeliveryChannel, prefs *NotificationPreferences) {
+    deliveryCtx := context.WithoutCancel(ctx)
+    quietUntil, quiet := prefs.quietUntil(time.Now())
+    var held []DeliveryChannel
     for _, channel := range channels {
         switch channel {
         case ChannelPush:
-            if prefs.PushEnabled && s.shouldSendForType(req.Type, prefs) {
-                go s.sendPushNotification(ctx, req.UserID, notification)
+            if prefs.PushEnabled && s.shouldSendForType(notification.Type, prefs) {
+                if quiet {
+                    held = append(held, ChannelPush)
+                } else {
+                    go s.deliverChannel(deliveryCtx, ChannelPush, notification.UserID, notification)
+                }
             }
         case ChannelEmail:
-            if prefs.EmailEnabled && s.shouldSendForType(req.Type, prefs) {
-                go s.sendEmailNotification(ctx, req.UserID, notification)
+            if prefs.EmailEnabled && s.shouldSendForType(notification.Type, prefs) {
+                go s.deliverChannel(deliveryCtx, ChannelEmail, notification.UserID, notification)
             }
         case ChannelSMS:
-            if prefs.SMSEnabled && s.shouldSendForType(req.Type, prefs) {
-                go s.sendSMSNotification(ctx, req.UserID, notification)
+            if prefs.SMSEnabled && s.shouldSendForType(notification.Type, prefs) {
+                if quiet {
+                    held = append(held, ChannelSMS)
+                } else {
+                    go s.deliverChannel(deliveryCtx, ChannelSMS, notification.UserID, notification)
+                }
             }
         }
     }
     
-    return notification, nil
+    if len(held) > 0 {
+        s.holdForQuietHours(ctx, notification, held, quietUntil)
+    }
 }
 
 // SendBatchNotifications sends notifications to multiple users
@@ -178,6 +353,12 @@ func (s *service) SendBatchNotifications(ctx context.Context, req *BroadcastNoti
         return err
     }
     
+    go func(ctx context.Context) {
+        for _, userID := range userIDs {
+            s.publishUnreadCount(ctx, userID)
+        }
+    }(context.WithoutCancel(ctx))
+    
     // Send through requested channels
     for _, channel := range req.Channels {
         switch channel {
@@ -215,8 +396,9 @@ func (s *service) GetNotifications(ctx context.Context, userID int64, limit, off
     }
     
     // Enrich notifications with actor information if needed
+    actors := s.resolveActors(ctx, notifications...)
     for _, n := range notifications {
-        s.enrichNotification(ctx, n)
+        s.enrichNotification(ctx, n, actors)
     }
     
     return &NotificationsResponse{
@@ -242,33 +424,52 @@ func (s *service) GetNotification(ctx context.Context, notificationID int64, use
         return nil, ErrUnauthorized
     }
     
-    s.enrichNotification(ctx, notification)
+    s.enrichNotification(ctx, notification, s.resolveActors(ctx, notification))
     return notification, nil
 }
 
 // MarkAsRead marks a notification as read
 func (s *service) MarkAsRead(ctx context.Context, notificationID int64, userID int64) error {
-    return s.repo.MarkAsRead(ctx, notificationID, userID)
+    if err := s.repo.MarkAsRead(ctx, notificationID, userID); err != nil {
+        return err
+    }
+    s.publishUnreadCount(ctx, userID)
+    return nil
 }
 
 // MarkAllAsRead marks all notifications as read for a user
 func (s *service) MarkAllAsRead(ctx context.Context, userID int64) error {
-    return s.repo.MarkAllAsRead(ctx, userID)
+    if err := s.repo.MarkAllAsRead(ctx, userID); err != nil {
+        return err
+    }
+    s.publishUnreadCount(ctx, userID)
+    return nil
 }
 
 // DeleteNotification deletes a notification
 func (s *service) DeleteNotification(ctx context.Context, notificationID int64, userID int64) error {
-    return s.repo.DeleteNotification(ctx, notificationID, userID)
+    if err := s.repo.DeleteNotification(ctx, notificationID, userID); err != nil {
+        return err
+    }
+    s.publishUnreadCount(ctx, userID)
+    return nil
 }
 
 // RegisterPushToken registers a push token for a user
 func (s *service) RegisterPushToken(ctx context.Context, userID int64, req *RegisterPushTokenRequest) error {
     token := &PushToken{
-        UserID:   userID,
-        Platform: req.Platform,
-        Token:    req.Token,
-        DeviceID: req.DeviceID,
-        IsActive: true,
+        UserID:    userID,
+        Platform:  req.Platform,
+        TokenType: req.TokenType,
+        Token:     req.Token,
+        DeviceID:  req.DeviceID,
+        IsActive:  true,
+    }
+    if token.TokenType == "" {
+        token.TokenType = TokenTypeFCM
+        if token.Platform == PlatformIOS {
+            token.TokenType = s.iosTokenType
+        }
     }
     
     return s.repo.SavePushToken(ctx, token)
@@ -279,6 +480,43 @@ func (s *service) UnregisterPushToken(ctx context.Context, token string) error {
     return s.repo.DeletePushToken(ctx, token)
 }
 
+// GetWebPushConfig returns the VAPID public key the browser client
+// subscribes with
+func (s *service) GetWebPushConfig(ctx context.Context) (*WebPushConfig, error) {
+    if s.vapidPublicKey == "" {
+        return nil, ErrWebPushDisabled
+    }
+    return &WebPushConfig{PublicKey: s.vapidPublicKey}, nil
+}
+
+// RegisterWebPushSubscription stores a browser's push subscription as a web
+// push token
+func (s *service) RegisterWebPushSubscription(ctx context.Context, userID int64, req *RegisterWebPushRequest) error {
+    if s.vapidPublicKey == "" {
+        return ErrWebPushDisabled
+    }
+    if err := req.validate(); err != nil {
+        return err
+    }
+    
+    token := &PushToken{
+        UserID:    userID,
+        Platform:  PlatformWeb,
+        TokenType: TokenTypeWebPush,
+        Token:     req.Token(),
+        DeviceID:  req.DeviceID,
+        IsActive:  true,
+    }
+    
+    return s.repo.SavePushToken(ctx, token)
+}
+
+// UnregisterWebPushSubscription removes the user's subscription for a push
+// service endpoint, as when the browser unsubscribes
+func (s *service) UnregisterWebPushSubscription(ctx context.Context, userID int64, endpoint string) error {
+    return s.repo.DeleteWebPushSubscription(ctx, userID, endpoint)
+}
+
 // GetPreferences retrieves user notification preferences
 func (s *service) GetPreferences(ctx context.Context, userID int64) (*NotificationPreferences, error) {
     return s.repo.GetUserPreferences(ctx, userID)
@@ -324,6 +562,30 @@ func (s *service) UpdatePreferences(ctx context.Context, userID int64, req *Upda
     if req.Promotions != nil {
         updates["promotions"] = *req.Promotions
     }
+    if req.WeeklyRecap != nil {
+        updates["weekly_recap"] = *req.WeeklyRecap
+    }
+    if req.ContactsJoined != nil {
+        updates["contacts_joined"] = *req.ContactsJoined
+    }
+    if req.QuietHoursStart != nil {
+        if err := validateQuietHours(*req.QuietHoursStart); err != nil {
+            return err
+        }
+        updates["quiet_hours_start"] = nullIfEmpty(*req.QuietHoursStart)
+    }
+    if req.QuietHoursEnd != nil {
+        if err := validateQuietHours(*req.QuietHoursEnd); err != nil {
+            return err
+        }
+        updates["quiet_hours_end"] = nullIfEmpty(*req.QuietHoursEnd)
+    }
+    if req.Timezone != nil {
+        if _, err := time.LoadLocation(*req.Timezone); err != nil || *req.Timezone == "" {
+            return ErrInvalidTimezone
+        }
+        updates["timezone"] = *req.Timezone
+    }
     
     return s.repo.UpdateUserPreferences(ctx, userID, updates)
 }
@@ -362,6 +624,18 @@ func (s *service) ProcessScheduledNotifications(ctx context.Context) error {
     }
     
     for _, scheduled := range notifications {
+        // Delivery held for quiet hours; the in-app notification exists
+        if scheduled.NotificationID != nil {
+            status := "sent"
+            sentAt := time.Now()
+            if err := s.deliverHeld(ctx, scheduled); err != nil {
+                status = "failed"
+                log.Printf("Failed to deliver held notification %d: %v", scheduled.ID, err)
+            }
+            s.repo.UpdateScheduledNotificationStatus(ctx, scheduled.ID, status, &sentAt)
+            continue
+        }
+        
         // Create and send the notification
         req := &CreateNotificationRequest{
             UserID:   *scheduled.UserID,
@@ -390,47 +664,58 @@ func (s *service) ProcessScheduledNotifications(ctx context.Context) error {
 // Utility notification methods
 
 func (s *service) SendWelcomeNotification(ctx context.Context, userID int64) error {
+    recipient := s.recipient(ctx, userID)
+    title, message := s.render(ctx, TypeWelcome, recipient.Language, map[string]interface{}{
+        "username": recipient.Username,
+    })
+    
     req := &CreateNotificationRequest{
         UserID:  userID,
         Type:    TypeWelcome,
-        Title:   "Welcome to Kiekky! 🎉",
-        Message: "Start exploring and connecting with amazing people around you.",
+        Title:   title,
+        Message: message,
         Data: NotificationData{
             "action": "onboarding",
         },
     }
     
-    _, err := s.SendNotification(ctx, req)
-    return err
+    return s.deliver(ctx, req)
 }
 
 func (s *service) SendFollowNotification(ctx context.Context, followerID, followedID int64) error {
-    // Get follower info (would need user service)
-    followerName := fmt.Sprintf("User %d", followerID)
+    lang := s.recipient(ctx, followedID).Language
+    title, message := s.render(ctx, TypeFollow, lang, map[string]interface{}{
+        "follower_name": s.actorName(ctx, followerID, lang),
+        "follower_id":   followerID,
+    })
     
     req := &CreateNotificationRequest{
         UserID:  followedID,
         Type:    TypeFollow,
-        Title:   "New Follower! 👥",
-        Message: fmt.Sprintf("%s started following you", followerName),
+        Title:   title,
+        Message: message,
         Data: NotificationData{
             "follower_id": followerID,
             "action":      "profile",
         },
     }
     
-    _, err := s.SendNotification(ctx, req)
-    return err
+    return s.deliver(ctx, req)
 }
 
 func (s *service) SendLikeNotification(ctx context.Context, likerID, postOwnerID int64, postID int64) error {
-    likerName := fmt.Sprintf("User %d", likerID)
+    lang := s.recipient(ctx, postOwnerID).Language
+    title, message := s.render(ctx, TypeLike, lang, map[string]interface{}{
+        "liker_name": s.actorName(ctx, likerID, lang),
+        "liker_id":   likerID,
+        "post_id":    postID,
+    })
     
     req := &CreateNotificationRequest{
         UserID:  postOwnerID,
         Type:    TypeLike,
-        Title:   "Someone liked your post! ❤️",
-        Message: fmt.Sprintf("%s liked your post", likerName),
+        Title:   title,
+        Message: message,
         Data: NotificationData{
             "liker_id": likerID,
             "post_id":  postID,
@@ -438,23 +723,28 @@ func (s *service) SendLikeNotification(ctx context.Context, likerID, postOwnerID
         },
     }
     
-    _, err := s.SendNotification(ctx, req)
-    return err
+    return s.deliver(ctx, req)
 }
 
 func (s *service) SendCommentNotification(ctx context.Context, commenterID, postOwnerID int64, postID int64, comment string) error {
-    commenterName := fmt.Sprintf("User %d", commenterID)
-    
     // Truncate comment if too long
     if len(comment) > 50 {
         comment = comment[:47] + "..."
     }
     
+    lang := s.recipient(ctx, postOwnerID).Language
+    title, message := s.render(ctx, TypeComment, lang, map[string]interface{}{
+        "commenter_name": s.actorName(ctx, commenterID, lang),
+        "commenter_id":   commenterID,
+        "post_id":        postID,
+        "comment":        comment,
+    })
+    
     req := &CreateNotificationRequest{
         UserID:  postOwnerID,
         Type:    TypeComment,
-        Title:   "New comment on your post 💬",
-        Message: fmt.Sprintf("%s: %s", commenterName, comment),
+        Title:   title,
+        Message: message,
         Data: NotificationData{
             "commenter_id": commenterID,
             "post_id":      postID,
@@ -463,61 +753,337 @@ func (s *service) SendCommentNotification(ctx context.Context, commenterID, post
         },
     }
     
-    _, err := s.SendNotification(ctx, req)
-    return err
+    return s.deliver(ctx, req)
 }
 
-func (s *service) SendMessageNotification(ctx context.Context, senderID, receiverID int64, message string) error {
-    senderName := fmt.Sprintf("User %d", senderID)
-    
+func (s *service) SendMessageNotification(ctx context.Context, senderID, receiverID, conversationID, messageID int64, message string) error {
     // Truncate message if too long
     if len(message) > 50 {
         message = message[:47] + "..."
     }
     
+    lang := s.recipient(ctx, receiverID).Language
+    title, body := s.render(ctx, TypeMessage, lang, map[string]interface{}{
+        "sender_name": s.actorName(ctx, senderID, lang),
+        "sender_id":   senderID,
+        "message":     message,
+    })
+    
     req := &CreateNotificationRequest{
         UserID:  receiverID,
         Type:    TypeMessage,
-        Title:   fmt.Sprintf("Message from %s 💌", senderName),
-        Message: message,
+        Title:   title,
+        Message: body,
         Data: NotificationData{
-            "sender_id": senderID,
-            "action":    "chat",
+            "sender_id":       senderID,
+            "conversation_id": conversationID,
+            "message_id":      messageID,
+            "action":          "chat",
         },
     }
     
-    _, err := s.SendNotification(ctx, req)
-    return err
+    return s.deliver(ctx, req)
 }
 
 func (s *service) SendMatchNotification(ctx context.Context, user1ID, user2ID int64) error {
-    // Send to both users
-    req1 := &CreateNotificationRequest{
-        UserID:  user1ID,
-        Type:    TypeMatch,
-        Title:   "It's a Match! 💕",
-        Message: "You have a new match! Start a conversation now.",
+    // Send to both users, each in their own language
+    for _, pair := range [][2]int64{{user1ID, user2ID}, {user2ID, user1ID}} {
+        userID, matchedID := pair[0], pair[1]
+        lang := s.recipient(ctx, userID).Language
+        title, message := s.render(ctx, TypeMatch, lang, map[string]interface{}{
+            "matched_user_name": s.actorName(ctx, matchedID, lang),
+            "matched_user_id":   matchedID,
+        })
+        
+        s.SendNotification(ctx, &CreateNotificationRequest{
+            UserID:  userID,
+            Type:    TypeMatch,
+            Title:   title,
+            Message: message,
+            Data: NotificationData{
+                "matched_user_id": matchedID,
+                "action":          "chat",
+            },
+        })
+    }
+    
+    return nil
+}
+
+// SendDateRequestNotification tells a user someone asked them on a date.
+// It offers Accept and Decline.
+func (s *service) SendDateRequestNotification(ctx context.Context, senderID, receiverID, requestID int64) error {
+    lang := s.recipient(ctx, receiverID).Language
+    title, message := s.render(ctx, TypeDateRequest, lang, map[string]interface{}{
+        "sender_name": s.actorName(ctx, senderID, lang),
+        "sender_id":   senderID,
+    })
+    
+    req := &CreateNotificationRequest{
+        UserID:  receiverID,
+        Type:    TypeDateRequest,
+        Title:   title,
+        Message: message,
         Data: NotificationData{
-            "matched_user_id": user2ID,
-            "action":          "chat",
+            "sender_id":       senderID,
+            "date_request_id": requestID,
         },
     }
     
-    req2 := &CreateNotificationRequest{
-        UserID:  user2ID,
-        Type:    TypeMatch,
-        Title:   "It's a Match! 💕",
-        Message: "You have a new match! Start a conversation now.",
+    return s.deliver(ctx, req)
+}
+
+// SendHotpicksNotification tells a user their hotpicks for the day are ready
+func (s *service) SendHotpicksNotification(ctx context.Context, userID int64, count int) error {
+    lang := s.recipient(ctx, userID).Language
+    title, message := s.render(ctx, TypeHotpicks, lang, map[string]interface{}{
+        "count": count,
+    })
+    
+    return s.deliver(ctx, &CreateNotificationRequest{
+        UserID:  userID,
+        Type:    TypeHotpicks,
+        Title:   title,
+        Message: message,
         Data: NotificationData{
-            "matched_user_id": user1ID,
-            "action":          "chat",
+            "count": count,
+        },
+    })
+}
+
+// SendContactJoinedNotification tells a user someone in their contacts has
+// joined
+func (s *service) SendContactJoinedNotification(ctx context.Context, userID, contactID int64) error {
+    lang := s.recipient(ctx, userID).Language
+    title, message := s.render(ctx, TypeContactJoined, lang, map[string]interface{}{
+        "contact_name": s.actorName(ctx, contactID, lang),
+        "contact_id":   contactID,
+    })
+    
+    return s.deliver(ctx, &CreateNotificationRequest{
+        UserID:  userID,
+        Type:    TypeContactJoined,
+        Title:   title,
+        Message: message,
+        Data: NotificationData{
+            "contact_id": contactID,
+        },
+    })
+}
+
+// GetTemplates returns all stored notification templates
+func (s *service) GetTemplates(ctx context.Context) ([]*NotificationTemplate, error) {
+    return s.repo.GetAllTemplates(ctx)
+}
+
+// PreviewTemplateUpdates shows what applying the shipped default templates would change
+func (s *service) PreviewTemplateUpdates(ctx context.Context) ([]*TemplateChange, error) {
+    return PlanTemplateUpdates(ctx, s.repo)
+}
+
+// ApplyTemplateUpdates applies pending default template changes
+func (s *service) ApplyTemplateUpdates(ctx context.Context, req *ApplyTemplatesRequest) ([]*TemplateChange, error) {
+    return ApplyTemplateUpdates(ctx, s.repo, req.Types, req.Language)
+}
+
+// GetTemplateVersions returns the version history of a template
+func (s *service) GetTemplateVersions(ctx context.Context, notificationType NotificationType, language string) ([]*TemplateVersion, error) {
+    versions, err := s.repo.GetTemplateVersions(ctx, notificationType, language)
+    if err != nil {
+        return nil, err
+    }
+    if len(versions) == 0 {
+        return nil, ErrTemplateNotFound
+    }
+    return versions, nil
+}
+
+// RollbackTemplate restores an earlier version of a template
+func (s *service) RollbackTemplate(ctx context.Context, notificationType NotificationType, language string, version int) (*NotificationTemplate, error) {
+    template, err := s.repo.RollbackTemplate(ctx, notificationType, language, version)
+    if err == sql.ErrNoRows {
+        return nil, ErrTemplateNotFound
+    }
+    return template, err
+}
+
+// SendMentionNotification notifies a user they were mentioned in a post or story
+func (s *service) SendMentionNotification(ctx context.Context, mentionerID, mentionedUserID int64, contentType string, contentID int64) error {
+    lang := s.recipient(ctx, mentionedUserID).Language
+    title, message := s.render(ctx, TypeMention, lang, map[string]interface{}{
+        "mentioner_name": s.actorName(ctx, mentionerID, lang),
+        "mentioner_id":   mentionerID,
+        "post_id":        contentID,
+    })
+    
+    req := &CreateNotificationRequest{
+        UserID:  mentionedUserID,
+        Type:    TypeMention,
+        Title:   title,
+        Message: message,
+        Data: NotificationData{
+            "mentioner_id": mentionerID,
+            "content_type": contentType,
+            "content_id":   contentID,
+            "action":       contentType,
         },
     }
     
-    s.SendNotification(ctx, req1)
-    s.SendNotification(ctx, req2)
+    return s.deliver(ctx, req)
+}
+
+// SendRepostNotification notifies a post owner that their post was reposted
+func (s *service) SendRepostNotification(ctx context.Context, reposterID, postOwnerID int64, postID, repostID int64) error {
+    lang := s.recipient(ctx, postOwnerID).Language
+    title, message := s.render(ctx, TypeRepost, lang, map[string]interface{}{
+        "reposter_name": s.actorName(ctx, reposterID, lang),
+        "reposter_id":   reposterID,
+        "post_id":       postID,
+        "repost_id":     repostID,
+    })
     
-    return nil
+    req := &CreateNotificationRequest{
+        UserID:  postOwnerID,
+        Type:    TypeRepost,
+        Title:   title,
+        Message: message,
+        Data: NotificationData{
+            "reposter_id": reposterID,
+            "post_id":     postID,
+            "repost_id":   repostID,
+            "action":      "post",
+        },
+    }
+    
+    return s.deliver(ctx, req)
+}
+
+// SendStoryReplyNotification tells a user someone replied to their story.
+// The reply itself is in their direct conversation, which the
+// notification opens.
+func (s *service) SendStoryReplyNotification(ctx context.Context, replierID, storyOwnerID, storyID int64, reply string) error {
+    if len(reply) > 50 {
+        reply = reply[:47] + "..."
+    }
+    
+    lang := s.recipient(ctx, storyOwnerID).Language
+    title, message := s.render(ctx, TypeStoryReply, lang, map[string]interface{}{
+        "replier_name": s.actorName(ctx, replierID, lang),
+        "replier_id":   replierID,
+        "story_id":     storyID,
+        "reply":        reply,
+    })
+    
+    req := &CreateNotificationRequest{
+        UserID:  storyOwnerID,
+        Type:    TypeStoryReply,
+        Title:   title,
+        Message: message,
+        Data: NotificationData{
+            "replier_id": replierID,
+            "sender_id":  replierID,
+            "story_id":   storyID,
+            "action":     "chat",
+        },
+    }
+    
+    return s.deliver(ctx, req)
+}
+
+// SendPostPublishedNotification tells a user their scheduled post went out
+func (s *service) SendPostPublishedNotification(ctx context.Context, userID, postID int64) error {
+    lang := s.recipient(ctx, userID).Language
+    title, message := s.render(ctx, TypePostPublished, lang, map[string]interface{}{
+        "post_id": postID,
+    })
+    
+    req := &CreateNotificationRequest{
+        UserID:  userID,
+        Type:    TypePostPublished,
+        Title:   title,
+        Message: message,
+        Data: NotificationData{
+            "post_id": postID,
+            "action":  "post",
+        },
+    }
+    
+    return s.deliver(ctx, req)
+}
+
+// SendNewDeviceLoginNotification warns a user that their account was signed
+// in to from a device they haven't used before
+func (s *service) SendNewDeviceLoginNotification(ctx context.Context, userID int64, device, location, ipAddress string, at time.Time) error {
+    lang := s.recipient(ctx, userID).Language
+    
+    req := &CreateNotificationRequest{
+        UserID:  userID,
+        Type:    TypeSecurity,
+        Title:   i18n.T(lang, "notification.new_device_login.title", nil),
+        Message: i18n.T(lang, "notification.new_device_login.body", i18n.Vars{
+            "device": device,
+            "near":   near(lang, location),
+        }),
+        Data: NotificationData{
+            "action":       "new_device_login",
+            "device":       device,
+            "location":     location,
+            "ip_address":   ipAddress,
+            "signed_in_at": at.UTC().Format(time.RFC3339),
+        },
+    }
+    
+    return s.deliver(ctx, req)
+}
+
+// SendSuspiciousLoginNotification tells a user that a signin to their
+// account was challenged for extra verification
+func (s *service) SendSuspiciousLoginNotification(ctx context.Context, userID int64, reason, location, ipAddress string, at time.Time) error {
+    lang := s.recipient(ctx, userID).Language
+    
+    req := &CreateNotificationRequest{
+        UserID:  userID,
+        Type:    TypeSecurity,
+        Title:   i18n.T(lang, "notification.suspicious_login.title", nil),
+        Message: i18n.T(lang, "notification.suspicious_login.body", i18n.Vars{
+            "reason": reason,
+            "near":   near(lang, location),
+        }),
+        Data: NotificationData{
+            "action":       "suspicious_login",
+            "reason":       reason,
+            "location":     location,
+            "ip_address":   ipAddress,
+            "attempted_at": at.UTC().Format(time.RFC3339),
+        },
+        Channels: []DeliveryChannel{ChannelPush, ChannelEmail},
+    }
+    
+    return s.deliver(ctx, req)
+}
+
+// SendAccountLockedNotification tells a user their account was locked after
+// repeated failed signins, with a link to unlock it
+func (s *service) SendAccountLockedNotification(ctx context.Context, userID int64, unlockURL string, until time.Time) error {
+    lang := s.recipient(ctx, userID).Language
+    
+    req := &CreateNotificationRequest{
+        UserID:  userID,
+        Type:    TypeSecurity,
+        Title:   i18n.T(lang, "notification.account_locked.title", nil),
+        Message: i18n.T(lang, "notification.account_locked.body", i18n.Vars{
+            "until":      until.UTC().Format("15:04"),
+            "unlock_url": unlockURL,
+        }),
+        Data: NotificationData{
+            "action":       "account_locked",
+            "unlock_url":   unlockURL,
+            "locked_until": until.UTC().Format(time.RFC3339),
+        },
+        Channels: []DeliveryChannel{ChannelEmail, ChannelPush},
+    }
+    
+    return s.deliver(ctx, req)
 }
 
 // CleanupOldNotifications removes old notifications
@@ -556,7 +1122,7 @@ func (s *service) shouldSendForType(notificationType NotificationType, prefs *No
         return prefs.Follows
     case TypeMessage:
         return prefs.Messages
-    case TypeMatch:
+    case TypeMatch, TypeDateRequest, TypeHotpicks:
         return prefs.Matches
     case TypeStoryView:
         return prefs.StoryViews
@@ -566,100 +1132,345 @@ func (s *service) shouldSendForType(notificationType NotificationType, prefs *No
         return prefs.Mentions
     case TypePromotion:
         return prefs.Promotions
+    case TypeWeeklyRecap:
+        return prefs.WeeklyRecap
+    case TypeContactJoined:
+        return prefs.ContactsJoined
     default:
         return true
     }
 }
 
-func (s *service) enrichNotification(ctx context.Context, notification *Notification) {
-    // Add actor information based on notification data
-    if actorID, ok := notification.Data["actor_id"].(float64); ok {
-        // Would need to fetch user info from user service
-        notification.Actor = &NotificationActor{
-            ID:          int64(actorID),
-            Username:    fmt.Sprintf("user_%d", int64(actorID)),
-            DisplayName: fmt.Sprintf("User %d", int64(actorID)),
+// SetUserInfoProvider sets where recipients' email addresses and phone
+// numbers, and actors' names and pictures, come from. Without it email and
+// SMS have nowhere to go. Deleted or suspended users resolve to a ghost.
+func (s *service) SetUserInfoProvider(provider UserInfoProvider) {
+    s.userInfo = provider
+}
+
+// SetBlocks sets the shared blocks service consulted before delivery
+func (s *service) SetBlocks(blockService blocks.Service) {
+    s.blocks = blockService
+}
+
+// deliver sends a notification on behalf of another user. One suppressed
+// because of a block is not an error for the caller.
+// recipient returns who a notification is for, with their preferred
+// language. Without a user info provider it's just the ID in English.
+func (s *service) recipient(ctx context.Context, userID int64) *UserInfo {
+    if s.userInfo != nil {
+        user, err := s.userInfo.GetUserInfo(ctx, userID)
+        if err == nil && user != nil {
+            user.Language = i18n.Match(user.Language)
+            return user
+        }
+        log.Printf("Failed to get notification recipient %d: %v", userID, err)
+    }
+    return &UserInfo{ID: userID, Language: i18n.Default}
+}
+
+// actorName is how the user who caused a notification is named in its text
+func (s *service) actorName(ctx context.Context, actorID int64, lang string) string {
+    if s.userInfo != nil {
+        if actor, err := s.userInfo.GetUserInfo(ctx, actorID); err == nil && actor != nil && actor.DisplayName != "" {
+            return actor.DisplayName
+        }
+    }
+    return i18n.T(lang, "notification.user_name", i18n.Vars{"id": actorID})
+}
+
+// render writes a notification's title and body in lang through the template
+// service, falling back to the i18n catalog if rendering fails
+func (s *service) render(ctx context.Context, notificationType NotificationType, lang string, data map[string]interface{}) (title, body string) {
+    if s.templateService != nil {
+        title, body, err := s.templateService.RenderTemplate(ctx, notificationType, lang, data)
+        if err == nil {
+            return title, body
+        }
+        log.Printf("Failed to render %s notification: %v", notificationType, err)
+    }
+    return renderCatalog(lang, notificationType, data)
+}
+
+// near is the " near <location>" part of security notifications, or empty
+func near(lang, location string) string {
+    if location == "" {
+        return ""
+    }
+    return i18n.T(lang, "notification.near", i18n.Vars{"location": location})
+}
+
+func (s *service) deliver(ctx context.Context, req *CreateNotificationRequest) error {
+    _, err := s.SendNotification(ctx, req)
+    if errors.Is(err, ErrActorBlocked) {
+        return nil
+    }
+    return err
+}
+
+// isActorBlocked reports whether the recipient has a block with the user who
+// caused the notification
+func (s *service) isActorBlocked(ctx context.Context, recipientID int64, data NotificationData) bool {
+    if s.blocks == nil {
+        return false
+    }
+
+    for _, key := range actorKeys {
+        actorID, ok := int64Of(data[key])
+        if !ok {
+            continue
+        }
+
+        blocked, err := s.blocks.IsBlocked(ctx, recipientID, actorID)
+        if err != nil {
+            log.Printf("Failed to check block for notification: %v", err)
+            continue
+        }
+        if blocked {
+            return true
         }
     }
+    return false
+}
+
+// resolveActors looks up the actors of notifications in one batch
+func (s *service) resolveActors(ctx context.Context, notifications ...*Notification) map[int64]*UserInfo {
+    if s.userInfo == nil {
+        return nil
+    }
     
-    // Add action URL based on notification type
-    switch notification.Type {
-    case TypeLike, TypeComment:
-        if postID, ok := notification.Data["post_id"].(float64); ok {
-            notification.ActionURL = fmt.Sprintf("/posts/%d", int64(postID))
+    var actorIDs []int64
+    for _, n := range notifications {
+        if actorID, ok := latestActorID(n); ok {
+            actorIDs = append(actorIDs, actorID)
         }
-    case TypeFollow:
-        if followerID, ok := notification.Data["follower_id"].(float64); ok {
-            notification.ActionURL = fmt.Sprintf("/users/%d", int64(followerID))
+    }
+    
+    actors, err := s.userInfo.GetUsersInfo(ctx, actorIDs)
+    if err != nil {
+        log.Printf("Failed to resolve notification actors: %v", err)
+        return nil
+    }
+    return actors
+}
+
+// actorIDOf returns the user who caused a notification, if its data names one
+func actorIDOf(data NotificationData) (int64, bool) {
+    for _, key := range actorKeys {
+        if actorID, ok := int64Of(data[key]); ok {
+            return actorID, true
         }
-    case TypeMessage, TypeMatch:
-        notification.ActionURL = "/messages"
     }
+    return 0, false
+}
+
+// latestActorID is the most recent actor of a notification; for a grouped
+// one that is the newest in its actor list
+func latestActorID(notification *Notification) (int64, bool) {
+    if len(notification.ActorIDs) > 0 {
+        return notification.ActorIDs[0], true
+    }
+    return actorIDOf(notification.Data)
+}
+
+// int64Of reads an ID from notification data. Data decoded from JSON holds
+// numbers as float64.
+func int64Of(value interface{}) (int64, bool) {
+    switch v := value.(type) {
+    case int64:
+        return v, true
+    case float64:
+        return int64(v), true
+    }
+    return 0, false
+}
+
+func (s *service) enrichNotification(ctx context.Context, notification *Notification, actors map[int64]*UserInfo) {
+    // Add actor information based on notification data
+    if actorID, ok := latestActorID(notification); ok {
+        if actor, ok := actors[actorID]; ok {
+            notification.Actor = &NotificationActor{
+                ID:             actor.ID,
+                Username:       actor.Username,
+                DisplayName:    actor.DisplayName,
+                ProfilePicture: actor.ProfilePicture,
+            }
+        } else {
+            notification.Actor = &NotificationActor{
+                ID:          actorID,
+                Username:    fmt.Sprintf("user_%d", actorID),
+                DisplayName: fmt.Sprintf("User %d", actorID),
+            }
+        }
+    }
+    
+    // Add action URL based on notification type
+    if path := linkPath(notification); path != "" {
+        notification.ActionURL = "/" + path
+    }
+    
+    notification.Actions = actionsFor(notification, i18n.FromContext(ctx))
 }
 
 // Channel-specific sending methods
 
-func (s *service) sendPushNotification(ctx context.Context, userID int64, notification *Notification) {
+func (s *service) sendPushNotification(ctx context.Context, userID int64, notification *Notification) (string, error) {
     if s.pushService == nil {
-        return
+        return "", errNothingToSend
     }
     
     tokens, err := s.repo.GetUserPushTokens(ctx, userID, nil)
-    if err != nil || len(tokens) == 0 {
-        return
+    if err != nil {
+        return "", err
+    }
+    if len(tokens) == 0 {
+        return "", errNothingToSend
     }
     
-    tokenStrings := make([]string, len(tokens))
-    for i, token := range tokens {
-        tokenStrings[i] = token.Token
+    payload := pushPayloadFor(notification)
+    if _, ok := actionTemplates[notification.Type]; ok {
+        payload.Actions = actionsFor(notification, s.recipient(ctx, userID).Language)
     }
     
-    push := &PushNotification{
-        Tokens: tokenStrings,
-        Title:  notification.Title,
-        Body:   notification.Message,
-        Data: map[string]string{
-            "notification_id": fmt.Sprintf("%d", notification.ID),
-            "type":           string(notification.Type),
-        },
+    // Each provider gets the tokens it issued. Reaching a device through any
+    // of them counts as delivered.
+    var messageIDs []string
+    var lastErr, retryable error
+    for tokenType, tokenStrings := range tokensByType(tokens) {
+        push := &PushNotification{
+            Tokens:      tokenStrings,
+            Title:       notification.Title,
+            Body:        notification.Message,
+            Data:        payload.Data(),
+            Image:       payload.ImageURL,
+            CollapseKey: payload.CollapseKey,
+            ChannelID:   payload.ChannelID,
+            Category:    payload.Category,
+            TokenType:   tokenType,
+        }
+        
+        err := s.pushService.SendPush(ctx, push)
+        s.dropInvalidTokens(ctx, tokenType, push.InvalidTokens)
+        if err == nil {
+            messageIDs = append(messageIDs, push.MessageID)
+            continue
+        }
+        lastErr = err
+        if !IsPermanent(err) {
+            retryable = err
+        }
     }
     
-    if err := s.pushService.SendPush(ctx, push); err != nil {
-        log.Printf("Failed to send push notification: %v", err)
+    if len(messageIDs) == 0 {
+        if retryable != nil {
+            return "", retryable
+        }
+        return "", lastErr
     }
+    return strings.Join(messageIDs, ","), nil
 }
 
-func (s *service) sendEmailNotification(ctx context.Context, userID int64, notification *Notification) {
-    if s.emailService == nil {
-        return
+// tokensByType groups push tokens by the provider that issued them
+func tokensByType(tokens []*PushToken) map[TokenType][]string {
+    grouped := make(map[TokenType][]string)
+    for _, token := range tokens {
+        tokenType := token.TokenType
+        if tokenType == "" {
+            tokenType = TokenTypeFCM
+        }
+        grouped[tokenType] = append(grouped[tokenType], token.Token)
+    }
+    return grouped
+}
+
+// dropInvalidTokens stops sending to tokens a provider reported as no
+// longer registered, such as those of uninstalled apps. Expired web push
+// subscriptions are deleted outright; a browser that subscribes again gets
+// a new endpoint.
+func (s *service) dropInvalidTokens(ctx context.Context, tokenType TokenType, tokens []string) {
+    for _, token := range tokens {
+        var err error
+        if tokenType == TokenTypeWebPush {
+            err = s.repo.DeletePushToken(ctx, token)
+        } else {
+            err = s.repo.DeactivatePushToken(ctx, token)
+        }
+        if err != nil {
+            log.Printf("Failed to drop invalid push token: %v", err)
+            continue
+        }
+        log.Printf("Dropped unregistered %s push token", tokenType)
+    }
+}
+
+func (s *service) sendEmailNotification(ctx context.Context, userID int64, notification *Notification) (string, error) {
+    if s.emailService == nil || s.userInfo == nil {
+        return "", errNothingToSend
+    }
+    
+    user, err := s.userInfo.GetUserInfo(ctx, userID)
+    if err != nil {
+        return "", err
+    }
+    if user.Email == nil {
+        return "", errNothingToSend
+    }
+    
+    message := s.notificationEmail(*user.Email, i18n.Match(user.Language), notification.Title, notification.Message, notification.ActionURL)
+    err = s.emailService.SendEmail(ctx, message)
+    return message.MessageID, err
+}
+
+// notificationEmail renders a notification as an HTML email in lang. If the
+// template fails the email still goes out as plain text.
+func (s *service) notificationEmail(to, lang, title, message, actionPath string) *EmailNotification {
+    if actionPath == "" {
+        actionPath = "/notifications"
     }
     
-    // Would need to get user email from user service
-    email := &EmailNotification{
-        To:      fmt.Sprintf("user%d@example.com", userID),
-        Subject: notification.Title,
-        Body:    notification.Message,
+    notification := &EmailNotification{
+        To:      to,
+        Subject: title,
+        Body:    message,
     }
     
-    if err := s.emailService.SendEmail(ctx, email); err != nil {
-        log.Printf("Failed to send email notification: %v", err)
+    rendered, err := email.Render("notification", lang, email.Data{
+        "Title":          title,
+        "Preheader":      message,
+        "Message":        message,
+        "ActionURL":      s.appURL + actionPath,
+        "PreferencesURL": s.appURL + "/settings/notifications",
+    })
+    if err != nil {
+        log.Printf("Failed to render notification email: %v", err)
+        return notification
     }
+    
+    notification.HTML = rendered.HTML
+    notification.Body = rendered.Text
+    return notification
 }
 
-func (s *service) sendSMSNotification(ctx context.Context, userID int64, notification *Notification) {
-    if s.smsService == nil {
-        return
+func (s *service) sendSMSNotification(ctx context.Context, userID int64, notification *Notification) (string, error) {
+    if s.smsService == nil || s.userInfo == nil {
+        return "", errNothingToSend
+    }
+    
+    user, err := s.userInfo.GetUserInfo(ctx, userID)
+    if err != nil {
+        return "", err
+    }
+    if user.Phone == nil {
+        return "", errNothingToSend
     }
     
-    // Would need to get user phone from user service
     sms := &SMSNotification{
-        To:      fmt.Sprintf("+1234567890%d", userID),
+        To:      *user.Phone,
         Message: fmt.Sprintf("%s: %s", notification.Title, notification.Message),
     }
     
-    if err := s.smsService.SendSMS(ctx, sms); err != nil {
-        log.Printf("Failed to send SMS notification: %v", err)
-    }
+    err = s.smsService.SendSMS(ctx, sms)
+    return sms.MessageID, err
 }
 
 func (s *service) sendBatchPushNotifications(ctx context.Context, userIDs []int64, title, message string, data NotificationData) {
@@ -672,42 +1483,63 @@ func (s *service) sendBatchPushNotifications(ctx context.Context, userIDs []int6
         return
     }
     
-    // Group tokens by platform for optimized sending
-    platformTokens := make(map[Platform][]string)
+    // Group tokens by platform for optimized sending, and by the provider
+    // that has to send to them
+    type tokenGroup struct {
+        platform  Platform
+        tokenType TokenType
+    }
+    groupTokens := make(map[tokenGroup][]string)
     for _, token := range tokens {
-        platformTokens[token.Platform] = append(platformTokens[token.Platform], token.Token)
+        group := tokenGroup{platform: token.Platform, tokenType: token.TokenType}
+        if group.tokenType == "" {
+            group.tokenType = TokenTypeFCM
+        }
+        groupTokens[group] = append(groupTokens[group], token.Token)
     }
     
-    for platform, tokenList := range platformTokens {
+    for group, tokenList := range groupTokens {
+        pushData := map[string]string{
+            "platform": string(group.platform),
+        }
+        for key, value := range data {
+            pushData[key] = fmt.Sprint(value)
+        }
+        
         push := &PushNotification{
-            Tokens: tokenList,
-            Title:  title,
-            Body:   message,
-            Data: map[string]string{
-                "platform": string(platform),
-            },
+            Tokens:    tokenList,
+            Title:     title,
+            Body:      message,
+            Data:      pushData,
+            TokenType: group.tokenType,
         }
         
         if err := s.pushService.SendBatchPush(ctx, []*PushNotification{push}); err != nil {
             log.Printf("Failed to send batch push notifications: %v", err)
         }
+        s.dropInvalidTokens(ctx, group.tokenType, push.InvalidTokens)
     }
 }
 
 func (s *service) sendBatchEmailNotifications(ctx context.Context, userIDs []int64, title, message string, data NotificationData) {
-    if s.emailService == nil {
+    if s.emailService == nil || s.userInfo == nil {
+        return
+    }
+    
+    recipients, err := s.userInfo.GetUsersInfo(ctx, userIDs)
+    if err != nil {
+        log.Printf("Failed to look up batch email recipients: %v", err)
         return
     }
     
-    // Would need to get user emails from user service
     emails := make([]*EmailNotification, 0, len(userIDs))
     for _, userID := range userIDs {
-        email := &EmailNotification{
-            To:      fmt.Sprintf("user%d@example.com", userID),
-            Subject: title,
-            Body:    message,
+        if user := recipients[userID]; user != nil && user.Email != nil {
+            emails = append(emails, s.notificationEmail(*user.Email, i18n.Match(user.Language), title, message, ""))
         }
-        emails = append(emails, email)
+    }
+    if len(emails) == 0 {
+        return
     }
     
     if err := s.emailService.SendBatchEmails(ctx, emails); err != nil {
@@ -716,21 +1548,30 @@ func (s *service) sendBatchEmailNotifications(ctx context.Context, userIDs []int
 }
 
 func (s *service) sendBatchSMSNotifications(ctx context.Context, userIDs []int64, message string) {
-    if s.smsService == nil {
+    if s.smsService == nil || s.userInfo == nil {
+        return
+    }
+    
+    recipients, err := s.userInfo.GetUsersInfo(ctx, userIDs)
+    if err != nil {
+        log.Printf("Failed to look up batch SMS recipients: %v", err)
         return
     }
     
-    // Would need to get user phones from user service
     smsList := make([]*SMSNotification, 0, len(userIDs))
     for _, userID := range userIDs {
-        sms := &SMSNotification{
-            To:      fmt.Sprintf("+1234567890%d", userID),
-            Message: message,
+        if user := recipients[userID]; user != nil && user.Phone != nil {
+            smsList = append(smsList, &SMSNotification{
+                To:      *user.Phone,
+                Message: message,
+            })
         }
-        smsList = append(smsList, sms)
+    }
+    if len(smsList) == 0 {
+        return
     }
     
     if err := s.smsService.SendBatchSMS(ctx, smsList); err != nil {
         log.Printf("Failed to send batch SMS notifications: %v", err)
     }
-}
\ No newline at end of file
+}
@@ -4,63 +4,42 @@ package notifications
 
 import (
     "context"
+    "errors"
     "fmt"
     "log"
-    "os"
     
-    "github.com/twilio/twilio-go"
-    twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/sms"
 )
 
-// TwilioSMSService implements SMS notifications using Twilio
-type TwilioSMSService struct {
-    client *twilio.RestClient
-    from   string
+// RoutedSMSService sends SMS notifications through the SMS router, which
+// picks a provider for each number and fails over between them
+type RoutedSMSService struct {
+    router *sms.Router
 }
 
-// NewTwilioSMSService creates a new Twilio SMS service
-func NewTwilioSMSService() (SMSService, error) {
-    accountSID := os.Getenv("TWILIO_ACCOUNT_SID")
-    authToken := os.Getenv("TWILIO_AUTH_TOKEN")
-    from := os.Getenv("TWILIO_PHONE_NUMBER")
-    
-    if accountSID == "" || authToken == "" || from == "" {
-        return nil, fmt.Errorf("incomplete Twilio configuration")
-    }
-    
-    client := twilio.NewRestClientWithParams(twilio.ClientParams{
-        Username: accountSID,
-        Password: authToken,
-    })
-    
-    return &TwilioSMSService{
-        client: client,
-        from:   from,
-    }, nil
+// NewRoutedSMSService creates an SMS service backed by router
+func NewRoutedSMSService(router *sms.Router) SMSService {
+    return &RoutedSMSService{router: router}
 }
 
 // SendSMS sends a single SMS
-func (s *TwilioSMSService) SendSMS(ctx context.Context, notification *SMSNotification) error {
-    params := &twilioApi.CreateMessageParams{}
-    params.SetTo(notification.To)
-    params.SetFrom(s.from)
-    params.SetBody(notification.Message)
-    
-    resp, err := s.client.Api.CreateMessage(params)
+func (s *RoutedSMSService) SendSMS(ctx context.Context, notification *SMSNotification) error {
+    result, err := s.router.Send(ctx, &sms.Message{To: notification.To, Body: notification.Message})
     if err != nil {
         log.Printf("Failed to send SMS to %s: %v", notification.To, err)
+        if errors.Is(err, sms.ErrRejected) {
+            return Permanent(err)
+        }
         return err
     }
     
-    if resp.Sid != nil {
-        log.Printf("Successfully sent SMS to %s with SID: %s", notification.To, *resp.Sid)
-    }
-    
+    log.Printf("Sent SMS to %s through %s with ID: %s", notification.To, result.Provider, result.MessageID)
+    notification.MessageID = result.MessageID
     return nil
 }
 
 // SendBatchSMS sends multiple SMS messages
-func (s *TwilioSMSService) SendBatchSMS(ctx context.Context, notifications []*SMSNotification) error {
+func (s *RoutedSMSService) SendBatchSMS(ctx context.Context, notifications []*SMSNotification) error {
     for _, notification := range notifications {
         if err := s.SendSMS(ctx, notification); err != nil {
             log.Printf("Failed to send SMS in batch: %v", err)
@@ -71,50 +50,6 @@ func (s *TwilioSMSService) SendBatchSMS(ctx context.Context, notifications []*SM
     return nil
 }
 
-// AfricasTalkingSMSService implements SMS notifications using Africa's Talking
-type AfricasTalkingSMSService struct {
-    username string
-    apiKey   string
-    from     string
-}
-
-// NewAfricasTalkingSMSService creates a new Africa's Talking SMS service
-func NewAfricasTalkingSMSService() (SMSService, error) {
-    username := os.Getenv("AT_USERNAME")
-    apiKey := os.Getenv("AT_API_KEY")
-    from := os.Getenv("AT_SENDER_ID")
-    
-    if username == "" || apiKey == "" {
-        return nil, fmt.Errorf("incomplete Africa's Talking configuration")
-    }
-    
-    return &AfricasTalkingSMSService{
-        username: username,
-        apiKey:   apiKey,
-        from:     from,
-    }, nil
-}
-
-// SendSMS sends a single SMS via Africa's Talking
-func (s *AfricasTalkingSMSService) SendSMS(ctx context.Context, notification *SMSNotification) error {
-    // Africa's Talking implementation would go here
-    log.Printf("Africa's Talking: Sending SMS to %s: %s", notification.To, notification.Message)
-    return nil
-}
-
-// SendBatchSMS sends multiple SMS messages via Africa's Talking
-func (s *AfricasTalkingSMSService) SendBatchSMS(ctx context.Context, notifications []*SMSNotification) error {
-    // Africa's Talking supports batch sending
-    recipients := make([]string, len(notifications))
-    for i, n := range notifications {
-        recipients[i] = n.To
-    }
-    
-    // Actual implementation would send to all recipients at once
-    log.Printf("Africa's Talking: Sending batch SMS to %d recipients", len(recipients))
-    return nil
-}
-
 // MockSMSService is a mock implementation for testing
 type MockSMSService struct {
     SentMessages []*SMSNotification
@@ -128,6 +63,7 @@ func NewMockSMSService() SMSService {
 
 func (m *MockSMSService) SendSMS(ctx context.Context, notification *SMSNotification) error {
     m.SentMessages = append(m.SentMessages, notification)
+    notification.MessageID = fmt.Sprintf("mock-sms-%d", len(m.SentMessages))
     log.Printf("Mock: Sending SMS to %s: %s", notification.To, notification.Message)
     return nil
 }
@@ -5,8 +5,13 @@ package notifications
 import (
     "bytes"
     "context"
+    "database/sql"
     "fmt"
+    "log"
+    "sort"
     "text/template"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
 )
 
 // DefaultTemplateService provides template rendering for notifications
@@ -19,13 +24,23 @@ func NewTemplateService(repo Repository) TemplateService {
     return &DefaultTemplateService{repo: repo}
 }
 
-// RenderTemplate renders a notification template with variables
+// RenderTemplate renders a notification template with variables in language.
+// A stored template in that language wins, then the i18n catalog's message for
+// it, then the stored English template, then the English catalog message.
 func (s *DefaultTemplateService) RenderTemplate(ctx context.Context, templateType NotificationType, language string, data map[string]interface{}) (title, body string, err error) {
-    // Get template from database
-    tmpl, err := s.repo.GetTemplate(ctx, templateType, language)
+    language = i18n.Match(language)
+    
+    tmpl, err := s.repo.GetTemplateExact(ctx, templateType, language)
     if err != nil {
-        // Fall back to default templates
-        return s.getDefaultTemplate(templateType, data)
+        // A catalog translation beats the stored English template
+        if i18n.Has(language, catalogKey(templateType)+".title") {
+            title, body = renderCatalog(language, templateType, data)
+            return title, body, nil
+        }
+        if tmpl, err = s.repo.GetTemplate(ctx, templateType, defaultTemplateLanguage); err != nil {
+            title, body = renderCatalog(language, templateType, data)
+            return title, body, nil
+        }
     }
     
     // Render title
@@ -58,103 +73,26 @@ func (s *DefaultTemplateService) renderString(templateStr string, data map[strin
     return buf.String(), nil
 }
 
-// getDefaultTemplate returns default templates for notification types
-func (s *DefaultTemplateService) getDefaultTemplate(notificationType NotificationType, data map[string]interface{}) (title, body string, err error) {
-    switch notificationType {
-    case TypeWelcome:
-        title = "Welcome to Kiekky! 🎉"
-        body = "Start exploring and connecting with amazing people around you."
-        
-    case TypeFollow:
-        username := s.getStringValue(data, "username", "Someone")
-        title = "New Follower! 👥"
-        body = fmt.Sprintf("%s started following you", username)
-        
-    case TypeLike:
-        username := s.getStringValue(data, "username", "Someone")
-        title = "Someone liked your post! ❤️"
-        body = fmt.Sprintf("%s liked your post", username)
-        
-    case TypeComment:
-        username := s.getStringValue(data, "username", "Someone")
-        comment := s.getStringValue(data, "comment", "")
-        title = "New comment on your post 💬"
-        body = fmt.Sprintf("%s commented: %s", username, s.truncate(comment, 50))
-        
-    case TypeMessage:
-        username := s.getStringValue(data, "username", "Someone")
-        message := s.getStringValue(data, "message", "")
-        title = fmt.Sprintf("Message from %s 💌", username)
-        body = s.truncate(message, 100)
-        
-    case TypeMatch:
-        username := s.getStringValue(data, "username", "someone")
-        title = "It's a Match! 💕"
-        body = fmt.Sprintf("You matched with %s! Start a conversation now.", username)
-        
-    case TypeStoryView:
-        username := s.getStringValue(data, "username", "Someone")
-        title = "Story View 👀"
-        body = fmt.Sprintf("%s viewed your story", username)
-        
-    case TypeStoryReply:
-        username := s.getStringValue(data, "username", "Someone")
-        reply := s.getStringValue(data, "reply", "")
-        title = "Story Reply 💬"
-        body = fmt.Sprintf("%s replied to your story: %s", username, s.truncate(reply, 50))
-        
-    case TypeMention:
-        username := s.getStringValue(data, "username", "Someone")
-        title = "You were mentioned! 📢"
-        body = fmt.Sprintf("%s mentioned you in a post", username)
-        
-    case TypeVerification:
-        code := s.getStringValue(data, "code", "")
-        title = "Verify Your Account 🔐"
-        body = fmt.Sprintf("Your verification code is: %s", code)
-        
-    case TypeSecurity:
-        action := s.getStringValue(data, "action", "security alert")
-        title = "Security Alert 🚨"
-        body = fmt.Sprintf("We noticed %s on your account", action)
-        
-    case TypePromotion:
-        offer := s.getStringValue(data, "offer", "special offer")
-        title = "Special Offer! 🎁"
-        body = fmt.Sprintf("Check out our %s", offer)
-        
-    case TypeMaintenance:
-        time := s.getStringValue(data, "time", "soon")
-        title = "Scheduled Maintenance 🔧"
-        body = fmt.Sprintf("We'll be performing maintenance %s", time)
-        
-    default:
-        title = "Kiekky Notification"
-        body = "You have a new notification"
-    }
-    
-    return title, body, nil
-}
-
-// getStringValue safely gets a string value from map
-func (s *DefaultTemplateService) getStringValue(data map[string]interface{}, key, defaultValue string) string {
-    if val, ok := data[key]; ok {
-        if strVal, ok := val.(string); ok {
-            return strVal
-        }
-    }
-    return defaultValue
+// catalogKey is the i18n key prefix for a notification type's messages
+func catalogKey(notificationType NotificationType) string {
+    return "notification." + string(notificationType)
 }
 
-// truncate truncates a string to specified length
-func (s *DefaultTemplateService) truncate(temp string, maxLen int) string {
-    if len(temp) <= maxLen {
-        return temp
+// renderCatalog renders a notification from the i18n catalog in lang, using
+// the generic notification message for types the catalog doesn't know
+func renderCatalog(lang string, notificationType NotificationType, data map[string]interface{}) (title, body string) {
+    key := catalogKey(notificationType)
+    if _, ok := i18n.Lookup(lang, key+".title"); !ok {
+        key = "notification.default"
     }
-    return temp[:maxLen-3] + "..."
+    
+    vars := i18n.Vars(data)
+    return i18n.T(lang, key+".title", vars), i18n.T(lang, key+".body", vars)
 }
 
-// Default notification templates for different languages
+// Default notification templates for different languages.
+// Bump Version whenever a template's text or variables change; seeding only
+// overwrites a stored template when the version here is higher.
 var defaultTemplates = map[string]map[NotificationType]*NotificationTemplate{
     "en": {
         TypeWelcome: {
@@ -162,42 +100,160 @@ var defaultTemplates = map[string]map[NotificationType]*NotificationTemplate{
             Language:      "en",
             TitleTemplate: "Welcome to Kiekky, {{.username}}! 🎉",
             BodyTemplate:  "We're excited to have you join our community. Complete your profile to get started!",
-            Variables:     []string{"username"},
+            Variables:     TemplateVariables{"username"},
+            Version:       1,
         },
         TypeFollow: {
             Type:          TypeFollow,
             Language:      "en",
             TitleTemplate: "New Follower! 👥",
             BodyTemplate:  "{{.follower_name}} started following you",
-            Variables:     []string{"follower_name", "follower_id"},
+            Variables:     TemplateVariables{"follower_name", "follower_id"},
+            Version:       1,
         },
         TypeLike: {
             Type:          TypeLike,
             Language:      "en",
             TitleTemplate: "Your post got a new like! ❤️",
             BodyTemplate:  "{{.liker_name}} liked your post",
-            Variables:     []string{"liker_name", "liker_id", "post_id"},
+            Variables:     TemplateVariables{"liker_name", "liker_id", "post_id"},
+            Version:       1,
         },
         TypeComment: {
             Type:          TypeComment,
             Language:      "en",
             TitleTemplate: "New comment on your post 💬",
             BodyTemplate:  "{{.commenter_name}}: {{.comment}}",
-            Variables:     []string{"commenter_name", "commenter_id", "post_id", "comment"},
+            Variables:     TemplateVariables{"commenter_name", "commenter_id", "post_id", "comment"},
+            Version:       1,
         },
         TypeMessage: {
             Type:          TypeMessage,
             Language:      "en",
             TitleTemplate: "Message from {{.sender_name}} 💌",
             BodyTemplate:  "{{.message}}",
-            Variables:     []string{"sender_name", "sender_id", "message"},
+            Variables:     TemplateVariables{"sender_name", "sender_id", "message"},
+            Version:       1,
         },
         TypeMatch: {
             Type:          TypeMatch,
             Language:      "en",
             TitleTemplate: "It's a Match! 💕",
             BodyTemplate:  "You matched with {{.matched_user_name}}! Start a conversation now.",
-            Variables:     []string{"matched_user_name", "matched_user_id"},
+            Variables:     TemplateVariables{"matched_user_name", "matched_user_id"},
+            Version:       1,
+        },
+        TypeDateRequest: {
+            Type:          TypeDateRequest,
+            Language:      "en",
+            TitleTemplate: "New date request 🌹",
+            BodyTemplate:  "{{.sender_name}} would like to go on a date with you",
+            Variables:     TemplateVariables{"sender_name", "sender_id"},
+            Version:       1,
+        },
+        TypeHotpicks: {
+            Type:          TypeHotpicks,
+            Language:      "en",
+            TitleTemplate: "Your hotpicks are here 🔥",
+            BodyTemplate:  "{{.count}} people picked for you today. Take a look before they're gone!",
+            Variables:     TemplateVariables{"count"},
+            Version:       1,
+        },
+        TypeContactJoined: {
+            Type:          TypeContactJoined,
+            Language:      "en",
+            TitleTemplate: "Someone you know is here 👋",
+            BodyTemplate:  "{{.contact_name}} from your contacts just joined Kiekky. Say hi!",
+            Variables:     TemplateVariables{"contact_name", "contact_id"},
+            Version:       1,
+        },
+        TypeStoryView: {
+            Type:          TypeStoryView,
+            Language:      "en",
+            TitleTemplate: "Story View 👀",
+            BodyTemplate:  "{{.viewer_name}} viewed your story",
+            Variables:     TemplateVariables{"viewer_name", "viewer_id", "story_id"},
+            Version:       1,
+        },
+        TypeStoryReply: {
+            Type:          TypeStoryReply,
+            Language:      "en",
+            TitleTemplate: "Story Reply 💬",
+            BodyTemplate:  "{{.replier_name}} replied to your story: {{.reply}}",
+            Variables:     TemplateVariables{"replier_name", "replier_id", "story_id", "reply"},
+            Version:       1,
+        },
+        TypeMention: {
+            Type:          TypeMention,
+            Language:      "en",
+            TitleTemplate: "You were mentioned! 📢",
+            BodyTemplate:  "{{.mentioner_name}} mentioned you in a post",
+            Variables:     TemplateVariables{"mentioner_name", "mentioner_id", "post_id"},
+            Version:       1,
+        },
+        TypeRepost: {
+            Type:          TypeRepost,
+            Language:      "en",
+            TitleTemplate: "Your post was reposted 🔁",
+            BodyTemplate:  "{{.reposter_name}} reposted your post",
+            Variables:     TemplateVariables{"reposter_name", "reposter_id", "post_id", "repost_id"},
+            Version:       1,
+        },
+        TypePostPublished: {
+            Type:          TypePostPublished,
+            Language:      "en",
+            TitleTemplate: "Your post is live 🚀",
+            BodyTemplate:  "Your scheduled post was published",
+            Variables:     TemplateVariables{"post_id"},
+            Version:       1,
+        },
+        TypeProfileUpdate: {
+            Type:          TypeProfileUpdate,
+            Language:      "en",
+            TitleTemplate: "Profile Updated ✅",
+            BodyTemplate:  "Your {{.field}} was updated successfully",
+            Variables:     TemplateVariables{"field"},
+            Version:       1,
+        },
+        TypeVerification: {
+            Type:          TypeVerification,
+            Language:      "en",
+            TitleTemplate: "Verify Your Account 🔐",
+            BodyTemplate:  "Your verification code is: {{.code}}",
+            Variables:     TemplateVariables{"code"},
+            Version:       1,
+        },
+        TypeSecurity: {
+            Type:          TypeSecurity,
+            Language:      "en",
+            TitleTemplate: "Security Alert 🚨",
+            BodyTemplate:  "We noticed {{.action}} on your account. If this wasn't you, secure your account now.",
+            Variables:     TemplateVariables{"action"},
+            Version:       1,
+        },
+        TypePromotion: {
+            Type:          TypePromotion,
+            Language:      "en",
+            TitleTemplate: "Special Offer! 🎁",
+            BodyTemplate:  "Check out our {{.offer}}",
+            Variables:     TemplateVariables{"offer"},
+            Version:       1,
+        },
+        TypeMaintenance: {
+            Type:          TypeMaintenance,
+            Language:      "en",
+            TitleTemplate: "Scheduled Maintenance 🔧",
+            BodyTemplate:  "We'll be performing maintenance {{.time}}",
+            Variables:     TemplateVariables{"time"},
+            Version:       1,
+        },
+        TypeWeeklyRecap: {
+            Type:          TypeWeeklyRecap,
+            Language:      "en",
+            TitleTemplate: "Your week on Kiekky, {{.username}} 📊",
+            BodyTemplate:  "{{.profile_views}} profile views, {{.likes_received}} likes and {{.new_matches}} new matches this week",
+            Variables:     TemplateVariables{"username", "profile_views", "likes_received", "new_matches", "top_post_id", "top_post_likes"},
+            Version:       1,
         },
     },
     "fr": {
@@ -206,9 +262,10 @@ var defaultTemplates = map[string]map[NotificationType]*NotificationTemplate{
             Language:      "fr",
             TitleTemplate: "Bienvenue sur Kiekky, {{.username}}! 🎉",
             BodyTemplate:  "Nous sommes ravis de vous accueillir dans notre communauté. Complétez votre profil pour commencer!",
-            Variables:     []string{"username"},
+            Variables:     TemplateVariables{"username"},
+            Version:       1,
         },
-        // Add more French templates...
+        // Other types fall back to English templates
     },
     "es": {
         TypeWelcome: {
@@ -216,28 +273,160 @@ var defaultTemplates = map[string]map[NotificationType]*NotificationTemplate{
             Language:      "es",
             TitleTemplate: "¡Bienvenido a Kiekky, {{.username}}! 🎉",
             BodyTemplate:  "Estamos emocionados de tenerte en nuestra comunidad. ¡Completa tu perfil para empezar!",
-            Variables:     []string{"username"},
+            Variables:     TemplateVariables{"username"},
+            Version:       1,
         },
-        // Add more Spanish templates...
+        // Other types fall back to English templates
     },
 }
 
-// InitializeDefaultTemplates initializes default templates in the database
-func InitializeDefaultTemplates(ctx context.Context, repo Repository) error {
-    for lang, templates := range defaultTemplates {
-        for _, tmpl := range templates {
-            existing, err := repo.GetTemplate(ctx, tmpl.Type, lang)
-            if err != nil || existing == nil {
-                if err := repo.CreateTemplate(ctx, tmpl); err != nil {
-                    return fmt.Errorf("failed to create template for %s in %s: %v", tmpl.Type, lang, err)
+// defaultTemplateLanguage must have a template for every notification type,
+// since GetTemplate falls back to it
+const defaultTemplateLanguage = "en"
+
+// PlanTemplateUpdates compares stored templates against the defaults shipped
+// with this build and returns what seeding would change, without writing anything
+func PlanTemplateUpdates(ctx context.Context, repo Repository) ([]*TemplateChange, error) {
+    for _, notificationType := range AllNotificationTypes {
+        if _, ok := defaultTemplates[defaultTemplateLanguage][notificationType]; !ok {
+            log.Printf("Warning: no default %s template for %s", defaultTemplateLanguage, notificationType)
+        }
+    }
+    
+    languages := make([]string, 0, len(defaultTemplates))
+    for lang := range defaultTemplates {
+        languages = append(languages, lang)
+    }
+    sort.Strings(languages)
+    
+    renderer := &DefaultTemplateService{repo: repo}
+    var changes []*TemplateChange
+    
+    for _, lang := range languages {
+        for _, notificationType := range AllNotificationTypes {
+            tmpl, ok := defaultTemplates[lang][notificationType]
+            if !ok {
+                continue
+            }
+            
+            change := &TemplateChange{
+                Type:       tmpl.Type,
+                Language:   lang,
+                NewVersion: tmpl.Version,
+                Proposed:   tmpl,
+                Preview:    renderer.preview(tmpl),
+            }
+            
+            existing, err := repo.GetTemplateExact(ctx, tmpl.Type, lang)
+            switch {
+            case err == sql.ErrNoRows:
+                change.Action = TemplateActionCreate
+            case err != nil:
+                return nil, fmt.Errorf("failed to get template for %s in %s: %v", tmpl.Type, lang, err)
+            default:
+                change.Current = existing
+                change.CurrentVersion = existing.Version
+                switch {
+                case existing.Version >= tmpl.Version:
+                    change.Action = TemplateActionUnchanged
+                case existing.Pinned:
+                    change.Action = TemplateActionPinned
+                default:
+                    change.Action = TemplateActionUpgrade
                 }
             }
+            
+            changes = append(changes, change)
         }
     }
     
+    return changes, nil
+}
+
+// ApplyTemplateUpdates applies pending template changes. When types is empty
+// every pending create/upgrade is applied and pinned templates are skipped;
+// naming a type explicitly also upgrades it if pinned and clears the pin.
+// language optionally restricts the update to a single language.
+func ApplyTemplateUpdates(ctx context.Context, repo Repository, types []NotificationType, language string) ([]*TemplateChange, error) {
+    changes, err := PlanTemplateUpdates(ctx, repo)
+    if err != nil {
+        return nil, err
+    }
+    
+    selected := make(map[NotificationType]bool, len(types))
+    for _, t := range types {
+        selected[t] = true
+    }
+    
+    var applied []*TemplateChange
+    for _, change := range changes {
+        if language != "" && change.Language != language {
+            continue
+        }
+        if len(selected) > 0 && !selected[change.Type] {
+            continue
+        }
+        
+        tmpl := *change.Proposed
+        switch change.Action {
+        case TemplateActionCreate:
+            if err := repo.CreateTemplate(ctx, &tmpl); err != nil {
+                return applied, fmt.Errorf("failed to create template for %s in %s: %v", tmpl.Type, tmpl.Language, err)
+            }
+        case TemplateActionUpgrade, TemplateActionPinned:
+            if change.Action == TemplateActionPinned && len(selected) == 0 {
+                continue
+            }
+            tmpl.Pinned = false
+            if err := repo.UpdateTemplate(ctx, &tmpl); err != nil {
+                return applied, fmt.Errorf("failed to upgrade template for %s in %s: %v", tmpl.Type, tmpl.Language, err)
+            }
+        default:
+            continue
+        }
+        
+        applied = append(applied, change)
+    }
+    
+    return applied, nil
+}
+
+// InitializeDefaultTemplates seeds missing templates and upgrades stored ones
+// whose version is older than the shipped default. It is safe to run on every start.
+func InitializeDefaultTemplates(ctx context.Context, repo Repository) error {
+    applied, err := ApplyTemplateUpdates(ctx, repo, nil, "")
+    if err != nil {
+        return err
+    }
+    
+    for _, change := range applied {
+        log.Printf("Notification template %s/%s: %s v%d -> v%d",
+            change.Type, change.Language, change.Action, change.CurrentVersion, change.NewVersion)
+    }
+    
     return nil
 }
 
+// preview renders a template with placeholder values for each variable
+func (s *DefaultTemplateService) preview(tmpl *NotificationTemplate) *TemplatePreview {
+    data := make(map[string]interface{}, len(tmpl.Variables))
+    for _, v := range tmpl.Variables {
+        data[v] = fmt.Sprintf("<%s>", v)
+    }
+    
+    title, err := s.renderString(tmpl.TitleTemplate, data)
+    if err != nil {
+        title = fmt.Sprintf("render error: %v", err)
+    }
+    
+    body, err := s.renderString(tmpl.BodyTemplate, data)
+    if err != nil {
+        body = fmt.Sprintf("render error: %v", err)
+    }
+    
+    return &TemplatePreview{Title: title, Body: body}
+}
+
 // MockTemplateService is a mock implementation for testing
 type MockTemplateService struct{}
 
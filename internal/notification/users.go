@@ -0,0 +1,75 @@
+// internal/notification/users.go
+
+package notifications
+
+import (
+    "context"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/i18n"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+// UserInfo is what delivery and display need to know about a user. Ghost
+// users (deleted, suspended or missing) carry the placeholder identity and
+// no contact details, so nothing is ever sent to them.
+type UserInfo struct {
+    ID             int64   `db:"id"`
+    Username       string  `db:"username"`
+    DisplayName    string  `db:"display_name"`
+    ProfilePicture *string `db:"profile_picture"`
+    Email          *string `db:"email"`
+    Phone          *string `db:"phone"`
+    Language       string  `db:"language"`
+    IsGhost        bool    `db:"is_ghost"`
+}
+
+// UserInfoProvider looks up users for notification delivery and actors
+type UserInfoProvider interface {
+    GetUserInfo(ctx context.Context, userID int64) (*UserInfo, error)
+    GetUsersInfo(ctx context.Context, userIDs []int64) (map[int64]*UserInfo, error)
+}
+
+type userInfoProvider struct {
+    users users.Client
+}
+
+// NewUserInfoProvider reads users through the shared users client
+func NewUserInfoProvider(client users.Client) UserInfoProvider {
+    return &userInfoProvider{users: client}
+}
+
+func (p *userInfoProvider) GetUserInfo(ctx context.Context, userID int64) (*UserInfo, error) {
+    infos, err := p.GetUsersInfo(ctx, []int64{userID})
+    if err != nil {
+        return nil, err
+    }
+    return infos[userID], nil
+}
+
+// GetUsersInfo returns info for every requested ID. Missing users come back
+// as ghosts.
+func (p *userInfoProvider) GetUsersInfo(ctx context.Context, userIDs []int64) (map[int64]*UserInfo, error) {
+    found, err := p.users.GetMany(ctx, userIDs)
+    if err != nil {
+        return nil, err
+    }
+
+    infos := make(map[int64]*UserInfo, len(found))
+    for id, user := range found {
+        info := &UserInfo{
+            ID:             user.ID,
+            Username:       user.Username,
+            DisplayName:    user.DisplayName,
+            ProfilePicture: user.ProfilePicture,
+            Email:          user.Email,
+            Phone:          user.Phone,
+            Language:       user.Language,
+            IsGhost:        user.IsGhost,
+        }
+        if info.Language == "" {
+            info.Language = i18n.Default
+        }
+        infos[id] = info
+    }
+    return infos, nil
+}
@@ -0,0 +1,401 @@
+// internal/notification/webpush.go
+
+package notifications
+
+import (
+    "bytes"
+    "context"
+    "crypto/aes"
+    "crypto/cipher"
+    "crypto/ecdh"
+    "crypto/ecdsa"
+    "crypto/elliptic"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/base64"
+    "encoding/binary"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "math/big"
+    "net/http"
+    "net/url"
+    "regexp"
+    "strings"
+    "time"
+
+    "github.com/golang-jwt/jwt/v4"
+    "golang.org/x/crypto/hkdf"
+)
+
+const (
+    // webPushRecordSize is the one aes128gcm record a payload is sent in
+    webPushRecordSize = 4096
+
+    // webPushMaxPayload leaves room in the record for the GCM tag and the
+    // padding delimiter
+    webPushMaxPayload = webPushRecordSize - 16 - 1
+
+    // webPushTTL is how long a push service holds a message for an offline
+    // browser
+    webPushTTL = 24 * time.Hour
+)
+
+// webPushTopic is what push services accept as a Topic header
+var webPushTopic = regexp.MustCompile(`^[A-Za-z0-9_-]{1,32}$`)
+
+// WebPushSubscription is a browser's PushSubscription, as its toJSON gives
+// it. It's stored as the token of a "web" push token.
+type WebPushSubscription struct {
+    Endpoint string                  `json:"endpoint" validate:"required,url"`
+    Keys     WebPushSubscriptionKeys `json:"keys" validate:"required"`
+}
+
+// WebPushSubscriptionKeys are a subscription's P-256 public key and auth
+// secret, base64url encoded
+type WebPushSubscriptionKeys struct {
+    P256dh string `json:"p256dh" validate:"required"`
+    Auth   string `json:"auth" validate:"required"`
+}
+
+// Token encodes the subscription the way it's stored, so the same
+// subscription always gives the same token
+func (s *WebPushSubscription) Token() string {
+    token, _ := json.Marshal(s)
+    return string(token)
+}
+
+// validate checks the subscription can be encrypted to over HTTPS
+func (s *WebPushSubscription) validate() error {
+    endpoint, err := url.Parse(s.Endpoint)
+    if err != nil || endpoint.Scheme != "https" || endpoint.Host == "" {
+        return fmt.Errorf("%w: endpoint must be an https URL", ErrInvalidSubscription)
+    }
+    if key, err := decodeBase64URL(s.Keys.P256dh); err != nil || len(key) != 65 || key[0] != 4 {
+        return fmt.Errorf("%w: p256dh must be an uncompressed P-256 key", ErrInvalidSubscription)
+    }
+    if auth, err := decodeBase64URL(s.Keys.Auth); err != nil || len(auth) != 16 {
+        return fmt.Errorf("%w: auth must be 16 bytes", ErrInvalidSubscription)
+    }
+    return nil
+}
+
+// VAPIDKeys is an application server's key pair for VAPID (RFC 8292), both
+// halves base64url encoded the way browsers and other servers expect
+type VAPIDKeys struct {
+    PublicKey  string
+    PrivateKey string
+}
+
+// GenerateVAPIDKeys creates a new VAPID key pair. Browsers bind their
+// subscriptions to the public key, so it has to stay the same once clients
+// have subscribed.
+func GenerateVAPIDKeys() (*VAPIDKeys, error) {
+    key, err := ecdh.P256().GenerateKey(rand.Reader)
+    if err != nil {
+        return nil, err
+    }
+    return &VAPIDKeys{
+        PublicKey:  base64.RawURLEncoding.EncodeToString(key.PublicKey().Bytes()),
+        PrivateKey: base64.RawURLEncoding.EncodeToString(key.Bytes()),
+    }, nil
+}
+
+// WebPushService sends push notifications to browsers through their push
+// services, encrypted per RFC 8291 and signed with the VAPID key
+type WebPushService struct {
+    key       *ecdsa.PrivateKey
+    publicKey string
+    subject   string
+    client    *http.Client
+}
+
+// NewWebPushService creates a web push service from a base64url VAPID
+// private key. subject is a mailto: or https: contact push services can
+// reach the operator at.
+func NewWebPushService(privateKey, subject string) (*WebPushService, error) {
+    if !strings.HasPrefix(subject, "mailto:") && !strings.HasPrefix(subject, "https:") {
+        return nil, errors.New("VAPID subject must be a mailto: or https: URL")
+    }
+
+    raw, err := decodeBase64URL(privateKey)
+    if err != nil {
+        return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+    }
+    ecdhKey, err := ecdh.P256().NewPrivateKey(raw)
+    if err != nil {
+        return nil, fmt.Errorf("invalid VAPID private key: %w", err)
+    }
+
+    public := ecdhKey.PublicKey().Bytes()
+    key := &ecdsa.PrivateKey{
+        PublicKey: ecdsa.PublicKey{
+            Curve: elliptic.P256(),
+            X:     new(big.Int).SetBytes(public[1:33]),
+            Y:     new(big.Int).SetBytes(public[33:]),
+        },
+        D: new(big.Int).SetBytes(raw),
+    }
+
+    return &WebPushService{
+        key:       key,
+        publicKey: base64.RawURLEncoding.EncodeToString(public),
+        subject:   subject,
+        client:    &http.Client{Timeout: 15 * time.Second},
+    }, nil
+}
+
+// PublicKey returns the VAPID public key browsers subscribe with
+func (s *WebPushService) PublicKey() string {
+    return s.publicKey
+}
+
+// SendPush sends a push notification to each of its subscriptions.
+// Reaching any one browser counts as delivered.
+func (s *WebPushService) SendPush(ctx context.Context, notification *PushNotification) error {
+    if len(notification.Tokens) == 0 {
+        return errors.New("no tokens provided")
+    }
+
+    payload, err := json.Marshal(webPushPayload(notification))
+    if err != nil {
+        return Permanent(err)
+    }
+    if len(payload) > webPushMaxPayload {
+        return Permanent(fmt.Errorf("web push payload is %d bytes, over the %d byte limit", len(payload), webPushMaxPayload))
+    }
+
+    var messageIDs []string
+    var lastErr, retryable error
+    for _, token := range notification.Tokens {
+        messageID, err := s.send(ctx, token, notification, payload)
+        if err == nil {
+            messageIDs = append(messageIDs, messageID)
+            continue
+        }
+
+        log.Printf("Failed to send web push notification: %v", err)
+        if errors.Is(err, errSubscriptionGone) {
+            notification.InvalidTokens = append(notification.InvalidTokens, token)
+        }
+        lastErr = err
+        if !IsPermanent(err) {
+            retryable = err
+        }
+    }
+
+    if len(messageIDs) == 0 {
+        if retryable != nil {
+            return retryable
+        }
+        return lastErr
+    }
+
+    notification.MessageID = strings.Join(messageIDs, ",")
+    return nil
+}
+
+// SendBatchPush sends multiple push notifications
+func (s *WebPushService) SendBatchPush(ctx context.Context, notifications []*PushNotification) error {
+    for _, notification := range notifications {
+        if err := s.SendPush(ctx, notification); err != nil {
+            log.Printf("Failed to send web push notification in batch: %v", err)
+        }
+    }
+
+    return nil
+}
+
+// errSubscriptionGone is a subscription the push service says has expired
+// or been unsubscribed
+var errSubscriptionGone = errors.New("web push subscription is gone")
+
+// webPushPayload is what the service worker's push event gets
+func webPushPayload(notification *PushNotification) map[string]interface{} {
+    payload := map[string]interface{}{
+        "title": notification.Title,
+        "body":  notification.Body,
+    }
+    if notification.Image != "" {
+        payload["image"] = notification.Image
+    }
+    if notification.CollapseKey != "" {
+        // Replaces an earlier notification with the same tag
+        payload["tag"] = notification.CollapseKey
+    }
+    if len(notification.Data) > 0 {
+        payload["data"] = notification.Data
+    }
+    return payload
+}
+
+// send encrypts the payload to one subscription and posts it to the
+// subscription's push service, returning the message's URL there
+func (s *WebPushService) send(ctx context.Context, token string, notification *PushNotification, payload []byte) (string, error) {
+    var subscription WebPushSubscription
+    if err := json.Unmarshal([]byte(token), &subscription); err != nil {
+        return "", Permanent(fmt.Errorf("%w: %v", errSubscriptionGone, err))
+    }
+    if err := subscription.validate(); err != nil {
+        return "", Permanent(fmt.Errorf("%w: %v", errSubscriptionGone, err))
+    }
+
+    body, err := encryptWebPush(&subscription, payload)
+    if err != nil {
+        return "", Permanent(err)
+    }
+
+    authorization, err := s.vapidAuthorization(subscription.Endpoint)
+    if err != nil {
+        return "", Permanent(err)
+    }
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodPost, subscription.Endpoint, bytes.NewReader(body))
+    if err != nil {
+        return "", Permanent(err)
+    }
+    req.Header.Set("Authorization", authorization)
+    req.Header.Set("Content-Encoding", "aes128gcm")
+    req.Header.Set("Content-Type", "application/octet-stream")
+    req.Header.Set("TTL", fmt.Sprint(int(webPushTTL.Seconds())))
+    req.Header.Set("Urgency", webPushUrgency(notification.Priority))
+    if webPushTopic.MatchString(notification.CollapseKey) {
+        req.Header.Set("Topic", notification.CollapseKey)
+    }
+
+    resp, err := s.client.Do(req)
+    if err != nil {
+        return "", fmt.Errorf("web push request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    switch {
+    case resp.StatusCode >= 200 && resp.StatusCode < 300:
+        return resp.Header.Get("Location"), nil
+    case resp.StatusCode == http.StatusNotFound || resp.StatusCode == http.StatusGone:
+        return "", Permanent(fmt.Errorf("%w: push service returned %s", errSubscriptionGone, resp.Status))
+    case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+        return "", fmt.Errorf("push service returned %s", resp.Status)
+    default:
+        return "", Permanent(fmt.Errorf("push service returned %s", resp.Status))
+    }
+}
+
+// vapidAuthorization signs the Authorization header for the push service
+// at endpoint's origin
+func (s *WebPushService) vapidAuthorization(endpoint string) (string, error) {
+    u, err := url.Parse(endpoint)
+    if err != nil {
+        return "", err
+    }
+
+    token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+        "aud": u.Scheme + "://" + u.Host,
+        "exp": time.Now().Add(12 * time.Hour).Unix(),
+        "sub": s.subject,
+    })
+    signed, err := token.SignedString(s.key)
+    if err != nil {
+        return "", fmt.Errorf("failed to sign VAPID token: %w", err)
+    }
+
+    return fmt.Sprintf("vapid t=%s, k=%s", signed, s.publicKey), nil
+}
+
+// encryptWebPush encrypts payload for a subscription as a single aes128gcm
+// record (RFC 8188), keyed as RFC 8291 describes
+func encryptWebPush(subscription *WebPushSubscription, payload []byte) ([]byte, error) {
+    rawUserKey, err := decodeBase64URL(subscription.Keys.P256dh)
+    if err != nil {
+        return nil, err
+    }
+    authSecret, err := decodeBase64URL(subscription.Keys.Auth)
+    if err != nil {
+        return nil, err
+    }
+    userKey, err := ecdh.P256().NewPublicKey(rawUserKey)
+    if err != nil {
+        return nil, fmt.Errorf("invalid subscription key: %w", err)
+    }
+
+    // A fresh key pair and salt for every message
+    serverKey, err := ecdh.P256().GenerateKey(rand.Reader)
+    if err != nil {
+        return nil, err
+    }
+    sharedSecret, err := serverKey.ECDH(userKey)
+    if err != nil {
+        return nil, err
+    }
+    salt := make([]byte, 16)
+    if _, err := rand.Read(salt); err != nil {
+        return nil, err
+    }
+    serverPublic := serverKey.PublicKey().Bytes()
+
+    // IKM mixes the shared secret with the subscription's auth secret
+    keyInfo := append([]byte("WebPush: info\x00"), rawUserKey...)
+    keyInfo = append(keyInfo, serverPublic...)
+    ikm, err := hkdfRead(sharedSecret, authSecret, keyInfo, 32)
+    if err != nil {
+        return nil, err
+    }
+
+    contentKey, err := hkdfRead(ikm, salt, []byte("Content-Encoding: aes128gcm\x00"), 16)
+    if err != nil {
+        return nil, err
+    }
+    nonce, err := hkdfRead(ikm, salt, []byte("Content-Encoding: nonce\x00"), 12)
+    if err != nil {
+        return nil, err
+    }
+
+    block, err := aes.NewCipher(contentKey)
+    if err != nil {
+        return nil, err
+    }
+    gcm, err := cipher.NewGCM(block)
+    if err != nil {
+        return nil, err
+    }
+
+    // The 0x02 delimiter marks the last (and only) record
+    plaintext := append(append([]byte(nil), payload...), 0x02)
+
+    header := make([]byte, 0, 16+4+1+len(serverPublic))
+    header = append(header, salt...)
+    header = binary.BigEndian.AppendUint32(header, webPushRecordSize)
+    header = append(header, byte(len(serverPublic)))
+    header = append(header, serverPublic...)
+
+    return gcm.Seal(header, nonce, plaintext, nil), nil
+}
+
+// hkdfRead derives length bytes with HKDF-SHA-256
+func hkdfRead(secret, salt, info []byte, length int) ([]byte, error) {
+    out := make([]byte, length)
+    if _, err := io.ReadFull(hkdf.New(sha256.New, secret, salt, info), out); err != nil {
+        return nil, err
+    }
+    return out, nil
+}
+
+// webPushUrgency maps our priority to the Urgency header
+func webPushUrgency(priority Priority) string {
+    switch priority {
+    case PriorityHigh:
+        return "high"
+    case PriorityLow:
+        return "low"
+    default:
+        return "normal"
+    }
+}
+
+// decodeBase64URL decodes base64url with or without padding, as browsers
+// and key generators variously give it
+func decodeBase64URL(s string) ([]byte, error) {
+    return base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+}
@@ -0,0 +1,34 @@
+// internal/onboarding/events.go
+// Onboarding steps completed by other modules' domain events
+
+package onboarding
+
+import (
+    "context"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+// SubscribeEvents subscribes onboarding to the events that complete each step
+func (s *service) SubscribeEvents(bus *events.Bus) {
+    events.Subscribe(bus, "onboarding", s.onUserVerified)
+    events.Subscribe(bus, "onboarding", s.onProfileCompleted)
+    events.Subscribe(bus, "onboarding", s.onProfilePhotoAdded)
+    events.Subscribe(bus, "onboarding", s.onPreferencesSaved)
+}
+
+func (s *service) onUserVerified(ctx context.Context, e events.UserVerified) error {
+    return s.completeStep(ctx, e.UserID, StepVerify)
+}
+
+func (s *service) onProfileCompleted(ctx context.Context, e events.ProfileCompleted) error {
+    return s.completeStep(ctx, e.UserID, StepProfile)
+}
+
+func (s *service) onProfilePhotoAdded(ctx context.Context, e events.ProfilePhotoAdded) error {
+    return s.completeStep(ctx, e.UserID, StepPhotos)
+}
+
+func (s *service) onPreferencesSaved(ctx context.Context, e events.DatingPreferencesSaved) error {
+    return s.completeStep(ctx, e.UserID, StepPreferences)
+}
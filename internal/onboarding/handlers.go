@@ -0,0 +1,35 @@
+// internal/onboarding/handlers.go
+
+package onboarding
+
+import (
+    "net/http"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// GetProgress handles GET /onboarding
+func (h *Handler) GetProgress(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    progress, err := h.service.GetProgress(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get onboarding progress")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, progress)
+}
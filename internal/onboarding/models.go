@@ -0,0 +1,45 @@
+// internal/onboarding/models.go
+
+package onboarding
+
+import "time"
+
+// Onboarding steps, in the order new users are taken through them
+const (
+    StepVerify      = "verify"
+    StepProfile     = "profile"
+    StepPhotos      = "photos"
+    StepPreferences = "preferences"
+)
+
+// Features gated behind onboarding steps
+const (
+    FeatureMessaging = "messaging"
+    FeatureSwiping   = "swiping"
+)
+
+// Step is one step of onboarding. Optional steps count towards the
+// completion percentage but never hold the user back.
+type Step struct {
+    Name     string
+    Required bool
+}
+
+// StepStatus is how far a user is with one step
+type StepStatus struct {
+    Step        string     `json:"step"`
+    Required    bool       `json:"required"`
+    Completed   bool       `json:"completed"`
+    CompletedAt *time.Time `json:"completed_at,omitempty"`
+}
+
+// Progress is a user's way through onboarding. NextSteps lists the required
+// steps still to do, in order; Features says which gated features they can
+// use already.
+type Progress struct {
+    Steps             []*StepStatus   `json:"steps"`
+    NextSteps         []string        `json:"next_steps"`
+    CompletionPercent int             `json:"completion_percent"`
+    Complete          bool            `json:"complete"`
+    Features          map[string]bool `json:"features"`
+}
@@ -0,0 +1,81 @@
+// internal/onboarding/repository.go
+
+package onboarding
+
+import (
+    "context"
+    "time"
+
+    "github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+    GetSteps(ctx context.Context, userID int64) (map[string]time.Time, error)
+    RecordStep(ctx context.Context, userID int64, step string) (bool, error)
+    GetAccountState(ctx context.Context, userID int64) (map[string]bool, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+// GetSteps returns when the user completed each step they have
+func (r *repository) GetSteps(ctx context.Context, userID int64) (map[string]time.Time, error) {
+    rows, err := r.db.QueryContext(ctx,
+        `SELECT step, completed_at FROM onboarding_steps WHERE user_id = $1`, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    steps := map[string]time.Time{}
+    for rows.Next() {
+        var step string
+        var completedAt time.Time
+        if err := rows.Scan(&step, &completedAt); err != nil {
+            return nil, err
+        }
+        steps[step] = completedAt
+    }
+    return steps, rows.Err()
+}
+
+// RecordStep marks the step done, returning false if it already was
+func (r *repository) RecordStep(ctx context.Context, userID int64, step string) (bool, error) {
+    result, err := r.db.ExecContext(ctx,
+        `INSERT INTO onboarding_steps (user_id, step) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, step)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// GetAccountState reads which steps the user's account shows as done, for
+// users who got that far before their steps were recorded
+func (r *repository) GetAccountState(ctx context.Context, userID int64) (map[string]bool, error) {
+    query := `
+        SELECT
+            COALESCE(u.is_verified, false),
+            u.date_of_birth IS NOT NULL AND u.gender IS NOT NULL,
+            u.profile_picture IS NOT NULL,
+            EXISTS(SELECT 1 FROM dating_preferences p WHERE p.user_id = u.id)
+        FROM users u
+        WHERE u.id = $1`
+
+    var verified, profile, photos, preferences bool
+    err := r.db.QueryRowContext(ctx, query, userID).Scan(&verified, &profile, &photos, &preferences)
+    if err != nil {
+        return nil, err
+    }
+    return map[string]bool{
+        StepVerify:      verified,
+        StepProfile:     profile,
+        StepPhotos:      photos,
+        StepPreferences: preferences,
+    }, nil
+}
@@ -0,0 +1,15 @@
+package onboarding
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/onboarding").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    // Steps are completed by the actions themselves: verifying, setting up
+    // the profile, adding a photo and saving dating preferences
+    api.HandleFunc("", handler.GetProgress).Methods("GET")
+}
@@ -0,0 +1,209 @@
+// internal/onboarding/service.go
+
+package onboarding
+
+import (
+    "context"
+    "fmt"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/analytics"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+// Steps is the onboarding flow, in order
+var Steps = []Step{
+    {Name: StepVerify, Required: true},
+    {Name: StepProfile, Required: true},
+    {Name: StepPhotos, Required: true},
+    {Name: StepPreferences, Required: false},
+}
+
+// featureSteps are the steps each gated feature needs
+var featureSteps = map[string][]string{
+    FeatureMessaging: {StepVerify, StepProfile},
+    FeatureSwiping:   {StepVerify, StepProfile, StepPhotos},
+}
+
+// stepEvents are the funnel events recorded as each step is completed.
+// Verification and profile setup are also tracked where they happen; the
+// funnel only counts them once.
+var stepEvents = map[string]string{
+    StepVerify:      analytics.EventVerified,
+    StepProfile:     analytics.EventProfileComplete,
+    StepPhotos:      analytics.EventPhotoAdded,
+    StepPreferences: analytics.EventPreferencesSet,
+}
+
+// Tracker records analytics funnel events
+type Tracker interface {
+    Track(ctx context.Context, userID int64, event string, properties map[string]interface{})
+}
+
+type Service interface {
+    GetProgress(ctx context.Context, userID int64) (*Progress, error)
+
+    // Feature gates
+    CanMessage(ctx context.Context, userID int64) (bool, error)
+    CanSwipe(ctx context.Context, userID int64) (bool, error)
+
+    // SubscribeEvents subscribes to the events that complete steps
+    SubscribeEvents(bus *events.Bus)
+
+    SetTracker(tracker Tracker)
+}
+
+type service struct {
+    repo    Repository
+    tracker Tracker
+}
+
+func NewService(repo Repository) Service {
+    return &service{repo: repo}
+}
+
+// SetTracker sets where onboarding funnel events go
+func (s *service) SetTracker(tracker Tracker) {
+    s.tracker = tracker
+}
+
+// GetProgress returns every step with whether the user has done it, the
+// required steps left, and the features they have unlocked
+func (s *service) GetProgress(ctx context.Context, userID int64) (*Progress, error) {
+    names := make([]string, len(Steps))
+    for i, step := range Steps {
+        names[i] = step.Name
+    }
+
+    completed, err := s.completedSteps(ctx, userID, names)
+    if err != nil {
+        return nil, err
+    }
+
+    progress := &Progress{
+        Steps:     make([]*StepStatus, len(Steps)),
+        NextSteps: []string{},
+        Features:  map[string]bool{},
+    }
+    done := 0
+    for i, step := range Steps {
+        status := &StepStatus{Step: step.Name, Required: step.Required}
+        if at, ok := completed[step.Name]; ok {
+            status.Completed = true
+            status.CompletedAt = &at
+            done++
+        } else if step.Required {
+            progress.NextSteps = append(progress.NextSteps, step.Name)
+        }
+        progress.Steps[i] = status
+    }
+    progress.CompletionPercent = done * 100 / len(Steps)
+    progress.Complete = len(progress.NextSteps) == 0
+
+    for feature, needed := range featureSteps {
+        progress.Features[feature] = hasAll(completed, needed)
+    }
+    return progress, nil
+}
+
+func (s *service) CanMessage(ctx context.Context, userID int64) (bool, error) {
+    return s.can(ctx, userID, FeatureMessaging)
+}
+
+func (s *service) CanSwipe(ctx context.Context, userID int64) (bool, error) {
+    return s.can(ctx, userID, FeatureSwiping)
+}
+
+func (s *service) can(ctx context.Context, userID int64, feature string) (bool, error) {
+    needed := featureSteps[feature]
+    completed, err := s.completedSteps(ctx, userID, needed)
+    if err != nil {
+        return false, err
+    }
+    return hasAll(completed, needed), nil
+}
+
+// completedSteps returns when the user completed each of their done steps.
+// If any of the wanted steps isn't recorded, the account is checked too, so
+// users who were part way through before onboarding was tracked aren't sent
+// back to the start.
+func (s *service) completedSteps(ctx context.Context, userID int64, wanted []string) (map[string]time.Time, error) {
+    completed, err := s.repo.GetSteps(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get onboarding steps: %w", err)
+    }
+    if hasAll(completed, wanted) {
+        return completed, nil
+    }
+
+    state, err := s.repo.GetAccountState(ctx, userID)
+    if err != nil {
+        return nil, fmt.Errorf("failed to get account state: %w", err)
+    }
+    backfilled := false
+    for _, step := range Steps {
+        if _, ok := completed[step.Name]; ok || !state[step.Name] {
+            continue
+        }
+        if err := s.completeStep(ctx, userID, step.Name); err != nil {
+            return nil, err
+        }
+        backfilled = true
+    }
+    if !backfilled {
+        return completed, nil
+    }
+    return s.repo.GetSteps(ctx, userID)
+}
+
+// completeStep records a step as done. The first time, it tracks the step's
+// funnel event, and recording the last required step tracks onboarding as
+// complete. Whether the profile is complete enough for discovery is up to the
+// profile service.
+func (s *service) completeStep(ctx context.Context, userID int64, step string) error {
+    recorded, err := s.repo.RecordStep(ctx, userID, step)
+    if err != nil {
+        return fmt.Errorf("failed to record onboarding step: %w", err)
+    }
+    if !recorded {
+        return nil
+    }
+    s.track(ctx, userID, stepEvents[step], nil)
+
+    completed, err := s.repo.GetSteps(ctx, userID)
+    if err != nil {
+        return err
+    }
+    required := false
+    for _, st := range Steps {
+        if _, ok := completed[st.Name]; st.Required && !ok {
+            return nil
+        }
+        if st.Name == step {
+            required = st.Required
+        }
+    }
+
+    // Only the last required step finishes onboarding; optional steps
+    // done afterwards don't finish it again
+    if required {
+        s.track(ctx, userID, analytics.EventOnboardingComplete, nil)
+    }
+    return nil
+}
+
+// track records a funnel event, if a tracker is set
+func (s *service) track(ctx context.Context, userID int64, event string, properties map[string]interface{}) {
+    if s.tracker != nil {
+        s.tracker.Track(ctx, userID, event, properties)
+    }
+}
+
+func hasAll(completed map[string]time.Time, steps []string) bool {
+    for _, step := range steps {
+        if _, ok := completed[step]; !ok {
+            return false
+        }
+    }
+    return true
+}
@@ -3,52 +3,41 @@
 package otp
 
 import (
-	"encoding/json"
 	"errors"
+	"math"
 	"net/http"
+	"strconv"
 
-	"github.com/go-playground/validator/v10"
 	"github.com/imadgeboyega/kiekky-backend/internal/common/utils"
 )
 
 // Handler handles OTP-related HTTP requests
 type Handler struct {
-	service   Service
-	validator *validator.Validate
+	service Service
 }
 
 // NewHandler creates a new OTP handler
 func NewHandler(service Service) *Handler {
 	return &Handler{
-		service:   service,
-		validator: validator.New(),
+		service: service,
 	}
 }
 
 // SendOTP handles sending OTP requests
 func (h *Handler) SendOTP(w http.ResponseWriter, r *http.Request) {
 	var req SendOTPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
-	// Validate request
-	if err := h.validator.Struct(req); err != nil {
-		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Get user ID from context if authenticated (optional)
-	if userID, ok := r.Context().Value("user_id").(int64); ok && req.UserID == 0 {
-		req.UserID = userID
-	}
-
 	// Generate and send OTP
 	response, err := h.service.GenerateOTP(r.Context(), &req)
 	if err != nil {
-		if errors.Is(err, ErrRateLimitExceeded) {
-			utils.ErrorResponse(w, err.Error(), http.StatusTooManyRequests)
+		if RespondRateLimited(w, err) {
+			return
+		}
+		if errors.Is(err, ErrEmailSuppressed) {
+			utils.ErrorResponse(w, "This email address can't receive email, please use another one", http.StatusUnprocessableEntity)
 			return
 		}
 		utils.ErrorResponse(w, "Failed to send OTP", http.StatusInternalServerError)
@@ -61,25 +50,16 @@ func (h *Handler) SendOTP(w http.ResponseWriter, r *http.Request) {
 // VerifyOTP handles OTP verification requests
 func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 	var req VerifyOTPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
-	// Validate request
-	if err := h.validator.Struct(req); err != nil {
-		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Get user ID from context if authenticated (optional)
-	if userID, ok := r.Context().Value("user_id").(int64); ok && req.UserID == 0 {
-		req.UserID = userID
-	}
-
 	// Verify OTP
 	err := h.service.VerifyOTP(r.Context(), &req)
 	if err != nil {
+		if RespondRateLimited(w, err) {
+			return
+		}
 		switch {
 		case errors.Is(err, ErrOTPExpired):
 			utils.ErrorResponse(w, "OTP has expired", http.StatusBadRequest)
@@ -105,27 +85,18 @@ func (h *Handler) VerifyOTP(w http.ResponseWriter, r *http.Request) {
 // ResendOTP handles OTP resend requests
 func (h *Handler) ResendOTP(w http.ResponseWriter, r *http.Request) {
 	var req ResendOTPRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
-	// Validate request
-	if err := h.validator.Struct(req); err != nil {
-		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
-		return
-	}
-
-	// Get user ID from context if authenticated (optional)
-	if userID, ok := r.Context().Value("user_id").(int64); ok && req.UserID == 0 {
-		req.UserID = userID
-	}
-
 	// Resend OTP
 	response, err := h.service.ResendOTP(r.Context(), &req)
 	if err != nil {
-		if errors.Is(err, ErrRateLimitExceeded) {
-			utils.ErrorResponse(w, err.Error(), http.StatusTooManyRequests)
+		if RespondRateLimited(w, err) {
+			return
+		}
+		if errors.Is(err, ErrEmailSuppressed) {
+			utils.ErrorResponse(w, "This email address can't receive email, please use another one", http.StatusUnprocessableEntity)
 			return
 		}
 		utils.ErrorResponse(w, "Failed to resend OTP", http.StatusInternalServerError)
@@ -133,4 +104,29 @@ func (h *Handler) ResendOTP(w http.ResponseWriter, r *http.Request) {
 	}
 
 	utils.SuccessResponse(w, response, http.StatusOK)
-}
\ No newline at end of file
+}
+
+// RespondRateLimited writes a 429 if err is a rate limit or lockout, saying
+// when to retry and whether to show a CAPTCHA first. It reports whether it
+// responded.
+func RespondRateLimited(w http.ResponseWriter, err error) bool {
+	if !errors.Is(err, ErrRateLimitExceeded) {
+		return false
+	}
+
+	details := map[string]interface{}{"captcha_required": false}
+	var limitErr *RateLimitError
+	if errors.As(err, &limitErr) {
+		retryAfter := int(math.Ceil(limitErr.RetryAfter.Seconds()))
+		w.Header().Set("Retry-After", strconv.Itoa(retryAfter))
+		details["retry_after"] = retryAfter
+		details["captcha_required"] = limitErr.CaptchaRequired
+	}
+
+	utils.RespondWithJSON(w, http.StatusTooManyRequests, utils.Response{
+		Success: false,
+		Error:   ErrRateLimitExceeded.Error(),
+		Data:    details,
+	})
+	return true
+}
@@ -0,0 +1,192 @@
+// internal/otp/limits.go
+// Layered send limits and lockouts after repeated wrong codes
+//
+// Sends are counted per destination, per client IP and per device in Redis,
+// so the limits hold across instances. Wrong codes lock the destination out
+// for a period that doubles with each lockout in a day. Without Redis only
+// the per-destination limit applies, counted in Postgres.
+
+package otp
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+)
+
+// lockoutLevelTTL is how long past lockouts count towards the next one's
+// length
+const lockoutLevelTTL = 24 * time.Hour
+
+// RateLimitError is returned when a send limit or lockout applies. It
+// matches ErrRateLimitExceeded.
+type RateLimitError struct {
+	RetryAfter time.Duration
+
+	// CaptchaRequired asks the client to have the user solve a CAPTCHA
+	// before trying again, as the limit hit points to automation
+	CaptchaRequired bool
+}
+
+func (e *RateLimitError) Error() string {
+	return ErrRateLimitExceeded.Error()
+}
+
+func (e *RateLimitError) Is(target error) bool {
+	return target == ErrRateLimitExceeded
+}
+
+// checkSendLimits counts a request for a code to destination against each
+// limit, and refuses it while the destination is locked out
+func (s *service) checkSendLimits(ctx context.Context, destination string) error {
+	limits := s.config.RateLimit
+
+	if s.redis == nil {
+		count, err := s.repo.CountRecentOTPs(ctx, destination, limits.Window)
+		if err != nil {
+			return fmt.Errorf("failed to check rate limit: %w", err)
+		}
+		if count >= limits.MaxRequests {
+			otpRateLimited.WithLabelValues("destination").Inc()
+			return &RateLimitError{RetryAfter: limits.Window}
+		}
+		return nil
+	}
+
+	if err := s.checkLockout(ctx, destination); err != nil {
+		return err
+	}
+
+	client := middleware.ClientInfoFromContext(ctx)
+	scopes := []struct {
+		name    string
+		value   string
+		max     int
+		captcha bool
+	}{
+		{"destination", normalizeDestination(destination), limits.MaxRequests, false},
+		{"ip", client.IP, limits.MaxPerIP, true},
+		{"device", client.DeviceID, limits.MaxPerDevice, true},
+	}
+
+	for _, scope := range scopes {
+		if scope.value == "" || scope.max <= 0 {
+			continue
+		}
+
+		count, ttl, err := s.hit(ctx, fmt.Sprintf("otp_sends:%s:%s", scope.name, scope.value), limits.Window)
+		if err != nil {
+			// Fail open; a Redis outage shouldn't stop signups
+			log.Printf("Failed to count OTP sends by %s: %v", scope.name, err)
+			continue
+		}
+		if count > int64(scope.max) {
+			otpRateLimited.WithLabelValues(scope.name).Inc()
+			return &RateLimitError{RetryAfter: ttl, CaptchaRequired: scope.captcha}
+		}
+	}
+	return nil
+}
+
+// checkLockout refuses destination while it's locked out
+func (s *service) checkLockout(ctx context.Context, destination string) error {
+	if s.redis == nil {
+		return nil
+	}
+
+	key := normalizeDestination(destination)
+	ttl, err := s.redis.TTL(ctx, lockoutKey(key)).Result()
+	if err != nil || ttl <= 0 {
+		return nil
+	}
+
+	level, _ := s.redis.Get(ctx, lockoutLevelKey(key)).Int()
+	otpRateLimited.WithLabelValues("lockout").Inc()
+	return &RateLimitError{RetryAfter: ttl, CaptchaRequired: level > 1}
+}
+
+// recordFailure counts a wrong code for destination. Once LockoutAfter
+// wrong codes are entered within the window the destination is locked out,
+// for LockoutDuration the first time and twice as long each time after.
+func (s *service) recordFailure(ctx context.Context, destination string) error {
+	limits := s.config.RateLimit
+	if s.redis == nil || limits.LockoutAfter <= 0 {
+		return nil
+	}
+
+	key := normalizeDestination(destination)
+	failures, _, err := s.hit(ctx, failuresKey(key), limits.Window)
+	if err != nil {
+		log.Printf("Failed to count wrong OTPs: %v", err)
+		return nil
+	}
+	if failures < int64(limits.LockoutAfter) {
+		return nil
+	}
+
+	level, err := s.redis.Incr(ctx, lockoutLevelKey(key)).Result()
+	if err != nil {
+		log.Printf("Failed to lock out OTP destination: %v", err)
+		return nil
+	}
+	s.redis.Expire(ctx, lockoutLevelKey(key), lockoutLevelTTL)
+
+	duration := limits.LockoutDuration
+	for i := int64(1); i < level && duration < limits.MaxLockout; i++ {
+		duration *= 2
+	}
+	if limits.MaxLockout > 0 && duration > limits.MaxLockout {
+		duration = limits.MaxLockout
+	}
+
+	s.redis.Set(ctx, lockoutKey(key), level, duration)
+	s.redis.Del(ctx, failuresKey(key))
+	otpRateLimited.WithLabelValues("lockout").Inc()
+	return &RateLimitError{RetryAfter: duration, CaptchaRequired: level > 1}
+}
+
+// clearFailures forgets wrong codes for destination after a right one
+func (s *service) clearFailures(ctx context.Context, destination string) {
+	if s.redis == nil {
+		return
+	}
+	s.redis.Del(ctx, failuresKey(normalizeDestination(destination)))
+}
+
+// hit counts one event in a fixed window starting at the first one, and
+// returns the count so far and the time left in the window
+func (s *service) hit(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	count, err := s.redis.Incr(ctx, key).Result()
+	if err != nil {
+		return 0, 0, err
+	}
+
+	ttl, err := s.redis.TTL(ctx, key).Result()
+	if err != nil || ttl <= 0 {
+		// First hit, or a key left without an expiry
+		s.redis.Expire(ctx, key, window)
+		ttl = window
+	}
+	return count, ttl, nil
+}
+
+// normalizeDestination makes addresses that differ only in case share limits
+func normalizeDestination(destination string) string {
+	return strings.ToLower(strings.TrimSpace(destination))
+}
+
+func failuresKey(destination string) string {
+	return fmt.Sprintf("otp_failures:%s", destination)
+}
+
+func lockoutKey(destination string) string {
+	return fmt.Sprintf("otp_lockout:%s", destination)
+}
+
+func lockoutLevelKey(destination string) string {
+	return fmt.Sprintf("otp_lockout_level:%s", destination)
+}
@@ -0,0 +1,25 @@
+// internal/otp/metrics.go
+
+package otp
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	otpRowsPurged = promauto.NewCounter(
+		prometheus.CounterOpts{
+			Name: "otp_cleanup_rows_purged_total",
+			Help: "Total number of expired or used OTP rows deleted by cleanup",
+		},
+	)
+
+	otpRateLimited = promauto.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "otp_rate_limited_total",
+			Help: "Total number of OTP requests refused by rate limits and lockouts, by scope",
+		},
+		[]string{"scope"},
+	)
+)
@@ -38,6 +38,7 @@ type OTP struct {
 	ExpiresAt  time.Time      `json:"expires_at" db:"expires_at"`
 	VerifiedAt *time.Time     `json:"verified_at,omitempty" db:"verified_at"`
 	CreatedAt  time.Time      `json:"created_at" db:"created_at"`
+	Language   string         `json:"-" db:"-"` // Language the code is sent in, not stored
 }
 
 // SendOTPRequest represents request to send OTP
@@ -47,6 +48,7 @@ type SendOTPRequest struct {
 	Phone     string         `json:"phone,omitempty" validate:"omitempty,e164"`
 	Type      OTPType        `json:"type" validate:"required,oneof=signup signin password_reset phone_verify email_verify"`
 	Method    DeliveryMethod `json:"method" validate:"required,oneof=email sms"`
+	Language  string         `json:"-"` // Recipient's preferred language; defaults to the request's
 }
 
 // VerifyOTPRequest represents request to verify OTP
@@ -65,6 +67,7 @@ type ResendOTPRequest struct {
 	Phone     string         `json:"phone,omitempty" validate:"omitempty,e164"`
 	Type      OTPType        `json:"type" validate:"required"`
 	Method    DeliveryMethod `json:"method" validate:"required,oneof=email sms"`
+	Language  string         `json:"-"`
 }
 
 // OTPResponse represents OTP operation response
@@ -82,18 +85,30 @@ type OTPConfig struct {
 	RateLimit   RateLimitConfig
 }
 
-// RateLimitConfig holds rate limiting configuration
+// RateLimitConfig holds rate limiting configuration. Limits other than
+// MaxRequests need Redis.
 type RateLimitConfig struct {
-	MaxRequests int           `json:"max_requests"`
-	Window      time.Duration `json:"window"`
+	MaxRequests  int           `json:"max_requests"`   // Codes per destination per Window
+	MaxPerIP     int           `json:"max_per_ip"`     // Codes per client IP per Window; 0 for no limit
+	MaxPerDevice int           `json:"max_per_device"` // Codes per device per Window; 0 for no limit
+	Window       time.Duration `json:"window"`
+
+	// LockoutAfter wrong codes within Window lock the destination out for
+	// LockoutDuration, doubling with each lockout up to MaxLockout. 0
+	// disables lockouts.
+	LockoutAfter    int           `json:"lockout_after"`
+	LockoutDuration time.Duration `json:"lockout_duration"`
+	MaxLockout      time.Duration `json:"max_lockout"`
 }
 
-// EmailTemplate represents email template data
+// EmailTemplate represents an email rendered from a template
 type EmailTemplate struct {
 	To           string
 	Subject      string
 	TemplateName string
 	Data         map[string]interface{}
+	HTML         string
+	Text         string // Plain text alternative
 }
 
 // SMSMessage represents SMS message data
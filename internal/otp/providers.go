@@ -6,14 +6,18 @@ import (
 	"bytes"
 	"context"
 	"fmt"
-	"html/template"
+	"log"
+	"mime"
+	"mime/multipart"
 	"net/smtp"
-	"path/filepath"
+	"net/textproto"
+	"sync"
+	"time"
 
 	"github.com/sendgrid/sendgrid-go"
 	"github.com/sendgrid/sendgrid-go/helpers/mail"
-	"github.com/twilio/twilio-go"
-	twilioApi "github.com/twilio/twilio-go/rest/api/v2010"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/common/sms"
 )
 
 // EmailProvider defines the email provider interface
@@ -46,37 +50,19 @@ func NewSMTPEmailProvider(host, port, username, password, from string) EmailProv
 	}
 }
 
-// SendEmail sends an email using SMTP
+// SendEmail sends an email using SMTP, as HTML with a plain text alternative
 func (p *SMTPEmailProvider) SendEmail(ctx context.Context, emailData *EmailTemplate) error {
-	// Load and parse template
-	templatePath := filepath.Join("templates", emailData.TemplateName+".html")
-	tmpl, err := template.ParseFiles(templatePath)
+	message, err := composeMessage(p.from, emailData)
 	if err != nil {
-		// Fallback to plain text if template not found
-		return p.sendPlainTextEmail(emailData)
-	}
-
-	// Execute template
-	var body bytes.Buffer
-	if err := tmpl.Execute(&body, emailData.Data); err != nil {
-		return fmt.Errorf("failed to execute template: %w", err)
+		return fmt.Errorf("failed to compose email: %w", err)
 	}
 
-	// Compose message
-	message := fmt.Sprintf("From: %s\r\n", p.from)
-	message += fmt.Sprintf("To: %s\r\n", emailData.To)
-	message += fmt.Sprintf("Subject: %s\r\n", emailData.Subject)
-	message += "MIME-version: 1.0;\r\n"
-	message += "Content-Type: text/html; charset=\"UTF-8\";\r\n"
-	message += "\r\n"
-	message += body.String()
-
 	// Set up authentication
 	auth := smtp.PlainAuth("", p.username, p.password, p.host)
 
 	// Send email
 	addr := fmt.Sprintf("%s:%s", p.host, p.port)
-	err = smtp.SendMail(addr, auth, p.from, []string{emailData.To}, []byte(message))
+	err = smtp.SendMail(addr, auth, p.from, []string{emailData.To}, message)
 	if err != nil {
 		return fmt.Errorf("failed to send email: %w", err)
 	}
@@ -84,23 +70,43 @@ func (p *SMTPEmailProvider) SendEmail(ctx context.Context, emailData *EmailTempl
 	return nil
 }
 
-// sendPlainTextEmail sends a plain text email (fallback)
-func (p *SMTPEmailProvider) sendPlainTextEmail(emailData *EmailTemplate) error {
-	code := emailData.Data["code"].(string)
-	expiresIn := emailData.Data["expiresIn"].(int)
-	
-	body := fmt.Sprintf("Your verification code is: %s\n\nThis code will expire in %d minutes.", code, expiresIn)
-	
-	message := fmt.Sprintf("From: %s\r\n", p.from)
-	message += fmt.Sprintf("To: %s\r\n", emailData.To)
-	message += fmt.Sprintf("Subject: %s\r\n", emailData.Subject)
-	message += "\r\n"
-	message += body
+// composeMessage builds a MIME message. Emails with HTML are sent as
+// multipart/alternative so clients without HTML show the plain text.
+func composeMessage(from string, emailData *EmailTemplate) ([]byte, error) {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", emailData.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", emailData.Subject))
+	buf.WriteString("MIME-Version: 1.0\r\n")
 
-	auth := smtp.PlainAuth("", p.username, p.password, p.host)
-	addr := fmt.Sprintf("%s:%s", p.host, p.port)
-	
-	return smtp.SendMail(addr, auth, p.from, []string{emailData.To}, []byte(message))
+	if emailData.HTML == "" {
+		buf.WriteString("Content-Type: text/plain; charset=\"UTF-8\"\r\n\r\n")
+		buf.WriteString(emailData.Text)
+		return buf.Bytes(), nil
+	}
+
+	parts := multipart.NewWriter(&buf)
+	fmt.Fprintf(&buf, "Content-Type: multipart/alternative; boundary=%q\r\n\r\n", parts.Boundary())
+
+	for _, part := range []struct{ contentType, body string }{
+		{"text/plain", emailData.Text},
+		{"text/html", emailData.HTML},
+	} {
+		w, err := parts.CreatePart(textproto.MIMEHeader{
+			"Content-Type": {part.contentType + "; charset=\"UTF-8\""},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.body)); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := parts.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
 }
 
 // SendGridEmailProvider implements EmailProvider using SendGrid
@@ -122,24 +128,7 @@ func (p *SendGridEmailProvider) SendEmail(ctx context.Context, emailData *EmailT
 	from := mail.NewEmail("Kiekky", p.from)
 	to := mail.NewEmail("", emailData.To)
 	
-	// Load and parse template
-	templatePath := filepath.Join("templates", emailData.TemplateName+".html")
-	tmpl, err := template.ParseFiles(templatePath)
-	
-	var htmlContent string
-	if err == nil {
-		var body bytes.Buffer
-		if err := tmpl.Execute(&body, emailData.Data); err == nil {
-			htmlContent = body.String()
-		}
-	}
-	
-	// Fallback to plain text
-	code := emailData.Data["code"].(string)
-	expiresIn := emailData.Data["expiresIn"].(int)
-	plainTextContent := fmt.Sprintf("Your verification code is: %s\n\nThis code will expire in %d minutes.", code, expiresIn)
-	
-	message := mail.NewSingleEmail(from, emailData.Subject, to, plainTextContent, htmlContent)
+	message := mail.NewSingleEmail(from, emailData.Subject, to, emailData.Text, emailData.HTML)
 	client := sendgrid.NewSendClient(p.apiKey)
 	
 	response, err := client.Send(message)
@@ -154,35 +143,100 @@ func (p *SendGridEmailProvider) SendEmail(ctx context.Context, emailData *EmailT
 	return nil
 }
 
-// TwilioSMSProvider implements SMSProvider using Twilio
-type TwilioSMSProvider struct {
-	client      *twilio.RestClient
-	phoneNumber string
+// SuppressionChecker reports addresses that have bounced or complained and
+// must not be emailed
+type SuppressionChecker interface {
+	IsSuppressed(ctx context.Context, email string) (bool, error)
 }
 
-// NewTwilioSMSProvider creates a new Twilio SMS provider
-func NewTwilioSMSProvider(accountSID, authToken, phoneNumber string) SMSProvider {
-	client := twilio.NewRestClientWithParams(twilio.ClientParams{
-		Username: accountSID,
-		Password: authToken,
-	})
-	
-	return &TwilioSMSProvider{
-		client:      client,
-		phoneNumber: phoneNumber,
+// SuppressedEmailProvider wraps an EmailProvider to refuse suppressed
+// addresses with ErrEmailSuppressed
+type SuppressedEmailProvider struct {
+	provider     EmailProvider
+	suppressions SuppressionChecker
+}
+
+// NewSuppressedEmailProvider creates a provider that checks suppressions
+// before sending through provider
+func NewSuppressedEmailProvider(provider EmailProvider, suppressions SuppressionChecker) EmailProvider {
+	return &SuppressedEmailProvider{
+		provider:     provider,
+		suppressions: suppressions,
+	}
+}
+
+// SendEmail sends an email unless its address is suppressed. A failed check
+// doesn't stop the email; a code that might bounce beats one never sent.
+func (p *SuppressedEmailProvider) SendEmail(ctx context.Context, emailData *EmailTemplate) error {
+	suppressed, err := p.suppressions.IsSuppressed(ctx, emailData.To)
+	if err != nil {
+		log.Printf("Failed to check email suppression for %s: %v", emailData.To, err)
+	} else if suppressed {
+		return ErrEmailSuppressed
 	}
+
+	return p.provider.SendEmail(ctx, emailData)
+}
+
+// FailoverEmailProvider sends through a primary provider, switching to a
+// fallback when the primary errors. The primary is skipped for the cooldown
+// after an error.
+type FailoverEmailProvider struct {
+	primary  EmailProvider
+	fallback EmailProvider
+	cooldown time.Duration
+
+	mu        sync.Mutex
+	downUntil time.Time
 }
 
-// SendSMS sends an SMS using Twilio
-func (p *TwilioSMSProvider) SendSMS(ctx context.Context, message *SMSMessage) error {
-	params := &twilioApi.CreateMessageParams{}
-	params.SetTo(message.To)
-	params.SetFrom(p.phoneNumber)
-	params.SetBody(message.Message)
+// NewFailoverEmailProvider creates a provider that fails over from primary
+// to fallback
+func NewFailoverEmailProvider(primary, fallback EmailProvider, cooldown time.Duration) EmailProvider {
+	return &FailoverEmailProvider{
+		primary:  primary,
+		fallback: fallback,
+		cooldown: cooldown,
+	}
+}
+
+// SendEmail sends an email through whichever provider is healthy
+func (p *FailoverEmailProvider) SendEmail(ctx context.Context, emailData *EmailTemplate) error {
+	p.mu.Lock()
+	primaryDown := time.Now().Before(p.downUntil)
+	p.mu.Unlock()
+
+	if !primaryDown {
+		err := p.primary.SendEmail(ctx, emailData)
+		if err == nil || ctx.Err() != nil {
+			return err
+		}
+
+		p.mu.Lock()
+		p.downUntil = time.Now().Add(p.cooldown)
+		p.mu.Unlock()
+		log.Printf("Primary email provider failed, failing over: %v", err)
+	}
+
+	return p.fallback.SendEmail(ctx, emailData)
+}
+
+// RoutedSMSProvider implements SMSProvider through the SMS router, which
+// picks a provider for each number and fails over between them
+type RoutedSMSProvider struct {
+	router *sms.Router
+}
+
+// NewRoutedSMSProvider creates an SMS provider backed by router
+func NewRoutedSMSProvider(router *sms.Router) SMSProvider {
+	return &RoutedSMSProvider{router: router}
+}
 
-	_, err := p.client.Api.CreateMessage(params)
+// SendSMS sends an SMS through the first provider that accepts it
+func (p *RoutedSMSProvider) SendSMS(ctx context.Context, message *SMSMessage) error {
+	_, err := p.router.Send(ctx, &sms.Message{To: message.To, Body: message.Message})
 	if err != nil {
-		return fmt.Errorf("failed to send SMS via Twilio: %w", err)
+		return fmt.Errorf("failed to send SMS: %w", err)
 	}
 
 	return nil
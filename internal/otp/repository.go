@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/imadgeboyega/kiekky-backend/internal/common/database"
 	"github.com/jmoiron/sqlx"
 )
 
@@ -20,8 +21,8 @@ type Repository interface {
 	UpdateOTPAttempts(ctx context.Context, id int64, attempts int) error
 	MarkOTPAsVerified(ctx context.Context, id int64) error
 	InvalidateOTPs(ctx context.Context, userID int64, otpType OTPType) error
-	CountRecentOTPs(ctx context.Context, userID int64, window time.Duration) (int, error)
-	DeleteExpiredOTPs(ctx context.Context, before time.Time) error
+	CountRecentOTPs(ctx context.Context, recipient string, window time.Duration) (int, error)
+	DeleteExpiredOTPs(ctx context.Context, before time.Time, batchSize int) (int64, error)
 }
 
 // postgresRepository implements Repository using PostgreSQL
@@ -127,6 +128,8 @@ func (r *postgresRepository) GetLatestOTPByRecipient(ctx context.Context, recipi
 func (r *postgresRepository) UpdateOTPAttempts(ctx context.Context, id int64, attempts int) error {
 	query := `UPDATE otps SET attempts = $1 WHERE id = $2`
 	
+	// Deliberately outside any transaction in ctx: a wrong code must use up
+	// an attempt even though the flow it was part of rolls back
 	result, err := r.db.ExecContext(ctx, query, attempts, id)
 	if err != nil {
 		return fmt.Errorf("failed to update OTP attempts: %w", err)
@@ -146,9 +149,9 @@ func (r *postgresRepository) UpdateOTPAttempts(ctx context.Context, id int64, at
 
 // MarkOTPAsVerified marks an OTP as verified
 func (r *postgresRepository) MarkOTPAsVerified(ctx context.Context, id int64) error {
-	query := `UPDATE otps SET verified = true, verified_at = $1 WHERE id = $2`
+	query := `UPDATE otps SET verified = true, verified_at = $1 WHERE id = $2 AND verified = false`
 	
-	result, err := r.db.ExecContext(ctx, query, time.Now(), id)
+	result, err := database.ConnX(ctx, r.db).ExecContext(ctx, query, time.Now(), id)
 	if err != nil {
 		return fmt.Errorf("failed to mark OTP as verified: %w", err)
 	}
@@ -180,17 +183,18 @@ func (r *postgresRepository) InvalidateOTPs(ctx context.Context, userID int64, o
 	return nil
 }
 
-// CountRecentOTPs counts OTPs created within a time window for rate limiting
-func (r *postgresRepository) CountRecentOTPs(ctx context.Context, userID int64, window time.Duration) (int, error) {
+// CountRecentOTPs counts OTPs sent to recipient within a time window for
+// rate limiting
+func (r *postgresRepository) CountRecentOTPs(ctx context.Context, recipient string, window time.Duration) (int, error) {
 	var count int
 	since := time.Now().Add(-window)
 	
 	query := `
 		SELECT COUNT(*) 
 		FROM otps 
-		WHERE user_id = $1 AND created_at > $2`
+		WHERE recipient = $1 AND created_at > $2`
 	
-	err := r.db.GetContext(ctx, &count, query, userID, since)
+	err := r.db.GetContext(ctx, &count, query, recipient, since)
 	if err != nil {
 		return 0, fmt.Errorf("failed to count recent OTPs: %w", err)
 	}
@@ -198,17 +202,41 @@ func (r *postgresRepository) CountRecentOTPs(ctx context.Context, userID int64,
 	return count, nil
 }
 
-// DeleteExpiredOTPs deletes OTPs that have expired
-func (r *postgresRepository) DeleteExpiredOTPs(ctx context.Context, before time.Time) error {
-	query := `DELETE FROM otps WHERE expires_at < $1 OR (verified = true AND verified_at < $2)`
-	
+// DeleteExpiredOTPs deletes expired OTPs, and verified OTPs older than 24 hours,
+// in batches of batchSize so a large backlog never holds one long lock on the
+// table. It returns the number of rows deleted, including on error.
+func (r *postgresRepository) DeleteExpiredOTPs(ctx context.Context, before time.Time, batchSize int) (int64, error) {
+	// Each branch matches one of the partial cleanup indexes
+	query := `
+		DELETE FROM otps
+		WHERE id IN (
+			SELECT id FROM otps WHERE expires_at < $1
+			UNION
+			SELECT id FROM otps WHERE verified = true AND verified_at < $2
+			LIMIT $3
+		)`
+
 	// Delete verified OTPs older than 24 hours
 	verifiedBefore := before.Add(-24 * time.Hour)
-	
-	_, err := r.db.ExecContext(ctx, query, before, verifiedBefore)
-	if err != nil {
-		return fmt.Errorf("failed to delete expired OTPs: %w", err)
-	}
 
-	return nil
-}
\ No newline at end of file
+	var total int64
+	for {
+		result, err := r.db.ExecContext(ctx, query, before, verifiedBefore, batchSize)
+		if err != nil {
+			return total, fmt.Errorf("failed to delete expired OTPs: %w", err)
+		}
+
+		deleted, err := result.RowsAffected()
+		if err != nil {
+			return total, fmt.Errorf("failed to get rows affected: %w", err)
+		}
+		total += deleted
+
+		if deleted < int64(batchSize) {
+			return total, nil
+		}
+		if err := ctx.Err(); err != nil {
+			return total, err
+		}
+	}
+}
@@ -10,14 +10,26 @@ import (
 	"log"
 	"math/big"
 	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/common/email"
+	"github.com/imadgeboyega/kiekky-backend/internal/i18n"
 )
 
+// cleanupBatchSize bounds how many rows a single cleanup DELETE touches
+const cleanupBatchSize = 1000
+
 var (
 	ErrOTPExpired       = errors.New("OTP has expired")
 	ErrOTPInvalid       = errors.New("invalid OTP code")
 	ErrOTPMaxAttempts   = errors.New("maximum verification attempts exceeded")
 	ErrOTPAlreadyUsed   = errors.New("OTP has already been used")
 	ErrRateLimitExceeded = errors.New("rate limit exceeded, please try again later")
+
+	// ErrEmailSuppressed means the address bounced or complained before and
+	// is no longer emailed
+	ErrEmailSuppressed = errors.New("this email address can't receive email")
 )
 
 // Service defines the OTP service interface
@@ -25,12 +37,13 @@ type Service interface {
 	GenerateOTP(ctx context.Context, req *SendOTPRequest) (*OTPResponse, error)
 	VerifyOTP(ctx context.Context, req *VerifyOTPRequest) error
 	ResendOTP(ctx context.Context, req *ResendOTPRequest) (*OTPResponse, error)
-	CleanupExpiredOTPs(ctx context.Context) error
+	CleanupExpiredOTPs(ctx context.Context) (int64, error)
 }
 
 // service implements the OTP service
 type service struct {
 	repo          Repository
+	redis         *redis.Client // nil limits sends per destination only, from Postgres
 	emailProvider EmailProvider
 	smsProvider   SMSProvider
 	config        *OTPConfig
@@ -39,6 +52,7 @@ type service struct {
 // NewService creates a new OTP service
 func NewService(
 	repo Repository,
+	redis *redis.Client,
 	emailProvider EmailProvider,
 	smsProvider SMSProvider,
 	config *OTPConfig,
@@ -50,14 +64,18 @@ func NewService(
 			Expiry:      10 * time.Minute,
 			MaxAttempts: 3,
 			RateLimit: RateLimitConfig{
-				MaxRequests: 3,
-				Window:      time.Hour,
+				MaxRequests:     3,
+				Window:          time.Hour,
+				LockoutAfter:    5,
+				LockoutDuration: 15 * time.Minute,
+				MaxLockout:      24 * time.Hour,
 			},
 		}
 	}
 
 	return &service{
 		repo:          repo,
+		redis:         redis,
 		emailProvider: emailProvider,
 		smsProvider:   smsProvider,
 		config:        config,
@@ -66,14 +84,15 @@ func NewService(
 
 // GenerateOTP generates and sends a new OTP
 func (s *service) GenerateOTP(ctx context.Context, req *SendOTPRequest) (*OTPResponse, error) {
-	// Check rate limit
-	count, err := s.repo.CountRecentOTPs(ctx, req.UserID, s.config.RateLimit.Window)
-	if err != nil {
-		return nil, fmt.Errorf("failed to check rate limit: %w", err)
+	// Determine recipient
+	recipient := req.Email
+	if req.Method == DeliveryMethodSMS {
+		recipient = req.Phone
 	}
 
-	if count >= s.config.RateLimit.MaxRequests {
-		return nil, ErrRateLimitExceeded
+	// Check rate limits
+	if err := s.checkSendLimits(ctx, recipient); err != nil {
+		return nil, err
 	}
 
 	// Invalidate any existing OTPs of the same type
@@ -87,12 +106,6 @@ func (s *service) GenerateOTP(ctx context.Context, req *SendOTPRequest) (*OTPRes
 		return nil, fmt.Errorf("failed to generate OTP code: %w", err)
 	}
 
-	// Determine recipient
-	recipient := req.Email
-	if req.Method == DeliveryMethodSMS {
-		recipient = req.Phone
-	}
-
 	// Create OTP record
 	otp := &OTP{
 		UserID:    req.UserID,
@@ -104,6 +117,7 @@ func (s *service) GenerateOTP(ctx context.Context, req *SendOTPRequest) (*OTPRes
 		Verified:  false,
 		ExpiresAt: time.Now().Add(s.config.Expiry),
 		CreatedAt: time.Now(),
+		Language:  i18n.Match(req.Language, i18n.FromContext(ctx)),
 	}
 
 	// Save OTP to database
@@ -143,6 +157,11 @@ func (s *service) VerifyOTP(ctx context.Context, req *VerifyOTPRequest) error {
 		return fmt.Errorf("failed to get OTP: %w", err)
 	}
 
+	// Refuse every code while the destination is locked out
+	if err := s.checkLockout(ctx, otp.Recipient); err != nil {
+		return err
+	}
+
 	// Check if OTP is already verified
 	if otp.Verified {
 		return ErrOTPAlreadyUsed
@@ -166,8 +185,12 @@ func (s *service) VerifyOTP(ctx context.Context, req *VerifyOTPRequest) error {
 
 	// Verify code
 	if otp.Code != req.Code {
+		if err := s.recordFailure(ctx, otp.Recipient); err != nil {
+			return err
+		}
 		return ErrOTPInvalid
 	}
+	s.clearFailures(ctx, otp.Recipient)
 
 	// Mark as verified
 	now := time.Now()
@@ -185,19 +208,23 @@ func (s *service) VerifyOTP(ctx context.Context, req *VerifyOTPRequest) error {
 func (s *service) ResendOTP(ctx context.Context, req *ResendOTPRequest) (*OTPResponse, error) {
 	// Convert ResendOTPRequest to SendOTPRequest
 	sendReq := &SendOTPRequest{
-		UserID: req.UserID,
-		Email:  req.Email,
-		Phone:  req.Phone,
-		Type:   req.Type,
-		Method: req.Method,
+		UserID:   req.UserID,
+		Email:    req.Email,
+		Phone:    req.Phone,
+		Type:     req.Type,
+		Method:   req.Method,
+		Language: req.Language,
 	}
 
 	return s.GenerateOTP(ctx, sendReq)
 }
 
-// CleanupExpiredOTPs removes expired OTPs from the database
-func (s *service) CleanupExpiredOTPs(ctx context.Context) error {
-	return s.repo.DeleteExpiredOTPs(ctx, time.Now())
+// CleanupExpiredOTPs removes expired OTPs from the database and returns the
+// number of rows purged
+func (s *service) CleanupExpiredOTPs(ctx context.Context) (int64, error) {
+	purged, err := s.repo.DeleteExpiredOTPs(ctx, time.Now(), cleanupBatchSize)
+	otpRowsPurged.Add(float64(purged))
+	return purged, err
 }
 
 // generateCode generates a random numeric code
@@ -234,17 +261,29 @@ func (s *service) sendOTPEmail(ctx context.Context, otp *OTP) error {
 		return errors.New("email provider not configured")
 	}
 
-	subject := s.getEmailSubject(otp.Type)
+	subject := s.getEmailSubject(otp.Type, otp.Language)
+	minutes := int(s.config.Expiry.Minutes())
+	
+	data := map[string]interface{}{
+		"Title":     subject,
+		"Preheader": i18n.T(otp.Language, "email.otp.preheader", i18n.Vars{"code": otp.Code}),
+		"Code":      otp.Code,
+		"Type":      string(otp.Type),
+		"Minutes":   minutes,
+	}
+	
+	msg, err := email.Render("otp", otp.Language, data)
+	if err != nil {
+		return err
+	}
 	
 	template := &EmailTemplate{
 		To:           otp.Recipient,
-		Subject:      subject,
-		TemplateName: "otp_verification",
-		Data: map[string]interface{}{
-			"code":      otp.Code,
-			"type":      string(otp.Type),
-			"expiresIn": int(s.config.Expiry.Minutes()),
-		},
+		Subject:      msg.Subject,
+		TemplateName: "otp",
+		Data:         data,
+		HTML:         msg.HTML,
+		Text:         msg.Text,
 	}
 
 	return s.emailProvider.SendEmail(ctx, template)
@@ -256,8 +295,10 @@ func (s *service) sendOTPSMS(ctx context.Context, otp *OTP) error {
 		return errors.New("SMS provider not configured")
 	}
 
-	message := fmt.Sprintf("Your verification code is: %s. It will expire in %d minutes.",
-		otp.Code, int(s.config.Expiry.Minutes()))
+	message := i18n.T(otp.Language, "otp.sms.body", i18n.Vars{
+		"code":    otp.Code,
+		"minutes": int(s.config.Expiry.Minutes()),
+	})
 
 	sms := &SMSMessage{
 		To:      otp.Recipient,
@@ -267,18 +308,11 @@ func (s *service) sendOTPSMS(ctx context.Context, otp *OTP) error {
 	return s.smsProvider.SendSMS(ctx, sms)
 }
 
-// getEmailSubject returns the email subject based on OTP type
-func (s *service) getEmailSubject(otpType OTPType) string {
-	subjects := map[OTPType]string{
-		OTPTypeSignup:        "Verify Your Account",
-		OTPTypeSignin:        "Two-Factor Authentication Code",
-		OTPTypePasswordReset: "Password Reset Code",
-		OTPTypePhoneVerify:   "Verify Your Phone Number",
-		OTPTypeEmailVerify:   "Verify Your Email Address",
+// getEmailSubject returns the email subject based on OTP type, in lang
+func (s *service) getEmailSubject(otpType OTPType, lang string) string {
+	key := "otp.subject." + string(otpType)
+	if _, ok := i18n.Lookup(lang, key); !ok {
+		key = "otp.subject.default"
 	}
-
-	if subject, ok := subjects[otpType]; ok {
-		return subject
-	}
-	return "Verification Code"
-}
\ No newline at end of file
+	return i18n.T(lang, key, nil)
+}
@@ -0,0 +1,88 @@
+// internal/password/breach.go
+
+package password
+
+import (
+    "bufio"
+    "context"
+    "crypto/sha1"
+    "encoding/hex"
+    "fmt"
+    "net/http"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// BreachChecker reports how often a password appears in known breaches
+type BreachChecker interface {
+    BreachCount(ctx context.Context, password string) (int, error)
+}
+
+// RangeClient checks passwords against a HaveIBeenPwned-compatible range
+// API using k-anonymity: only the first five characters of the password's
+// SHA-1 hash are sent, and the match is made locally. BaseURL can point at
+// a self-hosted mirror of the dataset.
+type RangeClient struct {
+    baseURL string
+    client  *http.Client
+}
+
+// DefaultRangeURL is the public Pwned Passwords API
+const DefaultRangeURL = "https://api.pwnedpasswords.com"
+
+// NewRangeClient creates a client that gives up after timeout
+func NewRangeClient(baseURL string, timeout time.Duration) *RangeClient {
+    if baseURL == "" {
+        baseURL = DefaultRangeURL
+    }
+    if timeout <= 0 {
+        timeout = 2 * time.Second
+    }
+
+    return &RangeClient{
+        baseURL: strings.TrimRight(baseURL, "/"),
+        client:  &http.Client{Timeout: timeout},
+    }
+}
+
+func (c *RangeClient) BreachCount(ctx context.Context, password string) (int, error) {
+    sum := sha1.Sum([]byte(password))
+    hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+    prefix, suffix := hash[:5], hash[5:]
+
+    req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/range/"+prefix, nil)
+    if err != nil {
+        return 0, err
+    }
+    // Padding hides how many suffixes share the prefix
+    req.Header.Set("Add-Padding", "true")
+    req.Header.Set("User-Agent", "kiekky-backend")
+
+    resp, err := c.client.Do(req)
+    if err != nil {
+        return 0, fmt.Errorf("range request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    if resp.StatusCode != http.StatusOK {
+        return 0, fmt.Errorf("range request returned %d", resp.StatusCode)
+    }
+
+    // Each line is SUFFIX:COUNT; padding entries have a count of 0
+    scanner := bufio.NewScanner(resp.Body)
+    for scanner.Scan() {
+        line := strings.TrimSpace(scanner.Text())
+        parts := strings.SplitN(line, ":", 2)
+        if len(parts) != 2 || !strings.EqualFold(parts[0], suffix) {
+            continue
+        }
+        count, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+        if err != nil {
+            return 0, fmt.Errorf("invalid range response: %w", err)
+        }
+        return count, nil
+    }
+
+    return 0, scanner.Err()
+}
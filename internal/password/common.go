@@ -0,0 +1,37 @@
+// internal/password/common.go
+
+package password
+
+// commonPasswordList is ordered most common first; a password's rank is
+// roughly how many guesses an attacker needs to reach it
+var commonPasswordList = []string{
+    "123456", "password", "12345678", "qwerty", "123456789", "12345", "1234", "111111",
+    "1234567", "dragon", "123123", "baseball", "abc123", "football", "monkey", "letmein",
+    "696969", "shadow", "master", "666666", "qwertyuiop", "123321", "mustang", "1234567890",
+    "michael", "654321", "superman", "1qaz2wsx", "7777777", "121212", "000000", "qazwsx",
+    "123qwe", "killer", "trustno1", "jordan", "jennifer", "zxcvbnm", "asdfgh", "hunter",
+    "buster", "soccer", "harley", "batman", "andrew", "tigger", "sunshine", "iloveyou",
+    "2000", "charlie", "robert", "thomas", "hockey", "ranger", "daniel", "starwars",
+    "klaster", "112233", "george", "computer", "michelle", "jessica", "pepper", "1111",
+    "zxcvbn", "555555", "11111111", "131313", "freedom", "777777", "pass", "maggie",
+    "159753", "aaaaaa", "ginger", "princess", "joshua", "cheese", "amanda", "summer",
+    "love", "ashley", "nicole", "chelsea", "biteme", "matthew", "access", "yankees",
+    "987654321", "dallas", "austin", "thunder", "taylor", "matrix", "welcome", "admin",
+    "login", "passw0rd", "password1", "qwerty123", "hello", "secret", "loveme", "flower",
+    "whatever", "lovely", "angel", "babygirl", "princess1", "samsung", "nigeria", "lagos",
+    "kiekky", "dating", "single", "sexy", "friends", "family", "blessed", "jesus",
+    "god", "money", "naija", "football1", "chocolate", "butterfly", "liverpool", "arsenal",
+    "manchester", "chelsea1", "barcelona", "realmadrid", "purple", "orange", "banana", "cookie",
+}
+
+// commonPasswords maps each common password, with substitutions undone, to its rank
+var commonPasswords = func() map[string]int {
+    ranks := make(map[string]int, len(commonPasswordList))
+    for i, p := range commonPasswordList {
+        key := string(unleet([]rune(p)))
+        if _, ok := ranks[key]; !ok {
+            ranks[key] = i + 1
+        }
+    }
+    return ranks
+}()
@@ -0,0 +1,109 @@
+// internal/password/policy.go
+// Password policy for signup and password reset
+//
+// A password must be long enough, score well on a zxcvbn-style strength
+// estimate and not appear in a known data breach. The breach check is
+// best-effort: if the range API is slow or down the password is accepted.
+
+package password
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+)
+
+var ErrWeakPassword = errors.New("password does not meet requirements")
+
+// WeakPasswordError explains why a password was refused. It matches
+// ErrWeakPassword with errors.Is.
+type WeakPasswordError struct {
+    Score       int      `json:"score"` // 0 (weakest) to 4
+    Reasons     []string `json:"reasons"`
+    Suggestions []string `json:"suggestions,omitempty"`
+    Breached    bool     `json:"breached,omitempty"`
+}
+
+func (e *WeakPasswordError) Error() string {
+    return fmt.Sprintf("%s: %s", ErrWeakPassword, strings.Join(e.Reasons, "; "))
+}
+
+func (e *WeakPasswordError) Is(target error) bool {
+    return target == ErrWeakPassword
+}
+
+// Config configures the policy
+type Config struct {
+    MinLength int
+    // MinScore is the lowest acceptable strength score, 0-4
+    MinScore int
+}
+
+// Policy checks new passwords
+type Policy struct {
+    minLength int
+    minScore  int
+    breaches  BreachChecker
+}
+
+// NewPolicy creates a password policy. breaches may be nil to skip the
+// breach check.
+func NewPolicy(cfg Config, breaches BreachChecker) *Policy {
+    if cfg.MinLength <= 0 {
+        cfg.MinLength = 8
+    }
+    if cfg.MinScore < 0 || cfg.MinScore > 4 {
+        cfg.MinScore = 2
+    }
+
+    return &Policy{
+        minLength: cfg.MinLength,
+        minScore:  cfg.MinScore,
+        breaches:  breaches,
+    }
+}
+
+// Check returns a *WeakPasswordError if the password is refused. userInputs
+// are things like the username and email, which make a password easy to
+// guess for anyone who knows the account.
+func (p *Policy) Check(ctx context.Context, password string, userInputs ...string) error {
+    strength := Estimate(password, userInputs...)
+    weak := &WeakPasswordError{
+        Score:       strength.Score,
+        Suggestions: strength.Suggestions,
+    }
+
+    if len([]rune(password)) < p.minLength {
+        weak.Reasons = append(weak.Reasons, fmt.Sprintf("must be at least %d characters", p.minLength))
+    }
+    if strength.Score < p.minScore {
+        reason := "is too easy to guess"
+        if strength.Warning != "" {
+            reason = strength.Warning
+        }
+        weak.Reasons = append(weak.Reasons, reason)
+    }
+    if len(weak.Reasons) > 0 {
+        return weak
+    }
+
+    if p.breaches == nil {
+        return nil
+    }
+
+    count, err := p.breaches.BreachCount(ctx, password)
+    if err != nil {
+        log.Printf("Skipping breached password check: %v", err)
+        return nil
+    }
+    if count > 0 {
+        weak.Breached = true
+        weak.Reasons = append(weak.Reasons, "has appeared in a data breach")
+        weak.Suggestions = append(weak.Suggestions, "Choose a password you haven't used on other sites")
+        return weak
+    }
+
+    return nil
+}
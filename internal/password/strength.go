@@ -0,0 +1,358 @@
+// internal/password/strength.go
+
+package password
+
+import (
+    "math"
+    "strings"
+    "unicode"
+)
+
+// Strength is a zxcvbn-style estimate of how hard a password is to guess
+type Strength struct {
+    // Score is 0 (guessable in under a thousand tries) to 4 (over ten billion)
+    Score int
+    // GuessesLog10 is the estimated number of guesses, as log10
+    GuessesLog10 float64
+    Warning      string
+    Suggestions  []string
+}
+
+// Pattern kinds, in the order their warnings take precedence
+const (
+    patternDictionary = "dictionary"
+    patternUserInput  = "user_input"
+    patternKeyboard   = "keyboard"
+    patternSequence   = "sequence"
+    patternRepeat     = "repeat"
+    patternYear       = "year"
+)
+
+var warnings = map[string]string{
+    patternDictionary: "is a commonly used password",
+    patternUserInput:  "contains your name, username or email",
+    patternKeyboard:   "uses a keyboard pattern that is easy to guess",
+    patternSequence:   "uses a sequence like abc or 6543 that is easy to guess",
+    patternRepeat:     "uses repeated characters like aaa that are easy to guess",
+    patternYear:       "contains a year, which is easy to guess",
+}
+
+// bruteForceCardinality is the guesses per character not covered by a
+// pattern, as in zxcvbn
+const bruteForceCardinality = 10
+
+// match is a guessable pattern covering password[start:end] (in runes)
+type match struct {
+    start, end   int
+    kind         string
+    guessesLog10 float64
+}
+
+// Estimate scores a password by finding the cheapest way to guess it as a
+// series of common words, names, keyboard patterns, sequences, repeats,
+// years and brute-forced characters
+func Estimate(password string, userInputs ...string) Strength {
+    runes := []rune(password)
+    if len(runes) == 0 {
+        return Strength{Warning: "is empty"}
+    }
+
+    lower := toLower(runes)
+    normalized := unleet(lower)
+
+    var matches []match
+    matches = append(matches, dictionaryMatches(runes, normalized, commonPasswords, patternDictionary)...)
+    matches = append(matches, dictionaryMatches(runes, normalized, userInputRanks(userInputs), patternUserInput)...)
+    matches = append(matches, keyboardMatches(lower)...)
+    matches = append(matches, sequenceMatches(lower)...)
+    matches = append(matches, repeatMatches(lower)...)
+    matches = append(matches, yearMatches(lower)...)
+
+    // Cheapest guess sequence covering the whole password
+    n := len(runes)
+    best := make([]float64, n+1)
+    via := make([]*match, n+1)
+    for i := 1; i <= n; i++ {
+        best[i] = best[i-1] + math.Log10(bruteForceCardinality)
+        via[i] = nil
+        for k := range matches {
+            m := &matches[k]
+            if m.end != i {
+                continue
+            }
+            // Each extra pattern in the sequence is another choice to guess
+            cost := best[m.start] + m.guessesLog10 + math.Log10(float64(m.start+1))
+            if cost < best[i] {
+                best[i] = cost
+                via[i] = m
+            }
+        }
+    }
+
+    strength := Strength{GuessesLog10: best[n]}
+    strength.Score = scoreFor(best[n])
+
+    // The warning comes from the pattern covering most of the password
+    covered := make(map[string]int)
+    for i := n; i > 0; {
+        if m := via[i]; m != nil {
+            covered[m.kind] += m.end - m.start
+            i = m.start
+        } else {
+            i--
+        }
+    }
+    if strength.Score <= 2 {
+        mostCovered := 0
+        for _, kind := range []string{patternDictionary, patternUserInput, patternKeyboard, patternSequence, patternRepeat, patternYear} {
+            if covered[kind] > mostCovered {
+                mostCovered = covered[kind]
+                strength.Warning = warnings[kind]
+            }
+        }
+    }
+    strength.Suggestions = suggestionsFor(runes, strength.Score)
+
+    return strength
+}
+
+// scoreFor maps guesses to zxcvbn's 0-4 score
+func scoreFor(guessesLog10 float64) int {
+    switch {
+    case guessesLog10 < 3:
+        return 0
+    case guessesLog10 < 6:
+        return 1
+    case guessesLog10 < 8:
+        return 2
+    case guessesLog10 < 10:
+        return 3
+    }
+    return 4
+}
+
+func suggestionsFor(runes []rune, score int) []string {
+    if score >= 3 {
+        return nil
+    }
+
+    suggestions := []string{"Use a few words together, avoiding common phrases"}
+    upper, other := 0, 0
+    for i, r := range runes {
+        switch {
+        case unicode.IsUpper(r) && i > 0:
+            upper++
+        case !unicode.IsLetter(r):
+            other++
+        }
+    }
+    if upper == 0 {
+        suggestions = append(suggestions, "Capitals help most away from the first letter")
+    }
+    if other == 0 {
+        suggestions = append(suggestions, "Numbers and symbols in unexpected places make it harder to guess")
+    }
+    return suggestions
+}
+
+// variationsLog10 accounts for capitals and substitutions in a dictionary
+// match: "Password" and "p@ssword" are tried soon after "password"
+func variationsLog10(original, lower, normalized []rune) float64 {
+    extra := 0.0
+    upper := 0
+    for i, r := range original {
+        if unicode.IsUpper(r) && i > 0 {
+            upper++
+        }
+    }
+    if upper > 0 {
+        extra += math.Log10(float64(upper + 1))
+    } else if unicode.IsUpper(original[0]) {
+        extra += math.Log10(2)
+    }
+    if string(lower) != string(normalized) && strings.IndexFunc(string(normalized), unicode.IsLetter) >= 0 {
+        extra += math.Log10(2)
+    }
+    return extra
+}
+
+func dictionaryMatches(runes, normalized []rune, ranks map[string]int, kind string) []match {
+    if len(ranks) == 0 {
+        return nil
+    }
+
+    lower := toLower(runes)
+    var matches []match
+    for i := 0; i < len(normalized); i++ {
+        for j := i + 3; j <= len(normalized); j++ {
+            rank, ok := ranks[string(normalized[i:j])]
+            if !ok {
+                continue
+            }
+            matches = append(matches, match{
+                start:        i,
+                end:          j,
+                kind:         kind,
+                guessesLog10: math.Log10(float64(rank)) + variationsLog10(runes[i:j], lower[i:j], normalized[i:j]),
+            })
+        }
+    }
+    return matches
+}
+
+// userInputRanks turns account details into dictionary words, so
+// "jane.doe@mail.com" makes "jane" and "doe" cheap to guess
+func userInputRanks(inputs []string) map[string]int {
+    ranks := make(map[string]int)
+    rank := 1
+    for _, input := range inputs {
+        input = strings.ToLower(input)
+        words := strings.FieldsFunc(input, func(r rune) bool {
+            return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+        })
+        for _, word := range append([]string{input}, words...) {
+            if len([]rune(word)) < 3 {
+                continue
+            }
+            key := string(unleet([]rune(word)))
+            if _, ok := ranks[key]; !ok {
+                ranks[key] = rank
+                rank++
+            }
+        }
+    }
+    return ranks
+}
+
+var keyboardRows = []string{
+    "`1234567890-=", "qwertyuiop[]\\", "asdfghjkl;'", "zxcvbnm,./",
+    "qazwsxedcrfvtgbyhnujmikolp", "1qaz2wsx3edc4rfv5tgb6yhn7ujm8ik9ol0p",
+}
+
+// keyboardMatches finds runs of four or more adjacent keys
+func keyboardMatches(lower []rune) []match {
+    var matches []match
+    for i := range lower {
+        for j := len(lower); j >= i+4; j-- {
+            chunk := string(lower[i:j])
+            found := false
+            for _, row := range keyboardRows {
+                if strings.Contains(row, chunk) || strings.Contains(reverse(row), chunk) {
+                    found = true
+                    break
+                }
+            }
+            if found {
+                matches = append(matches, match{start: i, end: j, kind: patternKeyboard, guessesLog10: math.Log10(float64(40 * (j - i)))})
+                break
+            }
+        }
+    }
+    return matches
+}
+
+// sequenceMatches finds runs like abcd, 1234 or 9876
+func sequenceMatches(lower []rune) []match {
+    var matches []match
+    for i := 0; i < len(lower)-2; {
+        delta := lower[i+1] - lower[i]
+        if delta != 1 && delta != -1 {
+            i++
+            continue
+        }
+        j := i + 1
+        for j < len(lower) && lower[j]-lower[j-1] == delta && sameClass(lower[j], lower[i]) {
+            j++
+        }
+        if j-i >= 3 {
+            base := 26.0
+            if unicode.IsDigit(lower[i]) {
+                base = 10
+            }
+            if strings.ContainsRune("a1z9", lower[i]) {
+                base = 4
+            }
+            guesses := base * float64(j-i)
+            if delta < 0 {
+                guesses *= 2
+            }
+            matches = append(matches, match{start: i, end: j, kind: patternSequence, guessesLog10: math.Log10(guesses)})
+        }
+        i = j
+    }
+    return matches
+}
+
+// repeatMatches finds a character repeated three or more times
+func repeatMatches(lower []rune) []match {
+    var matches []match
+    for i := 0; i < len(lower); {
+        j := i + 1
+        for j < len(lower) && lower[j] == lower[i] {
+            j++
+        }
+        if j-i >= 3 {
+            matches = append(matches, match{start: i, end: j, kind: patternRepeat, guessesLog10: math.Log10(float64(bruteForceCardinality * (j - i)))})
+        }
+        i = j
+    }
+    return matches
+}
+
+// yearMatches finds years from 1900 to 2039
+func yearMatches(lower []rune) []match {
+    var matches []match
+    for i := 0; i+4 <= len(lower); i++ {
+        chunk := string(lower[i : i+4])
+        if (strings.HasPrefix(chunk, "19") || strings.HasPrefix(chunk, "20")) && isDigits(chunk) && chunk < "2040" {
+            matches = append(matches, match{start: i, end: i + 4, kind: patternYear, guessesLog10: 2})
+        }
+    }
+    return matches
+}
+
+var leetSubstitutions = map[rune]rune{
+    '4': 'a', '@': 'a', '8': 'b', '3': 'e', '6': 'g', '1': 'i', '!': 'i',
+    '0': 'o', '5': 's', '$': 's', '7': 't', '+': 't', '2': 'z',
+}
+
+func unleet(lower []rune) []rune {
+    out := make([]rune, len(lower))
+    for i, r := range lower {
+        if sub, ok := leetSubstitutions[r]; ok {
+            r = sub
+        }
+        out[i] = r
+    }
+    return out
+}
+
+// toLower lowercases rune by rune, keeping positions aligned with the original
+func toLower(runes []rune) []rune {
+    out := make([]rune, len(runes))
+    for i, r := range runes {
+        out[i] = unicode.ToLower(r)
+    }
+    return out
+}
+
+func sameClass(a, b rune) bool {
+    return unicode.IsDigit(a) == unicode.IsDigit(b) && unicode.IsLetter(a) == unicode.IsLetter(b)
+}
+
+func isDigits(s string) bool {
+    for _, r := range s {
+        if !unicode.IsDigit(r) {
+            return false
+        }
+    }
+    return true
+}
+
+func reverse(s string) string {
+    r := []rune(s)
+    for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+        r[i], r[j] = r[j], r[i]
+    }
+    return string(r)
+}
@@ -0,0 +1,43 @@
+// internal/posts/counters.go
+// Like and comment counts are stored on each post and kept current by
+// database triggers, so feeds read them instead of counting per row. A
+// periodic reconciliation corrects any drift.
+package posts
+
+import "context"
+
+// Repository
+
+// ReconcileCounts recounts every post's likes and live comments and fixes
+// the stored counts that drifted. It returns how many posts were corrected.
+func (r *Repository) ReconcileCounts() (int64, error) {
+	query := `
+		UPDATE posts p
+		SET likes_count = actual.likes, comments_count = actual.comments
+		FROM (
+			SELECT
+				p.id,
+				(SELECT COUNT(*) FROM post_likes l WHERE l.post_id = p.id) AS likes,
+				(SELECT COUNT(*) FROM comments c WHERE c.post_id = p.id AND c.deleted_at IS NULL) AS comments
+			FROM posts p
+		) actual
+		WHERE p.id = actual.id
+		  AND (p.likes_count <> actual.likes OR p.comments_count <> actual.comments)`
+
+	result, err := r.db.Exec(query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// Service
+
+// ReconcileCounts corrects stored like and comment counts against the likes
+// and comments themselves
+func (s *Service) ReconcileCounts(ctx context.Context) (int64, error) {
+	if err := ctx.Err(); err != nil {
+		return 0, err
+	}
+	return s.repo.ReconcileCounts()
+}
@@ -0,0 +1,314 @@
+// internal/posts/drafts.go
+// Drafts: posts still being composed. They live apart from posts, so no
+// feed can ever show them, and media uploaded for them is tracked so files
+// from abandoned drafts can be removed.
+package posts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"log"
+	"mime/multipart"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+const (
+	// MaxDrafts is how many drafts a user can keep
+	MaxDrafts = 50
+
+	// DraftRetention is how long an untouched draft is kept
+	DraftRetention = 30 * 24 * time.Hour
+
+	// draftUploadGrace keeps fresh uploads around while a client is still
+	// attaching them to a draft
+	draftUploadGrace = 24 * time.Hour
+
+	maxPostMedia = 10
+)
+
+var (
+	ErrDraftNotFound = errors.New("draft not found")
+	ErrTooManyDrafts = errors.New("you can keep at most 50 drafts")
+	ErrTooManyMedia  = errors.New("maximum 10 media files allowed per post")
+)
+
+// Repository
+
+const draftColumns = `id, user_id, caption, location, visibility, media_urls, created_at, updated_at`
+
+func scanDraft(row interface{ Scan(...interface{}) error }, draft *Draft) error {
+	return row.Scan(
+		&draft.ID, &draft.UserID, &draft.Caption, &draft.Location, &draft.Visibility,
+		pq.Array(&draft.MediaURLs), &draft.CreatedAt, &draft.UpdatedAt,
+	)
+}
+
+// CreateDraft saves a new draft unless the user already has MaxDrafts
+func (r *Repository) CreateDraft(draft *Draft) error {
+	query := `
+		INSERT INTO post_drafts (user_id, caption, location, visibility, media_urls, created_at, updated_at)
+		SELECT $1, $2, $3, $4, $5, NOW(), NOW()
+		WHERE (SELECT COUNT(*) FROM post_drafts WHERE user_id = $1) < $6
+		RETURNING id, created_at, updated_at`
+
+	err := r.db.QueryRow(query, draft.UserID, draft.Caption, draft.Location, draft.Visibility,
+		pq.Array(draft.MediaURLs), MaxDrafts).
+		Scan(&draft.ID, &draft.CreatedAt, &draft.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrTooManyDrafts
+	}
+	return err
+}
+
+func (r *Repository) GetDraft(draftID, userID int64) (*Draft, error) {
+	query := `SELECT ` + draftColumns + ` FROM post_drafts WHERE id = $1 AND user_id = $2`
+
+	var draft Draft
+	err := scanDraft(r.db.QueryRow(query, draftID, userID), &draft)
+	if err == sql.ErrNoRows {
+		return nil, ErrDraftNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
+
+// GetDrafts returns the user's drafts, most recently edited first
+func (r *Repository) GetDrafts(userID int64) ([]Draft, error) {
+	query := `SELECT ` + draftColumns + ` FROM post_drafts WHERE user_id = $1 ORDER BY updated_at DESC`
+
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	drafts := []Draft{}
+	for rows.Next() {
+		var draft Draft
+		if err := scanDraft(rows, &draft); err != nil {
+			return nil, err
+		}
+		drafts = append(drafts, draft)
+	}
+	return drafts, rows.Err()
+}
+
+// UpdateDraft replaces the draft's contents
+func (r *Repository) UpdateDraft(draft *Draft) error {
+	query := `
+		UPDATE post_drafts
+		SET caption = $3, location = $4, visibility = $5, media_urls = $6, updated_at = NOW()
+		WHERE id = $1 AND user_id = $2
+		RETURNING created_at, updated_at`
+
+	err := r.db.QueryRow(query, draft.ID, draft.UserID, draft.Caption, draft.Location, draft.Visibility,
+		pq.Array(draft.MediaURLs)).
+		Scan(&draft.CreatedAt, &draft.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return ErrDraftNotFound
+	}
+	return err
+}
+
+func (r *Repository) DeleteDraft(draftID, userID int64) error {
+	result, err := r.db.Exec(`DELETE FROM post_drafts WHERE id = $1 AND user_id = $2`, draftID, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := result.RowsAffected(); n == 0 {
+		return ErrDraftNotFound
+	}
+	return nil
+}
+
+// AddDraftUpload records a file uploaded for a draft
+func (r *Repository) AddDraftUpload(draftID, userID int64, url string) error {
+	query := `
+		INSERT INTO post_draft_uploads (url, user_id, draft_id, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (url) DO NOTHING`
+	_, err := r.db.Exec(query, url, userID, draftID)
+	return err
+}
+
+// DeleteStaleDrafts deletes drafts not edited since before
+func (r *Repository) DeleteStaleDrafts(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM post_drafts WHERE updated_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ReleaseDraftUploads returns the files uploaded before before that no
+// draft or post uses any more, and stops tracking them along with uploads
+// that were published with a post
+func (r *Repository) ReleaseDraftUploads(before time.Time) ([]string, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Published files belong to their post now
+	_, err = tx.Exec(`
+		DELETE FROM post_draft_uploads u
+		WHERE u.created_at < $1
+		  AND EXISTS(SELECT 1 FROM post_media pm WHERE pm.media_url = u.url)`, before)
+	if err != nil {
+		return nil, err
+	}
+
+	orphaned, err := queryStrings(tx, `
+		DELETE FROM post_draft_uploads u
+		WHERE u.created_at < $1
+		  AND NOT EXISTS(
+			SELECT 1 FROM post_drafts d
+			WHERE d.id = u.draft_id AND u.url = ANY(d.media_urls)
+		  )
+		RETURNING u.url`, before)
+	if err != nil {
+		return nil, err
+	}
+
+	return orphaned, tx.Commit()
+}
+
+// Service
+
+// CreateDraft saves a partly composed post
+func (s *Service) CreateDraft(userID int64, req *DraftRequest) (*Draft, error) {
+	draft := req.toDraft()
+	draft.UserID = userID
+
+	if err := s.repo.CreateDraft(draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+func (s *Service) GetDraft(draftID, userID int64) (*Draft, error) {
+	return s.repo.GetDraft(draftID, userID)
+}
+
+func (s *Service) GetDrafts(userID int64) ([]Draft, error) {
+	return s.repo.GetDrafts(userID)
+}
+
+// UpdateDraft replaces a draft with the client's current composition
+func (s *Service) UpdateDraft(draftID, userID int64, req *DraftRequest) (*Draft, error) {
+	draft := req.toDraft()
+	draft.ID = draftID
+	draft.UserID = userID
+
+	if err := s.repo.UpdateDraft(draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// DeleteDraft discards a draft. Media uploaded for it is removed by the
+// draft cleanup job.
+func (s *Service) DeleteDraft(draftID, userID int64) error {
+	return s.repo.DeleteDraft(draftID, userID)
+}
+
+// AddDraftMedia uploads a file and attaches it to the draft
+func (s *Service) AddDraftMedia(ctx context.Context, draftID, userID int64, file multipart.File, header *multipart.FileHeader) (*Draft, error) {
+	draft, err := s.repo.GetDraft(draftID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(draft.MediaURLs) >= maxPostMedia {
+		return nil, ErrTooManyMedia
+	}
+
+	url, err := s.UploadMedia(ctx, file, header)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.repo.AddDraftUpload(draftID, userID, url); err != nil {
+		return nil, err
+	}
+
+	draft.MediaURLs = append(draft.MediaURLs, url)
+	if err := s.repo.UpdateDraft(draft); err != nil {
+		return nil, err
+	}
+	return draft, nil
+}
+
+// PublishDraft turns the draft into a post, published now or at publishAt,
+// and discards the draft
+func (s *Service) PublishDraft(draftID, userID int64, publishAt *time.Time) (*Post, error) {
+	draft, err := s.repo.GetDraft(draftID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	post, err := s.CreatePost(userID, &CreatePostRequest{
+		Caption:    draft.Caption,
+		Location:   draft.Location,
+		Visibility: draft.Visibility,
+		MediaURLs:  draft.MediaURLs,
+		PublishAt:  publishAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.DeleteDraft(draftID, userID); err != nil {
+		log.Printf("Failed to delete published draft %d: %v", draftID, err)
+	}
+	return post, nil
+}
+
+// CleanupDrafts deletes drafts untouched for DraftRetention and removes
+// uploaded files that no draft or post uses
+func (s *Service) CleanupDrafts(ctx context.Context) (drafts, files int64, err error) {
+	now := time.Now()
+
+	drafts, err = s.repo.DeleteStaleDrafts(now.Add(-DraftRetention))
+	if err != nil {
+		return 0, 0, err
+	}
+
+	orphaned, err := s.repo.ReleaseDraftUploads(now.Add(-draftUploadGrace))
+	if err != nil {
+		return drafts, 0, err
+	}
+
+	for _, url := range orphaned {
+		if ctx.Err() != nil {
+			return drafts, files, ctx.Err()
+		}
+		if !s.uploadService.IsStoredFile(url) {
+			continue
+		}
+		if err := s.uploadService.DeleteFile(url); err != nil {
+			log.Printf("Failed to delete abandoned draft media %s: %v", url, err)
+			continue
+		}
+		files++
+	}
+
+	return drafts, files, nil
+}
+
+func (req *DraftRequest) toDraft() *Draft {
+	draft := &Draft{
+		Caption:    req.Caption,
+		Location:   req.Location,
+		Visibility: req.Visibility,
+		MediaURLs:  req.MediaURLs,
+	}
+	if draft.MediaURLs == nil {
+		draft.MediaURLs = []string{}
+	}
+	return draft
+}
@@ -0,0 +1,21 @@
+// internal/posts/events.go
+// Posts tells other modules what happened through domain events rather
+// than calling them, see internal/events
+package posts
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+// SetEvents sets the bus post events are published on
+func (s *Service) SetEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+// publish stores an event about postID, in the transaction in ctx
+func (s *Service) publish(ctx context.Context, postID int64, event events.Event) error {
+	return s.events.Publish(ctx, strconv.FormatInt(postID, 10), event)
+}
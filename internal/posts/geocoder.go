@@ -0,0 +1,128 @@
+// internal/posts/geocoder.go
+// External place lookup for location tagging
+package posts
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Geocoder finds places by name with an external provider. Results are
+// saved to the places table, so posts only ever reference local places.
+type Geocoder interface {
+	Search(ctx context.Context, query string, near *Coordinates, limit int) ([]Place, error)
+}
+
+// NominatimGeocoder searches OpenStreetMap through a Nominatim server
+type NominatimGeocoder struct {
+	baseURL   string
+	userAgent string
+	client    *http.Client
+}
+
+// NewNominatimGeocoder creates a geocoder for the Nominatim server at baseURL
+func NewNominatimGeocoder(baseURL, userAgent string) Geocoder {
+	return &NominatimGeocoder{
+		baseURL:   strings.TrimRight(baseURL, "/"),
+		userAgent: userAgent,
+		client:    &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+type nominatimResult struct {
+	OSMType     string `json:"osm_type"`
+	OSMID       int64  `json:"osm_id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"display_name"`
+	Lat         string `json:"lat"`
+	Lon         string `json:"lon"`
+	Address     struct {
+		City        string `json:"city"`
+		Town        string `json:"town"`
+		Village     string `json:"village"`
+		CountryCode string `json:"country_code"`
+	} `json:"address"`
+}
+
+// Search looks query up, preferring results around near when it is set
+func (g *NominatimGeocoder) Search(ctx context.Context, query string, near *Coordinates, limit int) ([]Place, error) {
+	params := url.Values{}
+	params.Set("q", query)
+	params.Set("format", "jsonv2")
+	params.Set("addressdetails", "1")
+	params.Set("limit", strconv.Itoa(limit))
+	if near != nil {
+		// Roughly 50km around the user; a bias, not a filter
+		params.Set("viewbox", fmt.Sprintf("%f,%f,%f,%f",
+			near.Longitude-0.5, near.Latitude+0.5, near.Longitude+0.5, near.Latitude-0.5))
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, g.baseURL+"/search?"+params.Encode(), nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", g.userAgent)
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("nominatim request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("nominatim returned %s", resp.Status)
+	}
+
+	var results []nominatimResult
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("failed to decode nominatim response: %w", err)
+	}
+
+	places := make([]Place, 0, len(results))
+	for _, result := range results {
+		lat, latErr := strconv.ParseFloat(result.Lat, 64)
+		lng, lngErr := strconv.ParseFloat(result.Lon, 64)
+		if latErr != nil || lngErr != nil {
+			continue
+		}
+
+		name := result.Name
+		if name == "" {
+			name = strings.SplitN(result.DisplayName, ",", 2)[0]
+		}
+		city := result.Address.City
+		if city == "" {
+			city = result.Address.Town
+		}
+		if city == "" {
+			city = result.Address.Village
+		}
+
+		places = append(places, Place{
+			Name:       truncate(name, 255),
+			Address:    result.DisplayName,
+			City:       truncate(city, 100),
+			Country:    strings.ToUpper(result.Address.CountryCode),
+			Latitude:   lat,
+			Longitude:  lng,
+			Provider:   "nominatim",
+			ProviderID: fmt.Sprintf("%s:%d", result.OSMType, result.OSMID),
+		})
+	}
+	return places, nil
+}
+
+func truncate(s string, max int) string {
+	runes := []rune(s)
+	if len(runes) <= max {
+		return s
+	}
+	return string(runes[:max])
+}
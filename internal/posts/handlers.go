@@ -2,28 +2,54 @@
 package posts
 
 import (
-	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 	
 	"github.com/gorilla/mux"
+	"github.com/imadgeboyega/kiekky-backend/internal/auth"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
 	"github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+	"github.com/imadgeboyega/kiekky-backend/internal/moderation"
+	"github.com/imadgeboyega/kiekky-backend/internal/textfilter"
 )
 
+// defaultMaxUploadSize caps a post's media until SetUploadLimit is called
+const defaultMaxUploadSize = 100 << 20
+
 type Handler struct {
-	service *Service
+	service       *Service
+	maxUploadSize int64
 }
 
 func NewHandler(service *Service) *Handler {
-	return &Handler{service: service}
+	return &Handler{
+		service:       service,
+		maxUploadSize: defaultMaxUploadSize,
+	}
+}
+
+// SetUploadLimit caps the media sent with a post or draft, all files
+// together, in bytes
+func (h *Handler) SetUploadLimit(maxBytes int64) {
+	h.maxUploadSize = maxBytes
 }
 
 func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	// Parse multipart form for file uploads
-	err := r.ParseMultipartForm(10 << 20) // 10 MB max
+	err := utils.ParseUpload(w, r, h.maxUploadSize)
+	if utils.IsTooLarge(err) {
+		utils.ErrorResponse(w, utils.UploadTooLargeMessage(h.maxUploadSize), http.StatusRequestEntityTooLarge)
+		return
+	}
 	if err != nil && err != http.ErrNotMultipart {
 		utils.ErrorResponse(w, "Failed to parse form", http.StatusBadRequest)
 		return
@@ -34,8 +60,7 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 	// Handle JSON or form data
 	contentType := r.Header.Get("Content-Type")
 	if strings.Contains(contentType, "application/json") {
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+		if !utils.DecodeAndValidate(w, r, &req) {
 			return
 		}
 	} else {
@@ -43,6 +68,30 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 		req.Caption = r.FormValue("caption")
 		req.Location = r.FormValue("location")
 		req.Visibility = r.FormValue("visibility")
+		if v := r.FormValue("publish_at"); v != "" {
+			publishAt, err := time.Parse(time.RFC3339, v)
+			if err != nil {
+				utils.ErrorResponse(w, "publish_at must be an RFC 3339 timestamp", http.StatusBadRequest)
+				return
+			}
+			req.PublishAt = &publishAt
+		}
+		if v := r.FormValue("place_id"); v != "" {
+			placeID, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				utils.ErrorResponse(w, "Invalid place ID", http.StatusBadRequest)
+				return
+			}
+			req.PlaceID = &placeID
+		}
+		if r.FormValue("latitude") != "" || r.FormValue("longitude") != "" {
+			coords, ok := parseCoordinates(r.FormValue("latitude"), r.FormValue("longitude"))
+			if !ok {
+				utils.ErrorResponse(w, "latitude and longitude must be valid coordinates given together", http.StatusBadRequest)
+				return
+			}
+			req.Latitude, req.Longitude = &coords.Latitude, &coords.Longitude
+		}
 		
 		// Handle file uploads
 		if r.MultipartForm != nil && r.MultipartForm.File != nil {
@@ -55,14 +104,25 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 				defer file.Close()
 				
 				// Upload file and get URL
-				url, err := h.service.UploadMedia(file, fileHeader)
+				url, err := h.service.UploadMedia(r.Context(), file, fileHeader)
 				if err != nil {
+					if moderation.RespondUploadError(w, err) {
+						return
+					}
+					if errors.Is(err, ErrFileTooLarge) {
+						utils.ErrorResponse(w, err.Error(), http.StatusRequestEntityTooLarge)
+						return
+					}
 					utils.ErrorResponse(w, "Failed to upload media", http.StatusInternalServerError)
 					return
 				}
 				req.MediaURLs = append(req.MediaURLs, url)
 			}
 		}
+		
+		if !utils.Validate(w, r, &req) {
+			return
+		}
 	}
 	
 	post, err := h.service.CreatePost(userID, &req)
@@ -75,7 +135,11 @@ func (h *Handler) CreatePost(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	vars := mux.Vars(r)
 	postID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -98,7 +162,11 @@ func (h *Handler) GetPost(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	vars := mux.Vars(r)
 	postID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -108,8 +176,7 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	var req UpdatePostRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 	
@@ -117,6 +184,8 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		if err.Error() == "unauthorized to update this post" {
 			utils.ErrorResponse(w, err.Error(), http.StatusForbidden)
+		} else if errors.Is(err, textfilter.ErrContentRejected) {
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
 		} else {
 			utils.ErrorResponse(w, "Failed to update post", http.StatusInternalServerError)
 		}
@@ -127,7 +196,11 @@ func (h *Handler) UpdatePost(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	vars := mux.Vars(r)
 	postID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -146,11 +219,487 @@ func (h *Handler) DeletePost(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 	
-	utils.SuccessResponse(w, map[string]string{"message": "Post deleted successfully"}, http.StatusOK)
+	utils.SuccessResponse(w, map[string]interface{}{
+		"message":        "Post moved to trash",
+		"retention_days": int(TrashRetention.Hours() / 24),
+	}, http.StatusOK)
+}
+
+// GetScheduledPosts lists the user's scheduled posts, soonest first
+func (h *Handler) GetScheduledPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit := h.getPagination(r)
+	
+	scheduled, err := h.service.GetScheduledPosts(userID, page, limit)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to get scheduled posts", http.StatusInternalServerError)
+		return
+	}
+	
+	utils.SuccessResponse(w, scheduled, http.StatusOK)
+}
+
+// UpdateScheduledPost edits a scheduled post or moves its publish time
+func (h *Handler) UpdateScheduledPost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	
+	var req UpdateScheduledPostRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	
+	post, err := h.service.UpdateScheduledPost(postID, userID, &req)
+	if err != nil {
+		switch {
+		case err == ErrPostNotFound:
+			utils.ErrorResponse(w, "Scheduled post not found", http.StatusNotFound)
+		case err == ErrInvalidPublishTime, errors.Is(err, textfilter.ErrContentRejected):
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		default:
+			utils.ErrorResponse(w, "Failed to update scheduled post", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, post, http.StatusOK)
+}
+
+// CancelScheduledPost stops a scheduled post from going out
+func (h *Handler) CancelScheduledPost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	
+	if err := h.service.CancelScheduledPost(postID, userID); err != nil {
+		if err == ErrPostNotFound {
+			utils.ErrorResponse(w, "Scheduled post not found", http.StatusNotFound)
+		} else {
+			utils.ErrorResponse(w, "Failed to cancel scheduled post", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, map[string]string{"message": "Scheduled post cancelled"}, http.StatusOK)
+}
+
+// RestorePost takes a post out of the trash
+func (h *Handler) RestorePost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	
+	post, err := h.service.RestorePost(postID, userID)
+	if err != nil {
+		if err == ErrPostNotFound {
+			utils.ErrorResponse(w, "Post not found in trash", http.StatusNotFound)
+		} else {
+			utils.ErrorResponse(w, "Failed to restore post", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, post, http.StatusOK)
+}
+
+// GetDeletedPosts lists the user's posts in the trash
+func (h *Handler) GetDeletedPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit := h.getPagination(r)
+	
+	trash, err := h.service.GetDeletedPosts(userID, page, limit)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to get deleted posts", http.StatusInternalServerError)
+		return
+	}
+	
+	utils.SuccessResponse(w, trash, http.StatusOK)
+}
+
+// CreateDraft saves a post being composed
+func (h *Handler) CreateDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	var req DraftRequest
+	if r.ContentLength > 0 && !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	
+	draft, err := h.service.CreateDraft(userID, &req)
+	if err != nil {
+		if err == ErrTooManyDrafts {
+			utils.ErrorResponse(w, err.Error(), http.StatusConflict)
+		} else {
+			utils.ErrorResponse(w, "Failed to save draft", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, draft, http.StatusCreated)
+}
+
+// GetDrafts lists the user's drafts, most recently edited first
+func (h *Handler) GetDrafts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	drafts, err := h.service.GetDrafts(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to get drafts", http.StatusInternalServerError)
+		return
+	}
+	
+	utils.SuccessResponse(w, map[string]interface{}{
+		"drafts":         drafts,
+		"max_drafts":     MaxDrafts,
+		"retention_days": int(DraftRetention.Hours() / 24),
+	}, http.StatusOK)
+}
+
+func (h *Handler) GetDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	draftID, ok := parseDraftID(w, r)
+	if !ok {
+		return
+	}
+	
+	draft, err := h.service.GetDraft(draftID, userID)
+	if err != nil {
+		respondWithDraftError(w, err, "Failed to get draft")
+		return
+	}
+	
+	utils.SuccessResponse(w, draft, http.StatusOK)
+}
+
+// UpdateDraft replaces the draft with the client's current composition
+func (h *Handler) UpdateDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	draftID, ok := parseDraftID(w, r)
+	if !ok {
+		return
+	}
+	
+	var req DraftRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	
+	draft, err := h.service.UpdateDraft(draftID, userID, &req)
+	if err != nil {
+		respondWithDraftError(w, err, "Failed to save draft")
+		return
+	}
+	
+	utils.SuccessResponse(w, draft, http.StatusOK)
+}
+
+func (h *Handler) DeleteDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	draftID, ok := parseDraftID(w, r)
+	if !ok {
+		return
+	}
+	
+	if err := h.service.DeleteDraft(draftID, userID); err != nil {
+		respondWithDraftError(w, err, "Failed to delete draft")
+		return
+	}
+	
+	utils.SuccessResponse(w, map[string]string{"message": "Draft deleted"}, http.StatusOK)
+}
+
+// AddDraftMedia uploads a file (multipart field "media") to a draft
+func (h *Handler) AddDraftMedia(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	draftID, ok := parseDraftID(w, r)
+	if !ok {
+		return
+	}
+	
+	if err := utils.ParseUpload(w, r, h.maxUploadSize); err != nil {
+		if utils.IsTooLarge(err) {
+			utils.ErrorResponse(w, utils.UploadTooLargeMessage(h.maxUploadSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+	
+	file, header, err := r.FormFile("media")
+	if err != nil {
+		utils.ErrorResponse(w, "No media file provided", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	
+	draft, err := h.service.AddDraftMedia(r.Context(), draftID, userID, file, header)
+	if err != nil {
+		if moderation.RespondUploadError(w, err) {
+			return
+		}
+		respondWithDraftError(w, err, "Failed to upload media")
+		return
+	}
+	
+	utils.SuccessResponse(w, draft, http.StatusOK)
+}
+
+// PublishDraft posts the draft now, or schedules it when publish_at is set
+func (h *Handler) PublishDraft(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	draftID, ok := parseDraftID(w, r)
+	if !ok {
+		return
+	}
+	
+	var req PublishDraftRequest
+	if r.ContentLength > 0 && !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	
+	post, err := h.service.PublishDraft(draftID, userID, req.PublishAt)
+	if err != nil {
+		if err == ErrDraftNotFound {
+			utils.ErrorResponse(w, "Draft not found", http.StatusNotFound)
+		} else {
+			// CreatePost only fails validation or filtering in ways the user can fix
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, post, http.StatusCreated)
+}
+
+// SearchPlaces autocompletes place names for tagging, closest first when
+// lat and lng are given
+func (h *Handler) SearchPlaces(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	
+	q := strings.TrimSpace(query.Get("q"))
+	if len([]rune(q)) > 100 {
+		utils.ErrorResponse(w, "Query is too long", http.StatusBadRequest)
+		return
+	}
+	
+	var near *Coordinates
+	if query.Get("lat") != "" || query.Get("lng") != "" {
+		coords, ok := parseCoordinates(query.Get("lat"), query.Get("lng"))
+		if !ok {
+			utils.ErrorResponse(w, "lat and lng must be valid coordinates given together", http.StatusBadRequest)
+			return
+		}
+		near = coords
+	}
+	
+	limit, _ := strconv.Atoi(query.Get("limit"))
+	if limit <= 0 || limit > MaxPlaceResults {
+		limit = 10
+	}
+	
+	places, err := h.service.SearchPlaces(r.Context(), q, near, limit)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to search places", http.StatusInternalServerError)
+		return
+	}
+	
+	utils.SuccessResponse(w, map[string]interface{}{
+		"query":  q,
+		"places": places,
+	}, http.StatusOK)
+}
+
+func (h *Handler) GetPlace(w http.ResponseWriter, r *http.Request) {
+	placeID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid place ID", http.StatusBadRequest)
+		return
+	}
+	
+	place, err := h.service.GetPlace(placeID)
+	if err != nil {
+		if err == ErrPlaceNotFound {
+			utils.ErrorResponse(w, "Place not found", http.StatusNotFound)
+		} else {
+			utils.ErrorResponse(w, "Failed to get place", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, place, http.StatusOK)
+}
+
+// GetPlacePosts lists public posts tagged at a place, newest first
+func (h *Handler) GetPlacePosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit := h.getPagination(r)
+	
+	placeID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid place ID", http.StatusBadRequest)
+		return
+	}
+	
+	feed, err := h.service.GetPlacePosts(placeID, userID, page, limit)
+	if err != nil {
+		if err == ErrPlaceNotFound {
+			utils.ErrorResponse(w, "Place not found", http.StatusNotFound)
+		} else {
+			utils.ErrorResponse(w, "Failed to get place posts", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, feed, http.StatusOK)
+}
+
+// GetNearbyPosts lists public posts near lat/lng (or the user's saved
+// location) within radius_km, closest first
+func (h *Handler) GetNearbyPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit := h.getPagination(r)
+	query := r.URL.Query()
+	
+	var near *Coordinates
+	if query.Get("lat") != "" || query.Get("lng") != "" {
+		coords, ok := parseCoordinates(query.Get("lat"), query.Get("lng"))
+		if !ok {
+			utils.ErrorResponse(w, "lat and lng must be valid coordinates given together", http.StatusBadRequest)
+			return
+		}
+		near = coords
+	}
+	
+	radiusKm, _ := strconv.ParseFloat(query.Get("radius_km"), 64)
+	
+	feed, err := h.service.GetNearbyPosts(userID, near, radiusKm, page, limit)
+	if err != nil {
+		if err == ErrLocationRequired {
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		} else {
+			utils.ErrorResponse(w, "Failed to get nearby posts", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, feed, http.StatusOK)
+}
+
+// parseCoordinates parses a latitude and longitude pair
+func parseCoordinates(latStr, lngStr string) (*Coordinates, bool) {
+	lat, latErr := strconv.ParseFloat(latStr, 64)
+	lng, lngErr := strconv.ParseFloat(lngStr, 64)
+	if latErr != nil || lngErr != nil || lat < -90 || lat > 90 || lng < -180 || lng > 180 {
+		return nil, false
+	}
+	return &Coordinates{Latitude: lat, Longitude: lng}, true
+}
+
+func parseDraftID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	draftID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid draft ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return draftID, true
+}
+
+func respondWithDraftError(w http.ResponseWriter, err error, fallback string) {
+	switch err {
+	case ErrDraftNotFound:
+		utils.ErrorResponse(w, "Draft not found", http.StatusNotFound)
+	case ErrTooManyMedia:
+		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+	case ErrFileTooLarge:
+		utils.ErrorResponse(w, err.Error(), http.StatusRequestEntityTooLarge)
+	default:
+		utils.ErrorResponse(w, fallback, http.StatusInternalServerError)
+	}
 }
 
 func (h *Handler) LikePost(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	vars := mux.Vars(r)
 	postID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -174,7 +723,11 @@ func (h *Handler) LikePost(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) UnlikePost(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	vars := mux.Vars(r)
 	postID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -209,6 +762,10 @@ func (h *Handler) GetPostLikes(w http.ResponseWriter, r *http.Request) {
 	
 	likes, pagination, err := h.service.GetPostLikes(postID, page, limit)
 	if err != nil {
+		if err == ErrPostNotFound {
+			utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		utils.ErrorResponse(w, "Failed to get likes", http.StatusInternalServerError)
 		return
 	}
@@ -222,7 +779,11 @@ func (h *Handler) GetPostLikes(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) AddComment(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	vars := mux.Vars(r)
 	postID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -232,13 +793,16 @@ func (h *Handler) AddComment(w http.ResponseWriter, r *http.Request) {
 	}
 	
 	var req CommentRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 	
 	comment, err := h.service.AddComment(postID, userID, &req)
 	if err != nil {
+		if err == ErrPostNotFound {
+			utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
 		return
 	}
@@ -258,6 +822,10 @@ func (h *Handler) GetPostComments(w http.ResponseWriter, r *http.Request) {
 	
 	comments, pagination, err := h.service.GetPostComments(postID, page, limit)
 	if err != nil {
+		if err == ErrPostNotFound {
+			utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+			return
+		}
 		utils.ErrorResponse(w, "Failed to get comments", http.StatusInternalServerError)
 		return
 	}
@@ -270,8 +838,89 @@ func (h *Handler) GetPostComments(w http.ResponseWriter, r *http.Request) {
 	utils.SuccessResponse(w, response, http.StatusOK)
 }
 
+func (h *Handler) DeleteComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	commentID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+	
+	if err := h.service.DeleteComment(commentID, userID); err != nil {
+		switch err {
+		case ErrCommentNotFound:
+			utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		case ErrCannotDeleteComment:
+			utils.ErrorResponse(w, err.Error(), http.StatusForbidden)
+		default:
+			utils.ErrorResponse(w, "Failed to delete comment", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, map[string]interface{}{
+		"message":        "Comment moved to trash",
+		"retention_days": int(TrashRetention.Hours() / 24),
+	}, http.StatusOK)
+}
+
+// RestoreComment takes a comment the user deleted out of the trash
+func (h *Handler) RestoreComment(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	commentID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid comment ID", http.StatusBadRequest)
+		return
+	}
+	
+	if err := h.service.RestoreComment(commentID, userID); err != nil {
+		if err == ErrCommentNotFound {
+			utils.ErrorResponse(w, "Comment not found in trash", http.StatusNotFound)
+		} else {
+			utils.ErrorResponse(w, "Failed to restore comment", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, map[string]string{"message": "Comment restored"}, http.StatusOK)
+}
+
+// GetDeletedComments lists the comments the user deleted that are in the trash
+func (h *Handler) GetDeletedComments(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit := h.getPagination(r)
+	
+	trash, err := h.service.GetDeletedComments(userID, page, limit)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to get deleted comments", http.StatusInternalServerError)
+		return
+	}
+	
+	utils.SuccessResponse(w, trash, http.StatusOK)
+}
+
 func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	page, limit := h.getPagination(r)
 	
 	feed, err := h.service.GetFeed(userID, page, limit)
@@ -283,11 +932,22 @@ func (h *Handler) GetFeed(w http.ResponseWriter, r *http.Request) {
 	utils.SuccessResponse(w, feed, http.StatusOK)
 }
 
+// GetExplorePosts returns trending posts, favouring posters in the viewer's
+// country (from CDN geolocation, or ?country= when set)
 func (h *Handler) GetExplorePosts(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("userID").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	page, limit := h.getPagination(r)
 	
-	explore, err := h.service.GetExplorePosts(userID, page, limit)
+	country := r.URL.Query().Get("country")
+	if country == "" {
+		country = middleware.ClientInfoFromContext(r.Context()).Country
+	}
+	
+	explore, err := h.service.GetExplorePosts(r.Context(), userID, country, page, limit)
 	if err != nil {
 		utils.ErrorResponse(w, "Failed to get explore posts", http.StatusInternalServerError)
 		return
@@ -297,7 +957,11 @@ func (h *Handler) GetExplorePosts(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) GetUserPosts(w http.ResponseWriter, r *http.Request) {
-	requestingUserID := r.Context().Value("userID").(int64)
+	requestingUserID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	vars := mux.Vars(r)
 	userID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -317,6 +981,140 @@ func (h *Handler) GetUserPosts(w http.ResponseWriter, r *http.Request) {
 	utils.SuccessResponse(w, posts, http.StatusOK)
 }
 
+func (h *Handler) Repost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	
+	// Body is optional; it only carries the reposter's own caption
+	var req RepostRequest
+	if r.ContentLength > 0 && !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	
+	post, err := h.service.Repost(postID, userID, &req)
+	if err != nil {
+		switch err {
+		case ErrPostNotFound:
+			utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		case ErrCannotRepost:
+			utils.ErrorResponse(w, err.Error(), http.StatusForbidden)
+		case ErrAlreadyReposted:
+			utils.ErrorResponse(w, err.Error(), http.StatusConflict)
+		default:
+			if errors.Is(err, textfilter.ErrContentRejected) {
+				utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			utils.ErrorResponse(w, "Failed to repost", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, post, http.StatusCreated)
+}
+
+func (h *Handler) SavePost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	
+	// Body is optional; without it the post is saved outside any collection
+	var req SavePostRequest
+	if r.ContentLength > 0 && !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+	
+	if err := h.service.SavePost(postID, userID, &req); err != nil {
+		if err == ErrPostNotFound {
+			utils.ErrorResponse(w, err.Error(), http.StatusNotFound)
+		} else if err == ErrCollectionNameTooLong {
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+		} else {
+			utils.ErrorResponse(w, "Failed to save post", http.StatusInternalServerError)
+		}
+		return
+	}
+	
+	utils.SuccessResponse(w, map[string]interface{}{
+		"saved":      true,
+		"collection": strings.TrimSpace(req.Collection),
+	}, http.StatusOK)
+}
+
+func (h *Handler) UnsavePost(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	vars := mux.Vars(r)
+	postID, err := strconv.ParseInt(vars["id"], 10, 64)
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid post ID", http.StatusBadRequest)
+		return
+	}
+	
+	if err := h.service.UnsavePost(postID, userID); err != nil {
+		utils.ErrorResponse(w, "Failed to unsave post", http.StatusInternalServerError)
+		return
+	}
+	
+	utils.SuccessResponse(w, map[string]bool{"saved": false}, http.StatusOK)
+}
+
+func (h *Handler) GetSavedPosts(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	page, limit := h.getPagination(r)
+	
+	saved, err := h.service.GetSavedPosts(userID, r.URL.Query().Get("collection"), page, limit)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to get saved posts", http.StatusInternalServerError)
+		return
+	}
+	
+	utils.SuccessResponse(w, saved, http.StatusOK)
+}
+
+func (h *Handler) GetSavedCollections(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+	
+	collections, err := h.service.GetSavedCollections(userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to get collections", http.StatusInternalServerError)
+		return
+	}
+	
+	utils.SuccessResponse(w, collections, http.StatusOK)
+}
+
 func (h *Handler) getPagination(r *http.Request) (int, int) {
 	page := 1
 	limit := 20
@@ -14,6 +14,11 @@ type Post struct {
 	Visibility    string         `json:"visibility"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     *time.Time     `json:"deleted_at,omitempty"` // Only set in the trash
+	
+	// Only set for the author: in scheduled post listings and the trash
+	Status        string         `json:"status,omitempty"`
+	PublishAt     *time.Time     `json:"publish_at,omitempty"`
 	
 	// Joined fields
 	User          *UserInfo      `json:"user,omitempty"`
@@ -21,6 +26,19 @@ type Post struct {
 	LikesCount    int            `json:"likes_count"`
 	CommentsCount int            `json:"comments_count"`
 	IsLiked       bool           `json:"is_liked"`
+	IsSaved       bool           `json:"is_saved"`
+	Collection    *string        `json:"collection,omitempty"` // Only set in saved posts listings
+	RepostsCount  int            `json:"reposts_count"`
+	
+	// Reposts reference the original post, which is embedded with its author
+	OriginalPostID *int64        `json:"original_post_id,omitempty"`
+	OriginalPost   *Post         `json:"original_post,omitempty"`
+	
+	// Tagged place and coordinates; DistanceKm is only set in the nearby feed
+	Place          *Place        `json:"place,omitempty"`
+	Latitude       *float64      `json:"latitude,omitempty"`
+	Longitude      *float64      `json:"longitude,omitempty"`
+	DistanceKm     *float64      `json:"distance_km,omitempty"`
 }
 
 type PostMedia struct {
@@ -51,26 +69,104 @@ type Comment struct {
 	ParentID  *int64     `json:"parent_id,omitempty"`
 	Content   string     `json:"content"`
 	CreatedAt time.Time  `json:"created_at"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"` // Only set in the trash
 	User      *UserInfo  `json:"user,omitempty"`
 	Replies   []Comment  `json:"replies,omitempty"`
 }
 
 type CreatePostRequest struct {
-	Caption    string   `json:"caption"`
-	Location   string   `json:"location,omitempty"`
-	Visibility string   `json:"visibility"`
-	MediaURLs  []string `json:"media_urls,omitempty"`
+	Caption    string     `json:"caption" validate:"required_without=MediaURLs,max=2200"`
+	Location   string     `json:"location,omitempty" validate:"omitempty,max=100"`
+	Visibility string     `json:"visibility" validate:"omitempty,oneof=public private followers"`
+	MediaURLs  []string   `json:"media_urls,omitempty" validate:"omitempty,max=10,dive,required"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"` // Schedules the post instead of publishing it now
+	
+	// A tagged place, or bare coordinates; a place's name fills in Location
+	PlaceID    *int64     `json:"place_id,omitempty"`
+	Latitude   *float64   `json:"latitude,omitempty" validate:"omitempty,latitude"`
+	Longitude  *float64   `json:"longitude,omitempty" validate:"omitempty,longitude"`
 }
 
 type UpdatePostRequest struct {
-	Caption    string `json:"caption,omitempty"`
-	Location   string `json:"location,omitempty"`
-	Visibility string `json:"visibility,omitempty"`
+	Caption    string `json:"caption,omitempty" validate:"omitempty,max=2200"`
+	Location   string `json:"location,omitempty" validate:"omitempty,max=100"`
+	Visibility string `json:"visibility,omitempty" validate:"omitempty,oneof=public private followers"`
+}
+
+// Coordinates is a point on the map
+type Coordinates struct {
+	Latitude  float64
+	Longitude float64
+}
+
+// Place is a taggable location. Places come from the geocoder and are
+// stored locally so posts can reference them.
+type Place struct {
+	ID         int64    `json:"id"`
+	Name       string   `json:"name"`
+	Address    string   `json:"address,omitempty"`
+	City       string   `json:"city,omitempty"`
+	Country    string   `json:"country,omitempty"`
+	Latitude   float64  `json:"latitude"`
+	Longitude  float64  `json:"longitude"`
+	PostsCount int      `json:"posts_count,omitempty"`
+	DistanceKm *float64 `json:"distance_km,omitempty"`
+	
+	Provider   string   `json:"-"`
+	ProviderID string   `json:"-"`
+}
+
+// Draft is a post being composed. It can be saved empty and picked up
+// again on another device.
+type Draft struct {
+	ID         int64     `json:"id"`
+	UserID     int64     `json:"user_id"`
+	Caption    string    `json:"caption"`
+	Location   string    `json:"location,omitempty"`
+	Visibility string    `json:"visibility,omitempty"`
+	MediaURLs  []string  `json:"media_urls"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// DraftRequest is a draft's full contents; saving replaces what was there
+type DraftRequest struct {
+	Caption    string   `json:"caption" validate:"max=2200"`
+	Location   string   `json:"location,omitempty" validate:"omitempty,max=100"`
+	Visibility string   `json:"visibility,omitempty" validate:"omitempty,oneof=public private followers"`
+	MediaURLs  []string `json:"media_urls,omitempty" validate:"omitempty,max=10,dive,required"`
+}
+
+// PublishDraftRequest publishes a draft now, or schedules it with PublishAt
+type PublishDraftRequest struct {
+	PublishAt *time.Time `json:"publish_at,omitempty"`
+}
+
+// UpdateScheduledPostRequest edits a post before it is published
+type UpdateScheduledPostRequest struct {
+	Caption    string     `json:"caption,omitempty" validate:"omitempty,max=2200"`
+	Location   string     `json:"location,omitempty" validate:"omitempty,max=100"`
+	Visibility string     `json:"visibility,omitempty" validate:"omitempty,oneof=public private followers"`
+	PublishAt  *time.Time `json:"publish_at,omitempty"`
+}
+
+type RepostRequest struct {
+	Caption string `json:"caption,omitempty" validate:"omitempty,max=2200"`
+}
+
+type SavePostRequest struct {
+	Collection string `json:"collection,omitempty" validate:"omitempty,max=50"`
+}
+
+type SavedCollection struct {
+	Name       string    `json:"name"`
+	PostsCount int       `json:"posts_count"`
+	UpdatedAt  time.Time `json:"updated_at"`
 }
 
 type CommentRequest struct {
-	Content  string `json:"content"`
-	ParentID *int64 `json:"parent_id,omitempty"`
+	Content  string `json:"content" validate:"required,max=1000"`
+	ParentID *int64 `json:"parent_id,omitempty" validate:"omitempty,gt=0"`
 }
 
 type PaginationParams struct {
@@ -88,4 +184,19 @@ type PaginationMeta struct {
 type FeedResponse struct {
 	Posts      []Post         `json:"posts"`
 	Pagination PaginationMeta `json:"pagination"`
+}
+
+// TrashResponse lists deleted posts or comments that can still be restored
+type TrashResponse struct {
+	Posts         []Post         `json:"posts,omitempty"`
+	Comments      []Comment      `json:"comments,omitempty"`
+	RetentionDays int            `json:"retention_days"`
+	Pagination    PaginationMeta `json:"pagination"`
+}
+
+// PurgeResult is what a trash purge removed
+type PurgeResult struct {
+	Posts      int64
+	Comments   int64
+	MediaFiles int64
 }
\ No newline at end of file
@@ -0,0 +1,513 @@
+// internal/posts/places.go
+// Location tagging: place search, posts at a place and the nearby feed
+package posts
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/blocks"
+	"github.com/imadgeboyega/kiekky-backend/internal/users"
+	"github.com/lib/pq"
+)
+
+const (
+	// DefaultNearbyRadiusKm and MaxNearbyRadiusKm bound the nearby feed
+	DefaultNearbyRadiusKm = 25.0
+	MaxNearbyRadiusKm     = 200.0
+
+	// MaxPlaceResults caps place search results
+	MaxPlaceResults = 20
+
+	// minGeocodeQuery is the shortest query sent to the geocoder, so
+	// autocomplete doesn't call it on every keystroke
+	minGeocodeQuery = 3
+
+	geocodeTimeout = 3 * time.Second
+)
+
+var (
+	ErrPlaceNotFound    = errors.New("place not found")
+	ErrLocationRequired = errors.New("location required: pass lat and lng or set your location")
+	ErrInvalidLocation  = errors.New("latitude and longitude must be given together")
+)
+
+// distanceSQL is the great-circle distance in km between two points given
+// as SQL expressions
+func distanceSQL(lat1, lng1, lat2, lng2 string) string {
+	return fmt.Sprintf(`(6371 * 2 * ASIN(SQRT(
+		POWER(SIN(RADIANS(%[3]s - %[1]s) / 2), 2) +
+		COS(RADIANS(%[1]s)) * COS(RADIANS(%[3]s)) * POWER(SIN(RADIANS(%[4]s - %[2]s) / 2), 2))))`,
+		lat1, lng1, lat2, lng2)
+}
+
+// boundingBox returns the lat/lng deltas of a box around lat enclosing
+// radiusKm, used to narrow distance queries to an index range
+func boundingBox(lat, radiusKm float64) (latDelta, lngDelta float64) {
+	latDelta = radiusKm / 111.0
+	lngDelta = radiusKm / (111.0 * math.Max(math.Cos(lat*math.Pi/180), 0.01))
+	return latDelta, lngDelta
+}
+
+// Repository
+
+const placeColumns = `pl.id, pl.name, COALESCE(pl.address, ''), COALESCE(pl.city, ''), COALESCE(pl.country, ''),
+		pl.latitude, pl.longitude`
+
+// SearchPlaces finds places whose name starts with, or has a word starting
+// with, query. Prefix matches come first, then the closest to near, then
+// the most posted.
+func (r *Repository) SearchPlaces(query string, near *Coordinates, limit int) ([]Place, error) {
+	pattern := escapeLike(query)
+
+	var lat, lng interface{}
+	distance := "NULL::float8"
+	order := "prefix DESC, posts_count DESC, pl.id"
+	if near != nil {
+		lat, lng = near.Latitude, near.Longitude
+		distance = distanceSQL("$3::float8", "$4::float8", "pl.latitude", "pl.longitude")
+		order = "prefix DESC, distance_km ASC, posts_count DESC"
+	}
+
+	sqlQuery := `
+		SELECT ` + placeColumns + `,
+			(SELECT COUNT(*) FROM posts p
+			 WHERE p.place_id = pl.id AND p.visibility = 'public' AND ` + livePost + `) as posts_count,
+			` + distance + ` as distance_km,
+			pl.name ILIKE $1 || '%' as prefix
+		FROM places pl
+		WHERE pl.name ILIKE $1 || '%' OR pl.name ILIKE '% ' || $1 || '%'
+		ORDER BY ` + order + `
+		LIMIT $2`
+
+	args := []interface{}{pattern, limit}
+	if near != nil {
+		args = append(args, lat, lng)
+	}
+
+	rows, err := r.db.Query(sqlQuery, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	places := []Place{}
+	for rows.Next() {
+		var place Place
+		var distanceKm sql.NullFloat64
+		var prefix bool
+		if err := rows.Scan(&place.ID, &place.Name, &place.Address, &place.City, &place.Country,
+			&place.Latitude, &place.Longitude, &place.PostsCount, &distanceKm, &prefix); err != nil {
+			return nil, err
+		}
+		if distanceKm.Valid {
+			place.DistanceKm = &distanceKm.Float64
+		}
+		places = append(places, place)
+	}
+	return places, rows.Err()
+}
+
+func (r *Repository) GetPlace(placeID int64) (*Place, error) {
+	query := `
+		SELECT ` + placeColumns + `,
+			(SELECT COUNT(*) FROM posts p
+			 WHERE p.place_id = pl.id AND p.visibility = 'public' AND ` + livePost + `) as posts_count
+		FROM places pl
+		WHERE pl.id = $1`
+
+	var place Place
+	err := r.db.QueryRow(query, placeID).Scan(&place.ID, &place.Name, &place.Address, &place.City,
+		&place.Country, &place.Latitude, &place.Longitude, &place.PostsCount)
+	if err == sql.ErrNoRows {
+		return nil, ErrPlaceNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &place, nil
+}
+
+// SavePlace stores a geocoder result, updating it if it was seen before,
+// and sets its ID
+func (r *Repository) SavePlace(place *Place) error {
+	query := `
+		INSERT INTO places (name, address, city, country, latitude, longitude, provider, provider_id, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		ON CONFLICT (provider, provider_id) DO UPDATE
+		SET name = EXCLUDED.name, address = EXCLUDED.address, city = EXCLUDED.city,
+		    country = EXCLUDED.country, latitude = EXCLUDED.latitude, longitude = EXCLUDED.longitude,
+		    updated_at = NOW()
+		RETURNING id`
+
+	return r.db.QueryRow(query, place.Name, place.Address, place.City, place.Country,
+		place.Latitude, place.Longitude, place.Provider, place.ProviderID).Scan(&place.ID)
+}
+
+// geoPostColumns selects a post for the place and nearby feeds; $1 is the viewer
+const geoPostColumns = `
+			p.id,
+			p.user_id,
+			p.caption,
+			COALESCE(p.location, '') as location,
+			p.visibility,
+			p.created_at,
+			p.updated_at,
+			u.username,
+			COALESCE(u.profile_picture, '') as profile_picture,
+			p.likes_count,
+			p.comments_count,
+			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $1) as is_liked,
+			EXISTS(SELECT 1 FROM saved_posts WHERE post_id = p.id AND user_id = $1) as is_saved,
+			p.original_post_id,
+			(SELECT COUNT(*) FROM posts rp WHERE rp.original_post_id = p.id AND rp.deleted_at IS NULL) as reposts_count,
+			p.latitude,
+			p.longitude`
+
+// GetPlacePosts returns public posts tagged at placeID, newest first
+func (r *Repository) GetPlacePosts(placeID, userID int64, limit, offset int) ([]Post, int, error) {
+	where := `
+		WHERE p.place_id = $2 AND p.visibility = 'public' AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id")
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM posts p JOIN users u ON p.user_id = u.id` + where
+	if err := r.db.QueryRow(countQuery, userID, placeID).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + geoPostColumns + `,
+			NULL::float8 as distance_km
+		FROM posts p
+		JOIN users u ON p.user_id = u.id` + where + `
+		ORDER BY p.created_at DESC
+		LIMIT $3 OFFSET $4`
+
+	posts, err := r.scanGeoPosts(query, userID, placeID, limit, offset)
+	return posts, total, err
+}
+
+// userCoordinates returns the user's saved location, or nil
+func (s *Service) userCoordinates(userID int64) (*Coordinates, error) {
+	user, err := s.users.Get(context.Background(), userID)
+	if errors.Is(err, users.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if user.Latitude == nil || user.Longitude == nil {
+		return nil, nil
+	}
+	return &Coordinates{Latitude: *user.Latitude, Longitude: *user.Longitude}, nil
+}
+
+// GetNearbyPosts returns public posts within radiusKm of near, closest first
+func (r *Repository) GetNearbyPosts(near Coordinates, radiusKm float64, userID int64, limit, offset int) ([]Post, int, error) {
+	latDelta, lngDelta := boundingBox(near.Latitude, radiusKm)
+	distance := distanceSQL("$2::float8", "$3::float8", "p.latitude", "p.longitude")
+
+	where := `
+		WHERE p.latitude BETWEEN $2::float8 - $5::float8 AND $2::float8 + $5::float8
+		  AND p.longitude BETWEEN $3::float8 - $6::float8 AND $3::float8 + $6::float8
+		  AND ` + distance + ` <= $4::float8
+		  AND p.visibility = 'public' AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id")
+	args := []interface{}{userID, near.Latitude, near.Longitude, radiusKm, latDelta, lngDelta}
+
+	var total int
+	countQuery := `SELECT COUNT(*) FROM posts p JOIN users u ON p.user_id = u.id` + where
+	if err := r.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+
+	query := `
+		SELECT ` + geoPostColumns + `,
+			` + distance + ` as distance_km
+		FROM posts p
+		JOIN users u ON p.user_id = u.id` + where + `
+		ORDER BY distance_km ASC, p.created_at DESC
+		LIMIT $7 OFFSET $8`
+
+	posts, err := r.scanGeoPosts(query, append(args, limit, offset)...)
+	return posts, total, err
+}
+
+func (r *Repository) scanGeoPosts(query string, args ...interface{}) ([]Post, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	posts := []Post{}
+	for rows.Next() {
+		post := Post{User: &UserInfo{}}
+		var locationStr string
+		var lat, lng, distance sql.NullFloat64
+
+		err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Caption,
+			&locationStr,
+			&post.Visibility,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+			&post.User.Username,
+			&post.User.ProfilePicture,
+			&post.LikesCount,
+			&post.CommentsCount,
+			&post.IsLiked,
+			&post.IsSaved,
+			&post.OriginalPostID,
+			&post.RepostsCount,
+			&lat,
+			&lng,
+			&distance,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if locationStr != "" {
+			post.Location = sql.NullString{String: locationStr, Valid: true}
+		}
+		if lat.Valid && lng.Valid {
+			post.Latitude, post.Longitude = &lat.Float64, &lng.Float64
+		}
+		if distance.Valid {
+			rounded := math.Round(distance.Float64*10) / 10
+			post.DistanceKm = &rounded
+		}
+		post.User.ID = post.UserID
+
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	r.attachMedia(posts)
+	return posts, nil
+}
+
+// AttachPlaces adds the tagged place and coordinates to each post
+func (r *Repository) AttachPlaces(posts []Post) {
+	if len(posts) == 0 {
+		return
+	}
+	ids := make([]int64, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+
+	query := `
+		SELECT p.id, p.latitude, p.longitude, pl.id, pl.name, COALESCE(pl.address, ''), COALESCE(pl.city, ''),
+			COALESCE(pl.country, ''), pl.latitude, pl.longitude
+		FROM posts p
+		LEFT JOIN places pl ON pl.id = p.place_id
+		WHERE p.id = ANY($1) AND (p.place_id IS NOT NULL OR p.latitude IS NOT NULL)`
+
+	rows, err := r.db.Query(query, pq.Array(ids))
+	if err != nil {
+		log.Printf("Failed to load post places: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	type geo struct {
+		lat, lng sql.NullFloat64
+		place    *Place
+	}
+	found := map[int64]geo{}
+	for rows.Next() {
+		var postID int64
+		var g geo
+		var placeID sql.NullInt64
+		var name, address, city, country sql.NullString
+		var placeLat, placeLng sql.NullFloat64
+		if err := rows.Scan(&postID, &g.lat, &g.lng, &placeID, &name, &address, &city, &country,
+			&placeLat, &placeLng); err != nil {
+			log.Printf("Failed to scan post place: %v", err)
+			return
+		}
+		if placeID.Valid {
+			g.place = &Place{
+				ID:        placeID.Int64,
+				Name:      name.String,
+				Address:   address.String,
+				City:      city.String,
+				Country:   country.String,
+				Latitude:  placeLat.Float64,
+				Longitude: placeLng.Float64,
+			}
+		}
+		found[postID] = g
+	}
+
+	for i := range posts {
+		g, ok := found[posts[i].ID]
+		if !ok {
+			continue
+		}
+		posts[i].Place = g.place
+		if g.lat.Valid && g.lng.Valid {
+			lat, lng := g.lat.Float64, g.lng.Float64
+			posts[i].Latitude, posts[i].Longitude = &lat, &lng
+		}
+	}
+}
+
+// Service
+
+// SetGeocoder lets place search fall back to an external geocoder
+func (s *Service) SetGeocoder(geocoder Geocoder) {
+	s.geocoder = geocoder
+}
+
+// SearchPlaces autocompletes place names. Known places are searched first;
+// when there aren't enough, the geocoder is asked and its results saved.
+func (s *Service) SearchPlaces(ctx context.Context, query string, near *Coordinates, limit int) ([]Place, error) {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []Place{}, nil
+	}
+
+	places, err := s.repo.SearchPlaces(query, near, limit)
+	if err != nil {
+		return nil, err
+	}
+	if len(places) >= limit || s.geocoder == nil || len([]rune(query)) < minGeocodeQuery {
+		return places, nil
+	}
+
+	geoCtx, cancel := context.WithTimeout(ctx, geocodeTimeout)
+	defer cancel()
+
+	found, err := s.geocoder.Search(geoCtx, query, near, limit)
+	if err != nil {
+		log.Printf("Geocoder search for %q failed: %v", query, err)
+		return places, nil
+	}
+
+	seen := make(map[int64]bool, len(places))
+	for _, place := range places {
+		seen[place.ID] = true
+	}
+	for _, place := range found {
+		if len(places) >= limit {
+			break
+		}
+		if err := s.repo.SavePlace(&place); err != nil {
+			log.Printf("Failed to save place %s %s: %v", place.Provider, place.ProviderID, err)
+			continue
+		}
+		if seen[place.ID] {
+			continue
+		}
+		seen[place.ID] = true
+		places = append(places, place)
+	}
+	return places, nil
+}
+
+func (s *Service) GetPlace(placeID int64) (*Place, error) {
+	return s.repo.GetPlace(placeID)
+}
+
+// GetPlacePosts returns public posts tagged at the place, newest first
+func (s *Service) GetPlacePosts(placeID, userID int64, page, limit int) (*FeedResponse, error) {
+	if _, err := s.repo.GetPlace(placeID); err != nil {
+		return nil, err
+	}
+
+	offset := (page - 1) * limit
+	posts, total, err := s.repo.GetPlacePosts(placeID, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	s.repo.AttachOriginalPosts(posts, userID)
+	s.repo.AttachPlaces(posts)
+
+	return &FeedResponse{
+		Posts: posts,
+		Pagination: PaginationMeta{
+			Page:    page,
+			Limit:   limit,
+			Total:   total,
+			HasNext: offset+limit < total,
+		},
+	}, nil
+}
+
+// GetNearbyPosts returns public posts within radiusKm, closest first. near
+// defaults to the user's saved location.
+func (s *Service) GetNearbyPosts(userID int64, near *Coordinates, radiusKm float64, page, limit int) (*FeedResponse, error) {
+	if near == nil {
+		var err error
+		near, err = s.userCoordinates(userID)
+		if err != nil {
+			return nil, err
+		}
+		if near == nil {
+			return nil, ErrLocationRequired
+		}
+	}
+	if radiusKm <= 0 {
+		radiusKm = DefaultNearbyRadiusKm
+	}
+	radiusKm = math.Min(radiusKm, MaxNearbyRadiusKm)
+
+	offset := (page - 1) * limit
+	posts, total, err := s.repo.GetNearbyPosts(*near, radiusKm, userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	s.repo.AttachOriginalPosts(posts, userID)
+	s.repo.AttachPlaces(posts)
+
+	return &FeedResponse{
+		Posts: posts,
+		Pagination: PaginationMeta{
+			Page:    page,
+			Limit:   limit,
+			Total:   total,
+			HasNext: offset+limit < total,
+		},
+	}, nil
+}
+
+// resolveLocation sets the post's place and coordinates from the request.
+// A place's coordinates win over the client's, and its name is used as the
+// location text when none was given.
+func (s *Service) resolveLocation(post *Post, req *CreatePostRequest) error {
+	if req.PlaceID != nil {
+		place, err := s.repo.GetPlace(*req.PlaceID)
+		if err != nil {
+			return err
+		}
+		post.Place = place
+		post.Latitude, post.Longitude = &place.Latitude, &place.Longitude
+		if !post.Location.Valid {
+			post.Location = sql.NullString{String: truncate(place.Name, 100), Valid: true}
+		}
+		return nil
+	}
+
+	if (req.Latitude == nil) != (req.Longitude == nil) {
+		return ErrInvalidLocation
+	}
+	post.Latitude, post.Longitude = req.Latitude, req.Longitude
+	return nil
+}
+
+// escapeLike escapes LIKE wildcards so user input matches literally
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
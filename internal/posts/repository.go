@@ -2,9 +2,35 @@
 package posts
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"strings"
+	"log"
+	"time"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/blocks"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/database"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/sqlbuilder"
+	"github.com/imadgeboyega/kiekky-backend/internal/users"
+	"github.com/lib/pq"
+)
+
+// Post statuses. Scheduled posts are only visible to their author until
+// the scheduler publishes them.
+const (
+	StatusPublished = "published"
+	StatusScheduled = "scheduled"
+)
+
+// livePost matches posts everyone may see: published and not in the trash
+const livePost = "p.deleted_at IS NULL AND p.status = '" + StatusPublished + "'"
+
+// Posts by deleted or suspended users are hidden along with them, as are
+// their likes; their comments stay, attributed to a ghost user
+var (
+	activeAuthor = users.ActiveSQL("u")
+	ghostAuthor  = users.UsernameSQL("u") + ` as username,
+		       COALESCE(` + users.ProfilePictureSQL("u") + `, '') as profile_picture`
 )
 
 type Repository struct {
@@ -15,36 +41,43 @@ func NewRepository(db *sql.DB) *Repository {
 	return &Repository{db: db}
 }
 
-func (r *Repository) CreatePost(post *Post) error {
+// CreatePost inserts post, within the transaction in ctx when there is one
+func (r *Repository) CreatePost(ctx context.Context, post *Post) error {
 	query := `
-		INSERT INTO posts (user_id, caption, location, visibility, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, NOW(), NOW())
+		INSERT INTO posts (user_id, caption, location, visibility, original_post_id, status, publish_at,
+			place_id, latitude, longitude, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NOW(), NOW())
 		RETURNING id, created_at, updated_at`
 	
-	err := r.db.QueryRow(query, post.UserID, post.Caption, post.Location, post.Visibility).
+	var placeID *int64
+	if post.Place != nil {
+		placeID = &post.Place.ID
+	}
+	
+	err := database.Conn(ctx, r.db).QueryRowContext(ctx, query, post.UserID, post.Caption, post.Location, post.Visibility, post.OriginalPostID,
+		post.Status, post.PublishAt, placeID, post.Latitude, post.Longitude).
 		Scan(&post.ID, &post.CreatedAt, &post.UpdatedAt)
 	return err
 }
 
-func (r *Repository) AddPostMedia(media []PostMedia) error {
+// AddPostMedia inserts media, within the transaction in ctx when there is one
+func (r *Repository) AddPostMedia(ctx context.Context, media []PostMedia) error {
 	if len(media) == 0 {
 		return nil
 	}
 	
-	valueStrings := make([]string, 0, len(media))
-	valueArgs := make([]interface{}, 0, len(media)*4)
-	
+	rows := make([][]interface{}, len(media))
 	for i, m := range media {
-		valueStrings = append(valueStrings, fmt.Sprintf("($%d, $%d, $%d, $%d)",
-			i*4+1, i*4+2, i*4+3, i*4+4))
-		valueArgs = append(valueArgs, m.PostID, m.MediaURL, m.MediaType, m.Position)
+		rows[i] = []interface{}{m.PostID, m.MediaURL, m.MediaType, m.Position}
 	}
 	
-	query := fmt.Sprintf(`
-		INSERT INTO post_media (post_id, media_url, media_type, position)
-		VALUES %s`, strings.Join(valueStrings, ","))
+	query, args, err := sqlbuilder.Insert("post_media",
+		[]string{"post_id", "media_url", "media_type", "position"}, rows)
+	if err != nil {
+		return err
+	}
 	
-	_, err := r.db.Exec(query, valueArgs...)
+	_, err = database.Conn(ctx, r.db).ExecContext(ctx, query, args...)
 	return err
 }
 
@@ -55,22 +88,23 @@ func (r *Repository) GetPostByID(postID, userID int64) (*Post, error) {
 			p.created_at, p.updated_at,
 			u.username, 
 			COALESCE(u.profile_picture, '') as profile_picture,  -- Handle NULL
-			COUNT(DISTINCT l.user_id) as likes_count,
-			COUNT(DISTINCT c.id) as comments_count,
-			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $2) as is_liked
+			p.likes_count,
+			p.comments_count,
+			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $2) as is_liked,
+			EXISTS(SELECT 1 FROM saved_posts WHERE post_id = p.id AND user_id = $2) as is_saved,
+			p.original_post_id,
+			(SELECT COUNT(*) FROM posts rp WHERE rp.original_post_id = p.id AND rp.deleted_at IS NULL) as reposts_count
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		LEFT JOIN post_likes l ON p.id = l.post_id
-		LEFT JOIN comments c ON p.id = c.post_id
-		WHERE p.id = $1
-		GROUP BY p.id, u.id, u.username, u.profile_picture --  Add to GROUP BY`
+		WHERE p.id = $1 AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$2", "p.user_id")
 	
 	post := &Post{User: &UserInfo{}}
 	err := r.db.QueryRow(query, postID, userID).Scan(
 		&post.ID, &post.UserID, &post.Caption, &post.Location, &post.Visibility,
 		&post.CreatedAt, &post.UpdatedAt,
 		&post.User.Username, &post.User.ProfilePicture,
-		&post.LikesCount, &post.CommentsCount, &post.IsLiked,
+		&post.LikesCount, &post.CommentsCount, &post.IsLiked, &post.IsSaved,
+		&post.OriginalPostID, &post.RepostsCount,
 	)
 	
 	if err != nil {
@@ -112,72 +146,384 @@ func (r *Repository) GetPostMedia(postID int64) ([]PostMedia, error) {
 	return media, nil
 }
 
+// GetPostsMedia loads the media of several posts in one query, keyed by post
+func (r *Repository) GetPostsMedia(postIDs []int64) (map[int64][]PostMedia, error) {
+	media := make(map[int64][]PostMedia, len(postIDs))
+	if len(postIDs) == 0 {
+		return media, nil
+	}
+	
+	query := `SELECT id, post_id, media_url, media_type, position 
+			  FROM post_media WHERE post_id = ANY($1) ORDER BY post_id, position`
+	
+	rows, err := r.db.Query(query, pq.Array(postIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	for rows.Next() {
+		var m PostMedia
+		if err := rows.Scan(&m.ID, &m.PostID, &m.MediaURL, &m.MediaType, &m.Position); err != nil {
+			return nil, err
+		}
+		media[m.PostID] = append(media[m.PostID], m)
+	}
+	
+	return media, rows.Err()
+}
+
+// attachMedia fills in the media of each post with one query. Posts keep no
+// media when it can't be loaded, rather than failing the whole listing.
+func (r *Repository) attachMedia(posts []Post) {
+	if len(posts) == 0 {
+		return
+	}
+	ids := make([]int64, len(posts))
+	for i := range posts {
+		ids[i] = posts[i].ID
+	}
+	
+	media, err := r.GetPostsMedia(ids)
+	if err != nil {
+		log.Printf("Failed to load post media: %v", err)
+		return
+	}
+	for i := range posts {
+		posts[i].Media = media[posts[i].ID]
+	}
+}
+
 func (r *Repository) UpdatePost(postID int64, update *UpdatePostRequest) error {
-	var setClauses []string
-	var args []interface{}
-	argCount := 1
+	stmt := sqlbuilder.NewUpdate("posts")
 	
 	if update.Caption != "" {
-		setClauses = append(setClauses, fmt.Sprintf("caption = $%d", argCount))
-		args = append(args, update.Caption)
-		argCount++
+		stmt.Set("caption", update.Caption)
 	}
 	
 	if update.Location != "" {
-		setClauses = append(setClauses, fmt.Sprintf("location = $%d", argCount))
-		args = append(args, update.Location)
-		argCount++
+		stmt.Set("location", update.Location)
 	}
 	
 	if update.Visibility != "" {
-		setClauses = append(setClauses, fmt.Sprintf("visibility = $%d", argCount))
-		args = append(args, update.Visibility)
-		argCount++
+		stmt.Set("visibility", update.Visibility)
 	}
 	
-	if len(setClauses) == 0 {
+	if !stmt.Changed() {
 		return nil
 	}
 	
-	setClauses = append(setClauses, "updated_at = NOW()")
-	args = append(args, postID)
-	
-	query := fmt.Sprintf("UPDATE posts SET %s WHERE id = $%d",
-		strings.Join(setClauses, ", "), argCount)
+	query, args, err := stmt.
+		SetSQL("updated_at", "NOW()").
+		Where("id = ?", postID).
+		Where("deleted_at IS NULL").
+		Build()
+	if err != nil {
+		return err
+	}
 	
-	_, err := r.db.Exec(query, args...)
+	_, err = r.db.Exec(query, args...)
 	return err
 }
 
+// DeletePost moves the post to the trash. Its media, likes and comments are
+// kept until the post is purged, so it can be restored intact.
 func (r *Repository) DeletePost(postID int64) error {
+	query := `UPDATE posts SET deleted_at = NOW() WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Exec(query, postID)
+	return err
+}
+
+// RestorePost takes the user's post out of the trash, if it was deleted
+// after since. A repost isn't restored once the user has reposted the same
+// post again. It reports whether the post was restored.
+func (r *Repository) RestorePost(postID, userID int64, since time.Time) (bool, error) {
+	query := `
+		UPDATE posts p SET deleted_at = NULL
+		WHERE p.id = $1 AND p.user_id = $2 AND p.deleted_at > $3
+		  AND NOT EXISTS(
+			SELECT 1 FROM posts rp
+			WHERE rp.original_post_id = p.original_post_id AND rp.user_id = p.user_id
+			  AND rp.deleted_at IS NULL
+		  )`
+	result, err := r.db.Exec(query, postID, userID, since)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetDeletedPosts returns the user's posts deleted after since, most
+// recently deleted first
+func (r *Repository) GetDeletedPosts(userID int64, since time.Time, limit, offset int) ([]Post, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM posts WHERE user_id = $1 AND deleted_at > $2`
+	if err := r.db.QueryRow(countQuery, userID, since).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	
+	query := `
+		SELECT id, user_id, caption, location, visibility, created_at, updated_at,
+		       deleted_at, original_post_id, status, publish_at
+		FROM posts
+		WHERE user_id = $1 AND deleted_at > $2
+		ORDER BY deleted_at DESC
+		LIMIT $3 OFFSET $4`
+	
+	rows, err := r.db.Query(query, userID, since, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	
+	posts := []Post{}
+	for rows.Next() {
+		var post Post
+		err := rows.Scan(
+			&post.ID, &post.UserID, &post.Caption, &post.Location, &post.Visibility,
+			&post.CreatedAt, &post.UpdatedAt, &post.DeletedAt, &post.OriginalPostID,
+			&post.Status, &post.PublishAt,
+		)
+		if err != nil {
+			return nil, 0, err
+		}
+		
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	
+	r.attachMedia(posts)
+	return posts, total, nil
+}
+
+// PurgeDeletedPosts permanently deletes up to limit posts deleted before
+// before, with their media, likes, comments and saves. It returns how many
+// were purged and the media URLs no remaining post uses, whose files can
+// now be removed.
+func (r *Repository) PurgeDeletedPosts(before time.Time, limit int) (int64, []string, error) {
 	tx, err := r.db.Begin()
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 	defer tx.Rollback()
 	
-	// Delete related data
-	_, err = tx.Exec("DELETE FROM post_media WHERE post_id = $1", postID)
+	rows, err := tx.Query(`
+		SELECT id FROM posts
+		WHERE deleted_at < $1
+		ORDER BY deleted_at
+		LIMIT $2
+		FOR UPDATE SKIP LOCKED`, before, limit)
 	if err != nil {
-		return err
+		return 0, nil, err
+	}
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			return 0, nil, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, nil, err
+	}
+	if len(ids) == 0 {
+		return 0, nil, nil
 	}
 	
-	_, err = tx.Exec("DELETE FROM post_likes WHERE post_id = $1", postID)
+	urls, err := queryStrings(tx, `SELECT DISTINCT media_url FROM post_media WHERE post_id = ANY($1)`, pq.Array(ids))
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 	
-	_, err = tx.Exec("DELETE FROM comments WHERE post_id = $1", postID)
+	// Media, likes, comments and saves cascade; reposts keep their own row
+	result, err := tx.Exec(`DELETE FROM posts WHERE id = ANY($1)`, pq.Array(ids))
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
+	purged, _ := result.RowsAffected()
 	
-	_, err = tx.Exec("DELETE FROM posts WHERE id = $1", postID)
+	// The same file can be attached to more than one post
+	stillUsed, err := queryStrings(tx, `SELECT DISTINCT media_url FROM post_media WHERE media_url = ANY($1)`, pq.Array(urls))
 	if err != nil {
-		return err
+		return 0, nil, err
 	}
 	
-	return tx.Commit()
+	if err := tx.Commit(); err != nil {
+		return 0, nil, err
+	}
+	
+	used := make(map[string]bool, len(stillUsed))
+	for _, url := range stillUsed {
+		used[url] = true
+	}
+	orphaned := make([]string, 0, len(urls))
+	for _, url := range urls {
+		if !used[url] {
+			orphaned = append(orphaned, url)
+		}
+	}
+	
+	return purged, orphaned, nil
+}
+
+func queryStrings(tx *sql.Tx, query string, args ...interface{}) ([]string, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var values []string
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return values, rows.Err()
+}
+
+// GetScheduledPosts returns the user's posts waiting to be published,
+// soonest first
+func (r *Repository) GetScheduledPosts(userID int64, limit, offset int) ([]Post, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM posts WHERE user_id = $1 AND status = $2 AND deleted_at IS NULL`
+	if err := r.db.QueryRow(countQuery, userID, StatusScheduled).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	
+	query := `
+		SELECT ` + scheduledPostColumns + `
+		FROM posts
+		WHERE user_id = $1 AND status = $2 AND deleted_at IS NULL
+		ORDER BY publish_at, id
+		LIMIT $3 OFFSET $4`
+	
+	rows, err := r.db.Query(query, userID, StatusScheduled, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	
+	posts := []Post{}
+	for rows.Next() {
+		var post Post
+		if err := scanScheduledPost(rows, &post); err != nil {
+			return nil, 0, err
+		}
+		
+		posts = append(posts, post)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	
+	r.attachMedia(posts)
+	return posts, total, nil
+}
+
+// GetScheduledPost returns one of the user's scheduled posts
+func (r *Repository) GetScheduledPost(postID, userID int64) (*Post, error) {
+	query := `
+		SELECT ` + scheduledPostColumns + `
+		FROM posts
+		WHERE id = $1 AND user_id = $2 AND status = $3 AND deleted_at IS NULL`
+	
+	var post Post
+	if err := scanScheduledPost(r.db.QueryRow(query, postID, userID, StatusScheduled), &post); err != nil {
+		return nil, err
+	}
+	
+	media, err := r.GetPostMedia(post.ID)
+	if err != nil {
+		return nil, err
+	}
+	post.Media = media
+	
+	return &post, nil
+}
+
+// UpdateScheduledPost edits a post that hasn't been published yet. It
+// reports whether there was such a post; one published in the meantime is
+// left alone.
+func (r *Repository) UpdateScheduledPost(postID, userID int64, update *UpdateScheduledPostRequest) (bool, error) {
+	stmt := sqlbuilder.NewUpdate("posts").SetSQL("updated_at", "NOW()")
+	
+	if update.Caption != "" {
+		stmt.Set("caption", update.Caption)
+	}
+	if update.Location != "" {
+		stmt.Set("location", update.Location)
+	}
+	if update.Visibility != "" {
+		stmt.Set("visibility", update.Visibility)
+	}
+	if update.PublishAt != nil {
+		stmt.Set("publish_at", *update.PublishAt)
+	}
+	
+	query, args, err := stmt.
+		Where("id = ? AND user_id = ? AND status = ?", postID, userID, StatusScheduled).
+		Where("deleted_at IS NULL").
+		Build()
+	if err != nil {
+		return false, err
+	}
+	
+	result, err := r.db.Exec(query, args...)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// PublishDuePosts publishes up to limit scheduled posts whose time has
+// come. They take the publication time as their creation time, so they
+// surface at the top of feeds rather than where they were drafted.
+func (r *Repository) PublishDuePosts(ctx context.Context, now time.Time, limit int) ([]Post, error) {
+	query := `
+		UPDATE posts SET status = $1, created_at = NOW(), updated_at = NOW()
+		WHERE id IN (
+			SELECT id FROM posts
+			WHERE status = $2 AND deleted_at IS NULL AND publish_at <= $3
+			ORDER BY publish_at
+			LIMIT $4
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, user_id, publish_at`
+	
+	rows, err := database.Conn(ctx, r.db).QueryContext(ctx, query, StatusPublished, StatusScheduled, now, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	var published []Post
+	for rows.Next() {
+		post := Post{Status: StatusPublished}
+		if err := rows.Scan(&post.ID, &post.UserID, &post.PublishAt); err != nil {
+			return nil, err
+		}
+		published = append(published, post)
+	}
+	return published, rows.Err()
+}
+
+const scheduledPostColumns = `id, user_id, caption, location, visibility, status, publish_at, created_at, updated_at`
+
+func scanScheduledPost(row interface{ Scan(...interface{}) error }, post *Post) error {
+	return row.Scan(
+		&post.ID, &post.UserID, &post.Caption, &post.Location, &post.Visibility,
+		&post.Status, &post.PublishAt, &post.CreatedAt, &post.UpdatedAt,
+	)
 }
 
 func (r *Repository) LikePost(postID, userID int64) error {
@@ -196,7 +542,10 @@ func (r *Repository) UnlikePost(postID, userID int64) error {
 func (r *Repository) GetPostLikes(postID int64, limit, offset int) ([]Like, int, error) {
 	// Get total count
 	var total int
-	countQuery := `SELECT COUNT(*) FROM post_likes WHERE post_id = $1`
+	countQuery := `
+		SELECT COUNT(*) FROM post_likes l
+		JOIN users u ON l.user_id = u.id
+		WHERE l.post_id = $1 AND ` + activeAuthor
 	err := r.db.QueryRow(countQuery, postID).Scan(&total)
 	if err != nil {
 		return nil, 0, err
@@ -209,7 +558,7 @@ func (r *Repository) GetPostLikes(postID int64, limit, offset int) ([]Like, int,
 		       COALESCE(u.profile_picture, '') as profile_picture  -- Handle NULL
 		FROM post_likes l
 		JOIN users u ON l.user_id = u.id
-		WHERE l.post_id = $1
+		WHERE l.post_id = $1 AND ` + activeAuthor + `
 		ORDER BY l.created_at DESC
 		LIMIT $2 OFFSET $3`
 	
@@ -245,10 +594,106 @@ func (r *Repository) CreateComment(comment *Comment) error {
 	return err
 }
 
+// GetComment returns a comment on a post that hasn't been deleted, with the
+// post owner's ID
+func (r *Repository) GetComment(commentID int64) (*Comment, int64, error) {
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.content, c.created_at, p.user_id
+		FROM comments c
+		JOIN posts p ON p.id = c.post_id
+		WHERE c.id = $1 AND c.deleted_at IS NULL AND ` + livePost
+	
+	var comment Comment
+	var postOwnerID int64
+	err := r.db.QueryRow(query, commentID).Scan(
+		&comment.ID, &comment.PostID, &comment.UserID, &comment.ParentID,
+		&comment.Content, &comment.CreatedAt, &postOwnerID,
+	)
+	if err != nil {
+		return nil, 0, err
+	}
+	return &comment, postOwnerID, nil
+}
+
+// DeleteComment moves a comment, and so its replies, to the trash of the
+// user who deleted it
+func (r *Repository) DeleteComment(commentID, deletedBy int64) error {
+	query := `
+		UPDATE comments SET deleted_at = NOW(), deleted_by = $2
+		WHERE id = $1 AND deleted_at IS NULL`
+	_, err := r.db.Exec(query, commentID, deletedBy)
+	return err
+}
+
+// RestoreComment takes a comment the user deleted after since out of the
+// trash. Comments on posts that are themselves deleted stay where they are.
+func (r *Repository) RestoreComment(commentID, userID int64, since time.Time) (bool, error) {
+	query := `
+		UPDATE comments c SET deleted_at = NULL, deleted_by = NULL
+		WHERE c.id = $1 AND c.deleted_by = $2 AND c.deleted_at > $3
+		  AND EXISTS(SELECT 1 FROM posts p WHERE p.id = c.post_id AND ` + livePost + `)`
+	result, err := r.db.Exec(query, commentID, userID, since)
+	if err != nil {
+		return false, err
+	}
+	n, err := result.RowsAffected()
+	return n > 0, err
+}
+
+// GetDeletedComments returns the comments the user deleted after since,
+// most recently deleted first
+func (r *Repository) GetDeletedComments(userID int64, since time.Time, limit, offset int) ([]Comment, int, error) {
+	var total int
+	countQuery := `SELECT COUNT(*) FROM comments WHERE deleted_by = $1 AND deleted_at > $2`
+	if err := r.db.QueryRow(countQuery, userID, since).Scan(&total); err != nil {
+		return nil, 0, err
+	}
+	
+	query := `
+		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.content, c.created_at, c.deleted_at,
+		       ` + ghostAuthor + `
+		FROM comments c
+		JOIN users u ON c.user_id = u.id
+		WHERE c.deleted_by = $1 AND c.deleted_at > $2
+		ORDER BY c.deleted_at DESC
+		LIMIT $3 OFFSET $4`
+	
+	rows, err := r.db.Query(query, userID, since, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	
+	comments := []Comment{}
+	for rows.Next() {
+		comment := Comment{User: &UserInfo{}}
+		err := rows.Scan(&comment.ID, &comment.PostID, &comment.UserID, &comment.ParentID,
+			&comment.Content, &comment.CreatedAt, &comment.DeletedAt,
+			&comment.User.Username, &comment.User.ProfilePicture)
+		if err != nil {
+			return nil, 0, err
+		}
+		comment.User.ID = comment.UserID
+		comments = append(comments, comment)
+	}
+	
+	return comments, total, rows.Err()
+}
+
+// PurgeDeletedComments permanently deletes comments deleted before before.
+// Replies cascade with their parent.
+func (r *Repository) PurgeDeletedComments(before time.Time) (int64, error) {
+	result, err := r.db.Exec(`DELETE FROM comments WHERE deleted_at < $1`, before)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 func (r *Repository) GetPostComments(postID int64, limit, offset int) ([]Comment, int, error) {
 	// Get total count
 	var total int
-	countQuery := `SELECT COUNT(*) FROM comments WHERE post_id = $1 AND parent_id IS NULL`
+	countQuery := `SELECT COUNT(*) FROM comments WHERE post_id = $1 AND parent_id IS NULL AND deleted_at IS NULL`
 	err := r.db.QueryRow(countQuery, postID).Scan(&total)
 	if err != nil {
 		return nil, 0, err
@@ -257,11 +702,10 @@ func (r *Repository) GetPostComments(postID int64, limit, offset int) ([]Comment
 	// Get top-level comments - FIXED
 	query := `
 		SELECT c.id, c.post_id, c.user_id, c.content, c.created_at,
-		       u.username, 
-		       COALESCE(u.profile_picture, '') as profile_picture  -- Handle NULL
+		       ` + ghostAuthor + `
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.post_id = $1 AND c.parent_id IS NULL
+		WHERE c.post_id = $1 AND c.parent_id IS NULL AND c.deleted_at IS NULL
 		ORDER BY c.created_at DESC
 		LIMIT $2 OFFSET $3`
 	
@@ -282,36 +726,50 @@ func (r *Repository) GetPostComments(postID int64, limit, offset int) ([]Comment
 		}
 		comment.User.ID = comment.UserID
 		
-		// Get replies for each comment
-		replies, err := r.GetCommentReplies(comment.ID)
-		if err != nil {
-			return nil, 0, err
-		}
-		comment.Replies = replies
-		
 		comments = append(comments, comment)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
+	
+	// Load the replies of the whole page at once
+	ids := make([]int64, len(comments))
+	for i := range comments {
+		ids[i] = comments[i].ID
+	}
+	replies, err := r.GetCommentsReplies(ids)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range comments {
+		comments[i].Replies = replies[comments[i].ID]
+	}
 	
 	return comments, total, nil
 }
 
-func (r *Repository) GetCommentReplies(parentID int64) ([]Comment, error) {
+// GetCommentsReplies loads the replies to several comments in one query,
+// keyed by parent comment, oldest first
+func (r *Repository) GetCommentsReplies(parentIDs []int64) (map[int64][]Comment, error) {
+	replies := make(map[int64][]Comment, len(parentIDs))
+	if len(parentIDs) == 0 {
+		return replies, nil
+	}
+	
 	query := `
 		SELECT c.id, c.post_id, c.user_id, c.parent_id, c.content, c.created_at,
-		       u.username, 
-		       COALESCE(u.profile_picture, '') as profile_picture  -- Handle NULL
+		       ` + ghostAuthor + `
 		FROM comments c
 		JOIN users u ON c.user_id = u.id
-		WHERE c.parent_id = $1
+		WHERE c.parent_id = ANY($1) AND c.deleted_at IS NULL
 		ORDER BY c.created_at ASC`
 	
-	rows, err := r.db.Query(query, parentID)
+	rows, err := r.db.Query(query, pq.Array(parentIDs))
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	
-	var replies []Comment
 	for rows.Next() {
 		reply := Comment{User: &UserInfo{}}
 		err := rows.Scan(&reply.ID, &reply.PostID, &reply.UserID, &reply.ParentID,
@@ -321,10 +779,10 @@ func (r *Repository) GetCommentReplies(parentID int64) ([]Comment, error) {
 			return nil, err
 		}
 		reply.User.ID = reply.UserID
-		replies = append(replies, reply)
+		replies[*reply.ParentID] = append(replies[*reply.ParentID], reply)
 	}
 	
-	return replies, nil
+	return replies, rows.Err()
 }
 
 func (r *Repository) GetFeed(userID int64, limit, offset int) ([]Post, int, error) {
@@ -334,7 +792,8 @@ func (r *Repository) GetFeed(userID int64, limit, offset int) ([]Post, int, erro
 		SELECT COUNT(DISTINCT p.id)
 		FROM posts p
 		JOIN follows f ON p.user_id = f.following_id
-		WHERE f.follower_id = $1`
+		JOIN users u ON p.user_id = u.id
+		WHERE f.follower_id = $1 AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id")
 	
 	err := r.db.QueryRow(countQuery, userID).Scan(&total)
 	if err != nil {
@@ -353,16 +812,16 @@ func (r *Repository) GetFeed(userID int64, limit, offset int) ([]Post, int, erro
 			p.updated_at,
 			u.username, 
 			COALESCE(u.profile_picture, '') as profile_picture,  -- Handle NULL profile_picture
-			COALESCE(COUNT(DISTINCT l.user_id), 0) as likes_count,
-			COALESCE(COUNT(DISTINCT c.id), 0) as comments_count,
-			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $1) as is_liked
+			p.likes_count,
+			p.comments_count,
+			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $1) as is_liked,
+			EXISTS(SELECT 1 FROM saved_posts WHERE post_id = p.id AND user_id = $1) as is_saved,
+			p.original_post_id,
+			(SELECT COUNT(*) FROM posts rp WHERE rp.original_post_id = p.id AND rp.deleted_at IS NULL) as reposts_count
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
 		JOIN follows f ON p.user_id = f.following_id
-		LEFT JOIN post_likes l ON p.id = l.post_id
-		LEFT JOIN comments c ON p.id = c.post_id
-		WHERE f.follower_id = $1
-		GROUP BY p.id, u.id, u.username, u.profile_picture, p.location
+		WHERE f.follower_id = $1 AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id") + `
 		ORDER BY p.created_at DESC
 		LIMIT $2 OFFSET $3`
 	
@@ -390,6 +849,9 @@ func (r *Repository) GetFeed(userID int64, limit, offset int) ([]Post, int, erro
 			&post.LikesCount,
 			&post.CommentsCount,
 			&post.IsLiked,
+			&post.IsSaved,
+			&post.OriginalPostID,
+			&post.RepostsCount,
 		)
 		if err != nil {
 			continue // Skip problematic posts instead of failing entirely
@@ -404,21 +866,21 @@ func (r *Repository) GetFeed(userID int64, limit, offset int) ([]Post, int, erro
 		
 		post.User.ID = post.UserID
 		
-		// Get media (don't fail if media fetch fails)
-		media, _ := r.GetPostMedia(post.ID)
-		post.Media = media
-		
 		posts = append(posts, post)
 	}
 	
+	r.attachMedia(posts)
 	return posts, total, nil
 }
 
 func (r *Repository) GetExplorePosts(userID int64, limit, offset int) ([]Post, int, error) {
 	// Get total count
 	var total int
-	countQuery := `SELECT COUNT(*) FROM posts WHERE visibility = 'public'`
-	err := r.db.QueryRow(countQuery).Scan(&total)
+	countQuery := `
+		SELECT COUNT(*) FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.visibility = 'public' AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id")
+	err := r.db.QueryRow(countQuery, userID).Scan(&total)
 	if err != nil {
 		return []Post{}, 0, nil
 	}
@@ -435,15 +897,15 @@ func (r *Repository) GetExplorePosts(userID int64, limit, offset int) ([]Post, i
 			p.updated_at,
 			u.username,
 			COALESCE(u.profile_picture, '') as profile_picture,
-			COALESCE(COUNT(DISTINCT l.user_id), 0) as likes_count,
-			COALESCE(COUNT(DISTINCT c.id), 0) as comments_count,
-			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $1) as is_liked
+			p.likes_count,
+			p.comments_count,
+			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $1) as is_liked,
+			EXISTS(SELECT 1 FROM saved_posts WHERE post_id = p.id AND user_id = $1) as is_saved,
+			p.original_post_id,
+			(SELECT COUNT(*) FROM posts rp WHERE rp.original_post_id = p.id AND rp.deleted_at IS NULL) as reposts_count
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		LEFT JOIN post_likes l ON p.id = l.post_id
-		LEFT JOIN comments c ON p.id = c.post_id
-		WHERE p.visibility = 'public'
-		GROUP BY p.id, u.id, u.username, u.profile_picture, p.location
+		WHERE p.visibility = 'public' AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id") + `
 		ORDER BY p.created_at DESC
 		LIMIT $2 OFFSET $3`
 	
@@ -471,6 +933,9 @@ func (r *Repository) GetExplorePosts(userID int64, limit, offset int) ([]Post, i
 			&post.LikesCount,
 			&post.CommentsCount,
 			&post.IsLiked,
+			&post.IsSaved,
+			&post.OriginalPostID,
+			&post.RepostsCount,
 		)
 		if err != nil {
 			continue
@@ -485,21 +950,21 @@ func (r *Repository) GetExplorePosts(userID int64, limit, offset int) ([]Post, i
 		
 		post.User.ID = post.UserID
 		
-		// Get media
-		media, _ := r.GetPostMedia(post.ID)
-		post.Media = media
-		
 		posts = append(posts, post)
 	}
 	
+	r.attachMedia(posts)
 	return posts, total, nil
 }
 
 func (r *Repository) GetUserPosts(userID, requestingUserID int64, limit, offset int) ([]Post, int, error) {
 	// Get total count
 	var total int
-	countQuery := `SELECT COUNT(*) FROM posts WHERE user_id = $1`
-	err := r.db.QueryRow(countQuery, userID).Scan(&total)
+	countQuery := `
+		SELECT COUNT(*) FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.user_id = $1 AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$2", "p.user_id")
+	err := r.db.QueryRow(countQuery, userID, requestingUserID).Scan(&total)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -511,15 +976,15 @@ func (r *Repository) GetUserPosts(userID, requestingUserID int64, limit, offset
 			p.created_at, p.updated_at,
 			u.username, 
 			COALESCE(u.profile_picture, '') as profile_picture,  -- Handle NULL
-			COUNT(DISTINCT l.user_id) as likes_count,
-			COUNT(DISTINCT c.id) as comments_count,
-			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $2) as is_liked
+			p.likes_count,
+			p.comments_count,
+			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $2) as is_liked,
+			EXISTS(SELECT 1 FROM saved_posts WHERE post_id = p.id AND user_id = $2) as is_saved,
+			p.original_post_id,
+			(SELECT COUNT(*) FROM posts rp WHERE rp.original_post_id = p.id AND rp.deleted_at IS NULL) as reposts_count
 		FROM posts p
 		JOIN users u ON p.user_id = u.id
-		LEFT JOIN post_likes l ON p.id = l.post_id
-		LEFT JOIN comments c ON p.id = c.post_id
-		WHERE p.user_id = $1
-		GROUP BY p.id, u.id, u.username, u.profile_picture
+		WHERE p.user_id = $1 AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$2", "p.user_id") + `
 		ORDER BY p.created_at DESC
 		LIMIT $3 OFFSET $4`
 	
@@ -536,26 +1001,182 @@ func (r *Repository) GetUserPosts(userID, requestingUserID int64, limit, offset
 			&post.ID, &post.UserID, &post.Caption, &post.Location, &post.Visibility,
 			&post.CreatedAt, &post.UpdatedAt,
 			&post.User.Username, &post.User.ProfilePicture,
-			&post.LikesCount, &post.CommentsCount, &post.IsLiked,
+			&post.LikesCount, &post.CommentsCount, &post.IsLiked, &post.IsSaved,
+			&post.OriginalPostID, &post.RepostsCount,
 		)
 		if err != nil {
 			return nil, 0, err
 		}
 		post.User.ID = post.UserID
 		
-		// Get media for each post
-		media, err := r.GetPostMedia(post.ID)
+		posts = append(posts, post)
+	}
+	
+	r.attachMedia(posts)
+	return posts, total, nil
+}
+
+// HasReposted checks whether the user already reposted the post
+// GetFollowerIDs returns the users following userID
+func (r *Repository) GetFollowerIDs(userID int64) ([]int64, error) {
+	rows, err := r.db.Query(`SELECT follower_id FROM follows WHERE following_id = $1`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	ids := []int64{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+func (r *Repository) HasReposted(originalPostID, userID int64) (bool, error) {
+	var exists bool
+	query := `SELECT EXISTS(SELECT 1 FROM posts WHERE original_post_id = $1 AND user_id = $2 AND deleted_at IS NULL)`
+	err := r.db.QueryRow(query, originalPostID, userID).Scan(&exists)
+	return exists, err
+}
+
+// AttachOriginalPosts embeds the original post, with its author, into each repost.
+// Originals that were deleted or are no longer public are left out.
+func (r *Repository) AttachOriginalPosts(posts []Post, viewerID int64) {
+	for i := range posts {
+		if posts[i].OriginalPostID == nil {
+			continue
+		}
+		original, err := r.GetPostByID(*posts[i].OriginalPostID, viewerID)
+		if err != nil || (original.Visibility != "public" && original.UserID != viewerID) {
+			continue
+		}
+		posts[i].OriginalPost = original
+	}
+}
+
+func (r *Repository) PostExists(postID int64) (bool, error) {
+	var exists bool
+	err := r.db.QueryRow(`SELECT EXISTS(SELECT 1 FROM posts p WHERE p.id = $1 AND ` + livePost + `)`, postID).Scan(&exists)
+	return exists, err
+}
+
+// SavePost bookmarks a post, or moves an already saved post to another collection
+func (r *Repository) SavePost(postID, userID int64, collection string) error {
+	query := `
+		INSERT INTO saved_posts (user_id, post_id, collection, created_at)
+		VALUES ($1, $2, $3, NOW())
+		ON CONFLICT (user_id, post_id) DO UPDATE SET collection = EXCLUDED.collection`
+	_, err := r.db.Exec(query, userID, postID, collection)
+	return err
+}
+
+func (r *Repository) UnsavePost(postID, userID int64) error {
+	query := `DELETE FROM saved_posts WHERE post_id = $1 AND user_id = $2`
+	_, err := r.db.Exec(query, postID, userID)
+	return err
+}
+
+// GetSavedPosts returns the user's saved posts, most recently saved first.
+// An empty collection returns every saved post.
+func (r *Repository) GetSavedPosts(userID int64, collection string, limit, offset int) ([]Post, int, error) {
+	var total int
+	countQuery := `
+		SELECT COUNT(*) FROM saved_posts sp
+		JOIN posts p ON sp.post_id = p.id
+		JOIN users u ON p.user_id = u.id
+		WHERE sp.user_id = $1 AND ($2 = '' OR sp.collection = $2) AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id")
+	err := r.db.QueryRow(countQuery, userID, collection).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+	
+	query := `
+		SELECT 
+			p.id, p.user_id, p.caption, p.location, p.visibility,
+			p.created_at, p.updated_at,
+			u.username, 
+			COALESCE(u.profile_picture, '') as profile_picture,
+			p.likes_count,
+			p.comments_count,
+			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $1) as is_liked,
+			sp.collection,
+			p.original_post_id,
+			(SELECT COUNT(*) FROM posts rp WHERE rp.original_post_id = p.id AND rp.deleted_at IS NULL) as reposts_count
+		FROM saved_posts sp
+		JOIN posts p ON sp.post_id = p.id
+		JOIN users u ON p.user_id = u.id
+		WHERE sp.user_id = $1 AND ($2 = '' OR sp.collection = $2) AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id") + `
+		ORDER BY sp.created_at DESC
+		LIMIT $3 OFFSET $4`
+	
+	rows, err := r.db.Query(query, userID, collection, limit, offset)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+	
+	var posts []Post
+	for rows.Next() {
+		post := Post{User: &UserInfo{}, IsSaved: true}
+		var savedIn string
+		err := rows.Scan(
+			&post.ID, &post.UserID, &post.Caption, &post.Location, &post.Visibility,
+			&post.CreatedAt, &post.UpdatedAt,
+			&post.User.Username, &post.User.ProfilePicture,
+			&post.LikesCount, &post.CommentsCount, &post.IsLiked,
+			&savedIn,
+			&post.OriginalPostID, &post.RepostsCount,
+		)
 		if err != nil {
 			return nil, 0, err
 		}
-		post.Media = media
+		post.User.ID = post.UserID
+		if savedIn != "" {
+			post.Collection = &savedIn
+		}
 		
 		posts = append(posts, post)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, 0, err
+	}
 	
+	r.attachMedia(posts)
 	return posts, total, nil
 }
 
+// GetSavedCollections lists the user's named collections with their post counts
+func (r *Repository) GetSavedCollections(userID int64) ([]SavedCollection, error) {
+	query := `
+		SELECT sp.collection, COUNT(*), MAX(sp.created_at)
+		FROM saved_posts sp
+		JOIN posts p ON p.id = sp.post_id
+		WHERE sp.user_id = $1 AND sp.collection <> '' AND ` + livePost + `
+		GROUP BY sp.collection
+		ORDER BY MAX(sp.created_at) DESC`
+	
+	rows, err := r.db.Query(query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	
+	collections := []SavedCollection{}
+	for rows.Next() {
+		var c SavedCollection
+		if err := rows.Scan(&c.Name, &c.PostsCount, &c.UpdatedAt); err != nil {
+			return nil, err
+		}
+		collections = append(collections, c)
+	}
+	
+	return collections, rows.Err()
+}
+
 func (r *Repository) scanPosts(query string, args ...interface{}) ([]Post, error) {
 	rows, err := r.db.Query(query, args...)
 	if err != nil {
@@ -598,15 +1219,6 @@ func (r *Repository) scanPosts(query string, args ...interface{}) ([]Post, error
 		
 		post.User.ID = post.UserID
 		
-		// Get media for each post - with error handling
-		media, err := r.GetPostMedia(post.ID)
-		if err != nil {
-			// Don't fail entirely, just skip media for this post
-			post.Media = []PostMedia{}
-		} else {
-			post.Media = media
-		}
-		
 		posts = append(posts, post)
 	}
 	
@@ -614,12 +1226,13 @@ func (r *Repository) scanPosts(query string, args ...interface{}) ([]Post, error
 		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 	
+	r.attachMedia(posts)
 	return posts, nil
 }
 
 func (r *Repository) IsPostOwner(postID, userID int64) (bool, error) {
 	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM posts WHERE id = $1 AND user_id = $2)`
+	query := `SELECT EXISTS(SELECT 1 FROM posts p WHERE p.id = $1 AND p.user_id = $2 AND ` + livePost + `)`
 	err := r.db.QueryRow(query, postID, userID).Scan(&exists)
 	return exists, err
 }
@@ -655,15 +1268,6 @@ func (r *Repository) scanPostsWithCoalesce(query string, args ...interface{}) ([
 		
 		post.User.ID = post.UserID
 		
-		// Get media for each post
-		media, err := r.GetPostMedia(post.ID)
-		if err != nil {
-			// Don't fail, just set empty media
-			post.Media = []PostMedia{}
-		} else {
-			post.Media = media
-		}
-		
 		posts = append(posts, post)
 	}
 	
@@ -671,5 +1275,6 @@ func (r *Repository) scanPostsWithCoalesce(query string, args ...interface{}) ([
 		return nil, fmt.Errorf("rows iteration error: %w", err)
 	}
 	
+	r.attachMedia(posts)
 	return posts, nil
 }
\ No newline at end of file
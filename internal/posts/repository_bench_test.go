@@ -0,0 +1,210 @@
+package posts
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// These benchmarks compare loading media and replies one row at a time with
+// the bulk queries, against a fake driver that charges a fixed round trip
+// per query. Run with: go test -run ^$ -bench . ./internal/posts/
+
+const (
+	benchRoundTrip = 200 * time.Microsecond
+	benchPageSize  = 20 // A feed page
+	benchPerParent = 3  // Media per post, replies per comment
+)
+
+func init() {
+	sql.Register("postsbench", benchDriver{})
+}
+
+var benchQueries atomic.Int64
+
+type benchDriver struct{}
+
+func (benchDriver) Open(string) (driver.Conn, error) { return benchConn{}, nil }
+
+type benchConn struct{}
+
+func (benchConn) Prepare(string) (driver.Stmt, error) {
+	return nil, fmt.Errorf("postsbench: prepared statements not supported")
+}
+func (benchConn) Close() error { return nil }
+func (benchConn) Begin() (driver.Tx, error) {
+	return nil, fmt.Errorf("postsbench: transactions not supported")
+}
+
+// QueryContext answers the media and reply queries with benchPerParent rows
+// for each ID in the first argument, which is an ID or a pq.Array of them
+func (benchConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	benchQueries.Add(1)
+	time.Sleep(benchRoundTrip)
+
+	ids, err := benchIDs(args[0].Value)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := &benchRows{}
+	switch {
+	case strings.Contains(query, "FROM post_media"):
+		rows.columns = []string{"id", "post_id", "media_url", "media_type", "position"}
+		for _, id := range ids {
+			for i := 0; i < benchPerParent; i++ {
+				rows.values = append(rows.values, []driver.Value{
+					id*100 + int64(i), id, fmt.Sprintf("https://cdn.example.com/%d/%d.jpg", id, i), "image", int64(i),
+				})
+			}
+		}
+	case strings.Contains(query, "FROM comments"):
+		rows.columns = []string{"id", "post_id", "user_id", "parent_id", "content", "created_at", "username", "profile_picture"}
+		for _, id := range ids {
+			for i := 0; i < benchPerParent; i++ {
+				rows.values = append(rows.values, []driver.Value{
+					id*100 + int64(i), int64(1), int64(i + 1), id, "reply", time.Now(), "user", "",
+				})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("postsbench: unexpected query %q", query)
+	}
+	return rows, nil
+}
+
+// benchIDs reads a single ID or a Postgres array literal such as {1,2,3}
+func benchIDs(v driver.Value) ([]int64, error) {
+	switch v := v.(type) {
+	case int64:
+		return []int64{v}, nil
+	case string:
+		var ids []int64
+		for _, s := range strings.Split(strings.Trim(v, "{}"), ",") {
+			id, err := strconv.ParseInt(s, 10, 64)
+			if err != nil {
+				return nil, err
+			}
+			ids = append(ids, id)
+		}
+		return ids, nil
+	}
+	return nil, fmt.Errorf("postsbench: unexpected argument %T", v)
+}
+
+type benchRows struct {
+	columns []string
+	values  [][]driver.Value
+}
+
+func (r *benchRows) Columns() []string { return r.columns }
+func (r *benchRows) Close() error      { return nil }
+
+func (r *benchRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+func newBenchRepository(b *testing.B) *Repository {
+	db, err := sql.Open("postsbench", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.Cleanup(func() { db.Close() })
+	return NewRepository(db)
+}
+
+func benchPageIDs() []int64 {
+	ids := make([]int64, benchPageSize)
+	for i := range ids {
+		ids[i] = int64(i + 1)
+	}
+	return ids
+}
+
+// runQueryBench runs load b.N times and reports the queries each run made
+func runQueryBench(b *testing.B, load func() error) {
+	benchQueries.Store(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if err := load(); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(benchQueries.Load())/float64(b.N), "queries/op")
+}
+
+// BenchmarkPostMediaPerPost is how listings loaded media before the bulk
+// query: one GetPostMedia per post
+func BenchmarkPostMediaPerPost(b *testing.B) {
+	r := newBenchRepository(b)
+	ids := benchPageIDs()
+	runQueryBench(b, func() error {
+		for _, id := range ids {
+			if media, _ := r.GetPostMedia(id); len(media) != benchPerParent {
+				return fmt.Errorf("post %d: got %d media", id, len(media))
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkPostMediaBulk(b *testing.B) {
+	r := newBenchRepository(b)
+	ids := benchPageIDs()
+	runQueryBench(b, func() error {
+		media, err := r.GetPostsMedia(ids)
+		if err != nil {
+			return err
+		}
+		if len(media) != len(ids) {
+			return fmt.Errorf("got media for %d posts, want %d", len(media), len(ids))
+		}
+		return nil
+	})
+}
+
+// BenchmarkCommentRepliesPerComment issues one query per top-level comment,
+// as GetPostComments did before replies were batched
+func BenchmarkCommentRepliesPerComment(b *testing.B) {
+	r := newBenchRepository(b)
+	ids := benchPageIDs()
+	runQueryBench(b, func() error {
+		for _, id := range ids {
+			replies, err := r.GetCommentsReplies([]int64{id})
+			if err != nil {
+				return err
+			}
+			if len(replies[id]) != benchPerParent {
+				return fmt.Errorf("comment %d: got %d replies", id, len(replies[id]))
+			}
+		}
+		return nil
+	})
+}
+
+func BenchmarkCommentRepliesBulk(b *testing.B) {
+	r := newBenchRepository(b)
+	ids := benchPageIDs()
+	runQueryBench(b, func() error {
+		replies, err := r.GetCommentsReplies(ids)
+		if err != nil {
+			return err
+		}
+		if len(replies) != len(ids) {
+			return fmt.Errorf("got replies for %d comments, want %d", len(replies), len(ids))
+		}
+		return nil
+	})
+}
@@ -4,6 +4,7 @@ package posts
 import (
 	"github.com/gorilla/mux"
 	"github.com/imadgeboyega/kiekky-backend/internal/auth"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
 )
 
 func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
@@ -12,23 +13,55 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.M
 	api.Use(authMiddleware.Authenticate)
 	
 	// Feed operations - MUST COME BEFORE {id} routes!
-	api.HandleFunc("/posts/feed", handler.GetFeed).Methods("GET")
+	api.HandleFunc("/posts/feed", middleware.ConditionalGETFunc(middleware.CachePerUser, handler.GetFeed)).Methods("GET")
 	api.HandleFunc("/posts/explore", handler.GetExplorePosts).Methods("GET")
+	api.HandleFunc("/posts/nearby", handler.GetNearbyPosts).Methods("GET")
+	api.HandleFunc("/posts/saved", handler.GetSavedPosts).Methods("GET")
+	api.HandleFunc("/posts/saved/collections", handler.GetSavedCollections).Methods("GET")
+	api.HandleFunc("/posts/trash", handler.GetDeletedPosts).Methods("GET")
+	api.HandleFunc("/posts/scheduled", handler.GetScheduledPosts).Methods("GET")
+	api.HandleFunc("/posts/scheduled/{id}", handler.UpdateScheduledPost).Methods("PUT")
+	api.HandleFunc("/posts/scheduled/{id}", handler.CancelScheduledPost).Methods("DELETE")
+	
+	// Drafts
+	api.HandleFunc("/posts/drafts", handler.CreateDraft).Methods("POST")
+	api.HandleFunc("/posts/drafts", handler.GetDrafts).Methods("GET")
+	api.HandleFunc("/posts/drafts/{id}", handler.GetDraft).Methods("GET")
+	api.HandleFunc("/posts/drafts/{id}", handler.UpdateDraft).Methods("PUT")
+	api.HandleFunc("/posts/drafts/{id}", handler.DeleteDraft).Methods("DELETE")
+	api.HandleFunc("/posts/drafts/{id}/media", handler.AddDraftMedia).Methods("POST")
+	api.HandleFunc("/posts/drafts/{id}/publish", handler.PublishDraft).Methods("POST")
 	
 	// Post CRUD operations
 	api.HandleFunc("/posts", handler.CreatePost).Methods("POST")
 	api.HandleFunc("/posts/{id}", handler.GetPost).Methods("GET")
 	api.HandleFunc("/posts/{id}", handler.UpdatePost).Methods("PUT")
 	api.HandleFunc("/posts/{id}", handler.DeletePost).Methods("DELETE")
+	api.HandleFunc("/posts/{id}/restore", handler.RestorePost).Methods("POST")
 	
 	// Like operations
 	api.HandleFunc("/posts/{id}/like", handler.LikePost).Methods("POST")
 	api.HandleFunc("/posts/{id}/like", handler.UnlikePost).Methods("DELETE")
 	api.HandleFunc("/posts/{id}/likes", handler.GetPostLikes).Methods("GET")
 	
+	// Repost
+	api.HandleFunc("/posts/{id}/repost", handler.Repost).Methods("POST")
+	
+	// Save (bookmark) operations
+	api.HandleFunc("/posts/{id}/save", handler.SavePost).Methods("POST")
+	api.HandleFunc("/posts/{id}/save", handler.UnsavePost).Methods("DELETE")
+	
 	// Comment operations
 	api.HandleFunc("/posts/{id}/comment", handler.AddComment).Methods("POST")
 	api.HandleFunc("/posts/{id}/comments", handler.GetPostComments).Methods("GET")
+	api.HandleFunc("/comments/trash", handler.GetDeletedComments).Methods("GET")
+	api.HandleFunc("/comments/{id}", handler.DeleteComment).Methods("DELETE")
+	api.HandleFunc("/comments/{id}/restore", handler.RestoreComment).Methods("POST")
+	
+	// Places
+	api.HandleFunc("/places/search", handler.SearchPlaces).Methods("GET")
+	api.HandleFunc("/places/{id}", handler.GetPlace).Methods("GET")
+	api.HandleFunc("/places/{id}/posts", handler.GetPlacePosts).Methods("GET")
 	
 	// User posts
 	api.HandleFunc("/users/{id}/posts", handler.GetUserPosts).Methods("GET")
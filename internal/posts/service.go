@@ -2,16 +2,64 @@
 package posts
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"log"
 	"mime/multipart"
 	"path/filepath"
 	"strings"
+	"time"
+	
+	"github.com/imadgeboyega/kiekky-backend/internal/common/cache"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/database"
+	"github.com/imadgeboyega/kiekky-backend/internal/events"
+	"github.com/imadgeboyega/kiekky-backend/internal/textfilter"
+	"github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+const (
+	maxCollectionNameLength = 50
+	
+	// TrashRetention is how long deleted posts and comments can be restored
+	TrashRetention = 30 * 24 * time.Hour
+	
+	// purgeBatchSize is how many posts are purged per transaction
+	purgeBatchSize = 100
+	
+	// MaxScheduleAhead is how far in the future a post can be scheduled
+	MaxScheduleAhead = 90 * 24 * time.Hour
+	
+	// publishBatchSize is how many due posts are published per query
+	publishBatchSize = 100
+)
+
+var (
+	ErrPostNotFound          = errors.New("post not found")
+	ErrCommentNotFound       = errors.New("comment not found")
+	ErrCannotDeleteComment   = errors.New("unauthorized to delete this comment")
+	ErrCollectionNameTooLong = errors.New("collection name is too long")
+	ErrCannotRepost          = errors.New("post cannot be reposted")
+	ErrAlreadyReposted       = errors.New("post already reposted")
+	ErrInvalidPublishTime    = errors.New("publish_at must be in the future and within 90 days")
 )
 
+// MediaUploader is a step uploads pass through before storage, such as
+// content moderation
+type MediaUploader interface {
+	UploadFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, folder string) (string, error)
+}
+
 type Service struct {
 	repo          *Repository
 	uploadService *UploadService
+	mediaUploader MediaUploader
+	textFilter    textfilter.Filter
+	events        *events.Bus
+	cache         *cache.Cache
+	geocoder      Geocoder
+	uow           *database.UnitOfWork
+	users         users.Client
 }
 
 func NewService(repo *Repository, uploadService *UploadService) *Service {
@@ -21,31 +69,101 @@ func NewService(repo *Repository, uploadService *UploadService) *Service {
 	}
 }
 
+// SetUnitOfWork makes a post and its media save atomically
+func (s *Service) SetUnitOfWork(uow *database.UnitOfWork) {
+	s.uow = uow
+}
+
+// SetUsers sets the shared users client, which holds saved locations
+func (s *Service) SetUsers(client users.Client) {
+	s.users = client
+}
+
+// SetCache caches feeds and the trending ranking
+func (s *Service) SetCache(c *cache.Cache) {
+	s.cache = c
+}
+
+// invalidateFeeds drops the cached feeds of the given users, after a write
+// that changes what they'd see, such as their own like on a post
+func (s *Service) invalidateFeeds(userIDs ...int64) {
+	s.cache.Invalidate(context.Background(), cache.Feed, userIDs...)
+}
+
+// invalidateFollowerFeeds drops the cached feeds of the author and everyone
+// following them, after the author's posts change
+func (s *Service) invalidateFollowerFeeds(authorID int64) {
+	if s.cache == nil {
+		return
+	}
+	followers, err := s.repo.GetFollowerIDs(authorID)
+	if err != nil {
+		log.Printf("Failed to load followers of %d for feed invalidation: %v", authorID, err)
+	}
+	s.invalidateFeeds(append(followers, authorID)...)
+}
+
+// SetMediaUploader routes media uploads through uploader, which stores them
+// with the upload service itself
+func (s *Service) SetMediaUploader(uploader MediaUploader) {
+	s.mediaUploader = uploader
+}
+
+// SetTextFilter screens captions and comments for profanity and spam
+func (s *Service) SetTextFilter(filter textfilter.Filter) {
+	s.textFilter = filter
+}
+
+// filterText runs text through the text filter, when one is set
+func (s *Service) filterText(userID int64, field, text string) (string, error) {
+	if s.textFilter == nil {
+		return text, nil
+	}
+	return s.textFilter.Filter(context.Background(), userID, field, text)
+}
+
 func (s *Service) CreatePost(userID int64, req *CreatePostRequest) (*Post, error) {
 	// Validate input
 	if err := s.validateCreatePost(req); err != nil {
 		return nil, err
 	}
 	
+	caption, err := s.filterText(userID, textfilter.FieldCaption, req.Caption)
+	if err != nil {
+		return nil, err
+	}
+	req.Caption = caption
+	
 	// Create post
 	post := &Post{
 		UserID:     userID,
 		Caption:    req.Caption,
 		Visibility: req.Visibility,
+		Status:     StatusPublished,
+	}
+	if req.PublishAt != nil {
+		post.Status = StatusScheduled
+		post.PublishAt = req.PublishAt
 	}
 	
 	if req.Location != "" {
 		post.Location = sql.NullString{String: req.Location, Valid: true}
 	}
 	
-	// Save post to database
-	err := s.repo.CreatePost(post)
-	if err != nil {
+	if err := s.resolveLocation(post, req); err != nil {
 		return nil, err
 	}
 	
-	// Add media if provided
-	if len(req.MediaURLs) > 0 {
+	// Save the post and its media together, so a failed media insert
+	// doesn't leave a post without them
+	err = s.uow.Do(context.Background(), func(ctx context.Context) error {
+		if err := s.repo.CreatePost(ctx, post); err != nil {
+			return err
+		}
+		
+		if len(req.MediaURLs) == 0 {
+			return nil
+		}
 		media := make([]PostMedia, len(req.MediaURLs))
 		for i, url := range req.MediaURLs {
 			media[i] = PostMedia{
@@ -55,20 +173,99 @@ func (s *Service) CreatePost(userID int64, req *CreatePostRequest) (*Post, error
 				Position:  i,
 			}
 		}
-		
-		err = s.repo.AddPostMedia(media)
-		if err != nil {
-			return nil, err
+		if err := s.repo.AddPostMedia(ctx, media); err != nil {
+			return err
 		}
 		post.Media = media
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	
+	if post.Status == StatusScheduled {
+		return s.repo.GetScheduledPost(post.ID, userID)
 	}
+	s.invalidateFollowerFeeds(userID)
 	
 	// Get complete post data
-	return s.repo.GetPostByID(post.ID, userID)
+	return s.GetPost(post.ID, userID)
 }
 
 func (s *Service) GetPost(postID, userID int64) (*Post, error) {
-	return s.repo.GetPostByID(postID, userID)
+	post, err := s.repo.GetPostByID(postID, userID)
+	if err != nil {
+		return nil, err
+	}
+	
+	posts := []Post{*post}
+	s.repo.AttachOriginalPosts(posts, userID)
+	s.repo.AttachPlaces(posts)
+	return &posts[0], nil
+}
+
+// Repost shares a public post to the user's followers. Reposting a repost
+// references the root original so chains stay one level deep.
+func (s *Service) Repost(postID, userID int64, req *RepostRequest) (*Post, error) {
+	original, err := s.repo.GetPostByID(postID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrPostNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	
+	if original.OriginalPostID != nil {
+		original, err = s.repo.GetPostByID(*original.OriginalPostID, userID)
+		if err == sql.ErrNoRows {
+			return nil, ErrPostNotFound
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	
+	if original.UserID == userID || original.Visibility != "public" {
+		return nil, ErrCannotRepost
+	}
+	
+	reposted, err := s.repo.HasReposted(original.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if reposted {
+		return nil, ErrAlreadyReposted
+	}
+	
+	caption, err := s.filterText(userID, textfilter.FieldCaption, strings.TrimSpace(req.Caption))
+	if err != nil {
+		return nil, err
+	}
+	
+	repost := &Post{
+		UserID:         userID,
+		Caption:        caption,
+		Visibility:     "public",
+		Status:         StatusPublished,
+		OriginalPostID: &original.ID,
+	}
+	err = s.uow.Do(context.Background(), func(ctx context.Context) error {
+		if err := s.repo.CreatePost(ctx, repost); err != nil {
+			return err
+		}
+		return s.publish(ctx, repost.ID, events.PostReposted{
+			ReposterID: userID,
+			OwnerID:    original.UserID,
+			PostID:     original.ID,
+			RepostID:   repost.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.invalidateFollowerFeeds(userID)
+	
+	return s.GetPost(repost.ID, userID)
 }
 
 func (s *Service) UpdatePost(postID, userID int64, req *UpdatePostRequest) (*Post, error) {
@@ -81,11 +278,17 @@ func (s *Service) UpdatePost(postID, userID int64, req *UpdatePostRequest) (*Pos
 		return nil, errors.New("unauthorized to update this post")
 	}
 	
+	req.Caption, err = s.filterText(userID, textfilter.FieldCaption, req.Caption)
+	if err != nil {
+		return nil, err
+	}
+	
 	// Update post
 	err = s.repo.UpdatePost(postID, req)
 	if err != nil {
 		return nil, err
 	}
+	s.invalidateFollowerFeeds(userID)
 	
 	// Return updated post
 	return s.repo.GetPostByID(postID, userID)
@@ -101,9 +304,163 @@ func (s *Service) DeletePost(postID, userID int64) error {
 		return errors.New("unauthorized to delete this post")
 	}
 	
+	if err := s.repo.DeletePost(postID); err != nil {
+		return err
+	}
+	s.invalidateFollowerFeeds(userID)
+	return nil
+}
+
+// GetScheduledPosts lists the user's posts waiting to be published
+func (s *Service) GetScheduledPosts(userID int64, page, limit int) (*FeedResponse, error) {
+	offset := (page - 1) * limit
+	posts, total, err := s.repo.GetScheduledPosts(userID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &FeedResponse{
+		Posts: posts,
+		Pagination: PaginationMeta{
+			Page:    page,
+			Limit:   limit,
+			Total:   total,
+			HasNext: offset+limit < total,
+		},
+	}, nil
+}
+
+// UpdateScheduledPost edits a scheduled post, including when it goes out
+func (s *Service) UpdateScheduledPost(postID, userID int64, req *UpdateScheduledPostRequest) (*Post, error) {
+	if req.PublishAt != nil {
+		if err := validatePublishAt(*req.PublishAt); err != nil {
+			return nil, err
+		}
+	}
+	
+	var err error
+	req.Caption, err = s.filterText(userID, textfilter.FieldCaption, req.Caption)
+	if err != nil {
+		return nil, err
+	}
+	
+	updated, err := s.repo.UpdateScheduledPost(postID, userID, req)
+	if err != nil {
+		return nil, err
+	}
+	if !updated {
+		return nil, ErrPostNotFound
+	}
+	
+	return s.repo.GetScheduledPost(postID, userID)
+}
+
+// CancelScheduledPost moves a scheduled post to the trash. Restoring it
+// schedules it again, and it goes out straight away if its time has passed.
+func (s *Service) CancelScheduledPost(postID, userID int64) error {
+	if _, err := s.repo.GetScheduledPost(postID, userID); err != nil {
+		if err == sql.ErrNoRows {
+			return ErrPostNotFound
+		}
+		return err
+	}
+	
 	return s.repo.DeletePost(postID)
 }
 
+// PublishDuePosts publishes every scheduled post whose time has come and
+// lets each author know. It returns how many were published.
+func (s *Service) PublishDuePosts(ctx context.Context) (int, error) {
+	count := 0
+	for ctx.Err() == nil {
+		// Each post's published event is stored with it, so the author
+		// hears about it even if the process stops right after
+		var published []Post
+		err := s.uow.Do(ctx, func(ctx context.Context) error {
+			var err error
+			published, err = s.repo.PublishDuePosts(ctx, time.Now(), publishBatchSize)
+			if err != nil {
+				return err
+			}
+			for _, post := range published {
+				if err := s.publish(ctx, post.ID, events.PostPublished{UserID: post.UserID, PostID: post.ID}); err != nil {
+					return err
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			return count, err
+		}
+		count += len(published)
+		
+		authors := map[int64]bool{}
+		for _, post := range published {
+			if !authors[post.UserID] {
+				authors[post.UserID] = true
+				s.invalidateFollowerFeeds(post.UserID)
+			}
+		}
+		
+		if len(published) < publishBatchSize {
+			break
+		}
+	}
+	
+	return count, ctx.Err()
+}
+
+// requirePost returns ErrPostNotFound unless the post exists and isn't deleted
+func (s *Service) requirePost(postID int64) error {
+	exists, err := s.repo.PostExists(postID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return ErrPostNotFound
+	}
+	return nil
+}
+
+// RestorePost takes one of the user's posts out of the trash
+func (s *Service) RestorePost(postID, userID int64) (*Post, error) {
+	restored, err := s.repo.RestorePost(postID, userID, time.Now().Add(-TrashRetention))
+	if err != nil {
+		return nil, err
+	}
+	if !restored {
+		return nil, ErrPostNotFound
+	}
+	s.invalidateFollowerFeeds(userID)
+	
+	post, err := s.GetPost(postID, userID)
+	if err == sql.ErrNoRows {
+		// A cancelled scheduled post goes back to being scheduled
+		return s.repo.GetScheduledPost(postID, userID)
+	}
+	return post, err
+}
+
+// GetDeletedPosts lists the user's posts that can still be restored
+func (s *Service) GetDeletedPosts(userID int64, page, limit int) (*TrashResponse, error) {
+	offset := (page - 1) * limit
+	posts, total, err := s.repo.GetDeletedPosts(userID, time.Now().Add(-TrashRetention), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &TrashResponse{
+		Posts:         posts,
+		RetentionDays: int(TrashRetention.Hours() / 24),
+		Pagination: PaginationMeta{
+			Page:    page,
+			Limit:   limit,
+			Total:   total,
+			HasNext: offset+limit < total,
+		},
+	}, nil
+}
+
 func (s *Service) ToggleLike(postID, userID int64) (bool, error) {
 	// Check if already liked
 	post, err := s.repo.GetPostByID(postID, userID)
@@ -111,16 +468,25 @@ func (s *Service) ToggleLike(postID, userID int64) (bool, error) {
 		return false, err
 	}
 	
+	defer s.invalidateFeeds(userID)
+	
 	if post.IsLiked {
 		err = s.repo.UnlikePost(postID, userID)
 		return false, err
 	} else {
 		err = s.repo.LikePost(postID, userID)
+		if err == nil {
+			s.events.Emit(context.Background(), events.PostLiked{UserID: userID, PostID: postID, OwnerID: post.UserID})
+		}
 		return true, err
 	}
 }
 
 func (s *Service) GetPostLikes(postID int64, page, limit int) ([]Like, *PaginationMeta, error) {
+	if err := s.requirePost(postID); err != nil {
+		return nil, nil, err
+	}
+	
 	offset := (page - 1) * limit
 	likes, total, err := s.repo.GetPostLikes(postID, limit, offset)
 	if err != nil {
@@ -137,12 +503,69 @@ func (s *Service) GetPostLikes(postID int64, page, limit int) ([]Like, *Paginati
 	return likes, pagination, nil
 }
 
+// SavePost bookmarks a post, optionally into a named collection
+func (s *Service) SavePost(postID, userID int64, req *SavePostRequest) error {
+	collection := strings.TrimSpace(req.Collection)
+	if len(collection) > maxCollectionNameLength {
+		return ErrCollectionNameTooLong
+	}
+	
+	if err := s.requirePost(postID); err != nil {
+		return err
+	}
+	
+	defer s.invalidateFeeds(userID)
+	return s.repo.SavePost(postID, userID, collection)
+}
+
+func (s *Service) UnsavePost(postID, userID int64) error {
+	defer s.invalidateFeeds(userID)
+	return s.repo.UnsavePost(postID, userID)
+}
+
+func (s *Service) GetSavedPosts(userID int64, collection string, page, limit int) (*FeedResponse, error) {
+	offset := (page - 1) * limit
+	posts, total, err := s.repo.GetSavedPosts(userID, strings.TrimSpace(collection), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	if posts == nil {
+		posts = []Post{}
+	}
+	s.repo.AttachOriginalPosts(posts, userID)
+	s.repo.AttachPlaces(posts)
+	
+	return &FeedResponse{
+		Posts: posts,
+		Pagination: PaginationMeta{
+			Page:    page,
+			Limit:   limit,
+			Total:   total,
+			HasNext: offset+limit < total,
+		},
+	}, nil
+}
+
+func (s *Service) GetSavedCollections(userID int64) ([]SavedCollection, error) {
+	return s.repo.GetSavedCollections(userID)
+}
+
 func (s *Service) AddComment(postID, userID int64, req *CommentRequest) (*Comment, error) {
 	// Validate input
 	if strings.TrimSpace(req.Content) == "" {
 		return nil, errors.New("comment content cannot be empty")
 	}
 	
+	if err := s.requirePost(postID); err != nil {
+		return nil, err
+	}
+	
+	content, err := s.filterText(userID, textfilter.FieldComment, req.Content)
+	if err != nil {
+		return nil, err
+	}
+	req.Content = content
+	
 	comment := &Comment{
 		PostID:   postID,
 		UserID:   userID,
@@ -150,15 +573,20 @@ func (s *Service) AddComment(postID, userID int64, req *CommentRequest) (*Commen
 		Content:  req.Content,
 	}
 	
-	err := s.repo.CreateComment(comment)
+	err = s.repo.CreateComment(comment)
 	if err != nil {
 		return nil, err
 	}
+	s.invalidateFeeds(userID)
 	
 	return comment, nil
 }
 
 func (s *Service) GetPostComments(postID int64, page, limit int) ([]Comment, *PaginationMeta, error) {
+	if err := s.requirePost(postID); err != nil {
+		return nil, nil, err
+	}
+	
 	offset := (page - 1) * limit
 	comments, total, err := s.repo.GetPostComments(postID, limit, offset)
 	if err != nil {
@@ -175,12 +603,111 @@ func (s *Service) GetPostComments(postID int64, page, limit int) ([]Comment, *Pa
 	return comments, pagination, nil
 }
 
+// DeleteComment moves a comment to the trash. Its author and the post's
+// owner can delete it.
+func (s *Service) DeleteComment(commentID, userID int64) error {
+	comment, postOwnerID, err := s.repo.GetComment(commentID)
+	if err == sql.ErrNoRows {
+		return ErrCommentNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if comment.UserID != userID && postOwnerID != userID {
+		return ErrCannotDeleteComment
+	}
+	
+	defer s.invalidateFeeds(userID)
+	return s.repo.DeleteComment(commentID, userID)
+}
+
+// RestoreComment takes a comment the user deleted out of the trash
+func (s *Service) RestoreComment(commentID, userID int64) error {
+	restored, err := s.repo.RestoreComment(commentID, userID, time.Now().Add(-TrashRetention))
+	if err != nil {
+		return err
+	}
+	if !restored {
+		return ErrCommentNotFound
+	}
+	s.invalidateFeeds(userID)
+	return nil
+}
+
+// GetDeletedComments lists the comments the user deleted that can still be restored
+func (s *Service) GetDeletedComments(userID int64, page, limit int) (*TrashResponse, error) {
+	offset := (page - 1) * limit
+	comments, total, err := s.repo.GetDeletedComments(userID, time.Now().Add(-TrashRetention), limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	
+	return &TrashResponse{
+		Comments:      comments,
+		RetentionDays: int(TrashRetention.Hours() / 24),
+		Pagination: PaginationMeta{
+			Page:    page,
+			Limit:   limit,
+			Total:   total,
+			HasNext: offset+limit < total,
+		},
+	}, nil
+}
+
+// PurgeTrash permanently deletes posts and comments that have been in the
+// trash longer than TrashRetention, and removes media files no remaining
+// post uses
+func (s *Service) PurgeTrash(ctx context.Context) (*PurgeResult, error) {
+	before := time.Now().Add(-TrashRetention)
+	result := &PurgeResult{}
+	
+	for ctx.Err() == nil {
+		purged, orphaned, err := s.repo.PurgeDeletedPosts(before, purgeBatchSize)
+		if err != nil {
+			return result, err
+		}
+		result.Posts += purged
+		
+		for _, url := range orphaned {
+			if !s.uploadService.IsStoredFile(url) {
+				continue
+			}
+			if err := s.uploadService.DeleteFile(url); err != nil {
+				log.Printf("Failed to delete media %s of purged post: %v", url, err)
+				continue
+			}
+			result.MediaFiles++
+		}
+		
+		if purged < purgeBatchSize {
+			break
+		}
+	}
+	if err := ctx.Err(); err != nil {
+		return result, err
+	}
+	
+	comments, err := s.repo.PurgeDeletedComments(before)
+	result.Comments = comments
+	return result, err
+}
+
+// GetFeed returns posts from people the user follows. Pages are cached
+// until the user or someone they follow changes their posts.
 func (s *Service) GetFeed(userID int64, page, limit int) (*FeedResponse, error) {
+	return cache.Fetch(context.Background(), s.cache, cache.Feed, userID, cache.Variant(page, limit), func() (*FeedResponse, error) {
+		return s.loadFeed(userID, page, limit)
+	})
+}
+
+func (s *Service) loadFeed(userID int64, page, limit int) (*FeedResponse, error) {
 	offset := (page - 1) * limit
 	posts, total, err := s.repo.GetFeed(userID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
+	s.repo.AttachOriginalPosts(posts, userID)
+	s.repo.AttachPlaces(posts)
 	
 	return &FeedResponse{
 		Posts: posts,
@@ -193,12 +720,26 @@ func (s *Service) GetFeed(userID int64, page, limit int) (*FeedResponse, error)
 	}, nil
 }
 
-func (s *Service) GetExplorePosts(userID int64, page, limit int) (*FeedResponse, error) {
+// GetExplorePosts returns trending posts for the viewer, or the most recent
+// public posts when nothing is trending
+func (s *Service) GetExplorePosts(ctx context.Context, userID int64, country string, page, limit int) (*FeedResponse, error) {
+	trending, err := s.GetTrendingPosts(ctx, userID, country, page, limit)
+	if err != nil {
+		log.Printf("Failed to rank trending posts, falling back to recent: %v", err)
+	}
+	if trending != nil {
+		s.repo.AttachOriginalPosts(trending.Posts, userID)
+		s.repo.AttachPlaces(trending.Posts)
+		return trending, nil
+	}
+	
 	offset := (page - 1) * limit
 	posts, total, err := s.repo.GetExplorePosts(userID, limit, offset)
 	if err != nil {
 		return nil, err
 	}
+	s.repo.AttachOriginalPosts(posts, userID)
+	s.repo.AttachPlaces(posts)
 	
 	return &FeedResponse{
 		Posts: posts,
@@ -217,6 +758,8 @@ func (s *Service) GetUserPosts(userID, requestingUserID int64, page, limit int)
 	if err != nil {
 		return nil, err
 	}
+	s.repo.AttachOriginalPosts(posts, requestingUserID)
+	s.repo.AttachPlaces(posts)
 	
 	return &FeedResponse{
 		Posts: posts,
@@ -246,6 +789,21 @@ func (s *Service) validateCreatePost(req *CreatePostRequest) error {
 		return errors.New("maximum 10 media files allowed per post")
 	}
 	
+	if req.PublishAt != nil {
+		if err := validatePublishAt(*req.PublishAt); err != nil {
+			return err
+		}
+	}
+	
+	return nil
+}
+
+// validatePublishAt checks a scheduled post's publication time
+func validatePublishAt(publishAt time.Time) error {
+	now := time.Now()
+	if !publishAt.After(now) || publishAt.After(now.Add(MaxScheduleAhead)) {
+		return ErrInvalidPublishTime
+	}
 	return nil
 }
 
@@ -260,6 +818,14 @@ func (s *Service) getMediaType(url string) string {
 }
 
 // UploadMedia handles file upload to S3 or local storage
-func (s *Service) UploadMedia(file multipart.File, header *multipart.FileHeader) (string, error) {
-	return s.uploadService.UploadFile(file, header)
+func (s *Service) UploadMedia(ctx context.Context, file multipart.File, header *multipart.FileHeader) (string, error) {
+	if s.mediaUploader == nil {
+		return s.uploadService.UploadFile(file, header)
+	}
+
+	// Reject disallowed files before they are scanned or quarantined
+	if err := s.uploadService.validateFile(header); err != nil {
+		return "", err
+	}
+	return s.mediaUploader.UploadFile(ctx, file, header, "posts")
 }
\ No newline at end of file
@@ -0,0 +1,310 @@
+// internal/posts/trending.go
+// Trending ranking for the explore page. Recent public posts are scored by
+// engagement velocity with a time decay, boosted when the poster is in the
+// viewer's country, and diversified so no single author dominates.
+package posts
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/blocks"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/cache"
+	"github.com/lib/pq"
+)
+
+const (
+	// trendingWindow is how far back explore looks for trending posts
+	trendingWindow = 72 * time.Hour
+
+	// maxTrendingCandidates caps how many recent posts are scored
+	maxTrendingCandidates = 2000
+
+	// trendingGravity controls how fast a post's score decays with age
+	trendingGravity = 1.5
+
+	// localBoost multiplies the score of posts from the viewer's country
+	localBoost = 2.0
+
+	// authorPenalty scales each further post by the same author, and
+	// maxPerAuthor caps how many of their posts trend at once
+	authorPenalty = 0.5
+	maxPerAuthor  = 3
+
+	// reportThreshold keeps authors with this many open reports out of
+	// trending until the reports are reviewed
+	reportThreshold = 3
+)
+
+// trendingPost is a scored explore candidate
+type trendingPost struct {
+	PostID  int64   `json:"p"`
+	UserID  int64   `json:"u"`
+	Country string  `json:"c,omitempty"`
+	Score   float64 `json:"s"`
+}
+
+// Repository
+
+// GetTrendingCandidates returns recent public original posts with their
+// engagement, skipping authors with open reports against them
+func (r *Repository) GetTrendingCandidates(since time.Time, limit int) ([]trendingPost, error) {
+	query := `
+		SELECT
+			p.id,
+			p.user_id,
+			p.created_at,
+			p.likes_count,
+			p.comments_count,
+			(SELECT COUNT(*) FROM posts rp WHERE rp.original_post_id = p.id AND rp.deleted_at IS NULL) as reposts_count,
+			COALESCE((
+				SELECT ll.country FROM login_locations ll
+				WHERE ll.user_id = p.user_id AND ll.country IS NOT NULL
+				ORDER BY ll.last_seen_at DESC
+				LIMIT 1
+			), '') as country
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.visibility = 'public' AND ` + livePost + ` AND ` + activeAuthor + `
+		  AND p.original_post_id IS NULL
+		  AND p.created_at > $1
+		  AND (SELECT COUNT(*) FROM user_reports ur
+		       WHERE ur.reported_user_id = p.user_id AND ur.status = 'open') < $2
+		ORDER BY p.created_at DESC
+		LIMIT $3`
+
+	rows, err := r.db.Query(query, since, reportThreshold, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	now := time.Now()
+	candidates := []trendingPost{}
+	for rows.Next() {
+		var (
+			candidate                trendingPost
+			createdAt                time.Time
+			likes, comments, reposts int64
+		)
+		if err := rows.Scan(&candidate.PostID, &candidate.UserID, &createdAt,
+			&likes, &comments, &reposts, &candidate.Country); err != nil {
+			return nil, err
+		}
+		candidate.Score = trendingScore(likes, comments, reposts, now.Sub(createdAt))
+		candidates = append(candidates, candidate)
+	}
+	return candidates, rows.Err()
+}
+
+// GetHiddenAuthors returns the users whose posts viewerID shouldn't see in
+// explore: blocks in either direction and users viewerID has reported
+func (r *Repository) GetHiddenAuthors(viewerID int64) (map[int64]bool, error) {
+	query := `
+		SELECT blocked_id FROM blocked_users WHERE user_id = $1
+		UNION
+		SELECT user_id FROM blocked_users WHERE blocked_id = $1
+		UNION
+		SELECT reported_user_id FROM user_reports WHERE reporter_id = $1`
+
+	rows, err := r.db.Query(query, viewerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hidden := map[int64]bool{}
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		hidden[id] = true
+	}
+	return hidden, rows.Err()
+}
+
+// GetPostsByIDs returns the visible posts among postIDs, in postIDs order
+func (r *Repository) GetPostsByIDs(postIDs []int64, userID int64) ([]Post, error) {
+	query := `
+		SELECT
+			p.id,
+			p.user_id,
+			p.caption,
+			COALESCE(p.location, '') as location,
+			p.visibility,
+			p.created_at,
+			p.updated_at,
+			u.username,
+			COALESCE(u.profile_picture, '') as profile_picture,
+			p.likes_count,
+			p.comments_count,
+			EXISTS(SELECT 1 FROM post_likes WHERE post_id = p.id AND user_id = $1) as is_liked,
+			EXISTS(SELECT 1 FROM saved_posts WHERE post_id = p.id AND user_id = $1) as is_saved,
+			p.original_post_id,
+			(SELECT COUNT(*) FROM posts rp WHERE rp.original_post_id = p.id AND rp.deleted_at IS NULL) as reposts_count
+		FROM posts p
+		JOIN users u ON p.user_id = u.id
+		WHERE p.id = ANY($2) AND p.visibility = 'public' AND ` + livePost + ` AND ` + activeAuthor + ` AND ` + blocks.NotBlockedSQL("$1", "p.user_id")
+
+	rows, err := r.db.Query(query, userID, pq.Array(postIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	byID := make(map[int64]Post, len(postIDs))
+	for rows.Next() {
+		post := Post{User: &UserInfo{}}
+		var locationStr string
+
+		err := rows.Scan(
+			&post.ID,
+			&post.UserID,
+			&post.Caption,
+			&locationStr,
+			&post.Visibility,
+			&post.CreatedAt,
+			&post.UpdatedAt,
+			&post.User.Username,
+			&post.User.ProfilePicture,
+			&post.LikesCount,
+			&post.CommentsCount,
+			&post.IsLiked,
+			&post.IsSaved,
+			&post.OriginalPostID,
+			&post.RepostsCount,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		if locationStr != "" {
+			post.Location.String = locationStr
+			post.Location.Valid = true
+		}
+		post.User.ID = post.UserID
+
+		byID[post.ID] = post
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	posts := make([]Post, 0, len(byID))
+	for _, id := range postIDs {
+		if post, ok := byID[id]; ok {
+			posts = append(posts, post)
+		}
+	}
+	r.attachMedia(posts)
+	return posts, nil
+}
+
+// Service
+
+// GetTrendingPosts pages through explore's trending ranking for the viewer.
+// country is the viewer's ISO country code and may be empty. It returns nil
+// when nothing is trending so the caller can fall back to recent posts.
+func (s *Service) GetTrendingPosts(ctx context.Context, userID int64, country string, page, limit int) (*FeedResponse, error) {
+	candidates, err := s.trendingCandidates(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	hidden, err := s.repo.GetHiddenAuthors(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	ranked := rankTrending(candidates, strings.ToUpper(country), hidden)
+
+	offset := (page - 1) * limit
+	total := len(ranked)
+	ids := []int64{}
+	for i := offset; i < offset+limit && i < total; i++ {
+		ids = append(ids, ranked[i].PostID)
+	}
+
+	posts := []Post{}
+	if len(ids) > 0 {
+		posts, err = s.repo.GetPostsByIDs(ids, userID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FeedResponse{
+		Posts: posts,
+		Pagination: PaginationMeta{
+			Page:    page,
+			Limit:   limit,
+			Total:   total,
+			HasNext: offset+limit < total,
+		},
+	}, nil
+}
+
+// trendingCandidates returns the scored candidates, from the cache when
+// they were computed recently
+func (s *Service) trendingCandidates(ctx context.Context) ([]trendingPost, error) {
+	return cache.Fetch(ctx, s.cache, cache.Trending, 0, "", func() ([]trendingPost, error) {
+		return s.repo.GetTrendingCandidates(time.Now().Add(-trendingWindow), maxTrendingCandidates)
+	})
+}
+
+// trendingScore is weighted engagement per unit of age: comments count for
+// more than likes and reposts more still, and older posts need more
+// engagement to keep up
+func trendingScore(likes, comments, reposts int64, age time.Duration) float64 {
+	engagement := float64(likes) + 2*float64(comments) + 3*float64(reposts)
+	hours := math.Max(age.Hours(), 0)
+	return (engagement + 1) / math.Pow(hours+2, trendingGravity)
+}
+
+// rankTrending orders candidates for one viewer. Posts from the viewer's
+// country are boosted, hidden authors dropped, and each author's posts after
+// the first are penalised and capped at maxPerAuthor.
+func rankTrending(candidates []trendingPost, country string, hidden map[int64]bool) []trendingPost {
+	ranked := make([]trendingPost, 0, len(candidates))
+	for _, candidate := range candidates {
+		if hidden[candidate.UserID] {
+			continue
+		}
+		if country != "" && candidate.Country == country {
+			candidate.Score *= localBoost
+		}
+		ranked = append(ranked, candidate)
+	}
+	sortByScore(ranked)
+
+	perAuthor := map[int64]int{}
+	diversified := ranked[:0]
+	for _, candidate := range ranked {
+		n := perAuthor[candidate.UserID]
+		if n >= maxPerAuthor {
+			continue
+		}
+		perAuthor[candidate.UserID] = n + 1
+		candidate.Score *= math.Pow(authorPenalty, float64(n))
+		diversified = append(diversified, candidate)
+	}
+	sortByScore(diversified)
+
+	return diversified
+}
+
+func sortByScore(posts []trendingPost) {
+	sort.SliceStable(posts, func(i, j int) bool {
+		if posts[i].Score != posts[j].Score {
+			return posts[i].Score > posts[j].Score
+		}
+		return posts[i].PostID > posts[j].PostID
+	})
+}
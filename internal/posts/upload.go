@@ -3,6 +3,8 @@ package posts
 
 import (
 	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"mime/multipart"
@@ -17,6 +19,9 @@ import (
 	"github.com/google/uuid"
 )
 
+// ErrFileTooLarge is returned for a single media file over 10MB
+var ErrFileTooLarge = errors.New("file size exceeds maximum of 10MB")
+
 type UploadService struct {
 	s3Client   *s3.S3
 	bucketName string
@@ -72,6 +77,21 @@ func (us *UploadService) UploadFile(file multipart.File, header *multipart.FileH
 	return us.uploadToLocal(file, filename)
 }
 
+// ContextUploader adapts UploadService to the context-aware uploader shape
+// used by stories and profiles, so it can sit behind content moderation.
+// Posts are always stored under posts/, whatever the folder.
+type ContextUploader struct {
+	*UploadService
+}
+
+func (u ContextUploader) UploadFile(ctx context.Context, file multipart.File, header *multipart.FileHeader, folder string) (string, error) {
+	return u.UploadService.UploadFile(file, header)
+}
+
+func (u ContextUploader) DeleteFile(ctx context.Context, fileURL string) error {
+	return u.UploadService.DeleteFile(fileURL)
+}
+
 func (us *UploadService) uploadToS3(file multipart.File, filename string, header *multipart.FileHeader) (string, error) {
 	// Read file content
 	buffer := bytes.NewBuffer(nil)
@@ -130,7 +150,7 @@ func (us *UploadService) validateFile(header *multipart.FileHeader) error {
 	// Check file size (max 10MB)
 	maxSize := int64(10 << 20) // 10MB
 	if header.Size > maxSize {
-		return fmt.Errorf("file size exceeds maximum of 10MB")
+		return ErrFileTooLarge
 	}
 	
 	// Check file type
@@ -160,6 +180,19 @@ func (us *UploadService) generateFilename(originalName string) string {
 	return fmt.Sprintf("%s_%d%s", name, timestamp, ext)
 }
 
+// IsStoredFile reports whether fileURL points at a post upload in this
+// service's storage. Media URLs can come from clients, so anything else must
+// never be deleted.
+func (us *UploadService) IsStoredFile(fileURL string) bool {
+	if strings.Contains(fileURL, "..") {
+		return false
+	}
+	if us.useS3 {
+		return strings.HasPrefix(fileURL, fmt.Sprintf("https://%s.s3.amazonaws.com/", us.bucketName))
+	}
+	return strings.HasPrefix(fileURL, us.baseURL+"/uploads/")
+}
+
 func (us *UploadService) DeleteFile(fileURL string) error {
 	if us.useS3 {
 		return us.deleteFromS3(fileURL)
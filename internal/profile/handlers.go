@@ -3,33 +3,52 @@
 package profile
 
 import (
-	"encoding/json"
+	"context"
 	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"strconv"
 
-	"github.com/go-chi/chi/v5"
-	"github.com/go-playground/validator/v10"
+	"github.com/imadgeboyega/kiekky-backend/internal/auth"
 	"github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+	"github.com/imadgeboyega/kiekky-backend/internal/interests"
+	"github.com/imadgeboyega/kiekky-backend/internal/moderation"
+	"github.com/imadgeboyega/kiekky-backend/internal/textfilter"
 )
 
+// peopleYouMayKnowLimit is how many contacts lead the first discovery page
+const peopleYouMayKnowLimit = 10
+
 // Handler handles profile-related HTTP requests
+// defaultMaxUploadSize caps image uploads until SetUploadLimit is called
+const defaultMaxUploadSize = 10 << 20
+
 type Handler struct {
-	service   Service
-	validator *validator.Validate
+	service       Service
+	maxUploadSize int64
 }
 
 // NewHandler creates a new profile handler
 func NewHandler(service Service) *Handler {
 	return &Handler{
-		service:   service,
-		validator: validator.New(),
+		service:       service,
+		maxUploadSize: defaultMaxUploadSize,
 	}
 }
 
+// SetUploadLimit caps profile picture, cover and photo uploads, in bytes
+func (h *Handler) SetUploadLimit(maxBytes int64) {
+	h.maxUploadSize = maxBytes
+}
+
 // GetMyProfile handles getting current user's profile
 func (h *Handler) GetMyProfile(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	profile, err := h.service.GetMyProfile(r.Context(), userID)
 	if err != nil {
@@ -46,11 +65,14 @@ func (h *Handler) GetMyProfile(w http.ResponseWriter, r *http.Request) {
 
 // GetUserProfile handles getting another user's profile
 func (h *Handler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
-	viewerID := r.Context().Value("user_id").(int64)
+	viewerID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 	
 	// Get user ID from URL parameter
-	userIDStr := chi.URLParam(r, "id")
-	userID, err := strconv.ParseInt(userIDStr, 10, 64)
+	userID, err := utils.PathInt64(r, "id")
 	if err != nil {
 		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
 		return
@@ -75,22 +97,23 @@ func (h *Handler) GetUserProfile(w http.ResponseWriter, r *http.Request) {
 
 // UpdateProfile handles profile updates
 func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
-
-	var req UpdateProfileRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Validate request
-	if err := h.validator.Struct(req); err != nil {
-		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+	var req UpdateProfileRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
 	profile, err := h.service.UpdateProfile(r.Context(), userID, &req)
 	if err != nil {
+		if errors.Is(err, textfilter.ErrContentRejected) || errors.Is(err, interests.ErrUnknownInterest) {
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.ErrorResponse(w, "Failed to update profile", http.StatusInternalServerError)
 		return
 	}
@@ -100,22 +123,23 @@ func (h *Handler) UpdateProfile(w http.ResponseWriter, r *http.Request) {
 
 // SetupProfile handles initial profile setup
 func (h *Handler) SetupProfile(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
-
-	var req ProfileSetupRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Validate request
-	if err := h.validator.Struct(req); err != nil {
-		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+	var req ProfileSetupRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
 	profile, err := h.service.SetupProfile(r.Context(), userID, &req)
 	if err != nil {
+		if errors.Is(err, textfilter.ErrContentRejected) || errors.Is(err, interests.ErrUnknownInterest) {
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		utils.ErrorResponse(w, "Failed to setup profile", http.StatusInternalServerError)
 		return
 	}
@@ -125,11 +149,17 @@ func (h *Handler) SetupProfile(w http.ResponseWriter, r *http.Request) {
 
 // UploadProfilePicture handles profile picture upload
 func (h *Handler) UploadProfilePicture(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	// Parse multipart form (max 10MB)
-	err := r.ParseMultipartForm(10 << 20)
-	if err != nil {
+	if err := utils.ParseUpload(w, r, h.maxUploadSize); err != nil {
+		if utils.IsTooLarge(err) {
+			utils.ErrorResponse(w, utils.UploadTooLargeMessage(h.maxUploadSize), http.StatusRequestEntityTooLarge)
+			return
+		}
 		utils.ErrorResponse(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
@@ -144,13 +174,16 @@ func (h *Handler) UploadProfilePicture(w http.ResponseWriter, r *http.Request) {
 	url, err := h.service.UploadProfilePicture(r.Context(), userID, file, header)
 	if err != nil {
 		if errors.Is(err, ErrImageTooLarge) {
-			utils.ErrorResponse(w, "Image size exceeds 5MB limit", http.StatusBadRequest)
+			utils.ErrorResponse(w, "Image size exceeds 5MB limit", http.StatusRequestEntityTooLarge)
 			return
 		}
 		if errors.Is(err, ErrInvalidImageFormat) {
 			utils.ErrorResponse(w, "Invalid image format. Supported: JPG, PNG, GIF, WebP", http.StatusBadRequest)
 			return
 		}
+		if moderation.RespondUploadError(w, err) {
+			return
+		}
 		utils.ErrorResponse(w, "Failed to upload profile picture", http.StatusInternalServerError)
 		return
 	}
@@ -163,11 +196,17 @@ func (h *Handler) UploadProfilePicture(w http.ResponseWriter, r *http.Request) {
 
 // UploadCoverPhoto handles cover photo upload
 func (h *Handler) UploadCoverPhoto(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
-	// Parse multipart form (max 10MB)
-	err := r.ParseMultipartForm(10 << 20)
-	if err != nil {
+	if err := utils.ParseUpload(w, r, h.maxUploadSize); err != nil {
+		if utils.IsTooLarge(err) {
+			utils.ErrorResponse(w, utils.UploadTooLargeMessage(h.maxUploadSize), http.StatusRequestEntityTooLarge)
+			return
+		}
 		utils.ErrorResponse(w, "Failed to parse form", http.StatusBadRequest)
 		return
 	}
@@ -182,13 +221,16 @@ func (h *Handler) UploadCoverPhoto(w http.ResponseWriter, r *http.Request) {
 	url, err := h.service.UploadCoverPhoto(r.Context(), userID, file, header)
 	if err != nil {
 		if errors.Is(err, ErrImageTooLarge) {
-			utils.ErrorResponse(w, "Image size exceeds 5MB limit", http.StatusBadRequest)
+			utils.ErrorResponse(w, "Image size exceeds 5MB limit", http.StatusRequestEntityTooLarge)
 			return
 		}
 		if errors.Is(err, ErrInvalidImageFormat) {
 			utils.ErrorResponse(w, "Invalid image format. Supported: JPG, PNG, GIF, WebP", http.StatusBadRequest)
 			return
 		}
+		if moderation.RespondUploadError(w, err) {
+			return
+		}
 		utils.ErrorResponse(w, "Failed to upload cover photo", http.StatusInternalServerError)
 		return
 	}
@@ -201,7 +243,11 @@ func (h *Handler) UploadCoverPhoto(w http.ResponseWriter, r *http.Request) {
 
 // DeleteProfilePicture handles profile picture deletion
 func (h *Handler) DeleteProfilePicture(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	err := h.service.DeleteProfilePicture(r.Context(), userID)
 	if err != nil {
@@ -214,9 +260,160 @@ func (h *Handler) DeleteProfilePicture(w http.ResponseWriter, r *http.Request) {
 	}, http.StatusOK)
 }
 
+// GetPhotos handles listing the user's photo gallery
+func (h *Handler) GetPhotos(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	photos, err := h.service.GetPhotos(r.Context(), userID)
+	if err != nil {
+		utils.ErrorResponse(w, "Failed to get photos", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, photos, http.StatusOK)
+}
+
+// UploadPhoto handles adding a photo to the gallery
+func (h *Handler) UploadPhoto(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	if err := utils.ParseUpload(w, r, h.maxUploadSize); err != nil {
+		if utils.IsTooLarge(err) {
+			utils.ErrorResponse(w, utils.UploadTooLargeMessage(h.maxUploadSize), http.StatusRequestEntityTooLarge)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to parse form", http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("image")
+	if err != nil {
+		utils.ErrorResponse(w, "No image file provided", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	photo, err := h.service.UploadPhoto(r.Context(), userID, file, header)
+	if err != nil {
+		if errors.Is(err, ErrTooManyPhotos) {
+			utils.ErrorResponse(w, fmt.Sprintf("You can have up to %d photos", MaxProfilePhotos), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, ErrImageTooLarge) {
+			utils.ErrorResponse(w, "Image size exceeds 5MB limit", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if errors.Is(err, ErrInvalidImageFormat) {
+			utils.ErrorResponse(w, "Invalid image format. Supported: JPG, PNG, GIF, WebP", http.StatusBadRequest)
+			return
+		}
+		if moderation.RespondUploadError(w, err) {
+			return
+		}
+		utils.ErrorResponse(w, "Failed to upload photo", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, photo, http.StatusCreated)
+}
+
+// ReorderPhotos handles rearranging the gallery
+func (h *Handler) ReorderPhotos(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	var req ReorderPhotosRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
+		return
+	}
+
+	photos, err := h.service.ReorderPhotos(r.Context(), userID, req.PhotoIDs)
+	if err != nil {
+		if errors.Is(err, ErrInvalidPhotoOrder) {
+			utils.ErrorResponse(w, "List each of your photos exactly once", http.StatusBadRequest)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to reorder photos", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, photos, http.StatusOK)
+}
+
+// SetPrimaryPhoto handles choosing the photo used as the profile picture
+func (h *Handler) SetPrimaryPhoto(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	photoID, err := utils.PathInt64(r, "id")
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.SetPrimaryPhoto(r.Context(), userID, photoID); err != nil {
+		if errors.Is(err, ErrPhotoNotFound) {
+			utils.ErrorResponse(w, "Photo not found", http.StatusNotFound)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to set primary photo", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]string{
+		"message": "Primary photo updated successfully",
+	}, http.StatusOK)
+}
+
+// DeletePhoto handles removing a photo from the gallery
+func (h *Handler) DeletePhoto(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	photoID, err := utils.PathInt64(r, "id")
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid photo ID", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.service.DeletePhoto(r.Context(), userID, photoID); err != nil {
+		if errors.Is(err, ErrPhotoNotFound) {
+			utils.ErrorResponse(w, "Photo not found", http.StatusNotFound)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to delete photo", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]string{
+		"message": "Photo deleted successfully",
+	}, http.StatusOK)
+}
+
 // GetProfileCompletion handles profile completion check
 func (h *Handler) GetProfileCompletion(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	completion, err := h.service.GetProfileCompletion(r.Context(), userID)
 	if err != nil {
@@ -229,17 +426,14 @@ func (h *Handler) GetProfileCompletion(w http.ResponseWriter, r *http.Request) {
 
 // UpdatePrivacySettings handles privacy settings update
 func (h *Handler) UpdatePrivacySettings(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
-
-	var req UpdatePrivacyRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
-	// Validate request
-	if err := h.validator.Struct(req); err != nil {
-		utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+	var req UpdatePrivacyRequest
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
@@ -256,11 +450,14 @@ func (h *Handler) UpdatePrivacySettings(w http.ResponseWriter, r *http.Request)
 
 // UpdateNotificationSettings handles notification settings update
 func (h *Handler) UpdateNotificationSettings(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	var req UpdateNotificationRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		utils.ErrorResponse(w, "Invalid request body", http.StatusBadRequest)
+	if !utils.DecodeAndValidate(w, r, &req) {
 		return
 	}
 
@@ -277,7 +474,11 @@ func (h *Handler) UpdateNotificationSettings(w http.ResponseWriter, r *http.Requ
 
 // GetBlockedUsers handles getting blocked users list
 func (h *Handler) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	blockedIDs, err := h.service.GetBlockedUsers(r.Context(), userID)
 	if err != nil {
@@ -292,11 +493,14 @@ func (h *Handler) GetBlockedUsers(w http.ResponseWriter, r *http.Request) {
 
 // BlockUser handles blocking a user
 func (h *Handler) BlockUser(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	// Get blocked user ID from URL parameter
-	blockedIDStr := chi.URLParam(r, "id")
-	blockedID, err := strconv.ParseInt(blockedIDStr, 10, 64)
+	blockedID, err := utils.PathInt64(r, "id")
 	if err != nil {
 		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
 		return
@@ -323,11 +527,14 @@ func (h *Handler) BlockUser(w http.ResponseWriter, r *http.Request) {
 
 // UnblockUser handles unblocking a user
 func (h *Handler) UnblockUser(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	// Get blocked user ID from URL parameter
-	blockedIDStr := chi.URLParam(r, "id")
-	blockedID, err := strconv.ParseInt(blockedIDStr, 10, 64)
+	blockedID, err := utils.PathInt64(r, "id")
 	if err != nil {
 		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
 		return
@@ -344,9 +551,64 @@ func (h *Handler) UnblockUser(w http.ResponseWriter, r *http.Request) {
 	}, http.StatusOK)
 }
 
+// GetFollowers handles listing a user's followers
+func (h *Handler) GetFollowers(w http.ResponseWriter, r *http.Request) {
+	h.getFollowList(w, r, h.service.GetFollowers, "followers")
+}
+
+// GetFollowing handles listing the users a user follows
+func (h *Handler) GetFollowing(w http.ResponseWriter, r *http.Request) {
+	h.getFollowList(w, r, h.service.GetFollowing, "following")
+}
+
+type followListFunc func(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error)
+
+func (h *Handler) getFollowList(w http.ResponseWriter, r *http.Request, list followListFunc, key string) {
+	viewerID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	userID, err := utils.PathInt64(r, "id")
+	if err != nil {
+		utils.ErrorResponse(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+
+	limit, offset := 20, 0
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 && l <= 100 {
+		limit = l
+	}
+	if o, err := strconv.Atoi(r.URL.Query().Get("offset")); err == nil && o >= 0 {
+		offset = o
+	}
+
+	users, err := list(r.Context(), userID, viewerID, limit, offset)
+	if err != nil {
+		if errors.Is(err, ErrUserBlocked) {
+			utils.ErrorResponse(w, "User is blocked", http.StatusForbidden)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to get "+key, http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]interface{}{
+		key:      users,
+		"count":  len(users),
+		"limit":  limit,
+		"offset": offset,
+	}, http.StatusOK)
+}
+
 // DiscoverProfiles handles profile discovery
 func (h *Handler) DiscoverProfiles(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	// Parse query parameters for filters
 	filter := &DiscoverFilter{
@@ -372,6 +634,7 @@ func (h *Handler) DiscoverProfiles(w http.ResponseWriter, r *http.Request) {
 		dist, _ := strconv.Atoi(distance)
 		filter.MaxDistance = &dist
 	}
+	filter.VerifiedOnly = r.URL.Query().Get("verified_only") == "true"
 	if limit := r.URL.Query().Get("limit"); limit != "" {
 		l, _ := strconv.Atoi(limit)
 		if l > 0 && l <= 100 {
@@ -391,15 +654,32 @@ func (h *Handler) DiscoverProfiles(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	utils.SuccessResponse(w, map[string]interface{}{
+	response := map[string]interface{}{
 		"profiles": profiles,
 		"count":    len(profiles),
-	}, http.StatusOK)
+	}
+
+	// People you may know leads the first page only, and discovery still
+	// works without it
+	if filter.Offset == 0 {
+		known, err := h.service.PeopleYouMayKnow(r.Context(), userID, peopleYouMayKnowLimit)
+		if err != nil {
+			log.Printf("Failed to get people you may know for user %d: %v", userID, err)
+		} else {
+			response["people_you_may_know"] = known
+		}
+	}
+
+	utils.SuccessResponse(w, response, http.StatusOK)
 }
 
 // SearchUsers handles user search
 func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
-	userID := r.Context().Value("user_id").(int64)
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	query := r.URL.Query().Get("q")
 	if query == "" || len(query) < 2 {
@@ -440,11 +720,14 @@ func (h *Handler) SearchUsers(w http.ResponseWriter, r *http.Request) {
 
 // RecordProfileView handles recording a profile view
 func (h *Handler) RecordProfileView(w http.ResponseWriter, r *http.Request) {
-	viewerID := r.Context().Value("user_id").(int64)
+	viewerID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
 
 	// Get profile ID from URL parameter
-	profileIDStr := chi.URLParam(r, "id")
-	profileID, err := strconv.ParseInt(profileIDStr, 10, 64)
+	profileID, err := utils.PathInt64(r, "id")
 	if err != nil {
 		utils.ErrorResponse(w, "Invalid profile ID", http.StatusBadRequest)
 		return
@@ -462,4 +745,53 @@ func (h *Handler) RecordProfileView(w http.ResponseWriter, r *http.Request) {
 	utils.SuccessResponse(w, map[string]string{
 		"message": "Profile view recorded",
 	}, http.StatusOK)
-}
\ No newline at end of file
+}
+// GetViewStats handles profile view counts per day or week
+func (h *Handler) GetViewStats(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	days, _ := strconv.Atoi(r.URL.Query().Get("days"))
+
+	stats, err := h.service.GetViewStats(r.Context(), userID, r.URL.Query().Get("interval"), days)
+	if err != nil {
+		if errors.Is(err, ErrInvalidInterval) {
+			utils.ErrorResponse(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to get profile views", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, stats, http.StatusOK)
+}
+
+// GetProfileViewers handles listing who viewed the user's profile
+func (h *Handler) GetProfileViewers(w http.ResponseWriter, r *http.Request) {
+	userID, ok := auth.UserIDFromContext(r.Context())
+	if !ok {
+		utils.ErrorResponse(w, "Unauthorized", http.StatusUnauthorized)
+		return
+	}
+
+	limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+	offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+	viewers, err := h.service.GetProfileViewers(r.Context(), userID, limit, offset)
+	if err != nil {
+		if errors.Is(err, ErrViewersLocked) {
+			utils.ErrorResponse(w, "Upgrade to premium to see who viewed your profile", http.StatusPaymentRequired)
+			return
+		}
+		utils.ErrorResponse(w, "Failed to get profile viewers", http.StatusInternalServerError)
+		return
+	}
+
+	utils.SuccessResponse(w, map[string]interface{}{
+		"viewers": viewers,
+		"count":   len(viewers),
+	}, http.StatusOK)
+}
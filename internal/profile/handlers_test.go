@@ -0,0 +1,122 @@
+package profile
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/imadgeboyega/kiekky-backend/internal/auth"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// fakeAuth accepts any bearer token and authenticates it as user 1
+type fakeAuth struct {
+	auth.Service
+}
+
+func (fakeAuth) ValidateToken(ctx context.Context, token string) (*utils.JWTClaims, error) {
+	return &utils.JWTClaims{UserID: 1, Type: "access"}, nil
+}
+
+func (fakeAuth) CheckSession(ctx context.Context, claims *utils.JWTClaims) error { return nil }
+
+func (fakeAuth) TouchActivity(ctx context.Context, userID int64) {}
+
+// fakeService records the IDs it was called with and returns err
+type fakeService struct {
+	Service
+	err error
+
+	userID, targetID int64
+}
+
+func (s *fakeService) GetProfile(ctx context.Context, userID int64, viewerID int64) (*Profile, error) {
+	s.userID, s.targetID = viewerID, userID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &Profile{UserID: userID, Username: "someone"}, nil
+}
+
+func (s *fakeService) BlockUser(ctx context.Context, userID int64, blockedID int64) error {
+	s.userID, s.targetID = userID, blockedID
+	return s.err
+}
+
+func (s *fakeService) UnblockUser(ctx context.Context, userID int64, blockedID int64) error {
+	s.userID, s.targetID = userID, blockedID
+	return s.err
+}
+
+func serve(t *testing.T, service *fakeService, method, path string, authenticated bool) (*httptest.ResponseRecorder, utils.Response) {
+	t.Helper()
+
+	router := mux.NewRouter()
+	RegisterRoutes(router, NewHandler(service), auth.NewMiddleware(fakeAuth{}))
+
+	req := httptest.NewRequest(method, path, nil)
+	if authenticated {
+		req.Header.Set("Authorization", "Bearer token")
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+
+	var body utils.Response
+	if err := json.NewDecoder(rec.Body).Decode(&body); err != nil {
+		t.Fatalf("%s %s: decoding response: %v", method, path, err)
+	}
+	return rec, body
+}
+
+func TestUserIDRoutes(t *testing.T) {
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		err        error
+		unauth     bool
+		wantStatus int
+		wantError  string
+		wantTarget int64 // ID the service should get from the path
+	}{
+		{name: "get profile", method: "GET", path: "/api/v1/users/42/profile", wantStatus: http.StatusOK, wantTarget: 42},
+		{name: "get missing profile", method: "GET", path: "/api/v1/users/42/profile", err: ErrProfileNotFound, wantStatus: http.StatusNotFound, wantError: "Profile not found", wantTarget: 42},
+		{name: "get blocked profile", method: "GET", path: "/api/v1/users/42/profile", err: ErrUserBlocked, wantStatus: http.StatusForbidden, wantError: "User is blocked", wantTarget: 42},
+		{name: "get profile with bad ID", method: "GET", path: "/api/v1/users/abc/profile", wantStatus: http.StatusBadRequest, wantError: "Invalid user ID"},
+		{name: "get profile unauthenticated", method: "GET", path: "/api/v1/users/42/profile", unauth: true, wantStatus: http.StatusUnauthorized},
+
+		{name: "block", method: "POST", path: "/api/v1/users/7/block", wantStatus: http.StatusOK, wantTarget: 7},
+		{name: "block self", method: "POST", path: "/api/v1/users/1/block", err: ErrCannotBlockSelf, wantStatus: http.StatusBadRequest, wantError: "Cannot block yourself", wantTarget: 1},
+		{name: "block twice", method: "POST", path: "/api/v1/users/7/block", err: ErrAlreadyBlocked, wantStatus: http.StatusBadRequest, wantError: "User is already blocked", wantTarget: 7},
+		{name: "block failure", method: "POST", path: "/api/v1/users/7/block", err: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantError: "Failed to block user", wantTarget: 7},
+		{name: "block with bad ID", method: "POST", path: "/api/v1/users/7x/block", wantStatus: http.StatusBadRequest, wantError: "Invalid user ID"},
+
+		{name: "unblock", method: "DELETE", path: "/api/v1/users/7/block", wantStatus: http.StatusOK, wantTarget: 7},
+		{name: "unblock failure", method: "DELETE", path: "/api/v1/users/7/block", err: errors.New("db down"), wantStatus: http.StatusInternalServerError, wantError: "Failed to unblock user", wantTarget: 7},
+		{name: "unblock with bad ID", method: "DELETE", path: "/api/v1/users/-/block", wantStatus: http.StatusBadRequest, wantError: "Invalid user ID"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &fakeService{err: tt.err}
+			rec, body := serve(t, service, tt.method, tt.path, !tt.unauth)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (error %q)", rec.Code, tt.wantStatus, body.Error)
+			}
+			if tt.wantError != "" && body.Error != tt.wantError {
+				t.Errorf("error = %q, want %q", body.Error, tt.wantError)
+			}
+			if service.targetID != tt.wantTarget {
+				t.Errorf("service got target %d, want %d", service.targetID, tt.wantTarget)
+			}
+			if tt.wantTarget != 0 && service.userID != 1 {
+				t.Errorf("service got user %d, want the authenticated user 1", service.userID)
+			}
+		})
+	}
+}
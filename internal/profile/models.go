@@ -6,6 +6,11 @@ import (
 	"database/sql/driver"
 	"encoding/json"
 	"time"
+
+	"github.com/lib/pq"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/common/media"
+	"github.com/imadgeboyega/kiekky-backend/internal/users"
 )
 
 // Profile represents a user's profile
@@ -33,29 +38,58 @@ type Profile struct {
 	Instagram           *string            `json:"instagram" db:"instagram"`
 	Twitter             *string            `json:"twitter" db:"twitter"`
 	Website             *string            `json:"website" db:"website"`
+	Timezone            string             `json:"timezone" db:"timezone"`
+	PreferredLanguage   string             `json:"preferred_language" db:"preferred_language"`
 	PrivacySettings     PrivacySettings    `json:"privacy_settings" db:"privacy_settings"`
 	NotificationSettings NotificationSettings `json:"notification_settings" db:"notification_settings"`
 	EmailVerified       bool               `json:"email_verified" db:"email_verified"`
 	PhoneVerified       bool               `json:"phone_verified" db:"phone_verified"`
+	IsPhotoVerified     bool               `json:"is_photo_verified" db:"is_photo_verified"`
 	CompletionPercentage int               `json:"completion_percentage"`
-	LastActive          time.Time          `json:"last_active" db:"last_active"`
+	FollowersCount      int                `json:"followers_count" db:"followers_count"`
+	FollowingCount      int                `json:"following_count" db:"following_count"`
+	IsFollowing         bool               `json:"is_following"`    // viewer follows this user
+	IsFollowedBy        bool               `json:"is_followed_by"` // this user follows the viewer
+	Prompts             []*PromptAnswer    `json:"prompts"`
+	Photos              []*Photo           `json:"photos"`
+	LastActive          *time.Time         `json:"last_active,omitempty" db:"last_active"`
 	CreatedAt           time.Time          `json:"created_at" db:"created_at"`
 	UpdatedAt           time.Time          `json:"updated_at" db:"updated_at"`
 }
 
+// MarshalJSON signs the photos of profiles that aren't public
+func (p Profile) MarshalJSON() ([]byte, error) {
+	type profile Profile
+	out := profile(p)
+	if p.PrivacySettings.ProfileVisibility != "" && p.PrivacySettings.ProfileVisibility != "public" {
+		out.ProfilePicture = media.SignURLPtr(p.ProfilePicture)
+		out.CoverPhoto = media.SignURLPtr(p.CoverPhoto)
+		out.Photos = make([]*Photo, len(p.Photos))
+		for i, photo := range p.Photos {
+			signed := *photo
+			signed.URL = media.SignURL(photo.URL)
+			out.Photos[i] = &signed
+		}
+	}
+	return json.Marshal(out)
+}
+
 // PrivacySettings represents user privacy preferences
 type PrivacySettings struct {
 	ProfileVisibility   string `json:"profile_visibility"` // public, friends, private
 	ShowEmail          bool   `json:"show_email"`
 	ShowPhone          bool   `json:"show_phone"`
 	ShowLocation       bool   `json:"show_location"`
-	ShowOnlineStatus   bool   `json:"show_online_status"`
+	ShowOnlineStatus   bool   `json:"show_online_status"` // also covers last active; on unless turned off
 	AllowMessages      string `json:"allow_messages"` // everyone, friends, none
 	AllowProfileViews  bool   `json:"allow_profile_views"`
+	HideFromContacts   bool   `json:"hide_from_contacts"` // not found by phone number in contact sync
 }
 
-// Scan implements the sql.Scanner interface for PrivacySettings
+// Scan implements the sql.Scanner interface for PrivacySettings. Online
+// status is shown to users who never set it.
 func (p *PrivacySettings) Scan(value interface{}) error {
+	*p = PrivacySettings{ShowOnlineStatus: true}
 	if value == nil {
 		return nil
 	}
@@ -117,6 +151,8 @@ type UpdateProfileRequest struct {
 	Instagram          *string              `json:"instagram" validate:"omitempty,max=50"`
 	Twitter            *string              `json:"twitter" validate:"omitempty,max=50"`
 	Website            *string              `json:"website" validate:"omitempty,url,max=200"`
+	Timezone           *string              `json:"timezone" validate:"omitempty,timezone"`
+	PreferredLanguage  *string              `json:"preferred_language" validate:"omitempty,language"`
 }
 
 // ProfileSetupRequest represents initial profile setup
@@ -138,6 +174,7 @@ type UpdatePrivacyRequest struct {
 	ShowOnlineStatus  *bool   `json:"show_online_status"`
 	AllowMessages     *string `json:"allow_messages" validate:"omitempty,oneof=everyone friends none"`
 	AllowProfileViews *bool   `json:"allow_profile_views"`
+	HideFromContacts  *bool   `json:"hide_from_contacts"`
 }
 
 // UpdateNotificationRequest represents notification settings update
@@ -160,6 +197,31 @@ type ProfileView struct {
 	ViewedAt   time.Time `json:"viewed_at" db:"viewed_at"`
 }
 
+// ViewStats counts the views of a profile since a point in time, split into
+// days or weeks. Repeat views by the same viewer close together count once.
+type ViewStats struct {
+	Interval      string        `json:"interval"` // day or week
+	Since         time.Time     `json:"since"`
+	TotalViews    int           `json:"total_views" db:"total_views"`
+	UniqueViewers int           `json:"unique_viewers" db:"unique_viewers"`
+	Buckets       []*ViewBucket `json:"buckets"`
+}
+
+// ViewBucket is one day or week of profile views
+type ViewBucket struct {
+	Start         time.Time `json:"start" db:"start"`
+	Views         int       `json:"views" db:"views"`
+	UniqueViewers int       `json:"unique_viewers" db:"unique_viewers"`
+}
+
+// ProfileViewer is someone who viewed the user's profile, most recent first.
+// Only premium users see who they are.
+type ProfileViewer struct {
+	users.Identity
+	Views        int       `json:"views" db:"views"`
+	LastViewedAt time.Time `json:"last_viewed_at" db:"last_viewed_at"`
+}
+
 // BlockedUser represents a blocked user record
 type BlockedUser struct {
 	ID          int64     `json:"id" db:"id"`
@@ -168,9 +230,47 @@ type BlockedUser struct {
 	BlockedAt   time.Time `json:"blocked_at" db:"blocked_at"`
 }
 
+// PromptAnswer is an icebreaker prompt the user answered, in display order
+type PromptAnswer struct {
+	PromptID int64  `json:"prompt_id" db:"prompt_id"`
+	Prompt   string `json:"prompt" db:"prompt"`
+	Answer   string `json:"answer" db:"answer"`
+	Position int    `json:"position" db:"position"`
+}
+
+// Photo is one of the photos in a user's gallery, in display order. The
+// primary photo is also the user's profile picture.
+type Photo struct {
+	ID        int64     `json:"id" db:"id"`
+	UserID    int64     `json:"-" db:"user_id"`
+	URL       string    `json:"url" db:"url"`
+	Position  int       `json:"position" db:"position"`
+	IsPrimary bool      `json:"is_primary" db:"is_primary"`
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ReorderPhotosRequest lists every photo in the gallery in its new order
+type ReorderPhotosRequest struct {
+	PhotoIDs []int64 `json:"photo_ids" validate:"required,min=1"`
+}
+
+// FollowUser represents a user in a followers or following list.
+// IsFollowing and IsFollowedBy are relative to the viewer.
+type FollowUser struct {
+	UserID         int64     `json:"user_id" db:"user_id"`
+	Username       string    `json:"username" db:"username"`
+	DisplayName    *string   `json:"display_name" db:"display_name"`
+	ProfilePicture *string   `json:"profile_picture" db:"profile_picture"`
+	FollowedAt     time.Time `json:"followed_at" db:"followed_at"`
+	IsFollowing    bool      `json:"is_following" db:"is_following"`
+	IsFollowedBy   bool      `json:"is_followed_by" db:"is_followed_by"`
+	IsMutual       bool      `json:"is_mutual"`
+}
+
 // DiscoverFilter represents filters for discovering profiles
 type DiscoverFilter struct {
 	Gender             *string  `json:"gender"`
+	Genders            []string `json:"genders"` // any of these, when Gender isn't set
 	MinAge             *int     `json:"min_age"`
 	MaxAge             *int     `json:"max_age"`
 	Location           *string  `json:"location"`
@@ -178,10 +278,22 @@ type DiscoverFilter struct {
 	Interests          []string `json:"interests"`
 	RelationshipStatus *string  `json:"relationship_status"`
 	LookingFor         *string  `json:"looking_for"`
+	VerifiedOnly       bool     `json:"verified_only"` // only photo-verified profiles
 	Limit              int      `json:"limit"`
 	Offset             int      `json:"offset"`
 }
 
+// DatingPreferences are the discovery filters a user saved through the
+// dating preferences API. Discovery falls back to them for any filter a
+// request leaves out.
+type DatingPreferences struct {
+	MinAge        int            `db:"min_age"`
+	MaxAge        int            `db:"max_age"`
+	MaxDistanceKm int            `db:"max_distance_km"`
+	Genders       pq.StringArray `db:"genders"`
+	Intent        *string        `db:"intent"`
+}
+
 // SearchFilter represents filters for searching users
 type SearchFilter struct {
 	Query  string `json:"query" validate:"required,min=2"`
@@ -195,6 +307,18 @@ type ProfileCompletion struct {
 	Missing    []string                  `json:"missing_fields"`
 	Completed  []string                  `json:"completed_fields"`
 	Details    ProfileCompletionDetails `json:"details"`
+
+	// Discovery lists the profile once it reaches Threshold and has enough
+	// photos; Prompts say what's still needed
+	Threshold    int                 `json:"threshold"`
+	Discoverable bool                `json:"discoverable"`
+	Prompts      []*CompletionPrompt `json:"prompts"`
+}
+
+// CompletionPrompt is something the user can do to be shown in discovery
+type CompletionPrompt struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
 }
 
 // ProfileCompletionDetails represents detailed completion status
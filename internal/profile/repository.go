@@ -11,6 +11,9 @@ import (
 
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/blocks"
+	"github.com/imadgeboyega/kiekky-backend/internal/users"
 )
 
 // Repository defines the profile repository interface
@@ -20,24 +23,43 @@ type Repository interface {
 	UpdateProfile(ctx context.Context, userID int64, req *UpdateProfileRequest, dob *time.Time) (*Profile, error)
 	UpdateProfilePicture(ctx context.Context, userID int64, url string) error
 	UpdateCoverPhoto(ctx context.Context, userID int64, url string) error
+	SetProfileComplete(ctx context.Context, userID int64, complete bool) error
 	
 	// Settings
 	UpdatePrivacySettings(ctx context.Context, userID int64, req *UpdatePrivacyRequest) error
 	UpdateNotificationSettings(ctx context.Context, userID int64, req *UpdateNotificationRequest) error
 	
-	// Blocking
-	BlockUser(ctx context.Context, userID int64, blockedID int64) error
-	UnblockUser(ctx context.Context, userID int64, blockedID int64) error
-	GetBlockedUsers(ctx context.Context, userID int64) ([]int64, error)
-	IsBlocked(ctx context.Context, userID int64, targetID int64) (bool, error)
+	
+	// Follows
+	GetFollowers(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error)
+	GetFollowing(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error)
+	GetFollowRelation(ctx context.Context, viewerID int64, userID int64) (isFollowing bool, isFollowedBy bool, err error)
+	
+	// Prompts
+	GetPromptAnswers(ctx context.Context, userID int64) ([]*PromptAnswer, error)
+	
+	// Photos
+	GetPhotos(ctx context.Context, userID int64) ([]*Photo, error)
+	GetPhotosForUsers(ctx context.Context, userIDs []int64) (map[int64][]*Photo, error)
+	AddPhoto(ctx context.Context, userID int64, url string, maxPhotos int) (*Photo, error)
+	SetPrimaryPhoto(ctx context.Context, userID, photoID int64) error
+	ReorderPhotos(ctx context.Context, userID int64, photoIDs []int64) error
+	DeletePhoto(ctx context.Context, userID, photoID int64) (*Photo, error)
 	
 	// Discovery & Search
+	GetDatingPreferences(ctx context.Context, userID int64) (*DatingPreferences, error)
 	DiscoverProfiles(ctx context.Context, userID int64, filter *DiscoverFilter, excludeIDs []int64) ([]*Profile, error)
 	SearchUsers(ctx context.Context, filter *SearchFilter, excludeIDs []int64) ([]*Profile, error)
 	
 	// Profile Views
-	RecordProfileView(ctx context.Context, viewerID int64, profileID int64) error
+	RecordProfileView(ctx context.Context, viewerID int64, profileID int64, window time.Duration) error
 	GetProfileViews(ctx context.Context, userID int64, limit int) ([]*ProfileView, error)
+	GetViewStats(ctx context.Context, userID int64, interval string, since time.Time) (*ViewStats, error)
+	GetProfileViewers(ctx context.Context, userID int64, limit, offset int) ([]*ProfileViewer, error)
+	IsPremium(ctx context.Context, userID int64) (bool, error)
+	
+	// Counters
+	ReconcileFollowCounts(ctx context.Context) (int64, error)
 }
 
 // postgresRepository implements Repository using PostgreSQL
@@ -61,9 +83,13 @@ func (r *postgresRepository) GetProfileByUserID(ctx context.Context, userID int6
 			u.interests, u.looking_for, u.relationship_status,
 			u.height, u.education, u.work, u.languages,
 			u.instagram, u.twitter, u.website,
+			COALESCE(u.timezone, 'UTC') AS timezone,
+			COALESCE(u.preferred_language, 'en') AS preferred_language,
 			u.privacy_settings, u.notification_settings,
 			u.email_verified, u.phone_verified,
-			u.last_active, u.created_at, u.updated_at
+			COALESCE(u.is_photo_verified, false) AS is_photo_verified,
+			u.last_active, u.created_at, u.updated_at,
+			u.followers_count, u.following_count
 		FROM users u
 		WHERE u.id = $1`
 
@@ -170,6 +196,16 @@ func (r *postgresRepository) UpdateProfile(ctx context.Context, userID int64, re
 		args = append(args, *req.Website)
 		argCount++
 	}
+	if req.Timezone != nil {
+		setClauses = append(setClauses, fmt.Sprintf("timezone = $%d", argCount))
+		args = append(args, *req.Timezone)
+		argCount++
+	}
+	if req.PreferredLanguage != nil {
+		setClauses = append(setClauses, fmt.Sprintf("preferred_language = $%d", argCount))
+		args = append(args, *req.PreferredLanguage)
+		argCount++
+	}
 
 	// Always update updated_at
 	setClauses = append(setClauses, fmt.Sprintf("updated_at = $%d", argCount))
@@ -211,6 +247,22 @@ func (r *postgresRepository) UpdateProfilePicture(ctx context.Context, userID in
 	return err
 }
 
+// SetProfileComplete flags whether the profile is complete enough to be
+// listed in discovery. The first time it is, profile_completed_at is set.
+func (r *postgresRepository) SetProfileComplete(ctx context.Context, userID int64, complete bool) error {
+	query := `
+		UPDATE users
+		SET is_profile_complete = $2,
+			profile_completed_at = CASE WHEN $2 THEN COALESCE(profile_completed_at, NOW()) ELSE profile_completed_at END,
+			updated_at = NOW()
+		WHERE id = $1 AND COALESCE(is_profile_complete, false) <> $2`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, complete); err != nil {
+		return fmt.Errorf("failed to set profile complete: %w", err)
+	}
+	return nil
+}
+
 // UpdateCoverPhoto updates the cover photo URL
 func (r *postgresRepository) UpdateCoverPhoto(ctx context.Context, userID int64, url string) error {
 	query := `UPDATE users SET cover_photo = $1, updated_at = $2 WHERE id = $3`
@@ -258,6 +310,9 @@ func (r *postgresRepository) UpdatePrivacySettings(ctx context.Context, userID i
 	if req.AllowProfileViews != nil {
 		current.AllowProfileViews = *req.AllowProfileViews
 	}
+	if req.HideFromContacts != nil {
+		current.HideFromContacts = *req.HideFromContacts
+	}
 
 	// Save updated settings
 	updateQuery := `UPDATE users SET privacy_settings = $1, updated_at = $2 WHERE id = $3`
@@ -307,61 +362,339 @@ func (r *postgresRepository) UpdateNotificationSettings(ctx context.Context, use
 	return err
 }
 
-// BlockUser creates a block record
-func (r *postgresRepository) BlockUser(ctx context.Context, userID int64, blockedID int64) error {
-	query := `INSERT INTO blocked_users (user_id, blocked_id, blocked_at) VALUES ($1, $2, $3)`
-	_, err := r.db.ExecContext(ctx, query, userID, blockedID, time.Now())
-	return err
+// followListQuery lists one side of a user's follows. %[1]s is the column
+// matching the listed user and %[2]s the column holding the other side.
+// Users blocked in either direction with the viewer are left out.
+const followListQuery = `
+		SELECT 
+			u.id AS user_id, u.username, u.display_name, u.profile_picture,
+			f.created_at AS followed_at,
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = u.id) AS is_following,
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = u.id AND following_id = $2) AS is_followed_by
+		FROM follows f
+		JOIN users u ON u.id = f.%[2]s
+		WHERE f.%[1]s = $1
+		AND NOT EXISTS (
+			SELECT 1 FROM blocked_users b
+			WHERE (b.user_id = $2 AND b.blocked_id = u.id)
+			   OR (b.user_id = u.id AND b.blocked_id = $2)
+		)
+		ORDER BY f.created_at DESC, u.id DESC
+		LIMIT $3 OFFSET $4`
+
+// GetFollowers retrieves the users following userID
+func (r *postgresRepository) GetFollowers(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error) {
+	users := []*FollowUser{}
+	query := fmt.Sprintf(followListQuery, "following_id", "follower_id")
+
+	err := r.db.SelectContext(ctx, &users, query, userID, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get followers: %w", err)
+	}
+	return users, nil
 }
 
-// UnblockUser removes a block record
-func (r *postgresRepository) UnblockUser(ctx context.Context, userID int64, blockedID int64) error {
-	query := `DELETE FROM blocked_users WHERE user_id = $1 AND blocked_id = $2`
-	_, err := r.db.ExecContext(ctx, query, userID, blockedID)
-	return err
+// GetFollowing retrieves the users userID follows
+func (r *postgresRepository) GetFollowing(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error) {
+	users := []*FollowUser{}
+	query := fmt.Sprintf(followListQuery, "follower_id", "following_id")
+
+	err := r.db.SelectContext(ctx, &users, query, userID, viewerID, limit, offset)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get following: %w", err)
+	}
+	return users, nil
 }
 
-// GetBlockedUsers retrieves list of blocked user IDs
-func (r *postgresRepository) GetBlockedUsers(ctx context.Context, userID int64) ([]int64, error) {
-	var blockedIDs []int64
-	query := `SELECT blocked_id FROM blocked_users WHERE user_id = $1`
-	
-	err := r.db.SelectContext(ctx, &blockedIDs, query, userID)
+// GetPromptAnswers returns the user's icebreaker answers in display order
+func (r *postgresRepository) GetPromptAnswers(ctx context.Context, userID int64) ([]*PromptAnswer, error) {
+	query := `
+		SELECT a.prompt_id, p.text AS prompt, a.answer, a.position
+		FROM user_prompt_answers a
+		JOIN profile_prompts p ON p.id = a.prompt_id
+		WHERE a.user_id = $1
+		ORDER BY a.position`
+
+	answers := []*PromptAnswer{}
+	if err := r.db.SelectContext(ctx, &answers, query, userID); err != nil {
+		return nil, err
+	}
+	return answers, nil
+}
+
+// GetPhotos returns the user's gallery in display order
+func (r *postgresRepository) GetPhotos(ctx context.Context, userID int64) ([]*Photo, error) {
+	query := `
+		SELECT id, user_id, url, position, is_primary, created_at
+		FROM profile_photos
+		WHERE user_id = $1
+		ORDER BY position`
+
+	photos := []*Photo{}
+	if err := r.db.SelectContext(ctx, &photos, query, userID); err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+	return photos, nil
+}
+
+// GetPhotosForUsers returns the galleries of several users, keyed by user
+func (r *postgresRepository) GetPhotosForUsers(ctx context.Context, userIDs []int64) (map[int64][]*Photo, error) {
+	query := `
+		SELECT id, user_id, url, position, is_primary, created_at
+		FROM profile_photos
+		WHERE user_id = ANY($1)
+		ORDER BY user_id, position`
+
+	var photos []*Photo
+	if err := r.db.SelectContext(ctx, &photos, query, pq.Array(userIDs)); err != nil {
+		return nil, fmt.Errorf("failed to get photos: %w", err)
+	}
+
+	byUser := make(map[int64][]*Photo, len(userIDs))
+	for _, photo := range photos {
+		byUser[photo.UserID] = append(byUser[photo.UserID], photo)
+	}
+	return byUser, nil
+}
+
+// AddPhoto appends a photo to the end of the user's gallery, failing with
+// ErrTooManyPhotos once it holds maxPhotos. The first photo becomes primary.
+func (r *postgresRepository) AddPhoto(ctx context.Context, userID int64, url string, maxPhotos int) (*Photo, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
-	
-	return blockedIDs, nil
+	defer tx.Rollback()
+
+	// Lock the user so concurrent uploads can't both take the last slot
+	if _, err := tx.ExecContext(ctx, `SELECT 1 FROM users WHERE id = $1 FOR UPDATE`, userID); err != nil {
+		return nil, err
+	}
+
+	var count int
+	if err := tx.GetContext(ctx, &count, `SELECT COUNT(*) FROM profile_photos WHERE user_id = $1`, userID); err != nil {
+		return nil, err
+	}
+	if count >= maxPhotos {
+		return nil, ErrTooManyPhotos
+	}
+
+	var photo Photo
+	query := `
+		INSERT INTO profile_photos (user_id, url, position, is_primary)
+		VALUES ($1, $2, $3, $4)
+		RETURNING id, user_id, url, position, is_primary, created_at`
+	if err := tx.GetContext(ctx, &photo, query, userID, url, count, count == 0); err != nil {
+		return nil, fmt.Errorf("failed to add photo: %w", err)
+	}
+
+	if photo.IsPrimary {
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET profile_picture = $1, updated_at = NOW() WHERE id = $2`, url, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &photo, tx.Commit()
 }
 
-// IsBlocked checks if a user is blocked
-func (r *postgresRepository) IsBlocked(ctx context.Context, userID int64, targetID int64) (bool, error) {
-	var exists bool
-	query := `SELECT EXISTS(SELECT 1 FROM blocked_users WHERE user_id = $1 AND blocked_id = $2)`
-	
-	err := r.db.GetContext(ctx, &exists, query, userID, targetID)
-	return exists, err
+// SetPrimaryPhoto makes one of the user's photos primary and their profile
+// picture
+func (r *postgresRepository) SetPrimaryPhoto(ctx context.Context, userID, photoID int64) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `UPDATE profile_photos SET is_primary = FALSE WHERE user_id = $1 AND is_primary`, userID); err != nil {
+		return err
+	}
+
+	var url string
+	err = tx.GetContext(ctx, &url, `
+		UPDATE profile_photos SET is_primary = TRUE
+		WHERE id = $1 AND user_id = $2
+		RETURNING url`, photoID, userID)
+	if err == sql.ErrNoRows {
+		return ErrPhotoNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("failed to set primary photo: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `UPDATE users SET profile_picture = $1, updated_at = NOW() WHERE id = $2`, url, userID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ReorderPhotos positions the user's photos in the order of photoIDs, which
+// the caller has checked lists each of them once
+func (r *postgresRepository) ReorderPhotos(ctx context.Context, userID int64, photoIDs []int64) error {
+	query := `
+		UPDATE profile_photos
+		SET position = array_position($2::bigint[], id::bigint) - 1
+		WHERE user_id = $1 AND id = ANY($2)`
+
+	if _, err := r.db.ExecContext(ctx, query, userID, pq.Array(photoIDs)); err != nil {
+		return fmt.Errorf("failed to reorder photos: %w", err)
+	}
+	return nil
+}
+
+// DeletePhoto removes a photo from the user's gallery and closes the gap it
+// leaves. When it was primary, the first remaining photo takes its place.
+func (r *postgresRepository) DeletePhoto(ctx context.Context, userID, photoID int64) (*Photo, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var photo Photo
+	err = tx.GetContext(ctx, &photo, `
+		DELETE FROM profile_photos
+		WHERE id = $1 AND user_id = $2
+		RETURNING id, user_id, url, position, is_primary, created_at`, photoID, userID)
+	if err == sql.ErrNoRows {
+		return nil, ErrPhotoNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to delete photo: %w", err)
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		UPDATE profile_photos SET position = position - 1
+		WHERE user_id = $1 AND position > $2`, userID, photo.Position); err != nil {
+		return nil, err
+	}
+
+	if photo.IsPrimary {
+		var url sql.NullString
+		err := tx.GetContext(ctx, &url, `
+			UPDATE profile_photos SET is_primary = TRUE
+			WHERE id = (SELECT id FROM profile_photos WHERE user_id = $1 ORDER BY position LIMIT 1)
+			RETURNING url`, userID)
+		if err != nil && err != sql.ErrNoRows {
+			return nil, err
+		}
+		if _, err := tx.ExecContext(ctx, `UPDATE users SET profile_picture = $1, updated_at = NOW() WHERE id = $2`, url, userID); err != nil {
+			return nil, err
+		}
+	}
+
+	return &photo, tx.Commit()
+}
+
+// GetFollowRelation reports whether the viewer follows userID and whether userID follows the viewer
+func (r *postgresRepository) GetFollowRelation(ctx context.Context, viewerID int64, userID int64) (bool, bool, error) {
+	var relation struct {
+		IsFollowing  bool `db:"is_following"`
+		IsFollowedBy bool `db:"is_followed_by"`
+	}
+	query := `
+		SELECT 
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = $1 AND following_id = $2) AS is_following,
+			EXISTS(SELECT 1 FROM follows WHERE follower_id = $2 AND following_id = $1) AS is_followed_by`
+
+	err := r.db.GetContext(ctx, &relation, query, viewerID, userID)
+	return relation.IsFollowing, relation.IsFollowedBy, err
+}
+
+// GetDatingPreferences gets the user's saved dating preferences, or nil if
+// they haven't saved any
+func (r *postgresRepository) GetDatingPreferences(ctx context.Context, userID int64) (*DatingPreferences, error) {
+	var prefs DatingPreferences
+	query := `
+		SELECT min_age, max_age, max_distance_km, genders, intent
+		FROM dating_preferences
+		WHERE user_id = $1`
+
+	err := r.db.GetContext(ctx, &prefs, query, userID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &prefs, nil
 }
 
 // DiscoverProfiles implements profile discovery with filters
 func (r *postgresRepository) DiscoverProfiles(ctx context.Context, userID int64, filter *DiscoverFilter, excludeIDs []int64) ([]*Profile, error) {
-	// Implementation would include complex filtering logic
-	// This is a simplified version
 	query := `
 		SELECT 
 			u.id, u.id as user_id, u.username, u.email, u.display_name,
 			u.profile_picture, u.bio, u.gender, u.location,
-			u.interests, u.looking_for, u.relationship_status
+			u.interests, u.looking_for, u.relationship_status,
+			COALESCE(u.is_photo_verified, false) AS is_photo_verified
 		FROM users u
+		LEFT JOIN users me ON me.id = $1
 		WHERE u.id != $1
 		AND u.id != ALL($2)
+		AND ($5::boolean = FALSE OR u.is_photo_verified = TRUE)
+		AND COALESCE(u.is_profile_complete, false)
+		AND (SELECT COUNT(*) FROM profile_photos pp WHERE pp.user_id = u.id) >= $6`
+
+	args := []interface{}{userID, pq.Array(excludeIDs), filter.Limit, filter.Offset, filter.VerifiedOnly, MinDiscoveryPhotos}
+	argCount := len(args)
+
+	if filter.Gender != nil {
+		argCount++
+		query += fmt.Sprintf(" AND u.gender = $%d", argCount)
+		args = append(args, *filter.Gender)
+	} else if len(filter.Genders) > 0 {
+		argCount++
+		query += fmt.Sprintf(" AND u.gender = ANY($%d)", argCount)
+		args = append(args, pq.Array(filter.Genders))
+	}
+
+	if filter.MinAge != nil {
+		argCount++
+		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM AGE(u.date_of_birth)) >= $%d", argCount)
+		args = append(args, *filter.MinAge)
+	}
+
+	if filter.MaxAge != nil {
+		argCount++
+		query += fmt.Sprintf(" AND EXTRACT(YEAR FROM AGE(u.date_of_birth)) <= $%d", argCount)
+		args = append(args, *filter.MaxAge)
+	}
+
+	if filter.LookingFor != nil {
+		argCount++
+		query += fmt.Sprintf(" AND u.looking_for = $%d", argCount)
+		args = append(args, *filter.LookingFor)
+	}
+
+	if filter.MaxDistance != nil {
+		// Measured from the viewer's location; users on either side
+		// without one aren't ruled out
+		argCount++
+		query += fmt.Sprintf(`
+		AND (me.latitude IS NULL OR u.latitude IS NULL OR %s <= $%d)`,
+			distanceSQL("me.latitude", "me.longitude", "u.latitude", "u.longitude"), argCount)
+		args = append(args, *filter.MaxDistance)
+	}
+
+	query += `
 		LIMIT $3 OFFSET $4`
-	
+
 	var profiles []*Profile
-	err := r.db.SelectContext(ctx, &profiles, query, userID, pq.Array(excludeIDs), filter.Limit, filter.Offset)
+	err := r.db.SelectContext(ctx, &profiles, query, args...)
 	return profiles, err
 }
 
+// distanceSQL is the great-circle distance in km between two points given
+// as SQL expressions
+func distanceSQL(lat1, lng1, lat2, lng2 string) string {
+	return fmt.Sprintf(`(6371 * 2 * ASIN(SQRT(
+		POWER(SIN(RADIANS(%[3]s - %[1]s) / 2), 2) +
+		COS(RADIANS(%[1]s)) * COS(RADIANS(%[3]s)) * POWER(SIN(RADIANS(%[4]s - %[2]s) / 2), 2))))`,
+		lat1, lng1, lat2, lng2)
+}
+
 // SearchUsers searches for users
 func (r *postgresRepository) SearchUsers(ctx context.Context, filter *SearchFilter, excludeIDs []int64) ([]*Profile, error) {
 	query := `
@@ -380,10 +713,19 @@ func (r *postgresRepository) SearchUsers(ctx context.Context, filter *SearchFilt
 	return profiles, err
 }
 
-// RecordProfileView records a profile view
-func (r *postgresRepository) RecordProfileView(ctx context.Context, viewerID int64, profileID int64) error {
-	query := `INSERT INTO profile_views (viewer_id, profile_id, viewed_at) VALUES ($1, $2, $3)`
-	_, err := r.db.ExecContext(ctx, query, viewerID, profileID, time.Now())
+// RecordProfileView records a profile view, unless the viewer already viewed
+// it within window or either has blocked the other
+func (r *postgresRepository) RecordProfileView(ctx context.Context, viewerID int64, profileID int64, window time.Duration) error {
+	query := `
+		INSERT INTO profile_views (viewer_id, profile_id, viewed_at)
+		SELECT $1, $2, NOW()
+		WHERE ` + blocks.NotBlockedSQL("$1", "$2") + `
+		AND NOT EXISTS (
+			SELECT 1 FROM profile_views
+			WHERE viewer_id = $1 AND profile_id = $2
+			AND viewed_at > NOW() - $3 * INTERVAL '1 second'
+		)`
+	_, err := r.db.ExecContext(ctx, query, viewerID, profileID, window.Seconds())
 	return err
 }
 
@@ -399,4 +741,98 @@ func (r *postgresRepository) GetProfileViews(ctx context.Context, userID int64,
 	
 	err := r.db.SelectContext(ctx, &views, query, userID, limit)
 	return views, err
-}
\ No newline at end of file
+}
+
+// GetViewStats counts the user's profile views since a time, per day or week
+// (interval), leaving out viewers blocked either way. Buckets without views
+// are included.
+func (r *postgresRepository) GetViewStats(ctx context.Context, userID int64, interval string, since time.Time) (*ViewStats, error) {
+	stats := &ViewStats{Interval: interval, Since: since, Buckets: []*ViewBucket{}}
+
+	totals := `
+		SELECT COUNT(*) AS total_views, COUNT(DISTINCT pv.viewer_id) AS unique_viewers
+		FROM profile_views pv
+		WHERE pv.profile_id = $1 AND pv.viewed_at >= $2
+		AND ` + blocks.NotBlockedSQL("$1", "pv.viewer_id")
+	if err := r.db.GetContext(ctx, stats, totals, userID, since); err != nil {
+		return nil, fmt.Errorf("failed to count profile views: %w", err)
+	}
+
+	buckets := `
+		SELECT b.start, COUNT(pv.id) AS views, COUNT(DISTINCT pv.viewer_id) AS unique_viewers
+		FROM generate_series(date_trunc($3, $2::timestamp), date_trunc($3, NOW()), ('1 ' || $3)::interval) AS b(start)
+		LEFT JOIN profile_views pv
+			ON pv.profile_id = $1
+			AND pv.viewed_at >= GREATEST(b.start, $2::timestamp)
+			AND pv.viewed_at < b.start + ('1 ' || $3)::interval
+			AND ` + blocks.NotBlockedSQL("$1", "pv.viewer_id") + `
+		GROUP BY b.start
+		ORDER BY b.start`
+	if err := r.db.SelectContext(ctx, &stats.Buckets, buckets, userID, since, interval); err != nil {
+		return nil, fmt.Errorf("failed to get profile view buckets: %w", err)
+	}
+
+	return stats, nil
+}
+
+// GetProfileViewers lists who viewed the user's profile, most recent first,
+// leaving out inactive accounts and anyone blocked either way
+func (r *postgresRepository) GetProfileViewers(ctx context.Context, userID int64, limit, offset int) ([]*ProfileViewer, error) {
+	query := `
+		SELECT u.id, u.username, ` + users.DisplayNameSQL("u") + ` AS display_name,
+			` + users.ProfilePictureSQL("u") + ` AS profile_picture,
+			v.views, v.last_viewed_at
+		FROM (
+			SELECT viewer_id, COUNT(*) AS views, MAX(viewed_at) AS last_viewed_at
+			FROM profile_views
+			WHERE profile_id = $1 AND viewer_id <> $1
+			GROUP BY viewer_id
+		) v
+		JOIN users u ON u.id = v.viewer_id
+		WHERE ` + users.ActiveSQL("u") + `
+		AND ` + blocks.NotBlockedSQL("$1", "u.id") + `
+		ORDER BY v.last_viewed_at DESC
+		LIMIT $2 OFFSET $3`
+
+	viewers := []*ProfileViewer{}
+	if err := r.db.SelectContext(ctx, &viewers, query, userID, limit, offset); err != nil {
+		return nil, fmt.Errorf("failed to get profile viewers: %w", err)
+	}
+	return viewers, nil
+}
+
+// IsPremium reports the user's premium flag
+func (r *postgresRepository) IsPremium(ctx context.Context, userID int64) (bool, error) {
+	var premium bool
+	query := `SELECT COALESCE(is_premium, false) FROM users WHERE id = $1`
+
+	err := r.db.GetContext(ctx, &premium, query, userID)
+	if err == sql.ErrNoRows {
+		return false, ErrProfileNotFound
+	}
+	return premium, err
+}
+
+// ReconcileFollowCounts recounts every user's followers and followings and
+// fixes the stored counts that drifted. It returns how many users were
+// corrected.
+func (r *postgresRepository) ReconcileFollowCounts(ctx context.Context) (int64, error) {
+	query := `
+		UPDATE users u
+		SET followers_count = actual.followers, following_count = actual.following
+		FROM (
+			SELECT
+				u.id,
+				(SELECT COUNT(*) FROM follows WHERE following_id = u.id) AS followers,
+				(SELECT COUNT(*) FROM follows WHERE follower_id = u.id) AS following
+			FROM users u
+		) actual
+		WHERE u.id = actual.id
+		  AND (u.followers_count <> actual.followers OR u.following_count <> actual.following)`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
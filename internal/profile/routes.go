@@ -3,46 +3,57 @@
 package profile
 
 import (
-	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/mux"
 	"github.com/imadgeboyega/kiekky-backend/internal/auth"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
 )
 
 // RegisterRoutes registers all profile routes
-func RegisterRoutes(r chi.Router, handler *Handler, authMiddleware auth.Middleware) {
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
 	// Protected routes
-	r.Group(func(r chi.Router) {
-		r.Use(authMiddleware.Authenticate)
-
-		// Profile viewing
-		r.Get("/api/v1/profile", handler.GetMyProfile)
-		r.Get("/api/v1/users/{id}/profile", handler.GetUserProfile)
-		r.Put("/api/v1/profile", handler.UpdateProfile)
-		
-		// Profile setup
-		r.Post("/api/v1/profile/setup", handler.SetupProfile)
-		
-		// Profile pictures
-		r.Post("/api/v1/profile/picture", handler.UploadProfilePicture)
-		r.Post("/api/v1/profile/cover", handler.UploadCoverPhoto)
-		r.Delete("/api/v1/profile/picture", handler.DeleteProfilePicture)
-		
-		// Profile completion
-		r.Get("/api/v1/profile/completion", handler.GetProfileCompletion)
-		
-		// Privacy & Settings
-		r.Put("/api/v1/profile/privacy", handler.UpdatePrivacySettings)
-		r.Put("/api/v1/profile/notifications", handler.UpdateNotificationSettings)
-		
-		// Blocking
-		r.Get("/api/v1/profile/blocked", handler.GetBlockedUsers)
-		r.Post("/api/v1/users/{id}/block", handler.BlockUser)
-		r.Delete("/api/v1/users/{id}/block", handler.UnblockUser)
-		
-		// Discovery & Search
-		r.Get("/api/v1/discover", handler.DiscoverProfiles)
-		r.Get("/api/v1/search/users", handler.SearchUsers)
-		
-		// Profile views
-		r.Post("/api/v1/profile/views/{id}", handler.RecordProfileView)
-	})
-}
\ No newline at end of file
+	api := router.PathPrefix("/api/v1").Subrouter()
+	api.Use(authMiddleware.Authenticate)
+
+	// Profile management
+	api.HandleFunc("/profile", middleware.ConditionalGETFunc(middleware.CachePerUser, handler.GetMyProfile)).Methods("GET")
+	api.HandleFunc("/profile", handler.UpdateProfile).Methods("PUT")
+	api.HandleFunc("/profile/setup", handler.SetupProfile).Methods("POST")
+
+	// Profile pictures
+	api.HandleFunc("/profile/picture", handler.UploadProfilePicture).Methods("POST")
+	api.HandleFunc("/profile/cover", handler.UploadCoverPhoto).Methods("POST")
+	api.HandleFunc("/profile/picture", handler.DeleteProfilePicture).Methods("DELETE")
+
+	// Photo gallery
+	api.HandleFunc("/profile/photos", handler.GetPhotos).Methods("GET")
+	api.HandleFunc("/profile/photos", handler.UploadPhoto).Methods("POST")
+	api.HandleFunc("/profile/photos/order", handler.ReorderPhotos).Methods("PUT")
+	api.HandleFunc("/profile/photos/{id}/primary", handler.SetPrimaryPhoto).Methods("POST")
+	api.HandleFunc("/profile/photos/{id}", handler.DeletePhoto).Methods("DELETE")
+
+	// Profile completion
+	api.HandleFunc("/profile/completion", handler.GetProfileCompletion).Methods("GET")
+
+	// Privacy & Settings
+	api.HandleFunc("/profile/privacy", handler.UpdatePrivacySettings).Methods("PUT")
+	api.HandleFunc("/profile/notifications", handler.UpdateNotificationSettings).Methods("PUT")
+
+	// Blocking
+	api.HandleFunc("/profile/blocked", handler.GetBlockedUsers).Methods("GET")
+	api.HandleFunc("/users/{id}/block", handler.BlockUser).Methods("POST")
+	api.HandleFunc("/users/{id}/block", handler.UnblockUser).Methods("DELETE")
+
+	// Other users' profiles and follows
+	api.HandleFunc("/users/{id}/profile", handler.GetUserProfile).Methods("GET")
+	api.HandleFunc("/users/{id}/followers", handler.GetFollowers).Methods("GET")
+	api.HandleFunc("/users/{id}/following", handler.GetFollowing).Methods("GET")
+
+	// Discovery & Search
+	api.HandleFunc("/discover", handler.DiscoverProfiles).Methods("GET")
+	api.HandleFunc("/search/users", handler.SearchUsers).Methods("GET")
+
+	// Profile views
+	api.HandleFunc("/profile/views/{id}", handler.RecordProfileView).Methods("POST")
+	api.HandleFunc("/profile/views", handler.GetProfileViewers).Methods("GET")
+	api.HandleFunc("/profile/views/stats", handler.GetViewStats).Methods("GET")
+}
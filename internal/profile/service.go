@@ -6,10 +6,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log"
 	"mime/multipart"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
+
+	"github.com/imadgeboyega/kiekky-backend/internal/blocks"
+	"github.com/imadgeboyega/kiekky-backend/internal/common/cache"
+	"github.com/imadgeboyega/kiekky-backend/internal/events"
+	"github.com/imadgeboyega/kiekky-backend/internal/textfilter"
+	"github.com/imadgeboyega/kiekky-backend/internal/users"
 )
 
 var (
@@ -19,8 +27,35 @@ var (
 	ErrInvalidImageFormat  = errors.New("invalid image format")
 	ErrImageTooLarge       = errors.New("image size exceeds limit")
 	ErrProfileIncomplete   = errors.New("profile is incomplete")
-	ErrAlreadyBlocked      = errors.New("user is already blocked")
-	ErrCannotBlockSelf     = errors.New("cannot block yourself")
+	ErrAlreadyBlocked      = blocks.ErrAlreadyBlocked
+	ErrCannotBlockSelf     = blocks.ErrCannotBlockSelf
+	ErrTooManyPhotos       = errors.New("photo gallery is full")
+	ErrPhotoNotFound       = errors.New("photo not found")
+	ErrInvalidPhotoOrder   = errors.New("photo order must list each photo once")
+	ErrInvalidInterval     = errors.New("interval must be day or week")
+	ErrViewersLocked       = errors.New("seeing who viewed your profile requires premium")
+)
+
+const (
+	// MaxProfilePhotos is how many photos a gallery holds
+	MaxProfilePhotos = 9
+
+	// MinDiscoveryPhotos is how many photos a profile needs to be shown in
+	// discovery
+	MinDiscoveryPhotos = 2
+
+	// DefaultCompletionThreshold is the completion percentage that lists a
+	// profile in discovery, unless SetCompletionThreshold changes it
+	DefaultCompletionThreshold = 70
+
+	// profileViewWindow is how long repeat views by the same viewer count
+	// as one
+	profileViewWindow = 30 * time.Minute
+
+	DefaultViewStatsDays = 30
+	MaxViewStatsDays     = 90
+	DefaultViewersLimit  = 20
+	MaxViewersLimit      = 100
 )
 
 // Service defines the profile service interface
@@ -36,6 +71,32 @@ type Service interface {
 	UploadCoverPhoto(ctx context.Context, userID int64, file multipart.File, header *multipart.FileHeader) (string, error)
 	DeleteProfilePicture(ctx context.Context, userID int64) error
 	DeleteCoverPhoto(ctx context.Context, userID int64) error
+	ApplyApprovedPhoto(ctx context.Context, userID int64, folder, url string) error
+	
+	// Photo gallery
+	GetPhotos(ctx context.Context, userID int64) ([]*Photo, error)
+	UploadPhoto(ctx context.Context, userID int64, file multipart.File, header *multipart.FileHeader) (*Photo, error)
+	SetPrimaryPhoto(ctx context.Context, userID, photoID int64) error
+	ReorderPhotos(ctx context.Context, userID int64, photoIDs []int64) ([]*Photo, error)
+	DeletePhoto(ctx context.Context, userID, photoID int64) error
+	
+	// Text filtering
+	SetTextFilter(filter textfilter.Filter)
+
+	// Interests catalog
+	SetInterests(catalog InterestCatalog)
+
+	// Analytics
+	SetTracker(tracker Tracker)
+
+	// Caching
+	SetCache(c *cache.Cache)
+
+	// Discovery visibility
+	SetCompletionThreshold(percent int)
+
+	// Events
+	SetEvents(bus *events.Bus)
 	
 	// Profile Completion
 	GetProfileCompletion(ctx context.Context, userID int64) (*ProfileCompletion, error)
@@ -50,26 +111,74 @@ type Service interface {
 	GetBlockedUsers(ctx context.Context, userID int64) ([]int64, error)
 	IsBlocked(ctx context.Context, userID int64, targetID int64) (bool, error)
 	
+	// Follows
+	GetFollowers(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error)
+	GetFollowing(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error)
+	
 	// Discovery & Search
 	DiscoverProfiles(ctx context.Context, userID int64, filter *DiscoverFilter) ([]*Profile, error)
+	PeopleYouMayKnow(ctx context.Context, userID int64, limit int) ([]*users.Identity, error)
+	SetContacts(contacts ContactSuggester)
 	SearchUsers(ctx context.Context, userID int64, filter *SearchFilter) ([]*Profile, error)
 	
 	// Profile Views
 	RecordProfileView(ctx context.Context, viewerID int64, profileID int64) error
 	GetProfileViews(ctx context.Context, userID int64, limit int) ([]*ProfileView, error)
+	GetViewStats(ctx context.Context, userID int64, interval string, days int) (*ViewStats, error)
+	// GetProfileViewers fails with ErrViewersLocked for users without premium
+	GetProfileViewers(ctx context.Context, userID int64, limit, offset int) ([]*ProfileViewer, error)
+	SetEntitlements(entitlements Entitlements)
+	
+	// Counters
+	ReconcileFollowCounts(ctx context.Context) (int64, error)
 }
 
 // service implements the profile service
 type service struct {
 	repo          Repository
 	uploadService UploadService
+	blocks        blocks.Service
+	textFilter    textfilter.Filter
+	interests     InterestCatalog
+	contacts      ContactSuggester
+	tracker       Tracker
+	entitlements  Entitlements
+	cache         *cache.Cache
+	events        *events.Bus
+
+	completionThreshold int
+}
+
+// Tracker records analytics funnel events
+type Tracker interface {
+	Track(ctx context.Context, userID int64, event string, properties map[string]interface{})
+}
+
+// Entitlements is the part of billing that decides who sees their profile
+// viewers
+type Entitlements interface {
+	CanSeeProfileViews(ctx context.Context, userID int64) (bool, error)
+}
+
+// ContactSuggester finds people the user may know from their synced contacts
+type ContactSuggester interface {
+	PeopleYouMayKnow(ctx context.Context, userID int64, limit int) ([]*users.Identity, error)
 }
 
-// NewService creates a new profile service
-func NewService(repo Repository, uploadService UploadService) Service {
+// InterestCatalog maps free-text interests onto the canonical catalog
+type InterestCatalog interface {
+	Canonicalize(ctx context.Context, values []string) ([]string, error)
+}
+
+// NewService creates a new profile service. Blocking is delegated to the
+// shared blocks service so a block made here applies everywhere.
+func NewService(repo Repository, uploadService UploadService, blockService blocks.Service) Service {
 	return &service{
 		repo:          repo,
 		uploadService: uploadService,
+		blocks:        blockService,
+
+		completionThreshold: DefaultCompletionThreshold,
 	}
 }
 
@@ -85,7 +194,7 @@ func (s *service) GetProfile(ctx context.Context, userID int64, viewerID int64)
 	}
 
 	// Get profile
-	profile, err := s.repo.GetProfileByUserID(ctx, userID)
+	profile, err := s.loadProfile(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -94,12 +203,27 @@ func (s *service) GetProfile(ctx context.Context, userID int64, viewerID int64)
 	if userID != viewerID {
 		profile = s.applyPrivacySettings(profile, viewerID)
 		
+		profile.IsFollowing, profile.IsFollowedBy, err = s.repo.GetFollowRelation(ctx, viewerID, userID)
+		if err != nil {
+			return nil, err
+		}
+		
 		// Record profile view
 		go func() {
 			_ = s.RecordProfileView(context.Background(), viewerID, userID)
 		}()
 	}
 
+	profile.Prompts, err = s.repo.GetPromptAnswers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Photos, err = s.repo.GetPhotos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
 	// Calculate completion percentage
 	completion, _ := s.calculateCompletion(profile)
 	profile.CompletionPercentage = completion.Percentage
@@ -109,7 +233,17 @@ func (s *service) GetProfile(ctx context.Context, userID int64, viewerID int64)
 
 // GetMyProfile retrieves the current user's full profile
 func (s *service) GetMyProfile(ctx context.Context, userID int64) (*Profile, error) {
-	profile, err := s.repo.GetProfileByUserID(ctx, userID)
+	profile, err := s.loadProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Prompts, err = s.repo.GetPromptAnswers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	profile.Photos, err = s.repo.GetPhotos(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -133,11 +267,29 @@ func (s *service) UpdateProfile(ctx context.Context, userID int64, req *UpdatePr
 		dob = &parsed
 	}
 
+	if req.Bio != nil {
+		bio, err := s.filterBio(ctx, userID, *req.Bio)
+		if err != nil {
+			return nil, err
+		}
+		req.Bio = &bio
+	}
+
+	if req.Interests != nil {
+		interests, err := s.canonicalizeInterests(ctx, req.Interests)
+		if err != nil {
+			return nil, err
+		}
+		req.Interests = interests
+	}
+
 	// Update profile in repository
 	profile, err := s.repo.UpdateProfile(ctx, userID, req, dob)
 	if err != nil {
 		return nil, err
 	}
+	s.invalidate(ctx, userID)
+	s.syncCompleteness(ctx, userID)
 
 	// Calculate completion percentage
 	completion, _ := s.calculateCompletion(profile)
@@ -154,6 +306,18 @@ func (s *service) SetupProfile(ctx context.Context, userID int64, req *ProfileSe
 		return nil, fmt.Errorf("invalid date format, use YYYY-MM-DD")
 	}
 
+	bio, err := s.filterBio(ctx, userID, req.Bio)
+	if err != nil {
+		return nil, err
+	}
+	req.Bio = bio
+
+	interests, err := s.canonicalizeInterests(ctx, req.Interests)
+	if err != nil {
+		return nil, err
+	}
+	req.Interests = interests
+
 	// Convert to UpdateProfileRequest
 	updateReq := &UpdateProfileRequest{
 		DisplayName: &req.DisplayName,
@@ -164,7 +328,103 @@ func (s *service) SetupProfile(ctx context.Context, userID int64, req *ProfileSe
 		LookingFor:  &req.LookingFor,
 	}
 
-	return s.repo.UpdateProfile(ctx, userID, updateReq, &dob)
+	profile, err := s.repo.UpdateProfile(ctx, userID, updateReq, &dob)
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate(ctx, userID)
+	s.syncCompleteness(ctx, userID)
+
+	if s.tracker != nil {
+		s.tracker.Track(ctx, userID, "profile_complete", nil)
+	}
+	if err := s.events.Publish(ctx, strconv.FormatInt(userID, 10), events.ProfileCompleted{UserID: userID}); err != nil {
+		log.Printf("Failed to publish profile completion for user %d: %v", userID, err)
+	}
+	return profile, nil
+}
+
+// SetTracker sets where the profile_complete funnel event goes
+func (s *service) SetTracker(tracker Tracker) {
+	s.tracker = tracker
+}
+
+// SetEvents sets the bus profile completions and photos are published on
+func (s *service) SetEvents(bus *events.Bus) {
+	s.events = bus
+}
+
+// SetCache caches profiles between reads. Writes through this service
+// invalidate them.
+func (s *service) SetCache(c *cache.Cache) {
+	s.cache = c
+}
+
+// SetCompletionThreshold sets the completion percentage that lists a profile
+// in discovery
+func (s *service) SetCompletionThreshold(percent int) {
+	s.completionThreshold = percent
+}
+
+// syncCompleteness flags the profile complete once its completion reaches the
+// threshold, and clears the flag if it drops back below, which takes it out
+// of discovery until it's filled in again
+func (s *service) syncCompleteness(ctx context.Context, userID int64) {
+	profile, err := s.repo.GetProfileByUserID(ctx, userID)
+	if err != nil {
+		log.Printf("Failed to check profile completion for user %d: %v", userID, err)
+		return
+	}
+
+	completion, _ := s.calculateCompletion(profile)
+	if err := s.repo.SetProfileComplete(ctx, userID, completion.Percentage >= s.completionThreshold); err != nil {
+		log.Printf("Failed to update profile completion for user %d: %v", userID, err)
+	}
+}
+
+// profileRecord is a Profile as cached. It drops Profile's MarshalJSON so
+// photo URLs are cached unsigned and signed per response.
+type profileRecord Profile
+
+// loadProfile returns the user's profile without viewer-specific fields
+func (s *service) loadProfile(ctx context.Context, userID int64) (*Profile, error) {
+	record, err := cache.Fetch(ctx, s.cache, cache.Profile, userID, "", func() (*profileRecord, error) {
+		profile, err := s.repo.GetProfileByUserID(ctx, userID)
+		return (*profileRecord)(profile), err
+	})
+	return (*Profile)(record), err
+}
+
+// invalidate drops the user's cached profile after a write
+func (s *service) invalidate(ctx context.Context, userID int64) {
+	s.cache.Invalidate(ctx, cache.Profile, userID)
+}
+
+// SetTextFilter screens bios for profanity and spam
+func (s *service) SetTextFilter(filter textfilter.Filter) {
+	s.textFilter = filter
+}
+
+// SetInterests sets the catalog profile interests are stored as
+func (s *service) SetInterests(catalog InterestCatalog) {
+	s.interests = catalog
+}
+
+// canonicalizeInterests stores interests under their catalog names, when a
+// catalog is set
+func (s *service) canonicalizeInterests(ctx context.Context, values []string) ([]string, error) {
+	if s.interests == nil {
+		return values, nil
+	}
+	return s.interests.Canonicalize(ctx, values)
+}
+
+// filterBio runs a bio through the text filter, when one is set
+func (s *service) filterBio(ctx context.Context, userID int64, bio string) (string, error) {
+	if s.textFilter == nil {
+		return bio, nil
+	}
+	return s.textFilter.Filter(ctx, userID, textfilter.FieldBio, bio)
 }
 
 // UploadProfilePicture uploads a profile picture
@@ -180,8 +440,8 @@ func (s *service) UploadProfilePicture(ctx context.Context, userID int64, file m
 		return "", fmt.Errorf("failed to upload profile picture: %w", err)
 	}
 
-	// Update profile with new picture URL
-	if err := s.repo.UpdateProfilePicture(ctx, userID, url); err != nil {
+	// Add it to the gallery as the new profile picture
+	if _, err := s.addPhoto(ctx, userID, url, true); err != nil {
 		// Try to delete uploaded file
 		_ = s.uploadService.DeleteFile(ctx, url)
 		return "", err
@@ -209,12 +469,161 @@ func (s *service) UploadCoverPhoto(ctx context.Context, userID int64, file multi
 		_ = s.uploadService.DeleteFile(ctx, url)
 		return "", err
 	}
+	s.invalidate(ctx, userID)
 
 	return url, nil
 }
 
+// ApplyApprovedPhoto sets a picture that content moderation held for review
+// once an admin has approved and published it. folder is the one the upload
+// was made to.
+func (s *service) ApplyApprovedPhoto(ctx context.Context, userID int64, folder, url string) error {
+	defer s.invalidate(ctx, userID)
+
+	switch folder {
+	case "profile-pictures":
+		_, err := s.addPhoto(ctx, userID, url, true)
+		return err
+	case "profile-photos":
+		_, err := s.addPhoto(ctx, userID, url, false)
+		return err
+	case "cover-photos":
+		return s.repo.UpdateCoverPhoto(ctx, userID, url)
+	}
+	return fmt.Errorf("unknown profile photo folder %q", folder)
+}
+
+// GetPhotos returns the user's photo gallery in display order
+func (s *service) GetPhotos(ctx context.Context, userID int64) ([]*Photo, error) {
+	return s.repo.GetPhotos(ctx, userID)
+}
+
+// UploadPhoto adds a photo to the end of the user's gallery
+func (s *service) UploadPhoto(ctx context.Context, userID int64, file multipart.File, header *multipart.FileHeader) (*Photo, error) {
+	if err := s.validateImage(header); err != nil {
+		return nil, err
+	}
+
+	// Don't store a file there's no room for
+	photos, err := s.repo.GetPhotos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(photos) >= MaxProfilePhotos {
+		return nil, ErrTooManyPhotos
+	}
+
+	url, err := s.uploadService.UploadFile(ctx, file, header, "profile-photos")
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload photo: %w", err)
+	}
+
+	photo, err := s.addPhoto(ctx, userID, url, false)
+	if err != nil {
+		_ = s.uploadService.DeleteFile(ctx, url)
+		return nil, err
+	}
+
+	return photo, nil
+}
+
+// addPhoto stores an uploaded photo in the user's gallery, making it primary
+// if asked or if it's their first
+func (s *service) addPhoto(ctx context.Context, userID int64, url string, primary bool) (*Photo, error) {
+	defer s.invalidate(ctx, userID)
+
+	photo, err := s.repo.AddPhoto(ctx, userID, url, MaxProfilePhotos)
+	if err != nil {
+		return nil, err
+	}
+	defer s.syncCompleteness(ctx, userID)
+
+	if primary && !photo.IsPrimary {
+		if err := s.repo.SetPrimaryPhoto(ctx, userID, photo.ID); err != nil {
+			return nil, err
+		}
+		photo.IsPrimary = true
+	}
+	if photo.IsPrimary {
+		s.publishPhotoAdded(ctx, userID)
+	}
+
+	return photo, nil
+}
+
+// SetPrimaryPhoto makes one of the user's photos their profile picture
+func (s *service) SetPrimaryPhoto(ctx context.Context, userID, photoID int64) error {
+	defer s.invalidate(ctx, userID)
+	return s.repo.SetPrimaryPhoto(ctx, userID, photoID)
+}
+
+// ReorderPhotos puts the user's gallery in the order given, which must list
+// every photo in it exactly once
+func (s *service) ReorderPhotos(ctx context.Context, userID int64, photoIDs []int64) ([]*Photo, error) {
+	photos, err := s.repo.GetPhotos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(photoIDs) != len(photos) {
+		return nil, ErrInvalidPhotoOrder
+	}
+	owned := make(map[int64]bool, len(photos))
+	for _, photo := range photos {
+		owned[photo.ID] = true
+	}
+	for _, id := range photoIDs {
+		if !owned[id] {
+			return nil, ErrInvalidPhotoOrder
+		}
+		delete(owned, id)
+	}
+
+	if err := s.repo.ReorderPhotos(ctx, userID, photoIDs); err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetPhotos(ctx, userID)
+}
+
+// DeletePhoto removes a photo from the user's gallery and storage
+func (s *service) DeletePhoto(ctx context.Context, userID, photoID int64) error {
+	defer s.invalidate(ctx, userID)
+
+	photo, err := s.repo.DeletePhoto(ctx, userID, photoID)
+	if err != nil {
+		return err
+	}
+	s.syncCompleteness(ctx, userID)
+
+	if err := s.uploadService.DeleteFile(ctx, photo.URL); err != nil {
+		log.Printf("Failed to delete photo file %s: %v", photo.URL, err)
+	}
+	return nil
+}
+
+// publishPhotoAdded announces the user's first profile picture. Later ones
+// share the key, so they aren't published again.
+func (s *service) publishPhotoAdded(ctx context.Context, userID int64) {
+	if err := s.events.Publish(ctx, strconv.FormatInt(userID, 10), events.ProfilePhotoAdded{UserID: userID}); err != nil {
+		log.Printf("Failed to publish profile photo for user %d: %v", userID, err)
+	}
+}
+
 // DeleteProfilePicture removes the profile picture
 func (s *service) DeleteProfilePicture(ctx context.Context, userID int64) error {
+	// The profile picture is the primary photo in the gallery, and the next
+	// photo takes its place
+	photos, err := s.repo.GetPhotos(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, photo := range photos {
+		if photo.IsPrimary {
+			return s.DeletePhoto(ctx, userID, photo.ID)
+		}
+	}
+
 	// Get current profile picture URL
 	profile, err := s.repo.GetProfileByUserID(ctx, userID)
 	if err != nil {
@@ -227,7 +636,12 @@ func (s *service) DeleteProfilePicture(ctx context.Context, userID int64) error
 	}
 
 	// Update profile to remove picture
-	return s.repo.UpdateProfilePicture(ctx, userID, "")
+	defer s.invalidate(ctx, userID)
+	if err := s.repo.UpdateProfilePicture(ctx, userID, ""); err != nil {
+		return err
+	}
+	s.syncCompleteness(ctx, userID)
+	return nil
 }
 
 // DeleteCoverPhoto removes the cover photo
@@ -244,79 +658,158 @@ func (s *service) DeleteCoverPhoto(ctx context.Context, userID int64) error {
 	}
 
 	// Update profile to remove cover photo
+	defer s.invalidate(ctx, userID)
 	return s.repo.UpdateCoverPhoto(ctx, userID, "")
 }
 
 // GetProfileCompletion calculates profile completion percentage
 func (s *service) GetProfileCompletion(ctx context.Context, userID int64) (*ProfileCompletion, error) {
-	profile, err := s.repo.GetProfileByUserID(ctx, userID)
+	profile, err := s.loadProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	completion, err := s.calculateCompletion(profile)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.calculateCompletion(profile)
+	photos, err := s.repo.GetPhotos(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Say what it takes to be shown in discovery
+	completion.Threshold = s.completionThreshold
+	completion.Prompts = []*CompletionPrompt{}
+	if completion.Percentage < s.completionThreshold {
+		for _, field := range completion.Missing {
+			completion.Prompts = append(completion.Prompts, &CompletionPrompt{
+				Field:   field,
+				Message: completionPrompts[field],
+			})
+		}
+	}
+	if missing := MinDiscoveryPhotos - len(photos); missing > 0 {
+		message := fmt.Sprintf("Add %d more photos to be shown in discovery", missing)
+		if missing == 1 {
+			message = "Add 1 more photo to be shown in discovery"
+		}
+		completion.Prompts = append(completion.Prompts, &CompletionPrompt{Field: "photos", Message: message})
+	}
+	completion.Discoverable = len(completion.Prompts) == 0
+
+	return completion, nil
+}
+
+// completionPrompts tell the user how to fill in each missing field
+var completionPrompts = map[string]string{
+	"display_name":    "Add your name",
+	"date_of_birth":   "Add your date of birth",
+	"gender":          "Add your gender",
+	"profile_picture": "Add a profile picture",
+	"bio":             "Write a short bio",
+	"interests":       "Pick a few interests",
+	"location":        "Add your location",
+	"social_media":    "Link your Instagram, Twitter or website",
 }
 
 // UpdatePrivacySettings updates privacy settings
 func (s *service) UpdatePrivacySettings(ctx context.Context, userID int64, req *UpdatePrivacyRequest) error {
+	defer s.invalidate(ctx, userID)
 	return s.repo.UpdatePrivacySettings(ctx, userID, req)
 }
 
 // UpdateNotificationSettings updates notification settings
 func (s *service) UpdateNotificationSettings(ctx context.Context, userID int64, req *UpdateNotificationRequest) error {
+	defer s.invalidate(ctx, userID)
 	return s.repo.UpdateNotificationSettings(ctx, userID, req)
 }
 
 // BlockUser blocks another user
 func (s *service) BlockUser(ctx context.Context, userID int64, blockedID int64) error {
-	if userID == blockedID {
-		return ErrCannotBlockSelf
-	}
-
-	// Check if already blocked
-	exists, err := s.repo.IsBlocked(ctx, userID, blockedID)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return ErrAlreadyBlocked
-	}
-
-	return s.repo.BlockUser(ctx, userID, blockedID)
+	return s.blocks.Block(ctx, userID, blockedID)
 }
 
 // UnblockUser unblocks a user
 func (s *service) UnblockUser(ctx context.Context, userID int64, blockedID int64) error {
-	return s.repo.UnblockUser(ctx, userID, blockedID)
+	return s.blocks.Unblock(ctx, userID, blockedID)
 }
 
 // GetBlockedUsers gets list of blocked user IDs
 func (s *service) GetBlockedUsers(ctx context.Context, userID int64) ([]int64, error) {
-	return s.repo.GetBlockedUsers(ctx, userID)
+	return s.blocks.GetBlocked(ctx, userID)
 }
 
-// IsBlocked checks if a user is blocked
+// IsBlocked checks if either user has blocked the other
 func (s *service) IsBlocked(ctx context.Context, userID int64, targetID int64) (bool, error) {
-	// Check both directions
-	blocked, err := s.repo.IsBlocked(ctx, userID, targetID)
+	return s.blocks.IsBlocked(ctx, userID, targetID)
+}
+
+// GetFollowers lists who follows userID, as seen by viewerID
+func (s *service) GetFollowers(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error) {
+	if err := s.checkFollowListAccess(ctx, userID, viewerID); err != nil {
+		return nil, err
+	}
+
+	users, err := s.repo.GetFollowers(ctx, userID, viewerID, limit, offset)
+	if err != nil {
+		return nil, err
+	}
+	markMutual(users)
+	return users, nil
+}
+
+// GetFollowing lists who userID follows, as seen by viewerID
+func (s *service) GetFollowing(ctx context.Context, userID int64, viewerID int64, limit, offset int) ([]*FollowUser, error) {
+	if err := s.checkFollowListAccess(ctx, userID, viewerID); err != nil {
+		return nil, err
+	}
+
+	users, err := s.repo.GetFollowing(ctx, userID, viewerID, limit, offset)
 	if err != nil {
-		return false, err
+		return nil, err
+	}
+	markMutual(users)
+	return users, nil
+}
+
+// checkFollowListAccess hides a user's follow lists from anyone they block or are blocked by
+func (s *service) checkFollowListAccess(ctx context.Context, userID int64, viewerID int64) error {
+	if userID == viewerID {
+		return nil
+	}
+	blocked, err := s.IsBlocked(ctx, userID, viewerID)
+	if err != nil {
+		return err
 	}
 	if blocked {
-		return true, nil
+		return ErrUserBlocked
 	}
+	return nil
+}
 
-	// Check if target has blocked the user
-	return s.repo.IsBlocked(ctx, targetID, userID)
+// markMutual flags users who follow the viewer back
+func markMutual(users []*FollowUser) {
+	for _, u := range users {
+		u.IsMutual = u.IsFollowing && u.IsFollowedBy
+	}
 }
 
 // DiscoverProfiles discovers profiles based on filters
 func (s *service) DiscoverProfiles(ctx context.Context, userID int64, filter *DiscoverFilter) ([]*Profile, error) {
-	// Get blocked users to exclude
-	blockedUsers, err := s.repo.GetBlockedUsers(ctx, userID)
+	// Exclude users blocked in either direction
+	blockedUsers, err := s.blocks.RelatedIDs(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Saved dating preferences fill in the filters the request left out
+	prefs, err := s.repo.GetDatingPreferences(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
+	applyDatingPreferences(filter, prefs)
 
 	// Get profiles
 	profiles, err := s.repo.DiscoverProfiles(ctx, userID, filter, blockedUsers)
@@ -324,18 +817,69 @@ func (s *service) DiscoverProfiles(ctx context.Context, userID int64, filter *Di
 		return nil, err
 	}
 
+	// Attach each profile's gallery
+	userIDs := make([]int64, len(profiles))
+	for i, profile := range profiles {
+		userIDs[i] = profile.UserID
+	}
+	photos, err := s.repo.GetPhotosForUsers(ctx, userIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Apply privacy settings
 	for i, profile := range profiles {
+		profile.Photos = photos[profile.UserID]
+		if profile.Photos == nil {
+			profile.Photos = []*Photo{}
+		}
 		profiles[i] = s.applyPrivacySettings(profile, userID)
 	}
 
 	return profiles, nil
 }
 
+// SetContacts sets where people you may know come from
+func (s *service) SetContacts(contacts ContactSuggester) {
+	s.contacts = contacts
+}
+
+// PeopleYouMayKnow returns the user's contacts on the app, or none when
+// contact sync isn't set up
+func (s *service) PeopleYouMayKnow(ctx context.Context, userID int64, limit int) ([]*users.Identity, error) {
+	if s.contacts == nil {
+		return []*users.Identity{}, nil
+	}
+	return s.contacts.PeopleYouMayKnow(ctx, userID, limit)
+}
+
+// applyDatingPreferences sets each filter the request left out from the
+// user's saved preferences, if they have any
+func applyDatingPreferences(filter *DiscoverFilter, prefs *DatingPreferences) {
+	if prefs == nil {
+		return
+	}
+	if filter.Gender == nil && len(filter.Genders) == 0 {
+		filter.Genders = prefs.Genders
+	}
+	if filter.MinAge == nil {
+		filter.MinAge = &prefs.MinAge
+	}
+	if filter.MaxAge == nil {
+		filter.MaxAge = &prefs.MaxAge
+	}
+	if filter.MaxDistance == nil {
+		filter.MaxDistance = &prefs.MaxDistanceKm
+	}
+	if filter.LookingFor == nil {
+		filter.LookingFor = prefs.Intent
+	}
+}
+
 // SearchUsers searches for users by query
 func (s *service) SearchUsers(ctx context.Context, userID int64, filter *SearchFilter) ([]*Profile, error) {
-	// Get blocked users to exclude
-	blockedUsers, err := s.repo.GetBlockedUsers(ctx, userID)
+	// Exclude users blocked in either direction
+	blockedUsers, err := s.blocks.RelatedIDs(ctx, userID)
 	if err != nil {
 		return nil, err
 	}
@@ -360,7 +904,7 @@ func (s *service) RecordProfileView(ctx context.Context, viewerID int64, profile
 		return nil // Don't record self views
 	}
 
-	return s.repo.RecordProfileView(ctx, viewerID, profileID)
+	return s.repo.RecordProfileView(ctx, viewerID, profileID, profileViewWindow)
 }
 
 // GetProfileViews gets recent profile views
@@ -375,6 +919,68 @@ func (s *service) GetProfileViews(ctx context.Context, userID int64, limit int)
 	return s.repo.GetProfileViews(ctx, userID, limit)
 }
 
+// GetViewStats counts the user's profile views over the last days, per day
+// or week
+func (s *service) GetViewStats(ctx context.Context, userID int64, interval string, days int) (*ViewStats, error) {
+	if interval == "" {
+		interval = "day"
+	}
+	if interval != "day" && interval != "week" {
+		return nil, ErrInvalidInterval
+	}
+	if days <= 0 {
+		days = DefaultViewStatsDays
+	}
+	if days > MaxViewStatsDays {
+		days = MaxViewStatsDays
+	}
+
+	now := time.Now()
+	since := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location()).AddDate(0, 0, 1-days)
+	return s.repo.GetViewStats(ctx, userID, interval, since)
+}
+
+// SetEntitlements sets the billing checker. Without one, the users.is_premium
+// flag is used.
+func (s *service) SetEntitlements(entitlements Entitlements) {
+	s.entitlements = entitlements
+}
+
+// GetProfileViewers lists who viewed the user's profile, for premium users
+func (s *service) GetProfileViewers(ctx context.Context, userID int64, limit, offset int) ([]*ProfileViewer, error) {
+	var allowed bool
+	var err error
+	if s.entitlements != nil {
+		allowed, err = s.entitlements.CanSeeProfileViews(ctx, userID)
+	} else {
+		allowed, err = s.repo.IsPremium(ctx, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to check premium status: %w", err)
+	}
+	if !allowed {
+		return nil, ErrViewersLocked
+	}
+
+	if limit <= 0 {
+		limit = DefaultViewersLimit
+	}
+	if limit > MaxViewersLimit {
+		limit = MaxViewersLimit
+	}
+	if offset < 0 {
+		offset = 0
+	}
+
+	return s.repo.GetProfileViewers(ctx, userID, limit, offset)
+}
+
+// ReconcileFollowCounts corrects stored follower and following counts
+// against the follows table
+func (s *service) ReconcileFollowCounts(ctx context.Context) (int64, error) {
+	return s.repo.ReconcileFollowCounts(ctx)
+}
+
 // Helper methods
 
 // validateImage validates uploaded image
@@ -430,6 +1036,11 @@ func (s *service) applyPrivacySettings(profile *Profile, viewerID int64) *Profil
 		p.Longitude = nil
 	}
 
+	// Hide when they were last active if not allowed
+	if !p.PrivacySettings.ShowOnlineStatus {
+		p.LastActive = nil
+	}
+
 	return &p
 }
 
@@ -8,8 +8,10 @@ import (
 	"io"
 	"mime/multipart"
 	"os"
+	"path"
 	"path/filepath"
 	"bytes"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go/aws"
@@ -74,6 +76,10 @@ func (s *LocalUploadService) DeleteFile(ctx context.Context, url string) error {
 	// This is a simplified implementation
 	// You might need to adjust based on your URL structure
 	
+	if !strings.HasPrefix(url, s.baseURL+"/") {
+		return fmt.Errorf("not a local upload: %s", url)
+	}
+
 	// Remove base URL to get relative path
 	relativePath := url[len(s.baseURL):]
 	if relativePath[0] == '/' {
@@ -92,6 +98,26 @@ func (s *LocalUploadService) DeleteFile(ctx context.Context, url string) error {
 	return nil
 }
 
+// MoveFile moves a stored file into folder and returns its new URL
+func (s *LocalUploadService) MoveFile(ctx context.Context, url, folder string) (string, error) {
+	if !strings.HasPrefix(url, s.baseURL+"/") {
+		return "", fmt.Errorf("not a local upload: %s", url)
+	}
+	relativePath := url[len(s.baseURL)+1:]
+
+	fullPath := filepath.Join(s.uploadDir, folder)
+	if err := os.MkdirAll(fullPath, 0755); err != nil {
+		return "", fmt.Errorf("failed to create upload directory: %w", err)
+	}
+
+	filename := filepath.Base(relativePath)
+	if err := os.Rename(filepath.Join(s.uploadDir, relativePath), filepath.Join(fullPath, filename)); err != nil {
+		return "", fmt.Errorf("failed to move file: %w", err)
+	}
+
+	return fmt.Sprintf("%s/%s/%s", s.baseURL, folder, filename), nil
+}
+
 // S3UploadService implements AWS S3 file storage
 type S3UploadService struct {
 	s3Client   *s3.S3
@@ -170,4 +196,30 @@ func (s *S3UploadService) DeleteFile(ctx context.Context, url string) error {
 	}
 
 	return nil
-}
\ No newline at end of file
+}
+
+// MoveFile moves a stored object under folder and returns its new URL. S3
+// has no rename, so the object is copied and the original deleted.
+func (s *S3UploadService) MoveFile(ctx context.Context, url, folder string) (string, error) {
+	if !strings.HasPrefix(url, s.baseURL+"/") {
+		return "", fmt.Errorf("not an S3 upload: %s", url)
+	}
+	key := url[len(s.baseURL)+1:]
+	newKey := path.Join(folder, path.Base(key))
+
+	_, err := s.s3Client.CopyObjectWithContext(ctx, &s3.CopyObjectInput{
+		Bucket:     aws.String(s.bucket),
+		Key:        aws.String(newKey),
+		CopySource: aws.String(s.bucket + "/" + key),
+		ACL:        aws.String("public-read"),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to copy in S3: %w", err)
+	}
+
+	if err := s.DeleteFile(ctx, url); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s/%s", s.baseURL, newKey), nil
+}
@@ -0,0 +1,101 @@
+// internal/prompts/handlers.go
+
+package prompts
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// GetPrompts handles GET /prompts
+func (h *Handler) GetPrompts(w http.ResponseWriter, r *http.Request) {
+    catalog, err := h.service.GetPrompts(r.Context())
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get prompts")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, catalog)
+}
+
+// GetMyAnswers handles GET /prompts/answers
+func (h *Handler) GetMyAnswers(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    answers, err := h.service.GetAnswers(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get prompt answers")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, answers)
+}
+
+// SetMyAnswers handles PUT /prompts/answers
+func (h *Handler) SetMyAnswers(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    var req SetAnswersRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    answers, err := h.service.SetAnswers(r.Context(), userID, &req)
+    if err != nil {
+        switch err {
+        case ErrTooManyAnswers, ErrUnknownPrompt, ErrDuplicatePrompt, ErrInvalidAnswer:
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to save prompt answers")
+        }
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, answers)
+}
+
+// DeleteMyAnswer handles DELETE /prompts/answers/{promptId}
+func (h *Handler) DeleteMyAnswer(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    promptID, err := strconv.ParseInt(mux.Vars(r)["promptId"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid prompt ID")
+        return
+    }
+
+    if err := h.service.DeleteAnswer(r.Context(), userID, promptID); err != nil {
+        if err == ErrAnswerNotFound {
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete prompt answer")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Prompt answer deleted"})
+}
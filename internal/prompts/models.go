@@ -0,0 +1,34 @@
+// internal/prompts/models.go
+
+package prompts
+
+import "time"
+
+// Prompt is an entry in the curated icebreaker catalog
+type Prompt struct {
+    ID       int64  `json:"id" db:"id"`
+    Slug     string `json:"slug" db:"slug"`
+    Text     string `json:"text" db:"text"`
+    Category string `json:"category,omitempty" db:"category"`
+}
+
+// Answer is a user's answer to a catalog prompt. Position orders answers on
+// the profile, starting at 1.
+type Answer struct {
+    PromptID   int64     `json:"prompt_id" db:"prompt_id"`
+    PromptText string    `json:"prompt" db:"prompt_text"`
+    Answer     string    `json:"answer" db:"answer"`
+    Position   int       `json:"position" db:"position"`
+    UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// AnswerInput is one answer in a SetAnswersRequest
+type AnswerInput struct {
+    PromptID int64  `json:"prompt_id" validate:"required"`
+    Answer   string `json:"answer" validate:"required,max=150"`
+}
+
+// SetAnswersRequest replaces all of a user's answers, in display order
+type SetAnswersRequest struct {
+    Answers []AnswerInput `json:"answers" validate:"max=3,dive"`
+}
@@ -0,0 +1,112 @@
+// internal/prompts/repository.go
+
+package prompts
+
+import (
+    "context"
+    "database/sql"
+
+    "github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+    GetCatalog(ctx context.Context) ([]*Prompt, error)
+    GetUserAnswers(ctx context.Context, userID int64) ([]*Answer, error)
+    ReplaceUserAnswers(ctx context.Context, userID int64, answers []AnswerInput) error
+    DeleteUserAnswer(ctx context.Context, userID, promptID int64) (bool, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+// GetCatalog returns every active prompt
+func (r *repository) GetCatalog(ctx context.Context) ([]*Prompt, error) {
+    query := `
+        SELECT id, slug, text, COALESCE(category, '') AS category
+        FROM profile_prompts
+        WHERE is_active = true
+        ORDER BY id`
+
+    catalog := []*Prompt{}
+    if err := r.db.SelectContext(ctx, &catalog, query); err != nil {
+        return nil, err
+    }
+    return catalog, nil
+}
+
+// GetUserAnswers returns the user's answers in display order
+func (r *repository) GetUserAnswers(ctx context.Context, userID int64) ([]*Answer, error) {
+    query := `
+        SELECT a.prompt_id, p.text AS prompt_text, a.answer, a.position, a.updated_at
+        FROM user_prompt_answers a
+        JOIN profile_prompts p ON p.id = a.prompt_id
+        WHERE a.user_id = $1
+        ORDER BY a.position`
+
+    answers := []*Answer{}
+    if err := r.db.SelectContext(ctx, &answers, query, userID); err != nil {
+        return nil, err
+    }
+    return answers, nil
+}
+
+// ReplaceUserAnswers swaps the user's answers for the given list in one
+// transaction, numbering positions from 1 in list order
+func (r *repository) ReplaceUserAnswers(ctx context.Context, userID int64, answers []AnswerInput) error {
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return err
+    }
+    defer tx.Rollback()
+
+    if _, err := tx.ExecContext(ctx, `DELETE FROM user_prompt_answers WHERE user_id = $1`, userID); err != nil {
+        return err
+    }
+
+    for i, answer := range answers {
+        _, err := tx.ExecContext(ctx, `
+            INSERT INTO user_prompt_answers (user_id, prompt_id, answer, position)
+            VALUES ($1, $2, $3, $4)`,
+            userID, answer.PromptID, answer.Answer, i+1)
+        if err != nil {
+            return err
+        }
+    }
+
+    return tx.Commit()
+}
+
+// DeleteUserAnswer removes one answer and closes the gap in positions,
+// reporting whether the user had answered the prompt
+func (r *repository) DeleteUserAnswer(ctx context.Context, userID, promptID int64) (bool, error) {
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    var position int
+    err = tx.QueryRowxContext(ctx, `
+        DELETE FROM user_prompt_answers WHERE user_id = $1 AND prompt_id = $2
+        RETURNING position`, userID, promptID).Scan(&position)
+    if err != nil {
+        if err == sql.ErrNoRows {
+            return false, nil
+        }
+        return false, err
+    }
+
+    _, err = tx.ExecContext(ctx, `
+        UPDATE user_prompt_answers SET position = position - 1
+        WHERE user_id = $1 AND position > $2`, userID, position)
+    if err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
@@ -0,0 +1,17 @@
+package prompts
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/prompts").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("", middleware.ConditionalGETFunc(middleware.CacheCatalog, handler.GetPrompts)).Methods("GET")
+    api.HandleFunc("/answers", handler.GetMyAnswers).Methods("GET")
+    api.HandleFunc("/answers", handler.SetMyAnswers).Methods("PUT")
+    api.HandleFunc("/answers/{promptId}", handler.DeleteMyAnswer).Methods("DELETE")
+}
@@ -0,0 +1,133 @@
+// internal/prompts/service.go
+
+package prompts
+
+import (
+    "context"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "strings"
+    "time"
+    "unicode/utf8"
+
+    "github.com/go-redis/redis/v8"
+)
+
+const (
+    MaxAnswers      = 3
+    MaxAnswerLength = 150
+
+    catalogCacheKey = "prompts:catalog"
+    catalogCacheTTL = 1 * time.Hour
+)
+
+var (
+    ErrTooManyAnswers  = fmt.Errorf("at most %d prompts can be answered", MaxAnswers)
+    ErrUnknownPrompt   = errors.New("unknown prompt")
+    ErrDuplicatePrompt = errors.New("each prompt can only be answered once")
+    ErrInvalidAnswer   = fmt.Errorf("answers must be 1-%d characters", MaxAnswerLength)
+    ErrAnswerNotFound  = errors.New("prompt not answered")
+)
+
+type Service interface {
+    GetPrompts(ctx context.Context) ([]*Prompt, error)
+    GetAnswers(ctx context.Context, userID int64) ([]*Answer, error)
+    SetAnswers(ctx context.Context, userID int64, req *SetAnswersRequest) ([]*Answer, error)
+    DeleteAnswer(ctx context.Context, userID, promptID int64) error
+}
+
+type service struct {
+    repo  Repository
+    redis *redis.Client
+}
+
+// NewService creates the prompts service. redisClient may be nil, in which
+// case the catalog is read from Postgres on every request.
+func NewService(repo Repository, redisClient *redis.Client) Service {
+    return &service{
+        repo:  repo,
+        redis: redisClient,
+    }
+}
+
+func (s *service) GetPrompts(ctx context.Context) ([]*Prompt, error) {
+    return s.getCatalog(ctx)
+}
+
+func (s *service) GetAnswers(ctx context.Context, userID int64) ([]*Answer, error) {
+    return s.repo.GetUserAnswers(ctx, userID)
+}
+
+// SetAnswers replaces the user's answers. Answers are trimmed; each must name
+// an active catalog prompt, and no prompt may appear twice.
+func (s *service) SetAnswers(ctx context.Context, userID int64, req *SetAnswersRequest) ([]*Answer, error) {
+    if len(req.Answers) > MaxAnswers {
+        return nil, ErrTooManyAnswers
+    }
+
+    catalog, err := s.getCatalog(ctx)
+    if err != nil {
+        return nil, err
+    }
+    active := make(map[int64]bool, len(catalog))
+    for _, prompt := range catalog {
+        active[prompt.ID] = true
+    }
+
+    seen := make(map[int64]bool, len(req.Answers))
+    answers := make([]AnswerInput, 0, len(req.Answers))
+    for _, input := range req.Answers {
+        text := strings.TrimSpace(input.Answer)
+        switch {
+        case !active[input.PromptID]:
+            return nil, ErrUnknownPrompt
+        case seen[input.PromptID]:
+            return nil, ErrDuplicatePrompt
+        case text == "" || utf8.RuneCountInString(text) > MaxAnswerLength:
+            return nil, ErrInvalidAnswer
+        }
+        seen[input.PromptID] = true
+        answers = append(answers, AnswerInput{PromptID: input.PromptID, Answer: text})
+    }
+
+    if err := s.repo.ReplaceUserAnswers(ctx, userID, answers); err != nil {
+        return nil, fmt.Errorf("failed to save prompt answers: %w", err)
+    }
+    return s.repo.GetUserAnswers(ctx, userID)
+}
+
+func (s *service) DeleteAnswer(ctx context.Context, userID, promptID int64) error {
+    deleted, err := s.repo.DeleteUserAnswer(ctx, userID, promptID)
+    if err != nil {
+        return err
+    }
+    if !deleted {
+        return ErrAnswerNotFound
+    }
+    return nil
+}
+
+// getCatalog reads the catalog from Redis, falling back to Postgres
+func (s *service) getCatalog(ctx context.Context) ([]*Prompt, error) {
+    if s.redis != nil {
+        if data, err := s.redis.Get(ctx, catalogCacheKey).Bytes(); err == nil {
+            var catalog []*Prompt
+            if err := json.Unmarshal(data, &catalog); err == nil {
+                return catalog, nil
+            }
+        }
+    }
+
+    catalog, err := s.repo.GetCatalog(ctx)
+    if err != nil {
+        return nil, fmt.Errorf("failed to load prompts catalog: %w", err)
+    }
+
+    if s.redis != nil {
+        if data, err := json.Marshal(catalog); err == nil {
+            s.redis.Set(ctx, catalogCacheKey, data, catalogCacheTTL)
+        }
+    }
+    return catalog, nil
+}
@@ -0,0 +1,31 @@
+// internal/referrals/events.go
+// Referral progress driven by other modules' domain events
+
+package referrals
+
+import (
+    "context"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+// SubscribeEvents subscribes referrals to the invitee milestones a reward
+// waits for
+func (s *service) SubscribeEvents(bus *events.Bus) {
+    events.Subscribe(bus, "referrals", s.onUserVerified)
+    events.Subscribe(bus, "referrals", s.onProfileCompleted)
+}
+
+func (s *service) onUserVerified(ctx context.Context, e events.UserVerified) error {
+    if err := s.repo.MarkVerified(ctx, e.UserID); err != nil {
+        return err
+    }
+    return s.settle(ctx, e.UserID)
+}
+
+func (s *service) onProfileCompleted(ctx context.Context, e events.ProfileCompleted) error {
+    if err := s.repo.MarkProfileCompleted(ctx, e.UserID); err != nil {
+        return err
+    }
+    return s.settle(ctx, e.UserID)
+}
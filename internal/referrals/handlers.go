@@ -0,0 +1,39 @@
+// internal/referrals/handlers.go
+
+package referrals
+
+import (
+    "net/http"
+    "strconv"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// GetDashboard handles GET /referrals?limit=&offset=
+func (h *Handler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+
+    dashboard, err := h.service.GetDashboard(r.Context(), userID, limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get referrals")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, dashboard)
+}
@@ -0,0 +1,66 @@
+// internal/referrals/models.go
+
+package referrals
+
+import (
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+// Referral statuses
+const (
+    StatusPending  = "pending"
+    StatusRewarded = "rewarded"
+    StatusRejected = "rejected"
+)
+
+// Reward types
+const (
+    RewardCoins       = "coins"
+    RewardPremiumDays = "premium_days"
+)
+
+// Referral is a user who signed up with someone's invite code. It is
+// rewarded once the invitee has verified their account and completed their
+// profile, unless it looks like the referrer inviting themselves.
+type Referral struct {
+    ID                 int64      `json:"id" db:"id"`
+    ReferrerID         int64      `json:"referrer_id" db:"referrer_id"`
+    InviteeID          int64      `json:"invitee_id" db:"invitee_id"`
+    Code               string     `json:"code" db:"code"`
+    Status             string     `json:"status" db:"status"`
+    RejectReason       *string    `json:"-" db:"reject_reason"`
+    SignupIP           *string    `json:"-" db:"signup_ip"`
+    DeviceID           *string    `json:"-" db:"device_id"`
+    VerifiedAt         *time.Time `json:"verified_at,omitempty" db:"verified_at"`
+    ProfileCompletedAt *time.Time `json:"profile_completed_at,omitempty" db:"profile_completed_at"`
+    RewardedAt         *time.Time `json:"rewarded_at,omitempty" db:"rewarded_at"`
+    CreatedAt          time.Time  `json:"created_at" db:"created_at"`
+
+    Invitee *users.Identity `json:"invitee,omitempty"`
+}
+
+// Reward is what both parties get for a referral
+type Reward struct {
+    Type        string `json:"type"`
+    Coins       int    `json:"coins,omitempty"`
+    PremiumDays int    `json:"premium_days,omitempty"`
+}
+
+// Stats counts a user's referrals by status
+type Stats struct {
+    Invited  int `json:"invited" db:"invited"`
+    Pending  int `json:"pending" db:"pending"`
+    Rewarded int `json:"rewarded" db:"rewarded"`
+    Rejected int `json:"rejected" db:"rejected"`
+}
+
+// Dashboard is a user's invite code and how their invites are doing
+type Dashboard struct {
+    Code      string      `json:"code"`
+    InviteURL string      `json:"invite_url"`
+    Reward    Reward      `json:"reward"`
+    Stats     Stats       `json:"stats"`
+    Referrals []*Referral `json:"referrals"`
+}
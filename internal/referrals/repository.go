@@ -0,0 +1,217 @@
+// internal/referrals/repository.go
+
+package referrals
+
+import (
+    "context"
+    "database/sql"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+    "github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+    // Codes
+    GetCode(ctx context.Context, userID int64) (string, error)
+    CreateCode(ctx context.Context, userID int64, code string) (bool, error)
+    GetCodeOwner(ctx context.Context, code string) (int64, error)
+
+    // Referrals
+    CreateReferral(ctx context.Context, referral *Referral) error
+    GetReferralByInvitee(ctx context.Context, inviteeID int64) (*Referral, error)
+    MarkVerified(ctx context.Context, inviteeID int64) error
+    MarkProfileCompleted(ctx context.Context, inviteeID int64) error
+    MarkRewarded(ctx context.Context, id int64) error
+    Reject(ctx context.Context, id int64, reason string) error
+    GetReferrals(ctx context.Context, referrerID int64, limit, offset int) ([]*Referral, error)
+    GetStats(ctx context.Context, referrerID int64) (*Stats, error)
+
+    // Anti-abuse
+    ReferrerUsedIP(ctx context.Context, referrerID int64, ip string) (bool, error)
+    ReferrerUsedDevice(ctx context.Context, referrerID int64, fingerprint string) (bool, error)
+    DeviceReferred(ctx context.Context, referrerID, referralID int64, deviceID string) (bool, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+// GetCode returns the user's invite code, or "" if they don't have one yet
+func (r *repository) GetCode(ctx context.Context, userID int64) (string, error) {
+    var code string
+    err := r.db.GetContext(ctx, &code, `SELECT code FROM referral_codes WHERE user_id = $1`, userID)
+    if err == sql.ErrNoRows {
+        return "", nil
+    }
+    return code, err
+}
+
+// CreateCode gives the user an invite code, returning false if they already
+// have one or the code is taken
+func (r *repository) CreateCode(ctx context.Context, userID int64, code string) (bool, error) {
+    result, err := r.db.ExecContext(ctx,
+        `INSERT INTO referral_codes (user_id, code) VALUES ($1, $2) ON CONFLICT DO NOTHING`, userID, code)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+// GetCodeOwner returns the active user the code belongs to, or 0
+func (r *repository) GetCodeOwner(ctx context.Context, code string) (int64, error) {
+    query := `
+        SELECT c.user_id
+        FROM referral_codes c
+        JOIN users u ON u.id = c.user_id
+        WHERE c.code = $1 AND ` + users.ActiveSQL("u")
+
+    var userID int64
+    err := r.db.GetContext(ctx, &userID, query, code)
+    if err == sql.ErrNoRows {
+        return 0, nil
+    }
+    return userID, err
+}
+
+// CreateReferral records an attributed signup. An invitee is only ever
+// attributed to one referrer; later attempts are ignored.
+func (r *repository) CreateReferral(ctx context.Context, referral *Referral) error {
+    query := `
+        INSERT INTO referrals (referrer_id, invitee_id, code, signup_ip, device_id)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (invitee_id) DO NOTHING`
+
+    _, err := r.db.ExecContext(ctx, query,
+        referral.ReferrerID, referral.InviteeID, referral.Code, referral.SignupIP, referral.DeviceID)
+    return err
+}
+
+const referralColumns = `
+    r.id, r.referrer_id, r.invitee_id, r.code, r.status, r.reject_reason, r.signup_ip, r.device_id,
+    r.verified_at, r.profile_completed_at, r.rewarded_at, r.created_at`
+
+// GetReferralByInvitee returns the referral the user signed up through, or nil
+func (r *repository) GetReferralByInvitee(ctx context.Context, inviteeID int64) (*Referral, error) {
+    var referral Referral
+    err := r.db.GetContext(ctx, &referral,
+        `SELECT `+referralColumns+` FROM referrals r WHERE r.invitee_id = $1`, inviteeID)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &referral, nil
+}
+
+func (r *repository) MarkVerified(ctx context.Context, inviteeID int64) error {
+    _, err := r.db.ExecContext(ctx,
+        `UPDATE referrals SET verified_at = COALESCE(verified_at, NOW()) WHERE invitee_id = $1`, inviteeID)
+    return err
+}
+
+func (r *repository) MarkProfileCompleted(ctx context.Context, inviteeID int64) error {
+    _, err := r.db.ExecContext(ctx,
+        `UPDATE referrals SET profile_completed_at = COALESCE(profile_completed_at, NOW()) WHERE invitee_id = $1`, inviteeID)
+    return err
+}
+
+func (r *repository) MarkRewarded(ctx context.Context, id int64) error {
+    _, err := r.db.ExecContext(ctx,
+        `UPDATE referrals SET status = $2, rewarded_at = NOW() WHERE id = $1 AND status = $3`,
+        id, StatusRewarded, StatusPending)
+    return err
+}
+
+func (r *repository) Reject(ctx context.Context, id int64, reason string) error {
+    _, err := r.db.ExecContext(ctx,
+        `UPDATE referrals SET status = $2, reject_reason = $3 WHERE id = $1 AND status = $4`,
+        id, StatusRejected, reason, StatusPending)
+    return err
+}
+
+// GetReferrals returns the users the referrer invited, newest first, shown
+// as ghosts once their account is gone
+func (r *repository) GetReferrals(ctx context.Context, referrerID int64, limit, offset int) ([]*Referral, error) {
+    query := `
+        SELECT ` + referralColumns + `,
+            ` + users.UsernameSQL("u") + `, ` + users.DisplayNameSQL("u") + `, ` + users.ProfilePictureSQL("u") + `,
+            ` + users.GhostSQL("u") + `
+        FROM referrals r
+        LEFT JOIN users u ON u.id = r.invitee_id
+        WHERE r.referrer_id = $1
+        ORDER BY r.created_at DESC
+        LIMIT $2 OFFSET $3`
+
+    rows, err := r.db.QueryContext(ctx, query, referrerID, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+
+    referrals := []*Referral{}
+    for rows.Next() {
+        ref := &Referral{Invitee: &users.Identity{}}
+        err := rows.Scan(
+            &ref.ID, &ref.ReferrerID, &ref.InviteeID, &ref.Code, &ref.Status, &ref.RejectReason,
+            &ref.SignupIP, &ref.DeviceID, &ref.VerifiedAt, &ref.ProfileCompletedAt, &ref.RewardedAt, &ref.CreatedAt,
+            &ref.Invitee.Username, &ref.Invitee.DisplayName, &ref.Invitee.ProfilePicture, &ref.Invitee.IsGhost,
+        )
+        if err != nil {
+            return nil, err
+        }
+        ref.Invitee.ID = ref.InviteeID
+        referrals = append(referrals, ref)
+    }
+    return referrals, rows.Err()
+}
+
+func (r *repository) GetStats(ctx context.Context, referrerID int64) (*Stats, error) {
+    query := `
+        SELECT
+            COUNT(*) AS invited,
+            COUNT(*) FILTER (WHERE status = $2) AS pending,
+            COUNT(*) FILTER (WHERE status = $3) AS rewarded,
+            COUNT(*) FILTER (WHERE status = $4) AS rejected
+        FROM referrals
+        WHERE referrer_id = $1`
+
+    var stats Stats
+    err := r.db.GetContext(ctx, &stats, query, referrerID, StatusPending, StatusRewarded, StatusRejected)
+    if err != nil {
+        return nil, err
+    }
+    return &stats, nil
+}
+
+// ReferrerUsedIP reports whether the referrer has signed in from the IP
+func (r *repository) ReferrerUsedIP(ctx context.Context, referrerID int64, ip string) (bool, error) {
+    var used bool
+    err := r.db.GetContext(ctx, &used,
+        `SELECT EXISTS(SELECT 1 FROM sessions WHERE user_id = $1 AND ip_address = $2)`, referrerID, ip)
+    return used, err
+}
+
+// ReferrerUsedDevice reports whether the referrer has signed in on the
+// device with the fingerprint
+func (r *repository) ReferrerUsedDevice(ctx context.Context, referrerID int64, fingerprint string) (bool, error) {
+    var used bool
+    err := r.db.GetContext(ctx, &used,
+        `SELECT EXISTS(SELECT 1 FROM sessions WHERE user_id = $1 AND device_fingerprint = $2)`, referrerID, fingerprint)
+    return used, err
+}
+
+// DeviceReferred reports whether another of the referrer's invitees signed
+// up on the same device
+func (r *repository) DeviceReferred(ctx context.Context, referrerID, referralID int64, deviceID string) (bool, error) {
+    var used bool
+    err := r.db.GetContext(ctx, &used,
+        `SELECT EXISTS(SELECT 1 FROM referrals WHERE referrer_id = $1 AND id <> $2 AND device_id = $3)`,
+        referrerID, referralID, deviceID)
+    return used, err
+}
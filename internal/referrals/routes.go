@@ -0,0 +1,14 @@
+package referrals
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/referrals").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    // Codes are redeemed with referral_code at signup
+    api.HandleFunc("", handler.GetDashboard).Methods("GET")
+}
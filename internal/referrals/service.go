@@ -0,0 +1,298 @@
+// internal/referrals/service.go
+
+package referrals
+
+import (
+    "context"
+    "crypto/rand"
+    "crypto/sha256"
+    "encoding/hex"
+    "errors"
+    "fmt"
+    "math/big"
+    "strings"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/middleware"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
+)
+
+const (
+    DefaultListLimit = 20
+    MaxListLimit     = 100
+
+    codeLength = 8
+    // codeAlphabet leaves out characters that are easy to mix up when a
+    // code is read out or typed in
+    codeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+    // codeAttempts bounds retries when a generated code is already taken
+    codeAttempts = 5
+)
+
+// Reasons a referral is rejected instead of rewarded
+const (
+    RejectSameIP       = "same_ip"
+    RejectSameDevice   = "same_device"
+    RejectReusedDevice = "reused_device"
+)
+
+var ErrCodeNotFound = errors.New("referral code not found")
+
+// CoinWallet is the part of gifts that pays coin rewards
+type CoinWallet interface {
+    CreditReward(ctx context.Context, userID int64, coins int, reference string) error
+}
+
+// PremiumGranter is the part of billing that pays premium day rewards
+type PremiumGranter interface {
+    GrantPremium(ctx context.Context, userID int64, days int, reference string) error
+}
+
+// Config sets what a referral earns and where invite links point
+type Config struct {
+    RewardType        string // RewardCoins or RewardPremiumDays
+    RewardCoins       int
+    RewardPremiumDays int
+    AppURL            string
+}
+
+type Service interface {
+    // Signup attribution, called by auth
+    IsValidCode(ctx context.Context, code string) (bool, error)
+    Attribute(ctx context.Context, inviteeID int64, code string) error
+
+    GetDashboard(ctx context.Context, userID int64, limit, offset int) (*Dashboard, error)
+
+    // SubscribeEvents subscribes to the invitee milestones rewards wait for
+    SubscribeEvents(bus *events.Bus)
+
+    SetWallet(wallet CoinWallet)
+    SetPremium(premium PremiumGranter)
+}
+
+type service struct {
+    repo    Repository
+    config  *Config
+    wallet  CoinWallet
+    premium PremiumGranter
+}
+
+func NewService(repo Repository, config *Config) Service {
+    return &service{
+        repo:   repo,
+        config: config,
+    }
+}
+
+// SetWallet sets where coin rewards are credited
+func (s *service) SetWallet(wallet CoinWallet) {
+    s.wallet = wallet
+}
+
+// SetPremium sets where premium day rewards are granted
+func (s *service) SetPremium(premium PremiumGranter) {
+    s.premium = premium
+}
+
+func (s *service) IsValidCode(ctx context.Context, code string) (bool, error) {
+    ownerID, err := s.repo.GetCodeOwner(ctx, normalizeCode(code))
+    return ownerID != 0, err
+}
+
+// Attribute records that the new user signed up with the code, along with
+// the IP and device they signed up from for the abuse checks at reward time
+func (s *service) Attribute(ctx context.Context, inviteeID int64, code string) error {
+    code = normalizeCode(code)
+    referrerID, err := s.repo.GetCodeOwner(ctx, code)
+    if err != nil {
+        return err
+    }
+    if referrerID == 0 || referrerID == inviteeID {
+        return ErrCodeNotFound
+    }
+
+    referral := &Referral{
+        ReferrerID: referrerID,
+        InviteeID:  inviteeID,
+        Code:       code,
+    }
+    client := middleware.ClientInfoFromContext(ctx)
+    if client.IP != "" {
+        referral.SignupIP = &client.IP
+    }
+    if client.DeviceID != "" {
+        referral.DeviceID = &client.DeviceID
+    }
+
+    return s.repo.CreateReferral(ctx, referral)
+}
+
+// GetDashboard returns the user's invite code, creating it on first use,
+// with their referrals so far
+func (s *service) GetDashboard(ctx context.Context, userID int64, limit, offset int) (*Dashboard, error) {
+    code, err := s.getOrCreateCode(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    stats, err := s.repo.GetStats(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    referrals, err := s.repo.GetReferrals(ctx, userID, clampLimit(limit), offset)
+    if err != nil {
+        return nil, err
+    }
+
+    return &Dashboard{
+        Code:      code,
+        InviteURL: strings.TrimRight(s.config.AppURL, "/") + "/invite/" + code,
+        Reward:    s.reward(),
+        Stats:     *stats,
+        Referrals: referrals,
+    }, nil
+}
+
+// getOrCreateCode returns the user's invite code, generating one if needed
+func (s *service) getOrCreateCode(ctx context.Context, userID int64) (string, error) {
+    for i := 0; i < codeAttempts; i++ {
+        code, err := s.repo.GetCode(ctx, userID)
+        if err != nil {
+            return "", err
+        }
+        if code != "" {
+            return code, nil
+        }
+
+        // A lost race or a taken code both leave nothing created; the
+        // next pass picks up the winner's code or tries another
+        if _, err := s.repo.CreateCode(ctx, userID, generateCode()); err != nil {
+            return "", fmt.Errorf("failed to create referral code: %w", err)
+        }
+    }
+    return "", errors.New("failed to create a unique referral code")
+}
+
+// settle rewards both parties once the invitee has verified and completed
+// their profile, or rejects the referral if it looks like self-referral.
+// Rewards carry a reference per referral and user, so a retry after a
+// partial failure doesn't pay anyone twice.
+func (s *service) settle(ctx context.Context, inviteeID int64) error {
+    referral, err := s.repo.GetReferralByInvitee(ctx, inviteeID)
+    if err != nil {
+        return err
+    }
+    if referral == nil || referral.Status != StatusPending {
+        return nil
+    }
+    if referral.VerifiedAt == nil || referral.ProfileCompletedAt == nil {
+        return nil
+    }
+
+    reason, err := s.checkAbuse(ctx, referral)
+    if err != nil {
+        return err
+    }
+    if reason != "" {
+        return s.repo.Reject(ctx, referral.ID, reason)
+    }
+
+    for _, userID := range []int64{referral.ReferrerID, referral.InviteeID} {
+        reference := fmt.Sprintf("referral:%d:%d", referral.ID, userID)
+        if err := s.payReward(ctx, userID, reference); err != nil {
+            return fmt.Errorf("failed to reward user %d for referral %d: %w", userID, referral.ID, err)
+        }
+    }
+
+    return s.repo.MarkRewarded(ctx, referral.ID)
+}
+
+// checkAbuse returns why the referral shouldn't be rewarded, or "" if it
+// should. The invitee signing up from an IP or device the referrer uses, or
+// a device another of their invitees signed up on, points to one person
+// making accounts to collect rewards.
+func (s *service) checkAbuse(ctx context.Context, referral *Referral) (string, error) {
+    if referral.SignupIP != nil {
+        used, err := s.repo.ReferrerUsedIP(ctx, referral.ReferrerID, *referral.SignupIP)
+        if err != nil {
+            return "", err
+        }
+        if used {
+            return RejectSameIP, nil
+        }
+    }
+
+    if referral.DeviceID != nil {
+        used, err := s.repo.ReferrerUsedDevice(ctx, referral.ReferrerID, deviceFingerprint(*referral.DeviceID))
+        if err != nil {
+            return "", err
+        }
+        if used {
+            return RejectSameDevice, nil
+        }
+
+        reused, err := s.repo.DeviceReferred(ctx, referral.ReferrerID, referral.ID, *referral.DeviceID)
+        if err != nil {
+            return "", err
+        }
+        if reused {
+            return RejectReusedDevice, nil
+        }
+    }
+
+    return "", nil
+}
+
+// payReward gives the user the configured reward. Without somewhere to pay
+// it the referral stays pending, and the event is retried.
+func (s *service) payReward(ctx context.Context, userID int64, reference string) error {
+    switch s.config.RewardType {
+    case RewardPremiumDays:
+        if s.premium == nil {
+            return errors.New("no billing configured for premium rewards")
+        }
+        return s.premium.GrantPremium(ctx, userID, s.config.RewardPremiumDays, reference)
+    default:
+        if s.wallet == nil {
+            return errors.New("no wallet configured for coin rewards")
+        }
+        return s.wallet.CreditReward(ctx, userID, s.config.RewardCoins, reference)
+    }
+}
+
+func (s *service) reward() Reward {
+    if s.config.RewardType == RewardPremiumDays {
+        return Reward{Type: RewardPremiumDays, PremiumDays: s.config.RewardPremiumDays}
+    }
+    return Reward{Type: RewardCoins, Coins: s.config.RewardCoins}
+}
+
+// deviceFingerprint matches how auth fingerprints a session's device from
+// its X-Device-ID
+func deviceFingerprint(deviceID string) string {
+    sum := sha256.Sum256([]byte("id:" + deviceID))
+    return hex.EncodeToString(sum[:])
+}
+
+func normalizeCode(code string) string {
+    return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func generateCode() string {
+    code := make([]byte, codeLength)
+    for i := range code {
+        n, _ := rand.Int(rand.Reader, big.NewInt(int64(len(codeAlphabet))))
+        code[i] = codeAlphabet[n.Int64()]
+    }
+    return string(code)
+}
+
+func clampLimit(limit int) int {
+    if limit <= 0 {
+        return DefaultListLimit
+    }
+    if limit > MaxListLimit {
+        return MaxListLimit
+    }
+    return limit
+}
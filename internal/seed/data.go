@@ -0,0 +1,105 @@
+// internal/seed/data.go
+// Word lists demo users and their content are drawn from
+
+package seed
+
+type city struct {
+    name      string
+    latitude  float64
+    longitude float64
+    timezone  string
+}
+
+var cities = []city{
+    {"Lagos", 6.5244, 3.3792, "Africa/Lagos"},
+    {"Abuja", 9.0765, 7.3986, "Africa/Lagos"},
+    {"Accra", 5.6037, -0.1870, "Africa/Accra"},
+    {"Nairobi", -1.2921, 36.8219, "Africa/Nairobi"},
+    {"London", 51.5072, -0.1276, "Europe/London"},
+    {"Manchester", 53.4808, -2.2426, "Europe/London"},
+    {"Berlin", 52.5200, 13.4050, "Europe/Berlin"},
+    {"Paris", 48.8566, 2.3522, "Europe/Paris"},
+    {"New York", 40.7128, -74.0060, "America/New_York"},
+    {"Toronto", 43.6532, -79.3832, "America/Toronto"},
+}
+
+var womenNames = []string{
+    "Amara", "Chioma", "Ada", "Zainab", "Funmi", "Ngozi", "Temi", "Kemi", "Ama", "Efua",
+    "Wanjiru", "Amina", "Sophie", "Emma", "Chloe", "Lena", "Camille", "Olivia", "Maya", "Nia",
+}
+
+var menNames = []string{
+    "Tunde", "Emeka", "Chidi", "Segun", "Kwame", "Kofi", "Musa", "Ibrahim", "Kamau", "Jide",
+    "Obi", "Femi", "James", "Oliver", "Lukas", "Louis", "Daniel", "Noah", "Ethan", "Marcus",
+}
+
+var lastNames = []string{
+    "Okafor", "Adeyemi", "Balogun", "Mensah", "Otieno", "Nwosu", "Bello", "Eze", "Asante", "Mwangi",
+    "Smith", "Brown", "Schmidt", "Martin", "Johnson", "Williams", "Okoro", "Danjuma", "Owusu", "Kariuki",
+}
+
+var interestList = []string{
+    "Travel", "Music", "Afrobeats", "Cooking", "Fitness", "Football", "Photography", "Art",
+    "Movies", "Reading", "Hiking", "Dancing", "Fashion", "Tech", "Gaming", "Yoga",
+    "Coffee", "Wine", "Poetry", "Startups", "Basketball", "Running", "Food", "Nightlife",
+}
+
+var lookingFor = []string{"dating", "relationship", "friends", "networking"}
+
+var bios = []string{
+    "Weekend explorer, weekday overthinker.",
+    "Looking for someone to try every new restaurant in town with.",
+    "Engineer by day, amateur chef by night.",
+    "Ask me about my playlist.",
+    "Gym, jollof, repeat.",
+    "Always planning the next trip.",
+    "Dog person. Will show you pictures.",
+    "Bookshop regular and sunset chaser.",
+    "I make a very good cup of coffee.",
+    "Here for good conversations and better company.",
+}
+
+var captions = []string{
+    "Golden hour never misses 🌅",
+    "Sunday brunch with the crew",
+    "New city, who dis",
+    "Finally tried that place everyone talks about",
+    "Gym progress, slowly but surely 💪",
+    "Beach day was much needed",
+    "Throwback to last summer",
+    "Can't get enough of this view",
+    "Concert last night was unreal 🎶",
+    "Home cooked and proud of it",
+    "Weekend mood",
+    "Small wins count too",
+}
+
+var comments = []string{
+    "Love this!", "Where is this? 😍", "So good", "This is a vibe", "🔥🔥🔥",
+    "Need to go here", "Looking great!", "Take me with you next time", "Beautiful shot", "Haha yes",
+}
+
+var storyCaptions = []string{
+    "Morning run ✅", "Coffee first", "Guess where I am", "Today's fit", "Studio session", "",
+}
+
+var conversation = []string{
+    "Hey! How's your week going?",
+    "Pretty good, busy but good. Yours?",
+    "Same here. I saw you're into travel, where was your last trip?",
+    "Just got back from Accra actually, it was amazing",
+    "No way, I've been wanting to go!",
+    "You should, the food alone is worth it",
+    "Any recommendations?",
+    "So many. We should grab a coffee and I'll tell you all about it",
+    "I'd like that 😊",
+    "How about Saturday?",
+    "Saturday works!",
+}
+
+var hotpickReasons = []string{
+    "You share a lot of interests",
+    "Nearby and active today",
+    "You're looking for the same thing",
+    "Popular in your area",
+}
@@ -0,0 +1,460 @@
+// internal/seed/seed.go
+// Demo data for local development and load tests. Seeded users share an
+// email domain, so a later run can find and replace them without touching
+// real accounts.
+
+package seed
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "math/rand"
+    "strings"
+    "time"
+
+    "github.com/lib/pq"
+    "golang.org/x/crypto/bcrypt"
+)
+
+// EmailDomain is the domain every seeded user's email is at
+const EmailDomain = "seed.kiekky.local"
+
+// Password is every seeded user's password
+const Password = "kiekky-demo"
+
+var ErrAlreadySeeded = errors.New("database already has seeded users")
+
+// Options controls how much is seeded
+type Options struct {
+    Users int
+    // Seed makes runs with the same options produce the same data
+    Seed int64
+    // Reset deletes previously seeded users, and everything of theirs,
+    // first. Without it, seeding a database that has them fails.
+    Reset bool
+}
+
+// Summary counts what was seeded
+type Summary struct {
+    Users         int
+    Photos        int
+    Follows       int
+    Posts         int
+    Likes         int
+    Comments      int
+    Stories       int
+    Conversations int
+    Messages      int
+    Matches       int
+    Hotpicks      int
+}
+
+type user struct {
+    id        int64
+    city      city
+    gender    string
+    interests []string
+}
+
+type seeder struct {
+    tx      *sql.Tx
+    rand    *rand.Rand
+    now     time.Time
+    users   []*user
+    summary Summary
+}
+
+// Run seeds db in one transaction, so a failed run leaves nothing behind
+func Run(ctx context.Context, db *sql.DB, opts Options) (*Summary, error) {
+    if opts.Users < 2 {
+        return nil, fmt.Errorf("need at least 2 users, got %d", opts.Users)
+    }
+
+    tx, err := db.BeginTx(ctx, nil)
+    if err != nil {
+        return nil, err
+    }
+    defer tx.Rollback()
+
+    var existing int
+    if err := tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM users WHERE email LIKE $1`, "%@"+EmailDomain).Scan(&existing); err != nil {
+        return nil, err
+    }
+    if existing > 0 {
+        if !opts.Reset {
+            return nil, ErrAlreadySeeded
+        }
+        if _, err := tx.ExecContext(ctx, `DELETE FROM users WHERE email LIKE $1`, "%@"+EmailDomain); err != nil {
+            return nil, fmt.Errorf("failed to delete seeded users: %w", err)
+        }
+    }
+
+    s := &seeder{
+        tx:   tx,
+        rand: rand.New(rand.NewSource(opts.Seed)),
+        now:  time.Now().UTC(),
+    }
+
+    steps := []struct {
+        name string
+        run  func(ctx context.Context) error
+    }{
+        {"users", func(ctx context.Context) error { return s.seedUsers(ctx, opts.Users) }},
+        {"follows", s.seedFollows},
+        {"posts", s.seedPosts},
+        {"stories", s.seedStories},
+        {"matches", s.seedMatches},
+        {"hotpicks", s.seedHotpicks},
+    }
+    for _, step := range steps {
+        if err := step.run(ctx); err != nil {
+            return nil, fmt.Errorf("failed to seed %s: %w", step.name, err)
+        }
+    }
+
+    if err := tx.Commit(); err != nil {
+        return nil, err
+    }
+    return &s.summary, nil
+}
+
+// seedUsers creates complete, discoverable profiles spread across cities,
+// each with a small photo gallery
+func (s *seeder) seedUsers(ctx context.Context, count int) error {
+    hash, err := bcrypt.GenerateFromPassword([]byte(Password), bcrypt.DefaultCost)
+    if err != nil {
+        return err
+    }
+
+    for i := 1; i <= count; i++ {
+        u := &user{city: cities[s.rand.Intn(len(cities))]}
+
+        var first string
+        if i%2 == 0 {
+            u.gender, first = "female", womenNames[s.rand.Intn(len(womenNames))]
+        } else {
+            u.gender, first = "male", menNames[s.rand.Intn(len(menNames))]
+        }
+        last := lastNames[s.rand.Intn(len(lastNames))]
+        username := fmt.Sprintf("%s_%s%d", strings.ToLower(first), strings.ToLower(last), i)
+        u.interests = s.pick(interestList, 3+s.rand.Intn(4))
+
+        createdAt := s.ago(180 * 24 * time.Hour)
+        dob := s.now.AddDate(-(19 + s.rand.Intn(22)), -s.rand.Intn(12), -s.rand.Intn(28))
+        avatar := photoURL(i, 0)
+
+        err := s.tx.QueryRowContext(ctx, `
+            INSERT INTO users (
+                email, username, password_hash, display_name, bio, gender, date_of_birth,
+                location, latitude, longitude, timezone, interests, looking_for,
+                profile_picture, is_verified, is_profile_complete, profile_completed_at,
+                last_active, last_seen, created_at, updated_at
+            ) VALUES (
+                $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13,
+                $14, TRUE, TRUE, $15, $16, $16, $15, $15
+            )
+            RETURNING id`,
+            fmt.Sprintf("%s@%s", username, EmailDomain), username, string(hash),
+            first+" "+last, bios[s.rand.Intn(len(bios))], u.gender, dob,
+            u.city.name, s.jitter(u.city.latitude), s.jitter(u.city.longitude), u.city.timezone,
+            pq.Array(u.interests), lookingFor[s.rand.Intn(len(lookingFor))],
+            avatar, createdAt, s.ago(72*time.Hour),
+        ).Scan(&u.id)
+        if err != nil {
+            return err
+        }
+
+        photos := 2 + s.rand.Intn(3)
+        for position := 0; position < photos; position++ {
+            _, err := s.tx.ExecContext(ctx, `
+                INSERT INTO profile_photos (user_id, url, position, is_primary, created_at)
+                VALUES ($1, $2, $3, $4, $5)`,
+                u.id, photoURL(i, position), position, position == 0, createdAt)
+            if err != nil {
+                return err
+            }
+        }
+
+        s.users = append(s.users, u)
+        s.summary.Users++
+        s.summary.Photos += photos
+    }
+    return nil
+}
+
+// seedFollows follows mostly people in the same city, so feeds and
+// suggestions look local
+func (s *seeder) seedFollows(ctx context.Context) error {
+    for _, follower := range s.users {
+        targets := 5 + s.rand.Intn(20)
+        for _, following := range s.sample(targets) {
+            if following == follower || (following.city != follower.city && s.rand.Intn(3) > 0) {
+                continue
+            }
+            res, err := s.tx.ExecContext(ctx, `
+                INSERT INTO follows (follower_id, following_id, created_at)
+                VALUES ($1, $2, $3)
+                ON CONFLICT DO NOTHING`,
+                follower.id, following.id, s.ago(90*24*time.Hour))
+            if err != nil {
+                return err
+            }
+            n, _ := res.RowsAffected()
+            s.summary.Follows += int(n)
+        }
+    }
+    return nil
+}
+
+// seedPosts gives every user a few image posts, liked and commented on by
+// others. Like and comment counts are kept by the counting triggers.
+func (s *seeder) seedPosts(ctx context.Context) error {
+    for i, author := range s.users {
+        posts := s.rand.Intn(6)
+        for p := 0; p < posts; p++ {
+            createdAt := s.ago(60 * 24 * time.Hour)
+
+            var postID int64
+            err := s.tx.QueryRowContext(ctx, `
+                INSERT INTO posts (user_id, caption, location, visibility, latitude, longitude, created_at, updated_at)
+                VALUES ($1, $2, $3, 'public', $4, $5, $6, $6)
+                RETURNING id`,
+                author.id, captions[s.rand.Intn(len(captions))], author.city.name,
+                s.jitter(author.city.latitude), s.jitter(author.city.longitude), createdAt,
+            ).Scan(&postID)
+            if err != nil {
+                return err
+            }
+
+            _, err = s.tx.ExecContext(ctx, `
+                INSERT INTO post_media (post_id, media_url, media_type, position, created_at)
+                VALUES ($1, $2, 'image', 0, $3)`,
+                postID, fmt.Sprintf("https://picsum.photos/seed/kiekky-post-%d-%d/1080/1080", i+1, p), createdAt)
+            if err != nil {
+                return err
+            }
+
+            for _, liker := range s.sample(s.rand.Intn(30)) {
+                res, err := s.tx.ExecContext(ctx, `
+                    INSERT INTO post_likes (post_id, user_id, created_at)
+                    VALUES ($1, $2, $3)
+                    ON CONFLICT DO NOTHING`,
+                    postID, liker.id, s.after(createdAt))
+                if err != nil {
+                    return err
+                }
+                n, _ := res.RowsAffected()
+                s.summary.Likes += int(n)
+            }
+
+            for _, commenter := range s.sample(s.rand.Intn(6)) {
+                _, err := s.tx.ExecContext(ctx, `
+                    INSERT INTO comments (post_id, user_id, content, created_at)
+                    VALUES ($1, $2, $3, $4)`,
+                    postID, commenter.id, comments[s.rand.Intn(len(comments))], s.after(createdAt))
+                if err != nil {
+                    return err
+                }
+                s.summary.Comments++
+            }
+
+            s.summary.Posts++
+        }
+    }
+    return nil
+}
+
+// seedStories gives about a third of users stories posted in the last day
+// that haven't expired yet
+func (s *seeder) seedStories(ctx context.Context) error {
+    for i, u := range s.users {
+        if s.rand.Intn(3) != 0 {
+            continue
+        }
+        stories := 1 + s.rand.Intn(3)
+        for n := 0; n < stories; n++ {
+            createdAt := s.ago(20 * time.Hour)
+            _, err := s.tx.ExecContext(ctx, `
+                INSERT INTO stories (user_id, media_url, media_type, caption, expires_at, created_at)
+                VALUES ($1, $2, 'image', $3, $4, $5)`,
+                u.id, fmt.Sprintf("https://picsum.photos/seed/kiekky-story-%d-%d/1080/1920", i+1, n),
+                storyCaptions[s.rand.Intn(len(storyCaptions))], createdAt.Add(24*time.Hour), createdAt)
+            if err != nil {
+                return err
+            }
+            s.summary.Stories++
+        }
+    }
+    return nil
+}
+
+// seedMatches matches users with someone of the other gender, and starts a
+// conversation for most matches
+func (s *seeder) seedMatches(ctx context.Context) error {
+    for i := 0; i+1 < len(s.users); i += 2 {
+        if s.rand.Intn(2) == 0 {
+            continue
+        }
+        a, b := s.users[i], s.users[i+1]
+        if a.id > b.id {
+            a, b = b, a
+        }
+        matchedAt := s.ago(30 * 24 * time.Hour)
+
+        _, err := s.tx.ExecContext(ctx, `
+            INSERT INTO matches (user1_id, user2_id, match_type, compatibility_score, matched_at)
+            VALUES ($1, $2, 'date_accepted', $3, $4)
+            ON CONFLICT (user1_id, user2_id) DO NOTHING`,
+            a.id, b.id, 0.5+s.rand.Float64()/2, matchedAt)
+        if err != nil {
+            return err
+        }
+        s.summary.Matches++
+
+        if s.rand.Intn(4) > 0 {
+            if err := s.seedConversation(ctx, a, b, matchedAt); err != nil {
+                return err
+            }
+        }
+    }
+    return nil
+}
+
+// seedConversation starts a direct conversation between a and b with part
+// of a scripted chat, the last message left unread
+func (s *seeder) seedConversation(ctx context.Context, a, b *user, startedAt time.Time) error {
+    var conversationID int64
+    err := s.tx.QueryRowContext(ctx, `
+        INSERT INTO conversations (type, created_by, is_active, metadata, created_at, updated_at)
+        VALUES ('direct', $1, TRUE, '{}', $2, $2)
+        RETURNING id`,
+        a.id, startedAt,
+    ).Scan(&conversationID)
+    if err != nil {
+        return err
+    }
+
+    for _, participant := range []*user{a, b} {
+        _, err := s.tx.ExecContext(ctx, `
+            INSERT INTO conversation_participants (conversation_id, user_id, role, joined_at, notification_preference)
+            VALUES ($1, $2, 'member', $3, 'all')`,
+            conversationID, participant.id, startedAt)
+        if err != nil {
+            return err
+        }
+    }
+
+    sentAt := startedAt
+    lines := 2 + s.rand.Intn(len(conversation)-1)
+    var last string
+    var lastSender *user
+    for n := 0; n < lines; n++ {
+        sender := a
+        if n%2 == 1 {
+            sender = b
+        }
+        sentAt = sentAt.Add(time.Duration(1+s.rand.Intn(180)) * time.Minute)
+        last, lastSender = conversation[n], sender
+
+        _, err := s.tx.ExecContext(ctx, `
+            INSERT INTO messages (conversation_id, sender_id, content, message_type, metadata, delivered_at, created_at)
+            VALUES ($1, $2, $3, 'text', '{}', $4, $4)`,
+            conversationID, sender.id, last, sentAt)
+        if err != nil {
+            return err
+        }
+        s.summary.Messages++
+    }
+
+    _, err = s.tx.ExecContext(ctx, `
+        UPDATE conversations SET last_message_at = $2, last_message_preview = $3, updated_at = $2
+        WHERE id = $1`,
+        conversationID, sentAt, last)
+    if err != nil {
+        return err
+    }
+    _, err = s.tx.ExecContext(ctx, `
+        UPDATE conversation_participants
+        SET last_read_at = CASE WHEN user_id = $2 THEN $3 ELSE $3 - INTERVAL '1 second' END,
+            unread_count = CASE WHEN user_id = $2 THEN 0 ELSE 1 END
+        WHERE conversation_id = $1`,
+        conversationID, lastSender.id, sentAt)
+    if err != nil {
+        return err
+    }
+
+    s.summary.Conversations++
+    return nil
+}
+
+// seedHotpicks gives every user today's picks from people nearby, unseen
+// and expiring at the end of the day
+func (s *seeder) seedHotpicks(ctx context.Context) error {
+    expiresAt := s.now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+    for _, u := range s.users {
+        picked := 0
+        for _, candidate := range s.sample(20) {
+            if picked == 5 {
+                break
+            }
+            if candidate == u || candidate.gender == u.gender {
+                continue
+            }
+            _, err := s.tx.ExecContext(ctx, `
+                INSERT INTO hotpicks (user_id, recommended_user_id, score, reason, expires_at, created_at)
+                VALUES ($1, $2, $3, $4, $5, $6)`,
+                u.id, candidate.id, 0.6+s.rand.Float64()*0.4,
+                hotpickReasons[s.rand.Intn(len(hotpickReasons))], expiresAt, s.now)
+            if err != nil {
+                return err
+            }
+            picked++
+            s.summary.Hotpicks++
+        }
+    }
+    return nil
+}
+
+// pick returns n distinct items from list
+func (s *seeder) pick(list []string, n int) []string {
+    picked := make([]string, 0, n)
+    for _, i := range s.rand.Perm(len(list))[:min(n, len(list))] {
+        picked = append(picked, list[i])
+    }
+    return picked
+}
+
+// sample returns up to n distinct seeded users
+func (s *seeder) sample(n int) []*user {
+    n = min(n, len(s.users))
+    sampled := make([]*user, 0, n)
+    for _, i := range s.rand.Perm(len(s.users))[:n] {
+        sampled = append(sampled, s.users[i])
+    }
+    return sampled
+}
+
+// ago returns a random time within the last window
+func (s *seeder) ago(window time.Duration) time.Time {
+    return s.now.Add(-time.Duration(s.rand.Int63n(int64(window))))
+}
+
+// after returns a random time between t and now
+func (s *seeder) after(t time.Time) time.Time {
+    if !t.Before(s.now) {
+        return s.now
+    }
+    return t.Add(time.Duration(s.rand.Int63n(int64(s.now.Sub(t)))))
+}
+
+// jitter moves a coordinate up to about 10km, so users in a city aren't all
+// in one spot
+func (s *seeder) jitter(coordinate float64) float64 {
+    return coordinate + (s.rand.Float64()-0.5)*0.18
+}
+
+func photoURL(userIndex, position int) string {
+    return fmt.Sprintf("https://picsum.photos/seed/kiekky-user-%d-%d/600/800", userIndex, position)
+}
@@ -0,0 +1,301 @@
+// internal/stories/archive.go
+// Story archive
+// Expired stories move to their author's archive, where only the author can
+// see them, and are deleted once the author's retention has passed. Their
+// media moves under an archive prefix, so storage rules can treat it apart
+// from live stories. Users can turn the archive off, in which case stories
+// are deleted as soon as they expire, or keep it for a number of days of
+// their own choosing.
+
+package stories
+
+import (
+    "context"
+    "database/sql"
+    "fmt"
+    "log"
+    "time"
+)
+
+// archiveBatch is how many expired stories an archive run moves at a time
+const archiveBatch = 100
+
+// MediaMover is implemented by upload services that can move a stored file
+// to another folder without uploading it again
+type MediaMover interface {
+    MoveFile(ctx context.Context, fileURL, folder string) (string, error)
+}
+
+// ArchiveSettings is a user's story archive policy. RetentionDays is nil
+// when the user keeps the default.
+type ArchiveSettings struct {
+    Enabled              bool `json:"enabled" db:"archive_enabled"`
+    RetentionDays        *int `json:"retention_days" db:"retention_days"`
+    DefaultRetentionDays int  `json:"default_retention_days" db:"-"`
+}
+
+// UpdateArchiveSettingsRequest replaces a user's archive policy. Leaving out
+// retention_days goes back to the default.
+type UpdateArchiveSettingsRequest struct {
+    Enabled       bool `json:"enabled"`
+    RetentionDays *int `json:"retention_days" validate:"omitempty,min=1,max=365"`
+}
+
+// archivedStory is the part of an expired story archiving touches
+type archivedStory struct {
+    ID           int64   `db:"id"`
+    UserID       int64   `db:"user_id"`
+    MediaURL     string  `db:"media_url"`
+    ThumbnailURL *string `db:"thumbnail_url"`
+    PlaybackURL  *string `db:"playback_url"`
+    SharedPostID *int64  `db:"shared_post_id"`
+}
+
+// GetStoryArchive returns the user's own stories, active and archived,
+// newest first
+func (s *service) GetStoryArchive(ctx context.Context, userID int64, limit, offset int) ([]*Story, error) {
+    if limit <= 0 || limit > 100 {
+        limit = 30
+    }
+
+    stories, err := s.repo.GetStoryArchive(ctx, userID, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+    s.attachViewCounts(ctx, stories...)
+    return stories, nil
+}
+
+// GetArchiveSettings returns the user's archive policy
+func (s *service) GetArchiveSettings(ctx context.Context, userID int64) (*ArchiveSettings, error) {
+    settings, err := s.repo.GetArchiveSettings(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    settings.DefaultRetentionDays = s.defaultArchiveDays()
+    return settings, nil
+}
+
+// UpdateArchiveSettings replaces the user's archive policy. Turning the
+// archive off deletes the stories already in it, bar highlighted ones, on
+// the next cleanup run.
+func (s *service) UpdateArchiveSettings(ctx context.Context, userID int64, req *UpdateArchiveSettingsRequest) (*ArchiveSettings, error) {
+    settings := &ArchiveSettings{
+        Enabled:       req.Enabled,
+        RetentionDays: req.RetentionDays,
+    }
+    if err := s.repo.SaveArchiveSettings(ctx, userID, settings); err != nil {
+        return nil, err
+    }
+    settings.DefaultRetentionDays = s.defaultArchiveDays()
+    return settings, nil
+}
+
+func (s *service) defaultArchiveDays() int {
+    return int(s.archiveRetention / (24 * time.Hour))
+}
+
+// CleanupExpiredStories deletes the expired stories of users without an
+// archive and archived ones past their retention, then archives stories
+// that have expired since the last run
+func (s *service) CleanupExpiredStories(ctx context.Context) error {
+    mediaURLs, err := s.repo.PurgeExpiredStories(ctx, s.archiveRetention)
+    if err != nil {
+        return err
+    }
+
+    if s.uploadService != nil {
+        for _, url := range mediaURLs {
+            s.uploadService.DeleteFile(ctx, url)
+        }
+    }
+
+    archived, err := s.archiveExpiredStories(ctx)
+    if archived > 0 {
+        log.Printf("Archived %d expired stories", archived)
+    }
+    return err
+}
+
+// archiveExpiredStories moves newly expired stories into their authors'
+// archives and returns how many were moved
+func (s *service) archiveExpiredStories(ctx context.Context) (int, error) {
+    total := 0
+    for {
+        stories, err := s.repo.GetStoriesToArchive(ctx, archiveBatch)
+        if err != nil {
+            return total, err
+        }
+
+        archived := 0
+        for _, story := range stories {
+            if err := s.archiveStory(ctx, story); err != nil {
+                log.Printf("Failed to archive story %d: %v", story.ID, err)
+                continue
+            }
+            archived++
+        }
+        total += archived
+
+        // Stop when caught up, or when a whole batch failed so the same
+        // stories aren't retried in a loop
+        if len(stories) < archiveBatch || archived == 0 {
+            return total, nil
+        }
+    }
+}
+
+// archiveStory moves one story's media under the archive prefix and marks
+// it archived. Media that can't be moved stays where it is; media of shared
+// posts belongs to the post and is never moved.
+func (s *service) archiveStory(ctx context.Context, story *archivedStory) error {
+    moved := archivedStory{
+        ID:           story.ID,
+        MediaURL:     story.MediaURL,
+        ThumbnailURL: story.ThumbnailURL,
+        PlaybackURL:  story.PlaybackURL,
+    }
+
+    var undo []func()
+    if s.mediaMover != nil && story.SharedPostID == nil {
+        folder := fmt.Sprintf("stories/archive/%d", story.UserID)
+        move := func(url string) string {
+            newURL, err := s.mediaMover.MoveFile(ctx, url, folder)
+            if err != nil {
+                log.Printf("Failed to move story %d media to the archive: %v", story.ID, err)
+                return url
+            }
+            undo = append(undo, func() {
+                if _, err := s.mediaMover.MoveFile(ctx, newURL, fmt.Sprintf("stories/%d", story.UserID)); err != nil {
+                    log.Printf("Failed to restore story %d media %s: %v", story.ID, newURL, err)
+                }
+            })
+            return newURL
+        }
+
+        moved.MediaURL = move(story.MediaURL)
+        if story.ThumbnailURL != nil {
+            url := move(*story.ThumbnailURL)
+            moved.ThumbnailURL = &url
+        }
+        if story.PlaybackURL != nil {
+            url := move(*story.PlaybackURL)
+            moved.PlaybackURL = &url
+        }
+    }
+
+    if err := s.repo.MarkStoryArchived(ctx, &moved); err != nil {
+        for _, restore := range undo {
+            restore()
+        }
+        return err
+    }
+    return nil
+}
+
+// Repository
+
+// GetStoryArchive returns all of a user's stories, newest first
+func (r *postgresRepository) GetStoryArchive(ctx context.Context, userID int64, limit, offset int) ([]*Story, error) {
+    query := `
+        SELECT id, user_id, media_url, media_type, thumbnail_url, caption,
+               duration, is_highlighted, highlight_title, elements, shared_post_id,
+               audience, expires_at, archived_at, created_at, updated_at,
+               processing_status, processing_error, playback_url, hls_url
+        FROM stories
+        WHERE user_id = $1
+        ORDER BY created_at DESC
+        LIMIT $2 OFFSET $3`
+
+    stories := []*Story{}
+    if err := r.db.SelectContext(ctx, &stories, query, userID, limit, offset); err != nil {
+        return nil, err
+    }
+    for _, story := range stories {
+        story.IsExpired = time.Now().After(story.ExpiresAt)
+    }
+    return stories, nil
+}
+
+// GetArchiveSettings returns a user's archive policy, or the default one
+// for users who never changed it
+func (r *postgresRepository) GetArchiveSettings(ctx context.Context, userID int64) (*ArchiveSettings, error) {
+    settings := ArchiveSettings{Enabled: true}
+    err := r.db.GetContext(ctx, &settings,
+        "SELECT archive_enabled, retention_days FROM story_archive_settings WHERE user_id = $1", userID)
+    if err != nil && err != sql.ErrNoRows {
+        return nil, err
+    }
+    return &settings, nil
+}
+
+// SaveArchiveSettings stores a user's archive policy
+func (r *postgresRepository) SaveArchiveSettings(ctx context.Context, userID int64, settings *ArchiveSettings) error {
+    query := `
+        INSERT INTO story_archive_settings (user_id, archive_enabled, retention_days)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (user_id) DO UPDATE
+        SET archive_enabled = EXCLUDED.archive_enabled,
+            retention_days = EXCLUDED.retention_days,
+            updated_at = NOW()`
+
+    _, err := r.db.ExecContext(ctx, query, userID, settings.Enabled, settings.RetentionDays)
+    return err
+}
+
+// GetStoriesToArchive returns up to limit expired stories not archived yet,
+// leaving out videos still being processed
+func (r *postgresRepository) GetStoriesToArchive(ctx context.Context, limit int) ([]*archivedStory, error) {
+    query := `
+        SELECT id, user_id, media_url, thumbnail_url, playback_url, shared_post_id
+        FROM stories
+        WHERE archived_at IS NULL AND expires_at < NOW()
+          AND processing_status IN ('ready', 'failed')
+        ORDER BY expires_at
+        LIMIT $1`
+
+    var stories []*archivedStory
+    if err := r.db.SelectContext(ctx, &stories, query, limit); err != nil {
+        return nil, err
+    }
+    return stories, nil
+}
+
+// MarkStoryArchived records a story as archived, with its media's new URLs
+func (r *postgresRepository) MarkStoryArchived(ctx context.Context, story *archivedStory) error {
+    query := `
+        UPDATE stories
+        SET archived_at = NOW(), media_url = $2, thumbnail_url = $3, playback_url = $4, updated_at = NOW()
+        WHERE id = $1`
+
+    _, err := r.db.ExecContext(ctx, query, story.ID, story.MediaURL, story.ThumbnailURL, story.PlaybackURL)
+    return err
+}
+
+// PurgeExpiredStories deletes expired stories of users who turned their
+// archive off, and archived stories past their author's retention, or
+// defaultRetention for authors who kept the default. Highlighted stories
+// are kept. It returns the media files to delete.
+func (r *postgresRepository) PurgeExpiredStories(ctx context.Context, defaultRetention time.Duration) ([]string, error) {
+    query := `
+        WITH purged AS (
+            DELETE FROM stories s
+            WHERE s.is_highlighted = false AND s.expires_at < NOW()
+              AND (
+                  EXISTS(SELECT 1 FROM story_archive_settings a
+                         WHERE a.user_id = s.user_id AND NOT a.archive_enabled)
+                  OR s.archived_at < NOW() - COALESCE(
+                      (SELECT a.retention_days * INTERVAL '1 day' FROM story_archive_settings a
+                       WHERE a.user_id = s.user_id),
+                      $1 * INTERVAL '1 second')
+              )
+            RETURNING s.media_url, s.thumbnail_url, s.playback_url, s.shared_post_id
+        )
+        SELECT url FROM purged,
+            LATERAL unnest(ARRAY[media_url, thumbnail_url, playback_url]) AS url
+        WHERE shared_post_id IS NULL AND url IS NOT NULL`
+
+    var urls []string
+    err := r.db.SelectContext(ctx, &urls, query, defaultRetention.Seconds())
+    return urls, err
+}
@@ -3,7 +3,6 @@ package stories
 import (
     "context"
     "log"
-    "os"
     "time"
 )
 
@@ -12,15 +11,9 @@ type CleanupService struct {
     interval time.Duration
 }
 
-func NewCleanupService(service Service) *CleanupService {
-    // Get cleanup interval from env or default to 1 hour
-    intervalStr := os.Getenv("STORY_CLEANUP_INTERVAL")
-    interval := 1 * time.Hour
-    
-    if intervalStr != "" {
-        if parsed, err := time.ParseDuration(intervalStr); err == nil {
-            interval = parsed
-        }
+func NewCleanupService(service Service, interval time.Duration) *CleanupService {
+    if interval <= 0 {
+        interval = 1 * time.Hour
     }
     
     return &CleanupService{
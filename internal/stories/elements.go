@@ -0,0 +1,115 @@
+package stories
+
+import (
+    "database/sql/driver"
+    "encoding/json"
+    "errors"
+    "fmt"
+    "net/url"
+)
+
+// Story element (sticker) types
+const (
+    ElementMention = "mention" // A tagged user
+    ElementPost    = "post"    // A linked post, opens the post when tapped
+    ElementLink    = "link"    // An external link sticker
+)
+
+const maxStoryElements = 20
+
+var (
+    ErrInvalidElement = errors.New("invalid story element")
+    ErrPostNotFound   = errors.New("post not found")
+)
+
+// StoryElement is a structured sticker placed on a story. Position and size
+// are relative to the story frame (0..1) so clients can render at any size.
+type StoryElement struct {
+    Type     string  `json:"type"`
+    UserID   *int64  `json:"user_id,omitempty"`
+    Username string  `json:"username,omitempty"`
+    PostID   *int64  `json:"post_id,omitempty"`
+    URL      string  `json:"url,omitempty"`
+    Label    string  `json:"label,omitempty"`
+    DeepLink string  `json:"deep_link,omitempty"`
+    X        float64 `json:"x"`
+    Y        float64 `json:"y"`
+    Width    float64 `json:"width,omitempty"`
+    Height   float64 `json:"height,omitempty"`
+    Rotation float64 `json:"rotation,omitempty"`
+}
+
+// StoryElements is stored as JSONB on stories.elements
+type StoryElements []StoryElement
+
+// Scan implements sql.Scanner interface
+func (se *StoryElements) Scan(value interface{}) error {
+    if value == nil {
+        *se = StoryElements{}
+        return nil
+    }
+
+    bytes, ok := value.([]byte)
+    if !ok {
+        return nil
+    }
+
+    return json.Unmarshal(bytes, se)
+}
+
+// Value implements driver.Valuer interface
+func (se StoryElements) Value() (driver.Value, error) {
+    if se == nil {
+        return "[]", nil
+    }
+    return json.Marshal(se)
+}
+
+// MentionedUserIDs returns the distinct users tagged by mention stickers
+func (se StoryElements) MentionedUserIDs() []int64 {
+    seen := make(map[int64]bool)
+    var ids []int64
+    for _, el := range se {
+        if el.Type == ElementMention && el.UserID != nil && !seen[*el.UserID] {
+            seen[*el.UserID] = true
+            ids = append(ids, *el.UserID)
+        }
+    }
+    return ids
+}
+
+// postDeepLink is the in-app link that opens a post
+func postDeepLink(postID int64) string {
+    return fmt.Sprintf("kiekky://posts/%d", postID)
+}
+
+// validate checks element shape; references to users and posts are checked by the service
+func (el *StoryElement) validate() error {
+    if el.X < 0 || el.X > 1 || el.Y < 0 || el.Y > 1 {
+        return fmt.Errorf("%w: position must be between 0 and 1", ErrInvalidElement)
+    }
+
+    switch el.Type {
+    case ElementMention:
+        if el.UserID == nil {
+            return fmt.Errorf("%w: mention requires user_id", ErrInvalidElement)
+        }
+    case ElementPost:
+        if el.PostID == nil {
+            return fmt.Errorf("%w: post sticker requires post_id", ErrInvalidElement)
+        }
+        el.DeepLink = postDeepLink(*el.PostID)
+    case ElementLink:
+        u, err := url.Parse(el.URL)
+        if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+            return fmt.Errorf("%w: link sticker requires an http(s) url", ErrInvalidElement)
+        }
+        if len(el.Label) > 100 {
+            return fmt.Errorf("%w: link label is too long", ErrInvalidElement)
+        }
+    default:
+        return fmt.Errorf("%w: unknown type %q", ErrInvalidElement, el.Type)
+    }
+
+    return nil
+}
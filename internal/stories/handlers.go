@@ -1,46 +1,95 @@
 package stories
 
 import (
-    "encoding/json"
+    "errors"
     "net/http"
     "strconv"
     
     "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
     "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+    "github.com/imadgeboyega/kiekky-backend/internal/moderation"
+)
+
+// Upload caps used until SetUploadLimits is called
+const (
+    defaultMaxMediaSize = 100 << 20
+    defaultMaxCoverSize = 10 << 20
 )
 
 type Handler struct {
-    service Service
+    service      Service
+    maxMediaSize int64
+    maxCoverSize int64
 }
 
 func NewHandler(service Service) *Handler {
-    return &Handler{service: service}
+    return &Handler{
+        service:      service,
+        maxMediaSize: defaultMaxMediaSize,
+        maxCoverSize: defaultMaxCoverSize,
+    }
+}
+
+// SetUploadLimits caps story media and highlight cover uploads, in bytes
+func (h *Handler) SetUploadLimits(media, cover int64) {
+    h.maxMediaSize = media
+    h.maxCoverSize = cover
 }
 
 // CreateStory handles story creation
 func (h *Handler) CreateStory(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     var req CreateStoryRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
-    // Validate request
-    if req.MediaURL == "" || req.MediaType == "" {
-        utils.RespondWithError(w, http.StatusBadRequest, "Media URL and type are required")
+    story, err := h.service.CreateStory(r.Context(), userID, &req)
+    if err != nil {
+        if errors.Is(err, ErrInvalidElement) || errors.Is(err, ErrVideoTooLong) {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create story")
+        }
         return
     }
     
-    if req.MediaType != "image" && req.MediaType != "video" {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid media type")
+    utils.RespondWithJSON(w, http.StatusCreated, story)
+}
+
+// SharePostToStory reshares a post into the user's story
+func (h *Handler) SharePostToStory(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
         return
     }
     
-    story, err := h.service.CreateStory(r.Context(), userID, &req)
+    var req SharePostRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    story, err := h.service.SharePostToStory(r.Context(), userID, &req)
     if err != nil {
-        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create story")
+        switch {
+        case err == ErrPostNotFound:
+            utils.RespondWithError(w, http.StatusNotFound, "Post not found")
+        case err == ErrUnauthorized:
+            utils.RespondWithError(w, http.StatusForbidden, "This post can't be shared")
+        case err == ErrInvalidMedia:
+            utils.RespondWithError(w, http.StatusBadRequest, "Post has no media to share")
+        case errors.Is(err, ErrInvalidElement):
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to share post")
+        }
         return
     }
     
@@ -49,7 +98,11 @@ func (h *Handler) CreateStory(w http.ResponseWriter, r *http.Request) {
 
 // GetStory retrieves a specific story
 func (h *Handler) GetStory(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     storyID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -75,7 +128,11 @@ func (h *Handler) GetStory(w http.ResponseWriter, r *http.Request) {
 
 // GetActiveStories retrieves active stories feed
 func (h *Handler) GetActiveStories(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
     // Parse query parameters
     limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
@@ -94,9 +151,40 @@ func (h *Handler) GetActiveStories(w http.ResponseWriter, r *http.Request) {
     utils.RespondWithJSON(w, http.StatusOK, response)
 }
 
+// GetStoryFeed retrieves the story tray grouped by author
+func (h *Handler) GetStoryFeed(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+    
+    if limit <= 0 || limit > 50 {
+        limit = 20
+    }
+    if offset < 0 {
+        offset = 0
+    }
+    
+    response, err := h.service.GetStoryFeed(r.Context(), userID, limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get story feed")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, response)
+}
+
 // GetUserStories retrieves all stories for a specific user
 func (h *Handler) GetUserStories(w http.ResponseWriter, r *http.Request) {
-    viewerID := r.Context().Value("userID").(int64)
+    viewerID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     userID, err := strconv.ParseInt(vars["userId"], 10, 64)
@@ -116,7 +204,11 @@ func (h *Handler) GetUserStories(w http.ResponseWriter, r *http.Request) {
 
 // DeleteStory handles story deletion
 func (h *Handler) DeleteStory(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     storyID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -141,7 +233,11 @@ func (h *Handler) DeleteStory(w http.ResponseWriter, r *http.Request) {
 
 // ViewStory marks a story as viewed
 func (h *Handler) ViewStory(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     storyID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -166,7 +262,11 @@ func (h *Handler) ViewStory(w http.ResponseWriter, r *http.Request) {
 
 // ReplyToStory handles story replies
 func (h *Handler) ReplyToStory(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     storyID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -176,8 +276,7 @@ func (h *Handler) ReplyToStory(w http.ResponseWriter, r *http.Request) {
     }
     
     var req StoryReplyRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
@@ -200,7 +299,11 @@ func (h *Handler) ReplyToStory(w http.ResponseWriter, r *http.Request) {
 
 // GetStoryViews retrieves story views
 func (h *Handler) GetStoryViews(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     storyID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -226,7 +329,11 @@ func (h *Handler) GetStoryViews(w http.ResponseWriter, r *http.Request) {
 
 // GetStoryReplies retrieves story replies
 func (h *Handler) GetStoryReplies(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     storyID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -250,9 +357,109 @@ func (h *Handler) GetStoryReplies(w http.ResponseWriter, r *http.Request) {
     utils.RespondWithJSON(w, http.StatusOK, replies)
 }
 
+// RecordInteraction records what a viewer did with a story, e.g. watching
+// it to the end or tapping through to the author's profile
+func (h *Handler) RecordInteraction(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    vars := mux.Vars(r)
+    
+    storyID, err := strconv.ParseInt(vars["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid story ID")
+        return
+    }
+    
+    var req InteractionRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    if err := h.service.RecordInteraction(r.Context(), storyID, userID, &req); err != nil {
+        if err == ErrStoryNotFound {
+            utils.RespondWithError(w, http.StatusNotFound, "Story not found")
+        } else if err == ErrInvalidInteraction {
+            utils.RespondWithError(w, http.StatusBadRequest, "Invalid interaction type")
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to record interaction")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Interaction recorded"})
+}
+
+// GetStoryInsights retrieves a story's insights for its author
+func (h *Handler) GetStoryInsights(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    vars := mux.Vars(r)
+    
+    storyID, err := strconv.ParseInt(vars["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid story ID")
+        return
+    }
+    
+    insights, err := h.service.GetStoryInsights(r.Context(), storyID, userID)
+    if err != nil {
+        if err == ErrStoryNotFound {
+            utils.RespondWithError(w, http.StatusNotFound, "Story not found")
+        } else if err == ErrUnauthorized {
+            utils.RespondWithError(w, http.StatusForbidden, "Unauthorized")
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get insights")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, insights)
+}
+
+// GetInsightsSummary retrieves insights across the user's stories of the
+// last 7 or 30 days (?days=, 7 by default)
+func (h *Handler) GetInsightsSummary(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    days := 7
+    if daysStr := r.URL.Query().Get("days"); daysStr != "" {
+        var err error
+        if days, err = strconv.Atoi(daysStr); err != nil {
+            utils.RespondWithError(w, http.StatusBadRequest, "Invalid days")
+            return
+        }
+    }
+    
+    summary, err := h.service.GetInsightsSummary(r.Context(), userID, days)
+    if err != nil {
+        if err == ErrInvalidPeriod {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get insights")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, summary)
+}
+
 // MarkReplyAsRead marks a reply as read
 func (h *Handler) MarkReplyAsRead(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     replyID, err := strconv.ParseInt(vars["replyId"], 10, 64)
@@ -273,25 +480,94 @@ func (h *Handler) MarkReplyAsRead(w http.ResponseWriter, r *http.Request) {
     utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Reply marked as read"})
 }
 
+// GetCloseFriends lists the user's close friends
+func (h *Handler) GetCloseFriends(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    friends, err := h.service.GetCloseFriends(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get close friends")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, friends)
+}
+
+// AddCloseFriend adds a user to the close friends list
+func (h *Handler) AddCloseFriend(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    vars := mux.Vars(r)
+    
+    friendID, err := strconv.ParseInt(vars["userId"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+        return
+    }
+    
+    if err := h.service.AddCloseFriend(r.Context(), userID, friendID); err != nil {
+        if err == ErrInvalidFriend {
+            utils.RespondWithError(w, http.StatusBadRequest, "Invalid close friend")
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to add close friend")
+        }
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+        "message": "Added to close friends",
+    })
+}
+
+// RemoveCloseFriend removes a user from the close friends list
+func (h *Handler) RemoveCloseFriend(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    vars := mux.Vars(r)
+    
+    friendID, err := strconv.ParseInt(vars["userId"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+        return
+    }
+    
+    if err := h.service.RemoveCloseFriend(r.Context(), userID, friendID); err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to remove close friend")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{
+        "message": "Removed from close friends",
+    })
+}
+
 // CreateHighlight creates a story highlight
 func (h *Handler) CreateHighlight(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
-    
-    var req CreateHighlightRequest
-    if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-        utils.RespondWithError(w, http.StatusBadRequest, "Invalid request payload")
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
         return
     }
     
-    if req.Title == "" || len(req.StoryIDs) == 0 {
-        utils.RespondWithError(w, http.StatusBadRequest, "Title and story IDs are required")
+    var req CreateHighlightRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
         return
     }
     
     highlight, err := h.service.CreateHighlight(r.Context(), userID, &req)
     if err != nil {
-        if err == ErrUnauthorized {
-            utils.RespondWithError(w, http.StatusForbidden, "Unauthorized")
+        if errors.Is(err, ErrInvalidHighlight) {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
         } else {
             utils.RespondWithError(w, http.StatusInternalServerError, "Failed to create highlight")
         }
@@ -301,32 +577,240 @@ func (h *Handler) CreateHighlight(w http.ResponseWriter, r *http.Request) {
     utils.RespondWithJSON(w, http.StatusCreated, highlight)
 }
 
-// GetHighlights retrieves user highlights
+// GetHighlights retrieves the user's highlights, or another user's with
+// ?user_id= as the viewer may see them
 func (h *Handler) GetHighlights(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    viewerID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    ownerID := viewerID
     
     // Check if specific user ID is provided
     if userIDStr := r.URL.Query().Get("user_id"); userIDStr != "" {
         var err error
-        userID, err = strconv.ParseInt(userIDStr, 10, 64)
+        ownerID, err = strconv.ParseInt(userIDStr, 10, 64)
         if err != nil {
             utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
             return
         }
     }
     
-    highlights, err := h.service.GetUserHighlights(r.Context(), userID)
+    h.respondWithHighlights(w, r, ownerID, viewerID)
+}
+
+// GetUserHighlights retrieves another user's highlights as the viewer may
+// see them
+func (h *Handler) GetUserHighlights(w http.ResponseWriter, r *http.Request) {
+    viewerID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    ownerID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid user ID")
+        return
+    }
+    
+    h.respondWithHighlights(w, r, ownerID, viewerID)
+}
+
+func (h *Handler) respondWithHighlights(w http.ResponseWriter, r *http.Request, ownerID, viewerID int64) {
+    highlights, err := h.service.GetHighlightsForViewer(r.Context(), ownerID, viewerID)
     if err != nil {
-        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get highlights")
+        if err == ErrHighlightsHidden {
+            utils.RespondWithError(w, http.StatusForbidden, "This user's highlights are private")
+        } else {
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get highlights")
+        }
         return
     }
     
     utils.RespondWithJSON(w, http.StatusOK, highlights)
 }
 
+// UpdateHighlight edits a highlight's title, stories or cover
+func (h *Handler) UpdateHighlight(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    highlightID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid highlight ID")
+        return
+    }
+    
+    var req UpdateHighlightRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    highlight, err := h.service.UpdateHighlight(r.Context(), highlightID, userID, &req)
+    if err != nil {
+        h.respondHighlightError(w, err, "Failed to update highlight")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, highlight)
+}
+
+// UploadHighlightCover uploads a cover image for a highlight
+func (h *Handler) UploadHighlightCover(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    highlightID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid highlight ID")
+        return
+    }
+    
+    if err := utils.ParseUpload(w, r, h.maxCoverSize); err != nil {
+        if utils.IsTooLarge(err) {
+            utils.RespondWithError(w, http.StatusRequestEntityTooLarge, utils.UploadTooLargeMessage(h.maxCoverSize))
+            return
+        }
+        utils.RespondWithError(w, http.StatusBadRequest, "Failed to parse form")
+        return
+    }
+    
+    file, header, err := r.FormFile("cover")
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Failed to get file")
+        return
+    }
+    defer file.Close()
+    if header.Size > h.maxCoverSize {
+        utils.RespondWithError(w, http.StatusRequestEntityTooLarge, utils.UploadTooLargeMessage(h.maxCoverSize))
+        return
+    }
+    
+    highlight, err := h.service.UploadHighlightCover(r.Context(), highlightID, userID, file, header)
+    if err != nil {
+        if moderation.RespondUploadError(w, err) {
+            return
+        }
+        if errors.Is(err, ErrInvalidMedia) {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+            return
+        }
+        h.respondHighlightError(w, err, "Failed to upload cover")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, highlight)
+}
+
+// ReorderHighlights sets the order highlights are shown in
+func (h *Handler) ReorderHighlights(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    var req ReorderHighlightsRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    if err := h.service.ReorderHighlights(r.Context(), userID, &req); err != nil {
+        h.respondHighlightError(w, err, "Failed to reorder highlights")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Highlights reordered"})
+}
+
+func (h *Handler) respondHighlightError(w http.ResponseWriter, err error, fallback string) {
+    switch {
+    case err == ErrHighlightNotFound:
+        utils.RespondWithError(w, http.StatusNotFound, "Highlight not found")
+    case err == ErrUnauthorized:
+        utils.RespondWithError(w, http.StatusForbidden, "Unauthorized")
+    case errors.Is(err, ErrInvalidHighlight):
+        utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+    default:
+        utils.RespondWithError(w, http.StatusInternalServerError, fallback)
+    }
+}
+
+// GetStoryArchive lists the user's archived and current stories
+func (h *Handler) GetStoryArchive(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+    
+    stories, err := h.service.GetStoryArchive(r.Context(), userID, limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get story archive")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, stories)
+}
+
+// GetArchiveSettings returns the user's story archive policy
+func (h *Handler) GetArchiveSettings(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    settings, err := h.service.GetArchiveSettings(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get archive settings")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, settings)
+}
+
+// UpdateArchiveSettings turns the story archive on or off and sets how long
+// stories are kept in it
+func (h *Handler) UpdateArchiveSettings(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+    
+    var req UpdateArchiveSettingsRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+    
+    settings, err := h.service.UpdateArchiveSettings(r.Context(), userID, &req)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to update archive settings")
+        return
+    }
+    
+    utils.RespondWithJSON(w, http.StatusOK, settings)
+}
+
 // DeleteHighlight deletes a highlight
 func (h *Handler) DeleteHighlight(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     vars := mux.Vars(r)
     
     highlightID, err := strconv.ParseInt(vars["id"], 10, 64)
@@ -336,11 +820,7 @@ func (h *Handler) DeleteHighlight(w http.ResponseWriter, r *http.Request) {
     }
     
     if err := h.service.DeleteHighlight(r.Context(), highlightID, userID); err != nil {
-        if err == ErrUnauthorized {
-            utils.RespondWithError(w, http.StatusForbidden, "Unauthorized")
-        } else {
-            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to delete highlight")
-        }
+        h.respondHighlightError(w, err, "Failed to delete highlight")
         return
     }
     
@@ -349,11 +829,17 @@ func (h *Handler) DeleteHighlight(w http.ResponseWriter, r *http.Request) {
 
 // UploadMedia handles media file upload for stories
 func (h *Handler) UploadMedia(w http.ResponseWriter, r *http.Request) {
-    userID := r.Context().Value("userID").(int64)
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
     
-    // Parse multipart form
-    err := r.ParseMultipartForm(100 << 20) // 100MB max
-    if err != nil {
+    if err := utils.ParseUpload(w, r, h.maxMediaSize); err != nil {
+        if utils.IsTooLarge(err) {
+            utils.RespondWithError(w, http.StatusRequestEntityTooLarge, utils.UploadTooLargeMessage(h.maxMediaSize))
+            return
+        }
         utils.RespondWithError(w, http.StatusBadRequest, "Failed to parse form")
         return
     }
@@ -364,11 +850,20 @@ func (h *Handler) UploadMedia(w http.ResponseWriter, r *http.Request) {
         return
     }
     defer file.Close()
+    if header.Size > h.maxMediaSize {
+        utils.RespondWithError(w, http.StatusRequestEntityTooLarge, utils.UploadTooLargeMessage(h.maxMediaSize))
+        return
+    }
     
     url, err := h.service.UploadStoryMedia(r.Context(), userID, file, header)
     if err != nil {
+        if moderation.RespondUploadError(w, err) {
+            return
+        }
         if err == ErrInvalidMedia {
             utils.RespondWithError(w, http.StatusBadRequest, "Invalid media file")
+        } else if errors.Is(err, ErrVideoTooLong) {
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
         } else {
             utils.RespondWithError(w, http.StatusInternalServerError, "Failed to upload media")
         }
@@ -0,0 +1,313 @@
+// internal/stories/highlights.go
+// Highlight editing and viewing
+// Highlights are built from the author's story archive (see archive.go).
+// Stories in a highlight are kept for as long as the highlight is.
+
+package stories
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "mime/multipart"
+    "path/filepath"
+    "strings"
+    "time"
+
+    "github.com/lib/pq"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
+)
+
+var (
+    ErrHighlightNotFound = errors.New("highlight not found")
+    ErrHighlightsHidden  = errors.New("highlights are not visible to this user")
+    ErrInvalidHighlight  = errors.New("invalid highlight")
+)
+
+// maxCoverSize caps uploaded highlight covers
+const maxCoverSize = 5 * 1024 * 1024
+
+// UpdateHighlightRequest edits a highlight. Fields left out are unchanged.
+type UpdateHighlightRequest struct {
+    Title      *string `json:"title,omitempty" validate:"omitempty,min=1,max=100"`
+    StoryIDs   []int64 `json:"story_ids,omitempty" validate:"omitempty,min=1,max=100,dive,gt=0"`
+    CoverImage *string `json:"cover_image,omitempty" validate:"omitempty,url"`
+}
+
+// ReorderHighlightsRequest lists all of a user's highlights in display order
+type ReorderHighlightsRequest struct {
+    HighlightIDs []int64 `json:"highlight_ids" validate:"required,min=1,dive,gt=0"`
+}
+
+// Service
+
+// ownedStoryIDs dedupes ids, keeping their order, and checks every story
+// belongs to userID
+func (s *service) ownedStoryIDs(ctx context.Context, userID int64, ids []int64) ([]int64, error) {
+    seen := make(map[int64]bool, len(ids))
+    unique := make([]int64, 0, len(ids))
+    for _, id := range ids {
+        if !seen[id] {
+            seen[id] = true
+            unique = append(unique, id)
+        }
+    }
+
+    owned, err := s.repo.CountOwnedStories(ctx, userID, unique)
+    if err != nil {
+        return nil, err
+    }
+    if owned != len(unique) {
+        return nil, fmt.Errorf("%w: stories must be your own and ready", ErrInvalidHighlight)
+    }
+    return unique, nil
+}
+
+// loadHighlightStories fills in the stories of each highlight the viewer
+// may see, dropping highlights left with none
+func (s *service) loadHighlightStories(ctx context.Context, highlights []*StoryHighlight, ownerID, viewerID int64) ([]*StoryHighlight, error) {
+    owner, _ := s.repo.GetStoryUser(ctx, ownerID)
+
+    visible := make([]*StoryHighlight, 0, len(highlights))
+    for _, highlight := range highlights {
+        stories, err := s.repo.GetHighlightStories(ctx, highlight.StoryIDs, viewerID)
+        if err != nil {
+            return nil, err
+        }
+        if len(stories) == 0 && ownerID != viewerID {
+            continue
+        }
+
+        for _, story := range stories {
+            story.User = owner
+        }
+        s.attachViewCounts(ctx, stories...)
+        highlight.Stories = stories
+        visible = append(visible, highlight)
+    }
+    return visible, nil
+}
+
+// GetHighlightsForViewer returns a user's highlights as viewerID sees them:
+// only stories in the viewer's audience, and nothing when the profile is
+// hidden from them
+func (s *service) GetHighlightsForViewer(ctx context.Context, ownerID, viewerID int64) ([]*StoryHighlight, error) {
+    if ownerID == viewerID {
+        return s.GetUserHighlights(ctx, ownerID)
+    }
+
+    allowed, err := s.repo.CanViewHighlights(ctx, ownerID, viewerID)
+    if err != nil {
+        return nil, err
+    }
+    if !allowed {
+        return nil, ErrHighlightsHidden
+    }
+
+    highlights, err := s.repo.GetUserHighlights(ctx, ownerID)
+    if err != nil {
+        return nil, err
+    }
+    return s.loadHighlightStories(ctx, highlights, ownerID, viewerID)
+}
+
+// getOwnHighlight loads a highlight for editing by its owner
+func (s *service) getOwnHighlight(ctx context.Context, highlightID, userID int64) (*StoryHighlight, error) {
+    highlight, err := s.repo.GetHighlight(ctx, highlightID)
+    if err != nil {
+        return nil, err
+    }
+    if highlight.UserID != userID {
+        return nil, ErrUnauthorized
+    }
+    return highlight, nil
+}
+
+// UpdateHighlight changes a highlight's title, stories or cover
+func (s *service) UpdateHighlight(ctx context.Context, highlightID, userID int64, req *UpdateHighlightRequest) (*StoryHighlight, error) {
+    highlight, err := s.getOwnHighlight(ctx, highlightID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    oldCover := s.uploadedCover(highlight)
+    if req.Title != nil {
+        highlight.Title = *req.Title
+    }
+    if req.StoryIDs != nil {
+        highlight.StoryIDs, err = s.ownedStoryIDs(ctx, userID, req.StoryIDs)
+        if err != nil {
+            return nil, err
+        }
+    }
+    if req.CoverImage != nil {
+        highlight.CoverImage = req.CoverImage
+        highlight.CoverUploaded = false
+    }
+
+    err = s.uow.Do(ctx, func(ctx context.Context) error {
+        if err := s.repo.UpdateHighlight(ctx, highlight); err != nil {
+            return err
+        }
+        return s.repo.SyncHighlightedStories(ctx, userID)
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    if oldCover != "" && req.CoverImage != nil {
+        s.uploadService.DeleteFile(ctx, oldCover)
+    }
+    return s.reloadHighlight(ctx, highlight)
+}
+
+// UploadHighlightCover stores an image as the highlight's cover, replacing
+// any cover uploaded before
+func (s *service) UploadHighlightCover(ctx context.Context, highlightID, userID int64, file multipart.File, header *multipart.FileHeader) (*StoryHighlight, error) {
+    highlight, err := s.getOwnHighlight(ctx, highlightID, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    ext := strings.ToLower(filepath.Ext(header.Filename))
+    if ext != ".jpg" && ext != ".jpeg" && ext != ".png" && ext != ".webp" {
+        return nil, ErrInvalidMedia
+    }
+    if header.Size > maxCoverSize {
+        return nil, fmt.Errorf("%w: cover must be at most 5MB", ErrInvalidMedia)
+    }
+
+    url, err := s.uploadService.UploadFile(ctx, file, header, fmt.Sprintf("stories/%d/highlights", userID))
+    if err != nil {
+        return nil, err
+    }
+
+    oldCover := s.uploadedCover(highlight)
+    highlight.CoverImage = &url
+    highlight.CoverUploaded = true
+    if err := s.repo.UpdateHighlight(ctx, highlight); err != nil {
+        s.uploadService.DeleteFile(ctx, url)
+        return nil, err
+    }
+
+    if oldCover != "" {
+        s.uploadService.DeleteFile(ctx, oldCover)
+    }
+    return s.reloadHighlight(ctx, highlight)
+}
+
+// uploadedCover is the highlight's cover file when it was uploaded for it,
+// rather than taken from one of its stories
+func (s *service) uploadedCover(highlight *StoryHighlight) string {
+    if !highlight.CoverUploaded || highlight.CoverImage == nil || s.uploadService == nil {
+        return ""
+    }
+    return *highlight.CoverImage
+}
+
+// reloadHighlight fills in an edited highlight's stories for the response
+func (s *service) reloadHighlight(ctx context.Context, highlight *StoryHighlight) (*StoryHighlight, error) {
+    loaded, err := s.loadHighlightStories(ctx, []*StoryHighlight{highlight}, highlight.UserID, highlight.UserID)
+    if err != nil {
+        return nil, err
+    }
+    return loaded[0], nil
+}
+
+// ReorderHighlights sets the display order of the user's highlights
+func (s *service) ReorderHighlights(ctx context.Context, userID int64, req *ReorderHighlightsRequest) error {
+    return s.uow.Do(ctx, func(ctx context.Context) error {
+        updated, err := s.repo.UpdateHighlightPositions(ctx, userID, req.HighlightIDs)
+        if err != nil {
+            return err
+        }
+        if updated != len(req.HighlightIDs) {
+            return fmt.Errorf("%w: highlight_ids must be your own highlights, each once", ErrInvalidHighlight)
+        }
+        return nil
+    })
+}
+
+// Repository
+
+// CountOwnedStories counts the stories among ids that userID posted and
+// that have finished processing
+func (r *postgresRepository) CountOwnedStories(ctx context.Context, userID int64, ids []int64) (int, error) {
+    var count int
+    err := r.db.GetContext(ctx, &count, `
+        SELECT COUNT(*) FROM stories
+        WHERE user_id = $1 AND id = ANY($2) AND processing_status = 'ready'`,
+        userID, pq.Array(ids))
+    return count, err
+}
+
+// GetHighlightStories returns the stories among ids the viewer ($1) may
+// see, in the order of ids
+func (r *postgresRepository) GetHighlightStories(ctx context.Context, ids []int64, viewerID int64) ([]*Story, error) {
+    query := `
+        SELECT s.id, s.user_id, s.media_url, s.media_type, s.thumbnail_url, s.caption,
+               s.duration, s.is_highlighted, s.highlight_title, s.elements,
+               s.shared_post_id, s.audience, s.expires_at, s.created_at, s.updated_at,
+               s.processing_status, s.processing_error, s.playback_url, s.hls_url
+        FROM stories s
+        WHERE s.id = ANY($2) AND` + audienceFilter + `
+        ORDER BY array_position($2, s.id::bigint)`
+
+    stories := []*Story{}
+    if err := r.db.SelectContext(ctx, &stories, query, viewerID, pq.Array(ids)); err != nil {
+        return nil, err
+    }
+    for _, story := range stories {
+        story.IsExpired = time.Now().After(story.ExpiresAt)
+    }
+    return stories, nil
+}
+
+// CanViewHighlights reports whether viewerID may see ownerID's highlights:
+// the owner is active, neither has blocked the other, and the profile is
+// public or the viewer follows it
+func (r *postgresRepository) CanViewHighlights(ctx context.Context, ownerID, viewerID int64) (bool, error) {
+    query := `
+        SELECT EXISTS(
+            SELECT 1 FROM users u
+            WHERE u.id = $1 AND ` + users.ActiveSQL("u") + `
+              AND ` + blocks.NotBlockedSQL("$2", "u.id") + `
+              AND (COALESCE(u.privacy_settings->>'profile_visibility', 'public') = 'public'
+                   OR EXISTS(SELECT 1 FROM follows f WHERE f.follower_id = $2 AND f.following_id = u.id)))`
+
+    var allowed bool
+    err := r.db.GetContext(ctx, &allowed, query, ownerID, viewerID)
+    return allowed, err
+}
+
+// UpdateHighlightPositions numbers userID's highlights in the order given,
+// and returns how many of them were found
+func (r *postgresRepository) UpdateHighlightPositions(ctx context.Context, userID int64, ids []int64) (int, error) {
+    result, err := database.ConnX(ctx, r.db).ExecContext(ctx, `
+        UPDATE story_highlights h
+        SET position = o.position, updated_at = NOW()
+        FROM unnest($2::bigint[]) WITH ORDINALITY AS o(id, position)
+        WHERE h.id = o.id AND h.user_id = $1`,
+        userID, pq.Array(ids))
+    if err != nil {
+        return 0, err
+    }
+    updated, err := result.RowsAffected()
+    return int(updated), err
+}
+
+// SyncHighlightedStories marks exactly the user's stories that are in a
+// highlight as highlighted, which keeps them from expiring out of the
+// archive
+func (r *postgresRepository) SyncHighlightedStories(ctx context.Context, userID int64) error {
+    _, err := database.ConnX(ctx, r.db).ExecContext(ctx, `
+        UPDATE stories s
+        SET is_highlighted = EXISTS(
+            SELECT 1 FROM story_highlights h
+            WHERE h.user_id = s.user_id AND s.id = ANY(h.story_ids))
+        WHERE s.user_id = $1`, userID)
+    return err
+}
@@ -0,0 +1,280 @@
+// internal/stories/insights.go
+// Story insights for creators: who watched, how far, and what they did next.
+// Views come from story_views; completions, profile taps, forward taps and
+// exits are reported by viewers' apps as interactions.
+
+package stories
+
+import (
+    "context"
+    "errors"
+    "time"
+)
+
+// Interaction types viewers' apps report
+const (
+    InteractionCompleted  = "completed"   // watched to the end
+    InteractionProfileTap = "profile_tap" // opened the author's profile
+    InteractionForward    = "forward"     // skipped to the next story
+    InteractionExit       = "exit"        // left the story viewer
+)
+
+var (
+    ErrInvalidInteraction = errors.New("invalid story interaction")
+    ErrInvalidPeriod      = errors.New("days must be 7 or 30")
+)
+
+// InteractionRequest reports what a viewer did with a story
+type InteractionRequest struct {
+    Type string `json:"type" validate:"required,oneof=completed profile_tap forward exit"`
+}
+
+// InsightPoint is a count in one time bucket
+type InsightPoint struct {
+    Time  time.Time `json:"time" db:"bucket"`
+    Count int       `json:"count" db:"count"`
+}
+
+// StoryInsights are one story's numbers, for its author. CompletionRate is
+// the share of viewers who watched to the end, and is only set when the
+// story has a known duration.
+type StoryInsights struct {
+    StoryID        int64           `json:"story_id"`
+    Views          int             `json:"views" db:"views"`
+    Replies        int             `json:"replies" db:"replies"`
+    Completions    int             `json:"completions" db:"completions"`
+    ProfileTaps    int             `json:"profile_taps" db:"profile_taps"`
+    Forwards       int             `json:"forwards" db:"forwards"`
+    Exits          int             `json:"exits" db:"exits"`
+    CompletionRate *float64        `json:"completion_rate,omitempty"`
+    ViewsOverTime  []*InsightPoint `json:"views_over_time"`
+}
+
+// StoryInsightsSummary aggregates an author's stories posted in the last
+// Days days
+type StoryInsightsSummary struct {
+    Days             int             `json:"days"`
+    Stories          int             `json:"stories" db:"stories"`
+    Views            int             `json:"views" db:"views"`
+    Replies          int             `json:"replies" db:"replies"`
+    Completions      int             `json:"completions" db:"completions"`
+    ProfileTaps      int             `json:"profile_taps" db:"profile_taps"`
+    AvgViewsPerStory float64         `json:"avg_views_per_story"`
+    CompletionRate   *float64        `json:"completion_rate,omitempty"`
+    ViewsByDay       []*InsightPoint `json:"views_by_day"`
+    TopStories       []*TopStory     `json:"top_stories"`
+}
+
+// TopStory is one of an author's most viewed stories
+type TopStory struct {
+    StoryID   int64     `json:"story_id" db:"id"`
+    MediaType string    `json:"media_type" db:"media_type"`
+    Views     int       `json:"views" db:"views"`
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// topStoriesLimit is how many stories the summary ranks
+const topStoriesLimit = 5
+
+// Repository
+
+// RecordInteraction records an interaction once per viewer and type
+func (r *postgresRepository) RecordInteraction(ctx context.Context, storyID, userID int64, interaction string) error {
+    query := `
+        INSERT INTO story_interactions (story_id, user_id, interaction_type)
+        VALUES ($1, $2, $3)
+        ON CONFLICT (story_id, user_id, interaction_type) DO NOTHING`
+
+    _, err := r.db.ExecContext(ctx, query, storyID, userID, interaction)
+    return err
+}
+
+// GetStoryInsights counts a story's views, replies and interactions
+func (r *postgresRepository) GetStoryInsights(ctx context.Context, storyID int64) (*StoryInsights, error) {
+    query := `
+        SELECT
+            (SELECT COUNT(*) FROM story_views WHERE story_id = $1) AS views,
+            (SELECT COUNT(*) FROM story_replies WHERE story_id = $1) AS replies,
+            COUNT(*) FILTER (WHERE interaction_type = 'completed') AS completions,
+            COUNT(*) FILTER (WHERE interaction_type = 'profile_tap') AS profile_taps,
+            COUNT(*) FILTER (WHERE interaction_type = 'forward') AS forwards,
+            COUNT(*) FILTER (WHERE interaction_type = 'exit') AS exits
+        FROM story_interactions
+        WHERE story_id = $1`
+
+    insights := &StoryInsights{StoryID: storyID}
+    if err := r.db.GetContext(ctx, insights, query, storyID); err != nil {
+        return nil, err
+    }
+    return insights, nil
+}
+
+// GetStoryViewsByHour returns a story's views per hour, oldest first
+func (r *postgresRepository) GetStoryViewsByHour(ctx context.Context, storyID int64) ([]*InsightPoint, error) {
+    query := `
+        SELECT date_trunc('hour', viewed_at) AS bucket, COUNT(*) AS count
+        FROM story_views
+        WHERE story_id = $1
+        GROUP BY bucket
+        ORDER BY bucket`
+
+    points := []*InsightPoint{}
+    err := r.db.SelectContext(ctx, &points, query, storyID)
+    return points, err
+}
+
+// GetInsightsSummary totals the stories userID posted since since. Only
+// completions of stories with a known duration count towards the rate, so
+// completionViews is the views of those stories.
+func (r *postgresRepository) GetInsightsSummary(ctx context.Context, userID int64, since time.Time) (*StoryInsightsSummary, int, error) {
+    query := `
+        WITH mine AS (
+            SELECT id, duration FROM stories WHERE user_id = $1 AND created_at >= $2
+        ),
+        views AS (
+            SELECT sv.story_id, COUNT(*) AS n FROM story_views sv
+            JOIN mine ON mine.id = sv.story_id
+            GROUP BY sv.story_id
+        )
+        SELECT
+            (SELECT COUNT(*) FROM mine) AS stories,
+            COALESCE((SELECT SUM(n) FROM views), 0) AS views,
+            (SELECT COUNT(*) FROM story_replies sr JOIN mine ON mine.id = sr.story_id) AS replies,
+            (SELECT COUNT(*) FROM story_interactions si JOIN mine ON mine.id = si.story_id
+             WHERE si.interaction_type = 'completed' AND mine.duration > 0) AS completions,
+            (SELECT COUNT(*) FROM story_interactions si JOIN mine ON mine.id = si.story_id
+             WHERE si.interaction_type = 'profile_tap') AS profile_taps,
+            COALESCE((SELECT SUM(n) FROM views JOIN mine ON mine.id = views.story_id
+             WHERE mine.duration > 0), 0) AS completion_views`
+
+    var row struct {
+        StoryInsightsSummary
+        CompletionViews int `db:"completion_views"`
+    }
+    if err := r.db.GetContext(ctx, &row, query, userID, since); err != nil {
+        return nil, 0, err
+    }
+    summary := row.StoryInsightsSummary
+    return &summary, row.CompletionViews, nil
+}
+
+// GetViewsByDay returns daily views of the stories userID posted since since
+func (r *postgresRepository) GetViewsByDay(ctx context.Context, userID int64, since time.Time) ([]*InsightPoint, error) {
+    query := `
+        SELECT date_trunc('day', sv.viewed_at) AS bucket, COUNT(*) AS count
+        FROM story_views sv
+        JOIN stories s ON s.id = sv.story_id
+        WHERE s.user_id = $1 AND s.created_at >= $2
+        GROUP BY bucket
+        ORDER BY bucket`
+
+    points := []*InsightPoint{}
+    err := r.db.SelectContext(ctx, &points, query, userID, since)
+    return points, err
+}
+
+// GetTopStories returns userID's most viewed stories posted since since
+func (r *postgresRepository) GetTopStories(ctx context.Context, userID int64, since time.Time, limit int) ([]*TopStory, error) {
+    query := `
+        SELECT s.id, s.media_type, s.created_at,
+               (SELECT COUNT(*) FROM story_views sv WHERE sv.story_id = s.id) AS views
+        FROM stories s
+        WHERE s.user_id = $1 AND s.created_at >= $2
+        ORDER BY views DESC, s.created_at DESC
+        LIMIT $3`
+
+    top := []*TopStory{}
+    err := r.db.SelectContext(ctx, &top, query, userID, since, limit)
+    return top, err
+}
+
+// Service
+
+// RecordInteraction records what a viewer did with a story. Authors' own
+// interactions aren't counted.
+func (s *service) RecordInteraction(ctx context.Context, storyID, userID int64, req *InteractionRequest) error {
+    switch req.Type {
+    case InteractionCompleted, InteractionProfileTap, InteractionForward, InteractionExit:
+    default:
+        return ErrInvalidInteraction
+    }
+
+    story, err := s.repo.GetStory(ctx, storyID)
+    if err != nil {
+        return err
+    }
+    if story.UserID == userID {
+        return nil
+    }
+    if err := s.checkAudience(ctx, story, userID); err != nil {
+        return err
+    }
+
+    return s.repo.RecordInteraction(ctx, storyID, userID, req.Type)
+}
+
+// GetStoryInsights returns a story's insights to its author
+func (s *service) GetStoryInsights(ctx context.Context, storyID, userID int64) (*StoryInsights, error) {
+    story, err := s.repo.GetStory(ctx, storyID)
+    if err != nil {
+        return nil, err
+    }
+    if story.UserID != userID {
+        return nil, ErrUnauthorized
+    }
+
+    insights, err := s.repo.GetStoryInsights(ctx, storyID)
+    if err != nil {
+        return nil, err
+    }
+    insights.ViewsOverTime, err = s.repo.GetStoryViewsByHour(ctx, storyID)
+    if err != nil {
+        return nil, err
+    }
+
+    if story.Duration > 0 {
+        insights.CompletionRate = rate(insights.Completions, insights.Views)
+    }
+    return insights, nil
+}
+
+// GetInsightsSummary aggregates the author's stories of the last 7 or 30
+// days
+func (s *service) GetInsightsSummary(ctx context.Context, userID int64, days int) (*StoryInsightsSummary, error) {
+    if days != 7 && days != 30 {
+        return nil, ErrInvalidPeriod
+    }
+    since := time.Now().AddDate(0, 0, -days)
+
+    summary, completionViews, err := s.repo.GetInsightsSummary(ctx, userID, since)
+    if err != nil {
+        return nil, err
+    }
+    summary.Days = days
+    if summary.Stories > 0 {
+        summary.AvgViewsPerStory = float64(summary.Views) / float64(summary.Stories)
+    }
+    summary.CompletionRate = rate(summary.Completions, completionViews)
+
+    summary.ViewsByDay, err = s.repo.GetViewsByDay(ctx, userID, since)
+    if err != nil {
+        return nil, err
+    }
+    summary.TopStories, err = s.repo.GetTopStories(ctx, userID, since, topStoriesLimit)
+    if err != nil {
+        return nil, err
+    }
+    return summary, nil
+}
+
+// rate is part/whole, or nil when there is nothing to divide by
+func rate(part, whole int) *float64 {
+    if whole == 0 {
+        return nil
+    }
+    r := float64(part) / float64(whole)
+    if r > 1 {
+        r = 1
+    }
+    return &r
+}
@@ -3,8 +3,12 @@
 package stories
 
 import (
+    "encoding/json"
     "time"
+    
     "github.com/lib/pq"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/common/media"
 )
 
 // Story represents a user story
@@ -18,7 +22,20 @@ type Story struct {
     Duration       int        `json:"duration" db:"duration"` // seconds
     IsHighlighted  bool       `json:"is_highlighted" db:"is_highlighted"`
     HighlightTitle *string    `json:"highlight_title,omitempty" db:"highlight_title"`
+    Elements       StoryElements `json:"elements,omitempty" db:"elements"`
+    SharedPostID   *int64     `json:"shared_post_id,omitempty" db:"shared_post_id"`
+    Audience       string     `json:"audience" db:"audience"` // public, followers or close_friends
+    
+    // Video processing. PlaybackURL and HLSURL are the transcoded renditions
+    // of MediaURL; stories that aren't ready are only shown to their author.
+    ProcessingStatus   string  `json:"processing_status" db:"processing_status"` // pending, processing, ready or failed
+    ProcessingError    *string `json:"processing_error,omitempty" db:"processing_error"`
+    ProcessingAttempts int     `json:"-" db:"processing_attempts"`
+    PlaybackURL        *string `json:"playback_url,omitempty" db:"playback_url"`
+    HLSURL             *string `json:"hls_url,omitempty" db:"hls_url"`
+    
     ExpiresAt      time.Time  `json:"expires_at" db:"expires_at"`
+    ArchivedAt     *time.Time `json:"archived_at,omitempty" db:"archived_at"` // only loaded for the author's archive
     CreatedAt      time.Time  `json:"created_at" db:"created_at"`
     UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
     
@@ -29,6 +46,27 @@ type Story struct {
     User           *StoryUser `json:"user,omitempty"`
 }
 
+// MarshalJSON signs the media of close-friends stories so links can't be
+// passed on to people outside the list
+func (s Story) MarshalJSON() ([]byte, error) {
+    type story Story
+    out := story(s)
+    if s.Audience == AudienceCloseFriends {
+        out.MediaURL = media.SignURL(s.MediaURL)
+        out.ThumbnailURL = media.SignURLPtr(s.ThumbnailURL)
+        out.PlaybackURL = media.SignURLPtr(s.PlaybackURL)
+        out.HLSURL = media.SignURLPtr(s.HLSURL)
+    }
+    return json.Marshal(out)
+}
+
+// Story audiences
+const (
+    AudiencePublic       = "public"
+    AudienceFollowers    = "followers"
+    AudienceCloseFriends = "close_friends"
+)
+
 // StoryUser represents user info in story response
 type StoryUser struct {
     ID             int64   `json:"id"`
@@ -57,16 +95,20 @@ type StoryReply struct {
     User      *StoryUser `json:"user,omitempty"`
 }
 
-// StoryHighlight represents a collection of highlighted stories
+// StoryHighlight represents a collection of highlighted stories.
+// CoverUploaded is set when the cover was uploaded for the highlight, so
+// the file is deleted when the cover is replaced.
 type StoryHighlight struct {
-    ID         int64          `json:"id" db:"id"`
-    UserID     int64          `json:"user_id" db:"user_id"`
-    Title      string         `json:"title" db:"title"`
-    CoverImage *string        `json:"cover_image" db:"cover_image"`
-    StoryIDs   pq.Int64Array  `json:"story_ids" db:"story_ids"`
-    Stories    []*Story       `json:"stories,omitempty"`
-    CreatedAt  time.Time      `json:"created_at" db:"created_at"`
-    UpdatedAt  time.Time      `json:"updated_at" db:"updated_at"`
+    ID            int64          `json:"id" db:"id"`
+    UserID        int64          `json:"user_id" db:"user_id"`
+    Title         string         `json:"title" db:"title"`
+    CoverImage    *string        `json:"cover_image" db:"cover_image"`
+    CoverUploaded bool           `json:"-" db:"cover_uploaded"`
+    StoryIDs      pq.Int64Array  `json:"story_ids" db:"story_ids"`
+    Position      int            `json:"position" db:"position"`
+    Stories       []*Story       `json:"stories,omitempty"`
+    CreatedAt     time.Time      `json:"created_at" db:"created_at"`
+    UpdatedAt     time.Time      `json:"updated_at" db:"updated_at"`
 }
 
 // CreateStoryRequest represents request to create a story
@@ -76,19 +118,63 @@ type CreateStoryRequest struct {
     ThumbnailURL string  `json:"thumbnail_url,omitempty" validate:"omitempty,url"`
     Caption      string  `json:"caption,omitempty" validate:"omitempty,max=500"`
     Duration     int     `json:"duration,omitempty" validate:"omitempty,min=1,max=60"`
+    Elements     StoryElements `json:"elements,omitempty"`
+    Audience     string  `json:"audience,omitempty" validate:"omitempty,oneof=public followers close_friends"`
+}
+
+// SharePostRequest represents request to reshare a post into a story
+type SharePostRequest struct {
+    PostID   int64         `json:"post_id" validate:"required"`
+    Caption  string        `json:"caption,omitempty" validate:"omitempty,max=500"`
+    Elements StoryElements `json:"elements,omitempty"`
+    Audience string        `json:"audience,omitempty" validate:"omitempty,oneof=public followers close_friends"`
+}
+
+// SharedPost is the subset of a post needed to reshare it into a story
+type SharedPost struct {
+    ID         int64   `db:"id"`
+    UserID     int64   `db:"user_id"`
+    Visibility string  `db:"visibility"`
+    MediaURL   *string `db:"media_url"`
+    MediaType  *string `db:"media_type"`
 }
 
 // StoryReplyRequest represents request to reply to a story
 type StoryReplyRequest struct {
-    Message  string `json:"message,omitempty" validate:"omitempty,max=500"`
+    Message  string `json:"message,omitempty" validate:"required_without=Reaction,max=500"`
     Reaction string `json:"reaction,omitempty" validate:"omitempty,max=50"`
 }
 
 // CreateHighlightRequest represents request to create a highlight
 type CreateHighlightRequest struct {
     Title      string  `json:"title" validate:"required,min=1,max=100"`
-    StoryIDs   []int64 `json:"story_ids" validate:"required,min=1"`
-    CoverImage string  `json:"cover_image,omitempty"`
+    StoryIDs   []int64 `json:"story_ids" validate:"required,min=1,max=100,dive,gt=0"`
+    CoverImage string  `json:"cover_image,omitempty" validate:"omitempty,url"`
+}
+
+// CloseFriend represents a user on someone's close friends list
+type CloseFriend struct {
+    UserID    int64      `json:"user_id" db:"user_id"`
+    FriendID  int64      `json:"friend_id" db:"friend_id"`
+    CreatedAt time.Time  `json:"created_at" db:"created_at"`
+    Friend    *StoryUser `json:"friend,omitempty"`
+}
+
+// StoryGroup is one author's active stories in the story tray, oldest first
+type StoryGroup struct {
+    User       *StoryUser `json:"user"`
+    Stories    []*Story   `json:"stories"`
+    TotalCount int        `json:"total_count"`
+    SeenCount  int        `json:"seen_count"`
+    HasUnseen  bool       `json:"has_unseen"`
+    LatestAt   time.Time  `json:"latest_at"`
+}
+
+// StoryFeedResponse represents the paginated story tray, one group per author
+type StoryFeedResponse struct {
+    Groups     []*StoryGroup `json:"groups"`
+    TotalCount int           `json:"total_count"`
+    HasMore    bool          `json:"has_more"`
 }
 
 // StoriesResponse represents paginated stories response
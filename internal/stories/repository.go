@@ -7,15 +7,21 @@ import (
     
     "github.com/jmoiron/sqlx"
     "github.com/lib/pq"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/blocks"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/users"
 )
 
 type Repository interface {
     // Story CRUD
     CreateStory(ctx context.Context, story *Story) error
     GetStory(ctx context.Context, storyID int64) (*Story, error)
-    GetUserStories(ctx context.Context, userID int64, includeExpired bool) ([]*Story, error)
-    GetActiveStories(ctx context.Context, excludeUserID int64, limit int, offset int) ([]*Story, error)
-    GetActiveStoriesCount(ctx context.Context, excludeUserID int64) (int, error)
+    GetUserStories(ctx context.Context, userID int64, viewerID int64, includeExpired bool) ([]*Story, error)
+    GetActiveStories(ctx context.Context, viewerID int64, limit int, offset int) ([]*Story, error)
+    GetActiveStoriesCount(ctx context.Context, viewerID int64) (int, error)
+    GetStoryGroups(ctx context.Context, viewerID int64, limit int, offset int) ([]*StoryGroup, error)
+    CanViewStory(ctx context.Context, story *Story, viewerID int64) (bool, error)
     DeleteStory(ctx context.Context, storyID int64) error
     GetStoryWithUser(ctx context.Context, storyID int64, viewerID int64) (*Story, error)
     
@@ -29,21 +35,70 @@ type Repository interface {
     MarkReplyAsRead(ctx context.Context, replyID int64) error
     GetReply(ctx context.Context, replyID int64) (*StoryReply, error)
     
+    // Insights
+    RecordInteraction(ctx context.Context, storyID, userID int64, interaction string) error
+    GetStoryInsights(ctx context.Context, storyID int64) (*StoryInsights, error)
+    GetStoryViewsByHour(ctx context.Context, storyID int64) ([]*InsightPoint, error)
+    GetInsightsSummary(ctx context.Context, userID int64, since time.Time) (*StoryInsightsSummary, int, error)
+    GetViewsByDay(ctx context.Context, userID int64, since time.Time) ([]*InsightPoint, error)
+    GetTopStories(ctx context.Context, userID int64, since time.Time, limit int) ([]*TopStory, error)
+    
     // Highlights
     CreateHighlight(ctx context.Context, highlight *StoryHighlight) error
     GetUserHighlights(ctx context.Context, userID int64) ([]*StoryHighlight, error)
     GetHighlight(ctx context.Context, highlightID int64) (*StoryHighlight, error)
     DeleteHighlight(ctx context.Context, highlightID int64) error
     UpdateHighlight(ctx context.Context, highlight *StoryHighlight) error
+    UpdateHighlightPositions(ctx context.Context, userID int64, ids []int64) (int, error)
+    SyncHighlightedStories(ctx context.Context, userID int64) error
+    CountOwnedStories(ctx context.Context, userID int64, ids []int64) (int, error)
+    GetHighlightStories(ctx context.Context, ids []int64, viewerID int64) ([]*Story, error)
+    CanViewHighlights(ctx context.Context, ownerID, viewerID int64) (bool, error)
+    
+    // Archive
+    GetStoryArchive(ctx context.Context, userID int64, limit, offset int) ([]*Story, error)
+    GetArchiveSettings(ctx context.Context, userID int64) (*ArchiveSettings, error)
+    SaveArchiveSettings(ctx context.Context, userID int64, settings *ArchiveSettings) error
+    GetStoriesToArchive(ctx context.Context, limit int) ([]*archivedStory, error)
+    MarkStoryArchived(ctx context.Context, story *archivedStory) error
+    
+    // Video processing
+    ClaimPendingVideos(ctx context.Context, limit int) ([]*Story, error)
+    FinishVideoProcessing(ctx context.Context, story *Story) error
     
     // Cleanup
-    DeleteExpiredStories(ctx context.Context, before time.Time) error
-    GetExpiredStoryMedia(ctx context.Context, before time.Time) ([]string, error)
+    PurgeExpiredStories(ctx context.Context, defaultRetention time.Duration) ([]string, error)
     
     // User info
     GetStoryUser(ctx context.Context, userID int64) (*StoryUser, error)
+    GetSharedPost(ctx context.Context, postID int64) (*SharedPost, error)
+    
+    // Close friends
+    AddCloseFriend(ctx context.Context, userID, friendID int64) error
+    RemoveCloseFriend(ctx context.Context, userID, friendID int64) error
+    GetCloseFriends(ctx context.Context, userID int64) ([]*CloseFriend, error)
 }
 
+// audienceFilter restricts stories (aliased s) to those the viewer ($1) may see.
+// Stories of anyone with a block in either direction are never shown, and
+// videos still processing are only shown to their author.
+var audienceFilter = `
+    ((s.user_id = $1
+     OR s.audience = 'public'
+     OR (s.audience = 'followers' AND EXISTS(
+         SELECT 1 FROM follows f WHERE f.follower_id = $1 AND f.following_id = s.user_id))
+     OR (s.audience = 'close_friends' AND EXISTS(
+         SELECT 1 FROM close_friends cf WHERE cf.user_id = s.user_id AND cf.friend_id = $1)))
+     AND (s.user_id = $1 OR s.processing_status = 'ready')
+     AND ` + blocks.NotBlockedSQL("$1", "s.user_id") + `)`
+
+// activeAuthorFilter hides stories of deleted or suspended users
+var activeAuthorFilter = `
+    EXISTS(SELECT 1 FROM users au WHERE au.id = s.user_id AND ` + users.ActiveSQL("au") + `)`
+
+// ghostUserColumns selects a joined user "u" as a StoryUser, ghosted if inactive
+var ghostUserColumns = users.UsernameSQL("u") + `, ` + users.DisplayNameSQL("u") + `, ` + users.ProfilePictureSQL("u")
+
 type postgresRepository struct {
     db *sqlx.DB
 }
@@ -56,14 +111,16 @@ func NewPostgresRepository(db *sqlx.DB) Repository {
 func (r *postgresRepository) CreateStory(ctx context.Context, story *Story) error {
     query := `
         INSERT INTO stories (user_id, media_url, media_type, thumbnail_url, caption, 
-                           duration, is_highlighted, highlight_title, expires_at)
-        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+                           duration, is_highlighted, highlight_title, elements,
+                           shared_post_id, audience, expires_at, processing_status)
+        VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
         RETURNING id, created_at, updated_at`
     
     err := r.db.QueryRowContext(ctx, query,
         story.UserID, story.MediaURL, story.MediaType, story.ThumbnailURL,
         story.Caption, story.Duration, story.IsHighlighted, story.HighlightTitle,
-        story.ExpiresAt,
+        story.Elements, story.SharedPostID, story.Audience, story.ExpiresAt,
+        story.ProcessingStatus,
     ).Scan(&story.ID, &story.CreatedAt, &story.UpdatedAt)
     
     return err
@@ -74,7 +131,9 @@ func (r *postgresRepository) GetStory(ctx context.Context, storyID int64) (*Stor
     var story Story
     query := `
         SELECT id, user_id, media_url, media_type, thumbnail_url, caption,
-               duration, is_highlighted, highlight_title, expires_at, created_at, updated_at
+               duration, is_highlighted, highlight_title, elements, shared_post_id,
+               audience, expires_at, created_at, updated_at,
+               processing_status, processing_error, playback_url, hls_url
         FROM stories
         WHERE id = $1`
     
@@ -94,15 +153,15 @@ func (r *postgresRepository) GetStoryWithUser(ctx context.Context, storyID int64
         return nil, err
     }
     
-    // Get user info
+    // Get user info; a ghost's stories are hidden with them
     user, err := r.GetStoryUser(ctx, story.UserID)
     if err == nil {
+        if user.Username == users.GhostUsername {
+            return nil, ErrStoryNotFound
+        }
         story.User = user
     }
     
-    // Get view count
-    story.ViewCount, _ = r.GetStoryViewCount(ctx, storyID)
-    
     // Check if viewer has viewed
     if viewerID > 0 {
         story.HasViewed, _ = r.HasViewed(ctx, storyID, viewerID)
@@ -111,24 +170,24 @@ func (r *postgresRepository) GetStoryWithUser(ctx context.Context, storyID int64
     return story, nil
 }
 
-// GetUserStories retrieves all stories for a user
-func (r *postgresRepository) GetUserStories(ctx context.Context, userID int64, includeExpired bool) ([]*Story, error) {
+// GetUserStories retrieves a user's stories that the viewer is allowed to see
+func (r *postgresRepository) GetUserStories(ctx context.Context, userID int64, viewerID int64, includeExpired bool) ([]*Story, error) {
     query := `
-        SELECT s.*, 
-               COUNT(DISTINCT sv.viewer_id) as view_count
+        SELECT s.id, s.user_id, s.media_url, s.media_type, s.thumbnail_url, s.caption,
+               s.duration, s.is_highlighted, s.highlight_title, s.elements,
+               s.shared_post_id, s.audience, s.expires_at, s.created_at, s.updated_at,
+               s.processing_status, s.processing_error, s.playback_url, s.hls_url
         FROM stories s
-        LEFT JOIN story_views sv ON s.id = sv.story_id
-        WHERE s.user_id = $1`
+        WHERE s.user_id = $2 AND` + audienceFilter + ` AND` + activeAuthorFilter
     
     if !includeExpired {
         query += " AND s.expires_at > NOW()"
     }
     
     query += `
-        GROUP BY s.id
         ORDER BY s.created_at DESC`
     
-    rows, err := r.db.QueryContext(ctx, query, userID)
+    rows, err := r.db.QueryContext(ctx, query, viewerID, userID)
     if err != nil {
         return nil, err
     }
@@ -140,8 +199,9 @@ func (r *postgresRepository) GetUserStories(ctx context.Context, userID int64, i
         err := rows.Scan(
             &story.ID, &story.UserID, &story.MediaURL, &story.MediaType,
             &story.ThumbnailURL, &story.Caption, &story.Duration,
-            &story.IsHighlighted, &story.HighlightTitle, &story.ExpiresAt,
-            &story.CreatedAt, &story.UpdatedAt, &story.ViewCount,
+            &story.IsHighlighted, &story.HighlightTitle, &story.Elements,
+            &story.SharedPostID, &story.Audience, &story.ExpiresAt, &story.CreatedAt, &story.UpdatedAt,
+            &story.ProcessingStatus, &story.ProcessingError, &story.PlaybackURL, &story.HLSURL,
         )
         if err != nil {
             return nil, err
@@ -153,22 +213,23 @@ func (r *postgresRepository) GetUserStories(ctx context.Context, userID int64, i
     return stories, nil
 }
 
-// GetActiveStories retrieves active stories from followed users
-func (r *postgresRepository) GetActiveStories(ctx context.Context, excludeUserID int64, limit int, offset int) ([]*Story, error) {
+// GetActiveStories retrieves active stories of other users visible to the viewer
+func (r *postgresRepository) GetActiveStories(ctx context.Context, viewerID int64, limit int, offset int) ([]*Story, error) {
     query := `
         SELECT DISTINCT ON (s.user_id) 
-               s.*, u.username, u.display_name, u.profile_picture,
-               COUNT(DISTINCT sv.viewer_id) as view_count,
+               s.id, s.user_id, s.media_url, s.media_type, s.thumbnail_url, s.caption,
+               s.duration, s.is_highlighted, s.highlight_title, s.elements,
+               s.shared_post_id, s.audience, s.expires_at, s.created_at, s.updated_at,
+               s.processing_status, s.processing_error, s.playback_url, s.hls_url,
+               u.username, u.display_name, u.profile_picture,
                EXISTS(SELECT 1 FROM story_views WHERE story_id = s.id AND viewer_id = $1) as has_viewed
         FROM stories s
         INNER JOIN users u ON s.user_id = u.id
-        LEFT JOIN story_views sv ON s.id = sv.story_id
-        WHERE s.expires_at > NOW() AND s.user_id != $1
-        GROUP BY s.id, u.id
+        WHERE s.expires_at > NOW() AND s.user_id != $1 AND` + audienceFilter + ` AND ` + users.ActiveSQL("u") + `
         ORDER BY s.user_id, s.created_at DESC
         LIMIT $2 OFFSET $3`
     
-    rows, err := r.db.QueryContext(ctx, query, excludeUserID, limit, offset)
+    rows, err := r.db.QueryContext(ctx, query, viewerID, limit, offset)
     if err != nil {
         return nil, err
     }
@@ -181,10 +242,11 @@ func (r *postgresRepository) GetActiveStories(ctx context.Context, excludeUserID
         err := rows.Scan(
             &story.ID, &story.UserID, &story.MediaURL, &story.MediaType,
             &story.ThumbnailURL, &story.Caption, &story.Duration,
-            &story.IsHighlighted, &story.HighlightTitle, &story.ExpiresAt,
-            &story.CreatedAt, &story.UpdatedAt,
+            &story.IsHighlighted, &story.HighlightTitle, &story.Elements,
+            &story.SharedPostID, &story.Audience, &story.ExpiresAt, &story.CreatedAt, &story.UpdatedAt,
+            &story.ProcessingStatus, &story.ProcessingError, &story.PlaybackURL, &story.HLSURL,
             &user.Username, &user.DisplayName, &user.ProfilePicture,
-            &story.ViewCount, &story.HasViewed,
+            &story.HasViewed,
         )
         if err != nil {
             return nil, err
@@ -198,18 +260,106 @@ func (r *postgresRepository) GetActiveStories(ctx context.Context, excludeUserID
     return stories, nil
 }
 
+// GetStoryGroups returns all active stories of other users visible to the viewer,
+// grouped per author. Authors with unseen stories come first, then by most recent story.
+// Pagination applies to authors, not stories.
+func (r *postgresRepository) GetStoryGroups(ctx context.Context, viewerID int64, limit int, offset int) ([]*StoryGroup, error) {
+    query := `
+        WITH visible AS (
+            SELECT s.id, s.user_id, s.media_url, s.media_type, s.thumbnail_url, s.caption,
+                   s.duration, s.is_highlighted, s.highlight_title, s.elements,
+                   s.shared_post_id, s.audience, s.expires_at, s.created_at, s.updated_at,
+                   s.processing_status, s.processing_error, s.playback_url, s.hls_url,
+                   EXISTS(SELECT 1 FROM story_views WHERE story_id = s.id AND viewer_id = $1) AS has_viewed
+            FROM stories s
+            WHERE s.expires_at > NOW() AND s.user_id != $1 AND` + audienceFilter + ` AND` + activeAuthorFilter + `
+        ),
+        authors AS (
+            SELECT user_id,
+                   COUNT(*) AS total_count,
+                   COUNT(*) FILTER (WHERE has_viewed) AS seen_count,
+                   MAX(created_at) AS latest_at
+            FROM visible
+            GROUP BY user_id
+            ORDER BY COUNT(*) > COUNT(*) FILTER (WHERE has_viewed) DESC, MAX(created_at) DESC, user_id
+            LIMIT $2 OFFSET $3
+        )
+        SELECT v.id, v.user_id, v.media_url, v.media_type, v.thumbnail_url, v.caption,
+               v.duration, v.is_highlighted, v.highlight_title, v.elements,
+               v.shared_post_id, v.audience, v.expires_at, v.created_at, v.updated_at,
+               v.processing_status, v.processing_error, v.playback_url, v.hls_url,
+               v.has_viewed, u.username, u.display_name, u.profile_picture,
+               a.total_count, a.seen_count, a.latest_at
+        FROM visible v
+        INNER JOIN authors a ON a.user_id = v.user_id
+        INNER JOIN users u ON u.id = v.user_id
+        ORDER BY a.total_count > a.seen_count DESC, a.latest_at DESC, a.user_id, v.created_at ASC`
+    
+    rows, err := r.db.QueryContext(ctx, query, viewerID, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    var groups []*StoryGroup
+    var current *StoryGroup
+    for rows.Next() {
+        var story Story
+        var user StoryUser
+        var group StoryGroup
+        err := rows.Scan(
+            &story.ID, &story.UserID, &story.MediaURL, &story.MediaType,
+            &story.ThumbnailURL, &story.Caption, &story.Duration,
+            &story.IsHighlighted, &story.HighlightTitle, &story.Elements,
+            &story.SharedPostID, &story.Audience, &story.ExpiresAt, &story.CreatedAt, &story.UpdatedAt,
+            &story.ProcessingStatus, &story.ProcessingError, &story.PlaybackURL, &story.HLSURL,
+            &story.HasViewed, &user.Username, &user.DisplayName, &user.ProfilePicture,
+            &group.TotalCount, &group.SeenCount, &group.LatestAt,
+        )
+        if err != nil {
+            return nil, err
+        }
+        
+        if current == nil || current.User.ID != story.UserID {
+            user.ID = story.UserID
+            group.User = &user
+            group.HasUnseen = group.SeenCount < group.TotalCount
+            current = &group
+            groups = append(groups, current)
+        }
+        
+        story.User = current.User
+        current.Stories = append(current.Stories, &story)
+    }
+    
+    return groups, rows.Err()
+}
+
 // GetActiveStoriesCount gets total count of active stories
-func (r *postgresRepository) GetActiveStoriesCount(ctx context.Context, excludeUserID int64) (int, error) {
+func (r *postgresRepository) GetActiveStoriesCount(ctx context.Context, viewerID int64) (int, error) {
     var count int
     query := `
-        SELECT COUNT(DISTINCT user_id) 
-        FROM stories 
-        WHERE expires_at > NOW() AND user_id != $1`
+        SELECT COUNT(DISTINCT s.user_id) 
+        FROM stories s
+        WHERE s.expires_at > NOW() AND s.user_id != $1 AND` + audienceFilter + ` AND` + activeAuthorFilter
     
-    err := r.db.GetContext(ctx, &count, query, excludeUserID)
+    err := r.db.GetContext(ctx, &count, query, viewerID)
     return count, err
 }
 
+// CanViewStory checks whether the viewer is in the story's audience
+func (r *postgresRepository) CanViewStory(ctx context.Context, story *Story, viewerID int64) (bool, error) {
+    if story.UserID == viewerID {
+        return true, nil
+    }
+    
+    query := `SELECT EXISTS(SELECT 1 FROM stories s WHERE s.id = $2 AND` + audienceFilter + `)`
+    
+    var allowed bool
+    err := r.db.GetContext(ctx, &allowed, query, viewerID, story.ID)
+    return allowed, err
+}
+
 // DeleteStory deletes a story
 func (r *postgresRepository) DeleteStory(ctx context.Context, storyID int64) error {
     _, err := r.db.ExecContext(ctx, "DELETE FROM stories WHERE id = $1", storyID)
@@ -246,10 +396,10 @@ func (r *postgresRepository) GetStoryViewCount(ctx context.Context, storyID int6
 // GetStoryViews retrieves all views for a story
 func (r *postgresRepository) GetStoryViews(ctx context.Context, storyID int64) ([]*StoryView, error) {
     query := `
-        SELECT sv.*, u.username, u.display_name, u.profile_picture
+        SELECT sv.*, u.username, COALESCE(u.display_name, u.username), u.profile_picture
         FROM story_views sv
         INNER JOIN users u ON sv.viewer_id = u.id
-        WHERE sv.story_id = $1
+        WHERE sv.story_id = $1 AND ` + users.ActiveSQL("u") + `
         ORDER BY sv.viewed_at DESC`
     
     rows, err := r.db.QueryContext(ctx, query, storyID)
@@ -284,7 +434,7 @@ func (r *postgresRepository) CreateReply(ctx context.Context, reply *StoryReply)
         VALUES ($1, $2, $3, $4)
         RETURNING id, created_at`
     
-    err := r.db.QueryRowContext(ctx, query,
+    err := database.ConnX(ctx, r.db).QueryRowxContext(ctx, query,
         reply.StoryID, reply.UserID, reply.Message, reply.Reaction,
     ).Scan(&reply.ID, &reply.CreatedAt)
     
@@ -294,9 +444,9 @@ func (r *postgresRepository) CreateReply(ctx context.Context, reply *StoryReply)
 // GetStoryReplies retrieves all replies for a story
 func (r *postgresRepository) GetStoryReplies(ctx context.Context, storyID int64) ([]*StoryReply, error) {
     query := `
-        SELECT sr.*, u.username, u.display_name, u.profile_picture
+        SELECT sr.*, ` + ghostUserColumns + `
         FROM story_replies sr
-        INNER JOIN users u ON sr.user_id = u.id
+        LEFT JOIN users u ON sr.user_id = u.id
         WHERE sr.story_id = $1
         ORDER BY sr.created_at DESC`
     
@@ -348,7 +498,7 @@ func (r *postgresRepository) CreateHighlight(ctx context.Context, highlight *Sto
         VALUES ($1, $2, $3, $4)
         RETURNING id, created_at, updated_at`
     
-    err := r.db.QueryRowContext(ctx, query,
+    err := database.ConnX(ctx, r.db).QueryRowxContext(ctx, query,
         highlight.UserID, highlight.Title, highlight.CoverImage,
         pq.Array(highlight.StoryIDs),
     ).Scan(&highlight.ID, &highlight.CreatedAt, &highlight.UpdatedAt)
@@ -361,7 +511,7 @@ func (r *postgresRepository) GetUserHighlights(ctx context.Context, userID int64
     query := `
         SELECT * FROM story_highlights
         WHERE user_id = $1
-        ORDER BY created_at DESC`
+        ORDER BY position, created_at DESC`
     
     var highlights []*StoryHighlight
     err := r.db.SelectContext(ctx, &highlights, query, userID)
@@ -373,6 +523,9 @@ func (r *postgresRepository) GetHighlight(ctx context.Context, highlightID int64
     var highlight StoryHighlight
     query := `SELECT * FROM story_highlights WHERE id = $1`
     err := r.db.GetContext(ctx, &highlight, query, highlightID)
+    if err == sql.ErrNoRows {
+        return nil, ErrHighlightNotFound
+    }
     return &highlight, err
 }
 
@@ -380,11 +533,11 @@ func (r *postgresRepository) GetHighlight(ctx context.Context, highlightID int64
 func (r *postgresRepository) UpdateHighlight(ctx context.Context, highlight *StoryHighlight) error {
     query := `
         UPDATE story_highlights 
-        SET title = $2, cover_image = $3, story_ids = $4, updated_at = NOW()
+        SET title = $2, cover_image = $3, cover_uploaded = $4, story_ids = $5, updated_at = NOW()
         WHERE id = $1`
     
-    _, err := r.db.ExecContext(ctx, query,
-        highlight.ID, highlight.Title, highlight.CoverImage,
+    _, err := database.ConnX(ctx, r.db).ExecContext(ctx, query,
+        highlight.ID, highlight.Title, highlight.CoverImage, highlight.CoverUploaded,
         pq.Array(highlight.StoryIDs),
     )
     return err
@@ -392,35 +545,96 @@ func (r *postgresRepository) UpdateHighlight(ctx context.Context, highlight *Sto
 
 // DeleteHighlight deletes a highlight
 func (r *postgresRepository) DeleteHighlight(ctx context.Context, highlightID int64) error {
-    _, err := r.db.ExecContext(ctx, "DELETE FROM story_highlights WHERE id = $1", highlightID)
+    _, err := database.ConnX(ctx, r.db).ExecContext(ctx, "DELETE FROM story_highlights WHERE id = $1", highlightID)
     return err
 }
 
-// DeleteExpiredStories deletes expired stories
-func (r *postgresRepository) DeleteExpiredStories(ctx context.Context, before time.Time) error {
-    _, err := r.db.ExecContext(ctx,
-        "DELETE FROM stories WHERE expires_at < $1 AND is_highlighted = false", before)
-    return err
+// GetStoryUser retrieves user info for stories
+func (r *postgresRepository) GetStoryUser(ctx context.Context, userID int64) (*StoryUser, error) {
+    // Deleted or suspended users resolve to a ghost instead of an error
+    user := StoryUser{ID: userID}
+    query := `
+        SELECT ` + ghostUserColumns + `
+        FROM (SELECT $1::BIGINT AS id) req
+        LEFT JOIN users u ON u.id = req.id`
+    
+    err := r.db.QueryRowContext(ctx, query, userID).Scan(&user.Username, &user.DisplayName, &user.ProfilePicture)
+    return &user, err
+}
+
+// GetSharedPost retrieves a post and its first media item for resharing
+func (r *postgresRepository) GetSharedPost(ctx context.Context, postID int64) (*SharedPost, error) {
+    var post SharedPost
+    query := `
+        SELECT p.id, p.user_id, p.visibility, pm.media_url, pm.media_type
+        FROM posts p
+        LEFT JOIN LATERAL (
+            SELECT media_url, media_type FROM post_media
+            WHERE post_id = p.id
+            ORDER BY position
+            LIMIT 1
+        ) pm ON TRUE
+        WHERE p.id = $1 AND p.deleted_at IS NULL AND p.status = 'published'
+          AND EXISTS(SELECT 1 FROM users au WHERE au.id = p.user_id AND ` + users.ActiveSQL("au") + `)`
+    
+    err := r.db.GetContext(ctx, &post, query, postID)
+    if err == sql.ErrNoRows {
+        return nil, ErrPostNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &post, nil
 }
 
-// GetExpiredStoryMedia retrieves media URLs of expired stories for cleanup
-func (r *postgresRepository) GetExpiredStoryMedia(ctx context.Context, before time.Time) ([]string, error) {
+// AddCloseFriend adds a user to the close friends list
+func (r *postgresRepository) AddCloseFriend(ctx context.Context, userID, friendID int64) error {
     query := `
-        SELECT media_url FROM stories 
-        WHERE expires_at < $1 AND is_highlighted = false`
+        INSERT INTO close_friends (user_id, friend_id)
+        VALUES ($1, $2)
+        ON CONFLICT (user_id, friend_id) DO NOTHING`
     
-    var urls []string
-    err := r.db.SelectContext(ctx, &urls, query, before)
-    return urls, err
+    _, err := r.db.ExecContext(ctx, query, userID, friendID)
+    return err
 }
 
-// GetStoryUser retrieves user info for stories
-func (r *postgresRepository) GetStoryUser(ctx context.Context, userID int64) (*StoryUser, error) {
-    var user StoryUser
+// RemoveCloseFriend removes a user from the close friends list
+func (r *postgresRepository) RemoveCloseFriend(ctx context.Context, userID, friendID int64) error {
+    _, err := r.db.ExecContext(ctx,
+        "DELETE FROM close_friends WHERE user_id = $1 AND friend_id = $2", userID, friendID)
+    return err
+}
+
+// GetCloseFriends retrieves a user's close friends list
+func (r *postgresRepository) GetCloseFriends(ctx context.Context, userID int64) ([]*CloseFriend, error) {
     query := `
-        SELECT id, username, display_name, profile_picture
-        FROM users WHERE id = $1`
+        SELECT cf.user_id, cf.friend_id, cf.created_at,
+               u.username, COALESCE(u.display_name, u.username), u.profile_picture
+        FROM close_friends cf
+        INNER JOIN users u ON cf.friend_id = u.id
+        WHERE cf.user_id = $1 AND ` + users.ActiveSQL("u") + `
+        ORDER BY u.username`
     
-    err := r.db.GetContext(ctx, &user, query, userID)
-    return &user, err
+    rows, err := r.db.QueryContext(ctx, query, userID)
+    if err != nil {
+        return nil, err
+    }
+    defer rows.Close()
+    
+    friends := []*CloseFriend{}
+    for rows.Next() {
+        var cf CloseFriend
+        var user StoryUser
+        if err := rows.Scan(
+            &cf.UserID, &cf.FriendID, &cf.CreatedAt,
+            &user.Username, &user.DisplayName, &user.ProfilePicture,
+        ); err != nil {
+            return nil, err
+        }
+        user.ID = cf.FriendID
+        cf.Friend = &user
+        friends = append(friends, &cf)
+    }
+    
+    return friends, rows.Err()
 }
\ No newline at end of file
@@ -13,6 +13,19 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.M
     // Story management
     api.HandleFunc("", handler.CreateStory).Methods("POST")
     api.HandleFunc("", handler.GetActiveStories).Methods("GET")
+    api.HandleFunc("/feed", handler.GetStoryFeed).Methods("GET")
+    api.HandleFunc("/share", handler.SharePostToStory).Methods("POST")
+    
+    // Close friends, insights and the archive (registered before /{id} so the
+    // paths aren't taken as a story ID)
+    api.HandleFunc("/close-friends", handler.GetCloseFriends).Methods("GET")
+    api.HandleFunc("/close-friends/{userId}", handler.AddCloseFriend).Methods("POST")
+    api.HandleFunc("/close-friends/{userId}", handler.RemoveCloseFriend).Methods("DELETE")
+    api.HandleFunc("/insights", handler.GetInsightsSummary).Methods("GET")
+    api.HandleFunc("/archive", handler.GetStoryArchive).Methods("GET")
+    api.HandleFunc("/archive/settings", handler.GetArchiveSettings).Methods("GET")
+    api.HandleFunc("/archive/settings", handler.UpdateArchiveSettings).Methods("PUT")
+    
     api.HandleFunc("/{id}", handler.GetStory).Methods("GET")
     api.HandleFunc("/{id}", handler.DeleteStory).Methods("DELETE")
     
@@ -21,6 +34,8 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.M
     api.HandleFunc("/{id}/reply", handler.ReplyToStory).Methods("POST")
     api.HandleFunc("/{id}/views", handler.GetStoryViews).Methods("GET")
     api.HandleFunc("/{id}/replies", handler.GetStoryReplies).Methods("GET")
+    api.HandleFunc("/{id}/interactions", handler.RecordInteraction).Methods("POST")
+    api.HandleFunc("/{id}/insights", handler.GetStoryInsights).Methods("GET")
     api.HandleFunc("/replies/{replyId}/read", handler.MarkReplyAsRead).Methods("PUT")
     
     // User stories
@@ -29,8 +44,16 @@ func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.M
     // Highlights
     api.HandleFunc("/highlights", handler.CreateHighlight).Methods("POST")
     api.HandleFunc("/highlights", handler.GetHighlights).Methods("GET")
+    api.HandleFunc("/highlights/order", handler.ReorderHighlights).Methods("PUT")
+    api.HandleFunc("/highlights/{id}", handler.UpdateHighlight).Methods("PUT")
     api.HandleFunc("/highlights/{id}", handler.DeleteHighlight).Methods("DELETE")
+    api.HandleFunc("/highlights/{id}/cover", handler.UploadHighlightCover).Methods("POST")
     
     // Media upload
     api.HandleFunc("/upload", handler.UploadMedia).Methods("POST")
+    
+    // Highlights on user profiles
+    profiles := router.PathPrefix("/api/v1/users/{id}/highlights").Subrouter()
+    profiles.Use(authMiddleware.Authenticate)
+    profiles.HandleFunc("", handler.GetUserHighlights).Methods("GET")
 }
\ No newline at end of file
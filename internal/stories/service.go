@@ -5,10 +5,15 @@ import (
     "errors"
     "fmt"
     "mime/multipart"
-    "os"
     "path/filepath"
+    "log"
+    "strconv"
     "strings"
     "time"
+    
+    "github.com/imadgeboyega/kiekky-backend/internal/common/counters"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/database"
+    "github.com/imadgeboyega/kiekky-backend/internal/events"
 )
 
 var (
@@ -17,6 +22,7 @@ var (
     ErrStoryExpired  = errors.New("story has expired")
     ErrInvalidMedia  = errors.New("invalid media file")
     ErrInvalidReply  = errors.New("message or reaction is required")
+    ErrInvalidFriend = errors.New("invalid close friend")
 )
 
 type Service interface {
@@ -25,6 +31,7 @@ type Service interface {
     GetStory(ctx context.Context, storyID int64, viewerID int64) (*Story, error)
     GetUserStories(ctx context.Context, userID int64, viewerID int64) ([]*Story, error)
     GetActiveStories(ctx context.Context, viewerID int64, limit int, offset int) (*StoriesResponse, error)
+    GetStoryFeed(ctx context.Context, viewerID int64, limit int, offset int) (*StoryFeedResponse, error)
     DeleteStory(ctx context.Context, storyID int64, userID int64) error
     
     // Story interactions
@@ -33,17 +40,66 @@ type Service interface {
     GetStoryViews(ctx context.Context, storyID int64, userID int64) ([]*StoryView, error)
     GetStoryReplies(ctx context.Context, storyID int64, userID int64) ([]*StoryReply, error)
     MarkReplyAsRead(ctx context.Context, replyID int64, userID int64) error
+    RecordInteraction(ctx context.Context, storyID, userID int64, req *InteractionRequest) error
+    
+    // Insights, for story authors
+    GetStoryInsights(ctx context.Context, storyID, userID int64) (*StoryInsights, error)
+    GetInsightsSummary(ctx context.Context, userID int64, days int) (*StoryInsightsSummary, error)
     
     // Highlights
     CreateHighlight(ctx context.Context, userID int64, req *CreateHighlightRequest) (*StoryHighlight, error)
     GetUserHighlights(ctx context.Context, userID int64) ([]*StoryHighlight, error)
+    GetHighlightsForViewer(ctx context.Context, ownerID, viewerID int64) ([]*StoryHighlight, error)
+    UpdateHighlight(ctx context.Context, highlightID, userID int64, req *UpdateHighlightRequest) (*StoryHighlight, error)
+    UploadHighlightCover(ctx context.Context, highlightID, userID int64, file multipart.File, header *multipart.FileHeader) (*StoryHighlight, error)
+    ReorderHighlights(ctx context.Context, userID int64, req *ReorderHighlightsRequest) error
     DeleteHighlight(ctx context.Context, highlightID int64, userID int64) error
     
+    // Archive, of the user's own expired stories
+    GetStoryArchive(ctx context.Context, userID int64, limit, offset int) ([]*Story, error)
+    GetArchiveSettings(ctx context.Context, userID int64) (*ArchiveSettings, error)
+    UpdateArchiveSettings(ctx context.Context, userID int64, req *UpdateArchiveSettingsRequest) (*ArchiveSettings, error)
+    
     // Media upload
     UploadStoryMedia(ctx context.Context, userID int64, file multipart.File, header *multipart.FileHeader) (string, error)
     
+    // Sharing
+    SharePostToStory(ctx context.Context, userID int64, req *SharePostRequest) (*Story, error)
+    
+    // Close friends
+    AddCloseFriend(ctx context.Context, userID, friendID int64) error
+    RemoveCloseFriend(ctx context.Context, userID, friendID int64) error
+    GetCloseFriends(ctx context.Context, userID int64) ([]*CloseFriend, error)
+    
     // Cleanup
     CleanupExpiredStories(ctx context.Context) error
+    
+    // Video processing
+    ProcessPendingVideos(ctx context.Context) (int, error)
+    
+    // SetNotifier sets the notifier after initialization, since notifications
+    // are set up after stories
+    SetNotifier(notifier Notifier)
+    
+    // SetEvents sets the bus story replies are published on, which delivers
+    // them to the author's inbox
+    SetEvents(bus *events.Bus)
+    
+    // SetUnitOfWork saves a reply and its event, or a highlight and its
+    // stories, together
+    SetUnitOfWork(uow *database.UnitOfWork)
+    
+    // SetTranscoder turns on video processing: new video stories stay
+    // pending until transcoded
+    SetTranscoder(transcoder Transcoder)
+    
+    // SetArchiveRetention keeps expired stories in their author's archive
+    // for retention before they are deleted
+    SetArchiveRetention(retention time.Duration)
+    
+    // SetMediaMover moves archived stories' media under an archive prefix.
+    // Without one, archived media stays where it was uploaded.
+    SetMediaMover(mover MediaMover)
 }
 
 // UploadService interface for media uploads
@@ -52,23 +108,41 @@ type UploadService interface {
     DeleteFile(ctx context.Context, fileURL string) error
 }
 
+// Notifier sends notifications triggered by stories
+type Notifier interface {
+    SendMentionNotification(ctx context.Context, mentionerID, mentionedUserID int64, contentType string, contentID int64) error
+}
+
 type service struct {
-    repo          Repository
-    uploadService UploadService
-    expiryHours   int
+    repo             Repository
+    uploadService    UploadService
+    viewCounter      *counters.ViewCounter
+    notifier         Notifier
+    events           *events.Bus
+    uow              *database.UnitOfWork
+    transcoder       Transcoder
+    mediaMover       MediaMover
+    expiryHours      int
+    maxVideoSeconds  int
+    archiveRetention time.Duration
 }
 
-func NewService(repo Repository, uploadService UploadService) Service {
-    expiryHours := 24 // Default to 24 hours
-    if hours := os.Getenv("STORY_EXPIRY_HOURS"); hours != "" {
-        // Parse hours from env if available
-        fmt.Sscanf(hours, "%d", &expiryHours)
+// NewService creates the stories service. viewCounter may be nil, in which
+// case view counts are read from story_views directly.
+func NewService(repo Repository, uploadService UploadService, viewCounter *counters.ViewCounter, expiryHours, maxVideoSeconds int) Service {
+    if expiryHours <= 0 {
+        expiryHours = 24 // Default to 24 hours
+    }
+    if maxVideoSeconds <= 0 {
+        maxVideoSeconds = 30
     }
     
     return &service{
-        repo:          repo,
-        uploadService: uploadService,
-        expiryHours:   expiryHours,
+        repo:            repo,
+        uploadService:   uploadService,
+        viewCounter:     viewCounter,
+        expiryHours:     expiryHours,
+        maxVideoSeconds: maxVideoSeconds,
     }
 }
 
@@ -80,19 +154,39 @@ func (s *service) CreateStory(ctx context.Context, userID int64, req *CreateStor
         if req.MediaType == "image" {
             duration = 5
         } else {
-            duration = 15
+            duration = min(15, s.maxVideoSeconds)
+        }
+    }
+    
+    // Videos are measured again when transcoded, so the declared duration
+    // is only a first check
+    status := ProcessingReady
+    if req.MediaType == "video" {
+        if err := s.checkVideoDuration(float64(duration)); err != nil {
+            return nil, err
+        }
+        if s.transcoder != nil {
+            status = ProcessingPending
         }
     }
     
     // Calculate expiry time
     expiresAt := time.Now().Add(time.Duration(s.expiryHours) * time.Hour)
     
+    elements, err := s.prepareElements(ctx, userID, req.Elements)
+    if err != nil {
+        return nil, err
+    }
+    
     story := &Story{
-        UserID:       userID,
-        MediaURL:     req.MediaURL,
-        MediaType:    req.MediaType,
-        Duration:     duration,
-        ExpiresAt:    expiresAt,
+        UserID:           userID,
+        MediaURL:         req.MediaURL,
+        MediaType:        req.MediaType,
+        Duration:         duration,
+        Elements:         elements,
+        Audience:         audienceOrDefault(req.Audience),
+        ExpiresAt:        expiresAt,
+        ProcessingStatus: status,
     }
     
     if req.ThumbnailURL != "" {
@@ -102,19 +196,204 @@ func (s *service) CreateStory(ctx context.Context, userID int64, req *CreateStor
         story.Caption = &req.Caption
     }
     
+    return s.saveStory(ctx, story)
+}
+
+// SharePostToStory reshares a post into a story. The story reuses the post's
+// media and carries a post sticker that deep-links back to the original.
+func (s *service) SharePostToStory(ctx context.Context, userID int64, req *SharePostRequest) (*Story, error) {
+    post, err := s.repo.GetSharedPost(ctx, req.PostID)
+    if err != nil {
+        return nil, err
+    }
+    
+    if post.UserID != userID && post.Visibility != "public" {
+        return nil, ErrUnauthorized
+    }
+    if post.MediaURL == nil {
+        return nil, ErrInvalidMedia
+    }
+    
+    mediaType := "image"
+    duration := 5
+    if post.MediaType != nil && *post.MediaType == "video" {
+        mediaType = "video"
+        duration = 15
+    }
+    
+    postID := post.ID
+    elements := append(StoryElements{{
+        Type:   ElementPost,
+        PostID: &postID,
+        X:      0.5,
+        Y:      0.5,
+    }}, req.Elements...)
+    
+    elements, err = s.prepareElements(ctx, userID, elements)
+    if err != nil {
+        return nil, err
+    }
+    
+    story := &Story{
+        UserID:           userID,
+        MediaURL:         *post.MediaURL,
+        MediaType:        mediaType,
+        Duration:         duration,
+        Elements:         elements,
+        SharedPostID:     &postID,
+        Audience:         audienceOrDefault(req.Audience),
+        ExpiresAt:        time.Now().Add(time.Duration(s.expiryHours) * time.Hour),
+        ProcessingStatus: ProcessingReady, // the post's media is already processed
+    }
+    
+    if req.Caption != "" {
+        story.Caption = &req.Caption
+    }
+    
+    return s.saveStory(ctx, story)
+}
+
+// saveStory stores a story, attaches the author and notifies mentioned users
+func (s *service) saveStory(ctx context.Context, story *Story) (*Story, error) {
     if err := s.repo.CreateStory(ctx, story); err != nil {
         return nil, err
     }
     
     // Get user info
-    user, err := s.repo.GetStoryUser(ctx, userID)
+    user, err := s.repo.GetStoryUser(ctx, story.UserID)
     if err == nil {
         story.User = user
     }
     
+    s.notifyMentions(ctx, story)
+    
     return story, nil
 }
 
+// prepareElements validates stickers and resolves the users and posts they reference
+func (s *service) prepareElements(ctx context.Context, userID int64, elements StoryElements) (StoryElements, error) {
+    if len(elements) > maxStoryElements {
+        return nil, fmt.Errorf("%w: at most %d elements per story", ErrInvalidElement, maxStoryElements)
+    }
+    
+    for i := range elements {
+        el := &elements[i]
+        if err := el.validate(); err != nil {
+            return nil, err
+        }
+        
+        switch el.Type {
+        case ElementMention:
+            user, err := s.repo.GetStoryUser(ctx, *el.UserID)
+            if err != nil {
+                return nil, fmt.Errorf("%w: mentioned user %d not found", ErrInvalidElement, *el.UserID)
+            }
+            el.Username = user.Username
+        case ElementPost:
+            post, err := s.repo.GetSharedPost(ctx, *el.PostID)
+            if err != nil {
+                return nil, fmt.Errorf("%w: post %d not found", ErrInvalidElement, *el.PostID)
+            }
+            if post.UserID != userID && post.Visibility != "public" {
+                return nil, fmt.Errorf("%w: post %d is not public", ErrInvalidElement, *el.PostID)
+            }
+        }
+    }
+    
+    return elements, nil
+}
+
+// notifyMentions sends a mention notification to every user tagged in a story
+func (s *service) notifyMentions(ctx context.Context, story *Story) {
+    if s.notifier == nil {
+        return
+    }
+    
+    for _, mentionedID := range story.Elements.MentionedUserIDs() {
+        if mentionedID == story.UserID {
+            continue
+        }
+        if err := s.notifier.SendMentionNotification(ctx, story.UserID, mentionedID, "story", story.ID); err != nil {
+            log.Printf("Failed to send story mention notification to user %d: %v", mentionedID, err)
+        }
+    }
+}
+
+// checkAudience hides stories from viewers outside their audience
+func (s *service) checkAudience(ctx context.Context, story *Story, viewerID int64) error {
+    allowed, err := s.repo.CanViewStory(ctx, story, viewerID)
+    if err != nil {
+        return err
+    }
+    if !allowed {
+        // Don't reveal that a restricted story exists
+        return ErrStoryNotFound
+    }
+    return nil
+}
+
+func audienceOrDefault(audience string) string {
+    switch audience {
+    case AudienceFollowers, AudienceCloseFriends:
+        return audience
+    default:
+        return AudiencePublic
+    }
+}
+
+// AddCloseFriend adds a user to the caller's close friends list
+func (s *service) AddCloseFriend(ctx context.Context, userID, friendID int64) error {
+    if userID == friendID {
+        return ErrInvalidFriend
+    }
+    
+    if _, err := s.repo.GetStoryUser(ctx, friendID); err != nil {
+        return ErrInvalidFriend
+    }
+    
+    return s.repo.AddCloseFriend(ctx, userID, friendID)
+}
+
+// RemoveCloseFriend removes a user from the caller's close friends list
+func (s *service) RemoveCloseFriend(ctx context.Context, userID, friendID int64) error {
+    return s.repo.RemoveCloseFriend(ctx, userID, friendID)
+}
+
+// GetCloseFriends retrieves the caller's close friends list
+func (s *service) GetCloseFriends(ctx context.Context, userID int64) ([]*CloseFriend, error) {
+    return s.repo.GetCloseFriends(ctx, userID)
+}
+
+// SetNotifier sets the notifier used for mention notifications
+func (s *service) SetNotifier(notifier Notifier) {
+    s.notifier = notifier
+}
+
+// SetEvents sets the bus story replies are published on
+func (s *service) SetEvents(bus *events.Bus) {
+    s.events = bus
+}
+
+// SetUnitOfWork sets the unit of work replies and highlights are saved in
+func (s *service) SetUnitOfWork(uow *database.UnitOfWork) {
+    s.uow = uow
+}
+
+// SetTranscoder sets the transcoder story videos are processed with
+func (s *service) SetTranscoder(transcoder Transcoder) {
+    s.transcoder = transcoder
+}
+
+// SetArchiveRetention sets how long expired stories are archived
+func (s *service) SetArchiveRetention(retention time.Duration) {
+    s.archiveRetention = retention
+}
+
+// SetMediaMover sets the storage archived story media is moved with
+func (s *service) SetMediaMover(mover MediaMover) {
+    s.mediaMover = mover
+}
+
 // GetStory retrieves a story by ID
 func (s *service) GetStory(ctx context.Context, storyID int64, viewerID int64) (*Story, error) {
     story, err := s.repo.GetStoryWithUser(ctx, storyID, viewerID)
@@ -122,17 +401,23 @@ func (s *service) GetStory(ctx context.Context, storyID int64, viewerID int64) (
         return nil, err
     }
     
-    // Check if story is expired
-    if time.Now().After(story.ExpiresAt) && !story.IsHighlighted {
+    if err := s.checkAudience(ctx, story, viewerID); err != nil {
+        return nil, err
+    }
+    
+    // Expired stories are only shown to their author, from the archive
+    if time.Now().After(story.ExpiresAt) && !story.IsHighlighted && story.UserID != viewerID {
         return nil, ErrStoryExpired
     }
     
+    s.attachViewCounts(ctx, story)
+    
     return story, nil
 }
 
 // GetUserStories retrieves all stories for a user
 func (s *service) GetUserStories(ctx context.Context, userID int64, viewerID int64) ([]*Story, error) {
-    stories, err := s.repo.GetUserStories(ctx, userID, false)
+    stories, err := s.repo.GetUserStories(ctx, userID, viewerID, false)
     if err != nil {
         return nil, err
     }
@@ -148,6 +433,8 @@ func (s *service) GetUserStories(ctx context.Context, userID int64, viewerID int
         }
     }
     
+    s.attachViewCounts(ctx, stories...)
+    
     return stories, nil
 }
 
@@ -162,6 +449,8 @@ func (s *service) GetActiveStories(ctx context.Context, viewerID int64, limit in
         return nil, err
     }
     
+    s.attachViewCounts(ctx, stories...)
+    
     totalCount, err := s.repo.GetActiveStoriesCount(ctx, viewerID)
     if err != nil {
         totalCount = len(stories)
@@ -174,6 +463,36 @@ func (s *service) GetActiveStories(ctx context.Context, viewerID int64, limit in
     }, nil
 }
 
+// GetStoryFeed retrieves the story tray: every active story grouped per author
+func (s *service) GetStoryFeed(ctx context.Context, viewerID int64, limit int, offset int) (*StoryFeedResponse, error) {
+    if limit == 0 {
+        limit = 20
+    }
+    
+    groups, err := s.repo.GetStoryGroups(ctx, viewerID, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+    if groups == nil {
+        groups = []*StoryGroup{}
+    }
+    
+    for _, group := range groups {
+        s.attachViewCounts(ctx, group.Stories...)
+    }
+    
+    totalCount, err := s.repo.GetActiveStoriesCount(ctx, viewerID)
+    if err != nil {
+        totalCount = len(groups)
+    }
+    
+    return &StoryFeedResponse{
+        Groups:     groups,
+        TotalCount: totalCount,
+        HasMore:    offset+len(groups) < totalCount,
+    }, nil
+}
+
 // DeleteStory deletes a story
 func (s *service) DeleteStory(ctx context.Context, storyID int64, userID int64) error {
     story, err := s.repo.GetStory(ctx, storyID)
@@ -186,12 +505,15 @@ func (s *service) DeleteStory(ctx context.Context, storyID int64, userID int64)
         return ErrUnauthorized
     }
     
-    // Delete media file
-    if s.uploadService != nil {
+    // Delete media file, unless it belongs to a reshared post
+    if s.uploadService != nil && story.SharedPostID == nil {
         s.uploadService.DeleteFile(ctx, story.MediaURL)
         if story.ThumbnailURL != nil {
             s.uploadService.DeleteFile(ctx, *story.ThumbnailURL)
         }
+        if story.PlaybackURL != nil {
+            s.uploadService.DeleteFile(ctx, *story.PlaybackURL)
+        }
     }
     
     return s.repo.DeleteStory(ctx, storyID)
@@ -214,7 +536,21 @@ func (s *service) ViewStory(ctx context.Context, storyID int64, viewerID int64)
         return nil
     }
     
-    return s.repo.RecordView(ctx, storyID, viewerID)
+    if err := s.checkAudience(ctx, story, viewerID); err != nil {
+        return err
+    }
+    
+    if err := s.repo.RecordView(ctx, storyID, viewerID); err != nil {
+        return err
+    }
+    
+    if s.viewCounter != nil {
+        if err := s.viewCounter.Record(ctx, counters.KindStory, storyID, viewerID); err != nil {
+            log.Printf("Failed to record story view in counter: %v", err)
+        }
+    }
+    
+    return nil
 }
 
 // ReplyToStory creates a reply to a story
@@ -234,6 +570,10 @@ func (s *service) ReplyToStory(ctx context.Context, storyID int64, userID int64,
         return nil, ErrStoryExpired
     }
     
+    if err := s.checkAudience(ctx, story, userID); err != nil {
+        return nil, err
+    }
+    
     reply := &StoryReply{
         StoryID: storyID,
         UserID:  userID,
@@ -246,7 +586,27 @@ func (s *service) ReplyToStory(ctx context.Context, storyID int64, userID int64,
         reply.Reaction = &req.Reaction
     }
     
-    if err := s.repo.CreateReply(ctx, reply); err != nil {
+    // The reply reaches the author as a direct message and a notification
+    // through StoryReplied, stored with the reply so neither is lost
+    err = s.uow.Do(ctx, func(ctx context.Context) error {
+        if err := s.repo.CreateReply(ctx, reply); err != nil {
+            return err
+        }
+        if story.UserID == userID {
+            return nil
+        }
+        return s.events.Publish(ctx, strconv.FormatInt(reply.ID, 10), events.StoryReplied{
+            ReplyID:        reply.ID,
+            StoryID:        story.ID,
+            StoryOwnerID:   story.UserID,
+            ReplierID:      userID,
+            Message:        req.Message,
+            Reaction:       req.Reaction,
+            StoryMediaType: story.MediaType,
+            StoryExpiresAt: story.ExpiresAt,
+        })
+    })
+    if err != nil {
         return nil, err
     }
     
@@ -310,82 +670,69 @@ func (s *service) MarkReplyAsRead(ctx context.Context, replyID int64, userID int
     return s.repo.MarkReplyAsRead(ctx, replyID)
 }
 
-// CreateHighlight creates a story highlight
+// CreateHighlight creates a highlight from the user's own stories
 func (s *service) CreateHighlight(ctx context.Context, userID int64, req *CreateHighlightRequest) (*StoryHighlight, error) {
-    // Verify ownership of all stories
-    for _, storyID := range req.StoryIDs {
-        story, err := s.repo.GetStory(ctx, storyID)
-        if err != nil {
-            return nil, err
-        }
-        if story.UserID != userID {
-            return nil, ErrUnauthorized
-        }
-        
-        // Mark stories as highlighted
-        story.IsHighlighted = true
-        story.HighlightTitle = &req.Title
-        s.repo.CreateStory(ctx, story) // Update story
+    storyIDs, err := s.ownedStoryIDs(ctx, userID, req.StoryIDs)
+    if err != nil {
+        return nil, err
     }
     
     highlight := &StoryHighlight{
         UserID:   userID,
         Title:    req.Title,
-        StoryIDs: req.StoryIDs,
+        StoryIDs: storyIDs,
     }
     
     if req.CoverImage != "" {
         highlight.CoverImage = &req.CoverImage
     }
     
-    if err := s.repo.CreateHighlight(ctx, highlight); err != nil {
-        return nil, err
-    }
-    
-    // Load stories
-    for _, storyID := range highlight.StoryIDs {
-        story, err := s.repo.GetStoryWithUser(ctx, storyID, 0)
-        if err == nil {
-            highlight.Stories = append(highlight.Stories, story)
+    // Highlighted stories are kept past the archive retention
+    err = s.uow.Do(ctx, func(ctx context.Context) error {
+        if err := s.repo.CreateHighlight(ctx, highlight); err != nil {
+            return err
         }
+        return s.repo.SyncHighlightedStories(ctx, userID)
+    })
+    if err != nil {
+        return nil, err
     }
     
-    return highlight, nil
+    return s.reloadHighlight(ctx, highlight)
 }
 
-// GetUserHighlights retrieves all highlights for a user
+// GetUserHighlights retrieves all of the user's own highlights, in order
 func (s *service) GetUserHighlights(ctx context.Context, userID int64) ([]*StoryHighlight, error) {
     highlights, err := s.repo.GetUserHighlights(ctx, userID)
     if err != nil {
         return nil, err
     }
     
-    // Load stories for each highlight
-    for _, highlight := range highlights {
-        for _, storyID := range highlight.StoryIDs {
-            story, err := s.repo.GetStoryWithUser(ctx, storyID, 0)
-            if err == nil {
-                highlight.Stories = append(highlight.Stories, story)
-            }
-        }
-    }
-    
-    return highlights, nil
+    return s.loadHighlightStories(ctx, highlights, userID, userID)
 }
 
-// DeleteHighlight deletes a highlight
+// DeleteHighlight deletes a highlight, releasing its stories to expire
+// from the archive unless another highlight keeps them
 func (s *service) DeleteHighlight(ctx context.Context, highlightID int64, userID int64) error {
-    highlight, err := s.repo.GetHighlight(ctx, highlightID)
+    highlight, err := s.getOwnHighlight(ctx, highlightID, userID)
     if err != nil {
         return err
     }
     
-    // Check ownership
-    if highlight.UserID != userID {
-        return ErrUnauthorized
+    err = s.uow.Do(ctx, func(ctx context.Context) error {
+        if err := s.repo.DeleteHighlight(ctx, highlightID); err != nil {
+            return err
+        }
+        return s.repo.SyncHighlightedStories(ctx, userID)
+    })
+    if err != nil {
+        return err
     }
     
-    return s.repo.DeleteHighlight(ctx, highlightID)
+    if cover := s.uploadedCover(highlight); cover != "" {
+        s.uploadService.DeleteFile(ctx, cover)
+    }
+    return nil
 }
 
 // UploadStoryMedia uploads story media file
@@ -401,10 +748,11 @@ func (s *service) UploadStoryMedia(ctx context.Context, userID int64, file multi
         return "", ErrInvalidMedia
     }
     
-    // Check file size (100MB max)
-    maxSize := int64(100 * 1024 * 1024)
-    if header.Size > maxSize {
-        return "", errors.New("file size exceeds 100MB limit")
+    // Check video length
+    if ext == ".mp4" || ext == ".mov" || ext == ".avi" {
+        if err := s.probeUpload(ctx, file); err != nil {
+            return "", err
+        }
     }
     
     // Upload to storage
@@ -417,27 +765,32 @@ func (s *service) UploadStoryMedia(ctx context.Context, userID int64, file multi
     return url, nil
 }
 
-// CleanupExpiredStories removes expired stories
-func (s *service) CleanupExpiredStories(ctx context.Context) error {
-    before := time.Now()
+// attachViewCounts fills ViewCount from the approximate counter, falling back
+// to the exact count when no counter is configured
+func (s *service) attachViewCounts(ctx context.Context, stories ...*Story) {
+    if len(stories) == 0 {
+        return
+    }
     
-    // Get media URLs before deletion
-    mediaURLs, err := s.repo.GetExpiredStoryMedia(ctx, before)
-    if err != nil {
-        return err
+    if s.viewCounter == nil {
+        for _, story := range stories {
+            story.ViewCount, _ = s.repo.GetStoryViewCount(ctx, story.ID)
+        }
+        return
     }
     
-    // Delete from database
-    if err := s.repo.DeleteExpiredStories(ctx, before); err != nil {
-        return err
+    ids := make([]int64, len(stories))
+    for i, story := range stories {
+        ids[i] = story.ID
     }
     
-    // Delete media files
-    if s.uploadService != nil {
-        for _, url := range mediaURLs {
-            s.uploadService.DeleteFile(ctx, url)
-        }
+    counts, err := s.viewCounter.Counts(ctx, counters.KindStory, ids)
+    if err != nil {
+        log.Printf("Failed to load story view counts: %v", err)
+        return
     }
     
-    return nil
-}
\ No newline at end of file
+    for _, story := range stories {
+        story.ViewCount = counts[story.ID]
+    }
+}
@@ -0,0 +1,230 @@
+// internal/stories/transcode.go
+// Transcoders for story videos: ffmpeg on this host, or an external service
+
+package stories
+
+import (
+    "bytes"
+    "context"
+    "encoding/json"
+    "fmt"
+    "mime/multipart"
+    "net/http"
+    "net/textproto"
+    "os"
+    "os/exec"
+    "path/filepath"
+    "strconv"
+    "strings"
+    "time"
+)
+
+// FFmpegTranscoder transcodes videos with the ffmpeg and ffprobe binaries
+// and stores the results with the stories uploader. It produces an H.264/AAC
+// MP4 no wider than 1080px and a JPEG thumbnail of the first frame.
+type FFmpegTranscoder struct {
+    ffmpegPath  string
+    ffprobePath string
+    uploader    UploadService
+}
+
+// NewFFmpegTranscoder finds ffmpeg and ffprobe on the PATH
+func NewFFmpegTranscoder(uploader UploadService) (*FFmpegTranscoder, error) {
+    ffmpegPath, err := exec.LookPath("ffmpeg")
+    if err != nil {
+        return nil, fmt.Errorf("ffmpeg not found: %w", err)
+    }
+    ffprobePath, err := exec.LookPath("ffprobe")
+    if err != nil {
+        return nil, fmt.Errorf("ffprobe not found: %w", err)
+    }
+
+    return &FFmpegTranscoder{
+        ffmpegPath:  ffmpegPath,
+        ffprobePath: ffprobePath,
+        uploader:    uploader,
+    }, nil
+}
+
+// ProbeDuration returns the length of the video at input, a path or URL,
+// in seconds
+func (t *FFmpegTranscoder) ProbeDuration(ctx context.Context, input string) (float64, error) {
+    cmd := exec.CommandContext(ctx, t.ffprobePath,
+        "-v", "error",
+        "-show_entries", "format=duration",
+        "-of", "default=noprint_wrappers=1:nokey=1",
+        input,
+    )
+    out, err := cmd.Output()
+    if err != nil {
+        return 0, fmt.Errorf("failed to probe video: %w", err)
+    }
+
+    seconds, err := strconv.ParseFloat(strings.TrimSpace(string(out)), 64)
+    if err != nil {
+        return 0, fmt.Errorf("%w: no duration", ErrUnplayableVideo)
+    }
+    return seconds, nil
+}
+
+// Transcode checks the video's length, then renders and uploads the MP4
+// and thumbnail
+func (t *FFmpegTranscoder) Transcode(ctx context.Context, req *TranscodeRequest) (*VideoRendition, error) {
+    seconds, err := t.ProbeDuration(ctx, req.SourceURL)
+    if err != nil {
+        return nil, err
+    }
+    if seconds > req.MaxDuration.Seconds()+videoDurationTolerance {
+        return nil, fmt.Errorf("%w: %.1fs, at most %s", ErrVideoTooLong, seconds, req.MaxDuration)
+    }
+
+    dir, err := os.MkdirTemp("", fmt.Sprintf("story-%d-*", req.StoryID))
+    if err != nil {
+        return nil, err
+    }
+    defer os.RemoveAll(dir)
+
+    video := filepath.Join(dir, "video.mp4")
+    if err := t.run(ctx,
+        "-i", req.SourceURL,
+        "-map", "0:v:0", "-map", "0:a:0?",
+        "-vf", "scale='trunc(min(1080,iw)/2)*2':-2",
+        "-c:v", "libx264", "-preset", "veryfast", "-crf", "23", "-pix_fmt", "yuv420p",
+        "-c:a", "aac", "-b:a", "128k",
+        "-movflags", "+faststart",
+        video,
+    ); err != nil {
+        return nil, err
+    }
+
+    thumbnail := filepath.Join(dir, "thumbnail.jpg")
+    if err := t.run(ctx,
+        "-i", video,
+        "-frames:v", "1",
+        "-q:v", "3",
+        thumbnail,
+    ); err != nil {
+        return nil, err
+    }
+
+    folder := fmt.Sprintf("stories/%d", req.UserID)
+    playbackURL, err := t.upload(ctx, video, "video/mp4", folder)
+    if err != nil {
+        return nil, err
+    }
+    thumbnailURL, err := t.upload(ctx, thumbnail, "image/jpeg", folder)
+    if err != nil {
+        t.uploader.DeleteFile(ctx, playbackURL)
+        return nil, err
+    }
+
+    return &VideoRendition{
+        PlaybackURL:  playbackURL,
+        ThumbnailURL: thumbnailURL,
+        Duration:     seconds,
+    }, nil
+}
+
+// run calls ffmpeg, overwriting outputs and reporting its errors
+func (t *FFmpegTranscoder) run(ctx context.Context, args ...string) error {
+    args = append([]string{"-v", "error", "-y"}, args...)
+    if out, err := exec.CommandContext(ctx, t.ffmpegPath, args...).CombinedOutput(); err != nil {
+        return fmt.Errorf("ffmpeg failed: %v: %s", err, bytes.TrimSpace(out))
+    }
+    return nil
+}
+
+// upload stores a rendered file through the stories uploader
+func (t *FFmpegTranscoder) upload(ctx context.Context, path, contentType, folder string) (string, error) {
+    f, err := os.Open(path)
+    if err != nil {
+        return "", err
+    }
+    defer f.Close()
+
+    info, err := f.Stat()
+    if err != nil {
+        return "", err
+    }
+
+    header := &multipart.FileHeader{
+        Filename: filepath.Base(path),
+        Size:     info.Size(),
+        Header:   textproto.MIMEHeader{"Content-Type": {contentType}},
+    }
+    return t.uploader.UploadFile(ctx, f, header, folder)
+}
+
+// HTTPTranscoder hands videos to an external transcoding service. The
+// service receives a POST of
+//
+//     {"story_id": 1, "source_url": "...", "max_duration_seconds": 30}
+//
+// and answers 200 with {"playback_url", "hls_url", "thumbnail_url",
+// "duration"} once the renditions are stored, or 422 with {"error"} for a
+// video it can't use, including one over max_duration_seconds. Renditions
+// should be stored in the app's media bucket, since they are deleted with
+// the story through the stories uploader.
+type HTTPTranscoder struct {
+    url    string
+    secret string
+    client *http.Client
+}
+
+// NewHTTPTranscoder creates a transcoder for the service at url. secret,
+// when set, is sent as a bearer token.
+func NewHTTPTranscoder(url, secret string) *HTTPTranscoder {
+    return &HTTPTranscoder{
+        url:    url,
+        secret: secret,
+        client: &http.Client{Timeout: videoProcessingTimeout},
+    }
+}
+
+// Transcode asks the service for the video's renditions and waits for them
+func (t *HTTPTranscoder) Transcode(ctx context.Context, req *TranscodeRequest) (*VideoRendition, error) {
+    body, err := json.Marshal(map[string]interface{}{
+        "story_id":             req.StoryID,
+        "source_url":           req.SourceURL,
+        "max_duration_seconds": int(req.MaxDuration / time.Second),
+    })
+    if err != nil {
+        return nil, err
+    }
+
+    httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, bytes.NewReader(body))
+    if err != nil {
+        return nil, err
+    }
+    httpReq.Header.Set("Content-Type", "application/json")
+    if t.secret != "" {
+        httpReq.Header.Set("Authorization", "Bearer "+t.secret)
+    }
+
+    resp, err := t.client.Do(httpReq)
+    if err != nil {
+        return nil, fmt.Errorf("transcoder request failed: %w", err)
+    }
+    defer resp.Body.Close()
+
+    switch resp.StatusCode {
+    case http.StatusOK:
+    case http.StatusUnprocessableEntity:
+        var failure struct {
+            Error string `json:"error"`
+        }
+        json.NewDecoder(resp.Body).Decode(&failure)
+        return nil, fmt.Errorf("%w: %s", ErrUnplayableVideo, failure.Error)
+    default:
+        return nil, fmt.Errorf("transcoder returned %s", resp.Status)
+    }
+
+    var rendition VideoRendition
+    if err := json.NewDecoder(resp.Body).Decode(&rendition); err != nil {
+        return nil, fmt.Errorf("failed to decode transcoder response: %w", err)
+    }
+    if rendition.PlaybackURL == "" {
+        return nil, fmt.Errorf("transcoder returned no playback URL")
+    }
+    return &rendition, nil
+}
@@ -0,0 +1,232 @@
+// internal/stories/video.go
+// Story video processing
+// Uploaded videos play in whatever codec and size the phone recorded them
+// in. When a transcoder is configured, video stories are created pending
+// and only shown to their author until a background job has produced an
+// MP4 rendition (and, from transcoders that support it, HLS) and a
+// thumbnail. The story's expiry then counts from that point.
+
+package stories
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "math"
+    "mime/multipart"
+    "os"
+    "time"
+
+    "github.com/imadgeboyega/kiekky-backend/internal/common/media"
+)
+
+// Story processing statuses
+const (
+    ProcessingPending    = "pending"
+    ProcessingProcessing = "processing"
+    ProcessingReady      = "ready"
+    ProcessingFailed     = "failed"
+)
+
+const (
+    // videoProcessingBatch is how many videos a processing run claims
+    videoProcessingBatch = 5
+
+    // videoProcessingTimeout bounds transcoding one video
+    videoProcessingTimeout = 10 * time.Minute
+
+    // maxProcessingAttempts is how often a video is tried before its story
+    // is marked failed
+    maxProcessingAttempts = 3
+
+    // videoDurationTolerance allows for container durations running a
+    // little over the length the user trimmed to
+    videoDurationTolerance = 0.5
+)
+
+var (
+    ErrVideoTooLong    = errors.New("video is too long")
+    ErrUnplayableVideo = errors.New("video could not be processed")
+)
+
+// Transcoder produces the renditions of a story video
+type Transcoder interface {
+    // Transcode returns ErrVideoTooLong or ErrUnplayableVideo when the
+    // video can't be used at all; other errors are retried.
+    Transcode(ctx context.Context, req *TranscodeRequest) (*VideoRendition, error)
+}
+
+// DurationProber is implemented by transcoders that can measure a local
+// file, so uploads can be checked before they are stored
+type DurationProber interface {
+    ProbeDuration(ctx context.Context, path string) (float64, error)
+}
+
+// TranscodeRequest is one video to process
+type TranscodeRequest struct {
+    StoryID     int64
+    UserID      int64
+    SourceURL   string
+    MaxDuration time.Duration
+}
+
+// VideoRendition is what a transcoder produced. HLSURL is empty when the
+// transcoder doesn't produce HLS.
+type VideoRendition struct {
+    PlaybackURL  string  `json:"playback_url"`
+    HLSURL       string  `json:"hls_url,omitempty"`
+    ThumbnailURL string  `json:"thumbnail_url"`
+    Duration     float64 `json:"duration"`
+}
+
+// checkVideoDuration rejects a declared or measured duration over the limit
+func (s *service) checkVideoDuration(seconds float64) error {
+    if seconds > float64(s.maxVideoSeconds)+videoDurationTolerance {
+        return fmt.Errorf("%w: stories can be at most %d seconds", ErrVideoTooLong, s.maxVideoSeconds)
+    }
+    return nil
+}
+
+// probeUpload measures an uploaded video when the transcoder can, and
+// leaves file rewound for the upload
+func (s *service) probeUpload(ctx context.Context, file multipart.File) error {
+    prober, ok := s.transcoder.(DurationProber)
+    if !ok {
+        return nil
+    }
+
+    tmp, err := os.CreateTemp("", "story-upload-*")
+    if err != nil {
+        return err
+    }
+    defer os.Remove(tmp.Name())
+    defer tmp.Close()
+
+    if _, err := io.Copy(tmp, file); err != nil {
+        return err
+    }
+    if _, err := file.Seek(0, io.SeekStart); err != nil {
+        return err
+    }
+
+    seconds, err := prober.ProbeDuration(ctx, tmp.Name())
+    if err != nil {
+        return ErrInvalidMedia
+    }
+    return s.checkVideoDuration(seconds)
+}
+
+// ProcessPendingVideos transcodes the videos of pending stories and
+// returns how many became ready
+func (s *service) ProcessPendingVideos(ctx context.Context) (int, error) {
+    if s.transcoder == nil {
+        return 0, nil
+    }
+
+    stories, err := s.repo.ClaimPendingVideos(ctx, videoProcessingBatch)
+    if err != nil {
+        return 0, err
+    }
+
+    ready := 0
+    for _, story := range stories {
+        err := s.processVideo(ctx, story)
+
+        switch {
+        case err == nil:
+            story.ProcessingStatus = ProcessingReady
+            story.ProcessingError = nil
+            ready++
+        case errors.Is(err, ErrVideoTooLong), errors.Is(err, ErrUnplayableVideo),
+            story.ProcessingAttempts >= maxProcessingAttempts:
+            story.ProcessingStatus = ProcessingFailed
+            msg := err.Error()
+            story.ProcessingError = &msg
+            log.Printf("Story %d video processing failed: %v", story.ID, err)
+        default:
+            story.ProcessingStatus = ProcessingPending
+            log.Printf("Story %d video processing failed, will retry: %v", story.ID, err)
+        }
+
+        if err := s.repo.FinishVideoProcessing(ctx, story); err != nil {
+            log.Printf("Failed to finish processing story %d: %v", story.ID, err)
+        }
+    }
+    return ready, nil
+}
+
+// processVideo transcodes one story's video and fills in its renditions
+func (s *service) processVideo(ctx context.Context, story *Story) error {
+    ctx, cancel := context.WithTimeout(ctx, videoProcessingTimeout)
+    defer cancel()
+
+    rendition, err := s.transcoder.Transcode(ctx, &TranscodeRequest{
+        StoryID:     story.ID,
+        UserID:      story.UserID,
+        SourceURL:   media.SignURL(story.MediaURL),
+        MaxDuration: time.Duration(s.maxVideoSeconds) * time.Second,
+    })
+    if err != nil {
+        return err
+    }
+    if err := s.checkVideoDuration(rendition.Duration); err != nil {
+        return err
+    }
+
+    story.PlaybackURL = &rendition.PlaybackURL
+    if rendition.HLSURL != "" {
+        story.HLSURL = &rendition.HLSURL
+    }
+    if story.ThumbnailURL == nil && rendition.ThumbnailURL != "" {
+        story.ThumbnailURL = &rendition.ThumbnailURL
+    }
+    if rendition.Duration > 0 {
+        story.Duration = int(math.Min(math.Ceil(rendition.Duration), float64(s.maxVideoSeconds)))
+    }
+    return nil
+}
+
+// Repository
+
+// ClaimPendingVideos marks up to limit pending video stories as processing
+// and returns them, oldest first. Stories left processing for half an hour
+// (a crashed worker) are picked up again.
+func (r *postgresRepository) ClaimPendingVideos(ctx context.Context, limit int) ([]*Story, error) {
+    query := `
+        UPDATE stories
+        SET processing_status = 'processing', processing_started_at = NOW(),
+            processing_attempts = processing_attempts + 1
+        WHERE id IN (
+            SELECT id FROM stories
+            WHERE processing_status = 'pending'
+               OR (processing_status = 'processing' AND processing_started_at < NOW() - INTERVAL '30 minutes')
+            ORDER BY created_at
+            LIMIT $1
+            FOR UPDATE SKIP LOCKED
+        )
+        RETURNING id, user_id, media_url, media_type, thumbnail_url, duration,
+                  processing_status, processing_attempts, created_at`
+
+    var stories []*Story
+    if err := r.db.SelectContext(ctx, &stories, query, limit); err != nil {
+        return nil, err
+    }
+    return stories, nil
+}
+
+// FinishVideoProcessing stores the outcome of processing a story's video.
+// A story that becomes ready gets its full lifetime from now on.
+func (r *postgresRepository) FinishVideoProcessing(ctx context.Context, story *Story) error {
+    query := `
+        UPDATE stories
+        SET processing_status = $2, processing_error = $3, playback_url = $4, hls_url = $5,
+            thumbnail_url = $6, duration = $7, updated_at = NOW(),
+            expires_at = CASE WHEN $2 = 'ready' THEN NOW() + (expires_at - created_at) ELSE expires_at END
+        WHERE id = $1`
+
+    _, err := r.db.ExecContext(ctx, query, story.ID, story.ProcessingStatus, story.ProcessingError,
+        story.PlaybackURL, story.HLSURL, story.ThumbnailURL, story.Duration)
+    return err
+}
@@ -0,0 +1,108 @@
+// internal/suppression/handlers.go
+
+package suppression
+
+import (
+    "errors"
+    "io"
+    "log"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// maxWebhookSize bounds webhook bodies; SendGrid batches events, up to a
+// few hundred per post
+const maxWebhookSize = 4 << 20
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// HandleWebhook handles POST /email/webhooks/{provider}. Providers retry on
+// non-2xx responses, so only a processing failure returns 5xx.
+func (h *Handler) HandleWebhook(w http.ResponseWriter, r *http.Request) {
+    provider := mux.Vars(r)["provider"]
+
+    payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookSize))
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid payload")
+        return
+    }
+
+    if err := h.service.HandleWebhook(r.Context(), provider, payload, r.Header); err != nil {
+        switch {
+        case errors.Is(err, ErrUnknownProvider):
+            utils.RespondWithError(w, http.StatusNotFound, "Unknown provider")
+        case errors.Is(err, ErrInvalidSignature):
+            utils.RespondWithError(w, http.StatusBadRequest, "Invalid signature")
+        default:
+            log.Printf("Failed to process %s email webhook: %v", provider, err)
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to process webhook")
+        }
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]bool{"received": true})
+}
+
+// ListSuppressions handles GET /admin/email/suppressions
+func (h *Handler) ListSuppressions(w http.ResponseWriter, r *http.Request) {
+    q := r.URL.Query()
+    filter := &ListFilter{Reason: Reason(q.Get("reason")), Query: q.Get("q")}
+    filter.Limit, _ = strconv.Atoi(q.Get("limit"))
+    filter.Offset, _ = strconv.Atoi(q.Get("offset"))
+    if filter.Limit <= 0 || filter.Limit > 100 {
+        filter.Limit = 50
+    }
+    if filter.Offset < 0 {
+        filter.Offset = 0
+    }
+
+    suppressions, err := h.service.List(r.Context(), filter)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get suppressions")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]interface{}{
+        "suppressions": suppressions,
+    })
+}
+
+// AddSuppression handles POST /admin/email/suppressions
+func (h *Handler) AddSuppression(w http.ResponseWriter, r *http.Request) {
+    var req AddSuppressionRequest
+    if !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    suppression, err := h.service.Add(r.Context(), &req)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to suppress address")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusCreated, suppression)
+}
+
+// RemoveSuppression handles DELETE /admin/email/suppressions/{email}, letting
+// email go to the address again
+func (h *Handler) RemoveSuppression(w http.ResponseWriter, r *http.Request) {
+    if err := h.service.Remove(r.Context(), mux.Vars(r)["email"]); err != nil {
+        if errors.Is(err, ErrNotFound) {
+            utils.RespondWithError(w, http.StatusNotFound, "Address is not suppressed")
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to remove suppression")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, map[string]string{"message": "Suppression removed"})
+}
@@ -0,0 +1,54 @@
+// internal/suppression/models.go
+
+package suppression
+
+import (
+    "errors"
+    "time"
+)
+
+var (
+    ErrNotFound         = errors.New("address is not suppressed")
+    ErrUnknownProvider  = errors.New("unknown email provider")
+    ErrInvalidSignature = errors.New("invalid webhook signature")
+)
+
+// Reason is why an address stopped receiving email
+type Reason string
+
+const (
+    ReasonBounce    Reason = "bounce"    // Hard bounce: the mailbox doesn't exist
+    ReasonComplaint Reason = "complaint" // The recipient marked us as spam
+    ReasonManual    Reason = "manual"    // Added by staff
+)
+
+// Suppression is an address no email is sent to
+type Suppression struct {
+    Email     string    `json:"email" db:"email"`
+    Reason    Reason    `json:"reason" db:"reason"`
+    Source    string    `json:"source" db:"source"` // Provider that reported it, or "admin"
+    Detail    string    `json:"detail,omitempty" db:"detail"`
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
+    UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// Event is a bounce or complaint reported by a provider
+type Event struct {
+    Email  string
+    Reason Reason
+    Detail string
+}
+
+// ListFilter narrows the suppression list
+type ListFilter struct {
+    Reason Reason
+    Query  string // Matches part of the address
+    Limit  int
+    Offset int
+}
+
+// AddSuppressionRequest is a staff member suppressing an address by hand
+type AddSuppressionRequest struct {
+    Email  string `json:"email" validate:"required,email"`
+    Detail string `json:"detail" validate:"max=500"`
+}
@@ -0,0 +1,17 @@
+// internal/suppression/provider.go
+
+package suppression
+
+import (
+    "net/http"
+)
+
+// Provider is an email provider that reports bounces and complaints by
+// webhook. SendGrid and SES are built in.
+type Provider interface {
+    Name() string
+
+    // ParseWebhook verifies a webhook's signature and returns the bounces
+    // and complaints in it. Other events are dropped.
+    ParseWebhook(payload []byte, header http.Header) ([]*Event, error)
+}
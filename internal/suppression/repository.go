@@ -0,0 +1,99 @@
+// internal/suppression/repository.go
+
+package suppression
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+    "fmt"
+    "strings"
+
+    "github.com/jmoiron/sqlx"
+)
+
+type Repository interface {
+    IsSuppressed(ctx context.Context, email string) (bool, error)
+    Get(ctx context.Context, email string) (*Suppression, error)
+    Upsert(ctx context.Context, s *Suppression) error
+    Delete(ctx context.Context, email string) (bool, error)
+    List(ctx context.Context, filter *ListFilter) ([]*Suppression, error)
+}
+
+type postgresRepository struct {
+    db *sqlx.DB
+}
+
+func NewPostgresRepository(db *sqlx.DB) Repository {
+    return &postgresRepository{db: db}
+}
+
+func (r *postgresRepository) IsSuppressed(ctx context.Context, email string) (bool, error) {
+    var exists bool
+    err := r.db.GetContext(ctx, &exists,
+        `SELECT EXISTS(SELECT 1 FROM email_suppressions WHERE email = $1)`, email)
+    return exists, err
+}
+
+func (r *postgresRepository) Get(ctx context.Context, email string) (*Suppression, error) {
+    var s Suppression
+    err := r.db.GetContext(ctx, &s, `SELECT * FROM email_suppressions WHERE email = $1`, email)
+    if errors.Is(err, sql.ErrNoRows) {
+        return nil, ErrNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &s, nil
+}
+
+// Upsert records a suppression. A complaint is never downgraded to a bounce,
+// since it's the recipient asking us to stop.
+func (r *postgresRepository) Upsert(ctx context.Context, s *Suppression) error {
+    query := `
+        INSERT INTO email_suppressions (email, reason, source, detail, created_at, updated_at)
+        VALUES ($1, $2, $3, $4, NOW(), NOW())
+        ON CONFLICT (email) DO UPDATE SET
+            reason = CASE WHEN email_suppressions.reason = 'complaint'
+                THEN email_suppressions.reason ELSE EXCLUDED.reason END,
+            source = EXCLUDED.source,
+            detail = EXCLUDED.detail,
+            updated_at = NOW()
+        RETURNING reason, created_at, updated_at`
+
+    return r.db.QueryRowxContext(ctx, query, s.Email, s.Reason, s.Source, s.Detail).
+        Scan(&s.Reason, &s.CreatedAt, &s.UpdatedAt)
+}
+
+func (r *postgresRepository) Delete(ctx context.Context, email string) (bool, error) {
+    result, err := r.db.ExecContext(ctx, `DELETE FROM email_suppressions WHERE email = $1`, email)
+    if err != nil {
+        return false, err
+    }
+    rows, err := result.RowsAffected()
+    return rows > 0, err
+}
+
+func (r *postgresRepository) List(ctx context.Context, filter *ListFilter) ([]*Suppression, error) {
+    var conditions []string
+    var args []interface{}
+    if filter.Reason != "" {
+        args = append(args, filter.Reason)
+        conditions = append(conditions, fmt.Sprintf("reason = $%d", len(args)))
+    }
+    if filter.Query != "" {
+        args = append(args, "%"+strings.ToLower(filter.Query)+"%")
+        conditions = append(conditions, fmt.Sprintf("email LIKE $%d", len(args)))
+    }
+
+    query := `SELECT * FROM email_suppressions`
+    if len(conditions) > 0 {
+        query += ` WHERE ` + strings.Join(conditions, " AND ")
+    }
+    args = append(args, filter.Limit, filter.Offset)
+    query += fmt.Sprintf(` ORDER BY updated_at DESC LIMIT $%d OFFSET $%d`, len(args)-1, len(args))
+
+    suppressions := []*Suppression{}
+    err := r.db.SelectContext(ctx, &suppressions, query, args...)
+    return suppressions, err
+}
@@ -0,0 +1,21 @@
+// internal/suppression/routes.go
+
+package suppression
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    // Webhooks are authenticated by the provider's signature, not a user token
+    router.HandleFunc("/api/v1/email/webhooks/{provider}", handler.HandleWebhook).Methods("POST")
+
+    api := router.PathPrefix("/api/v1/admin/email/suppressions").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+    api.Use(authMiddleware.RequireStaff)
+
+    api.HandleFunc("", handler.ListSuppressions).Methods("GET")
+    api.HandleFunc("", handler.AddSuppression).Methods("POST")
+    api.HandleFunc("/{email}", handler.RemoveSuppression).Methods("DELETE")
+}
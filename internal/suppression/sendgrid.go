@@ -0,0 +1,67 @@
+// internal/suppression/sendgrid.go
+
+package suppression
+
+import (
+    "crypto/ecdsa"
+    "encoding/json"
+    "fmt"
+    "net/http"
+
+    "github.com/sendgrid/sendgrid-go/helpers/eventwebhook"
+)
+
+// SendGridProvider reads SendGrid's signed event webhook
+type SendGridProvider struct {
+    publicKey *ecdsa.PublicKey
+}
+
+// NewSendGridProvider creates a SendGrid provider from the event webhook's
+// verification key, as shown in SendGrid's mail settings
+func NewSendGridProvider(publicKey string) (*SendGridProvider, error) {
+    key, err := eventwebhook.ConvertPublicKeyBase64ToECDSA(publicKey)
+    if err != nil {
+        return nil, fmt.Errorf("invalid SendGrid webhook public key: %w", err)
+    }
+    return &SendGridProvider{publicKey: key}, nil
+}
+
+func (p *SendGridProvider) Name() string {
+    return "sendgrid"
+}
+
+// ParseWebhook reads a batch of SendGrid events. Blocked messages are
+// bounces too, but they're the receiving server refusing us for now rather
+// than a mailbox that doesn't exist, so they aren't suppressed.
+func (p *SendGridProvider) ParseWebhook(payload []byte, header http.Header) ([]*Event, error) {
+    ok, err := eventwebhook.VerifySignature(
+        p.publicKey,
+        payload,
+        header.Get(eventwebhook.VerificationHTTPHeader),
+        header.Get(eventwebhook.TimestampHTTPHeader),
+    )
+    if err != nil || !ok {
+        return nil, ErrInvalidSignature
+    }
+
+    var batch []struct {
+        Email  string `json:"email"`
+        Event  string `json:"event"`
+        Type   string `json:"type"`
+        Reason string `json:"reason"`
+    }
+    if err := json.Unmarshal(payload, &batch); err != nil {
+        return nil, fmt.Errorf("invalid SendGrid events: %w", err)
+    }
+
+    var events []*Event
+    for _, e := range batch {
+        switch {
+        case e.Event == "bounce" && e.Type != "blocked":
+            events = append(events, &Event{Email: e.Email, Reason: ReasonBounce, Detail: e.Reason})
+        case e.Event == "spamreport":
+            events = append(events, &Event{Email: e.Email, Reason: ReasonComplaint})
+        }
+    }
+    return events, nil
+}
@@ -0,0 +1,113 @@
+// internal/suppression/service.go
+// Email suppression list
+//
+// Addresses that hard bounce or complain are suppressed so OTPs and
+// notifications stop going to them; sending to them anyway hurts our sender
+// reputation with every provider. Providers report bounces and complaints by
+// webhook, and staff can add or lift suppressions by hand.
+
+package suppression
+
+import (
+    "context"
+    "log"
+    "net/http"
+    "strings"
+)
+
+type Service interface {
+    // IsSuppressed reports whether email must not be sent to
+    IsSuppressed(ctx context.Context, email string) (bool, error)
+
+    List(ctx context.Context, filter *ListFilter) ([]*Suppression, error)
+    Add(ctx context.Context, req *AddSuppressionRequest) (*Suppression, error)
+    Remove(ctx context.Context, email string) error
+
+    // HandleWebhook records the bounces and complaints in a provider's webhook
+    HandleWebhook(ctx context.Context, provider string, payload []byte, header http.Header) error
+
+    // RegisterProvider adds a provider whose webhooks are accepted
+    RegisterProvider(provider Provider)
+}
+
+type service struct {
+    repo      Repository
+    providers map[string]Provider
+}
+
+func NewService(repo Repository) Service {
+    return &service{
+        repo:      repo,
+        providers: make(map[string]Provider),
+    }
+}
+
+func (s *service) RegisterProvider(provider Provider) {
+    s.providers[provider.Name()] = provider
+}
+
+func (s *service) IsSuppressed(ctx context.Context, email string) (bool, error) {
+    return s.repo.IsSuppressed(ctx, normalize(email))
+}
+
+func (s *service) List(ctx context.Context, filter *ListFilter) ([]*Suppression, error) {
+    return s.repo.List(ctx, filter)
+}
+
+func (s *service) Add(ctx context.Context, req *AddSuppressionRequest) (*Suppression, error) {
+    suppression := &Suppression{
+        Email:  normalize(req.Email),
+        Reason: ReasonManual,
+        Source: "admin",
+        Detail: req.Detail,
+    }
+    if err := s.repo.Upsert(ctx, suppression); err != nil {
+        return nil, err
+    }
+    return suppression, nil
+}
+
+func (s *service) Remove(ctx context.Context, email string) error {
+    removed, err := s.repo.Delete(ctx, normalize(email))
+    if err != nil {
+        return err
+    }
+    if !removed {
+        return ErrNotFound
+    }
+    return nil
+}
+
+func (s *service) HandleWebhook(ctx context.Context, providerName string, payload []byte, header http.Header) error {
+    provider, ok := s.providers[providerName]
+    if !ok {
+        return ErrUnknownProvider
+    }
+
+    events, err := provider.ParseWebhook(payload, header)
+    if err != nil {
+        return err
+    }
+
+    for _, event := range events {
+        email := normalize(event.Email)
+        if email == "" {
+            continue
+        }
+        if err := s.repo.Upsert(ctx, &Suppression{
+            Email:  email,
+            Reason: event.Reason,
+            Source: providerName,
+            Detail: event.Detail,
+        }); err != nil {
+            return err
+        }
+        log.Printf("Suppressed %s after a %s reported by %s", email, event.Reason, providerName)
+    }
+    return nil
+}
+
+// normalize makes addresses compare equal regardless of case
+func normalize(email string) string {
+    return strings.ToLower(strings.TrimSpace(email))
+}
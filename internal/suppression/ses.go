@@ -0,0 +1,241 @@
+// internal/suppression/ses.go
+
+package suppression
+
+import (
+    "crypto/x509"
+    "encoding/base64"
+    "encoding/json"
+    "encoding/pem"
+    "errors"
+    "fmt"
+    "io"
+    "net/http"
+    "net/url"
+    "regexp"
+    "strings"
+    "sync"
+    "time"
+)
+
+// snsHost matches the hosts SNS signing certificates and subscription
+// confirmations are served from
+var snsHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// SESProvider reads SES bounce and complaint notifications delivered by an
+// SNS topic. Messages are verified against the certificate SNS signed them
+// with, and the topic's subscription is confirmed when SNS asks.
+type SESProvider struct {
+    topicARN string
+    client   *http.Client
+
+    mu    sync.Mutex
+    certs map[string]*x509.Certificate
+}
+
+// NewSESProvider creates an SES provider for the SNS topic SES notifies.
+// Anyone can send SNS messages signed by Amazon, so messages from any other
+// topic are refused.
+func NewSESProvider(topicARN string) *SESProvider {
+    return &SESProvider{
+        topicARN: topicARN,
+        client:   &http.Client{Timeout: 10 * time.Second},
+        certs:    make(map[string]*x509.Certificate),
+    }
+}
+
+func (p *SESProvider) Name() string {
+    return "ses"
+}
+
+type snsMessage struct {
+    Type             string `json:"Type"`
+    MessageID        string `json:"MessageId"`
+    Token            string `json:"Token"`
+    TopicArn         string `json:"TopicArn"`
+    Subject          string `json:"Subject"`
+    Message          string `json:"Message"`
+    Timestamp        string `json:"Timestamp"`
+    SignatureVersion string `json:"SignatureVersion"`
+    Signature        string `json:"Signature"`
+    SigningCertURL   string `json:"SigningCertURL"`
+    SubscribeURL     string `json:"SubscribeURL"`
+}
+
+type sesNotification struct {
+    NotificationType string `json:"notificationType"`
+    EventType        string `json:"eventType"` // Set instead by configuration set event publishing
+    Bounce           *struct {
+        BounceType        string `json:"bounceType"`
+        BouncedRecipients []struct {
+            EmailAddress   string `json:"emailAddress"`
+            DiagnosticCode string `json:"diagnosticCode"`
+        } `json:"bouncedRecipients"`
+    } `json:"bounce"`
+    Complaint *struct {
+        ComplaintFeedbackType string `json:"complaintFeedbackType"`
+        ComplainedRecipients  []struct {
+            EmailAddress string `json:"emailAddress"`
+        } `json:"complainedRecipients"`
+    } `json:"complaint"`
+}
+
+// ParseWebhook reads an SNS message. Only permanent bounces are suppressed;
+// transient ones, such as a full mailbox, may clear up.
+func (p *SESProvider) ParseWebhook(payload []byte, header http.Header) ([]*Event, error) {
+    var msg snsMessage
+    if err := json.Unmarshal(payload, &msg); err != nil {
+        return nil, fmt.Errorf("invalid SNS message: %w", err)
+    }
+    if msg.TopicArn != p.topicARN {
+        return nil, ErrInvalidSignature
+    }
+    if err := p.verify(&msg); err != nil {
+        return nil, err
+    }
+
+    switch msg.Type {
+    case "SubscriptionConfirmation":
+        return nil, p.confirm(msg.SubscribeURL)
+    case "Notification":
+    default:
+        return nil, nil
+    }
+
+    var n sesNotification
+    if err := json.Unmarshal([]byte(msg.Message), &n); err != nil {
+        return nil, fmt.Errorf("invalid SES notification: %w", err)
+    }
+
+    var events []*Event
+    kind := n.NotificationType
+    if kind == "" {
+        kind = n.EventType
+    }
+    switch {
+    case kind == "Bounce" && n.Bounce != nil && n.Bounce.BounceType == "Permanent":
+        for _, r := range n.Bounce.BouncedRecipients {
+            events = append(events, &Event{Email: r.EmailAddress, Reason: ReasonBounce, Detail: r.DiagnosticCode})
+        }
+    case kind == "Complaint" && n.Complaint != nil:
+        for _, r := range n.Complaint.ComplainedRecipients {
+            events = append(events, &Event{Email: r.EmailAddress, Reason: ReasonComplaint, Detail: n.Complaint.ComplaintFeedbackType})
+        }
+    }
+    return events, nil
+}
+
+// verify checks msg's signature against its SNS signing certificate
+func (p *SESProvider) verify(msg *snsMessage) error {
+    signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+    if err != nil {
+        return ErrInvalidSignature
+    }
+
+    var algorithm x509.SignatureAlgorithm
+    switch msg.SignatureVersion {
+    case "1":
+        algorithm = x509.SHA1WithRSA
+    case "2":
+        algorithm = x509.SHA256WithRSA
+    default:
+        return ErrInvalidSignature
+    }
+
+    cert, err := p.certificate(msg.SigningCertURL)
+    if err != nil {
+        return err
+    }
+    if err := cert.CheckSignature(algorithm, []byte(msg.stringToSign()), signature); err != nil {
+        return ErrInvalidSignature
+    }
+    return nil
+}
+
+// stringToSign builds the text SNS signs: selected fields, by name, each
+// followed by its value
+func (m *snsMessage) stringToSign() string {
+    fields := [][2]string{{"Message", m.Message}, {"MessageId", m.MessageID}}
+    if m.Type == "Notification" {
+        if m.Subject != "" {
+            fields = append(fields, [2]string{"Subject", m.Subject})
+        }
+    } else {
+        fields = append(fields, [2]string{"SubscribeURL", m.SubscribeURL})
+    }
+    fields = append(fields, [2]string{"Timestamp", m.Timestamp})
+    if m.Type != "Notification" {
+        fields = append(fields, [2]string{"Token", m.Token})
+    }
+    fields = append(fields, [2]string{"TopicArn", m.TopicArn}, [2]string{"Type", m.Type})
+
+    var b strings.Builder
+    for _, f := range fields {
+        b.WriteString(f[0] + "\n" + f[1] + "\n")
+    }
+    return b.String()
+}
+
+// certificate fetches and caches a signing certificate. Only certificates
+// served by SNS over HTTPS are trusted.
+func (p *SESProvider) certificate(certURL string) (*x509.Certificate, error) {
+    if !isSNSURL(certURL) {
+        return nil, ErrInvalidSignature
+    }
+
+    p.mu.Lock()
+    cert, ok := p.certs[certURL]
+    p.mu.Unlock()
+    if ok {
+        return cert, nil
+    }
+
+    resp, err := p.client.Get(certURL)
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch SNS certificate: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return nil, fmt.Errorf("failed to fetch SNS certificate: %s", resp.Status)
+    }
+
+    body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+    if err != nil {
+        return nil, fmt.Errorf("failed to fetch SNS certificate: %w", err)
+    }
+    block, _ := pem.Decode(body)
+    if block == nil {
+        return nil, errors.New("invalid SNS certificate")
+    }
+    cert, err = x509.ParseCertificate(block.Bytes)
+    if err != nil {
+        return nil, fmt.Errorf("invalid SNS certificate: %w", err)
+    }
+
+    p.mu.Lock()
+    p.certs[certURL] = cert
+    p.mu.Unlock()
+    return cert, nil
+}
+
+// confirm accepts the topic's subscription so notifications start flowing
+func (p *SESProvider) confirm(subscribeURL string) error {
+    if !isSNSURL(subscribeURL) {
+        return ErrInvalidSignature
+    }
+
+    resp, err := p.client.Get(subscribeURL)
+    if err != nil {
+        return fmt.Errorf("failed to confirm SNS subscription: %w", err)
+    }
+    defer resp.Body.Close()
+    if resp.StatusCode != http.StatusOK {
+        return fmt.Errorf("failed to confirm SNS subscription: %s", resp.Status)
+    }
+    return nil
+}
+
+func isSNSURL(raw string) bool {
+    u, err := url.Parse(raw)
+    return err == nil && u.Scheme == "https" && snsHost.MatchString(u.Hostname())
+}
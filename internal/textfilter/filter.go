@@ -0,0 +1,332 @@
+// internal/textfilter/filter.go
+// Profanity and spam filtering for user-written text
+//
+// Captions, comments, messages and bios pass through the filter before they
+// are stored. Spam is always rejected; profanity is masked or rejected
+// depending on the configured mode. Every violation is counted against the
+// user so moderators can spot repeat offenders.
+
+package textfilter
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "log"
+    "strings"
+    "sync"
+    "unicode"
+)
+
+// Fields text can come from, recorded with each violation
+const (
+    FieldCaption = "caption"
+    FieldComment = "comment"
+    FieldMessage = "message"
+    FieldBio     = "bio"
+)
+
+// Violation kinds
+const (
+    KindProfanity = "profanity"
+    KindSpam      = "spam"
+)
+
+// Mode is what happens to text containing listed words
+type Mode string
+
+const (
+    ModeMask   Mode = "mask"
+    ModeReject Mode = "reject"
+)
+
+// Severity ranks listed words
+type Severity int
+
+const (
+    SeverityMild Severity = iota + 1
+    SeverityModerate
+    SeveritySevere
+)
+
+func (s Severity) String() string {
+    switch s {
+    case SeverityMild:
+        return "mild"
+    case SeverityModerate:
+        return "moderate"
+    case SeveritySevere:
+        return "severe"
+    }
+    return "none"
+}
+
+var ErrContentRejected = errors.New("content violates community guidelines")
+
+// RejectedError explains why text was refused. It matches ErrContentRejected
+// with errors.Is.
+type RejectedError struct {
+    Kind   string
+    Reason string
+}
+
+func (e *RejectedError) Error() string {
+    return fmt.Sprintf("%s: %s", ErrContentRejected, e.Reason)
+}
+
+func (e *RejectedError) Is(target error) bool {
+    return target == ErrContentRejected
+}
+
+// Filter checks user-written text before it is stored, returning the text to
+// store (masked where needed) or a *RejectedError
+type Filter interface {
+    Filter(ctx context.Context, userID int64, field, text string) (string, error)
+}
+
+// Recorder keeps per-user violation counts, e.g. the moderation module
+type Recorder interface {
+    RecordViolation(ctx context.Context, userID int64, kind, source string) error
+}
+
+// Config configures the filter. Words are matched whole, ignoring case,
+// common character substitutions (0 for o, @ for a...) and stretched letters.
+type Config struct {
+    Words    map[Severity][]string
+    Mode     Mode
+    MaxLinks int // Most links a text may contain; 0 allows none
+}
+
+// Service is the default Filter
+type Service struct {
+    words    map[string]Severity
+    mode     Mode
+    maxLinks int
+
+    mu       sync.RWMutex
+    recorder Recorder
+}
+
+// NewService creates a text filter
+func NewService(cfg Config) *Service {
+    words := make(map[string]Severity)
+    for severity, list := range cfg.Words {
+        for _, word := range list {
+            word = normalize(strings.TrimSpace(word))
+            if word != "" && severity > words[word] {
+                words[word] = severity
+            }
+        }
+    }
+
+    mode := cfg.Mode
+    if mode != ModeReject {
+        mode = ModeMask
+    }
+
+    return &Service{
+        words:    words,
+        mode:     mode,
+        maxLinks: cfg.MaxLinks,
+    }
+}
+
+// SetRecorder sets where violations are counted, since moderation is set
+// up separately
+func (s *Service) SetRecorder(recorder Recorder) {
+    s.mu.Lock()
+    defer s.mu.Unlock()
+    s.recorder = recorder
+}
+
+// Result is what the filter found in a text
+type Result struct {
+    Matches  []Match
+    Severity Severity // Highest severity among the matches
+    Spam     string   // Why the text looks like spam, if it does
+}
+
+// Match is a listed word found in the text, as byte offsets
+type Match struct {
+    Start, End int
+    Severity   Severity
+}
+
+// Check analyses text without recording anything
+func (s *Service) Check(text string) *Result {
+    result := &Result{Spam: detectSpam(text, s.maxLinks)}
+
+    for _, span := range wordSpans(text) {
+        severity, ok := s.lookup(text[span[0]:span[1]])
+        if !ok {
+            continue
+        }
+        result.Matches = append(result.Matches, Match{Start: span[0], End: span[1], Severity: severity})
+        if severity > result.Severity {
+            result.Severity = severity
+        }
+    }
+    return result
+}
+
+// Filter rejects spam, and masks or rejects profanity according to the mode
+func (s *Service) Filter(ctx context.Context, userID int64, field, text string) (string, error) {
+    if strings.TrimSpace(text) == "" {
+        return text, nil
+    }
+
+    result := s.Check(text)
+    if result.Spam != "" {
+        s.record(ctx, userID, KindSpam, field)
+        return "", &RejectedError{Kind: KindSpam, Reason: result.Spam}
+    }
+    if len(result.Matches) == 0 {
+        return text, nil
+    }
+
+    s.record(ctx, userID, KindProfanity, field)
+    if s.mode == ModeReject {
+        return "", &RejectedError{Kind: KindProfanity, Reason: "inappropriate language"}
+    }
+    return mask(text, result.Matches), nil
+}
+
+func (s *Service) record(ctx context.Context, userID int64, kind, field string) {
+    s.mu.RLock()
+    recorder := s.recorder
+    s.mu.RUnlock()
+
+    if recorder == nil || userID == 0 {
+        return
+    }
+    if err := recorder.RecordViolation(ctx, userID, kind, field); err != nil {
+        log.Printf("Failed to record %s violation for user %d: %v", kind, userID, err)
+    }
+}
+
+// lookup finds a word in the lists. Stretched words ("fuuuck") are also
+// tried with their runs of repeated letters squeezed to two and to one.
+func (s *Service) lookup(word string) (Severity, bool) {
+    word = normalize(word)
+    if severity, ok := s.words[word]; ok {
+        return severity, true
+    }
+    if !hasRun(word, 3) {
+        return 0, false
+    }
+    for _, max := range []int{2, 1} {
+        if severity, ok := s.words[squeeze(word, max)]; ok {
+            return severity, true
+        }
+    }
+    return 0, false
+}
+
+// leet undoes common character substitutions
+var leet = map[rune]rune{
+    '0': 'o',
+    '1': 'i',
+    '3': 'e',
+    '4': 'a',
+    '5': 's',
+    '7': 't',
+    '@': 'a',
+    '$': 's',
+}
+
+func normalize(word string) string {
+    var b strings.Builder
+    for _, r := range strings.ToLower(word) {
+        if sub, ok := leet[r]; ok {
+            r = sub
+        }
+        b.WriteRune(r)
+    }
+    return b.String()
+}
+
+func isWordRune(r rune) bool {
+    if unicode.IsLetter(r) || unicode.IsDigit(r) {
+        return true
+    }
+    _, ok := leet[r]
+    return ok
+}
+
+// wordSpans returns the byte offsets of each word in text
+func wordSpans(text string) [][2]int {
+    var spans [][2]int
+    start := -1
+    for i, r := range text {
+        if isWordRune(r) {
+            if start < 0 {
+                start = i
+            }
+            continue
+        }
+        if start >= 0 {
+            spans = append(spans, [2]int{start, i})
+            start = -1
+        }
+    }
+    if start >= 0 {
+        spans = append(spans, [2]int{start, len(text)})
+    }
+    return spans
+}
+
+// hasRun reports whether any character repeats n or more times in a row
+func hasRun(s string, n int) bool {
+    count := 0
+    var prev rune
+    for i, r := range s {
+        if i > 0 && r == prev {
+            count++
+        } else {
+            count = 1
+        }
+        if count >= n {
+            return true
+        }
+        prev = r
+    }
+    return false
+}
+
+// squeeze shortens runs of a repeated character to at most max
+func squeeze(s string, max int) string {
+    var b strings.Builder
+    count := 0
+    var prev rune
+    for i, r := range s {
+        if i > 0 && r == prev {
+            count++
+        } else {
+            count = 1
+        }
+        if count <= max {
+            b.WriteRune(r)
+        }
+        prev = r
+    }
+    return b.String()
+}
+
+// mask keeps the first letter of each match and stars out the rest
+func mask(text string, matches []Match) string {
+    var b strings.Builder
+    last := 0
+    for _, m := range matches {
+        b.WriteString(text[last:m.Start])
+        for i, r := range text[m.Start:m.End] {
+            if i == 0 {
+                b.WriteRune(r)
+            } else {
+                b.WriteByte('*')
+            }
+        }
+        last = m.End
+    }
+    b.WriteString(text[last:])
+    return b.String()
+}
@@ -0,0 +1,62 @@
+// internal/textfilter/spam.go
+
+package textfilter
+
+import (
+    "regexp"
+    "strings"
+)
+
+const (
+    // A text of at least repetitionMinWords words is spam when one word
+    // makes up more than repetitionShare of it
+    repetitionMinWords = 8
+    repetitionShare    = 0.6
+
+    // floodRun is how many times one character may repeat before it is spam
+    floodRun = 20
+)
+
+var (
+    // Invites to other platforms, used to move users off the app
+    invitePattern = regexp.MustCompile(`(?i)\b(?:chat\.whatsapp\.com|wa\.me/|t\.me/|telegram\.(?:me|dog)/|discord\.gg/|discord(?:app)?\.com/invite|snapchat\.com/add|kik\.me/|onlyfans\.com)`)
+
+    linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+\.(?:com|net|org|io|co|me|xyz|info|biz|ly|gg|app|link|click|site|online|top)\b(?:/\S*)?`)
+)
+
+// detectSpam returns why text looks like spam, or "" if it doesn't
+func detectSpam(text string, maxLinks int) string {
+    if invitePattern.MatchString(text) {
+        return "invite links are not allowed"
+    }
+    if len(linkPattern.FindAllStringIndex(text, maxLinks+1)) > maxLinks {
+        if maxLinks == 0 {
+            return "links are not allowed"
+        }
+        return "too many links"
+    }
+    if hasRun(text, floodRun) {
+        return "repeated characters"
+    }
+    if isRepetitive(text) {
+        return "repetitive content"
+    }
+    return ""
+}
+
+// isRepetitive catches the same word pasted over and over
+func isRepetitive(text string) bool {
+    words := strings.Fields(strings.ToLower(text))
+    if len(words) < repetitionMinWords {
+        return false
+    }
+
+    counts := make(map[string]int)
+    for _, w := range words {
+        counts[w]++
+        if float64(counts[w]) > float64(len(words))*repetitionShare {
+            return true
+        }
+    }
+    return false
+}
@@ -0,0 +1,61 @@
+// internal/textfilter/wordlist.go
+
+package textfilter
+
+import (
+    "bufio"
+    "fmt"
+    "os"
+    "path/filepath"
+    "strings"
+)
+
+// DefaultWords is used when no word list directory is configured. Severe
+// terms (slurs) are left to deployments' own lists.
+var DefaultWords = map[Severity][]string{
+    SeverityMild: {
+        "damn", "crap", "bloody", "bugger", "piss", "pissed",
+    },
+    SeverityModerate: {
+        "shit", "shitty", "bullshit", "fuck", "fucker", "fucking", "motherfucker",
+        "bitch", "bastard", "ass", "asshole", "dick", "dickhead", "cock", "pussy",
+        "cunt", "whore", "slut", "twat", "wanker",
+    },
+}
+
+// wordListFiles maps each severity to its file in a word list directory
+var wordListFiles = map[Severity]string{
+    SeverityMild:     "mild.txt",
+    SeverityModerate: "moderate.txt",
+    SeveritySevere:   "severe.txt",
+}
+
+// LoadWordLists reads mild.txt, moderate.txt and severe.txt from dir, one
+// word per line with # comments. Missing files are skipped.
+func LoadWordLists(dir string) (map[Severity][]string, error) {
+    words := make(map[Severity][]string)
+    for severity, name := range wordListFiles {
+        f, err := os.Open(filepath.Join(dir, name))
+        if os.IsNotExist(err) {
+            continue
+        }
+        if err != nil {
+            return nil, fmt.Errorf("failed to open word list: %w", err)
+        }
+
+        scanner := bufio.NewScanner(f)
+        for scanner.Scan() {
+            line := strings.TrimSpace(scanner.Text())
+            if line == "" || strings.HasPrefix(line, "#") {
+                continue
+            }
+            words[severity] = append(words[severity], line)
+        }
+        err = scanner.Err()
+        f.Close()
+        if err != nil {
+            return nil, fmt.Errorf("failed to read word list %s: %w", name, err)
+        }
+    }
+    return words, nil
+}
@@ -0,0 +1,151 @@
+// internal/users/client.go
+// Typed reads of the users table for every other module. Lookups by ID go
+// through the Client; queries that list content join users and use the SQL
+// fragments in ghost.go and presence.go, so both apply the same rules.
+
+package users
+
+import (
+    "context"
+    "errors"
+
+    "github.com/jmoiron/sqlx"
+    "github.com/lib/pq"
+)
+
+var ErrUserNotFound = errors.New("user not found")
+
+// Client reads users for other modules
+type Client interface {
+    // Get returns the user, failing with ErrUserNotFound if there's no such
+    // user. Inactive users come back as ghosts.
+    Get(ctx context.Context, userID int64) (*User, error)
+
+    // GetMany returns a user for every requested ID. Users that are missing
+    // or not active come back as ghosts.
+    GetMany(ctx context.Context, userIDs []int64) (map[int64]*User, error)
+
+    // Resolve and ResolveOne return only how users are shown next to content
+    Resolve(ctx context.Context, userIDs []int64) (map[int64]*Identity, error)
+    ResolveOne(ctx context.Context, userID int64) (*Identity, error)
+}
+
+type client struct {
+    db *sqlx.DB
+}
+
+func NewClient(db *sqlx.DB) Client {
+    return &client{db: db}
+}
+
+// userColumns selects a User from users u
+var userColumns = `
+        u.id, u.username, COALESCE(u.display_name, u.username) AS display_name, u.profile_picture,
+        ` + GhostSQL("u") + ` AS is_ghost,
+        u.bio, u.gender, u.date_of_birth, u.location, u.latitude, u.longitude,
+        u.interests, u.looking_for,
+        COALESCE(u.is_verified, false) AS is_verified,
+        COALESCE(u.is_photo_verified, false) AS is_photo_verified,
+        COALESCE(u.is_premium, false) AS is_premium,
+        COALESCE(u.is_profile_complete, false) AS is_profile_complete,
+        u.profile_completed_at,
+        ` + IsOnlineSQL("u") + ` AS is_online,
+        ` + LastSeenSQL("u") + ` AS last_seen,
+        NOT ` + ShowsPresenceSQL("u") + ` AS hides_presence,
+        u.last_active,
+        NULLIF(u.email, '') AS email, NULLIF(u.phone, '') AS phone,
+        COALESCE(u.timezone, 'UTC') AS timezone,
+        COALESCE(u.preferred_language, 'en') AS language,
+        u.created_at`
+
+func (c *client) Get(ctx context.Context, userID int64) (*User, error) {
+    found, err := c.find(ctx, []int64{userID})
+    if err != nil {
+        return nil, err
+    }
+    if len(found) == 0 {
+        return nil, ErrUserNotFound
+    }
+    return found[0], nil
+}
+
+func (c *client) GetMany(ctx context.Context, userIDs []int64) (map[int64]*User, error) {
+    result := make(map[int64]*User, len(userIDs))
+    if len(userIDs) == 0 {
+        return result, nil
+    }
+
+    found, err := c.find(ctx, userIDs)
+    if err != nil {
+        return nil, err
+    }
+    for _, user := range found {
+        result[user.ID] = user
+    }
+    for _, id := range userIDs {
+        if _, ok := result[id]; !ok {
+            result[id] = &User{Identity: *Ghost(id)}
+        }
+    }
+    return result, nil
+}
+
+// find loads the users that exist, with ghosts stripped down to their
+// placeholder identity
+func (c *client) find(ctx context.Context, userIDs []int64) ([]*User, error) {
+    query := `SELECT ` + userColumns + `
+        FROM users u
+        WHERE u.id = ANY($1)`
+
+    var found []*User
+    if err := c.db.SelectContext(ctx, &found, query, pq.Array(userIDs)); err != nil {
+        return nil, err
+    }
+    for i, user := range found {
+        if user.IsGhost {
+            found[i] = &User{Identity: *Ghost(user.ID), CreatedAt: user.CreatedAt}
+        }
+    }
+    return found, nil
+}
+
+// Resolve returns an identity for every requested ID. Users that are missing
+// or not active resolve to their ghost.
+func (c *client) Resolve(ctx context.Context, userIDs []int64) (map[int64]*Identity, error) {
+    identities := make(map[int64]*Identity, len(userIDs))
+    if len(userIDs) == 0 {
+        return identities, nil
+    }
+
+    query := `
+        SELECT id, username, COALESCE(display_name, username) AS display_name, profile_picture,
+               ` + GhostSQL("u") + ` AS is_ghost
+        FROM users u
+        WHERE id = ANY($1)`
+
+    var found []*Identity
+    if err := c.db.SelectContext(ctx, &found, query, pq.Array(userIDs)); err != nil {
+        return nil, err
+    }
+
+    for _, identity := range found {
+        if identity.IsGhost {
+            identity = Ghost(identity.ID)
+        }
+        identities[identity.ID] = identity
+    }
+    for _, id := range userIDs {
+        if _, ok := identities[id]; !ok {
+            identities[id] = Ghost(id)
+        }
+    }
+    return identities, nil
+}
+
+func (c *client) ResolveOne(ctx context.Context, userID int64) (*Identity, error) {
+    identities, err := c.Resolve(ctx, []int64{userID})
+    if err != nil {
+        return nil, err
+    }
+    return identities[userID], nil
+}
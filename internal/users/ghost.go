@@ -0,0 +1,79 @@
+// internal/users/ghost.go
+// Rendering policy for deleted and suspended users ("ghost users")
+//
+// A user who is deleted, suspended or banned (or whose row is gone) is never
+// shown by name. Content they own on its own — posts and stories — is hidden
+// with them. Content that is part of someone else's thread — comments,
+// replies and messages — stays, attributed to a "Deleted user" placeholder so
+// conversations still read. Lists of people (likers, viewers) drop them.
+
+package users
+
+import "fmt"
+
+// Account statuses (users.account_status)
+const (
+    StatusActive    = "active"
+    StatusSuspended = "suspended"
+    StatusBanned    = "banned"
+    StatusDeleted   = "deleted"
+)
+
+// Placeholder identity shown in place of a ghost user
+const (
+    GhostUsername    = "deleted_user"
+    GhostDisplayName = "Deleted user"
+)
+
+// Identity is how a user is shown next to their content
+type Identity struct {
+    ID             int64   `json:"id" db:"id"`
+    Username       string  `json:"username" db:"username"`
+    DisplayName    string  `json:"display_name" db:"display_name"`
+    ProfilePicture *string `json:"profile_picture,omitempty" db:"profile_picture"`
+    IsGhost        bool    `json:"is_ghost,omitempty" db:"is_ghost"`
+}
+
+// Ghost returns the placeholder identity for userID
+func Ghost(userID int64) *Identity {
+    return &Identity{
+        ID:          userID,
+        Username:    GhostUsername,
+        DisplayName: GhostDisplayName,
+        IsGhost:     true,
+    }
+}
+
+// IsGhostStatus reports whether a user with this account status is shown as a ghost
+func IsGhostStatus(status string) bool {
+    return status != "" && status != StatusActive
+}
+
+// SQL fragments for queries that join users. alias is the joined users table;
+// a LEFT JOIN that found no row counts as a ghost.
+
+// ActiveSQL is true when the joined user is active. Use it to hide content.
+func ActiveSQL(alias string) string {
+    return fmt.Sprintf("COALESCE(%s.account_status, '%s') = '%s'", alias, StatusActive, StatusActive)
+}
+
+// GhostSQL is true when the joined user must be shown as a ghost
+func GhostSQL(alias string) string {
+    return fmt.Sprintf("(%[1]s.id IS NULL OR COALESCE(%[1]s.account_status, '%[2]s') <> '%[2]s')", alias, StatusActive)
+}
+
+// UsernameSQL selects the username, or the ghost username
+func UsernameSQL(alias string) string {
+    return fmt.Sprintf("CASE WHEN %s THEN '%s' ELSE %s.username END", GhostSQL(alias), GhostUsername, alias)
+}
+
+// DisplayNameSQL selects the display name (falling back to the username), or the ghost name
+func DisplayNameSQL(alias string) string {
+    return fmt.Sprintf("CASE WHEN %s THEN '%s' ELSE COALESCE(%[3]s.display_name, %[3]s.username) END",
+        GhostSQL(alias), GhostDisplayName, alias)
+}
+
+// ProfilePictureSQL selects the profile picture, or NULL for a ghost
+func ProfilePictureSQL(alias string) string {
+    return fmt.Sprintf("CASE WHEN %s THEN NULL ELSE %s.profile_picture END", GhostSQL(alias), alias)
+}
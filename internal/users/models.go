@@ -0,0 +1,60 @@
+// internal/users/models.go
+
+package users
+
+import (
+    "time"
+
+    "github.com/lib/pq"
+)
+
+// User is the users read model other modules see a user through. A ghost
+// user keeps only the placeholder identity; their profile, presence and
+// contact details are left empty. Presence is also left empty for users who
+// hide it.
+type User struct {
+    Identity
+
+    Bio                *string        `json:"bio,omitempty" db:"bio"`
+    Gender             *string        `json:"gender,omitempty" db:"gender"`
+    DateOfBirth        *time.Time     `json:"date_of_birth,omitempty" db:"date_of_birth"`
+    Location           *string        `json:"location,omitempty" db:"location"`
+    Latitude           *float64       `json:"latitude,omitempty" db:"latitude"`
+    Longitude          *float64       `json:"longitude,omitempty" db:"longitude"`
+    Interests          pq.StringArray `json:"interests" db:"interests"`
+    LookingFor         *string        `json:"looking_for,omitempty" db:"looking_for"`
+    IsVerified         bool           `json:"is_verified" db:"is_verified"`
+    IsPhotoVerified    bool           `json:"is_photo_verified" db:"is_photo_verified"`
+    IsPremium          bool           `json:"is_premium" db:"is_premium"`
+    IsProfileComplete  bool           `json:"is_profile_complete" db:"is_profile_complete"`
+    ProfileCompletedAt *time.Time     `json:"profile_completed_at,omitempty" db:"profile_completed_at"`
+
+    // Presence, as others may see it
+    IsOnline      bool       `json:"is_online" db:"is_online"`
+    LastSeen      *time.Time `json:"last_seen,omitempty" db:"last_seen"`
+    HidesPresence bool       `json:"-" db:"hides_presence"`
+
+    // When the user last made a request, for ranking; never shown
+    LastActive *time.Time `json:"-" db:"last_active"`
+
+    // Contact details and locale, for delivery only
+    Email    *string `json:"-" db:"email"`
+    Phone    *string `json:"-" db:"phone"`
+    Timezone string  `json:"-" db:"timezone"`
+    Language string  `json:"-" db:"language"`
+
+    CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// Age is the user's age in whole years, or 0 when their date of birth isn't set
+func (u *User) Age(now time.Time) int {
+    if u.DateOfBirth == nil {
+        return 0
+    }
+    dob := *u.DateOfBirth
+    age := now.Year() - dob.Year()
+    if now.Month() < dob.Month() || (now.Month() == dob.Month() && now.Day() < dob.Day()) {
+        age--
+    }
+    return age
+}
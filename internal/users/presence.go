@@ -0,0 +1,27 @@
+// internal/users/presence.go
+// Online status and last seen, which users can hide from others with the
+// show_online_status privacy setting. Users who never set it are shown.
+
+package users
+
+import "fmt"
+
+// ShowsPresenceSQL is true when the joined user lets others see whether
+// they're online and when they were last seen
+func ShowsPresenceSQL(alias string) string {
+    return fmt.Sprintf("COALESCE((%s.privacy_settings->>'show_online_status')::boolean, true)", alias)
+}
+
+// IsOnlineSQL selects whether the joined user is online, false for a ghost or
+// a user hiding their presence
+func IsOnlineSQL(alias string) string {
+    return fmt.Sprintf("(COALESCE(%[1]s.is_online, false) AND NOT %[2]s AND %[3]s)",
+        alias, GhostSQL(alias), ShowsPresenceSQL(alias))
+}
+
+// LastSeenSQL selects when the joined user was last seen, NULL for a ghost or
+// a user hiding their presence
+func LastSeenSQL(alias string) string {
+    return fmt.Sprintf("CASE WHEN %s OR NOT %s THEN NULL ELSE %s.last_seen END",
+        GhostSQL(alias), ShowsPresenceSQL(alias), alias)
+}
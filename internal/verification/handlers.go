@@ -0,0 +1,223 @@
+// internal/verification/handlers.go
+
+package verification
+
+import (
+    "errors"
+    "io"
+    "log"
+    "net/http"
+    "strconv"
+
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+    "github.com/imadgeboyega/kiekky-backend/internal/common/utils"
+)
+
+// maxSelfieUploadSize bounds the multipart body of a selfie upload
+const maxSelfieUploadSize = MaxSelfieSize + 1<<20
+
+type Handler struct {
+    service Service
+}
+
+func NewHandler(service Service) *Handler {
+    return &Handler{service: service}
+}
+
+// StartChallenge handles POST /verification/challenge
+func (h *Handler) StartChallenge(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    submission, err := h.service.StartChallenge(r.Context(), userID)
+    if err != nil {
+        if errors.Is(err, ErrAlreadyVerified) || errors.Is(err, ErrReviewPending) {
+            utils.RespondWithError(w, http.StatusConflict, err.Error())
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to start verification")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, submission)
+}
+
+// SubmitSelfie handles POST /verification/{id}/selfie with the image in the "selfie" field
+func (h *Handler) SubmitSelfie(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    submissionID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid verification ID")
+        return
+    }
+
+    r.Body = http.MaxBytesReader(w, r.Body, maxSelfieUploadSize)
+    if err := r.ParseMultipartForm(maxSelfieUploadSize); err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Selfie too large or invalid form")
+        return
+    }
+
+    file, _, err := r.FormFile("selfie")
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Selfie is required")
+        return
+    }
+    defer file.Close()
+
+    submission, err := h.service.SubmitSelfie(r.Context(), userID, submissionID, file)
+    if err != nil {
+        switch {
+        case errors.Is(err, ErrSubmissionNotFound):
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+        case errors.Is(err, ErrChallengeNotActive):
+            utils.RespondWithError(w, http.StatusConflict, err.Error())
+        case errors.Is(err, ErrInvalidSelfie), errors.Is(err, ErrSelfieTooLarge):
+            utils.RespondWithError(w, http.StatusBadRequest, err.Error())
+        case errors.Is(err, ErrStoreUnavailable):
+            utils.RespondWithError(w, http.StatusServiceUnavailable, err.Error())
+        default:
+            utils.RespondWithError(w, http.StatusInternalServerError, "Failed to submit selfie")
+        }
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusAccepted, submission)
+}
+
+// GetStatus handles GET /verification
+func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
+    userID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    status, err := h.service.GetStatus(r.Context(), userID)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get verification status")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, status)
+}
+
+// ListSubmissions handles GET /admin/verifications?status=&limit=&offset=
+func (h *Handler) ListSubmissions(w http.ResponseWriter, r *http.Request) {
+    status := Status(r.URL.Query().Get("status"))
+    if status == "" {
+        status = StatusPending
+    }
+
+    limit, _ := strconv.Atoi(r.URL.Query().Get("limit"))
+    if limit <= 0 || limit > 100 {
+        limit = 20
+    }
+    offset, _ := strconv.Atoi(r.URL.Query().Get("offset"))
+    if offset < 0 {
+        offset = 0
+    }
+
+    items, err := h.service.ListSubmissions(r.Context(), status, limit, offset)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to get verifications")
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, items)
+}
+
+// GetSelfie handles GET /admin/verifications/{id}/selfie
+func (h *Handler) GetSelfie(w http.ResponseWriter, r *http.Request) {
+    submissionID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid verification ID")
+        return
+    }
+
+    selfie, err := h.service.OpenSelfie(r.Context(), submissionID)
+    if err != nil {
+        if errors.Is(err, ErrSubmissionNotFound) {
+            utils.RespondWithError(w, http.StatusNotFound, err.Error())
+            return
+        }
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to load selfie")
+        return
+    }
+    defer selfie.Close()
+
+    w.Header().Set("Cache-Control", "no-store")
+    if _, err := io.Copy(w, selfie); err != nil {
+        log.Printf("Failed to stream selfie for verification %d: %v", submissionID, err)
+    }
+}
+
+// Approve handles POST /admin/verifications/{id}/approve
+func (h *Handler) Approve(w http.ResponseWriter, r *http.Request) {
+    adminID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    submissionID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid verification ID")
+        return
+    }
+
+    submission, err := h.service.Approve(r.Context(), submissionID, adminID)
+    if err != nil {
+        h.respondReviewError(w, err)
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, submission)
+}
+
+// Reject handles POST /admin/verifications/{id}/reject
+func (h *Handler) Reject(w http.ResponseWriter, r *http.Request) {
+    adminID, ok := auth.UserIDFromContext(r.Context())
+    if !ok {
+        utils.RespondWithError(w, http.StatusUnauthorized, "Unauthorized")
+        return
+    }
+
+    submissionID, err := strconv.ParseInt(mux.Vars(r)["id"], 10, 64)
+    if err != nil {
+        utils.RespondWithError(w, http.StatusBadRequest, "Invalid verification ID")
+        return
+    }
+
+    var req RejectRequest
+    if r.ContentLength > 0 && !utils.DecodeAndValidate(w, r, &req) {
+        return
+    }
+
+    submission, err := h.service.Reject(r.Context(), submissionID, adminID, req.Reason)
+    if err != nil {
+        h.respondReviewError(w, err)
+        return
+    }
+
+    utils.RespondWithJSON(w, http.StatusOK, submission)
+}
+
+func (h *Handler) respondReviewError(w http.ResponseWriter, err error) {
+    switch {
+    case errors.Is(err, ErrSubmissionNotFound):
+        utils.RespondWithError(w, http.StatusNotFound, err.Error())
+    case errors.Is(err, ErrNotPending):
+        utils.RespondWithError(w, http.StatusConflict, err.Error())
+    default:
+        utils.RespondWithError(w, http.StatusInternalServerError, "Failed to review verification")
+    }
+}
@@ -0,0 +1,19 @@
+// internal/verification/matcher.go
+
+package verification
+
+import "context"
+
+// MatchResult is a face-match provider's verdict on a selfie
+type MatchResult struct {
+    // Score is the provider's confidence, 0-1, that the selfie shows the
+    // person in the profile picture
+    Score       float64
+    PoseMatched bool
+}
+
+// FaceMatcher compares a selfie against the user's profile picture. A
+// matcher is optional; without one every submission goes to admin review.
+type FaceMatcher interface {
+    Compare(ctx context.Context, selfie []byte, profilePictureURL string, pose *Pose) (*MatchResult, error)
+}
@@ -0,0 +1,58 @@
+// internal/verification/models.go
+
+package verification
+
+import "time"
+
+type Status string
+
+const (
+    // StatusAwaitingSelfie is a challenge that has been issued but not yet answered
+    StatusAwaitingSelfie Status = "awaiting_selfie"
+    StatusPending        Status = "pending"
+    StatusApproved       Status = "approved"
+    StatusRejected       Status = "rejected"
+    StatusExpired        Status = "expired"
+)
+
+// Pose is a pose the selfie has to show, so an old photo can't be reused
+type Pose struct {
+    ID          string `json:"id"`
+    Instruction string `json:"instruction"`
+}
+
+// Submission is one verification attempt, from challenge through review
+type Submission struct {
+    ID          int64      `json:"id" db:"id"`
+    UserID      int64      `json:"user_id" db:"user_id"`
+    PoseID      string     `json:"pose_id" db:"pose_id"`
+    Status      Status     `json:"status" db:"status"`
+    SelfieRef   *string    `json:"-" db:"selfie_ref"`
+    MatchScore  *float64   `json:"match_score,omitempty" db:"match_score"`
+    ReviewedBy  *int64     `json:"reviewed_by,omitempty" db:"reviewed_by"`
+    ReviewNote  *string    `json:"review_note,omitempty" db:"review_note"`
+    ExpiresAt   time.Time  `json:"expires_at" db:"expires_at"`
+    SubmittedAt *time.Time `json:"submitted_at,omitempty" db:"submitted_at"`
+    ReviewedAt  *time.Time `json:"reviewed_at,omitempty" db:"reviewed_at"`
+    CreatedAt   time.Time  `json:"created_at" db:"created_at"`
+
+    Pose *Pose `json:"pose,omitempty"`
+}
+
+// ReviewItem is a submission in the admin review queue
+type ReviewItem struct {
+    Submission
+    Username       string  `json:"username" db:"username"`
+    ProfilePicture *string `json:"profile_picture,omitempty" db:"profile_picture"`
+}
+
+// StatusResponse is a user's verification state
+type StatusResponse struct {
+    IsPhotoVerified bool        `json:"is_photo_verified"`
+    Latest          *Submission `json:"latest,omitempty"`
+}
+
+// RejectRequest is the admin's reason for rejecting a submission
+type RejectRequest struct {
+    Reason string `json:"reason" validate:"omitempty,max=500"`
+}
@@ -0,0 +1,28 @@
+// internal/verification/poses.go
+
+package verification
+
+import "math/rand"
+
+var poses = []*Pose{
+    {ID: "thumbs_up", Instruction: "Give a thumbs up next to your face"},
+    {ID: "peace_sign", Instruction: "Hold up a peace sign next to your face"},
+    {ID: "hand_on_head", Instruction: "Put one hand on top of your head"},
+    {ID: "touch_nose", Instruction: "Touch your nose with one finger"},
+    {ID: "three_fingers", Instruction: "Hold up three fingers beside your cheek"},
+    {ID: "look_left", Instruction: "Turn your head to the left and smile"},
+}
+
+// GetPose returns the pose with the given ID, or nil
+func GetPose(id string) *Pose {
+    for _, pose := range poses {
+        if pose.ID == id {
+            return pose
+        }
+    }
+    return nil
+}
+
+func randomPose() *Pose {
+    return poses[rand.Intn(len(poses))]
+}
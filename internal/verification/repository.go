@@ -0,0 +1,185 @@
+// internal/verification/repository.go
+
+package verification
+
+import (
+    "context"
+    "database/sql"
+    "errors"
+
+    "github.com/jmoiron/sqlx"
+)
+
+var ErrSubmissionNotFound = errors.New("verification submission not found")
+
+type Repository interface {
+    CreateChallenge(ctx context.Context, submission *Submission) error
+    GetSubmission(ctx context.Context, id int64) (*Submission, error)
+    GetLatestSubmission(ctx context.Context, userID int64) (*Submission, error)
+    SubmitSelfie(ctx context.Context, id int64, selfieRef string) (bool, error)
+    SetMatchScore(ctx context.Context, id int64, score float64) error
+    ListSubmissions(ctx context.Context, status Status, limit, offset int) ([]*ReviewItem, error)
+    Approve(ctx context.Context, id int64, reviewerID *int64) (bool, error)
+    Reject(ctx context.Context, id int64, reviewerID int64, reason string) (bool, error)
+
+    IsPhotoVerified(ctx context.Context, userID int64) (bool, error)
+    GetProfilePicture(ctx context.Context, userID int64) (string, error)
+}
+
+type repository struct {
+    db *sqlx.DB
+}
+
+func NewRepository(db *sqlx.DB) Repository {
+    return &repository{db: db}
+}
+
+const submissionColumns = `
+    id, user_id, pose_id, status, selfie_ref, match_score, reviewed_by, review_note,
+    expires_at, submitted_at, reviewed_at, created_at`
+
+func (r *repository) CreateChallenge(ctx context.Context, submission *Submission) error {
+    query := `
+        INSERT INTO photo_verifications (user_id, pose_id, status, expires_at)
+        VALUES ($1, $2, $3, $4)
+        RETURNING id, created_at`
+
+    return r.db.QueryRowContext(ctx, query,
+        submission.UserID, submission.PoseID, submission.Status, submission.ExpiresAt,
+    ).Scan(&submission.ID, &submission.CreatedAt)
+}
+
+func (r *repository) GetSubmission(ctx context.Context, id int64) (*Submission, error) {
+    var submission Submission
+    query := `SELECT ` + submissionColumns + ` FROM photo_verifications WHERE id = $1`
+
+    err := r.db.GetContext(ctx, &submission, query, id)
+    if err == sql.ErrNoRows {
+        return nil, ErrSubmissionNotFound
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &submission, nil
+}
+
+// GetLatestSubmission returns the user's most recent attempt, or nil if none
+func (r *repository) GetLatestSubmission(ctx context.Context, userID int64) (*Submission, error) {
+    var submission Submission
+    query := `SELECT ` + submissionColumns + ` FROM photo_verifications
+        WHERE user_id = $1 ORDER BY created_at DESC, id DESC LIMIT 1`
+
+    err := r.db.GetContext(ctx, &submission, query, userID)
+    if err == sql.ErrNoRows {
+        return nil, nil
+    }
+    if err != nil {
+        return nil, err
+    }
+    return &submission, nil
+}
+
+// SubmitSelfie attaches the selfie to a live challenge and queues it for
+// review, reporting false if the challenge was already answered or expired
+func (r *repository) SubmitSelfie(ctx context.Context, id int64, selfieRef string) (bool, error) {
+    query := `
+        UPDATE photo_verifications
+        SET status = $2, selfie_ref = $3, submitted_at = NOW()
+        WHERE id = $1 AND status = $4 AND expires_at > NOW()`
+
+    result, err := r.db.ExecContext(ctx, query, id, StatusPending, selfieRef, StatusAwaitingSelfie)
+    if err != nil {
+        return false, err
+    }
+    n, err := result.RowsAffected()
+    return n > 0, err
+}
+
+func (r *repository) SetMatchScore(ctx context.Context, id int64, score float64) error {
+    _, err := r.db.ExecContext(ctx, `UPDATE photo_verifications SET match_score = $2 WHERE id = $1`, id, score)
+    return err
+}
+
+// ListSubmissions returns submissions in the given status, oldest submitted first
+func (r *repository) ListSubmissions(ctx context.Context, status Status, limit, offset int) ([]*ReviewItem, error) {
+    query := `
+        SELECT v.id, v.user_id, v.pose_id, v.status, v.selfie_ref, v.match_score, v.reviewed_by,
+               v.review_note, v.expires_at, v.submitted_at, v.reviewed_at, v.created_at,
+               u.username, u.profile_picture
+        FROM photo_verifications v
+        JOIN users u ON u.id = v.user_id
+        WHERE v.status = $1
+        ORDER BY v.submitted_at NULLS LAST, v.id
+        LIMIT $2 OFFSET $3`
+
+    items := []*ReviewItem{}
+    err := r.db.SelectContext(ctx, &items, query, status, limit, offset)
+    return items, err
+}
+
+// Approve marks a pending submission approved and the user photo verified.
+// reviewerID is nil when the face-match provider approved it.
+func (r *repository) Approve(ctx context.Context, id int64, reviewerID *int64) (bool, error) {
+    tx, err := r.db.BeginTxx(ctx, nil)
+    if err != nil {
+        return false, err
+    }
+    defer tx.Rollback()
+
+    var userID int64
+    err = tx.QueryRowContext(ctx, `
+        UPDATE photo_verifications
+        SET status = $2, reviewed_by = $3, reviewed_at = NOW()
+        WHERE id = $1 AND status = $4
+        RETURNING user_id`, id, StatusApproved, reviewerID, StatusPending).Scan(&userID)
+    if err == sql.ErrNoRows {
+        return false, nil
+    }
+    if err != nil {
+        return false, err
+    }
+
+    _, err = tx.ExecContext(ctx, `
+        UPDATE users SET is_photo_verified = TRUE, photo_verified_at = NOW(), updated_at = NOW()
+        WHERE id = $1`, userID)
+    if err != nil {
+        return false, err
+    }
+
+    return true, tx.Commit()
+}
+
+// Reject marks a pending submission rejected
+func (r *repository) Reject(ctx context.Context, id int64, reviewerID int64, reason string) (bool, error) {
+    query := `
+        UPDATE photo_verifications
+        SET status = $2, reviewed_by = $3, review_note = NULLIF($4, ''), reviewed_at = NOW()
+        WHERE id = $1 AND status = $5`
+
+    result, err := r.db.ExecContext(ctx, query, id, StatusRejected, reviewerID, reason, StatusPending)
+    if err != nil {
+        return false, err
+    }
+    n, err := result.RowsAffected()
+    return n > 0, err
+}
+
+func (r *repository) IsPhotoVerified(ctx context.Context, userID int64) (bool, error) {
+    var verified bool
+    err := r.db.GetContext(ctx, &verified,
+        `SELECT COALESCE(is_photo_verified, false) FROM users WHERE id = $1`, userID)
+    if err == sql.ErrNoRows {
+        return false, nil
+    }
+    return verified, err
+}
+
+func (r *repository) GetProfilePicture(ctx context.Context, userID int64) (string, error) {
+    var picture string
+    err := r.db.GetContext(ctx, &picture,
+        `SELECT COALESCE(profile_picture, '') FROM users WHERE id = $1`, userID)
+    if err == sql.ErrNoRows {
+        return "", nil
+    }
+    return picture, err
+}
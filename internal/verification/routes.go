@@ -0,0 +1,25 @@
+package verification
+
+import (
+    "github.com/gorilla/mux"
+    "github.com/imadgeboyega/kiekky-backend/internal/auth"
+)
+
+func RegisterRoutes(router *mux.Router, handler *Handler, authMiddleware *auth.Middleware) {
+    api := router.PathPrefix("/api/v1/verification").Subrouter()
+    api.Use(authMiddleware.Authenticate)
+
+    api.HandleFunc("", handler.GetStatus).Methods("GET")
+    api.HandleFunc("/challenge", handler.StartChallenge).Methods("POST")
+    api.HandleFunc("/{id:[0-9]+}/selfie", handler.SubmitSelfie).Methods("POST")
+
+    // Reviewing submissions (support and admins)
+    admin := router.PathPrefix("/api/v1/admin/verifications").Subrouter()
+    admin.Use(authMiddleware.Authenticate)
+    admin.Use(authMiddleware.RequireStaff)
+
+    admin.HandleFunc("", handler.ListSubmissions).Methods("GET")
+    admin.HandleFunc("/{id:[0-9]+}/selfie", handler.GetSelfie).Methods("GET")
+    admin.HandleFunc("/{id:[0-9]+}/approve", handler.Approve).Methods("POST")
+    admin.HandleFunc("/{id:[0-9]+}/reject", handler.Reject).Methods("POST")
+}
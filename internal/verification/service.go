@@ -0,0 +1,286 @@
+// internal/verification/service.go
+
+package verification
+
+import (
+    "context"
+    "errors"
+    "fmt"
+    "io"
+    "log"
+    "net/http"
+    "time"
+)
+
+const (
+    MaxSelfieSize = 5 << 20
+
+    // challengeTTL is how long the user has to take the selfie once shown a pose
+    challengeTTL = 15 * time.Minute
+
+    matchTimeout = 30 * time.Second
+)
+
+var (
+    ErrAlreadyVerified    = errors.New("profile is already photo verified")
+    ErrReviewPending      = errors.New("a verification is already awaiting review")
+    ErrChallengeNotActive = errors.New("verification challenge has expired or was already used")
+    ErrInvalidSelfie      = errors.New("selfie must be a JPEG or PNG image")
+    ErrSelfieTooLarge     = fmt.Errorf("selfie must be at most %d MB", MaxSelfieSize>>20)
+    ErrNotPending         = errors.New("submission is not awaiting review")
+    ErrStoreUnavailable   = errors.New("selfie storage is not configured")
+)
+
+var allowedSelfieTypes = map[string]string{
+    "image/jpeg": ".jpg",
+    "image/png":  ".png",
+}
+
+type Service interface {
+    // User flow
+    StartChallenge(ctx context.Context, userID int64) (*Submission, error)
+    SubmitSelfie(ctx context.Context, userID, submissionID int64, selfie io.Reader) (*Submission, error)
+    GetStatus(ctx context.Context, userID int64) (*StatusResponse, error)
+
+    // Admin review
+    ListSubmissions(ctx context.Context, status Status, limit, offset int) ([]*ReviewItem, error)
+    OpenSelfie(ctx context.Context, submissionID int64) (io.ReadCloser, error)
+    Approve(ctx context.Context, submissionID, reviewerID int64) (*Submission, error)
+    Reject(ctx context.Context, submissionID, reviewerID int64, reason string) (*Submission, error)
+
+    // Configuration
+    SetFaceMatcher(matcher FaceMatcher, approveThreshold float64)
+}
+
+type service struct {
+    repo             Repository
+    store            SelfieStore
+    matcher          FaceMatcher
+    approveThreshold float64
+}
+
+// NewService creates the photo verification service. Without a face matcher
+// every selfie waits for admin review.
+func NewService(repo Repository, store SelfieStore) Service {
+    return &service{
+        repo:  repo,
+        store: store,
+    }
+}
+
+// SetFaceMatcher enables automatic approval of selfies the provider scores at
+// or above approveThreshold with the requested pose. Anything else still goes
+// to admin review; the provider never rejects on its own.
+func (s *service) SetFaceMatcher(matcher FaceMatcher, approveThreshold float64) {
+    s.matcher = matcher
+    s.approveThreshold = approveThreshold
+}
+
+// StartChallenge picks the pose the selfie must show. An unanswered challenge
+// is returned again until it expires, so reloading doesn't reroll the pose.
+func (s *service) StartChallenge(ctx context.Context, userID int64) (*Submission, error) {
+    verified, err := s.repo.IsPhotoVerified(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if verified {
+        return nil, ErrAlreadyVerified
+    }
+
+    latest, err := s.repo.GetLatestSubmission(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if latest != nil {
+        switch {
+        case latest.Status == StatusPending:
+            return nil, ErrReviewPending
+        case latest.Status == StatusAwaitingSelfie && time.Now().Before(latest.ExpiresAt):
+            latest.Pose = GetPose(latest.PoseID)
+            return latest, nil
+        }
+    }
+
+    pose := randomPose()
+    submission := &Submission{
+        UserID:    userID,
+        PoseID:    pose.ID,
+        Status:    StatusAwaitingSelfie,
+        ExpiresAt: time.Now().Add(challengeTTL),
+        Pose:      pose,
+    }
+    if err := s.repo.CreateChallenge(ctx, submission); err != nil {
+        return nil, fmt.Errorf("failed to create verification challenge: %w", err)
+    }
+    return submission, nil
+}
+
+// SubmitSelfie stores the selfie against the user's challenge and queues it
+// for review, letting the face matcher approve it first when configured
+func (s *service) SubmitSelfie(ctx context.Context, userID, submissionID int64, selfie io.Reader) (*Submission, error) {
+    if s.store == nil {
+        return nil, ErrStoreUnavailable
+    }
+
+    submission, err := s.repo.GetSubmission(ctx, submissionID)
+    if err != nil {
+        return nil, err
+    }
+    if submission.UserID != userID {
+        return nil, ErrSubmissionNotFound
+    }
+    if submission.Status != StatusAwaitingSelfie || !time.Now().Before(submission.ExpiresAt) {
+        return nil, ErrChallengeNotActive
+    }
+
+    data, err := io.ReadAll(io.LimitReader(selfie, MaxSelfieSize+1))
+    if err != nil {
+        return nil, err
+    }
+    if len(data) > MaxSelfieSize {
+        return nil, ErrSelfieTooLarge
+    }
+    contentType := http.DetectContentType(data)
+    ext, ok := allowedSelfieTypes[contentType]
+    if !ok {
+        return nil, ErrInvalidSelfie
+    }
+
+    name := fmt.Sprintf("%d_%d_%d%s", userID, submission.ID, time.Now().Unix(), ext)
+    ref, err := s.store.Save(ctx, name, data, contentType)
+    if err != nil {
+        return nil, err
+    }
+
+    ok, err = s.repo.SubmitSelfie(ctx, submission.ID, ref)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        return nil, ErrChallengeNotActive
+    }
+
+    if s.matcher != nil {
+        s.autoReview(ctx, submission, data)
+    }
+
+    return s.getSubmission(ctx, submission.ID)
+}
+
+// autoReview asks the face matcher about a fresh submission. Failures are
+// logged and leave the submission for admin review.
+func (s *service) autoReview(ctx context.Context, submission *Submission, selfie []byte) {
+    ctx, cancel := context.WithTimeout(ctx, matchTimeout)
+    defer cancel()
+
+    picture, err := s.repo.GetProfilePicture(ctx, submission.UserID)
+    if err != nil || picture == "" {
+        return
+    }
+
+    result, err := s.matcher.Compare(ctx, selfie, picture, GetPose(submission.PoseID))
+    if err != nil {
+        log.Printf("Face match failed for verification %d: %v", submission.ID, err)
+        return
+    }
+
+    if err := s.repo.SetMatchScore(ctx, submission.ID, result.Score); err != nil {
+        log.Printf("Failed to record match score for verification %d: %v", submission.ID, err)
+    }
+
+    if result.PoseMatched && result.Score >= s.approveThreshold {
+        if _, err := s.repo.Approve(ctx, submission.ID, nil); err != nil {
+            log.Printf("Failed to auto-approve verification %d: %v", submission.ID, err)
+        }
+    }
+}
+
+func (s *service) GetStatus(ctx context.Context, userID int64) (*StatusResponse, error) {
+    verified, err := s.repo.IsPhotoVerified(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+
+    latest, err := s.repo.GetLatestSubmission(ctx, userID)
+    if err != nil {
+        return nil, err
+    }
+    if latest != nil {
+        if latest.Status == StatusAwaitingSelfie && !time.Now().Before(latest.ExpiresAt) {
+            latest.Status = StatusExpired
+        }
+        latest.Pose = GetPose(latest.PoseID)
+    }
+
+    return &StatusResponse{
+        IsPhotoVerified: verified,
+        Latest:          latest,
+    }, nil
+}
+
+func (s *service) ListSubmissions(ctx context.Context, status Status, limit, offset int) ([]*ReviewItem, error) {
+    items, err := s.repo.ListSubmissions(ctx, status, limit, offset)
+    if err != nil {
+        return nil, err
+    }
+    for _, item := range items {
+        item.Pose = GetPose(item.PoseID)
+    }
+    return items, nil
+}
+
+// OpenSelfie streams a submission's selfie for review
+func (s *service) OpenSelfie(ctx context.Context, submissionID int64) (io.ReadCloser, error) {
+    if s.store == nil {
+        return nil, ErrStoreUnavailable
+    }
+
+    submission, err := s.repo.GetSubmission(ctx, submissionID)
+    if err != nil {
+        return nil, err
+    }
+    if submission.SelfieRef == nil {
+        return nil, ErrSubmissionNotFound
+    }
+
+    return s.store.Open(ctx, *submission.SelfieRef)
+}
+
+func (s *service) Approve(ctx context.Context, submissionID, reviewerID int64) (*Submission, error) {
+    ok, err := s.repo.Approve(ctx, submissionID, &reviewerID)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        return nil, s.notPending(ctx, submissionID)
+    }
+    return s.getSubmission(ctx, submissionID)
+}
+
+func (s *service) Reject(ctx context.Context, submissionID, reviewerID int64, reason string) (*Submission, error) {
+    ok, err := s.repo.Reject(ctx, submissionID, reviewerID, reason)
+    if err != nil {
+        return nil, err
+    }
+    if !ok {
+        return nil, s.notPending(ctx, submissionID)
+    }
+    return s.getSubmission(ctx, submissionID)
+}
+
+// notPending tells a missing submission apart from one already decided
+func (s *service) notPending(ctx context.Context, submissionID int64) error {
+    if _, err := s.repo.GetSubmission(ctx, submissionID); err != nil {
+        return err
+    }
+    return ErrNotPending
+}
+
+func (s *service) getSubmission(ctx context.Context, submissionID int64) (*Submission, error) {
+    submission, err := s.repo.GetSubmission(ctx, submissionID)
+    if err != nil {
+        return nil, err
+    }
+    submission.Pose = GetPose(submission.PoseID)
+    return submission, nil
+}
@@ -0,0 +1,105 @@
+// internal/verification/storage.go
+
+package verification
+
+import (
+    "bytes"
+    "context"
+    "fmt"
+    "io"
+    "os"
+    "path/filepath"
+
+    "github.com/aws/aws-sdk-go/aws"
+    "github.com/aws/aws-sdk-go/aws/session"
+    "github.com/aws/aws-sdk-go/service/s3"
+)
+
+// SelfieStore keeps verification selfies. Selfies are biometric data, so they
+// are never written anywhere publicly served; admins view them through the API.
+type SelfieStore interface {
+    Save(ctx context.Context, name string, data []byte, contentType string) (string, error)
+    Open(ctx context.Context, ref string) (io.ReadCloser, error)
+}
+
+// S3SelfieStore keeps selfies as private, encrypted S3 objects
+type S3SelfieStore struct {
+    s3Client *s3.S3
+    bucket   string
+    prefix   string
+}
+
+// NewS3SelfieStore creates an S3 selfie store
+func NewS3SelfieStore(bucket, region string) (SelfieStore, error) {
+    sess, err := session.NewSession(&aws.Config{
+        Region: aws.String(region),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to create AWS session: %w", err)
+    }
+
+    return &S3SelfieStore{
+        s3Client: s3.New(sess),
+        bucket:   bucket,
+        prefix:   "verification/selfies",
+    }, nil
+}
+
+// Save uploads the selfie and returns its object key
+func (s *S3SelfieStore) Save(ctx context.Context, name string, data []byte, contentType string) (string, error) {
+    key := fmt.Sprintf("%s/%s", s.prefix, name)
+
+    _, err := s.s3Client.PutObjectWithContext(ctx, &s3.PutObjectInput{
+        Bucket:               aws.String(s.bucket),
+        Key:                  aws.String(key),
+        Body:                 bytes.NewReader(data),
+        ContentType:          aws.String(contentType),
+        ACL:                  aws.String("private"),
+        ServerSideEncryption: aws.String("AES256"),
+    })
+    if err != nil {
+        return "", fmt.Errorf("failed to upload selfie to S3: %w", err)
+    }
+
+    return key, nil
+}
+
+// Open streams a stored selfie
+func (s *S3SelfieStore) Open(ctx context.Context, ref string) (io.ReadCloser, error) {
+    out, err := s.s3Client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+        Bucket: aws.String(s.bucket),
+        Key:    aws.String(ref),
+    })
+    if err != nil {
+        return nil, fmt.Errorf("failed to read selfie from S3: %w", err)
+    }
+    return out.Body, nil
+}
+
+// LocalSelfieStore writes selfies to a private directory on disk
+type LocalSelfieStore struct {
+    dir string
+}
+
+// NewLocalSelfieStore creates a local selfie store. dir must not be served over HTTP.
+func NewLocalSelfieStore(dir string) SelfieStore {
+    return &LocalSelfieStore{dir: dir}
+}
+
+// Save writes the selfie and returns its file name
+func (s *LocalSelfieStore) Save(ctx context.Context, name string, data []byte, contentType string) (string, error) {
+    if err := os.MkdirAll(s.dir, 0700); err != nil {
+        return "", fmt.Errorf("failed to create selfie directory: %w", err)
+    }
+
+    if err := os.WriteFile(filepath.Join(s.dir, name), data, 0600); err != nil {
+        return "", fmt.Errorf("failed to write selfie: %w", err)
+    }
+
+    return name, nil
+}
+
+// Open reads a stored selfie
+func (s *LocalSelfieStore) Open(ctx context.Context, ref string) (io.ReadCloser, error) {
+    return os.Open(filepath.Join(s.dir, filepath.Base(ref)))
+}